@@ -3,12 +3,15 @@ package api
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"os"
 	"runtime"
+	"strings"
 	"sync"
 
 	"github.com/gorilla/mux"
@@ -21,27 +24,31 @@ import (
 
 // Config contains the configuration fields for the Benthos API.
 type Config struct {
-	Address        string                     `json:"address" yaml:"address"`
-	Enabled        bool                       `json:"enabled" yaml:"enabled"`
-	RootPath       string                     `json:"root_path" yaml:"root_path"`
-	DebugEndpoints bool                       `json:"debug_endpoints" yaml:"debug_endpoints"`
-	CertFile       string                     `json:"cert_file" yaml:"cert_file"`
-	KeyFile        string                     `json:"key_file" yaml:"key_file"`
-	CORS           httpserver.CORSConfig      `json:"cors" yaml:"cors"`
-	BasicAuth      httpserver.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	Address           string                     `json:"address" yaml:"address"`
+	Enabled           bool                       `json:"enabled" yaml:"enabled"`
+	RootPath          string                     `json:"root_path" yaml:"root_path"`
+	DebugEndpoints    bool                       `json:"debug_endpoints" yaml:"debug_endpoints"`
+	DebugBasicAuth    httpserver.BasicAuthConfig `json:"debug_basic_auth" yaml:"debug_basic_auth"`
+	DebugClientCAFile string                     `json:"debug_client_ca_file" yaml:"debug_client_ca_file"`
+	CertFile          string                     `json:"cert_file" yaml:"cert_file"`
+	KeyFile           string                     `json:"key_file" yaml:"key_file"`
+	CORS              httpserver.CORSConfig      `json:"cors" yaml:"cors"`
+	BasicAuth         httpserver.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
 }
 
 // NewConfig creates a new API config with default values.
 func NewConfig() Config {
 	return Config{
-		Address:        "0.0.0.0:4195",
-		Enabled:        true,
-		RootPath:       "/benthos",
-		DebugEndpoints: false,
-		CertFile:       "",
-		KeyFile:        "",
-		CORS:           httpserver.NewServerCORSConfig(),
-		BasicAuth:      httpserver.NewBasicAuthConfig(),
+		Address:           "0.0.0.0:4195",
+		Enabled:           true,
+		RootPath:          "/benthos",
+		DebugEndpoints:    false,
+		DebugBasicAuth:    httpserver.NewBasicAuthConfig(),
+		DebugClientCAFile: "",
+		CertFile:          "",
+		KeyFile:           "",
+		CORS:              httpserver.NewServerCORSConfig(),
+		BasicAuth:         httpserver.NewBasicAuthConfig(),
 	}
 }
 
@@ -110,6 +117,18 @@ func New(
 	if err := conf.BasicAuth.Validate(); err != nil {
 		return nil, err
 	}
+	if err := conf.DebugBasicAuth.Validate(); err != nil {
+		return nil, fmt.Errorf("debug_basic_auth: %w", err)
+	}
+
+	if conf.DebugClientCAFile != "" {
+		if conf.CertFile == "" {
+			return nil, errors.New("debug_client_ca_file requires cert_file and key_file to be specified")
+		}
+		if server.TLSConfig, err = debugClientTLSConfig(conf); err != nil {
+			return nil, err
+		}
+	}
 
 	t := &Type{
 		conf:      conf,
@@ -221,6 +240,28 @@ func New(
 				" parameter, or for 1 second if not specified.",
 			pprof.Trace,
 		)
+		t.RegisterEndpoint(
+			"/debug/pprof/allocs", "DEBUG: Responds with a pprof-formatted allocs profile.",
+			pprof.Index,
+		)
+		t.RegisterEndpoint(
+			"/debug/pprof/threadcreate", "DEBUG: Responds with a pprof-formatted threadcreate profile.",
+			pprof.Index,
+		)
+		t.RegisterEndpoint(
+			"/debug/runtime/gomaxprocs",
+			"DEBUG: GET the current GOMAXPROCS value, or POST a new value"+
+				" with the query parameter `value`.",
+			handleGOMAXPROCS,
+		)
+		t.RegisterEndpoint(
+			"/debug/runtime/gc",
+			"DEBUG: GET the current GC percent and memory limit, or POST new"+
+				" values with the query parameters `gc_percent` and"+
+				" `memory_limit` (in bytes). The query parameter `run=true`"+
+				" triggers a garbage collection.",
+			handleGCTuning,
+		)
 	}
 
 	t.RegisterEndpoint("/ping", "Ping me.", handlePing)
@@ -257,12 +298,16 @@ func (t *Type) RegisterEndpoint(path, desc string, handlerFunc http.HandlerFunc)
 	defer t.handlersMut.Unlock()
 
 	if _, exists := t.handlers[path]; !exists {
-		wrapHandler := t.conf.BasicAuth.WrapHandler(func(w http.ResponseWriter, r *http.Request) {
+		var handler http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
 			t.handlersMut.RLock()
 			h := t.handlers[path]
 			t.handlersMut.RUnlock()
 			h(w, r)
-		})
+		}
+		if strings.HasPrefix(path, "/debug/") {
+			handler = t.wrapDebugHandler(handler)
+		}
+		wrapHandler := t.conf.BasicAuth.WrapHandler(handler)
 
 		GetMuxRoute(t.mux, path).Handler(wrapHandler)
 		GetMuxRoute(t.mux, t.conf.RootPath+path).Handler(wrapHandler)
@@ -270,6 +315,46 @@ func (t *Type) RegisterEndpoint(path, desc string, handlerFunc http.HandlerFunc)
 	t.handlers[path] = handlerFunc
 }
 
+func debugClientTLSConfig(conf Config) (*tls.Config, error) {
+	caBytes, err := os.ReadFile(conf.DebugClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read debug_client_ca_file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, errors.New("debug_client_ca_file did not contain any valid certificates")
+	}
+
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cert_file and key_file: %w", err)
+	}
+
+	// Client certificates are verified when provided, but only required by
+	// debug endpoints.
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// wrapDebugHandler enforces the authentication specific to debug endpoints.
+func (t *Type) wrapDebugHandler(next http.HandlerFunc) http.HandlerFunc {
+	next = t.conf.DebugBasicAuth.WrapHandler(next)
+	if t.conf.DebugClientCAFile == "" {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			http.Error(w, "A verified client certificate is required", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
 // ListenAndServe launches the API and blocks until the server closes or fails.
 func (t *Type) ListenAndServe() error {
 	if !t.conf.Enabled {
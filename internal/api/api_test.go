@@ -1,8 +1,11 @@
 package api_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"runtime/debug"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -183,3 +186,115 @@ func TestAPIBasicAuth(t *testing.T) {
 		}(tc))
 	}
 }
+
+func TestAPIDebugBasicAuth(t *testing.T) {
+	conf := api.NewConfig()
+	conf.DebugEndpoints = true
+	conf.DebugBasicAuth.Enabled = true
+	conf.DebugBasicAuth.Algorithm = "sha256"
+	conf.DebugBasicAuth.Username = "myuser"
+	conf.DebugBasicAuth.PasswordHash = "K7gNU3sdo+OL0wNhqoVWhr3g6s1xYv72ol/pe/Unols="
+	conf.DebugBasicAuth.Salt = "EzrwNJYw2wkErVVV1P36FQ=="
+
+	s, err := api.New("", "", conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	handler := s.Handler()
+
+	tests := []struct {
+		path         string
+		user, pass   string
+		expectedCode int
+	}{
+		{path: "/ping", expectedCode: http.StatusOK},
+		{path: "/debug/runtime/gomaxprocs", expectedCode: http.StatusUnauthorized},
+		{path: "/debug/runtime/gomaxprocs", user: "myuser", pass: "wrong", expectedCode: http.StatusUnauthorized},
+		{path: "/debug/runtime/gomaxprocs", user: "myuser", pass: "secret", expectedCode: http.StatusOK},
+		{path: "/debug/stack", expectedCode: http.StatusUnauthorized},
+		{path: "/debug/stack", user: "myuser", pass: "secret", expectedCode: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		request, _ := http.NewRequest("GET", test.path, http.NoBody)
+		if test.user != "" {
+			request.SetBasicAuth(test.user, test.pass)
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, test.expectedCode, response.Code, test.path)
+	}
+}
+
+func TestAPIDebugClientCAValidation(t *testing.T) {
+	conf := api.NewConfig()
+	conf.DebugClientCAFile = "./does_not_exist.pem"
+
+	_, err := api.New("", "", conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires cert_file and key_file")
+}
+
+func TestAPIRuntimeEndpoints(t *testing.T) {
+	conf := api.NewConfig()
+	conf.DebugEndpoints = true
+
+	s, err := api.New("", "", conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	handler := s.Handler()
+
+	doReq := func(method, path string) (int, map[string]any) {
+		t.Helper()
+		request, _ := http.NewRequest(method, path, http.NoBody)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		var res map[string]any
+		if response.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(response.Body.Bytes(), &res))
+		}
+		return response.Code, res
+	}
+
+	prevProcs := runtime.GOMAXPROCS(0)
+	t.Cleanup(func() {
+		runtime.GOMAXPROCS(prevProcs)
+	})
+
+	code, res := doReq("GET", "/debug/runtime/gomaxprocs")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(prevProcs), res["gomaxprocs"])
+
+	code, res = doReq("POST", "/debug/runtime/gomaxprocs?value=1")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(1), res["gomaxprocs"])
+
+	code, _ = doReq("POST", "/debug/runtime/gomaxprocs?value=nope")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	prevPercent := debug.SetGCPercent(100)
+	prevLimit := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() {
+		debug.SetGCPercent(prevPercent)
+		debug.SetMemoryLimit(prevLimit)
+	})
+
+	code, res = doReq("POST", "/debug/runtime/gc?gc_percent=50&memory_limit=1073741824&run=true")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(50), res["gc_percent"])
+	assert.Equal(t, float64(1073741824), res["memory_limit"])
+
+	code, res = doReq("GET", "/debug/runtime/gc")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(50), res["gc_percent"])
+
+	code, res = doReq("POST", "/debug/runtime/gc?gc_percent=-1")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(-1), res["gc_percent"])
+
+	code, _ = doReq("POST", "/debug/runtime/gc")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = doReq("DELETE", "/debug/runtime/gc")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
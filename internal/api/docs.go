@@ -21,6 +21,8 @@ func Spec() docs.FieldSpecs {
 		docs.FieldBool(
 			"debug_endpoints", "Whether to register a few extra endpoints that can be useful for debugging performance or behavioral problems.",
 		).HasDefault(false),
+		debugBasicAuthFieldSpec(),
+		docs.FieldString("debug_client_ca_file", "An optional file containing certificate authorities, when set requests to [debug endpoints](#debug-endpoints) must present a client certificate that is signed by one of them. Requires `cert_file` and `key_file` to be set.").Advanced().HasDefault("").AtVersion("4.20.0"),
 		docs.FieldString("cert_file", "An optional certificate file for enabling TLS.").Advanced().HasDefault(""),
 		docs.FieldString("key_file", "An optional key file for enabling TLS.").Advanced().HasDefault(""),
 		httpserver.ServerCORSFieldSpec(),
@@ -28,6 +30,13 @@ func Spec() docs.FieldSpecs {
 	}
 }
 
+func debugBasicAuthFieldSpec() docs.FieldSpec {
+	spec := httpserver.BasicAuthFieldSpec()
+	spec.Name = "debug_basic_auth"
+	spec.Description = "Allows you to enforce basic authentication for requests to [debug endpoints](#debug-endpoints) only, which is applied in addition to `basic_auth`."
+	return spec.AtVersion("4.20.0")
+}
+
 //go:embed docs.md
 var httpDocs string
 
@@ -57,6 +66,13 @@ http:
   enabled: true
   root_path: /benthos
   debug_endpoints: false
+  debug_basic_auth:
+    enabled: false
+    username: ""
+    password_hash: ""
+    algorithm: "sha256"
+    salt: ""
+  debug_client_ca_file: ""
   cert_file: ""
   key_file: ""
   cors:
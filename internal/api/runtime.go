@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+)
+
+func writeRuntimeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func handleGOMAXPROCS(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+	case "POST", "PUT":
+		valueStr := r.URL.Query().Get("value")
+		value, err := strconv.Atoi(valueStr)
+		if err != nil || value < 1 {
+			http.Error(w, "Query parameter `value` must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		runtime.GOMAXPROCS(value)
+	default:
+		http.Error(w, "Method not supported", http.StatusMethodNotAllowed)
+		return
+	}
+	writeRuntimeJSON(w, map[string]any{
+		"gomaxprocs": runtime.GOMAXPROCS(0),
+		"num_cpu":    runtime.NumCPU(),
+	})
+}
+
+func currentGCPercent() int {
+	// Read from runtime metrics as setting the GC percent in order to read it
+	// would race with concurrent changes.
+	samples := []metrics.Sample{{Name: "/gc/gogc:percent"}}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindUint64 {
+		return 100
+	}
+	// A disabled GC is stored as -1, which is wrapped when read as a uint64.
+	return int(int64(samples[0].Value.Uint64()))
+}
+
+func handleGCTuning(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+	case "POST", "PUT":
+		query := r.URL.Query()
+
+		var gcPercent *int
+		if v := query.Get("gc_percent"); v != "" {
+			percent, err := strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, "Query parameter `gc_percent` must be an integer", http.StatusBadRequest)
+				return
+			}
+			gcPercent = &percent
+		}
+
+		var memLimit *int64
+		if v := query.Get("memory_limit"); v != "" {
+			limit, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || limit < 0 {
+				http.Error(w, "Query parameter `memory_limit` must be a non-negative integer number of bytes", http.StatusBadRequest)
+				return
+			}
+			memLimit = &limit
+		}
+
+		if gcPercent == nil && memLimit == nil && query.Get("run") != "true" {
+			http.Error(w, "At least one of the query parameters `gc_percent`, `memory_limit` or `run` must be set", http.StatusBadRequest)
+			return
+		}
+		if gcPercent != nil {
+			debug.SetGCPercent(*gcPercent)
+		}
+		if memLimit != nil {
+			debug.SetMemoryLimit(*memLimit)
+		}
+		if query.Get("run") == "true" {
+			runtime.GC()
+		}
+	default:
+		http.Error(w, "Method not supported", http.StatusMethodNotAllowed)
+		return
+	}
+	writeRuntimeJSON(w, map[string]any{
+		"gc_percent": currentGCPercent(),
+		// A negative limit reads the current value without changing it.
+		"memory_limit": debug.SetMemoryLimit(-1),
+	})
+}
@@ -8,21 +8,25 @@ import (
 
 // Config contains configuration parameters for a batch policy.
 type Config struct {
-	ByteSize   int                `json:"byte_size" yaml:"byte_size"`
-	Count      int                `json:"count" yaml:"count"`
-	Check      string             `json:"check" yaml:"check"`
-	Period     string             `json:"period" yaml:"period"`
-	Processors []processor.Config `json:"processors" yaml:"processors"`
+	ByteSize           int                `json:"byte_size" yaml:"byte_size"`
+	CompressedByteSize int                `json:"compressed_byte_size" yaml:"compressed_byte_size"`
+	Compression        string             `json:"compression" yaml:"compression"`
+	Count              int                `json:"count" yaml:"count"`
+	Check              string             `json:"check" yaml:"check"`
+	Period             string             `json:"period" yaml:"period"`
+	Processors         []processor.Config `json:"processors" yaml:"processors"`
 }
 
 // NewConfig creates a default PolicyConfig.
 func NewConfig() Config {
 	return Config{
-		ByteSize:   0,
-		Count:      0,
-		Check:      "",
-		Period:     "",
-		Processors: []processor.Config{},
+		ByteSize:           0,
+		CompressedByteSize: 0,
+		Compression:        "gzip",
+		Count:              0,
+		Check:              "",
+		Period:             "",
+		Processors:         []processor.Config{},
 	}
 }
 
@@ -44,7 +48,7 @@ func FromAny(v any) (conf Config, err error) {
 
 // IsNoop returns true if this batch policy configuration does nothing.
 func (p Config) IsNoop() bool {
-	if p.ByteSize > 0 {
+	if p.ByteSize > 0 || p.CompressedByteSize > 0 {
 		return false
 	}
 	if p.Count > 1 {
@@ -64,7 +68,7 @@ func (p Config) IsNoop() bool {
 
 // IsLimited returns true if there's any limit on the batching policy.
 func (p Config) IsLimited() bool {
-	if p.ByteSize > 0 {
+	if p.ByteSize > 0 || p.CompressedByteSize > 0 {
 		return true
 	}
 	if p.Count > 0 {
@@ -82,7 +86,7 @@ func (p Config) IsLimited() bool {
 // IsHardLimited returns true if there's a realistic limit on the batching
 // policy, where checks are not included.
 func (p Config) IsHardLimited() bool {
-	if p.ByteSize > 0 {
+	if p.ByteSize > 0 || p.CompressedByteSize > 0 {
 		return true
 	}
 	if p.Count > 0 {
@@ -0,0 +1,98 @@
+package policy
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// The maximum number of uncompressed bytes written to an estimator between
+// flushes of its compressor, at which point the compression ratio is measured.
+// Each flush adds a small overhead to the compressed size, and so for small
+// targets the compressor is flushed more often in order that the ratio is
+// measured well before the target is reached.
+const (
+	estimatorMaxFlushBytes    = 64 * 1024
+	estimatorFlushesPerTarget = 16
+)
+
+type resettableCompressor interface {
+	io.Writer
+	Flush() error
+	Reset(w io.Writer)
+}
+
+type countingWriter struct {
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += len(p)
+	return len(p), nil
+}
+
+// compressedSizeEstimator estimates the size of a batch once compressed by
+// streaming the messages added to it through a compressor and counting the
+// bytes emitted. Compressors buffer their output, and so the size of data
+// written since the compressor was last flushed is extrapolated from the most
+// recently measured compression ratio.
+type compressedSizeEstimator struct {
+	counter    countingWriter
+	compressor resettableCompressor
+
+	flushBytes int
+	rawTotal   int
+	rawFlushed int
+	ratio      float64
+}
+
+func newCompressedSizeEstimator(algorithm string, target int) (*compressedSizeEstimator, error) {
+	e := &compressedSizeEstimator{
+		flushBytes: min(max(target/estimatorFlushesPerTarget, 1), estimatorMaxFlushBytes),
+		ratio:      1,
+	}
+
+	var err error
+	switch algorithm {
+	case "gzip", "":
+		e.compressor = gzip.NewWriter(&e.counter)
+	case "zlib":
+		e.compressor = zlib.NewWriter(&e.counter)
+	case "flate":
+		e.compressor, err = flate.NewWriter(&e.counter, flate.DefaultCompression)
+	default:
+		err = fmt.Errorf("compression algorithm not recognised: %v", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *compressedSizeEstimator) add(b []byte) {
+	_, _ = e.compressor.Write(b)
+	e.rawTotal += len(b)
+
+	if e.rawTotal-e.rawFlushed >= e.flushBytes {
+		_ = e.compressor.Flush()
+		e.rawFlushed = e.rawTotal
+		e.ratio = float64(e.counter.n) / float64(e.rawFlushed)
+	}
+}
+
+// estimate returns the estimated compressed size of all data added since the
+// last reset.
+func (e *compressedSizeEstimator) estimate() int {
+	return e.counter.n + int(float64(e.rawTotal-e.rawFlushed)*e.ratio)
+}
+
+// reset clears the data added to the estimator, the last measured compression
+// ratio is retained as the initial estimate for the next batch.
+func (e *compressedSizeEstimator) reset() {
+	e.counter.n = 0
+	e.rawTotal = 0
+	e.rawFlushed = 0
+	e.compressor.Reset(&e.counter)
+}
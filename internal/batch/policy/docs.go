@@ -34,6 +34,15 @@ Allows you to configure a [batching policy](/docs/configuration/batching).`,
 				"byte_size",
 				"An amount of bytes at which the batch should be flushed. If `0` disables size based batching.",
 			).HasDefault(0),
+			docs.FieldInt(
+				"compressed_byte_size",
+				"An amount of bytes at which the batch should be flushed, measured as the estimated size of the batch once compressed with the `compression` algorithm. This is useful for outputs that compress batches into objects, such as `aws_s3` with `archive` and `compress` batch processors, in order to consistently produce objects near a target size. If `0` disables compressed size based batching.",
+				134217728,
+			).Advanced().HasDefault(0).AtVersion("4.20.0"),
+			docs.FieldString(
+				"compression",
+				"The compression algorithm used to estimate the compressed size of a batch when `compressed_byte_size` is set, which should match the algorithm used to compress the batch.",
+			).HasOptions("gzip", "zlib", "flate").Advanced().HasDefault("gzip").AtVersion("4.20.0"),
 			docs.FieldString(
 				"period",
 				"A period in which an incomplete batch should be flushed regardless of its size.",
@@ -24,6 +24,8 @@ func TestBatchPolicySanit(t *testing.T) {
 
 	expSanit := `count: 0
 byte_size: 0
+compressed_byte_size: 0
+compression: gzip
 period: ""
 check: ""
 processors: []
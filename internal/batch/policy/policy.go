@@ -22,6 +22,8 @@ type Batcher struct {
 	log log.Modular
 
 	byteSize  int
+	compSize  int
+	estimator *compressedSizeEstimator
 	count     int
 	period    time.Duration
 	check     *mapping.Executor
@@ -32,10 +34,11 @@ type Batcher struct {
 	triggered bool
 	lastBatch time.Time
 
-	mSizeBatch   metrics.StatCounter
-	mCountBatch  metrics.StatCounter
-	mPeriodBatch metrics.StatCounter
-	mCheckBatch  metrics.StatCounter
+	mSizeBatch     metrics.StatCounter
+	mCompSizeBatch metrics.StatCounter
+	mCountBatch    metrics.StatCounter
+	mPeriodBatch   metrics.StatCounter
+	mCheckBatch    metrics.StatCounter
 }
 
 // New creates an empty policy with default rules.
@@ -44,7 +47,7 @@ func New(conf batchconfig.Config, mgr bundle.NewManagement) (*Batcher, error) {
 		return nil, errors.New("batch policy must have at least one active trigger")
 	}
 	if !conf.IsHardLimited() {
-		mgr.Logger().Warnln("Batch policy should have at least one of count, period or byte_size set in order to provide a hard batch ceiling.")
+		mgr.Logger().Warnln("Batch policy should have at least one of count, period, byte_size or compressed_byte_size set in order to provide a hard batch ceiling.")
 	}
 	var err error
 	var check *mapping.Executor
@@ -59,6 +62,12 @@ func New(conf batchconfig.Config, mgr bundle.NewManagement) (*Batcher, error) {
 			return nil, fmt.Errorf("failed to parse duration string: %v", err)
 		}
 	}
+	var estimator *compressedSizeEstimator
+	if conf.CompressedByteSize > 0 {
+		if estimator, err = newCompressedSizeEstimator(conf.Compression, conf.CompressedByteSize); err != nil {
+			return nil, err
+		}
+	}
 	var procs []iprocessor.V1
 	for i, pconf := range conf.Processors {
 		pMgr := mgr.IntoPath("processors", strconv.Itoa(i))
@@ -73,18 +82,21 @@ func New(conf batchconfig.Config, mgr bundle.NewManagement) (*Batcher, error) {
 	return &Batcher{
 		log: mgr.Logger(),
 
-		byteSize: conf.ByteSize,
-		count:    conf.Count,
-		period:   period,
-		check:    check,
-		procs:    procs,
+		byteSize:  conf.ByteSize,
+		compSize:  conf.CompressedByteSize,
+		estimator: estimator,
+		count:     conf.Count,
+		period:    period,
+		check:     check,
+		procs:     procs,
 
 		lastBatch: time.Now(),
 
-		mSizeBatch:   batchOn.With("size"),
-		mCountBatch:  batchOn.With("count"),
-		mPeriodBatch: batchOn.With("period"),
-		mCheckBatch:  batchOn.With("check"),
+		mSizeBatch:     batchOn.With("size"),
+		mCompSizeBatch: batchOn.With("compressed_size"),
+		mCountBatch:    batchOn.With("count"),
+		mPeriodBatch:   batchOn.With("period"),
+		mCheckBatch:    batchOn.With("check"),
 	}, nil
 }
 
@@ -98,6 +110,9 @@ func (p *Batcher) Add(part *message.Part) bool {
 		// so we only do it when there's a byte size based trigger.
 		p.sizeTally += len(part.AsBytes())
 	}
+	if p.estimator != nil {
+		p.estimator.add(part.AsBytes())
+	}
 	p.parts = append(p.parts, part)
 
 	if !p.triggered && p.count > 0 && len(p.parts) >= p.count {
@@ -110,6 +125,11 @@ func (p *Batcher) Add(part *message.Part) bool {
 		p.mSizeBatch.Incr(1)
 		p.log.Traceln("Batching based on byte_size")
 	}
+	if !p.triggered && p.estimator != nil && p.estimator.estimate() >= p.compSize {
+		p.triggered = true
+		p.mCompSizeBatch.Incr(1)
+		p.log.Traceln("Batching based on compressed_byte_size")
+	}
 	if p.check != nil && !p.triggered {
 		tmpMsg := message.Batch(p.parts)
 		test, err := p.check.QueryPart(tmpMsg.Len()-1, tmpMsg)
@@ -156,6 +176,9 @@ func (p *Batcher) flushAny(ctx context.Context) []message.Batch {
 	}
 	p.parts = nil
 	p.sizeTally = 0
+	if p.estimator != nil {
+		p.estimator.reset()
+	}
 	p.lastBatch = time.Now()
 	p.triggered = false
 
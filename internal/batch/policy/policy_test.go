@@ -1,7 +1,10 @@
 package policy_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"math/rand"
 	"reflect"
 	"testing"
 	"time"
@@ -161,6 +164,65 @@ func TestPolicySize(t *testing.T) {
 	}
 }
 
+func TestPolicyCompressedSize(t *testing.T) {
+	conf := batchconfig.NewConfig()
+	conf.CompressedByteSize = 50000
+
+	pol, err := policy.New(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*30)
+	t.Cleanup(func() {
+		require.NoError(t, pol.Close(tCtx))
+		done()
+	})
+
+	// Generate messages that compress by roughly a factor of two.
+	rng := rand.New(rand.NewSource(1))
+	nextMsg := func() []byte {
+		b := make([]byte, 1000)
+		for i := range b {
+			if i%2 == 0 {
+				b[i] = byte('a' + rng.Intn(26))
+			} else {
+				b[i] = ' '
+			}
+		}
+		return b
+	}
+
+	for i := 0; i < 2; i++ {
+		var rawSize int
+		for !pol.Add(message.NewPart(nextMsg())) {
+			rawSize += 1000
+			require.Less(t, rawSize, 1000000, "batch was never triggered")
+		}
+
+		msg := pol.Flush(tCtx)
+		require.NotNil(t, msg)
+
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		for _, b := range message.GetAllBytes(msg) {
+			_, _ = gw.Write(b)
+		}
+		require.NoError(t, gw.Close())
+
+		assert.Greater(t, msg.Len(), 50, "batch should exceed the uncompressed target")
+		assert.InDelta(t, 50000, buf.Len(), 5000)
+	}
+}
+
+func TestPolicyCompressedSizeBadAlgorithm(t *testing.T) {
+	conf := batchconfig.NewConfig()
+	conf.CompressedByteSize = 50000
+	conf.Compression = "nope"
+
+	_, err := policy.New(conf, mock.NewManager())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nope")
+}
+
 func TestPolicyCheck(t *testing.T) {
 	conf := batchconfig.NewConfig()
 	conf.Check = `content() == "bar"`
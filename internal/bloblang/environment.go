@@ -1,6 +1,8 @@
 package bloblang
 
 import (
+	"sync"
+
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
@@ -12,15 +14,66 @@ import (
 type Environment struct {
 	pCtx            parser.Context
 	maxMapRecursion int
+	binders         *resourceBinders
+}
+
+// ResourceFunctionBinder returns a function constructor bound to the resources
+// of a manager. The manager is provided as an opaque value in order to avoid an
+// import cycle, and is expected to implement bundle.NewManagement.
+type ResourceFunctionBinder func(mgr any) query.FunctionCtor
+
+// ResourceMethodBinder returns a method constructor bound to the resources of a
+// manager. The manager is provided as an opaque value in order to avoid an
+// import cycle, and is expected to implement bundle.NewManagement.
+type ResourceMethodBinder func(mgr any) query.MethodCtor
+
+type resourceBinders struct {
+	mut       sync.RWMutex
+	functions map[string]ResourceFunctionBinder
+	methods   map[string]ResourceMethodBinder
+}
+
+func newResourceBinders() *resourceBinders {
+	return &resourceBinders{
+		functions: map[string]ResourceFunctionBinder{},
+		methods:   map[string]ResourceMethodBinder{},
+	}
+}
+
+// without returns a copy of the binders excluding a list of function and
+// method names.
+func (r *resourceBinders) without(functions, methods []string) *resourceBinders {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+
+	c := newResourceBinders()
+	for k, v := range r.functions {
+		c.functions[k] = v
+	}
+	for k, v := range r.methods {
+		c.methods[k] = v
+	}
+	for _, k := range functions {
+		delete(c.functions, k)
+	}
+	for _, k := range methods {
+		delete(c.methods, k)
+	}
+	return c
 }
 
+// Binders of the global environment are shared in the same way as its
+// functions and methods.
+var globalResourceBinders = newResourceBinders()
+
 // GlobalEnvironment returns the global default environment. Modifying this
 // environment will impact all Bloblang parses that aren't initialized with an
 // isolated environment, as well as any new environments initialized after the
 // changes.
 func GlobalEnvironment() *Environment {
 	return &Environment{
-		pCtx: parser.GlobalContext(),
+		pCtx:    parser.GlobalContext(),
+		binders: globalResourceBinders,
 	}
 }
 
@@ -43,7 +96,8 @@ func NewEnvironment() *Environment {
 // empty, where no functions or methods are initially available.
 func NewEmptyEnvironment() *Environment {
 	return &Environment{
-		pCtx: parser.EmptyContext(),
+		pCtx:    parser.EmptyContext(),
+		binders: newResourceBinders(),
 	}
 }
 
@@ -77,6 +131,18 @@ func (e *Environment) NewMapping(blobl string) (*mapping.Executor, error) {
 	return exec, nil
 }
 
+// NewQuery parses a single Bloblang query expression using the Environment to
+// determine the features (functions and methods) available to the query.
+//
+// When a parsing error occurs the error will be the type *parser.Error.
+func (e *Environment) NewQuery(expr string) (query.Function, error) {
+	fn, err := parser.ParseQuery(e.pCtx, expr)
+	if err != nil {
+		return nil, err
+	}
+	return fn, nil
+}
+
 // Deactivated returns a version of the environment where constructors are
 // disabled for all functions and methods, allowing mappings to be parsed and
 // validated but not executed.
@@ -101,17 +167,87 @@ func (e *Environment) OnlyPure() *Environment {
 	env := *e
 	env.pCtx.Functions = env.pCtx.Functions.OnlyPure()
 	env.pCtx.Methods = env.pCtx.Methods.OnlyPure()
+	env.binders = env.binders.without(nil, nil)
 	return &env
 }
 
-// RegisterMethod adds a new Bloblang method to the environment.
+// RegisterMethod adds a new Bloblang method to the environment, replacing any
+// resource binder previously set for a method of the same name.
 func (e *Environment) RegisterMethod(spec query.MethodSpec, ctor query.MethodCtor) error {
-	return e.pCtx.Methods.Add(spec, ctor)
+	if err := e.pCtx.Methods.Add(spec, ctor); err != nil {
+		return err
+	}
+	e.binders.mut.Lock()
+	delete(e.binders.methods, spec.Name)
+	e.binders.mut.Unlock()
+	return nil
 }
 
-// RegisterFunction adds a new Bloblang function to the environment.
+// RegisterFunction adds a new Bloblang function to the environment, replacing
+// any resource binder previously set for a function of the same name.
 func (e *Environment) RegisterFunction(spec query.FunctionSpec, ctor query.FunctionCtor) error {
-	return e.pCtx.Functions.Add(spec, ctor)
+	if err := e.pCtx.Functions.Add(spec, ctor); err != nil {
+		return err
+	}
+	e.binders.mut.Lock()
+	delete(e.binders.functions, spec.Name)
+	e.binders.mut.Unlock()
+	return nil
+}
+
+// SetResourceFunctionBinder sets a binder for a function registered to the
+// environment. When a manager is created with the environment the function is
+// replaced with one constructed from the binder, allowing it to access the
+// resources of the manager.
+//
+// The function should be registered with a constructor that fails when
+// executed unbound.
+func (e *Environment) SetResourceFunctionBinder(name string, binder ResourceFunctionBinder) {
+	e.binders.mut.Lock()
+	e.binders.functions[name] = binder
+	e.binders.mut.Unlock()
+}
+
+// SetResourceMethodBinder sets a binder for a method registered to the
+// environment. When a manager is created with the environment the method is
+// replaced with one constructed from the binder, allowing it to access the
+// resources of the manager.
+//
+// The method should be registered with a constructor that fails when executed
+// unbound.
+func (e *Environment) SetResourceMethodBinder(name string, binder ResourceMethodBinder) {
+	e.binders.mut.Lock()
+	e.binders.methods[name] = binder
+	e.binders.mut.Unlock()
+}
+
+// WithResourcesBound returns a copy of the environment where the functions and
+// methods that have resource binders are replaced with versions bound to a
+// manager.
+func (e *Environment) WithResourcesBound(mgr any) *Environment {
+	e.binders.mut.RLock()
+	defer e.binders.mut.RUnlock()
+
+	if len(e.binders.functions) == 0 && len(e.binders.methods) == 0 {
+		return e
+	}
+
+	env := *e
+	env.pCtx.Functions = env.pCtx.Functions.Without()
+	env.pCtx.Methods = env.pCtx.Methods.Without()
+	env.binders = newResourceBinders()
+
+	for _, spec := range e.pCtx.Functions.Docs() {
+		if bind, exists := e.binders.functions[spec.Name]; exists {
+			_ = env.pCtx.Functions.Add(spec, bind(mgr))
+		}
+	}
+	for _, spec := range e.pCtx.Methods.Docs() {
+		if bind, exists := e.binders.methods[spec.Name]; exists {
+			_ = env.pCtx.Methods.Add(spec, bind(mgr))
+		}
+	}
+	return &env
 }
 
 // WithImporter returns a new environment where Bloblang imports are performed
@@ -156,6 +292,7 @@ func (e *Environment) WithCustomImporter(fn func(name string) ([]byte, error)) *
 func (e *Environment) WithoutMethods(names ...string) *Environment {
 	env := *e
 	env.pCtx.Methods = env.pCtx.Methods.Without(names...)
+	env.binders = env.binders.without(nil, names)
 	return &env
 }
 
@@ -165,6 +302,7 @@ func (e *Environment) WithoutMethods(names ...string) *Environment {
 func (e *Environment) WithoutFunctions(names ...string) *Environment {
 	env := *e
 	env.pCtx.Functions = env.pCtx.Functions.Without(names...)
+	env.binders = env.binders.without(names, nil)
 	return &env
 }
 
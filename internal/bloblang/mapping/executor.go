@@ -57,6 +57,12 @@ func NewStatement(input []rune, assignment Assignment, query query.Function) Sta
 	}
 }
 
+// Input returns the slice of the parsed expression that begins with this
+// statement, which may be empty.
+func (s Statement) Input() []rune {
+	return s.input
+}
+
 //------------------------------------------------------------------------------
 
 // Executor is a parsed bloblang mapping that can be executed on a Benthos
@@ -98,6 +104,17 @@ func (e *Executor) Annotation() string {
 	return e.annotation
 }
 
+// Input returns the parsed expression that created the executor, which may be
+// empty.
+func (e *Executor) Input() []rune {
+	return e.input
+}
+
+// Statements returns the ordered list of statements executed by the mapping.
+func (e *Executor) Statements() []Statement {
+	return e.statements
+}
+
 // Maps returns any map definitions contained within the mapping.
 func (e *Executor) Maps() map[string]query.Function {
 	return e.maps
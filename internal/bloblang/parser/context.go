@@ -2,9 +2,11 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 )
@@ -16,6 +18,12 @@ type Context struct {
 	Methods      *query.MethodSet
 	namedContext *namedContext
 	importer     Importer
+
+	// The path of the file currently being parsed along with the paths of all
+	// files that imported it, used for detecting import cycles.
+	importStack []string
+
+	userFunctions map[string]*userFunction
 }
 
 // EmptyContext returns a parser context with no functions, methods or import
@@ -86,9 +94,32 @@ func (pCtx Context) WithImporter(importer Importer) Context {
 // itself be relative (to the current importer directory) or absolute.
 func (pCtx Context) WithImporterRelativeToFile(pathStr string) Context {
 	pCtx.importer = pCtx.importer.RelativeToFile(pathStr)
+	pCtx.importStack = append(pCtx.importStack[:len(pCtx.importStack):len(pCtx.importStack)], pCtx.resolveImportPath(pathStr))
 	return pCtx
 }
 
+// resolveImportPath returns the path of an import relative to the file
+// currently being parsed, which is used for identifying files within the
+// import stack.
+func (pCtx Context) resolveImportPath(pathStr string) string {
+	if !filepath.IsAbs(pathStr) && len(pCtx.importStack) > 0 {
+		pathStr = filepath.Join(filepath.Dir(pCtx.importStack[len(pCtx.importStack)-1]), pathStr)
+	}
+	return filepath.Clean(pathStr)
+}
+
+// checkImportCycle returns an error if importing a given path would result in
+// an import cycle.
+func (pCtx Context) checkImportCycle(pathStr string) error {
+	resolved := pCtx.resolveImportPath(pathStr)
+	for i, p := range pCtx.importStack {
+		if p == resolved {
+			return fmt.Errorf("import cycle detected: %v", strings.Join(append(pCtx.importStack[i:len(pCtx.importStack):len(pCtx.importStack)], resolved), " -> "))
+		}
+	}
+	return nil
+}
+
 // Deactivated returns a version of the parser context where all functions and
 // methods exist but can no longer be instantiated. This means it's possible to
 // parse and validate mappings but not execute them. If the context also has an
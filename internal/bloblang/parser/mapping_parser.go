@@ -3,6 +3,7 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/Jeffail/gabs/v2"
@@ -43,6 +44,14 @@ func ParseMapping(pCtx Context, expr string) (*mapping.Executor, *Error) {
 //------------------------------------------------------------------------------'
 
 func parseExecutor(pCtx Context) Func {
+	return parseExecutorWithFunctions(pCtx, nil)
+}
+
+// parseExecutorWithFunctions parses a mapping where any functions that are
+// defined or imported are added to the provided map, allowing them to be
+// exported by imports. When the provided map is nil functions are only
+// accessible within the mapping itself.
+func parseExecutorWithFunctions(pCtx Context, funcs map[string]*userFunction) Func {
 	newline := NewlineAllowComment()
 	whitespace := SpacesAndTabs()
 	allWhitespace := DiscardAll(OneOf(whitespace, newline))
@@ -51,9 +60,15 @@ func parseExecutor(pCtx Context) Func {
 		maps := map[string]query.Function{}
 		statements := []mapping.Statement{}
 
+		pCtx := pCtx
+		if pCtx.userFunctions = funcs; pCtx.userFunctions == nil {
+			pCtx.userFunctions = map[string]*userFunction{}
+		}
+
 		statement := OneOf(
 			importParser(maps, pCtx),
 			mapParser(maps, pCtx),
+			funcParser(maps, pCtx),
 			letStatementParser(pCtx),
 			metaStatementParser(false, pCtx),
 			plainMappingStatementParser(pCtx),
@@ -115,6 +130,9 @@ func singleRootImport(pCtx Context) Func {
 		}
 
 		fpath := res.Payload.([]any)[3].(string)
+		if err := pCtx.checkImportCycle(fpath); err != nil {
+			return Fail(NewFatalError(input, err), input)
+		}
 		contents, err := pCtx.importer.Import(fpath)
 		if err != nil {
 			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
@@ -200,6 +218,9 @@ func importParser(maps map[string]query.Function, pCtx Context) Func {
 		}
 
 		fpath := res.Payload.([]any)[2].(string)
+		if err := pCtx.checkImportCycle(fpath); err != nil {
+			return Fail(NewFatalError(input, err), input)
+		}
 		contents, err := pCtx.importer.Import(fpath)
 		if err != nil {
 			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
@@ -208,14 +229,29 @@ func importParser(maps map[string]query.Function, pCtx Context) Func {
 		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
 
 		importContent := []rune(string(contents))
-		execRes := parseExecutor(nextCtx)(importContent)
+		importedFuncs := map[string]*userFunction{}
+		execRes := parseExecutorWithFunctions(nextCtx, importedFuncs)(importContent)
 		if execRes.Err != nil {
 			return Fail(NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
 		}
 
 		exec := execRes.Payload.(*mapping.Executor)
-		if len(exec.Maps()) == 0 {
-			err := fmt.Errorf("no maps to import from '%v'", fpath)
+		if len(exec.Maps()) == 0 && len(importedFuncs) == 0 {
+			err := fmt.Errorf("no maps or functions to import from '%v'", fpath)
+			return Fail(NewFatalError(input, err), input)
+		}
+
+		var funcCollisions []string
+		for k, v := range importedFuncs {
+			if _, exists := pCtx.userFunctions[k]; exists {
+				funcCollisions = append(funcCollisions, k)
+			} else {
+				pCtx.userFunctions[k] = v
+			}
+		}
+		if len(funcCollisions) > 0 {
+			sort.Strings(funcCollisions)
+			err := fmt.Errorf("function name collisions from import '%v': %v", fpath, funcCollisions)
 			return Fail(NewFatalError(input, err), input)
 		}
 
@@ -303,6 +339,141 @@ func mapParser(maps map[string]query.Function, pCtx Context) Func {
 	}
 }
 
+type userFunction struct {
+	params     query.Params
+	paramNames []string
+	exec       *mapping.Executor
+}
+
+func (u *userFunction) call(name string, args *query.ParsedParams, argFns []query.Function) query.Function {
+	return query.ClosureFunction("function "+name, func(ctx query.FunctionContext) (any, error) {
+		resolved, err := args.ResolveDynamic(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("function '%s': %w", name, err)
+		}
+
+		// Function bodies run with a fresh variable scope containing only the
+		// parameters, so variables of the caller are neither visible nor
+		// modified.
+		vars := make(map[string]any, len(u.paramNames))
+		for i, v := range resolved.Raw() {
+			vars[u.paramNames[i]] = v
+		}
+		ctx.Vars = vars
+		return u.exec.Exec(ctx)
+	}, func(ctx query.TargetsContext) (query.TargetsContext, []query.TargetPath) {
+		var targets []query.TargetPath
+		for _, fn := range argFns {
+			_, argTargets := fn.QueryTargets(ctx)
+			targets = append(targets, argTargets...)
+		}
+		_, bodyTargets := u.exec.QueryTargets(ctx)
+		return ctx, append(targets, bodyTargets...)
+	})
+}
+
+func funcParser(maps map[string]query.Function, pCtx Context) Func {
+	newline := NewlineAllowComment()
+	whitespace := SpacesAndTabs()
+	allWhitespace := DiscardAll(OneOf(whitespace, newline))
+
+	p := Sequence(
+		Term("func"),
+		whitespace,
+		// Prevents a missing name from being captured by the next parser
+		MustBe(
+			Expect(
+				SnakeCase(),
+				"function name",
+			),
+		),
+		MustBe(
+			DelimitedPattern(
+				Sequence(
+					Char('('),
+					allWhitespace,
+				),
+				Expect(varNameParser(), "parameter name"),
+				Sequence(
+					allWhitespace,
+					Char(','),
+					allWhitespace,
+				),
+				Sequence(
+					allWhitespace,
+					Char(')'),
+				),
+				true,
+			),
+		),
+		SpacesAndTabs(),
+		DelimitedPattern(
+			Sequence(
+				Char('{'),
+				allWhitespace,
+			),
+			OneOf(
+				letStatementParser(pCtx),
+				metaStatementParser(true, pCtx),
+				plainMappingStatementParser(pCtx),
+			),
+			Sequence(
+				Discard(whitespace),
+				newline,
+				allWhitespace,
+			),
+			Sequence(
+				allWhitespace,
+				Char('}'),
+			),
+			true,
+		),
+	)
+
+	return func(input []rune) Result {
+		res := p(input)
+		if res.Err != nil {
+			return res
+		}
+
+		seqSlice := res.Payload.([]any)
+		ident := seqSlice[2].(string)
+		paramSlice := seqSlice[3].([]any)
+		stmtSlice := seqSlice[5].([]any)
+
+		if _, exists := pCtx.userFunctions[ident]; exists {
+			return Fail(NewFatalError(input, fmt.Errorf("function name collision: %v", ident)), input)
+		}
+		if _, err := pCtx.Functions.Params(ident); err == nil {
+			return Fail(NewFatalError(input, fmt.Errorf("function name collides with an existing function: %v", ident)), input)
+		}
+
+		fn := &userFunction{
+			params: query.NewParams(),
+		}
+		for _, v := range paramSlice {
+			name := v.(string)
+			for _, existing := range fn.paramNames {
+				if existing == name {
+					return Fail(NewFatalError(input, fmt.Errorf("duplicate parameter name: %v", name)), input)
+				}
+			}
+			fn.paramNames = append(fn.paramNames, name)
+			fn.params = fn.params.Add(query.ParamAny(name, ""))
+		}
+
+		statements := make([]mapping.Statement, len(stmtSlice))
+		for i, v := range stmtSlice {
+			statements[i] = v.(mapping.Statement)
+		}
+
+		fn.exec = mapping.NewExecutor("func "+ident, input, maps, statements...)
+		pCtx.userFunctions[ident] = fn
+
+		return Success(ident, res.Remaining)
+	}
+}
+
 func letStatementParser(pCtx Context) Func {
 	p := Sequence(
 		Expect(Term("let"), "assignment"),
@@ -37,12 +37,12 @@ func TestMappingErrors(t *testing.T) {
 		},
 		"no mappings": {
 			mapping:     ``,
-			errContains: `line 1 char 1: expected import, map, or assignment`,
+			errContains: `line 1 char 1: expected import, map, func, or assignment`,
 		},
 		"no mappings 2": {
 			mapping: `
    `,
-			errContains: `line 2 char 4: expected import, map, or assignment`,
+			errContains: `line 2 char 4: expected import, map, func, or assignment`,
 		},
 		"double mapping": {
 			mapping:     `foo = bar bar = baz`,
@@ -83,13 +83,13 @@ meta foo = "bar"`,
 		"bad char 2": {
 			mapping: `let foo = bar
 !foo = bar`,
-			errContains: `line 2 char 1: expected import, map, or assignment`,
+			errContains: `line 2 char 1: expected import, map, func, or assignment`,
 		},
 		"bad char 3": {
 			mapping: `let foo = bar
 !foo = bar
 this = that`,
-			errContains: `line 2 char 1: expected import, map, or assignment`,
+			errContains: `line 2 char 1: expected import, map, func, or assignment`,
 		},
 		"bad query": {
 			mapping:     `foo = blah.`,
@@ -139,7 +139,7 @@ foo = bar.apply("from_import")`, badMapFile),
 			mapping: fmt.Sprintf(`import "%v"
 
 foo = bar.apply("from_import")`, noMapsFile),
-			errContains: fmt.Sprintf(`line 1 char 1: no maps to import from '%v'`, noMapsFile),
+			errContains: fmt.Sprintf(`line 1 char 1: no maps or functions to import from '%v'`, noMapsFile),
 		},
 		"colliding maps file import": {
 			mapping: fmt.Sprintf(`map "foo" { this = that }			
@@ -152,7 +152,7 @@ foo = bar.apply("foo")`, goodMapFile),
 		"quotes at root": {
 			mapping: `
 "root.something" = 5 + 2`,
-			errContains: "line 2 char 1: expected import, map, or assignment",
+			errContains: "line 2 char 1: expected import, map, func, or assignment",
 		},
 	}
 
@@ -502,3 +502,129 @@ root = this.apply("foo")`, goodMapFile),
 		})
 	}
 }
+
+func TestMappingFunctions(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "enrich.blobl"), []byte(`import "./strings.blobl"
+
+func full_name(first, last) {
+  root = shout($first) + " " + shout($last)
+}
+
+map person {
+  root.name = full_name(this.first, this.last)
+}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "strings.blobl"), []byte(`func shout(v) {
+  root = $v.uppercase()
+}`), 0o644))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.blobl"), []byte(`import "./b.blobl"`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.blobl"), []byte(`import "./a.blobl"`), 0o644))
+
+	tests := map[string]struct {
+		mapping string
+		input   string
+		output  string
+		err     string
+	}{
+		"local function": {
+			mapping: `func greet(name, greeting) {
+  let tmp = $greeting + " " + $name
+  root = $tmp
+}
+root.a = greet(this.name, "hello")
+root.b = greet(greeting: "hey", name: this.name.uppercase())`,
+			input:  `{"name":"bob"}`,
+			output: `{"a":"hello bob","b":"hey BOB"}`,
+		},
+		"function with this context": {
+			mapping: `func tagged() {
+  root = this.tags.join(",")
+}
+root.tags = tagged()`,
+			input:  `{"tags":["a","b"]}`,
+			output: `{"tags":"a,b"}`,
+		},
+		"function variables are isolated": {
+			mapping: `func fn(v) {
+  root = $v + $tmp.or("unset")
+}
+let tmp = "set"
+root = fn("value: ")`,
+			input:  `{}`,
+			output: `value: unset`,
+		},
+		"imported functions": {
+			mapping: fmt.Sprintf(`import "%v"
+root.a = full_name(this.first, this.last)
+root.b = shout("nested")
+root.c = this.apply("person")`, filepath.Join(dir, "lib", "enrich.blobl")),
+			input:  `{"first":"john","last":"oates"}`,
+			output: `{"a":"JOHN OATES","b":"NESTED","c":{"name":"JOHN OATES"}}`,
+		},
+		"import cycle": {
+			mapping: fmt.Sprintf(`import "%v"`, filepath.Join(dir, "a.blobl")),
+			err:     "import cycle detected",
+		},
+		"recursion not allowed": {
+			mapping: `func fn(v) {
+  root = fn($v)
+}`,
+			err: "unrecognised function 'fn'",
+		},
+		"duplicate function": {
+			mapping: `func fn(v) {
+  root = $v
+}
+func fn(v) {
+  root = $v
+}`,
+			err: "line 4 char 1: function name collision: fn",
+		},
+		"builtin function collision": {
+			mapping: `func uuid_v4() {
+  root = "nope"
+}`,
+			err: "line 1 char 1: function name collides with an existing function: uuid_v4",
+		},
+		"duplicate parameters": {
+			mapping: `func fn(a, a) {
+  root = $a
+}`,
+			err: "line 1 char 1: duplicate parameter name: a",
+		},
+		"wrong arg count": {
+			mapping: `func fn(a, b) {
+  root = $a
+}
+root = fn("foo")`,
+			err: "line 4 char 8: missing parameter: b",
+		},
+		"import collision": {
+			mapping: fmt.Sprintf(`func shout(v) {
+  root = $v
+}
+import "%v"`, filepath.Join(dir, "lib", "strings.blobl")),
+			err: "function name collisions from import",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			exec, perr := ParseMapping(GlobalContext(), test.mapping)
+			if test.err != "" {
+				require.NotNil(t, perr)
+				assert.Contains(t, perr.ErrorAtPosition([]rune(test.mapping)), test.err)
+				return
+			}
+			require.Nil(t, perr)
+
+			resPart, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(test.input)}))
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(resPart.AsBytes()))
+		})
+	}
+}
@@ -388,6 +388,25 @@ func functionParser(pCtx Context) Func {
 		seqSlice := res.Payload.([]any)
 
 		targetFunc := seqSlice[0].(string)
+		if uFn, exists := pCtx.userFunctions[targetFunc]; exists {
+			args := seqSlice[1].([]any)
+			parsedParams, err := extractArgsParserResult(uFn.params, args)
+			if err != nil {
+				return Fail(NewFatalError(input, err), input)
+			}
+
+			var argFns []query.Function
+			for _, arg := range args {
+				if nArg, isNamed := arg.(namedArg); isNamed {
+					arg = nArg.value
+				}
+				if fn, isFn := arg.(query.Function); isFn {
+					argFns = append(argFns, fn)
+				}
+			}
+			return Success(uFn.call(targetFunc, parsedParams, argFns), res.Remaining)
+		}
+
 		params, err := pCtx.Functions.Params(targetFunc)
 		if err != nil {
 			return Fail(NewFatalError(input, err), input)
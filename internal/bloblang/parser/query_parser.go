@@ -25,6 +25,20 @@ func queryParser(pCtx Context) Func {
 	}
 }
 
+// ParseQuery attempts to parse a single query expression, returning an error if
+// the expression is followed by anything other than whitespace.
+func ParseQuery(pCtx Context, expr string) (query.Function, *Error) {
+	res := queryParser(pCtx)([]rune(expr))
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	remaining := DiscardAll(OneOf(SpacesAndTabs(), Newline()))(res.Remaining)
+	if len(remaining.Remaining) > 0 {
+		return nil, NewError(remaining.Remaining, "end of input")
+	}
+	return res.Payload.(query.Function), nil
+}
+
 func tryParseQuery(expr string) (query.Function, *Error) {
 	res := queryParser(Context{
 		Functions: query.AllFunctions,
@@ -154,6 +154,7 @@ var (
 	MethodCategoryParsing        = "Parsing"
 	MethodCategoryObjectAndArray = "Object & Array Manipulation"
 	MethodCategoryGeoIP          = "GeoIP"
+	MethodCategoryGeospatial     = "Geospatial"
 	MethodCategoryDeprecated     = "Deprecated"
 	MethodCategoryPlugin         = "Plugin"
 )
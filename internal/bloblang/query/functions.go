@@ -670,6 +670,30 @@ var _ = registerFunction(
 
 //------------------------------------------------------------------------------
 
+// seededRandom returns a func that provides a pseudo-random number generator
+// seeded with the result of a query, which is resolved once during the
+// lifetime of the mapping. The returned func must be called with the returned
+// mutex held.
+func seededRandom(seedFn Function) (func(ctx FunctionContext) (*rand.Rand, error), *sync.Mutex) {
+	var randMut sync.Mutex
+	var r *rand.Rand
+	return func(ctx FunctionContext) (*rand.Rand, error) {
+		if r != nil {
+			return r, nil
+		}
+		seedI, err := seedFn.Exec(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to seed random number generator: %v", err)
+		}
+		seed, err := IToInt(seedI)
+		if err != nil {
+			return nil, fmt.Errorf("failed to seed random number generator: %v", err)
+		}
+		r = rand.New(rand.NewSource(seed))
+		return r, nil
+	}, &randMut
+}
+
 var _ = registerFunction(
 	NewFunctionSpec(
 		FunctionCategoryGeneral, "random_int",
@@ -720,25 +744,15 @@ func randomIntFunction(args *ParsedParams) (Function, error) {
 	if max == math.MaxInt64 {
 		return nil, fmt.Errorf("max must be smaller than the max allowed for an int64 (%d)", uint64(math.MaxInt64))
 	}
-	var randMut sync.Mutex
-	var r *rand.Rand
+	getRand, randMut := seededRandom(seedFn)
 
 	return ClosureFunction("function random_int", func(ctx FunctionContext) (any, error) {
 		randMut.Lock()
 		defer randMut.Unlock()
 
-		if r == nil {
-			seedI, err := seedFn.Exec(ctx)
-			if err != nil {
-				return nil, fmt.Errorf("failed to seed random number generator: %v", err)
-			}
-
-			seed, err := IToInt(seedI)
-			if err != nil {
-				return nil, fmt.Errorf("failed to seed random number generator: %v", err)
-			}
-
-			r = rand.New(rand.NewSource(seed))
+		r, err := getRand(ctx)
+		if err != nil {
+			return nil, err
 		}
 		// Int63n generates a random number within a half-open interval [0,n)
 		v := r.Int63n(max-min+1) + min
@@ -850,21 +864,95 @@ root.doc.contents = (this.body.content | this.thing.body)`,
 
 //------------------------------------------------------------------------------
 
-var _ = registerSimpleFunction(
+var _ = registerFunction(
 	NewFunctionSpec(
 		FunctionCategoryGeneral, "uuid_v4",
 		"Generates a new RFC-4122 UUID each time it is invoked and prints a string representation.",
 		NewExampleSpec("", `root.id = uuid_v4()`),
-	),
-	func(_ FunctionContext) (any, error) {
-		u4, err := uuid.NewV4()
+		NewExampleSpec("It is possible to specify a seed argument in order to generate a deterministic sequence of UUIDs, which is useful for reproducible test fixtures. If a query is provided it will only be resolved once during the lifetime of the mapping.", `root.id = uuid_v4(42)`),
+	).
+		Param(ParamQuery(
+			"seed",
+			"An optional seed to use for generating a deterministic sequence of UUIDs, if a query is provided it will only be resolved once during the lifetime of the mapping.",
+			true,
+		).Optional()),
+	uuidV4Function,
+)
+
+func uuidV4Function(args *ParsedParams) (Function, error) {
+	// Tolerate a nil set of params for callers that initialise the function
+	// directly without parsing arguments.
+	var seedFn Function
+	if args != nil {
+		var err error
+		if seedFn, err = args.FieldOptionalQuery("seed"); err != nil {
+			return nil, err
+		}
+	}
+	if seedFn == nil {
+		return ClosureFunction("function uuid_v4", func(_ FunctionContext) (any, error) {
+			u4, err := uuid.NewV4()
+			if err != nil {
+				panic(err)
+			}
+			return u4.String(), nil
+		}, nil), nil
+	}
+
+	getRand, randMut := seededRandom(seedFn)
+	return ClosureFunction("function uuid_v4", func(ctx FunctionContext) (any, error) {
+		randMut.Lock()
+		defer randMut.Unlock()
+
+		r, err := getRand(ctx)
 		if err != nil {
-			panic(err)
+			return nil, err
+		}
+		u4, err := uuid.NewGenWithOptions(uuid.WithRandomReader(r)).NewV4()
+		if err != nil {
+			return nil, err
 		}
 		return u4.String(), nil
-	},
+	}, nil), nil
+}
+
+//------------------------------------------------------------------------------
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "uuid_v7",
+		"Generates a new time-ordered UUID version 7 each time it is invoked and prints a string representation. UUIDs are ordered by their timestamp with millisecond precision, which makes them well suited as database keys, but the order of UUIDs generated within the same millisecond is not guaranteed.",
+		NewExampleSpec("", `root.id = uuid_v7()`),
+		NewExampleSpec("It is possible to specify the timestamp of the UUID, which is useful when backfilling records.", `root.id = uuid_v7(this.created_at.ts_parse("2006-01-02T15:04:05Z07:00"))`),
+	).AtVersion("4.20.0").
+		Param(ParamAny("time", "An optional timestamp to derive the UUID from, defaults to the current time.").Optional()),
+	uuidV7Function,
 )
 
+func uuidV7Function(args *ParsedParams) (Function, error) {
+	tsArg, err := args.Field("time")
+	if err != nil {
+		return nil, err
+	}
+	gen := uuid.NewGen()
+	if tsArg != nil {
+		t, err := IGetTimestamp(tsArg)
+		if err != nil {
+			return nil, err
+		}
+		gen = uuid.NewGenWithOptions(uuid.WithEpochFunc(func() time.Time {
+			return t
+		}))
+	}
+	return ClosureFunction("function uuid_v7", func(_ FunctionContext) (any, error) {
+		u7, err := gen.NewV7()
+		if err != nil {
+			return nil, err
+		}
+		return u7.String(), nil
+	}, nil), nil
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerFunction(
@@ -874,12 +962,20 @@ var _ = registerFunction(
 		NewExampleSpec("", `root.id = nanoid()`),
 		NewExampleSpec("It is possible to specify an optional length parameter.", `root.id = nanoid(54)`),
 		NewExampleSpec("It is also possible to specify an optional custom alphabet after the length parameter.", `root.id = nanoid(54, "abcde")`),
+		NewExampleSpec("It is possible to specify a seed argument in order to generate a deterministic sequence of IDs, which is useful for reproducible test fixtures.", `root.id = nanoid(seed: 42)`),
 	).
 		Param(ParamInt64("length", "An optional length.").Optional()).
-		Param(ParamString("alphabet", "An optional custom alphabet to use for generating IDs. When specified the field `length` must also be present.").Optional()),
+		Param(ParamString("alphabet", "An optional custom alphabet to use for generating IDs. When specified the field `length` must also be present.").Optional()).
+		Param(ParamQuery(
+			"seed",
+			"An optional seed to use for generating a deterministic sequence of IDs, if a query is provided it will only be resolved once during the lifetime of the mapping.",
+			true,
+		).Optional()),
 	nanoidFunction,
 )
 
+const nanoidDefaultAlphabet = "_-0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
 func nanoidFunction(args *ParsedParams) (Function, error) {
 	lenArg, err := args.FieldOptionalInt64("length")
 	if err != nil {
@@ -892,6 +988,41 @@ func nanoidFunction(args *ParsedParams) (Function, error) {
 	if alphabetArg != nil && lenArg == nil {
 		return nil, errors.New("field length must be specified when an alphabet is specified")
 	}
+	seedFn, err := args.FieldOptionalQuery("seed")
+	if err != nil {
+		return nil, err
+	}
+	if seedFn != nil {
+		alphabet := []rune(nanoidDefaultAlphabet)
+		if alphabetArg != nil {
+			alphabet = []rune(*alphabetArg)
+		}
+		if len(alphabet) == 0 {
+			return nil, errors.New("alphabet must not be empty")
+		}
+		length := 21
+		if lenArg != nil {
+			length = int(*lenArg)
+		}
+		if length < 1 {
+			return nil, errors.New("length must be a positive integer")
+		}
+		getRand, randMut := seededRandom(seedFn)
+		return ClosureFunction("function nanoid", func(ctx FunctionContext) (any, error) {
+			randMut.Lock()
+			defer randMut.Unlock()
+
+			r, err := getRand(ctx)
+			if err != nil {
+				return nil, err
+			}
+			id := make([]rune, length)
+			for i := range id {
+				id[i] = alphabet[r.Intn(len(alphabet))]
+			}
+			return string(id), nil
+		}, nil), nil
+	}
 	return ClosureFunction("function nanoid", func(ctx FunctionContext) (any, error) {
 		if alphabetArg != nil {
 			return gonanoid.Generate(*alphabetArg, int(*lenArg))
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -268,6 +269,83 @@ func TestNanoidFunctionAlphabet(t *testing.T) {
 	assert.Equal(t, "a", res)
 }
 
+func TestNanoidFunctionSeeded(t *testing.T) {
+	sequence := func() []any {
+		e, err := InitFunctionHelper("nanoid", int64(10), "abc", int64(42))
+		require.Nil(t, err)
+
+		var ids []any
+		for i := 0; i < 3; i++ {
+			res, err := e.Exec(FunctionContext{})
+			require.NoError(t, err)
+			require.Len(t, res, 10)
+			ids = append(ids, res)
+		}
+		return ids
+	}
+
+	first := sequence()
+	assert.Equal(t, first, sequence())
+	assert.NotEqual(t, first[0], first[1])
+	assert.Regexp(t, "^[abc]{10}$", first[0])
+}
+
+func TestNanoidFunctionSeededBadLength(t *testing.T) {
+	for _, length := range []int64{0, -1} {
+		_, err := InitFunctionHelper("nanoid", length, "abc", int64(42))
+		require.Error(t, err, length)
+	}
+}
+
+func TestUUIDV4FunctionSeeded(t *testing.T) {
+	sequence := func(seed int64) []any {
+		e, err := InitFunctionHelper("uuid_v4", seed)
+		require.Nil(t, err)
+
+		var ids []any
+		for i := 0; i < 3; i++ {
+			res, err := e.Exec(FunctionContext{})
+			require.NoError(t, err)
+			ids = append(ids, res)
+		}
+		return ids
+	}
+
+	first := sequence(42)
+	assert.Equal(t, first, sequence(42))
+	assert.NotEqual(t, first, sequence(43))
+	assert.NotEqual(t, first[0], first[1])
+
+	u, err := uuid.FromString(first[0].(string))
+	require.NoError(t, err)
+	assert.Equal(t, byte(uuid.V4), u.Version())
+}
+
+func TestUUIDV7Function(t *testing.T) {
+	e, err := InitFunctionHelper("uuid_v7")
+	require.Nil(t, err)
+
+	var prev string
+	for i := 0; i < 100; i++ {
+		res, err := e.Exec(FunctionContext{})
+		require.NoError(t, err)
+
+		u, err := uuid.FromString(res.(string))
+		require.NoError(t, err)
+		assert.Equal(t, byte(uuid.V7), u.Version())
+		assert.Greater(t, res.(string), prev)
+		prev = res.(string)
+	}
+
+	e, err = InitFunctionHelper("uuid_v7", "2023-05-01T10:00:00Z")
+	require.Nil(t, err)
+
+	res, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	// The first 48 bits contain the unix timestamp in milliseconds.
+	assert.Equal(t, "0187d6c1-3900", res.(string)[:13])
+}
+
 func TestKsuidFunction(t *testing.T) {
 	e, err := InitFunctionHelper("ksuid")
 	require.Nil(t, err)
@@ -66,7 +66,7 @@ func (m *MethodSet) Init(name string, target Function, args *ParsedParams) (Func
 		return nil, badMethodErr(name)
 	}
 	if m.disableCtors {
-		return disabledMethod(name), nil
+		return disabledMethod(name, target), nil
 	}
 	return wrapMethodCtorWithDynamicArgs(name, target, args, ctor)
 }
@@ -156,10 +156,12 @@ func MethodDocs() []MethodSpec {
 
 //------------------------------------------------------------------------------
 
-func disabledMethod(name string) Function {
+// disabledMethod returns a function that fails when executed, but still reports
+// the targets of the method target as those are known statically.
+func disabledMethod(name string, target Function) Function {
 	return ClosureFunction("method "+name, func(ctx FunctionContext) (any, error) {
 		return nil, errors.New("this method has been disabled")
-	}, func(ctx TargetsContext) (TargetsContext, []TargetPath) { return ctx, nil })
+	}, target.QueryTargets)
 }
 
 func wrapMethodCtorWithDynamicArgs(name string, target Function, args *ParsedParams, fn MethodCtor) (Function, error) {
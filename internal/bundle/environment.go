@@ -45,9 +45,11 @@ func (e *Environment) Clone() *Environment {
 	for _, v := range e.inputs.specs {
 		_ = newEnv.inputs.Add(v.constructor, v.spec)
 	}
+	newEnv.inputs.middlewares = append(newEnv.inputs.middlewares, e.inputs.middlewares...)
 	for _, v := range e.outputs.specs {
 		_ = newEnv.outputs.Add(v.constructor, v.spec)
 	}
+	newEnv.outputs.middlewares = append(newEnv.outputs.middlewares, e.outputs.middlewares...)
 	for _, v := range e.processors.specs {
 		_ = newEnv.processors.Add(v.constructor, v.spec)
 	}
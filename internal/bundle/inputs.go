@@ -7,6 +7,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
 )
 
 // AllInputs is a set containing every single input that has been imported.
@@ -27,6 +28,12 @@ func (e *Environment) InputInit(conf input.Config, mgr NewManagement) (input.Str
 	return e.inputs.Init(conf, mgr)
 }
 
+// InputMiddlewareAdd adds a middleware to this environment that is applied to
+// every input initialised from it.
+func (e *Environment) InputMiddlewareAdd(constructor InputMiddlewareConstructor) {
+	e.inputs.AddMiddleware(constructor)
+}
+
 // InputDocs returns a slice of input specs, which document each method.
 func (e *Environment) InputDocs() []docs.ComponentSpec {
 	return e.inputs.Docs()
@@ -37,6 +44,11 @@ func (e *Environment) InputDocs() []docs.ComponentSpec {
 // InputConstructor constructs an input component.
 type InputConstructor func(input.Config, NewManagement) (input.Streamed, error)
 
+// InputMiddlewareConstructor constructs a middleware that intercepts the
+// batches of an input, or returns a nil middleware in order to leave the input
+// unmodified.
+type InputMiddlewareConstructor func(input.Config, NewManagement) (transaction.Middleware, error)
+
 type inputSpec struct {
 	constructor InputConstructor
 	spec        docs.ComponentSpec
@@ -44,7 +56,8 @@ type inputSpec struct {
 
 // InputSet contains an explicit set of inputs available to a Benthos service.
 type InputSet struct {
-	specs map[string]inputSpec
+	specs       map[string]inputSpec
+	middlewares []InputMiddlewareConstructor
 }
 
 // Add a new input to this set by providing a constructor and documentation.
@@ -64,6 +77,13 @@ func (s *InputSet) Add(constructor InputConstructor, spec docs.ComponentSpec) er
 	return nil
 }
 
+// AddMiddleware adds a middleware to this set that is applied to every input
+// initialised from it, where middlewares intercept batches in the order that
+// they were added.
+func (s *InputSet) AddMiddleware(constructor InputMiddlewareConstructor) {
+	s.middlewares = append(s.middlewares, constructor)
+}
+
 // Init attempts to initialise an input from a config.
 func (s *InputSet) Init(conf input.Config, mgr NewManagement) (input.Streamed, error) {
 	spec, exists := s.specs[conf.Type]
@@ -71,10 +91,27 @@ func (s *InputSet) Init(conf input.Config, mgr NewManagement) (input.Streamed, e
 		return nil, component.ErrInvalidType("input", conf.Type)
 	}
 	c, err := spec.constructor(conf, mgr)
+	if err == nil {
+		c, err = s.wrapMiddlewares(conf, mgr, c)
+	}
 	err = wrapComponentErr(mgr, "input", err)
 	return c, err
 }
 
+func (s *InputSet) wrapMiddlewares(conf input.Config, mgr NewManagement, c input.Streamed) (input.Streamed, error) {
+	for _, ctor := range s.middlewares {
+		mw, err := ctor(conf, mgr)
+		if err != nil {
+			c.TriggerCloseNow()
+			return nil, fmt.Errorf("failed to create input middleware: %w", err)
+		}
+		if mw != nil {
+			c = input.WrapWithMiddleware(c, mw)
+		}
+	}
+	return c, nil
+}
+
 // Docs returns a slice of input specs, which document each method.
 func (s *InputSet) Docs() []docs.ComponentSpec {
 	var docs []docs.ComponentSpec
@@ -8,6 +8,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
 )
 
 // AllOutputs is a set containing every single output that has been imported.
@@ -32,6 +33,12 @@ func (e *Environment) OutputInit(
 	return e.outputs.Init(conf, mgr, pipelines...)
 }
 
+// OutputMiddlewareAdd adds a middleware to this environment that is applied to
+// every output initialised from it.
+func (e *Environment) OutputMiddlewareAdd(constructor OutputMiddlewareConstructor) {
+	e.outputs.AddMiddleware(constructor)
+}
+
 // OutputDocs returns a slice of output specs, which document each method.
 func (e *Environment) OutputDocs() []docs.ComponentSpec {
 	return e.outputs.Docs()
@@ -42,6 +49,11 @@ func (e *Environment) OutputDocs() []docs.ComponentSpec {
 // OutputConstructor constructs an output component.
 type OutputConstructor func(output.Config, NewManagement, ...processor.PipelineConstructorFunc) (output.Streamed, error)
 
+// OutputMiddlewareConstructor constructs a middleware that intercepts the
+// batches of an output, or returns a nil middleware in order to leave the output
+// unmodified.
+type OutputMiddlewareConstructor func(output.Config, NewManagement) (transaction.Middleware, error)
+
 type outputSpec struct {
 	constructor OutputConstructor
 	spec        docs.ComponentSpec
@@ -49,7 +61,8 @@ type outputSpec struct {
 
 // OutputSet contains an explicit set of outputs available to a Benthos service.
 type OutputSet struct {
-	specs map[string]outputSpec
+	specs       map[string]outputSpec
+	middlewares []OutputMiddlewareConstructor
 }
 
 // Add a new output to this set by providing a spec (name, documentation, and
@@ -70,6 +83,13 @@ func (s *OutputSet) Add(constructor OutputConstructor, spec docs.ComponentSpec)
 	return nil
 }
 
+// AddMiddleware adds a middleware to this set that is applied to every output
+// initialised from it, where middlewares intercept batches in the order that
+// they were added.
+func (s *OutputSet) AddMiddleware(constructor OutputMiddlewareConstructor) {
+	s.middlewares = append(s.middlewares, constructor)
+}
+
 // Init attempts to initialise an output from a config.
 func (s *OutputSet) Init(
 	conf output.Config,
@@ -81,10 +101,29 @@ func (s *OutputSet) Init(
 		return nil, component.ErrInvalidType("output", conf.Type)
 	}
 	c, err := spec.constructor(conf, mgr, pipelines...)
+	if err == nil {
+		c, err = s.wrapMiddlewares(conf, mgr, c)
+	}
 	err = wrapComponentErr(mgr, "output", err)
 	return c, err
 }
 
+// Middlewares are applied in reverse so that the first middleware added is the
+// outermost, and therefore intercepts batches first.
+func (s *OutputSet) wrapMiddlewares(conf output.Config, mgr NewManagement, c output.Streamed) (output.Streamed, error) {
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		mw, err := s.middlewares[i](conf, mgr)
+		if err == nil && mw != nil {
+			c, err = output.WrapWithMiddleware(c, mw)
+		}
+		if err != nil {
+			c.TriggerCloseNow()
+			return nil, fmt.Errorf("failed to create output middleware: %w", err)
+		}
+	}
+	return c, nil
+}
+
 // Docs returns a slice of output specs, which document each method.
 func (s *OutputSet) Docs() []docs.ComponentSpec {
 	var docs []docs.ComponentSpec
@@ -73,7 +73,11 @@ func (s *TracerSet) Init(conf tracer.Config, nm NewManagement) (trace.TracerProv
 	if !exists {
 		return nil, component.ErrInvalidType("tracer", conf.Type)
 	}
-	return spec.constructor(conf, nm)
+	prov, err := spec.constructor(conf, nm)
+	if err != nil {
+		return nil, err
+	}
+	return tracer.WithSampling(prov, conf.Sampling, nm.BloblEnvironment(), nm.Logger())
 }
 
 // Docs returns a slice of tracer specs, which document each method.
@@ -0,0 +1,444 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	gmetrics "github.com/rcrowley/go-metrics"
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/common"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+const benchDefaultMapping = `root.id = uuid_v4()
+root.name = fake("name")
+root.email = fake("email")
+root.created_at = now()`
+
+func benchCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "bench",
+		Usage: "Benchmark the pipeline of a config with generated data",
+		Description: `
+Runs the pipeline of a config with an input that generates messages at a target
+rate and an output that drops them, and then reports the throughput achieved
+along with the latencies of each component. The component with the highest
+load is reported as the bottleneck of the pipeline.
+
+  benthos -c ./config.yaml bench
+  benthos -c ./config.yaml bench --rate 50000 --duration 1m
+  benthos -c ./config.yaml bench --mapping 'root.doc = file("./doc.json").parse_json()'
+  benthos -c ./config.yaml bench --keep-output
+
+Load is the proportion of time that a component was busy, accounting for the
+number of pipeline threads and the maximum number of in flight messages of
+outputs. The generated messages are batched when the target rate exceeds 1000
+messages per second.`[1:],
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "rate",
+				Value: 1000,
+				Usage: "The target number of messages to generate per second, set to 0 in order to generate messages as fast as possible",
+			},
+			&cli.DurationFlag{
+				Name:  "duration",
+				Value: time.Second * 30,
+				Usage: "The period of time to benchmark for",
+			},
+			&cli.DurationFlag{
+				Name:  "warmup",
+				Value: time.Second * 5,
+				Usage: "A period of time to run the pipeline before benchmarking, which is excluded from results",
+			},
+			&cli.StringFlag{
+				Name:  "mapping",
+				Value: benchDefaultMapping,
+				Usage: "A Bloblang mapping used to generate each message",
+			},
+			&cli.BoolFlag{
+				Name:  "keep-input",
+				Usage: "Use the input of the config rather than generating messages",
+			},
+			&cli.BoolFlag{
+				Name:  "keep-output",
+				Usage: "Use the output of the config rather than dropping messages",
+			},
+			&cli.DurationFlag{
+				Name:  "report-interval",
+				Value: time.Second * 5,
+				Usage: "The period of time between each progress report, set to 0 in order to disable them",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			_, _, confReader := common.ReadConfig(c, false)
+			conf, _, err := confReader.Read()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Logs are kept to warnings and above by default as they would
+			// otherwise drown out the progress reports.
+			conf.Logger.LogLevel = "WARN"
+			logger, err := common.CreateLogger(c, conf, true)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+				os.Exit(1)
+			}
+
+			bConf := benchConfig{
+				rate:           c.Int("rate"),
+				duration:       c.Duration("duration"),
+				warmup:         c.Duration("warmup"),
+				mapping:        c.String("mapping"),
+				keepInput:      c.Bool("keep-input"),
+				keepOutput:     c.Bool("keep-output"),
+				reportInterval: c.Duration("report-interval"),
+			}
+			report, err := runBench(c.Context, conf, bConf, logger, c.App.Writer)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Benchmark error: %v\n", err)
+				os.Exit(1)
+			}
+			report.write(c.App.Writer)
+			return nil
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type benchConfig struct {
+	rate           int
+	duration       time.Duration
+	warmup         time.Duration
+	mapping        string
+	keepInput      bool
+	keepOutput     bool
+	reportInterval time.Duration
+}
+
+// apply replaces the input and output of a config according to the benchmark
+// config.
+func (b benchConfig) apply(conf *config.Type) {
+	if !b.keepInput {
+		inConf := input.NewConfig()
+		inConf.Type = "generate"
+		inConf.Generate.Mapping = b.mapping
+		inConf.Generate.Count = 0
+		inConf.Generate.Interval = ""
+		inConf.Generate.BatchSize = 1
+		if b.rate > 0 {
+			// Tickers are unreliable at sub-millisecond intervals, and so
+			// higher rates are achieved with batches.
+			batchSize := (b.rate + 999) / 1000
+			inConf.Generate.BatchSize = batchSize
+			inConf.Generate.Interval = (time.Second * time.Duration(batchSize) / time.Duration(b.rate)).String()
+		}
+		conf.Input = inConf
+	}
+	if !b.keepOutput {
+		outConf := output.NewConfig()
+		outConf.Type = "drop"
+		conf.Output = outConf
+	}
+}
+
+type benchLatency struct {
+	count              int64
+	mean               float64
+	p50, p90, p99, max time.Duration
+}
+
+func (l benchLatency) String() string {
+	if l.count == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("p50 %v, p90 %v, p99 %v, max %v", l.p50, l.p90, l.p99, l.max)
+}
+
+// benchStage summarises the metrics of a single component.
+type benchStage struct {
+	path     string
+	label    string
+	kind     string
+	received int64
+	sent     int64
+	errors   int64
+	latency  benchLatency
+
+	// The number of messages or batches that can be processed in parallel.
+	parallelism int
+	load        float64
+}
+
+type benchReport struct {
+	conf    benchConfig
+	elapsed time.Duration
+	sent    int64
+
+	endToEnd   benchLatency
+	stages     []*benchStage
+	bottleneck *benchStage
+}
+
+func (r *benchReport) throughput() float64 {
+	if r.elapsed <= 0 {
+		return 0
+	}
+	return float64(r.sent) / r.elapsed.Seconds()
+}
+
+func (r *benchReport) write(w io.Writer) {
+	fmt.Fprintf(w, "\nBenchmarked for %v", r.elapsed.Round(time.Millisecond))
+	if !r.conf.keepInput {
+		if r.conf.rate > 0 {
+			fmt.Fprintf(w, " at a target rate of %v msg/s", r.conf.rate)
+		} else {
+			fmt.Fprint(w, " at an unlimited rate")
+		}
+	}
+	fmt.Fprintf(w, "\n\nThroughput: %.1f msg/s (%v messages)\n", r.throughput(), r.sent)
+	fmt.Fprintf(w, "End-to-end latency: %v\n\n", r.endToEnd)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tLABEL\tRECEIVED\tSENT\tERRORS\tP50\tP90\tP99\tLOAD")
+	for _, s := range r.stages {
+		label := s.label
+		if label == "" {
+			label = "-"
+		}
+		load := "-"
+		if s.kind != "input" {
+			load = fmt.Sprintf("%.0f%%", s.load*100)
+		}
+		p50, p90, p99 := "-", "-", "-"
+		if s.latency.count > 0 {
+			p50, p90, p99 = s.latency.p50.String(), s.latency.p90.String(), s.latency.p99.String()
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", s.path, label, s.received, s.sent, s.errors, p50, p90, p99, load)
+	}
+	_ = tw.Flush()
+
+	if r.bottleneck != nil {
+		fmt.Fprintf(w, "\nBottleneck: %v", r.bottleneck.path)
+		if r.bottleneck.label != "" {
+			fmt.Fprintf(w, " (%v)", r.bottleneck.label)
+		}
+		fmt.Fprintf(w, " with a load of %.0f%%\n", r.bottleneck.load*100)
+	}
+	if !r.conf.keepInput && r.conf.rate > 0 && r.throughput() < float64(r.conf.rate)*0.95 {
+		fmt.Fprintf(w, "\nThe target rate of %v msg/s was not reached.\n", r.conf.rate)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// runBench runs the stream of a config according to a benchmark config and
+// returns a report of the metrics gathered after the warm up period.
+func runBench(ctx context.Context, conf config.Type, bConf benchConfig, logger log.Modular, progress io.Writer) (*benchReport, error) {
+	bConf.apply(&conf)
+
+	local := metrics.NewLocal()
+	mgr, err := manager.New(conf.ResourceConfig,
+		manager.OptSetLogger(logger),
+		manager.OptSetMetrics(metrics.NewNamespaced(local)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		mgr.TriggerStopConsuming()
+		mgr.TriggerCloseNow()
+		waitCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+		defer done()
+		_ = mgr.WaitForClose(waitCtx)
+	}()
+
+	closedChan := make(chan struct{})
+	strm, err := stream.New(conf.Config, mgr, stream.OptOnClose(func() {
+		close(closedChan)
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	wait := func(d time.Duration) bool {
+		select {
+		case <-time.After(d):
+			return true
+		case <-closedChan:
+		case <-ctx.Done():
+		}
+		return false
+	}
+
+	if bConf.warmup > 0 {
+		fmt.Fprintf(progress, "Warming up for %v\n", bConf.warmup)
+		wait(bConf.warmup)
+	}
+
+	// Gauges are stored as counters and are therefore captured before the
+	// counters are reset.
+	gauges := local.FlushCounters()
+	_ = local.FlushTimings()
+
+	start := time.Now()
+	if bConf.reportInterval > 0 {
+		fmt.Fprintf(progress, "Benchmarking for %v\n", bConf.duration)
+	}
+	for remaining := bConf.duration; remaining > 0; {
+		interval := remaining
+		if bConf.reportInterval > 0 && bConf.reportInterval < interval {
+			interval = bConf.reportInterval
+		}
+		if !wait(interval) {
+			break
+		}
+		remaining -= interval
+		if bConf.reportInterval > 0 && remaining > 0 {
+			elapsed := time.Since(start)
+			sent := sentCount(local.GetCounters())
+			fmt.Fprintf(progress, "%v: %.1f msg/s\n", elapsed.Round(time.Second), float64(sent)/elapsed.Seconds())
+		}
+	}
+	elapsed := time.Since(start)
+	counters, timings := local.GetCounters(), local.GetTimings()
+
+	stopCtx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+	if err := strm.Stop(stopCtx); err != nil {
+		logger.Warnf("Failed to stop stream cleanly: %v", err)
+	}
+
+	threads := conf.Pipeline.Threads
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	return newBenchReport(bConf, elapsed, threads, gauges, counters, timings), nil
+}
+
+// sentCount returns the number of messages sent by the root output.
+func sentCount(counters map[string]int64) (sent int64) {
+	for k, v := range counters {
+		if name, tagNames, tagValues := metrics.ReverseLabelledPath(k); name == "output_sent" && benchTag("path", tagNames, tagValues) == "root.output" {
+			sent += v
+		}
+	}
+	return
+}
+
+func benchTag(name string, tagNames, tagValues []string) string {
+	for i, k := range tagNames {
+		if k == name && i < len(tagValues) {
+			return tagValues[i]
+		}
+	}
+	return ""
+}
+
+func newBenchReport(
+	bConf benchConfig,
+	elapsed time.Duration,
+	threads int,
+	gauges, counters map[string]int64,
+	timings map[string]gmetrics.Timer,
+) *benchReport {
+	r := &benchReport{
+		conf:    bConf,
+		elapsed: elapsed,
+		sent:    sentCount(counters),
+	}
+
+	stages := map[string]*benchStage{}
+	getStage := func(path, label, kind string) *benchStage {
+		s, exists := stages[path]
+		if !exists {
+			s = &benchStage{path: path, label: label, kind: kind, parallelism: 1}
+			if kind == "processor" {
+				s.parallelism = threads
+			}
+			stages[path] = s
+		}
+		return s
+	}
+
+	for k, v := range counters {
+		name, tagNames, tagValues := metrics.ReverseLabelledPath(k)
+		kind, field, ok := strings.Cut(name, "_")
+		if !ok || (kind != "input" && kind != "processor" && kind != "output") {
+			continue
+		}
+		s := getStage(benchTag("path", tagNames, tagValues), benchTag("label", tagNames, tagValues), kind)
+		switch field {
+		case "received":
+			s.received += v
+		case "sent":
+			s.sent += v
+		case "error":
+			s.errors += v
+		}
+	}
+	for k, v := range gauges {
+		if name, tagNames, tagValues := metrics.ReverseLabelledPath(k); name == "output_max_in_flight" && v > 0 {
+			getStage(benchTag("path", tagNames, tagValues), benchTag("label", tagNames, tagValues), "output").parallelism = int(v)
+		}
+	}
+	for k, t := range timings {
+		name, tagNames, tagValues := metrics.ReverseLabelledPath(k)
+		kind, field, ok := strings.Cut(name, "_")
+		if !ok || field != "latency_ns" || (kind != "input" && kind != "processor" && kind != "output") {
+			continue
+		}
+		ps := t.Percentiles([]float64{0.5, 0.9, 0.99})
+		l := benchLatency{
+			count: t.Count(),
+			mean:  t.Mean(),
+			p50:   time.Duration(ps[0]),
+			p90:   time.Duration(ps[1]),
+			p99:   time.Duration(ps[2]),
+			max:   time.Duration(t.Max()),
+		}
+		s := getStage(benchTag("path", tagNames, tagValues), benchTag("label", tagNames, tagValues), kind)
+		s.latency = l
+		if kind == "input" && s.path == "root.input" {
+			r.endToEnd = l
+		}
+	}
+
+	for _, s := range stages {
+		if s.kind == "input" || elapsed <= 0 {
+			continue
+		}
+		s.load = s.latency.mean * float64(s.latency.count) / float64(elapsed) / float64(s.parallelism)
+		if r.bottleneck == nil || s.load > r.bottleneck.load {
+			r.bottleneck = s
+		}
+	}
+
+	kindOrder := map[string]int{"input": 0, "processor": 1, "output": 2}
+	for _, s := range stages {
+		r.stages = append(r.stages, s)
+	}
+	sort.Slice(r.stages, func(i, j int) bool {
+		if ki, kj := kindOrder[r.stages[i].kind], kindOrder[r.stages[j].kind]; ki != kj {
+			return ki < kj
+		}
+		return r.stages[i].path < r.stages[j].path
+	})
+	return r
+}
@@ -0,0 +1,55 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	icli "github.com/benthosdev/benthos/v4/internal/cli"
+)
+
+func TestBenchCLIBottleneck(t *testing.T) {
+	tmpDir := t.TempDir()
+	confPath := filepath.Join(tmpDir, "foo.yaml")
+
+	require.NoError(t, os.WriteFile(confPath, []byte(`
+input:
+  stdin: {}
+pipeline:
+  threads: 1
+  processors:
+    - mapping: 'root = content().uppercase()'
+    - label: slow_thing
+      sleep:
+        duration: 5ms
+output:
+  stdout: {}
+`), 0o644))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	var buf bytes.Buffer
+	app := icli.App()
+	app.Writer = &buf
+	require.NoError(t, app.RunContext(ctx, []string{
+		"benthos", "-c", confPath, "bench",
+		"--rate", "1000",
+		"--duration", "500ms",
+		"--warmup", "100ms",
+		"--mapping", `root = "hello world"`,
+	}))
+
+	out := buf.String()
+	assert.Contains(t, out, "Throughput:")
+	assert.Contains(t, out, "root.pipeline.processors.0")
+	assert.Contains(t, out, "root.output")
+	assert.Contains(t, out, "Bottleneck: root.pipeline.processors.1 (slow_thing)")
+	assert.Contains(t, out, "The target rate of 1000 msg/s was not reached.")
+}
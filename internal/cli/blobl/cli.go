@@ -64,6 +64,34 @@ Find out more about Bloblang at: https://benthos.dev/docs/guides/bloblang/about`
 		},
 		Action: run,
 		Subcommands: []*cli.Command{
+			{
+				Name:  "repl",
+				Usage: "EXPERIMENTAL: Run an interactive Bloblang shell",
+				Description: `
+Run an interactive shell for executing Bloblang against a sample message,
+where variables, metadata and the output document are retained between
+statements. Mapping files can be opened and executed one statement at a time
+in order to inspect intermediate values.
+
+Type :help within the shell for a list of commands.`[1:],
+				Action: runRepl,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "input-file",
+						Aliases: []string{"i"},
+						Usage:   "an optional path to a file to load as the initial input message.",
+					},
+					&cli.StringFlag{
+						Name:  "meta-file",
+						Usage: "an optional path to a JSON file containing an object to load as the initial input message metadata.",
+					},
+					&cli.StringFlag{
+						Name:    "mapping-file",
+						Aliases: []string{"m"},
+						Usage:   "an optional path to a mapping file to open for stepping through.",
+					},
+				},
+			},
 			{
 				Name:        "server",
 				Usage:       "EXPERIMENTAL: Run a web server that hosts a Bloblang app",
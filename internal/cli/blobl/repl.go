@@ -0,0 +1,453 @@
+package blobl
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Jeffail/gabs/v2"
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+const replHelp = `Lines are executed as Bloblang against the current session state. A lone
+query (e.g. this.foo.uppercase()) prints its result, whereas assignments
+(e.g. root.foo = this.bar, let x = 5, meta baz = "buz") modify the output
+document, variables and metadata, which persist between lines. Multiple line
+statements are continued until they are complete, an empty line aborts them.
+
+Commands:
+  :input <document>  Set the contents of the input message
+  :load <file>       Load the contents of the input message from a file
+  :meta <file>       Load the metadata of the input message from a JSON file
+  :open <file>       Open a mapping file in order to step through it
+  :step [n]          Execute the next n (default 1) statements of the mapping
+  :run               Execute all remaining statements of the mapping
+  :vars              Print the current value of all variables
+  :show              Print the input message and the current output
+  :reset             Reset the output, variables and mapping position
+  :help              Print this help message
+  :quit              Exit the REPL`
+
+type replSession struct {
+	env    *bloblang.Environment
+	out    io.Writer
+	prompt bool
+
+	input  *message.Part
+	output *message.Part
+	result any
+	vars   map[string]any
+	maps   map[string]query.Function
+
+	stepExec *mapping.Executor
+	stepNext int
+}
+
+func newReplSession(out io.Writer) *replSession {
+	s := &replSession{
+		env:   bloblang.NewEnvironment(),
+		out:   out,
+		input: message.NewPart([]byte(`{"message":"hello world"}`)),
+		vars:  map[string]any{},
+		maps:  map[string]query.Function{},
+	}
+	s.reset()
+	return s
+}
+
+func (s *replSession) reset() {
+	s.output = s.input.ShallowCopy()
+	s.result = query.Nothing(nil)
+	for k := range s.vars {
+		delete(s.vars, k)
+	}
+	s.stepNext = 0
+}
+
+func (s *replSession) printf(format string, args ...any) {
+	fmt.Fprintf(s.out, format, args...)
+}
+
+func (s *replSession) printErr(err error) {
+	fmt.Fprintln(s.out, red(err.Error()))
+}
+
+func (s *replSession) execContexts() (query.FunctionContext, mapping.AssignmentContext, func() error) {
+	var valuePtr *any
+	var parseErr error
+
+	lazyValue := func() *any {
+		if valuePtr == nil && parseErr == nil {
+			if jObj, err := s.input.AsStructured(); err == nil {
+				valuePtr = &jObj
+			} else if errors.Is(err, message.ErrMessagePartNotExist) {
+				parseErr = errors.New("message is empty")
+			} else {
+				parseErr = fmt.Errorf("parse as json: %w", err)
+			}
+		}
+		return valuePtr
+	}
+
+	fnCtx := query.FunctionContext{
+		Maps:     s.maps,
+		Vars:     s.vars,
+		MsgBatch: message.Batch{s.input},
+		NewMeta:  s.output,
+		NewValue: &s.result,
+	}.WithValueFunc(lazyValue)
+
+	assignCtx := mapping.AssignmentContext{
+		Vars:  s.vars,
+		Meta:  s.output,
+		Value: &s.result,
+	}
+	return fnCtx, assignCtx, func() error { return parseErr }
+}
+
+func wrapReplExecErr(err, parseErr error) error {
+	var ctxErr query.ErrNoContext
+	if parseErr != nil && errors.As(err, &ctxErr) {
+		if ctxErr.FieldName != "" {
+			return fmt.Errorf("unable to reference message as structured (with 'this.%v'): %w", ctxErr.FieldName, parseErr)
+		}
+		return fmt.Errorf("unable to reference message as structured (with 'this'): %w", parseErr)
+	}
+	return err
+}
+
+func replFormat(v any) string {
+	switch t := v.(type) {
+	case query.Nothing:
+		return "nothing"
+	case query.Delete:
+		return "deleted"
+	case []byte:
+		return strconv.Quote(string(t))
+	}
+	return gabs.Wrap(v).StringIndent("", "  ")
+}
+
+// isIncomplete returns true if a parse error occurred at the very end of the
+// input, implying that more lines are needed in order to complete it.
+func isIncomplete(src string, err error) bool {
+	var perr *parser.Error
+	if !errors.As(err, &perr) {
+		return false
+	}
+	return strings.TrimSpace(src) != "" && strings.TrimSpace(string(perr.Input)) == ""
+}
+
+func formatParseErr(src string, err error) error {
+	var perr *parser.Error
+	if errors.As(err, &perr) {
+		return fmt.Errorf("failed to parse: %v", perr.ErrorAtPositionStructured("", []rune(src)))
+	}
+	return err
+}
+
+// eval executes a Bloblang query or mapping against the session state. Parse
+// errors are returned unformatted so that incomplete statements can be
+// detected.
+func (s *replSession) eval(src string) error {
+	if fn, err := s.env.NewQuery(src); err == nil {
+		fnCtx, _, parseErr := s.execContexts()
+		v, err := fn.Exec(fnCtx)
+		if err != nil {
+			return wrapReplExecErr(err, parseErr())
+		}
+		s.printf("%v\n", replFormat(v))
+		return nil
+	}
+
+	exec, err := s.env.NewMapping(src)
+	if err != nil {
+		return err
+	}
+	for k, v := range exec.Maps() {
+		s.maps[k] = v
+	}
+
+	fnCtx, assignCtx, parseErr := s.execContexts()
+	if err := exec.ExecOnto(fnCtx, assignCtx); err != nil {
+		return wrapReplExecErr(err, parseErr())
+	}
+	if len(exec.Statements()) > 0 {
+		s.printf("%v\n", replFormat(s.result))
+	}
+	return nil
+}
+
+func (s *replSession) loadInput(content []byte) {
+	s.input.SetBytes(content)
+	s.reset()
+}
+
+func (s *replSession) loadMeta(path string) error {
+	metaBytes, err := ifs.ReadFile(ifs.OS(), path)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return fmt.Errorf("failed to parse metadata file as a JSON object: %w", err)
+	}
+	_ = s.input.MetaIterMut(func(k string, _ any) error {
+		s.input.MetaDelete(k)
+		return nil
+	})
+	for k, v := range meta {
+		s.input.MetaSetMut(k, v)
+	}
+	s.reset()
+	return nil
+}
+
+func (s *replSession) openMapping(path string) error {
+	mappingBytes, err := ifs.ReadFile(ifs.OS(), path)
+	if err != nil {
+		return fmt.Errorf("failed to read mapping file: %w", err)
+	}
+	src := string(mappingBytes)
+	exec, err := s.env.WithImporterRelativeToFile(path).NewMapping(src)
+	if err != nil {
+		return formatParseErr(src, err)
+	}
+	for k, v := range exec.Maps() {
+		s.maps[k] = v
+	}
+	s.stepExec = exec
+	s.reset()
+	s.printf("opened mapping with %v statements\n", len(exec.Statements()))
+	return nil
+}
+
+// statementSource returns the line number and trimmed source of a statement
+// within the currently opened mapping.
+func (s *replSession) statementSource(i int) (line int, src string) {
+	stmts := s.stepExec.Statements()
+	input := stmts[i].Input()
+	line, _ = mapping.LineAndColOf(s.stepExec.Input(), input)
+
+	end := len(input)
+	if i+1 < len(stmts) {
+		end -= len(stmts[i+1].Input())
+	}
+	var lines []string
+	for _, l := range strings.Split(string(input[:end]), "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "#") {
+			lines = append(lines, l)
+		}
+	}
+	return line, strings.Join(lines, "\n")
+}
+
+func (s *replSession) step(n int) error {
+	if s.stepExec == nil {
+		return errors.New("no mapping has been opened, use :open <file>")
+	}
+	stmts := s.stepExec.Statements()
+	if s.stepNext >= len(stmts) {
+		return errors.New("reached the end of the mapping, use :reset to start again")
+	}
+	for ; n > 0 && s.stepNext < len(stmts); n-- {
+		i := s.stepNext
+		line, src := s.statementSource(i)
+		s.printf("[%v/%v] line %v: %v\n", i+1, len(stmts), line, src)
+
+		exec := mapping.NewExecutor(s.stepExec.Annotation(), s.stepExec.Input(), s.stepExec.Maps(), stmts[i])
+		fnCtx, assignCtx, parseErr := s.execContexts()
+		if err := exec.ExecOnto(fnCtx, assignCtx); err != nil {
+			return wrapReplExecErr(err, parseErr())
+		}
+		s.stepNext++
+	}
+	s.printf("%v\n", replFormat(s.result))
+	return nil
+}
+
+func (s *replSession) printVars() {
+	if len(s.vars) == 0 {
+		s.printf("no variables set\n")
+		return
+	}
+	keys := make([]string, 0, len(s.vars))
+	for k := range s.vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s.printf("$%v = %v\n", k, replFormat(s.vars[k]))
+	}
+}
+
+func replMeta(p *message.Part) string {
+	meta := map[string]any{}
+	_ = p.MetaIterMut(func(k string, v any) error {
+		meta[k] = v
+		return nil
+	})
+	return replFormat(meta)
+}
+
+func (s *replSession) show() {
+	s.printf("input:\n%v\n", string(s.input.AsBytes()))
+	s.printf("input metadata:\n%v\n", replMeta(s.input))
+	s.printf("output:\n%v\n", replFormat(s.result))
+	s.printf("output metadata:\n%v\n", replMeta(s.output))
+	if s.stepExec != nil {
+		s.printf("mapping position: %v/%v\n", s.stepNext, len(s.stepExec.Statements()))
+	}
+}
+
+// command executes a colon prefixed REPL command and returns true if the
+// session should be terminated.
+func (s *replSession) command(line string) (bool, error) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case ":quit", ":exit", ":q":
+		return true, nil
+	case ":help", ":h":
+		s.printf("%v\n", replHelp)
+	case ":input":
+		s.loadInput([]byte(arg))
+	case ":load":
+		content, err := ifs.ReadFile(ifs.OS(), arg)
+		if err != nil {
+			return false, fmt.Errorf("failed to read input file: %w", err)
+		}
+		s.loadInput(content)
+	case ":meta":
+		return false, s.loadMeta(arg)
+	case ":open":
+		return false, s.openMapping(arg)
+	case ":step", ":s":
+		n := 1
+		if arg != "" {
+			var err error
+			if n, err = strconv.Atoi(arg); err != nil || n < 1 {
+				return false, fmt.Errorf("invalid step count: %v", arg)
+			}
+		}
+		return false, s.step(n)
+	case ":run":
+		if s.stepExec == nil {
+			return false, s.step(1)
+		}
+		return false, s.step(len(s.stepExec.Statements()))
+	case ":vars":
+		s.printVars()
+	case ":show":
+		s.show()
+	case ":reset":
+		s.reset()
+	default:
+		return false, fmt.Errorf("unrecognised command %v, use :help for a list of commands", name)
+	}
+	return false, nil
+}
+
+// run reads lines from the reader until it is exhausted or the session is
+// terminated.
+func (s *replSession) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 1024*1024)
+
+	var pending []string
+	for {
+		if s.prompt {
+			if len(pending) > 0 {
+				s.printf("... ")
+			} else {
+				s.printf("> ")
+			}
+		}
+		if !scanner.Scan() {
+			if len(pending) > 0 {
+				src := strings.Join(pending, "\n")
+				if err := s.eval(src); err != nil {
+					s.printErr(formatParseErr(src, err))
+				}
+			}
+			return scanner.Err()
+		}
+		line := scanner.Text()
+
+		if len(pending) == 0 {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if strings.HasPrefix(strings.TrimSpace(line), ":") {
+				exit, err := s.command(line)
+				if err != nil {
+					s.printErr(err)
+				}
+				if exit {
+					return nil
+				}
+				continue
+			}
+		}
+
+		aborted := len(pending) > 0 && strings.TrimSpace(line) == ""
+		pending = append(pending, line)
+		src := strings.Join(pending, "\n")
+
+		err := s.eval(src)
+		if err != nil && !aborted && isIncomplete(src, err) {
+			continue
+		}
+		pending = nil
+		if err != nil {
+			s.printErr(formatParseErr(src, err))
+		}
+	}
+}
+
+func runRepl(c *cli.Context) error {
+	s := newReplSession(os.Stdout)
+	s.prompt = true
+
+	if inputFile := c.String("input-file"); inputFile != "" {
+		content, err := ifs.ReadFile(ifs.OS(), inputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, red("failed to read input file: %v\n"), err)
+			os.Exit(1)
+		}
+		s.loadInput(content)
+	}
+	if metaFile := c.String("meta-file"); metaFile != "" {
+		if err := s.loadMeta(metaFile); err != nil {
+			fmt.Fprintln(os.Stderr, red(err.Error()))
+			os.Exit(1)
+		}
+	}
+	if mappingFile := c.String("mapping-file"); mappingFile != "" {
+		if err := s.openMapping(mappingFile); err != nil {
+			fmt.Fprintln(os.Stderr, red(err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	s.printf("Bloblang REPL, type :help for a list of commands.\n")
+	if err := s.run(os.Stdin); err != nil {
+		fmt.Fprintln(os.Stderr, red(err.Error()))
+		os.Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package blobl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runReplLines(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	color.NoColor = true
+
+	var out bytes.Buffer
+	s := newReplSession(&out)
+	require.NoError(t, s.run(strings.NewReader(strings.Join(lines, "\n"))))
+	return out.String()
+}
+
+func TestReplStatePersists(t *testing.T) {
+	out := runReplLines(t,
+		`:input {"name":"foo","tags":["a","b"]}`,
+		`let count = this.tags.length()`,
+		`root.name = this.name.uppercase()`,
+		`root.count = $count`,
+		`meta kind = "test"`,
+		`@kind`,
+		`this.tags.join(",")`,
+		`:vars`,
+	)
+
+	assert.Equal(t, `nothing
+{
+  "name": "FOO"
+}
+{
+  "count": 2,
+  "name": "FOO"
+}
+{
+  "count": 2,
+  "name": "FOO"
+}
+"test"
+"a,b"
+$count = 2
+`, out)
+}
+
+func TestReplMultipleLines(t *testing.T) {
+	out := runReplLines(t,
+		`:input {"a":1}`,
+		`map double {`,
+		`  root = this * 2`,
+		`}`,
+		`root.b = this.a.apply("double")`,
+		`root.c = [`,
+		``,
+		`root.d = "nope`,
+	)
+
+	assert.Equal(t, `{
+  "b": 2
+}
+failed to parse: line 2 char 1: expected query
+  |
+2 | 
+  | ^---
+failed to parse: line 1 char 15: required: expected end quote
+  |
+1 | root.d = "nope
+  |               ^---
+`, out)
+}
+
+func TestReplErrors(t *testing.T) {
+	out := runReplLines(t,
+		`:input not json`,
+		`this.foo`,
+		`:nope`,
+		`:step`,
+		`root = content().uppercase()`,
+	)
+
+	assert.Equal(t, `unable to reference message as structured (with 'this.foo'): parse as json: invalid character 'o' in literal null (expecting 'u')
+unrecognised command :nope, use :help for a list of commands
+no mapping has been opened, use :open <file>
+"NOT JSON"
+`, out)
+}
+
+func TestReplStepping(t *testing.T) {
+	dir := t.TempDir()
+
+	mappingPath := filepath.Join(dir, "mapping.blobl")
+	require.NoError(t, os.WriteFile(mappingPath, []byte(`
+let name = this.name.capitalize()
+
+# Build the output document
+root.greeting = "hello " + $name
+root.source = @source
+`), 0o644))
+
+	inputPath := filepath.Join(dir, "input.json")
+	require.NoError(t, os.WriteFile(inputPath, []byte(`{"name":"bob"}`), 0o644))
+
+	metaPath := filepath.Join(dir, "meta.json")
+	require.NoError(t, os.WriteFile(metaPath, []byte(`{"source":"tests"}`), 0o644))
+
+	out := runReplLines(t,
+		`:load `+inputPath,
+		`:meta `+metaPath,
+		`:open `+mappingPath,
+		`:step`,
+		`:vars`,
+		`:step 5`,
+		`:step`,
+		`:reset`,
+		`:vars`,
+		`:run`,
+	)
+
+	assert.Equal(t, `opened mapping with 3 statements
+[1/3] line 2: let name = this.name.capitalize()
+nothing
+$name = "Bob"
+[2/3] line 5: root.greeting = "hello " + $name
+[3/3] line 6: root.source = @source
+{
+  "greeting": "hello Bob",
+  "source": "tests"
+}
+reached the end of the mapping, use :reset to start again
+no variables set
+[1/3] line 2: let name = this.name.capitalize()
+[2/3] line 5: root.greeting = "hello " + $name
+[3/3] line 6: root.source = @source
+{
+  "greeting": "hello Bob",
+  "source": "tests"
+}
+`, out)
+}
@@ -0,0 +1,176 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/httpserver"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	strmmgr "github.com/benthosdev/benthos/v4/internal/stream/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream/manager/managementpb"
+)
+
+type grpcStoppable struct {
+	server *grpc.Server
+	health *health.Server
+}
+
+// Stop attempts to gracefully stop the server, and forcefully closes any
+// remaining calls, such as stats watchers, when the context is cancelled.
+func (g *grpcStoppable) Stop(ctx context.Context) error {
+	g.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		g.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		g.server.Stop()
+	}
+	return nil
+}
+
+// grpcServerCredentials returns the transport credentials of the gRPC server
+// from the TLS fields of the HTTP server config, along with an optional CA used
+// to require and verify client certificates. Returns nil when TLS is disabled.
+func grpcServerCredentials(conf api.Config, clientCAFile string) (credentials.TransportCredentials, error) {
+	if conf.CertFile == "" && conf.KeyFile == "" {
+		if clientCAFile != "" {
+			return nil, errors.New("a gRPC client CA file requires the http fields cert_file and key_file to be specified")
+		}
+		return nil, nil
+	}
+	if conf.CertFile == "" || conf.KeyFile == "" {
+		return nil, errors.New("both cert_file and key_file must be specified, or neither")
+	}
+
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cert_file and key_file: %w", err)
+	}
+	tlsConf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		caBytes, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gRPC client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBytes) {
+			return nil, errors.New("gRPC client CA file did not contain any valid certificates")
+		}
+		tlsConf.ClientCAs = pool
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(tlsConf), nil
+}
+
+// grpcBasicAuth checks the credentials of the authorization metadata of a call
+// against the basic authentication config of the HTTP server. The health
+// service is exempt so that probes do not require credentials.
+func grpcBasicAuth(ctx context.Context, conf httpserver.BasicAuthConfig, fullMethod string) error {
+	if !conf.Enabled || strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/") {
+		return nil
+	}
+
+	var user, pass string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			if !strings.HasPrefix(v, "Basic ") {
+				continue
+			}
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(v, "Basic "))
+			if err != nil {
+				continue
+			}
+			if i := strings.IndexByte(string(decoded), ':'); i >= 0 {
+				user, pass = string(decoded[:i]), string(decoded[i+1:])
+				break
+			}
+		}
+	}
+
+	ok, err := conf.Matches(user, pass)
+	if err != nil {
+		return status.Error(codes.Internal, "failed to check credentials")
+	}
+	if !ok {
+		return status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	return nil
+}
+
+// serveStreamsGRPC serves the gRPC management API of a stream manager, along
+// with the standard gRPC health service, on an address. The TLS and basic
+// authentication settings of the HTTP server config are applied to the server.
+func serveStreamsGRPC(address string, conf api.Config, clientCAFile string, streamMgr *strmmgr.Type, logger log.Modular) (Stoppable, error) {
+	if err := conf.BasicAuth.Validate(); err != nil {
+		return nil, fmt.Errorf("basic_auth: %w", err)
+	}
+	creds, err := grpcServerCredentials(conf, clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := grpcBasicAuth(ctx, conf.BasicAuth, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := grpcBasicAuth(ss.Context(), conf.BasicAuth, info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	} else {
+		logger.Warnln("The gRPC management server is running without TLS, set the http fields cert_file and key_file in order to enable it")
+	}
+
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &grpcStoppable{
+		server: grpc.NewServer(opts...),
+		health: health.NewServer(),
+	}
+	managementpb.RegisterManagementServer(g.server, strmmgr.NewGRPCServer(streamMgr))
+	grpc_health_v1.RegisterHealthServer(g.server, g.health)
+
+	go func() {
+		logger.Infof("Listening for gRPC management requests at: %v", lis.Addr())
+		if err := g.server.Serve(lis); err != nil {
+			logger.Errorf("gRPC management server error: %v", err)
+		}
+	}()
+	return g, nil
+}
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/httpserver"
+)
+
+func TestGRPCBasicAuth(t *testing.T) {
+	conf := httpserver.NewBasicAuthConfig()
+	conf.Enabled = true
+	conf.Username = "myuser"
+	conf.PasswordHash = "K7gNU3sdo+OL0wNhqoVWhr3g6s1xYv72ol/pe/Unols="
+
+	withAuth := func(user, pass string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+pass)),
+		))
+	}
+
+	method := "/benthos.management.v1.Management/CreateStream"
+
+	assert.NoError(t, grpcBasicAuth(withAuth("myuser", "secret"), conf, method))
+	assert.Equal(t, codes.Unauthenticated, status.Code(grpcBasicAuth(withAuth("myuser", "nope"), conf, method)))
+	assert.Equal(t, codes.Unauthenticated, status.Code(grpcBasicAuth(context.Background(), conf, method)))
+
+	// Health probes do not require credentials.
+	assert.NoError(t, grpcBasicAuth(context.Background(), conf, "/grpc.health.v1.Health/Check"))
+
+	conf.Enabled = false
+	assert.NoError(t, grpcBasicAuth(context.Background(), conf, method))
+}
+
+func TestGRPCServerCredentials(t *testing.T) {
+	conf := api.NewConfig()
+
+	creds, err := grpcServerCredentials(conf, "")
+	require.NoError(t, err)
+	assert.Nil(t, creds)
+
+	_, err = grpcServerCredentials(conf, "./ca.pem")
+	require.Error(t, err)
+
+	conf.CertFile = "./cert.pem"
+	_, err = grpcServerCredentials(conf, "")
+	require.Error(t, err)
+}
@@ -17,8 +17,10 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/events"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
@@ -35,10 +37,14 @@ func CreateManager(
 ) (stoppableMgr *StoppableManager, err error) {
 	var stats *metrics.Namespaced
 	var trac trace.TracerProvider
+	var evts *events.Bus
 	defer func() {
 		if err == nil {
 			return
 		}
+		if evts != nil {
+			evts.TriggerCloseNow()
+		}
 		if trac != nil {
 			if shutter, ok := trac.(interface {
 				Shutdown(context.Context) error
@@ -89,6 +95,30 @@ func CreateManager(
 		return
 	}
 
+	// Create our event bus, which is only needed when events are routed to an
+	// output.
+	if conf.Events.Output != nil {
+		if evts, err = events.NewBus(conf.Events.Types, conf.Events.BufferSize, logger, stats); err != nil {
+			err = fmt.Errorf("failed to initialise events: %w", err)
+			return
+		}
+		mgrOpts = append(mgrOpts, manager.OptSetEventEmitter(evts))
+
+		if conf.Events.Lineage.Enabled {
+			if r := conf.Events.Lineage.SampleRatio; r <= 0 || r > 1 {
+				err = fmt.Errorf("events.lineage.sample_ratio must be greater than 0 and no greater than 1, got %v", r)
+				return
+			}
+			mgrOpts = append(mgrOpts, manager.OptSetLineageSampleRatio(conf.Events.Lineage.SampleRatio))
+		}
+	} else if conf.Events.Lineage.Enabled {
+		logger.Warnln("Message lineage is enabled but will not be recorded as an events output has not been configured")
+	}
+
+	if conf.Replay.Cache != "" {
+		mgrOpts = append(mgrOpts, manager.OptSetReplayStore(conf.Replay.Cache, conf.Replay.KeyPrefix, conf.Replay.MaxEntries, conf.Replay.AckCaptured))
+	}
+
 	mgrOpts = append([]manager.OptFunc{
 		manager.OptSetAPIReg(httpServer),
 		manager.OptSetStreamHTTPNamespacing(c.Bool("prefix-stream-endpoints")),
@@ -105,7 +135,21 @@ func CreateManager(
 		return
 	}
 
+	var evtsOut output.Streamed
+	if evts != nil {
+		if evtsOut, err = mgr.IntoPath("events", "output").NewOutput(*conf.Events.Output); err != nil {
+			err = fmt.Errorf("failed to initialise events output: %w", err)
+			return
+		}
+		if err = evtsOut.Consume(evts.TransactionChan()); err != nil {
+			err = fmt.Errorf("failed to initialise events output: %w", err)
+			return
+		}
+	}
+
 	stoppableMgr = newStoppableManager(httpServer, mgr)
+	stoppableMgr.events = evts
+	stoppableMgr.eventsOut = evtsOut
 	return
 }
 
@@ -241,6 +285,9 @@ type StoppableManager struct {
 	api           *api.Type
 	apiClosedChan chan struct{}
 	mgr           *manager.Type
+
+	events    *events.Bus
+	eventsOut output.Streamed
 }
 
 // Manager returns the underlying manager type.
@@ -277,6 +324,16 @@ func (s *StoppableManager) Stop(ctx context.Context) error {
 	if err := s.mgr.WaitForClose(ctx); err != nil {
 		return err
 	}
+	if s.events != nil {
+		// Events are flushed last so that those emitted during shut down are
+		// still delivered.
+		s.events.TriggerStopConsuming()
+		if err := s.eventsOut.WaitForClose(ctx); err != nil {
+			s.events.TriggerCloseNow()
+			s.eventsOut.TriggerCloseNow()
+			return err
+		}
+	}
 	if err := s.mgr.CloseObservability(ctx); err != nil {
 		s.mgr.Logger().Errorf("Failed to cleanly close observability components: %w", err)
 	}
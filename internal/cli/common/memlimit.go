@@ -0,0 +1,146 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+// Values at or above this threshold reported by cgroup v1 indicate that no
+// memory limit has been set.
+const cgroupV1UnlimitedThreshold = math.MaxInt64 / 2
+
+var errNoCgroupMemoryLimit = errors.New("no cgroup memory limit found")
+
+// selfCgroupPaths parses the contents of /proc/self/cgroup and returns the
+// path of the cgroup v2 unified hierarchy and the path of the cgroup v1 memory
+// controller that the process belongs to, defaulting to the root of each.
+func selfCgroupPaths(b []byte) (v2Path, v1Path string) {
+	v2Path, v1Path = "/", "/"
+	for _, line := range strings.Split(string(b), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			v2Path = parts[2]
+			continue
+		}
+		for _, c := range strings.Split(parts[1], ",") {
+			if c == "memory" {
+				v1Path = parts[2]
+			}
+		}
+	}
+	return
+}
+
+// lowestCgroupLimit reads a limit file within the directory of a cgroup and
+// each of its ancestors, returning the lowest limit found as the limits of
+// ancestors also apply to the cgroup. Directories that do not exist, such as
+// when the path is relative to a cgroup namespace, are skipped.
+func lowestCgroupLimit(root, cgroupPath, file string, parse func(string) (int64, bool, error)) (int64, error) {
+	var lowest int64
+	dir := filepath.Join(root, filepath.Clean("/"+cgroupPath))
+	for {
+		b, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, err
+		}
+		if err == nil {
+			limit, limited, err := parse(strings.TrimSpace(string(b)))
+			if err != nil {
+				return 0, err
+			}
+			if limited && (lowest == 0 || limit < lowest) {
+				lowest = limit
+			}
+		}
+		if dir == root {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	if lowest == 0 {
+		return 0, errNoCgroupMemoryLimit
+	}
+	return lowest, nil
+}
+
+// cgroupMemoryLimit attempts to read the memory limit of the cgroup the process
+// runs within, where root is the mount point of the cgroup filesystem (usually
+// /sys/fs/cgroup) and selfCgroup is the file describing the cgroups of the
+// process (usually /proc/self/cgroup).
+func cgroupMemoryLimit(root, selfCgroup string) (int64, error) {
+	root = filepath.Clean(root)
+
+	var v2Path, v1Path string
+	if b, err := os.ReadFile(selfCgroup); err == nil {
+		v2Path, v1Path = selfCgroupPaths(b)
+	} else if errors.Is(err, os.ErrNotExist) {
+		v2Path, v1Path = "/", "/"
+	} else {
+		return 0, err
+	}
+
+	// cgroup v2
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return lowestCgroupLimit(root, v2Path, "memory.max", func(v string) (int64, bool, error) {
+			if v == "max" {
+				return 0, false, nil
+			}
+			limit, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return 0, false, fmt.Errorf("failed to parse cgroup v2 memory limit: %w", err)
+			}
+			return limit, limit > 0, nil
+		})
+	}
+
+	// cgroup v1
+	return lowestCgroupLimit(filepath.Join(root, "memory"), v1Path, "memory.limit_in_bytes", func(v string) (int64, bool, error) {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to parse cgroup v1 memory limit: %w", err)
+		}
+		return limit, limit > 0 && limit < cgroupV1UnlimitedThreshold, nil
+	})
+}
+
+// applyRuntimeConfig tunes the Go runtime according to the runtime section of
+// a config.
+func applyRuntimeConfig(conf config.RuntimeConfig, cgroupRoot, selfCgroup string, logger log.Modular) {
+	if !conf.MemoryLimitFromCgroup {
+		return
+	}
+	if _, exists := os.LookupEnv("GOMEMLIMIT"); exists {
+		logger.Debugln("Ignoring cgroup memory limit as GOMEMLIMIT is set")
+		return
+	}
+	if conf.MemoryLimitRatio <= 0 || conf.MemoryLimitRatio > 1 {
+		logger.Errorf("Ignoring cgroup memory limit as memory_limit_ratio must be greater than 0 and no greater than 1, got %v", conf.MemoryLimitRatio)
+		return
+	}
+
+	limit, err := cgroupMemoryLimit(cgroupRoot, selfCgroup)
+	if err != nil {
+		if errors.Is(err, errNoCgroupMemoryLimit) {
+			logger.Debugln("No cgroup memory limit found")
+		} else {
+			logger.Warnf("Failed to read cgroup memory limit: %v", err)
+		}
+		return
+	}
+
+	memLimit := int64(float64(limit) * conf.MemoryLimitRatio)
+	debug.SetMemoryLimit(memLimit)
+	logger.Infof("Set runtime memory limit to %v bytes from cgroup memory limit of %v bytes", memLimit, limit)
+}
@@ -0,0 +1,129 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCgroupMemoryLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		selfCgroup  string
+		files       map[string]string
+		limit       int64
+		noLimit     bool
+		errContains string
+	}{
+		{
+			name:       "v2 limit",
+			selfCgroup: "0::/\n",
+			files:      map[string]string{"cgroup.controllers": "memory\n", "memory.max": "1073741824\n"},
+			limit:      1073741824,
+		},
+		{
+			name:       "v2 unlimited",
+			selfCgroup: "0::/\n",
+			files:      map[string]string{"cgroup.controllers": "memory\n", "memory.max": "max\n"},
+			noLimit:    true,
+		},
+		{
+			name:        "v2 invalid",
+			selfCgroup:  "0::/\n",
+			files:       map[string]string{"cgroup.controllers": "memory\n", "memory.max": "nope\n"},
+			errContains: "failed to parse cgroup v2 memory limit",
+		},
+		{
+			name:       "v2 own cgroup",
+			selfCgroup: "0::/kubepods/pod1/app\n",
+			files: map[string]string{
+				"cgroup.controllers":             "memory\n",
+				"kubepods/pod1/memory.max":       "max\n",
+				"kubepods/pod1/app/memory.max":   "536870912\n",
+				"kubepods/pod2/app/memory.max":   "1024\n",
+				"kubepods/pod1/other/memory.max": "2048\n",
+			},
+			limit: 536870912,
+		},
+		{
+			name:       "v2 ancestor limit",
+			selfCgroup: "0::/kubepods/pod1/app\n",
+			files: map[string]string{
+				"cgroup.controllers":           "memory\n",
+				"kubepods/pod1/memory.max":     "268435456\n",
+				"kubepods/pod1/app/memory.max": "max\n",
+			},
+			limit: 268435456,
+		},
+		{
+			name:       "v2 namespaced cgroup",
+			selfCgroup: "0::/../../kubepods/pod1/app\n",
+			files:      map[string]string{"cgroup.controllers": "memory\n", "memory.max": "1073741824\n"},
+			limit:      1073741824,
+		},
+		{
+			name:       "v2 root without limit",
+			selfCgroup: "0::/\n",
+			files:      map[string]string{"cgroup.controllers": "memory\n"},
+			noLimit:    true,
+		},
+		{
+			name:       "v1 limit",
+			selfCgroup: "4:memory:/\n",
+			files:      map[string]string{"memory/memory.limit_in_bytes": "536870912\n"},
+			limit:      536870912,
+		},
+		{
+			name:       "v1 own cgroup",
+			selfCgroup: "5:cpu,cpuacct:/docker/abc\n4:memory:/docker/abc\n",
+			files: map[string]string{
+				"memory/memory.limit_in_bytes":            "9223372036854771712\n",
+				"memory/docker/abc/memory.limit_in_bytes": "134217728\n",
+			},
+			limit: 134217728,
+		},
+		{
+			name:       "v1 unlimited",
+			selfCgroup: "4:memory:/\n",
+			files:      map[string]string{"memory/memory.limit_in_bytes": "9223372036854771712\n"},
+			noLimit:    true,
+		},
+		{
+			name:    "missing",
+			files:   map[string]string{},
+			noLimit: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			for p, c := range test.files {
+				fullPath := filepath.Join(root, p)
+				require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+				require.NoError(t, os.WriteFile(fullPath, []byte(c), 0o644))
+			}
+
+			selfCgroup := filepath.Join(t.TempDir(), "cgroup")
+			if test.selfCgroup != "" {
+				require.NoError(t, os.WriteFile(selfCgroup, []byte(test.selfCgroup), 0o644))
+			}
+
+			limit, err := cgroupMemoryLimit(root, selfCgroup)
+			switch {
+			case test.noLimit:
+				assert.ErrorIs(t, err, errNoCgroupMemoryLimit)
+			case test.errContains != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, test.limit, limit)
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package common
 
 import (
+	"fmt"
+
 	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
 
 	"github.com/urfave/cli/v2"
 )
@@ -10,7 +14,7 @@ import (
 // ReadConfig attempts to read a general service wide config via a returned
 // config.Reader based on input CLI flags. This includes applying any config
 // overrides expressed by the --set flag.
-func ReadConfig(c *cli.Context, streamsMode bool) (mainPath string, inferred bool, conf *config.Reader) {
+func ReadConfig(c *cli.Context, streamsMode bool, extraOpts ...config.OptFunc) (mainPath string, inferred bool, conf *config.Reader) {
 	path := c.String("config")
 	if path == "" {
 		// Iterate default config paths
@@ -27,11 +31,32 @@ func ReadConfig(c *cli.Context, streamsMode bool) (mainPath string, inferred boo
 		}
 	}
 	opts := []config.OptFunc{
+		config.OptAddOverlays(c.StringSlice("overlay")...),
 		config.OptAddOverrides(c.StringSlice("set")...),
 		config.OptTestSuffix("_benthos_test"),
+		config.OptSetSecretsRefreshPeriod(c.Duration("secrets-refresh-interval")),
 	}
 	if streamsMode {
 		opts = append(opts, config.OptSetStreamPaths(c.Args().Slice()...))
 	}
+	opts = append(opts, extraOpts...)
 	return path, inferred, config.NewReader(path, c.StringSlice("resources"), opts...)
 }
+
+// ReadLintRules reads and parses custom linting rule packs from a list of file
+// paths.
+func ReadLintRules(env *bloblang.Environment, paths []string) ([]docs.LintRule, error) {
+	var rules []docs.LintRule
+	for _, p := range paths {
+		ruleBytes, err := ifs.ReadFile(ifs.OS(), p)
+		if err != nil {
+			return nil, err
+		}
+		pRules, err := docs.ParseLintRules(env, ruleBytes)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", p, err)
+		}
+		rules = append(rules, pRules...)
+	}
+	return rules, nil
+}
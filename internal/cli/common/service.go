@@ -6,14 +6,19 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/events"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 	strmmgr "github.com/benthosdev/benthos/v4/internal/stream/manager"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
 
 	"github.com/urfave/cli/v2"
 )
@@ -21,7 +26,22 @@ import (
 // RunService runs a service command (either the default or the streams
 // subcommand).
 func RunService(c *cli.Context, version, dateBuilt string, streamsMode bool) int {
-	mainPath, inferredMainPath, confReader := ReadConfig(c, streamsMode)
+	var readerOpts []config.OptFunc
+	var lintRules []docs.LintRule
+	if streamsMode {
+		var err error
+		if lintRules, err = ReadLintRules(bloblang.GlobalEnvironment(), c.StringSlice("lint-rules")); err != nil {
+			fmt.Fprintf(os.Stderr, "Lint rules read error: %v\n", err)
+			return 1
+		}
+		if len(lintRules) > 0 {
+			lConf := docs.NewLintConfig()
+			lConf.Rules = lintRules
+			readerOpts = append(readerOpts, config.OptSetLintConfig(lConf))
+		}
+	}
+
+	mainPath, inferredMainPath, confReader := ReadConfig(c, streamsMode, readerOpts...)
 
 	conf, lints, err := confReader.Read()
 	if err != nil {
@@ -38,6 +58,8 @@ func RunService(c *cli.Context, version, dateBuilt string, streamsMode bool) int
 		return 1
 	}
 
+	applyRuntimeConfig(conf.Runtime, "/sys/fs/cgroup", "/proc/self/cgroup", logger)
+
 	if mainPath == "" {
 		logger.Infof("Running without a main config file")
 	} else if inferredMainPath {
@@ -72,7 +94,24 @@ func RunService(c *cli.Context, version, dateBuilt string, streamsMode bool) int
 	watching := c.Bool("watcher")
 	if streamsMode {
 		enableStreamsAPI := !c.Bool("no-api")
-		stoppableStream = initStreamsMode(strict, watching, enableStreamsAPI, confReader, stoppableManager.Manager())
+		storeOpt, err := streamsStoreOpt(c, stoppableManager.Manager())
+		if err != nil {
+			logger.Errorf("Failed to create streams store: %v", err)
+			return 1
+		}
+		streamMgr := initStreamsMode(strict, watching, enableStreamsAPI, confReader, stoppableManager.Manager(), storeOpt,
+			strmmgr.OptDashboard(c.Bool("dashboard")),
+			strmmgr.OptLintRules(lintRules),
+		)
+		stoppableStream = streamMgr
+		if grpcAddress := c.String("grpc-address"); grpcAddress != "" {
+			grpcServer, err := serveStreamsGRPC(grpcAddress, conf.HTTP, c.String("grpc-client-ca-file"), streamMgr, logger)
+			if err != nil {
+				logger.Errorf("Failed to create gRPC management server: %v", err)
+				return 1
+			}
+			stoppableStream = CombineStoppables(grpcServer, streamMgr)
+		}
 	} else {
 		stoppableStream, dataStreamClosedChan = initNormalMode(conf, strict, watching, confReader, stoppableManager.Manager())
 	}
@@ -104,13 +143,75 @@ func DelayShutdown(ctx context.Context, duration time.Duration) error {
 	return nil
 }
 
+// streamsStoreOpt returns a stream manager option that configures a persistent
+// store for streams, if one has been specified via CLI flags.
+func streamsStoreOpt(c *cli.Context, mgr *manager.Type) (func(*strmmgr.Type), error) {
+	var storeTypes []string
+	for _, flag := range []string{"store-cache", "store-sql-driver", "store-etcd-endpoint"} {
+		if c.String(flag) != "" {
+			storeTypes = append(storeTypes, flag)
+		}
+	}
+	if len(storeTypes) > 1 {
+		return nil, fmt.Errorf("only one store can be specified, got flags: %v", strings.Join(storeTypes, ", "))
+	}
+
+	var store strmmgr.Store
+	var err error
+	if cacheName := c.String("store-cache"); cacheName != "" {
+		if store, err = strmmgr.NewCacheStore(mgr, cacheName, c.String("store-cache-prefix")); err != nil {
+			return nil, err
+		}
+	} else if driver := c.String("store-sql-driver"); driver != "" {
+		if store, err = strmmgr.NewSQLStore(driver, c.String("store-sql-dsn"), c.String("store-sql-table")); err != nil {
+			return nil, err
+		}
+	} else if endpoint := c.String("store-etcd-endpoint"); endpoint != "" {
+		if store, err = strmmgr.NewEtcdStore(endpoint, c.String("store-etcd-prefix")); err != nil {
+			return nil, err
+		}
+	}
+
+	singletons := c.StringSlice("store-singleton")
+	if store == nil {
+		if len(singletons) > 0 {
+			return nil, errors.New("singleton streams require a store to be specified")
+		}
+		return func(*strmmgr.Type) {}, nil
+	}
+
+	syncInterval := c.Duration("store-sync-interval")
+	if len(singletons) == 0 {
+		return strmmgr.OptStore(store, syncInterval), nil
+	}
+	if _, ok := store.(strmmgr.Elector); !ok {
+		return nil, errors.New("singleton streams require a store that supports leader election, which the cache store does not")
+	}
+	if syncInterval <= 0 {
+		return nil, errors.New("singleton streams require a non-zero store sync interval")
+	}
+	for _, pattern := range singletons {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid singleton pattern '%v': %w", pattern, err)
+		}
+	}
+	return func(t *strmmgr.Type) {
+		strmmgr.OptStore(store, syncInterval)(t)
+		strmmgr.OptSingletons(singletons)(t)
+	}, nil
+}
+
 func initStreamsMode(
 	strict, watching, enableAPI bool,
 	confReader *config.Reader,
 	mgr *manager.Type,
-) Stoppable {
+	opts ...func(*strmmgr.Type),
+) *strmmgr.Type {
 	logger := mgr.Logger()
-	streamMgr := strmmgr.New(mgr, strmmgr.OptAPIEnabled(enableAPI))
+	streamMgr := strmmgr.New(mgr, append([]func(*strmmgr.Type){
+		strmmgr.OptAPIEnabled(enableAPI),
+		strmmgr.OptHotReload(watching || enableAPI),
+	}, opts...)...)
 
 	streamConfs := map[string]stream.Config{}
 	lints, err := confReader.ReadStreams(streamConfs)
@@ -164,6 +265,9 @@ func initStreamsMode(
 			logger.Errorf("Failed to create stream config watcher: %v", err)
 			os.Exit(1)
 		}
+	} else if err := confReader.BeginSecretsRefreshing(mgr, strict); err != nil {
+		logger.Errorf("Failed to create stream config secrets refresher: %v", err)
+		os.Exit(1)
 	}
 	return streamMgr
 }
@@ -176,16 +280,38 @@ func initNormalMode(
 ) (newStream Stoppable, stoppedChan chan struct{}) {
 	logger := mgr.Logger()
 
+	var drainInput, drainFlush time.Duration
+	if td := conf.Drain.InputTimeout; len(td) > 0 {
+		var err error
+		if drainInput, err = time.ParseDuration(td); err != nil {
+			logger.Errorf("Failed to parse drain input timeout period string: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if td := conf.Drain.FlushTimeout; len(td) > 0 {
+		var err error
+		if drainFlush, err = time.ParseDuration(td); err != nil {
+			logger.Errorf("Failed to parse drain flush timeout period string: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	stoppedChan = make(chan struct{})
 	var closeOnce sync.Once
+	var currentStream *stream.Type
 	streamInit := func() (Stoppable, error) {
-		return stream.New(conf.Config, mgr, stream.OptOnClose(func() {
+		strm, err := stream.New(conf.Config, mgr, stream.OptHotReload(watching), stream.OptDrainTimeouts(drainInput, drainFlush), stream.OptOnClose(func() {
 			if !watching {
 				closeOnce.Do(func() {
 					close(stoppedChan)
 				})
 			}
 		}))
+		if err != nil {
+			return nil, err
+		}
+		currentStream = strm
+		return strm, nil
 	}
 
 	var stoppableStream *SwappableStopper
@@ -201,10 +327,26 @@ func initNormalMode(
 		ctx, done := context.WithTimeout(context.Background(), 30*time.Second)
 		defer done()
 		// NOTE: We're ignoring observability field changes for now.
-		return stoppableStream.Replace(ctx, func() (Stoppable, error) {
+		if currentStream != nil {
+			applied, err := currentStream.UpdateInPlace(ctx, newStreamConf.Config)
+			if err != nil {
+				logger.Warnf("Failed to apply config changes in place, restarting stream instead: %v", err)
+			} else if applied {
+				conf.Config = newStreamConf.Config
+				logger.Infoln("Applied config changes without restarting stream")
+				mgr.Events().Emit(events.New(events.TypeConfigReloaded, "Applied config changes without restarting stream"))
+				return nil
+			}
+		}
+		currentStream = nil
+		if err := stoppableStream.Replace(ctx, func() (Stoppable, error) {
 			conf.Config = newStreamConf.Config
 			return streamInit()
-		})
+		}); err != nil {
+			return err
+		}
+		mgr.Events().Emit(events.New(events.TypeConfigReloaded, "Applied config changes by restarting stream"))
+		return nil
 	}); err != nil {
 		logger.Errorf("Failed to create config file watcher: %v", err)
 		os.Exit(1)
@@ -215,6 +357,9 @@ func initNormalMode(
 			logger.Errorf("Failed to create config file watcher: %v", err)
 			os.Exit(1)
 		}
+	} else if err := confReader.BeginSecretsRefreshing(mgr, strict); err != nil {
+		logger.Errorf("Failed to create config secrets refresher: %v", err)
+		os.Exit(1)
 	}
 
 	newStream = stoppableStream
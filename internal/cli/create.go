@@ -113,7 +113,12 @@ forward slashes:
   benthos create stdin/bloblang,awk/nats
   benthos create file,http_server/protobuf/http_client
 
-If the expression is omitted a default config is created.`[1:],
+If the expression is omitted a default config is created.
+
+Alternatively, the --wizard flag asks a series of questions about the
+components and settings of the config instead:
+
+  benthos create --wizard > ./config.yaml`[1:],
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:    "small",
@@ -121,8 +126,24 @@ If the expression is omitted a default config is created.`[1:],
 				Value:   false,
 				Usage:   "Print only the main components of a Benthos config (input, pipeline, output) and omit all fields marked as advanced.",
 			},
+			&cli.BoolFlag{
+				Name:    "wizard",
+				Aliases: []string{"w"},
+				Value:   false,
+				Usage:   "Ask a series of questions about the desired components and print a commented config with recommended batching, retry and metrics settings. Questions are written to stderr.",
+			},
 		},
 		Action: func(c *cli.Context) error {
+			if c.Bool("wizard") {
+				configYAML, err := CreateWizardConfig(c.App.Reader, c.App.ErrWriter)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Generate error: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Fprintln(c.App.Writer, string(configYAML))
+				return nil
+			}
+
 			conf := config.New()
 
 			if expression := c.Args().First(); len(expression) > 0 {
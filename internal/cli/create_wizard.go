@@ -0,0 +1,230 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/pipeline"
+)
+
+type wizardCreateConfig struct {
+	Input    input.Config    `json:"input" yaml:"input"`
+	Pipeline pipeline.Config `json:"pipeline" yaml:"pipeline"`
+	Output   output.Config   `json:"output" yaml:"output"`
+	Metrics  metrics.Config  `json:"metrics" yaml:"metrics"`
+}
+
+type wizard struct {
+	scanner *bufio.Scanner
+	prompts io.Writer
+}
+
+// ask prints a question and returns the answer, or the default value when the
+// answer is empty or the input has been exhausted. When a check is provided the
+// question is repeated until the check passes.
+func (w *wizard) ask(question, defaultValue string, check func(string) error) string {
+	for {
+		fmt.Fprintf(w.prompts, "%v [%v]: ", question, defaultValue)
+		if !w.scanner.Scan() {
+			fmt.Fprintln(w.prompts)
+			return defaultValue
+		}
+		answer := strings.TrimSpace(w.scanner.Text())
+		if answer == "" {
+			answer = defaultValue
+		}
+		if check == nil {
+			return answer
+		}
+		if err := check(answer); err != nil {
+			fmt.Fprintf(w.prompts, "%v\n", err)
+			continue
+		}
+		return answer
+	}
+}
+
+func (w *wizard) confirm(question string, defaultValue bool) bool {
+	defStr := "Y/n"
+	if !defaultValue {
+		defStr = "y/N"
+	}
+	answer := w.ask(question, defStr, func(s string) error {
+		switch strings.ToLower(s) {
+		case "y/n", "y", "yes", "n", "no":
+			return nil
+		}
+		return errors.New("please answer yes or no")
+	})
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	}
+	return defaultValue
+}
+
+func checkComponents(typeStr string, exists func(string) bool) func(string) error {
+	return func(s string) error {
+		for _, t := range strings.Split(s, ",") {
+			if t = strings.TrimSpace(t); t == "" || t == "none" {
+				continue
+			}
+			if !exists(t) {
+				return fmt.Errorf("unrecognised %v type '%v', run `benthos list %vs` to see all options", typeStr, t, typeStr)
+			}
+		}
+		return nil
+	}
+}
+
+func specHasField(spec docs.ComponentSpec, name string) bool {
+	for _, f := range spec.Config.Children {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWizardConfig asks a series of questions about the desired components of
+// a config, with the questions written to prompts and the answers read from
+// in, and returns a commented config containing recommended batching, retry
+// and metrics settings.
+func CreateWizardConfig(in io.Reader, prompts io.Writer) ([]byte, error) {
+	w := &wizard{scanner: bufio.NewScanner(in), prompts: prompts}
+
+	inputType := w.ask("Which input should messages be consumed from? (e.g. kafka, amqp_0_9, http_server, file)", "stdin", checkComponents("input", func(s string) bool {
+		_, exists := bundle.AllInputs.DocsFor(s)
+		return exists
+	}))
+	procTypes := w.ask("Which processors should be applied to messages, as a comma separated list? (e.g. mapping, branch, http)", "mapping", checkComponents("processor", func(s string) bool {
+		_, exists := bundle.AllProcessors.DocsFor(s)
+		return exists
+	}))
+	if procTypes == "none" {
+		procTypes = ""
+	}
+	outputType := w.ask("Which output should messages be written to? (e.g. kafka, aws_s3, http_client, file)", "stdout", checkComponents("output", func(s string) bool {
+		_, exists := bundle.AllOutputs.DocsFor(s)
+		return exists
+	}))
+
+	outputSpec, _ := bundle.AllOutputs.DocsFor(outputType)
+	batching := specHasField(outputSpec, "batching") && w.confirm("Should messages be batched before being written?", true)
+	retry := w.confirm("Should failed writes be retried with a back off?", true)
+
+	defaultMetrics := "prometheus"
+	if _, exists := bundle.AllMetrics.DocsFor(defaultMetrics); !exists {
+		defaultMetrics = "none"
+	}
+	metricsType := w.ask("Which metrics exporter should be used? (e.g. prometheus, statsd, json_api, none)", defaultMetrics, checkComponents("metric", func(s string) bool {
+		_, exists := bundle.AllMetrics.DocsFor(s)
+		return exists
+	}))
+	if metricsType == "" {
+		metricsType = "none"
+	}
+
+	conf := config.New()
+	if err := addExpression(&conf, inputType+"/"+procTypes+"/"+outputType); err != nil {
+		return nil, err
+	}
+	conf.Metrics.Type = metricsType
+
+	outputPath := []string{"output", outputType}
+	if retry {
+		inner := conf.Output
+		conf.Output = output.NewConfig()
+		conf.Output.Type = "retry"
+		conf.Output.Retry.Output = &inner
+		outputPath = []string{"output", "retry", "output", outputType}
+	}
+
+	var node yaml.Node
+	if err := node.Encode(wizardCreateConfig{
+		Input:    conf.Input,
+		Pipeline: conf.Pipeline,
+		Output:   conf.Output,
+		Metrics:  conf.Metrics,
+	}); err != nil {
+		return nil, err
+	}
+
+	sanitConf := docs.NewSanitiseConfig()
+	sanitConf.RemoveTypeField = true
+	sanitConf.RemoveDeprecated = true
+	sanitConf.ForExample = true
+	sanitConf.Filter = func(spec docs.FieldSpec, _ any) bool {
+		return !spec.IsAdvanced
+	}
+	if err := config.Spec().SanitiseYAML(&node, sanitConf); err != nil {
+		return nil, err
+	}
+
+	if batching {
+		var batchNode yaml.Node
+		if err := batchNode.Encode(map[string]any{
+			"count":  100,
+			"period": "1s",
+		}); err != nil {
+			return nil, err
+		}
+		batchNode.HeadComment = "Messages are flushed as a batch once either 100 have accumulated or a second has\npassed, tune these values for the throughput and latency required."
+		if err := config.Spec().SetYAMLPath(docs.DeprecatedProvider, &node, &batchNode, append(outputPath, "batching")...); err != nil {
+			return nil, err
+		}
+	}
+
+	if retry {
+		var backoffNode yaml.Node
+		if err := backoffNode.Encode(map[string]any{
+			"initial_interval": "500ms",
+			"max_interval":     "3s",
+		}); err != nil {
+			return nil, err
+		}
+		if err := config.Spec().SetYAMLPath(docs.DeprecatedProvider, &node, &backoffNode, "output", "retry", "backoff"); err != nil {
+			return nil, err
+		}
+	}
+
+	commentKey(&node, "input", "The input consumes messages from "+inputType+", fill in any required fields.")
+	commentKey(&node, "pipeline", "Processors are applied to each message consumed, in the order they are listed.\nThe number of parallel threads can be tuned with pipeline.threads.")
+	outputComment := "The output writes messages to " + outputType + "."
+	if retry {
+		outputComment += "\nFailed writes are retried indefinitely with an exponential back off, which can be\nlimited with max_retries."
+	}
+	commentKey(&node, "output", outputComment)
+	if metricsType != "none" {
+		commentKey(&node, "metrics", "Metrics are exported with "+metricsType+", see https://www.benthos.dev/docs/components/metrics/about\nfor the metrics emitted by each component.")
+	}
+
+	return config.MarshalYAML(node)
+}
+
+// commentKey adds a head comment to a key of the root mapping of a config.
+func commentKey(node *yaml.Node, key, comment string) {
+	root := node
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	for i := 0; i < len(root.Content)-1; i += 2 {
+		if root.Content[i].Value == key {
+			root.Content[i].HeadComment = comment
+			return
+		}
+	}
+}
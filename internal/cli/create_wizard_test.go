@@ -0,0 +1,79 @@
+package cli_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	icli "github.com/benthosdev/benthos/v4/internal/cli"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/prometheus"
+	_ "github.com/benthosdev/benthos/v4/public/components/statsd"
+)
+
+func TestCreateWizardConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		answers  []string
+		contains []string
+		lacks    []string
+	}{
+		{
+			name:    "defaults",
+			answers: nil,
+			contains: []string{
+				"stdin:",
+				"mapping:",
+				"retry:",
+				"stdout:",
+				"prometheus: {}",
+			},
+			lacks: []string{"batching:"},
+		},
+		{
+			name:    "batching and no retries",
+			answers: []string{"generate", "mapping,log", "http_client", "y", "n", "none"},
+			contains: []string{
+				"generate:",
+				"log:",
+				"http_client:",
+				"batching:",
+				"count: 100",
+				"# Messages are flushed as a batch",
+			},
+			lacks: []string{"retry:", "prometheus:", "# Metrics are exported"},
+		},
+		{
+			name:    "invalid answers are asked again",
+			answers: []string{"not_an_input", "stdin", "none", "stdout", "maybe", "no", "statsd"},
+			contains: []string{
+				"stdin:",
+				"statsd:",
+			},
+			lacks: []string{"retry:", "pipeline:\n  threads: -1\n  processors:\n    -"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var prompts bytes.Buffer
+			confBytes, err := icli.CreateWizardConfig(strings.NewReader(strings.Join(test.answers, "\n")), &prompts)
+			require.NoError(t, err)
+
+			var v any
+			require.NoError(t, yaml.Unmarshal(confBytes, &v))
+
+			for _, c := range test.contains {
+				assert.Contains(t, string(confBytes), c)
+			}
+			for _, c := range test.lacks {
+				assert.NotContains(t, string(confBytes), c)
+			}
+		})
+	}
+}
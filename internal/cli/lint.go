@@ -14,10 +14,12 @@ import (
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/cli/common"
 	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
 	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
 )
 
 var (
@@ -30,9 +32,9 @@ type pathLint struct {
 	lint   docs.Lint
 }
 
-func lintFile(path string, skipEnvVarCheck bool, lConf docs.LintConfig) (pathLints []pathLint) {
+func lintFile(path string, overlayPaths []string, skipEnvVarCheck bool, lConf docs.LintConfig) (pathLints []pathLint) {
 	conf := config.New()
-	lints, err := config.ReadFileLinted(ifs.OS(), path, skipEnvVarCheck, lConf, &conf)
+	lints, err := config.ReadFileWithOverlaysLinted(ifs.OS(), path, overlayPaths, skipEnvVarCheck, lConf, &conf)
 	if err != nil {
 		var l docs.Lint
 		if errors.As(err, &l) {
@@ -134,12 +136,20 @@ func lintCliCommand() *cli.Command {
 Exits with a status code 1 if any linting errors are detected:
 
   benthos -c target.yaml lint
+  benthos -c base.yaml -o prod.yaml lint
   benthos lint ./configs/*.yaml
   benthos lint ./foo.yaml ./bar.yaml
   benthos lint ./configs/...
 
 If a path ends with '...' then Benthos will walk the target and lint any
-files with the .yaml or .yml extension.`[1:],
+files with the .yaml or .yml extension.
+
+Overlays specified with --overlay are merged onto the config specified with
+-c/--config and the result is linted as a whole.
+
+Custom rule packs specified with --rules are checked against each component
+of a config, for more information check out the docs at:
+https://benthos.dev/docs/configuration/about#custom-rules`[1:],
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:  "deprecated",
@@ -156,6 +166,15 @@ files with the .yaml or .yml extension.`[1:],
 				Value: false,
 				Usage: "Do not produce lint errors when environment interpolations exist without defaults within configs but aren't defined.",
 			},
+			&cli.StringFlag{
+				Name:  "input-schema",
+				Value: "",
+				Usage: "An optional path to a JSON Schema or Avro schema (.avsc) describing the documents that Bloblang mappings are executed upon. Mappings that are executed upon documents as they were consumed are checked for references to fields that cannot exist within the schema.",
+			},
+			&cli.StringSliceFlag{
+				Name:  "rules",
+				Usage: "A list of paths to custom lint rule packs, where each rule is a Bloblang predicate that components of a config must satisfy.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if code := LintAction(c, os.Stderr); code != 0 {
@@ -174,9 +193,11 @@ func LintAction(c *cli.Context, stderr io.Writer) int {
 		fmt.Fprintf(stderr, "Lint paths error: %v\n", err)
 		return 1
 	}
-	if conf := c.String("config"); len(conf) > 0 {
-		targets = append(targets, conf)
+	mainConf := c.String("config")
+	if len(mainConf) > 0 {
+		targets = append(targets, mainConf)
 	}
+	overlays := c.StringSlice("overlay")
 	targets = append(targets, c.StringSlice("resources")...)
 
 	lConf := docs.NewLintConfig()
@@ -184,6 +205,27 @@ func LintAction(c *cli.Context, stderr io.Writer) int {
 	lConf.RequireLabels = c.Bool("labels")
 	skipEnvVarCheck := c.Bool("skip-env-var-check")
 
+	if schemaPath := c.String("input-schema"); schemaPath != "" {
+		schemaBytes, err := ifs.ReadFile(ifs.OS(), schemaPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "Input schema error: %v\n", err)
+			return 1
+		}
+		var format string
+		if path.Ext(schemaPath) == ".avsc" {
+			format = "avro"
+		}
+		if lConf.BloblangInputSchema, err = docs.ParseInputSchema(format, schemaBytes); err != nil {
+			fmt.Fprintf(stderr, "Input schema error: %v\n", err)
+			return 1
+		}
+	}
+
+	if lConf.Rules, err = common.ReadLintRules(bloblang.GlobalEnvironment(), c.StringSlice("rules")); err != nil {
+		fmt.Fprintf(stderr, "Lint rules error: %v\n", err)
+		return 1
+	}
+
 	var pathLintMut sync.Mutex
 	var pathLints []pathLint
 	threads := runtime.NumCPU()
@@ -203,7 +245,11 @@ func LintAction(c *cli.Context, stderr io.Writer) int {
 				if path.Ext(target) == ".md" {
 					lints = lintMDSnippets(target, lConf)
 				} else {
-					lints = lintFile(target, skipEnvVarCheck, lConf)
+					var targetOverlays []string
+					if target == mainConf {
+						targetOverlays = overlays
+					}
+					lints = lintFile(target, targetOverlays, skipEnvVarCheck, lConf)
 				}
 				if len(lints) > 0 {
 					pathLintMut.Lock()
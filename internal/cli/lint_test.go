@@ -118,6 +118,28 @@ output:
 				"field nah is invalid",
 			},
 		},
+		{
+			name: "overlay with c flag",
+			args: []string{"benthos", "-c", tFile("foo.yaml"), "-o", tFile("prod.yaml"), "lint"},
+			files: map[string]string{
+				"foo.yaml": `
+input:
+  generate:
+    mapping: 'root.id = uuid_v4()'
+output:
+  drop: {}
+`,
+				"prod.yaml": `
+input:
+  generate:
+    huh: what
+`,
+			},
+			expectedCode: 1,
+			expectedLints: []string{
+				"field huh not recognised",
+			},
+		},
 		{
 			name: "env var missing",
 			args: []string{"benthos", "lint", tFile("foo.yaml")},
@@ -148,6 +170,177 @@ output:
 `,
 			},
 		},
+		{
+			name: "json schema field references",
+			args: []string{"benthos", "lint", "--input-schema", tFile("schema.json"), tFile("foo.yaml")},
+			files: map[string]string{
+				"schema.json": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "name": { "type": "string" },
+    "tags": { "type": "array", "items": { "type": "string" } }
+  }
+}`,
+				"foo.yaml": `
+pipeline:
+  processors:
+    - mapping: |
+        root.name = this.name.uppercase()
+        root.first_tag = this.tags.index(0)
+        root.id = this.nmae
+        root.inner = this.name.first
+output:
+  drop: {}
+`,
+			},
+			expectedCode: 1,
+			expectedLints: []string{
+				"(5,1) reference to this.nmae cannot exist: field nmae does not exist within the root of the document according to the input schema",
+				"(5,1) reference to this.name.first cannot exist: field name is of type string and therefore cannot contain field first",
+			},
+		},
+		{
+			name: "avro schema field references",
+			args: []string{"benthos", "lint", "--input-schema", tFile("schema.avsc"), tFile("foo.yaml")},
+			files: map[string]string{
+				"schema.avsc": `{
+  "type": "record",
+  "name": "user",
+  "fields": [
+    { "name": "name", "type": "string" },
+    { "name": "address", "type": ["null", { "type": "record", "name": "address", "fields": [{ "name": "city", "type": "string" }] }] }
+  ]
+}`,
+				"foo.yaml": `
+pipeline:
+  processors:
+    - mapping: |
+        root.city = this.address.city
+        root.wrapped_city = this.address.address.city
+        root.bad = this.address.town
+output:
+  drop: {}
+`,
+			},
+			expectedCode: 1,
+			expectedLints: []string{
+				"(5,1) reference to this.address.town cannot exist: field town does not exist within field address according to the input schema",
+			},
+		},
+		{
+			name: "open json schema",
+			args: []string{"benthos", "lint", "--input-schema", tFile("schema.json"), tFile("foo.yaml")},
+			files: map[string]string{
+				"schema.json": `{
+  "type": "object",
+  "properties": {
+    "name": { "type": "string" }
+  }
+}`,
+				"foo.yaml": `
+pipeline:
+  processors:
+    - mapping: |
+        root.id = this.nmae
+output:
+  drop: {}
+`,
+			},
+		},
+		{
+			name: "json schema only applied to mappings of consumed documents",
+			args: []string{"benthos", "lint", "--input-schema", tFile("schema.json"), tFile("foo.yaml")},
+			files: map[string]string{
+				"schema.json": `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "name": { "type": "string" }
+  }
+}`,
+				"foo.yaml": `
+input:
+  stdin: {}
+  processors:
+    - log:
+        message: consumed
+pipeline:
+  processors:
+    - mapping: |
+        root.id = this.nmae
+    - mapping: |
+        root.name = this.id
+output:
+  drop: {}
+  processors:
+    - mapping: |
+        root = this.nope
+`,
+			},
+			expectedCode: 1,
+			expectedLints: []string{
+				"(10,1) reference to this.nmae cannot exist: field nmae does not exist within the root of the document according to the input schema",
+			},
+		},
+		{
+			name: "json schema skipped after nested input processors",
+			args: []string{"benthos", "lint", "--input-schema", tFile("schema.json"), tFile("foo.yaml")},
+			files: map[string]string{
+				"schema.json": `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "name": { "type": "string" }
+  }
+}`,
+				"foo.yaml": `
+input:
+  broker:
+    inputs:
+      - stdin: {}
+        processors:
+          - mapping: 'root.id = this.name'
+pipeline:
+  processors:
+    - mapping: |
+        root.name = this.id
+output:
+  drop: {}
+`,
+			},
+		},
+		{
+			name: "custom rule packs",
+			args: []string{"benthos", "lint", "--rules", tFile("rules.yaml"), tFile("foo.yaml")},
+			files: map[string]string{
+				"rules.yaml": `
+rules:
+  - name: labelled_outputs
+    description: outputs must be labelled
+    type: output
+    check: this.label.or("") != ""
+  - name: short_intervals
+    level: warning
+    component: generate
+    check: this.generate.interval != "1h"
+`,
+				"foo.yaml": `
+input:
+  generate:
+    mapping: 'root.id = uuid_v4()'
+    interval: 1h
+output:
+  drop: {}
+`,
+			},
+			expectedCode: 1,
+			expectedLints: []string{
+				"(3,1) rule short_intervals failed",
+				"(7,1) rule labelled_outputs failed: outputs must be labelled",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/replay"
+)
+
+func replayCliCommand() *cli.Command {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Value: "http://localhost:4195",
+			Usage: "The address of the HTTP server of a running Benthos instance",
+		},
+		&cli.StringFlag{
+			Name:  "stream",
+			Value: "",
+			Usage: "The ID of a stream when the instance is running in streams mode",
+		},
+	}
+
+	return &cli.Command{
+		Name:  "replay",
+		Usage: "Inspect and re-inject batches captured by the replay store",
+		Description: `
+Interacts with the replay store of a running Benthos instance via its HTTP
+server, where batches that were rejected by the pipeline are captured when
+the replay section of the config is set. Once the cause of a failure has been
+fixed captured batches can be re-injected into the pipeline, and are deleted
+from the store once delivered successfully.
+
+  benthos replay list
+  benthos replay show 3c1e1b9e-0f7c-4e2a-9d0e-1a5b0e7e4b2f
+  benthos replay inject 3c1e1b9e-0f7c-4e2a-9d0e-1a5b0e7e4b2f
+  benthos replay inject --all
+  benthos replay --address http://benthos:4195 --stream foo list`[1:],
+		Flags: flags,
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List captured batches",
+				Action: func(c *cli.Context) error {
+					var res struct {
+						Entries []replay.Summary `json:"entries"`
+					}
+					if err := replayRequest(c, http.MethodGet, "", nil, &res); err != nil {
+						return replayExit(err)
+					}
+					printReplaySummaries(os.Stdout, res.Entries)
+					return nil
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "Print a captured batch as JSON",
+				ArgsUsage: "<id>",
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() != 1 {
+						return replayExit(fmt.Errorf("expected a single batch ID, got %v arguments", c.Args().Len()))
+					}
+					var res json.RawMessage
+					if err := replayRequest(c, http.MethodGet, c.Args().First(), nil, &res); err != nil {
+						return replayExit(err)
+					}
+					var buf bytes.Buffer
+					_ = json.Indent(&buf, res, "", "  ")
+					fmt.Println(buf.String())
+					return nil
+				},
+			},
+			{
+				Name:      "inject",
+				Usage:     "Re-inject captured batches into the pipeline",
+				ArgsUsage: "[<id>...]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Re-inject all captured batches",
+					},
+					&cli.DurationFlag{
+						Name:  "timeout",
+						Value: time.Second * 30,
+						Usage: "The maximum period of time to wait for each request to be delivered",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					ids := c.Args().Slice()
+					if len(ids) == 0 && !c.Bool("all") {
+						return replayExit(fmt.Errorf("expected batch IDs or the --all flag"))
+					}
+					if len(ids) == 0 {
+						ids = []string{""}
+					}
+					query := url.Values{"timeout": []string{c.Duration("timeout").String()}}
+
+					var failed bool
+					for _, id := range ids {
+						var res struct {
+							Replayed []string          `json:"replayed"`
+							Failed   map[string]string `json:"failed"`
+						}
+						if err := replayRequest(c, http.MethodPost, id, query, &res); err != nil {
+							if len(res.Failed) == 0 {
+								fmt.Fprintf(os.Stderr, "Failed to inject %v: %v\n", replayIDOrAll(id), err)
+								failed = true
+								continue
+							}
+						}
+						for _, r := range res.Replayed {
+							fmt.Printf("Injected %v\n", r)
+						}
+						for r, reason := range res.Failed {
+							fmt.Fprintf(os.Stderr, "Failed to inject %v: %v\n", r, reason)
+							failed = true
+						}
+					}
+					if failed {
+						os.Exit(1)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete captured batches without re-injecting them",
+				ArgsUsage: "<id>...",
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() == 0 {
+						return replayExit(fmt.Errorf("expected at least one batch ID"))
+					}
+					for _, id := range c.Args().Slice() {
+						if err := replayRequest(c, http.MethodDelete, id, nil, nil); err != nil {
+							return replayExit(fmt.Errorf("failed to delete %v: %w", id, err))
+						}
+						fmt.Printf("Deleted %v\n", id)
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func replayIDOrAll(id string) string {
+	if id == "" {
+		return "all batches"
+	}
+	return id
+}
+
+func replayExit(err error) error {
+	fmt.Fprintf(os.Stderr, "Replay error: %v\n", err)
+	os.Exit(1)
+	return nil
+}
+
+// replayRequest calls the replay endpoint of an instance, decoding a JSON
+// response into res when it is not nil. The response is decoded even when the
+// request fails, as failed re-injections are described by the response body.
+func replayRequest(c *cli.Context, method, id string, query url.Values, res any) error {
+	u, err := url.Parse(c.String("address"))
+	if err != nil {
+		return fmt.Errorf("failed to parse address: %w", err)
+	}
+	u.Path = path.Join("/", u.Path, c.String("stream"), "replay")
+
+	if query == nil {
+		query = url.Values{}
+	}
+	if id != "" {
+		query.Set("id", id)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(c.Context, method, u.String(), http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if res != nil && strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		if err := json.Unmarshal(body, res); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%v: %v", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func printReplaySummaries(w io.Writer, summaries []replay.Summary) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCAPTURED\tINPUT\tMESSAGES\tERROR")
+	for _, s := range summaries {
+		input := s.Label
+		if input == "" {
+			input = s.Path
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\n", s.ID, s.CapturedAt.Format(time.RFC3339), input, s.Messages, s.Error)
+	}
+	_ = tw.Flush()
+}
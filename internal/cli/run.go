@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime/debug"
+	"time"
 
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v3"
@@ -91,6 +92,11 @@ func App() *cli.App {
 			Value:   "",
 			Usage:   "a path to a configuration file",
 		},
+		&cli.StringSliceFlag{
+			Name:    "overlay",
+			Aliases: []string{"o"},
+			Usage:   "deep-merge an overlay file onto the main configuration file, can be specified multiple times where overlays are merged in order",
+		},
 		&cli.StringSliceFlag{
 			Name:    "resources",
 			Aliases: []string{"r"},
@@ -112,6 +118,11 @@ func App() *cli.App {
 			Value:   false,
 			Usage:   "EXPERIMENTAL: watch config files for changes and automatically apply them",
 		},
+		&cli.DurationFlag{
+			Name:  "secrets-refresh-interval",
+			Value: 0,
+			Usage: "a period at which secrets referenced within config files are re-resolved, where configs with changed secrets are automatically reloaded, set to zero in order to only resolve secrets when configs are read",
+		},
 	}
 
 	app := &cli.App{
@@ -218,6 +229,8 @@ variables have been resolved:
 				},
 			},
 			lintCliCommand(),
+			benchCliCommand(),
+			replayCliCommand(),
 			{
 				Name:  "streams",
 				Usage: "Run Benthos in streams mode",
@@ -243,11 +256,70 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 						Value: false,
 						Usage: "Disable the HTTP API for streams mode",
 					},
+					&cli.BoolFlag{
+						Name:  "dashboard",
+						Value: false,
+						Usage: "Serve a web dashboard at /dashboard for viewing and editing streams, requires the HTTP API",
+					},
+					&cli.StringFlag{
+						Name:  "grpc-address",
+						Value: "",
+						Usage: "An optional address to serve a gRPC management API from, which mirrors the streams, stats and ready endpoints of the HTTP API. The TLS and basic authentication settings of the HTTP server are also applied to the gRPC server",
+					},
+					&cli.StringFlag{
+						Name:  "grpc-client-ca-file",
+						Value: "",
+						Usage: "An optional path to a PEM encoded CA certificate used to verify client certificates of the gRPC management API, which enables mutual TLS and requires the http.cert_file and http.key_file fields to be set",
+					},
+					&cli.StringSliceFlag{
+						Name:  "lint-rules",
+						Usage: "A list of paths to custom lint rule packs that are enforced on stream configs, including those submitted via the HTTP API",
+					},
 					&cli.BoolFlag{
 						Name:  "prefix-stream-endpoints",
 						Value: true,
 						Usage: "Whether HTTP endpoints registered by stream configs should be prefixed with the stream ID",
 					},
+					&cli.StringFlag{
+						Name:  "store-cache",
+						Usage: "The name of a cache resource to use as a persistent store for streams created via the HTTP API",
+					},
+					&cli.StringFlag{
+						Name:  "store-cache-prefix",
+						Value: "benthos_streams_",
+						Usage: "A prefix to add to all keys written to the store cache",
+					},
+					&cli.StringFlag{
+						Name:  "store-sql-driver",
+						Usage: "The name of an SQL driver to use for a persistent store of streams created via the HTTP API",
+					},
+					&cli.StringFlag{
+						Name:  "store-sql-dsn",
+						Usage: "The data source name of the SQL store",
+					},
+					&cli.StringFlag{
+						Name:  "store-sql-table",
+						Value: "benthos_streams",
+						Usage: "The table to use for the SQL store, which is created if it does not exist",
+					},
+					&cli.StringFlag{
+						Name:  "store-etcd-endpoint",
+						Usage: "The URL of the JSON gateway of an etcd cluster to use as a persistent store of streams created via the HTTP API, e.g. http://localhost:2379, credentials can be specified as user info of the URL",
+					},
+					&cli.StringFlag{
+						Name:  "store-etcd-prefix",
+						Value: "/benthos/",
+						Usage: "A prefix to add to all keys written to the etcd store",
+					},
+					&cli.StringSliceFlag{
+						Name:  "store-singleton",
+						Usage: "A pattern of stream IDs, such as foo_*, of streams within the store that are only run by one instance at a time, which requires an SQL or etcd store",
+					},
+					&cli.DurationFlag{
+						Name:  "store-sync-interval",
+						Value: time.Second * 30,
+						Usage: "The period of time between synchronising streams from the store, set to zero in order to only read the store at start up",
+					},
 				},
 				Action: func(c *cli.Context) error {
 					os.Exit(common.RunService(c, Version, DateBuilt, true))
@@ -13,14 +13,16 @@ func CliCommand() *cli.Command {
 EXPERIMENTAL: This subcommand, and templates in general, are experimental and
 therefore are subject to change outside of major version releases.
 
-Allows linting and generating Benthos templates.
+Allows linting and testing Benthos templates.
 
   benthos template lint ./path/to/templates/...
+  benthos template test ./path/to/templates/...
 
 For more information check out the docs at:
 https://benthos.dev/docs/configuration/templating`[1:],
 		Subcommands: []*cli.Command{
 			lintCliCommand(),
+			testCliCommand(),
 		},
 	}
 }
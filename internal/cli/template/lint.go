@@ -23,7 +23,22 @@ type pathLint struct {
 	lint   docs.Lint
 }
 
-func lintFile(path string) (pathLints []pathLint) {
+// readTemplates reads the template configs at a list of paths, which are made
+// available to be nested within each other during tests. Templates that cannot
+// be read are skipped as they are reported individually.
+func readTemplates(paths []string) (confs []template.Config) {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if conf, _, err := template.ReadConfigFile(path); err == nil {
+			confs = append(confs, conf)
+		}
+	}
+	return
+}
+
+func lintFile(path string, others []template.Config) (pathLints []pathLint) {
 	conf, lints, err := template.ReadConfigFile(path)
 	if err != nil {
 		pathLints = append(pathLints, pathLint{
@@ -40,7 +55,7 @@ func lintFile(path string) (pathLints []pathLint) {
 		})
 	}
 
-	testErrors, err := conf.Test()
+	testErrors, err := conf.Test(others...)
 	if err != nil {
 		pathLints = append(pathLints, pathLint{
 			source: path,
@@ -78,12 +93,14 @@ files with the .yaml or .yml extension.`[1:],
 				fmt.Fprintf(os.Stderr, "Lint paths error: %v\n", err)
 				os.Exit(1)
 			}
+			others := readTemplates(targets)
+
 			var pathLints []pathLint
 			for _, target := range targets {
 				if target == "" {
 					continue
 				}
-				lints := lintFile(target)
+				lints := lintFile(target, others)
 				if len(lints) > 0 {
 					pathLints = append(pathLints, lints...)
 				}
@@ -0,0 +1,89 @@
+package template
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+	"github.com/benthosdev/benthos/v4/internal/template"
+)
+
+var green = color.New(color.FgGreen).SprintFunc()
+
+// testFile executes the tests of a template file and prints the results,
+// returning the number of tests executed and the number that failed.
+func testFile(path string, others []template.Config) (tests, failed int) {
+	conf, _, err := template.ReadConfigFile(path)
+	if err != nil {
+		fmt.Printf("Template '%v' %v: %v\n", path, red("failed"), err)
+		return 0, 1
+	}
+
+	results, err := conf.RunTests(others...)
+	if err != nil {
+		fmt.Printf("Template '%v' %v: %v\n", path, red("failed"), err)
+		return 0, 1
+	}
+
+	for _, res := range results {
+		tests++
+		if len(res.Failures) == 0 {
+			fmt.Printf("Template '%v' test '%v' %v\n", path, res.Name, green("succeeded"))
+			continue
+		}
+		failed++
+		fmt.Printf("Template '%v' test '%v' %v\n", path, res.Name, red("failed"))
+		for _, f := range res.Failures {
+			fmt.Printf("  %v\n", f)
+		}
+	}
+	return
+}
+
+func testCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "test",
+		Usage: "Execute the unit tests of Benthos templates",
+		Description: `
+Executes the tests defined within templates and reports the result of each
+test, exiting with a status code 1 if any test fails:
+
+  benthos template test ./templates/*.yaml
+  benthos template test ./templates/...
+
+Templates provided are able to be nested within each other during tests. If a
+path ends with '...' then Benthos will walk the target and test any files with
+the .yaml or .yml extension.`[1:],
+		Action: func(c *cli.Context) error {
+			targets, err := ifilepath.GlobsAndSuperPaths(ifs.OS(), c.Args().Slice(), "yaml", "yml")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Test paths error: %v\n", err)
+				os.Exit(1)
+			}
+			others := readTemplates(targets)
+
+			var tests, failed int
+			for _, target := range targets {
+				if target == "" {
+					continue
+				}
+
+				t, f := testFile(target, others)
+				tests += t
+				failed += f
+			}
+
+			if tests == 0 && failed == 0 {
+				fmt.Printf("%v\n", yellow("No tests were found"))
+			}
+			if failed > 0 {
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 
@@ -64,14 +65,17 @@ func (i *InputPart) UnmarshalYAML(value *yaml.Node) error {
 
 // Case contains a definition of a single Benthos config test case.
 type Case struct {
-	Name             string               `yaml:"name"`
-	Environment      map[string]string    `yaml:"environment"`
-	TargetProcessors string               `yaml:"target_processors"`
-	TargetMapping    string               `yaml:"target_mapping"`
-	Mocks            map[string]yaml.Node `yaml:"mocks"`
-	InputBatch       []InputPart          `yaml:"input_batch"`
-	InputBatches     [][]InputPart        `yaml:"input_batches"`
-	OutputBatches    [][]ConditionsMap    `yaml:"output_batches"`
+	Name             string                              `yaml:"name"`
+	Environment      map[string]string                   `yaml:"environment"`
+	TargetProcessors string                              `yaml:"target_processors"`
+	TargetMapping    string                              `yaml:"target_mapping"`
+	Mocks            map[string]yaml.Node                `yaml:"mocks"`
+	MockCaches       map[string]map[string]string        `yaml:"mock_caches"`
+	MockHTTP         []HTTPMock                          `yaml:"mock_http"`
+	InputBatch       []InputPart                         `yaml:"input_batch"`
+	InputBatches     [][]InputPart                       `yaml:"input_batches"`
+	OutputBatches    [][]ConditionsMap                   `yaml:"output_batches"`
+	OutputCaches     map[string]map[string]ConditionsMap `yaml:"output_caches"`
 
 	line int
 }
@@ -90,9 +94,12 @@ func NewCase() Case {
 		TargetProcessors: "/pipeline/processors",
 		TargetMapping:    "",
 		Mocks:            map[string]yaml.Node{},
+		MockCaches:       map[string]map[string]string{},
+		MockHTTP:         []HTTPMock{},
 		InputBatch:       []InputPart{},
 		InputBatches:     [][]InputPart{},
 		OutputBatches:    [][]ConditionsMap{},
+		OutputCaches:     map[string]map[string]ConditionsMap{},
 	}
 }
 
@@ -134,11 +141,29 @@ type ProcProvider interface {
 // ExecuteFrom executes a test case from the perspective of a given directory,
 // which is used for obtaining relative condition file imports.
 func (c *Case) ExecuteFrom(dir string, provider ProcProvider) (failures []CaseFailure, err error) {
+	return c.executeFrom(dir, provider, false)
+}
+
+func (c *Case) executeFrom(dir string, provider ProcProvider, updateGoldenFiles bool) (failures []CaseFailure, err error) {
 	var procSet []iprocessor.V1
+	var caches CacheAccessor
+	var httpMocks *httpMockTransport
 	if c.TargetMapping != "" {
 		if procSet, err = provider.ProvideBloblang(c.TargetMapping); err != nil {
 			return nil, fmt.Errorf("failed to initialise Bloblang mapping '%v': %v", c.TargetMapping, err)
 		}
+	} else if len(c.MockCaches) > 0 || len(c.MockHTTP) > 0 || len(c.OutputCaches) > 0 {
+		mProvider, ok := provider.(MockedProcProvider)
+		if !ok {
+			return nil, errors.New("mocking caches and HTTP endpoints is not supported by this test runner")
+		}
+		httpMocks = newHTTPMockTransport(c.MockHTTP)
+		if procSet, caches, err = mProvider.ProvideMocked(c.TargetProcessors, c.Environment, c.Mocks, ComponentMocks{
+			Caches:        c.MockCaches,
+			HTTPTransport: httpMocks,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to initialise processors '%v': %v", c.TargetProcessors, err)
+		}
 	} else {
 		if procSet, err = provider.Provide(c.TargetProcessors, c.Environment, c.Mocks); err != nil {
 			return nil, fmt.Errorf("failed to initialise processors '%v': %v", c.TargetProcessors, err)
@@ -202,7 +227,7 @@ func (c *Case) ExecuteFrom(dir string, provider ProcProvider) (failures []CaseFa
 				reportFailure(fmt.Sprintf("unexpected message from batch %v: %s", i, part.AsBytes()))
 				return nil
 			}
-			condErrs := expectedBatch[i2].CheckAll(dir, part)
+			condErrs := expectedBatch[i2].checkAll(dir, updateGoldenFiles, part)
 			for _, condErr := range condErrs {
 				reportFailure(fmt.Sprintf("batch %v message %v: %v", i, i2, condErr))
 			}
@@ -212,5 +237,16 @@ func (c *Case) ExecuteFrom(dir string, provider ProcProvider) (failures []CaseFa
 			return nil
 		})
 	}
+
+	if httpMocks != nil {
+		for _, reason := range httpMocks.check(dir, updateGoldenFiles) {
+			reportFailure(reason)
+		}
+	}
+	if caches != nil {
+		for _, reason := range checkCaches(dir, updateGoldenFiles, caches, c.OutputCaches) {
+			reportFailure(reason)
+		}
+	}
 	return
 }
@@ -23,6 +23,7 @@ fail the process will report the errors and exit with a status code 1.
   benthos test ./path/to/configs/...
   benthos test ./foo_configs/*.yaml ./bar_configs/*.yaml
   benthos test ./foo.yaml
+  benthos test --update --coverage ./path/to/configs/...
 
 For more information check out the docs at:
 https://benthos.dev/docs/configuration/unit_testing`[1:],
@@ -32,6 +33,16 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 				Value: "",
 				Usage: "allow components to write logs at a provided level to stdout.",
 			},
+			&cli.BoolFlag{
+				Name:  "update",
+				Value: false,
+				Usage: "write the outputs of tests to the files of golden file conditions (output_matches_file) rather than checking against them.",
+			},
+			&cli.BoolFlag{
+				Name:  "coverage",
+				Value: false,
+				Usage: "print a summary of which processors of the tested configs were exercised by tests.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if len(c.StringSlice("set")) > 0 {
@@ -44,6 +55,13 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 				fmt.Printf("Failed to resolve resource glob pattern: %v\n", err)
 				os.Exit(1)
 			}
+			var opts []RunOptFunc
+			if c.Bool("update") {
+				opts = append(opts, OptRunUpdateGoldenFiles())
+			}
+			if c.Bool("coverage") {
+				opts = append(opts, OptRunCoverage(NewCoverage()))
+			}
 			if logLevel := c.String("log"); len(logLevel) > 0 {
 				logConf := log.NewConfig()
 				logConf.LogLevel = logLevel
@@ -52,10 +70,10 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 					fmt.Printf("Failed to init logger: %v\n", err)
 					os.Exit(1)
 				}
-				if RunAll(c.Args().Slice(), "_benthos_test", true, logger, resourcesPaths) {
+				if RunAll(c.Args().Slice(), "_benthos_test", true, logger, resourcesPaths, opts...) {
 					os.Exit(0)
 				}
-			} else if RunAll(c.Args().Slice(), "_benthos_test", true, log.Noop(), resourcesPaths) {
+			} else if RunAll(c.Args().Slice(), "_benthos_test", true, log.Noop(), resourcesPaths, opts...) {
 				os.Exit(0)
 			}
 			os.Exit(1)
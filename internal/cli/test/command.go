@@ -110,10 +110,42 @@ func lintTarget(path, testSuffix string) ([]docs.Lint, error) {
 
 //------------------------------------------------------------------------------
 
+type runConfig struct {
+	updateGoldenFiles bool
+	coverage          *Coverage
+}
+
+// RunOptFunc is an optional setting for the execution of tests.
+type RunOptFunc func(*runConfig)
+
+// OptRunUpdateGoldenFiles causes golden file conditions to write the output of
+// tests to their files rather than check against them.
+func OptRunUpdateGoldenFiles() RunOptFunc {
+	return func(c *runConfig) {
+		c.updateGoldenFiles = true
+	}
+}
+
+// OptRunCoverage sets a tracker that records which processors were exercised
+// by the tests executed.
+func OptRunCoverage(cov *Coverage) RunOptFunc {
+	return func(c *runConfig) {
+		c.coverage = cov
+	}
+}
+
 // RunAll executes the test command for a slice of paths. The path can either be
 // a config file, a config files test definition file, a directory, or the
 // wildcard pattern './...'.
-func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, resourcesPaths []string) bool {
+func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, resourcesPaths []string, opts ...RunOptFunc) bool {
+	var conf runConfig
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	if conf.coverage != nil {
+		defer conf.coverage.Report(os.Stdout)
+	}
+
 	targets, err := GetTestTargets(paths, testSuffix)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to obtain test targets: %v\n", err)
@@ -146,7 +178,7 @@ func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, re
 				return false
 			}
 		}
-		if failCases, err = targets[target].Execute(target, resourcesPaths, logger); err != nil {
+		if failCases, err = targets[target].Execute(target, resourcesPaths, logger, opts...); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to execute test target '%v': %v\n", target, err)
 			return false
 		}
@@ -6,6 +6,9 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/benthosdev/benthos/v4/internal/cli/test"
 	"github.com/benthosdev/benthos/v4/internal/log"
 )
@@ -256,3 +259,42 @@ tests:
 		t.Error("Unexpected result")
 	}
 }
+
+func TestCommandRunCoverage(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"foo.yaml": `
+pipeline:
+  processors:
+  - switch:
+    - check: this.type == "a"
+      processors:
+      - mapping: 'root = "a"'
+    - processors:
+      - mapping: 'root = "b"'`,
+		"foo_benthos_test.yaml": `
+tests:
+  - name: example test
+    target_processors: '/pipeline/processors'
+    input_batch:
+      - content: '{"type":"a"}'
+    output_batches:
+      -
+        - output_matches_file: ./golden/a.txt`,
+	})
+	require.NoError(t, err)
+
+	assert.False(t, test.RunAll([]string{filepath.Join(testDir, "foo.yaml")}, "_benthos_test", true, log.Noop(), nil))
+	assert.True(t, test.RunAll([]string{filepath.Join(testDir, "foo.yaml")}, "_benthos_test", true, log.Noop(), nil, test.OptRunUpdateGoldenFiles()))
+
+	cov := test.NewCoverage()
+	assert.True(t, test.RunAll([]string{filepath.Join(testDir, "foo.yaml")}, "_benthos_test", true, log.Noop(), nil, test.OptRunCoverage(cov)))
+
+	results := cov.Results()
+	require.Len(t, results, 1)
+	assert.Equal(t, map[string]bool{
+		"root.pipeline.processors.0":                       true,
+		"root.pipeline.processors.0.switch.0.processors.0": true,
+		"root.pipeline.processors.0.switch.1.processors.0": false,
+	}, results[0].Processors)
+	assert.Equal(t, 2, results[0].Exercised())
+}
@@ -0,0 +1,281 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// ComponentMocks describes mocked dependencies of the processors of a test
+// case, which are injected into the resources from which they're constructed.
+type ComponentMocks struct {
+	// Caches that should be replaced with memory caches, keyed by the label of
+	// the cache resource, and with values being the initial contents of the
+	// cache.
+	Caches map[string]map[string]string
+
+	// A transport to be used by all HTTP clients of the processors.
+	HTTPTransport http.RoundTripper
+}
+
+// CacheAccessor provides access to the cache resources of a test case after it
+// has been executed.
+type CacheAccessor interface {
+	AccessCache(ctx context.Context, name string, fn func(cache.V1)) error
+}
+
+// MockedProcProvider is an optional extension of ProcProvider that is able to
+// construct processors with mocked caches and HTTP endpoints.
+type MockedProcProvider interface {
+	ProvideMocked(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node, componentMocks ComponentMocks) ([]iprocessor.V1, CacheAccessor, error)
+}
+
+//------------------------------------------------------------------------------
+
+// HTTPMockResponse defines the response of a mocked HTTP endpoint.
+type HTTPMockResponse struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers"`
+	Content string            `yaml:"content"`
+}
+
+// UnmarshalYAML extracts an HTTPMockResponse from a YAML node.
+func (r *HTTPMockResponse) UnmarshalYAML(value *yaml.Node) error {
+	r.Status = http.StatusOK
+
+	rawMap := map[string]yaml.Node{}
+	if err := value.Decode(&rawMap); err != nil {
+		return fmt.Errorf("line %v: %v", value.Line, err)
+	}
+	for k, v := range rawMap {
+		switch k {
+		case "status":
+			if err := v.Decode(&r.Status); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+		case "headers":
+			if err := v.Decode(&r.Headers); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+		case "content":
+			if err := v.Decode(&r.Content); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+		case "json_content":
+			if err := yamlNodeToTestString(&v, &r.Content); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+		default:
+			return fmt.Errorf("line %v: mock response field not recognised: %v", v.Line, k)
+		}
+	}
+	return nil
+}
+
+// HTTPMock defines a mocked HTTP endpoint, the response it serves and the
+// conditions that requests made to it are expected to meet.
+type HTTPMock struct {
+	URL      string           `yaml:"url"`
+	Verb     string           `yaml:"verb"`
+	Response HTTPMockResponse `yaml:"response"`
+	Requests []ConditionsMap  `yaml:"requests"`
+}
+
+// UnmarshalYAML extracts an HTTPMock from a YAML node.
+func (h *HTTPMock) UnmarshalYAML(value *yaml.Node) error {
+	type mockAlias HTTPMock
+	aliased := mockAlias{
+		Response: HTTPMockResponse{Status: http.StatusOK},
+	}
+	if err := value.Decode(&aliased); err != nil {
+		return err
+	}
+	if aliased.URL == "" {
+		return fmt.Errorf("line %v: a url must be specified for a mocked endpoint", value.Line)
+	}
+	*h = HTTPMock(aliased)
+	return nil
+}
+
+func (h *HTTPMock) matches(req *http.Request) bool {
+	if h.Verb != "" && !strings.EqualFold(h.Verb, req.Method) {
+		return false
+	}
+	return h.URL == req.URL.String()
+}
+
+// httpMockTransport serves the responses of mocked endpoints and records the
+// requests made to them.
+type httpMockTransport struct {
+	mocks []HTTPMock
+
+	mut        sync.Mutex
+	requests   [][]*message.Part
+	unexpected []string
+}
+
+func newHTTPMockTransport(mocks []HTTPMock) *httpMockTransport {
+	return &httpMockTransport{
+		mocks:    mocks,
+		requests: make([][]*message.Part, len(mocks)),
+	}
+}
+
+// requestToPart converts a request into a message so that it can be checked
+// using the same conditions as output messages.
+func requestToPart(req *http.Request) (*message.Part, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+	p := message.NewPart(body)
+	p.MetaSetMut("http_verb", req.Method)
+	p.MetaSetMut("http_url", req.URL.String())
+	for k, v := range req.Header {
+		p.MetaSetMut(k, strings.Join(v, ","))
+	}
+	return p, nil
+}
+
+func (t *httpMockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	part, err := requestToPart(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	for i, m := range t.mocks {
+		if !m.matches(req) {
+			continue
+		}
+		t.requests[i] = append(t.requests[i], part)
+
+		res := &http.Response{
+			Status:        fmt.Sprintf("%v %v", m.Response.Status, http.StatusText(m.Response.Status)),
+			StatusCode:    m.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{},
+			Body:          io.NopCloser(bytes.NewReader([]byte(m.Response.Content))),
+			ContentLength: int64(len(m.Response.Content)),
+			Request:       req,
+		}
+		for k, v := range m.Response.Headers {
+			res.Header.Set(k, v)
+		}
+		return res, nil
+	}
+
+	t.unexpected = append(t.unexpected, req.Method+" "+req.URL.String())
+	return &http.Response{
+		Status:     "404 Not Found",
+		StatusCode: http.StatusNotFound,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
+
+// check returns a failure reason for each request that did not meet the
+// conditions of a mocked endpoint.
+func (t *httpMockTransport) check(dir string, update bool) (reasons []string) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	for _, u := range t.unexpected {
+		reasons = append(reasons, fmt.Sprintf("unexpected HTTP request: %v", u))
+	}
+	for i, m := range t.mocks {
+		if len(m.Requests) == 0 {
+			continue
+		}
+		reqs := t.requests[i]
+		if lExp, lAct := len(m.Requests), len(reqs); lExp != lAct {
+			reasons = append(reasons, fmt.Sprintf("mismatch of HTTP request count to %v, expected %v, got %v", m.URL, lExp, lAct))
+		}
+		for j, req := range reqs {
+			if len(m.Requests) <= j {
+				break
+			}
+			for _, condErr := range m.Requests[j].checkAll(dir, update, req) {
+				reasons = append(reasons, fmt.Sprintf("HTTP request %v to %v: %v", j, m.URL, condErr))
+			}
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// mockCacheConfig returns the config of a memory cache with a label and initial
+// values.
+func mockCacheConfig(label string, values map[string]string) (cache.Config, error) {
+	conf := cache.NewConfig()
+
+	var node yaml.Node
+	if err := node.Encode(map[string]any{
+		"label": label,
+		"memory": map[string]any{
+			"init_values": values,
+		},
+	}); err != nil {
+		return conf, err
+	}
+	err := node.Decode(&conf)
+	return conf, err
+}
+
+// checkCaches returns a failure reason for each cache key that did not meet its
+// conditions.
+func checkCaches(dir string, update bool, accessor CacheAccessor, expected map[string]map[string]ConditionsMap) (reasons []string) {
+	labels := make([]string, 0, len(expected))
+	for k := range expected {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		keys := make([]string, 0, len(expected[label]))
+		for k := range expected[label] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		if err := accessor.AccessCache(context.Background(), label, func(c cache.V1) {
+			for _, key := range keys {
+				v, err := c.Get(context.Background(), key)
+				if err != nil {
+					reasons = append(reasons, fmt.Sprintf("cache %v key %v: %v", label, key, err))
+					continue
+				}
+				for _, condErr := range expected[label][key].checkAll(dir, update, message.NewPart(v)) {
+					reasons = append(reasons, fmt.Sprintf("cache %v key %v: %v", label, key, condErr))
+				}
+			}
+		}); err != nil {
+			reasons = append(reasons, fmt.Sprintf("cache %v: %v", label, err))
+		}
+	}
+	return
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"sync"
 
 	"github.com/nsf/jsondiff"
 	yaml "gopkg.in/yaml.v3"
@@ -75,6 +77,12 @@ func (c *ConditionsMap) UnmarshalYAML(value *yaml.Node) error {
 				return fmt.Errorf("line %v: %v", v.Line, err)
 			}
 			cond = val
+		case "output_matches_file":
+			val := OutputMatchesFileCondition("")
+			if err := v.Decode(&val); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			cond = val
 		case "file_json_equals":
 			val := FileJSONEqualsCondition("")
 			if err := v.Decode(&val); err != nil {
@@ -104,13 +112,25 @@ func (c *ConditionsMap) UnmarshalYAML(value *yaml.Node) error {
 // CheckAll checks all conditions against a message part. Conditions are
 // executed in alphabetical order.
 func (c ConditionsMap) CheckAll(dir string, part *message.Part) (errs []error) {
+	return c.checkAll(dir, false, part)
+}
+
+// checkAll checks all conditions against a message part, where golden file
+// conditions are instead updated with the message when update is true.
+func (c ConditionsMap) checkAll(dir string, update bool, part *message.Part) (errs []error) {
 	condTypes := []string{}
 	for k := range c {
 		condTypes = append(condTypes, k)
 	}
 	sort.Strings(condTypes)
 	for _, k := range condTypes {
-		if relCheck, ok := c[k].(interface {
+		if updater, ok := c[k].(interface {
+			updateFrom(string, *message.Part) error
+		}); ok && update {
+			if err := updater.updateFrom(dir, part); err != nil {
+				errs = append(errs, fmt.Errorf("%v: %v", k, err))
+			}
+		} else if relCheck, ok := c[k].(interface {
 			checkFrom(string, *message.Part) error
 		}); ok {
 			if err := relCheck.checkFrom(dir, part); err != nil {
@@ -126,7 +146,11 @@ func (c ConditionsMap) CheckAll(dir string, part *message.Part) (errs []error) {
 //------------------------------------------------------------------------------
 
 type bloblangCondition struct {
-	m *mapping.Executor
+	expr string
+	m    *mapping.Executor
+
+	relMut sync.Mutex
+	relM   map[string]*mapping.Executor
 }
 
 func parseBloblangCondition(n yaml.Node) (*bloblangCondition, error) {
@@ -138,16 +162,57 @@ func parseBloblangCondition(n yaml.Node) (*bloblangCondition, error) {
 
 	m, err := bloblang.GlobalEnvironment().NewMapping(expr)
 	if err != nil {
-		return nil, err
+		// Imports that cannot be found relative to the working directory are
+		// attempted again relative to the test definition once checked.
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		m = nil
 	}
 
-	return &bloblangCondition{m}, nil
+	return &bloblangCondition{expr: expr, m: m}, nil
 }
 
 // Check this condition against a message part.
 func (b *bloblangCondition) Check(p *message.Part) error {
+	return b.checkFrom("", p)
+}
+
+func (b *bloblangCondition) mappingFrom(dir string) (*mapping.Executor, error) {
+	if b.m != nil {
+		return b.m, nil
+	}
+
+	b.relMut.Lock()
+	defer b.relMut.Unlock()
+
+	if m, exists := b.relM[dir]; exists {
+		return m, nil
+	}
+
+	// The importer resolves relative paths from the directory of a file, and
+	// therefore we provide a (non-existent) file within the test directory.
+	m, err := bloblang.GlobalEnvironment().
+		WithImporterRelativeToFile(filepath.Join(dir, "test_definition.yaml")).
+		NewMapping(b.expr)
+	if err != nil {
+		return nil, err
+	}
+	if b.relM == nil {
+		b.relM = map[string]*mapping.Executor{}
+	}
+	b.relM[dir] = m
+	return m, nil
+}
+
+func (b *bloblangCondition) checkFrom(dir string, p *message.Part) error {
+	m, err := b.mappingFrom(dir)
+	if err != nil {
+		return err
+	}
+
 	msg := message.Batch{p}
-	res, err := b.m.QueryPart(0, msg)
+	res, err := m.QueryPart(0, msg)
 	if err != nil {
 		return err
 	}
@@ -247,6 +312,51 @@ func (c FileEqualsCondition) checkFrom(dir string, p *message.Part) error {
 
 //------------------------------------------------------------------------------
 
+// OutputMatchesFileCondition is a golden file condition that compares the
+// contents of a message against a file at the string path. When tests are run
+// with updates enabled the file is instead written with the contents of the
+// message.
+type OutputMatchesFileCondition string
+
+// Check this condition against a message part.
+func (c OutputMatchesFileCondition) Check(p *message.Part) error {
+	return c.checkFrom("", p)
+}
+
+func (c OutputMatchesFileCondition) checkFrom(dir string, p *message.Part) error {
+	relPath := filepath.Join(dir, string(c))
+
+	fileContent, err := ifs.ReadFile(ifs.OS(), relPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("golden file %v does not exist, run the tests with --update in order to create it", relPath)
+		}
+		return fmt.Errorf("failed to read golden file: %w", err)
+	}
+
+	if exp, act := string(fileContent), string(p.AsBytes()); exp != act {
+		return fmt.Errorf("content mismatch with golden file %v, run the tests with --update if this change is expected\n  expected: %v\n  received: %v", relPath, blue(exp), red(act))
+	}
+	return nil
+}
+
+func (c OutputMatchesFileCondition) updateFrom(dir string, p *message.Part) error {
+	relPath := filepath.Join(dir, string(c))
+
+	if fileContent, err := ifs.ReadFile(ifs.OS(), relPath); err == nil && bytes.Equal(fileContent, p.AsBytes()) {
+		return nil
+	}
+	if err := ifs.OS().MkdirAll(filepath.Dir(relPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create golden file directory: %w", err)
+	}
+	if err := ifs.WriteFile(ifs.OS(), relPath, p.AsBytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write golden file: %w", err)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
 // FileJSONEqualsCondition is a string condition that tests the contents of the file
 // against the contents of a message using JSON comparison and is true if the expected
 // and actual documents are both valid JSON and deeply equal.
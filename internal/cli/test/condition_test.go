@@ -77,6 +77,30 @@ tests:
 	require.EqualError(t, yaml.Unmarshal([]byte(conf), &tests), "line 3: expected query, but reached end of input")
 }
 
+func TestBloblangConditionRelativeImport(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.blobl"), []byte(`func is_foo(v) {
+  root = $v == "foo bar"
+}`), 0o644))
+
+	conf := `
+tests:
+  bloblang: |
+    import "./lib.blobl"
+    root = is_foo(content().string())`
+
+	tests := struct {
+		Tests ConditionsMap
+	}{
+		Tests: ConditionsMap{},
+	}
+
+	require.NoError(t, yaml.Unmarshal([]byte(conf), &tests))
+
+	assert.Empty(t, tests.Tests.CheckAll(dir, message.NewPart([]byte("foo bar"))))
+	assert.NotEmpty(t, tests.Tests.CheckAll(dir, message.NewPart([]byte("bar baz"))))
+}
+
 func TestConditionUnmarshalUnknownCond(t *testing.T) {
 	conf := `
 tests:
@@ -597,3 +621,32 @@ func TestFileJSONContainsCondition(t *testing.T) {
 		})
 	}
 }
+
+func TestOutputMatchesFileCondition(t *testing.T) {
+	color.NoColor = true
+
+	dir := t.TempDir()
+
+	conds := ConditionsMap{
+		"output_matches_file": OutputMatchesFileCondition("golden/foo.txt"),
+	}
+
+	errs := conds.CheckAll(dir, message.NewPart([]byte("foo bar")))
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "run the tests with --update in order to create it")
+
+	assert.Empty(t, conds.checkAll(dir, true, message.NewPart([]byte("foo bar"))))
+
+	fileBytes, err := os.ReadFile(filepath.Join(dir, "golden", "foo.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo bar", string(fileBytes))
+
+	assert.Empty(t, conds.CheckAll(dir, message.NewPart([]byte("foo bar"))))
+
+	errs = conds.CheckAll(dir, message.NewPart([]byte("bar baz")))
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "content mismatch with golden file")
+
+	assert.Empty(t, conds.checkAll(dir, true, message.NewPart([]byte("bar baz"))))
+	assert.Empty(t, conds.CheckAll(dir, message.NewPart([]byte("bar baz"))))
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+// Coverage tracks which processors of the config files targeted by tests were
+// exercised, which is determined by whether each processor constructed for a
+// test received any messages.
+type Coverage struct {
+	mut    sync.Mutex
+	locals map[string][]*metrics.Local
+}
+
+// NewCoverage returns an empty coverage tracker.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		locals: map[string][]*metrics.Local{},
+	}
+}
+
+// metricsFor returns a metrics exporter to be used by the processors of a test
+// that targets a config file.
+func (c *Coverage) metricsFor(configPath string) *metrics.Namespaced {
+	local := metrics.NewLocal()
+
+	c.mut.Lock()
+	c.locals[configPath] = append(c.locals[configPath], local)
+	c.mut.Unlock()
+
+	return metrics.NewNamespaced(local)
+}
+
+// ProcessorCoverage describes the coverage of the processors of a config file.
+type ProcessorCoverage struct {
+	ConfigPath string
+
+	// The path of each processor constructed for the tests of the config,
+	// mapped to whether it was exercised.
+	Processors map[string]bool
+}
+
+// Exercised returns the number of processors that were exercised.
+func (p ProcessorCoverage) Exercised() (n int) {
+	for _, v := range p.Processors {
+		if v {
+			n++
+		}
+	}
+	return
+}
+
+// Results returns the coverage of each config file targeted by tests, sorted
+// by the path of the config.
+func (c *Coverage) Results() []ProcessorCoverage {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var results []ProcessorCoverage
+	for configPath, locals := range c.locals {
+		res := ProcessorCoverage{
+			ConfigPath: configPath,
+			Processors: map[string]bool{},
+		}
+		for _, l := range locals {
+			for k, v := range l.GetCounters() {
+				name, tagNames, tagValues := metrics.ReverseLabelledPath(k)
+				if name != "processor_received" {
+					continue
+				}
+				for i, tn := range tagNames {
+					if tn != "path" {
+						continue
+					}
+					res.Processors[tagValues[i]] = res.Processors[tagValues[i]] || v > 0
+				}
+			}
+		}
+		results = append(results, res)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ConfigPath < results[j].ConfigPath
+	})
+	return results
+}
+
+// Report writes a human readable summary of the coverage to a writer.
+func (c *Coverage) Report(w io.Writer) {
+	results := c.Results()
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\nCoverage:\n\n")
+	for _, res := range results {
+		total, exercised := len(res.Processors), res.Exercised()
+		percent := 100.0
+		if total > 0 {
+			percent = float64(exercised) / float64(total) * 100
+		}
+		fmt.Fprintf(w, "%v: %v/%v processors exercised (%.1f%%)\n", res.ConfigPath, exercised, total, percent)
+
+		var missed []string
+		for k, v := range res.Processors {
+			if !v {
+				missed = append(missed, k)
+			}
+		}
+		sort.Strings(missed)
+		for _, m := range missed {
+			fmt.Fprintf(w, "  %v %v\n", yellow("not exercised:"), m)
+		}
+	}
+}
@@ -13,11 +13,17 @@ type Definition struct {
 }
 
 // Execute the test definition.
-func (d Definition) Execute(testFilePath string, resourcesPaths []string, logger log.Modular) ([]CaseFailure, error) {
+func (d Definition) Execute(testFilePath string, resourcesPaths []string, logger log.Modular, opts ...RunOptFunc) ([]CaseFailure, error) {
+	var conf runConfig
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
 	procsProvider := NewProcessorsProvider(
 		testFilePath,
 		OptAddResourcesPaths(resourcesPaths),
 		OptProcessorsProviderSetLogger(logger),
+		OptProcessorsProviderSetCoverage(conf.coverage),
 	)
 
 	dir := filepath.Dir(testFilePath)
@@ -25,7 +31,7 @@ func (d Definition) Execute(testFilePath string, resourcesPaths []string, logger
 	var totalFailures []CaseFailure
 	for i, c := range d.Cases {
 		cleanupEnv := setEnvironment(c.Environment)
-		failures, err := c.ExecuteFrom(dir, procsProvider)
+		failures, err := c.executeFrom(dir, procsProvider, conf.updateGoldenFiles)
 		if err != nil {
 			cleanupEnv()
 			return nil, fmt.Errorf("test case %v failed: %v", i, err)
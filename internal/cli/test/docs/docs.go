@@ -65,6 +65,32 @@ It is also possible to target processors in a separate file by prefixing the tar
 				},
 			},
 		).Map().Optional(),
+		docs.FieldString(
+			"mock_caches",
+			"An optional map of cache resource labels to key/values. Each cache is replaced with a [`memory` cache][caches.memory] prepopulated with the key/values, allowing processors that interact with caches to be tested without their dependencies. The contents of these caches can then be checked with `output_caches`.",
+			map[string]any{
+				"foo_cache": map[string]any{
+					"user-1": `{"name":"alice"}`,
+				},
+			},
+		).Map().Optional(),
+		docs.FieldObject(
+			"mock_http",
+			"An optional list of mocked HTTP endpoints. When specified all HTTP requests made by the processors of the test, such as those of the [`http` processor][processors.http], are served by these mocks instead of being sent. A request that does not match any mock receives a 404 response and fails the test.",
+		).Array().Optional().WithChildren(
+			docs.FieldString("url", "The full URL of the endpoint, including any query parameters, which must match a request exactly.", "http://example.com/users?id=1"),
+			docs.FieldString("verb", "An optional HTTP verb that a request must have in order to match the endpoint.", "POST").HasDefault(""),
+			docs.FieldObject("response", "The response served by the endpoint.").WithChildren(
+				docs.FieldInt("status", "The status code of the response.").HasDefault(200),
+				docs.FieldString("headers", "A map of headers to add to the response.").Map().Optional(),
+				docs.FieldString("content", "The raw content of the response body.").HasDefault(""),
+				docs.FieldAnything("json_content", "Sets the body of the response to a JSON document matching the structure of the value.", map[string]any{"id": 1}).Optional(),
+			),
+			docs.FieldObject(
+				"requests",
+				"An optional list of conditions that requests made to the endpoint are expected to meet, in the order they were made. When specified the number of requests must match the number of elements. The body of a request is checked as the content of a message, its verb and URL as the metadata keys `http_verb` and `http_url`, and its headers as metadata keys of the same name.",
+			).Array().Optional().WithChildren(outputConditionFields()...),
+		),
 		docs.FieldObject(
 			"input_batch", "Define a batch of messages to feed into your test, specify either an `input_batch` or a series of `input_batches`.",
 		).Array().Optional().WithChildren(
@@ -99,48 +125,68 @@ It is also possible to target processors in a separate file by prefixing the tar
 		),
 		docs.FieldObject(
 			"output_batches", "List of output batches.",
-		).ArrayOfArrays().Optional().WithChildren(
-			docs.FieldString("content", "The raw content of the input message.").HasDefault(""),
-			docs.FieldAnything("metadata", "A map of metadata key/values to add to the input message.").Map().Optional(),
-			docs.FieldString(
-				`bloblang`,
-				"Executes a Bloblang mapping on the output message, if the result is anything other than a boolean equalling `true` the test fails.",
-				"this.age > 10 && @foo.length() > 0",
-			).Optional(),
-			docs.FieldString(`content_equals`, "Checks the full raw contents of a message against a value.").Optional(),
-			docs.FieldString(`content_matches`, "Checks whether the full raw contents of a message matches a regular expression (re2).", "^foo [a-z]+ bar$").Optional(),
-			docs.FieldAnything(
-				`metadata_equals`,
-				"Checks a map of metadata keys to values against the metadata stored in the message. If there is a value mismatch between a key of the condition versus the message metadata this condition will fail.",
-				map[string]any{
-					"example_key": "example metadata value",
+		).ArrayOfArrays().Optional().WithChildren(outputConditionFields()...),
+		docs.FieldObject(
+			"output_caches",
+			"An optional map of mocked caches, specified with `mock_caches`, to the keys that are expected to be written to them once the test has been executed. Each key is mapped to conditions that are checked against its value, where the test fails if the key does not exist.",
+			map[string]any{
+				"foo_cache": map[string]any{
+					"user-1": map[string]any{
+						"json_equals": map[string]any{"name": "bob"},
+					},
 				},
-			).Map().Optional(),
-			docs.FieldString(
-				`file_equals`,
-				"Checks that the contents of a message matches the contents of a file. The path of the file should be relative to the path of the test file.",
-				"./foo/bar.txt",
-			).Optional(),
-			docs.FieldString(
-				`file_json_equals`,
-				"Checks that both the message and the file contents are valid JSON documents, and that they are structurally equivalent. Will ignore formatting and ordering differences. The path of the file should be relative to the path of the test file.",
-				"./foo/bar.json",
-			).Optional(),
-			docs.FieldAnything(
-				`json_equals`,
-				"Checks that both the message and the condition are valid JSON documents, and that they are structurally equivalent. Will ignore formatting and ordering differences.",
-				map[string]any{"key": "value"},
-			).Optional(),
-			docs.FieldAnything(
-				`json_contains`,
-				"Checks that both the message and the condition are valid JSON documents, and that the message is a superset of the condition.",
-				map[string]any{"key": "value"},
-			).Optional(),
-			docs.FieldString(
-				`file_json_contains`,
-				"Checks that both the message and the file contents are valid JSON documents, and that the message is a superset of the condition. Will ignore formatting and ordering differences. The path of the file should be relative to the path of the test file.",
-				"./foo/bar.json",
-			).Optional(),
-		),
+			},
+		).Map().Optional(),
 	)
 }
+
+func outputConditionFields() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldString("content", "The raw content of the input message.").HasDefault(""),
+		docs.FieldAnything("metadata", "A map of metadata key/values to add to the input message.").Map().Optional(),
+		docs.FieldString(
+			`bloblang`,
+			"Executes a Bloblang mapping on the output message, if the result is anything other than a boolean equalling `true` the test fails.",
+			"this.age > 10 && @foo.length() > 0",
+		).Optional(),
+		docs.FieldString(`content_equals`, "Checks the full raw contents of a message against a value.").Optional(),
+		docs.FieldString(`content_matches`, "Checks whether the full raw contents of a message matches a regular expression (re2).", "^foo [a-z]+ bar$").Optional(),
+		docs.FieldAnything(
+			`metadata_equals`,
+			"Checks a map of metadata keys to values against the metadata stored in the message. If there is a value mismatch between a key of the condition versus the message metadata this condition will fail.",
+			map[string]any{
+				"example_key": "example metadata value",
+			},
+		).Map().Optional(),
+		docs.FieldString(
+			`file_equals`,
+			"Checks that the contents of a message matches the contents of a file. The path of the file should be relative to the path of the test file.",
+			"./foo/bar.txt",
+		).Optional(),
+		docs.FieldString(
+			`output_matches_file`,
+			"Checks that the contents of a message matches the contents of a golden file, where the file is written with the contents of the message instead when tests are executed with the `--update` flag. The path of the file should be relative to the path of the test file.",
+			"./golden/foo.txt",
+		).Optional(),
+		docs.FieldString(
+			`file_json_equals`,
+			"Checks that both the message and the file contents are valid JSON documents, and that they are structurally equivalent. Will ignore formatting and ordering differences. The path of the file should be relative to the path of the test file.",
+			"./foo/bar.json",
+		).Optional(),
+		docs.FieldAnything(
+			`json_equals`,
+			"Checks that both the message and the condition are valid JSON documents, and that they are structurally equivalent. Will ignore formatting and ordering differences.",
+			map[string]any{"key": "value"},
+		).Optional(),
+		docs.FieldAnything(
+			`json_contains`,
+			"Checks that both the message and the condition are valid JSON documents, and that the message is a superset of the condition.",
+			map[string]any{"key": "value"},
+		).Optional(),
+		docs.FieldString(
+			`file_json_contains`,
+			"Checks that both the message and the file contents are valid JSON documents, and that the message is a superset of the condition. Will ignore formatting and ordering differences. The path of the file should be relative to the path of the test file.",
+			"./foo/bar.json",
+		).Optional(),
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/Jeffail/gabs/v2"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/docs"
@@ -26,6 +29,12 @@ import (
 type cachedConfig struct {
 	mgr   manager.ResourceConfig
 	procs []processor.Config
+
+	// The config file and path within it of the processors, which are used for
+	// annotating their observability.
+	configPath string
+	procsPath  []string
+	single     bool
 }
 
 // ProcessorsProvider consumes a Benthos config and, given a JSON Pointer,
@@ -34,6 +43,7 @@ type ProcessorsProvider struct {
 	targetPath     string
 	resourcesPaths []string
 	cachedConfigs  map[string]cachedConfig
+	coverage       *Coverage
 
 	logger log.Modular
 }
@@ -65,6 +75,14 @@ func OptProcessorsProviderSetLogger(logger log.Modular) func(*ProcessorsProvider
 	}
 }
 
+// OptProcessorsProviderSetCoverage sets a coverage tracker that records which
+// of the provided processors were exercised.
+func OptProcessorsProviderSetCoverage(c *Coverage) func(*ProcessorsProvider) {
+	return func(p *ProcessorsProvider) {
+		p.coverage = c
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // Provide attempts to extract an array of processors from a Benthos config.
@@ -79,6 +97,49 @@ func (p *ProcessorsProvider) Provide(jsonPtr string, environment map[string]stri
 	return p.initProcs(confs)
 }
 
+// ProvideMocked attempts to extract an array of processors from a Benthos
+// config in the same way as Provide, where cache resources are replaced with
+// mocked memory caches and all HTTP clients use a mocked transport. The
+// resources of the processors are also returned so that caches can be
+// inspected once the processors have been executed.
+func (p *ProcessorsProvider) ProvideMocked(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node, componentMocks ComponentMocks) ([]processor.V1, CacheAccessor, error) {
+	confs, err := p.getConfs(jsonPtr, environment, mocks)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	labels := make([]string, 0, len(componentMocks.Caches))
+	for k := range componentMocks.Caches {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+
+	// Copy the cache resources as the cached config must remain untouched.
+	caches := append([]cache.Config(nil), confs.mgr.ResourceCaches...)
+	for _, label := range labels {
+		mockConf, err := mockCacheConfig(label, componentMocks.Caches[label])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create mock cache '%v': %w", label, err)
+		}
+		replaced := false
+		for i, c := range caches {
+			if c.Label == label {
+				caches[i], replaced = mockConf, true
+			}
+		}
+		if !replaced {
+			caches = append(caches, mockConf)
+		}
+	}
+	confs.mgr.ResourceCaches = caches
+
+	var opts []manager.OptFunc
+	if componentMocks.HTTPTransport != nil {
+		opts = append(opts, manager.OptSetHTTPTransport(componentMocks.HTTPTransport))
+	}
+	return p.initMockedProcs(confs, opts...)
+}
+
 // ProvideBloblang attempts to parse a Bloblang mapping and returns a processor
 // slice that executes it.
 func (p *ProcessorsProvider) ProvideBloblang(pathStr string) ([]processor.V1, error) {
@@ -143,18 +204,32 @@ func (b *bloblangProc) Close(context.Context) error {
 //------------------------------------------------------------------------------
 
 func (p *ProcessorsProvider) initProcs(confs cachedConfig) ([]processor.V1, error) {
-	mgr, err := manager.New(confs.mgr, manager.OptSetLogger(p.logger))
+	procs, _, err := p.initMockedProcs(confs)
+	return procs, err
+}
+
+func (p *ProcessorsProvider) initMockedProcs(confs cachedConfig, opts ...manager.OptFunc) ([]processor.V1, CacheAccessor, error) {
+	opts = append([]manager.OptFunc{manager.OptSetLogger(p.logger)}, opts...)
+	if p.coverage != nil {
+		opts = append(opts, manager.OptSetMetrics(p.coverage.metricsFor(confs.configPath)))
+	}
+
+	mgr, err := manager.New(confs.mgr, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialise resources: %v", err)
+		return nil, nil, fmt.Errorf("failed to initialise resources: %v", err)
 	}
 
 	procs := make([]processor.V1, len(confs.procs))
 	for i, conf := range confs.procs {
-		if procs[i], err = mgr.NewProcessor(conf); err != nil {
-			return nil, fmt.Errorf("failed to initialise processor index '%v': %v", i, err)
+		procPath := confs.procsPath
+		if !confs.single {
+			procPath = append(procPath[:len(procPath):len(procPath)], strconv.Itoa(i))
+		}
+		if procs[i], err = mgr.IntoPath(procPath...).NewProcessor(conf); err != nil {
+			return nil, nil, fmt.Errorf("failed to initialise processor index '%v': %v", i, err)
 		}
 	}
-	return procs, nil
+	return procs, mgr, nil
 }
 
 func confTargetID(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) string {
@@ -359,6 +434,7 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 		return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
 	}
 
+	confs.configPath, confs.procsPath = targetPath, pathSlice
 	if root.Kind == yaml.SequenceNode {
 		if err = root.Decode(&confs.procs); err != nil {
 			return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
@@ -369,6 +445,7 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 			return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
 		}
 		confs.procs = append(confs.procs, procConf)
+		confs.single = true
 	}
 
 	p.cachedConfigs[cacheKey] = confs
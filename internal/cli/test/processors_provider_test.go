@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -408,3 +409,135 @@ pipeline:
 	_, err = provider.Provide("/pipeline/processors", nil, nil)
 	require.EqualError(t, err, "failed to initialise resources: cache resource label 'barcache' collides with a previously defined resource")
 }
+
+func TestProcessorsProviderComponentMocks(t *testing.T) {
+	files := map[string]string{
+		"config1.yaml": `
+cache_resources:
+  - label: users
+    memory:
+      init_values:
+        "2": this should be replaced
+
+pipeline:
+  processors:
+    - branch:
+        request_map: 'root = this'
+        processors:
+          - http:
+              url: http://example.com/users/${! this.id }
+              verb: GET
+        result_map: 'root.user = this'
+    - cache:
+        resource: users
+        operator: set
+        key: ${! this.id }
+        value: ${! this.user.name }
+    - cache:
+        resource: extra
+        operator: get
+        key: foo
+`,
+	}
+
+	testDir, err := initTestFiles(t, files)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		testCase string
+		failures []string
+	}{
+		{
+			name: "all pass",
+			testCase: `
+name: all pass
+mock_caches:
+  users: {}
+  extra:
+    foo: '{"id":1}'
+mock_http:
+  - url: http://example.com/users/1
+    verb: GET
+    response:
+      json_content:
+        name: alice
+    requests:
+      - metadata_equals:
+          http_verb: GET
+input_batch:
+  - json_content:
+      id: 1
+output_batches:
+  - - json_equals:
+        id: 1
+output_caches:
+  users:
+    "1":
+      content_equals: alice
+`,
+		},
+		{
+			name: "failed assertions",
+			testCase: `
+name: failed assertions
+mock_caches:
+  users: {}
+  extra:
+    foo: '{"id":2}'
+mock_http:
+  - url: http://example.com/users/1
+    response:
+      status: 200
+      content: '{"name":"alice"}'
+    requests:
+      - metadata_equals:
+          http_verb: POST
+      - content_equals: nope
+input_batch:
+  - json_content:
+      id: 2
+output_caches:
+  users:
+    "1":
+      content_equals: alice
+    "2":
+      content_equals: alice
+`,
+			failures: []string{
+				"unexpected HTTP request: GET http://example.com/users/2",
+				"mismatch of HTTP request count to http://example.com/users/1, expected 2, got 0",
+				"cache users key 1: key does not exist",
+				"cache users key 2: content_equals: content mismatch",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var c test.Case
+			require.NoError(t, yaml.Unmarshal([]byte(tt.testCase), &c))
+
+			failures, err := c.ExecuteFrom(testDir, test.NewProcessorsProvider(filepath.Join(testDir, "config1.yaml")))
+			require.NoError(t, err)
+
+			var reasons []string
+			for _, f := range failures {
+				reasons = append(reasons, f.Reason)
+			}
+			for _, exp := range tt.failures {
+				found := false
+				for _, r := range reasons {
+					if strings.Contains(r, exp) {
+						found = true
+					}
+				}
+				assert.True(t, found, "expected failure %q in %v", exp, reasons)
+			}
+			if len(tt.failures) == 0 {
+				assert.Empty(t, reasons)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// The escape character of the delim-escaped codec.
+const delimEscapeChar = '\\'
+
+// ErrFrameTooLarge is returned by framed reader codecs when a frame exceeds the
+// configured maximum size. Since the boundaries of subsequent frames cannot be
+// trusted once this happens the reader is not able to recover.
+var ErrFrameTooLarge = errors.New("frame exceeds the maximum message size")
+
+type framedReader struct {
+	next      func() ([]byte, error)
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newFramedReader(r io.ReadCloser, next func() ([]byte, error), ackFn ReaderAckFn) *framedReader {
+	return &framedReader{
+		next:      next,
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}
+}
+
+func (a *framedReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+func (a *framedReader) Next(ctx context.Context) ([]*message.Part, ReaderAckFn, error) {
+	frame, err := a.next()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err == nil {
+		a.pending++
+		return []*message.Part{message.NewPart(frame)}, a.ack, nil
+	}
+
+	if errors.Is(err, io.EOF) {
+		a.finished = true
+	} else {
+		_ = a.sourceAck(ctx, err)
+	}
+	return nil, nil, err
+}
+
+func (a *framedReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
+func lengthPrefixDecoder(format string) (func(r *bufio.Reader) (uint64, error), error) {
+	fixed := func(size int, fn func(b []byte) uint64) func(r *bufio.Reader) (uint64, error) {
+		return func(r *bufio.Reader) (uint64, error) {
+			b := make([]byte, size)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return 0, err
+			}
+			return fn(b), nil
+		}
+	}
+
+	switch format {
+	case "uvarint":
+		return func(r *bufio.Reader) (uint64, error) {
+			return binary.ReadUvarint(r)
+		}, nil
+	case "uint8":
+		return fixed(1, func(b []byte) uint64 { return uint64(b[0]) }), nil
+	case "uint16be":
+		return fixed(2, func(b []byte) uint64 { return uint64(binary.BigEndian.Uint16(b)) }), nil
+	case "uint16le":
+		return fixed(2, func(b []byte) uint64 { return uint64(binary.LittleEndian.Uint16(b)) }), nil
+	case "uint32be":
+		return fixed(4, func(b []byte) uint64 { return uint64(binary.BigEndian.Uint32(b)) }), nil
+	case "uint32le":
+		return fixed(4, func(b []byte) uint64 { return uint64(binary.LittleEndian.Uint32(b)) }), nil
+	case "uint64be":
+		return fixed(8, binary.BigEndian.Uint64), nil
+	case "uint64le":
+		return fixed(8, binary.LittleEndian.Uint64), nil
+	}
+	return nil, fmt.Errorf("length prefix format not recognised: %v", format)
+}
+
+// newLengthPrefixedReader consumes frames where each frame is preceded by its
+// length encoded in the given format. Frames that exceed the max scan token
+// size result in an error rather than being truncated.
+func newLengthPrefixedReader(conf ReaderConfig, r io.ReadCloser, format string, ackFn ReaderAckFn) (Reader, error) {
+	readLength, err := lengthPrefixDecoder(format)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize := uint64(conf.MaxScanTokenSize)
+	buf := bufio.NewReader(r)
+
+	return newFramedReader(r, func() ([]byte, error) {
+		length, err := readLength(buf)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if _, peekErr := buf.Peek(1); errors.Is(peekErr, io.EOF) {
+					return nil, io.EOF
+				}
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("failed to read frame length: %w", io.ErrUnexpectedEOF)
+			}
+			return nil, fmt.Errorf("failed to read frame length: %w", err)
+		}
+		if length > maxSize {
+			return nil, fmt.Errorf("%w: frame of %v bytes exceeds the maximum of %v bytes", ErrFrameTooLarge, length, maxSize)
+		}
+
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(buf, frame); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("failed to read frame: %w", err)
+		}
+		return frame, nil
+	}, ackFn), nil
+}
+
+//------------------------------------------------------------------------------
+
+// newEscapedDelimReader consumes segments divided by a custom delimiter, where
+// occurrences of the delimiter that are preceded by a backslash are treated as
+// part of the segment. The escape character is removed from both escaped
+// delimiters and escaped backslashes, and all other escape sequences are left
+// untouched.
+func newEscapedDelimReader(conf ReaderConfig, r io.ReadCloser, delim string, ackFn ReaderAckFn) (Reader, error) {
+	scanner := bufio.NewScanner(r)
+	if conf.MaxScanTokenSize != bufio.MaxScanTokenSize {
+		scanner.Buffer([]byte{}, conf.MaxScanTokenSize)
+	}
+
+	delimBytes := []byte(delim)
+
+	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+
+		for i := 0; i < len(data); i++ {
+			if data[i] == delimEscapeChar {
+				rest := data[i+1:]
+				if !atEOF && len(rest) < len(delimBytes) && bytes.HasPrefix(delimBytes, rest) {
+					// Request more data to determine what is escaped.
+					return 0, nil, nil
+				}
+				if len(rest) > 0 && rest[0] == delimEscapeChar {
+					i++
+				} else if bytes.HasPrefix(rest, delimBytes) {
+					i += len(delimBytes)
+				}
+				continue
+			}
+			if bytes.HasPrefix(data[i:], delimBytes) {
+				return i + len(delimBytes), data[0:i], nil
+			}
+		}
+
+		// If we're at EOF, we have a final, non-terminated segment. Return it.
+		if atEOF {
+			return len(data), data, nil
+		}
+
+		// Request more data.
+		return 0, nil, nil
+	})
+
+	return newFramedReader(r, func() ([]byte, error) {
+		if !scanner.Scan() {
+			err := scanner.Err()
+			if err == nil {
+				return nil, io.EOF
+			}
+			if errors.Is(err, bufio.ErrTooLong) {
+				err = fmt.Errorf("%w: segment exceeds the maximum of %v bytes", ErrFrameTooLarge, conf.MaxScanTokenSize)
+			}
+			return nil, err
+		}
+		return unescapeDelim(scanner.Bytes(), delimBytes), nil
+	}, ackFn), nil
+}
+
+// unescapeDelim returns a copy of a segment with escaped delimiters and escaped
+// escape characters replaced with their literal values.
+func unescapeDelim(b, delim []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == delimEscapeChar && i+1 < len(b) {
+			if b[i+1] == delimEscapeChar {
+				out = append(out, delimEscapeChar)
+				i++
+				continue
+			}
+			if bytes.HasPrefix(b[i+1:], delim) {
+				out = append(out, delim...)
+				i += len(delim)
+				continue
+			}
+		}
+		out = append(out, b[i])
+	}
+	return out
+}
@@ -36,8 +36,10 @@ var ReaderDocs = docs.FieldString(
 	"csv:x", "Consume structured rows as values separated by a custom delimiter, the first row must be a header row. The custom delimiter must be a single character, e.g. the codec `\"csv:\\t\"` would consume a tab delimited file.",
 	"csv-safe", "Consume structured rows like `csv`, but sends messages with empty maps on failure to parse. Includes row number and parsing errors (if any) in the message's metadata.",
 	"delim:x", "Consume the file in segments divided by a custom delimiter.",
+	"delim-escaped:x", "Consume the file in segments divided by a custom delimiter, where occurrences of the delimiter preceded by a backslash are treated as part of a segment. The backslash is removed from escaped delimiters, and a double backslash is consumed as a single backslash. Segments that exceed the maximum message size result in an error.",
 	"gzip", "Decompress a gzip file, this codec should precede another codec, e.g. `gzip/all-bytes`, `gzip/tar`, `gzip/csv`, etc.",
 	"pgzip", "Decompress a gzip file in parallel, this codec should precede another codec, e.g. `pgzip/all-bytes`, `pgzip/tar`, `pgzip/csv`, etc.",
+	"length-prefixed:x", "Consume frames where each frame is preceded by its length in bytes, where x is the encoding of the length and is one of `uvarint`, `uint8`, `uint16be`, `uint16le`, `uint32be`, `uint32le`, `uint64be` or `uint64le`. Frames that exceed the maximum message size result in an error rather than being truncated.",
 	"lines", "Consume the file in segments divided by linebreaks.",
 	"multipart", "Consumes the output of another codec and batches messages together. A batch ends when an empty message is consumed. For example, the codec `lines/multipart` could be used to consume multipart messages where an empty line indicates the end of each batch.",
 	"regex:(?m)^\\d\\d:\\d\\d:\\d\\d", "Consume the file in segments divided by regular expression.",
@@ -278,6 +280,24 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 			return newCustomDelimReader(conf, r, by, fn)
 		}, true, nil
 	}
+	if strings.HasPrefix(codec, "delim-escaped:") {
+		by := strings.TrimPrefix(codec, "delim-escaped:")
+		if by == "" {
+			return nil, false, errors.New("escaped delimiter codec requires a non-empty delimiter")
+		}
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newEscapedDelimReader(conf, r, by, fn)
+		}, true, nil
+	}
+	if strings.HasPrefix(codec, "length-prefixed:") {
+		format := strings.TrimPrefix(codec, "length-prefixed:")
+		if _, err := lengthPrefixDecoder(format); err != nil {
+			return nil, false, err
+		}
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newLengthPrefixedReader(conf, r, format, fn)
+		}, true, nil
+	}
 	if strings.HasPrefix(codec, "csv:") {
 		by := strings.TrimPrefix(codec, "csv:")
 		if by == "" {
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -904,3 +905,111 @@ func TestRegexpSplitReader(t *testing.T) {
 	data = []byte("")
 	testReaderSuite(t, "regex:split", "", data)
 }
+
+func TestEscapedDelimReader(t *testing.T) {
+	data := []byte(`fooXbar\XbazXqu\\Xquz`)
+	testReaderSuite(t, "delim-escaped:X", "", data, "foo", "barXbaz", `qu\`, "quz")
+
+	data = []byte(`foo<>bar\<>baz<>\n\<\\<>`)
+	testReaderSuite(t, "delim-escaped:<>", "", data, "foo", "bar<>baz", `\n\<\`)
+
+	data = []byte("")
+	testReaderSuite(t, "delim-escaped:X", "", data)
+}
+
+func TestLengthPrefixedReader(t *testing.T) {
+	frames := []string{"foo", "", "hello world", "bar"}
+
+	for _, test := range []struct {
+		format string
+		encode func(b []byte, l int) []byte
+	}{
+		{format: "uvarint", encode: func(b []byte, l int) []byte { return binary.AppendUvarint(b, uint64(l)) }},
+		{format: "uint8", encode: func(b []byte, l int) []byte { return append(b, byte(l)) }},
+		{format: "uint16be", encode: func(b []byte, l int) []byte { return binary.BigEndian.AppendUint16(b, uint16(l)) }},
+		{format: "uint16le", encode: func(b []byte, l int) []byte { return binary.LittleEndian.AppendUint16(b, uint16(l)) }},
+		{format: "uint32be", encode: func(b []byte, l int) []byte { return binary.BigEndian.AppendUint32(b, uint32(l)) }},
+		{format: "uint32le", encode: func(b []byte, l int) []byte { return binary.LittleEndian.AppendUint32(b, uint32(l)) }},
+		{format: "uint64be", encode: func(b []byte, l int) []byte { return binary.BigEndian.AppendUint64(b, uint64(l)) }},
+		{format: "uint64le", encode: func(b []byte, l int) []byte { return binary.LittleEndian.AppendUint64(b, uint64(l)) }},
+	} {
+		test := test
+		t.Run(test.format, func(t *testing.T) {
+			var data []byte
+			for _, f := range frames {
+				data = test.encode(data, len(f))
+				data = append(data, f...)
+			}
+			testReaderSuite(t, "length-prefixed:"+test.format, "", data, frames...)
+		})
+	}
+}
+
+func TestLengthPrefixedReaderErrors(t *testing.T) {
+	_, err := GetReader("length-prefixed:nope", NewReaderConfig())
+	require.Error(t, err)
+
+	readAll := func(t *testing.T, conf ReaderConfig, data []byte) ([]string, error) {
+		t.Helper()
+
+		ctor, err := GetReader("length-prefixed:uint16be", conf)
+		require.NoError(t, err)
+
+		var ackErr error
+		r, err := ctor("", noopCloser{bytes.NewReader(data), false}, func(ctx context.Context, err error) error {
+			ackErr = err
+			return nil
+		})
+		require.NoError(t, err)
+		defer r.Close(context.Background())
+
+		var frames []string
+		for {
+			p, _, err := r.Next(context.Background())
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return frames, nil
+				}
+				assert.Equal(t, err, ackErr)
+				return frames, err
+			}
+			frames = append(frames, string(p[0].AsBytes()))
+		}
+	}
+
+	conf := NewReaderConfig()
+	conf.MaxScanTokenSize = 5
+
+	frames, err := readAll(t, conf, []byte("\x00\x03foo\x00\x06foobar\x00\x03baz"))
+	assert.Equal(t, []string{"foo"}, frames)
+	require.ErrorIs(t, err, ErrFrameTooLarge)
+
+	frames, err = readAll(t, NewReaderConfig(), []byte("\x00\x03foo\x00\x06foo"))
+	assert.Equal(t, []string{"foo"}, frames)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	frames, err = readAll(t, NewReaderConfig(), []byte("\x00\x03foo\x00"))
+	assert.Equal(t, []string{"foo"}, frames)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestEscapedDelimReaderTooLarge(t *testing.T) {
+	conf := NewReaderConfig()
+	conf.MaxScanTokenSize = 5
+
+	ctor, err := GetReader("delim-escaped:X", conf)
+	require.NoError(t, err)
+
+	r, err := ctor("", noopCloser{bytes.NewReader([]byte("fooXfoobarbazXbar")), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	p, _, err := r.Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(p[0].AsBytes()))
+
+	_, _, err = r.Next(context.Background())
+	require.ErrorIs(t, err, ErrFrameTooLarge)
+	require.NoError(t, r.Close(context.Background()))
+}
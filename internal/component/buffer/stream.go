@@ -201,8 +201,8 @@ func (m *Stream) outputLoop() {
 				if !open {
 					return
 				}
-				mLatency.Timing(time.Since(startedAt).Nanoseconds())
-				tracing.FinishSpans(msg)
+				metrics.TimingWithContext(mLatency, msg.Get(0).GetContext(), time.Since(startedAt).Nanoseconds())
+				tracing.CompleteSpans(m.tracer, "", msg, res)
 				if ackErr := ackFunc(closeNowCtx, res); ackErr != nil {
 					if ackErr != component.ErrTypeClosed {
 						m.log.Errorf("Failed to ack buffer message: %v\n", ackErr)
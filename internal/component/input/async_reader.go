@@ -10,8 +10,13 @@ import (
 	"github.com/cenkalti/backoff/v4"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/events"
+	"github.com/benthosdev/benthos/v4/internal/health"
+	"github.com/benthosdev/benthos/v4/internal/lineage"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/internal/tap"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
@@ -25,7 +30,8 @@ type AsyncReader struct {
 	typeStr string
 	reader  Async
 
-	mgr component.Observability
+	mgr    component.Observability
+	health *health.Component
 
 	transactions chan message.Transaction
 	shutSig      *shutdown.Signaller
@@ -60,6 +66,7 @@ func NewAsyncReader(
 	for _, opt := range opts {
 		opt(rdr)
 	}
+	rdr.health = health.FromManager(mgr, "input", typeStr, rdr.Connected)
 
 	go rdr.loop()
 	return rdr, nil
@@ -86,8 +93,18 @@ func (r *AsyncReader) loop() {
 		mLatency    = r.mgr.Metrics().GetTimer("input_latency_ns")
 
 		traceName = "input_" + r.typeStr
+
+		evts = events.FromManager(r.mgr)
+		lin  = lineage.FromManager(r.mgr)
+		tp   = tap.FromManager(r.mgr, "input", r.typeStr)
 	)
 
+	// The label of the input is used by tracing samplers to scope rules.
+	var label string
+	if l, ok := r.mgr.(interface{ Label() string }); ok {
+		label = l.Label()
+	}
+
 	closeAtLeisureCtx, calDone := r.shutSig.CloseAtLeisureCtx(context.Background())
 	defer calDone()
 
@@ -98,6 +115,8 @@ func (r *AsyncReader) loop() {
 		_ = r.reader.Close(context.Background())
 
 		atomic.StoreInt32(&r.connected, 0)
+		r.health.Deregister()
+		tp.Deregister()
 
 		close(r.transactions)
 		r.shutSig.ShutdownComplete()
@@ -120,11 +139,13 @@ func (r *AsyncReader) loop() {
 					return false
 				}
 				r.mgr.Logger().Errorf("Failed to connect to %v: %v\n", r.typeStr, err)
+				r.health.SetError(err)
 				mFailedConn.Incr(1)
 
 				nextBoff := r.connBackoff.NextBackOff()
 				if nextBoff == backoff.Stop {
 					r.mgr.Logger().Errorf("Maximum number of connection attempt retries has been met, gracefully terminating input %v", r.typeStr)
+					evts.Emit(events.New(events.TypeRetriesExhausted, "Maximum number of connection attempt retries has been met, gracefully terminating input "+r.typeStr))
 					return false
 				}
 
@@ -152,6 +173,7 @@ func (r *AsyncReader) loop() {
 		if errors.Is(err, component.ErrNotConnected) {
 			mLostConn.Incr(1)
 			atomic.StoreInt32(&r.connected, 0)
+			evts.Emit(events.New(events.TypeConnectionLost, "Input "+r.typeStr+" lost connection"))
 
 			// Continue to try to reconnect while still active.
 			if !initConnection() {
@@ -170,10 +192,12 @@ func (r *AsyncReader) loop() {
 		if err != nil || len(msg) == 0 {
 			if err != nil && err != component.ErrTimeout && err != component.ErrNotConnected {
 				r.mgr.Logger().Errorf("Failed to read message: %v\n", err)
+				r.health.SetError(err)
 			}
 			nextBoff := r.readBackoff.NextBackOff()
 			if nextBoff == backoff.Stop {
 				r.mgr.Logger().Errorf("Maximum number of read attempt retries has been met, gracefully terminating input %v", r.typeStr)
+				evts.Emit(events.New(events.TypeRetriesExhausted, "Maximum number of read attempt retries has been met, gracefully terminating input "+r.typeStr))
 				return
 			}
 			select {
@@ -184,7 +208,9 @@ func (r *AsyncReader) loop() {
 			continue
 		} else {
 			r.readBackoff.Reset()
+			r.health.MarkSuccess()
 			mRcvd.Incr(int64(msg.Len()))
+			tp.Observe(msg)
 			r.mgr.Logger().Tracef("Consumed %v messages from '%v'.\n", msg.Len(), r.typeStr)
 		}
 
@@ -192,6 +218,7 @@ func (r *AsyncReader) loop() {
 
 		resChan := make(chan error, 1)
 		tracing.InitSpans(r.mgr.Tracer(), traceName, msg)
+		lin.Start(msg, r.typeStr)
 		select {
 		case r.transactions <- message.NewTransaction(msg, resChan):
 		case <-r.shutSig.CloseAtLeisureChan():
@@ -215,8 +242,9 @@ func (r *AsyncReader) loop() {
 				return
 			}
 
-			mLatency.Timing(time.Since(startedAt).Nanoseconds())
-			tracing.FinishSpans(m)
+			metrics.TimingWithContext(mLatency, m.Get(0).GetContext(), time.Since(startedAt).Nanoseconds())
+			tracing.CompleteSpans(r.mgr.Tracer(), label, m, res)
+			lin.Complete(m, res)
 
 			if err = aFn(closeNowCtx, res); err != nil {
 				r.mgr.Logger().Errorf("Failed to acknowledge message: %v\n", err)
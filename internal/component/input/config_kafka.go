@@ -41,6 +41,7 @@ type KafkaConfig struct {
 	TLS                 btls.Config              `json:"tls" yaml:"tls"`
 	SASL                sasl.Config              `json:"sasl" yaml:"sasl"`
 	MultiHeader         bool                     `json:"multi_header" yaml:"multi_header"`
+	PropagateTrace      bool                     `json:"propagate_trace_context" yaml:"propagate_trace_context"`
 	Batching            batchconfig.Config       `json:"batching" yaml:"batching"`
 }
 
@@ -62,6 +63,7 @@ func NewKafkaConfig() KafkaConfig {
 		TLS:                 btls.NewConfig(),
 		SASL:                sasl.NewConfig(),
 		MultiHeader:         false,
+		PropagateTrace:      false,
 		Batching:            batchconfig.NewConfig(),
 	}
 }
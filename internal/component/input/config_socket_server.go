@@ -2,26 +2,37 @@ package input
 
 // SocketServerTLSConfig contains config for TLS.
 type SocketServerTLSConfig struct {
-	CertFile   string `json:"cert_file" yaml:"cert_file"`
-	KeyFile    string `json:"key_file" yaml:"key_file"`
-	SelfSigned bool   `json:"self_signed" yaml:"self_signed"`
+	CertFile         string   `json:"cert_file" yaml:"cert_file"`
+	KeyFile          string   `json:"key_file" yaml:"key_file"`
+	SelfSigned       bool     `json:"self_signed" yaml:"self_signed"`
+	ClientCAFile     string   `json:"client_ca_file" yaml:"client_ca_file"`
+	AllowedClientCNs []string `json:"allowed_client_common_names" yaml:"allowed_client_common_names"`
 }
 
 // SocketServerConfig contains configuration for the SocketServer input type.
 type SocketServerConfig struct {
-	Network   string                `json:"network" yaml:"network"`
-	Address   string                `json:"address" yaml:"address"`
-	Codec     string                `json:"codec" yaml:"codec"`
-	MaxBuffer int                   `json:"max_buffer" yaml:"max_buffer"`
-	TLS       SocketServerTLSConfig `json:"tls" yaml:"tls"`
+	Network           string                `json:"network" yaml:"network"`
+	Address           string                `json:"address" yaml:"address"`
+	Codec             string                `json:"codec" yaml:"codec"`
+	MaxBuffer         int                   `json:"max_buffer" yaml:"max_buffer"`
+	MaxConnections    int                   `json:"max_connections" yaml:"max_connections"`
+	MaxBytesPerSecond int                   `json:"max_bytes_per_second" yaml:"max_bytes_per_second"`
+	IdleTimeout       string                `json:"idle_timeout" yaml:"idle_timeout"`
+	TLS               SocketServerTLSConfig `json:"tls" yaml:"tls"`
 }
 
 // NewSocketServerConfig creates a new SocketServerConfig with default values.
 func NewSocketServerConfig() SocketServerConfig {
 	return SocketServerConfig{
-		Network:   "",
-		Address:   "",
-		Codec:     "lines",
-		MaxBuffer: 1000000,
+		Network:           "",
+		Address:           "",
+		Codec:             "lines",
+		MaxBuffer:         1000000,
+		MaxConnections:    0,
+		MaxBytesPerSecond: 0,
+		IdleTimeout:       "",
+		TLS: SocketServerTLSConfig{
+			AllowedClientCNs: []string{},
+		},
 	}
 }
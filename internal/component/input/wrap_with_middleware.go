@@ -0,0 +1,84 @@
+package input
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
+)
+
+// WithMiddleware is a type that wraps an input type and intercepts the batches
+// it consumes with a middleware, and implements the input.Type interface in
+// order to act like an ordinary input.
+type WithMiddleware struct {
+	in          Streamed
+	interceptor *transaction.Interceptor
+
+	transactions chan message.Transaction
+	shutSig      *shutdown.Signaller
+}
+
+// WrapWithMiddleware routes the transactions of an input through a middleware
+// and returns a type that acts like an ordinary input.
+func WrapWithMiddleware(in Streamed, mw transaction.Middleware) *WithMiddleware {
+	m := &WithMiddleware{
+		in:           in,
+		interceptor:  transaction.NewInterceptor(mw),
+		transactions: make(chan message.Transaction),
+		shutSig:      shutdown.NewSignaller(),
+	}
+	go m.loop()
+	return m
+}
+
+func (m *WithMiddleware) loop() {
+	defer m.shutSig.ShutdownComplete()
+
+	ctx, done := m.shutSig.CloseNowCtx(context.Background())
+	defer done()
+
+	m.interceptor.Forward(ctx, m.in.TransactionChan(), m.transactions)
+	m.interceptor.Wait()
+}
+
+//------------------------------------------------------------------------------
+
+// TransactionChan returns the channel used for consuming transactions from this
+// input.
+func (m *WithMiddleware) TransactionChan() <-chan message.Transaction {
+	return m.transactions
+}
+
+// Connected returns a boolean indicating whether this input is currently
+// connected to its target.
+func (m *WithMiddleware) Connected() bool {
+	return m.in.Connected()
+}
+
+//------------------------------------------------------------------------------
+
+// TriggerStopConsuming instructs the input to start shutting down resources
+// once all pending messages are delivered and acknowledged. This call does
+// not block.
+func (m *WithMiddleware) TriggerStopConsuming() {
+	m.in.TriggerStopConsuming()
+}
+
+// TriggerCloseNow triggers the shut down of this component but should not block
+// the calling goroutine.
+func (m *WithMiddleware) TriggerCloseNow() {
+	m.in.TriggerCloseNow()
+	m.shutSig.CloseNow()
+}
+
+// WaitForClose is a blocking call to wait until the component has finished
+// shutting down and cleaning up resources.
+func (m *WithMiddleware) WaitForClose(ctx context.Context) error {
+	select {
+	case <-m.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return m.in.WaitForClose(ctx)
+}
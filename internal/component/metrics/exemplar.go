@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StatTimerExemplar is an optional interface implemented by timers that are
+// able to attach exemplars, which are labels identifying a specific event such
+// as a trace ID, to timing values.
+type StatTimerExemplar interface {
+	// TimingWithExemplar sets a timing metric along with exemplar labels.
+	TimingWithExemplar(delta int64, exemplar map[string]string)
+}
+
+// ExemplarTraceIDLabel is the exemplar label used for trace IDs.
+const ExemplarTraceIDLabel = "trace_id"
+
+// TimingWithContext sets a timing metric and, when the context contains a
+// sampled trace and the timer supports exemplars, attaches the trace ID of the
+// context as an exemplar.
+func TimingWithContext(t StatTimer, ctx context.Context, delta int64) {
+	if et, ok := t.(StatTimerExemplar); ok && ctx != nil {
+		if sCtx := trace.SpanContextFromContext(ctx); sCtx.IsSampled() {
+			et.TimingWithExemplar(delta, map[string]string{
+				ExemplarTraceIDLabel: sCtx.TraceID().String(),
+			})
+			return
+		}
+	}
+	t.Timing(delta)
+}
@@ -0,0 +1,106 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// AdaptiveConcurrency is an AIMD (additive increase, multiplicative decrease)
+// controller that limits the number of writes an output has in flight. The
+// limit grows by one for each successful write made whilst the limit is being
+// saturated, and shrinks by a ratio for each write that fails or exceeds a
+// latency threshold, which allows the parallelism of an output to track the
+// capacity of the downstream service.
+type AdaptiveConcurrency struct {
+	minLimit         int
+	maxLimit         int
+	backoffRatio     float64
+	latencyThreshold time.Duration
+
+	mut        sync.Mutex
+	limit      float64
+	inFlight   int
+	releasedCh chan struct{}
+}
+
+// NewAdaptiveConcurrency creates an AIMD controller that starts at the minimum
+// limit and never exceeds the maximum limit. A latency threshold of zero means
+// only failed writes reduce the limit.
+func NewAdaptiveConcurrency(minLimit, maxLimit int, backoffRatio float64, latencyThreshold time.Duration) (*AdaptiveConcurrency, error) {
+	if minLimit < 1 {
+		return nil, errors.New("minimum in flight must be greater than zero")
+	}
+	if maxLimit < minLimit {
+		return nil, errors.New("maximum in flight must not be less than the minimum in flight")
+	}
+	if backoffRatio <= 0 || backoffRatio >= 1 {
+		return nil, errors.New("backoff ratio must be between zero and one")
+	}
+	return &AdaptiveConcurrency{
+		minLimit:         minLimit,
+		maxLimit:         maxLimit,
+		backoffRatio:     backoffRatio,
+		latencyThreshold: latencyThreshold,
+		limit:            float64(minLimit),
+		releasedCh:       make(chan struct{}),
+	}, nil
+}
+
+// Limit returns the current maximum number of writes allowed in flight.
+func (a *AdaptiveConcurrency) Limit() int {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	return int(a.limit)
+}
+
+// acquire blocks until a write is permitted by the current limit, or the
+// context is cancelled.
+func (a *AdaptiveConcurrency) acquire(ctx context.Context) error {
+	for {
+		a.mut.Lock()
+		if a.inFlight < int(a.limit) {
+			a.inFlight++
+			a.mut.Unlock()
+			return nil
+		}
+		waitCh := a.releasedCh
+		a.mut.Unlock()
+
+		select {
+		case <-waitCh:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release marks a write permitted by acquire as finished and adjusts the limit
+// according to its outcome.
+func (a *AdaptiveConcurrency) release(latency time.Duration, err error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err != nil || (a.latencyThreshold > 0 && latency > a.latencyThreshold) {
+		a.limit = max(a.limit*a.backoffRatio, float64(a.minLimit))
+	} else if a.inFlight*2 >= int(a.limit) {
+		a.limit = min(a.limit+1, float64(a.maxLimit))
+	}
+	a.inFlight--
+
+	close(a.releasedCh)
+	a.releasedCh = make(chan struct{})
+}
+
+// cancel marks a write permitted by acquire as abandoned without affecting the
+// limit.
+func (a *AdaptiveConcurrency) cancel() {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	a.inFlight--
+
+	close(a.releasedCh)
+	a.releasedCh = make(chan struct{})
+}
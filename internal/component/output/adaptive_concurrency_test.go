@@ -0,0 +1,107 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveConcurrencyConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		min, max     int
+		backoffRatio float64
+		errContains  string
+	}{
+		{name: "zero min", min: 0, max: 10, backoffRatio: 0.5, errContains: "minimum"},
+		{name: "max below min", min: 5, max: 2, backoffRatio: 0.5, errContains: "maximum"},
+		{name: "zero ratio", min: 1, max: 10, backoffRatio: 0, errContains: "ratio"},
+		{name: "ratio of one", min: 1, max: 10, backoffRatio: 1, errContains: "ratio"},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewAdaptiveConcurrency(test.min, test.max, test.backoffRatio, 0)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errContains)
+		})
+	}
+}
+
+func TestAdaptiveConcurrencyAIMD(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := NewAdaptiveConcurrency(2, 5, 0.5, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.Limit())
+
+	// Successful writes whilst the limit is saturated increase the limit by one
+	// each, up to the maximum.
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.acquire(ctx))
+		require.NoError(t, c.acquire(ctx))
+		c.release(time.Millisecond, nil)
+		c.release(time.Millisecond, nil)
+	}
+	assert.Equal(t, 5, c.Limit())
+
+	// Successful writes that do not saturate the limit leave it unchanged.
+	c.limit = 4
+	require.NoError(t, c.acquire(ctx))
+	c.release(time.Millisecond, nil)
+	assert.Equal(t, 4, c.Limit())
+
+	// Failed writes and slow writes reduce the limit by the ratio.
+	require.NoError(t, c.acquire(ctx))
+	c.release(time.Millisecond, errors.New("nope"))
+	assert.Equal(t, 2, c.Limit())
+
+	c.limit = 4
+	require.NoError(t, c.acquire(ctx))
+	c.release(time.Second*2, nil)
+	assert.Equal(t, 2, c.Limit())
+
+	// The limit never drops below the minimum.
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.acquire(ctx))
+		c.release(time.Millisecond, errors.New("nope"))
+	}
+	assert.Equal(t, 2, c.Limit())
+}
+
+func TestAdaptiveConcurrencyBlocks(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := NewAdaptiveConcurrency(1, 5, 0.5, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, c.acquire(ctx))
+
+	tCtx, done := context.WithTimeout(ctx, time.Millisecond*10)
+	defer done()
+	require.Error(t, c.acquire(tCtx))
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- c.acquire(ctx)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired beyond the limit")
+	case <-time.After(time.Millisecond * 10):
+	}
+
+	c.cancel()
+
+	select {
+	case err := <-acquired:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, 1, c.Limit())
+}
@@ -14,9 +14,13 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/events"
+	"github.com/benthosdev/benthos/v4/internal/health"
+	"github.com/benthosdev/benthos/v4/internal/lineage"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/internal/tap"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
@@ -48,10 +52,15 @@ type AsyncWriter struct {
 	writer      AsyncSink
 
 	injectTracingMap *mapping.Executor
+	concurrency      *AdaptiveConcurrency
 
-	log    log.Modular
-	stats  metrics.Type
-	tracer trace.TracerProvider
+	log     log.Modular
+	stats   metrics.Type
+	tracer  trace.TracerProvider
+	events  events.Emitter
+	health  *health.Component
+	lineage *lineage.Recorder
+	tap     *tap.Point
 
 	transactions <-chan message.Transaction
 
@@ -67,9 +76,13 @@ func NewAsyncWriter(typeStr string, maxInflight int, w AsyncSink, mgr component.
 		log:          mgr.Logger(),
 		stats:        mgr.Metrics(),
 		tracer:       mgr.Tracer(),
+		events:       events.FromManager(mgr),
+		lineage:      lineage.FromManager(mgr),
 		transactions: nil,
 		shutSig:      shutdown.NewSignaller(),
 	}
+	aWriter.health = health.FromManager(mgr, "output", typeStr, aWriter.Connected)
+	aWriter.tap = tap.FromManager(mgr, "output", typeStr)
 	return aWriter, nil
 }
 
@@ -79,6 +92,12 @@ func (w *AsyncWriter) SetInjectTracingMap(exec *mapping.Executor) {
 	w.injectTracingMap = exec
 }
 
+// SetAdaptiveConcurrency sets a controller that limits the number of writes in
+// flight below the maximum, adapting it according to the outcome of each write.
+func (w *AsyncWriter) SetAdaptiveConcurrency(c *AdaptiveConcurrency) {
+	w.concurrency = c
+}
+
 //------------------------------------------------------------------------------
 
 func (w *AsyncWriter) latencyMeasuringWrite(ctx context.Context, msg message.Batch) (latencyNs int64, err error) {
@@ -125,6 +144,8 @@ func (w *AsyncWriter) loop() {
 		mConn       = w.stats.GetCounter("output_connection_up")
 		mFailedConn = w.stats.GetCounter("output_connection_failed")
 		mLostConn   = w.stats.GetCounter("output_connection_lost")
+		mInFlight   = w.stats.GetGauge("output_in_flight")
+		mMaxFlight  = w.stats.GetGauge("output_max_in_flight")
 
 		traceName = "output_" + w.typeStr
 	)
@@ -133,6 +154,8 @@ func (w *AsyncWriter) loop() {
 		_ = w.writer.Close(context.Background())
 
 		atomic.StoreInt32(&w.isConnected, 0)
+		w.health.Deregister()
+		w.tap.Deregister()
 		w.shutSig.ShutdownComplete()
 	}()
 
@@ -151,6 +174,7 @@ func (w *AsyncWriter) loop() {
 					return false
 				}
 				w.log.Errorf("Failed to connect to %v: %v\n", w.typeStr, err)
+				w.health.SetError(err)
 				mFailedConn.Incr(1)
 				select {
 				case <-time.After(connBackoff.NextBackOff()):
@@ -169,6 +193,12 @@ func (w *AsyncWriter) loop() {
 	mConn.Incr(1)
 	atomic.StoreInt32(&w.isConnected, 1)
 
+	if w.concurrency != nil {
+		mMaxFlight.Set(int64(w.concurrency.Limit()))
+	} else {
+		mMaxFlight.Set(int64(w.maxInflight))
+	}
+
 	wg := sync.WaitGroup{}
 	wg.Add(w.maxInflight)
 
@@ -189,6 +219,7 @@ func (w *AsyncWriter) loop() {
 			}
 		}
 		mLostConn.Incr(1)
+		w.events.Emit(events.New(events.TypeConnectionLost, "Output "+w.typeStr+" lost connection"))
 
 		// Continue to try to reconnect while still active.
 		for {
@@ -210,36 +241,58 @@ func (w *AsyncWriter) loop() {
 		defer wg.Done()
 
 		for {
+			// When the concurrency is adaptive a write must be permitted
+			// before a transaction is read, which applies back pressure.
+			if w.concurrency != nil {
+				if err := w.concurrency.acquire(closeLeisureCtx); err != nil {
+					return
+				}
+			}
+
 			var ts message.Transaction
 			var open bool
 			select {
 			case ts, open = <-w.transactions:
-				if !open {
-					return
-				}
 			case <-w.shutSig.CloseAtLeisureChan():
+			}
+			if !open {
+				if w.concurrency != nil {
+					w.concurrency.cancel()
+				}
 				return
 			}
+			mInFlight.Incr(1)
+			w.tap.Observe(ts.Payload)
 
 			w.log.Tracef("Attempting to write %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
-			_, spans := tracing.WithChildSpans(w.tracer, traceName, ts.Payload)
-			w.injectSpans(ts.Payload, spans)
+			// The payload is written with the output spans attached so that
+			// writers propagating trace context reference them as the parent.
+			payload, spans := tracing.WithChildSpans(w.tracer, traceName, ts.Payload)
+			w.injectSpans(payload, spans)
 
-			latency, err := w.latencyMeasuringWrite(closeLeisureCtx, ts.Payload)
+			writeStarted := time.Now()
+			latency, err := w.latencyMeasuringWrite(closeLeisureCtx, payload)
 
 			// If our writer says it is not connected.
 			if errors.Is(err, component.ErrNotConnected) {
-				latency, err = connectLoop(ts.Payload)
+				latency, err = connectLoop(payload)
 			} else if err != nil {
 				mError.Incr(1)
 			}
 
+			mInFlight.Decr(1)
+			if w.concurrency != nil {
+				w.concurrency.release(time.Duration(latency), err)
+				mMaxFlight.Set(int64(w.concurrency.Limit()))
+			}
+
 			// Close immediately if our writer is closed.
 			if errors.Is(err, component.ErrTypeClosed) {
 				return
 			}
 
 			if err != nil {
+				w.health.SetError(err)
 				if w.typeStr != "reject" {
 					// TODO: Maybe reintroduce a sleep here if we encounter a
 					// busy retry loop.
@@ -248,15 +301,17 @@ func (w *AsyncWriter) loop() {
 					w.log.Debugf("Rejecting message: %v\n", err)
 				}
 			} else {
+				w.health.MarkSuccess()
 				mBatchSent.Incr(1)
 				mSent.Incr(int64(batch.MessageCollapsedCount(ts.Payload)))
-				mLatency.Timing(latency)
+				metrics.TimingWithContext(mLatency, ts.Payload.Get(0).GetContext(), latency)
 				w.log.Tracef("Successfully wrote %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
 			}
 
 			for _, s := range spans {
 				s.Finish()
 			}
+			w.lineage.Delivered(payload, w.typeStr, writeStarted, err)
 
 			_ = ts.Ack(closeLeisureCtx, err)
 		}
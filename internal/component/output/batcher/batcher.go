@@ -80,6 +80,8 @@ func (m *Impl) loop() {
 		nextTimedBatchChan = time.After(tNext)
 	}
 
+	mPending := m.stats.GetGauge("output_batch_pending")
+
 	var pendingTrans []*transaction.Tracked
 	for !m.shutSig.ShouldCloseAtLeisure() {
 		if nextTimedBatchChan == nil {
@@ -112,6 +114,7 @@ func (m *Impl) loop() {
 					return nil
 				})
 				pendingTrans = append(pendingTrans, trackedTran)
+				mPending.Set(int64(m.batcher.Count()))
 			}
 		case <-nextTimedBatchChan:
 			flushBatch = true
@@ -125,6 +128,7 @@ func (m *Impl) loop() {
 		}
 
 		sendMsg := m.batcher.Flush(closeNowCtx)
+		mPending.Set(int64(m.batcher.Count()))
 		if sendMsg == nil {
 			continue
 		}
@@ -25,6 +25,12 @@ type CassandraConfig struct {
 	Consistency              string                `json:"consistency" yaml:"consistency"`
 	Timeout                  string                `json:"timeout" yaml:"timeout"`
 	LoggedBatch              bool                  `json:"logged_batch" yaml:"logged_batch"`
+	GroupByPartition         bool                  `json:"group_by_partition" yaml:"group_by_partition"`
+	Idempotent               bool                  `json:"idempotent" yaml:"idempotent"`
+	TokenAware               bool                  `json:"token_aware" yaml:"token_aware"`
+	HostSelectionPolicy      string                `json:"host_selection_policy" yaml:"host_selection_policy"`
+	LocalDC                  string                `json:"local_dc" yaml:"local_dc"`
+	MaxPreparedStatements    int                   `json:"max_prepared_statements" yaml:"max_prepared_statements"`
 	// TODO: V4 Remove this and replace with explicit values.
 	retries.Config `json:",inline" yaml:",inline"`
 	MaxInFlight    int                `json:"max_in_flight" yaml:"max_in_flight"`
@@ -56,5 +62,11 @@ func NewCassandraConfig() CassandraConfig {
 		MaxInFlight:              64,
 		Batching:                 batchconfig.NewConfig(),
 		LoggedBatch:              true,
+		GroupByPartition:         false,
+		Idempotent:               false,
+		TokenAware:               true,
+		HostSelectionPolicy:      "round_robin",
+		LocalDC:                  "",
+		MaxPreparedStatements:    1000,
 	}
 }
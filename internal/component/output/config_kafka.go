@@ -31,6 +31,7 @@ type KafkaConfig struct {
 	StaticHeaders    map[string]string            `json:"static_headers" yaml:"static_headers"`
 	Metadata         metadata.ExcludeFilterConfig `json:"metadata" yaml:"metadata"`
 	InjectTracingMap string                       `json:"inject_tracing_map" yaml:"inject_tracing_map"`
+	PropagateTrace   bool                         `json:"propagate_trace_context" yaml:"propagate_trace_context"`
 }
 
 // NewKafkaConfig creates a new KafkaConfig with default values.
@@ -41,25 +42,26 @@ func NewKafkaConfig() KafkaConfig {
 	rConf.Backoff.MaxElapsedTime = "30s"
 
 	return KafkaConfig{
-		Addresses:     []string{},
-		ClientID:      "benthos",
-		RackID:        "",
-		Key:           "",
-		Partitioner:   "fnv1a_hash",
-		Partition:     "",
-		Topic:         "",
-		Compression:   "none",
-		MaxMsgBytes:   1000000,
-		Timeout:       "5s",
-		AckReplicas:   false,
-		TargetVersion: "2.0.0",
-		StaticHeaders: map[string]string{},
-		Metadata:      metadata.NewExcludeFilterConfig(),
-		TLS:           btls.NewConfig(),
-		SASL:          sasl.NewConfig(),
-		MaxInFlight:   64,
-		Config:        rConf,
-		RetryAsBatch:  false,
-		Batching:      batchconfig.NewConfig(),
+		Addresses:      []string{},
+		ClientID:       "benthos",
+		RackID:         "",
+		Key:            "",
+		Partitioner:    "fnv1a_hash",
+		Partition:      "",
+		Topic:          "",
+		Compression:    "none",
+		MaxMsgBytes:    1000000,
+		Timeout:        "5s",
+		AckReplicas:    false,
+		TargetVersion:  "2.0.0",
+		StaticHeaders:  map[string]string{},
+		Metadata:       metadata.NewExcludeFilterConfig(),
+		TLS:            btls.NewConfig(),
+		SASL:           sasl.NewConfig(),
+		MaxInFlight:    64,
+		Config:         rConf,
+		RetryAsBatch:   false,
+		Batching:       batchconfig.NewConfig(),
+		PropagateTrace: false,
 	}
 }
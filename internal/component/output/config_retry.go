@@ -10,26 +10,94 @@ import (
 type RetryConfig struct {
 	Output         *Config `json:"output" yaml:"output"`
 	retries.Config `json:",inline" yaml:",inline"`
+	Policies       []RetryPolicyConfig `json:"policies" yaml:"policies"`
+	Budget         RetryBudgetConfig   `json:"budget" yaml:"budget"`
 }
 
 // NewRetryConfig creates a new RetryConfig with default values.
 func NewRetryConfig() RetryConfig {
 	return RetryConfig{
-		Output: nil,
+		Output:   nil,
+		Config:   retries.NewConfig(),
+		Policies: []RetryPolicyConfig{},
+		Budget:   NewRetryBudgetConfig(),
+	}
+}
+
+// RetryPolicyConfig contains configuration values for a retry policy that
+// applies to a specific class of errors.
+type RetryPolicyConfig struct {
+	Check          string `json:"check" yaml:"check"`
+	retries.Config `json:",inline" yaml:",inline"`
+}
+
+// NewRetryPolicyConfig creates a new RetryPolicyConfig with default values.
+func NewRetryPolicyConfig() RetryPolicyConfig {
+	return RetryPolicyConfig{
+		Check:  "",
 		Config: retries.NewConfig(),
 	}
 }
 
+// UnmarshalJSON ensures that when parsing configs that are in a slice the
+// default values are still applied.
+func (r *RetryPolicyConfig) UnmarshalJSON(bytes []byte) error {
+	type confAlias RetryPolicyConfig
+	aliased := confAlias(NewRetryPolicyConfig())
+
+	if err := json.Unmarshal(bytes, &aliased); err != nil {
+		return err
+	}
+
+	*r = RetryPolicyConfig(aliased)
+	return nil
+}
+
+// UnmarshalYAML ensures that when parsing configs that are in a slice the
+// default values are still applied.
+func (r *RetryPolicyConfig) UnmarshalYAML(unmarshal func(any) error) error {
+	type confAlias RetryPolicyConfig
+	aliased := confAlias(NewRetryPolicyConfig())
+
+	if err := unmarshal(&aliased); err != nil {
+		return err
+	}
+
+	*r = RetryPolicyConfig(aliased)
+	return nil
+}
+
+// RetryBudgetConfig contains configuration values for limiting the overall
+// rate of retries attempted by a Retry output.
+type RetryBudgetConfig struct {
+	Ratio               float64 `json:"ratio" yaml:"ratio"`
+	MinRetriesPerSecond float64 `json:"min_retries_per_second" yaml:"min_retries_per_second"`
+	Window              string  `json:"window" yaml:"window"`
+}
+
+// NewRetryBudgetConfig creates a new RetryBudgetConfig with default values.
+func NewRetryBudgetConfig() RetryBudgetConfig {
+	return RetryBudgetConfig{
+		Ratio:               0,
+		MinRetriesPerSecond: 10,
+		Window:              "10s",
+	}
+}
+
 type dummyRetryConfig struct {
 	Output         any `json:"output" yaml:"output"`
 	retries.Config `json:",inline" yaml:",inline"`
+	Policies       []RetryPolicyConfig `json:"policies" yaml:"policies"`
+	Budget         RetryBudgetConfig   `json:"budget" yaml:"budget"`
 }
 
 // MarshalJSON prints an empty object instead of nil.
 func (r RetryConfig) MarshalJSON() ([]byte, error) {
 	dummy := dummyRetryConfig{
-		Output: r.Output,
-		Config: r.Config,
+		Output:   r.Output,
+		Config:   r.Config,
+		Policies: r.Policies,
+		Budget:   r.Budget,
 	}
 	if r.Output == nil {
 		dummy.Output = struct{}{}
@@ -40,8 +108,10 @@ func (r RetryConfig) MarshalJSON() ([]byte, error) {
 // MarshalYAML prints an empty object instead of nil.
 func (r RetryConfig) MarshalYAML() (any, error) {
 	dummy := dummyRetryConfig{
-		Output: r.Output,
-		Config: r.Config,
+		Output:   r.Output,
+		Config:   r.Config,
+		Policies: r.Policies,
+		Budget:   r.Budget,
 	}
 	if r.Output == nil {
 		dummy.Output = struct{}{}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
+)
+
+// WithMiddleware is a type that wraps an output type and intercepts the batches
+// it writes with a middleware, and implements the output.Type interface in
+// order to act like an ordinary output.
+type WithMiddleware struct {
+	out         Streamed
+	interceptor *transaction.Interceptor
+
+	transactionsIn <-chan message.Transaction
+	transactions   chan message.Transaction
+	shutSig        *shutdown.Signaller
+}
+
+// WrapWithMiddleware routes transactions through a middleware before they
+// reach an output and returns a type that acts like an ordinary output.
+func WrapWithMiddleware(out Streamed, mw transaction.Middleware) (*WithMiddleware, error) {
+	m := &WithMiddleware{
+		out:          out,
+		interceptor:  transaction.NewInterceptor(mw),
+		transactions: make(chan message.Transaction),
+		shutSig:      shutdown.NewSignaller(),
+	}
+	if err := out.Consume(m.transactions); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *WithMiddleware) loop() {
+	defer m.shutSig.ShutdownComplete()
+
+	ctx, done := m.shutSig.CloseNowCtx(context.Background())
+	defer done()
+
+	m.interceptor.Forward(ctx, m.transactionsIn, m.transactions)
+	m.interceptor.Wait()
+}
+
+//------------------------------------------------------------------------------
+
+// Consume starts the type listening to a message channel from a
+// producer.
+func (m *WithMiddleware) Consume(tsChan <-chan message.Transaction) error {
+	if m.transactionsIn != nil {
+		return component.ErrAlreadyStarted
+	}
+	m.transactionsIn = tsChan
+	go m.loop()
+	return nil
+}
+
+// Connected returns a boolean indicating whether this output is currently
+// connected to its target.
+func (m *WithMiddleware) Connected() bool {
+	return m.out.Connected()
+}
+
+//------------------------------------------------------------------------------
+
+// TriggerCloseNow triggers a closure of this object but does not block.
+func (m *WithMiddleware) TriggerCloseNow() {
+	m.shutSig.CloseNow()
+	m.out.TriggerCloseNow()
+}
+
+// WaitForClose is a blocking call to wait until the object has finished closing
+// down and cleaning up resources.
+func (m *WithMiddleware) WaitForClose(ctx context.Context) error {
+	if err := m.out.WaitForClose(ctx); err != nil {
+		return err
+	}
+	select {
+	case <-m.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
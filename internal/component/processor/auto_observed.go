@@ -6,8 +6,11 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/lineage"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/profile"
+	"github.com/benthosdev/benthos/v4/internal/tap"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
@@ -67,6 +70,10 @@ type v2ToV1Processor struct {
 	mBatchSent     metrics.StatCounter
 	mError         metrics.StatCounter
 	mLatency       metrics.StatTimer
+
+	lineage *lineage.Recorder
+	profile *profile.Processor
+	tap     *tap.Point
 }
 
 // NewAutoObservedProcessor wraps an AutoObserved processor with an
@@ -81,6 +88,10 @@ func NewAutoObservedProcessor(typeStr string, p AutoObserved, mgr component.Obse
 		mBatchSent:     mgr.Metrics().GetCounter("processor_batch_sent"),
 		mError:         mgr.Metrics().GetCounter("processor_error"),
 		mLatency:       mgr.Metrics().GetTimer("processor_latency_ns"),
+
+		lineage: lineage.FromManager(mgr),
+		profile: profile.FromManager(mgr, typeStr),
+		tap:     tap.FromManager(mgr, "processor", typeStr),
 	}
 }
 
@@ -94,6 +105,7 @@ func (a *v2ToV1Processor) ProcessBatch(ctx context.Context, msg message.Batch) (
 	_ = msg.Iter(func(i int, part *message.Part) error {
 		_, span := tracing.WithChildSpan(a.mgr.Tracer(), a.typeStr, part)
 
+		pStarted := time.Now()
 		nextParts, err := a.p.Process(ctx, part)
 		if err != nil {
 			a.mError.Incr(1)
@@ -103,23 +115,29 @@ func (a *v2ToV1Processor) ProcessBatch(ctx context.Context, msg message.Batch) (
 		}
 
 		span.Finish()
+		a.lineage.Processed(message.Batch{part}, a.typeStr, pStarted)
 		if len(nextParts) > 0 {
 			newParts = append(newParts, nextParts...)
 		}
 		return nil
 	})
 
-	a.mLatency.Timing(time.Since(tStarted).Nanoseconds())
+	latency := time.Since(tStarted)
+	metrics.TimingWithContext(a.mLatency, msg.Get(0).GetContext(), latency.Nanoseconds())
+	a.profile.Record(msg.Len(), latency)
 	if len(newParts) == 0 {
 		return nil, nil
 	}
 
+	a.tap.Observe(newParts)
 	a.mSent.Incr(int64(len(newParts)))
 	a.mBatchSent.Incr(1)
 	return []message.Batch{newParts}, nil
 }
 
 func (a *v2ToV1Processor) Close(ctx context.Context) error {
+	a.profile.Deregister()
+	a.tap.Deregister()
 	return a.p.Close(ctx)
 }
 
@@ -196,6 +214,10 @@ type v2BatchedToV1Processor struct {
 	mBatchSent     metrics.StatCounter
 	mError         metrics.StatCounter
 	mLatency       metrics.StatTimer
+
+	lineage *lineage.Recorder
+	profile *profile.Processor
+	tap     *tap.Point
 }
 
 // NewAutoObservedBatchProcessor wraps an AutoObservedBatched processor with an
@@ -210,6 +232,10 @@ func NewAutoObservedBatchedProcessor(typeStr string, p AutoObservedBatched, mgr
 		mBatchSent:     mgr.Metrics().GetCounter("processor_batch_sent"),
 		mError:         mgr.Metrics().GetCounter("processor_error"),
 		mLatency:       mgr.Metrics().GetTimer("processor_latency_ns"),
+
+		lineage: lineage.FromManager(mgr),
+		profile: profile.FromManager(mgr, typeStr),
+		tap:     tap.FromManager(mgr, "processor", typeStr),
 	}
 }
 
@@ -240,13 +266,17 @@ func (a *v2BatchedToV1Processor) ProcessBatch(ctx context.Context, msg message.B
 	for _, s := range spans {
 		s.Finish()
 	}
+	a.lineage.Processed(msg, a.typeStr, tStarted)
 
-	a.mLatency.Timing(time.Since(tStarted).Nanoseconds())
+	latency := time.Since(tStarted)
+	metrics.TimingWithContext(a.mLatency, msg.Get(0).GetContext(), latency.Nanoseconds())
+	a.profile.Record(msg.Len(), latency)
 	if len(outputBatches) == 0 {
 		return nil, nil
 	}
 
 	for _, m := range outputBatches {
+		a.tap.Observe(m)
 		a.mSent.Incr(int64(m.Len()))
 	}
 	a.mBatchSent.Incr(int64(len(outputBatches)))
@@ -254,5 +284,7 @@ func (a *v2BatchedToV1Processor) ProcessBatch(ctx context.Context, msg message.B
 }
 
 func (a *v2BatchedToV1Processor) Close(ctx context.Context) error {
+	a.profile.Deregister()
+	a.tap.Deregister()
 	return a.p.Close(ctx)
 }
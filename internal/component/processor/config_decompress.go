@@ -2,12 +2,14 @@ package processor
 
 // DecompressConfig contains configuration fields for the Decompress processor.
 type DecompressConfig struct {
-	Algorithm string `json:"algorithm" yaml:"algorithm"`
+	Algorithm  string `json:"algorithm" yaml:"algorithm"`
+	Dictionary string `json:"dictionary" yaml:"dictionary"`
 }
 
 // NewDecompressConfig returns a DecompressConfig with default values.
 func NewDecompressConfig() DecompressConfig {
 	return DecompressConfig{
-		Algorithm: "",
+		Algorithm:  "",
+		Dictionary: "",
 	}
 }
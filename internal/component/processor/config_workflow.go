@@ -1,12 +1,17 @@
 package processor
 
+import (
+	"encoding/json"
+)
+
 // WorkflowConfig is a config struct containing fields for the Workflow
 // processor.
 type WorkflowConfig struct {
-	MetaPath        string                  `json:"meta_path" yaml:"meta_path"`
-	Order           [][]string              `json:"order" yaml:"order"`
-	BranchResources []string                `json:"branch_resources" yaml:"branch_resources"`
-	Branches        map[string]BranchConfig `json:"branches" yaml:"branches"`
+	MetaPath        string                               `json:"meta_path" yaml:"meta_path"`
+	Order           [][]string                           `json:"order" yaml:"order"`
+	BranchResources []string                             `json:"branch_resources" yaml:"branch_resources"`
+	Branches        map[string]BranchConfig              `json:"branches" yaml:"branches"`
+	ErrorPolicies   map[string]WorkflowErrorPolicyConfig `json:"error_policies" yaml:"error_policies"`
 }
 
 // NewWorkflowConfig returns a default WorkflowConfig.
@@ -16,5 +21,49 @@ func NewWorkflowConfig() WorkflowConfig {
 		Order:           [][]string{},
 		BranchResources: []string{},
 		Branches:        map[string]BranchConfig{},
+		ErrorPolicies:   map[string]WorkflowErrorPolicyConfig{},
+	}
+}
+
+// WorkflowErrorPolicyConfig describes how a workflow should react to the
+// failure of a particular branch.
+type WorkflowErrorPolicyConfig struct {
+	Action      string `json:"action" yaml:"action"`
+	FallbackMap string `json:"fallback_map" yaml:"fallback_map"`
+}
+
+// NewWorkflowErrorPolicyConfig returns a default WorkflowErrorPolicyConfig.
+func NewWorkflowErrorPolicyConfig() WorkflowErrorPolicyConfig {
+	return WorkflowErrorPolicyConfig{
+		Action:      "continue",
+		FallbackMap: "",
+	}
+}
+
+// UnmarshalJSON ensures that when parsing configs that are in a map or slice
+// the default values are still applied.
+func (w *WorkflowErrorPolicyConfig) UnmarshalJSON(bytes []byte) error {
+	type confAlias WorkflowErrorPolicyConfig
+	aliased := confAlias(NewWorkflowErrorPolicyConfig())
+
+	if err := json.Unmarshal(bytes, &aliased); err != nil {
+		return err
+	}
+
+	*w = WorkflowErrorPolicyConfig(aliased)
+	return nil
+}
+
+// UnmarshalYAML ensures that when parsing configs that are in a map or slice
+// the default values are still applied.
+func (w *WorkflowErrorPolicyConfig) UnmarshalYAML(unmarshal func(any) error) error {
+	type confAlias WorkflowErrorPolicyConfig
+	aliased := confAlias(NewWorkflowErrorPolicyConfig())
+
+	if err := unmarshal(&aliased); err != nil {
+		return err
 	}
+
+	*w = WorkflowErrorPolicyConfig(aliased)
+	return nil
 }
@@ -20,6 +20,7 @@ type Config struct {
 	CloudTrace CloudTraceConfig `json:"gcp_cloudtrace" yaml:"gcp_cloudtrace"`
 	None       struct{}         `json:"none" yaml:"none"`
 	Plugin     any              `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	Sampling   SamplingConfig   `json:"sampling" yaml:"sampling"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
@@ -30,6 +31,7 @@ func NewConfig() Config {
 		CloudTrace: NewCloudTraceConfig(),
 		None:       struct{}{},
 		Plugin:     nil,
+		Sampling:   NewSamplingConfig(),
 	}
 }
 
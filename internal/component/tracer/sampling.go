@@ -0,0 +1,138 @@
+package tracer
+
+import (
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/tracing"
+)
+
+// SamplingConfig contains configuration for tail based sampling of traces.
+type SamplingConfig struct {
+	Enabled          bool                 `json:"enabled" yaml:"enabled"`
+	Rules            []SamplingRuleConfig `json:"rules" yaml:"rules"`
+	DefaultRatio     float64              `json:"default_ratio" yaml:"default_ratio"`
+	MaxPendingTraces int                  `json:"max_pending_traces" yaml:"max_pending_traces"`
+}
+
+// SamplingRuleConfig describes a rule that determines the ratio at which
+// traces of matching messages are sampled.
+type SamplingRuleConfig struct {
+	Inputs []string `json:"inputs" yaml:"inputs"`
+	Check  string   `json:"check" yaml:"check"`
+	Ratio  float64  `json:"ratio" yaml:"ratio"`
+}
+
+// NewSamplingConfig returns a SamplingConfig with default values.
+func NewSamplingConfig() SamplingConfig {
+	return SamplingConfig{
+		Enabled:          false,
+		Rules:            []SamplingRuleConfig{},
+		DefaultRatio:     1,
+		MaxPendingTraces: 10000,
+	}
+}
+
+// NewSamplingRuleConfig returns a SamplingRuleConfig with default values.
+func NewSamplingRuleConfig() SamplingRuleConfig {
+	return SamplingRuleConfig{
+		Inputs: []string{},
+		Check:  "",
+		Ratio:  1,
+	}
+}
+
+// UnmarshalYAML ensures that when parsing rules the default values are still
+// applied.
+func (r *SamplingRuleConfig) UnmarshalYAML(value *yaml.Node) error {
+	type ruleAlias SamplingRuleConfig
+	aliased := ruleAlias(NewSamplingRuleConfig())
+	if err := value.Decode(&aliased); err != nil {
+		return err
+	}
+	*r = SamplingRuleConfig(aliased)
+	return nil
+}
+
+type samplingRule struct {
+	inputs map[string]struct{}
+	check  *mapping.Executor
+	ratio  float64
+}
+
+func ratioErr(ratio float64) error {
+	if ratio < 0 || ratio > 1 {
+		return fmt.Errorf("ratio must be between 0 and 1, got %v", ratio)
+	}
+	return nil
+}
+
+// WithSampling wraps a tracer provider with tail based sampling according to a
+// sampling config. If sampling is not enabled then the provider is returned
+// unchanged.
+func WithSampling(prov trace.TracerProvider, conf SamplingConfig, env *bloblang.Environment, logger log.Modular) (trace.TracerProvider, error) {
+	if !conf.Enabled {
+		return prov, nil
+	}
+	if err := ratioErr(conf.DefaultRatio); err != nil {
+		return nil, fmt.Errorf("default_ratio: %w", err)
+	}
+	if conf.MaxPendingTraces <= 0 {
+		return nil, errors.New("max_pending_traces must be greater than zero")
+	}
+
+	rules := make([]samplingRule, 0, len(conf.Rules))
+	for i, rConf := range conf.Rules {
+		if err := ratioErr(rConf.Ratio); err != nil {
+			return nil, fmt.Errorf("rule %v: %w", i, err)
+		}
+		r := samplingRule{ratio: rConf.Ratio}
+		if len(rConf.Inputs) > 0 {
+			r.inputs = map[string]struct{}{}
+			for _, in := range rConf.Inputs {
+				r.inputs[in] = struct{}{}
+			}
+		}
+		if rConf.Check != "" {
+			var err error
+			if r.check, err = env.NewMapping(rConf.Check); err != nil {
+				return nil, fmt.Errorf("rule %v: failed to parse check: %w", i, err)
+			}
+		}
+		rules = append(rules, r)
+	}
+
+	return tracing.NewTailSampler(prov, func(label string, part *message.Part, errored bool) float64 {
+		if errored && part.ErrorGet() == nil {
+			part = part.ShallowCopy()
+			part.ErrorSet(errors.New("message delivery or processing failed"))
+		}
+		batch := message.Batch{part}
+		for i, r := range rules {
+			if r.inputs != nil {
+				if _, exists := r.inputs[label]; !exists {
+					continue
+				}
+			}
+			if r.check != nil {
+				matched, err := r.check.QueryPart(0, batch)
+				if err != nil {
+					logger.Debugf("Tracing sampling rule %v check failed: %v", i, err)
+					continue
+				}
+				if !matched {
+					continue
+				}
+			}
+			return r.ratio
+		}
+		return conf.DefaultRatio
+	}, conf.DefaultRatio, conf.MaxPendingTraces), nil
+}
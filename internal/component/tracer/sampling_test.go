@@ -0,0 +1,88 @@
+package tracer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/tracing"
+)
+
+func TestSamplingRules(t *testing.T) {
+	var conf SamplingConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+enabled: true
+rules:
+  - check: errored()
+    ratio: 1
+  - inputs: [ foo ]
+    check: this.important
+  - check: this.important
+    ratio: 0
+default_ratio: 0
+max_pending_traces: 100
+`), &conf))
+
+	rec := tracetest.NewSpanRecorder()
+	prov, err := WithSampling(tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(rec)), conf, bloblang.GlobalEnvironment(), log.Noop())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		label   string
+		content string
+		errored bool
+		sampled bool
+	}{
+		{name: "errored", label: "bar", content: `{}`, errored: true, sampled: true},
+		{name: "important from foo", label: "foo", content: `{"important":true}`, sampled: true},
+		{name: "important from bar", label: "bar", content: `{"important":true}`, sampled: false},
+		{name: "default", label: "foo", content: `{"important":false}`, sampled: false},
+		{name: "check fails", label: "foo", content: `not structured`, sampled: false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			before := len(rec.Ended())
+
+			b := message.Batch{message.NewPart([]byte(test.content))}
+			tracing.InitSpans(prov, "input", b)
+
+			var bErr error
+			if test.errored {
+				bErr = assert.AnError
+			}
+			tracing.CompleteSpans(prov, test.label, b, bErr)
+
+			assert.Equal(t, test.sampled, len(rec.Ended()) > before)
+		})
+	}
+}
+
+func TestSamplingConfigErrors(t *testing.T) {
+	prov := tracesdk.NewTracerProvider()
+
+	conf := NewSamplingConfig()
+	p, err := WithSampling(prov, conf, bloblang.GlobalEnvironment(), log.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, prov, p)
+
+	conf.Enabled = true
+	conf.DefaultRatio = 2
+	_, err = WithSampling(prov, conf, bloblang.GlobalEnvironment(), log.Noop())
+	assert.EqualError(t, err, "default_ratio: ratio must be between 0 and 1, got 2")
+
+	conf.DefaultRatio = 1
+	conf.Rules = []SamplingRuleConfig{{Check: "this.foo ==", Ratio: 1}}
+	_, err = WithSampling(prov, conf, bloblang.GlobalEnvironment(), log.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rule 0: failed to parse check")
+}
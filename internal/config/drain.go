@@ -0,0 +1,26 @@
+package config
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// DrainConfig describes the phases of a graceful shutdown of the stream.
+type DrainConfig struct {
+	InputTimeout string `json:"input_timeout" yaml:"input_timeout"`
+	FlushTimeout string `json:"flush_timeout" yaml:"flush_timeout"`
+}
+
+// NewDrainConfig returns a DrainConfig with default values.
+func NewDrainConfig() DrainConfig {
+	return DrainConfig{
+		InputTimeout: "0s",
+		FlushTimeout: "0s",
+	}
+}
+
+func drainField() docs.FieldSpec {
+	return docs.FieldObject("drain", "Configures the phases of a graceful shutdown, which takes place when the service receives a SIGTERM or SIGINT, or when the `/drain` endpoint is called. Inputs are stopped first, followed by buffers, processors and outputs flushing any messages that are in flight. The number of messages left unflushed when a shutdown completes is logged and emitted as a field of the `stream_stopped` event.").WithChildren(
+		docs.FieldString("input_timeout", "The maximum period of time to wait for inputs to stop consuming and close, which includes waiting for the messages they have already consumed to be acknowledged, where zero means the period is only limited by the `shutdown_timeout`.").HasDefault("0s"),
+		docs.FieldString("flush_timeout", "The maximum period of time to wait for buffers, processors and outputs to flush messages that are in flight once inputs have stopped, where zero means the period is only limited by the `shutdown_timeout`. Once exceeded the remaining components are closed forcefully.").HasDefault("0s"),
+	).Advanced().AtVersion("4.20.0")
+}
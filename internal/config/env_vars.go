@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 )
 
 var (
@@ -42,6 +44,12 @@ func ReplaceEnvVariables(inBytes []byte, lookupFn func(string) (string, bool)) (
 	var missingVarsErr ErrMissingEnvVars
 
 	replaced = envRegex.ReplaceAllFunc(inBytes, func(content []byte) []byte {
+		// Secret references share the same syntax and are resolved
+		// separately.
+		if secrets.IsReference(content) {
+			return content
+		}
+
 		var value string
 		var ok bool
 		if len(content) > 3 {
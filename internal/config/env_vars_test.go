@@ -50,6 +50,8 @@ func TestEnvSwapping(t *testing.T) {
 		"foo ${BENTHOS_TEST_THIS_DOESNT_EXIST_LOL} baz":                            {errContains: "required environment variables were not set: [BENTHOS_TEST_THIS_DOESNT_EXIST_LOL]"},
 		"foo ${BENTHOS_TEST_NOPE_A} baz ${BENTHOS_TEST_NOPE_B} buz":                {errContains: "required environment variables were not set: [BENTHOS_TEST_NOPE_A BENTHOS_TEST_NOPE_B]"},
 		"foo ${DOES_NOT_EXIST::} baz":                                              {result: "foo : baz"},
+		"foo ${secret:vault:kv/data/foo#bar} baz":                                  {result: "foo ${secret:vault:kv/data/foo#bar} baz"},
+		"foo ${secret:bar} baz":                                                    {result: "foo bar baz"},
 	}
 
 	for in, exp := range tests {
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/events"
+)
+
+// EventsConfig describes how structured events emitted by components should be
+// delivered.
+type EventsConfig struct {
+	Types      []string       `json:"types" yaml:"types"`
+	BufferSize int            `json:"buffer_size" yaml:"buffer_size"`
+	Lineage    LineageConfig  `json:"lineage" yaml:"lineage"`
+	Output     *output.Config `json:"output,omitempty" yaml:"output,omitempty"`
+}
+
+// LineageConfig describes whether the lineage of messages should be recorded
+// and emitted as events.
+type LineageConfig struct {
+	Enabled     bool    `json:"enabled" yaml:"enabled"`
+	SampleRatio float64 `json:"sample_ratio" yaml:"sample_ratio"`
+}
+
+// NewEventsConfig returns an EventsConfig with default values.
+func NewEventsConfig() EventsConfig {
+	return EventsConfig{
+		Types:      []string{},
+		BufferSize: 1000,
+		Lineage: LineageConfig{
+			Enabled:     false,
+			SampleRatio: 1,
+		},
+		Output: nil,
+	}
+}
+
+func eventsField() docs.FieldSpec {
+	types := events.Types()
+	typeNames := make([]string, 0, len(types))
+	for k := range types {
+		typeNames = append(typeNames, string(k))
+	}
+	sort.Strings(typeNames)
+
+	var typeOpts []string
+	for _, k := range typeNames {
+		typeOpts = append(typeOpts, k, types[events.Type(k)])
+	}
+
+	return docs.FieldObject("events", "Routes structured events emitted by components, such as lost connections and dropped messages, to an output. Each event is delivered as a JSON object containing the fields `type`, `timestamp`, `message`, and when applicable `stream`, `label`, `path` and `fields`.").WithChildren(
+		docs.FieldString("types", "An optional list of event types to deliver, when empty all events are delivered.").Array().HasAnnotatedOptions(typeOpts...).HasDefault([]any{}),
+		docs.FieldInt("buffer_size", "The maximum number of events to buffer whilst waiting for them to be delivered, once exceeded new events are dropped.").HasDefault(1000),
+		docs.FieldObject("lineage", "Records the lineage of sampled messages, which is emitted as a `message_lineage` event once a message has been acknowledged. The event lists each component that handled the message along with how long processors took, and each output the message was delivered to along with the outcome.").WithChildren(
+			docs.FieldBool("enabled", "Whether to record the lineage of messages.").HasDefault(false),
+			docs.FieldFloat("sample_ratio", "The ratio of messages consumed by inputs to record the lineage of, between 0 and 1.").HasDefault(1),
+		).AtVersion("4.20.0"),
+		docs.FieldOutput("output", "An output to deliver events to, when omitted events are not emitted.").Optional(),
+	).Advanced().AtVersion("4.20.0")
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
@@ -18,11 +19,38 @@ import (
 // ReadFileLinted will attempt to read a configuration file path into a
 // structure. Returns an array of lint messages or an error.
 func ReadFileLinted(fs ifs.FS, path string, skipEnvVarCheck bool, lConf docs.LintConfig, config *Type) ([]docs.Lint, error) {
+	return ReadFileWithOverlaysLinted(fs, path, nil, skipEnvVarCheck, lConf, config)
+}
+
+// ReadFileWithOverlaysLinted will attempt to read a configuration file path,
+// deep-merge any overlay files onto it, and read the result into a structure.
+// Returns an array of lint messages of the merged config or an error.
+func ReadFileWithOverlaysLinted(fs ifs.FS, path string, overlayPaths []string, skipEnvVarCheck bool, lConf docs.LintConfig, config *Type) ([]docs.Lint, error) {
 	configBytes, lints, _, err := ReadFileEnvSwap(fs, path, os.LookupEnv)
 	if err != nil {
 		return nil, err
 	}
 
+	var rawNode yaml.Node
+	if err := yaml.Unmarshal(configBytes, &rawNode); err != nil {
+		return nil, err
+	}
+	for _, p := range overlayPaths {
+		overlayBytes, oLints, _, err := ReadFileEnvSwap(fs, p, os.LookupEnv)
+		if err != nil {
+			return nil, fmt.Errorf("overlay %v: %w", p, err)
+		}
+		lints = append(lints, oLints...)
+
+		var overlayNode yaml.Node
+		if err := yaml.Unmarshal(overlayBytes, &overlayNode); err != nil {
+			return nil, fmt.Errorf("overlay %v: %w", p, err)
+		}
+		if err := MergeYAMLOverlay(&rawNode, &overlayNode); err != nil {
+			return nil, fmt.Errorf("overlay %v: %w", p, err)
+		}
+	}
+
 	if skipEnvVarCheck {
 		var newLints []docs.Lint
 		for _, l := range lints {
@@ -33,15 +61,15 @@ func ReadFileLinted(fs ifs.FS, path string, skipEnvVarCheck bool, lConf docs.Lin
 		lints = newLints
 	}
 
-	if err := yaml.Unmarshal(configBytes, config); err != nil {
-		return nil, err
+	if rawNode.Kind != 0 {
+		if err := rawNode.Decode(config); err != nil {
+			return nil, err
+		}
 	}
 
-	newLints, err := LintBytes(lConf, configBytes)
-	if err != nil {
-		return nil, err
+	if !bytes.HasPrefix(configBytes, []byte("# BENTHOS LINT DISABLE")) {
+		lints = append(lints, lintNode(lConf, &rawNode)...)
 	}
-	lints = append(lints, newLints...)
 	return lints, nil
 }
 
@@ -57,7 +85,13 @@ func LintBytes(lintConf docs.LintConfig, rawBytes []byte) ([]docs.Lint, error) {
 		return nil, err
 	}
 
-	return Spec().LintYAML(docs.NewLintContext(lintConf), &rawNode), nil
+	return lintNode(lintConf, &rawNode), nil
+}
+
+func lintNode(lConf docs.LintConfig, node *yaml.Node) []docs.Lint {
+	ctx := docs.NewLintContext(lConf)
+	lints := Spec().LintYAML(ctx, node)
+	return append(lints, docs.LintInputSchema(ctx, node)...)
 }
 
 // ReadFileEnvSwap reads a file and replaces any environment variable
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// OverlayAppendTag is a YAML tag that can be given to a sequence within an
+// overlay in order to append its elements to the sequence of the config being
+// overlayed, rather than replacing it.
+const OverlayAppendTag = "!append"
+
+// OptAddOverlays adds one or more overlay files to the config reader, which are
+// deep-merged onto the main config in the order provided.
+func OptAddOverlays(paths ...string) OptFunc {
+	return func(r *Reader) {
+		r.overlayPaths = append(r.overlayPaths, paths...)
+	}
+}
+
+func (r *Reader) isOverlayPath(path string) bool {
+	for _, p := range r.overlayPaths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// applyOverlays reads each overlay file and merges it onto the raw node of the
+// main config.
+func (r *Reader) applyOverlays(rawNode *yaml.Node) (lints []string, err error) {
+	for _, path := range r.overlayPaths {
+		var overlayBytes []byte
+		var dLints []docs.Lint
+		var modTime time.Time
+		if overlayBytes, dLints, modTime, err = r.readFileSwap(path); err != nil {
+			return nil, fmt.Errorf("overlay %v: %w", path, err)
+		}
+		for _, l := range dLints {
+			lints = append(lints, path+l.Error())
+		}
+		r.modTimeLastRead[path] = modTime
+
+		var overlayNode yaml.Node
+		if err = yaml.Unmarshal(overlayBytes, &overlayNode); err != nil {
+			return nil, fmt.Errorf("overlay %v: %w", path, err)
+		}
+		if err = MergeYAMLOverlay(rawNode, &overlayNode); err != nil {
+			return nil, fmt.Errorf("overlay %v: %w", path, err)
+		}
+	}
+	return
+}
+
+func yamlDocContent(n *yaml.Node) *yaml.Node {
+	if n.Kind == 0 {
+		return nil
+	}
+	if n.Kind == yaml.DocumentNode {
+		if len(n.Content) == 0 {
+			return nil
+		}
+		return n.Content[0]
+	}
+	return n
+}
+
+func isYAMLNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+// MergeYAMLOverlay deep-merges an overlay onto a base YAML node, modifying the
+// base node in place. The semantics of the merge are as follows:
+//
+// - Objects are merged key by key, where keys only present within the overlay
+// are added.
+// - A key with an explicit null value within the overlay removes that key.
+// - Arrays within the overlay replace those of the base, unless the overlay
+// array is given the tag `!append`, in which case the elements are appended.
+// - All other values within the overlay replace those of the base.
+func MergeYAMLOverlay(base, overlay *yaml.Node) error {
+	overlay = yamlDocContent(overlay)
+	if overlay == nil {
+		return nil
+	}
+
+	if base.Kind == yaml.DocumentNode || base.Kind == 0 {
+		if len(base.Content) == 0 || base.Kind == 0 {
+			base.Kind = yaml.DocumentNode
+			base.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+		}
+		base = base.Content[0]
+	}
+	return mergeYAMLNodes(base, overlay)
+}
+
+func mergeYAMLNodes(base, overlay *yaml.Node) error {
+	switch {
+	case overlay.Kind == yaml.AliasNode:
+		return mergeYAMLNodes(base, overlay.Alias)
+
+	case overlay.Kind == yaml.MappingNode && base.Kind == yaml.MappingNode:
+		for i := 0; i < len(overlay.Content)-1; i += 2 {
+			key, value := overlay.Content[i], overlay.Content[i+1]
+
+			baseIndex := -1
+			for j := 0; j < len(base.Content)-1; j += 2 {
+				if base.Content[j].Value == key.Value {
+					baseIndex = j
+					break
+				}
+			}
+
+			if isYAMLNull(value) {
+				if baseIndex >= 0 {
+					base.Content = append(base.Content[:baseIndex], base.Content[baseIndex+2:]...)
+				}
+				continue
+			}
+			if baseIndex < 0 {
+				base.Content = append(base.Content, key, stripOverlayTags(value))
+				continue
+			}
+			if err := mergeYAMLNodes(base.Content[baseIndex+1], value); err != nil {
+				return fmt.Errorf("%v: %w", key.Value, err)
+			}
+		}
+		return nil
+
+	case overlay.Kind == yaml.SequenceNode && overlay.Tag == OverlayAppendTag:
+		if base.Kind != yaml.SequenceNode {
+			return errors.New("the tag " + OverlayAppendTag + " can only be used to append to an array")
+		}
+		base.Content = append(base.Content, stripOverlayTags(overlay).Content...)
+		return nil
+	}
+
+	*base = *stripOverlayTags(overlay)
+	return nil
+}
+
+// stripOverlayTags returns a node with any overlay specific tags removed, as
+// the node is being added without a counterpart to merge with.
+func stripOverlayTags(n *yaml.Node) *yaml.Node {
+	if n.Kind == yaml.SequenceNode && n.Tag == OverlayAppendTag {
+		c := *n
+		c.Tag = ""
+		n = &c
+	}
+	for i, child := range n.Content {
+		n.Content[i] = stripOverlayTags(child)
+	}
+	return n
+}
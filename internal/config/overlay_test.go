@@ -0,0 +1,132 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeYAMLOverlay(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        string
+		overlay     string
+		output      string
+		errContains string
+	}{
+		{
+			name:    "deep merge objects",
+			base:    "a:\n  b: 1\n  c:\n    d: 2\nz: true\n",
+			overlay: "a:\n  c:\n    d: 3\n    e: 4\n  f: 5\n",
+			output:  "a:\n  b: 1\n  c:\n    d: 3\n    e: 4\n  f: 5\nz: true\n",
+		},
+		{
+			name:    "null removes keys",
+			base:    "a:\n  b: 1\n  c: 2\n",
+			overlay: "a:\n  b: null\n  x: ~\n",
+			output:  "a:\n  c: 2\n",
+		},
+		{
+			name:    "arrays are replaced",
+			base:    "a: [ 1, 2 ]\n",
+			overlay: "a: [ 3 ]\n",
+			output:  "a: [3]\n",
+		},
+		{
+			name:    "arrays are appended",
+			base:    "a: [ 1, 2 ]\n",
+			overlay: "a: !append [ 3 ]\n",
+			output:  "a: [1, 2, 3]\n",
+		},
+		{
+			name:    "appended arrays without a base",
+			base:    "b: 1\n",
+			overlay: "a: !append [ 3 ]\n",
+			output:  "b: 1\na: [3]\n",
+		},
+		{
+			name:    "types are replaced",
+			base:    "a:\n  b: 1\n",
+			overlay: "a: foo\n",
+			output:  "a: foo\n",
+		},
+		{
+			name:    "empty base",
+			base:    "",
+			overlay: "a: foo\n",
+			output:  "a: foo\n",
+		},
+		{
+			name:    "empty overlay",
+			base:    "a: foo\n",
+			overlay: "",
+			output:  "a: foo\n",
+		},
+		{
+			name:        "append to non-array",
+			base:        "a:\n  b: 1\n",
+			overlay:     "a: !append [ 3 ]\n",
+			errContains: "a: the tag !append can only be used to append to an array",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var base, overlay yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.base), &base))
+			require.NoError(t, yaml.Unmarshal([]byte(test.overlay), &overlay))
+
+			err := MergeYAMLOverlay(&base, &overlay)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			enc := yaml.NewEncoder(&buf)
+			enc.SetIndent(2)
+			require.NoError(t, enc.Encode(&base))
+			assert.Equal(t, test.output, buf.String())
+		})
+	}
+}
+
+func TestReaderOverlays(t *testing.T) {
+	confDir := t.TempDir()
+
+	mainPath := filepath.Join(confDir, "main.yaml")
+	require.NoError(t, os.WriteFile(mainPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "base"'
+    interval: 1s
+output:
+  drop: {}
+`), 0o644))
+
+	prodPath := filepath.Join(confDir, "prod.yaml")
+	require.NoError(t, os.WriteFile(prodPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "prod"'
+logger:
+  level: WARN
+`), 0o644))
+
+	conf, lints, err := newDummyReader(mainPath, nil, OptAddOverlays(prodPath)).Read()
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+
+	assert.Equal(t, `root = "prod"`, conf.Input.Generate.Mapping)
+	assert.Equal(t, "1s", conf.Input.Generate.Interval)
+	assert.Equal(t, "drop", conf.Output.Type)
+	assert.Equal(t, "WARN", conf.Logger.LogLevel)
+}
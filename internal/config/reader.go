@@ -6,9 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
-	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/gabs/v2"
@@ -53,6 +53,7 @@ type Reader struct {
 	mainPath      string
 	resourcePaths []string
 	streamsPaths  []string
+	overlayPaths  []string
 	overrides     []string
 
 	modTimeLastRead map[string]time.Time
@@ -79,6 +80,11 @@ type Reader struct {
 	changeFlushPeriod  time.Duration
 	changeDelayPeriod  time.Duration
 	filesRefreshPeriod time.Duration
+
+	// Tracks the secrets referenced by config files when we last read them.
+	secretsMut           sync.Mutex
+	secretsFileInfo      map[string]secretsFileInfo
+	secretsRefreshPeriod time.Duration
 }
 
 // NewReader creates a new config reader.
@@ -98,6 +104,7 @@ func NewReader(mainPath string, resourcePaths []string, opts ...OptFunc) *Reader
 		streamFileInfo:     map[string]streamFileInfo{},
 		resourceFileInfo:   map[string]resourceFileInfo{},
 		resourceSources:    newResourceSourceInfo(),
+		secretsFileInfo:    map[string]secretsFileInfo{},
 		changeFlushPeriod:  defaultChangeFlushPeriod,
 		changeDelayPeriod:  defaultChangeDelayPeriod,
 		filesRefreshPeriod: defaultFilesRefreshPeriod,
@@ -278,7 +285,7 @@ func (r *Reader) readMain(mainPath string, conf *Type) (lints []string, err erro
 		}
 	}()
 
-	if mainPath == "" && len(r.overrides) == 0 {
+	if mainPath == "" && len(r.overlayPaths) == 0 && len(r.overrides) == 0 {
 		return
 	}
 
@@ -287,7 +294,7 @@ func (r *Reader) readMain(mainPath string, conf *Type) (lints []string, err erro
 	if mainPath != "" {
 		var dLints []docs.Lint
 		var modTime time.Time
-		if confBytes, dLints, modTime, err = ReadFileEnvSwap(r.fs, mainPath, os.LookupEnv); err != nil {
+		if confBytes, dLints, modTime, err = r.readFileSwap(mainPath); err != nil {
 			return
 		}
 		for _, l := range dLints {
@@ -299,6 +306,12 @@ func (r *Reader) readMain(mainPath string, conf *Type) (lints []string, err erro
 		}
 	}
 
+	var oLints []string
+	if oLints, err = r.applyOverlays(&rawNode); err != nil {
+		return
+	}
+	lints = append(lints, oLints...)
+
 	confSpec := Spec()
 	if r.streamsMode {
 		// Spec is limited to just non-stream fields when in streams mode (no
@@ -320,6 +333,18 @@ func (r *Reader) readMain(mainPath string, conf *Type) (lints []string, err erro
 	return
 }
 
+// triggerFileUpdate attempts to re-read a config file of any kind and apply
+// the changes.
+func (r *Reader) triggerFileUpdate(mgr bundle.NewManagement, strict bool, path string) error {
+	if path == r.mainPath || r.isOverlayPath(path) {
+		return r.TriggerMainUpdate(mgr, strict, r.mainPath)
+	}
+	if _, exists := r.streamFileInfo[path]; exists {
+		return r.TriggerStreamUpdate(mgr, strict, path)
+	}
+	return r.TriggerResourceUpdate(mgr, strict, path)
+}
+
 // TriggerMainUpdate attempts to re-read the main configuration file, trigger
 // the provided main update func, and apply changes to resources to the provided
 // manager as appropriate.
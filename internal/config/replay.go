@@ -0,0 +1,33 @@
+package config
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// ReplayConfig describes whether batches rejected by the pipeline should be
+// captured so that they can be re-injected later.
+type ReplayConfig struct {
+	Cache       string `json:"cache" yaml:"cache"`
+	KeyPrefix   string `json:"key_prefix" yaml:"key_prefix"`
+	MaxEntries  int    `json:"max_entries" yaml:"max_entries"`
+	AckCaptured bool   `json:"ack_captured" yaml:"ack_captured"`
+}
+
+// NewReplayConfig returns a ReplayConfig with default values.
+func NewReplayConfig() ReplayConfig {
+	return ReplayConfig{
+		Cache:       "",
+		KeyPrefix:   "benthos_replay_",
+		MaxEntries:  1000,
+		AckCaptured: false,
+	}
+}
+
+func replayField() docs.FieldSpec {
+	return docs.FieldObject("replay", "Captures batches that are rejected after being consumed by an input, along with their metadata, the error they were rejected with and the position of the input, and stores them within a cache resource. Captured batches can be listed, inspected and re-injected into the pipeline once the cause of the failure has been fixed, either with the `/replay` endpoint of the HTTP server or the `benthos replay` subcommand.").WithChildren(
+		docs.FieldString("cache", "The label of a cache resource to store captured batches within, such as an `aws_s3` or `gcp_cloud_storage` cache, where an empty string disables the replay store.").HasDefault(""),
+		docs.FieldString("key_prefix", "A prefix added to the keys of the cache that captured batches are stored under.").HasDefault("benthos_replay_"),
+		docs.FieldInt("max_entries", "The maximum number of captured batches to keep, once exceeded the oldest batches are deleted. Set to zero in order to keep an unlimited number of batches.").HasDefault(1000),
+		docs.FieldBool("ack_captured", "Whether batches that are captured successfully should be acknowledged rather than rejected, which prevents inputs from redelivering them as the replay store takes ownership of them.").HasDefault(false),
+	).Advanced().AtVersion("4.20.0")
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
-	"os"
 	"path/filepath"
 	"time"
 
@@ -208,7 +207,7 @@ func (r *Reader) readResource(path string, conf *manager.ResourceConfig) (lints
 	var confBytes []byte
 	var dLints []docs.Lint
 	var modTime time.Time
-	if confBytes, dLints, modTime, err = ReadFileEnvSwap(r.fs, path, os.LookupEnv); err != nil {
+	if confBytes, dLints, modTime, err = r.readFileSwap(path); err != nil {
 		return
 	}
 	for _, l := range dLints {
@@ -289,6 +288,27 @@ func (r *Reader) TriggerResourceDelete(mgr bundle.NewManagement, path string) er
 	return nil
 }
 
+// resourceUnchanged returns true when the config of a resource is identical to
+// the config previously read from the same file, and that file is still the
+// source of the resource. Unchanged resources are left as they are rather than
+// being replaced, which preserves any state they hold such as the contents of a
+// memory cache.
+func resourceUnchanged[T any](path, label string, conf *T, prev map[string]*T, sources map[string]string) bool {
+	prevConf, exists := prev[label]
+	if !exists || sources[label] != path {
+		return false
+	}
+	prevBytes, err := yaml.Marshal(prevConf)
+	if err != nil {
+		return false
+	}
+	confBytes, err := yaml.Marshal(conf)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(prevBytes, confBytes)
+}
+
 func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, currentInfo, prevInfo resourceFileInfo) error {
 	// Kind of arbitrary, but I feel better about having some sort of timeout.
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
@@ -297,6 +317,10 @@ func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, cur
 	// WARNING: The order here is actually kind of important, we want to start
 	// with components that could be dependencies of other components. This is
 	// a "best attempt", so not all edge cases need to be accounted for.
+	//
+	// Each stored config is recorded within prevInfo so that, if a later
+	// resource fails to update, the next attempt compares against the configs
+	// that were actually applied.
 
 	unaccounted := map[string]struct{}{}
 	for k := range prevInfo.rateLimits {
@@ -304,10 +328,14 @@ func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, cur
 	}
 	for k, v := range currentInfo.rateLimits {
 		delete(unaccounted, k)
+		if resourceUnchanged(path, k, v, prevInfo.rateLimits, r.resourceSources.rateLimits) {
+			continue
+		}
 		if err := mgr.StoreRateLimit(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return fmt.Errorf("resource %v: %w", k, err)
 		}
+		prevInfo.rateLimits[k] = v
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k := range unaccounted {
@@ -320,10 +348,14 @@ func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, cur
 	}
 	for k, v := range currentInfo.caches {
 		delete(unaccounted, k)
+		if resourceUnchanged(path, k, v, prevInfo.caches, r.resourceSources.caches) {
+			continue
+		}
 		if err := mgr.StoreCache(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return fmt.Errorf("resource %v: %w", k, err)
 		}
+		prevInfo.caches[k] = v
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k := range unaccounted {
@@ -336,10 +368,14 @@ func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, cur
 	}
 	for k, v := range currentInfo.processors {
 		delete(unaccounted, k)
+		if resourceUnchanged(path, k, v, prevInfo.processors, r.resourceSources.processors) {
+			continue
+		}
 		if err := mgr.StoreProcessor(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return fmt.Errorf("resource %v: %w", k, err)
 		}
+		prevInfo.processors[k] = v
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k := range unaccounted {
@@ -352,10 +388,14 @@ func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, cur
 	}
 	for k, v := range currentInfo.inputs {
 		delete(unaccounted, k)
+		if resourceUnchanged(path, k, v, prevInfo.inputs, r.resourceSources.inputs) {
+			continue
+		}
 		if err := mgr.StoreInput(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return fmt.Errorf("resource %v: %w", k, err)
 		}
+		prevInfo.inputs[k] = v
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k := range unaccounted {
@@ -368,10 +408,14 @@ func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, cur
 	}
 	for k, v := range currentInfo.outputs {
 		delete(unaccounted, k)
+		if resourceUnchanged(path, k, v, prevInfo.outputs, r.resourceSources.outputs) {
+			continue
+		}
 		if err := mgr.StoreOutput(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return fmt.Errorf("resource %v: %w", k, err)
 		}
+		prevInfo.outputs[k] = v
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k := range unaccounted {
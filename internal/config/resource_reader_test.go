@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -246,3 +247,102 @@ processor_resources:
 	assertProc("barproc", "hello world", "hello world and a replaced bar")
 	assertProc("bazproc", "hello world", "hello world and a new baz")
 }
+
+func TestReaderResourceUnchangedKept(t *testing.T) {
+	confDir := t.TempDir()
+
+	mainFilePath := filepath.Join(confDir, "main.yaml")
+	require.NoError(t, os.WriteFile(mainFilePath, []byte(`
+input:
+  inproc: meow
+
+output:
+  drop: {}
+`), 0o644))
+
+	resFilePath := filepath.Join(confDir, "a_res.yaml")
+	require.NoError(t, os.WriteFile(resFilePath, []byte(`
+cache_resources:
+  - label: foocache
+    memory: {}
+processor_resources:
+  - label: fooproc
+    mapping: |
+      root = content().uppercase()
+`), 0o644))
+
+	rdr := NewReader(mainFilePath, []string{confDir + "/*_res.yaml"})
+	rdr.changeDelayPeriod = 1 * time.Millisecond
+	rdr.changeFlushPeriod = 1 * time.Millisecond
+
+	conf, lints, err := rdr.Read()
+	require.NoError(t, err)
+	require.Empty(t, lints)
+
+	require.NoError(t, rdr.SubscribeConfigChanges(func(conf *Type) error {
+		return nil
+	}))
+
+	testMgr, err := manager.New(conf.ResourceConfig)
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginFileWatching(testMgr, true))
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	require.NoError(t, testMgr.AccessCache(tCtx, "foocache", func(c cache.V1) {
+		require.NoError(t, c.Set(tCtx, "foo", []byte("bar"), nil))
+	}))
+
+	checkProc := func(name, input string) (output string) {
+		require.NoError(t, testMgr.AccessProcessor(tCtx, name, func(p processor.V1) {
+			res, err := p.ProcessBatch(tCtx, message.Batch{
+				message.NewPart([]byte(input)),
+			})
+			if err != nil || len(res) != 1 || len(res[0]) != 1 {
+				return
+			}
+			output = string(res[0][0].AsBytes())
+		}))
+		return
+	}
+	getCache := func() (value string) {
+		require.NoError(t, testMgr.AccessCache(tCtx, "foocache", func(c cache.V1) {
+			v, _ := c.Get(tCtx, "foo")
+			value = string(v)
+		}))
+		return
+	}
+
+	// Updating only the processor keeps the cache and its contents.
+	require.NoError(t, os.WriteFile(resFilePath, []byte(`
+cache_resources:
+  - label: foocache
+    memory: {}
+processor_resources:
+  - label: fooproc
+    mapping: |
+      root = content().uppercase() + "!!!"
+`), 0o644))
+
+	require.Eventually(t, func() bool {
+		return checkProc("fooproc", "hello world") == "HELLO WORLD!!!"
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, "bar", getCache())
+
+	// Changing the cache config replaces it.
+	require.NoError(t, os.WriteFile(resFilePath, []byte(`
+cache_resources:
+  - label: foocache
+    memory:
+      default_ttl: 1h
+processor_resources:
+  - label: fooproc
+    mapping: |
+      root = content().uppercase() + "!!!"
+`), 0o644))
+
+	require.Eventually(t, func() bool {
+		return getCache() == ""
+	}, time.Second, time.Millisecond*10)
+}
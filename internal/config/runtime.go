@@ -0,0 +1,26 @@
+package config
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// RuntimeConfig describes tuning options for the Go runtime of the process.
+type RuntimeConfig struct {
+	MemoryLimitFromCgroup bool    `json:"memory_limit_from_cgroup" yaml:"memory_limit_from_cgroup"`
+	MemoryLimitRatio      float64 `json:"memory_limit_ratio" yaml:"memory_limit_ratio"`
+}
+
+// NewRuntimeConfig returns a RuntimeConfig with default values.
+func NewRuntimeConfig() RuntimeConfig {
+	return RuntimeConfig{
+		MemoryLimitFromCgroup: false,
+		MemoryLimitRatio:      0.9,
+	}
+}
+
+func runtimeField() docs.FieldSpec {
+	return docs.FieldObject("runtime", "Tunes the Go runtime of the process.").WithChildren(
+		docs.FieldBool("memory_limit_from_cgroup", "Whether to set the soft memory limit of the runtime from the memory limit of the cgroup the process runs within, which allows the garbage collector to avoid the process being killed when running within a container. This setting is ignored when the `GOMEMLIMIT` environment variable is set.").HasDefault(false),
+		docs.FieldFloat("memory_limit_ratio", "The ratio of the cgroup memory limit to use as the soft memory limit of the runtime.").HasDefault(0.9),
+	).Advanced().AtVersion("4.20.0")
+}
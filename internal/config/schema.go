@@ -21,6 +21,10 @@ type Type struct {
 	Logger                 log.Config     `json:"logger" yaml:"logger"`
 	Metrics                metrics.Config `json:"metrics" yaml:"metrics"`
 	Tracer                 tracer.Config  `json:"tracer" yaml:"tracer"`
+	Events                 EventsConfig   `json:"events" yaml:"events"`
+	Runtime                RuntimeConfig  `json:"runtime" yaml:"runtime"`
+	Drain                  DrainConfig    `json:"drain" yaml:"drain"`
+	Replay                 ReplayConfig   `json:"replay" yaml:"replay"`
 	SystemCloseDelay       string         `json:"shutdown_delay" yaml:"shutdown_delay"`
 	SystemCloseTimeout     string         `json:"shutdown_timeout" yaml:"shutdown_timeout"`
 	Tests                  []any          `json:"tests,omitempty" yaml:"tests,omitempty"`
@@ -35,6 +39,10 @@ func New() Type {
 		Logger:             log.NewConfig(),
 		Metrics:            metrics.NewConfig(),
 		Tracer:             tracer.NewConfig(),
+		Events:             NewEventsConfig(),
+		Runtime:            NewRuntimeConfig(),
+		Drain:              NewDrainConfig(),
+		Replay:             NewReplayConfig(),
 		SystemCloseDelay:   "",
 		SystemCloseTimeout: "20s",
 		Tests:              nil,
@@ -56,6 +64,10 @@ var observabilityFields = docs.FieldSpecs{
 	docs.FieldObject("logger", "Describes how operational logs should be emitted.").WithChildren(log.Spec()...),
 	docs.FieldMetrics("metrics", "A mechanism for exporting metrics.").Optional(),
 	docs.FieldTracer("tracer", "A mechanism for exporting traces.").Optional(),
+	eventsField(),
+	runtimeField(),
+	drainField(),
+	replayField(),
 	docs.FieldString("shutdown_delay", "A period of time to wait for metrics and traces to be pulled or pushed from the process.").HasDefault("0s"),
 	docs.FieldString("shutdown_timeout", "The maximum period of time to wait for a clean shutdown. If this time is exceeded Benthos will forcefully close.").HasDefault("20s"),
 }
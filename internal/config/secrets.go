@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
+)
+
+const defaultSecretsResolveTimeout = 30 * time.Second
+
+type secretsFileInfo struct {
+	refs   []secrets.Reference
+	values map[string]string
+}
+
+// OptSetSecretsRefreshPeriod sets a period at which secrets referenced within
+// config files are re-resolved, where files containing secrets that have
+// changed are re-read. A period of zero disables re-resolution.
+func OptSetSecretsRefreshPeriod(period time.Duration) OptFunc {
+	return func(r *Reader) {
+		r.secretsRefreshPeriod = period
+	}
+}
+
+// readFileSwap reads a config file, replacing environment variable
+// interpolations and secret references, and tracks the secrets referenced so
+// that they can be re-resolved later.
+func (r *Reader) readFileSwap(path string) (configBytes []byte, lints []docs.Lint, modTime time.Time, err error) {
+	if configBytes, lints, modTime, err = ReadFileEnvSwap(r.fs, path, os.LookupEnv); err != nil {
+		return
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), defaultSecretsResolveTimeout)
+	defer done()
+
+	refs := secrets.ParseReferences(configBytes)
+	var values map[string]string
+	if configBytes, values, err = secrets.Replace(ctx, configBytes); err != nil {
+		return
+	}
+
+	r.secretsMut.Lock()
+	if len(refs) > 0 {
+		r.secretsFileInfo[path] = secretsFileInfo{refs: refs, values: values}
+	} else {
+		delete(r.secretsFileInfo, path)
+	}
+	r.secretsMut.Unlock()
+	return
+}
+
+// changedSecretsPaths re-resolves the secrets referenced by each config file
+// read and returns the paths of those where the value of a secret has changed.
+func (r *Reader) changedSecretsPaths(mgr bundle.NewManagement) (paths []string) {
+	r.secretsMut.Lock()
+	infos := make(map[string]secretsFileInfo, len(r.secretsFileInfo))
+	for k, v := range r.secretsFileInfo {
+		infos[k] = v
+	}
+	r.secretsMut.Unlock()
+
+	for path, info := range infos {
+		ctx, done := context.WithTimeout(context.Background(), defaultSecretsResolveTimeout)
+		values, err := secrets.ResolveAll(ctx, info.refs)
+		done()
+		if err != nil {
+			mgr.Logger().Errorf("Failed to refresh secrets of config %v: %v", path, err)
+			continue
+		}
+		for k, v := range values {
+			if info.values[k] != v {
+				mgr.Logger().Infof("Secrets of config %v have changed", path)
+				paths = append(paths, path)
+				break
+			}
+		}
+	}
+	return
+}
+
+type secretsRefresher struct {
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+func (s *secretsRefresher) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closeChan)
+	})
+	return nil
+}
+
+// BeginSecretsRefreshing creates a goroutine that periodically re-resolves the
+// secrets referenced within config files, and re-reads those where a secret
+// has changed as if the file itself were updated. This should only be used
+// when BeginFileWatching is not, as file watching also refreshes secrets.
+//
+// WARNING: Either SubscribeConfigChanges or SubscribeStreamChanges must be
+// called before this, as otherwise it is unsafe to register them during
+// refreshing.
+func (r *Reader) BeginSecretsRefreshing(mgr bundle.NewManagement, strict bool) error {
+	if r.watcher != nil {
+		return errors.New("a file watcher has already been started")
+	}
+	if r.secretsRefreshPeriod <= 0 {
+		return nil
+	}
+
+	refresher := &secretsRefresher{closeChan: make(chan struct{})}
+	r.watcher = refresher
+
+	go func() {
+		ticker := time.NewTicker(r.secretsRefreshPeriod)
+		defer ticker.Stop()
+
+		pending := map[string]struct{}{}
+		for {
+			select {
+			case <-ticker.C:
+				for _, p := range r.changedSecretsPaths(mgr) {
+					pending[p] = struct{}{}
+				}
+				for p := range pending {
+					if !ShouldReread(r.triggerFileUpdate(mgr, strict, p)) {
+						delete(pending, p)
+					}
+				}
+			case <-refresher.closeChan:
+				return
+			}
+		}
+	}()
+	return nil
+}
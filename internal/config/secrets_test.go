@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
+)
+
+func TestReaderSecretsRefreshing(t *testing.T) {
+	var secretValue atomic.Value
+	secretValue.Store("first")
+	secrets.RegisterProvider("config_test", secrets.ProviderFunc(func(ctx context.Context, path string) (string, error) {
+		return path + "-" + secretValue.Load().(string), nil
+	}))
+
+	confDir := t.TempDir()
+	confFilePath := filepath.Join(confDir, "main.yaml")
+	require.NoError(t, os.WriteFile(confFilePath, []byte(`
+input:
+  generate:
+    mapping: 'root = "${secret:config_test:foo}"'
+output:
+  drop: {}
+`), 0o644))
+
+	rdr := newDummyReader(confFilePath, nil, OptSetSecretsRefreshPeriod(time.Millisecond))
+
+	conf, _, err := rdr.Read()
+	require.NoError(t, err)
+	assert.Equal(t, `root = "foo-first"`, conf.Input.Generate.Mapping)
+
+	changeChan := make(chan string, 1)
+	require.NoError(t, rdr.SubscribeConfigChanges(func(conf *Type) error {
+		select {
+		case changeChan <- conf.Input.Generate.Mapping:
+		default:
+		}
+		return nil
+	}))
+
+	testMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginSecretsRefreshing(testMgr, true))
+	t.Cleanup(func() {
+		_ = rdr.Close(context.Background())
+	})
+
+	// No changes are triggered when secrets are unchanged.
+	select {
+	case m := <-changeChan:
+		t.Fatalf("Unexpected config change: %v", m)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	secretValue.Store("second")
+
+	select {
+	case m := <-changeChan:
+		assert.Equal(t, `root = "foo-second"`, m)
+	case <-time.After(time.Second * 5):
+		require.FailNow(t, "Expected a config change to be triggered")
+	}
+}
+
+func TestReaderSecretsUnknownProvider(t *testing.T) {
+	confDir := t.TempDir()
+	confFilePath := filepath.Join(confDir, "main.yaml")
+	require.NoError(t, os.WriteFile(confFilePath, []byte(`
+input:
+  generate:
+    mapping: 'root = "${secret:does_not_exist:foo}"'
+`), 0o644))
+
+	_, _, err := newDummyReader(confFilePath, nil).Read()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "secret provider does_not_exist not recognised")
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -15,6 +14,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	tdocs "github.com/benthosdev/benthos/v4/internal/cli/test/docs"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/events"
 	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
@@ -49,7 +49,7 @@ func (r *Reader) readStreamFileConfig(path string) (conf stream.Config, lints []
 	var confBytes []byte
 	var dLints []docs.Lint
 	var modTime time.Time
-	if confBytes, dLints, modTime, err = ReadFileEnvSwap(r.fs, path, os.LookupEnv); err != nil {
+	if confBytes, dLints, modTime, err = r.readFileSwap(path); err != nil {
 		return
 	}
 	for _, l := range dLints {
@@ -230,5 +230,9 @@ func (r *Reader) TriggerStreamUpdate(mgr bundle.NewManagement, strict bool, path
 		return err
 	}
 	mgr.Logger().Infof("Updated stream %v config from file.", info.id)
+
+	evt := events.New(events.TypeConfigReloaded, "Updated stream config from file")
+	evt.Stream = info.id
+	events.FromManager(mgr).Emit(evt)
 	return nil
 }
@@ -110,6 +110,14 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 			}
 		}
 
+		for _, p := range r.overlayPaths {
+			if _, err := r.fs.Stat(p); err == nil {
+				if err := addNotWatching([]string{p}); err != nil {
+					return err
+				}
+			}
+		}
+
 		streamsPaths, err := r.streamPathsExpanded()
 		if err != nil {
 			return err
@@ -147,6 +155,13 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 		changeTicker := time.NewTicker(r.changeFlushPeriod)
 		defer changeTicker.Stop()
 
+		var secretsTickerChan <-chan time.Time
+		if r.secretsRefreshPeriod > 0 {
+			secretsTicker := time.NewTicker(r.secretsRefreshPeriod)
+			defer secretsTicker.Stop()
+			secretsTickerChan = secretsTicker.C
+		}
+
 		for {
 			select {
 			case event, ok := <-watcher.Events:
@@ -171,21 +186,19 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 					if time.Since(change.at) < r.changeDelayPeriod {
 						continue
 					}
-					var succeeded bool
-					if nameClean == r.mainPath {
-						succeeded = !ShouldReread(r.TriggerMainUpdate(mgr, strict, r.mainPath))
-					} else if _, exists := r.streamFileInfo[nameClean]; exists {
-						succeeded = !ShouldReread(r.TriggerStreamUpdate(mgr, strict, nameClean))
-					} else {
-						succeeded = !ShouldReread(r.TriggerResourceUpdate(mgr, strict, nameClean))
-					}
-					if succeeded {
+					if succeeded := !ShouldReread(r.triggerFileUpdate(mgr, strict, nameClean)); succeeded {
 						delete(collapsedChanges, nameClean)
 					} else {
 						change.at = time.Now()
 						collapsedChanges[nameClean] = change
 					}
 				}
+			case <-secretsTickerChan:
+				// Files with changed secrets are re-read immediately rather
+				// than waiting for further writes to settle.
+				for _, p := range r.changedSecretsPaths(mgr) {
+					collapsedChanges[p] = fileChange{at: time.Now().Add(-r.changeDelayPeriod)}
+				}
 			case <-filesTicker.C:
 				if err := refreshFiles(); err != nil {
 					mgr.Logger().Errorf("Failed to refresh watched paths: %v", err)
@@ -1,6 +1,13 @@
 package docs
 
 import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
 )
 
@@ -26,6 +33,162 @@ func LintBloblangMapping(ctx LintContext, line, col int, v any) []Lint {
 	return []Lint{NewLintError(line, LintBadBloblang, err.Error())}
 }
 
+// Processors that never change the contents of messages, and therefore the
+// documents seen by any processors that follow them still match the input
+// schema.
+var inputSchemaPassthroughProcessors = map[string]struct{}{
+	"bounds_check":  {},
+	"dedupe":        {},
+	"json_schema":   {},
+	"log":           {},
+	"metric":        {},
+	"noop":          {},
+	"rate_limit":    {},
+	"sleep":         {},
+	"sync_response": {},
+}
+
+// Processors that execute a mapping upon the document as a whole.
+var inputSchemaMappingProcessors = map[string]struct{}{
+	"bloblang": {},
+	"mapping":  {},
+	"mutation": {},
+}
+
+// LintInputSchema checks the mappings of a config that are executed upon
+// documents exactly as they were consumed against the input schema of the lint
+// config, and does nothing when no schema has been provided. The processors of
+// the input are walked followed by those of the pipeline, and the walk stops
+// at the first processor that might change the document, since from that
+// point onwards the schema no longer describes it.
+func LintInputSchema(ctx LintContext, node *yaml.Node) []Lint {
+	schema := ctx.conf.BloblangInputSchema
+	if schema == nil {
+		return nil
+	}
+
+	root := unwrapDocumentNode(node)
+	var procs []*yaml.Node
+	if input := yamlMapValue(root, "input"); input != nil {
+		// Processors nested within the input, such as those of broker
+		// children, might change documents before they reach any others.
+		for i := 0; i < len(input.Content)-1; i += 2 {
+			if input.Content[i].Value != "processors" && yamlHasProcessors(input.Content[i+1]) {
+				return nil
+			}
+		}
+		if p := yamlMapValue(input, "processors"); p != nil {
+			procs = append(procs, p.Content...)
+		}
+	}
+	if p := yamlMapValue(yamlMapValue(root, "pipeline"), "processors"); p != nil {
+		procs = append(procs, p.Content...)
+	}
+
+	for _, p := range procs {
+		p = unwrapDocumentNode(p)
+		if p.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		var pType string
+		for i := 0; i < len(p.Content)-1; i += 2 {
+			if k := p.Content[i].Value; k != "label" {
+				pType = k
+			}
+		}
+		if t := yamlMapValue(p, "type"); t != nil {
+			pType = t.Value
+		}
+
+		if _, exists := inputSchemaPassthroughProcessors[pType]; exists {
+			continue
+		}
+		if _, exists := inputSchemaMappingProcessors[pType]; !exists {
+			return nil
+		}
+
+		mNode := yamlMapValue(p, pType)
+		if mNode == nil || mNode.Kind != yaml.ScalarNode {
+			return nil
+		}
+		exec, err := ctx.conf.BloblangEnv.Parse(mNode.Value)
+		if err != nil {
+			// Parse errors are reported by LintBloblangMapping.
+			return nil
+		}
+		line := mNode.Line
+		if mNode.Style == yaml.LiteralStyle {
+			line++
+		}
+		return lintMappingAgainstSchema(schema, line, exec)
+	}
+	return nil
+}
+
+func yamlMapValue(node *yaml.Node, key string) *yaml.Node {
+	node = unwrapDocumentNode(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == key {
+			return unwrapDocumentNode(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+func yamlHasProcessors(node *yaml.Node) bool {
+	node = unwrapDocumentNode(node)
+	if node == nil {
+		return false
+	}
+	if node.Kind == yaml.MappingNode {
+		if p := yamlMapValue(node, "processors"); p != nil && len(p.Content) > 0 {
+			return true
+		}
+	}
+	for _, c := range node.Content {
+		if yamlHasProcessors(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func lintMappingAgainstSchema(schema *InputSchema, line int, exec *bloblang.Executor) []Lint {
+	unwrapped, ok := exec.XUnwrapper().(interface {
+		Unwrap() *mapping.Executor
+	})
+	if !ok {
+		return nil
+	}
+	m := unwrapped.Unwrap()
+	_, targets := m.QueryTargets(query.TargetsContext{
+		Maps: m.Maps(),
+	})
+
+	var lints []Lint
+	reported := map[string]struct{}{}
+	for _, t := range targets {
+		if t.Type != query.TargetValue {
+			continue
+		}
+		index, explanation := schema.checkPath(t.Path)
+		if index == -1 {
+			continue
+		}
+		pathStr := strings.Join(t.Path[:index+1], ".")
+		if _, exists := reported[pathStr]; exists {
+			continue
+		}
+		reported[pathStr] = struct{}{}
+		lints = append(lints, NewLintError(line, LintBadBloblang, fmt.Sprintf("reference to this.%v cannot exist: %v", pathStr, explanation)))
+	}
+	return lints
+}
+
 // LintBloblangField is function for linting a config field expected to be an
 // interpolation string.
 func LintBloblangField(ctx LintContext, line, col int, v any) []Lint {
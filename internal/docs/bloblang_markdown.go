@@ -320,6 +320,7 @@ func BloblangMethodsMarkdown() ([]byte, error) {
 		query.MethodCategoryParsing,
 		query.MethodCategoryEncoding,
 		query.MethodCategoryGeoIP,
+		query.MethodCategoryGeospatial,
 		query.MethodCategoryDeprecated,
 	} {
 		methods := methodCategory{
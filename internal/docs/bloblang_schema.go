@@ -0,0 +1,447 @@
+package docs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InputSchema describes the structure of documents that Bloblang mappings are
+// expected to be executed upon, and is used in order to statically detect
+// references to fields that cannot exist. A nil *InputSchema describes a value
+// that is entirely unconstrained.
+type InputSchema struct {
+	// The types that the value may be, where an empty set means any type.
+	types map[string]struct{}
+
+	properties map[string]*InputSchema
+
+	// When closed is true only fields within properties may exist, otherwise
+	// additional fields may exist and match the additional schema.
+	closed     bool
+	additional *InputSchema
+
+	items *InputSchema
+
+	// When variants are present the value matches any one of them.
+	variants []*InputSchema
+}
+
+const (
+	inputSchemaObject = "object"
+	inputSchemaArray  = "array"
+)
+
+func (s *InputSchema) allows(t string) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	_, ok := s.types[t]
+	return ok
+}
+
+func (s *InputSchema) typeNames() string {
+	names := make([]string, 0, len(s.types))
+	for k := range s.types {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return strings.Join(names, " or ")
+}
+
+// checkPath walks a path of field segments through the schema and returns the
+// index of the first segment that cannot exist along with an explanation, or
+// -1 if the path may exist.
+func (s *InputSchema) checkPath(path []string) (int, string) {
+	return s.checkPathFrom(path, 0, 0)
+}
+
+// The maximum number of segments walked, guarding against recursive schemas
+// combined with long paths.
+const inputSchemaMaxDepth = 256
+
+func (s *InputSchema) checkPathFrom(path []string, i, depth int) (int, string) {
+	if s == nil || i >= len(path) || depth > inputSchemaMaxDepth {
+		return -1, ""
+	}
+	if len(s.variants) > 0 {
+		var (
+			bestIndex    = -1
+			bestIsObject bool
+			explanation  string
+		)
+		for _, v := range s.variants {
+			index, exp := v.checkPathFrom(path, i, depth+1)
+			if index == -1 {
+				return -1, ""
+			}
+			// Report the variant that got the furthest, preferring those that
+			// could be an object as they give more useful explanations.
+			isObject := len(v.variants) > 0 || v.allows(inputSchemaObject)
+			if index > bestIndex || (index == bestIndex && isObject && !bestIsObject) {
+				bestIndex, bestIsObject, explanation = index, isObject, exp
+			}
+		}
+		return bestIndex, explanation
+	}
+
+	seg := path[i]
+	allowsObj, allowsArr := s.allows(inputSchemaObject), s.allows(inputSchemaArray)
+
+	parent := "the root of the document"
+	if i > 0 {
+		parent = fmt.Sprintf("field %v", strings.Join(path[:i], "."))
+	}
+	if !allowsObj && !allowsArr {
+		return i, fmt.Sprintf("%v is of type %v and therefore cannot contain field %v", parent, s.typeNames(), seg)
+	}
+
+	// Array segments are checked leniently as methods such as index() are not
+	// represented within the path.
+	if allowsArr {
+		if index, exp := s.items.checkPathFrom(path, i+1, depth+1); index == -1 || !allowsObj {
+			return index, exp
+		}
+	}
+
+	if p, exists := s.properties[seg]; exists {
+		return p.checkPathFrom(path, i+1, depth+1)
+	}
+	if !s.closed {
+		return s.additional.checkPathFrom(path, i+1, depth+1)
+	}
+	return i, fmt.Sprintf("field %v does not exist within %v according to the input schema", seg, parent)
+}
+
+//------------------------------------------------------------------------------
+
+// ParseInputSchema attempts to parse an input schema from either a JSON Schema
+// or an Avro schema document. When the format is empty it is inferred from the
+// document.
+func ParseInputSchema(format string, schemaBytes []byte) (*InputSchema, error) {
+	var raw any
+	if err := json.Unmarshal(schemaBytes, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse schema as JSON: %w", err)
+	}
+	if format == "" {
+		format = "json_schema"
+		if isAvroSchema(raw) {
+			format = "avro"
+		}
+	}
+	switch format {
+	case "json_schema":
+		p := &jsonSchemaParser{root: raw, refs: map[string]*InputSchema{}}
+		return p.parse(raw)
+	case "avro":
+		p := &avroSchemaParser{named: map[string]*InputSchema{}}
+		return p.parse(raw, "")
+	}
+	return nil, fmt.Errorf("unrecognised schema format: %v", format)
+}
+
+func isAvroSchema(raw any) bool {
+	switch t := raw.(type) {
+	case string, []any:
+		return true
+	case map[string]any:
+		if _, exists := t["$schema"]; exists {
+			return false
+		}
+		switch t["type"] {
+		case "record", "enum", "fixed", "map":
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+type jsonSchemaParser struct {
+	root any
+	refs map[string]*InputSchema
+}
+
+func (p *jsonSchemaParser) resolveRef(ref string) (*InputSchema, error) {
+	if s, exists := p.refs[ref]; exists {
+		return s, nil
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only local schema references are supported: %v", ref)
+	}
+
+	target := p.root
+	for _, seg := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if seg == "" {
+			continue
+		}
+		seg = strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+		obj, ok := target.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("failed to resolve schema reference: %v", ref)
+		}
+		if target, ok = obj[seg]; !ok {
+			return nil, fmt.Errorf("failed to resolve schema reference: %v", ref)
+		}
+	}
+
+	// Register a placeholder before parsing in order to support recursive
+	// schemas.
+	s := &InputSchema{}
+	p.refs[ref] = s
+	parsed, err := p.parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if parsed != nil {
+		*s = *parsed
+	}
+	return s, nil
+}
+
+func (p *jsonSchemaParser) parseAll(v any) ([]*InputSchema, error) {
+	arr, ok := v.([]any)
+	if !ok {
+		return nil, errors.New("expected an array of schemas")
+	}
+	var schemas []*InputSchema
+	for _, ele := range arr {
+		s, err := p.parse(ele)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			// One of the options is unconstrained.
+			return nil, nil
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, nil
+}
+
+func (p *jsonSchemaParser) parse(raw any) (*InputSchema, error) {
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		if b, isBool := raw.(bool); isBool {
+			if !b {
+				return &InputSchema{types: map[string]struct{}{"never": {}}}, nil
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("expected schema object, got %T", raw)
+	}
+
+	if ref, ok := obj["$ref"].(string); ok {
+		return p.resolveRef(ref)
+	}
+
+	s := &InputSchema{}
+	switch t := obj["type"].(type) {
+	case string:
+		s.types = map[string]struct{}{t: {}}
+	case []any:
+		s.types = map[string]struct{}{}
+		for _, e := range t {
+			if str, ok := e.(string); ok {
+				s.types[str] = struct{}{}
+			}
+		}
+	}
+	if props, ok := obj["properties"].(map[string]any); ok {
+		s.properties = map[string]*InputSchema{}
+		for k, v := range props {
+			var err error
+			if s.properties[k], err = p.parse(v); err != nil {
+				return nil, fmt.Errorf("property %v: %w", k, err)
+			}
+		}
+	}
+
+	if _, hasPatterns := obj["patternProperties"]; !hasPatterns {
+		switch t := obj["additionalProperties"].(type) {
+		case bool:
+			s.closed = !t
+		case map[string]any:
+			var err error
+			if s.additional, err = p.parse(t); err != nil {
+				return nil, fmt.Errorf("additionalProperties: %w", err)
+			}
+		}
+	}
+
+	switch t := obj["items"].(type) {
+	case map[string]any:
+		var err error
+		if s.items, err = p.parse(t); err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+	case []any:
+		variants, err := p.parseAll(t)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		if len(variants) > 0 {
+			s.items = &InputSchema{variants: variants}
+		}
+	}
+
+	// Combinators are approximated as a union with the surrounding schema,
+	// which is lenient for allOf but ensures that valid paths are never
+	// rejected.
+	var variants []*InputSchema
+	for _, k := range []string{"anyOf", "oneOf", "allOf"} {
+		v, exists := obj[k]
+		if !exists {
+			continue
+		}
+		kVariants, err := p.parseAll(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", k, err)
+		}
+		variants = append(variants, kVariants...)
+	}
+	if len(variants) > 0 {
+		if len(s.types) > 0 || s.properties != nil || s.closed || s.items != nil || s.additional != nil {
+			variants = append(variants, s)
+		}
+		s = &InputSchema{variants: variants}
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+type avroSchemaParser struct {
+	named map[string]*InputSchema
+}
+
+var avroPrimitives = map[string]string{
+	"null":    "null",
+	"boolean": "boolean",
+	"int":     "number",
+	"long":    "number",
+	"float":   "number",
+	"double":  "number",
+	"bytes":   "string",
+	"string":  "string",
+}
+
+func avroFullName(name, namespace string) string {
+	if namespace == "" || strings.Contains(name, ".") {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func (p *avroSchemaParser) parse(raw any, namespace string) (*InputSchema, error) {
+	switch t := raw.(type) {
+	case string:
+		if prim, exists := avroPrimitives[t]; exists {
+			return &InputSchema{types: map[string]struct{}{prim: {}}}, nil
+		}
+		if s, exists := p.named[avroFullName(t, namespace)]; exists {
+			return s, nil
+		}
+		if s, exists := p.named[t]; exists {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unknown avro type: %v", t)
+	case []any:
+		return p.parseUnion(t, namespace)
+	case map[string]any:
+		return p.parseComplex(t, namespace)
+	}
+	return nil, fmt.Errorf("unexpected avro schema of type %T", raw)
+}
+
+// parseUnion returns a schema matching any branch of a union. Since unions are
+// commonly represented in JSON by wrapping the value in an object keyed by the
+// branch type, that form is also accepted.
+func (p *avroSchemaParser) parseUnion(branches []any, namespace string) (*InputSchema, error) {
+	s := &InputSchema{}
+	for _, b := range branches {
+		bs, err := p.parse(b, namespace)
+		if err != nil {
+			return nil, err
+		}
+		s.variants = append(s.variants, bs)
+
+		name, _ := b.(string)
+		if obj, ok := b.(map[string]any); ok {
+			name, _ = obj["name"].(string)
+			if name == "" {
+				name, _ = obj["type"].(string)
+			} else {
+				ns, _ := obj["namespace"].(string)
+				if ns == "" {
+					ns = namespace
+				}
+				name = avroFullName(name, ns)
+			}
+		}
+		if name != "" && name != "null" {
+			s.variants = append(s.variants, &InputSchema{
+				types:      map[string]struct{}{inputSchemaObject: {}},
+				properties: map[string]*InputSchema{name: bs},
+				closed:     true,
+			})
+		}
+	}
+	return s, nil
+}
+
+func (p *avroSchemaParser) parseComplex(obj map[string]any, namespace string) (*InputSchema, error) {
+	if ns, ok := obj["namespace"].(string); ok {
+		namespace = ns
+	}
+	register := func(s *InputSchema) {
+		if name, ok := obj["name"].(string); ok {
+			p.named[avroFullName(name, namespace)] = s
+		}
+	}
+
+	switch obj["type"] {
+	case "record", "error":
+		s := &InputSchema{
+			types:      map[string]struct{}{inputSchemaObject: {}},
+			properties: map[string]*InputSchema{},
+			closed:     true,
+		}
+		register(s)
+		fields, _ := obj["fields"].([]any)
+		for _, f := range fields {
+			fObj, ok := f.(map[string]any)
+			if !ok {
+				return nil, errors.New("expected record field to be an object")
+			}
+			name, _ := fObj["name"].(string)
+			fs, err := p.parse(fObj["type"], namespace)
+			if err != nil {
+				return nil, fmt.Errorf("field %v: %w", name, err)
+			}
+			s.properties[name] = fs
+		}
+		return s, nil
+	case "enum", "fixed":
+		s := &InputSchema{types: map[string]struct{}{"string": {}}}
+		register(s)
+		return s, nil
+	case "array":
+		items, err := p.parse(obj["items"], namespace)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		return &InputSchema{types: map[string]struct{}{inputSchemaArray: {}}, items: items}, nil
+	case "map":
+		values, err := p.parse(obj["values"], namespace)
+		if err != nil {
+			return nil, fmt.Errorf("values: %w", err)
+		}
+		return &InputSchema{types: map[string]struct{}{inputSchemaObject: {}}, additional: values}, nil
+	}
+	// Primitive types with attributes such as logical types.
+	return p.parse(obj["type"], namespace)
+}
@@ -0,0 +1,101 @@
+package docs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputSchemaCheckPath(t *testing.T) {
+	jsonSchema := `{
+  "type": "object",
+  "additionalProperties": false,
+  "definitions": {
+    "node": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "value": { "type": "integer" },
+        "children": { "type": "array", "items": { "$ref": "#/definitions/node" } }
+      }
+    }
+  },
+  "properties": {
+    "tree": { "$ref": "#/definitions/node" },
+    "labels": { "type": "object", "additionalProperties": { "type": "string" } },
+    "anything": {},
+    "either": {
+      "anyOf": [
+        { "type": "string" },
+        { "type": "object", "properties": { "a": { "type": "string" } }, "additionalProperties": false }
+      ]
+    },
+    "nullable": { "type": ["null", "object"], "properties": { "b": { "type": "boolean" } }, "additionalProperties": false }
+  }
+}`
+
+	avroSchema := `{
+  "type": "record",
+  "name": "event",
+  "namespace": "com.example",
+  "fields": [
+    { "name": "id", "type": "string" },
+    { "name": "attrs", "type": { "type": "map", "values": "long" } },
+    { "name": "items", "type": { "type": "array", "items": { "type": "record", "name": "item", "fields": [{ "name": "sku", "type": "string" }] } } },
+    { "name": "next", "type": ["null", "com.example.event"] }
+  ]
+}`
+
+	tests := []struct {
+		name        string
+		schema      string
+		format      string
+		path        []string
+		index       int
+		explanation string
+	}{
+		{name: "json root field", schema: jsonSchema, path: []string{"tree"}, index: -1},
+		{name: "json unknown root field", schema: jsonSchema, path: []string{"nope"}, index: 0, explanation: "field nope does not exist within the root of the document according to the input schema"},
+		{name: "json recursive ref", schema: jsonSchema, path: []string{"tree", "children", "0", "children", "1", "value"}, index: -1},
+		{name: "json recursive ref unknown", schema: jsonSchema, path: []string{"tree", "children", "0", "valeu"}, index: 3, explanation: "field valeu does not exist within field tree.children.0 according to the input schema"},
+		{name: "json scalar mismatch", schema: jsonSchema, path: []string{"tree", "value", "foo"}, index: 2, explanation: "field tree.value is of type integer and therefore cannot contain field foo"},
+		{name: "json additional properties", schema: jsonSchema, path: []string{"labels", "whatever"}, index: -1},
+		{name: "json additional properties mismatch", schema: jsonSchema, path: []string{"labels", "whatever", "deeper"}, index: 2},
+		{name: "json unconstrained", schema: jsonSchema, path: []string{"anything", "a", "b", "c"}, index: -1},
+		{name: "json any of", schema: jsonSchema, path: []string{"either", "a"}, index: -1},
+		{name: "json any of unknown", schema: jsonSchema, path: []string{"either", "b"}, index: 1, explanation: "field b does not exist within field either according to the input schema"},
+		{name: "json type union", schema: jsonSchema, path: []string{"nullable", "b"}, index: -1},
+		{name: "avro record", schema: avroSchema, path: []string{"items", "0", "sku"}, index: -1},
+		{name: "avro unknown", schema: avroSchema, path: []string{"items", "0", "name"}, index: 2},
+		{name: "avro map", schema: avroSchema, path: []string{"attrs", "foo"}, index: -1},
+		{name: "avro recursive union", schema: avroSchema, path: []string{"next", "next", "id"}, index: -1},
+		{name: "avro wrapped union", schema: avroSchema, path: []string{"next", "com.example.event", "id"}, index: -1},
+		{name: "avro scalar mismatch", schema: avroSchema, path: []string{"id", "foo"}, index: 1, explanation: "field id is of type string and therefore cannot contain field foo"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			s, err := ParseInputSchema(test.format, []byte(test.schema))
+			require.NoError(t, err)
+
+			index, explanation := s.checkPath(test.path)
+			assert.Equal(t, test.index, index)
+			if test.explanation != "" {
+				assert.Equal(t, test.explanation, explanation)
+			}
+		})
+	}
+}
+
+func TestInputSchemaParseErrors(t *testing.T) {
+	_, err := ParseInputSchema("", []byte(`{"$ref":"http://example.com/schema.json"}`))
+	assert.EqualError(t, err, "only local schema references are supported: http://example.com/schema.json")
+
+	_, err = ParseInputSchema("avro", []byte(`{"type":"record","name":"foo","fields":[{"name":"bar","type":"baz"}]}`))
+	assert.EqualError(t, err, "field bar: unknown avro type: baz")
+
+	_, err = ParseInputSchema("", []byte(`not json`))
+	assert.Error(t, err)
+}
@@ -649,6 +649,13 @@ type LintConfig struct {
 
 	// Require labels for components.
 	RequireLabels bool
+
+	// An optional schema of the documents consumed by the input, used by
+	// LintInputSchema to detect references to fields that cannot exist.
+	BloblangInputSchema *InputSchema
+
+	// Custom rules that are checked against each component of a config.
+	Rules []LintRule
 }
 
 // NewLintConfig creates a default linting config.
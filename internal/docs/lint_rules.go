@@ -0,0 +1,155 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+// LintRule is a custom linting rule consisting of a Bloblang predicate that is
+// executed against the config of each component it applies to, where a result
+// of false means the component violates the rule.
+type LintRule struct {
+	Name        string
+	Description string
+	Level       LintLevel
+
+	// The type of component the rule applies to, where an empty type means the
+	// rule applies to components of any type.
+	Type Type
+
+	// The name of the component implementation the rule applies to, where an
+	// empty name means the rule applies to all implementations.
+	Component string
+
+	check *bloblang.Executor
+}
+
+type lintRulesConfig struct {
+	Rules []lintRuleConfig `yaml:"rules"`
+}
+
+type lintRuleConfig struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Level       string `yaml:"level"`
+	Type        string `yaml:"type"`
+	Component   string `yaml:"component"`
+	Check       string `yaml:"check"`
+}
+
+// ParseLintRules parses a YAML document containing a pack of custom linting
+// rules, where the checks of each rule are parsed with the provided Bloblang
+// environment.
+func ParseLintRules(env *bloblang.Environment, b []byte) ([]LintRule, error) {
+	var conf lintRulesConfig
+	if err := yaml.Unmarshal(b, &conf); err != nil {
+		return nil, err
+	}
+
+	rules := make([]LintRule, 0, len(conf.Rules))
+	for i, rc := range conf.Rules {
+		if rc.Name == "" {
+			return nil, fmt.Errorf("rule %v: a name must be specified", i)
+		}
+		if rc.Check == "" {
+			return nil, fmt.Errorf("rule %v: a check must be specified", rc.Name)
+		}
+
+		r := LintRule{
+			Name:        rc.Name,
+			Description: rc.Description,
+			Type:        Type(rc.Type),
+			Component:   rc.Component,
+		}
+
+		switch rc.Level {
+		case "", "error":
+			r.Level = LintError
+		case "warning":
+			r.Level = LintWarning
+		default:
+			return nil, fmt.Errorf("rule %v: level must be either error or warning, got %v", rc.Name, rc.Level)
+		}
+
+		if r.Type != "" && !isComponentType(r.Type) {
+			return nil, fmt.Errorf("rule %v: unrecognised component type %v", rc.Name, rc.Type)
+		}
+
+		var err error
+		if r.check, err = env.Parse(rc.Check); err != nil {
+			return nil, fmt.Errorf("rule %v: failed to parse check: %w", rc.Name, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func isComponentType(t Type) bool {
+	for _, ct := range Types() {
+		if ct == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (r LintRule) appliesTo(cType Type, name string) bool {
+	if r.Type != "" && r.Type != cType {
+		return false
+	}
+	if r.Component != "" && r.Component != name {
+		return false
+	}
+	return true
+}
+
+func (r LintRule) lint(line int, v any) []Lint {
+	newLint := NewLintError
+	if r.Level == LintWarning {
+		newLint = NewLintWarning
+	}
+
+	res, err := r.check.Query(v)
+	if err != nil {
+		if errors.Is(err, bloblang.ErrRootDeleted) {
+			return nil
+		}
+		return []Lint{newLint(line, LintCustom, fmt.Sprintf("rule %v failed to execute: %v", r.Name, err))}
+	}
+
+	pass, ok := res.(bool)
+	if !ok {
+		return []Lint{newLint(line, LintCustom, fmt.Sprintf("rule %v must return a boolean, got %T", r.Name, res))}
+	}
+	if pass {
+		return nil
+	}
+
+	what := fmt.Sprintf("rule %v failed", r.Name)
+	if r.Description != "" {
+		what += ": " + r.Description
+	}
+	return []Lint{newLint(line, LintCustom, what)}
+}
+
+func lintYAMLRules(ctx LintContext, cType Type, name string, node *yaml.Node) (lints []Lint) {
+	var v any
+	decoded := false
+	for _, r := range ctx.conf.Rules {
+		if !r.appliesTo(cType, name) {
+			continue
+		}
+		if !decoded {
+			if err := node.Decode(&v); err != nil {
+				return nil
+			}
+			decoded = true
+		}
+		lints = append(lints, r.lint(node.Line, v)...)
+	}
+	return
+}
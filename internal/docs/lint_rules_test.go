@@ -0,0 +1,155 @@
+package docs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestLintRules(t *testing.T) {
+	prov := docs.NewMappedDocsProvider()
+	for _, name := range []string{"foo", "bar"} {
+		prov.RegisterDocs(docs.ComponentSpec{
+			Name: name,
+			Type: docs.TypeOutput,
+			Config: docs.FieldComponent().WithChildren(
+				docs.FieldString("compression", "").HasDefault("none"),
+			),
+		})
+	}
+
+	rules, err := docs.ParseLintRules(bloblang.GlobalEnvironment(), []byte(`
+rules:
+  - name: foo_compression
+    description: foo must be compressed
+    type: output
+    component: foo
+    check: this.foo.compression.or("none") != "none"
+  - name: no_labels
+    level: warning
+    check: '!this.exists("label")'
+  - name: inputs_only
+    type: input
+    check: "false"
+`))
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+
+	tests := []struct {
+		name   string
+		config string
+		res    []docs.Lint
+	}{
+		{
+			name: "passes all rules",
+			config: `
+foo:
+  compression: gzip
+`,
+		},
+		{
+			name: "fails component rule",
+			config: `
+foo: {}
+`,
+			res: []docs.Lint{
+				docs.NewLintError(2, docs.LintCustom, "rule foo_compression failed: foo must be compressed"),
+			},
+		},
+		{
+			name: "fails warning rule",
+			config: `
+label: meow
+bar: {}
+`,
+			res: []docs.Lint{
+				docs.NewLintWarning(2, docs.LintCustom, "rule no_labels failed"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.config), &node))
+
+			lConf := docs.NewLintConfig()
+			lConf.DocsProvider = prov
+			lConf.Rules = rules
+
+			lints := docs.LintYAML(docs.NewLintContext(lConf), docs.TypeOutput, &node)
+			assert.Equal(t, test.res, lints)
+		})
+	}
+}
+
+func TestLintRulesNonBoolean(t *testing.T) {
+	prov := docs.NewMappedDocsProvider()
+	prov.RegisterDocs(docs.ComponentSpec{
+		Name:   "foo",
+		Type:   docs.TypeOutput,
+		Config: docs.FieldComponent().WithChildren(),
+	})
+
+	rules, err := docs.ParseLintRules(bloblang.GlobalEnvironment(), []byte(`
+rules:
+  - name: not_a_bool
+    check: this.foo
+`))
+	require.NoError(t, err)
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`foo: {}`), &node))
+
+	lConf := docs.NewLintConfig()
+	lConf.DocsProvider = prov
+	lConf.Rules = rules
+
+	assert.Equal(t, []docs.Lint{
+		docs.NewLintError(1, docs.LintCustom, "rule not_a_bool must return a boolean, got map[string]interface {}"),
+	}, docs.LintYAML(docs.NewLintContext(lConf), docs.TypeOutput, &node))
+}
+
+func TestParseLintRulesErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing name": `
+rules:
+  - check: 'true'
+`,
+		"missing check": `
+rules:
+  - name: foo
+`,
+		"bad level": `
+rules:
+  - name: foo
+    level: meh
+    check: 'true'
+`,
+		"bad type": `
+rules:
+  - name: foo
+    type: nope
+    check: 'true'
+`,
+		"bad check": `
+rules:
+  - name: foo
+    check: 'this.'
+`,
+	}
+
+	for name, conf := range tests {
+		conf := conf
+		t.Run(name, func(t *testing.T) {
+			_, err := docs.ParseLintRules(bloblang.GlobalEnvironment(), []byte(conf))
+			require.Error(t, err)
+		})
+	}
+}
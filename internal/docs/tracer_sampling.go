@@ -0,0 +1,16 @@
+package docs
+
+// TracerSamplingFieldSpec is a field spec that describes tail based sampling of
+// traces for a tracer.
+func TracerSamplingFieldSpec(name string) FieldSpec {
+	return FieldObject(name, "EXPERIMENTAL: Optional tail based sampling of traces, where the decision of whether to export the trace of a message is made once the message has been delivered (or has failed to be delivered). Rules are evaluated in order and the ratio of the first matching rule is used to sample the trace, the `default_ratio` is used when no rules match. For more information check out the [tracers documentation](/docs/components/tracers/about#tail-sampling).").WithChildren(
+		FieldBool("enabled", "Whether tail based sampling is enabled.").HasDefault(false),
+		FieldObject("rules", "A list of sampling rules.").Array().WithChildren(
+			FieldString("inputs", "An optional list of input labels that the rule applies to. When empty the rule applies to messages from all inputs.").Array().HasDefault([]any{}),
+			FieldBloblang("check", "An optional [Bloblang query](/docs/guides/bloblang/about) that should return a boolean value indicating whether the rule applies to a message. The query is executed on the message as it was when consumed by the input, and the functions `errored()` and `error()` reflect whether the message failed to be delivered or had errors recorded against its trace.", `errored()`, `this.user.tier == "premium"`).HasDefault(""),
+			FieldFloat("ratio", "The ratio (between 0 and 1) of matching traces to sample.").HasDefault(1.0),
+		).HasDefault([]any{}),
+		FieldFloat("default_ratio", "The ratio (between 0 and 1) of traces to sample when no rules match.").HasDefault(1.0),
+		FieldInt("max_pending_traces", "The maximum number of traces to hold pending a sampling decision. When exceeded the oldest pending traces are sampled at the `default_ratio`.").HasDefault(10000).Advanced(),
+	).Advanced().AtVersion("4.20.0")
+}
@@ -531,6 +531,7 @@ func LintYAML(ctx LintContext, cType Type, node *yaml.Node) []Lint {
 		lints = append(lints, NewLintError(node.Line, LintMissingLabel, fmt.Sprintf("label is required for %s", cSpec.Name)))
 	}
 
+	lints = append(lints, lintYAMLRules(ctx, cType, name, node)...)
 	return lints
 }
 
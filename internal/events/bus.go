@@ -0,0 +1,151 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// Bus is an Emitter that buffers events and exposes them as a stream of
+// transactions in the same way as an input, which allows them to be consumed
+// by any output.
+//
+// Emitting an event never blocks, if the buffer of pending events is full then
+// the event is discarded instead.
+type Bus struct {
+	types map[Type]struct{}
+
+	events       chan Event
+	transactions chan message.Transaction
+
+	log      log.Modular
+	mDropped metrics.StatCounter
+
+	shutSig *shutdown.Signaller
+}
+
+// NewBus creates a new event bus that buffers up to bufferSize events. If one
+// or more types are specified then events of any other type are ignored.
+func NewBus(types []string, bufferSize int, logger log.Modular, stats metrics.Type) (*Bus, error) {
+	if bufferSize <= 0 {
+		return nil, fmt.Errorf("buffer_size must be greater than zero, got %v", bufferSize)
+	}
+
+	var typeSet map[Type]struct{}
+	if len(types) > 0 {
+		known := Types()
+		typeSet = make(map[Type]struct{}, len(types))
+		for _, t := range types {
+			if _, exists := known[Type(t)]; !exists {
+				return nil, fmt.Errorf("unrecognised event type: %v", t)
+			}
+			typeSet[Type(t)] = struct{}{}
+		}
+	}
+
+	b := &Bus{
+		types:        typeSet,
+		events:       make(chan Event, bufferSize),
+		transactions: make(chan message.Transaction),
+		log:          logger,
+		mDropped:     stats.GetCounter("events_dropped"),
+		shutSig:      shutdown.NewSignaller(),
+	}
+	go b.loop()
+	return b, nil
+}
+
+// Emit an event to the bus.
+func (b *Bus) Emit(e Event) {
+	if b.types != nil {
+		if _, exists := b.types[e.Type]; !exists {
+			return
+		}
+	}
+	select {
+	case b.events <- e:
+	default:
+		b.mDropped.Incr(1)
+	}
+}
+
+func (b *Bus) loop() {
+	defer func() {
+		close(b.transactions)
+		b.shutSig.ShutdownComplete()
+	}()
+
+	send := func(e Event) bool {
+		part := message.NewPart(nil)
+		part.SetStructuredMut(e.AsMap())
+
+		resChan := make(chan error, 1)
+		select {
+		case b.transactions <- message.NewTransaction(message.Batch{part}, resChan):
+		case <-b.shutSig.CloseNowChan():
+			return false
+		}
+		select {
+		case res := <-resChan:
+			if res != nil {
+				b.log.Errorf("Failed to deliver %v event: %v\n", e.Type, res)
+			}
+		case <-b.shutSig.CloseNowChan():
+			return false
+		}
+		return true
+	}
+
+	for {
+		select {
+		case e := <-b.events:
+			if !send(e) {
+				return
+			}
+		case <-b.shutSig.CloseAtLeisureChan():
+			// Flush any events that are still pending before closing.
+			for {
+				select {
+				case e := <-b.events:
+					if !send(e) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// TransactionChan returns a channel of transactions, each containing a single
+// message describing an event as a structured object.
+func (b *Bus) TransactionChan() <-chan message.Transaction {
+	return b.transactions
+}
+
+// TriggerStopConsuming instructs the bus to deliver any pending events and then
+// close the transactions channel. This call does not block.
+func (b *Bus) TriggerStopConsuming() {
+	b.shutSig.CloseAtLeisure()
+}
+
+// TriggerCloseNow instructs the bus to close the transactions channel
+// immediately, discarding pending events. This call does not block.
+func (b *Bus) TriggerCloseNow() {
+	b.shutSig.CloseNow()
+}
+
+// WaitForClose blocks until the bus has closed its transactions channel.
+func (b *Bus) WaitForClose(ctx context.Context) error {
+	select {
+	case <-b.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
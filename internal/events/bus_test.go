@@ -0,0 +1,99 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/events"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+)
+
+func readEvent(t *testing.T, b *events.Bus) map[string]any {
+	t.Helper()
+
+	select {
+	case tran, open := <-b.TransactionChan():
+		require.True(t, open)
+		require.Len(t, tran.Payload, 1)
+
+		v, err := tran.Payload[0].AsStructured()
+		require.NoError(t, err)
+		require.NoError(t, tran.Ack(context.Background(), nil))
+
+		obj, ok := v.(map[string]any)
+		require.True(t, ok)
+		delete(obj, "timestamp")
+		return obj
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return nil
+}
+
+func TestBusDelivery(t *testing.T) {
+	b, err := events.NewBus([]string{"connection_lost", "batch_dropped"}, 10, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetEventEmitter(b))
+	require.NoError(t, err)
+
+	events.FromManager(mgr.ForStream("foo").IntoPath("input")).Emit(events.New(events.TypeConnectionLost, "lost it"))
+	events.FromManager(mgr).Emit(events.New(events.TypeStreamStarted, "ignored"))
+	events.FromManager(mgr).Emit(events.New(events.TypeBatchDropped, "dropped it").WithField("size", 5))
+
+	assert.Equal(t, map[string]any{
+		"type":    "connection_lost",
+		"message": "lost it",
+		"stream":  "foo",
+		"path":    "root.input",
+	}, readEvent(t, b))
+
+	assert.Equal(t, map[string]any{
+		"type":    "batch_dropped",
+		"message": "dropped it",
+		"fields":  map[string]any{"size": 5},
+	}, readEvent(t, b))
+
+	b.TriggerStopConsuming()
+	_, open := <-b.TransactionChan()
+	assert.False(t, open)
+	require.NoError(t, b.WaitForClose(context.Background()))
+}
+
+func TestBusFlushOnStop(t *testing.T) {
+	b, err := events.NewBus(nil, 2, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		b.Emit(events.New(events.TypeStreamStopped, msg))
+	}
+	b.TriggerStopConsuming()
+
+	var msgs []any
+	for tran := range b.TransactionChan() {
+		v, err := tran.Payload[0].AsStructured()
+		require.NoError(t, err)
+		msgs = append(msgs, v.(map[string]any)["message"])
+		require.NoError(t, tran.Ack(context.Background(), nil))
+	}
+
+	// The buffer is full after the first event is read by the bus, and
+	// therefore at least one event must have been dropped.
+	assert.GreaterOrEqual(t, len(msgs), 2)
+	assert.Less(t, len(msgs), 4)
+	assert.Equal(t, "a", msgs[0])
+}
+
+func TestBusConfigErrors(t *testing.T) {
+	_, err := events.NewBus(nil, 0, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "buffer_size must be greater than zero, got 0")
+
+	_, err = events.NewBus([]string{"nope"}, 10, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "unrecognised event type: nope")
+}
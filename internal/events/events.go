@@ -0,0 +1,133 @@
+// Package events provides a mechanism for components to emit structured events
+// describing notable operational occurrences, such as lost connections and
+// dropped messages, which can be routed to an output by the service.
+package events
+
+import (
+	"time"
+)
+
+// Type describes the kind of an event.
+type Type string
+
+// Types of event emitted by Benthos components.
+const (
+	TypeStreamStarted    Type = "stream_started"
+	TypeStreamStopped    Type = "stream_stopped"
+	TypeConfigReloaded   Type = "config_reloaded"
+	TypeConnectionLost   Type = "connection_lost"
+	TypeBatchDropped     Type = "batch_dropped"
+	TypeRetriesExhausted Type = "retries_exhausted"
+	TypeMessageLineage   Type = "message_lineage"
+)
+
+// Types returns all event types along with a description of each.
+func Types() map[Type]string {
+	return map[Type]string{
+		TypeStreamStarted:    "A stream has been created and started.",
+		TypeStreamStopped:    "A stream has stopped, either due to the input terminating or due to a shut down.",
+		TypeConfigReloaded:   "A configuration change has been applied, either in place or by restarting the stream.",
+		TypeConnectionLost:   "An input or output has lost its connection and will attempt to reconnect.",
+		TypeBatchDropped:     "A batch of messages has been dropped rather than delivered, for example by a `drop_on` output.",
+		TypeRetriesExhausted: "A component has exhausted its retry attempts, for example when an input gives up reconnecting or a `retry` output reaches its `max_retries`.",
+		TypeMessageLineage:   "A message sampled for lineage has been acknowledged, the event contains the components that handled it and the outputs it was delivered to. Only emitted when `lineage.enabled` is `true`.",
+	}
+}
+
+// Event is a structured description of something notable that happened within
+// a Benthos service.
+type Event struct {
+	Type      Type
+	Timestamp time.Time
+	Message   string
+
+	// The stream, label and path of the component that emitted the event, which
+	// are populated automatically by the manager.
+	Stream string
+	Label  string
+	Path   string
+
+	// Fields contains optional additional details specific to the type of the
+	// event.
+	Fields map[string]any
+}
+
+// New creates an event of a given type with a message and the current time as
+// the timestamp.
+func New(t Type, msg string) Event {
+	return Event{
+		Type:      t,
+		Timestamp: time.Now(),
+		Message:   msg,
+	}
+}
+
+// WithField returns the event with an additional field set.
+func (e Event) WithField(k string, v any) Event {
+	fields := make(map[string]any, len(e.Fields)+1)
+	for fk, fv := range e.Fields {
+		fields[fk] = fv
+	}
+	fields[k] = v
+	e.Fields = fields
+	return e
+}
+
+// AsMap returns a structured representation of the event.
+func (e Event) AsMap() map[string]any {
+	m := map[string]any{
+		"type":      string(e.Type),
+		"timestamp": e.Timestamp.Format(time.RFC3339Nano),
+		"message":   e.Message,
+	}
+	if e.Stream != "" {
+		m["stream"] = e.Stream
+	}
+	if e.Label != "" {
+		m["label"] = e.Label
+	}
+	if e.Path != "" {
+		m["path"] = e.Path
+	}
+	if len(e.Fields) > 0 {
+		fields := make(map[string]any, len(e.Fields))
+		for k, v := range e.Fields {
+			fields[k] = v
+		}
+		m["fields"] = fields
+	}
+	return m
+}
+
+//------------------------------------------------------------------------------
+
+// Emitter receives events from components. Implementations must not block.
+type Emitter interface {
+	Emit(e Event)
+}
+
+// EmitterFunc is a closure that implements Emitter.
+type EmitterFunc func(e Event)
+
+// Emit calls the closure with the event.
+func (f EmitterFunc) Emit(e Event) {
+	f(e)
+}
+
+type noopEmitter struct{}
+
+func (noopEmitter) Emit(Event) {}
+
+// Noop returns an emitter that discards all events.
+func Noop() Emitter {
+	return noopEmitter{}
+}
+
+// FromManager returns the event emitter of a manager, or an emitter that
+// discards events if the manager does not support them.
+func FromManager(mgr any) Emitter {
+	if em, ok := mgr.(interface{ Events() Emitter }); ok {
+		return em.Events()
+	}
+	return Noop()
+}
@@ -0,0 +1,181 @@
+// Package health provides a registry for tracking the connection state and
+// recent activity of individual inputs and outputs, which is used in order to
+// provide detailed readiness information.
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Registry keeps track of the health of components.
+type Registry struct {
+	mut        sync.Mutex
+	components map[*Component]struct{}
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		components: map[*Component]struct{}{},
+	}
+}
+
+// Register a new component with the registry, where connected is called in
+// order to determine whether the component is currently connected. The returned
+// component should be deregistered once it is closed.
+func (r *Registry) Register(stream, label, path, kind, typeStr string, connected func() bool) *Component {
+	c := &Component{
+		reg:       r,
+		stream:    stream,
+		label:     label,
+		path:      path,
+		kind:      kind,
+		typeStr:   typeStr,
+		connected: connected,
+	}
+	r.mut.Lock()
+	r.components[c] = struct{}{}
+	r.mut.Unlock()
+	return c
+}
+
+// Statuses returns the status of each registered component of a given stream,
+// sorted by path, kind and label.
+func (r *Registry) Statuses(stream string) []Status {
+	r.mut.Lock()
+	statuses := make([]Status, 0, len(r.components))
+	for c := range r.components {
+		if c.stream == stream {
+			statuses = append(statuses, c.Status())
+		}
+	}
+	r.mut.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Path != statuses[j].Path {
+			return statuses[i].Path < statuses[j].Path
+		}
+		if statuses[i].Kind != statuses[j].Kind {
+			return statuses[i].Kind < statuses[j].Kind
+		}
+		return statuses[i].Label < statuses[j].Label
+	})
+	return statuses
+}
+
+//------------------------------------------------------------------------------
+
+// Component tracks the health of a single input or output. All methods are
+// safe to call concurrently.
+type Component struct {
+	reg *Registry
+
+	stream  string
+	label   string
+	path    string
+	kind    string
+	typeStr string
+
+	connected func() bool
+
+	mut           sync.Mutex
+	lastErr       string
+	lastErrAt     time.Time
+	lastSuccessAt time.Time
+}
+
+// NewUnregistered returns a component that tracks health information but is not
+// registered to any registry.
+func NewUnregistered(kind, typeStr string, connected func() bool) *Component {
+	return &Component{kind: kind, typeStr: typeStr, connected: connected}
+}
+
+// SetError records the most recent error encountered by the component.
+func (c *Component) SetError(err error) {
+	if err == nil {
+		return
+	}
+	c.mut.Lock()
+	c.lastErr = err.Error()
+	c.lastErrAt = time.Now()
+	c.mut.Unlock()
+}
+
+// MarkSuccess records that the component successfully consumed or delivered a
+// message.
+func (c *Component) MarkSuccess() {
+	c.mut.Lock()
+	c.lastSuccessAt = time.Now()
+	c.mut.Unlock()
+}
+
+// Deregister removes the component from its registry.
+func (c *Component) Deregister() {
+	if c.reg == nil {
+		return
+	}
+	c.reg.mut.Lock()
+	delete(c.reg.components, c)
+	c.reg.mut.Unlock()
+}
+
+// Status returns the current status of the component.
+func (c *Component) Status() Status {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	s := Status{
+		Label:     c.label,
+		Path:      c.path,
+		Kind:      c.kind,
+		Type:      c.typeStr,
+		Connected: c.connected(),
+		LastError: c.lastErr,
+	}
+	if !c.lastErrAt.IsZero() {
+		s.LastErrorAt = c.lastErrAt.Format(time.RFC3339Nano)
+	}
+	if !c.lastSuccessAt.IsZero() {
+		s.LastSuccessAt = c.lastSuccessAt.Format(time.RFC3339Nano)
+		s.SinceLastSuccess = time.Since(c.lastSuccessAt).String()
+	}
+	return s
+}
+
+// Status is a snapshot of the health of a component.
+type Status struct {
+	Label            string `json:"label,omitempty"`
+	Path             string `json:"path"`
+	Kind             string `json:"kind"`
+	Type             string `json:"type"`
+	Connected        bool   `json:"connected"`
+	LastError        string `json:"last_error,omitempty"`
+	LastErrorAt      string `json:"last_error_at,omitempty"`
+	LastSuccessAt    string `json:"last_success_at,omitempty"`
+	SinceLastSuccess string `json:"since_last_success,omitempty"`
+}
+
+//------------------------------------------------------------------------------
+
+// FromManager registers a component with the health registry of a manager, or
+// returns an unregistered component if the manager does not support health
+// tracking.
+func FromManager(mgr any, kind, typeStr string, connected func() bool) *Component {
+	if hm, ok := mgr.(interface {
+		RegisterHealth(kind, typeStr string, connected func() bool) *Component
+	}); ok {
+		return hm.RegisterHealth(kind, typeStr, connected)
+	}
+	return NewUnregistered(kind, typeStr, connected)
+}
+
+// StatusesFromManager returns the status of each component registered to the
+// stream of a manager, or nil if the manager does not support health tracking.
+func StatusesFromManager(mgr any) []Status {
+	if hm, ok := mgr.(interface{ HealthStatuses() []Status }); ok {
+		return hm.HealthStatuses()
+	}
+	return nil
+}
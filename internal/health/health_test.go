@@ -0,0 +1,44 @@
+package health
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryStatuses(t *testing.T) {
+	reg := NewRegistry()
+
+	out := reg.Register("foo", "b", "root.output", "output", "drop", func() bool { return false })
+	in := reg.Register("foo", "a", "root.input", "input", "generate", func() bool { return true })
+	other := reg.Register("bar", "", "root.input", "input", "generate", func() bool { return true })
+
+	out.SetError(errors.New("nope"))
+	in.MarkSuccess()
+
+	statuses := reg.Statuses("foo")
+	require.Len(t, statuses, 2)
+
+	assert.Equal(t, "a", statuses[0].Label)
+	assert.Equal(t, "input", statuses[0].Kind)
+	assert.True(t, statuses[0].Connected)
+	assert.Empty(t, statuses[0].LastError)
+	assert.NotEmpty(t, statuses[0].LastSuccessAt)
+	assert.NotEmpty(t, statuses[0].SinceLastSuccess)
+
+	assert.Equal(t, "b", statuses[1].Label)
+	assert.False(t, statuses[1].Connected)
+	assert.Equal(t, "nope", statuses[1].LastError)
+	assert.NotEmpty(t, statuses[1].LastErrorAt)
+	assert.Empty(t, statuses[1].LastSuccessAt)
+
+	out.Deregister()
+	in.Deregister()
+	assert.Empty(t, reg.Statuses("foo"))
+	assert.Len(t, reg.Statuses("bar"), 1)
+
+	other.Deregister()
+	assert.Empty(t, reg.Statuses("bar"))
+}
@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	acFieldAdaptiveConcurrency = "adaptive_concurrency"
+	acFieldEnabled             = "enabled"
+	acFieldMinInFlight         = "min_in_flight"
+	acFieldBackoffRatio        = "backoff_ratio"
+	acFieldLatencyThreshold    = "latency_threshold"
+)
+
+// AdaptiveConcurrencyField returns a config field spec for an optional AIMD
+// controller that limits the number of requests an output has in flight.
+func AdaptiveConcurrencyField() *service.ConfigField {
+	return service.NewObjectField(acFieldAdaptiveConcurrency,
+		service.NewBoolField(acFieldEnabled).
+			Description("Whether to adapt the number of requests in flight to the capacity of the server.").
+			Default(false),
+		service.NewIntField(acFieldMinInFlight).
+			Description("The minimum number of requests in flight, which is also the number of requests in flight when the output starts.").
+			Default(1),
+		service.NewFloatField(acFieldBackoffRatio).
+			Description("The ratio by which the number of requests in flight is multiplied when a request fails or exceeds the latency threshold, must be between zero and one.").
+			Default(0.9),
+		service.NewDurationField(acFieldLatencyThreshold).
+			Description("An optional latency above which a request is considered a sign of congestion, and therefore reduces the number of requests in flight even when it succeeds. When empty only failed requests reduce the number of requests in flight.").
+			Example("500ms").
+			Default(""),
+	).Description("Adapt the number of requests in flight using an AIMD (additive increase, multiplicative decrease) algorithm, where the limit increases by one for each successful request made whilst the limit is saturated, and is reduced by the `backoff_ratio` for each request that fails or exceeds the `latency_threshold`. The limit never exceeds `max_in_flight`, which allows the parallelism of the output to track the capacity of the server automatically. The current limit is exposed with the gauge `output_max_in_flight`.").
+		Advanced().
+		Version("4.20.0")
+}
+
+// AdaptiveConcurrencyFromParsed returns an AIMD controller from a parsed config
+// field spec created with AdaptiveConcurrencyField, or nil if the controller
+// is disabled.
+func AdaptiveConcurrencyFromParsed(pConf *service.ParsedConfig, maxInFlight int) (*output.AdaptiveConcurrency, error) {
+	pConf = pConf.Namespace(acFieldAdaptiveConcurrency)
+
+	if enabled, err := pConf.FieldBool(acFieldEnabled); err != nil || !enabled {
+		return nil, err
+	}
+
+	minInFlight, err := pConf.FieldInt(acFieldMinInFlight)
+	if err != nil {
+		return nil, err
+	}
+
+	backoffRatio, err := pConf.FieldFloat(acFieldBackoffRatio)
+	if err != nil {
+		return nil, err
+	}
+
+	latencyStr, err := pConf.FieldString(acFieldLatencyThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	var latencyThreshold time.Duration
+	if latencyStr != "" {
+		if latencyThreshold, err = pConf.FieldDuration(acFieldLatencyThreshold); err != nil {
+			return nil, err
+		}
+	}
+	return output.NewAdaptiveConcurrency(minInFlight, maxInFlight, backoffRatio, latencyThreshold)
+}
@@ -10,6 +10,8 @@ func AuthFieldSpecs() []*service.ConfigField {
 		oAuthFieldSpec(),
 		BasicAuthField(),
 		jwtFieldSpec(),
+		azureADFieldSpec(),
+		awsSigV4FieldSpec(),
 	}
 }
 
@@ -21,6 +23,8 @@ func AuthFieldSpecsExpanded() []*service.ConfigField {
 		oAuth2FieldSpec(),
 		BasicAuthField(),
 		jwtFieldSpec(),
+		azureADFieldSpec(),
+		awsSigV4FieldSpec(),
 	}
 }
 
@@ -93,8 +97,82 @@ func oAuth2FieldSpec() *service.ConfigField {
 			Default([]string{}).
 			Advanced().
 			Version("3.45.0"),
+
+		service.NewStringEnumField("grant_type", OAuth2GrantClientCredentials, OAuth2GrantJWTBearer, OAuth2GrantRefreshToken, OAuth2GrantTokenExchange).
+			Description("The grant used to obtain access tokens. The `client_credentials` grant authenticates with the client key and secret, the `jwt_bearer` grant authenticates with an assertion signed by a private key as used by service accounts, the `refresh_token` grant exchanges a refresh token obtained out of band via an authorization code flow, and the `token_exchange` grant exchanges a subject token issued by another identity provider.").
+			Default(OAuth2GrantClientCredentials).
+			Advanced().
+			Version("4.20.0"),
+
+		service.NewStringField("refresh_token").
+			Description("A refresh token obtained via an authorization code flow, used by the `refresh_token` grant.").
+			Default("").
+			Secret().
+			Advanced().
+			Version("4.20.0"),
+
+		service.NewObjectField("jwt_bearer",
+			service.NewStringField("private_key_file").
+				Description("A file containing a PEM encoded RSA private key used to sign assertions.").
+				Default(""),
+			service.NewStringField("private_key_id").
+				Description("An optional identifier of the private key, added to assertions as the `kid` header.").
+				Default(""),
+			service.NewStringField("issuer").
+				Description("The issuer of assertions, such as the email of a service account. Defaults to the `client_key` when empty.").
+				Default(""),
+			service.NewStringField("subject").
+				Description("An optional subject of assertions, such as the user to act on behalf of.").
+				Default(""),
+			service.NewStringField("audience").
+				Description("The audience of assertions. Defaults to the `token_url` when empty.").
+				Default(""),
+			service.NewStringField("expiry").
+				Description("The period of time assertions are valid for.").
+				Default("1h"),
+			service.NewAnyMapField("claims").
+				Description("Additional claims to add to assertions.").
+				Default(map[string]any{}),
+		).
+			Description("Settings for the `jwt_bearer` grant ([RFC 7523](https://www.rfc-editor.org/rfc/rfc7523)).").
+			Advanced().
+			Version("4.20.0"),
+
+		service.NewObjectField("token_exchange",
+			service.NewStringField("subject_token").
+				Description("The token to exchange.").
+				Default("").
+				Secret(),
+			service.NewStringField("subject_token_file").
+				Description("A file to read the token to exchange from each time a token is requested, such as a projected service account token, taking precedence over `subject_token`.").
+				Default(""),
+			service.NewStringField("subject_token_type").
+				Description("The type of the token to exchange.").
+				Default("urn:ietf:params:oauth:token-type:jwt"),
+			service.NewStringField("audience").
+				Description("An optional audience of the requested token.").
+				Default(""),
+			service.NewStringField("resource").
+				Description("An optional URI of the resource the requested token is intended for.").
+				Default(""),
+		).
+			Description("Settings for the `token_exchange` grant ([RFC 8693](https://www.rfc-editor.org/rfc/rfc8693)).").
+			Advanced().
+			Version("4.20.0"),
+
+		service.NewStringField("token_cache").
+			Description("An optional [cache resource](/docs/components/caches/about) used for sharing access tokens between components and across restarts, reducing the number of tokens requested from the token provider.").
+			Default("").
+			Advanced().
+			Version("4.20.0"),
+
+		service.NewStringField("token_cache_key").
+			Description("The key under which access tokens are stored within the `token_cache`, components configured with the same key share tokens. Defaults to a key derived from the grant, token URL and client key when empty.").
+			Default("").
+			Advanced().
+			Version("4.20.0"),
 	).
-		Description("Allows you to specify open authentication via OAuth version 2 using the client credentials token flow.").
+		Description("Allows you to specify open authentication via OAuth version 2, using the client credentials token flow by default.").
 		Advanced()
 }
 
@@ -126,6 +204,80 @@ func jwtFieldSpec() *service.ConfigField {
 		Advanced()
 }
 
+func azureADFieldSpec() *service.ConfigField {
+	return service.NewObjectField("azure_ad",
+		service.NewBoolField("enabled").
+			Description("Whether to authenticate requests with Azure AD access tokens.").
+			Default(false),
+
+		service.NewStringField("tenant_id").
+			Description("The Azure AD tenant to obtain tokens from.").
+			Default(""),
+
+		service.NewStringField("client_id").
+			Description("The client ID of an application registration to authenticate as.").
+			Default(""),
+
+		service.NewStringField("client_secret").
+			Description("The client secret of the application registration. When empty the [default credential chain](https://pkg.go.dev/github.com/Azure/azure-sdk-for-go/sdk/azidentity#DefaultAzureCredential) is used instead, which includes environment variables and managed identities.").
+			Default("").Secret(),
+
+		service.NewStringListField("scopes").
+			Description("The scopes to request tokens for, usually the application ID URI of the target resource suffixed with `/.default`.").
+			Default([]string{}).
+			Example([]string{"https://management.azure.com/.default"}),
+	).
+		Description("Allows you to authenticate requests with [Azure Active Directory](https://learn.microsoft.com/en-us/azure/active-directory/develop/v2-oauth2-client-creds-grant-flow) access tokens, as required by services such as API Management.").
+		Advanced().
+		Version("4.20.0")
+}
+
+func awsSigV4FieldSpec() *service.ConfigField {
+	return service.NewObjectField("aws_sigv4",
+		service.NewBoolField("enabled").
+			Description("Whether to sign requests with AWS Signature Version 4.").
+			Default(false),
+
+		service.NewStringField("service").
+			Description("The name of the AWS service requests are signed for.").
+			Default("").
+			Example("es").
+			Example("execute-api"),
+
+		service.NewStringField("region").
+			Description("The AWS region requests are signed for. When empty the region is obtained from the environment.").
+			Default(""),
+
+		service.NewObjectField("credentials",
+			service.NewStringField("profile").
+				Description("A profile from `~/.aws/credentials` to use.").
+				Default(""),
+			service.NewStringField("id").
+				Description("The ID of credentials to use.").
+				Default(""),
+			service.NewStringField("secret").
+				Description("The secret for the credentials being used.").
+				Default("").Secret(),
+			service.NewStringField("token").
+				Description("The token for the credentials being used, required when using short term credentials.").
+				Default(""),
+			service.NewBoolField("from_ec2_role").
+				Description("Use the credentials of a host EC2 machine configured to assume [an IAM role associated with the instance](https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_use_switch-role-ec2.html).").
+				Default(false),
+			service.NewStringField("role").
+				Description("A role ARN to assume.").
+				Default(""),
+			service.NewStringField("role_external_id").
+				Description("An external ID to provide when assuming a role.").
+				Default(""),
+		).
+			Description("Optional manual configuration of AWS credentials to use. More information can be found [in this document](/docs/guides/cloud/aws)."),
+	).
+		Description("Allows you to sign requests with [AWS Signature Version 4](https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_aws-signing.html), as required by IAM protected endpoints such as Amazon OpenSearch Service and API Gateway. Signing is applied after all other authentication methods and headers.").
+		Advanced().
+		Version("4.20.0")
+}
+
 //------------------------------------------------------------------------------
 
 // AuthSignerFromParsed takes a parsed config which is expected to contain
@@ -150,6 +302,12 @@ func authConfFromParsed(conf *service.ParsedConfig) (oldConf AuthConfig, err err
 	if oldConf.JWT, err = jwtAuthFromParsed(conf); err != nil {
 		return
 	}
+	if oldConf.AzureAD, err = azureADFromParsed(conf); err != nil {
+		return
+	}
+	if oldConf.AWSSigV4, err = awsSigV4FromParsed(conf); err != nil {
+		return
+	}
 	return
 }
 
@@ -230,3 +388,68 @@ func jwtAuthFromParsed(conf *service.ParsedConfig) (res JWTConfig, err error) {
 	}
 	return
 }
+
+func azureADFromParsed(conf *service.ParsedConfig) (res AzureADConfig, err error) {
+	res = NewAzureADConfig()
+	if !conf.Contains("azure_ad") {
+		return
+	}
+	conf = conf.Namespace("azure_ad")
+	if res.Enabled, err = conf.FieldBool("enabled"); err != nil {
+		return
+	}
+	if res.TenantID, err = conf.FieldString("tenant_id"); err != nil {
+		return
+	}
+	if res.ClientID, err = conf.FieldString("client_id"); err != nil {
+		return
+	}
+	if res.ClientSecret, err = conf.FieldString("client_secret"); err != nil {
+		return
+	}
+	if res.Scopes, err = conf.FieldStringList("scopes"); err != nil {
+		return
+	}
+	return
+}
+
+func awsSigV4FromParsed(conf *service.ParsedConfig) (res AWSSigV4Config, err error) {
+	res = NewAWSSigV4Config()
+	if !conf.Contains("aws_sigv4") {
+		return
+	}
+	conf = conf.Namespace("aws_sigv4")
+	if res.Enabled, err = conf.FieldBool("enabled"); err != nil {
+		return
+	}
+	if res.Service, err = conf.FieldString("service"); err != nil {
+		return
+	}
+	if res.Region, err = conf.FieldString("region"); err != nil {
+		return
+	}
+	cConf := conf.Namespace("credentials")
+	c := &res.Credentials
+	if c.Profile, err = cConf.FieldString("profile"); err != nil {
+		return
+	}
+	if c.ID, err = cConf.FieldString("id"); err != nil {
+		return
+	}
+	if c.Secret, err = cConf.FieldString("secret"); err != nil {
+		return
+	}
+	if c.Token, err = cConf.FieldString("token"); err != nil {
+		return
+	}
+	if c.FromEC2Role, err = cConf.FieldBool("from_ec2_role"); err != nil {
+		return
+	}
+	if c.Role, err = cConf.FieldString("role"); err != nil {
+		return
+	}
+	if c.RoleExternalID, err = cConf.FieldString("role_external_id"); err != nil {
+		return
+	}
+	return
+}
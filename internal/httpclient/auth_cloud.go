@@ -0,0 +1,232 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// AWSCredentialsConfig contains configuration params for AWS credentials.
+type AWSCredentialsConfig struct {
+	Profile        string `json:"profile" yaml:"profile"`
+	ID             string `json:"id" yaml:"id"`
+	Secret         string `json:"secret" yaml:"secret"`
+	Token          string `json:"token" yaml:"token"`
+	FromEC2Role    bool   `json:"from_ec2_role" yaml:"from_ec2_role"`
+	Role           string `json:"role" yaml:"role"`
+	RoleExternalID string `json:"role_external_id" yaml:"role_external_id"`
+}
+
+// AWSSigV4Config holds the configuration parameters for signing requests with
+// AWS Signature Version 4.
+type AWSSigV4Config struct {
+	Enabled     bool                 `json:"enabled" yaml:"enabled"`
+	Service     string               `json:"service" yaml:"service"`
+	Region      string               `json:"region" yaml:"region"`
+	Credentials AWSCredentialsConfig `json:"credentials" yaml:"credentials"`
+
+	// internal private fields
+	signerMx *sync.Mutex
+	signer   **v4.Signer
+	region   *string
+}
+
+// NewAWSSigV4Config returns a new AWSSigV4Config with default values.
+func NewAWSSigV4Config() AWSSigV4Config {
+	var signer *v4.Signer
+	var region string
+	return AWSSigV4Config{
+		Enabled:  false,
+		Service:  "",
+		Region:   "",
+		signerMx: &sync.Mutex{},
+		signer:   &signer,
+		region:   &region,
+	}
+}
+
+// Sign method to sign an HTTP request with AWS Signature Version 4.
+func (s AWSSigV4Config) Sign(req *http.Request) error {
+	if !s.Enabled {
+		return nil
+	}
+
+	signer, region, err := s.getSigner()
+	if err != nil {
+		return err
+	}
+
+	// The body is hashed as part of the signature, and therefore must be read
+	// in full before the request is sent.
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		body, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+	} else if req.Body != nil {
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body.Close()
+	}
+
+	if _, err := signer.Sign(req, bytes.NewReader(body), s.Service, region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	return nil
+}
+
+// getSigner creates the signer once, as resolving credentials may involve
+// requests to other services. Needs mutex locking as Sign might be called by
+// parallel threads.
+func (s AWSSigV4Config) getSigner() (*v4.Signer, string, error) {
+	s.signerMx.Lock()
+	defer s.signerMx.Unlock()
+
+	if *s.signer != nil {
+		return *s.signer, *s.region, nil
+	}
+
+	if s.Service == "" {
+		return nil, "", errors.New("a service must be specified in order to sign requests with aws_sigv4")
+	}
+
+	awsConf := aws.NewConfig()
+	if s.Region != "" {
+		awsConf = awsConf.WithRegion(s.Region)
+	}
+	c := s.Credentials
+	if c.Profile != "" {
+		awsConf = awsConf.WithCredentials(credentials.NewSharedCredentials("", c.Profile))
+	} else if c.ID != "" {
+		awsConf = awsConf.WithCredentials(credentials.NewStaticCredentials(c.ID, c.Secret, c.Token))
+	}
+
+	sess, err := session.NewSession(awsConf)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create aws session: %w", err)
+	}
+	if c.Role != "" {
+		var opts []func(*stscreds.AssumeRoleProvider)
+		if c.RoleExternalID != "" {
+			externalID := c.RoleExternalID
+			opts = append(opts, func(p *stscreds.AssumeRoleProvider) {
+				p.ExternalID = &externalID
+			})
+		}
+		sess.Config = sess.Config.WithCredentials(stscreds.NewCredentials(sess, c.Role, opts...))
+	}
+	if c.FromEC2Role {
+		sess.Config = sess.Config.WithCredentials(ec2rolecreds.NewCredentials(sess))
+	}
+
+	*s.signer = v4.NewSigner(sess.Config.Credentials)
+	*s.region = aws.StringValue(sess.Config.Region)
+	if *s.region == "" {
+		*s.signer = nil
+		return nil, "", errors.New("a region must be specified in order to sign requests with aws_sigv4")
+	}
+	return *s.signer, *s.region, nil
+}
+
+//------------------------------------------------------------------------------
+
+// AzureADConfig holds the configuration parameters for authenticating requests
+// with Azure Active Directory access tokens.
+type AzureADConfig struct {
+	Enabled      bool     `json:"enabled" yaml:"enabled"`
+	TenantID     string   `json:"tenant_id" yaml:"tenant_id"`
+	ClientID     string   `json:"client_id" yaml:"client_id"`
+	ClientSecret string   `json:"client_secret" yaml:"client_secret"`
+	Scopes       []string `json:"scopes" yaml:"scopes"`
+
+	// internal private fields
+	tokenMx *sync.Mutex
+	cred    *azcore.TokenCredential
+	token   *azcore.AccessToken
+}
+
+// NewAzureADConfig returns a new AzureADConfig with default values.
+func NewAzureADConfig() AzureADConfig {
+	var cred azcore.TokenCredential
+	return AzureADConfig{
+		Enabled:      false,
+		TenantID:     "",
+		ClientID:     "",
+		ClientSecret: "",
+		Scopes:       []string{},
+		tokenMx:      &sync.Mutex{},
+		cred:         &cred,
+		token:        &azcore.AccessToken{},
+	}
+}
+
+// Sign method to sign an HTTP request with an Azure AD access token.
+func (a AzureADConfig) Sign(req *http.Request) error {
+	if !a.Enabled {
+		return nil
+	}
+
+	token, err := a.getToken(req)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// getToken returns a cached access token, obtaining a new one when the cached
+// token is due to expire. Needs mutex locking as Sign might be called by
+// parallel threads.
+func (a AzureADConfig) getToken(req *http.Request) (string, error) {
+	a.tokenMx.Lock()
+	defer a.tokenMx.Unlock()
+
+	if a.token.Token != "" && time.Until(a.token.ExpiresOn) > time.Minute {
+		return a.token.Token, nil
+	}
+
+	if *a.cred == nil {
+		if len(a.Scopes) == 0 {
+			return "", errors.New("at least one scope must be specified in order to authenticate requests with azure_ad")
+		}
+		var err error
+		if a.ClientSecret != "" {
+			*a.cred, err = azidentity.NewClientSecretCredential(a.TenantID, a.ClientID, a.ClientSecret, nil)
+		} else {
+			*a.cred, err = azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+				TenantID: a.TenantID,
+			})
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create azure credential: %w", err)
+		}
+	}
+
+	token, err := (*a.cred).GetToken(req.Context(), policy.TokenRequestOptions{Scopes: a.Scopes})
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain azure ad token: %w", err)
+	}
+	*a.token = token
+	return token.Token, nil
+}
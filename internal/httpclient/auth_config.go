@@ -16,8 +16,8 @@ import (
 
 	"github.com/golang-jwt/jwt"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/clientcredentials"
 
+	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
 )
 
@@ -26,6 +26,8 @@ type AuthConfig struct {
 	OAuth     OAuthConfig     `json:"oauth" yaml:"oauth"`
 	BasicAuth BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
 	JWT       JWTConfig       `json:"jwt" yaml:"jwt"`
+	AzureAD   AzureADConfig   `json:"azure_ad" yaml:"azure_ad"`
+	AWSSigV4  AWSSigV4Config  `json:"aws_sigv4" yaml:"aws_sigv4"`
 }
 
 // NewAuthConfig creates a new Config with default values.
@@ -34,6 +36,8 @@ func NewAuthConfig() AuthConfig {
 		OAuth:     NewOAuthConfig(),
 		BasicAuth: NewBasicAuthConfig(),
 		JWT:       NewJWTConfig(),
+		AzureAD:   NewAzureADConfig(),
+		AWSSigV4:  NewAWSSigV4Config(),
 	}
 }
 
@@ -45,7 +49,15 @@ func (c AuthConfig) Sign(f ifs.FS, req *http.Request) error {
 	if err := c.JWT.Sign(f, req); err != nil {
 		return err
 	}
-	return c.BasicAuth.Sign(req)
+	if err := c.BasicAuth.Sign(req); err != nil {
+		return err
+	}
+	if err := c.AzureAD.Sign(req); err != nil {
+		return err
+	}
+	// Signature Version 4 covers the headers of the request, and must
+	// therefore be applied last.
+	return c.AWSSigV4.Sign(req)
 }
 
 //------------------------------------------------------------------------------
@@ -261,36 +273,100 @@ func (oauth OAuthConfig) computeHMAC(
 
 // OAuth2Config holds the configuration parameters for an OAuth2 exchange.
 type OAuth2Config struct {
-	Enabled      bool     `json:"enabled" yaml:"enabled"`
-	ClientKey    string   `json:"client_key" yaml:"client_key"`
-	ClientSecret string   `json:"client_secret" yaml:"client_secret"`
-	TokenURL     string   `json:"token_url" yaml:"token_url"`
-	Scopes       []string `json:"scopes" yaml:"scopes"`
+	Enabled       bool                      `json:"enabled" yaml:"enabled"`
+	ClientKey     string                    `json:"client_key" yaml:"client_key"`
+	ClientSecret  string                    `json:"client_secret" yaml:"client_secret"`
+	TokenURL      string                    `json:"token_url" yaml:"token_url"`
+	Scopes        []string                  `json:"scopes" yaml:"scopes"`
+	GrantType     string                    `json:"grant_type" yaml:"grant_type"`
+	RefreshToken  string                    `json:"refresh_token" yaml:"refresh_token"`
+	JWTBearer     OAuth2JWTBearerConfig     `json:"jwt_bearer" yaml:"jwt_bearer"`
+	TokenExchange OAuth2TokenExchangeConfig `json:"token_exchange" yaml:"token_exchange"`
+	TokenCache    string                    `json:"token_cache" yaml:"token_cache"`
+	TokenCacheKey string                    `json:"token_cache_key" yaml:"token_cache_key"`
 }
 
 // NewOAuth2Config returns a new OAuth2Config with default values.
 func NewOAuth2Config() OAuth2Config {
 	return OAuth2Config{
-		Enabled:      false,
-		ClientKey:    "",
-		ClientSecret: "",
-		TokenURL:     "",
-		Scopes:       []string{},
+		Enabled:       false,
+		ClientKey:     "",
+		ClientSecret:  "",
+		TokenURL:      "",
+		Scopes:        []string{},
+		GrantType:     OAuth2GrantClientCredentials,
+		RefreshToken:  "",
+		JWTBearer:     NewOAuth2JWTBearerConfig(),
+		TokenExchange: NewOAuth2TokenExchangeConfig(),
+		TokenCache:    "",
+		TokenCacheKey: "",
+	}
+}
+
+// OAuth2JWTBearerConfig holds the configuration parameters for an OAuth2 JWT
+// bearer assertion grant.
+type OAuth2JWTBearerConfig struct {
+	PrivateKeyFile string         `json:"private_key_file" yaml:"private_key_file"`
+	PrivateKeyID   string         `json:"private_key_id" yaml:"private_key_id"`
+	Issuer         string         `json:"issuer" yaml:"issuer"`
+	Subject        string         `json:"subject" yaml:"subject"`
+	Audience       string         `json:"audience" yaml:"audience"`
+	Expiry         string         `json:"expiry" yaml:"expiry"`
+	Claims         map[string]any `json:"claims" yaml:"claims"`
+}
+
+// NewOAuth2JWTBearerConfig returns a new OAuth2JWTBearerConfig with default
+// values.
+func NewOAuth2JWTBearerConfig() OAuth2JWTBearerConfig {
+	return OAuth2JWTBearerConfig{
+		PrivateKeyFile: "",
+		PrivateKeyID:   "",
+		Issuer:         "",
+		Subject:        "",
+		Audience:       "",
+		Expiry:         "1h",
+		Claims:         map[string]any{},
+	}
+}
+
+// OAuth2TokenExchangeConfig holds the configuration parameters for an OAuth2
+// token exchange grant.
+type OAuth2TokenExchangeConfig struct {
+	SubjectToken     string `json:"subject_token" yaml:"subject_token"`
+	SubjectTokenFile string `json:"subject_token_file" yaml:"subject_token_file"`
+	SubjectTokenType string `json:"subject_token_type" yaml:"subject_token_type"`
+	Audience         string `json:"audience" yaml:"audience"`
+	Resource         string `json:"resource" yaml:"resource"`
+}
+
+// NewOAuth2TokenExchangeConfig returns a new OAuth2TokenExchangeConfig with
+// default values.
+func NewOAuth2TokenExchangeConfig() OAuth2TokenExchangeConfig {
+	return OAuth2TokenExchangeConfig{
+		SubjectToken:     "",
+		SubjectTokenFile: "",
+		SubjectTokenType: defaultSubjectTokenType,
+		Audience:         "",
+		Resource:         "",
 	}
 }
 
 // Client returns an http.Client with OAuth2 configured.
-func (oauth OAuth2Config) Client(ctx context.Context, base *http.Client) *http.Client {
+func (oauth OAuth2Config) Client(ctx context.Context, base *http.Client, mgr bundle.NewManagement) (*http.Client, error) {
 	if !oauth.Enabled {
-		return base
+		return base, nil
 	}
 
-	conf := &clientcredentials.Config{
-		ClientID:     oauth.ClientKey,
-		ClientSecret: oauth.ClientSecret,
-		TokenURL:     oauth.TokenURL,
-		Scopes:       oauth.Scopes,
-	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, base)
 
-	return conf.Client(context.WithValue(ctx, oauth2.HTTPClient, base))
+	src, err := oauth.tokenSource(ctx, mgr)
+	if err != nil {
+		return nil, err
+	}
+	if oauth.TokenCache != "" {
+		if src, err = oauth.cachedTokenSource(ctx, mgr, src); err != nil {
+			return nil, err
+		}
+	}
+	return oauth2.NewClient(ctx, src), nil
 }
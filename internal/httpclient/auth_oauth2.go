@@ -0,0 +1,297 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/jwt"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+// Grant types supported by the OAuth2 config.
+const (
+	OAuth2GrantClientCredentials = "client_credentials"
+	OAuth2GrantJWTBearer         = "jwt_bearer"
+	OAuth2GrantRefreshToken      = "refresh_token"
+	OAuth2GrantTokenExchange     = "token_exchange"
+)
+
+const (
+	tokenExchangeGrantType  = "urn:ietf:params:oauth:grant-type:token-exchange"
+	defaultSubjectTokenType = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// tokenSource returns a source of tokens for the configured grant type, token
+// requests are performed with the HTTP client stored within the context.
+func (oauth OAuth2Config) tokenSource(ctx context.Context, mgr bundle.NewManagement) (oauth2.TokenSource, error) {
+	switch oauth.GrantType {
+	case "", OAuth2GrantClientCredentials:
+		conf := &clientcredentials.Config{
+			ClientID:     oauth.ClientKey,
+			ClientSecret: oauth.ClientSecret,
+			TokenURL:     oauth.TokenURL,
+			Scopes:       oauth.Scopes,
+		}
+		return conf.TokenSource(ctx), nil
+
+	case OAuth2GrantRefreshToken:
+		if oauth.RefreshToken == "" {
+			return nil, errors.New("a refresh_token must be specified with the refresh_token grant")
+		}
+		conf := &oauth2.Config{
+			ClientID:     oauth.ClientKey,
+			ClientSecret: oauth.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: oauth.TokenURL},
+			Scopes:       oauth.Scopes,
+		}
+		return conf.TokenSource(ctx, &oauth2.Token{RefreshToken: oauth.RefreshToken}), nil
+
+	case OAuth2GrantJWTBearer:
+		bConf := oauth.JWTBearer
+		if bConf.PrivateKeyFile == "" {
+			return nil, errors.New("a jwt_bearer.private_key_file must be specified with the jwt_bearer grant")
+		}
+		privateKey, err := ifs.ReadFile(mgr.FS(), bConf.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %v", err)
+		}
+
+		var expiry time.Duration
+		if bConf.Expiry != "" {
+			if expiry, err = time.ParseDuration(bConf.Expiry); err != nil {
+				return nil, fmt.Errorf("failed to parse jwt_bearer expiry: %v", err)
+			}
+		}
+
+		issuer := bConf.Issuer
+		if issuer == "" {
+			issuer = oauth.ClientKey
+		}
+		conf := &jwt.Config{
+			Email:         issuer,
+			PrivateKey:    privateKey,
+			PrivateKeyID:  bConf.PrivateKeyID,
+			Subject:       bConf.Subject,
+			Scopes:        oauth.Scopes,
+			TokenURL:      oauth.TokenURL,
+			Expires:       expiry,
+			Audience:      bConf.Audience,
+			PrivateClaims: bConf.Claims,
+		}
+		return conf.TokenSource(ctx), nil
+
+	case OAuth2GrantTokenExchange:
+		if oauth.TokenExchange.SubjectToken == "" && oauth.TokenExchange.SubjectTokenFile == "" {
+			return nil, errors.New("either a token_exchange.subject_token or token_exchange.subject_token_file must be specified with the token_exchange grant")
+		}
+		return oauth2.ReuseTokenSource(nil, &tokenExchangeSource{
+			ctx:  ctx,
+			conf: oauth,
+			fs:   mgr.FS(),
+		}), nil
+	}
+	return nil, fmt.Errorf("oauth2 grant type %v not recognised", oauth.GrantType)
+}
+
+//------------------------------------------------------------------------------
+
+// tokenExchangeSource obtains tokens by exchanging a subject token as
+// described in RFC 8693.
+type tokenExchangeSource struct {
+	ctx  context.Context
+	conf OAuth2Config
+	fs   ifs.FS
+}
+
+func (t *tokenExchangeSource) subjectToken() (string, error) {
+	if t.conf.TokenExchange.SubjectTokenFile == "" {
+		return t.conf.TokenExchange.SubjectToken, nil
+	}
+	// The file is read for each exchange as subject tokens such as projected
+	// service account tokens are rotated on disk.
+	b, err := ifs.ReadFile(t.fs, t.conf.TokenExchange.SubjectTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read subject token: %v", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (t *tokenExchangeSource) Token() (*oauth2.Token, error) {
+	subjectToken, err := t.subjectToken()
+	if err != nil {
+		return nil, err
+	}
+
+	eConf := t.conf.TokenExchange
+	subjectTokenType := eConf.SubjectTokenType
+	if subjectTokenType == "" {
+		subjectTokenType = defaultSubjectTokenType
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", tokenExchangeGrantType)
+	form.Set("subject_token", subjectToken)
+	form.Set("subject_token_type", subjectTokenType)
+	if eConf.Audience != "" {
+		form.Set("audience", eConf.Audience)
+	}
+	if eConf.Resource != "" {
+		form.Set("resource", eConf.Resource)
+	}
+	if len(t.conf.Scopes) > 0 {
+		form.Set("scope", strings.Join(t.conf.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, t.conf.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if t.conf.ClientKey != "" {
+		req.SetBasicAuth(url.QueryEscape(t.conf.ClientKey), url.QueryEscape(t.conf.ClientSecret))
+	}
+
+	client := http.DefaultClient
+	if c, ok := t.ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		client = c
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token exchange response: %w", err)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("token exchange request returned status code %v: %s", res.StatusCode, body)
+	}
+
+	var tRes struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tRes); err != nil {
+		return nil, fmt.Errorf("failed to parse token exchange response: %w", err)
+	}
+	if tRes.AccessToken == "" {
+		return nil, errors.New("token exchange response did not contain an access token")
+	}
+
+	token := &oauth2.Token{
+		AccessToken: tRes.AccessToken,
+		TokenType:   tRes.TokenType,
+	}
+	if tRes.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(tRes.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+//------------------------------------------------------------------------------
+
+// cachedTokenSource returns a source of tokens that shares tokens through a
+// cache resource, only requesting new tokens from the underlying source when
+// the cache has no valid token.
+func (oauth OAuth2Config) cachedTokenSource(ctx context.Context, mgr bundle.NewManagement, src oauth2.TokenSource) (oauth2.TokenSource, error) {
+	if !mgr.ProbeCache(oauth.TokenCache) {
+		return nil, fmt.Errorf("token cache resource '%v' was not found", oauth.TokenCache)
+	}
+
+	key := oauth.TokenCacheKey
+	if key == "" {
+		clientKey := oauth.ClientKey
+		if oauth.GrantType == OAuth2GrantJWTBearer && oauth.JWTBearer.Issuer != "" {
+			clientKey = oauth.JWTBearer.Issuer
+		}
+		grantType := oauth.GrantType
+		if grantType == "" {
+			grantType = OAuth2GrantClientCredentials
+		}
+		key = "oauth2_token:" + grantType + ":" + oauth.TokenURL + ":" + clientKey
+	}
+
+	return oauth2.ReuseTokenSource(nil, &cacheTokenSource{
+		ctx:      ctx,
+		mgr:      mgr,
+		log:      mgr.Logger(),
+		resource: oauth.TokenCache,
+		key:      key,
+		src:      src,
+	}), nil
+}
+
+type cacheTokenSource struct {
+	ctx      context.Context
+	mgr      bundle.NewManagement
+	log      log.Modular
+	resource string
+	key      string
+	src      oauth2.TokenSource
+}
+
+func (c *cacheTokenSource) Token() (*oauth2.Token, error) {
+	var raw []byte
+	var cErr error
+	if err := c.mgr.AccessCache(c.ctx, c.resource, func(cache cache.V1) {
+		raw, cErr = cache.Get(c.ctx, c.key)
+	}); err != nil {
+		cErr = err
+	}
+	if cErr == nil {
+		var token oauth2.Token
+		if err := json.Unmarshal(raw, &token); err != nil {
+			c.log.Warnf("Failed to parse cached oauth2 token: %v\n", err)
+		} else if token.Valid() {
+			return &token, nil
+		}
+	} else if !errors.Is(cErr, component.ErrKeyNotFound) {
+		c.log.Warnf("Failed to read cached oauth2 token: %v\n", cErr)
+	}
+
+	token, err := c.src.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if raw, err = json.Marshal(token); err != nil {
+		c.log.Warnf("Failed to serialise oauth2 token for caching: %v\n", err)
+		return token, nil
+	}
+
+	var ttl *time.Duration
+	if !token.Expiry.IsZero() {
+		d := time.Until(token.Expiry)
+		if d <= 0 {
+			return token, nil
+		}
+		ttl = &d
+	}
+	if err := c.mgr.AccessCache(c.ctx, c.resource, func(cache cache.V1) {
+		cErr = cache.Set(c.ctx, c.key, raw, ttl)
+	}); err != nil {
+		cErr = err
+	}
+	if cErr != nil {
+		c.log.Warnf("Failed to cache oauth2 token: %v\n", cErr)
+	}
+	return token, nil
+}
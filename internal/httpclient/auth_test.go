@@ -1,8 +1,16 @@
 package httpclient
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/golang-jwt/jwt"
 
 	"github.com/benthosdev/benthos/v4/public/service"
@@ -40,6 +48,21 @@ jwt:
     buz: 3
   signing_method: foomethod
   private_key_file: fookeyfile
+
+azure_ad:
+  enabled: true
+  tenant_id: footenant
+  client_id: fooclient
+  client_secret: foosecret
+  scopes: [ foo/.default ]
+
+aws_sigv4:
+  enabled: true
+  service: es
+  region: eu-west-1
+  credentials:
+    id: fooid
+    secret: foosecret
 `, service.NewEnvironment())
 	require.NoError(t, err)
 
@@ -67,4 +90,74 @@ jwt:
 	}, authConf.JWT.Headers)
 	assert.Equal(t, "foomethod", authConf.JWT.SigningMethod)
 	assert.Equal(t, "fookeyfile", authConf.JWT.PrivateKeyFile)
+
+	assert.True(t, authConf.AzureAD.Enabled)
+	assert.Equal(t, "footenant", authConf.AzureAD.TenantID)
+	assert.Equal(t, "fooclient", authConf.AzureAD.ClientID)
+	assert.Equal(t, "foosecret", authConf.AzureAD.ClientSecret)
+	assert.Equal(t, []string{"foo/.default"}, authConf.AzureAD.Scopes)
+
+	assert.True(t, authConf.AWSSigV4.Enabled)
+	assert.Equal(t, "es", authConf.AWSSigV4.Service)
+	assert.Equal(t, "eu-west-1", authConf.AWSSigV4.Region)
+	assert.Equal(t, "fooid", authConf.AWSSigV4.Credentials.ID)
+	assert.Equal(t, "foosecret", authConf.AWSSigV4.Credentials.Secret)
+}
+
+func TestAuthAWSSigV4Sign(t *testing.T) {
+	conf := NewAWSSigV4Config()
+	conf.Enabled = true
+	conf.Service = "es"
+	conf.Region = "eu-west-1"
+	conf.Credentials.ID = "fooid"
+	conf.Credentials.Secret = "foosecret"
+
+	req, err := http.NewRequest("POST", "https://search.example.com/foo/_doc", bytes.NewReader([]byte(`{"foo":"bar"}`)))
+	require.NoError(t, err)
+	require.NoError(t, conf.Sign(req))
+
+	authHeader := req.Header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(authHeader, "AWS4-HMAC-SHA256 Credential=fooid/"), authHeader)
+	assert.Contains(t, authHeader, "/eu-west-1/es/aws4_request")
+	assert.NotEmpty(t, req.Header.Get("X-Amz-Date"))
+
+	// The body remains intact after being hashed.
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, string(body))
+
+	conf = NewAWSSigV4Config()
+	conf.Enabled = true
+	require.Error(t, conf.Sign(req))
+}
+
+type fakeTokenCredential struct {
+	calls int
+}
+
+func (f *fakeTokenCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	f.calls++
+	return azcore.AccessToken{
+		Token:     "footoken" + strings.Join(opts.Scopes, ","),
+		ExpiresOn: time.Now().Add(time.Hour),
+	}, nil
+}
+
+func TestAuthAzureADSign(t *testing.T) {
+	conf := NewAzureADConfig()
+	conf.Enabled = true
+	conf.Scopes = []string{"foo/.default"}
+
+	cred := &fakeTokenCredential{}
+	*conf.cred = cred
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", "https://api.example.com", http.NoBody)
+		require.NoError(t, err)
+		require.NoError(t, conf.Sign(req))
+		assert.Equal(t, "Bearer footokenfoo/.default", req.Header.Get("Authorization"))
+	}
+
+	// Tokens are reused until they are due to expire.
+	assert.Equal(t, 1, cred.calls)
 }
@@ -82,39 +82,33 @@ func NewClientFromOldConfig(conf OldConfig, mgr bundle.NewManagement, opts ...Re
 		}
 	}
 
+	transport, err := conf.Transport.NewTransport()
+	if err != nil {
+		return nil, err
+	}
 	if conf.TLS.Enabled {
 		tlsConf, err := conf.TLS.Get(mgr.FS())
 		if err != nil {
 			return nil, err
 		}
 		if tlsConf != nil {
-			if c, ok := http.DefaultTransport.(*http.Transport); ok {
-				cloned := c.Clone()
-				cloned.TLSClientConfig = tlsConf
-				h.client.Transport = cloned
-			} else {
-				h.client.Transport = &http.Transport{
-					TLSClientConfig: tlsConf,
-				}
-			}
+			transport.TLSClientConfig = tlsConf
 		}
 	}
-
 	if conf.ProxyURL != "" {
 		proxyURL, err := url.Parse(conf.ProxyURL)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse proxy_url string: %v", err)
 		}
-		if h.client.Transport != nil {
-			if tr, ok := h.client.Transport.(*http.Transport); ok {
-				tr.Proxy = http.ProxyURL(proxyURL)
-			} else {
-				return nil, fmt.Errorf("unable to apply proxy_url to transport, unexpected type %T", h.client.Transport)
-			}
-		} else {
-			h.client.Transport = &http.Transport{
-				Proxy: http.ProxyURL(proxyURL),
-			}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	h.client.Transport = transport
+
+	// The manager may override the transport, which is the case when HTTP
+	// endpoints are mocked within unit tests.
+	if tm, ok := mgr.(interface{ HTTPTransport() http.RoundTripper }); ok {
+		if rt := tm.HTTPTransport(); rt != nil {
+			h.client.Transport = rt
 		}
 	}
 
@@ -123,7 +117,9 @@ func NewClientFromOldConfig(conf OldConfig, mgr bundle.NewManagement, opts ...Re
 		return nil, fmt.Errorf("failed to config logger for request dump: %v", err)
 	}
 
-	h.client = conf.OAuth2.Client(h.clientCtx, h.client)
+	if h.client, err = conf.OAuth2.Client(h.clientCtx, h.client, mgr); err != nil {
+		return nil, fmt.Errorf("failed to configure oauth2: %w", err)
+	}
 
 	for _, c := range conf.BackoffOn {
 		h.backoffOn[c] = struct{}{}
@@ -334,6 +330,30 @@ func (h *Client) checkStatus(code int) (succeeded bool, retStrat retryStrategy)
 // performs it, and then returns the *http.Response, allowing the raw response
 // to be consumed.
 func (h *Client) SendToResponse(ctx context.Context, sendMsg message.Batch) (res *http.Response, err error) {
+	return h.sendToResponse(ctx, sendMsg, nil)
+}
+
+// SendConditionalToResponse is equivalent to SendToResponse except the
+// provided conditional headers (such as If-None-Match) are added to each
+// request attempt, and a 304 Not Modified response is considered successful.
+func (h *Client) SendConditionalToResponse(ctx context.Context, sendMsg message.Batch, conditions http.Header) (res *http.Response, err error) {
+	return h.sendToResponse(ctx, sendMsg, conditions)
+}
+
+// RequestKey returns a string that identifies the request that would be
+// created from a message batch by its verb, URL, host, headers and body, which
+// is suitable for use as a cache key.
+func (h *Client) RequestKey(sendMsg message.Batch) (string, error) {
+	return h.reqCreator.Key(sendMsg)
+}
+
+// RequestHeader returns the headers of the request that would be created from
+// a message batch, excluding those added by authentication and tracing.
+func (h *Client) RequestHeader(sendMsg message.Batch) (http.Header, error) {
+	return h.reqCreator.Header(sendMsg)
+}
+
+func (h *Client) sendToResponse(ctx context.Context, sendMsg message.Batch, conditions http.Header) (res *http.Response, err error) {
 	var spans []*tracing.Span
 	if sendMsg != nil {
 		sendMsg, spans = tracing.WithChildSpans(h.mgr.Tracer(), "http_request", sendMsg)
@@ -352,8 +372,25 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg message.Batch) (res
 		}
 	}
 
+	createReq := func() (*http.Request, error) {
+		req, err := h.reqCreator.Create(sendMsg)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range conditions {
+			req.Header[k] = v
+		}
+		return req, nil
+	}
+	checkStatus := func(code int) (bool, retryStrategy) {
+		if conditions != nil && code == http.StatusNotModified {
+			return true, noRetry
+		}
+		return h.checkStatus(code)
+	}
+
 	var req *http.Request
-	if req, err = h.reqCreator.Create(sendMsg); err != nil {
+	if req, err = createReq(); err != nil {
 		logErr(err)
 		return nil, err
 	}
@@ -374,7 +411,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg message.Batch) (res
 	startedAt := time.Now()
 	if res, err = h.client.Do(req.WithContext(ctx)); err == nil {
 		h.incrCode(res.StatusCode)
-		if resolved, retryStrat := h.checkStatus(res.StatusCode); !resolved {
+		if resolved, retryStrat := checkStatus(res.StatusCode); !resolved {
 			rateLimited = retryStrat == retryBackoff
 			if retryStrat == noRetry {
 				numRetries = 0
@@ -390,7 +427,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg message.Batch) (res
 	i, j := 0, numRetries
 	for i < j && err != nil {
 		logErr(err)
-		if req, err = h.reqCreator.Create(sendMsg); err != nil {
+		if req, err = createReq(); err != nil {
 			continue
 		}
 		if rateLimited {
@@ -410,7 +447,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg message.Batch) (res
 		startedAt = time.Now()
 		if res, err = h.client.Do(req.WithContext(ctx)); err == nil {
 			h.incrCode(res.StatusCode)
-			if resolved, retryStrat := h.checkStatus(res.StatusCode); !resolved {
+			if resolved, retryStrat := checkStatus(res.StatusCode); !resolved {
 				rateLimited = retryStrat == retryBackoff
 				if retryStrat == noRetry {
 					j = 0
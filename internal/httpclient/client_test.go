@@ -3,6 +3,10 @@ package httpclient
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"mime"
@@ -10,12 +14,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/textproto"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -628,3 +635,199 @@ func TestHTTPClientOAuth2AndTLSConf(t *testing.T) {
 	mBytes := resBatch[0].AsBytes()
 	assert.Equal(t, "HELLO WORLD", string(mBytes))
 }
+
+func TestHTTPClientTransportConf(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	for _, test := range []struct {
+		http2 bool
+		proto string
+	}{
+		{http2: true, proto: "HTTP/2.0"},
+		{http2: false, proto: "HTTP/1.1"},
+	} {
+		conf := NewOldConfig()
+		conf.URL = ts.URL
+		conf.TLS.Enabled = true
+		conf.TLS.InsecureSkipVerify = true
+		conf.Transport.HTTP2 = test.http2
+		conf.Transport.MaxIdleConnsPerHost = 50
+
+		h, err := NewClientFromOldConfig(conf, mock.NewManager())
+		require.NoError(t, err)
+
+		resBatch, err := h.Send(context.Background(), message.Batch{
+			message.NewPart([]byte("hello world")),
+		})
+		require.NoError(t, err)
+		require.Len(t, resBatch, 1)
+		assert.Equal(t, test.proto, string(resBatch[0].AsBytes()))
+		require.NoError(t, h.Close(context.Background()))
+	}
+
+	conf := NewOldConfig()
+	conf.Transport.IdleConnTimeout = "nope"
+	_, err := NewClientFromOldConfig(conf, mock.NewManager())
+	require.Error(t, err)
+}
+
+func testOAuth2Send(t *testing.T, conf OldConfig, mgr *mock.Manager) {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer footoken", r.Header.Get("Authorization"))
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		_, _ = w.Write(bytes.ToUpper(b))
+	}))
+	defer ts.Close()
+
+	conf.URL = ts.URL + "/testpost"
+
+	h, err := NewClientFromOldConfig(conf, mgr)
+	require.NoError(t, err)
+	defer h.Close(context.Background())
+
+	resBatch, err := h.Send(context.Background(), message.Batch{
+		message.NewPart([]byte("hello world")),
+	})
+	require.NoError(t, err)
+	require.Len(t, resBatch, 1)
+	assert.Equal(t, "HELLO WORLD", string(resBatch[0].AsBytes()))
+}
+
+func TestHTTPClientOAuth2JWTBearer(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}), 0o600))
+
+	tsOAuth2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+
+		claims := jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(r.Form.Get("assertion"), claims, func(*jwt.Token) (any, error) {
+			return &key.PublicKey, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "fooissuer", claims["iss"])
+		assert.Equal(t, "foosubject", claims["sub"])
+		assert.Equal(t, "fooaudience", claims["aud"])
+		assert.Equal(t, "foo bar", claims["scope"])
+		assert.Equal(t, "barvalue", claims["bar"])
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"footoken","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tsOAuth2.Close()
+
+	conf := NewOldConfig()
+	conf.OAuth2.Enabled = true
+	conf.OAuth2.GrantType = OAuth2GrantJWTBearer
+	conf.OAuth2.TokenURL = tsOAuth2.URL
+	conf.OAuth2.Scopes = []string{"foo", "bar"}
+	conf.OAuth2.JWTBearer.PrivateKeyFile = keyFile
+	conf.OAuth2.JWTBearer.Issuer = "fooissuer"
+	conf.OAuth2.JWTBearer.Subject = "foosubject"
+	conf.OAuth2.JWTBearer.Audience = "fooaudience"
+	conf.OAuth2.JWTBearer.Claims = map[string]any{"bar": "barvalue"}
+
+	testOAuth2Send(t, conf, mock.NewManager())
+}
+
+func TestHTTPClientOAuth2RefreshToken(t *testing.T) {
+	tsOAuth2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Basic Zm9va2V5OmZvb3NlY3JldA==", r.Header.Get("Authorization"))
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "refresh_token", r.Form.Get("grant_type"))
+		assert.Equal(t, "foorefresh", r.Form.Get("refresh_token"))
+		_, _ = w.Write([]byte(`access_token=footoken&token_type=Bearer&expires_in=3600`))
+	}))
+	defer tsOAuth2.Close()
+
+	conf := NewOldConfig()
+	conf.OAuth2.Enabled = true
+	conf.OAuth2.GrantType = OAuth2GrantRefreshToken
+	conf.OAuth2.TokenURL = tsOAuth2.URL
+	conf.OAuth2.ClientKey = "fookey"
+	conf.OAuth2.ClientSecret = "foosecret"
+	conf.OAuth2.RefreshToken = "foorefresh"
+
+	testOAuth2Send(t, conf, mock.NewManager())
+}
+
+func TestHTTPClientOAuth2TokenExchange(t *testing.T) {
+	subjectFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(subjectFile, []byte("foosubjecttoken\n"), 0o600))
+
+	tsOAuth2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Basic Zm9va2V5OmZvb3NlY3JldA==", r.Header.Get("Authorization"))
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:token-exchange", r.Form.Get("grant_type"))
+		assert.Equal(t, "foosubjecttoken", r.Form.Get("subject_token"))
+		assert.Equal(t, "urn:ietf:params:oauth:token-type:jwt", r.Form.Get("subject_token_type"))
+		assert.Equal(t, "fooaudience", r.Form.Get("audience"))
+		assert.Equal(t, "foo", r.Form.Get("scope"))
+		_, _ = w.Write([]byte(`{"access_token":"footoken","issued_token_type":"urn:ietf:params:oauth:token-type:access_token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tsOAuth2.Close()
+
+	conf := NewOldConfig()
+	conf.OAuth2.Enabled = true
+	conf.OAuth2.GrantType = OAuth2GrantTokenExchange
+	conf.OAuth2.TokenURL = tsOAuth2.URL
+	conf.OAuth2.ClientKey = "fookey"
+	conf.OAuth2.ClientSecret = "foosecret"
+	conf.OAuth2.Scopes = []string{"foo"}
+	conf.OAuth2.TokenExchange.SubjectTokenFile = subjectFile
+	conf.OAuth2.TokenExchange.Audience = "fooaudience"
+
+	testOAuth2Send(t, conf, mock.NewManager())
+
+	conf.OAuth2.TokenExchange.SubjectTokenFile = ""
+	_, err := NewClientFromOldConfig(conf, mock.NewManager())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "subject_token")
+}
+
+func TestHTTPClientOAuth2TokenCache(t *testing.T) {
+	var tokenReqs uint32
+	tsOAuth2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&tokenReqs, 1)
+		_, _ = w.Write([]byte(`access_token=footoken&token_type=Bearer&expires_in=3600`))
+	}))
+	defer tsOAuth2.Close()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	conf := NewOldConfig()
+	conf.OAuth2.Enabled = true
+	conf.OAuth2.TokenURL = tsOAuth2.URL
+	conf.OAuth2.ClientKey = "fookey"
+	conf.OAuth2.ClientSecret = "foosecret"
+	conf.OAuth2.TokenCache = "foocache"
+
+	// Separate clients share the token stored within the cache.
+	testOAuth2Send(t, conf, mgr)
+	testOAuth2Send(t, conf, mgr)
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&tokenReqs))
+
+	item, exists := mgr.Caches["foocache"]["oauth2_token:client_credentials:"+tsOAuth2.URL+":fookey"]
+	require.True(t, exists)
+	assert.Contains(t, item.Value, `"access_token":"footoken"`)
+
+	conf.OAuth2.TokenCache = "barcache"
+	_, err := NewClientFromOldConfig(conf, mgr)
+	require.Error(t, err)
+}
@@ -37,6 +37,7 @@ func OldFieldSpec(defaultVerb string, forOutput bool, extraChildren ...docs.Fiel
 		docs.FieldObject("metadata", "Specify optional matching rules to determine which metadata keys should be added to the HTTP request as headers.").Advanced().
 			WithChildren(metadata.IncludeFilterDocs()...),
 		docs.FieldString("dump_request_log_level", "EXPERIMENTAL: Optionally set a level at which the request and response payload of each request made will be logged.").Advanced().HasDefault("").HasOptions("TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL", "").AtVersion("4.12.0"),
+		docs.FieldBool("propagate_trace_context", "Whether to add [W3C trace context](https://www.w3.org/TR/trace-context/) `traceparent` and `tracestate` headers to requests referencing the span of the request, allowing downstream services to continue the trace. Any headers of the same name that are set explicitly are replaced.").Advanced().HasDefault(false).AtVersion("4.20.0"),
 	}
 
 	extractHeadersDesc := "Specify which response headers should be added to resulting messages as metadata. Header keys are lowercased before matching, so ensure that your patterns target lowercased versions of the header keys that you expect."
@@ -59,6 +60,7 @@ func OldFieldSpec(defaultVerb string, forOutput bool, extraChildren ...docs.Fiel
 		docs.FieldInt("drop_on", "A list of status codes whereby the request should be considered to have failed but retries should not be attempted. This is useful for preventing wasted retries for requests that will never succeed. Note that with these status codes the _request_ is dropped, but _message_ that caused the request will not be dropped.").Array().Advanced().HasDefault([]any{}),
 		docs.FieldInt("successful_on", "A list of status codes whereby the attempt should be considered successful, this is useful for dropping requests that return non-2XX codes indicating that the message has been dealt with, such as a 303 See Other or a 409 Conflict. All 2XX codes are considered successful unless they are present within `backoff_on` or `drop_on`, regardless of this field.").Array().Advanced().HasDefault([]any{}),
 		docs.FieldURL("proxy_url", "An optional HTTP proxy URL.").Advanced().HasDefault(""),
+		transportFieldSpec(),
 	)
 	httpSpecs = append(httpSpecs, extraChildren...)
 
@@ -120,8 +122,10 @@ type OldConfig struct {
 	DropOn              []int                        `json:"drop_on" yaml:"drop_on"`
 	SuccessfulOn        []int                        `json:"successful_on" yaml:"successful_on"`
 	DumpRequestLogLevel string                       `json:"dump_request_log_level" yaml:"dump_request_log_level"`
+	PropagateTrace      bool                         `json:"propagate_trace_context" yaml:"propagate_trace_context"`
 	TLS                 tls.Config                   `json:"tls" yaml:"tls"`
 	ProxyURL            string                       `json:"proxy_url" yaml:"proxy_url"`
+	Transport           TransportConfig              `json:"transport" yaml:"transport"`
 	AuthConfig          `json:",inline" yaml:",inline"`
 	OAuth2              OAuth2Config `json:"oauth2" yaml:"oauth2"`
 }
@@ -142,7 +146,9 @@ func NewOldConfig() OldConfig {
 		BackoffOn:       []int{429},
 		DropOn:          []int{},
 		SuccessfulOn:    []int{},
+		PropagateTrace:  false,
 		TLS:             tls.NewConfig(),
+		Transport:       NewTransportConfig(),
 		AuthConfig:      NewAuthConfig(),
 		OAuth2:          NewOAuth2Config(),
 	}
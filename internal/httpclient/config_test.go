@@ -98,6 +98,24 @@ oauth2:
 				o.OAuth2.ClientKey = "moo"
 			}),
 		},
+		{
+			name: "transport overrides",
+			inputYAML: `
+url: example.com/foo5
+transport:
+  max_idle_conns_per_host: 64
+  max_conns_per_host: 128
+  idle_conn_timeout: ""
+  http2: false
+`,
+			outputConf: fromDefault(func(o *OldConfig) {
+				o.URL = "example.com/foo5"
+				o.Transport.MaxIdleConnsPerHost = 64
+				o.Transport.MaxConnsPerHost = 128
+				o.Transport.IdleConnTimeout = ""
+				o.Transport.HTTP2 = false
+			}),
+		},
 	}
 
 	for _, test := range tests {
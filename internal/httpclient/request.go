@@ -2,19 +2,25 @@ package httpclient
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
+	"sort"
 	"strings"
 
+	"go.opentelemetry.io/otel/propagation"
+
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/metadata"
+	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
 // MultipartExpressions represents three dynamic expressions that define a
@@ -46,6 +52,7 @@ type RequestCreator struct {
 	verb             string
 	headers          map[string]*field.Expression
 	metaInsertFilter *metadata.IncludeFilter
+	propagateTrace   bool
 }
 
 // RequestOpt represents a customisation of a request creator.
@@ -60,6 +67,8 @@ func RequestCreatorFromOldConfig(conf OldConfig, mgr bundle.NewManagement, opts
 		reqSigner: conf.AuthConfig.Sign,
 		verb:      conf.Verb,
 		headers:   map[string]*field.Expression{},
+
+		propagateTrace: conf.PropagateTrace,
 	}
 	for _, opt := range opts {
 		opt(r)
@@ -235,19 +244,12 @@ func (r *RequestCreator) Create(refBatch message.Batch) (req *http.Request, err
 		return
 	}
 
-	for k, v := range r.headers {
-		var hStr string
-		if hStr, err = v.String(0, refBatch); err != nil {
-			err = fmt.Errorf("header '%v' interpolation error: %w", k, err)
-			return
-		}
-		req.Header.Add(k, hStr)
+	var header http.Header
+	if header, err = r.Header(refBatch); err != nil {
+		return
 	}
-	if len(refBatch) > 0 {
-		_ = r.metaInsertFilter.Iter(refBatch[0], func(k string, v any) error {
-			req.Header.Add(k, query.IToString(v))
-			return nil
-		})
+	for k, v := range header {
+		req.Header[k] = v
 	}
 
 	if r.host != nil {
@@ -260,7 +262,78 @@ func (r *RequestCreator) Create(refBatch message.Batch) (req *http.Request, err
 		req.Header.Del("Content-Type")
 		req.Header.Add("Content-Type", overrideContentType)
 	}
+	if r.propagateTrace && len(refBatch) > 0 {
+		tracing.InjectW3CTraceContext(refBatch[0].GetContext(), propagation.HeaderCarrier(req.Header))
+	}
 
 	err = r.reqSigner(r.fs, req)
 	return
 }
+
+// Header returns the headers of the request that would be created from a
+// reference batch, consisting of the configured headers and any metadata
+// included as headers, but excluding headers added by authentication and
+// tracing.
+func (r *RequestCreator) Header(refBatch message.Batch) (http.Header, error) {
+	header := http.Header{}
+	for k, v := range r.headers {
+		hStr, err := v.String(0, refBatch)
+		if err != nil {
+			return nil, fmt.Errorf("header '%v' interpolation error: %w", k, err)
+		}
+		header.Add(k, hStr)
+	}
+	if len(refBatch) > 0 {
+		_ = r.metaInsertFilter.Iter(refBatch[0], func(k string, v any) error {
+			header.Add(k, query.IToString(v))
+			return nil
+		})
+	}
+	return header, nil
+}
+
+// Key returns a string that identifies the request that would be created from
+// a reference batch, consisting of its verb, URL and a hash of its host,
+// headers and body.
+func (r *RequestCreator) Key(refBatch message.Batch) (string, error) {
+	body, _, err := r.body(refBatch)
+	if err != nil {
+		return "", err
+	}
+
+	urlStr, err := r.url.String(0, refBatch)
+	if err != nil {
+		return "", fmt.Errorf("url interpolation error: %w", err)
+	}
+
+	header, err := r.Header(refBatch)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if r.host != nil {
+		hostStr, err := r.host.String(0, refBatch)
+		if err != nil {
+			return "", fmt.Errorf("host interpolation error: %w", err)
+		}
+		_, _ = fmt.Fprintf(h, "Host: %s\r\n", hostStr)
+	}
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			_, _ = fmt.Fprintf(h, "%s: %s\r\n", k, v)
+		}
+	}
+	_, _ = h.Write([]byte("\r\n"))
+	if body != nil {
+		if _, err := io.Copy(h, body); err != nil {
+			return "", err
+		}
+	}
+	return r.verb + " " + urlStr + " " + hex.EncodeToString(h.Sum(nil)), nil
+}
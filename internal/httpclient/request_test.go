@@ -1,10 +1,14 @@
 package httpclient
 
 import (
+	"context"
 	"testing"
 
+	"go.opentelemetry.io/otel/propagation"
+
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/tracing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -31,3 +35,33 @@ func TestSingleMessageHeaders(t *testing.T) {
 	assert.Equal(t, []string{"barvalue"}, req.Header.Values("more_bar"))
 	assert.Equal(t, []string(nil), req.Header.Values("ignore_baz"))
 }
+
+func TestPropagateTraceContextHeaders(t *testing.T) {
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	spanCtx := tracing.ExtractW3CTraceContext(context.Background(), propagation.MapCarrier{
+		"traceparent": traceParent,
+	})
+
+	oldConf := NewOldConfig()
+	oldConf.Headers["traceparent"] = "nope"
+
+	reqCreator, err := RequestCreatorFromOldConfig(oldConf, mock.NewManager())
+	require.NoError(t, err)
+
+	req, err := reqCreator.Create(message.Batch{message.NewPart(nil).WithContext(spanCtx)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nope"}, req.Header.Values("traceparent"))
+
+	oldConf.PropagateTrace = true
+	reqCreator, err = RequestCreatorFromOldConfig(oldConf, mock.NewManager())
+	require.NoError(t, err)
+
+	req, err = reqCreator.Create(message.Batch{message.NewPart(nil).WithContext(spanCtx)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{traceParent}, req.Header.Values("traceparent"))
+
+	req, err = reqCreator.Create(message.Batch{message.NewPart(nil)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nope"}, req.Header.Values("traceparent"))
+}
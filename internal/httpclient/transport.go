@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+func transportFieldSpec() docs.FieldSpec {
+	return docs.FieldObject("transport", "Customise the connection pool and protocol settings of the underlying HTTP transport, which is useful for tuning components that make a high volume of requests.").WithChildren(
+		docs.FieldInt("max_idle_conns", "The maximum number of idle connections to keep open across all hosts. Zero means no limit.").HasDefault(100),
+		docs.FieldInt("max_idle_conns_per_host", "The maximum number of idle connections to keep open per host. This should be increased to at least the number of requests expected to be in flight with a single host, otherwise connections are closed and reopened frequently.").HasDefault(2),
+		docs.FieldInt("max_conns_per_host", "The maximum number of connections per host, including those that are active. Requests are blocked until a connection is available once the limit is reached. Zero means no limit.").HasDefault(0),
+		docs.FieldString("idle_conn_timeout", "The maximum period of time an idle connection is kept open before it is closed. An empty string means connections are kept open indefinitely.").HasDefault("90s"),
+		docs.FieldString("keep_alive", "The interval between TCP keep-alive probes of open connections. An empty string disables keep-alive probes.").HasDefault("30s"),
+		docs.FieldBool("disable_keep_alives", "Whether to disable HTTP keep-alives, in which case a new connection is opened for each request.").HasDefault(false),
+		docs.FieldBool("http2", "Whether to attempt HTTP/2 connections with hosts that support it.").HasDefault(true),
+	).Advanced().AtVersion("4.20.0")
+}
+
+// TransportConfig contains configuration params for the connection pool and
+// protocol settings of an HTTP transport.
+type TransportConfig struct {
+	MaxIdleConns        int    `json:"max_idle_conns" yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost int    `json:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int    `json:"max_conns_per_host" yaml:"max_conns_per_host"`
+	IdleConnTimeout     string `json:"idle_conn_timeout" yaml:"idle_conn_timeout"`
+	KeepAlive           string `json:"keep_alive" yaml:"keep_alive"`
+	DisableKeepAlives   bool   `json:"disable_keep_alives" yaml:"disable_keep_alives"`
+	HTTP2               bool   `json:"http2" yaml:"http2"`
+}
+
+// NewTransportConfig returns a TransportConfig with default values, which
+// match those of the default transport of the standard library.
+func NewTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: http.DefaultMaxIdleConnsPerHost,
+		MaxConnsPerHost:     0,
+		IdleConnTimeout:     "90s",
+		KeepAlive:           "30s",
+		DisableKeepAlives:   false,
+		HTTP2:               true,
+	}
+}
+
+// NewTransport creates an HTTP transport with the configured settings.
+func (t TransportConfig) NewTransport() (*http.Transport, error) {
+	var tr *http.Transport
+	if c, ok := http.DefaultTransport.(*http.Transport); ok {
+		tr = c.Clone()
+	} else {
+		tr = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+
+	tr.MaxIdleConns = t.MaxIdleConns
+	tr.MaxIdleConnsPerHost = t.MaxIdleConnsPerHost
+	tr.MaxConnsPerHost = t.MaxConnsPerHost
+	tr.DisableKeepAlives = t.DisableKeepAlives
+
+	tr.IdleConnTimeout = 0
+	if t.IdleConnTimeout != "" {
+		var err error
+		if tr.IdleConnTimeout, err = time.ParseDuration(t.IdleConnTimeout); err != nil {
+			return nil, fmt.Errorf("failed to parse idle_conn_timeout: %v", err)
+		}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: -1,
+	}
+	if t.KeepAlive != "" {
+		var err error
+		if dialer.KeepAlive, err = time.ParseDuration(t.KeepAlive); err != nil {
+			return nil, fmt.Errorf("failed to parse keep_alive: %v", err)
+		}
+	}
+	tr.DialContext = dialer.DialContext
+
+	tr.ForceAttemptHTTP2 = t.HTTP2
+	if !t.HTTP2 {
+		// A non-nil empty map prevents the transport from negotiating HTTP/2.
+		tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return tr, nil
+}
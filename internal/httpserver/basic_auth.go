@@ -85,7 +85,7 @@ func (b BasicAuthConfig) WrapHandler(next http.HandlerFunc) http.HandlerFunc {
 			pass = ""
 		}
 
-		if ok, err := b.matches(user, pass); !ok || err != nil {
+		if ok, err := b.Matches(user, pass); !ok || err != nil {
 			if err != nil {
 				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 				return
@@ -112,7 +112,9 @@ func BasicAuthFieldSpec() docs.FieldSpec {
 	).Advanced()
 }
 
-func (b BasicAuthConfig) matches(user, pass string) (bool, error) {
+// Matches returns true if the provided credentials match the configured
+// username and password hash.
+func (b BasicAuthConfig) Matches(user, pass string) (bool, error) {
 	expectedPassHash, err := base64.StdEncoding.DecodeString(b.PasswordHash)
 	if err != nil {
 		return false, err
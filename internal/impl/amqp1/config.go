@@ -18,8 +18,17 @@ const (
 	saslPassField = "password"
 
 	// Input
-	sourceAddrField     = "source_address"
-	azureRenewLockField = "azure_renew_lock"
+	sourceAddrField         = "source_address"
+	azureRenewLockField     = "azure_renew_lock"
+	creditField             = "credit"
+	senderSettleModeField   = "sender_settle_mode"
+	receiverSettleModeField = "receiver_settle_mode"
+	durableField            = "durable_subscription"
+	durableEnabledField     = "enabled"
+	durableNameField        = "name"
+	durableContainerIDField = "container_id"
+	durableDurabilityField  = "durability"
+	durableExpiryField      = "expiry_policy"
 
 	// Output
 	targetAddrField  = "target_address"
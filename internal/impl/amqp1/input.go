@@ -30,10 +30,21 @@ This input adds the following metadata fields to each message:
 - amqp_content_type
 - amqp_content_encoding
 - amqp_creation_time
-- All string typed message annotations
+- All message annotations
+- All delivery annotations
 `+"```"+`
 
-You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).`).
+Annotations with string, boolean, numeric, binary or timestamp values are added with any hyphens of their keys replaced with underscores, e.g. the annotation `+"`x-opt-sequence-number`"+` becomes the metadata key `+"`x_opt_sequence_number`"+`.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).
+
+### Durable Subscriptions
+
+Brokers such as ActiveMQ Artemis identify a durable subscription to a topic by the container ID of the connection and the name of the link. When `+"`durable_subscription.enabled`"+` is `+"`true`"+` the subscription is resumed when the input reconnects, and is left in place when the input is closed so that messages sent in the meantime are retained by the broker.
+
+### Flow Control
+
+The `+"`credit`"+` field sets the number of messages the broker may send before they are acknowledged, raising it increases throughput at the cost of more messages being redelivered after a failure. The settlement modes of the link can be set with `+"`sender_settle_mode` and `receiver_settle_mode`"+`, where a `+"`sender_settle_mode`"+` of `+"`settled`"+` results in at-most-once delivery as messages are settled by the broker before they are sent.`).
 		Fields(
 			service.NewURLField(urlField).
 				Description("A URL to connect to.").
@@ -49,6 +60,45 @@ You can access these metadata fields using [function interpolation](/docs/config
 				Version("3.45.0").
 				Default(false).
 				Advanced(),
+			service.NewIntField(creditField).
+				Description("The maximum number of messages that can be received before they are acknowledged.").
+				Default(10).
+				Advanced(),
+			service.NewStringField(senderSettleModeField).
+				Description("The settlement mode requested of the sender, which is either `unsettled`, `settled` or `mixed`. When empty the mode of the server is accepted.").
+				Default("").
+				Advanced(),
+			service.NewStringField(receiverSettleModeField).
+				Description("The settlement mode of the receiver, which is either `first`, where messages are settled as soon as they are acknowledged, or `second`, where messages are only settled once the sender has confirmed the acknowledgement. When empty the mode of the server is accepted.").
+				Default("").
+				Advanced(),
+			service.NewObjectField(durableField,
+				service.NewBoolField(durableEnabledField).
+					Description("Whether to consume from a durable subscription.").
+					Default(false),
+				service.NewStringField(durableNameField).
+					Description("The name of the link, which identifies the subscription and must be the same each time the input connects.").
+					Default(""),
+				service.NewStringField(durableContainerIDField).
+					Description("The container ID of the connection, which together with the name identifies the subscription. When empty a random ID is generated by the client, which prevents a subscription from being resumed on brokers that include the container ID in its identity.").
+					Default(""),
+				service.NewStringAnnotatedEnumField(durableDurabilityField, map[string]string{
+					"configuration":   "Only the existence and configuration of the subscription is retained.",
+					"unsettled_state": "The unsettled state of messages is also retained.",
+				}).
+					Description("The durability of the source terminus.").
+					Default("unsettled_state"),
+				service.NewStringAnnotatedEnumField(durableExpiryField, map[string]string{
+					"link-detach":      "The subscription expires once the link is detached.",
+					"session-end":      "The subscription expires once the session ends.",
+					"connection-close": "The subscription expires once the connection is closed.",
+					"never":            "The subscription never expires.",
+				}).
+					Description("When the subscription expires after the input disconnects.").
+					Default("never"),
+			).
+				Description("Consume from a durable subscription, which retains its position and unsettled messages while the input is disconnected.").
+				Advanced(),
 			service.NewTLSToggledField(tlsField),
 			saslFieldSpec(),
 		)
@@ -70,7 +120,9 @@ type amqp1Reader struct {
 	url        string
 	sourceAddr string
 	renewLock  bool
+	durable    bool
 	connOpts   []amqp.ConnOption
+	linkOpts   []amqp.LinkOption
 	log        *service.Logger
 
 	m    sync.RWMutex
@@ -99,6 +151,33 @@ func amqp1ReaderFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (
 		return nil, err
 	}
 
+	credit, err := conf.FieldInt(creditField)
+	if err != nil {
+		return nil, err
+	}
+	if credit < 1 {
+		return nil, errors.New("credit must be at least 1")
+	}
+	a.linkOpts = append(a.linkOpts, amqp.LinkSourceAddress(a.sourceAddr), amqp.LinkCredit(uint32(credit)))
+
+	settleOpts, err := settleModeOptsFromParsed(conf)
+	if err != nil {
+		return nil, err
+	}
+	a.linkOpts = append(a.linkOpts, settleOpts...)
+
+	if a.durable, err = conf.FieldBool(durableField, durableEnabledField); err != nil {
+		return nil, err
+	}
+	if a.durable {
+		durableConnOpts, durableLinkOpts, err := durableOptsFromParsed(conf.Namespace(durableField))
+		if err != nil {
+			return nil, err
+		}
+		a.connOpts = append(a.connOpts, durableConnOpts...)
+		a.linkOpts = append(a.linkOpts, durableLinkOpts...)
+	}
+
 	tlsConf, enabled, err := conf.FieldTLSToggled(tlsField)
 	if err != nil {
 		return nil, err
@@ -110,6 +189,95 @@ func amqp1ReaderFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (
 	return &a, nil
 }
 
+func settleModeOptsFromParsed(conf *service.ParsedConfig) (opts []amqp.LinkOption, err error) {
+	var senderMode, receiverMode string
+	if senderMode, err = conf.FieldString(senderSettleModeField); err != nil {
+		return
+	}
+	if receiverMode, err = conf.FieldString(receiverSettleModeField); err != nil {
+		return
+	}
+
+	switch senderMode {
+	case "":
+	case "unsettled":
+		opts = append(opts, amqp.LinkSenderSettle(amqp.ModeUnsettled))
+	case "settled":
+		opts = append(opts, amqp.LinkSenderSettle(amqp.ModeSettled))
+	case "mixed":
+		opts = append(opts, amqp.LinkSenderSettle(amqp.ModeMixed))
+	default:
+		return nil, fmt.Errorf("sender settle mode '%v' not recognised", senderMode)
+	}
+
+	switch receiverMode {
+	case "":
+	case "first":
+		opts = append(opts, amqp.LinkReceiverSettle(amqp.ModeFirst))
+	case "second":
+		opts = append(opts, amqp.LinkReceiverSettle(amqp.ModeSecond))
+	default:
+		return nil, fmt.Errorf("receiver settle mode '%v' not recognised", receiverMode)
+	}
+	return
+}
+
+func durableOptsFromParsed(conf *service.ParsedConfig) (connOpts []amqp.ConnOption, linkOpts []amqp.LinkOption, err error) {
+	var name, containerID, durabilityStr, expiryStr string
+	if name, err = conf.FieldString(durableNameField); err != nil {
+		return
+	}
+	if name == "" {
+		err = errors.New("a durable subscription requires a name")
+		return
+	}
+	if containerID, err = conf.FieldString(durableContainerIDField); err != nil {
+		return
+	}
+	if containerID != "" {
+		connOpts = append(connOpts, amqp.ConnContainerID(containerID))
+	}
+
+	if durabilityStr, err = conf.FieldString(durableDurabilityField); err != nil {
+		return
+	}
+	var durability amqp.Durability
+	switch durabilityStr {
+	case "configuration":
+		durability = amqp.DurabilityConfiguration
+	case "unsettled_state":
+		durability = amqp.DurabilityUnsettledState
+	default:
+		err = fmt.Errorf("durability '%v' not recognised", durabilityStr)
+		return
+	}
+
+	if expiryStr, err = conf.FieldString(durableExpiryField); err != nil {
+		return
+	}
+	var expiry amqp.ExpiryPolicy
+	switch expiryStr {
+	case "link-detach":
+		expiry = amqp.ExpiryLinkDetach
+	case "session-end":
+		expiry = amqp.ExpirySessionEnd
+	case "connection-close":
+		expiry = amqp.ExpiryConnectionClose
+	case "never":
+		expiry = amqp.ExpiryNever
+	default:
+		err = fmt.Errorf("expiry policy '%v' not recognised", expiryStr)
+		return
+	}
+
+	linkOpts = append(linkOpts,
+		amqp.LinkName(name),
+		amqp.LinkSourceDurability(durability),
+		amqp.LinkSourceExpiryPolicy(expiry),
+	)
+	return
+}
+
 func (a *amqp1Reader) Connect(ctx context.Context) (err error) {
 	a.m.Lock()
 	defer a.m.Unlock()
@@ -121,6 +289,7 @@ func (a *amqp1Reader) Connect(ctx context.Context) (err error) {
 	conn := &amqp1Conn{
 		log:                    a.log,
 		lockRenewAddressPrefix: randomString(15),
+		durable:                a.durable,
 	}
 
 	// Create client
@@ -135,10 +304,7 @@ func (a *amqp1Reader) Connect(ctx context.Context) (err error) {
 	}
 
 	// Create a receiver
-	if conn.receiver, err = conn.session.NewReceiver(
-		amqp.LinkSourceAddress(a.sourceAddr),
-		amqp.LinkCredit(10),
-	); err != nil {
+	if conn.receiver, err = conn.session.NewReceiver(a.linkOpts...); err != nil {
 		_ = conn.Close(ctx)
 		return
 	}
@@ -204,30 +370,7 @@ func (a *amqp1Reader) ReadBatch(ctx context.Context) (service.MessageBatch, serv
 		return nil, nil, err
 	}
 
-	var part *service.Message
-
-	if data := amqpMsg.GetData(); data != nil {
-		part = service.NewMessage(data)
-	} else if value, ok := amqpMsg.Value.(string); ok {
-		part = service.NewMessage([]byte(value))
-	} else {
-		part = service.NewMessage(nil)
-	}
-
-	if amqpMsg.Properties != nil {
-		amqpSetMetadata(part, "amqp_content_type", amqpMsg.Properties.ContentType)
-		amqpSetMetadata(part, "amqp_content_encoding", amqpMsg.Properties.ContentEncoding)
-		amqpSetMetadata(part, "amqp_creation_time", amqpMsg.Properties.CreationTime)
-	}
-	if amqpMsg.Annotations != nil {
-		for k, v := range amqpMsg.Annotations {
-			keyStr, keyIsStr := k.(string)
-			valStr, valIsStr := v.(string)
-			if keyIsStr && valIsStr {
-				amqpSetMetadata(part, keyStr, valStr)
-			}
-		}
-	}
+	part := amqpMessageToPart(amqpMsg)
 
 	var done chan struct{}
 	if a.renewLock {
@@ -253,6 +396,38 @@ func (a *amqp1Reader) Close(ctx context.Context) error {
 	return a.disconnect(ctx)
 }
 
+func amqpMessageToPart(amqpMsg *amqp.Message) *service.Message {
+	var part *service.Message
+
+	if data := amqpMsg.GetData(); data != nil {
+		part = service.NewMessage(data)
+	} else if value, ok := amqpMsg.Value.(string); ok {
+		part = service.NewMessage([]byte(value))
+	} else {
+		part = service.NewMessage(nil)
+	}
+
+	if props := amqpMsg.Properties; props != nil {
+		if props.ContentType != nil {
+			amqpSetMetadata(part, "amqp_content_type", *props.ContentType)
+		}
+		if props.ContentEncoding != nil {
+			amqpSetMetadata(part, "amqp_content_encoding", *props.ContentEncoding)
+		}
+		if props.CreationTime != nil {
+			amqpSetMetadata(part, "amqp_creation_time", *props.CreationTime)
+		}
+	}
+	for _, annotations := range []amqp.Annotations{amqpMsg.DeliveryAnnotations, amqpMsg.Annotations} {
+		for k, v := range annotations {
+			if keyStr, keyIsStr := k.(string); keyIsStr {
+				amqpSetMetadata(part, keyStr, v)
+			}
+		}
+	}
+	return part
+}
+
 //------------------------------------------------------------------------------
 
 type amqp1Conn struct {
@@ -264,6 +439,7 @@ type amqp1Conn struct {
 
 	log                    *service.Logger
 	lockRenewAddressPrefix string
+	durable                bool
 }
 
 func (c *amqp1Conn) Close(ctx context.Context) error {
@@ -277,7 +453,10 @@ func (c *amqp1Conn) Close(ctx context.Context) error {
 			c.log.Errorf("Failed to cleanly close renew lock receiver: %v\n", err)
 		}
 	}
-	if c.receiver != nil {
+	// Closing the receiver of a durable subscription would also remove the
+	// subscription, and so we leave it to expire as configured when the
+	// session and connection are closed.
+	if c.receiver != nil && !c.durable {
 		if err := c.receiver.Close(ctx); err != nil {
 			c.log.Errorf("Failed to cleanly close receiver: %v\n", err)
 		}
@@ -437,6 +616,12 @@ func amqpSetMetadata(p *service.Message, k string, v any) {
 		metaValue = strconv.Itoa(int(v))
 	case int64:
 		metaValue = strconv.Itoa(int(v))
+	case uint16:
+		metaValue = strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		metaValue = strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		metaValue = strconv.FormatUint(v, 10)
 	case nil:
 		metaValue = ""
 	case string:
@@ -0,0 +1,53 @@
+package amqp1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAMQP1MessageToPart(t *testing.T) {
+	enqueuedAt := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	contentType := "application/json"
+	createdAt := time.Date(2023, 1, 2, 3, 4, 0, 0, time.UTC)
+
+	part := amqpMessageToPart(&amqp.Message{
+		Data: [][]byte{[]byte(`{"foo":"bar"}`)},
+		Properties: &amqp.MessageProperties{
+			ContentType:  &contentType,
+			CreationTime: &createdAt,
+		},
+		DeliveryAnnotations: amqp.Annotations{
+			"x-opt-lock-token": "abc",
+			"x-opt-priority":   uint32(4),
+		},
+		Annotations: amqp.Annotations{
+			"x-opt-sequence-number": int64(12),
+			"x-opt-enqueued-time":   enqueuedAt,
+			"x-opt-partition-key":   "foo",
+			int64(10):               "ignored",
+		},
+	})
+
+	b, err := part.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, string(b))
+
+	meta := map[string]any{}
+	require.NoError(t, part.MetaWalkMut(func(k string, v any) error {
+		meta[k] = v
+		return nil
+	}))
+	assert.Equal(t, map[string]any{
+		"amqp_content_type":     "application/json",
+		"amqp_creation_time":    "2023-01-02T03:04:00Z",
+		"x_opt_lock_token":      "abc",
+		"x_opt_priority":        "4",
+		"x_opt_sequence_number": "12",
+		"x_opt_enqueued_time":   "2023-01-02T03:04:05Z",
+		"x_opt_partition_key":   "foo",
+	}, meta)
+}
@@ -0,0 +1,628 @@
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+type avroBloblangCodec struct {
+	codec    *goavro.Codec
+	schema   any
+	named    map[string]any
+	encoding string
+	rawJSON  bool
+	logical  bool
+}
+
+func avroCodecFromParams(args *bloblang.ParsedParams) (*avroBloblangCodec, error) {
+	schema, err := args.GetString("schema")
+	if err != nil {
+		return nil, err
+	}
+	schemaPath, err := args.GetString("schema_path")
+	if err != nil {
+		return nil, err
+	}
+	c := &avroBloblangCodec{}
+	if c.encoding, err = args.GetString("encoding"); err != nil {
+		return nil, err
+	}
+	switch c.encoding {
+	case "textual", "binary", "single":
+	default:
+		return nil, fmt.Errorf("encoding '%v' not recognised", c.encoding)
+	}
+	if c.rawJSON, err = args.GetBool("raw_json"); err != nil {
+		return nil, err
+	}
+	if c.logical, err = args.GetBool("logical_types"); err != nil {
+		return nil, err
+	}
+
+	if schemaPath != "" {
+		if schema != "" {
+			return nil, errors.New("only one of `schema` or `schema_path` may be specified")
+		}
+		if !(strings.HasPrefix(schemaPath, "file://") || strings.HasPrefix(schemaPath, "http://")) {
+			return nil, errors.New("invalid schema_path provided, must start with file:// or http://")
+		}
+		if schema, err = loadSchema(schemaPath); err != nil {
+			return nil, fmt.Errorf("failed to load Avro schema definition: %v", err)
+		}
+	}
+	if schema == "" {
+		return nil, errors.New("a schema must be specified with either the `schema` or `schema_path` parameters")
+	}
+
+	if err := json.Unmarshal([]byte(schema), &c.schema); err != nil {
+		// Schemas consisting of only a primitive type name are valid without
+		// quotes.
+		c.schema = schema
+	}
+	if !c.logical {
+		c.schema = stripLogicalTypes(c.schema)
+		schemaBytes, err := json.Marshal(c.schema)
+		if err != nil {
+			return nil, err
+		}
+		schema = string(schemaBytes)
+	}
+
+	c.named = map[string]any{}
+	collectNamedTypes(c.schema, "", c.named)
+
+	if c.codec, err = goavro.NewCodec(schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %v", err)
+	}
+	return c, nil
+}
+
+func (c *avroBloblangCodec) decode(b []byte) (v any, err error) {
+	switch c.encoding {
+	case "textual":
+		v, _, err = c.codec.NativeFromTextual(b)
+	case "binary":
+		v, _, err = c.codec.NativeFromBinary(b)
+	case "single":
+		v, _, err = c.codec.NativeFromSingle(b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Avro document: %v", err)
+	}
+	return c.fromNative(c.schema, v), nil
+}
+
+func (c *avroBloblangCodec) encode(v any) (b []byte, err error) {
+	if v, err = c.toNative(c.schema, v); err != nil {
+		return nil, err
+	}
+	switch c.encoding {
+	case "textual":
+		b, err = c.codec.TextualFromNative(nil, v)
+	case "binary":
+		b, err = c.codec.BinaryFromNative(nil, v)
+	case "single":
+		b, err = c.codec.SingleFromNative(nil, v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Avro document: %v", err)
+	}
+	return b, nil
+}
+
+//------------------------------------------------------------------------------
+
+func stripLogicalTypes(schema any) any {
+	switch t := schema.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(t))
+		for k, v := range t {
+			if k == "logicalType" {
+				continue
+			}
+			res[k] = stripLogicalTypes(v)
+		}
+		return res
+	case []any:
+		res := make([]any, len(t))
+		for i, v := range t {
+			res[i] = stripLogicalTypes(v)
+		}
+		return res
+	}
+	return schema
+}
+
+func schemaFullName(s map[string]any, namespace string) (fullName, ns string) {
+	name, _ := s["name"].(string)
+	if n, ok := s["namespace"].(string); ok {
+		namespace = n
+	}
+	if strings.Contains(name, ".") {
+		return name, name[:strings.LastIndex(name, ".")]
+	}
+	if namespace == "" {
+		return name, namespace
+	}
+	return namespace + "." + name, namespace
+}
+
+// collectNamedTypes walks a schema and registers each named type (records,
+// enums and fixed) under both its short and full name so that references to
+// them can be resolved whilst coercing values.
+func collectNamedTypes(schema any, namespace string, named map[string]any) {
+	switch t := schema.(type) {
+	case map[string]any:
+		typeStr, _ := t["type"].(string)
+		switch typeStr {
+		case "record", "error", "enum", "fixed":
+			fullName, ns := schemaFullName(t, namespace)
+			named[fullName] = t
+			if name, _ := t["name"].(string); name != "" {
+				if _, exists := named[name]; !exists {
+					named[name] = t
+				}
+			}
+			namespace = ns
+		}
+		if fields, ok := t["fields"].([]any); ok {
+			for _, f := range fields {
+				if fObj, ok := f.(map[string]any); ok {
+					collectNamedTypes(fObj["type"], namespace, named)
+				}
+			}
+		}
+		for _, k := range []string{"type", "items", "values"} {
+			if v, ok := t[k]; ok {
+				if _, isStr := v.(string); !isStr {
+					collectNamedTypes(v, namespace, named)
+				}
+			}
+		}
+	case []any:
+		for _, v := range t {
+			collectNamedTypes(v, namespace, named)
+		}
+	}
+}
+
+func unionBranchName(branch any, named map[string]any) string {
+	switch t := branch.(type) {
+	case string:
+		if n, ok := named[t].(map[string]any); ok {
+			fullName, _ := schemaFullName(n, "")
+			return fullName
+		}
+		return t
+	case map[string]any:
+		typeStr, _ := t["type"].(string)
+		switch typeStr {
+		case "record", "error", "enum", "fixed":
+			fullName, _ := schemaFullName(t, "")
+			return fullName
+		}
+		if lt, ok := t["logicalType"].(string); ok {
+			return typeStr + "." + lt
+		}
+		return typeStr
+	}
+	return ""
+}
+
+// toNative walks a value alongside its schema and converts it into the form
+// expected by the codec. Values of logical types that cannot be represented
+// natively within a mapping are converted, and when raw_json is enabled plain
+// union values are wrapped in objects keyed by the name of their type.
+func (c *avroBloblangCodec) toNative(schema, v any) (any, error) {
+	switch t := schema.(type) {
+	case string:
+		if n, ok := c.named[t]; ok {
+			return c.toNative(n, v)
+		}
+		return v, nil
+	case []any:
+		if v == nil {
+			return nil, nil
+		}
+		if c.rawJSON {
+			for _, branch := range t {
+				if branch == "null" || !c.valueMatches(branch, v) {
+					continue
+				}
+				res, err := c.toNative(branch, v)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{unionBranchName(branch, c.named): res}, nil
+			}
+			return nil, fmt.Errorf("value of type %T does not match any type of union", v)
+		}
+		obj, ok := v.(map[string]any)
+		if !ok || len(obj) != 1 {
+			return v, nil
+		}
+		for k, inner := range obj {
+			for _, branch := range t {
+				if unionBranchName(branch, c.named) == k {
+					res, err := c.toNative(branch, inner)
+					if err != nil {
+						return nil, err
+					}
+					return map[string]any{k: res}, nil
+				}
+			}
+		}
+		return v, nil
+	case map[string]any:
+		if lt, ok := t["logicalType"].(string); ok && v != nil {
+			return coerceLogicalValue(lt, v)
+		}
+		switch t["type"] {
+		case "record", "error":
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return v, nil
+			}
+			fields, _ := t["fields"].([]any)
+			res := make(map[string]any, len(obj))
+			for k, e := range obj {
+				res[k] = e
+			}
+			for _, f := range fields {
+				fObj, _ := f.(map[string]any)
+				name, _ := fObj["name"].(string)
+				e, exists := res[name]
+				if !exists {
+					continue
+				}
+				var err error
+				if res[name], err = c.toNative(fObj["type"], e); err != nil {
+					return nil, fmt.Errorf("field %v: %w", name, err)
+				}
+			}
+			return res, nil
+		case "array":
+			arr, ok := v.([]any)
+			if !ok {
+				return v, nil
+			}
+			res := make([]any, len(arr))
+			for i, e := range arr {
+				var err error
+				if res[i], err = c.toNative(t["items"], e); err != nil {
+					return nil, fmt.Errorf("index %v: %w", i, err)
+				}
+			}
+			return res, nil
+		case "map":
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return v, nil
+			}
+			res := make(map[string]any, len(obj))
+			for k, e := range obj {
+				var err error
+				if res[k], err = c.toNative(t["values"], e); err != nil {
+					return nil, fmt.Errorf("key %v: %w", k, err)
+				}
+			}
+			return res, nil
+		case "enum", "fixed":
+			return v, nil
+		}
+		return c.toNative(t["type"], v)
+	}
+	return v, nil
+}
+
+// valueMatches returns whether a plain value could be encoded as a given union
+// branch, branches are matched in the order they are defined.
+func (c *avroBloblangCodec) valueMatches(schema, v any) bool {
+	var typeStr, logicalType string
+	switch t := schema.(type) {
+	case string:
+		if n, ok := c.named[t]; ok {
+			return c.valueMatches(n, v)
+		}
+		typeStr = t
+	case map[string]any:
+		switch tt := t["type"].(type) {
+		case string:
+			typeStr = tt
+		default:
+			return c.valueMatches(tt, v)
+		}
+		logicalType, _ = t["logicalType"].(string)
+	default:
+		return false
+	}
+
+	isNumber := func() bool {
+		switch v.(type) {
+		case int, int32, int64, uint32, uint64, float32, float64, json.Number:
+			return true
+		}
+		return false
+	}
+	isInteger := func() bool {
+		switch n := v.(type) {
+		case int, int32, int64, uint32, uint64:
+			return true
+		case float64:
+			return n == float64(int64(n))
+		case json.Number:
+			_, err := n.Int64()
+			return err == nil
+		}
+		return false
+	}
+
+	switch logicalType {
+	case "decimal":
+		if _, isStr := v.(string); isStr {
+			_, err := coerceLogicalValue(logicalType, v)
+			return err == nil
+		}
+		if _, isRat := v.(*big.Rat); isRat {
+			return true
+		}
+		return isNumber()
+	case "time-millis", "time-micros", "timestamp-millis", "timestamp-micros", "local-timestamp-millis", "local-timestamp-micros", "date":
+		switch v.(type) {
+		case time.Time, time.Duration:
+			return true
+		case string:
+			_, err := coerceLogicalValue(logicalType, v)
+			return err == nil
+		}
+	}
+
+	switch typeStr {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "int", "long":
+		return isInteger()
+	case "float", "double":
+		return isNumber()
+	case "string", "enum":
+		_, ok := v.(string)
+		return ok
+	case "bytes", "fixed":
+		switch v.(type) {
+		case []byte, string:
+			return true
+		}
+	case "record", "error", "map":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	}
+	return false
+}
+
+// fromNative walks a value produced by the codec alongside its schema and
+// converts it into a form that is easier to work with in mappings.
+func (c *avroBloblangCodec) fromNative(schema, v any) any {
+	switch t := schema.(type) {
+	case string:
+		if n, ok := c.named[t]; ok {
+			return c.fromNative(n, v)
+		}
+	case []any:
+		obj, ok := v.(map[string]any)
+		if !ok || len(obj) != 1 {
+			break
+		}
+		for k, inner := range obj {
+			for _, branch := range t {
+				if unionBranchName(branch, c.named) == k {
+					inner = c.fromNative(branch, inner)
+					if c.rawJSON {
+						return inner
+					}
+					return map[string]any{k: inner}
+				}
+			}
+		}
+	case map[string]any:
+		if _, ok := t["logicalType"]; ok {
+			break
+		}
+		switch t["type"] {
+		case "record", "error":
+			obj, ok := v.(map[string]any)
+			if !ok {
+				break
+			}
+			fields, _ := t["fields"].([]any)
+			for _, f := range fields {
+				fObj, _ := f.(map[string]any)
+				name, _ := fObj["name"].(string)
+				if e, exists := obj[name]; exists {
+					obj[name] = c.fromNative(fObj["type"], e)
+				}
+			}
+			return obj
+		case "array":
+			arr, ok := v.([]any)
+			if !ok {
+				break
+			}
+			for i, e := range arr {
+				arr[i] = c.fromNative(t["items"], e)
+			}
+			return arr
+		case "map":
+			obj, ok := v.(map[string]any)
+			if !ok {
+				break
+			}
+			for k, e := range obj {
+				obj[k] = c.fromNative(t["values"], e)
+			}
+			return obj
+		case "enum", "fixed":
+		default:
+			return c.fromNative(t["type"], v)
+		}
+	}
+	return normaliseLogicalValue(v)
+}
+
+func coerceLogicalValue(logicalType string, v any) (any, error) {
+	switch logicalType {
+	case "decimal":
+		switch t := v.(type) {
+		case *big.Rat:
+			return t, nil
+		case json.Number:
+			if r, ok := new(big.Rat).SetString(t.String()); ok {
+				return r, nil
+			}
+		case string:
+			if r, ok := new(big.Rat).SetString(t); ok {
+				return r, nil
+			}
+		case float64:
+			return new(big.Rat).SetFloat64(t), nil
+		case int64:
+			return new(big.Rat).SetInt64(t), nil
+		case uint64:
+			return new(big.Rat).SetUint64(t), nil
+		case int:
+			return new(big.Rat).SetInt64(int64(t)), nil
+		}
+		return nil, fmt.Errorf("expected a decimal number, got %T", v)
+	case "time-millis", "time-micros":
+		if s, ok := v.(string); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %v value: %w", logicalType, err)
+			}
+			return d, nil
+		}
+	case "timestamp-millis", "timestamp-micros", "local-timestamp-millis", "local-timestamp-micros", "date":
+		if s, ok := v.(string); ok {
+			ts, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %v value: %w", logicalType, err)
+			}
+			return ts, nil
+		}
+	}
+	return v, nil
+}
+
+// normaliseLogicalValue converts the logical type values produced by the codec
+// into values that are easier to work with in mappings.
+func normaliseLogicalValue(v any) any {
+	switch t := v.(type) {
+	case *big.Rat:
+		return json.Number(ratDecimalString(t))
+	case time.Duration:
+		return t.String()
+	}
+	return v
+}
+
+// ratDecimalString formats a rational as a decimal with the fewest digits that
+// represent it exactly, decimal logical types always have a denominator that is
+// a power of ten.
+func ratDecimalString(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	denom := r.Denom()
+	ten, pow := big.NewInt(10), big.NewInt(1)
+	mod := new(big.Int)
+	for prec := 1; prec <= 64; prec++ {
+		pow.Mul(pow, ten)
+		if mod.Mod(pow, denom).Sign() == 0 {
+			return r.FloatString(prec)
+		}
+	}
+	return r.FloatString(64)
+}
+
+//------------------------------------------------------------------------------
+
+func init() {
+	avroParams := func(spec *bloblang.PluginSpec) *bloblang.PluginSpec {
+		return spec.
+			Param(bloblang.NewStringParam("schema").Description("A full Avro schema to use.").Default("")).
+			Param(bloblang.NewStringParam("schema_path").Description("The path of a schema document to apply, which must begin with either `file://` or `http://`. Use either this or the `schema` parameter.").Default("")).
+			Param(bloblang.NewStringParam("encoding").Description("An Avro encoding format to use, one of `textual`, `binary` or `single`.").Default("binary")).
+			Param(bloblang.NewBoolParam("raw_json").Description("Whether union values should be represented as plain values rather than objects keyed by the name of their type, as is the case with the [Avro JSON encoding](https://avro.apache.org/docs/current/specification/#json-encoding). When formatting a union value the first type of the union that the value matches is used.").Default(false)).
+			Param(bloblang.NewBoolParam("logical_types").Description("Whether logical types within the schema should be honoured. When disabled values of logical types are treated as their underlying primitive types.").Default(true))
+	}
+
+	parseSpec := avroParams(bloblang.NewPluginSpec().
+		Category(query.MethodCategoryParsing).
+		Version("4.20.0").
+		Description("Parses an [Avro](https://avro.apache.org/) encoded document into a structured value using a schema. Decimal logical types are parsed as numbers, time of day logical types are parsed as duration strings, and date and timestamp logical types are parsed as timestamps.").
+		Example("", `root.doc = this.doc.decode("base64").parse_avro(schema: """{"type":"record","name":"Foo","fields":[{"name":"a","type":"string"}]}""")`,
+			[2]string{
+				`{"doc":"BGhp"}`,
+				`{"doc":{"a":"hi"}}`,
+			},
+		))
+
+	if err := bloblang.RegisterMethodV2(
+		"parse_avro", parseSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			c, err := avroCodecFromParams(args)
+			if err != nil {
+				return nil, err
+			}
+			return func(v any) (any, error) {
+				b, err := query.IGetBytes(v)
+				if err != nil {
+					return nil, err
+				}
+				return c.decode(b)
+			}, nil
+		},
+	); err != nil {
+		panic(err)
+	}
+
+	formatSpec := avroParams(bloblang.NewPluginSpec().
+		Category(query.MethodCategoryParsing).
+		Version("4.20.0").
+		Description("Formats a structured value as an [Avro](https://avro.apache.org/) encoded document in bytes format using a schema. Values of decimal logical types may be numbers or strings, values of time of day logical types may be duration strings, and values of date and timestamp logical types may be timestamps, RFC 3339 formatted strings or numbers.").
+		Example("", `root.doc = this.doc.format_avro(schema: """{"type":"record","name":"Foo","fields":[{"name":"a","type":"string"}]}""").encode("base64")`,
+			[2]string{
+				`{"doc":{"a":"hi"}}`,
+				`{"doc":"BGhp"}`,
+			},
+		))
+
+	if err := bloblang.RegisterMethodV2(
+		"format_avro", formatSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			c, err := avroCodecFromParams(args)
+			if err != nil {
+				return nil, err
+			}
+			return func(v any) (any, error) {
+				return c.encode(v)
+			}, nil
+		},
+	); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,124 @@
+package avro
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+const bloblangTestSchema = `{
+  "type": "record",
+  "name": "Foo",
+  "namespace": "com.example",
+  "fields": [
+    { "name": "name", "type": "string" },
+    { "name": "price", "type": { "type": "bytes", "logicalType": "decimal", "precision": 8, "scale": 2 } },
+    { "name": "at", "type": { "type": "long", "logicalType": "timestamp-millis" } },
+    { "name": "maybe", "type": [ "null", { "type": "bytes", "logicalType": "decimal", "precision": 8, "scale": 2 } ] },
+    { "name": "children", "type": { "type": "array", "items": { "type": "record", "name": "Bar", "fields": [
+      { "name": "id", "type": "int" }
+    ] } } },
+    { "name": "favourite", "type": [ "null", "Bar" ] }
+  ]
+}`
+
+func TestAvroBloblangRoundTrip(t *testing.T) {
+	schemaArg, err := json.Marshal(bloblangTestSchema)
+	require.NoError(t, err)
+
+	for _, encoding := range []string{"binary", "textual", "single"} {
+		encoding := encoding
+		t.Run(encoding, func(t *testing.T) {
+			exec, err := bloblang.Parse(`root = this.format_avro(schema: ` + string(schemaArg) + `, encoding: "` + encoding + `").parse_avro(schema: ` + string(schemaArg) + `, encoding: "` + encoding + `")`)
+			require.NoError(t, err)
+
+			res, err := exec.Query(map[string]any{
+				"name":  "foo",
+				"price": json.Number("12.5"),
+				"at":    "2023-01-02T03:04:05Z",
+				"maybe": map[string]any{"bytes.decimal": "3.25"},
+				"children": []any{
+					map[string]any{"id": 1},
+				},
+				"favourite": map[string]any{"com.example.Bar": map[string]any{"id": 2}},
+			})
+			require.NoError(t, err)
+
+			obj, ok := res.(map[string]any)
+			require.True(t, ok)
+
+			at, ok := obj["at"].(time.Time)
+			require.True(t, ok, "%T", obj["at"])
+			assert.True(t, at.Equal(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)))
+			delete(obj, "at")
+
+			assert.Equal(t, map[string]any{
+				"name":  "foo",
+				"price": json.Number("12.5"),
+				"maybe": map[string]any{"bytes.decimal": json.Number("3.25")},
+				"children": []any{
+					map[string]any{"id": int32(1)},
+				},
+				"favourite": map[string]any{"com.example.Bar": map[string]any{"id": int32(2)}},
+			}, obj)
+		})
+	}
+}
+
+func TestAvroBloblangRawJSON(t *testing.T) {
+	schemaArg, err := json.Marshal(bloblangTestSchema)
+	require.NoError(t, err)
+
+	exec, err := bloblang.Parse(`root = this.format_avro(schema: ` + string(schemaArg) + `, raw_json: true).parse_avro(schema: ` + string(schemaArg) + `, raw_json: true)`)
+	require.NoError(t, err)
+
+	res, err := exec.Query(map[string]any{
+		"name":      "foo",
+		"price":     "1",
+		"at":        int64(0),
+		"maybe":     12.75,
+		"children":  []any{},
+		"favourite": nil,
+	})
+	require.NoError(t, err)
+
+	obj, ok := res.(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, json.Number("1"), obj["price"])
+	assert.Equal(t, json.Number("12.75"), obj["maybe"])
+	assert.Nil(t, obj["favourite"])
+}
+
+func TestAvroBloblangNoLogicalTypes(t *testing.T) {
+	exec, err := bloblang.Parse(`root = this.format_avro(schema: """{"type":"long","logicalType":"timestamp-millis"}""", logical_types: false).parse_avro(schema: """{"type":"long","logicalType":"timestamp-millis"}""", logical_types: false)`)
+	require.NoError(t, err)
+
+	res, err := exec.Query(int64(1000))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), res)
+}
+
+func TestAvroBloblangErrors(t *testing.T) {
+	_, err := bloblang.Parse(`root = this.parse_avro()`)
+	require.Error(t, err)
+
+	_, err = bloblang.Parse(`root = this.parse_avro(schema: "{}")`)
+	require.Error(t, err)
+
+	_, err = bloblang.Parse(`root = this.parse_avro(schema_path: "nope.avsc")`)
+	require.Error(t, err)
+
+	_, err = bloblang.Parse(`root = this.parse_avro(schema: "string", encoding: "nope")`)
+	require.Error(t, err)
+
+	exec, err := bloblang.Parse(`root = this.format_avro(schema: """{"type":"bytes","logicalType":"decimal","precision":4,"scale":2}""")`)
+	require.NoError(t, err)
+
+	_, err = exec.Query("not a number")
+	require.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func init() {
+	var (
+		client     *s3.S3
+		clientErr  error
+		clientOnce sync.Once
+	)
+
+	// Lookup tables are referenced by URLs of the form s3://bucket/key, and
+	// credentials, region, etc, are obtained from the default AWS credential
+	// chain and shared config.
+	service.RegisterLookupSource("s3", func(ctx context.Context, path string) ([]byte, error) {
+		u, err := url.Parse(path)
+		if err != nil {
+			return nil, err
+		}
+		key := strings.TrimPrefix(u.Path, "/")
+		if u.Host == "" || key == "" {
+			return nil, fmt.Errorf("expected path of the form s3://bucket/key, got %v", path)
+		}
+
+		clientOnce.Do(func() {
+			var sess *session.Session
+			if sess, clientErr = session.NewSessionWithOptions(session.Options{
+				SharedConfigState: session.SharedConfigEnable,
+			}); clientErr == nil {
+				client = s3.New(sess)
+			}
+		})
+		if clientErr != nil {
+			return nil, clientErr
+		}
+
+		out, err := client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(u.Host),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer out.Body.Close()
+		return io.ReadAll(out.Body)
+	})
+}
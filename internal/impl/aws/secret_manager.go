@@ -0,0 +1,52 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func init() {
+	var (
+		client     *secretsmanager.SecretsManager
+		clientErr  error
+		clientOnce sync.Once
+	)
+
+	// Secrets are referenced by their name or ARN, and credentials, region,
+	// etc, are obtained from the default AWS credential chain and shared
+	// config.
+	service.RegisterSecretProvider("aws", func(ctx context.Context, path string) (string, error) {
+		clientOnce.Do(func() {
+			var sess *session.Session
+			if sess, clientErr = session.NewSessionWithOptions(session.Options{
+				SharedConfigState: session.SharedConfigEnable,
+			}); clientErr == nil {
+				client = secretsmanager.New(sess)
+			}
+		})
+		if clientErr != nil {
+			return "", clientErr
+		}
+
+		out, err := client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String(path),
+		})
+		if err != nil {
+			return "", err
+		}
+		if out.SecretString != nil {
+			return *out.SecretString, nil
+		}
+		if out.SecretBinary != nil {
+			return string(out.SecretBinary), nil
+		}
+		return "", errors.New("secret does not contain a value")
+	})
+}
@@ -0,0 +1,229 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/cli"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	aiFieldConnectionString = "connection_string"
+	aiFieldRoleName         = "role_name"
+	aiFieldRoleInstance     = "role_instance"
+	aiFieldTimeout          = "timeout"
+
+	aiDefaultIngestionEndpoint = "https://dc.services.visualstudio.com"
+
+	// The maximum number of telemetry items sent within a single request.
+	aiMaxBatchSize = 500
+)
+
+func appInsightsConfigFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewStringField(aiFieldConnectionString).
+			Description("The connection string of an Application Insights resource, which can be found on the overview page of the resource within the Azure portal.").
+			Example("InstrumentationKey=00000000-0000-0000-0000-000000000000;IngestionEndpoint=https://westeurope-5.in.applicationinsights.azure.com/").
+			Secret(),
+		service.NewStringField(aiFieldRoleName).
+			Description("The cloud role name to tag telemetry with, which identifies the service within the application map of Application Insights.").
+			Default("benthos"),
+		service.NewStringField(aiFieldRoleInstance).
+			Description("The cloud role instance to tag telemetry with, which identifies the individual instance of the service. Defaults to the hostname when empty.").
+			Default(""),
+		service.NewDurationField(aiFieldTimeout).
+			Description("The maximum period of time to wait for each request to the ingestion endpoint.").
+			Default("30s").
+			Advanced(),
+	}
+}
+
+// appInsightsClient sends telemetry items to the ingestion endpoint of an
+// Application Insights resource.
+type appInsightsClient struct {
+	url                string
+	instrumentationKey string
+	tags               map[string]string
+	client             *http.Client
+}
+
+func appInsightsClientFromParsed(conf *service.ParsedConfig) (*appInsightsClient, error) {
+	connStr, err := conf.FieldString(aiFieldConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	iKey, endpoint, err := parseAppInsightsConnectionString(connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	roleName, err := conf.FieldString(aiFieldRoleName)
+	if err != nil {
+		return nil, err
+	}
+	roleInstance, err := conf.FieldString(aiFieldRoleInstance)
+	if err != nil {
+		return nil, err
+	}
+	if roleInstance == "" {
+		if roleInstance, err = os.Hostname(); err != nil {
+			return nil, fmt.Errorf("failed to obtain hostname for role_instance: %w", err)
+		}
+	}
+
+	timeout, err := conf.FieldDuration(aiFieldTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return newAppInsightsClient(iKey, endpoint, roleName, roleInstance, timeout), nil
+}
+
+func newAppInsightsClient(iKey, endpoint, roleName, roleInstance string, timeout time.Duration) *appInsightsClient {
+	tags := map[string]string{
+		"ai.internal.sdkVersion": "benthos:" + cli.Version,
+	}
+	if roleName != "" {
+		tags["ai.cloud.role"] = roleName
+	}
+	if roleInstance != "" {
+		tags["ai.cloud.roleInstance"] = roleInstance
+	}
+	return &appInsightsClient{
+		url:                strings.TrimSuffix(endpoint, "/") + "/v2/track",
+		instrumentationKey: iKey,
+		tags:               tags,
+		client:             &http.Client{Timeout: timeout},
+	}
+}
+
+// parseAppInsightsConnectionString returns the instrumentation key and
+// ingestion endpoint of a connection string.
+func parseAppInsightsConnectionString(connStr string) (iKey, endpoint string, err error) {
+	var suffix string
+	for _, part := range strings.Split(connStr, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(k) {
+		case "instrumentationkey":
+			iKey = v
+		case "ingestionendpoint":
+			endpoint = v
+		case "endpointsuffix":
+			suffix = v
+		}
+	}
+	if iKey == "" {
+		return "", "", errors.New("connection string does not contain an InstrumentationKey")
+	}
+	if endpoint == "" {
+		if suffix != "" {
+			endpoint = "https://dc." + strings.TrimPrefix(suffix, ".")
+		} else {
+			endpoint = aiDefaultIngestionEndpoint
+		}
+	}
+	return iKey, endpoint, nil
+}
+
+//------------------------------------------------------------------------------
+
+type aiEnvelope struct {
+	Name string            `json:"name"`
+	Time string            `json:"time"`
+	IKey string            `json:"iKey"`
+	Tags map[string]string `json:"tags"`
+	Data aiData            `json:"data"`
+}
+
+type aiData struct {
+	BaseType string `json:"baseType"`
+	BaseData any    `json:"baseData"`
+}
+
+// envelope wraps the data of a telemetry item of a given type (e.g. Metric)
+// along with the tags of the client and any additional tags.
+func (c *appInsightsClient) envelope(itemType string, t time.Time, baseData any, tags map[string]string) aiEnvelope {
+	allTags := make(map[string]string, len(c.tags)+len(tags))
+	for k, v := range c.tags {
+		allTags[k] = v
+	}
+	for k, v := range tags {
+		allTags[k] = v
+	}
+	return aiEnvelope{
+		Name: "Microsoft.ApplicationInsights." + strings.ReplaceAll(c.instrumentationKey, "-", "") + "." + itemType,
+		Time: t.UTC().Format(time.RFC3339Nano),
+		IKey: c.instrumentationKey,
+		Tags: allTags,
+		Data: aiData{
+			BaseType: itemType + "Data",
+			BaseData: baseData,
+		},
+	}
+}
+
+type aiTrackResponse struct {
+	ItemsReceived int `json:"itemsReceived"`
+	ItemsAccepted int `json:"itemsAccepted"`
+	Errors        []struct {
+		Index      int    `json:"index"`
+		StatusCode int    `json:"statusCode"`
+		Message    string `json:"message"`
+	} `json:"errors"`
+}
+
+// track sends telemetry items to the ingestion endpoint in batches.
+func (c *appInsightsClient) track(ctx context.Context, items []aiEnvelope) error {
+	for len(items) > 0 {
+		batch := items
+		if len(batch) > aiMaxBatchSize {
+			batch = batch[:aiMaxBatchSize]
+		}
+		items = items[len(batch):]
+
+		if err := c.trackBatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *appInsightsClient) trackBatch(ctx context.Context, items []aiEnvelope) error {
+	body, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	switch res.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusPartialContent:
+		var tRes aiTrackResponse
+		if err := json.Unmarshal(resBody, &tRes); err != nil || len(tRes.Errors) == 0 {
+			return fmt.Errorf("ingestion endpoint accepted %v of %v items", tRes.ItemsAccepted, len(items))
+		}
+		return fmt.Errorf("ingestion endpoint accepted %v of %v items: %v", tRes.ItemsAccepted, len(items), tRes.Errors[0].Message)
+	}
+	return fmt.Errorf("ingestion endpoint returned status %v: %s", res.StatusCode, bytes.TrimSpace(resBody))
+}
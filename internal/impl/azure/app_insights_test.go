@@ -0,0 +1,185 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type ingestedItem struct {
+	Name string            `json:"name"`
+	IKey string            `json:"iKey"`
+	Tags map[string]string `json:"tags"`
+	Data struct {
+		BaseType string         `json:"baseType"`
+		BaseData map[string]any `json:"baseData"`
+	} `json:"data"`
+}
+
+func testIngestionServer(t *testing.T) (*httptest.Server, func() []ingestedItem) {
+	t.Helper()
+
+	var mut sync.Mutex
+	var items []ingestedItem
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/track", r.URL.Path)
+
+		var batch []ingestedItem
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mut.Lock()
+		items = append(items, batch...)
+		mut.Unlock()
+		_, _ = w.Write([]byte(`{"itemsReceived":1,"itemsAccepted":1,"errors":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []ingestedItem {
+		mut.Lock()
+		defer mut.Unlock()
+		return items
+	}
+}
+
+func TestAppInsightsConnectionString(t *testing.T) {
+	for _, test := range []struct {
+		connStr  string
+		iKey     string
+		endpoint string
+		errs     bool
+	}{
+		{
+			connStr:  "InstrumentationKey=abc;IngestionEndpoint=https://foo.example.com/",
+			iKey:     "abc",
+			endpoint: "https://foo.example.com/",
+		},
+		{
+			connStr:  "InstrumentationKey=abc",
+			iKey:     "abc",
+			endpoint: aiDefaultIngestionEndpoint,
+		},
+		{
+			connStr:  "InstrumentationKey=abc;EndpointSuffix=applicationinsights.azure.cn",
+			iKey:     "abc",
+			endpoint: "https://dc.applicationinsights.azure.cn",
+		},
+		{
+			connStr: "IngestionEndpoint=https://foo.example.com/",
+			errs:    true,
+		},
+	} {
+		iKey, endpoint, err := parseAppInsightsConnectionString(test.connStr)
+		if test.errs {
+			assert.Error(t, err, test.connStr)
+			continue
+		}
+		require.NoError(t, err, test.connStr)
+		assert.Equal(t, test.iKey, iKey, test.connStr)
+		assert.Equal(t, test.endpoint, endpoint, test.connStr)
+	}
+}
+
+func TestAppInsightsMetrics(t *testing.T) {
+	srv, getItems := testIngestionServer(t)
+
+	client := newAppInsightsClient("00000000-0000-0000-0000-000000000000", srv.URL, "foo", "bar", time.Second)
+	m := newAppInsightsMetrics(client, time.Hour, service.MockResources().Logger())
+
+	m.NewCounterCtor("counter", "label")("a").Incr(2)
+	m.NewCounterCtor("counter", "label")("a").Incr(3)
+	m.NewCounterCtor("idle_counter")()
+
+	timer := m.NewTimerCtor("timer")()
+	timer.Timing(10)
+	timer.Timing(30)
+
+	m.NewGaugeCtor("gauge")().Set(5)
+
+	require.NoError(t, m.flush(context.Background()))
+
+	metrics := map[string]map[string]any{}
+	for _, item := range getItems() {
+		assert.Equal(t, "Microsoft.ApplicationInsights.00000000000000000000000000000000.Metric", item.Name)
+		assert.Equal(t, "MetricData", item.Data.BaseType)
+		assert.Equal(t, "foo", item.Tags["ai.cloud.role"])
+		assert.Equal(t, "bar", item.Tags["ai.cloud.roleInstance"])
+
+		points := item.Data.BaseData["metrics"].([]any)
+		require.Len(t, points, 1)
+		point := points[0].(map[string]any)
+		if props, ok := item.Data.BaseData["properties"]; ok {
+			point["properties"] = props
+		}
+		metrics[point["name"].(string)] = point
+	}
+	require.Len(t, metrics, 3)
+
+	assert.Equal(t, 5.0, metrics["counter"]["value"])
+	assert.Equal(t, map[string]any{"label": "a"}, metrics["counter"]["properties"])
+
+	assert.Equal(t, 40.0, metrics["timer"]["value"])
+	assert.Equal(t, 2.0, metrics["timer"]["count"])
+	assert.Equal(t, 10.0, metrics["timer"]["min"])
+	assert.Equal(t, 30.0, metrics["timer"]["max"])
+	assert.Equal(t, 10.0, metrics["timer"]["stdDev"])
+
+	assert.Equal(t, 5.0, metrics["gauge"]["value"])
+
+	// Only gauges are sent for periods without any updates.
+	require.NoError(t, m.Close(context.Background()))
+
+	items := getItems()
+	require.Len(t, items, 4)
+	assert.Equal(t, "gauge", items[3].Data.BaseData["metrics"].([]any)[0].(map[string]any)["name"])
+}
+
+func TestAppInsightsTracer(t *testing.T) {
+	srv, getItems := testIngestionServer(t)
+
+	client := newAppInsightsClient("abc", srv.URL, "foo", "bar", time.Second)
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSyncer(&aiSpanExporter{client: client}))
+
+	ctx, root := tp.Tracer("test").Start(context.Background(), "root")
+	_, child := tp.Tracer("test").Start(ctx, "child")
+	child.SetStatus(codes.Error, "nope")
+	child.End()
+	root.End()
+
+	items := getItems()
+	require.Len(t, items, 2)
+
+	dep, req := items[0], items[1]
+
+	assert.Equal(t, "RemoteDependencyData", dep.Data.BaseType)
+	assert.Equal(t, "child", dep.Data.BaseData["name"])
+	assert.Equal(t, false, dep.Data.BaseData["success"])
+	assert.Equal(t, "InProc", dep.Data.BaseData["type"])
+	assert.Equal(t, root.SpanContext().SpanID().String(), dep.Tags["ai.operation.parentId"])
+	assert.Equal(t, root.SpanContext().TraceID().String(), dep.Tags["ai.operation.id"])
+
+	assert.Equal(t, "RequestData", req.Data.BaseType)
+	assert.Equal(t, "root", req.Data.BaseData["name"])
+	assert.Equal(t, true, req.Data.BaseData["success"])
+	assert.Equal(t, root.SpanContext().SpanID().String(), req.Data.BaseData["id"])
+	assert.Equal(t, root.SpanContext().TraceID().String(), req.Tags["ai.operation.id"])
+
+	require.NoError(t, tp.Shutdown(context.Background()))
+}
+
+func TestAppInsightsDuration(t *testing.T) {
+	assert.Equal(t, "0.00:00:01.5000000", formatAIDuration(time.Millisecond*1500))
+	assert.Equal(t, "1.02:03:04.0000001", formatAIDuration(time.Hour*26+time.Minute*3+time.Second*4+100))
+}
@@ -0,0 +1,293 @@
+package azure
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	aimFieldFlushPeriod = "flush_period"
+)
+
+func appInsightsMetricsSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.20.0").
+		Summary(`Send metrics to [Azure Monitor Application Insights](https://learn.microsoft.com/en-us/azure/azure-monitor/app/app-insights-overview) as custom metrics.`).
+		Description(`
+Metrics are aggregated in memory and sent to the ingestion endpoint of an Application Insights resource at the end of each ` + "`flush_period`" + `, where the labels of each metric are sent as custom dimensions, and all metrics are tagged with the cloud role name and instance of the service. Tracing events can also be sent to Application Insights with the ` + "[`azure_app_insights` tracer](/docs/components/tracers/azure_app_insights)" + `.
+
+### Aggregation
+
+Counters are sent as the sum of increments made during each period, and are omitted for periods without any. Timing metrics are sent in nanoseconds as aggregates of the count, sum, minimum, maximum and standard deviation of each period. Gauges are sent with their most recent value at the end of every period.
+
+### Costs
+
+Application Insights bills by the volume of data ingested, it is therefore recommended that you reduce the metrics that are exposed with a ` + "`mapping`" + ` like this:
+
+` + "```yaml" + `
+metrics:
+  mapping: |
+    if ![
+      "input_received",
+      "input_latency_ns",
+      "output_sent",
+    ].contains(this) { deleted() }
+  azure_app_insights:
+    connection_string: ${APPLICATIONINSIGHTS_CONNECTION_STRING}
+` + "```" + ``).
+		Fields(appInsightsConfigFields()...).
+		Field(service.NewDurationField(aimFieldFlushPeriod).
+			Description("The period of time between each request sending the metrics aggregated since the previous one.").
+			Default("1m").
+			Advanced())
+}
+
+func init() {
+	err := service.RegisterMetricsExporter("azure_app_insights", appInsightsMetricsSpec(),
+		func(conf *service.ParsedConfig, log *service.Logger) (service.MetricsExporter, error) {
+			client, err := appInsightsClientFromParsed(conf)
+			if err != nil {
+				return nil, err
+			}
+			flushPeriod, err := conf.FieldDuration(aimFieldFlushPeriod)
+			if err != nil {
+				return nil, err
+			}
+			return newAppInsightsMetrics(client, flushPeriod, log), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type aiMetricKind int
+
+const (
+	aiMetricCounter aiMetricKind = iota
+	aiMetricTimer
+	aiMetricGauge
+)
+
+// aiMetricSeries aggregates the values of a metric with a given set of label
+// values over a flush period.
+type aiMetricSeries struct {
+	root  *aiMetrics
+	kind  aiMetricKind
+	name  string
+	props map[string]string
+
+	count           int64
+	sum, sumSquares float64
+	min, max        float64
+}
+
+func (s *aiMetricSeries) observe(v float64) {
+	s.root.mut.Lock()
+	if s.kind == aiMetricGauge {
+		s.count, s.sum = 1, v
+	} else {
+		if s.count == 0 || v < s.min {
+			s.min = v
+		}
+		if s.count == 0 || v > s.max {
+			s.max = v
+		}
+		s.count++
+		s.sum += v
+		s.sumSquares += v * v
+	}
+	s.root.mut.Unlock()
+}
+
+// Incr increments a counter by an amount.
+func (s *aiMetricSeries) Incr(count int64) {
+	s.observe(float64(count))
+}
+
+// Timing adds a timing value in nanoseconds.
+func (s *aiMetricSeries) Timing(delta int64) {
+	s.observe(float64(delta))
+}
+
+// Set sets the value of a gauge.
+func (s *aiMetricSeries) Set(value int64) {
+	s.observe(float64(value))
+}
+
+type aiMetricDataPoint struct {
+	Name   string   `json:"name"`
+	Kind   int      `json:"kind"`
+	Value  float64  `json:"value"`
+	Count  *int64   `json:"count,omitempty"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	StdDev *float64 `json:"stdDev,omitempty"`
+}
+
+type aiMetricData struct {
+	Ver        int                 `json:"ver"`
+	Metrics    []aiMetricDataPoint `json:"metrics"`
+	Properties map[string]string   `json:"properties,omitempty"`
+}
+
+// drain returns the data of the series for the current period and resets it,
+// which must be called whilst holding the mutex of the exporter. Returns false
+// if there is nothing to send.
+func (s *aiMetricSeries) drain() (aiMetricData, bool) {
+	if s.count == 0 {
+		return aiMetricData{}, false
+	}
+
+	point := aiMetricDataPoint{
+		Name:  s.name,
+		Value: s.sum,
+	}
+	if s.kind == aiMetricTimer {
+		count, min, max := s.count, s.min, s.max
+		mean := s.sum / float64(s.count)
+		stdDev := math.Sqrt(math.Max(0, s.sumSquares/float64(s.count)-mean*mean))
+
+		point.Kind = 1
+		point.Count, point.Min, point.Max, point.StdDev = &count, &min, &max, &stdDev
+	}
+
+	// Gauges retain their value so that it is sent for every period.
+	if s.kind != aiMetricGauge {
+		s.count, s.sum, s.sumSquares, s.min, s.max = 0, 0, 0, 0, 0
+	}
+	return aiMetricData{
+		Ver:        2,
+		Metrics:    []aiMetricDataPoint{point},
+		Properties: s.props,
+	}, true
+}
+
+//------------------------------------------------------------------------------
+
+type aiMetrics struct {
+	client *appInsightsClient
+
+	mut    sync.Mutex
+	series map[string]*aiMetricSeries
+
+	flushPeriod time.Duration
+	ctx         context.Context
+	cancel      func()
+	loopDone    chan struct{}
+
+	log *service.Logger
+}
+
+func newAppInsightsMetrics(client *appInsightsClient, flushPeriod time.Duration, log *service.Logger) *aiMetrics {
+	a := &aiMetrics{
+		client:      client,
+		series:      map[string]*aiMetricSeries{},
+		flushPeriod: flushPeriod,
+		loopDone:    make(chan struct{}),
+		log:         log,
+	}
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+	go a.loop()
+	return a
+}
+
+// getSeries returns the series of a metric with a given set of label values,
+// creating it if it does not yet exist.
+func (a *aiMetrics) getSeries(kind aiMetricKind, name string, labelKeys, labelValues []string) *aiMetricSeries {
+	id := name + "\x00" + strings.Join(labelValues, "\x00")
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if s, exists := a.series[id]; exists {
+		return s
+	}
+
+	var props map[string]string
+	if len(labelKeys) > 0 {
+		props = make(map[string]string, len(labelKeys))
+		for i, k := range labelKeys {
+			if i < len(labelValues) {
+				props[k] = labelValues[i]
+			}
+		}
+	}
+	s := &aiMetricSeries{
+		root:  a,
+		kind:  kind,
+		name:  name,
+		props: props,
+	}
+	a.series[id] = s
+	return s
+}
+
+func (a *aiMetrics) NewCounterCtor(name string, labelKeys ...string) service.MetricsExporterCounterCtor {
+	return func(labelValues ...string) service.MetricsExporterCounter {
+		return a.getSeries(aiMetricCounter, name, labelKeys, labelValues)
+	}
+}
+
+func (a *aiMetrics) NewTimerCtor(name string, labelKeys ...string) service.MetricsExporterTimerCtor {
+	return func(labelValues ...string) service.MetricsExporterTimer {
+		return a.getSeries(aiMetricTimer, name, labelKeys, labelValues)
+	}
+}
+
+func (a *aiMetrics) NewGaugeCtor(name string, labelKeys ...string) service.MetricsExporterGaugeCtor {
+	return func(labelValues ...string) service.MetricsExporterGauge {
+		return a.getSeries(aiMetricGauge, name, labelKeys, labelValues)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (a *aiMetrics) loop() {
+	defer close(a.loopDone)
+
+	ticker := time.NewTicker(a.flushPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.flush(a.ctx); err != nil && a.ctx.Err() == nil {
+				a.log.Errorf("Failed to send metrics to Application Insights: %v", err)
+			}
+		}
+	}
+}
+
+func (a *aiMetrics) flush(ctx context.Context) error {
+	now := time.Now()
+
+	a.mut.Lock()
+	items := make([]aiEnvelope, 0, len(a.series))
+	for _, s := range a.series {
+		if data, ok := s.drain(); ok {
+			items = append(items, a.client.envelope("Metric", now, data, nil))
+		}
+	}
+	a.mut.Unlock()
+
+	return a.client.track(ctx, items)
+}
+
+func (a *aiMetrics) Close(ctx context.Context) error {
+	a.cancel()
+	select {
+	case <-a.loopDone:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return a.flush(ctx)
+}
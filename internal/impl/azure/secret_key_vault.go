@@ -0,0 +1,105 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const keyVaultAPIVersion = "7.4"
+
+func init() {
+	var (
+		cred     *azidentity.DefaultAzureCredential
+		credErr  error
+		credOnce sync.Once
+		client   = &http.Client{Timeout: 30 * time.Second}
+	)
+
+	// Secrets are referenced with a path of the form `vault/name`, or
+	// `vault/name/version` for a specific version, where vault is either the
+	// name of the key vault or its full hostname. Credentials are obtained from
+	// the default Azure credential chain.
+	service.RegisterSecretProvider("azure", func(ctx context.Context, path string) (string, error) {
+		vaultHost, secretPath, err := keyVaultSecretURL(path)
+		if err != nil {
+			return "", err
+		}
+
+		credOnce.Do(func() {
+			cred, credErr = azidentity.NewDefaultAzureCredential(nil)
+		})
+		if credErr != nil {
+			return "", credErr
+		}
+
+		token, err := cred.GetToken(ctx, policy.TokenRequestOptions{
+			Scopes: []string{"https://vault.azure.net/.default"},
+		})
+		if err != nil {
+			return "", err
+		}
+
+		u := url.URL{
+			Scheme:   "https",
+			Host:     vaultHost,
+			Path:     secretPath,
+			RawQuery: "api-version=" + keyVaultAPIVersion,
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", u.String(), http.NoBody)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.Token)
+
+		res, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+
+		resBytes, err := io.ReadAll(res.Body)
+		if err != nil {
+			return "", err
+		}
+		if res.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("key vault responded with status %v: %s", res.StatusCode, resBytes)
+		}
+
+		var body struct {
+			Value *string `json:"value"`
+		}
+		if err := json.Unmarshal(resBytes, &body); err != nil {
+			return "", fmt.Errorf("failed to parse key vault response: %w", err)
+		}
+		if body.Value == nil {
+			return "", errors.New("key vault response did not contain a value")
+		}
+		return *body.Value, nil
+	})
+}
+
+func keyVaultSecretURL(path string) (host, secretPath string, err error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) < 2 || len(segments) > 3 {
+		return "", "", fmt.Errorf("expected a secret path of the form vault/name or vault/name/version, got %v", path)
+	}
+	host = segments[0]
+	if !strings.Contains(host, ".") {
+		host += ".vault.azure.net"
+	}
+	secretPath = "/secrets/" + strings.Join(segments[1:], "/")
+	return
+}
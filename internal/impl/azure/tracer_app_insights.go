@@ -0,0 +1,166 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	aitFieldSamplingRatio = "sampling_ratio"
+	aitFieldFlushInterval = "flush_interval"
+)
+
+func appInsightsTracerSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.20.0").
+		Summary(`Send tracing events to [Azure Monitor Application Insights](https://learn.microsoft.com/en-us/azure/azure-monitor/app/app-insights-overview).`).
+		Description(`
+Root spans are sent as requests and all other spans as in-process dependencies, where the attributes of each span are sent as custom dimensions and all spans are tagged with the cloud role name and instance of the service. Metrics can also be sent to Application Insights with the ` + "[`azure_app_insights` metrics exporter](/docs/components/metrics/azure_app_insights)" + `.`).
+		Fields(appInsightsConfigFields()...).
+		Field(service.NewFloatField(aitFieldSamplingRatio).
+			Description("The ratio of traces to sample, where 1 or more means all traces are sampled and 0 means no traces are sampled. Reducing the sampling ratio is recommended for high volume production workloads.").
+			Default(1.0).
+			Advanced()).
+		Field(service.NewDurationField(aitFieldFlushInterval).
+			Description("The maximum period of time between each request sending tracing spans.").
+			Default("5s").
+			Advanced())
+}
+
+func init() {
+	err := service.RegisterOtelTracerProvider("azure_app_insights", appInsightsTracerSpec(),
+		func(conf *service.ParsedConfig) (trace.TracerProvider, error) {
+			client, err := appInsightsClientFromParsed(conf)
+			if err != nil {
+				return nil, err
+			}
+			ratio, err := conf.FieldFloat(aitFieldSamplingRatio)
+			if err != nil {
+				return nil, err
+			}
+			flushInterval, err := conf.FieldDuration(aitFieldFlushInterval)
+			if err != nil {
+				return nil, err
+			}
+			return tracesdk.NewTracerProvider(
+				tracesdk.WithBatcher(&aiSpanExporter{client: client}, tracesdk.WithBatchTimeout(flushInterval)),
+				tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.TraceIDRatioBased(ratio))),
+			), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type aiRequestData struct {
+	Ver          int               `json:"ver"`
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Duration     string            `json:"duration"`
+	ResponseCode string            `json:"responseCode"`
+	Success      bool              `json:"success"`
+	Properties   map[string]string `json:"properties,omitempty"`
+}
+
+type aiDependencyData struct {
+	Ver        int               `json:"ver"`
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Duration   string            `json:"duration"`
+	ResultCode string            `json:"resultCode"`
+	Success    bool              `json:"success"`
+	Type       string            `json:"type"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// aiSpanExporter converts spans into request and dependency telemetry items.
+type aiSpanExporter struct {
+	client *appInsightsClient
+}
+
+// formatAIDuration formats a duration in the form d.hh:mm:ss.fffffff expected
+// by Application Insights.
+func formatAIDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	ticks := d / 100
+	return fmt.Sprintf("%d.%02d:%02d:%02d.%07d",
+		d/(24*time.Hour),
+		(d/time.Hour)%24,
+		(d/time.Minute)%60,
+		(d/time.Second)%60,
+		ticks%10000000,
+	)
+}
+
+func spanToAIEnvelope(c *appInsightsClient, s tracesdk.ReadOnlySpan) aiEnvelope {
+	tags := map[string]string{
+		"ai.operation.id": s.SpanContext().TraceID().String(),
+	}
+	if s.Parent().IsValid() {
+		tags["ai.operation.parentId"] = s.Parent().SpanID().String()
+	}
+
+	var props map[string]string
+	if attrs := s.Attributes(); len(attrs) > 0 {
+		props = make(map[string]string, len(attrs))
+		for _, a := range attrs {
+			props[string(a.Key)] = a.Value.Emit()
+		}
+	}
+
+	success := s.Status().Code != codes.Error
+	resultCode := "0"
+	if !success {
+		resultCode = "1"
+	}
+
+	id := s.SpanContext().SpanID().String()
+	duration := formatAIDuration(s.EndTime().Sub(s.StartTime()))
+
+	if !s.Parent().IsValid() || s.Parent().IsRemote() || s.SpanKind() == trace.SpanKindServer || s.SpanKind() == trace.SpanKindConsumer {
+		tags["ai.operation.name"] = s.Name()
+		return c.envelope("Request", s.StartTime(), aiRequestData{
+			Ver:          2,
+			ID:           id,
+			Name:         s.Name(),
+			Duration:     duration,
+			ResponseCode: resultCode,
+			Success:      success,
+			Properties:   props,
+		}, tags)
+	}
+	return c.envelope("RemoteDependency", s.StartTime(), aiDependencyData{
+		Ver:        2,
+		ID:         id,
+		Name:       s.Name(),
+		Duration:   duration,
+		ResultCode: resultCode,
+		Success:    success,
+		Type:       "InProc",
+		Properties: props,
+	}, tags)
+}
+
+func (e *aiSpanExporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	items := make([]aiEnvelope, 0, len(spans))
+	for _, s := range spans {
+		items = append(items, spanToAIEnvelope(e.client, s))
+	}
+	return e.client.track(ctx, items)
+}
+
+func (e *aiSpanExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
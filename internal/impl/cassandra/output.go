@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -11,7 +12,9 @@ import (
 	"time"
 
 	"github.com/gocql/gocql"
+	"github.com/hailocab/go-hostpool"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
@@ -46,7 +49,13 @@ Runs a query against a Cassandra database for each message in order to insert da
 		Description: output.Description(true, true, `
 Query arguments can be set using a bloblang array for the fields using the `+"`args_mapping`"+` field.
 
-When populating timestamp columns the value must either be a string in ISO 8601 format (2006-01-02T15:04:05Z07:00), or an integer representing unix time in seconds.`),
+When populating timestamp columns the value must either be a string in ISO 8601 format (2006-01-02T15:04:05Z07:00), or an integer representing unix time in seconds.
+
+### Performance
+
+By default queries are routed directly to a replica that owns the partition being written to, which can be disabled with the field `+"`token_aware`"+`. For high throughput inserts it's usually best to disable `+"`logged_batch`"+` and enable `+"`group_by_partition`"+`, where each batch of messages is split into an unlogged batch per partition so that each can be routed to a replica.
+
+Queries that can safely be applied more than once, such as most inserts, should be marked with `+"`idempotent`"+` so that they're retried after write timeouts.`),
 		Examples: []docs.AnnotatedExample{
 			{
 				Title:   "Basic Inserts",
@@ -66,6 +75,26 @@ output:
     batching:
       count: 500
       period: 1s
+`,
+			},
+			{
+				Title:   "Partitioned Batches",
+				Summary: "Insert readings of sensors into a table partitioned by sensor ID, where each batch of messages is split into an unlogged batch per sensor that is routed directly to a replica of the partition.",
+				Config: `
+output:
+  cassandra:
+    addresses:
+      - localhost:9042
+    query: 'INSERT INTO foo.readings (sensor_id, ts, value) VALUES (?, ?, ?)'
+    args_mapping: 'root = [ this.sensor_id, this.ts, this.value ]'
+    logged_batch: false
+    group_by_partition: true
+    idempotent: true
+    host_selection_policy: dc_aware
+    local_dc: dc1
+    batching:
+      count: 500
+      period: 1s
 `,
 			},
 			{
@@ -119,6 +148,34 @@ output:
 				"logged_batch",
 				"If enabled the driver will perform a logged batch. Disabling this prompts unlogged batches to be used instead, which are less efficient but necessary for alternative storages that do not support logged batches.",
 			).Advanced(),
+			docs.FieldBool(
+				"group_by_partition",
+				"If enabled the messages of a batch are split into an unlogged batch per partition key, which are executed in parallel. This avoids the coordinator of a batch fanning writes out to many partitions and requires `logged_batch` to be disabled.",
+			).Advanced(),
+			docs.FieldBool(
+				"idempotent",
+				"Whether the query is idempotent, meaning it can be safely applied more than once. Idempotent queries are retried after write timeouts, whereas other queries are not as the write may have succeeded.",
+			).Advanced(),
+			docs.FieldBool(
+				"token_aware",
+				"Whether to route queries directly to replicas that own the partition of the query, falling back to the `host_selection_policy` when no replica is available.",
+			).Advanced(),
+			docs.FieldString(
+				"host_selection_policy",
+				"The policy used to select hosts to send queries to.",
+			).HasAnnotatedOptions(
+				"round_robin", "Distribute queries evenly across all hosts.",
+				"dc_aware", "Distribute queries evenly across the hosts of `local_dc`, only using remote hosts when none are available.",
+				"latency_aware", "Prefer hosts that have recently responded the fastest and without errors, using an epsilon-greedy strategy.",
+			).Advanced(),
+			docs.FieldString(
+				"local_dc",
+				"The name of the local data centre, which is required by the `dc_aware` host selection policy.",
+			).Advanced(),
+			docs.FieldInt(
+				"max_prepared_statements",
+				"The maximum number of prepared statements to cache per session.",
+			).Advanced(),
 			docs.FieldInt("max_retries", "The maximum number of retries before giving up on a request.").Advanced(),
 			docs.FieldObject("backoff", "Control time intervals between retry attempts.").WithChildren(
 				docs.FieldString("initial_interval", "The initial period to wait between retry attempts."),
@@ -148,8 +205,9 @@ type cassandraWriter struct {
 	session  *gocql.Session
 	connLock sync.RWMutex
 
-	argsMapping *mapping.Executor
-	batchType   gocql.BatchType
+	argsMapping  *mapping.Executor
+	batchType    gocql.BatchType
+	hostSelector func() gocql.HostSelectionPolicy
 }
 
 func newCassandraWriter(conf output.CassandraConfig, mgr bundle.NewManagement) (*cassandraWriter, error) {
@@ -176,12 +234,45 @@ func newCassandraWriter(conf output.CassandraConfig, mgr bundle.NewManagement) (
 	}
 	c.batchType = gocql.UnloggedBatch
 	if c.conf.LoggedBatch {
+		if c.conf.GroupByPartition {
+			return nil, errors.New("group_by_partition requires logged_batch to be disabled")
+		}
 		c.batchType = gocql.LoggedBatch
 	}
+	if c.hostSelector, err = hostSelectionPolicyFromConfig(conf); err != nil {
+		return nil, err
+	}
 
 	return &c, nil
 }
 
+func hostSelectionPolicyFromConfig(conf output.CassandraConfig) (func() gocql.HostSelectionPolicy, error) {
+	var fallback func() gocql.HostSelectionPolicy
+	switch conf.HostSelectionPolicy {
+	case "round_robin":
+		fallback = gocql.RoundRobinHostPolicy
+	case "dc_aware":
+		if conf.LocalDC == "" {
+			return nil, errors.New("a local_dc must be specified for the dc_aware host selection policy")
+		}
+		fallback = func() gocql.HostSelectionPolicy {
+			return gocql.DCAwareRoundRobinPolicy(conf.LocalDC)
+		}
+	case "latency_aware":
+		fallback = func() gocql.HostSelectionPolicy {
+			return gocql.HostPoolHostPolicy(hostpool.NewEpsilonGreedy(nil, 0, &hostpool.LinearEpsilonValueCalculator{}))
+		}
+	default:
+		return nil, fmt.Errorf("host selection policy '%v' not recognised", conf.HostSelectionPolicy)
+	}
+	if !conf.TokenAware {
+		return fallback, nil
+	}
+	return func() gocql.HostSelectionPolicy {
+		return gocql.TokenAwareHostPolicy(fallback())
+	}, nil
+}
+
 func (c *cassandraWriter) parseArgs(mgr bundle.NewManagement) error {
 	if c.conf.ArgsMapping != "" {
 		var err error
@@ -223,7 +314,10 @@ func (c *cassandraWriter) Connect(ctx context.Context) error {
 		NumRetries: int(c.conf.Config.MaxRetries),
 		Min:        c.backoffMin,
 		Max:        c.backoffMax,
+		Idempotent: c.conf.Idempotent,
 	}
+	conn.PoolConfig.HostSelectionPolicy = c.hostSelector()
+	conn.MaxPreparedStmts = c.conf.MaxPreparedStatements
 	if tout := c.conf.Timeout; len(tout) > 0 {
 		var err error
 		if conn.Timeout, err = time.ParseDuration(tout); err != nil {
@@ -252,6 +346,9 @@ func (c *cassandraWriter) WriteBatch(ctx context.Context, msg message.Batch) err
 	if msg.Len() == 1 {
 		return c.writeRow(session, msg)
 	}
+	if c.conf.GroupByPartition {
+		return c.writePartitionedBatches(session, msg)
+	}
 	return c.writeBatch(session, msg)
 }
 
@@ -260,7 +357,7 @@ func (c *cassandraWriter) writeRow(session *gocql.Session, msg message.Batch) er
 	if err != nil {
 		return fmt.Errorf("parsing args: %w", err)
 	}
-	return session.Query(c.conf.Query, values...).Exec()
+	return session.Query(c.conf.Query, values...).Idempotent(c.conf.Idempotent).Exec()
 }
 
 func (c *cassandraWriter) writeBatch(session *gocql.Session, msg message.Batch) error {
@@ -271,7 +368,11 @@ func (c *cassandraWriter) writeBatch(session *gocql.Session, msg message.Batch)
 		if err != nil {
 			return fmt.Errorf("parsing args for part: %d: %w", i, err)
 		}
-		batch.Query(c.conf.Query, values...)
+		batch.Entries = append(batch.Entries, gocql.BatchEntry{
+			Stmt:       c.conf.Query,
+			Args:       values,
+			Idempotent: c.conf.Idempotent,
+		})
 		return nil
 	}); err != nil {
 		return err
@@ -280,6 +381,76 @@ func (c *cassandraWriter) writeBatch(session *gocql.Session, msg message.Batch)
 	return session.ExecuteBatch(batch)
 }
 
+type partitionBatch struct {
+	indexes []int
+	batch   *gocql.Batch
+}
+
+// writePartitionedBatches splits the messages of a batch by the routing key of
+// their queries and executes an unlogged batch for each partition in parallel,
+// which allows token aware routing to send each batch directly to a replica.
+func (c *cassandraWriter) writePartitionedBatches(session *gocql.Session, msg message.Batch) error {
+	var partitions []*partitionBatch
+	partitionsByKey := map[string]*partitionBatch{}
+
+	if err := msg.Iter(func(i int, p *message.Part) error {
+		values, err := c.mapArgs(msg, i)
+		if err != nil {
+			return fmt.Errorf("parsing args for part: %d: %w", i, err)
+		}
+
+		q := session.Query(c.conf.Query, values...)
+		routingKey, err := q.GetRoutingKey()
+		q.Release()
+		if err != nil {
+			return fmt.Errorf("determining routing key for part: %d: %w", i, err)
+		}
+
+		pb, exists := partitionsByKey[string(routingKey)]
+		if !exists {
+			pb = &partitionBatch{batch: session.NewBatch(gocql.UnloggedBatch)}
+			partitionsByKey[string(routingKey)] = pb
+			partitions = append(partitions, pb)
+		}
+		pb.indexes = append(pb.indexes, i)
+		pb.batch.Entries = append(pb.batch.Entries, gocql.BatchEntry{
+			Stmt:       c.conf.Query,
+			Args:       values,
+			Idempotent: c.conf.Idempotent,
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var batchErr *batch.Error
+	var errMut sync.Mutex
+	var wg sync.WaitGroup
+
+	wg.Add(len(partitions))
+	for _, pb := range partitions {
+		go func(pb *partitionBatch) {
+			defer wg.Done()
+			if err := session.ExecuteBatch(pb.batch); err != nil {
+				errMut.Lock()
+				if batchErr == nil {
+					batchErr = batch.NewError(msg, err)
+				}
+				for _, i := range pb.indexes {
+					batchErr.Failed(i, err)
+				}
+				errMut.Unlock()
+			}
+		}(pb)
+	}
+	wg.Wait()
+
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
 func (c *cassandraWriter) mapArgs(msg message.Batch, index int) ([]any, error) {
 	if c.argsMapping != nil {
 		// We've got an "args_mapping" field, extract values from there.
@@ -319,6 +490,7 @@ func (c *cassandraWriter) Close(context.Context) error {
 type decorator struct {
 	NumRetries int
 	Min, Max   time.Duration
+	Idempotent bool
 }
 
 func (d *decorator) Attempt(q gocql.RetryableQuery) bool {
@@ -351,6 +523,9 @@ func (d *decorator) GetRetryType(err error) gocql.RetryType {
 		return gocql.Retry
 	// write timeout - uncertain whetever write was successful or not
 	case *gocql.RequestErrWriteTimeout:
+		if d.Idempotent {
+			return gocql.Retry
+		}
 		if t.Received > 0 {
 			return gocql.Ignore
 		}
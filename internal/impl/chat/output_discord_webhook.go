@@ -0,0 +1,186 @@
+package chat
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	dwFieldThreadID  = "thread_id"
+	dwFieldUsername  = "username"
+	dwFieldAvatarURL = "avatar_url"
+
+	// The maximum number of embeds within a single Discord message, and the
+	// maximum number of fields within a single embed.
+	discordMaxEmbeds      = 10
+	discordMaxEmbedFields = 25
+)
+
+func discordWebhookOutputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services", "Social").
+		Version("4.20.0").
+		Summary("Posts messages to a Discord channel via a webhook.").
+		Description(`
+Unlike the `+"[`discord`](/docs/components/outputs/discord)"+` output this output does not require a bot, and instead posts messages to a [channel webhook](https://discord.com/developers/docs/resources/webhook#execute-webhook).
+
+Messages are converted into embeds depending on their contents:
+
+- A JSON object containing a `+"`content`"+` or `+"`embeds`"+` field is treated as a native Discord message, where its embeds are used as they are and its content is added to the content of the posted message.
+- Any other JSON object is rendered as an embed with a field for each key of the object, sorted by key.
+- All other messages are rendered as an embed with their raw contents as the description.
+
+The embeds of all messages within a batch are combined into a single Discord message, which is split into multiple messages when the number of embeds exceeds the limit imposed by Discord. The `+"`url`"+`, `+"`thread_id`"+`, `+"`username`"+` and `+"`avatar_url`"+` fields are resolved from the first message of a batch.
+`+webhookDescriptionRateLimits).
+		Fields(
+			service.NewInterpolatedStringField(whFieldURL).
+				Description("The webhook URL to post messages to.").
+				Example("https://discord.com/api/webhooks/0000/XXXX").
+				Secret(),
+			service.NewInterpolatedStringField(dwFieldThreadID).
+				Description("An optional thread within the channel of the webhook to post messages to.").
+				Default("").
+				Example(`${! meta("thread_id") }`),
+			service.NewInterpolatedStringField(dwFieldUsername).
+				Description("An optional username to post messages as, overriding the default of the webhook.").
+				Default("").
+				Advanced(),
+			service.NewInterpolatedStringField(dwFieldAvatarURL).
+				Description("An optional URL of an avatar to post messages with, overriding the default of the webhook.").
+				Default("").
+				Advanced(),
+		).
+		Fields(webhookCommonFields()...)
+}
+
+func init() {
+	registerWebhookOutput("discord_webhook", discordWebhookOutputSpec(), newDiscordWebhookWriter)
+}
+
+func newDiscordWebhookWriter(conf *service.ParsedConfig, mgr *service.Resources) (*webhookWriter, error) {
+	r := &discordRenderer{}
+
+	var err error
+	if r.url, err = conf.FieldInterpolatedString(whFieldURL); err != nil {
+		return nil, err
+	}
+	if r.threadID, err = conf.FieldInterpolatedString(dwFieldThreadID); err != nil {
+		return nil, err
+	}
+	if r.username, err = conf.FieldInterpolatedString(dwFieldUsername); err != nil {
+		return nil, err
+	}
+	if r.avatarURL, err = conf.FieldInterpolatedString(dwFieldAvatarURL); err != nil {
+		return nil, err
+	}
+	return newWebhookWriter(conf, mgr, r.render)
+}
+
+type discordRenderer struct {
+	url       *service.InterpolatedString
+	threadID  *service.InterpolatedString
+	username  *service.InterpolatedString
+	avatarURL *service.InterpolatedString
+}
+
+// discordMessageEmbeds returns the embeds and content of a message.
+func discordMessageEmbeds(msg *service.Message) ([]any, string, error) {
+	if obj := structuredObject(msg); obj != nil {
+		content, hasContent := obj["content"].(string)
+		embeds, hasEmbeds := obj["embeds"].([]any)
+		if hasContent || hasEmbeds {
+			return embeds, content, nil
+		}
+
+		keys, values := sortedFields(obj)
+		var fields []any
+		for i, k := range keys {
+			fields = append(fields, map[string]any{"name": k, "value": values[i], "inline": true})
+		}
+		var result []any
+		for _, c := range chunk(fields, discordMaxEmbedFields) {
+			result = append(result, map[string]any{"fields": c})
+		}
+		return result, "", nil
+	}
+
+	raw, err := msg.AsBytes()
+	if err != nil {
+		return nil, "", err
+	}
+	return []any{map[string]any{"description": string(raw)}}, "", nil
+}
+
+func (d *discordRenderer) render(batch service.MessageBatch) ([]webhookPayload, error) {
+	rawURL, err := batch.TryInterpolatedString(0, d.url)
+	if err != nil {
+		return nil, fmt.Errorf("url interpolation: %w", err)
+	}
+	threadID, err := batch.TryInterpolatedString(0, d.threadID)
+	if err != nil {
+		return nil, fmt.Errorf("thread_id interpolation: %w", err)
+	}
+	username, err := batch.TryInterpolatedString(0, d.username)
+	if err != nil {
+		return nil, fmt.Errorf("username interpolation: %w", err)
+	}
+	avatarURL, err := batch.TryInterpolatedString(0, d.avatarURL)
+	if err != nil {
+		return nil, fmt.Errorf("avatar_url interpolation: %w", err)
+	}
+
+	if threadID != "" {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse url: %w", err)
+		}
+		q := u.Query()
+		q.Set("thread_id", threadID)
+		u.RawQuery = q.Encode()
+		rawURL = u.String()
+	}
+
+	var embeds []any
+	var contents []string
+	for _, msg := range batch {
+		mEmbeds, content, err := discordMessageEmbeds(msg)
+		if err != nil {
+			return nil, err
+		}
+		embeds = append(embeds, mEmbeds...)
+		if content != "" {
+			contents = append(contents, content)
+		}
+	}
+
+	embedChunks := chunk(embeds, discordMaxEmbeds)
+	if len(embedChunks) == 0 {
+		embedChunks = [][]any{nil}
+	}
+
+	var payloads []webhookPayload
+	for i, c := range embedChunks {
+		body := map[string]any{}
+		if len(c) > 0 {
+			body["embeds"] = c
+		}
+		if i == 0 && len(contents) > 0 {
+			body["content"] = strings.Join(contents, "\n")
+		}
+		if len(body) == 0 {
+			continue
+		}
+		if username != "" {
+			body["username"] = username
+		}
+		if avatarURL != "" {
+			body["avatar_url"] = avatarURL
+		}
+		payloads = append(payloads, webhookPayload{url: rawURL, body: body})
+	}
+	return payloads, nil
+}
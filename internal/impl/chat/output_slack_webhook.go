@@ -0,0 +1,183 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	swFieldChannel  = "channel"
+	swFieldThreadTS = "thread_ts"
+	swFieldUsername = "username"
+
+	// The maximum number of blocks within a single Slack message, and the
+	// maximum number of fields within a single section block.
+	slackMaxBlocks        = 50
+	slackMaxSectionFields = 10
+)
+
+func slackWebhookOutputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services", "Social").
+		Version("4.20.0").
+		Summary("Posts messages to a Slack channel via an incoming webhook.").
+		Description(`
+Messages are converted into [Block Kit](https://api.slack.com/block-kit) blocks depending on their contents:
+
+- A JSON object containing a `+"`blocks`"+` field is treated as a native Slack message, where its blocks are used as they are and its `+"`text`"+` field, if present, is used as the notification text.
+- Any other JSON object is rendered as a section block with a field for each key of the object, sorted by key.
+- All other messages are rendered as a section block with their raw contents as `+"`mrkdwn`"+` formatted text.
+
+The blocks of all messages within a batch are combined into a single Slack message, which is split into multiple messages when the number of blocks exceeds the limit imposed by Slack. The `+"`channel`"+`, `+"`thread_ts`"+` and `+"`username`"+` fields are resolved from the first message of a batch.
+`+webhookDescriptionRateLimits).
+		Fields(
+			service.NewInterpolatedStringField(whFieldURL).
+				Description("The incoming webhook URL to post messages to.").
+				Example("https://hooks.slack.com/services/T000/B000/XXXX").
+				Secret(),
+			service.NewInterpolatedStringField(swFieldChannel).
+				Description("An optional channel to post messages to, overriding the default channel of the webhook where supported.").
+				Default("").
+				Example("#alerts").
+				Example(`${! meta("channel") }`),
+			service.NewInterpolatedStringField(swFieldThreadTS).
+				Description("An optional timestamp of a parent message in order to post messages as replies within a thread.").
+				Default("").
+				Example(`${! meta("thread_ts") }`),
+			service.NewInterpolatedStringField(swFieldUsername).
+				Description("An optional username to post messages as, overriding the default of the webhook where supported.").
+				Default("").
+				Advanced(),
+		).
+		Fields(webhookCommonFields()...).
+		Example(
+			"Alerts in Threads",
+			"Post alerts to a channel determined by the severity of each alert, grouping alerts into threads per incident.",
+			`
+output:
+  slack_webhook:
+    url: ${SLACK_WEBHOOK_URL}
+    channel: '${! if json("severity") == "critical" { "#incidents" } else { "#alerts" } }'
+    thread_ts: '${! meta("incident_ts").or("") }'
+    batching:
+      count: 10
+      period: 5s
+`,
+		)
+}
+
+func init() {
+	registerWebhookOutput("slack_webhook", slackWebhookOutputSpec(), newSlackWebhookWriter)
+}
+
+func newSlackWebhookWriter(conf *service.ParsedConfig, mgr *service.Resources) (*webhookWriter, error) {
+	r := &slackRenderer{}
+
+	var err error
+	if r.url, err = conf.FieldInterpolatedString(whFieldURL); err != nil {
+		return nil, err
+	}
+	if r.channel, err = conf.FieldInterpolatedString(swFieldChannel); err != nil {
+		return nil, err
+	}
+	if r.threadTS, err = conf.FieldInterpolatedString(swFieldThreadTS); err != nil {
+		return nil, err
+	}
+	if r.username, err = conf.FieldInterpolatedString(swFieldUsername); err != nil {
+		return nil, err
+	}
+	return newWebhookWriter(conf, mgr, r.render)
+}
+
+type slackRenderer struct {
+	url      *service.InterpolatedString
+	channel  *service.InterpolatedString
+	threadTS *service.InterpolatedString
+	username *service.InterpolatedString
+}
+
+func slackText(text string) map[string]any {
+	return map[string]any{"type": "mrkdwn", "text": text}
+}
+
+// messageBlocks returns the Block Kit blocks and the notification text of a
+// message.
+func slackMessageBlocks(msg *service.Message) ([]any, string, error) {
+	if obj := structuredObject(msg); obj != nil {
+		if blocks, ok := obj["blocks"].([]any); ok {
+			text, _ := obj["text"].(string)
+			return blocks, text, nil
+		}
+
+		keys, values := sortedFields(obj)
+		var blocks []any
+		var fields []any
+		for i, k := range keys {
+			fields = append(fields, slackText(fmt.Sprintf("*%v*\n%v", k, values[i])))
+		}
+		for _, c := range chunk(fields, slackMaxSectionFields) {
+			blocks = append(blocks, map[string]any{"type": "section", "fields": c})
+		}
+		return blocks, "", nil
+	}
+
+	raw, err := msg.AsBytes()
+	if err != nil {
+		return nil, "", err
+	}
+	return []any{map[string]any{"type": "section", "text": slackText(string(raw))}}, string(raw), nil
+}
+
+func (s *slackRenderer) render(batch service.MessageBatch) ([]webhookPayload, error) {
+	url, err := batch.TryInterpolatedString(0, s.url)
+	if err != nil {
+		return nil, fmt.Errorf("url interpolation: %w", err)
+	}
+	channel, err := batch.TryInterpolatedString(0, s.channel)
+	if err != nil {
+		return nil, fmt.Errorf("channel interpolation: %w", err)
+	}
+	threadTS, err := batch.TryInterpolatedString(0, s.threadTS)
+	if err != nil {
+		return nil, fmt.Errorf("thread_ts interpolation: %w", err)
+	}
+	username, err := batch.TryInterpolatedString(0, s.username)
+	if err != nil {
+		return nil, fmt.Errorf("username interpolation: %w", err)
+	}
+
+	var blocks []any
+	var texts []string
+	for _, msg := range batch {
+		mBlocks, text, err := slackMessageBlocks(msg)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, mBlocks...)
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	var payloads []webhookPayload
+	for _, c := range chunk(blocks, slackMaxBlocks) {
+		body := map[string]any{"blocks": c}
+		if len(texts) > 0 {
+			body["text"] = strings.Join(texts, "\n")
+		}
+		if channel != "" {
+			body["channel"] = channel
+		}
+		if threadTS != "" {
+			body["thread_ts"] = threadTS
+		}
+		if username != "" {
+			body["username"] = username
+		}
+		payloads = append(payloads, webhookPayload{url: url, body: body})
+	}
+	return payloads, nil
+}
@@ -0,0 +1,143 @@
+package chat
+
+import (
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	twFieldTitle = "title"
+)
+
+func teamsWebhookOutputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services", "Social").
+		Version("4.20.0").
+		Summary("Posts messages to a Microsoft Teams channel via an incoming webhook.").
+		Description(`
+Messages are converted into the elements of an [Adaptive Card](https://adaptivecards.io/) depending on their contents:
+
+- A JSON object with a `+"`type`"+` of `+"`AdaptiveCard`"+` is treated as a native card, where its `+"`body`"+` and `+"`actions`"+` are used as they are.
+- Any other JSON object is rendered as a fact set with a fact for each key of the object, sorted by key.
+- All other messages are rendered as a text block containing their raw contents.
+
+The elements of all messages within a batch are combined into a single card, separated by a horizontal line. The `+"`url`"+` and `+"`title`"+` fields are resolved from the first message of a batch.
+`+webhookDescriptionRateLimits).
+		Fields(
+			service.NewInterpolatedStringField(whFieldURL).
+				Description("The incoming webhook URL to post messages to.").
+				Example("https://example.webhook.office.com/webhookb2/XXXX").
+				Secret(),
+			service.NewInterpolatedStringField(twFieldTitle).
+				Description("An optional title to add to the top of each card.").
+				Default("").
+				Example(`Alerts for ${! meta("service") }`),
+		).
+		Fields(webhookCommonFields()...)
+}
+
+func init() {
+	registerWebhookOutput("teams_webhook", teamsWebhookOutputSpec(), newTeamsWebhookWriter)
+}
+
+func newTeamsWebhookWriter(conf *service.ParsedConfig, mgr *service.Resources) (*webhookWriter, error) {
+	r := &teamsRenderer{}
+
+	var err error
+	if r.url, err = conf.FieldInterpolatedString(whFieldURL); err != nil {
+		return nil, err
+	}
+	if r.title, err = conf.FieldInterpolatedString(twFieldTitle); err != nil {
+		return nil, err
+	}
+	return newWebhookWriter(conf, mgr, r.render)
+}
+
+type teamsRenderer struct {
+	url   *service.InterpolatedString
+	title *service.InterpolatedString
+}
+
+// teamsMessageElements returns the card body elements and actions of a
+// message.
+func teamsMessageElements(msg *service.Message) (body, actions []any, err error) {
+	if obj := structuredObject(msg); obj != nil {
+		if t, _ := obj["type"].(string); t == "AdaptiveCard" {
+			body, _ = obj["body"].([]any)
+			actions, _ = obj["actions"].([]any)
+			return body, actions, nil
+		}
+
+		keys, values := sortedFields(obj)
+		facts := make([]any, len(keys))
+		for i, k := range keys {
+			facts[i] = map[string]any{"title": k, "value": values[i]}
+		}
+		return []any{map[string]any{"type": "FactSet", "facts": facts}}, nil, nil
+	}
+
+	raw, err := msg.AsBytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	return []any{map[string]any{"type": "TextBlock", "text": string(raw), "wrap": true}}, nil, nil
+}
+
+func (t *teamsRenderer) render(batch service.MessageBatch) ([]webhookPayload, error) {
+	url, err := batch.TryInterpolatedString(0, t.url)
+	if err != nil {
+		return nil, fmt.Errorf("url interpolation: %w", err)
+	}
+	title, err := batch.TryInterpolatedString(0, t.title)
+	if err != nil {
+		return nil, fmt.Errorf("title interpolation: %w", err)
+	}
+
+	var body, actions []any
+	if title != "" {
+		body = append(body, map[string]any{
+			"type":   "TextBlock",
+			"text":   title,
+			"size":   "Large",
+			"weight": "Bolder",
+			"wrap":   true,
+		})
+	}
+	for i, msg := range batch {
+		mBody, mActions, err := teamsMessageElements(msg)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && len(mBody) > 0 {
+			if first, ok := mBody[0].(map[string]any); ok {
+				first["separator"] = true
+			}
+		}
+		body = append(body, mBody...)
+		actions = append(actions, mActions...)
+	}
+
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if len(actions) > 0 {
+		card["actions"] = actions
+	}
+	return []webhookPayload{{
+		url: url,
+		body: map[string]any{
+			"type": "message",
+			"attachments": []any{
+				map[string]any{
+					"contentType": "application/vnd.microsoft.card.adaptive",
+					"content":     card,
+				},
+			},
+		},
+	}}, nil
+}
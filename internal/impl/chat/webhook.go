@@ -0,0 +1,288 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	whFieldURL           = "url"
+	whFieldRateLimit     = "rate_limit"
+	whFieldMaxRetries    = "max_retries"
+	whFieldMaxRetryAfter = "max_retry_after"
+	whFieldTimeout       = "timeout"
+	whFieldBatching      = "batching"
+)
+
+// webhookCommonFields returns the config fields that are shared by all chat
+// webhook outputs, which are appended after the platform specific fields.
+func webhookCommonFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewStringField(whFieldRateLimit).
+			Description("An optional [`rate_limit`](/docs/components/rate_limits/about) resource to throttle requests by, in addition to the rate limits enforced by the platform.").
+			Default("").
+			Advanced(),
+		service.NewIntField(whFieldMaxRetries).
+			Description("The maximum number of times to retry a request that was rejected due to rate limiting before giving up.").
+			Default(3).
+			Advanced(),
+		service.NewDurationField(whFieldMaxRetryAfter).
+			Description("The maximum period of time to wait before retrying a request that was rejected due to rate limiting, requests that the platform asks to delay for longer than this are failed immediately.").
+			Default("1m").
+			Advanced(),
+		service.NewDurationField(whFieldTimeout).
+			Description("The maximum period of time to wait for a request to complete.").
+			Default("10s").
+			Advanced(),
+		service.NewBatchPolicyField(whFieldBatching),
+		service.NewOutputMaxInFlightField().Default(1),
+	}
+}
+
+const webhookDescriptionRateLimits = `
+### Rate Limits
+
+Requests that are rejected by the platform with a 429 status code are retried after the period of time indicated by the ` + "`Retry-After`" + ` header of the response, up to ` + "`max_retries`" + ` times. Messages within a batch are combined into as few requests as possible, and therefore [batching](/docs/configuration/batching) can be used in order to reduce the number of requests made during bursts of activity.`
+
+// webhookPayload is a rendered request destined for a webhook URL.
+type webhookPayload struct {
+	url  string
+	body any
+}
+
+// webhookRenderer converts a batch of messages into one or more webhook
+// payloads specific to a given platform.
+type webhookRenderer func(batch service.MessageBatch) ([]webhookPayload, error)
+
+type webhookWriter struct {
+	log *service.Logger
+	mgr *service.Resources
+
+	client        *http.Client
+	render        webhookRenderer
+	rateLimit     string
+	maxRetries    int
+	maxRetryAfter time.Duration
+
+	sleepFn func(ctx context.Context, d time.Duration) error
+}
+
+func newWebhookWriter(conf *service.ParsedConfig, mgr *service.Resources, render webhookRenderer) (*webhookWriter, error) {
+	w := &webhookWriter{
+		log:     mgr.Logger(),
+		mgr:     mgr,
+		render:  render,
+		sleepFn: sleepWithContext,
+	}
+
+	timeout, err := conf.FieldDuration(whFieldTimeout)
+	if err != nil {
+		return nil, err
+	}
+	w.client = &http.Client{Timeout: timeout}
+
+	if w.rateLimit, err = conf.FieldString(whFieldRateLimit); err != nil {
+		return nil, err
+	}
+	if w.rateLimit != "" && !mgr.HasRateLimit(w.rateLimit) {
+		return nil, fmt.Errorf("rate limit resource '%v' was not found", w.rateLimit)
+	}
+	if w.maxRetries, err = conf.FieldInt(whFieldMaxRetries); err != nil {
+		return nil, err
+	}
+	if w.maxRetryAfter, err = conf.FieldDuration(whFieldMaxRetryAfter); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func registerWebhookOutput(name string, spec *service.ConfigSpec, ctor func(conf *service.ParsedConfig, mgr *service.Resources) (*webhookWriter, error)) {
+	err := service.RegisterBatchOutput(name, spec,
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			out service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldMaxInFlight(); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy(whFieldBatching); err != nil {
+				return
+			}
+			out, err = ctor(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (w *webhookWriter) waitForAccess(ctx context.Context) error {
+	if w.rateLimit == "" {
+		return nil
+	}
+	for {
+		var period time.Duration
+		var err error
+		if rerr := w.mgr.AccessRateLimit(ctx, w.rateLimit, func(rl service.RateLimit) {
+			period, err = rl.Access(ctx)
+		}); rerr != nil {
+			err = rerr
+		}
+		if err != nil {
+			w.log.Errorf("Rate limit error: %v\n", err)
+			period = time.Second
+		}
+		if period <= 0 {
+			return nil
+		}
+		if err := w.sleepFn(ctx, period); err != nil {
+			return err
+		}
+	}
+}
+
+// retryAfter parses the period of time a rate limited request should be
+// delayed by, which is given in seconds and may be fractional.
+func retryAfter(res *http.Response) time.Duration {
+	if v := res.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t)
+		}
+	}
+	return time.Second
+}
+
+func (w *webhookWriter) post(ctx context.Context, p webhookPayload) error {
+	body, err := json.Marshal(p.body)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := w.waitForAccess(ctx); err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := w.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+
+		if res.StatusCode >= 200 && res.StatusCode < 300 {
+			return nil
+		}
+		if res.StatusCode != http.StatusTooManyRequests {
+			return fmt.Errorf("request returned unexpected status code %v: %s", res.StatusCode, resBody)
+		}
+
+		delay := retryAfter(res)
+		if attempt >= w.maxRetries {
+			return errors.New("request was rate limited and the maximum number of retries was reached")
+		}
+		if delay > w.maxRetryAfter {
+			return fmt.Errorf("request was rate limited with a retry after period of %v, which exceeds the maximum of %v", delay, w.maxRetryAfter)
+		}
+		w.log.Debugf("Request was rate limited, retrying after %v", delay)
+		if err := w.sleepFn(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *webhookWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (w *webhookWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	payloads, err := w.render(batch)
+	if err != nil {
+		return err
+	}
+	for _, p := range payloads {
+		if err := w.post(ctx, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *webhookWriter) Close(ctx context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// structuredObject returns the contents of a message as an object when it is
+// a JSON object, otherwise nil.
+func structuredObject(msg *service.Message) map[string]any {
+	v, err := msg.AsStructured()
+	if err != nil {
+		return nil
+	}
+	obj, _ := v.(map[string]any)
+	return obj
+}
+
+// sortedFields returns the keys and string values of an object in a stable
+// order, where non-string values are serialised as JSON.
+func sortedFields(obj map[string]any) (keys, values []string) {
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch t := obj[k].(type) {
+		case string:
+			values = append(values, t)
+		default:
+			b, _ := json.Marshal(t)
+			values = append(values, string(b))
+		}
+	}
+	return
+}
+
+// chunk splits a slice into chunks of at most n elements.
+func chunk[T any](items []T, n int) [][]T {
+	var chunks [][]T
+	for len(items) > n {
+		chunks = append(chunks, items[:n])
+		items = items[n:]
+	}
+	if len(items) > 0 {
+		chunks = append(chunks, items)
+	}
+	return chunks
+}
@@ -0,0 +1,272 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type recordedRequest struct {
+	query string
+	body  map[string]any
+}
+
+type webhookServer struct {
+	*httptest.Server
+
+	mut       sync.Mutex
+	requests  []recordedRequest
+	responses []func(w http.ResponseWriter)
+}
+
+func newWebhookServer(t *testing.T, responses ...func(w http.ResponseWriter)) *webhookServer {
+	t.Helper()
+
+	s := &webhookServer{responses: responses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(b, &body))
+
+		s.mut.Lock()
+		s.requests = append(s.requests, recordedRequest{query: r.URL.RawQuery, body: body})
+		var res func(w http.ResponseWriter)
+		if len(s.responses) > 0 {
+			res, s.responses = s.responses[0], s.responses[1:]
+		}
+		s.mut.Unlock()
+
+		if res != nil {
+			res(w)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) received() []recordedRequest {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]recordedRequest(nil), s.requests...)
+}
+
+func testWebhookWriter(t *testing.T, spec *service.ConfigSpec, ctor func(*service.ParsedConfig, *service.Resources) (*webhookWriter, error), confStr string) *webhookWriter {
+	t.Helper()
+
+	pConf, err := spec.ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	w, err := ctor(pConf, service.MockResources())
+	require.NoError(t, err)
+	return w
+}
+
+func jsonRoundTrip(t *testing.T, v any) any {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	var res any
+	require.NoError(t, json.Unmarshal(b, &res))
+	return res
+}
+
+func TestSlackWebhookRender(t *testing.T) {
+	srv := newWebhookServer(t)
+	w := testWebhookWriter(t, slackWebhookOutputSpec(), newSlackWebhookWriter, `
+url: `+srv.URL+`
+channel: '${! meta("channel") }'
+thread_ts: '1234.5678'
+`)
+
+	msgA := service.NewMessage([]byte("*something* happened"))
+	msgA.MetaSetMut("channel", "#alerts")
+	msgB := service.NewMessage([]byte(`{"service":"foo","status":500}`))
+	msgC := service.NewMessage([]byte(`{"text":"native","blocks":[{"type":"divider"}]}`))
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{msgA, msgB, msgC}))
+
+	reqs := srv.received()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, jsonRoundTrip(t, map[string]any{
+		"channel":   "#alerts",
+		"thread_ts": "1234.5678",
+		"text":      "*something* happened\nnative",
+		"blocks": []any{
+			map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": "*something* happened"}},
+			map[string]any{"type": "section", "fields": []any{
+				map[string]any{"type": "mrkdwn", "text": "*service*\nfoo"},
+				map[string]any{"type": "mrkdwn", "text": "*status*\n500"},
+			}},
+			map[string]any{"type": "divider"},
+		},
+	}), jsonRoundTrip(t, reqs[0].body))
+}
+
+func TestSlackWebhookBlockLimit(t *testing.T) {
+	srv := newWebhookServer(t)
+	w := testWebhookWriter(t, slackWebhookOutputSpec(), newSlackWebhookWriter, `
+url: `+srv.URL+`
+`)
+
+	var batch service.MessageBatch
+	for i := 0; i < 60; i++ {
+		batch = append(batch, service.NewMessage([]byte("hello")))
+	}
+	require.NoError(t, w.WriteBatch(context.Background(), batch))
+
+	reqs := srv.received()
+	require.Len(t, reqs, 2)
+	assert.Len(t, reqs[0].body["blocks"], 50)
+	assert.Len(t, reqs[1].body["blocks"], 10)
+}
+
+func TestTeamsWebhookRender(t *testing.T) {
+	srv := newWebhookServer(t)
+	w := testWebhookWriter(t, teamsWebhookOutputSpec(), newTeamsWebhookWriter, `
+url: `+srv.URL+`
+title: Alerts
+`)
+
+	msgA := service.NewMessage([]byte("something happened"))
+	msgB := service.NewMessage([]byte(`{"service":"foo"}`))
+	msgC := service.NewMessage([]byte(`{"type":"AdaptiveCard","body":[{"type":"Image","url":"http://example.com/a.png"}],"actions":[{"type":"Action.OpenUrl","url":"http://example.com"}]}`))
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{msgA, msgB, msgC}))
+
+	reqs := srv.received()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, jsonRoundTrip(t, map[string]any{
+		"type": "message",
+		"attachments": []any{
+			map[string]any{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]any{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body": []any{
+						map[string]any{"type": "TextBlock", "text": "Alerts", "size": "Large", "weight": "Bolder", "wrap": true},
+						map[string]any{"type": "TextBlock", "text": "something happened", "wrap": true},
+						map[string]any{"type": "FactSet", "separator": true, "facts": []any{
+							map[string]any{"title": "service", "value": "foo"},
+						}},
+						map[string]any{"type": "Image", "url": "http://example.com/a.png", "separator": true},
+					},
+					"actions": []any{
+						map[string]any{"type": "Action.OpenUrl", "url": "http://example.com"},
+					},
+				},
+			},
+		},
+	}), jsonRoundTrip(t, reqs[0].body))
+}
+
+func TestDiscordWebhookRender(t *testing.T) {
+	srv := newWebhookServer(t)
+	w := testWebhookWriter(t, discordWebhookOutputSpec(), newDiscordWebhookWriter, `
+url: `+srv.URL+`/api/webhooks/123/abc
+thread_id: '${! meta("thread") }'
+username: benthos
+`)
+
+	var batch service.MessageBatch
+	for i := 0; i < 11; i++ {
+		msg := service.NewMessage([]byte("hello"))
+		msg.MetaSetMut("thread", "456")
+		batch = append(batch, msg)
+	}
+	batch = append(batch, service.NewMessage([]byte(`{"content":"native content"}`)))
+
+	require.NoError(t, w.WriteBatch(context.Background(), batch))
+
+	reqs := srv.received()
+	require.Len(t, reqs, 2)
+
+	assert.Equal(t, "thread_id=456", reqs[0].query)
+	assert.Equal(t, "native content", reqs[0].body["content"])
+	assert.Equal(t, "benthos", reqs[0].body["username"])
+	assert.Len(t, reqs[0].body["embeds"], 10)
+
+	assert.Nil(t, reqs[1].body["content"])
+	assert.Equal(t, []any{map[string]any{"description": "hello"}}, reqs[1].body["embeds"])
+}
+
+func TestWebhookRetryAfter(t *testing.T) {
+	rateLimited := func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "2.5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+
+	srv := newWebhookServer(t, rateLimited, rateLimited)
+	w := testWebhookWriter(t, slackWebhookOutputSpec(), newSlackWebhookWriter, `
+url: `+srv.URL+`
+`)
+
+	var sleeps []time.Duration
+	w.sleepFn = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte("hello"))}))
+	assert.Len(t, srv.received(), 3)
+	assert.Equal(t, []time.Duration{2500 * time.Millisecond, 2500 * time.Millisecond}, sleeps)
+}
+
+func TestWebhookRetryLimits(t *testing.T) {
+	rateLimited := func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+
+	srv := newWebhookServer(t, rateLimited, rateLimited, rateLimited)
+	w := testWebhookWriter(t, slackWebhookOutputSpec(), newSlackWebhookWriter, `
+url: `+srv.URL+`
+max_retries: 2
+`)
+	w.sleepFn = func(ctx context.Context, d time.Duration) error { return nil }
+
+	err := w.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte("hello"))})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "maximum number of retries")
+	assert.Len(t, srv.received(), 3)
+
+	srv = newWebhookServer(t, func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	w = testWebhookWriter(t, slackWebhookOutputSpec(), newSlackWebhookWriter, `
+url: `+srv.URL+`
+`)
+
+	err = w.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte("hello"))})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the maximum")
+	assert.Len(t, srv.received(), 1)
+
+	srv = newWebhookServer(t, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("invalid_blocks"))
+	})
+	w = testWebhookWriter(t, slackWebhookOutputSpec(), newSlackWebhookWriter, `
+url: `+srv.URL+`
+`)
+
+	err = w.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte("hello"))})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid_blocks")
+}
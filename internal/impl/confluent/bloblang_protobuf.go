@@ -0,0 +1,243 @@
+package confluent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func protobufBloblangSpec(description string) *bloblang.PluginSpec {
+	return bloblang.NewPluginSpec().
+		Category(query.MethodCategoryParsing).
+		Version("4.20.0").
+		Description(description + `
+
+Descriptors are loaded either from a ` + "`path`" + `, which is read from the filesystem of the stream, or from the latest version of a ` + "`subject`" + ` within a [Schema Registry](https://docs.confluent.io/platform/current/schema-registry/index.html), and exactly one of the two must be specified. Descriptors are loaded the first time the method is executed and cached by each invocation of the method within a mapping, they are then reloaded once they are older than the ` + "`refresh_period`" + `. When a reload fails the previously loaded descriptors continue to be used.
+
+Schema Registry wire format headers are neither added nor removed by this method, in order to work with them use the ` + "[`schema_registry_encode`](/docs/components/processors/schema_registry_encode) and [`schema_registry_decode`](/docs/components/processors/schema_registry_decode)" + ` processors instead.`).
+		Param(bloblang.NewStringParam("path").Description("A path to either a directory of `.proto` files, a single `.proto` file (in which case the directory it resides in is used for resolving imports), or a serialised `FileDescriptorSet` such as one produced with `protoc --include_imports --descriptor_set_out`.").Default("")).
+		Param(bloblang.NewStringParam("message").Description("The fully qualified name of the message type.")).
+		Param(bloblang.NewStringParam("schema_registry_url").Description("The base URL of a schema registry to obtain descriptors from. Basic authentication credentials can be specified as user info of the URL.").Default("")).
+		Param(bloblang.NewStringParam("subject").Description("The subject of the schema registry to obtain the latest version of descriptors from.").Default("")).
+		Param(bloblang.NewStringParam("refresh_period").Description("The period after which descriptors are reloaded from their source.").Default("5m"))
+}
+
+// protobufDescriptorCache lazily loads descriptors from a source, and reloads
+// them once they're older than a refresh period.
+type protobufDescriptorCache struct {
+	load    func(ctx context.Context, res *service.Resources) (*protoregistry.Types, error)
+	message protoreflect.FullName
+	refresh time.Duration
+
+	mut      sync.Mutex
+	types    *protoregistry.Types
+	msgType  protoreflect.MessageType
+	loadedAt time.Time
+}
+
+func protobufDescriptorCacheFromParams(args *bloblang.ParsedParams) (*protobufDescriptorCache, error) {
+	path, err := args.GetString("path")
+	if err != nil {
+		return nil, err
+	}
+	message, err := args.GetString("message")
+	if err != nil {
+		return nil, err
+	}
+	urlStr, err := args.GetString("schema_registry_url")
+	if err != nil {
+		return nil, err
+	}
+	subject, err := args.GetString("subject")
+	if err != nil {
+		return nil, err
+	}
+	refreshStr, err := args.GetString("refresh_period")
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := time.ParseDuration(refreshStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse refresh_period: %w", err)
+	}
+
+	c := &protobufDescriptorCache{
+		message: protoreflect.FullName(message),
+		refresh: refresh,
+	}
+	switch {
+	case path != "" && urlStr != "":
+		return nil, errors.New("only one of path or schema_registry_url can be specified")
+	case path != "":
+		c.load = func(ctx context.Context, res *service.Resources) (*protoregistry.Types, error) {
+			_, types, err := protobuf.RegistriesFromPath(res.FS(), path)
+			return types, err
+		}
+	case urlStr != "":
+		if subject == "" {
+			return nil, errors.New("a subject must be specified along with schema_registry_url")
+		}
+		c.load = func(ctx context.Context, res *service.Resources) (*protoregistry.Types, error) {
+			return protobufTypesFromRegistry(ctx, res, urlStr, subject)
+		}
+	default:
+		return nil, errors.New("either path or schema_registry_url must be specified")
+	}
+	return c, nil
+}
+
+func protobufTypesFromRegistry(ctx context.Context, res *service.Resources, urlStr, subject string) (*protoregistry.Types, error) {
+	client, err := newSchemaRegistryClient(urlStr, func(ifs.FS, *http.Request) error { return nil }, nil, res)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := client.GetSchemaBySubjectAndVersion(ctx, subject, nil)
+	if err != nil {
+		return nil, err
+	}
+	if info.Type != "PROTOBUF" {
+		return nil, fmt.Errorf("schema of subject '%v' is of type '%v', expected PROTOBUF", subject, info.Type)
+	}
+
+	regMap := map[string]string{
+		".": info.Schema,
+	}
+	if err := client.WalkReferences(ctx, info.References, func(ctx context.Context, name string, si SchemaInfo) error {
+		regMap[name] = si.Schema
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	_, types, err := protobuf.RegistriesFromMap(regMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proto schema of subject '%v': %w", subject, err)
+	}
+	return types, nil
+}
+
+// get returns the registry of types and the type of the target message,
+// loading them when they've not yet been loaded or have expired.
+func (c *protobufDescriptorCache) get(ctx context.Context, res *service.Resources) (*protoregistry.Types, protoreflect.MessageType, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.types != nil && time.Since(c.loadedAt) < c.refresh {
+		return c.types, c.msgType, nil
+	}
+
+	types, err := c.load(ctx, res)
+	var msgType protoreflect.MessageType
+	if err == nil {
+		if msgType, err = types.FindMessageByName(c.message); err != nil {
+			err = fmt.Errorf("unable to find message '%v' definition", c.message)
+		}
+	}
+	if err != nil {
+		if c.types == nil {
+			return nil, nil, err
+		}
+		res.Logger().Errorf("Failed to reload protobuf descriptors, continuing with previously loaded descriptors: %v", err)
+		c.loadedAt = time.Now()
+		return c.types, c.msgType, nil
+	}
+
+	c.types, c.msgType, c.loadedAt = types, msgType, time.Now()
+	return types, msgType, nil
+}
+
+func init() {
+	if err := service.RegisterBloblangMethodWithResources(
+		"parse_protobuf",
+		protobufBloblangSpec("Parses a [Protobuf](https://developers.google.com/protocol-buffers) encoded message into a structured document. The resulting document follows the [Protobuf JSON mapping](https://protobuf.dev/programming-guides/proto3/#json).").
+			Example("", `root.person = content().parse_protobuf("./schemas/person.proto", "testing.Person")`).
+			Example("Descriptors can also be obtained from a schema registry.", `root.person = content().parse_protobuf(message: "testing.Person", schema_registry_url: "http://localhost:8081", subject: "people-value")`),
+		func(args *bloblang.ParsedParams) (service.BloblangResourceMethod, error) {
+			cache, err := protobufDescriptorCacheFromParams(args)
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx *service.BloblangContext, v any) (any, error) {
+				types, mt, err := cache.get(ctx.Context(), ctx.Resources())
+				if err != nil {
+					return nil, err
+				}
+
+				b, err := query.IGetBytes(v)
+				if err != nil {
+					return nil, err
+				}
+
+				dynMsg := dynamicpb.NewMessage(mt.Descriptor())
+				if err := proto.Unmarshal(b, dynMsg); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal protobuf message '%v': %w", mt.Descriptor().FullName(), err)
+				}
+
+				jBytes, err := protojson.MarshalOptions{Resolver: types}.Marshal(dynMsg)
+				if err != nil {
+					return nil, err
+				}
+
+				dec := json.NewDecoder(bytes.NewReader(jBytes))
+				dec.UseNumber()
+				var jObj any
+				if err := dec.Decode(&jObj); err != nil {
+					return nil, err
+				}
+				return jObj, nil
+			}, nil
+		},
+	); err != nil {
+		panic(err)
+	}
+
+	if err := service.RegisterBloblangMethodWithResources(
+		"format_protobuf",
+		protobufBloblangSpec("Formats a structured document as a [Protobuf](https://developers.google.com/protocol-buffers) encoded message in bytes format. The document is expected to follow the [Protobuf JSON mapping](https://protobuf.dev/programming-guides/proto3/#json).").
+			Example("", `root = this.person.format_protobuf("./schemas/person.proto", "testing.Person")`).
+			Example("Descriptors can also be obtained from a schema registry.", `root = this.person.format_protobuf(message: "testing.Person", schema_registry_url: "http://localhost:8081", subject: "people-value")`),
+		func(args *bloblang.ParsedParams) (service.BloblangResourceMethod, error) {
+			cache, err := protobufDescriptorCacheFromParams(args)
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx *service.BloblangContext, v any) (any, error) {
+				types, mt, err := cache.get(ctx.Context(), ctx.Resources())
+				if err != nil {
+					return nil, err
+				}
+
+				jBytes, err := json.Marshal(v)
+				if err != nil {
+					return nil, err
+				}
+
+				dynMsg := dynamicpb.NewMessage(mt.Descriptor())
+				if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal(jBytes, dynMsg); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal JSON message '%v': %w", mt.Descriptor().FullName(), err)
+				}
+				return proto.Marshal(dynMsg)
+			}, nil
+		},
+	); err != nil {
+		panic(err)
+	}
+}
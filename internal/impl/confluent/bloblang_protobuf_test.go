@@ -0,0 +1,239 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
+)
+
+// testProtobufParams parses named arguments of the protobuf methods.
+func testProtobufParams(t *testing.T, params map[string]any) *bloblang.ParsedParams {
+	t.Helper()
+
+	var args []string
+	for k, v := range params {
+		vBytes, err := json.Marshal(v)
+		require.NoError(t, err)
+		args = append(args, k+": "+string(vBytes))
+	}
+
+	var parsed *bloblang.ParsedParams
+	env := bloblang.NewEmptyEnvironment()
+	require.NoError(t, env.RegisterMethodV2("test_params", protobufBloblangSpec(""), func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		parsed = args
+		return func(v any) (any, error) { return v, nil }, nil
+	}))
+
+	_, err := env.Parse(`root = this.test_params(` + strings.Join(args, ", ") + `)`)
+	require.NoError(t, err)
+	return parsed
+}
+
+// runProtobufMapping executes a mapping on a message within a stream and
+// returns the resulting message contents, or the error of the mapping.
+func runProtobufMapping(t *testing.T, mapping string, input string) (string, error) {
+	t.Helper()
+
+	b := service.NewStreamBuilder()
+	require.NoError(t, b.SetLoggerYAML("level: NONE"))
+	require.NoError(t, b.AddProcessorYAML(`
+mapping: |
+  `+mapping+`
+`))
+
+	pushFn, err := b.AddProducerFunc()
+	require.NoError(t, err)
+
+	var outMut sync.Mutex
+	var out string
+	var outErr error
+	require.NoError(t, b.AddConsumerFunc(func(_ context.Context, m *service.Message) error {
+		outMut.Lock()
+		defer outMut.Unlock()
+
+		mBytes, err := m.AsBytes()
+		require.NoError(t, err)
+		out, outErr = string(mBytes), m.GetError()
+		return nil
+	}))
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+
+	go func() {
+		assert.NoError(t, pushFn(context.Background(), service.NewMessage([]byte(input))))
+		assert.NoError(t, strm.Stop(context.Background()))
+	}()
+	require.NoError(t, strm.Run(context.Background()))
+
+	outMut.Lock()
+	defer outMut.Unlock()
+	return out, outErr
+}
+
+func TestProtobufBloblangPath(t *testing.T) {
+	schemaPath := "../../../config/test/protobuf/schema"
+
+	res, err := runProtobufMapping(t,
+		`root = this.format_protobuf("`+schemaPath+`", "testing.Person").parse_protobuf("`+schemaPath+`/person.proto", "testing.Person")`,
+		`{"firstName":"john","lastName":"oates","age":10}`,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, `{"age":10,"firstName":"john","lastName":"oates"}`, res)
+
+	_, err = runProtobufMapping(t,
+		`root = this.format_protobuf("./does/not/exist", "testing.Person")`,
+		`{"firstName":"john"}`,
+	)
+	require.Error(t, err)
+
+	_, err = runProtobufMapping(t,
+		`root = this.format_protobuf("`+schemaPath+`", "testing.Nope")`,
+		`{"firstName":"john"}`,
+	)
+	require.Error(t, err)
+
+	_, err = runProtobufMapping(t,
+		`root = content().parse_protobuf("`+schemaPath+`", "testing.Person")`,
+		`not a protobuf message`,
+	)
+	require.Error(t, err)
+}
+
+func TestProtobufBloblangRefresh(t *testing.T) {
+	dir := t.TempDir()
+	protoPath := filepath.Join(dir, "thing.proto")
+
+	writeSchema := func(field string) {
+		require.NoError(t, os.WriteFile(protoPath, []byte(`
+syntax = "proto3";
+package testing;
+
+message Thing {
+  string `+field+` = 1;
+}
+`), 0o644))
+	}
+	writeSchema("foo")
+
+	c, err := protobufDescriptorCacheFromParams(testProtobufParams(t, map[string]any{
+		"path":           protoPath,
+		"message":        "testing.Thing",
+		"refresh_period": "1h",
+	}))
+	require.NoError(t, err)
+
+	ctx, res := context.Background(), service.MockResources()
+	_, mt, err := c.get(ctx, res)
+	require.NoError(t, err)
+	assert.NotNil(t, mt.Descriptor().Fields().ByName("foo"))
+
+	// Descriptors are cached until they expire.
+	writeSchema("bar")
+	_, mt, err = c.get(ctx, res)
+	require.NoError(t, err)
+	assert.NotNil(t, mt.Descriptor().Fields().ByName("foo"))
+
+	c.loadedAt = c.loadedAt.Add(-2 * c.refresh)
+	_, mt, err = c.get(ctx, res)
+	require.NoError(t, err)
+	assert.NotNil(t, mt.Descriptor().Fields().ByName("bar"))
+
+	// Failed reloads continue with the previous descriptors.
+	require.NoError(t, os.Remove(protoPath))
+	c.loadedAt = c.loadedAt.Add(-2 * c.refresh)
+	_, mt, err = c.get(ctx, res)
+	require.NoError(t, err)
+	assert.NotNil(t, mt.Descriptor().Fields().ByName("bar"))
+}
+
+func TestProtobufBloblangSchemaRegistry(t *testing.T) {
+	thingSchema := `
+syntax = "proto3";
+package testing;
+
+import "stuff.proto";
+
+message Thing {
+  string name = 1;
+  Stuff stuff = 2;
+}
+`
+	stuffSchema := `
+syntax = "proto3";
+package testing;
+
+message Stuff {
+  int64 count = 1;
+}
+`
+
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/subjects/things-value/versions/latest":
+			return mustJBytes(t, map[string]any{
+				"id":         1,
+				"schemaType": "PROTOBUF",
+				"schema":     thingSchema,
+				"references": []any{
+					map[string]any{"name": "stuff.proto", "subject": "stuff-value", "version": 1},
+				},
+			}), nil
+		case "/subjects/stuff-value/versions/1":
+			return mustJBytes(t, map[string]any{
+				"id":         2,
+				"schemaType": "PROTOBUF",
+				"schema":     stuffSchema,
+			}), nil
+		case "/subjects/avro-value/versions/latest":
+			return mustJBytes(t, map[string]any{
+				"id":     3,
+				"schema": testSchema,
+			}), nil
+		}
+		return nil, nil
+	})
+
+	res, err := runProtobufMapping(t,
+		`root = this.format_protobuf(message: "testing.Thing", schema_registry_url: "`+urlStr+`", subject: "things-value").parse_protobuf(message: "testing.Thing", schema_registry_url: "`+urlStr+`", subject: "things-value")`,
+		`{"name":"foo","stuff":{"count":5}}`,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"foo","stuff":{"count":"5"}}`, res)
+
+	_, err = runProtobufMapping(t,
+		`root = this.format_protobuf(message: "testing.Thing", schema_registry_url: "`+urlStr+`", subject: "avro-value")`,
+		`{"name":"foo"}`,
+	)
+	require.Error(t, err)
+
+	_, err = runProtobufMapping(t,
+		`root = this.format_protobuf(message: "testing.Thing", schema_registry_url: "`+urlStr+`", subject: "nope-value")`,
+		`{"name":"foo"}`,
+	)
+	require.Error(t, err)
+}
+
+func TestProtobufBloblangBadParams(t *testing.T) {
+	for _, params := range []map[string]any{
+		{"message": "testing.Thing"},
+		{"message": "testing.Thing", "path": "./foo", "schema_registry_url": "http://localhost:8081", "subject": "foo"},
+		{"message": "testing.Thing", "schema_registry_url": "http://localhost:8081"},
+		{"message": "testing.Thing", "path": "./foo", "refresh_period": "nope"},
+	} {
+		_, err := protobufDescriptorCacheFromParams(testProtobufParams(t, params))
+		assert.Error(t, err, params)
+	}
+}
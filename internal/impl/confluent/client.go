@@ -134,6 +134,48 @@ func (c *schemaRegistryClient) GetSchemaBySubjectAndVersion(ctx context.Context,
 	return
 }
 
+// SetConfig updates the config of a subject, or the global config when the
+// subject is empty, returning the config as acknowledged by the registry.
+func (c *schemaRegistryClient) SetConfig(ctx context.Context, subject string, config []byte) ([]byte, error) {
+	reqPath := "/config"
+	if subject != "" {
+		reqPath = fmt.Sprintf("/config/%s", subject)
+	}
+	return c.adminRequest(ctx, "PUT", reqPath, nil, config, subject)
+}
+
+// DeleteSubject deletes all versions of a subject, returning the list of
+// versions that were deleted. When permanent is true the subject must have
+// already been soft deleted.
+func (c *schemaRegistryClient) DeleteSubject(ctx context.Context, subject string, permanent bool) ([]byte, error) {
+	return c.adminRequest(ctx, "DELETE", fmt.Sprintf("/subjects/%s", subject), permanentQuery(permanent), nil, subject)
+}
+
+// DeleteSubjectVersion deletes a version of a subject, which can either be a
+// version number or `latest`, returning the version that was deleted. When
+// permanent is true the version must have already been soft deleted.
+func (c *schemaRegistryClient) DeleteSubjectVersion(ctx context.Context, subject, version string, permanent bool) ([]byte, error) {
+	return c.adminRequest(ctx, "DELETE", fmt.Sprintf("/subjects/%s/versions/%s", subject, version), permanentQuery(permanent), nil, subject)
+}
+
+func permanentQuery(permanent bool) url.Values {
+	if !permanent {
+		return nil
+	}
+	return url.Values{"permanent": []string{"true"}}
+}
+
+func (c *schemaRegistryClient) adminRequest(ctx context.Context, verb, reqPath string, query url.Values, body []byte, subject string) ([]byte, error) {
+	resCode, resBody, err := c.doRequestWithBody(ctx, verb, reqPath, query, body)
+	if err != nil {
+		return nil, fmt.Errorf("request failed for schema subject '%v': %w", subject, err)
+	}
+	if resCode == http.StatusNotFound {
+		return nil, fmt.Errorf("schema subject '%v' not found by registry", subject)
+	}
+	return resBody, nil
+}
+
 type RefWalkFn func(ctx context.Context, name string, info SchemaInfo) error
 
 // For each reference provided the schema info is obtained and the provided
@@ -170,19 +212,34 @@ func (c *schemaRegistryClient) walkReferencesTracked(ctx context.Context, seen m
 }
 
 func (c *schemaRegistryClient) doRequest(ctx context.Context, verb, reqPath string) (resCode int, resBody []byte, err error) {
+	return c.doRequestWithBody(ctx, verb, reqPath, nil, nil)
+}
+
+func (c *schemaRegistryClient) doRequestWithBody(ctx context.Context, verb, reqPath string, query url.Values, body []byte) (resCode int, resBody []byte, err error) {
 	reqURL := *c.schemaRegistryBaseURL
 	reqURL.Path = path.Join(reqURL.Path, reqPath)
-
-	var req *http.Request
-	if req, err = http.NewRequestWithContext(ctx, verb, reqURL.String(), http.NoBody); err != nil {
-		return
-	}
-	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
-	if err = c.requestSigner(c.mgr.FS(), req); err != nil {
-		return
+	if len(query) > 0 {
+		reqURL.RawQuery = query.Encode()
 	}
 
 	for i := 0; i < 3; i++ {
+		var reqBody io.Reader = http.NoBody
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+
+		var req *http.Request
+		if req, err = http.NewRequestWithContext(ctx, verb, reqURL.String(), reqBody); err != nil {
+			return
+		}
+		req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
+		if body != nil {
+			req.Header.Add("Content-Type", "application/vnd.schemaregistry.v1+json")
+		}
+		if err = c.requestSigner(c.mgr.FS(), req); err != nil {
+			return
+		}
+
 		var res *http.Response
 		if res, err = c.client.Do(req); err != nil {
 			c.mgr.Logger().Errorf("request failed: %v", err)
@@ -190,6 +247,7 @@ func (c *schemaRegistryClient) doRequest(ctx context.Context, verb, reqPath stri
 		}
 
 		if resCode = res.StatusCode; resCode == http.StatusNotFound {
+			_ = res.Body.Close()
 			break
 		}
 
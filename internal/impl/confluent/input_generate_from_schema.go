@@ -0,0 +1,450 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+	"github.com/benthosdev/benthos/v4/internal/httpclient"
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	gfsFieldURL              = "url"
+	gfsFieldSubject          = "subject"
+	gfsFieldSchemaPath       = "schema_path"
+	gfsFieldSchemaType       = "schema_type"
+	gfsFieldProtobufMessage  = "protobuf_message"
+	gfsFieldAvroRawJSON      = "avro_raw_json"
+	gfsFieldOverrides        = "overrides"
+	gfsFieldOverridesPath    = "path"
+	gfsFieldOverridesMapping = "mapping"
+	gfsFieldInterval         = "interval"
+	gfsFieldCount            = "count"
+	gfsFieldBatchSize        = "batch_size"
+	gfsFieldSeed             = "seed"
+	gfsFieldTLS              = "tls"
+)
+
+func generateFromSchemaInputSpec() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Categories("Utility", "Integration").
+		Summary("Generates random JSON messages that conform to an Avro, JSON or Protobuf schema, which is either obtained from a Confluent Schema Registry service or read from a file.").
+		Description(`
+This input is useful for load testing pipelines with realistic payloads that are valid under the schemas that they are eventually encoded with. The schema is obtained once when the input connects, either as the latest schema of a `+"`subject`"+` from a [Confluent Schema Registry service](https://docs.confluent.io/platform/current/schema-registry/index.html), or from a file at `+"`schema_path`"+`.
+
+Values are generated randomly according to their type, where strings are random alphanumeric characters, and timestamps and dates (Avro logical types, JSON Schema formats and the `+"`google.protobuf.Timestamp`"+` type) are the current time. Optional values are sometimes left empty, and nested records or messages beyond a depth of five are left empty in order to support recursive schemas.
+
+By default messages generated from Avro schemas are formatted as [Avro JSON](https://avro.apache.org/docs/current/specification/_print/#json-encoding), which is the format expected by the `+"[`schema_registry_encode` processor](/docs/components/processors/schema_registry_encode)"+` by default, and messages generated from Protobuf schemas use the [JSON mapping](https://developers.google.com/protocol-buffers/docs/proto3#json) of the message.
+
+### Overrides
+
+Random values are often not realistic enough for fields such as identifiers or enumerations that downstream components depend upon. The values of individual fields can be overridden with the field `+"[`overrides`](#overrides)"+`, where each override is a [Bloblang mapping](/docs/guides/bloblang/about) executed against the generated document, the result of which replaces the value at a dot separated path. A path segment of `+"`*`"+` targets all elements of an array, and a mapping that deletes the root removes the field.`).
+		Field(service.NewURLField(gfsFieldURL).
+			Description("The base URL of a schema registry service to obtain the schema from.").
+			Optional()).
+		Field(service.NewStringField(gfsFieldSubject).
+			Description("The subject to obtain the latest schema of, this field is required when a `url` is set.").
+			Example("foo-value").
+			Optional()).
+		Field(service.NewStringField(gfsFieldSchemaPath).
+			Description("The path of a file to read the schema from instead of obtaining it from a registry.").
+			Example("./schemas/user.avsc").
+			Optional()).
+		Field(service.NewStringEnumField(gfsFieldSchemaType, "avro", "json", "protobuf").
+			Description("The type of the schema read from the `schema_path`. Schemas obtained from a registry use the type of the registered schema.").
+			Default("avro")).
+		Field(service.NewStringField(gfsFieldProtobufMessage).
+			Description("The fully qualified name of the message to generate from a Protobuf schema, by default the first message of the schema is used.").
+			Example("things.foo").
+			Optional().
+			Advanced()).
+		Field(service.NewBoolField(gfsFieldAvroRawJSON).
+			Description("Whether messages generated from Avro schemas should be formatted as standard JSON rather than [Avro JSON](https://avro.apache.org/docs/current/specification/_print/#json-encoding), in which case the values of unions are not wrapped within an object of the type name.").
+			Default(false).
+			Advanced()).
+		Field(service.NewObjectListField(gfsFieldOverrides,
+			service.NewStringField(gfsFieldOverridesPath).
+				Description("The dot separated path of the field to override."),
+			service.NewBloblangField(gfsFieldOverridesMapping).
+				Description("A mapping executed against the generated document, the result of which becomes the value of the field."),
+		).
+			Description("A list of overrides for the values of specific fields, which are applied in order.").
+			Example([]any{
+				map[string]any{"path": "id", "mapping": "root = uuid_v4()"},
+				map[string]any{"path": "age", "mapping": "root = random_int(min: 18, max: 90)"},
+			}).
+			Default([]any{})).
+		Field(service.NewStringField(gfsFieldInterval).
+			Description("The time interval at which batches of messages are generated, expressed as a duration string. If set to an empty string messages are generated as fast as downstream components can process them.").
+			Example("").
+			Example("100ms").
+			Default("1s")).
+		Field(service.NewIntField(gfsFieldCount).
+			Description("An optional number of messages to generate, if set above 0 the specified number of messages is generated and then the input shuts down.").
+			Default(0)).
+		Field(service.NewIntField(gfsFieldBatchSize).
+			Description("The number of generated messages in each batch.").
+			Default(1)).
+		Field(service.NewIntField(gfsFieldSeed).
+			Description("An optional seed for the random generation of values, which results in the same sequence of messages each time a stream is run.").
+			Optional().
+			Advanced()).
+		LintRule(`root = match {
+  this.exists("url") == this.exists("schema_path") => [ "exactly one of the fields url or schema_path must be specified" ],
+  this.exists("url") && !this.exists("subject") => [ "a subject must be specified when a url is set" ],
+}`).
+		Example("Load Testing an Encoder",
+			"In this example we generate a thousand messages per second from the latest schema of a subject, with realistic identifiers, and encode them with the same subject.",
+			`
+input:
+  generate_from_schema:
+    url: http://localhost:8081
+    subject: users-value
+    interval: 100ms
+    batch_size: 100
+    overrides:
+      - path: id
+        mapping: root = uuid_v4()
+  processors:
+    - schema_registry_encode:
+        url: http://localhost:8081
+        subject: users-value
+
+output:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topic: users
+`)
+
+	for _, f := range httpclient.AuthFieldSpecs() {
+		spec = spec.Field(f)
+	}
+
+	return spec.Field(service.NewTLSField(gfsFieldTLS))
+}
+
+func init() {
+	err := service.RegisterBatchInput("generate_from_schema", generateFromSchemaInputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return newGenerateFromSchemaInputFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type schemaOverride struct {
+	path []string
+	exec *bloblang.Executor
+}
+
+type generateFromSchemaInput struct {
+	client          *schemaRegistryClient
+	subject         string
+	protobufMessage string
+	avroRawJSON     bool
+	overrides       []schemaOverride
+	interval        time.Duration
+	batchSize       int
+	limited         bool
+
+	genMut    sync.Mutex
+	gen       valueGenerator
+	rand      *rand.Rand
+	remaining int
+	firstRead bool
+
+	nowFn func() time.Time
+}
+
+func newGenerateFromSchemaInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*generateFromSchemaInput, error) {
+	g := &generateFromSchemaInput{
+		firstRead: true,
+		nowFn:     time.Now,
+	}
+
+	var err error
+	if conf.Contains(gfsFieldProtobufMessage) {
+		if g.protobufMessage, err = conf.FieldString(gfsFieldProtobufMessage); err != nil {
+			return nil, err
+		}
+	}
+	if g.avroRawJSON, err = conf.FieldBool(gfsFieldAvroRawJSON); err != nil {
+		return nil, err
+	}
+
+	overrideConfs, err := conf.FieldObjectList(gfsFieldOverrides)
+	if err != nil {
+		return nil, err
+	}
+	for _, oc := range overrideConfs {
+		pathStr, err := oc.FieldString(gfsFieldOverridesPath)
+		if err != nil {
+			return nil, err
+		}
+		exec, err := oc.FieldBloblang(gfsFieldOverridesMapping)
+		if err != nil {
+			return nil, err
+		}
+		g.overrides = append(g.overrides, schemaOverride{
+			path: strings.Split(pathStr, "."),
+			exec: exec,
+		})
+	}
+
+	intervalStr, err := conf.FieldString(gfsFieldInterval)
+	if err != nil {
+		return nil, err
+	}
+	if intervalStr != "" {
+		if g.interval, err = time.ParseDuration(intervalStr); err != nil {
+			return nil, fmt.Errorf("failed to parse interval: %w", err)
+		}
+	}
+	if g.remaining, err = conf.FieldInt(gfsFieldCount); err != nil {
+		return nil, err
+	}
+	g.limited = g.remaining > 0
+	if g.batchSize, err = conf.FieldInt(gfsFieldBatchSize); err != nil {
+		return nil, err
+	}
+	if g.batchSize < 1 {
+		return nil, errors.New("batch size must be greater than zero")
+	}
+
+	seed := time.Now().UnixNano()
+	if conf.Contains(gfsFieldSeed) {
+		seedInt, err := conf.FieldInt(gfsFieldSeed)
+		if err != nil {
+			return nil, err
+		}
+		seed = int64(seedInt)
+	}
+	g.rand = rand.New(rand.NewSource(seed))
+
+	if conf.Contains(gfsFieldSchemaPath) {
+		schemaPath, err := conf.FieldString(gfsFieldSchemaPath)
+		if err != nil {
+			return nil, err
+		}
+		schemaType, err := conf.FieldString(gfsFieldSchemaType)
+		if err != nil {
+			return nil, err
+		}
+		schemaBytes, err := ifs.ReadFile(mgr.FS(), schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+		if g.gen, err = g.newGenerator(context.Background(), SchemaInfo{
+			Type:   strings.ToUpper(schemaType),
+			Schema: string(schemaBytes),
+		}); err != nil {
+			return nil, err
+		}
+		return g, nil
+	}
+
+	if !conf.Contains(gfsFieldURL) || !conf.Contains(gfsFieldSubject) {
+		return nil, errors.New("either a url and subject or a schema_path must be specified")
+	}
+	urlStr, err := conf.FieldString(gfsFieldURL)
+	if err != nil {
+		return nil, err
+	}
+	if g.subject, err = conf.FieldString(gfsFieldSubject); err != nil {
+		return nil, err
+	}
+	authSigner, err := httpclient.AuthSignerFromParsed(conf)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS(gfsFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	if g.client, err = newSchemaRegistryClient(urlStr, authSigner, tlsConf, mgr); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *generateFromSchemaInput) newGenerator(ctx context.Context, info SchemaInfo) (valueGenerator, error) {
+	switch info.Type {
+	case "", "AVRO":
+		schema := info.Schema
+		if g.client != nil {
+			var err error
+			if schema, err = resolveAvroReferences(ctx, g.client, info); err != nil {
+				return nil, err
+			}
+		}
+		return newAvroGenerator(schema, g.avroRawJSON)
+	case "JSON":
+		return newJSONSchemaGenerator([]byte(info.Schema))
+	case "PROTOBUF":
+		return g.newProtobufGenerator(ctx, info)
+	}
+	return nil, fmt.Errorf("schema type %v not supported", info.Type)
+}
+
+func (g *generateFromSchemaInput) newProtobufGenerator(ctx context.Context, info SchemaInfo) (valueGenerator, error) {
+	regMap := map[string]string{
+		".": info.Schema,
+	}
+	if g.client != nil {
+		if err := g.client.WalkReferences(ctx, info.References, func(ctx context.Context, name string, si SchemaInfo) error {
+			regMap[name] = si.Schema
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	files, types, err := protobuf.RegistriesFromMap(regMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proto schema: %v", err)
+	}
+
+	if g.protobufMessage != "" {
+		msgType, err := types.FindMessageByName(protoreflect.FullName(g.protobufMessage))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find message %v: %w", g.protobufMessage, err)
+		}
+		return &protobufGenerator{desc: msgType.Descriptor()}, nil
+	}
+
+	targetFile, err := files.FindFileByPath(".")
+	if err != nil {
+		return nil, err
+	}
+	if targetFile.Messages().Len() == 0 {
+		return nil, errors.New("schema does not contain any messages")
+	}
+	return &protobufGenerator{desc: targetFile.Messages().Get(0)}, nil
+}
+
+func (g *generateFromSchemaInput) Connect(ctx context.Context) error {
+	g.genMut.Lock()
+	defer g.genMut.Unlock()
+
+	if g.gen != nil {
+		return nil
+	}
+
+	info, err := g.client.GetSchemaBySubjectAndVersion(ctx, g.subject, nil)
+	if err != nil {
+		return err
+	}
+	g.gen, err = g.newGenerator(ctx, info)
+	return err
+}
+
+func setAtPath(v any, path []string, value any, deleted bool) any {
+	if len(path) == 0 {
+		return value
+	}
+
+	if path[0] == "*" {
+		if arr, ok := v.([]any); ok {
+			for i, e := range arr {
+				arr[i] = setAtPath(e, path[1:], value, deleted)
+			}
+		}
+		return v
+	}
+
+	obj, ok := v.(map[string]any)
+	if !ok {
+		obj = map[string]any{}
+	}
+	if deleted && len(path) == 1 {
+		delete(obj, path[0])
+		return obj
+	}
+	obj[path[0]] = setAtPath(obj[path[0]], path[1:], value, deleted)
+	return obj
+}
+
+func (g *generateFromSchemaInput) generate() (*service.Message, error) {
+	doc, err := g.gen.Generate(g.rand, g.nowFn())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range g.overrides {
+		v, err := o.exec.Query(doc)
+		if err != nil {
+			if !errors.Is(err, bloblang.ErrRootDeleted) {
+				return nil, fmt.Errorf("failed to execute override of %v: %w", strings.Join(o.path, "."), err)
+			}
+			doc = setAtPath(doc, o.path, nil, true)
+			continue
+		}
+		doc = setAtPath(doc, o.path, v, false)
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewMessage(b), nil
+}
+
+func (g *generateFromSchemaInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	g.genMut.Lock()
+	defer g.genMut.Unlock()
+
+	if g.gen == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	batchSize := g.batchSize
+	if g.limited {
+		if g.remaining <= 0 {
+			return nil, nil, service.ErrEndOfInput
+		}
+		if g.remaining < batchSize {
+			batchSize = g.remaining
+		}
+	}
+
+	if !g.firstRead && g.interval > 0 {
+		select {
+		case <-time.After(g.interval):
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	g.firstRead = false
+
+	batch := make(service.MessageBatch, 0, batchSize)
+	for i := 0; i < batchSize; i++ {
+		msg, err := g.generate()
+		if err != nil {
+			return nil, nil, err
+		}
+		batch = append(batch, msg)
+	}
+	if g.limited {
+		g.remaining -= len(batch)
+	}
+	return batch, func(context.Context, error) error { return nil }, nil
+}
+
+func (g *generateFromSchemaInput) Close(ctx context.Context) error {
+	return nil
+}
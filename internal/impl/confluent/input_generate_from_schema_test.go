@@ -0,0 +1,320 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newGenerateFromSchemaFromYAML(t testing.TB, confStr string) *generateFromSchemaInput {
+	t.Helper()
+
+	conf, err := generateFromSchemaInputSpec().ParseYAML(confStr, service.NewEnvironment())
+	require.NoError(t, err)
+
+	g, err := newGenerateFromSchemaInputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	require.NoError(t, g.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = g.Close(context.Background())
+	})
+	return g
+}
+
+func readGeneratedDocs(t testing.TB, g *generateFromSchemaInput, n int) (docs [][]byte) {
+	t.Helper()
+
+	for len(docs) < n {
+		batch, ackFn, err := g.ReadBatch(context.Background())
+		require.NoError(t, err)
+		for _, m := range batch {
+			b, err := m.AsBytes()
+			require.NoError(t, err)
+			docs = append(docs, b)
+		}
+		require.NoError(t, ackFn(context.Background(), nil))
+	}
+	return
+}
+
+const testRecursiveSchema = `{
+	"type": "record",
+	"name": "node",
+	"fields": [
+		{ "name": "id", "type": { "type": "string", "logicalType": "uuid" } },
+		{ "name": "created_at", "type": { "type": "long", "logicalType": "timestamp-millis" } },
+		{ "name": "kind", "type": { "type": "enum", "name": "kind", "symbols": [ "leaf", "branch" ] } },
+		{ "name": "weights", "type": { "type": "map", "values": "double" } },
+		{ "name": "children", "type": { "type": "array", "items": "node" } },
+		{ "name": "parent", "type": [ "null", "node" ] }
+	]
+}`
+
+func TestGenerateFromSchemaAvro(t *testing.T) {
+	tmpDir := t.TempDir()
+	for name, schema := range map[string]string{
+		"identity.avsc": testSchema,
+		"node.avsc":     testRecursiveSchema,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte(schema), 0o644))
+	}
+
+	for _, test := range []struct {
+		name    string
+		schema  string
+		rawJSON bool
+	}{
+		{name: "avro json", schema: testSchema},
+		{name: "raw json", schema: testSchema, rawJSON: true},
+		{name: "recursive avro json", schema: testRecursiveSchema},
+		{name: "recursive raw json", schema: testRecursiveSchema, rawJSON: true},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			schemaPath := filepath.Join(tmpDir, "identity.avsc")
+			if test.schema == testRecursiveSchema {
+				schemaPath = filepath.Join(tmpDir, "node.avsc")
+			}
+
+			rawJSON := "false"
+			newCodec := goavro.NewCodec
+			if test.rawJSON {
+				rawJSON = "true"
+				newCodec = goavro.NewCodecForStandardJSONFull
+			}
+			codec, err := newCodec(test.schema)
+			require.NoError(t, err)
+
+			g := newGenerateFromSchemaFromYAML(t, `
+schema_path: `+schemaPath+`
+avro_raw_json: `+rawJSON+`
+interval: ""
+batch_size: 10
+`)
+			for _, doc := range readGeneratedDocs(t, g, 50) {
+				_, _, err := codec.NativeFromTextual(doc)
+				require.NoError(t, err, string(doc))
+			}
+		})
+	}
+}
+
+func TestGenerateFromSchemaOverrides(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "identity.avsc")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(testSchema), 0o644))
+
+	g := newGenerateFromSchemaFromYAML(t, `
+schema_path: `+schemaPath+`
+avro_raw_json: true
+interval: ""
+overrides:
+  - path: Name
+    mapping: 'root = "foo"'
+  - path: Address.State
+    mapping: 'root = this.Name + " state"'
+  - path: MaybeHobby
+    mapping: 'root = deleted()'
+`)
+	for _, doc := range readGeneratedDocs(t, g, 10) {
+		var v map[string]any
+		require.NoError(t, json.Unmarshal(doc, &v))
+		assert.Equal(t, "foo", v["Name"])
+		assert.Equal(t, "foo state", v["Address"].(map[string]any)["State"])
+		assert.NotContains(t, v, "MaybeHobby")
+	}
+}
+
+func TestGenerateFromSchemaJSONSchema(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "user.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{
+  "type": "object",
+  "properties": {
+    "id": { "type": "string", "format": "uuid" },
+    "age": { "type": "integer", "minimum": 18, "maximum": 90 },
+    "status": { "enum": [ "active", "inactive" ] },
+    "tags": { "type": "array", "items": { "type": "string", "maxLength": 4 }, "minItems": 1 },
+    "address": { "$ref": "#/definitions/address" }
+  },
+  "definitions": {
+    "address": {
+      "type": "object",
+      "properties": {
+        "city": { "type": "string" }
+      }
+    }
+  }
+}`), 0o644))
+
+	g := newGenerateFromSchemaFromYAML(t, `
+schema_path: `+schemaPath+`
+schema_type: json
+interval: ""
+`)
+	for _, doc := range readGeneratedDocs(t, g, 20) {
+		var v struct {
+			ID      string   `json:"id"`
+			Age     int      `json:"age"`
+			Status  string   `json:"status"`
+			Tags    []string `json:"tags"`
+			Address struct {
+				City string `json:"city"`
+			} `json:"address"`
+		}
+		require.NoError(t, json.Unmarshal(doc, &v))
+		assert.Len(t, v.ID, 36)
+		assert.GreaterOrEqual(t, v.Age, 18)
+		assert.LessOrEqual(t, v.Age, 90)
+		assert.Contains(t, []string{"active", "inactive"}, v.Status)
+		require.NotEmpty(t, v.Tags)
+		for _, tag := range v.Tags {
+			assert.LessOrEqual(t, len(tag), 4)
+		}
+		assert.NotEmpty(t, v.Address.City)
+	}
+}
+
+func TestGenerateFromSchemaRegistryProtobuf(t *testing.T) {
+	thingsSchema := `
+syntax = "proto3";
+package things;
+
+import "google/protobuf/timestamp.proto";
+
+message foo {
+  int32 a = 1;
+  int64 b = 2;
+  repeated bar bars = 3;
+  map<string, double> weights = 4;
+  google.protobuf.Timestamp created_at = 5;
+  oneof choice {
+    string c = 6;
+    bar d = 7;
+  }
+  status e = 8;
+}
+
+message bar {
+  string b = 1;
+  bytes data = 2;
+  bar next = 3;
+}
+
+enum status {
+  UNKNOWN = 0;
+  ACTIVE = 1;
+}
+`
+
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/subjects/things/versions/latest":
+			return mustJBytes(t, map[string]any{
+				"id":         1,
+				"version":    1,
+				"schema":     thingsSchema,
+				"schemaType": "PROTOBUF",
+			}), nil
+		}
+		return nil, nil
+	})
+
+	files, types, err := protobuf.RegistriesFromMap(map[string]string{".": thingsSchema})
+	require.NoError(t, err)
+
+	targetFile, err := files.FindFileByPath(".")
+	require.NoError(t, err)
+
+	for _, msgName := range []string{"", "things.bar"} {
+		conf := `
+url: ` + urlStr + `
+subject: things
+interval: ""
+batch_size: 5
+`
+		desc := targetFile.Messages().Get(0)
+		if msgName != "" {
+			conf += "protobuf_message: " + msgName + "\n"
+			desc = targetFile.Messages().ByName(protoreflect.Name("bar"))
+		}
+
+		g := newGenerateFromSchemaFromYAML(t, conf)
+		for _, doc := range readGeneratedDocs(t, g, 20) {
+			dynMsg := dynamicpb.NewMessage(desc)
+			require.NoError(t, protojson.UnmarshalOptions{Resolver: types}.Unmarshal(doc, dynMsg), string(doc))
+		}
+	}
+}
+
+func TestGenerateFromSchemaCountAndSeed(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "identity.avsc")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(testSchema), 0o644))
+
+	conf := `
+schema_path: ` + schemaPath + `
+interval: ""
+count: 5
+batch_size: 2
+seed: 10
+`
+
+	readAll := func() (docs []string) {
+		g := newGenerateFromSchemaFromYAML(t, conf)
+		for {
+			batch, _, err := g.ReadBatch(context.Background())
+			if err == service.ErrEndOfInput {
+				return
+			}
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(batch), 2)
+			for _, m := range batch {
+				b, err := m.AsBytes()
+				require.NoError(t, err)
+				docs = append(docs, string(b))
+			}
+		}
+	}
+
+	first := readAll()
+	assert.Len(t, first, 5)
+
+	second := readAll()
+	assert.Equal(t, first, second)
+}
+
+func TestGenerateFromSchemaConfigLints(t *testing.T) {
+	env := service.NewEnvironment()
+	require.NoError(t, env.RegisterBatchInput("generate_from_schema", generateFromSchemaInputSpec(), func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+		return newGenerateFromSchemaInputFromConfig(conf, mgr)
+	}))
+
+	for _, test := range []struct {
+		config string
+		errMsg string
+	}{
+		{
+			config: `generate_from_schema: { count: 10 }`,
+			errMsg: "exactly one of the fields url or schema_path must be specified",
+		},
+		{
+			config: `generate_from_schema: { url: http://localhost:8081 }`,
+			errMsg: "a subject must be specified when a url is set",
+		},
+	} {
+		err := env.NewStreamBuilder().AddInputYAML(test.config)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.errMsg)
+	}
+}
@@ -0,0 +1,631 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/benthosdev/benthos/v4/internal/httpclient"
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	sdpFieldURL           = "url"
+	sdpFieldSubject       = "subject"
+	sdpFieldRefreshPeriod = "refresh_period"
+	sdpFieldSchema        = "schema"
+	sdpFieldSampleRate    = "sample_rate"
+	sdpFieldOutput        = "output"
+	sdpFieldTLS           = "tls"
+)
+
+func schemaDriftProcConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Categories("Parsing", "Integration").
+		Summary("Compares the structure of JSON messages against a schema and reports fields that have been added or have changed type, giving early warning of schema drift before encoding starts to fail.").
+		Description(`
+Messages pass through this processor unchanged. A sample of messages, determined by the field `+"[`sample_rate`](#sample_rate)"+`, are parsed as JSON and compared against either the latest schema of a subject from a [Confluent Schema Registry service](https://docs.confluent.io/platform/current/schema-registry/index.html), or a static Avro schema. Schemas obtained from the registry can either be Avro or Protobuf, and are refreshed periodically.
+
+Each difference found results in a drift event, where an event of the kind `+"`new_field`"+` is emitted for each field of a message that is not defined by the schema, and an event of the kind `+"`type_change`"+` is emitted for each value of a message with a type that does not match the schema. Fields that are defined by the schema but are missing from a message are not reported.
+
+Avro schemas are compared against messages in standard JSON format, meaning the values of unions are expected without a wrapping object, and where a union contains multiple records the record that matches a message most closely is used. When a Protobuf schema contains multiple messages each of them are considered in the same way.
+
+### Drift Events
+
+Drift events are JSON documents of the following form:
+
+`+"```json"+`
+{
+  "kind": "type_change",
+  "path": "address.zip",
+  "expected": "string",
+  "actual": "number",
+  "subject": "users-value",
+  "schema_id": 3,
+  "timestamp": "2023-11-14T22:13:20Z"
+}
+`+"```"+`
+
+Where the `+"`path`"+` is the dot separated path of the field, with array elements represented by `+"`*`"+`. The `+"`expected`"+` field is omitted from `+"`new_field`"+` events, and the `+"`subject`"+` and `+"`schema_id`"+` fields are omitted when a static schema is used.
+
+When the field `+"[`output`](#output)"+` is set the events of each message are written as a batch to the [output resource](/docs/configuration/resources) of that name, otherwise they are logged as warnings. Failing to write events does not cause a message to fail.
+
+### Metrics
+
+The counter `+"`schema_drift_detected`"+` is incremented for each drift event, with the label `+"`kind`"+`.`).
+		Field(service.NewURLField(sdpFieldURL).
+			Description("The base URL of a schema registry service to obtain schemas from.").
+			Optional()).
+		Field(service.NewInterpolatedStringField(sdpFieldSubject).
+			Description("The schema subject to compare messages against, this field is required when a `url` is set.").
+			Example("foo").
+			Example(`${! meta("kafka_topic") }-value`).
+			Optional()).
+		Field(service.NewDurationField(sdpFieldRefreshPeriod).
+			Description("The period after which the schema of a subject is obtained again from the registry.").
+			Default("10m").
+			Advanced()).
+		Field(service.NewStringField(sdpFieldSchema).
+			Description("A static Avro schema to compare messages against instead of obtaining schemas from a registry.").
+			Example(`{"type":"record","name":"user","fields":[{"name":"name","type":"string"},{"name":"age","type":"int"}]}`).
+			Optional()).
+		Field(service.NewFloatField(sdpFieldSampleRate).
+			Description("The ratio of messages to compare against the schema, where `1` compares all messages.").
+			LintRule(`root = if this < 0 || this > 1 { ["sample rate must be between 0.0 and 1.0"] }`).
+			Default(1.0)).
+		Field(service.NewStringField(sdpFieldOutput).
+			Description("The name of an [output resource](/docs/configuration/resources) to write drift events to. When omitted drift events are logged.").
+			Optional()).
+		LintRule(`root = match {
+  this.exists("url") == this.exists("schema") => [ "exactly one of the fields url or schema must be specified" ],
+  this.exists("url") && !this.exists("subject") => [ "a subject must be specified when a url is set" ],
+}`).
+		Example("Reporting Drift from Kafka",
+			"In this example we compare a tenth of the messages consumed from a topic against the latest schema of its subject, and write drift events to a separate topic.",
+			`
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ users ]
+    consumer_group: benthos
+    processors:
+      - schema_drift:
+          url: http://localhost:8081
+          subject: ${! meta("kafka_topic") }-value
+          sample_rate: 0.1
+          output: drift_events
+
+output_resources:
+  - label: drift_events
+    kafka:
+      addresses: [ localhost:9092 ]
+      topic: schema_drift
+`)
+
+	for _, f := range httpclient.AuthFieldSpecs() {
+		spec = spec.Field(f)
+	}
+
+	return spec.Field(service.NewTLSField(sdpFieldTLS))
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"schema_drift", schemaDriftProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newSchemaDriftProcFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type cachedDriftSchema struct {
+	node      *driftNode
+	id        int
+	fetchedAt time.Time
+}
+
+type schemaDriftProc struct {
+	client        *schemaRegistryClient
+	subject       *service.InterpolatedString
+	refreshPeriod time.Duration
+	static        *driftNode
+
+	schemas  map[string]*cachedDriftSchema
+	cacheMut sync.Mutex
+
+	sampleRate float64
+	output     string
+	mDrift     *service.MetricCounter
+
+	logger *service.Logger
+	mgr    *service.Resources
+	nowFn  func() time.Time
+	randFn func() float64
+}
+
+func newSchemaDriftProcFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*schemaDriftProc, error) {
+	p := &schemaDriftProc{
+		schemas: map[string]*cachedDriftSchema{},
+		mDrift:  mgr.Metrics().NewCounter("schema_drift_detected", "kind"),
+		logger:  mgr.Logger(),
+		mgr:     mgr,
+		nowFn:   time.Now,
+		randFn:  rand.Float64,
+	}
+
+	var err error
+	if p.sampleRate, err = conf.FieldFloat(sdpFieldSampleRate); err != nil {
+		return nil, err
+	}
+	if conf.Contains(sdpFieldOutput) {
+		if p.output, err = conf.FieldString(sdpFieldOutput); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.Contains(sdpFieldSchema) {
+		schema, err := conf.FieldString(sdpFieldSchema)
+		if err != nil {
+			return nil, err
+		}
+		if p.static, err = avroDriftSchema(schema); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	if !conf.Contains(sdpFieldURL) || !conf.Contains(sdpFieldSubject) {
+		return nil, errors.New("either a url and subject or a schema must be specified")
+	}
+	urlStr, err := conf.FieldString(sdpFieldURL)
+	if err != nil {
+		return nil, err
+	}
+	if p.subject, err = conf.FieldInterpolatedString(sdpFieldSubject); err != nil {
+		return nil, err
+	}
+	if p.refreshPeriod, err = conf.FieldDuration(sdpFieldRefreshPeriod); err != nil {
+		return nil, err
+	}
+	authSigner, err := httpclient.AuthSignerFromParsed(conf)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS(sdpFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	if p.client, err = newSchemaRegistryClient(urlStr, authSigner, tlsConf, mgr); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *schemaDriftProc) getSchema(subject string) (*cachedDriftSchema, error) {
+	p.cacheMut.Lock()
+	defer p.cacheMut.Unlock()
+
+	cached, exists := p.schemas[subject]
+	if exists && p.nowFn().Sub(cached.fetchedAt) < p.refreshPeriod {
+		return cached, nil
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	info, err := p.client.GetSchemaBySubjectAndVersion(ctx, subject, nil)
+	if err == nil {
+		var node *driftNode
+		switch info.Type {
+		case "PROTOBUF":
+			node, err = p.protobufDriftSchema(ctx, info)
+		case "", "AVRO":
+			var schema string
+			if schema, err = resolveAvroReferences(ctx, p.client, info); err == nil {
+				node, err = avroDriftSchema(schema)
+			}
+		default:
+			err = fmt.Errorf("schema type %v not supported", info.Type)
+		}
+		if err == nil {
+			cached = &cachedDriftSchema{node: node, id: info.ID, fetchedAt: p.nowFn()}
+			p.schemas[subject] = cached
+			return cached, nil
+		}
+	}
+
+	if exists {
+		// Continue using the stale schema until the registry recovers.
+		p.logger.Errorf("Failed to refresh schema subject '%v': %v", subject, err)
+		cached.fetchedAt = p.nowFn()
+		return cached, nil
+	}
+	return nil, err
+}
+
+func (p *schemaDriftProc) protobufDriftSchema(ctx context.Context, info SchemaInfo) (*driftNode, error) {
+	regMap := map[string]string{
+		".": info.Schema,
+	}
+	if err := p.client.WalkReferences(ctx, info.References, func(ctx context.Context, name string, si SchemaInfo) error {
+		regMap[name] = si.Schema
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	files, _, err := protobuf.RegistriesFromMap(regMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proto schema: %v", err)
+	}
+
+	targetFile, err := files.FindFileByPath(".")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[protoreflect.FullName]*driftNode{}
+	msgs := targetFile.Messages()
+	if msgs.Len() == 1 {
+		return protobufDriftMessage(msgs.Get(0), seen), nil
+	}
+	root := &driftNode{}
+	for i := 0; i < msgs.Len(); i++ {
+		root.branches = append(root.branches, protobufDriftMessage(msgs.Get(i), seen))
+	}
+	return root, nil
+}
+
+// driftEvent describes a single difference between a message and a schema.
+type driftEvent struct {
+	Kind      string    `json:"kind"`
+	Path      string    `json:"path"`
+	Expected  string    `json:"expected,omitempty"`
+	Actual    string    `json:"actual"`
+	Subject   string    `json:"subject,omitempty"`
+	SchemaID  int       `json:"schema_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (p *schemaDriftProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	if p.sampleRate < 1 && p.randFn() >= p.sampleRate {
+		return service.MessageBatch{msg}, nil
+	}
+
+	node, subject, schemaID := p.static, "", 0
+	if node == nil {
+		var err error
+		if subject, err = p.subject.TryString(msg); err != nil {
+			p.logger.Errorf("Subject interpolation error: %v", err)
+			return service.MessageBatch{msg}, nil
+		}
+		cached, err := p.getSchema(subject)
+		if err != nil {
+			p.logger.Errorf("Failed to obtain schema for subject '%v': %v", subject, err)
+			return service.MessageBatch{msg}, nil
+		}
+		node, schemaID = cached.node, cached.id
+	}
+
+	b, err := msg.AsBytes()
+	if err != nil {
+		return service.MessageBatch{msg}, nil
+	}
+	v, err := parseJSONWithNumbers(b)
+	if err != nil {
+		p.logger.Debugf("Skipping schema drift detection of message that is not valid JSON: %v", err)
+		return service.MessageBatch{msg}, nil
+	}
+
+	events := dedupeDriftEvents(node.check("", v))
+	if len(events) == 0 {
+		return service.MessageBatch{msg}, nil
+	}
+
+	now := p.nowFn()
+	batch := make(service.MessageBatch, 0, len(events))
+	for _, e := range events {
+		p.mDrift.Incr(1, e.Kind)
+
+		e.Subject, e.SchemaID, e.Timestamp = subject, schemaID, now
+		eBytes, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		if p.output == "" {
+			p.logger.Warnf("Schema drift detected: %s", eBytes)
+			continue
+		}
+		batch = append(batch, service.NewMessage(eBytes))
+	}
+
+	if len(batch) > 0 {
+		var writeErr error
+		if err := p.mgr.AccessOutput(ctx, p.output, func(o *service.ResourceOutput) {
+			writeErr = o.WriteBatch(ctx, batch)
+		}); err != nil {
+			writeErr = err
+		}
+		if writeErr != nil {
+			p.logger.Errorf("Failed to write schema drift events: %v", writeErr)
+		}
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+func (p *schemaDriftProc) Close(ctx context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// driftNode describes the expected structure of a JSON value. A node with an
+// empty kind and no branches accepts any value.
+type driftNode struct {
+	kind     string
+	fields   map[string]*driftNode
+	values   *driftNode
+	branches []*driftNode
+}
+
+func jsonKind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64, int, int64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func joinDriftPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func (n *driftNode) expected() string {
+	if len(n.branches) == 0 {
+		return n.kind
+	}
+	kinds := map[string]struct{}{}
+	for _, b := range n.branches {
+		kinds[b.expected()] = struct{}{}
+	}
+	sorted := make([]string, 0, len(kinds))
+	for k := range kinds {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	return strings.Join(sorted, "|")
+}
+
+func (n *driftNode) check(path string, v any) []driftEvent {
+	kind := jsonKind(v)
+
+	if len(n.branches) > 0 {
+		// Use the branch that results in the fewest events.
+		var best []driftEvent
+		matched := false
+		for _, b := range n.branches {
+			if b.kind != "" && len(b.branches) == 0 && b.kind != kind {
+				continue
+			}
+			events := b.check(path, v)
+			if !matched || len(events) < len(best) {
+				best, matched = events, true
+			}
+			if len(best) == 0 {
+				break
+			}
+		}
+		if !matched {
+			return []driftEvent{{Kind: "type_change", Path: path, Expected: n.expected(), Actual: kind}}
+		}
+		return best
+	}
+
+	if n.kind == "" {
+		return nil
+	}
+	if n.kind != kind {
+		return []driftEvent{{Kind: "type_change", Path: path, Expected: n.kind, Actual: kind}}
+	}
+
+	var events []driftEvent
+	switch t := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			switch {
+			case n.fields != nil:
+				child, exists := n.fields[k]
+				if !exists {
+					events = append(events, driftEvent{Kind: "new_field", Path: joinDriftPath(path, k), Actual: jsonKind(t[k])})
+					continue
+				}
+				events = append(events, child.check(joinDriftPath(path, k), t[k])...)
+			case n.values != nil:
+				events = append(events, n.values.check(joinDriftPath(path, k), t[k])...)
+			}
+		}
+	case []any:
+		if n.values != nil {
+			for _, e := range t {
+				events = append(events, n.values.check(joinDriftPath(path, "*"), e)...)
+			}
+		}
+	}
+	return events
+}
+
+func dedupeDriftEvents(events []driftEvent) []driftEvent {
+	seen := map[driftEvent]struct{}{}
+	deduped := events[:0]
+	for _, e := range events {
+		if _, exists := seen[e]; exists {
+			continue
+		}
+		seen[e] = struct{}{}
+		deduped = append(deduped, e)
+	}
+	return deduped
+}
+
+//------------------------------------------------------------------------------
+
+func avroDriftSchema(schema string) (*driftNode, error) {
+	parsed, err := parseAvroSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return avroDriftNode(parsed, parsed.root, map[string]*driftNode{}), nil
+}
+
+func avroDriftPrimitive(typeName string) *driftNode {
+	switch typeName {
+	case "null":
+		return &driftNode{kind: "null"}
+	case "boolean":
+		return &driftNode{kind: "boolean"}
+	case "int", "long", "float", "double":
+		return &driftNode{kind: "number"}
+	case "string", "bytes", "enum", "fixed":
+		return &driftNode{kind: "string"}
+	}
+	return &driftNode{}
+}
+
+func avroDriftNode(a *avroSchema, schema any, seen map[string]*driftNode) *driftNode {
+	switch t := a.resolve(schema).(type) {
+	case string:
+		return avroDriftPrimitive(t)
+	case []any:
+		n := &driftNode{}
+		for _, b := range t {
+			n.branches = append(n.branches, avroDriftNode(a, b, seen))
+		}
+		return n
+	case map[string]any:
+		typeName, isStr := t["type"].(string)
+		if !isStr {
+			return avroDriftNode(a, t["type"], seen)
+		}
+		switch typeName {
+		case "record", "error":
+			fullName, _ := t["_fullname"].(string)
+			if n, exists := seen[fullName]; exists {
+				return n
+			}
+			n := &driftNode{kind: "object", fields: map[string]*driftNode{}}
+			seen[fullName] = n
+			fields, _ := t["fields"].([]any)
+			for _, f := range fields {
+				if fObj, ok := f.(map[string]any); ok {
+					name, _ := fObj["name"].(string)
+					n.fields[name] = avroDriftNode(a, fObj["type"], seen)
+				}
+			}
+			return n
+		case "array":
+			return &driftNode{kind: "array", values: avroDriftNode(a, t["items"], seen)}
+		case "map":
+			return &driftNode{kind: "object", values: avroDriftNode(a, t["values"], seen)}
+		}
+		return avroDriftPrimitive(typeName)
+	}
+	return &driftNode{}
+}
+
+//------------------------------------------------------------------------------
+
+func protobufDriftMessage(desc protoreflect.MessageDescriptor, seen map[protoreflect.FullName]*driftNode) *driftNode {
+	if n, exists := seen[desc.FullName()]; exists {
+		return n
+	}
+
+	switch desc.FullName() {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration", "google.protobuf.FieldMask":
+		return &driftNode{kind: "string"}
+	}
+	if strings.HasPrefix(string(desc.FullName()), "google.protobuf.") {
+		// Well known types such as Struct, Value and the wrappers have special
+		// JSON representations that we do not check.
+		return &driftNode{}
+	}
+
+	n := &driftNode{kind: "object", fields: map[string]*driftNode{}}
+	seen[desc.FullName()] = n
+
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+
+		var fNode *driftNode
+		switch {
+		case fd.IsList():
+			fNode = &driftNode{kind: "array", values: protobufDriftValue(fd, seen)}
+		case fd.IsMap():
+			fNode = &driftNode{kind: "object", values: protobufDriftValue(fd.MapValue(), seen)}
+		default:
+			fNode = protobufDriftValue(fd, seen)
+		}
+
+		// Any field can be explicitly set to null in order to use its default.
+		fNode = &driftNode{branches: []*driftNode{{kind: "null"}, fNode}}
+
+		n.fields[fd.JSONName()] = fNode
+		n.fields[string(fd.Name())] = fNode
+	}
+	return n
+}
+
+func protobufDriftValue(fd protoreflect.FieldDescriptor, seen map[protoreflect.FullName]*driftNode) *driftNode {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &driftNode{kind: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.FloatKind, protoreflect.DoubleKind:
+		return &driftNode{kind: "number"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// 64-bit integers are represented as strings in the protobuf JSON
+		// mapping, but numbers are also accepted.
+		return &driftNode{branches: []*driftNode{{kind: "number"}, {kind: "string"}}}
+	case protoreflect.EnumKind:
+		return &driftNode{branches: []*driftNode{{kind: "number"}, {kind: "string"}}}
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return &driftNode{kind: "string"}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protobufDriftMessage(fd.Message(), seen)
+	}
+	return &driftNode{}
+}
@@ -0,0 +1,249 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type driftEventsCapture struct {
+	mut    sync.Mutex
+	events []map[string]any
+}
+
+func (d *driftEventsCapture) mockOpt(t testing.TB) service.MockResourcesOptFn {
+	return func(m *mock.Manager) {
+		m.Outputs["drift"] = mock.OutputWriter(func(ctx context.Context, tran message.Transaction) error {
+			d.mut.Lock()
+			for _, p := range tran.Payload {
+				var e map[string]any
+				require.NoError(t, json.Unmarshal(p.AsBytes(), &e))
+				delete(e, "timestamp")
+				d.events = append(d.events, e)
+			}
+			d.mut.Unlock()
+			return tran.Ack(ctx, nil)
+		})
+	}
+}
+
+func (d *driftEventsCapture) take() []map[string]any {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	events := d.events
+	d.events = nil
+	return events
+}
+
+func newSchemaDriftProcFromYAML(t testing.TB, confStr string, mgr *service.Resources) *schemaDriftProc {
+	t.Helper()
+
+	conf, err := schemaDriftProcConfig().ParseYAML(confStr, service.NewEnvironment())
+	require.NoError(t, err)
+
+	p, err := newSchemaDriftProcFromConfig(conf, mgr)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = p.Close(context.Background())
+	})
+	return p
+}
+
+func TestSchemaDriftAvroStatic(t *testing.T) {
+	var capture driftEventsCapture
+	p := newSchemaDriftProcFromYAML(t, `
+schema: |
+  {
+    "type": "record",
+    "name": "user",
+    "fields": [
+      { "name": "name", "type": "string" },
+      { "name": "age", "type": ["null", "int"] },
+      { "name": "tags", "type": { "type": "array", "items": "string" } },
+      { "name": "address", "type": ["null", {
+        "type": "record",
+        "name": "address",
+        "fields": [
+          { "name": "city", "type": "string" }
+        ]
+      }]}
+    ]
+  }
+output: drift
+`, service.MockResources(capture.mockOpt(t)))
+
+	tests := []struct {
+		name   string
+		input  string
+		events []map[string]any
+	}{
+		{
+			name:  "matches schema",
+			input: `{"name":"foo","age":null,"tags":["a","b"],"address":{"city":"bar"}}`,
+		},
+		{
+			name:  "missing fields",
+			input: `{"name":"foo"}`,
+		},
+		{
+			name:  "new fields",
+			input: `{"name":"foo","email":"foo@example.com","address":{"city":"bar","zip":"12345"}}`,
+			events: []map[string]any{
+				{"kind": "new_field", "path": "address.zip", "actual": "string"},
+				{"kind": "new_field", "path": "email", "actual": "string"},
+			},
+		},
+		{
+			name:  "type changes",
+			input: `{"name":"foo","age":"10","tags":["a",1,2]}`,
+			events: []map[string]any{
+				{"kind": "type_change", "path": "age", "expected": "null|number", "actual": "string"},
+				{"kind": "type_change", "path": "tags.*", "expected": "string", "actual": "number"},
+			},
+		},
+		{
+			name:  "not json",
+			input: `not json`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			msg := service.NewMessage([]byte(test.input))
+			res, err := p.Process(context.Background(), msg)
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+
+			b, err := res[0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, test.input, string(b))
+
+			assert.Equal(t, test.events, capture.take())
+		})
+	}
+}
+
+func TestSchemaDriftSampling(t *testing.T) {
+	var capture driftEventsCapture
+	p := newSchemaDriftProcFromYAML(t, `
+schema: '{"type":"record","name":"foo","fields":[]}'
+sample_rate: 0.5
+output: drift
+`, service.MockResources(capture.mockOpt(t)))
+
+	p.randFn = func() float64 { return 0.7 }
+	_, err := p.Process(context.Background(), service.NewMessage([]byte(`{"a":"b"}`)))
+	require.NoError(t, err)
+	assert.Empty(t, capture.take())
+
+	p.randFn = func() float64 { return 0.2 }
+	_, err = p.Process(context.Background(), service.NewMessage([]byte(`{"a":"b"}`)))
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{"kind": "new_field", "path": "a", "actual": "string"},
+	}, capture.take())
+}
+
+func TestSchemaDriftRegistryProtobuf(t *testing.T) {
+	thingsSchema := `
+syntax = "proto3";
+package things;
+
+import "google/protobuf/timestamp.proto";
+
+message foo {
+  int32 a = 1;
+  int64 big_number = 2;
+  repeated bar bars = 3;
+  google.protobuf.Timestamp created_at = 4;
+}
+
+message bar {
+  string b = 1;
+}
+`
+
+	var schemaVersion int
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/subjects/things/versions/latest":
+			schemaVersion++
+			return mustJBytes(t, map[string]any{
+				"id":         5,
+				"version":    schemaVersion,
+				"schema":     thingsSchema,
+				"schemaType": "PROTOBUF",
+			}), nil
+		}
+		return nil, nil
+	})
+
+	var capture driftEventsCapture
+	p := newSchemaDriftProcFromYAML(t, `
+url: `+urlStr+`
+subject: ${! @subject }
+refresh_period: 1m
+output: drift
+`, service.MockResources(capture.mockOpt(t)))
+
+	now := time.Now()
+	p.nowFn = func() time.Time { return now }
+
+	msg := service.NewMessage([]byte(`{"a":1,"bigNumber":"20","bars":[{"b":"x","c":true}],"createdAt":"2023-11-14T22:13:20Z"}`))
+	msg.MetaSetMut("subject", "things")
+	_, err := p.Process(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{"kind": "new_field", "path": "bars.*.c", "actual": "boolean", "subject": "things", "schema_id": 5.0},
+	}, capture.take())
+
+	msg = service.NewMessage([]byte(`{"a":"1","big_number":20,"created_at":10}`))
+	msg.MetaSetMut("subject", "things")
+	_, err = p.Process(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{"kind": "type_change", "path": "a", "expected": "null|number", "actual": "string", "subject": "things", "schema_id": 5.0},
+		{"kind": "type_change", "path": "created_at", "expected": "null|string", "actual": "number", "subject": "things", "schema_id": 5.0},
+	}, capture.take())
+	assert.Equal(t, 1, schemaVersion)
+
+	now = now.Add(time.Minute)
+	_, err = p.Process(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, 2, schemaVersion)
+}
+
+func TestSchemaDriftConfigLints(t *testing.T) {
+	env := service.NewEnvironment()
+	require.NoError(t, env.RegisterProcessor("schema_drift", schemaDriftProcConfig(), func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+		return newSchemaDriftProcFromConfig(conf, mgr)
+	}))
+
+	for _, test := range []struct {
+		config string
+		errMsg string
+	}{
+		{
+			config: `schema_drift: { sample_rate: 0.5 }`,
+			errMsg: "exactly one of the fields url or schema must be specified",
+		},
+		{
+			config: `schema_drift: { url: http://localhost:8081 }`,
+			errMsg: "a subject must be specified when a url is set",
+		},
+	} {
+		err := env.NewStreamBuilder().AddProcessorYAML(test.config)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.errMsg)
+	}
+}
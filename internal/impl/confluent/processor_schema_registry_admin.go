@@ -0,0 +1,280 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/httpclient"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	sraFieldURL           = "url"
+	sraFieldOperation     = "operation"
+	sraFieldSubject       = "subject"
+	sraFieldVersion       = "version"
+	sraFieldCompatibility = "compatibility"
+	sraFieldPermanent     = "permanent"
+	sraFieldTLS           = "tls"
+)
+
+var schemaRegistryCompatibilityLevels = []string{
+	"BACKWARD", "BACKWARD_TRANSITIVE",
+	"FORWARD", "FORWARD_TRANSITIVE",
+	"FULL", "FULL_TRANSITIVE",
+	"NONE",
+}
+
+func schemaRegistryAdminProcConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Categories("Integration").
+		Summary("Performs administrative operations on a Confluent Schema Registry service, such as setting the compatibility level of subjects and deleting versions, where the operation and its arguments can be derived from the contents of each message.").
+		Description(`
+This processor allows governance workflows of a [Confluent Schema Registry service](https://docs.confluent.io/platform/current/schema-registry/index.html) to be driven by Benthos pipelines, such as applying compatibility levels from a source of truth, or cleaning up subjects of deleted topics.
+
+The contents of each message are replaced with the response of the registry, and if an operation fails then the message remains unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling). In order to preserve the original contents of a message wrap this processor within a `+"[`branch` processor](/docs/components/processors/branch)"+`.
+
+### Operations
+
+The following operations are supported:
+
+- `+"`set_compatibility`"+`: Sets the compatibility level of the `+"`subject`"+` to the `+"`compatibility`"+`, or the global compatibility level when the subject is empty.
+- `+"`set_config`"+`: Updates the config of the `+"`subject`"+`, or the global config when the subject is empty, with the contents of the message, which must be a JSON object such as `+"`{\"compatibility\":\"FULL\"}`"+`.
+- `+"`delete_subject`"+`: Deletes all versions of the `+"`subject`"+`.
+- `+"`delete_version`"+`: Deletes the `+"`version`"+` of the `+"`subject`"+`.
+
+Deletions are soft by default, meaning the registry retains the schemas. A permanent (hard) deletion is performed when `+"`permanent`"+` resolves to `+"`true`"+`, which is only possible for subjects and versions that have already been soft deleted.`).
+		Field(service.NewURLField(sraFieldURL).Description("The base URL of the schema registry service.")).
+		Field(service.NewInterpolatedStringField(sraFieldOperation).
+			Description("The operation to perform, which must resolve to one of `set_compatibility`, `set_config`, `delete_subject` or `delete_version`.").
+			Example("set_compatibility").
+			Example(`${! this.operation }`)).
+		Field(service.NewInterpolatedStringField(sraFieldSubject).
+			Description("The subject to perform the operation on. An empty subject targets the global config of the `set_compatibility` and `set_config` operations.").
+			Example(`${! this.subject }`).
+			Default("")).
+		Field(service.NewInterpolatedStringField(sraFieldVersion).
+			Description("The version to delete with the `delete_version` operation, which can either be a version number or `latest`.").
+			Example(`${! this.version }`).
+			Default("latest")).
+		Field(service.NewInterpolatedStringField(sraFieldCompatibility).
+			Description("The compatibility level to set with the `set_compatibility` operation, which must resolve to one of `"+strings.Join(schemaRegistryCompatibilityLevels, "`, `")+"`.").
+			Example("FULL").
+			Example(`${! this.compatibility }`).
+			Default("")).
+		Field(service.NewInterpolatedStringField(sraFieldPermanent).
+			Description("Whether deletions should be permanent, which must resolve to either `true` or `false`.").
+			Example(`${! this.hard_delete }`).
+			Default("false").
+			Advanced()).
+		Example("Applying Compatibility Levels",
+			"In this example documents describing the desired compatibility level of subjects are consumed from a topic and applied to the registry.",
+			`
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ schema_governance ]
+    consumer_group: benthos
+
+pipeline:
+  processors:
+    - schema_registry_admin:
+        url: http://localhost:8081
+        operation: set_compatibility
+        subject: ${! this.subject }
+        compatibility: ${! this.compatibility }
+
+output:
+  drop: {}
+`)
+
+	for _, f := range httpclient.AuthFieldSpecs() {
+		spec = spec.Field(f)
+	}
+
+	return spec.Field(service.NewTLSField(sraFieldTLS))
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"schema_registry_admin", schemaRegistryAdminProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newSchemaRegistryAdminProcFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type schemaRegistryAdminProc struct {
+	client        *schemaRegistryClient
+	operation     *service.InterpolatedString
+	subject       *service.InterpolatedString
+	version       *service.InterpolatedString
+	compatibility *service.InterpolatedString
+	permanent     *service.InterpolatedString
+}
+
+func newSchemaRegistryAdminProcFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*schemaRegistryAdminProc, error) {
+	p := &schemaRegistryAdminProc{}
+
+	var err error
+	if p.operation, err = conf.FieldInterpolatedString(sraFieldOperation); err != nil {
+		return nil, err
+	}
+	if p.subject, err = conf.FieldInterpolatedString(sraFieldSubject); err != nil {
+		return nil, err
+	}
+	if p.version, err = conf.FieldInterpolatedString(sraFieldVersion); err != nil {
+		return nil, err
+	}
+	if p.compatibility, err = conf.FieldInterpolatedString(sraFieldCompatibility); err != nil {
+		return nil, err
+	}
+	if p.permanent, err = conf.FieldInterpolatedString(sraFieldPermanent); err != nil {
+		return nil, err
+	}
+
+	urlStr, err := conf.FieldString(sraFieldURL)
+	if err != nil {
+		return nil, err
+	}
+	authSigner, err := httpclient.AuthSignerFromParsed(conf)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS(sraFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	if p.client, err = newSchemaRegistryClient(urlStr, authSigner, tlsConf, mgr); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *schemaRegistryAdminProc) requireSubject(msg *service.Message) (string, error) {
+	subject, err := p.subject.TryString(msg)
+	if err != nil {
+		return "", fmt.Errorf("subject interpolation error: %w", err)
+	}
+	if subject == "" {
+		return "", errors.New("a subject is required for this operation")
+	}
+	return subject, nil
+}
+
+func (p *schemaRegistryAdminProc) isPermanent(msg *service.Message) (bool, error) {
+	permanentStr, err := p.permanent.TryString(msg)
+	if err != nil {
+		return false, fmt.Errorf("permanent interpolation error: %w", err)
+	}
+	permanent, err := strconv.ParseBool(permanentStr)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse permanent: %w", err)
+	}
+	return permanent, nil
+}
+
+func (p *schemaRegistryAdminProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	operation, err := p.operation.TryString(msg)
+	if err != nil {
+		return nil, fmt.Errorf("operation interpolation error: %w", err)
+	}
+
+	var resBody []byte
+	switch operation {
+	case "set_compatibility":
+		subject, err := p.subject.TryString(msg)
+		if err != nil {
+			return nil, fmt.Errorf("subject interpolation error: %w", err)
+		}
+		compatibility, err := p.compatibility.TryString(msg)
+		if err != nil {
+			return nil, fmt.Errorf("compatibility interpolation error: %w", err)
+		}
+		compatibility = strings.ToUpper(compatibility)
+		valid := false
+		for _, l := range schemaRegistryCompatibilityLevels {
+			if l == compatibility {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("compatibility level '%v' not recognised", compatibility)
+		}
+		body, err := json.Marshal(map[string]string{"compatibility": compatibility})
+		if err != nil {
+			return nil, err
+		}
+		if resBody, err = p.client.SetConfig(ctx, subject, body); err != nil {
+			return nil, err
+		}
+	case "set_config":
+		subject, err := p.subject.TryString(msg)
+		if err != nil {
+			return nil, fmt.Errorf("subject interpolation error: %w", err)
+		}
+		body, err := msg.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		var config map[string]any
+		if err := json.Unmarshal(body, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse config from message: %w", err)
+		}
+		if resBody, err = p.client.SetConfig(ctx, subject, body); err != nil {
+			return nil, err
+		}
+	case "delete_subject":
+		subject, err := p.requireSubject(msg)
+		if err != nil {
+			return nil, err
+		}
+		permanent, err := p.isPermanent(msg)
+		if err != nil {
+			return nil, err
+		}
+		if resBody, err = p.client.DeleteSubject(ctx, subject, permanent); err != nil {
+			return nil, err
+		}
+	case "delete_version":
+		subject, err := p.requireSubject(msg)
+		if err != nil {
+			return nil, err
+		}
+		version, err := p.version.TryString(msg)
+		if err != nil {
+			return nil, fmt.Errorf("version interpolation error: %w", err)
+		}
+		if version != "latest" {
+			if _, err := strconv.Atoi(version); err != nil {
+				return nil, fmt.Errorf("version '%v' must either be a number or latest", version)
+			}
+		}
+		permanent, err := p.isPermanent(msg)
+		if err != nil {
+			return nil, err
+		}
+		if resBody, err = p.client.DeleteSubjectVersion(ctx, subject, version, permanent); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("operation '%v' not recognised", operation)
+	}
+
+	msg.SetBytes(resBody)
+	return service.MessageBatch{msg}, nil
+}
+
+func (p *schemaRegistryAdminProc) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,177 @@
+package confluent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestSchemaRegistryAdmin(t *testing.T) {
+	var reqMut sync.Mutex
+	var requests []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqMut.Lock()
+		defer reqMut.Unlock()
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		reqStr := r.Method + " " + r.URL.Path
+		if r.URL.RawQuery != "" {
+			reqStr += "?" + r.URL.RawQuery
+		}
+		if len(body) > 0 {
+			reqStr += " " + string(body)
+		}
+		requests = append(requests, reqStr)
+
+		switch r.URL.Path {
+		case "/config", "/config/foo":
+			_, _ = w.Write(body)
+		case "/subjects/foo":
+			_, _ = w.Write([]byte(`[1,2,3]`))
+		case "/subjects/foo/versions/latest", "/subjects/foo/versions/2":
+			_, _ = w.Write([]byte(`3`))
+		case "/subjects/bar":
+			http.Error(w, `{"error_code":40405,"message":"Subject 'bar' was not deleted first before being permanently deleted"}`, http.StatusConflict)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	conf, err := schemaRegistryAdminProcConfig().ParseYAML(`
+url: `+ts.URL+`
+operation: ${! this.operation }
+subject: ${! this.subject.or("") }
+version: ${! this.version.or("latest") }
+compatibility: ${! this.compatibility.or("") }
+permanent: ${! this.permanent.or(false) }
+`, service.NewEnvironment())
+	require.NoError(t, err)
+
+	proc, err := newSchemaRegistryAdminProcFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = proc.Close(context.Background())
+	})
+
+	tests := []struct {
+		name        string
+		input       string
+		output      string
+		request     string
+		errContains string
+	}{
+		{
+			name:    "set subject compatibility",
+			input:   `{"operation":"set_compatibility","subject":"foo","compatibility":"full"}`,
+			output:  `{"compatibility":"FULL"}`,
+			request: `PUT /config/foo {"compatibility":"FULL"}`,
+		},
+		{
+			name:    "set global compatibility",
+			input:   `{"operation":"set_compatibility","compatibility":"BACKWARD"}`,
+			output:  `{"compatibility":"BACKWARD"}`,
+			request: `PUT /config {"compatibility":"BACKWARD"}`,
+		},
+		{
+			name:        "set bad compatibility",
+			input:       `{"operation":"set_compatibility","compatibility":"SORTOF"}`,
+			errContains: "compatibility level 'SORTOF' not recognised",
+		},
+		{
+			name:    "set global config",
+			input:   `{"operation":"set_config","compatibility":"NONE"}`,
+			output:  `{"operation":"set_config","compatibility":"NONE"}`,
+			request: `PUT /config {"operation":"set_config","compatibility":"NONE"}`,
+		},
+		{
+			name:    "soft delete subject",
+			input:   `{"operation":"delete_subject","subject":"foo"}`,
+			output:  `[1,2,3]`,
+			request: `DELETE /subjects/foo`,
+		},
+		{
+			name:    "hard delete subject",
+			input:   `{"operation":"delete_subject","subject":"foo","permanent":true}`,
+			output:  `[1,2,3]`,
+			request: `DELETE /subjects/foo?permanent=true`,
+		},
+		{
+			name:        "hard delete subject not soft deleted",
+			input:       `{"operation":"delete_subject","subject":"bar","permanent":true}`,
+			request:     `DELETE /subjects/bar?permanent=true`,
+			errContains: "was not deleted first",
+		},
+		{
+			name:        "delete subject without subject",
+			input:       `{"operation":"delete_subject"}`,
+			errContains: "a subject is required",
+		},
+		{
+			name:    "delete latest version",
+			input:   `{"operation":"delete_version","subject":"foo"}`,
+			output:  `3`,
+			request: `DELETE /subjects/foo/versions/latest`,
+		},
+		{
+			name:    "hard delete version",
+			input:   `{"operation":"delete_version","subject":"foo","version":2,"permanent":true}`,
+			output:  `3`,
+			request: `DELETE /subjects/foo/versions/2?permanent=true`,
+		},
+		{
+			name:        "delete version not found",
+			input:       `{"operation":"delete_version","subject":"baz","version":2}`,
+			request:     `DELETE /subjects/baz/versions/2`,
+			errContains: "schema subject 'baz' not found by registry",
+		},
+		{
+			name:        "bad operation",
+			input:       `{"operation":"nope"}`,
+			errContains: "operation 'nope' not recognised",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			reqMut.Lock()
+			requests = nil
+			reqMut.Unlock()
+
+			res, err := proc.Process(context.Background(), service.NewMessage([]byte(test.input)))
+
+			reqMut.Lock()
+			if test.request != "" {
+				require.NotEmpty(t, requests)
+				assert.Equal(t, test.request, requests[0])
+			} else {
+				assert.Empty(t, requests)
+			}
+			reqMut.Unlock()
+
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+
+			b, err := res[0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(b))
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -39,6 +41,11 @@ For example, the union schema ` + "`[\"null\",\"string\",\"Foo\"]`, where `Foo`"
 - a ` + "`Foo` instance as `{\"Foo\": {...}}`, where `{...}` indicates the JSON encoding of a `Foo`" + ` instance.
 
 However, it is possible to instead create documents in [standard/raw JSON format](https://pkg.go.dev/github.com/linkedin/goavro/v2#NewCodecForStandardJSONFull) by setting the field ` + "[`avro_raw_json`](#avro_raw_json) to `true`" + `.
+
+### Schema IDs in Headers
+
+By default the schema ID is extracted from the prefix of the message payload as described by the [Confluent wire format](https://docs.confluent.io/platform/current/schema-registry/fundamentals/serdes-develop/index.html#wire-format). However, some producers place the schema ID in a record header instead, in which case the payload has no prefix. When the field ` + "[`schema_id_metadata_key`](#schema_id_metadata_key)" + ` is set the schema ID is read from the metadata key of that name, which for the Kafka inputs is populated from the record headers. Messages without the metadata key fall back to extracting the schema ID from the payload.
+
 ### Protobuf Format
 
 This processor decodes protobuf messages to JSON documents, you can read more about JSON mapping of protobuf messages here: https://developers.google.com/protocol-buffers/docs/proto3#json
@@ -46,6 +53,10 @@ This processor decodes protobuf messages to JSON documents, you can read more ab
 		Field(service.NewBoolField("avro_raw_json").
 			Description("Whether Avro messages should be decoded into normal JSON (\"json that meets the expectations of regular internet json\") rather than [Avro JSON](https://avro.apache.org/docs/current/specification/_print/#json-encoding). If `true` the schema returned from the subject should be decoded as [standard json](https://pkg.go.dev/github.com/linkedin/goavro/v2#NewCodecForStandardJSONFull) instead of as [avro json](https://pkg.go.dev/github.com/linkedin/goavro/v2#NewCodec). There is a [comment in goavro](https://github.com/linkedin/goavro/blob/5ec5a5ee7ec82e16e6e2b438d610e1cab2588393/union.go#L224-L249), the [underlining library used for avro serialization](https://github.com/linkedin/goavro), that explains in more detail the difference between the standard json and avro json.").
 			Advanced().Default(false)).
+		Field(service.NewStringField("schema_id_metadata_key").
+			Description("An optional metadata key to read the schema ID from, in which case the payload of a message is expected to have no schema ID prefix. The value can either be the ID as a decimal string, or as a four byte big-endian integer optionally preceded by a zero magic byte. Messages without the metadata key fall back to extracting the schema ID from the payload.").
+			Example("schema_id").
+			Advanced().Optional()).
 		Field(service.NewURLField("url").Description("The base URL of the schema registry service."))
 
 	for _, f := range httpclient.AuthFieldSpecs() {
@@ -69,8 +80,9 @@ func init() {
 //------------------------------------------------------------------------------
 
 type schemaRegistryDecoder struct {
-	avroRawJSON bool
-	client      *schemaRegistryClient
+	avroRawJSON         bool
+	schemaIDMetadataKey string
+	client              *schemaRegistryClient
 
 	schemas    map[int]*cachedSchemaDecoder
 	cacheMut   sync.RWMutex
@@ -98,7 +110,17 @@ func newSchemaRegistryDecoderFromConfig(conf *service.ParsedConfig, mgr *service
 	if err != nil {
 		return nil, err
 	}
-	return newSchemaRegistryDecoder(urlStr, authSigner, tlsConf, avroRawJSON, mgr)
+	s, err := newSchemaRegistryDecoder(urlStr, authSigner, tlsConf, avroRawJSON, mgr)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Contains("schema_id_metadata_key") {
+		if s.schemaIDMetadataKey, err = conf.FieldString("schema_id_metadata_key"); err != nil {
+			_ = s.Close(context.Background())
+			return nil, err
+		}
+	}
+	return s, nil
 }
 
 func newSchemaRegistryDecoder(
@@ -139,8 +161,13 @@ func (s *schemaRegistryDecoder) Process(ctx context.Context, msg *service.Messag
 		return nil, errors.New("unable to reference message as bytes")
 	}
 
-	id, remaining, err := extractID(b)
-	if err != nil {
+	var id int
+	remaining := b
+	if v, exists := s.metadataSchemaID(msg); exists {
+		if id, err = extractIDFromMetadata(v); err != nil {
+			return nil, fmt.Errorf("metadata key %v: %w", s.schemaIDMetadataKey, err)
+		}
+	} else if id, remaining, err = extractID(b); err != nil {
 		return nil, err
 	}
 
@@ -193,6 +220,45 @@ func extractID(b []byte) (id int, remaining []byte, err error) {
 	return
 }
 
+func (s *schemaRegistryDecoder) metadataSchemaID(msg *service.Message) (any, bool) {
+	if s.schemaIDMetadataKey == "" {
+		return nil, false
+	}
+	return msg.MetaGetMut(s.schemaIDMetadataKey)
+}
+
+// extractIDFromMetadata parses a schema ID from a metadata value, which is
+// either a decimal string or a big-endian integer in binary form with an
+// optional magic byte, as header values are written by some producers.
+func extractIDFromMetadata(v any) (int, error) {
+	var b []byte
+	switch t := v.(type) {
+	case string:
+		b = []byte(t)
+	case []byte:
+		b = t
+	case int:
+		return t, nil
+	case int64:
+		return int(t), nil
+	case uint32:
+		return int(t), nil
+	default:
+		return 0, fmt.Errorf("unsupported schema ID type %T", v)
+	}
+
+	if id, err := strconv.Atoi(strings.TrimSpace(string(b))); err == nil {
+		return id, nil
+	}
+	switch {
+	case len(b) == 4:
+		return int(binary.BigEndian.Uint32(b)), nil
+	case len(b) == 5 && b[0] == 0:
+		return int(binary.BigEndian.Uint32(b[1:])), nil
+	}
+	return 0, fmt.Errorf("unable to parse schema ID from value of length %v", len(b))
+}
+
 const (
 	schemaStaleAfter       = time.Minute * 10
 	schemaCachePurgePeriod = time.Minute
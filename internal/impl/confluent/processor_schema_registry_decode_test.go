@@ -283,6 +283,89 @@ func TestSchemaRegistryDecodeAvro(t *testing.T) {
 	decoder.cacheMut.Unlock()
 }
 
+func TestSchemaRegistryDecodeAvroMetadataID(t *testing.T) {
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		if path == "/schemas/ids/3" {
+			return mustJBytes(t, map[string]any{
+				"schema": testSchema,
+			}), nil
+		}
+		return nil, nil
+	})
+
+	decoder, err := newSchemaRegistryDecoder(urlStr, noopReqSign, nil, false, service.MockResources())
+	require.NoError(t, err)
+	decoder.schemaIDMetadataKey = "schema_id"
+
+	expected := `{"Name":"foo","MaybeHobby":null,"Address": null}`
+
+	tests := []struct {
+		name        string
+		metadata    any
+		input       string
+		errContains string
+	}{
+		{
+			name:     "decimal string",
+			metadata: "3",
+			input:    "\x06foo\x00\x00",
+		},
+		{
+			name:     "binary value",
+			metadata: "\x00\x00\x00\x03",
+			input:    "\x06foo\x00\x00",
+		},
+		{
+			name:     "binary value with magic byte",
+			metadata: []byte("\x00\x00\x00\x00\x03"),
+			input:    "\x06foo\x00\x00",
+		},
+		{
+			name:     "integer value",
+			metadata: int64(3),
+			input:    "\x06foo\x00\x00",
+		},
+		{
+			name:  "missing metadata falls back to payload",
+			input: "\x00\x00\x00\x00\x03\x06foo\x00\x00",
+		},
+		{
+			name:        "bad value",
+			metadata:    "not a number",
+			input:       "\x06foo\x00\x00",
+			errContains: "metadata key schema_id: unable to parse schema ID",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			inMsg := service.NewMessage([]byte(test.input))
+			if test.metadata != nil {
+				inMsg.MetaSetMut("schema_id", test.metadata)
+			}
+
+			outMsgs, err := decoder.Process(context.Background(), inMsg)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, outMsgs, 1)
+
+			b, err := outMsgs[0].AsBytes()
+			require.NoError(t, err)
+
+			jdopts := jsondiff.DefaultJSONOptions()
+			diff, explanation := jsondiff.Compare(b, []byte(expected), &jdopts)
+			assert.Equalf(t, jsondiff.FullMatch.String(), diff.String(), "%s: %s", test.name, explanation)
+		})
+	}
+
+	require.NoError(t, decoder.Close(context.Background()))
+}
+
 func TestSchemaRegistryDecodeAvroRawJson(t *testing.T) {
 	payload3, err := json.Marshal(struct {
 		Schema string `json:"schema"`
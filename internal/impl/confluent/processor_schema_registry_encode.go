@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -68,7 +67,13 @@ We will be considering alternative approaches in future so please [get in touch]
 			Example("1h")).
 		Field(service.NewBoolField("avro_raw_json").
 			Description("Whether messages encoded in Avro format should be parsed as normal JSON (\"json that meets the expectations of regular internet json\") rather than [Avro JSON](https://avro.apache.org/docs/current/specification/_print/#json-encoding). If `true` the schema returned from the subject should be parsed as [standard json](https://pkg.go.dev/github.com/linkedin/goavro/v2#NewCodecForStandardJSONFull) instead of as [avro json](https://pkg.go.dev/github.com/linkedin/goavro/v2#NewCodec). There is a [comment in goavro](https://github.com/linkedin/goavro/blob/5ec5a5ee7ec82e16e6e2b438d610e1cab2588393/union.go#L224-L249), the [underlining library used for avro serialization](https://github.com/linkedin/goavro), that explains in more detail the difference between standard json and avro json.").
-			Advanced().Default(false).Version("3.59.0"))
+			Advanced().Default(false).Version("3.59.0")).
+		Field(service.NewBoolField("coerce").
+			Description("Whether to attempt to coerce the values of documents into the types expected by the schema before encoding, such as parsing numbers and booleans from strings, formatting numbers as strings, and converting RFC 3339 timestamps into the epoch based logical timestamp types of Avro (or epoch seconds into the `google.protobuf.Timestamp` type of Protobuf).").
+			Advanced().Default(false)).
+		Field(service.NewStringEnumField("validation", "strict", "lenient").
+			Description("Determines how fields of a document that are not defined by the schema are handled. When `strict` these fields cause the message to fail encoding, and when `lenient` they are dropped from the encoded message. Since unknown fields no longer cause an attempt to fail, `lenient` validation of a protobuf schema with multiple messages only drops fields when no message matches all of them, in which case the message that the fewest fields are dropped from is selected.").
+			Advanced().Default("strict"))
 
 	for _, f := range httpclient.AuthFieldSpecs() {
 		spec = spec.Field(f.Version("4.7.0"))
@@ -94,6 +99,8 @@ type schemaRegistryEncoder struct {
 	client             *schemaRegistryClient
 	subject            *service.InterpolatedString
 	avroRawJSON        bool
+	coerce             bool
+	lenient            bool
 	schemaRefreshAfter time.Duration
 
 	schemas    map[string]*cachedSchemaEncoder
@@ -139,7 +146,21 @@ func newSchemaRegistryEncoderFromConfig(conf *service.ParsedConfig, mgr *service
 	if err != nil {
 		return nil, err
 	}
-	return newSchemaRegistryEncoder(urlStr, authSigner, tlsConf, subject, avroRawJSON, refreshPeriod, refreshTicker, mgr)
+	coerce, err := conf.FieldBool("coerce")
+	if err != nil {
+		return nil, err
+	}
+	validation, err := conf.FieldString("validation")
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSchemaRegistryEncoder(urlStr, authSigner, tlsConf, subject, avroRawJSON, refreshPeriod, refreshTicker, mgr)
+	if err != nil {
+		return nil, err
+	}
+	s.coerce = coerce
+	s.lenient = validation == "lenient"
+	return s, nil
 }
 
 func newSchemaRegistryEncoder(
@@ -195,22 +216,10 @@ func (s *schemaRegistryEncoder) ProcessBatch(ctx context.Context, batch service.
 			continue
 		}
 
-		if err := encoder(msg); err != nil {
+		if err := encoder(msg, schemaIDHeader(id)); err != nil {
 			msg.SetError(err)
 			continue
 		}
-
-		rawBytes, err := msg.AsBytes()
-		if err != nil {
-			msg.SetError(errors.New("unable to reference encoded message as bytes"))
-			continue
-		}
-
-		if rawBytes, err = insertID(id, rawBytes); err != nil {
-			msg.SetError(err)
-			continue
-		}
-		msg.SetBytes(rawBytes)
 	}
 	return []service.MessageBatch{batch}, nil
 }
@@ -230,7 +239,10 @@ func (s *schemaRegistryEncoder) Close(ctx context.Context) error {
 
 //------------------------------------------------------------------------------
 
-type schemaEncoder func(m *service.Message) error
+// schemaEncoder serialises a message by appending its encoded form to a header
+// containing the schema ID, which avoids copying the encoded message in order
+// to prepend the ID.
+type schemaEncoder func(m *service.Message, header []byte) error
 
 type cachedSchemaEncoder struct {
 	lastUsedUnixSeconds    int64
@@ -239,13 +251,12 @@ type cachedSchemaEncoder struct {
 	encoder                schemaEncoder
 }
 
-func insertID(id int, content []byte) ([]byte, error) {
-	newBytes := make([]byte, len(content)+5)
-
-	binary.BigEndian.PutUint32(newBytes[1:], uint32(id))
-	copy(newBytes[5:], content)
-
-	return newBytes, nil
+// schemaIDHeader returns the wire format header of a message encoded with a
+// schema ID, which is a zero magic byte followed by the ID as four bytes.
+func schemaIDHeader(id int) []byte {
+	header := make([]byte, 5, 64)
+	binary.BigEndian.PutUint32(header[1:], uint32(id))
+	return header
 }
 
 func (s *schemaRegistryEncoder) refreshEncoders() {
@@ -424,6 +424,121 @@ func TestSchemaRegistryEncodeAvroRawJSONLogicalTypes(t *testing.T) {
 	encoder.cacheMut.Unlock()
 }
 
+func TestSchemaRegistryEncodeAvroCoerce(t *testing.T) {
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/subjects/foo/versions/latest":
+			return mustJBytes(t, map[string]any{"schema": testSchema, "id": 3}), nil
+		case "/subjects/bar/versions/latest":
+			return mustJBytes(t, map[string]any{"schema": testSchemaLogicalTypes, "id": 4}), nil
+		}
+		return nil, errors.New("nope")
+	})
+
+	subj, err := service.NewInterpolatedString("${! @subject }")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		subject     string
+		rawJSON     bool
+		lenient     bool
+		input       string
+		expected    string
+		errContains string
+	}{
+		{
+			name:     "avro json scalars",
+			subject:  "foo",
+			input:    `{"Name":123,"MaybeHobby":"dancing"}`,
+			expected: `{"Name":"123","MaybeHobby":{"string":"dancing"}}`,
+		},
+		{
+			name:     "avro json nested record",
+			subject:  "foo",
+			input:    `{"Address":{"City":"foo","State":10},"Name":"foo","MaybeHobby":null}`,
+			expected: `{"Address":{"my.namespace.com.address":{"City":{"string":"foo"},"State":"10"}},"Name":"foo","MaybeHobby":null}`,
+		},
+		{
+			name:        "avro json unknown field strict",
+			subject:     "foo",
+			input:       `{"Name":"foo","MaybeHobby":null,"Age":10}`,
+			errContains: "field Age is not defined by the schema",
+		},
+		{
+			name:     "avro json unknown field lenient",
+			subject:  "foo",
+			lenient:  true,
+			input:    `{"Name":"foo","MaybeHobby":null,"Age":10}`,
+			expected: `{"Name":"foo","MaybeHobby":null}`,
+		},
+		{
+			name:     "raw json timestamps",
+			subject:  "bar",
+			rawJSON:  true,
+			input:    `{"int_time_millis":"35245000","long_time_micros":20192000000000,"long_timestamp_micros":"1970-01-01T00:00:01Z","pos_0_33333333":"!"}`,
+			expected: `{"int_time_millis":35245000,"long_time_micros":20192000000000,"long_timestamp_micros":1000000,"pos_0_33333333":"!"}`,
+		},
+		{
+			name:        "raw json not coercible",
+			subject:     "bar",
+			rawJSON:     true,
+			input:       `{"int_time_millis":"nope"}`,
+			errContains: "field int_time_millis: value does not match any branch of the union",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, test.rawJSON, time.Minute*10, time.Minute, service.MockResources())
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = encoder.Close(context.Background())
+			})
+
+			plainEncoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, test.rawJSON, time.Minute*10, time.Minute, service.MockResources())
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = plainEncoder.Close(context.Background())
+			})
+
+			encoder.coerce = true
+			encoder.lenient = test.lenient
+
+			inMsg := service.NewMessage([]byte(test.input))
+			inMsg.MetaSetMut("subject", test.subject)
+
+			outBatches, err := encoder.ProcessBatch(context.Background(), service.MessageBatch{inMsg})
+			require.NoError(t, err)
+			require.Len(t, outBatches, 1)
+			require.Len(t, outBatches[0], 1)
+
+			err = outBatches[0][0].GetError()
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+
+			expMsg := service.NewMessage([]byte(test.expected))
+			expMsg.MetaSetMut("subject", test.subject)
+
+			expBatches, err := plainEncoder.ProcessBatch(context.Background(), service.MessageBatch{expMsg})
+			require.NoError(t, err)
+			require.NoError(t, expBatches[0][0].GetError())
+
+			expBytes, err := expBatches[0][0].AsBytes()
+			require.NoError(t, err)
+
+			b, err := outBatches[0][0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, string(expBytes), string(b))
+		})
+	}
+}
+
 func TestSchemaRegistryEncodeClearExpired(t *testing.T) {
 	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
 		return nil, fmt.Errorf("nope")
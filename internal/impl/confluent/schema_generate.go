@@ -0,0 +1,527 @@
+package confluent
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// The maximum depth of nested records or messages that are generated, beyond
+// which optional values are left empty in order to prevent infinite recursion
+// within recursive schemas.
+const generateMaxDepth = 5
+
+const generateAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// valueGenerator produces random documents that conform to a schema.
+type valueGenerator interface {
+	Generate(r *rand.Rand, now time.Time) (any, error)
+}
+
+func generateString(r *rand.Rand, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		sb.WriteByte(generateAlphabet[r.Intn(len(generateAlphabet))])
+	}
+	return sb.String()
+}
+
+func generateUUID(r *rand.Rand) string {
+	var b [16]byte
+	_, _ = r.Read(b[:])
+	id := uuid.UUID(b)
+	id.SetVersion(uuid.V4)
+	id.SetVariant(uuid.VariantRFC4122)
+	return id.String()
+}
+
+// generateCount returns the number of elements to generate for an array or
+// map, which is zero beyond the maximum depth.
+func generateCount(r *rand.Rand, depth int) int {
+	if depth >= generateMaxDepth {
+		return 0
+	}
+	return 1 + r.Intn(3)
+}
+
+//------------------------------------------------------------------------------
+
+type avroGenerator struct {
+	*avroSchema
+	rawJSON bool
+}
+
+func newAvroGenerator(schema string, rawJSON bool) (*avroGenerator, error) {
+	parsed, err := parseAvroSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &avroGenerator{avroSchema: parsed, rawJSON: rawJSON}, nil
+}
+
+func (a *avroGenerator) Generate(r *rand.Rand, now time.Time) (any, error) {
+	return a.generate(r, now, a.root, 0)
+}
+
+func (a *avroGenerator) generate(r *rand.Rand, now time.Time, schema any, depth int) (any, error) {
+	switch t := a.resolve(schema).(type) {
+	case string:
+		return a.generatePrimitive(r, now, t, "", nil)
+	case []any:
+		return a.generateUnion(r, now, t, depth)
+	case map[string]any:
+		typeName, isStr := t["type"].(string)
+		if !isStr {
+			return a.generate(r, now, t["type"], depth)
+		}
+		switch typeName {
+		case "record", "error":
+			obj := map[string]any{}
+			fields, _ := t["fields"].([]any)
+			for _, f := range fields {
+				fObj, ok := f.(map[string]any)
+				if !ok {
+					continue
+				}
+				name, _ := fObj["name"].(string)
+				v, err := a.generate(r, now, fObj["type"], depth+1)
+				if err != nil {
+					return nil, fmt.Errorf("field %v: %w", name, err)
+				}
+				obj[name] = v
+			}
+			return obj, nil
+		case "array":
+			n := generateCount(r, depth)
+			arr := make([]any, n)
+			for i := range arr {
+				var err error
+				if arr[i], err = a.generate(r, now, t["items"], depth+1); err != nil {
+					return nil, err
+				}
+			}
+			return arr, nil
+		case "map":
+			n := generateCount(r, depth)
+			obj := make(map[string]any, n)
+			for i := 0; i < n; i++ {
+				v, err := a.generate(r, now, t["values"], depth+1)
+				if err != nil {
+					return nil, err
+				}
+				obj["key_"+strconv.Itoa(i)] = v
+			}
+			return obj, nil
+		case "enum":
+			symbols, _ := t["symbols"].([]any)
+			if len(symbols) == 0 {
+				return nil, errors.New("enum has no symbols")
+			}
+			return symbols[r.Intn(len(symbols))], nil
+		case "fixed":
+			size, _ := t["size"].(float64)
+			return generateString(r, int(size)), nil
+		}
+		logical, _ := t["logicalType"].(string)
+		return a.generatePrimitive(r, now, typeName, logical, t)
+	}
+	return nil, fmt.Errorf("unrecognised schema: %v", schema)
+}
+
+func (a *avroGenerator) generateUnion(r *rand.Rand, now time.Time, branches []any, depth int) (any, error) {
+	if len(branches) == 0 {
+		return nil, errors.New("union has no branches")
+	}
+
+	b := branches[r.Intn(len(branches))]
+	if depth >= generateMaxDepth {
+		for _, nb := range branches {
+			if nb == "null" {
+				b = nb
+				break
+			}
+		}
+	}
+	if b == "null" {
+		return nil, nil
+	}
+
+	v, err := a.generate(r, now, b, depth)
+	if err != nil {
+		return nil, err
+	}
+	if a.rawJSON {
+		return v, nil
+	}
+	return map[string]any{a.branchName(b): v}, nil
+}
+
+func (a *avroGenerator) generatePrimitive(r *rand.Rand, now time.Time, typeName, logicalType string, schema map[string]any) (any, error) {
+	switch logicalType {
+	case "timestamp-millis", "local-timestamp-millis":
+		return now.UnixMilli(), nil
+	case "timestamp-micros", "local-timestamp-micros":
+		return now.UnixMicro(), nil
+	case "date":
+		return now.Unix() / 86400, nil
+	case "time-millis":
+		return int64(r.Intn(86400000)), nil
+	case "time-micros":
+		return r.Int63n(86400000000), nil
+	case "uuid":
+		return generateUUID(r), nil
+	}
+
+	switch typeName {
+	case "null":
+		return nil, nil
+	case "boolean":
+		return r.Intn(2) == 1, nil
+	case "int":
+		return int64(r.Int31n(10000)), nil
+	case "long":
+		return r.Int63n(1000000), nil
+	case "float", "double":
+		return float64(r.Intn(100000)) / 100, nil
+	case "string":
+		return generateString(r, 8+r.Intn(8)), nil
+	case "bytes":
+		return generateString(r, 4+r.Intn(4)), nil
+	}
+	return nil, fmt.Errorf("unrecognised type: %v", typeName)
+}
+
+//------------------------------------------------------------------------------
+
+type jsonSchemaGenerator struct {
+	root map[string]any
+}
+
+func newJSONSchemaGenerator(schema []byte) (*jsonSchemaGenerator, error) {
+	v, err := parseJSONWithNumbers(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	root, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected schema to be an object, got %T", v)
+	}
+	return &jsonSchemaGenerator{root: root}, nil
+}
+
+func (j *jsonSchemaGenerator) Generate(r *rand.Rand, now time.Time) (any, error) {
+	return j.generate(r, now, j.root, 0)
+}
+
+func (j *jsonSchemaGenerator) resolveRef(ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only local references are supported, got %v", ref)
+	}
+	var current any = j.root
+	for _, seg := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if seg == "" {
+			continue
+		}
+		seg = strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("failed to resolve reference %v", ref)
+		}
+		current = obj[seg]
+	}
+	schema, ok := current.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("failed to resolve reference %v", ref)
+	}
+	return schema, nil
+}
+
+func jsonSchemaNumber(schema map[string]any, key string) (float64, bool) {
+	n, ok := schema[key].(interface{ Float64() (float64, error) })
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func (j *jsonSchemaGenerator) generate(r *rand.Rand, now time.Time, schema map[string]any, depth int) (any, error) {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := j.resolveRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		return j.generate(r, now, resolved, depth)
+	}
+	if c, exists := schema["const"]; exists {
+		return c, nil
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[r.Intn(len(enum))], nil
+	}
+	for _, k := range []string{"oneOf", "anyOf"} {
+		if options, ok := schema[k].([]any); ok && len(options) > 0 {
+			option, ok := options[r.Intn(len(options))].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("expected %v options to be objects", k)
+			}
+			return j.generate(r, now, option, depth)
+		}
+	}
+	if all, ok := schema["allOf"].([]any); ok {
+		merged := map[string]any{}
+		for _, s := range all {
+			sObj, ok := s.(map[string]any)
+			if !ok {
+				continue
+			}
+			v, err := j.generate(r, now, sObj, depth)
+			if err != nil {
+				return nil, err
+			}
+			if vObj, ok := v.(map[string]any); ok {
+				for k, e := range vObj {
+					merged[k] = e
+				}
+			}
+		}
+		return merged, nil
+	}
+
+	var typeName string
+	switch t := schema["type"].(type) {
+	case string:
+		typeName = t
+	case []any:
+		// Prefer a type other than null where one exists.
+		for _, e := range t {
+			if s, _ := e.(string); s != "" && (typeName == "" || typeName == "null") {
+				typeName = s
+			}
+		}
+	case nil:
+		switch {
+		case schema["properties"] != nil:
+			typeName = "object"
+		case schema["items"] != nil:
+			typeName = "array"
+		default:
+			return nil, nil
+		}
+	}
+
+	switch typeName {
+	case "object":
+		obj := map[string]any{}
+		props, _ := schema["properties"].(map[string]any)
+		required := map[string]struct{}{}
+		if req, ok := schema["required"].([]any); ok {
+			for _, k := range req {
+				if s, ok := k.(string); ok {
+					required[s] = struct{}{}
+				}
+			}
+		}
+		keys := make([]string, 0, len(props))
+		for k := range props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if _, isRequired := required[k]; !isRequired && depth >= generateMaxDepth {
+				continue
+			}
+			pSchema, ok := props[k].(map[string]any)
+			if !ok {
+				continue
+			}
+			v, err := j.generate(r, now, pSchema, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("property %v: %w", k, err)
+			}
+			obj[k] = v
+		}
+		return obj, nil
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		n := generateCount(r, depth)
+		if minItems, ok := jsonSchemaNumber(schema, "minItems"); ok && n < int(minItems) {
+			n = int(minItems)
+		}
+		if maxItems, ok := jsonSchemaNumber(schema, "maxItems"); ok && n > int(maxItems) {
+			n = int(maxItems)
+		}
+		arr := make([]any, n)
+		for i := range arr {
+			if items == nil {
+				continue
+			}
+			var err error
+			if arr[i], err = j.generate(r, now, items, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case "string":
+		switch schema["format"] {
+		case "date-time":
+			return now.Format(time.RFC3339), nil
+		case "date":
+			return now.Format("2006-01-02"), nil
+		case "uuid":
+			return generateUUID(r), nil
+		case "email":
+			return generateString(r, 8) + "@example.com", nil
+		}
+		n := 8 + r.Intn(8)
+		if minLen, ok := jsonSchemaNumber(schema, "minLength"); ok && n < int(minLen) {
+			n = int(minLen)
+		}
+		if maxLen, ok := jsonSchemaNumber(schema, "maxLength"); ok && n > int(maxLen) {
+			n = int(maxLen)
+		}
+		return generateString(r, n), nil
+	case "integer", "number":
+		min, max := 0.0, 10000.0
+		if v, ok := jsonSchemaNumber(schema, "minimum"); ok {
+			min = v
+		}
+		if v, ok := jsonSchemaNumber(schema, "maximum"); ok {
+			max = v
+		}
+		if max < min {
+			max = min
+		}
+		if typeName == "integer" {
+			return int64(min) + r.Int63n(int64(max-min)+1), nil
+		}
+		return min + float64(r.Intn(int((max-min)*100)+1))/100, nil
+	case "boolean":
+		return r.Intn(2) == 1, nil
+	case "null":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unrecognised type: %v", typeName)
+}
+
+//------------------------------------------------------------------------------
+
+type protobufGenerator struct {
+	desc protoreflect.MessageDescriptor
+}
+
+func (p *protobufGenerator) Generate(r *rand.Rand, now time.Time) (any, error) {
+	return generateProtobufMessage(r, now, p.desc, 0), nil
+}
+
+func generateProtobufMessage(r *rand.Rand, now time.Time, desc protoreflect.MessageDescriptor, depth int) any {
+	switch desc.FullName() {
+	case "google.protobuf.Timestamp":
+		return now.Format(time.RFC3339Nano)
+	case "google.protobuf.Duration":
+		return strconv.Itoa(r.Intn(3600)) + "s"
+	}
+	if strings.HasPrefix(string(desc.FullName()), "google.protobuf.") {
+		return nil
+	}
+
+	obj := map[string]any{}
+	if depth >= generateMaxDepth {
+		return obj
+	}
+
+	// Only one field of each oneof can be set.
+	skip := map[protoreflect.FullName]struct{}{}
+	oneofs := desc.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		o := oneofs.Get(i)
+		if o.IsSynthetic() {
+			continue
+		}
+		chosen := r.Intn(o.Fields().Len())
+		for j := 0; j < o.Fields().Len(); j++ {
+			if j != chosen {
+				skip[o.Fields().Get(j).FullName()] = struct{}{}
+			}
+		}
+	}
+
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if _, skipped := skip[fd.FullName()]; skipped {
+			continue
+		}
+
+		var v any
+		switch {
+		case fd.IsList():
+			n := generateCount(r, depth)
+			arr := make([]any, 0, n)
+			for j := 0; j < n; j++ {
+				if e := generateProtobufValue(r, now, fd, depth+1); e != nil {
+					arr = append(arr, e)
+				}
+			}
+			v = arr
+		case fd.IsMap():
+			n := generateCount(r, depth)
+			m := make(map[string]any, n)
+			for j := 0; j < n; j++ {
+				if e := generateProtobufValue(r, now, fd.MapValue(), depth+1); e != nil {
+					m[generateProtobufMapKey(fd.MapKey(), j)] = e
+				}
+			}
+			v = m
+		default:
+			v = generateProtobufValue(r, now, fd, depth+1)
+		}
+		if v != nil {
+			obj[fd.JSONName()] = v
+		}
+	}
+	return obj
+}
+
+func generateProtobufMapKey(fd protoreflect.FieldDescriptor, i int) string {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(i%2 == 0)
+	case protoreflect.StringKind:
+		return "key_" + strconv.Itoa(i)
+	}
+	return strconv.Itoa(i)
+}
+
+func generateProtobufValue(r *rand.Rand, now time.Time, fd protoreflect.FieldDescriptor, depth int) any {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return r.Intn(2) == 1
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return int64(r.Int31n(10000))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return r.Int63n(1000000)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return float64(r.Intn(100000)) / 100
+	case protoreflect.StringKind:
+		return generateString(r, 8+r.Intn(8))
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString([]byte(generateString(r, 4+r.Intn(4))))
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		return string(values.Get(r.Intn(values.Len())).Name())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return generateProtobufMessage(r, now, fd.Message(), depth)
+	}
+	return nil
+}
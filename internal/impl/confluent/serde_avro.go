@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/linkedin/goavro/v2"
 
@@ -62,18 +63,31 @@ func (s *schemaRegistryEncoder) getAvroEncoder(ctx context.Context, info SchemaI
 		}
 	}
 
-	return func(m *service.Message) error {
+	var coercer *avroCoercer
+	if s.coerce || s.lenient {
+		if coercer, err = newAvroCoercer(schema, s.avroRawJSON, s.coerce, s.lenient); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(m *service.Message, header []byte) error {
 		b, err := m.AsBytes()
 		if err != nil {
 			return err
 		}
 
+		if coercer != nil {
+			if b, err = coercer.Apply(b); err != nil {
+				return err
+			}
+		}
+
 		datum, _, err := codec.NativeFromTextual(b)
 		if err != nil {
 			return err
 		}
 
-		binary, err := codec.BinaryFromNative(nil, datum)
+		binary, err := codec.BinaryFromNative(header, datum)
 		if err != nil {
 			return err
 		}
@@ -121,3 +135,111 @@ func (s *schemaRegistryDecoder) getAvroDecoder(ctx context.Context, info SchemaI
 
 	return decoder, nil
 }
+
+//------------------------------------------------------------------------------
+
+var avroPrimitives = map[string]struct{}{
+	"null": {}, "boolean": {}, "int": {}, "long": {}, "float": {}, "double": {}, "bytes": {}, "string": {},
+}
+
+// avroSchema is a parsed Avro schema along with an index of the named types
+// that it defines.
+type avroSchema struct {
+	root  any
+	named map[string]map[string]any
+}
+
+func parseAvroSchema(schema string) (*avroSchema, error) {
+	a := &avroSchema{
+		named: map[string]map[string]any{},
+	}
+	if err := json.Unmarshal([]byte(schema), &a.root); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	a.register(a.root, "")
+	return a, nil
+}
+
+func avroFullName(obj map[string]any, namespace string) string {
+	name, _ := obj["name"].(string)
+	if strings.Contains(name, ".") {
+		return name
+	}
+	if ns, ok := obj["namespace"].(string); ok {
+		namespace = ns
+	}
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func avroNamespace(fullName string) string {
+	if i := strings.LastIndex(fullName, "."); i >= 0 {
+		return fullName[:i]
+	}
+	return ""
+}
+
+func (a *avroSchema) register(schema any, namespace string) {
+	switch t := schema.(type) {
+	case []any:
+		for _, s := range t {
+			a.register(s, namespace)
+		}
+	case map[string]any:
+		switch t["type"] {
+		case "record", "error", "enum", "fixed":
+			fullName := avroFullName(t, namespace)
+			t["_fullname"] = fullName
+			a.named[fullName] = t
+			if i := strings.LastIndex(fullName, "."); i >= 0 {
+				if _, exists := a.named[fullName[i+1:]]; !exists {
+					a.named[fullName[i+1:]] = t
+				}
+			}
+			namespace = avroNamespace(fullName)
+		}
+		if fields, ok := t["fields"].([]any); ok {
+			for _, f := range fields {
+				if fObj, ok := f.(map[string]any); ok {
+					a.register(fObj["type"], namespace)
+				}
+			}
+		}
+		if inner, ok := t["type"].(map[string]any); ok {
+			a.register(inner, namespace)
+		}
+		a.register(t["items"], namespace)
+		a.register(t["values"], namespace)
+	}
+}
+
+func (a *avroSchema) resolve(schema any) any {
+	if name, ok := schema.(string); ok {
+		if _, isPrimitive := avroPrimitives[name]; !isPrimitive {
+			if named, exists := a.named[name]; exists {
+				return named
+			}
+		}
+	}
+	return schema
+}
+
+// branchName returns the name of a union branch as it's written in Avro JSON.
+func (a *avroSchema) branchName(schema any) string {
+	switch t := a.resolve(schema).(type) {
+	case string:
+		return t
+	case map[string]any:
+		if fullName, ok := t["_fullname"].(string); ok {
+			return fullName
+		}
+		typeName, _ := t["type"].(string)
+		if logical, ok := t["logicalType"].(string); ok {
+			return typeName + "." + logical
+		}
+		return typeName
+	}
+	return ""
+}
@@ -0,0 +1,450 @@
+package confluent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Coercion is performed on documents that have been parsed with numbers as
+// json.Number values, which allows integers to pass through without losing
+// precision.
+func parseJSONWithNumbers(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func coerceInt(v any) (json.Number, error) {
+	switch t := v.(type) {
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return t, nil
+		}
+		f, err := t.Float64()
+		if err != nil || f != math.Trunc(f) {
+			return "", fmt.Errorf("expected integer, got %v", t)
+		}
+		return json.Number(strconv.FormatInt(int64(f), 10)), nil
+	case string:
+		s := strings.TrimSpace(t)
+		if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return json.Number(s), nil
+		}
+		return coerceInt(json.Number(s))
+	case bool:
+		if t {
+			return "1", nil
+		}
+		return "0", nil
+	}
+	return "", fmt.Errorf("expected integer, got %T", v)
+}
+
+func coerceFloat(v any) (json.Number, error) {
+	switch t := v.(type) {
+	case json.Number:
+		return t, nil
+	case string:
+		s := strings.TrimSpace(t)
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return "", fmt.Errorf("expected number, got %q", t)
+		}
+		return json.Number(s), nil
+	}
+	return "", fmt.Errorf("expected number, got %T", v)
+}
+
+func coerceBool(v any) (bool, error) {
+	switch t := v.(type) {
+	case bool:
+		return t, nil
+	case string:
+		return strconv.ParseBool(strings.TrimSpace(t))
+	case json.Number:
+		return strconv.ParseBool(t.String())
+	}
+	return false, fmt.Errorf("expected boolean, got %T", v)
+}
+
+func coerceString(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case json.Number:
+		return t.String(), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	}
+	return "", fmt.Errorf("expected string, got %T", v)
+}
+
+// coerceTime parses a timestamp from either an RFC 3339 string or a number of
+// seconds since the unix epoch.
+func coerceTime(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case string:
+		if ts, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(t)); err == nil {
+			return ts, nil
+		}
+		return coerceTime(json.Number(strings.TrimSpace(t)))
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("expected timestamp, got %q", t)
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("expected timestamp, got %T", v)
+}
+
+//------------------------------------------------------------------------------
+
+// avroCoercer walks documents against an Avro schema, coercing the types of
+// values and dropping fields that are not defined by the schema.
+type avroCoercer struct {
+	*avroSchema
+	rawJSON bool
+	coerce  bool
+	lenient bool
+}
+
+func newAvroCoercer(schema string, rawJSON, coerce, lenient bool) (*avroCoercer, error) {
+	parsed, err := parseAvroSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &avroCoercer{
+		avroSchema: parsed,
+		rawJSON:    rawJSON,
+		coerce:     coerce,
+		lenient:    lenient,
+	}, nil
+}
+
+// Apply parses a JSON document, walks it against the schema and returns the
+// serialised result, or an error if the document cannot match the schema.
+func (a *avroCoercer) Apply(b []byte) ([]byte, error) {
+	v, err := parseJSONWithNumbers(b)
+	if err != nil {
+		return nil, err
+	}
+	if v, err = a.walk(a.root, v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func (a *avroCoercer) walk(schema any, v any) (any, error) {
+	switch t := a.resolve(schema).(type) {
+	case string:
+		return a.walkPrimitive(t, "", v)
+	case []any:
+		return a.walkUnion(t, v)
+	case map[string]any:
+		switch typeName := t["type"].(type) {
+		case string:
+			switch typeName {
+			case "record", "error":
+				return a.walkRecord(t, v)
+			case "array":
+				arr, ok := v.([]any)
+				if !ok {
+					return nil, fmt.Errorf("expected array, got %T", v)
+				}
+				for i, e := range arr {
+					var err error
+					if arr[i], err = a.walk(t["items"], e); err != nil {
+						return nil, fmt.Errorf("index %v: %w", i, err)
+					}
+				}
+				return arr, nil
+			case "map":
+				obj, ok := v.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("expected map, got %T", v)
+				}
+				for k, e := range obj {
+					var err error
+					if obj[k], err = a.walk(t["values"], e); err != nil {
+						return nil, fmt.Errorf("key %v: %w", k, err)
+					}
+				}
+				return obj, nil
+			case "enum", "fixed":
+				return a.walkPrimitive("string", "", v)
+			}
+			logical, _ := t["logicalType"].(string)
+			return a.walkPrimitive(typeName, logical, v)
+		default:
+			return a.walk(typeName, v)
+		}
+	}
+	return v, nil
+}
+
+func (a *avroCoercer) walkRecord(schema map[string]any, v any) (any, error) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected record, got %T", v)
+	}
+
+	known := map[string]struct{}{}
+	fields, _ := schema["fields"].([]any)
+	for _, f := range fields {
+		fObj, ok := f.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := fObj["name"].(string)
+		known[name] = struct{}{}
+
+		fv, exists := obj[name]
+		if !exists {
+			continue
+		}
+		var err error
+		if obj[name], err = a.walk(fObj["type"], fv); err != nil {
+			return nil, fmt.Errorf("field %v: %w", name, err)
+		}
+	}
+
+	for k := range obj {
+		if _, exists := known[k]; exists {
+			continue
+		}
+		if !a.lenient {
+			return nil, fmt.Errorf("field %v is not defined by the schema", k)
+		}
+		delete(obj, k)
+	}
+	return obj, nil
+}
+
+func (a *avroCoercer) walkUnion(branches []any, v any) (any, error) {
+	if v == nil {
+		for _, b := range branches {
+			if b == "null" {
+				return nil, nil
+			}
+		}
+		return nil, errors.New("value is null but the union does not contain null")
+	}
+
+	if !a.rawJSON {
+		if obj, ok := v.(map[string]any); ok && len(obj) == 1 {
+			for k, inner := range obj {
+				for _, b := range branches {
+					if a.branchName(b) == k {
+						res, err := a.walk(b, inner)
+						if err != nil {
+							return nil, err
+						}
+						obj[k] = res
+						return obj, nil
+					}
+				}
+			}
+		}
+		if !a.coerce {
+			return nil, errors.New("value does not match any branch of the union")
+		}
+	}
+
+	// Find the first branch that the value can be coerced into, where values
+	// of Avro JSON are wrapped within an object of the branch name.
+	var errs []string
+	for _, b := range branches {
+		if b == "null" {
+			continue
+		}
+		res, err := a.walk(b, copyJSON(v))
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if !a.rawJSON {
+			return map[string]any{a.branchName(b): res}, nil
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("value does not match any branch of the union: %v", strings.Join(errs, ", "))
+}
+
+func copyJSON(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, e := range t {
+			m[k] = copyJSON(e)
+		}
+		return m
+	case []any:
+		s := make([]any, len(t))
+		for i, e := range t {
+			s[i] = copyJSON(e)
+		}
+		return s
+	}
+	return v
+}
+
+func (a *avroCoercer) walkPrimitive(typeName, logicalType string, v any) (any, error) {
+	if !a.coerce {
+		if !avroPrimitiveMatches(typeName, v) {
+			return nil, fmt.Errorf("expected %v, got %T", typeName, v)
+		}
+		return v, nil
+	}
+
+	switch typeName {
+	case "null":
+		if v != nil {
+			return nil, fmt.Errorf("expected null, got %T", v)
+		}
+		return nil, nil
+	case "boolean":
+		return coerceBool(v)
+	case "int", "long":
+		switch logicalType {
+		case "timestamp-millis", "timestamp-micros", "local-timestamp-millis", "local-timestamp-micros":
+			if s, ok := v.(string); ok {
+				if ts, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(s)); err == nil {
+					if strings.HasSuffix(logicalType, "micros") {
+						return json.Number(strconv.FormatInt(ts.UnixMicro(), 10)), nil
+					}
+					return json.Number(strconv.FormatInt(ts.UnixMilli(), 10)), nil
+				}
+			}
+		case "date":
+			if s, ok := v.(string); ok {
+				if ts, err := time.Parse("2006-01-02", strings.TrimSpace(s)); err == nil {
+					return json.Number(strconv.FormatInt(ts.Unix()/86400, 10)), nil
+				}
+			}
+		}
+		return coerceInt(v)
+	case "float", "double":
+		return coerceFloat(v)
+	case "string", "bytes":
+		return coerceString(v)
+	}
+	return v, nil
+}
+
+func avroPrimitiveMatches(typeName string, v any) bool {
+	switch typeName {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "int", "long", "float", "double":
+		_, ok := v.(json.Number)
+		return ok
+	case "string", "bytes":
+		_, ok := v.(string)
+		return ok
+	}
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+// coerceProtobufJSON walks a JSON document against a protobuf message
+// descriptor and coerces the types of values to match their fields.
+func coerceProtobufJSON(data []byte, desc protoreflect.MessageDescriptor) ([]byte, error) {
+	v, err := parseJSONWithNumbers(data)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return data, nil
+	}
+	if err := coerceProtobufMessage(obj, desc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(obj)
+}
+
+func coerceProtobufMessage(obj map[string]any, desc protoreflect.MessageDescriptor) error {
+	fields := desc.Fields()
+	for k, v := range obj {
+		fd := fields.ByJSONName(k)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(k))
+		}
+		if fd == nil || v == nil {
+			continue
+		}
+
+		var err error
+		switch {
+		case fd.IsList():
+			if arr, ok := v.([]any); ok {
+				for i, e := range arr {
+					if arr[i], err = coerceProtobufValue(fd, e); err != nil {
+						return fmt.Errorf("field %v: index %v: %w", k, i, err)
+					}
+				}
+			}
+		case fd.IsMap():
+			if m, ok := v.(map[string]any); ok {
+				for mk, e := range m {
+					if m[mk], err = coerceProtobufValue(fd.MapValue(), e); err != nil {
+						return fmt.Errorf("field %v: key %v: %w", k, mk, err)
+					}
+				}
+			}
+		default:
+			if obj[k], err = coerceProtobufValue(fd, v); err != nil {
+				return fmt.Errorf("field %v: %w", k, err)
+			}
+		}
+	}
+	return nil
+}
+
+func coerceProtobufValue(fd protoreflect.FieldDescriptor, v any) (any, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return coerceBool(v)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return coerceInt(v)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return coerceFloat(v)
+	case protoreflect.StringKind:
+		return coerceString(v)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		msgDesc := fd.Message()
+		if msgDesc.FullName() == "google.protobuf.Timestamp" {
+			ts, err := coerceTime(v)
+			if err != nil {
+				return nil, err
+			}
+			return ts.Format(time.RFC3339Nano), nil
+		}
+		if obj, ok := v.(map[string]any); ok && !strings.HasPrefix(string(msgDesc.FullName()), "google.protobuf.") {
+			if err := coerceProtobufMessage(obj, msgDesc); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
@@ -3,7 +3,9 @@ package confluent
 import (
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 
 	"google.golang.org/protobuf/encoding/protojson"
@@ -101,8 +103,10 @@ func (s *schemaRegistryEncoder) getProtobufEncoder(ctx context.Context, info Sch
 		return nil, err
 	}
 	msgTypesCache := newCachedMessageTypes(targetFile.Messages(), types)
+	msgTypesCache.coerce = s.coerce
+	msgTypesCache.lenient = s.lenient
 
-	return func(m *service.Message) error {
+	return func(m *service.Message, header []byte) error {
 		b, err := m.AsBytes()
 		if err != nil {
 			return err
@@ -113,12 +117,12 @@ func (s *schemaRegistryEncoder) getProtobufEncoder(ctx context.Context, info Sch
 			return err
 		}
 
-		data, err := proto.Marshal(dynMsg)
+		data, err := proto.MarshalOptions{}.MarshalAppend(append(header, indexBytes...), dynMsg)
 		if err != nil {
 			return fmt.Errorf("failed to marshal protobuf message: %w", err)
 		}
 
-		m.SetBytes(append(indexBytes, data...)) // TODO: Only allocate once by passing id through
+		m.SetBytes(data)
 		return nil
 	}, nil
 }
@@ -160,6 +164,11 @@ type cachedMessageTypes struct {
 	msgTypeMap    map[string]protoreflect.MessageDescriptor
 	allTypes      *protoregistry.Types
 
+	// Whether to coerce values into the types of fields, and whether to
+	// discard fields not defined by a message rather than fail.
+	coerce  bool
+	lenient bool
+
 	lastSuccessful string
 	cacheMut       sync.Mutex
 }
@@ -190,7 +199,7 @@ func newCachedMessageTypes(rootMsgs protoreflect.MessageDescriptors, allTypes *p
 
 func (c *cachedMessageTypes) TryParseMsg(data []byte) (*dynamicpb.Message, []byte, error) {
 	if c.singleMsgType != nil {
-		d, err := c.tryDesc(data, c.singleMsgType)
+		d, err := c.tryDesc(data, c.singleMsgType, c.lenient)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -203,7 +212,7 @@ func (c *cachedMessageTypes) TryParseMsg(data []byte) (*dynamicpb.Message, []byt
 
 	if len(lastSuccessful) > 0 {
 		if msgDesc, ok := c.msgTypeMap[lastSuccessful]; ok {
-			if dynMsg, err := c.tryDesc(data, msgDesc); err == nil {
+			if dynMsg, err := c.tryDesc(data, msgDesc, false); err == nil {
 				// Happy path: We had a cached message index that worked with a
 				// previous encode attempt and it worked again, so no need to
 				// perform any random checks.
@@ -212,14 +221,57 @@ func (c *cachedMessageTypes) TryParseMsg(data []byte) (*dynamicpb.Message, []byt
 		}
 	}
 
+	keys := make([]string, 0, len(c.msgTypeMap))
+	for k := range c.msgTypeMap {
+		keys = append(keys, k)
+	}
+
+	dynMsg, k, err := c.tryAll(data, keys, false)
+	if err != nil && c.lenient {
+		// Only discard unknown fields once no message matches all fields,
+		// otherwise any message would match, and prefer the messages that the
+		// fewest fields would be discarded from.
+		unknown := unknownFieldCounts(data, c.msgTypeMap)
+		sort.SliceStable(keys, func(i, j int) bool {
+			if unknown[keys[i]] == unknown[keys[j]] {
+				return keys[i] < keys[j]
+			}
+			return unknown[keys[i]] < unknown[keys[j]]
+		})
+		dynMsg, k, err = c.tryAll(data, keys, true)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return dynMsg, []byte(k), nil
+}
+
+func unknownFieldCounts(data []byte, msgTypes map[string]protoreflect.MessageDescriptor) map[string]int {
+	var obj map[string]json.RawMessage
+	_ = json.Unmarshal(data, &obj)
+
+	counts := make(map[string]int, len(msgTypes))
+	for k, desc := range msgTypes {
+		fields := desc.Fields()
+		for name := range obj {
+			if fields.ByJSONName(name) == nil && fields.ByName(protoreflect.Name(name)) == nil {
+				counts[k]++
+			}
+		}
+	}
+	return counts
+}
+
+func (c *cachedMessageTypes) tryAll(data []byte, keys []string, discardUnknown bool) (*dynamicpb.Message, string, error) {
 	var errs error
-	for k, msgDesc := range c.msgTypeMap {
-		dynMsg, err := c.tryDesc(data, msgDesc)
+	for _, k := range keys {
+		msgDesc := c.msgTypeMap[k]
+		dynMsg, err := c.tryDesc(data, msgDesc, discardUnknown)
 		if err == nil {
 			c.cacheMut.Lock()
 			c.lastSuccessful = k
 			c.cacheMut.Unlock()
-			return dynMsg, []byte(k), nil
+			return dynMsg, k, nil
 		}
 		if errs != nil {
 			errs = fmt.Errorf("%v, %v", errs, err)
@@ -227,13 +279,21 @@ func (c *cachedMessageTypes) TryParseMsg(data []byte) (*dynamicpb.Message, []byt
 			errs = err
 		}
 	}
-	return nil, nil, errs
+	return nil, "", errs
 }
 
-func (c *cachedMessageTypes) tryDesc(data []byte, desc protoreflect.MessageDescriptor) (*dynamicpb.Message, error) {
+func (c *cachedMessageTypes) tryDesc(data []byte, desc protoreflect.MessageDescriptor, discardUnknown bool) (*dynamicpb.Message, error) {
+	if c.coerce {
+		var err error
+		if data, err = coerceProtobufJSON(data, desc); err != nil {
+			return nil, fmt.Errorf("coerce '%v': %w", desc.Name(), err)
+		}
+	}
+
 	dynMsg := dynamicpb.NewMessage(desc)
 	opts := protojson.UnmarshalOptions{
-		Resolver: c.allTypes,
+		Resolver:       c.allTypes,
+		DiscardUnknown: discardUnknown,
 	}
 	if err := opts.Unmarshal(data, dynMsg); err != nil {
 		return nil, fmt.Errorf("unmarshal '%v': %w", desc.Name(), err)
@@ -376,3 +376,114 @@ message bar {
 		})
 	})
 }
+
+func TestProtobufEncodeCoerce(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	thingsSchema := `
+syntax = "proto3";
+package things;
+
+import "google/protobuf/timestamp.proto";
+
+message foo {
+  int32 a = 1;
+  bool b = 2;
+  string c = 3;
+  repeated double d = 4;
+  bar e = 5;
+  google.protobuf.Timestamp f = 6;
+}
+
+message bar {
+  int32 g = 1;
+}
+`
+
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/subjects/things/versions/latest", "/schemas/ids/1":
+			return mustJBytes(t, map[string]any{
+				"id":         1,
+				"version":    10,
+				"schema":     thingsSchema,
+				"schemaType": "PROTOBUF",
+			}), nil
+		}
+		return nil, nil
+	})
+
+	subj, err := service.NewInterpolatedString("things")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		lenient     bool
+		input       string
+		output      string
+		errContains string
+	}{
+		{
+			name:   "coerce scalars",
+			input:  `{"a":"123","b":"true","c":45,"d":["1.5",2],"e":{"g":"7"},"f":1700000000}`,
+			output: `{"a":123,"b":true,"c":"45","d":[1.5,2],"e":{"g":7},"f":"2023-11-14T22:13:20Z"}`,
+		},
+		{
+			name:        "not coercible",
+			input:       `{"a":"nope"}`,
+			errContains: "field a: expected integer",
+		},
+		{
+			name:        "unknown field strict",
+			input:       `{"a":1,"z":"what"}`,
+			errContains: "unknown field \"z\"",
+		},
+		{
+			name:    "unknown field lenient",
+			lenient: true,
+			input:   `{"a":"1","z":"what"}`,
+			output:  `{"a":1}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, false, time.Minute*10, time.Minute, service.MockResources())
+			require.NoError(t, err)
+			encoder.coerce = true
+			encoder.lenient = test.lenient
+
+			decoder, err := newSchemaRegistryDecoder(urlStr, noopReqSign, nil, false, service.MockResources())
+			require.NoError(t, err)
+
+			t.Cleanup(func() {
+				_ = encoder.Close(tCtx)
+				_ = decoder.Close(tCtx)
+			})
+
+			encodedMsgs, err := encoder.ProcessBatch(tCtx, service.MessageBatch{service.NewMessage([]byte(test.input))})
+			require.NoError(t, err)
+			require.Len(t, encodedMsgs, 1)
+			require.Len(t, encodedMsgs[0], 1)
+
+			encodedMsg := encodedMsgs[0][0]
+			if test.errContains != "" {
+				require.Error(t, encodedMsg.GetError())
+				assert.Contains(t, encodedMsg.GetError().Error(), test.errContains)
+				return
+			}
+			require.NoError(t, encodedMsg.GetError())
+
+			decodedMsgs, err := decoder.Process(tCtx, encodedMsg)
+			require.NoError(t, err)
+			require.Len(t, decodedMsgs, 1)
+			require.NoError(t, decodedMsgs[0].GetError())
+
+			b, err := decodedMsgs[0].AsBytes()
+			require.NoError(t, err)
+			assert.JSONEq(t, test.output, string(b))
+		})
+	}
+}
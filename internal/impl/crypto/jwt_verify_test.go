@@ -0,0 +1,183 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestBloblangParseJWTUnverified(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "foo",
+	}).SignedString([]byte("whatever"))
+	require.NoError(t, err)
+
+	exe, err := bloblang.Parse(`root = this.parse_jwt()`)
+	require.NoError(t, err)
+
+	res, err := exe.Query(token)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"sub": "foo"}, res)
+
+	_, err = exe.Query("not a token")
+	require.Error(t, err)
+}
+
+func TestBloblangVerifyJWTKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, rsaPub := testPEMKeyPair(t, rsaKey, &rsaKey.PublicKey)
+
+	secret := []byte("dont-tell-anyone")
+	claims := jwt.MapClaims{"sub": "foo"}
+
+	hsToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	require.NoError(t, err)
+
+	rsToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(rsaKey)
+	require.NoError(t, err)
+
+	expiredToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "foo",
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	}).SignedString(secret)
+	require.NoError(t, err)
+
+	// A token signed with the public key as an HMAC secret must not be
+	// accepted when a PEM public key is configured.
+	confusedToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(rsaPub))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		mapping     string
+		token       string
+		errContains string
+	}{
+		{name: "hmac", mapping: fmt.Sprintf(`root = this.verify_jwt(key: %q)`, secret), token: hsToken},
+		{name: "rsa", mapping: fmt.Sprintf(`root = this.verify_jwt(key: %q)`, rsaPub), token: rsToken},
+		{name: "rsa restricted", mapping: fmt.Sprintf(`root = this.verify_jwt(key: %q, algorithms: ["RS256"])`, rsaPub), token: rsToken},
+		{name: "wrong algorithm", mapping: fmt.Sprintf(`root = this.verify_jwt(key: %q, algorithms: ["PS256"])`, rsaPub), token: rsToken, errContains: "signing method RS256 is invalid"},
+		{name: "wrong secret", mapping: `root = this.verify_jwt(key: "nope")`, token: hsToken, errContains: "signature is invalid"},
+		{name: "expired", mapping: fmt.Sprintf(`root = this.verify_jwt(key: %q)`, secret), token: expiredToken, errContains: "expired"},
+		{name: "algorithm confusion", mapping: fmt.Sprintf(`root = this.verify_jwt(key: %q)`, rsaPub), token: confusedToken, errContains: "signing method HS256 is invalid"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			exe, err := bloblang.Parse(test.mapping)
+			require.NoError(t, err)
+
+			res, err := exe.Query(test.token)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, map[string]any{"sub": "foo"}, res)
+		})
+	}
+}
+
+func TestBloblangVerifyJWTBadParams(t *testing.T) {
+	for _, mapping := range []string{
+		`root = this.verify_jwt()`,
+		`root = this.verify_jwt(key: "foo", jwks_url: "http://localhost")`,
+		`root = this.verify_jwt(key: "foo", algorithms: ["RS256"])`,
+		`root = this.verify_jwt(key: "foo", cache_ttl: "nope")`,
+		`root = this.verify_jwt(key: "-----BEGIN PUBLIC KEY-----\nnope\n-----END PUBLIC KEY-----")`,
+	} {
+		_, err := bloblang.Parse(mapping)
+		assert.Error(t, err, mapping)
+	}
+}
+
+func TestBloblangVerifyJWTJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	keys := []map[string]any{
+		{
+			"kty": "RSA", "kid": "rsa1", "alg": "RS256", "use": "sig",
+			"n": b64(rsaKey.N.Bytes()),
+			"e": b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		},
+		{
+			"kty": "EC", "kid": "ec1", "crv": "P-256",
+			"x": b64(ecKey.X.Bytes()),
+			"y": b64(ecKey.Y.Bytes()),
+		},
+	}
+
+	var fetches int32
+	var rotated int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		set := keys[:1]
+		if atomic.LoadInt32(&rotated) == 1 {
+			set = keys
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": set})
+	}))
+	defer srv.Close()
+
+	defer func(prev time.Duration) {
+		jwksRefreshInterval = prev
+	}(jwksRefreshInterval)
+	jwksRefreshInterval = 0
+
+	newToken := func(method jwt.SigningMethod, kid string, key any) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{"sub": "foo"})
+		token.Header["kid"] = kid
+		s, err := token.SignedString(key)
+		require.NoError(t, err)
+		return s
+	}
+
+	exe, err := bloblang.Parse(fmt.Sprintf(`root = this.verify_jwt(jwks_url: %q)`, srv.URL))
+	require.NoError(t, err)
+
+	res, err := exe.Query(newToken(jwt.SigningMethodRS256, "rsa1", rsaKey))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"sub": "foo"}, res)
+
+	res, err = exe.Query(newToken(jwt.SigningMethodRS256, "rsa1", rsaKey))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"sub": "foo"}, res)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	// The key has an explicit algorithm that must match.
+	_, err = exe.Query(newToken(jwt.SigningMethodRS512, "rsa1", rsaKey))
+	require.Error(t, err)
+
+	// Unknown key IDs trigger a refresh of the key set.
+	atomic.StoreInt32(&rotated, 1)
+	res, err = exe.Query(newToken(jwt.SigningMethodES256, "ec1", ecKey))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"sub": "foo"}, res)
+
+	_, err = exe.Query(newToken(jwt.SigningMethodES256, "nope", ecKey))
+	require.Error(t, err)
+}
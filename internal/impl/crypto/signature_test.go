@@ -0,0 +1,125 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func testPEMKeyPair(t *testing.T, priv any, pub any) (privPEM, pubPEM string) {
+	t.Helper()
+
+	privBytes, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+
+	privPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privBytes}))
+	pubPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}))
+	return
+}
+
+func TestBloblangHMAC(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		expected  string
+	}{
+		{algorithm: "md5", expected: "80070713463e7749b90c2dc24911e275"},
+		{algorithm: "sha1", expected: "de7c9b85b8b78aa6bc8a7a36f70a90701c9db4d9"},
+		{algorithm: "sha256", expected: "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.algorithm, func(t *testing.T) {
+			exe, err := bloblang.Parse(fmt.Sprintf(`root = this.hmac(%q, "key").encode("hex")`, test.algorithm))
+			require.NoError(t, err)
+
+			res, err := exe.Query([]byte("The quick brown fox jumps over the lazy dog"))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+
+	_, err := bloblang.Parse(`root = content().hmac("nope", "key")`)
+	require.Error(t, err)
+}
+
+func TestBloblangSignVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaPriv, rsaPub := testPEMKeyPair(t, rsaKey, &rsaKey.PublicKey)
+	ecPriv, ecPub := testPEMKeyPair(t, ecKey, &ecKey.PublicKey)
+	edPrivPEM, edPubPEM := testPEMKeyPair(t, edPriv, edPub)
+
+	tests := []struct {
+		algorithm string
+		priv, pub string
+	}{
+		{algorithm: "RS256", priv: rsaPriv, pub: rsaPub},
+		{algorithm: "RS512", priv: rsaPriv, pub: rsaPub},
+		{algorithm: "PS256", priv: rsaPriv, pub: rsaPub},
+		{algorithm: "ES256", priv: ecPriv, pub: ecPub},
+		{algorithm: "ES384", priv: ecPriv, pub: ecPub},
+		{algorithm: "EdDSA", priv: edPrivPEM, pub: edPubPEM},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.algorithm, func(t *testing.T) {
+			signExe, err := bloblang.Parse(fmt.Sprintf(`root = this.sign(%q, %q)`, test.algorithm, test.priv))
+			require.NoError(t, err)
+
+			sig, err := signExe.Query([]byte("hello world"))
+			require.NoError(t, err)
+
+			verifyExe, err := bloblang.Parse(fmt.Sprintf(`root = this.message.verify(%q, %q, this.signature)`, test.algorithm, test.pub))
+			require.NoError(t, err)
+
+			res, err := verifyExe.Query(map[string]any{
+				"message":   "hello world",
+				"signature": sig,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, true, res)
+
+			res, err = verifyExe.Query(map[string]any{
+				"message":   "hello world!",
+				"signature": sig,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, false, res)
+		})
+	}
+
+	_, err = bloblang.Parse(fmt.Sprintf(`root = content().sign("ES256", %q)`, rsaPriv))
+	require.Error(t, err)
+
+	_, err = bloblang.Parse(fmt.Sprintf(`root = content().verify("RS256", %q, "")`, edPubPEM))
+	require.Error(t, err)
+
+	_, err = bloblang.Parse(`root = content().sign("RS256", "not a key")`)
+	require.Error(t, err)
+
+	_, err = bloblang.Parse(fmt.Sprintf(`root = content().sign("HS256", %q)`, rsaPriv))
+	require.Error(t, err)
+}
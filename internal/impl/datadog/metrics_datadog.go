@@ -0,0 +1,439 @@
+package datadog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	ddmFieldAPIKey      = "api_key"
+	ddmFieldSite        = "site"
+	ddmFieldHost        = "host"
+	ddmFieldTags        = "tags"
+	ddmFieldFlushPeriod = "flush_period"
+	ddmFieldProxyURL    = "proxy_url"
+	ddmFieldTimeout     = "timeout"
+
+	// The maximum number of series sent within a single request.
+	ddMaxSeriesPerRequest = 500
+)
+
+func ddMetricsSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.20.0").
+		Summary(`Send metrics directly to the [Datadog API](https://docs.datadoghq.com/api/latest/metrics/) without an agent.`).
+		Description(`
+Counters are sent as count metrics of the increments made during each flush period, and gauges are sent with their most recent value at the end of every flush period. Timing metrics are sent in nanoseconds as [distributions](https://docs.datadoghq.com/metrics/distributions/), where every value is sent to Datadog and aggregated globally, which allows for accurate percentiles across all hosts rather than the per agent approximations of DogStatsD histograms.
+
+All metrics are sent with the host they originate from, which can be overridden with the field `+"`host`"+`, and the labels of each metric are added as tags along with any tags specified in the field `+"`tags`"+`.
+
+### Costs
+
+Datadog bills by the number of custom metrics, where each combination of metric name and tag values counts as a metric, and distributions count as multiple metrics. It is therefore recommended that you reduce the metrics that are exposed with a `+"`mapping`"+` like this:
+
+`+"```yaml"+`
+metrics:
+  mapping: |
+    if ![
+      "input_received",
+      "input_latency_ns",
+      "output_sent",
+    ].contains(this) { deleted() }
+  datadog:
+    api_key: ${DD_API_KEY}
+`+"```"+``).
+		Fields(
+			service.NewStringField(ddmFieldAPIKey).
+				Description("A Datadog API key.").
+				Secret(),
+			service.NewStringField(ddmFieldSite).
+				Description("The [Datadog site](https://docs.datadoghq.com/getting_started/site/) to send metrics to.").
+				Examples("datadoghq.eu", "us3.datadoghq.com").
+				Default("datadoghq.com"),
+			service.NewStringField(ddmFieldHost).
+				Description("The host to send metrics from. Defaults to the hostname when empty.").
+				Default(""),
+			service.NewStringListField(ddmFieldTags).
+				Description("A list of tags to add to all metrics.").
+				Example([]string{"env:prod", "service:benthos"}).
+				Default([]string{}),
+			service.NewDurationField(ddmFieldFlushPeriod).
+				Description("The period of time between each request sending the metrics aggregated since the previous one.").
+				Default("10s").
+				Advanced(),
+			service.NewURLField(ddmFieldProxyURL).
+				Description("An optional HTTP proxy URL.").
+				Optional().
+				Advanced(),
+			service.NewDurationField(ddmFieldTimeout).
+				Description("The maximum period of time to wait for each request to the API.").
+				Default("30s").
+				Advanced(),
+		)
+}
+
+func init() {
+	err := service.RegisterMetricsExporter("datadog", ddMetricsSpec(),
+		func(conf *service.ParsedConfig, log *service.Logger) (service.MetricsExporter, error) {
+			return newDDMetricsFromParsed(conf, log)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newDDMetricsFromParsed(conf *service.ParsedConfig, log *service.Logger) (*ddMetrics, error) {
+	apiKey, err := conf.FieldString(ddmFieldAPIKey)
+	if err != nil {
+		return nil, err
+	}
+
+	site, err := conf.FieldString(ddmFieldSite)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := conf.FieldString(ddmFieldHost)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" {
+		if host, err = os.Hostname(); err != nil {
+			return nil, fmt.Errorf("failed to obtain hostname: %w", err)
+		}
+	}
+
+	tags, err := conf.FieldStringList(ddmFieldTags)
+	if err != nil {
+		return nil, err
+	}
+
+	flushPeriod, err := conf.FieldDuration(ddmFieldFlushPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.FieldDuration(ddmFieldTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if conf.Contains(ddmFieldProxyURL) {
+		proxyStr, err := conf.FieldString(ddmFieldProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		proxyURL, err := url.Parse(proxyStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy_url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	baseURL := "https://api." + strings.TrimPrefix(site, "api.")
+	client := &http.Client{Timeout: timeout, Transport: transport}
+	return newDDMetrics(baseURL, apiKey, host, tags, flushPeriod, client, log), nil
+}
+
+//------------------------------------------------------------------------------
+
+type ddMetricKind int
+
+const (
+	ddMetricCount ddMetricKind = iota
+	ddMetricDistribution
+	ddMetricGauge
+)
+
+// ddSeries aggregates the values of a metric with a given set of tags over a
+// flush period.
+type ddSeries struct {
+	root *ddMetrics
+	kind ddMetricKind
+	name string
+	tags []string
+
+	touched bool
+	value   int64
+	values  []float64
+}
+
+// Incr increments a counter by an amount.
+func (s *ddSeries) Incr(count int64) {
+	s.root.mut.Lock()
+	s.touched = true
+	s.value += count
+	s.root.mut.Unlock()
+}
+
+// Timing adds a timing value in nanoseconds.
+func (s *ddSeries) Timing(delta int64) {
+	s.root.mut.Lock()
+	s.touched = true
+	s.values = append(s.values, float64(delta))
+	s.root.mut.Unlock()
+}
+
+// Set sets the value of a gauge.
+func (s *ddSeries) Set(value int64) {
+	s.root.mut.Lock()
+	s.touched = true
+	s.value = value
+	s.root.mut.Unlock()
+}
+
+//------------------------------------------------------------------------------
+
+type ddPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+type ddResource struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type ddMetricSeries struct {
+	Metric    string       `json:"metric"`
+	Type      int          `json:"type"`
+	Interval  int64        `json:"interval,omitempty"`
+	Points    []ddPoint    `json:"points"`
+	Tags      []string     `json:"tags,omitempty"`
+	Resources []ddResource `json:"resources"`
+}
+
+type ddDistributionSeries struct {
+	Metric string   `json:"metric"`
+	Points [][2]any `json:"points"`
+	Tags   []string `json:"tags,omitempty"`
+	Host   string   `json:"host"`
+}
+
+type ddMetrics struct {
+	baseURL string
+	apiKey  string
+	host    string
+	tags    []string
+	client  *http.Client
+
+	mut    sync.Mutex
+	series map[string]*ddSeries
+
+	flushPeriod time.Duration
+	ctx         context.Context
+	cancel      func()
+	loopDone    chan struct{}
+
+	log *service.Logger
+}
+
+func newDDMetrics(baseURL, apiKey, host string, tags []string, flushPeriod time.Duration, client *http.Client, log *service.Logger) *ddMetrics {
+	d := &ddMetrics{
+		baseURL:     baseURL,
+		apiKey:      apiKey,
+		host:        host,
+		tags:        tags,
+		client:      client,
+		series:      map[string]*ddSeries{},
+		flushPeriod: flushPeriod,
+		loopDone:    make(chan struct{}),
+		log:         log,
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	go d.loop()
+	return d
+}
+
+// getSeries returns the series of a metric with a given set of label values,
+// creating it if it does not yet exist.
+func (d *ddMetrics) getSeries(kind ddMetricKind, name string, labelKeys, labelValues []string) *ddSeries {
+	id := name + "\x00" + strings.Join(labelValues, "\x00")
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	if s, exists := d.series[id]; exists {
+		return s
+	}
+
+	tags := make([]string, 0, len(d.tags)+len(labelKeys))
+	tags = append(tags, d.tags...)
+	for i, k := range labelKeys {
+		if i < len(labelValues) && labelValues[i] != "" {
+			tags = append(tags, k+":"+labelValues[i])
+		}
+	}
+	s := &ddSeries{
+		root: d,
+		kind: kind,
+		name: name,
+		tags: tags,
+	}
+	d.series[id] = s
+	return s
+}
+
+func (d *ddMetrics) NewCounterCtor(name string, labelKeys ...string) service.MetricsExporterCounterCtor {
+	return func(labelValues ...string) service.MetricsExporterCounter {
+		return d.getSeries(ddMetricCount, name, labelKeys, labelValues)
+	}
+}
+
+func (d *ddMetrics) NewTimerCtor(name string, labelKeys ...string) service.MetricsExporterTimerCtor {
+	return func(labelValues ...string) service.MetricsExporterTimer {
+		return d.getSeries(ddMetricDistribution, name, labelKeys, labelValues)
+	}
+}
+
+func (d *ddMetrics) NewGaugeCtor(name string, labelKeys ...string) service.MetricsExporterGaugeCtor {
+	return func(labelValues ...string) service.MetricsExporterGauge {
+		return d.getSeries(ddMetricGauge, name, labelKeys, labelValues)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (d *ddMetrics) loop() {
+	defer close(d.loopDone)
+
+	ticker := time.NewTicker(d.flushPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.flush(d.ctx); err != nil && d.ctx.Err() == nil {
+				d.log.Errorf("Failed to send metrics to Datadog: %v", err)
+			}
+		}
+	}
+}
+
+// drain returns the series and distributions aggregated since the previous
+// flush, and resets counters and distributions.
+func (d *ddMetrics) drain(now time.Time) (series []ddMetricSeries, dists []ddDistributionSeries) {
+	ts := now.Unix()
+	resources := []ddResource{{Name: d.host, Type: "host"}}
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	for _, s := range d.series {
+		if !s.touched {
+			continue
+		}
+		switch s.kind {
+		case ddMetricCount:
+			series = append(series, ddMetricSeries{
+				Metric:    s.name,
+				Type:      1,
+				Interval:  int64(d.flushPeriod / time.Second),
+				Points:    []ddPoint{{Timestamp: ts, Value: float64(s.value)}},
+				Tags:      s.tags,
+				Resources: resources,
+			})
+			s.value, s.touched = 0, false
+		case ddMetricGauge:
+			// Gauges retain their value so that it is sent for every period.
+			series = append(series, ddMetricSeries{
+				Metric:    s.name,
+				Type:      3,
+				Points:    []ddPoint{{Timestamp: ts, Value: float64(s.value)}},
+				Tags:      s.tags,
+				Resources: resources,
+			})
+		case ddMetricDistribution:
+			dists = append(dists, ddDistributionSeries{
+				Metric: s.name,
+				Points: [][2]any{{ts, s.values}},
+				Tags:   s.tags,
+				Host:   d.host,
+			})
+			s.values, s.touched = nil, false
+		}
+	}
+
+	sort.Slice(series, func(i, j int) bool { return series[i].Metric < series[j].Metric })
+	sort.Slice(dists, func(i, j int) bool { return dists[i].Metric < dists[j].Metric })
+	return
+}
+
+func (d *ddMetrics) flush(ctx context.Context) error {
+	series, dists := d.drain(time.Now())
+
+	for len(series) > 0 {
+		batch := series
+		if len(batch) > ddMaxSeriesPerRequest {
+			batch = batch[:ddMaxSeriesPerRequest]
+		}
+		series = series[len(batch):]
+		if err := d.post(ctx, "/api/v2/series", map[string]any{"series": batch}); err != nil {
+			return err
+		}
+	}
+
+	for len(dists) > 0 {
+		batch := dists
+		if len(batch) > ddMaxSeriesPerRequest {
+			batch = batch[:ddMaxSeriesPerRequest]
+		}
+		dists = dists[len(batch):]
+		if err := d.post(ctx, "/api/v1/distribution_points", map[string]any{"series": batch}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *ddMetrics) post(ctx context.Context, path string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", d.apiKey)
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	return fmt.Errorf("%v returned status %v: %s", path, res.StatusCode, bytes.TrimSpace(resBody))
+}
+
+func (d *ddMetrics) Close(ctx context.Context) error {
+	d.cancel()
+	select {
+	case <-d.loopDone:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return d.flush(ctx)
+}
@@ -0,0 +1,123 @@
+package datadog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestDatadogMetrics(t *testing.T) {
+	var mut sync.Mutex
+	payloads := map[string][]map[string]any{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "fookey", r.Header.Get("DD-API-KEY"))
+
+		var payload struct {
+			Series []map[string]any `json:"series"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+		mut.Lock()
+		payloads[r.URL.Path] = append(payloads[r.URL.Path], payload.Series...)
+		mut.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+
+	d := newDDMetrics(srv.URL, "fookey", "foohost", []string{"env:test"}, time.Hour, srv.Client(), service.MockResources().Logger())
+
+	d.NewCounterCtor("counter", "label")("a").Incr(2)
+	d.NewCounterCtor("counter", "label")("a").Incr(3)
+	d.NewCounterCtor("idle_counter")()
+	d.NewGaugeCtor("gauge")().Set(7)
+
+	timer := d.NewTimerCtor("timer", "label")("b")
+	timer.Timing(10)
+	timer.Timing(20)
+
+	require.NoError(t, d.flush(context.Background()))
+
+	mut.Lock()
+	series, dists := payloads["/api/v2/series"], payloads["/api/v1/distribution_points"]
+	mut.Unlock()
+
+	require.Len(t, series, 2)
+
+	assert.Equal(t, "counter", series[0]["metric"])
+	assert.Equal(t, 1.0, series[0]["type"])
+	assert.Equal(t, []any{"env:test", "label:a"}, series[0]["tags"])
+	assert.Equal(t, 5.0, series[0]["points"].([]any)[0].(map[string]any)["value"])
+	assert.Equal(t, []any{map[string]any{"name": "foohost", "type": "host"}}, series[0]["resources"])
+
+	assert.Equal(t, "gauge", series[1]["metric"])
+	assert.Equal(t, 3.0, series[1]["type"])
+	assert.Equal(t, 7.0, series[1]["points"].([]any)[0].(map[string]any)["value"])
+
+	require.Len(t, dists, 1)
+	assert.Equal(t, "timer", dists[0]["metric"])
+	assert.Equal(t, "foohost", dists[0]["host"])
+	assert.Equal(t, []any{"env:test", "label:b"}, dists[0]["tags"])
+	assert.Equal(t, []any{10.0, 20.0}, dists[0]["points"].([]any)[0].([]any)[1])
+
+	// Only gauges are sent for periods without any updates.
+	require.NoError(t, d.Close(context.Background()))
+
+	mut.Lock()
+	defer mut.Unlock()
+	require.Len(t, payloads["/api/v2/series"], 3)
+	assert.Equal(t, "gauge", payloads["/api/v2/series"][2]["metric"])
+	assert.Len(t, payloads["/api/v1/distribution_points"], 1)
+}
+
+func TestDatadogMetricsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["Forbidden"]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	d := newDDMetrics(srv.URL, "fookey", "foohost", nil, time.Hour, srv.Client(), service.MockResources().Logger())
+	d.NewCounterCtor("counter")().Incr(1)
+
+	err := d.flush(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+	assert.Contains(t, err.Error(), "Forbidden")
+
+	require.NoError(t, d.Close(context.Background()))
+}
+
+func TestDatadogMetricsConfig(t *testing.T) {
+	conf, err := ddMetricsSpec().ParseYAML(`
+api_key: foo
+site: datadoghq.eu
+host: barhost
+proxy_url: http://localhost:8080
+`, nil)
+	require.NoError(t, err)
+
+	d, err := newDDMetricsFromParsed(conf, service.MockResources().Logger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = d.Close(context.Background())
+	})
+
+	assert.Equal(t, "https://api.datadoghq.eu", d.baseURL)
+	assert.Equal(t, "barhost", d.host)
+
+	req, err := http.NewRequest(http.MethodPost, d.baseURL, http.NoBody)
+	require.NoError(t, err)
+	proxyURL, err := d.client.Transport.(*http.Transport).Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080", proxyURL.String())
+}
@@ -3,13 +3,11 @@ package discord
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"sync"
 
 	"github.com/bwmarrin/discordgo"
 
-	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -63,9 +61,8 @@ func init() {
 type reader struct {
 	log     *service.Logger
 	shutSig *shutdown.Signaller
-	mgr     *service.Resources
 
-	checkpointer *checkpoint.Capped[string]
+	checkpointer *service.CacheCheckpointer
 
 	// Config
 	channelID string
@@ -79,10 +76,8 @@ type reader struct {
 
 func newReader(conf *service.ParsedConfig, mgr *service.Resources) (*reader, error) {
 	r := &reader{
-		log:          mgr.Logger(),
-		shutSig:      shutdown.NewSignaller(),
-		mgr:          mgr,
-		checkpointer: checkpoint.NewCapped[string](1024),
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
 	}
 	var err error
 	if r.channelID, err = conf.FieldString("channel_id"); err != nil {
@@ -97,6 +92,9 @@ func newReader(conf *service.ParsedConfig, mgr *service.Resources) (*reader, err
 	if r.cacheKey, err = conf.FieldString("cache_key"); err != nil {
 		return nil, err
 	}
+	if r.checkpointer, err = service.NewCacheCheckpointer(mgr, r.cache, r.cacheKey, 1024); err != nil {
+		return nil, err
+	}
 	return r, nil
 }
 
@@ -108,21 +106,11 @@ func (r *reader) Connect(ctx context.Context) error {
 	}
 
 	// Obtain the newest message we've already seen.
-	var lastMsgID string
-	var cacheErr error
-	err := r.mgr.AccessCache(ctx, r.cache, func(c service.Cache) {
-		var lastMsgIDBytes []byte
-		if lastMsgIDBytes, cacheErr = c.Get(ctx, r.cacheKey); errors.Is(cacheErr, service.ErrKeyNotFound) {
-			cacheErr = nil
-		}
-		lastMsgID = string(lastMsgIDBytes)
-	})
-	if err == nil {
-		err = cacheErr
-	}
+	lastMsgIDBytes, err := r.checkpointer.Get(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to obtain latest seen message ID: %v", err)
 	}
+	lastMsgID := string(lastMsgIDBytes)
 
 	sess, doneWithSessFn, err := getGlobalSession(r.botToken)
 	if err != nil {
@@ -221,25 +209,11 @@ func (r *reader) Read(ctx context.Context) (*service.Message, service.AckFunc, e
 		return nil, nil, err
 	}
 
-	release, err := r.checkpointer.Track(ctx, msgEvent.ID, 1)
+	ackFn, err := r.checkpointer.Track(ctx, []byte(msgEvent.ID), 1, nil)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	msg := service.NewMessage(jBytes)
-	return msg, func(ctx context.Context, err error) error {
-		highestID := release()
-		if highestID == nil {
-			return nil
-		}
-		var setErr error
-		if err := r.mgr.AccessCache(ctx, r.cache, func(c service.Cache) {
-			setErr = c.Set(ctx, r.cacheKey, []byte(*highestID), nil)
-		}); err != nil {
-			return err
-		}
-		return setErr
-	}, nil
+	return service.NewMessage(jBytes), ackFn, nil
 }
 
 func (r *reader) Close(ctx context.Context) error {
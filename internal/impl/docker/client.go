@@ -0,0 +1,125 @@
+package docker
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// dockerClient performs requests against the Docker Engine API.
+type dockerClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func newDockerClient(host string, tlsConf *tls.Config) (*dockerClient, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	d := &dockerClient{client: &http.Client{Transport: transport}}
+
+	switch u.Scheme {
+	case "unix":
+		socketPath := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+		d.baseURL = "http://docker"
+	case "tcp", "http", "https":
+		scheme := "http"
+		if tlsConf != nil || u.Scheme == "https" {
+			scheme = "https"
+			transport.TLSClientConfig = tlsConf
+		}
+		d.baseURL = scheme + "://" + u.Host
+	default:
+		return nil, fmt.Errorf("host scheme %v not supported", u.Scheme)
+	}
+	return d, nil
+}
+
+type dockerError struct {
+	StatusCode int
+	Message    string `json:"message"`
+}
+
+func (e *dockerError) Error() string {
+	return fmt.Sprintf("docker returned status %v: %v", e.StatusCode, e.Message)
+}
+
+// get performs a GET request of an API path, returning an error for any
+// response status other than 200.
+func (d *dockerClient) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := d.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1<<16))
+		dErr := &dockerError{}
+		if err := json.Unmarshal(body, dErr); err != nil || dErr.Message == "" {
+			dErr.Message = strings.TrimSpace(string(body))
+		}
+		dErr.StatusCode = res.StatusCode
+		return nil, dErr
+	}
+	return res, nil
+}
+
+func (d *dockerClient) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	res, err := d.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+type containerInfo struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Config struct {
+		Tty    bool              `json:"Tty"`
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	State struct {
+		Running bool `json:"Running"`
+	} `json:"State"`
+}
+
+func (d *dockerClient) inspect(ctx context.Context, id string) (*containerInfo, error) {
+	var info containerInfo
+	if err := d.getJSON(ctx, "/containers/"+url.PathEscape(id)+"/json", nil, &info); err != nil {
+		return nil, err
+	}
+	info.Name = strings.TrimPrefix(info.Name, "/")
+	return &info, nil
+}
+
+// filtersQuery encodes filters in the format expected by the API.
+func filtersQuery(filters map[string][]string) url.Values {
+	fBytes, _ := json.Marshal(filters)
+	return url.Values{"filters": []string{string(fBytes)}}
+}
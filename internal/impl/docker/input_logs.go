@@ -0,0 +1,697 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	dlFieldHost              = "host"
+	dlFieldTLS               = "tls"
+	dlFieldNames             = "names"
+	dlFieldLabels            = "labels"
+	dlFieldStdout            = "stdout"
+	dlFieldStderr            = "stderr"
+	dlFieldStartFromOldest   = "start_from_oldest"
+	dlFieldMultiline         = "multiline"
+	dlFieldMultilinePattern  = "pattern"
+	dlFieldMultilineMaxLines = "max_lines"
+	dlFieldMultilineTimeout  = "timeout"
+)
+
+func logsInputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.20.0").
+		Summary("Tails the logs of Docker containers that match name and label filters.").
+		Description(`
+Running containers that match the filters are discovered via the Docker Engine API, and the logs of each container are tailed with each line emitted as a message. The API is also watched for containers that start after the input, which are tailed from the beginning of their logs. Tailing of a container resumes from the most recent line consumed when its log stream is interrupted, or when the container restarts.
+
+Any container runtime that exposes the Docker Engine API can be consumed from, such as Podman, and containers must use a logging driver that supports reading logs, such as the default `+"`json-file`"+` or `+"`local`"+` drivers.
+
+### Multiline Logs
+
+When a `+"`multiline.pattern`"+` is configured lines that do not match the pattern are merged with the preceding lines of the same stream, which is useful for log records that span multiple lines such as stack traces. A merged record is emitted once a line that matches the pattern is written, once it reaches `+"`multiline.max_lines`"+` lines, or once no further lines are written for the `+"`multiline.timeout`"+`.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- docker_container_id
+- docker_container_name
+- docker_image
+- docker_stream
+- docker_labels
+- docker_timestamp
+`+"```"+`
+
+The field `+"`docker_stream`"+` is either `+"`stdout` or `stderr`"+`, `+"`docker_labels`"+` is an object containing the labels of the container, and `+"`docker_timestamp`"+` is the time the first line of the message was written formatted as RFC 3339.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).`).
+		Fields(
+			service.NewStringField(dlFieldHost).
+				Description("The address of the Docker Engine API, either a unix socket or a TCP address.").
+				Default("unix:///var/run/docker.sock").
+				Example("tcp://localhost:2375"),
+			service.NewTLSToggledField(dlFieldTLS),
+			service.NewStringListField(dlFieldNames).
+				Description("An optional list of regular expressions, where only containers with a name matching at least one of the expressions are tailed.").
+				Default([]any{}).
+				Example([]any{"^nginx", "api-[0-9]+$"}),
+			service.NewStringListField(dlFieldLabels).
+				Description("An optional list of label filters of the form `key` or `key=value`, where only containers matching all of the filters are tailed.").
+				Default([]any{}).
+				Example([]any{"com.example.logs=true"}),
+			service.NewBoolField(dlFieldStdout).
+				Description("Whether to consume the standard output of containers.").
+				Default(true).
+				Advanced(),
+			service.NewBoolField(dlFieldStderr).
+				Description("Whether to consume the standard error of containers.").
+				Default(true).
+				Advanced(),
+			service.NewBoolField(dlFieldStartFromOldest).
+				Description("Whether to consume the logs of containers that are already running when the input starts from the beginning, otherwise only lines written after the input starts are consumed.").
+				Default(false).
+				Advanced(),
+			service.NewObjectField(dlFieldMultiline,
+				service.NewStringField(dlFieldMultilinePattern).
+					Description("A regular expression that matches the first line of each record, where lines that do not match are merged with the preceding lines. Merging is disabled when empty.").
+					Default("").
+					Examples(`^\d{4}-\d{2}-\d{2}`, `^\S`),
+				service.NewIntField(dlFieldMultilineMaxLines).
+					Description("The maximum number of lines to merge into a single record.").
+					Default(500),
+				service.NewDurationField(dlFieldMultilineTimeout).
+					Description("The period of time to wait for further lines of a record before it is emitted.").
+					Default("1s"),
+			).
+				Description("Merge lines that belong to a single log record.").
+				Advanced(),
+		).
+		Example(
+			"Edge Log Shipper",
+			"In this example the logs of all containers labelled for collection are tailed, with stack traces merged into the log line that precedes them, and written to a Loki compatible HTTP endpoint.",
+			`
+input:
+  docker_logs:
+    labels: [ logging=enabled ]
+    multiline:
+      pattern: '^\d{4}-\d{2}-\d{2}'
+  processors:
+    - mapping: |
+        root.message = content().string()
+        root.container = meta("docker_container_name")
+        root.stream = meta("docker_stream")
+        root.timestamp = meta("docker_timestamp")
+
+output:
+  http_client:
+    url: http://localhost:3100/loki/api/v1/push
+    verb: POST
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterInput("docker_logs", logsInputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newLogsInputFromParsed(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// containerTail is the state of the tailing of a single container.
+type containerTail struct {
+	active   bool
+	lastLine time.Time
+}
+
+type logsInput struct {
+	client          *dockerClient
+	names           []*regexp.Regexp
+	labels          []string
+	stdout          bool
+	stderr          bool
+	startFromOldest bool
+
+	pattern      *regexp.Regexp
+	maxLines     int
+	flushTimeout time.Duration
+
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+	nowFn   func() time.Time
+
+	retryPeriod time.Duration
+
+	tailsMut sync.Mutex
+	tails    map[string]*containerTail
+	tailsWG  sync.WaitGroup
+
+	connMut sync.Mutex
+	msgChan chan *service.Message
+}
+
+func newLogsInputFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*logsInput, error) {
+	l := &logsInput{
+		log:         mgr.Logger(),
+		shutSig:     shutdown.NewSignaller(),
+		nowFn:       time.Now,
+		retryPeriod: time.Second,
+		tails:       map[string]*containerTail{},
+	}
+
+	host, err := conf.FieldString(dlFieldHost)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled(dlFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	if !tlsEnabled {
+		tlsConf = nil
+	}
+	if l.client, err = newDockerClient(host, tlsConf); err != nil {
+		return nil, err
+	}
+
+	names, err := conf.FieldStringList(dlFieldNames)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range names {
+		re, err := regexp.Compile(n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse name expression '%v': %w", n, err)
+		}
+		l.names = append(l.names, re)
+	}
+	if l.labels, err = conf.FieldStringList(dlFieldLabels); err != nil {
+		return nil, err
+	}
+	if l.stdout, err = conf.FieldBool(dlFieldStdout); err != nil {
+		return nil, err
+	}
+	if l.stderr, err = conf.FieldBool(dlFieldStderr); err != nil {
+		return nil, err
+	}
+	if !l.stdout && !l.stderr {
+		return nil, errors.New("at least one of stdout or stderr must be enabled")
+	}
+	if l.startFromOldest, err = conf.FieldBool(dlFieldStartFromOldest); err != nil {
+		return nil, err
+	}
+
+	mConf := conf.Namespace(dlFieldMultiline)
+	pattern, err := mConf.FieldString(dlFieldMultilinePattern)
+	if err != nil {
+		return nil, err
+	}
+	if pattern != "" {
+		if l.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("failed to parse multiline pattern: %w", err)
+		}
+	}
+	if l.maxLines, err = mConf.FieldInt(dlFieldMultilineMaxLines); err != nil {
+		return nil, err
+	}
+	if l.flushTimeout, err = mConf.FieldDuration(dlFieldMultilineTimeout); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *logsInput) Connect(ctx context.Context) error {
+	l.connMut.Lock()
+	defer l.connMut.Unlock()
+	if l.msgChan != nil {
+		return nil
+	}
+
+	msgChan := make(chan *service.Message)
+	go func() {
+		runCtx, done := l.shutSig.CloseAtLeisureCtx(context.Background())
+		defer func() {
+			done()
+			l.tailsWG.Wait()
+			l.shutSig.ShutdownComplete()
+		}()
+
+		// Lines written before the input started are skipped for containers
+		// that were already running, unless starting from the oldest lines.
+		var since time.Time
+		if !l.startFromOldest {
+			since = l.nowFn()
+		}
+		for {
+			err := l.watchContainers(runCtx, msgChan, &since)
+			if runCtx.Err() != nil {
+				return
+			}
+			if err != nil {
+				l.log.Errorf("Failed to watch containers: %v\n", err)
+			}
+			select {
+			case <-time.After(l.retryPeriod):
+			case <-runCtx.Done():
+				return
+			}
+		}
+	}()
+
+	l.msgChan = msgChan
+	l.log.Infof("Tailing logs of Docker containers from %v", l.client.baseURL)
+	return nil
+}
+
+// watchContainers starts tailing the running containers that match the
+// filters, and then any containers that start thereafter. The since time is
+// applied to the containers that are running when first listed.
+func (l *logsInput) watchContainers(ctx context.Context, msgChan chan<- *service.Message, since *time.Time) error {
+	// Events are subscribed to before listing containers so that containers
+	// starting in between are not missed.
+	filters := map[string][]string{
+		"type":  {"container"},
+		"event": {"start", "destroy"},
+	}
+	if len(l.labels) > 0 {
+		filters["label"] = l.labels
+	}
+	res, err := l.client.get(ctx, "/events", filtersQuery(filters))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	listFilters := map[string][]string{}
+	if len(l.labels) > 0 {
+		listFilters["label"] = l.labels
+	}
+	var containers []struct {
+		ID string `json:"Id"`
+	}
+	if err := l.client.getJSON(ctx, "/containers/json", filtersQuery(listFilters), &containers); err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+	for _, c := range containers {
+		l.startTail(ctx, msgChan, c.ID, *since)
+	}
+	*since = time.Time{}
+
+	dec := json.NewDecoder(res.Body)
+	for {
+		var event struct {
+			Action string `json:"Action"`
+			Actor  struct {
+				ID string `json:"ID"`
+			} `json:"Actor"`
+		}
+		if err := dec.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		switch event.Action {
+		case "start":
+			l.startTail(ctx, msgChan, event.Actor.ID, time.Time{})
+		case "destroy":
+			l.tailsMut.Lock()
+			if t, exists := l.tails[event.Actor.ID]; exists && !t.active {
+				delete(l.tails, event.Actor.ID)
+			}
+			l.tailsMut.Unlock()
+		}
+	}
+}
+
+// startTail starts tailing a container unless it is already being tailed.
+func (l *logsInput) startTail(ctx context.Context, msgChan chan<- *service.Message, id string, since time.Time) {
+	l.tailsMut.Lock()
+	defer l.tailsMut.Unlock()
+
+	t, exists := l.tails[id]
+	if !exists {
+		t = &containerTail{lastLine: since}
+		l.tails[id] = t
+	}
+	if t.active {
+		return
+	}
+	t.active = true
+
+	l.tailsWG.Add(1)
+	go func() {
+		defer l.tailsWG.Done()
+		l.tailContainer(ctx, msgChan, id, t)
+	}()
+}
+
+func (l *logsInput) matchesName(name string) bool {
+	if len(l.names) == 0 {
+		return true
+	}
+	for _, re := range l.names {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// tailContainer follows the logs of a container for as long as it is running.
+func (l *logsInput) tailContainer(ctx context.Context, msgChan chan<- *service.Message, id string, t *containerTail) {
+	defer func() {
+		l.tailsMut.Lock()
+		t.active = false
+		l.tailsMut.Unlock()
+	}()
+
+	for {
+		info, err := l.client.inspect(ctx, id)
+		if ctx.Err() != nil {
+			return
+		}
+		var dErr *dockerError
+		if errors.As(err, &dErr) && dErr.StatusCode == http.StatusNotFound {
+			l.tailsMut.Lock()
+			delete(l.tails, id)
+			l.tailsMut.Unlock()
+			return
+		}
+
+		if err == nil {
+			if !info.State.Running || !l.matchesName(info.Name) {
+				return
+			}
+
+			l.tailsMut.Lock()
+			since := t.lastLine
+			l.tailsMut.Unlock()
+
+			err = l.streamLogs(ctx, msgChan, info, since, func(ts time.Time) {
+				// Lines of stdout and stderr may be emitted out of order when
+				// a line is split across frames.
+				l.tailsMut.Lock()
+				if ts.After(t.lastLine) {
+					t.lastLine = ts
+				}
+				l.tailsMut.Unlock()
+			})
+			if ctx.Err() != nil {
+				return
+			}
+		}
+		if err != nil {
+			l.log.Errorf("Failed to tail logs of container %v: %v\n", id, err)
+		}
+
+		// The log stream ends when the container stops, otherwise the stream
+		// is resumed.
+		select {
+		case <-time.After(l.retryPeriod):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (l *logsInput) streamLogs(ctx context.Context, msgChan chan<- *service.Message, info *containerInfo, since time.Time, onLine func(ts time.Time)) error {
+	query := url.Values{}
+	query.Set("follow", "1")
+	query.Set("timestamps", "1")
+	if l.stdout {
+		query.Set("stdout", "1")
+	}
+	if l.stderr {
+		query.Set("stderr", "1")
+	}
+	if !since.IsZero() {
+		query.Set("since", fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond()))
+	}
+
+	res, err := l.client.get(ctx, "/containers/"+url.PathEscape(info.ID)+"/logs", query)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	lines := make(chan logLine)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- readLogLines(res.Body, info.Config.Tty, func(line logLine) error {
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+		close(lines)
+	}()
+
+	labels := make(map[string]any, len(info.Config.Labels))
+	for k, v := range info.Config.Labels {
+		labels[k] = v
+	}
+
+	emit := func(record []logLine) error {
+		if len(record) == 0 {
+			return nil
+		}
+		msg := service.NewMessage(joinLines(record))
+		msg.MetaSetMut("docker_container_id", info.ID)
+		msg.MetaSetMut("docker_container_name", info.Name)
+		msg.MetaSetMut("docker_image", info.Config.Image)
+		msg.MetaSetMut("docker_stream", record[0].stream)
+		msg.MetaSetMut("docker_labels", labels)
+		if !record[0].timestamp.IsZero() {
+			msg.MetaSetMut("docker_timestamp", record[0].timestamp.Format(time.RFC3339Nano))
+		}
+
+		select {
+		case msgChan <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if ts := record[len(record)-1].timestamp; !ts.IsZero() {
+			onLine(ts)
+		}
+		return nil
+	}
+
+	mergers := map[string]*multilineMerger{}
+	flushAll := func() error {
+		for _, m := range mergers {
+			if err := emit(m.flush()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var flushChan <-chan time.Time
+	for {
+		select {
+		case line, open := <-lines:
+			if !open {
+				if err := flushAll(); err != nil {
+					return err
+				}
+				return <-readErr
+			}
+
+			// Lines that were consumed prior to resuming are skipped.
+			if !line.timestamp.IsZero() && !line.timestamp.After(since) {
+				continue
+			}
+
+			m, exists := mergers[line.stream]
+			if !exists {
+				m = &multilineMerger{pattern: l.pattern, maxLines: l.maxLines}
+				mergers[line.stream] = m
+			}
+			for _, record := range m.add(line) {
+				if err := emit(record); err != nil {
+					return err
+				}
+			}
+			if l.pattern != nil {
+				flushChan = time.After(l.flushTimeout)
+			}
+		case <-flushChan:
+			flushChan = nil
+			if err := flushAll(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// readLogLines reads the lines of a log stream, which is multiplexed into
+// frames of stdout and stderr unless the container uses a TTY.
+func readLogLines(r io.Reader, tty bool, fn func(line logLine) error) error {
+	stdout := &lineSplitter{stream: "stdout", fn: fn, trimCR: tty}
+	stderr := &lineSplitter{stream: "stderr", fn: fn}
+
+	if tty {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				if werr := stdout.write(buf[:n]); werr != nil {
+					return werr
+				}
+			}
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return stdout.close()
+				}
+				return err
+			}
+		}
+	}
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				if err := stdout.close(); err != nil {
+					return err
+				}
+				return stderr.close()
+			}
+			return err
+		}
+
+		frame := make([]byte, binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return err
+		}
+
+		var err error
+		switch header[0] {
+		case 1:
+			err = stdout.write(frame)
+		case 2:
+			err = stderr.write(frame)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// lineSplitter splits the data of a stream into lines, parsing the timestamp
+// that prefixes each line.
+type lineSplitter struct {
+	stream  string
+	fn      func(line logLine) error
+	trimCR  bool
+	partial []byte
+}
+
+func (s *lineSplitter) write(p []byte) error {
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			s.partial = append(s.partial, p...)
+			return nil
+		}
+		line := append(s.partial, p[:i]...)
+		s.partial = nil
+		p = p[i+1:]
+		if err := s.emit(line); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *lineSplitter) close() error {
+	if len(s.partial) == 0 {
+		return nil
+	}
+	line := s.partial
+	s.partial = nil
+	return s.emit(line)
+}
+
+func (s *lineSplitter) emit(line []byte) error {
+	if s.trimCR {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+	}
+
+	l := logLine{stream: s.stream, data: line}
+	if i := bytes.IndexByte(line, ' '); i > 0 {
+		if ts, err := time.Parse(time.RFC3339Nano, string(line[:i])); err == nil {
+			l.timestamp, l.data = ts, line[i+1:]
+		}
+	}
+	return s.fn(l)
+}
+
+func (l *logsInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	l.connMut.Lock()
+	msgChan := l.msgChan
+	l.connMut.Unlock()
+	if msgChan == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	select {
+	case msg := <-msgChan:
+		return msg, func(context.Context, error) error {
+			// Nacks are handled by AutoRetryNacks.
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (l *logsInput) Close(ctx context.Context) error {
+	go func() {
+		l.shutSig.CloseAtLeisure()
+		l.connMut.Lock()
+		if l.msgChan == nil {
+			// Indicates that we were never connected, so indicate shutdown is
+			// complete.
+			l.shutSig.ShutdownComplete()
+		}
+		l.connMut.Unlock()
+	}()
+	select {
+	case <-l.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// fakeDockerServer emulates the endpoints of the Docker Engine API used by the
+// input. Events and log data are consumed from channels so that tests can
+// control when they are sent, where an empty string ends the response.
+type fakeDockerServer struct {
+	*httptest.Server
+
+	mut        sync.Mutex
+	containers map[string]string
+	logQueries map[string][]url.Values
+
+	events chan string
+	logs   map[string]chan string
+}
+
+func newFakeDockerServer(t *testing.T) *fakeDockerServer {
+	t.Helper()
+
+	s := &fakeDockerServer{
+		containers: map[string]string{},
+		logQueries: map[string][]url.Values{},
+		events:     make(chan string),
+		logs:       map[string]chan string{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var stream chan string
+		switch path := r.URL.Path; {
+		case path == "/events":
+			stream = s.events
+		case path == "/containers/json":
+			s.mut.Lock()
+			var list []string
+			for id := range s.containers {
+				list = append(list, fmt.Sprintf(`{"Id":%q}`, id))
+			}
+			s.mut.Unlock()
+			_, _ = w.Write([]byte("[" + strings.Join(list, ",") + "]"))
+			return
+		case strings.HasSuffix(path, "/json"):
+			s.mut.Lock()
+			info, exists := s.containers[strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/json")]
+			s.mut.Unlock()
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message":"no such container"}`))
+				return
+			}
+			_, _ = w.Write([]byte(info))
+			return
+		case strings.HasSuffix(path, "/logs"):
+			id := strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/logs")
+			s.mut.Lock()
+			s.logQueries[id] = append(s.logQueries[id], r.URL.Query())
+			s.mut.Unlock()
+			stream = s.logStream(id)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case data := <-stream:
+				if data == "" {
+					return
+				}
+				_, _ = w.Write([]byte(data))
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeDockerServer) setContainer(id, name string, tty bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.containers[id] = fmt.Sprintf(`{"Id":%q,"Name":"/%v","Config":{"Tty":%v,"Image":"foo:latest","Labels":{"logging":"enabled"}},"State":{"Running":true}}`, id, name, tty)
+}
+
+func (s *fakeDockerServer) logStream(id string) chan string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	c, exists := s.logs[id]
+	if !exists {
+		c = make(chan string)
+		s.logs[id] = c
+	}
+	return c
+}
+
+// sinceTimes returns the since parameter of each log request of a container.
+func (s *fakeDockerServer) sinceTimes(id string) []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	var times []string
+	for _, q := range s.logQueries[id] {
+		times = append(times, q.Get("since"))
+	}
+	return times
+}
+
+func (s *fakeDockerServer) send(t *testing.T, ch chan string, data ...string) {
+	t.Helper()
+	for _, d := range data {
+		select {
+		case ch <- d:
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out sending to fake docker server")
+		}
+	}
+}
+
+// frame encodes data as a frame of a multiplexed log stream.
+func frame(stream byte, data string) string {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	return string(header) + data
+}
+
+func testLogsInput(t *testing.T, srv *fakeDockerServer, confStr string) *logsInput {
+	t.Helper()
+
+	pConf, err := logsInputSpec().ParseYAML(fmt.Sprintf("host: tcp://%v\n", srv.Listener.Addr())+confStr, nil)
+	require.NoError(t, err)
+
+	l, err := newLogsInputFromParsed(pConf, service.MockResources())
+	require.NoError(t, err)
+	l.retryPeriod = time.Millisecond * 10
+	l.nowFn = func() time.Time {
+		return time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	t.Cleanup(func() {
+		require.NoError(t, l.Close(context.Background()))
+	})
+	return l
+}
+
+func readLogMessage(t *testing.T, l *logsInput) string {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, _, err := l.Read(ctx)
+	require.NoError(t, err)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+
+	name, _ := msg.MetaGet("docker_container_name")
+	stream, _ := msg.MetaGet("docker_stream")
+	return name + " " + stream + ": " + string(b)
+}
+
+func TestDockerLogsInput(t *testing.T) {
+	srv := newFakeDockerServer(t)
+	srv.setContainer("abc", "web-1", false)
+	srv.setContainer("def", "db", false)
+
+	l := testLogsInput(t, srv, `
+names: [ '^web' ]
+labels: [ logging=enabled ]
+`)
+	require.NoError(t, l.Connect(context.Background()))
+
+	abcLogs := srv.logStream("abc")
+	srv.send(t, abcLogs,
+		frame(1, "2023-01-01T00:00:01.5Z hello\n2023-01-01T00:00:02Z wor"),
+		frame(2, "2023-01-01T00:00:02.5Z oops\n"),
+		frame(1, "ld\n"),
+	)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, _, err := l.Read(ctx)
+	require.NoError(t, err)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	meta := map[string]any{}
+	_ = msg.MetaWalkMut(func(k string, v any) error {
+		meta[k] = v
+		return nil
+	})
+	assert.Equal(t, map[string]any{
+		"docker_container_id":   "abc",
+		"docker_container_name": "web-1",
+		"docker_image":          "foo:latest",
+		"docker_stream":         "stdout",
+		"docker_labels":         map[string]any{"logging": "enabled"},
+		"docker_timestamp":      "2023-01-01T00:00:01.5Z",
+	}, meta)
+
+	assert.Equal(t, "web-1 stderr: oops", readLogMessage(t, l))
+	assert.Equal(t, "web-1 stdout: world", readLogMessage(t, l))
+
+	// When the stream ends whilst the container is running it is resumed, and
+	// lines that were already consumed are skipped.
+	srv.send(t, abcLogs, "", frame(1, "2023-01-01T00:00:02Z world\n2023-01-01T00:00:03Z again\n"))
+	assert.Equal(t, "web-1 stdout: again", readLogMessage(t, l))
+
+	// Containers that start after the input are tailed from the beginning.
+	srv.setContainer("ghi", "web-2", true)
+	srv.send(t, srv.events, `{"Type":"container","Action":"start","Actor":{"ID":"ghi"}}`+"\n")
+	srv.send(t, srv.logStream("ghi"), "2022-12-31T00:00:00Z from tty\r\n")
+	assert.Equal(t, "web-2 stdout: from tty", readLogMessage(t, l))
+
+	assert.Equal(t, []string{"1672531200.000000000", "1672531202.500000000"}, srv.sinceTimes("abc"))
+	assert.Equal(t, []string{""}, srv.sinceTimes("ghi"))
+	assert.Empty(t, srv.sinceTimes("def"))
+
+	srv.mut.Lock()
+	q := srv.logQueries["abc"][0]
+	srv.mut.Unlock()
+	assert.Equal(t, "1", q.Get("stdout"))
+	assert.Equal(t, "1", q.Get("stderr"))
+	assert.Equal(t, "1", q.Get("timestamps"))
+}
+
+func TestDockerLogsInputMultiline(t *testing.T) {
+	srv := newFakeDockerServer(t)
+	srv.setContainer("abc", "web-1", false)
+
+	l := testLogsInput(t, srv, `
+start_from_oldest: true
+multiline:
+  pattern: '^\d{4}'
+  max_lines: 3
+  timeout: 10ms
+`)
+	require.NoError(t, l.Connect(context.Background()))
+
+	abcLogs := srv.logStream("abc")
+	srv.send(t, abcLogs, frame(1, strings.Join([]string{
+		"2023-01-01T00:00:01Z 2023 ERROR foo",
+		"2023-01-01T00:00:01Z   at a",
+		"2023-01-01T00:00:01Z   at b",
+		"2023-01-01T00:00:02Z 2023 INFO bar",
+		"2023-01-01T00:00:03Z   baz",
+		"2023-01-01T00:00:03Z   buz",
+		"2023-01-01T00:00:03Z   bev",
+	}, "\n")+"\n"))
+
+	assert.Equal(t, "web-1 stdout: 2023 ERROR foo\n  at a\n  at b", readLogMessage(t, l))
+	assert.Equal(t, "web-1 stdout: 2023 INFO bar\n  baz\n  buz", readLogMessage(t, l))
+
+	// Pending lines are emitted after the timeout.
+	assert.Equal(t, "web-1 stdout:   bev", readLogMessage(t, l))
+	assert.Equal(t, []string{""}, srv.sinceTimes("abc"))
+}
+
+func TestDockerLogsInputBadConfig(t *testing.T) {
+	for _, conf := range []string{
+		`host: ftp://localhost`,
+		`names: [ '(' ]`,
+		`multiline: { pattern: '(' }`,
+		`{ stdout: false, stderr: false }`,
+	} {
+		pConf, err := logsInputSpec().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		_, err = newLogsInputFromParsed(pConf, service.MockResources())
+		assert.Error(t, err, conf)
+	}
+}
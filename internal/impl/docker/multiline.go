@@ -0,0 +1,55 @@
+package docker
+
+import (
+	"bytes"
+	"regexp"
+	"time"
+)
+
+// logLine is a single line of the logs of a container.
+type logLine struct {
+	stream    string
+	timestamp time.Time
+	data      []byte
+}
+
+// multilineMerger merges consecutive lines of a stream into a single record,
+// where each record begins with a line that matches a pattern.
+type multilineMerger struct {
+	pattern  *regexp.Regexp
+	maxLines int
+
+	pending []logLine
+}
+
+// add a line to the merger, returning any records that are complete.
+func (m *multilineMerger) add(line logLine) [][]logLine {
+	if m.pattern == nil {
+		return [][]logLine{{line}}
+	}
+
+	var records [][]logLine
+	if len(m.pending) > 0 && (m.pattern.Match(line.data) || len(m.pending) >= m.maxLines) {
+		records = append(records, m.flush())
+	}
+	m.pending = append(m.pending, line)
+	return records
+}
+
+// flush returns the pending record, if any.
+func (m *multilineMerger) flush() []logLine {
+	record := m.pending
+	m.pending = nil
+	return record
+}
+
+func joinLines(record []logLine) []byte {
+	if len(record) == 1 {
+		return record[0].data
+	}
+	lines := make([][]byte, len(record))
+	for i, l := range record {
+		lines[i] = l.data
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
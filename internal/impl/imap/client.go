@@ -0,0 +1,283 @@
+package imap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// response is a single line sent by the server, where any literals within the
+// line are extracted in the order they appear.
+type response struct {
+	line     string
+	literals [][]byte
+}
+
+// maxLiteralSize is the largest literal accepted from a server, which protects
+// against exhausting memory when a server sends a bogus literal size.
+const maxLiteralSize = 256 << 20
+
+// client is a minimal IMAP4rev1 client supporting only the commands required
+// for consuming messages from a mailbox.
+type client struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	timeout time.Duration
+
+	tagN int
+	caps map[string]struct{}
+}
+
+func dialClient(address string, tlsConf *tls.Config, timeout time.Duration) (*client, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if tlsConf != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConf)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &client{
+		conn:    conn,
+		r:       bufio.NewReader(conn),
+		timeout: timeout,
+	}
+	c.setDeadline()
+
+	greeting, err := c.readResponse()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting.line, "* OK") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected greeting: %v", greeting.line)
+	}
+	if err := c.refreshCapabilities(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *client) setDeadline() {
+	if c.timeout > 0 {
+		_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+}
+
+// readResponse reads a line from the server along with any literals it
+// contains, which are denoted by a {N} suffix followed by N bytes.
+func (c *client) readResponse() (*response, error) {
+	res := &response{}
+	var line strings.Builder
+	for {
+		l, err := c.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		l = strings.TrimRight(l, "\r\n")
+
+		size, hasLiteral := literalSize(l)
+		if !hasLiteral {
+			line.WriteString(l)
+			break
+		}
+
+		if size < 0 || size > maxLiteralSize {
+			return nil, fmt.Errorf("literal size %v exceeds the limit of %v bytes", size, maxLiteralSize)
+		}
+
+		line.WriteString(l[:strings.LastIndexByte(l, '{')])
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return nil, err
+		}
+		res.literals = append(res.literals, literal)
+		line.WriteString("{}")
+	}
+	res.line = line.String()
+	return res, nil
+}
+
+func literalSize(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	i := strings.LastIndexByte(line, '{')
+	if i == -1 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(line[i+1 : len(line)-1])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// execute sends a command and returns the untagged responses received before
+// the tagged completion, or an error if the command did not succeed.
+func (c *client) execute(cmd string) ([]*response, error) {
+	c.tagN++
+	tag := fmt.Sprintf("B%04d", c.tagN)
+
+	c.setDeadline()
+	if _, err := io.WriteString(c.conn, tag+" "+cmd+"\r\n"); err != nil {
+		return nil, err
+	}
+
+	var untagged []*response
+	for {
+		res, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.HasPrefix(res.line, "+"):
+			// A continuation at this stage only occurs for failed SASL
+			// exchanges, which are aborted by sending an empty response.
+			if _, err := io.WriteString(c.conn, "\r\n"); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(res.line, tag+" "):
+			status := strings.TrimPrefix(res.line, tag+" ")
+			if !strings.HasPrefix(status, "OK") {
+				return untagged, fmt.Errorf("command %v failed: %v", strings.Fields(cmd)[0], status)
+			}
+			return untagged, nil
+		default:
+			untagged = append(untagged, res)
+		}
+	}
+}
+
+func (c *client) refreshCapabilities() error {
+	untagged, err := c.execute("CAPABILITY")
+	if err != nil {
+		return err
+	}
+	c.caps = map[string]struct{}{}
+	for _, res := range untagged {
+		if !strings.HasPrefix(res.line, "* CAPABILITY ") {
+			continue
+		}
+		for _, capability := range strings.Fields(strings.TrimPrefix(res.line, "* CAPABILITY ")) {
+			c.caps[strings.ToUpper(capability)] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (c *client) hasCapability(name string) bool {
+	_, exists := c.caps[name]
+	return exists
+}
+
+// quote returns a string as an IMAP quoted string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (c *client) login(username, password string) error {
+	if _, err := c.execute("LOGIN " + quote(username) + " " + quote(password)); err != nil {
+		return err
+	}
+	return c.refreshCapabilities()
+}
+
+func (c *client) authenticateXOAUTH2(username, token string) error {
+	if !c.hasCapability("AUTH=XOAUTH2") {
+		return errors.New("server does not support XOAUTH2 authentication")
+	}
+	ir := base64.StdEncoding.EncodeToString([]byte("user=" + username + "\x01auth=Bearer " + token + "\x01\x01"))
+	if _, err := c.execute("AUTHENTICATE XOAUTH2 " + ir); err != nil {
+		return err
+	}
+	return c.refreshCapabilities()
+}
+
+func (c *client) selectMailbox(mailbox string) error {
+	_, err := c.execute("SELECT " + quote(mailbox))
+	return err
+}
+
+// search returns the UIDs of messages matching the given criteria.
+func (c *client) search(criteria string) ([]uint32, error) {
+	untagged, err := c.execute("UID SEARCH " + criteria)
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint32
+	for _, res := range untagged {
+		if !strings.HasPrefix(res.line, "* SEARCH") {
+			continue
+		}
+		for _, f := range strings.Fields(strings.TrimPrefix(res.line, "* SEARCH")) {
+			uid, err := strconv.ParseUint(f, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse search result: %w", err)
+			}
+			uids = append(uids, uint32(uid))
+		}
+	}
+	return uids, nil
+}
+
+// fetch returns the full raw contents of a message without marking it as
+// seen.
+func (c *client) fetch(uid uint32) ([]byte, error) {
+	untagged, err := c.execute(fmt.Sprintf("UID FETCH %v (BODY.PEEK[])", uid))
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range untagged {
+		if strings.Contains(res.line, " FETCH ") && len(res.literals) > 0 {
+			return res.literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("message %v was not found", uid)
+}
+
+func (c *client) markSeen(uid uint32) error {
+	_, err := c.execute(fmt.Sprintf(`UID STORE %v +FLAGS.SILENT (\Seen)`, uid))
+	return err
+}
+
+// move moves a message to another mailbox, falling back to a copy followed by
+// flagging the original as deleted when the server does not support MOVE. The
+// original is only expunged when the server supports UIDPLUS, as a general
+// EXPUNGE would also remove any other messages flagged as deleted.
+func (c *client) move(uid uint32, mailbox string) error {
+	if c.hasCapability("MOVE") {
+		_, err := c.execute(fmt.Sprintf("UID MOVE %v %v", uid, quote(mailbox)))
+		return err
+	}
+	if _, err := c.execute(fmt.Sprintf("UID COPY %v %v", uid, quote(mailbox))); err != nil {
+		return err
+	}
+	if _, err := c.execute(fmt.Sprintf(`UID STORE %v +FLAGS.SILENT (\Deleted)`, uid)); err != nil {
+		return err
+	}
+	if !c.hasCapability("UIDPLUS") {
+		return nil
+	}
+	_, err := c.execute(fmt.Sprintf("UID EXPUNGE %v", uid))
+	return err
+}
+
+func (c *client) logout() error {
+	_, _ = c.execute("LOGOUT")
+	return c.conn.Close()
+}
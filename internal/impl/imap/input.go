@@ -0,0 +1,454 @@
+package imap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	iiFieldAddress            = "address"
+	iiFieldTLS                = "tls"
+	iiFieldMailbox            = "mailbox"
+	iiFieldAuth               = "auth"
+	iiFieldAuthMechanism      = "mechanism"
+	iiFieldAuthUsername       = "username"
+	iiFieldAuthPassword       = "password"
+	iiFieldAuthToken          = "token"
+	iiFieldAuthOAuth2         = "oauth2"
+	iiFieldAuthOAuth2Enabled  = "enabled"
+	iiFieldAuthOAuth2ID       = "client_id"
+	iiFieldAuthOAuth2Secret   = "client_secret"
+	iiFieldAuthOAuth2TokenURL = "token_url"
+	iiFieldAuthOAuth2Scopes   = "scopes"
+	iiFieldSearch             = "search"
+	iiFieldPollInterval       = "poll_interval"
+	iiFieldMarkSeen           = "mark_seen"
+	iiFieldMoveTo             = "move_to"
+	iiFieldTimeout            = "timeout"
+	iiAuthMechanismLogin      = "login"
+	iiAuthMechanismXOAUTH2    = "xoauth2"
+)
+
+func imapInputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.20.0").
+		Summary("Polls an IMAP mailbox for emails.").
+		Description(`
+Emails matching the `+"`search`"+` criteria are consumed from a mailbox, and when no emails are found the mailbox is polled again after the `+"`poll_interval`"+`. Each email results in a batch where the first message contains the body of the email, preferring the plain text part over the HTML part when both are present, and subsequent messages contain the attachments of the email.
+
+Emails are fetched without being marked as seen. Once the batch of an email is acknowledged the email is marked as seen when `+"`mark_seen`"+` is true, and then moved to the mailbox `+"`move_to`"+` when it is set. Emails that have not been acknowledged are not consumed again until the input reconnects, and therefore the default search criteria of `+"`UNSEEN`"+` results in at-least-once delivery.
+
+### Authentication
+
+The `+"`login`"+` mechanism authenticates with a username and password. The `+"`xoauth2`"+` mechanism authenticates with an OAuth2 access token, which is required by providers such as Gmail and Office 365. The token can either be set statically with the field `+"`token`"+`, or obtained and refreshed automatically using a client credentials flow configured within `+"`oauth2`"+`.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- imap_uid
+- imap_mailbox
+- email_from
+- email_to
+- email_cc
+- email_subject
+- email_date
+- email_message_id
+- attachment_filename (attachments only)
+- content_type
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).`).
+		Fields(
+			service.NewStringField(iiFieldAddress).
+				Description("The address of the IMAP server to connect to, including the port.").
+				Example("imap.gmail.com:993").
+				Example("outlook.office365.com:993"),
+			service.NewTLSToggledField(iiFieldTLS).
+				Description("Custom TLS settings, most IMAP servers require TLS to be enabled."),
+			service.NewStringField(iiFieldMailbox).
+				Description("The mailbox to consume emails from.").
+				Default("INBOX"),
+			service.NewObjectField(iiFieldAuth,
+				service.NewStringEnumField(iiFieldAuthMechanism, iiAuthMechanismLogin, iiAuthMechanismXOAUTH2).
+					Description("The authentication mechanism to use.").
+					Default(iiAuthMechanismLogin),
+				service.NewStringField(iiFieldAuthUsername).
+					Description("The username to authenticate with.").
+					Default(""),
+				service.NewStringField(iiFieldAuthPassword).
+					Description("A password to authenticate with when the mechanism is `login`.").
+					Default("").
+					Secret(),
+				service.NewStringField(iiFieldAuthToken).
+					Description("A static OAuth2 access token to authenticate with when the mechanism is `xoauth2`.").
+					Default("").
+					Secret(),
+				service.NewObjectField(iiFieldAuthOAuth2,
+					service.NewBoolField(iiFieldAuthOAuth2Enabled).
+						Description("Whether to obtain access tokens with a client credentials flow.").
+						Default(false),
+					service.NewStringField(iiFieldAuthOAuth2ID).
+						Description("The client ID of the application.").
+						Default(""),
+					service.NewStringField(iiFieldAuthOAuth2Secret).
+						Description("The client secret of the application.").
+						Default("").
+						Secret(),
+					service.NewStringField(iiFieldAuthOAuth2TokenURL).
+						Description("The URL of the token endpoint.").
+						Default("").
+						Example("https://login.microsoftonline.com/00000000-0000-0000-0000-000000000000/oauth2/v2.0/token"),
+					service.NewStringListField(iiFieldAuthOAuth2Scopes).
+						Description("A list of scopes to request.").
+						Default([]any{}).
+						Example([]string{"https://outlook.office365.com/.default"}),
+				).
+					Description("Obtain access tokens for the `xoauth2` mechanism with a client credentials flow, taking precedence over a static `token`.").
+					Advanced(),
+			).
+				Description("Authentication with the IMAP server."),
+			service.NewStringField(iiFieldSearch).
+				Description("The [search criteria](https://www.rfc-editor.org/rfc/rfc3501#section-6.4.4) used for finding emails to consume.").
+				Default("UNSEEN").
+				Example(`UNSEEN FROM "alerts@example.com"`).
+				Example("ALL"),
+			service.NewDurationField(iiFieldPollInterval).
+				Description("The period to wait before polling the mailbox again when no emails are found.").
+				Default("30s"),
+			service.NewBoolField(iiFieldMarkSeen).
+				Description("Whether to mark emails as seen once they are acknowledged.").
+				Default(true),
+			service.NewStringField(iiFieldMoveTo).
+				Description("An optional mailbox to move emails to once they are acknowledged. When the server does not support the `MOVE` extension emails are copied and then flagged as deleted, and are only expunged from the original mailbox when the server supports the `UIDPLUS` extension.").
+				Default("").
+				Example("Processed"),
+			service.NewDurationField(iiFieldTimeout).
+				Description("The maximum period to wait for responses from the server.").
+				Default("30s").
+				Advanced(),
+		).
+		Example(
+			"Office 365 Alerts",
+			"Consume alert emails from an Office 365 shared mailbox, moving them into a separate folder once processed.",
+			`
+input:
+  imap:
+    address: outlook.office365.com:993
+    tls:
+      enabled: true
+    search: UNSEEN SUBJECT "Alert"
+    move_to: Processed
+    auth:
+      mechanism: xoauth2
+      username: alerts@example.com
+      oauth2:
+        enabled: true
+        client_id: ${CLIENT_ID}
+        client_secret: ${CLIENT_SECRET}
+        token_url: https://login.microsoftonline.com/${TENANT_ID}/oauth2/v2.0/token
+        scopes: [ https://outlook.office365.com/.default ]
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput("imap", imapInputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			i, err := newIMAPInput(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksBatched(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type imapInput struct {
+	address      string
+	tlsConf      *tls.Config
+	mailbox      string
+	mechanism    string
+	username     string
+	password     string
+	tokenSource  oauth2.TokenSource
+	criteria     string
+	pollInterval time.Duration
+	markSeen     bool
+	moveTo       string
+	timeout      time.Duration
+
+	log *service.Logger
+
+	cMut     sync.Mutex
+	cli      *client
+	pending  []uint32
+	inFlight map[uint32]struct{}
+}
+
+func newIMAPInput(conf *service.ParsedConfig, mgr *service.Resources) (*imapInput, error) {
+	i := &imapInput{
+		log:      mgr.Logger(),
+		inFlight: map[uint32]struct{}{},
+	}
+
+	var err error
+	if i.address, err = conf.FieldString(iiFieldAddress); err != nil {
+		return nil, err
+	}
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled(iiFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		i.tlsConf = tlsConf
+	}
+	if i.mailbox, err = conf.FieldString(iiFieldMailbox); err != nil {
+		return nil, err
+	}
+	if i.criteria, err = conf.FieldString(iiFieldSearch); err != nil {
+		return nil, err
+	}
+	if i.pollInterval, err = conf.FieldDuration(iiFieldPollInterval); err != nil {
+		return nil, err
+	}
+	if i.markSeen, err = conf.FieldBool(iiFieldMarkSeen); err != nil {
+		return nil, err
+	}
+	if i.moveTo, err = conf.FieldString(iiFieldMoveTo); err != nil {
+		return nil, err
+	}
+	if i.timeout, err = conf.FieldDuration(iiFieldTimeout); err != nil {
+		return nil, err
+	}
+
+	authConf := conf.Namespace(iiFieldAuth)
+	if i.mechanism, err = authConf.FieldString(iiFieldAuthMechanism); err != nil {
+		return nil, err
+	}
+	if i.username, err = authConf.FieldString(iiFieldAuthUsername); err != nil {
+		return nil, err
+	}
+	if i.password, err = authConf.FieldString(iiFieldAuthPassword); err != nil {
+		return nil, err
+	}
+	if i.mechanism == iiAuthMechanismXOAUTH2 {
+		if i.tokenSource, err = tokenSourceFromConfig(authConf); err != nil {
+			return nil, err
+		}
+	}
+	return i, nil
+}
+
+func tokenSourceFromConfig(conf *service.ParsedConfig) (oauth2.TokenSource, error) {
+	oConf := conf.Namespace(iiFieldAuthOAuth2)
+	enabled, err := oConf.FieldBool(iiFieldAuthOAuth2Enabled)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		token, err := conf.FieldString(iiFieldAuthToken)
+		if err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return nil, errors.New("the xoauth2 mechanism requires either a token or an oauth2 client credentials flow")
+		}
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), nil
+	}
+
+	var ccConf clientcredentials.Config
+	if ccConf.ClientID, err = oConf.FieldString(iiFieldAuthOAuth2ID); err != nil {
+		return nil, err
+	}
+	if ccConf.ClientSecret, err = oConf.FieldString(iiFieldAuthOAuth2Secret); err != nil {
+		return nil, err
+	}
+	if ccConf.TokenURL, err = oConf.FieldString(iiFieldAuthOAuth2TokenURL); err != nil {
+		return nil, err
+	}
+	if ccConf.Scopes, err = oConf.FieldStringList(iiFieldAuthOAuth2Scopes); err != nil {
+		return nil, err
+	}
+	return ccConf.TokenSource(context.Background()), nil
+}
+
+func (i *imapInput) Connect(ctx context.Context) error {
+	i.cMut.Lock()
+	defer i.cMut.Unlock()
+
+	if i.cli != nil {
+		return nil
+	}
+
+	cli, err := dialClient(i.address, i.tlsConf, i.timeout)
+	if err != nil {
+		return err
+	}
+
+	switch i.mechanism {
+	case iiAuthMechanismXOAUTH2:
+		var token *oauth2.Token
+		if token, err = i.tokenSource.Token(); err != nil {
+			err = fmt.Errorf("failed to obtain access token: %w", err)
+			break
+		}
+		err = cli.authenticateXOAUTH2(i.username, token.AccessToken)
+	default:
+		err = cli.login(i.username, i.password)
+	}
+	if err == nil {
+		err = cli.selectMailbox(i.mailbox)
+	}
+	if err != nil {
+		_ = cli.logout()
+		return err
+	}
+
+	i.cli = cli
+	i.pending = nil
+	i.inFlight = map[uint32]struct{}{}
+	i.log.Infof("Consuming emails from IMAP mailbox %v at %v", i.mailbox, i.address)
+	return nil
+}
+
+// disconnect drops the current connection after a failed command so that the
+// next read triggers a reconnect. Must be called with cMut held.
+func (i *imapInput) disconnect() {
+	if i.cli != nil {
+		_ = i.cli.logout()
+		i.cli = nil
+	}
+}
+
+// nextUID returns the next UID that is not already in flight, searching the
+// mailbox when no UIDs are pending. Returns zero when no emails were found.
+// Must be called with cMut held.
+func (i *imapInput) nextUID() (uint32, error) {
+	if len(i.pending) == 0 {
+		uids, err := i.cli.search(i.criteria)
+		if err != nil {
+			return 0, err
+		}
+		for _, uid := range uids {
+			if _, exists := i.inFlight[uid]; !exists {
+				i.pending = append(i.pending, uid)
+			}
+		}
+	}
+	if len(i.pending) == 0 {
+		return 0, nil
+	}
+	uid := i.pending[0]
+	i.pending = i.pending[1:]
+	return uid, nil
+}
+
+func (i *imapInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for {
+		batch, uid, err := i.readNext()
+		if err != nil {
+			return nil, nil, err
+		}
+		if batch != nil {
+			return batch, func(ctx context.Context, err error) error {
+				// Nacks are handled by AutoRetryNacksBatched.
+				return i.ack(uid)
+			}, nil
+		}
+
+		select {
+		case <-time.After(i.pollInterval):
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+func (i *imapInput) readNext() (service.MessageBatch, uint32, error) {
+	i.cMut.Lock()
+	defer i.cMut.Unlock()
+
+	if i.cli == nil {
+		return nil, 0, service.ErrNotConnected
+	}
+
+	uid, err := i.nextUID()
+	if err != nil {
+		i.log.Errorf("Failed to search mailbox: %v", err)
+		i.disconnect()
+		return nil, 0, service.ErrNotConnected
+	}
+	if uid == 0 {
+		return nil, 0, nil
+	}
+
+	raw, err := i.cli.fetch(uid)
+	if err != nil {
+		i.log.Errorf("Failed to fetch email %v: %v", uid, err)
+		i.disconnect()
+		return nil, 0, service.ErrNotConnected
+	}
+
+	batch, err := parseEmail(raw)
+	if err != nil {
+		// Emails that can't be parsed are still consumed in their raw form
+		// rather than blocking the mailbox.
+		i.log.Warnf("Failed to parse email %v: %v", uid, err)
+		batch = service.MessageBatch{service.NewMessage(raw)}
+	}
+	for _, msg := range batch {
+		msg.MetaSetMut("imap_uid", int64(uid))
+		msg.MetaSetMut("imap_mailbox", i.mailbox)
+	}
+
+	i.inFlight[uid] = struct{}{}
+	return batch, uid, nil
+}
+
+func (i *imapInput) ack(uid uint32) error {
+	i.cMut.Lock()
+	defer i.cMut.Unlock()
+
+	if _, exists := i.inFlight[uid]; !exists || i.cli == nil {
+		// The connection was reset since the email was read, in which case it
+		// remains in the mailbox and will be consumed again.
+		return service.ErrNotConnected
+	}
+
+	var err error
+	if i.markSeen {
+		err = i.cli.markSeen(uid)
+	}
+	if err == nil && i.moveTo != "" {
+		err = i.cli.move(uid, i.moveTo)
+	}
+	if err != nil {
+		i.disconnect()
+		return fmt.Errorf("failed to update email %v: %w", uid, err)
+	}
+	delete(i.inFlight, uid)
+	return nil
+}
+
+func (i *imapInput) Close(ctx context.Context) error {
+	i.cMut.Lock()
+	defer i.cMut.Unlock()
+
+	i.disconnect()
+	return nil
+}
@@ -0,0 +1,336 @@
+package imap
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type fakeEmail struct {
+	raw     string
+	seen    bool
+	mailbox string
+}
+
+// fakeIMAPServer implements just enough of IMAP4rev1 to exercise the input.
+type fakeIMAPServer struct {
+	t        *testing.T
+	listener net.Listener
+	caps     string
+
+	mut    sync.Mutex
+	emails map[uint32]*fakeEmail
+	auths  []string
+	cmds   []string
+}
+
+func newFakeIMAPServer(t *testing.T, caps string, emails ...string) *fakeIMAPServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	s := &fakeIMAPServer{t: t, listener: l, caps: caps, emails: map[uint32]*fakeEmail{}}
+	for i, e := range emails {
+		s.emails[uint32(i+1)] = &fakeEmail{raw: e, mailbox: "INBOX"}
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeIMAPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	write := func(format string, args ...any) {
+		_, _ = fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+	write("* OK fake server ready")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(strings.TrimSpace(line))
+		if len(fields) < 2 {
+			continue
+		}
+		tag, cmd, args := fields[0], strings.ToUpper(fields[1]), fields[2:]
+		if cmd == "UID" && len(args) > 0 {
+			cmd, args = "UID "+strings.ToUpper(args[0]), args[1:]
+		}
+
+		s.mut.Lock()
+		s.cmds = append(s.cmds, cmd)
+		switch cmd {
+		case "CAPABILITY":
+			write("* CAPABILITY IMAP4rev1 %v", s.caps)
+		case "LOGIN":
+			s.auths = append(s.auths, "login "+strings.Join(args, " "))
+		case "AUTHENTICATE":
+			ir, _ := base64.StdEncoding.DecodeString(args[1])
+			s.auths = append(s.auths, "xoauth2 "+string(ir))
+		case "SELECT":
+			write("* %v EXISTS", len(s.emails))
+		case "UID SEARCH":
+			var uids []int
+			for uid, e := range s.emails {
+				if e.mailbox == "INBOX" && !e.seen {
+					uids = append(uids, int(uid))
+				}
+			}
+			sort.Ints(uids)
+			var strs []string
+			for _, uid := range uids {
+				strs = append(strs, strconv.Itoa(uid))
+			}
+			write("* SEARCH %v", strings.Join(strs, " "))
+		case "UID FETCH":
+			uid, _ := strconv.Atoi(args[0])
+			if e, exists := s.emails[uint32(uid)]; exists {
+				write("* %v FETCH (UID %v BODY[] {%v}", uid, uid, len(e.raw))
+				_, _ = conn.Write([]byte(e.raw))
+				write(")")
+			}
+		case "UID STORE":
+			uid, _ := strconv.Atoi(args[0])
+			if e, exists := s.emails[uint32(uid)]; exists && strings.Contains(line, `\Seen`) {
+				e.seen = true
+			}
+		case "UID MOVE":
+			uid, _ := strconv.Atoi(args[0])
+			if e, exists := s.emails[uint32(uid)]; exists {
+				e.mailbox = strings.Trim(args[1], `"`)
+			}
+		case "LOGOUT":
+			write("* BYE")
+			write("%v OK done", tag)
+			s.mut.Unlock()
+			return
+		}
+		write("%v OK done", tag)
+		s.mut.Unlock()
+	}
+}
+
+func (s *fakeIMAPServer) email(uid uint32) fakeEmail {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return *s.emails[uid]
+}
+
+func (s *fakeIMAPServer) commands() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]string(nil), s.cmds...)
+}
+
+func (s *fakeIMAPServer) authentications() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]string(nil), s.auths...)
+}
+
+func testIMAPInput(t *testing.T, confStr string) *imapInput {
+	t.Helper()
+
+	pConf, err := imapInputSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	i, err := newIMAPInput(pConf, service.MockResources())
+	require.NoError(t, err)
+
+	require.NoError(t, i.Connect(context.Background()))
+	t.Cleanup(func() { _ = i.Close(context.Background()) })
+	return i
+}
+
+const testMultipartEmail = "From: Alice <alice@example.com>\r\n" +
+	"To: bob@example.com\r\n" +
+	"Subject: =?UTF-8?Q?Caf=C3=A9_report?=\r\n" +
+	"Message-ID: <abc@example.com>\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p>hello world</p>\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"hello =3D world\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/csv\r\n" +
+	"Content-Disposition: attachment; filename=\"report.csv\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"YSxiCjEs\r\n" +
+	"Mgo=\r\n" +
+	"--outer--\r\n"
+
+func TestIMAPInputReadAndAck(t *testing.T) {
+	srv := newFakeIMAPServer(t, "MOVE", testMultipartEmail, "Subject: plain\r\n\r\njust text\r\n")
+	i := testIMAPInput(t, `
+address: `+srv.listener.Addr().String()+`
+auth:
+  username: bob
+  password: hunter2
+move_to: Done
+poll_interval: 10ms
+`)
+
+	assert.Equal(t, []string{`login "bob" "hunter2"`}, srv.authentications())
+
+	batch, ackFn, err := i.ReadBatch(context.Background())
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+
+	body, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello = world", strings.TrimSpace(string(body)))
+
+	for k, v := range map[string]any{
+		"imap_uid":         int64(1),
+		"imap_mailbox":     "INBOX",
+		"email_from":       "Alice <alice@example.com>",
+		"email_to":         "bob@example.com",
+		"email_subject":    "Café report",
+		"email_message_id": "<abc@example.com>",
+		"content_type":     "text/plain",
+	} {
+		actual, exists := batch[0].MetaGetMut(k)
+		require.True(t, exists, k)
+		assert.Equal(t, v, actual, k)
+	}
+
+	attachment, err := batch[1].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", string(attachment))
+	filename, _ := batch[1].MetaGet("attachment_filename")
+	assert.Equal(t, "report.csv", filename)
+	contentType, _ := batch[1].MetaGet("content_type")
+	assert.Equal(t, "text/csv", contentType)
+	subject, _ := batch[1].MetaGet("email_subject")
+	assert.Equal(t, "Café report", subject)
+
+	// The first email is in flight and must not be read again before it is
+	// acknowledged.
+	batch2, ackFn2, err := i.ReadBatch(context.Background())
+	require.NoError(t, err)
+	require.Len(t, batch2, 1)
+	body, err = batch2[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "just text", strings.TrimSpace(string(body)))
+
+	assert.False(t, srv.email(1).seen)
+	require.NoError(t, ackFn(context.Background(), nil))
+	assert.True(t, srv.email(1).seen)
+	assert.Equal(t, "Done", srv.email(1).mailbox)
+
+	require.NoError(t, ackFn2(context.Background(), nil))
+	assert.True(t, srv.email(2).seen)
+
+	ctx, done := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer done()
+	_, _, err = i.ReadBatch(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestIMAPInputXOAUTH2(t *testing.T) {
+	srv := newFakeIMAPServer(t, "AUTH=XOAUTH2")
+	testIMAPInput(t, `
+address: `+srv.listener.Addr().String()+`
+auth:
+  mechanism: xoauth2
+  username: bob@example.com
+  token: footoken
+`)
+
+	assert.Equal(t, []string{"xoauth2 user=bob@example.com\x01auth=Bearer footoken\x01\x01"}, srv.authentications())
+}
+
+func TestIMAPInputXOAUTH2RequiresToken(t *testing.T) {
+	pConf, err := imapInputSpec().ParseYAML(`
+address: localhost:993
+auth:
+  mechanism: xoauth2
+  username: bob@example.com
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newIMAPInput(pConf, service.MockResources())
+	require.Error(t, err)
+}
+
+func TestIMAPClientLiteralLimits(t *testing.T) {
+	for _, line := range []string{
+		"* 1 FETCH (UID 1 BODY[] {-1}\r\n",
+		"* 1 FETCH (UID 1 BODY[] {99999999999}\r\n",
+	} {
+		c := &client{r: bufio.NewReader(strings.NewReader(line))}
+		_, err := c.readResponse()
+		require.Error(t, err, line)
+		assert.Contains(t, err.Error(), "exceeds the limit", line)
+	}
+}
+
+func TestIMAPInputMoveFallback(t *testing.T) {
+	for _, test := range []struct {
+		caps     string
+		expunged bool
+	}{
+		{caps: "", expunged: false},
+		{caps: "UIDPLUS", expunged: true},
+	} {
+		srv := newFakeIMAPServer(t, test.caps, "Subject: plain\r\n\r\njust text\r\n")
+		i := testIMAPInput(t, `
+address: `+srv.listener.Addr().String()+`
+auth:
+  username: bob
+  password: hunter2
+move_to: Done
+poll_interval: 10ms
+`)
+
+		_, ackFn, err := i.ReadBatch(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, ackFn(context.Background(), nil))
+
+		cmds := srv.commands()
+		assert.Contains(t, cmds, "UID COPY", test.caps)
+		assert.NotContains(t, cmds, "EXPUNGE", test.caps)
+		if test.expunged {
+			assert.Contains(t, cmds, "UID EXPUNGE", test.caps)
+		} else {
+			assert.NotContains(t, cmds, "UID EXPUNGE", test.caps)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package imap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type emailPart struct {
+	contentType string
+	filename    string
+	body        []byte
+}
+
+var headerDecoder = &mime.WordDecoder{}
+
+func decodeHeader(v string) string {
+	if d, err := headerDecoder.DecodeHeader(v); err == nil {
+		return d
+	}
+	return v
+}
+
+// decodeTransfer decodes a part body according to its
+// Content-Transfer-Encoding header.
+func decodeTransfer(encoding string, r io.Reader) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		// Line breaks within base64 bodies are ignored by the decoder, but any
+		// other whitespace is not.
+		raw = bytes.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' {
+				return -1
+			}
+			return r
+		}, raw)
+		return io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(raw)))
+	case "quoted-printable":
+		return io.ReadAll(quotedprintable.NewReader(r))
+	}
+	return io.ReadAll(r)
+}
+
+// collectParts walks the MIME tree of an entity, appending leaf parts.
+func collectParts(header textproto.MIMEHeader, body io.Reader, parts []emailPart) ([]emailPart, error) {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "application/octet-stream", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		boundary := params["boundary"]
+		if boundary == "" {
+			return nil, errors.New("multipart entity is missing a boundary")
+		}
+		mr := multipart.NewReader(body, boundary)
+		for {
+			p, err := mr.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return parts, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if parts, err = collectParts(p.Header, p, parts); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	data, err := decodeTransfer(header.Get("Content-Transfer-Encoding"), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %v part: %w", mediaType, err)
+	}
+
+	var filename string
+	if disp, dParams, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil {
+		filename = dParams["filename"]
+		if filename == "" && disp == "attachment" {
+			filename = "attachment"
+		}
+	}
+	if filename == "" {
+		filename = params["name"]
+	}
+	return append(parts, emailPart{
+		contentType: mediaType,
+		filename:    decodeHeader(filename),
+		body:        data,
+	}), nil
+}
+
+// parseEmail converts a raw email into a batch where the first message is the
+// body of the email and each subsequent message is an attachment.
+func parseEmail(raw []byte) (service.MessageBatch, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := collectParts(textproto.MIMEHeader(m.Header), m.Body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyIndex := -1
+	for idx, p := range parts {
+		if p.filename != "" {
+			continue
+		}
+		if p.contentType == "text/plain" {
+			bodyIndex = idx
+			break
+		}
+		if p.contentType == "text/html" && bodyIndex == -1 {
+			bodyIndex = idx
+		}
+	}
+
+	bodyMsg := service.NewMessage(nil)
+	bodyMsg.MetaSetMut("content_type", "text/plain")
+	if bodyIndex >= 0 {
+		bodyMsg.SetBytes(parts[bodyIndex].body)
+		bodyMsg.MetaSetMut("content_type", parts[bodyIndex].contentType)
+	}
+	batch := service.MessageBatch{bodyMsg}
+	for _, p := range parts {
+		if p.filename == "" {
+			continue
+		}
+		msg := service.NewMessage(p.body)
+		msg.MetaSetMut("content_type", p.contentType)
+		msg.MetaSetMut("attachment_filename", p.filename)
+		batch = append(batch, msg)
+	}
+
+	for _, msg := range batch {
+		msg.MetaSetMut("email_from", decodeHeader(m.Header.Get("From")))
+		msg.MetaSetMut("email_to", decodeHeader(m.Header.Get("To")))
+		msg.MetaSetMut("email_cc", decodeHeader(m.Header.Get("Cc")))
+		msg.MetaSetMut("email_subject", decodeHeader(m.Header.Get("Subject")))
+		msg.MetaSetMut("email_date", m.Header.Get("Date"))
+		msg.MetaSetMut("email_message_id", m.Header.Get("Message-Id"))
+	}
+	return batch, nil
+}
@@ -0,0 +1,617 @@
+//go:build !wasm
+
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	fwFieldPaths           = "paths"
+	fwFieldPollInterval    = "poll_interval"
+	fwFieldStartFromOldest = "start_from_oldest"
+	fwFieldMaxBuffer       = "max_buffer"
+	fwFieldCheckpointCache = "checkpoint_cache"
+	fwFieldCheckpointKey   = "checkpoint_key_prefix"
+)
+
+func fileWatcherInputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Local").
+		Version("4.20.0").
+		Summary("Continuously tails files on disk that match glob patterns, emitting each line as a message.").
+		Description(`
+Files matching the `+"`paths`"+` are tailed for as long as the input runs, with each line written to a file emitted as a message. Lines that have not been terminated with a newline are held until the remainder of the line is written. The paths are expanded periodically so that files created after the input starts are discovered, and these files are consumed from their beginning.
+
+Changes to files are detected via filesystem notifications where supported, and otherwise by checking files for changes every `+"`poll_interval`"+`.
+
+### Rotation
+
+When a file is renamed or removed and a new file is created at its path the remaining lines of the old file are consumed before the new file is consumed from its beginning. When a file is truncated it is consumed again from its beginning.
+
+### Checkpoints
+
+When a `+"`checkpoint_cache`"+` is configured the offset of each file is stored within the cache once the messages of all lines up to that offset have been acknowledged, under a key consisting of the `+"`checkpoint_key_prefix`"+` followed by the path of the file. When the input starts, files with a stored checkpoint resume from it, which means lines may be consumed more than once but lines that were not delivered are never skipped.
+
+Alongside the offset the checkpoint stores a fingerprint of the file, which is a hash of up to its first 1KB. A file that is smaller than its checkpoint or has a different fingerprint is assumed to have been replaced whilst the input was not running, and is consumed from its beginning. Files that begin with the same content, such as a common header longer than the fingerprint, cannot be told apart.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- path
+- offset
+`+"```"+`
+
+The field `+"`offset`"+` is the byte offset of the line within the file.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).`).
+		Fields(
+			service.NewStringListField(fwFieldPaths).
+				Description("A list of paths to tail. Glob patterns are supported, including super globs (double star).").
+				Example([]any{"/var/log/app/*.log"}),
+			service.NewDurationField(fwFieldPollInterval).
+				Description("The period of time between expanding the paths in order to discover new files, and between checking files for changes when filesystem notifications are not available.").
+				Default("1s").
+				Advanced(),
+			service.NewBoolField(fwFieldStartFromOldest).
+				Description("Whether to consume files that exist when the input starts from their beginning, otherwise only lines written after the input starts are consumed. Files with a stored checkpoint always resume from it.").
+				Default(true),
+			service.NewIntField(fwFieldMaxBuffer).
+				Description("The maximum size of a line in bytes, lines that exceed this size are split into multiple messages.").
+				Default(1000000).
+				Advanced(),
+			service.NewStringField(fwFieldCheckpointCache).
+				Description("An optional [cache resource](/docs/components/caches/about) to store the offsets of files within, allowing the input to resume where it left off when restarted.").
+				Optional(),
+			service.NewStringField(fwFieldCheckpointKey).
+				Description("A prefix for the keys under which the offsets of files are stored within the `checkpoint_cache`.").
+				Default("file_watcher_").
+				Advanced(),
+		).
+		Example(
+			"Log Shipper",
+			"In this example the logs of an application are tailed, including log files created by rotation, with offsets stored in a file cache so that the input resumes where it left off after a restart.",
+			`
+input:
+  file_watcher:
+    paths: [ /var/log/app/*.log ]
+    checkpoint_cache: offsets
+  processors:
+    - mapping: |
+        root.message = content().string()
+        root.file = meta("path")
+
+cache_resources:
+  - label: offsets
+    file:
+      directory: /var/lib/benthos/offsets
+
+output:
+  stdout: {}
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterInput("file_watcher", fileWatcherInputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newFileWatcherInputFromParsed(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// fileTail is the state of the tailing of a single path, which persists across
+// rotations of the file at the path.
+type fileTail struct {
+	path    string
+	wake    chan struct{}
+	cp      *service.CacheCheckpointer
+	active  bool
+	resumed bool
+}
+
+// fileFingerprintSize is the maximum number of bytes at the beginning of a file
+// that are hashed in order to identify it.
+const fileFingerprintSize = 1024
+
+// fileIdentity is a fingerprint of a file, which is the hash of the first Size
+// bytes of the file.
+type fileIdentity struct {
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Size        int64  `json:"fingerprint_size,omitempty"`
+}
+
+// fileCheckpoint is the value stored within the checkpoint cache for a path.
+type fileCheckpoint struct {
+	Offset int64 `json:"offset"`
+	fileIdentity
+}
+
+func fingerprintFile(r io.ReaderAt, size int64) (fileIdentity, error) {
+	b := make([]byte, size)
+	if _, err := r.ReadAt(b, 0); err != nil && !errors.Is(err, io.EOF) {
+		return fileIdentity{}, err
+	}
+	sum := sha256.Sum256(b)
+	return fileIdentity{Fingerprint: hex.EncodeToString(sum[:]), Size: size}, nil
+}
+
+type fileLine struct {
+	msg   *service.Message
+	ackFn service.AckFunc
+}
+
+type fileWatcherInput struct {
+	paths           []string
+	pollInterval    time.Duration
+	startFromOldest bool
+	maxBuffer       int
+	cacheName       string
+	keyPrefix       string
+
+	res     *service.Resources
+	fs      *service.FS
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+
+	tailsMut sync.Mutex
+	tails    map[string]*fileTail
+	tailsWG  sync.WaitGroup
+
+	connMut sync.Mutex
+	msgChan chan fileLine
+}
+
+func newFileWatcherInputFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*fileWatcherInput, error) {
+	w := &fileWatcherInput{
+		res:     mgr,
+		fs:      mgr.FS(),
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+		tails:   map[string]*fileTail{},
+	}
+
+	var err error
+	if w.paths, err = conf.FieldStringList(fwFieldPaths); err != nil {
+		return nil, err
+	}
+	if len(w.paths) == 0 {
+		return nil, errors.New("at least one path must be specified")
+	}
+	if w.pollInterval, err = conf.FieldDuration(fwFieldPollInterval); err != nil {
+		return nil, err
+	}
+	if w.pollInterval <= 0 {
+		return nil, errors.New("poll_interval must be greater than zero")
+	}
+	if w.startFromOldest, err = conf.FieldBool(fwFieldStartFromOldest); err != nil {
+		return nil, err
+	}
+	if w.maxBuffer, err = conf.FieldInt(fwFieldMaxBuffer); err != nil {
+		return nil, err
+	}
+	if w.maxBuffer <= 0 {
+		return nil, errors.New("max_buffer must be greater than zero")
+	}
+	if conf.Contains(fwFieldCheckpointCache) {
+		if w.cacheName, err = conf.FieldString(fwFieldCheckpointCache); err != nil {
+			return nil, err
+		}
+		if !mgr.HasCache(w.cacheName) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", w.cacheName)
+		}
+	}
+	if w.keyPrefix, err = conf.FieldString(fwFieldCheckpointKey); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *fileWatcherInput) Connect(ctx context.Context) error {
+	w.connMut.Lock()
+	defer w.connMut.Unlock()
+	if w.msgChan != nil {
+		return nil
+	}
+
+	msgChan := make(chan fileLine)
+	go func() {
+		runCtx, done := w.shutSig.CloseAtLeisureCtx(context.Background())
+		defer func() {
+			done()
+			w.tailsWG.Wait()
+			w.shutSig.ShutdownComplete()
+		}()
+		w.watch(runCtx, msgChan)
+	}()
+
+	w.msgChan = msgChan
+	w.log.Infof("Tailing files matching %v", strings.Join(w.paths, ", "))
+	return nil
+}
+
+// watch periodically expands the paths and starts tailing any files that are
+// not already being tailed, and forwards filesystem notifications to the
+// tails of the files they concern.
+func (w *fileWatcherInput) watch(ctx context.Context, msgChan chan<- fileLine) {
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	watchedDirs := map[string]struct{}{}
+
+	notifier, err := fsnotify.NewWatcher()
+	if err != nil {
+		w.log.Warnf("Filesystem notifications are not available, falling back to polling: %v", err)
+	} else {
+		defer notifier.Close()
+		events, errs = notifier.Events, notifier.Errors
+	}
+	watchDir := func(dir string) {
+		if notifier == nil {
+			return
+		}
+		if _, exists := watchedDirs[dir]; exists {
+			return
+		}
+		if err := notifier.Add(dir); err != nil {
+			w.log.Debugf("Failed to watch directory '%v' for changes: %v", dir, err)
+			return
+		}
+		watchedDirs[dir] = struct{}{}
+	}
+	for _, p := range w.paths {
+		if dir := staticDir(p); dir != "" {
+			watchDir(dir)
+		}
+	}
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	initial := true
+	for {
+		paths, err := ifilepath.Globs(w.fs, w.paths)
+		if err != nil {
+			w.log.Errorf("Failed to expand paths: %v", err)
+		}
+		for _, p := range paths {
+			info, err := w.fs.Stat(p)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			watchDir(filepath.Dir(p))
+			w.startTail(ctx, msgChan, p, initial && !w.startFromOldest)
+		}
+		initial = false
+
+		select {
+		case <-ticker.C:
+		case event := <-events:
+			w.tailsMut.Lock()
+			t, exists := w.tails[filepath.Clean(event.Name)]
+			w.tailsMut.Unlock()
+			if exists {
+				select {
+				case t.wake <- struct{}{}:
+				default:
+				}
+			}
+			if !event.Has(fsnotify.Create) {
+				continue
+			}
+		case err := <-errs:
+			w.log.Debugf("Filesystem notification error: %v", err)
+			continue
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// staticDir returns the longest directory of a path pattern that does not
+// contain glob characters.
+func staticDir(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, `*?[\`) {
+		next := filepath.Dir(dir)
+		if next == dir {
+			return ""
+		}
+		dir = next
+	}
+	return dir
+}
+
+func (w *fileWatcherInput) startTail(ctx context.Context, msgChan chan<- fileLine, path string, fromEnd bool) {
+	w.tailsMut.Lock()
+	defer w.tailsMut.Unlock()
+
+	t, exists := w.tails[path]
+	if exists && t.active {
+		return
+	}
+	if !exists {
+		t = &fileTail{
+			path: path,
+			wake: make(chan struct{}, 1),
+		}
+		if w.cacheName != "" {
+			var err error
+			if t.cp, err = service.NewCacheCheckpointer(w.res, w.cacheName, w.keyPrefix+path, 1024); err != nil {
+				w.log.Errorf("Failed to create checkpointer for file '%v': %v", path, err)
+				return
+			}
+		}
+		w.tails[path] = t
+	}
+	t.active = true
+
+	w.tailsWG.Add(1)
+	go func() {
+		defer func() {
+			w.tailsMut.Lock()
+			t.active = false
+			w.tailsMut.Unlock()
+			w.tailsWG.Done()
+		}()
+		w.tailFile(ctx, msgChan, t, fromEnd)
+	}()
+}
+
+// tailFile consumes the file at the path of a tail until it is removed,
+// following it across rotations.
+func (w *fileWatcherInput) tailFile(ctx context.Context, msgChan chan<- fileLine, t *fileTail, fromEnd bool) {
+	var resumeFrom fileCheckpoint
+	if !t.resumed {
+		t.resumed = true
+		if t.cp != nil {
+			cpBytes, err := t.cp.Get(ctx)
+			if err != nil {
+				w.log.Errorf("Failed to read checkpoint of file '%v': %v", t.path, err)
+				return
+			}
+			if cpBytes != nil {
+				if err := json.Unmarshal(cpBytes, &resumeFrom); err != nil {
+					w.log.Errorf("Failed to parse checkpoint of file '%v': %v", t.path, err)
+					return
+				}
+				fromEnd = false
+			}
+		}
+	}
+
+	for {
+		replaced, err := w.consumeFile(ctx, msgChan, t, resumeFrom, fromEnd)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.log.Errorf("Failed to tail file '%v': %v", t.path, err)
+			}
+			return
+		}
+		if !replaced {
+			w.log.Debugf("Stopped tailing file '%v' as it was removed", t.path)
+			return
+		}
+		w.log.Infof("File '%v' was rotated, consuming new file from the beginning", t.path)
+		resumeFrom, fromEnd = fileCheckpoint{}, false
+	}
+}
+
+// consumeFile reads lines from the file currently at a path until it has been
+// fully consumed after being rotated or removed. Returns true if the path has
+// been replaced by another file.
+func (w *fileWatcherInput) consumeFile(ctx context.Context, msgChan chan<- fileLine, t *fileTail, resumeFrom fileCheckpoint, fromEnd bool) (bool, error) {
+	f, err := w.fs.Open(t.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	seeker, ok := f.(io.Seeker)
+	if !ok {
+		return false, errors.New("file does not support seeking")
+	}
+	// Files that cannot be read at an offset are not fingerprinted, and their
+	// checkpoints are trusted as long as they're within the size of the file.
+	readerAt, _ := f.(io.ReaderAt)
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	offset := resumeFrom.Offset
+	if fromEnd {
+		offset = info.Size()
+	} else if offset > info.Size() {
+		w.log.Warnf("File '%v' is smaller than its checkpoint, consuming from the beginning", t.path)
+		offset = 0
+	} else if resumeFrom.Size > 0 && readerAt != nil {
+		id, err := fingerprintFile(readerAt, resumeFrom.Size)
+		if err != nil {
+			return false, err
+		}
+		if id != resumeFrom.fileIdentity {
+			w.log.Warnf("File '%v' does not match its checkpoint, consuming from the beginning", t.path)
+			offset = 0
+		}
+	}
+
+	// The fingerprint of the file covers only the bytes that have been
+	// consumed, and so it grows with the offset until it reaches its maximum
+	// size.
+	var id fileIdentity
+	emit := func(line []byte) error {
+		if end := offset + int64(len(line)); readerAt != nil && id.Size < fileFingerprintSize && end > id.Size {
+			var err error
+			if id, err = fingerprintFile(readerAt, min(end, fileFingerprintSize)); err != nil {
+				return err
+			}
+		}
+		return w.emitLine(ctx, msgChan, t, line, offset, id)
+	}
+	if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	rdr := bufio.NewReader(f)
+	var line []byte
+	var replaced, removed bool
+	for {
+		b, err := rdr.ReadSlice('\n')
+		line = append(line, b...)
+		if err == nil || len(line) >= w.maxBuffer {
+			if err := emit(line); err != nil {
+				return false, err
+			}
+			offset += int64(len(line))
+			line = nil
+			continue
+		}
+		if !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+			return false, err
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+
+		// The remaining lines of a rotated or removed file have now been
+		// consumed, excluding any unterminated line, which is flushed.
+		if replaced || removed {
+			if len(line) > 0 {
+				if err := emit(line); err != nil {
+					return false, err
+				}
+			}
+			return replaced, nil
+		}
+
+		select {
+		case <-t.wake:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+
+		pathInfo, err := w.fs.Stat(t.path)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return false, err
+			}
+			removed = true
+			continue
+		}
+		if !os.SameFile(info, pathInfo) {
+			replaced = true
+			continue
+		}
+		if pathInfo.Size() < offset+int64(len(line)) {
+			w.log.Infof("File '%v' was truncated, consuming from the beginning", t.path)
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return false, err
+			}
+			rdr.Reset(f)
+			offset, line, id = 0, nil, fileIdentity{}
+		}
+	}
+}
+
+func (w *fileWatcherInput) emitLine(ctx context.Context, msgChan chan<- fileLine, t *fileTail, line []byte, offset int64, id fileIdentity) error {
+	next := offset + int64(len(line))
+	line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+
+	msg := service.NewMessage(line)
+	msg.MetaSetMut("path", t.path)
+	msg.MetaSetMut("offset", offset)
+
+	ackFn := func(context.Context, error) error {
+		// Nacks are handled by AutoRetryNacks.
+		return nil
+	}
+	if t.cp != nil {
+		cpBytes, err := json.Marshal(fileCheckpoint{Offset: next, fileIdentity: id})
+		if err != nil {
+			return err
+		}
+		if ackFn, err = t.cp.Track(ctx, cpBytes, 1, nil); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case msgChan <- fileLine{msg: msg, ackFn: ackFn}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (w *fileWatcherInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	w.connMut.Lock()
+	msgChan := w.msgChan
+	w.connMut.Unlock()
+	if msgChan == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	select {
+	case l := <-msgChan:
+		return l.msg, l.ackFn, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (w *fileWatcherInput) Close(ctx context.Context) error {
+	go func() {
+		w.shutSig.CloseAtLeisure()
+		w.connMut.Lock()
+		if w.msgChan == nil {
+			// Indicates that we were never connected, so indicate shutdown is
+			// complete.
+			w.shutSig.ShutdownComplete()
+		}
+		w.connMut.Unlock()
+	}()
+	select {
+	case <-w.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+//go:build !wasm
+
+package io
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testFileWatcher(t *testing.T, res *service.Resources, confStr string, args ...any) *fileWatcherInput {
+	t.Helper()
+
+	conf, err := fileWatcherInputSpec().ParseYAML(fmt.Sprintf(confStr, args...), nil)
+	require.NoError(t, err)
+
+	w, err := newFileWatcherInputFromParsed(conf, res)
+	require.NoError(t, err)
+	require.NoError(t, w.Connect(context.Background()))
+	t.Cleanup(func() {
+		ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+		defer done()
+		require.NoError(t, w.Close(ctx))
+	})
+	return w
+}
+
+func readFileWatcherLine(t *testing.T, w *fileWatcherInput) (string, string) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, ackFn, err := w.Read(ctx)
+	require.NoError(t, err)
+	require.NoError(t, ackFn(ctx, nil))
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	path, _ := msg.MetaGet("path")
+	return string(b), filepath.Base(path)
+}
+
+func appendToFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestFileWatcherTail(t *testing.T) {
+	dir := t.TempDir()
+	appendToFile(t, filepath.Join(dir, "a.log"), "a1\na2\n")
+
+	w := testFileWatcher(t, service.MockResources(), `
+paths: [ "%v/*.log" ]
+poll_interval: 10ms
+`, dir)
+
+	for _, exp := range []string{"a1", "a2"} {
+		line, path := readFileWatcherLine(t, w)
+		assert.Equal(t, exp, line)
+		assert.Equal(t, "a.log", path)
+	}
+
+	// Partial lines are held until they are terminated.
+	appendToFile(t, filepath.Join(dir, "a.log"), "a3 part")
+	appendToFile(t, filepath.Join(dir, "a.log"), " two\r\n")
+	line, _ := readFileWatcherLine(t, w)
+	assert.Equal(t, "a3 part two", line)
+
+	// New files are discovered and consumed from the beginning.
+	appendToFile(t, filepath.Join(dir, "b.log"), "b1\n")
+	line, path := readFileWatcherLine(t, w)
+	assert.Equal(t, "b1", line)
+	assert.Equal(t, "b.log", path)
+}
+
+func TestFileWatcherRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "first\n")
+
+	w := testFileWatcher(t, service.MockResources(), `
+paths: [ "%v" ]
+poll_interval: 10ms
+`, path)
+
+	line, _ := readFileWatcherLine(t, w)
+	assert.Equal(t, "first", line)
+
+	// Rename the file and keep writing to it before creating a new file.
+	appendToFile(t, path, "second\n")
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendToFile(t, path+".1", "third\n")
+	appendToFile(t, path, "fourth\n")
+
+	for _, exp := range []string{"second", "third", "fourth"} {
+		line, _ := readFileWatcherLine(t, w)
+		assert.Equal(t, exp, line)
+	}
+
+	// Truncated files are consumed from the beginning.
+	require.NoError(t, os.Truncate(path, 0))
+	time.Sleep(time.Millisecond * 50)
+	appendToFile(t, path, "fifth\n")
+
+	line, _ = readFileWatcherLine(t, w)
+	assert.Equal(t, "fifth", line)
+}
+
+func TestFileWatcherStartFromEnd(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "old\n")
+
+	w := testFileWatcher(t, service.MockResources(), `
+paths: [ "%v" ]
+poll_interval: 10ms
+start_from_oldest: false
+`, path)
+
+	time.Sleep(time.Millisecond * 50)
+	appendToFile(t, path, "new\n")
+
+	line, _ := readFileWatcherLine(t, w)
+	assert.Equal(t, "new", line)
+}
+
+func TestFileWatcherCheckpoints(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "a\nb\n")
+
+	res := service.MockResources(service.MockResourcesOptAddCache("offsets"))
+	confStr := `
+paths: [ "%v" ]
+poll_interval: 10ms
+checkpoint_cache: offsets
+`
+
+	w := testFileWatcher(t, res, confStr, path)
+	for _, exp := range []string{"a", "b"} {
+		line, _ := readFileWatcherLine(t, w)
+		assert.Equal(t, exp, line)
+	}
+
+	var cp []byte
+	require.NoError(t, res.AccessCache(context.Background(), "offsets", func(c service.Cache) {
+		var err error
+		cp, err = c.Get(context.Background(), "file_watcher_"+path)
+		require.NoError(t, err)
+	}))
+	var fcp fileCheckpoint
+	require.NoError(t, json.Unmarshal(cp, &fcp))
+	assert.Equal(t, int64(4), fcp.Offset)
+	assert.Equal(t, int64(4), fcp.Size)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+	require.NoError(t, w.Close(ctx))
+
+	// A new input resumes from the checkpoint.
+	appendToFile(t, path, "c\n")
+	w = testFileWatcher(t, res, confStr, path)
+
+	line, _ := readFileWatcherLine(t, w)
+	assert.Equal(t, "c", line)
+}
+
+func TestFileWatcherCheckpointReplaced(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "a\nb\n")
+
+	res := service.MockResources(service.MockResourcesOptAddCache("offsets"))
+	confStr := `
+paths: [ "%v" ]
+poll_interval: 10ms
+checkpoint_cache: offsets
+`
+
+	w := testFileWatcher(t, res, confStr, path)
+	for _, exp := range []string{"a", "b"} {
+		line, _ := readFileWatcherLine(t, w)
+		assert.Equal(t, exp, line)
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+	require.NoError(t, w.Close(ctx))
+
+	// The file is rotated whilst the input isn't running, and the new file is
+	// larger than the checkpoint.
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendToFile(t, path, "cccc\ndddd\n")
+	w = testFileWatcher(t, res, confStr, path)
+
+	for _, exp := range []string{"cccc", "dddd"} {
+		line, _ := readFileWatcherLine(t, w)
+		assert.Equal(t, exp, line)
+	}
+}
@@ -1,6 +1,7 @@
 package io
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/tls"
@@ -20,6 +21,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/klauspost/compress/gzip"
+	"go.opentelemetry.io/otel/propagation"
 
 	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
@@ -55,6 +57,10 @@ const (
 	hsiFieldResponseStatus          = "status"
 	hsiFieldResponseHeaders         = "headers"
 	hsiFieldResponseExtractMetadata = "metadata_headers"
+	hsiFieldStream                  = "stream"
+	hsiFieldStreamEnabled           = "enabled"
+	hsiFieldStreamChunkSize         = "chunk_size"
+	hsiFieldPropagateTrace          = "propagate_trace_context"
 )
 
 type hsiConfig struct {
@@ -70,6 +76,13 @@ type hsiConfig struct {
 	KeyFile            string
 	CORS               httpserver.CORSConfig
 	Response           hsiResponseConfig
+	Stream             hsiStreamConfig
+	PropagateTrace     bool
+}
+
+type hsiStreamConfig struct {
+	Enabled   bool
+	ChunkSize int
 }
 
 type hsiResponseConfig struct {
@@ -126,6 +139,25 @@ func hsiConfigFromParsed(pConf *service.ParsedConfig) (conf hsiConfig, err error
 	if conf.Response, err = hsiResponseConfigFromParsed(pConf.Namespace(hsiFieldResponse)); err != nil {
 		return
 	}
+	if conf.Stream, err = hsiStreamConfigFromParsed(pConf.Namespace(hsiFieldStream)); err != nil {
+		return
+	}
+	if conf.PropagateTrace, err = pConf.FieldBool(hsiFieldPropagateTrace); err != nil {
+		return
+	}
+	return
+}
+
+func hsiStreamConfigFromParsed(pConf *service.ParsedConfig) (conf hsiStreamConfig, err error) {
+	if conf.Enabled, err = pConf.FieldBool(hsiFieldStreamEnabled); err != nil {
+		return
+	}
+	if conf.ChunkSize, err = pConf.FieldInt(hsiFieldStreamChunkSize); err != nil {
+		return
+	}
+	if conf.ChunkSize <= 0 {
+		err = errors.New("stream chunk_size must be greater than zero")
+	}
 	return
 }
 
@@ -179,7 +211,13 @@ The following fields specify endpoints that are registered for sending messages,
 
 This endpoint expects POST requests where the entire request body is consumed as a single message.
 
-If the request contains a multipart `+"`content-type`"+` header as per [rfc1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html) then the multiple parts are consumed as a batch of messages, where each body part is a message of the batch.
+If the request contains a multipart `+"`content-type`"+` header as per [rfc1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html) then the multiple parts are consumed as a batch of messages, where each body part is a message of the batch. This includes `+"`multipart/form-data`"+` requests such as file uploads, where the form field name, filename and content type of each part are added to its message as metadata.
+
+#### Streaming
+
+By default request bodies are read in full before being consumed, which can be problematic for large uploads. When `+"`stream.enabled`"+` is set to `+"`true`"+` bodies are instead read in chunks of up to `+"`stream.chunk_size`"+` bytes, where each chunk is consumed as a message of its own and the next chunk is only read once the previous has been delivered. For multipart requests each part is chunked in the same way.
+
+Each chunk has the metadata fields `+"`http_server_chunk_index`"+`, the index of the chunk within its body or part starting from zero, and `+"`http_server_chunk_final`"+`, which is `+"`true`"+` for the last chunk of a body or part. Synchronous responses are not supported when streaming, and a request that has been partially consumed at the point of a failure is responded to with an error status, in which case the chunks that were already delivered are not retracted.
 
 #### `+"`ws_path` (defaults to `/post/ws`)"+`
 
@@ -200,6 +238,11 @@ This input adds the following metadata fields to each message:
 - http_server_request_path
 - http_server_verb
 - http_server_remote_ip
+- http_server_part_field_name (multipart requests only)
+- http_server_part_filename (multipart requests only)
+- http_server_part_content_type (multipart requests only)
+- http_server_chunk_index (streamed requests only)
+- http_server_chunk_final (streamed requests only)
 - All headers (only first values are taken)
 - All query parameters
 - All path parameters
@@ -266,6 +309,22 @@ You can access these metadata fields using [function interpolation](/docs/config
 			).
 				Description("Customise messages returned via [synchronous responses](/docs/guides/sync_responses).").
 				Advanced(),
+			service.NewObjectField(hsiFieldStream,
+				service.NewBoolField(hsiFieldStreamEnabled).
+					Description("Whether to consume request bodies in chunks rather than reading them in full.").
+					Default(false),
+				service.NewIntField(hsiFieldStreamChunkSize).
+					Description("The maximum size in bytes of each chunk.").
+					Default(1048576),
+			).
+				Description("Stream large request bodies as a sequence of chunked messages rather than buffering them in memory.").
+				Advanced().
+				Version("4.20.0"),
+			service.NewBoolField(hsiFieldPropagateTrace).
+				Description("Whether to extract [W3C trace context](https://www.w3.org/TR/trace-context/) from the `traceparent` and `tracestate` headers of requests. When enabled messages consumed from requests containing these headers are traced as children of the remote span rather than as new roots, which includes the messages of websocket connections opened by such requests.").
+				Advanced().
+				Version("4.20.0").
+				Default(false),
 		)
 }
 
@@ -403,7 +462,9 @@ func (h *httpServerInput) extractMessageFromRequest(r *http.Request) (message.Ba
 			if msgBytes, err = io.ReadAll(p); err != nil {
 				return nil, err
 			}
-			msg = append(msg, message.NewPart(msgBytes))
+			part := message.NewPart(msgBytes)
+			addPartMetadata(part, p)
+			msg = append(msg, part)
 		}
 	} else {
 		var msgBytes []byte
@@ -414,50 +475,28 @@ func (h *httpServerInput) extractMessageFromRequest(r *http.Request) (message.Ba
 	}
 
 	_ = msg.Iter(func(i int, p *message.Part) error {
-		p.MetaSetMut("http_server_user_agent", r.UserAgent())
-		p.MetaSetMut("http_server_request_path", r.URL.Path)
-		p.MetaSetMut("http_server_verb", r.Method)
-		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
-			p.MetaSetMut("http_server_remote_ip", host)
-		}
-
-		if r.TLS != nil {
-			var tlsVersion string
-			switch r.TLS.Version {
-			case tls.VersionTLS10:
-				tlsVersion = "TLSv1.0"
-			case tls.VersionTLS11:
-				tlsVersion = "TLSv1.1"
-			case tls.VersionTLS12:
-				tlsVersion = "TLSv1.2"
-			case tls.VersionTLS13:
-				tlsVersion = "TLSv1.3"
-			}
-			p.MetaSetMut("http_server_tls_version", tlsVersion)
-			if len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
-				p.MetaSetMut("http_server_tls_subject", r.TLS.VerifiedChains[0][0].Subject.String())
-			}
-			p.MetaSetMut("http_server_tls_cipher_suite", tls.CipherSuiteName(r.TLS.CipherSuite))
-		}
-		for k, v := range r.Header {
-			if len(v) > 0 {
-				p.MetaSetMut(k, v[0])
-			}
-		}
-		for k, v := range r.URL.Query() {
-			if len(v) > 0 {
-				p.MetaSetMut(k, v[0])
-			}
-		}
-		for k, v := range mux.Vars(r) {
-			p.MetaSetMut(k, v)
-		}
-		for _, c := range r.Cookies() {
-			p.MetaSetMut(c.Name, c.Value)
-		}
+		addRequestMetadata(p, r)
 		return nil
 	})
 
+	h.initSpans(r, msg)
+	return msg, nil
+}
+
+// extractTraceContext attaches the W3C trace context of a request to the
+// messages consumed from it when trace propagation is enabled.
+func (h *httpServerInput) extractTraceContext(r *http.Request, msg message.Batch) {
+	if !h.conf.PropagateTrace {
+		return
+	}
+	for i, p := range msg {
+		msg[i] = p.WithContext(tracing.ExtractW3CTraceContext(p.GetContext(), propagation.HeaderCarrier(r.Header)))
+	}
+}
+
+func (h *httpServerInput) initSpans(r *http.Request, msg message.Batch) {
+	h.extractTraceContext(r, msg)
+
 	textMapGeneric := map[string]any{}
 	for k, vals := range r.Header {
 		for _, v := range vals {
@@ -466,7 +505,65 @@ func (h *httpServerInput) extractMessageFromRequest(r *http.Request) (message.Ba
 	}
 
 	_ = tracing.InitSpansFromParentTextMap(h.mgr.Tracer(), "input_http_server_post", textMapGeneric, msg)
-	return msg, nil
+}
+
+// addRequestMetadata adds metadata describing an HTTP request to a message.
+func addRequestMetadata(p *message.Part, r *http.Request) {
+	p.MetaSetMut("http_server_user_agent", r.UserAgent())
+	p.MetaSetMut("http_server_request_path", r.URL.Path)
+	p.MetaSetMut("http_server_verb", r.Method)
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		p.MetaSetMut("http_server_remote_ip", host)
+	}
+
+	if r.TLS != nil {
+		var tlsVersion string
+		switch r.TLS.Version {
+		case tls.VersionTLS10:
+			tlsVersion = "TLSv1.0"
+		case tls.VersionTLS11:
+			tlsVersion = "TLSv1.1"
+		case tls.VersionTLS12:
+			tlsVersion = "TLSv1.2"
+		case tls.VersionTLS13:
+			tlsVersion = "TLSv1.3"
+		}
+		p.MetaSetMut("http_server_tls_version", tlsVersion)
+		if len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			p.MetaSetMut("http_server_tls_subject", r.TLS.VerifiedChains[0][0].Subject.String())
+		}
+		p.MetaSetMut("http_server_tls_cipher_suite", tls.CipherSuiteName(r.TLS.CipherSuite))
+	}
+	for k, v := range r.Header {
+		if len(v) > 0 {
+			p.MetaSetMut(k, v[0])
+		}
+	}
+	for k, v := range r.URL.Query() {
+		if len(v) > 0 {
+			p.MetaSetMut(k, v[0])
+		}
+	}
+	for k, v := range mux.Vars(r) {
+		p.MetaSetMut(k, v)
+	}
+	for _, c := range r.Cookies() {
+		p.MetaSetMut(c.Name, c.Value)
+	}
+}
+
+// addPartMetadata adds metadata describing a part of a multipart request to a
+// message.
+func addPartMetadata(p *message.Part, mp *multipart.Part) {
+	if name := mp.FormName(); name != "" {
+		p.MetaSetMut("http_server_part_field_name", name)
+	}
+	if filename := mp.FileName(); filename != "" {
+		p.MetaSetMut("http_server_part_filename", filename)
+	}
+	if contentType := mp.Header.Get("Content-Type"); contentType != "" {
+		p.MetaSetMut("http_server_part_content_type", contentType)
+	}
 }
 
 func (h *httpServerInput) postHandler(w http.ResponseWriter, r *http.Request) {
@@ -500,13 +597,21 @@ func (h *httpServerInput) postHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if h.conf.Stream.Enabled {
+		h.streamHandler(w, r)
+		return
+	}
+
 	msg, err := h.extractMessageFromRequest(r)
 	if err != nil {
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		h.log.Warnf("Request read failed: %v\n", err)
 		return
 	}
-	defer tracing.FinishSpans(msg)
+	var outcome error
+	defer func() {
+		tracing.CompleteSpans(h.mgr.Tracer(), h.mgr.Label(), msg, outcome)
+	}()
 
 	startedAt := time.Now()
 
@@ -532,6 +637,7 @@ func (h *httpServerInput) postHandler(w http.ResponseWriter, r *http.Request) {
 
 	select {
 	case res, open := <-resChan:
+		outcome = res
 		if !open {
 			http.Error(w, "Server closing", http.StatusServiceUnavailable)
 			return
@@ -652,6 +758,124 @@ func (h *httpServerInput) postHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// streamHandler consumes the body of a request as a sequence of chunks, where
+// each chunk is delivered as a message before the next chunk is read.
+func (h *httpServerInput) streamHandler(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		h.log.Warnf("Request read failed: %v\n", err)
+		return
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		if status, err := h.streamChunks(r, r.Body, nil); err != nil {
+			http.Error(w, err.Error(), status)
+		}
+		return
+	}
+
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			h.log.Warnf("Request read failed: %v\n", err)
+			return
+		}
+		if status, err := h.streamChunks(r, p, p); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+}
+
+// streamChunks reads a body in chunks and delivers each chunk as a message,
+// returning the status code to respond with upon failure.
+func (h *httpServerInput) streamChunks(r *http.Request, body io.Reader, mp *multipart.Part) (int, error) {
+	br := bufio.NewReader(body)
+	for index := 0; ; index++ {
+		chunk := make([]byte, h.conf.Stream.ChunkSize)
+		n, err := io.ReadFull(br, chunk)
+
+		final := false
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			final = true
+		} else if err != nil {
+			h.log.Warnf("Request read failed: %v\n", err)
+			return http.StatusBadRequest, errors.New("bad request")
+		} else if _, err := br.Peek(1); errors.Is(err, io.EOF) {
+			final = true
+		}
+
+		part := message.NewPart(chunk[:n])
+		addRequestMetadata(part, r)
+		if mp != nil {
+			addPartMetadata(part, mp)
+		}
+		part.MetaSetMut("http_server_chunk_index", int64(index))
+		part.MetaSetMut("http_server_chunk_final", final)
+
+		if status, err := h.deliverChunk(r, message.Batch{part}); err != nil {
+			return status, err
+		}
+		if final {
+			return 0, nil
+		}
+	}
+}
+
+// deliverChunk sends a chunk of a streamed request through the pipeline and
+// waits for it to be acknowledged.
+func (h *httpServerInput) deliverChunk(r *http.Request, msg message.Batch) (int, error) {
+	h.initSpans(r, msg)
+
+	var outcome error
+	defer func() {
+		tracing.CompleteSpans(h.mgr.Tracer(), h.mgr.Label(), msg, outcome)
+	}()
+
+	startedAt := time.Now()
+	h.mPostRcvd.Incr(1)
+
+	resChan := make(chan error, 1)
+	select {
+	case h.transactions <- message.NewTransaction(msg, resChan):
+	case <-time.After(h.conf.Timeout):
+		return http.StatusRequestTimeout, errors.New("request timed out")
+	case <-r.Context().Done():
+		return http.StatusRequestTimeout, errors.New("request timed out")
+	case <-h.shutSig.CloseAtLeisureChan():
+		return http.StatusServiceUnavailable, errors.New("server closing")
+	}
+
+	select {
+	case res, open := <-resChan:
+		outcome = res
+		if !open {
+			return http.StatusServiceUnavailable, errors.New("server closing")
+		} else if res != nil {
+			return http.StatusBadGateway, res
+		}
+		h.mLatency.Timing(time.Since(startedAt).Nanoseconds())
+	case <-time.After(h.conf.Timeout):
+		return http.StatusRequestTimeout, errors.New("request timed out")
+	case <-r.Context().Done():
+		return http.StatusRequestTimeout, errors.New("request timed out")
+	case <-h.shutSig.CloseNowChan():
+		return http.StatusServiceUnavailable, errors.New("server closing")
+	}
+	return 0, nil
+}
+
 func (h *httpServerInput) wsHandler(w http.ResponseWriter, r *http.Request) {
 	h.handlerWG.Add(1)
 	defer h.handlerWG.Done()
@@ -732,6 +956,7 @@ func (h *httpServerInput) wsHandler(w http.ResponseWriter, r *http.Request) {
 		for _, c := range r.Cookies() {
 			part.MetaSetMut(c.Name, c.Value)
 		}
+		h.extractTraceContext(r, msg)
 		tracing.InitSpans(h.mgr.Tracer(), "input_http_server_websocket", msg)
 
 		store := transaction.NewResultStore()
@@ -742,12 +967,13 @@ func (h *httpServerInput) wsHandler(w http.ResponseWriter, r *http.Request) {
 		case <-h.shutSig.CloseAtLeisureChan():
 			return
 		}
+		var outcome error
 		select {
 		case res, open := <-resChan:
 			if !open {
 				return
 			}
-			if res != nil {
+			if outcome = res; res != nil {
 				throt.Retry()
 			} else {
 				tTaken := time.Since(startedAt).Nanoseconds()
@@ -767,7 +993,7 @@ func (h *httpServerInput) wsHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		tracing.FinishSpans(msg)
+		tracing.CompleteSpans(h.mgr.Tracer(), h.mgr.Label(), msg, outcome)
 	}
 }
 
@@ -21,6 +21,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/api"
@@ -357,6 +358,79 @@ http_server:
 	assert.Contains(t, "bar", part.MetaGetStr("foo"))
 }
 
+func TestHTTPServerPropagateTraceContext(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	traceHeader := http.Header{}
+	traceHeader.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	for _, propagate := range []bool{true, false} {
+		reg := apiRegGorillaMutWrapper{mut: mux.NewRouter()}
+		mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(reg))
+		require.NoError(t, err)
+
+		conf := parseYAMLInputConf(t, `
+http_server:
+  path: /testpost
+  ws_path: /testws
+  propagate_trace_context: %v
+`, propagate)
+
+		server, err := mgr.NewInput(conf)
+		require.NoError(t, err)
+
+		testServer := httptest.NewServer(reg.mut)
+
+		readTraceID := func() string {
+			t.Helper()
+			var tran message.Transaction
+			select {
+			case tran = <-server.TransactionChan():
+				require.NoError(t, tran.Ack(tCtx, nil))
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out")
+			}
+			traceID := trace.SpanContextFromContext(tran.Payload.Get(0).GetContext()).TraceID()
+			if !traceID.IsValid() {
+				return ""
+			}
+			return traceID.String()
+		}
+
+		if propagate {
+			go func() {
+				req, cerr := http.NewRequest("POST", testServer.URL+"/testpost", bytes.NewReader([]byte("hello world")))
+				require.NoError(t, cerr)
+				req.Header = traceHeader.Clone()
+				resp, cerr := http.DefaultClient.Do(req)
+				require.NoError(t, cerr)
+				resp.Body.Close()
+			}()
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", readTraceID())
+		}
+
+		purl, err := url.Parse(testServer.URL + "/testws")
+		require.NoError(t, err)
+		purl.Scheme = "ws"
+
+		client, _, err := websocket.DefaultDialer.Dial(purl.String(), traceHeader.Clone())
+		require.NoError(t, err)
+		require.NoError(t, client.WriteMessage(websocket.BinaryMessage, []byte("hello world")))
+
+		if propagate {
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", readTraceID())
+		} else {
+			assert.Empty(t, readTraceID())
+		}
+
+		require.NoError(t, client.Close())
+		server.TriggerCloseNow()
+		assert.NoError(t, server.WaitForClose(tCtx))
+		testServer.Close()
+	}
+}
+
 func TestHTTPServerPathParameters(t *testing.T) {
 	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
 	defer done()
@@ -1320,3 +1394,175 @@ http_server:
 	assert.Equal(t, "200 OK", resp.Status)
 	assert.Equal(t, "foo", resp.Header.Get("Access-Control-Allow-Origin"))
 }
+
+func TestHTTPServerMultipartFormData(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	reg := apiRegGorillaMutWrapper{mut: mux.NewRouter()}
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(reg))
+	require.NoError(t, err)
+
+	conf := parseYAMLInputConf(t, `
+http_server:
+  path: /upload
+`)
+
+	h, err := mgr.NewInput(conf)
+	require.NoError(t, err)
+	defer func() {
+		h.TriggerStopConsuming()
+		assert.NoError(t, h.WaitForClose(tCtx))
+	}()
+
+	server := httptest.NewServer(reg.mut)
+	defer server.Close()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	require.NoError(t, writer.WriteField("description", "some files"))
+	fw, err := writer.CreateFormFile("upload", "foo.txt")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("foo contents"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	go func() {
+		res, err := http.Post(server.URL+"/upload", writer.FormDataContentType(), body)
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			res.Body.Close()
+		}
+	}()
+
+	var ts message.Transaction
+	select {
+	case ts = <-h.TransactionChan():
+	case <-time.After(time.Second * 5):
+		t.Fatal("Timed out waiting for message")
+	}
+
+	require.Equal(t, 2, ts.Payload.Len())
+
+	p := ts.Payload.Get(0)
+	assert.Equal(t, "some files", string(p.AsBytes()))
+	assert.Equal(t, "description", p.MetaGetStr("http_server_part_field_name"))
+	assert.Equal(t, "", p.MetaGetStr("http_server_part_filename"))
+
+	p = ts.Payload.Get(1)
+	assert.Equal(t, "foo contents", string(p.AsBytes()))
+	assert.Equal(t, "upload", p.MetaGetStr("http_server_part_field_name"))
+	assert.Equal(t, "foo.txt", p.MetaGetStr("http_server_part_filename"))
+	assert.Equal(t, "application/octet-stream", p.MetaGetStr("http_server_part_content_type"))
+	assert.Equal(t, "POST", p.MetaGetStr("http_server_verb"))
+
+	require.NoError(t, ts.Ack(tCtx, nil))
+}
+
+func TestHTTPServerStream(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	reg := apiRegGorillaMutWrapper{mut: mux.NewRouter()}
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(reg))
+	require.NoError(t, err)
+
+	conf := parseYAMLInputConf(t, `
+http_server:
+  path: /upload
+  stream:
+    enabled: true
+    chunk_size: 4
+`)
+
+	h, err := mgr.NewInput(conf)
+	require.NoError(t, err)
+	defer func() {
+		h.TriggerStopConsuming()
+		assert.NoError(t, h.WaitForClose(tCtx))
+	}()
+
+	server := httptest.NewServer(reg.mut)
+	defer server.Close()
+
+	type chunk struct {
+		content  string
+		index    any
+		final    any
+		filename string
+	}
+
+	readChunks := func(n int, ackErr error) (chunks []chunk) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			var ts message.Transaction
+			select {
+			case ts = <-h.TransactionChan():
+			case <-time.After(time.Second * 5):
+				t.Fatal("Timed out waiting for message")
+			}
+			require.Equal(t, 1, ts.Payload.Len())
+			p := ts.Payload.Get(0)
+			index, _ := p.MetaGetMut("http_server_chunk_index")
+			final, _ := p.MetaGetMut("http_server_chunk_final")
+			chunks = append(chunks, chunk{
+				content:  string(p.AsBytes()),
+				index:    index,
+				final:    final,
+				filename: p.MetaGetStr("http_server_part_filename"),
+			})
+			require.NoError(t, ts.Ack(tCtx, ackErr))
+		}
+		return
+	}
+
+	// A body that is a multiple of the chunk size.
+	resChan := make(chan int, 1)
+	go func() {
+		res, err := http.Post(server.URL+"/upload", "text/plain", bytes.NewBufferString("abcdefgh"))
+		if assert.NoError(t, err) {
+			res.Body.Close()
+			resChan <- res.StatusCode
+		}
+	}()
+	assert.Equal(t, []chunk{
+		{content: "abcd", index: int64(0), final: false},
+		{content: "efgh", index: int64(1), final: true},
+	}, readChunks(2, nil))
+	assert.Equal(t, http.StatusOK, <-resChan)
+
+	// A multipart body where each part is chunked.
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	fw, err := writer.CreateFormFile("upload", "foo.txt")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("foobar"))
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteField("description", "x"))
+	require.NoError(t, writer.Close())
+
+	go func() {
+		res, err := http.Post(server.URL+"/upload", writer.FormDataContentType(), body)
+		if assert.NoError(t, err) {
+			res.Body.Close()
+			resChan <- res.StatusCode
+		}
+	}()
+	assert.Equal(t, []chunk{
+		{content: "foob", index: int64(0), final: false, filename: "foo.txt"},
+		{content: "ar", index: int64(1), final: true, filename: "foo.txt"},
+		{content: "x", index: int64(0), final: true},
+	}, readChunks(3, nil))
+	assert.Equal(t, http.StatusOK, <-resChan)
+
+	// A rejected chunk results in an error response.
+	go func() {
+		res, err := http.Post(server.URL+"/upload", "text/plain", bytes.NewBufferString("abcdefgh"))
+		if assert.NoError(t, err) {
+			res.Body.Close()
+			resChan <- res.StatusCode
+		}
+	}()
+	readChunks(1, errors.New("nope"))
+	assert.Equal(t, http.StatusBadGateway, <-resChan)
+}
@@ -26,12 +26,12 @@ func init() {
 		Name:    "socket",
 		Summary: `Connects to a tcp or unix socket and consumes a continuous stream of messages.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("network", "A network type to assume (unix|tcp).").HasOptions(
-				"unix", "tcp",
+			docs.FieldString("network", "A network type to assume.").HasOptions(
+				"unix", "tcp", "unixpacket",
 			),
 			docs.FieldString("address", "The address to connect to.", "/tmp/benthos.sock", "127.0.0.1:6000"),
 			codec.ReaderDocs.AtVersion("3.42.0"),
-			docs.FieldInt("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed.").Advanced(),
+			docs.FieldInt("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed, messages that exceed this size when using the `lines`, `delim`, `delim-escaped` or `length-prefixed` codecs result in an error and the connection being reset.").Advanced(),
 		).ChildDefaultAndTypesFromStruct(input.NewSocketConfig()),
 		Categories: []string{
 			"Network",
@@ -66,7 +66,7 @@ type socketReader struct {
 
 func newSocketReader(conf input.SocketConfig, logger log.Modular) (*socketReader, error) {
 	switch conf.Network {
-	case "tcp", "unix":
+	case "tcp", "unix", "unixpacket":
 	default:
 		return nil, fmt.Errorf("socket network '%v' is not supported by this input", conf.Network)
 	}
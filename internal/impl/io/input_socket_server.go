@@ -13,6 +13,7 @@ import (
 	"io"
 	"math/big"
 	"net"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -35,18 +36,49 @@ func init() {
 		Name:    "socket_server",
 		Summary: `Creates a server that receives a stream of messages over a tcp, udp or unix socket.`,
 		Description: `
-The field ` + "`max_buffer`" + ` specifies the maximum amount of memory to allocate _per connection_ for buffering lines of data. If a line of data from a connection exceeds this value then the connection will be closed.`,
+The field ` + "`max_buffer`" + ` specifies the maximum amount of memory to allocate _per connection_ for buffering lines of data. If a line of data from a connection exceeds this value then the connection will be closed.
+
+### Connection Limits
+
+For stream based networks the fields ` + "`max_connections`" + `, ` + "`max_bytes_per_second`" + ` and ` + "`idle_timeout`" + ` can be used in order to protect the server from misbehaving clients. Connections accepted whilst the maximum number of connections are open are closed immediately, the rate limit is applied to each connection individually, and connections that do not send any data within the idle timeout are closed.
+
+### Unix Sockets
+
+The networks ` + "`unixgram`" + ` and ` + "`unixpacket`" + ` can be used in order to receive unix datagram and sequenced packet (` + "`SOCK_SEQPACKET`" + `) sockets respectively. The ` + "`unixgram`" + ` socket file is removed when the input shuts down.
+
+### Metadata
+
+On Linux, messages received over the ` + "`unix`" + `, ` + "`unixpacket`" + ` and ` + "`unixgram`" + ` networks are annotated with the credentials of the process that sent them:
+
+` + "```text" + `
+- peer_pid
+- peer_uid
+- peer_gid
+` + "```" + `
+
+For the ` + "`unixgram`" + ` network the credentials are those of the sender of the most recently read datagram.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).
+
+### Client Authentication
+
+When the ` + "`network`" + ` is set to ` + "`tls`" + ` the field ` + "`tls.client_ca_file`" + ` can be set in order to require clients to present a certificate signed by one of the provided authorities. Access can be further restricted to specific clients by listing the common names of their certificates in ` + "`tls.allowed_client_common_names`" + `.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("network", "A network type to accept.").HasOptions(
-				"unix", "tcp", "udp", "tls",
+				"unix", "tcp", "udp", "tls", "unixgram", "unixpacket",
 			),
 			docs.FieldString("address", "The address to listen from.", "/tmp/benthos.sock", "0.0.0.0:6000"),
 			codec.ReaderDocs.AtVersion("3.42.0"),
-			docs.FieldInt("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed.").Advanced(),
+			docs.FieldInt("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed, messages that exceed this size when using the `lines`, `delim`, `delim-escaped` or `length-prefixed` codecs result in an error and the connection being reset.").Advanced(),
+			docs.FieldInt("max_connections", "The maximum number of connections to be open at any given time, connections that are accepted beyond this limit are closed immediately. Set to `0` in order to allow unlimited connections.").Advanced().HasDefault(0).AtVersion("4.20.0"),
+			docs.FieldInt("max_bytes_per_second", "The maximum number of bytes to read per second from each connection. Set to `0` in order to disable rate limiting.").Advanced().HasDefault(0).AtVersion("4.20.0"),
+			docs.FieldString("idle_timeout", "The maximum period of time to wait for data from a connection before closing it. Set to an empty string in order to disable the timeout.", "30s", "5m").Advanced().HasDefault("").AtVersion("4.20.0"),
 			docs.FieldObject("tls", "TLS specific configuration, valid when the `network` is set to `tls`.").WithChildren(
 				docs.FieldString("cert_file", "PEM encoded certificate for use with TLS.").HasDefault(""),
 				docs.FieldString("key_file", "PEM encoded private key for use with TLS.").HasDefault(""),
 				docs.FieldBool("self_signed", "Whether to generate self signed certificates.").HasDefault(false),
+				docs.FieldString("client_ca_file", "An optional file containing PEM encoded certificate authorities, when set clients must present a certificate signed by one of them.").Advanced().HasDefault("").AtVersion("4.20.0"),
+				docs.FieldString("allowed_client_common_names", "An optional list of client certificate common names that are allowed to connect, requires `client_ca_file` to be set. When empty any client with a valid certificate is allowed.").Array().Advanced().HasDefault([]any{}).AtVersion("4.20.0"),
 			),
 		).ChildDefaultAndTypesFromStruct(input.NewSocketConfig()),
 		Categories: []string{
@@ -67,14 +99,64 @@ func (w *wrapPacketConn) Read(p []byte) (n int, err error) {
 	return
 }
 
+// credPacketConn reads datagrams from a unixgram socket and keeps track of the
+// credentials of the most recent sender.
+type credPacketConn struct {
+	*net.UnixConn
+	oob []byte
+
+	mut   sync.Mutex
+	creds *peerCredentials
+}
+
+func (w *credPacketConn) Read(p []byte) (n int, err error) {
+	var oobn int
+	if n, oobn, _, _, err = w.ReadMsgUnix(p, w.oob); err != nil {
+		return
+	}
+	creds, _ := parseDatagramCredentials(w.oob[:oobn])
+
+	w.mut.Lock()
+	w.creds = creds
+	w.mut.Unlock()
+	return
+}
+
+func (w *credPacketConn) lastCredentials() *peerCredentials {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.creds
+}
+
+// peerCredentials describes the process on the other end of a unix socket.
+type peerCredentials struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+func (c *peerCredentials) setMetadata(parts []*message.Part) {
+	if c == nil {
+		return
+	}
+	for _, p := range parts {
+		p.MetaSetMut("peer_pid", int64(c.PID))
+		p.MetaSetMut("peer_uid", int64(c.UID))
+		p.MetaSetMut("peer_gid", int64(c.GID))
+	}
+}
+
 type socketServerInput struct {
 	conf  input.SocketServerConfig
 	stats metrics.Type
 	log   log.Modular
 
-	codecCtor codec.ReaderConstructor
-	listener  net.Listener
-	conn      net.PacketConn
+	codecCtor   codec.ReaderConstructor
+	listener    net.Listener
+	conn        net.PacketConn
+	credConn    *credPacketConn
+	idleTimeout time.Duration
+	connSlots   chan struct{}
 
 	retriesMut   sync.RWMutex
 	transactions chan message.Transaction
@@ -101,10 +183,20 @@ func newSocketServerInput(conf input.Config, mgr bundle.NewManagement, log log.M
 		return nil, err
 	}
 
+	var idleTimeout time.Duration
+	if sconf.IdleTimeout != "" {
+		if idleTimeout, err = time.ParseDuration(sconf.IdleTimeout); err != nil {
+			return nil, fmt.Errorf("failed to parse idle_timeout: %w", err)
+		}
+	}
+	if len(sconf.TLS.AllowedClientCNs) > 0 && sconf.TLS.ClientCAFile == "" {
+		return nil, errors.New("allowed_client_common_names requires client_ca_file to be set")
+	}
+
 	switch sconf.Network {
-	case "tcp", "unix":
+	case "tcp", "unix", "unixpacket":
 		ln, err = net.Listen(sconf.Network, sconf.Address)
-	case "udp":
+	case "udp", "unixgram":
 		cn, err = net.ListenPacket(sconf.Network, sconf.Address)
 	case "tls":
 		var cert tls.Certificate
@@ -114,6 +206,9 @@ func newSocketServerInput(conf input.Config, mgr bundle.NewManagement, log log.M
 		config := &tls.Config{
 			Certificates: []tls.Certificate{cert},
 		}
+		if err = applyClientAuth(config, sconf.TLS); err != nil {
+			return nil, err
+		}
 		ln, err = tls.Listen("tcp", sconf.Address, config)
 	default:
 		return nil, fmt.Errorf("socket network '%v' is not supported by this input", sconf.Network)
@@ -127,9 +222,10 @@ func newSocketServerInput(conf input.Config, mgr bundle.NewManagement, log log.M
 		stats: stats,
 		log:   log,
 
-		codecCtor: ctor,
-		listener:  ln,
-		conn:      cn,
+		codecCtor:   ctor,
+		listener:    ln,
+		conn:        cn,
+		idleTimeout: idleTimeout,
 
 		transactions: make(chan message.Transaction),
 		closedChan:   make(chan struct{}),
@@ -137,6 +233,21 @@ func newSocketServerInput(conf input.Config, mgr bundle.NewManagement, log log.M
 		mRcvd:    stats.GetCounter("input_received"),
 		mLatency: stats.GetTimer("input_latency_ns"),
 	}
+	if uConn, ok := cn.(*net.UnixConn); ok && peerCredentialsSupported {
+		// Enabled before any datagrams can be received as credentials are
+		// only attached to datagrams sent after the option is set.
+		if err := enableDatagramCredentials(uConn); err != nil {
+			log.Warnf("Failed to enable sender credentials of datagrams: %v\n", err)
+		} else {
+			t.credConn = &credPacketConn{
+				UnixConn: uConn,
+				oob:      make([]byte, datagramCredentialsOOBSize),
+			}
+		}
+	}
+	if sconf.MaxConnections > 0 {
+		t.connSlots = make(chan struct{}, sconf.MaxConnections)
+	}
 	t.ctx, t.closeFn = context.WithCancel(context.Background())
 
 	if ln == nil {
@@ -256,6 +367,21 @@ acceptLoop:
 				return
 			}
 		}
+		if t.connSlots != nil {
+			select {
+			case t.connSlots <- struct{}{}:
+			default:
+				t.log.Warnf("Rejecting connection from %v as the maximum of %v connections are open\n", conn.RemoteAddr(), cap(t.connSlots))
+				conn.Close()
+				continue acceptLoop
+			}
+		}
+		var creds *peerCredentials
+		if peerCredentialsSupported && (t.conf.Network == "unix" || t.conf.Network == "unixpacket") {
+			if creds, err = unixPeerCredentials(conn); err != nil {
+				t.log.Warnf("Failed to obtain peer credentials of connection: %v\n", err)
+			}
+		}
 		connCtx, connDone := context.WithCancel(t.ctx)
 		go func() {
 			<-connCtx.Done()
@@ -267,8 +393,19 @@ acceptLoop:
 				connDone()
 				wg.Done()
 				c.Close()
+				if t.connSlots != nil {
+					<-t.connSlots
+				}
 			}()
-			codec, err := t.codecCtor("", c, func(ctx context.Context, err error) error {
+			rdr := &serverConnReader{
+				conn:        c,
+				ctx:         connCtx,
+				idleTimeout: t.idleTimeout,
+			}
+			if t.conf.MaxBytesPerSecond > 0 {
+				rdr.limiter = newByteRateLimiter(t.conf.MaxBytesPerSecond)
+			}
+			codec, err := t.codecCtor("", rdr, func(ctx context.Context, err error) error {
 				return nil
 			})
 			if err != nil {
@@ -285,6 +422,7 @@ acceptLoop:
 					return
 				}
 				t.mRcvd.Incr(int64(len(parts)))
+				creds.setMetadata(parts)
 
 				// We simply bounce rejected messages in a loop downstream so
 				// there's no benefit to aggregating acks.
@@ -305,11 +443,20 @@ func (t *socketServerInput) udpLoop() {
 		// nolint:staticcheck, gocritic // Ignore SA2001 empty critical section, Ignore badLock
 		t.retriesMut.Unlock()
 
+		if t.conf.Network == "unixgram" {
+			_ = os.Remove(t.conf.Address)
+		}
+
 		close(t.transactions)
 		close(t.closedChan)
 	}()
 
-	codec, err := t.codecCtor("", &wrapPacketConn{PacketConn: t.conn}, func(ctx context.Context, err error) error {
+	var connRdr io.ReadCloser = &wrapPacketConn{PacketConn: t.conn}
+	if t.credConn != nil {
+		connRdr = t.credConn
+	}
+
+	codec, err := t.codecCtor("", connRdr, func(ctx context.Context, err error) error {
 		return nil
 	})
 	if err != nil {
@@ -323,7 +470,7 @@ func (t *socketServerInput) udpLoop() {
 		t.conn.Close()
 	}()
 
-	t.log.Infof("Receiving udp socket messages from address: %v\n", t.conn.LocalAddr())
+	t.log.Infof("Receiving %v socket messages from address: %v\n", t.conf.Network, t.conn.LocalAddr())
 
 	for {
 		parts, ackFn, err := codec.Next(t.ctx)
@@ -334,6 +481,9 @@ func (t *socketServerInput) udpLoop() {
 			return
 		}
 		t.mRcvd.Incr(int64(len(parts)))
+		if t.credConn != nil {
+			t.credConn.lastCredentials().setMetadata(parts)
+		}
 
 		// We simply bounce rejected messages in a loop downstream so
 		// there's no benefit to aggregating acks.
@@ -373,6 +523,118 @@ func (t *socketServerInput) WaitForClose(ctx context.Context) error {
 
 //------------------------------------------------------------------------------
 
+// serverConnReader wraps a connection accepted by the socket server in order to
+// enforce per connection idle timeouts and rate limits.
+type serverConnReader struct {
+	conn        net.Conn
+	ctx         context.Context
+	idleTimeout time.Duration
+	limiter     *byteRateLimiter
+}
+
+func (s *serverConnReader) Read(p []byte) (n int, err error) {
+	if s.limiter != nil && len(p) > s.limiter.rate {
+		p = p[:s.limiter.rate]
+	}
+	if s.idleTimeout > 0 {
+		if err = s.conn.SetReadDeadline(time.Now().Add(s.idleTimeout)); err != nil {
+			return 0, err
+		}
+	}
+	if n, err = s.conn.Read(p); err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			err = fmt.Errorf("no data received within idle timeout of %v", s.idleTimeout)
+		}
+	}
+	if n > 0 && s.limiter != nil {
+		if waitErr := s.limiter.take(s.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return
+}
+
+func (s *serverConnReader) Close() error {
+	return s.conn.Close()
+}
+
+// byteRateLimiter is a token bucket that refills at a fixed number of bytes
+// per second, with a burst size of one second worth of bytes.
+type byteRateLimiter struct {
+	rate   int
+	tokens float64
+	last   time.Time
+}
+
+func newByteRateLimiter(bytesPerSecond int) *byteRateLimiter {
+	return &byteRateLimiter{
+		rate:   bytesPerSecond,
+		tokens: float64(bytesPerSecond),
+		last:   time.Now(),
+	}
+}
+
+// take consumes n bytes worth of tokens, blocking until the bucket is no
+// longer in debt or the context is cancelled.
+func (b *byteRateLimiter) take(ctx context.Context, n int) error {
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * float64(b.rate)
+	if b.tokens > float64(b.rate) {
+		b.tokens = float64(b.rate)
+	}
+	b.last = now
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return nil
+	}
+
+	select {
+	case <-time.After(time.Duration(-b.tokens / float64(b.rate) * float64(time.Second))):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func applyClientAuth(config *tls.Config, sconf input.SocketServerTLSConfig) error {
+	if sconf.ClientCAFile == "" {
+		return nil
+	}
+
+	caBytes, err := os.ReadFile(sconf.ClientCAFile)
+	if err != nil {
+		return fmt.Errorf("failed to read client_ca_file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return errors.New("client_ca_file did not contain any valid certificates")
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+
+	if len(sconf.AllowedClientCNs) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]struct{}, len(sconf.AllowedClientCNs))
+	for _, cn := range sconf.AllowedClientCNs {
+		allowed[cn] = struct{}{}
+	}
+	config.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("client certificate required")
+		}
+		cn := cs.PeerCertificates[0].Subject.CommonName
+		if _, exists := allowed[cn]; !exists {
+			return fmt.Errorf("client certificate common name %q is not allowed", cn)
+		}
+		return nil
+	}
+	return nil
+}
+
 func createSelfSignedCertificate() (tls.Certificate, error) {
 	priv, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
 	certOptions := &x509.Certificate{
@@ -2,11 +2,21 @@ package io_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"math/big"
 	"net"
+	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -1306,3 +1316,289 @@ func TestTLSSocketServerBasic(t *testing.T) {
 	wg.Wait()
 	conn.Close()
 }
+
+func TestTCPSocketServerMaxConnections(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*20)
+	defer done()
+
+	conf := input.NewConfig()
+	conf.Type = "socket_server"
+	conf.SocketServer.Network = "tcp"
+	conf.SocketServer.Address = "127.0.0.1:0"
+	conf.SocketServer.MaxConnections = 1
+
+	rdr, err := mock.NewManager().NewInput(conf)
+	require.NoError(t, err)
+
+	addr := rdr.(interface{ Addr() net.Addr }).Addr()
+
+	defer func() {
+		rdr.TriggerStopConsuming()
+		assert.NoError(t, rdr.WaitForClose(tCtx))
+	}()
+
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Second * 5))
+	_, err = conn.Write([]byte("foo\n"))
+	require.NoError(t, err)
+
+	select {
+	case tran := <-rdr.TransactionChan():
+		assert.Equal(t, [][]byte{[]byte("foo")}, message.GetAllBytes(tran.Payload))
+		require.NoError(t, tran.Ack(tCtx, nil))
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	// The second connection is closed immediately by the server.
+	conn2, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	defer conn2.Close()
+
+	_ = conn2.SetReadDeadline(time.Now().Add(time.Second * 5))
+	_, err = conn2.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		assert.False(t, netErr.Timeout())
+	}
+}
+
+func TestTCPSocketServerIdleTimeout(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*20)
+	defer done()
+
+	conf := input.NewConfig()
+	conf.Type = "socket_server"
+	conf.SocketServer.Network = "tcp"
+	conf.SocketServer.Address = "127.0.0.1:0"
+	conf.SocketServer.IdleTimeout = "100ms"
+
+	rdr, err := mock.NewManager().NewInput(conf)
+	require.NoError(t, err)
+
+	addr := rdr.(interface{ Addr() net.Addr }).Addr()
+
+	defer func() {
+		rdr.TriggerStopConsuming()
+		assert.NoError(t, rdr.WaitForClose(tCtx))
+	}()
+
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	_, err = conn.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		assert.False(t, netErr.Timeout())
+	}
+}
+
+func TestTCPSocketServerRateLimit(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*20)
+	defer done()
+
+	conf := input.NewConfig()
+	conf.Type = "socket_server"
+	conf.SocketServer.Network = "tcp"
+	conf.SocketServer.Address = "127.0.0.1:0"
+	conf.SocketServer.MaxBytesPerSecond = 20
+
+	rdr, err := mock.NewManager().NewInput(conf)
+	require.NoError(t, err)
+
+	addr := rdr.(interface{ Addr() net.Addr }).Addr()
+
+	defer func() {
+		rdr.TriggerStopConsuming()
+		assert.NoError(t, rdr.WaitForClose(tCtx))
+	}()
+
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	tStarted := time.Now()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Second * 5))
+	_, err = conn.Write([]byte("foo bar baz buz\nfoo bar baz buz\nfoo bar baz buz\n"))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		select {
+		case tran := <-rdr.TransactionChan():
+			assert.Equal(t, [][]byte{[]byte("foo bar baz buz")}, message.GetAllBytes(tran.Payload))
+			require.NoError(t, tran.Ack(tCtx, nil))
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+
+	// 48 bytes at 20 bytes per second with an initial burst of 20 bytes.
+	assert.Greater(t, time.Since(tStarted), time.Second)
+}
+
+func TestTLSSocketServerClientCommonNames(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*20)
+	defer done()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caBytes, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caBytes}), 0o644))
+
+	clientCert := func(cn string) tls.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		certBytes, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+		require.NoError(t, err)
+
+		return tls.Certificate{
+			Certificate: [][]byte{certBytes},
+			PrivateKey:  key,
+		}
+	}
+
+	conf := input.NewConfig()
+	conf.Type = "socket_server"
+	conf.SocketServer.Network = "tls"
+	conf.SocketServer.Address = "127.0.0.1:0"
+	conf.SocketServer.TLS.SelfSigned = true
+	conf.SocketServer.TLS.ClientCAFile = caFile
+	conf.SocketServer.TLS.AllowedClientCNs = []string{"partner"}
+
+	rdr, err := mock.NewManager().NewInput(conf)
+	require.NoError(t, err)
+
+	addr := rdr.(interface{ Addr() net.Addr }).Addr()
+
+	defer func() {
+		rdr.TriggerStopConsuming()
+		assert.NoError(t, rdr.WaitForClose(tCtx))
+	}()
+
+	for _, cn := range []string{"stranger", ""} {
+		tlsConf := &tls.Config{InsecureSkipVerify: true}
+		if cn != "" {
+			tlsConf.Certificates = []tls.Certificate{clientCert(cn)}
+		}
+		conn, err := tls.Dial("tcp", addr.String(), tlsConf)
+		require.NoError(t, err)
+
+		// Handshake errors surface on the first read with TLS 1.3
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+		_, err = conn.Read(make([]byte, 1))
+		require.Error(t, err, cn)
+		conn.Close()
+	}
+
+	conn, err := tls.Dial("tcp", addr.String(), &tls.Config{
+		InsecureSkipVerify: true,
+		Certificates:       []tls.Certificate{clientCert("partner")},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Second * 5))
+	_, err = conn.Write([]byte("foo\n"))
+	require.NoError(t, err)
+
+	select {
+	case tran := <-rdr.TransactionChan():
+		assert.Equal(t, [][]byte{[]byte("foo")}, message.GetAllBytes(tran.Payload))
+		require.NoError(t, tran.Ack(tCtx, nil))
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+}
+
+func TestSocketServerClientCommonNamesRequiresCA(t *testing.T) {
+	conf := input.NewConfig()
+	conf.Type = "socket_server"
+	conf.SocketServer.Network = "tls"
+	conf.SocketServer.Address = "127.0.0.1:0"
+	conf.SocketServer.TLS.SelfSigned = true
+	conf.SocketServer.TLS.AllowedClientCNs = []string{"partner"}
+
+	_, err := mock.NewManager().NewInput(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "client_ca_file")
+}
+
+func TestSocketServerUnixPeerCredentials(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on linux")
+	}
+
+	for _, network := range []string{"unix", "unixpacket", "unixgram"} {
+		network := network
+		t.Run(network, func(t *testing.T) {
+			tCtx, done := context.WithTimeout(context.Background(), time.Second*20)
+			defer done()
+
+			conf := input.NewConfig()
+			conf.Type = "socket_server"
+			conf.SocketServer.Network = network
+			conf.SocketServer.Address = filepath.Join(t.TempDir(), "benthos.sock")
+
+			rdr, err := mock.NewManager().NewInput(conf)
+			require.NoError(t, err)
+
+			defer func() {
+				rdr.TriggerStopConsuming()
+				assert.NoError(t, rdr.WaitForClose(tCtx))
+			}()
+
+			conn, err := net.Dial(network, conf.SocketServer.Address)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_ = conn.SetWriteDeadline(time.Now().Add(time.Second * 5))
+			_, err = conn.Write([]byte("foo\n"))
+			require.NoError(t, err)
+
+			select {
+			case tran := <-rdr.TransactionChan():
+				require.Len(t, tran.Payload, 1)
+				part := tran.Payload.Get(0)
+				assert.Equal(t, "foo", string(part.AsBytes()))
+				assert.Equal(t, strconv.Itoa(os.Getpid()), part.MetaGetStr("peer_pid"))
+				assert.Equal(t, strconv.Itoa(os.Getuid()), part.MetaGetStr("peer_uid"))
+				assert.Equal(t, strconv.Itoa(os.Getgid()), part.MetaGetStr("peer_gid"))
+				require.NoError(t, tran.Ack(tCtx, nil))
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out")
+			}
+		})
+	}
+}
@@ -1048,3 +1048,63 @@ func BenchmarkTCPSocketNoCutOff(b *testing.B) {
 	wg.Wait()
 	conn.Close()
 }
+
+func TestTCPSocketLengthPrefixedMaxBuffer(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*20)
+	defer done()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		if ln, err = net.Listen("tcp6", "[::1]:0"); err != nil {
+			t.Fatalf("failed to listen on a port: %v", err)
+		}
+	}
+	defer ln.Close()
+
+	conf := input.NewConfig()
+	conf.Socket.Network = "tcp"
+	conf.Socket.Codec = "length-prefixed:uint16be"
+	conf.Socket.MaxBuffer = 5
+	conf.Socket.Address = ln.Addr().String()
+
+	rdr, err := newSocketInput(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	defer func() {
+		rdr.TriggerStopConsuming()
+		require.NoError(t, rdr.WaitForClose(ctx))
+	}()
+
+	readNextMsg := func() message.Batch {
+		t.Helper()
+		select {
+		case tran := <-rdr.TransactionChan():
+			require.NoError(t, tran.Ack(ctx, nil))
+			return tran.Payload.DeepCopy()
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		return nil
+	}
+
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Second * 5))
+	_, err = conn.Write([]byte("\x00\x03foo\x00\x06foobar\x00\x03baz"))
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]byte{[]byte("foo")}, message.GetAllBytes(readNextMsg()))
+
+	// The oversized frame resets the connection rather than being truncated.
+	conn2, err := ln.Accept()
+	require.NoError(t, err)
+	conn.Close()
+
+	_ = conn2.SetWriteDeadline(time.Now().Add(time.Second * 5))
+	_, err = conn2.Write([]byte("\x00\x03bar"))
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]byte{[]byte("bar")}, message.GetAllBytes(readNextMsg()))
+	conn2.Close()
+}
@@ -41,6 +41,7 @@ It's possible to propagate the response from each HTTP request back to the input
 			service.NewIntField("max_in_flight").
 				Description("The maximum number of parallel message batches to have in flight at any given time.").
 				Default(64),
+			httpclient.AdaptiveConcurrencyField(),
 			service.NewBatchPolicyField("batching"),
 			service.NewObjectListField("multipart",
 				service.NewInterpolatedStringField("content_type").
@@ -90,10 +91,18 @@ func init() {
 				return
 			}
 
+			var concurrency *output.AdaptiveConcurrency
+			if concurrency, err = httpclient.AdaptiveConcurrencyFromParsed(conf, maxInFlight); err != nil {
+				return
+			}
+
 			var o output.Streamed
 			if o, err = output.NewAsyncWriter("http_client", maxInFlight, wr, oldMgr); err != nil {
 				return
 			}
+			if concurrency != nil {
+				o.(*output.AsyncWriter).SetAdaptiveConcurrency(concurrency)
+			}
 			if !batchAsMultipart {
 				o = output.OnlySinglePayloads(o)
 			}
@@ -661,3 +661,65 @@ http_client:
 	h.TriggerCloseNow()
 	require.NoError(t, h.WaitForClose(ctx))
 }
+
+func TestHTTPClientAdaptiveConcurrency(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	var inFlight, maxInFlight int32
+	unblockChan := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			if m := atomic.LoadInt32(&maxInFlight); n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		<-unblockChan
+	}))
+	defer ts.Close()
+
+	conf := parseYAMLOutputConf(t, `
+http_client:
+  url: %v/testpost
+  max_in_flight: 10
+  adaptive_concurrency:
+    enabled: true
+    min_in_flight: 1
+`, ts.URL)
+
+	h, err := mock.NewManager().NewOutput(conf)
+	require.NoError(t, err)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, h.Consume(tChan))
+
+	resChan := make(chan error, 5)
+	go func() {
+		for i := 0; i < 5; i++ {
+			select {
+			case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello world")}), resChan):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Only the minimum number of requests are permitted until one succeeds.
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&maxInFlight))
+	close(unblockChan)
+
+	for i := 0; i < 5; i++ {
+		select {
+		case err := <-resChan:
+			require.NoError(t, err)
+		case <-ctx.Done():
+			t.Fatal("Action timed out")
+		}
+	}
+
+	h.TriggerCloseNow()
+	require.NoError(t, h.WaitForClose(ctx))
+}
@@ -25,7 +25,7 @@ func init() {
 		Summary: `Connects to a (tcp/udp/unix) server and sends a continuous stream of data, dividing messages according to the specified codec.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("network", "The network type to connect as.").HasOptions(
-				"unix", "tcp", "udp",
+				"unix", "tcp", "udp", "unixgram", "unixpacket",
 			),
 			docs.FieldString("address", "The address (or path) to connect to.", "/tmp/benthos.sock", "localhost:9000"),
 			codec.WriterDocs,
@@ -61,7 +61,7 @@ type socketWriter struct {
 
 func newSocketWriter(conf output.SocketConfig, mgr bundle.NewManagement, log log.Modular) (*socketWriter, error) {
 	switch conf.Network {
-	case "tcp", "udp", "unix":
+	case "tcp", "udp", "unix", "unixgram", "unixpacket":
 	default:
 		return nil, fmt.Errorf("socket network '%v' is not supported by this output", conf.Network)
 	}
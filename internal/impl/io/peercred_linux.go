@@ -0,0 +1,85 @@
+//go:build linux
+
+package io
+
+import (
+	"errors"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+const peerCredentialsSupported = true
+
+// unixPeerCredentials returns the credentials of the process on the other end
+// of a connected unix socket.
+func unixPeerCredentials(conn net.Conn) (*peerCredentials, error) {
+	uConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, errors.New("connection is not a unix socket")
+	}
+	rawConn, err := uConn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var ucred *unix.Ucred
+	var credErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		ucred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return nil, err
+	}
+	if credErr != nil {
+		return nil, credErr
+	}
+	return &peerCredentials{
+		PID: ucred.Pid,
+		UID: ucred.Uid,
+		GID: ucred.Gid,
+	}, nil
+}
+
+// enableDatagramCredentials instructs the kernel to attach the credentials of
+// the sender to each datagram received on a unixgram socket.
+func enableDatagramCredentials(conn *net.UnixConn) error {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var optErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		optErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_PASSCRED, 1)
+	}); err != nil {
+		return err
+	}
+	return optErr
+}
+
+// datagramCredentialsOOBSize is the size of the buffer required for receiving
+// the credentials of a datagram sender.
+var datagramCredentialsOOBSize = unix.CmsgSpace(unix.SizeofUcred)
+
+// parseDatagramCredentials extracts sender credentials from the out-of-band
+// data of a unixgram datagram.
+func parseDatagramCredentials(oob []byte) (*peerCredentials, error) {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range msgs {
+		if m.Header.Level != unix.SOL_SOCKET || m.Header.Type != unix.SCM_CREDENTIALS {
+			continue
+		}
+		ucred, err := unix.ParseUnixCredentials(&m)
+		if err != nil {
+			return nil, err
+		}
+		return &peerCredentials{
+			PID: ucred.Pid,
+			UID: ucred.Uid,
+			GID: ucred.Gid,
+		}, nil
+	}
+	return nil, errors.New("datagram did not contain sender credentials")
+}
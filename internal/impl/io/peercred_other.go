@@ -0,0 +1,26 @@
+//go:build !linux
+
+package io
+
+import (
+	"errors"
+	"net"
+)
+
+const peerCredentialsSupported = false
+
+var errPeerCredentialsNotSupported = errors.New("peer credentials are not supported on this platform")
+
+func unixPeerCredentials(conn net.Conn) (*peerCredentials, error) {
+	return nil, errPeerCredentialsNotSupported
+}
+
+func enableDatagramCredentials(conn *net.UnixConn) error {
+	return errPeerCredentialsNotSupported
+}
+
+var datagramCredentialsOOBSize = 0
+
+func parseDatagramCredentials(oob []byte) (*peerCredentials, error) {
+	return nil, errPeerCredentialsNotSupported
+}
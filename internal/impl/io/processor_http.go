@@ -43,7 +43,8 @@ Use the field `+"`extract_headers`"+` to specify rules for which other headers s
 
 ## Error Handling
 
-When all retry attempts for a message are exhausted the processor cancels the attempt. These failed messages will continue through the pipeline unchanged, but can be dropped or placed in a dead letter queue according to your config, you can read about these patterns [here](/docs/configuration/error_handling).`).
+When all retry attempts for a message are exhausted the processor cancels the attempt. These failed messages will continue through the pipeline unchanged, but can be dropped or placed in a dead letter queue according to your config, you can read about these patterns [here](/docs/configuration/error_handling).
+`+httpProcCacheDescription).
 		Example(
 			"Branched Request",
 			`This example uses a `+"[`branch` processor](/docs/components/processors/branch/)"+` to strip the request message into an empty body, grab an HTTP payload, and place the result back into the original message at the path `+"`repo.status`"+`:`,
@@ -61,7 +62,8 @@ pipeline:
 		).
 		Field(httpclient.ConfigField("POST", false,
 			service.NewBoolField("batch_as_multipart").Description("Send message batches as a single request using [RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html).").Advanced().Default(false),
-			service.NewBoolField("parallel").Description("When processing batched messages, whether to send messages of the batch in parallel, otherwise they are sent serially.").Default(false)),
+			service.NewBoolField("parallel").Description("When processing batched messages, whether to send messages of the batch in parallel, otherwise they are sent serially.").Default(false),
+			httpProcCacheField()),
 		)
 }
 
@@ -83,6 +85,7 @@ func init() {
 
 type httpProc struct {
 	client      *httpclient.Client
+	cache       *httpProcCache
 	asMultipart bool
 	parallel    bool
 	rawURL      string
@@ -119,15 +122,26 @@ func newHTTPProcFromParsed(conf *service.ParsedConfig, mgr bundle.NewManagement)
 	if g.client, err = httpclient.NewClientFromOldConfig(oldConf, mgr); err != nil {
 		return nil, err
 	}
+	if g.cache, err = newHTTPProcCacheFromParsed(conf.Namespace(hpFieldCache), oldConf.Verb, g.client, mgr); err != nil {
+		return nil, err
+	}
 	return g, nil
 }
 
+// send performs a request, using the response cache when configured.
+func (h *httpProc) send(ctx context.Context, msg message.Batch) (message.Batch, error) {
+	if h.cache != nil && msg.Len() == 1 {
+		return h.cache.send(ctx, msg)
+	}
+	return h.client.Send(ctx, msg)
+}
+
 func (h *httpProc) ProcessBatch(ctx *processor.BatchProcContext, msg message.Batch) ([]message.Batch, error) {
 	var responseMsg message.Batch
 
 	if h.asMultipart || msg.Len() == 1 {
 		// Easy, just do a single request.
-		resultMsg, err := h.client.Send(context.Background(), msg)
+		resultMsg, err := h.send(context.Background(), msg)
 		if err != nil {
 			var code int
 			var hErr component.ErrUnexpectedHTTPRes
@@ -166,7 +180,7 @@ func (h *httpProc) ProcessBatch(ctx *processor.BatchProcContext, msg message.Bat
 		_ = msg.Iter(func(i int, p *message.Part) error {
 			tmpMsg := message.QuickBatch(nil)
 			tmpMsg = append(tmpMsg, p)
-			result, err := h.client.Send(context.Background(), tmpMsg)
+			result, err := h.send(context.Background(), tmpMsg)
 			if err != nil {
 				h.log.Errorf("HTTP request to '%v' failed: %v", h.rawURL, err)
 
@@ -205,7 +219,7 @@ func (h *httpProc) ProcessBatch(ctx *processor.BatchProcContext, msg message.Bat
 			go func() {
 				for index := range reqChan {
 					tmpMsg := message.Batch{msg.Get(index)}
-					result, err := h.client.Send(context.Background(), tmpMsg)
+					result, err := h.send(context.Background(), tmpMsg)
 					if err == nil && result.Len() != 1 {
 						err = fmt.Errorf("unexpected response size: %v", result.Len())
 					}
@@ -0,0 +1,412 @@
+package io
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/httpclient"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	hpFieldCache         = "cache"
+	hpFieldCacheResource = "resource"
+	hpFieldCacheKey      = "key"
+	hpFieldCacheTTL      = "ttl"
+	hpFieldCacheStaleTTL = "stale_ttl"
+	hpFieldCacheVerbs    = "verbs"
+
+	httpCacheMetaKey         = "http_cache"
+	httpCacheHit             = "hit"
+	httpCacheMiss            = "miss"
+	httpCacheRevalidated     = "revalidated"
+	httpCacheDefaultStaleTTL = "1h"
+)
+
+func httpProcCacheField() *service.ConfigField {
+	return service.NewObjectField(hpFieldCache,
+		service.NewStringField(hpFieldCacheResource).
+			Description("A [cache resource](/docs/components/caches/about) to store responses in, caching is disabled when empty.").
+			Default(""),
+		service.NewInterpolatedStringField(hpFieldCacheKey).
+			Description("An optional key to cache responses under. When empty the key is derived from the verb, URL, headers and body of the request. An explicit key must identify every aspect of a request that affects its response, including the caller when requests are authenticated with interpolated headers, as otherwise the response to one request can be served to another.").
+			Default("").
+			Example(`${! json("user_id") }`),
+		service.NewStringField(hpFieldCacheTTL).
+			Description("An optional period of time to cache responses for, overriding the `Cache-Control` and `Expires` headers of responses.").
+			Default("").
+			Example("5m"),
+		service.NewStringField(hpFieldCacheStaleTTL).
+			Description("The period of time to retain stale responses that have an `ETag` or `Last-Modified` header, during which they are revalidated with a conditional request rather than fetched again in full.").
+			Default(httpCacheDefaultStaleTTL).
+			Advanced(),
+		service.NewStringListField(hpFieldCacheVerbs).
+			Description("The request verbs that responses may be cached for. Caching responses of other verbs such as `POST` is only safe when the requests are idempotent, and requires adding them to this list explicitly.").
+			Default([]any{"GET", "HEAD"}).
+			Advanced(),
+	).
+		Description("Cache successful responses of single message requests in a cache resource, avoiding repeated identical requests to an upstream service.").
+		Advanced().
+		Version("4.20.0")
+}
+
+const httpProcCacheDescription = `
+## Caching Responses
+
+When ` + "`cache.resource`" + ` is set successful responses are stored in the cache, and subsequent identical requests are served from the cache for as long as the response remains fresh. The freshness of a response is determined by the ` + "`max-age`" + ` directive of its ` + "`Cache-Control`" + ` header or its ` + "`Expires`" + ` header, or alternatively by an explicit ` + "`cache.ttl`" + `. Responses with a ` + "`no-store`" + ` directive are not cached unless a ` + "`cache.ttl`" + ` is set, and responses with a ` + "`private`" + ` directive or a ` + "`Vary: *`" + ` header are never cached. Responses with a ` + "`Vary`" + ` header are only served from the cache to requests with the same values of the listed headers.
+
+Stale responses that have an ` + "`ETag`" + ` or ` + "`Last-Modified`" + ` header are retained for the ` + "`cache.stale_ttl`" + ` and revalidated with a conditional request, where a ` + "`304 Not Modified`" + ` response results in the cached response being used and refreshed.
+
+By default only responses to ` + "`GET`" + ` and ` + "`HEAD`" + ` requests are cached, and since the default verb of this processor is ` + "`POST`" + ` the ` + "`verb`" + ` must either be set or added to ` + "`cache.verbs`" + `. Only requests consisting of a single message are cached, and therefore batches sent with ` + "`batch_as_multipart`" + ` bypass the cache. Messages resulting from a cached request have a metadata field ` + "`http_cache`" + ` set to either ` + "`hit`" + `, ` + "`miss`" + ` or ` + "`revalidated`" + `.`
+
+// httpCacheEntry is a response stored within a cache.
+type httpCacheEntry struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	FreshUntil time.Time   `json:"fresh_until"`
+
+	// The values of request headers listed by the Vary header of the response.
+	Vary map[string][]string `json:"vary,omitempty"`
+}
+
+func varyNames(header http.Header) []string {
+	var names []string
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+// shareable returns false when a response must not be stored in a cache shared
+// by multiple requests, either because it is private or varies on aspects of
+// the request other than its headers.
+func (e *httpCacheEntry) shareable() bool {
+	for _, directive := range strings.Split(e.Header.Get("Cache-Control"), ",") {
+		if d := strings.ToLower(strings.TrimSpace(directive)); d == "private" || strings.HasPrefix(d, "private=") {
+			return false
+		}
+	}
+	for _, name := range varyNames(e.Header) {
+		if name == "*" {
+			return false
+		}
+	}
+	return true
+}
+
+// setVary records the values of the request headers the response varies on.
+func (e *httpCacheEntry) setVary(reqHeader http.Header) {
+	e.Vary = nil
+	for _, name := range varyNames(e.Header) {
+		if e.Vary == nil {
+			e.Vary = map[string][]string{}
+		}
+		e.Vary[name] = reqHeader.Values(name)
+	}
+}
+
+// matchesVary returns true if the request headers the response varies on have
+// the same values as those of the request the response was stored for.
+func (e *httpCacheEntry) matchesVary(reqHeader http.Header) bool {
+	for name, values := range e.Vary {
+		if strings.Join(values, ",") != strings.Join(reqHeader.Values(name), ",") {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *httpCacheEntry) hasValidators() bool {
+	return e.Header.Get("ETag") != "" || e.Header.Get("Last-Modified") != ""
+}
+
+func (e *httpCacheEntry) conditions() http.Header {
+	h := http.Header{}
+	if v := e.Header.Get("ETag"); v != "" {
+		h.Set("If-None-Match", v)
+	}
+	if v := e.Header.Get("Last-Modified"); v != "" {
+		h.Set("If-Modified-Since", v)
+	}
+	return h
+}
+
+func (e *httpCacheEntry) response() *http.Response {
+	return &http.Response{
+		StatusCode: e.StatusCode,
+		Header:     e.Header,
+		Body:       io.NopCloser(bytes.NewReader(e.Body)),
+	}
+}
+
+// responseFreshness returns the period of time a response remains fresh for
+// according to its headers, and whether it may be stored at all.
+func responseFreshness(header http.Header, now time.Time) (time.Duration, bool) {
+	var maxAge *time.Duration
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store":
+			return 0, false
+		case directive == "no-cache":
+			zero := time.Duration(0)
+			maxAge = &zero
+		case strings.HasPrefix(directive, "max-age=") && maxAge == nil:
+			if secs, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				d := time.Duration(secs) * time.Second
+				maxAge = &d
+			}
+		}
+	}
+	if maxAge != nil {
+		return *maxAge, true
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			return 0, true
+		}
+		if date, err := http.ParseTime(header.Get("Date")); err == nil {
+			now = date
+		}
+		return expiresAt.Sub(now), true
+	}
+	return 0, true
+}
+
+type httpProcCache struct {
+	resource string
+	key      *field.Expression
+	ttl      time.Duration
+	staleTTL time.Duration
+
+	client *httpclient.Client
+	mgr    bundle.NewManagement
+	log    log.Modular
+	nowFn  func() time.Time
+}
+
+func newHTTPProcCacheFromParsed(conf *service.ParsedConfig, verb string, client *httpclient.Client, mgr bundle.NewManagement) (*httpProcCache, error) {
+	c := &httpProcCache{
+		client: client,
+		mgr:    mgr,
+		log:    mgr.Logger(),
+		nowFn:  time.Now,
+	}
+
+	var err error
+	if c.resource, err = conf.FieldString(hpFieldCacheResource); err != nil {
+		return nil, err
+	}
+	if c.resource == "" {
+		return nil, nil
+	}
+	if !mgr.ProbeCache(c.resource) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", c.resource)
+	}
+
+	verbs, err := conf.FieldStringList(hpFieldCacheVerbs)
+	if err != nil {
+		return nil, err
+	}
+	verbCached := false
+	for _, v := range verbs {
+		if strings.EqualFold(v, verb) {
+			verbCached = true
+		}
+	}
+	if !verbCached {
+		return nil, fmt.Errorf("responses of %v requests are not cached, add the verb to the field %v.%v in order to cache them", verb, hpFieldCache, hpFieldCacheVerbs)
+	}
+
+	keyStr, err := conf.FieldString(hpFieldCacheKey)
+	if err != nil {
+		return nil, err
+	}
+	if keyStr != "" {
+		if c.key, err = mgr.BloblEnvironment().NewField(keyStr); err != nil {
+			return nil, fmt.Errorf("failed to parse cache key expression: %v", err)
+		}
+	}
+
+	ttlStr, err := conf.FieldString(hpFieldCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	if ttlStr != "" {
+		if c.ttl, err = time.ParseDuration(ttlStr); err != nil {
+			return nil, fmt.Errorf("failed to parse cache ttl: %v", err)
+		}
+	}
+
+	staleTTLStr, err := conf.FieldString(hpFieldCacheStaleTTL)
+	if err != nil {
+		return nil, err
+	}
+	if c.staleTTL, err = time.ParseDuration(staleTTLStr); err != nil {
+		return nil, fmt.Errorf("failed to parse cache stale_ttl: %v", err)
+	}
+	return c, nil
+}
+
+func (c *httpProcCache) get(ctx context.Context, key string) *httpCacheEntry {
+	var raw []byte
+	var cErr error
+	if err := c.mgr.AccessCache(ctx, c.resource, func(cache cache.V1) {
+		raw, cErr = cache.Get(ctx, key)
+	}); err != nil {
+		cErr = err
+	}
+	if cErr != nil {
+		if !errors.Is(cErr, component.ErrKeyNotFound) {
+			c.log.Warnf("Failed to read cached HTTP response: %v\n", cErr)
+		}
+		return nil
+	}
+
+	var entry httpCacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		c.log.Warnf("Failed to parse cached HTTP response: %v\n", err)
+		return nil
+	}
+	return &entry
+}
+
+// store writes an entry to the cache, setting its freshness from either the
+// explicit TTL or the headers of the response.
+func (c *httpProcCache) store(ctx context.Context, key string, entry *httpCacheEntry) {
+	if !entry.shareable() {
+		return
+	}
+	now := c.nowFn()
+
+	freshness, storable := c.ttl, true
+	if c.ttl <= 0 {
+		freshness, storable = responseFreshness(entry.Header, now)
+	}
+	if !storable {
+		return
+	}
+	if freshness < 0 {
+		freshness = 0
+	}
+	entry.FreshUntil = now.Add(freshness)
+
+	retention := freshness
+	if entry.hasValidators() {
+		retention += c.staleTTL
+	}
+	if retention <= 0 {
+		return
+	}
+
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		c.log.Warnf("Failed to serialise HTTP response for caching: %v\n", err)
+		return
+	}
+
+	var cErr error
+	if err := c.mgr.AccessCache(ctx, c.resource, func(cache cache.V1) {
+		cErr = cache.Set(ctx, key, raw, &retention)
+	}); err != nil {
+		cErr = err
+	}
+	if cErr != nil {
+		c.log.Warnf("Failed to cache HTTP response: %v\n", cErr)
+	}
+}
+
+func setCacheStatus(batch message.Batch, status string) message.Batch {
+	for _, p := range batch {
+		p.MetaSetMut(httpCacheMetaKey, status)
+	}
+	return batch
+}
+
+// send performs a request for a single message, serving the response from the
+// cache when a fresh response is available.
+func (c *httpProcCache) send(ctx context.Context, msg message.Batch) (message.Batch, error) {
+	var key string
+	var err error
+	if c.key != nil {
+		key, err = c.key.String(0, msg)
+	} else {
+		key, err = c.client.RequestKey(msg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cache key: %w", err)
+	}
+
+	reqHeader, err := c.client.RequestHeader(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := c.get(ctx, key)
+	if entry != nil && !entry.matchesVary(reqHeader) {
+		entry = nil
+	}
+	if entry != nil && c.nowFn().Before(entry.FreshUntil) {
+		resMsg, err := c.client.ResponseToBatch(entry.response())
+		return setCacheStatus(resMsg, httpCacheHit), err
+	}
+
+	var res *http.Response
+	if entry != nil && entry.hasValidators() {
+		res, err = c.client.SendConditionalToResponse(ctx, msg, entry.conditions())
+	} else {
+		res, err = c.client.SendToResponse(ctx, msg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := httpCacheMiss
+	if res.StatusCode == http.StatusNotModified && entry != nil {
+		if res.Body != nil {
+			res.Body.Close()
+		}
+		for k, v := range res.Header {
+			entry.Header[k] = v
+		}
+		status = httpCacheRevalidated
+	} else {
+		entry = &httpCacheEntry{StatusCode: res.StatusCode, Header: res.Header}
+		if res.Body != nil {
+			entry.Body, err = io.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if entry.StatusCode >= 200 && entry.StatusCode <= 299 {
+		entry.setVary(reqHeader)
+		c.store(ctx, key, entry)
+	}
+
+	resMsg, err := c.client.ResponseToBatch(entry.response())
+	return setCacheStatus(resMsg, status), err
+}
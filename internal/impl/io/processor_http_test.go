@@ -6,9 +6,11 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -430,3 +432,162 @@ http:
 		}
 	}
 }
+
+func TestHTTPProcessorCache(t *testing.T) {
+	var reqCount, notModifiedCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddUint32(&notModifiedCount, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/nostore":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "X-Region")
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = fmt.Fprintf(w, "%s %s", r.URL.Path, body)
+	}))
+	defer ts.Close()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	newProc := func(path, extra string) processor.V1 {
+		t.Helper()
+		conf := parseYAMLProcConf(t, `
+http:
+  url: %v%v
+  cache:
+    resource: foocache
+    verbs: [ POST ]
+%v
+`, ts.URL, path, extra)
+		p, err := mgr.NewProcessor(conf)
+		require.NoError(t, err)
+		return p
+	}
+
+	process := func(p processor.V1, content string) (string, string) {
+		t.Helper()
+		msgs, res := p.ProcessBatch(context.Background(), message.QuickBatch([][]byte{[]byte(content)}))
+		require.NoError(t, res)
+		require.Len(t, msgs, 1)
+		require.NoError(t, msgs[0].Get(0).ErrorGet())
+		return string(msgs[0].Get(0).AsBytes()), msgs[0].Get(0).MetaGetStr("http_cache")
+	}
+
+	fresh := newProc("/fresh", "")
+	for _, exp := range [][2]string{
+		{"/fresh foo", "miss"},
+		{"/fresh foo", "hit"},
+		{"/fresh bar", "miss"},
+		{"/fresh bar", "hit"},
+	} {
+		body, status := process(fresh, strings.Fields(exp[0])[1])
+		assert.Equal(t, exp[0], body)
+		assert.Equal(t, exp[1], status)
+	}
+	assert.Equal(t, uint32(2), atomic.LoadUint32(&reqCount))
+
+	etag := newProc("/etag", "")
+	for _, exp := range []string{"miss", "revalidated", "revalidated"} {
+		body, status := process(etag, "foo")
+		assert.Equal(t, "/etag foo", body)
+		assert.Equal(t, exp, status)
+	}
+	assert.Equal(t, uint32(5), atomic.LoadUint32(&reqCount))
+	assert.Equal(t, uint32(2), atomic.LoadUint32(&notModifiedCount))
+
+	noStore := newProc("/nostore", "")
+	for i := 0; i < 2; i++ {
+		_, status := process(noStore, "foo")
+		assert.Equal(t, "miss", status)
+	}
+	assert.Equal(t, uint32(7), atomic.LoadUint32(&reqCount))
+
+	explicit := newProc("/nostore", `    ttl: 1m
+    key: '${! content().uppercase() }'`)
+	for _, exp := range []string{"miss", "hit"} {
+		body, status := process(explicit, "foo")
+		assert.Equal(t, "/nostore foo", body)
+		assert.Equal(t, exp, status)
+	}
+	assert.Equal(t, uint32(8), atomic.LoadUint32(&reqCount))
+	assert.Contains(t, mgr.Caches["foocache"], "FOO")
+	assert.Equal(t, time.Minute, *mgr.Caches["foocache"]["FOO"].TTL)
+
+	private := newProc("/private", `    ttl: 1m`)
+	for i := 0; i < 2; i++ {
+		_, status := process(private, "foo")
+		assert.Equal(t, "miss", status)
+	}
+	assert.Equal(t, uint32(10), atomic.LoadUint32(&reqCount))
+
+	// Interpolated headers are part of the default key, and therefore
+	// responses aren't shared between callers with different credentials.
+	authed := newProc("/fresh", `  headers:
+    Authorization: '${! meta("token") }'`)
+	for _, exp := range [][2]string{
+		{"alice", "miss"},
+		{"alice", "hit"},
+		{"bob", "miss"},
+	} {
+		part := message.NewPart([]byte("foo"))
+		part.MetaSetMut("token", exp[0])
+		msgs, res := authed.ProcessBatch(context.Background(), message.Batch{part})
+		require.NoError(t, res)
+		assert.Equal(t, exp[1], msgs[0].Get(0).MetaGetStr("http_cache"), exp[0])
+	}
+	assert.Equal(t, uint32(12), atomic.LoadUint32(&reqCount))
+
+	vary := newProc("/vary", `    key: vary
+  headers:
+    X-Region: '${! content() }'`)
+	for _, exp := range [][2]string{
+		{"eu", "miss"},
+		{"eu", "hit"},
+		{"us", "miss"},
+		{"us", "hit"},
+	} {
+		_, status := process(vary, exp[0])
+		assert.Equal(t, exp[1], status, exp[0])
+	}
+	assert.Equal(t, uint32(14), atomic.LoadUint32(&reqCount))
+}
+
+func TestHTTPProcessorCacheVerbs(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	conf := parseYAMLProcConf(t, `
+http:
+  url: http://localhost:1234
+  cache:
+    resource: foocache
+`)
+	_, err := mgr.NewProcessor(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "responses of POST requests are not cached")
+
+	conf = parseYAMLProcConf(t, `
+http:
+  url: http://localhost:1234
+  verb: GET
+  cache:
+    resource: foocache
+`)
+	_, err = mgr.NewProcessor(conf)
+	require.NoError(t, err)
+}
@@ -48,4 +48,42 @@ func init() {
 		}); err != nil {
 		panic(err)
 	}
+
+	if err := bloblang.RegisterMethodV2("json_path_rfc9535",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryObjectAndArray).
+			Version("4.20.0").
+			Description("Executes a JSONPath expression following [RFC 9535](https://www.rfc-editor.org/rfc/rfc9535) on a value and returns an array of all matched values, which is empty when nothing matches. Supports filter selectors with comparisons, logical operators and the standard functions `length`, `count`, `match`, `search` and `value`, as well as recursive descent and array slices. Members of objects are visited in lexicographical key order.\n\nUnlike [`json_path`](#json_path), which follows the original JSONPath syntax along with Gval expressions and returns a single value when the expression selects one, this method always returns an array of matches.").
+			Example("", `root.all_names = this.json_path_rfc9535("$..name")`, [2]string{
+				`{"name":"alice","foo":{"name":"bob"}}`,
+				`{"all_names":["alice","bob"]}`,
+			}, [2]string{
+				`{"thing":["this","bar",{"name":"alice"}]}`,
+				`{"all_names":["alice"]}`,
+			}).
+			Example("Filters can combine comparisons, existence tests and functions.", `root.cheap_books = this.json_path_rfc9535("$.store.book[?@.price < 10 && match(@.category, 'fic.*')].title")`, [2]string{
+				`{"store":{"book":[{"title":"a","category":"fiction","price":8.95},{"title":"b","category":"reference","price":5},{"title":"c","category":"fiction","price":22.99}]}}`,
+				`{"cheap_books":["a"]}`,
+			}).
+			Example("Slices support negative indexes and steps.", `root.last_two = this.json_path_rfc9535("$.items[-2:]")
+root.reversed = this.json_path_rfc9535("$.items[::-1]")`, [2]string{
+				`{"items":[1,2,3,4]}`,
+				`{"last_two":[3,4],"reversed":[4,3,2,1]}`,
+			}).
+			Param(bloblang.NewStringParam("expression").Description("The JSONPath expression to execute.")),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			expressionStr, err := args.GetString("expression")
+			if err != nil {
+				return nil, err
+			}
+			q, err := parseRFCQuery(expressionStr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse json path expression: %w", err)
+			}
+			return func(v any) (any, error) {
+				return q.selectFrom(v), nil
+			}, nil
+		}); err != nil {
+		panic(err)
+	}
 }
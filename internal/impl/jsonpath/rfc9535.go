@@ -0,0 +1,1261 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// rfcQuery is a compiled JSONPath query following RFC 9535.
+type rfcQuery struct {
+	segments []segment
+}
+
+// parseRFCQuery parses an RFC 9535 JSONPath query.
+func parseRFCQuery(expr string) (*rfcQuery, error) {
+	p := &parser{input: []rune(expr)}
+
+	if !p.consume('$') {
+		return nil, p.errorf("expected root identifier '$'")
+	}
+	segments, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected input")
+	}
+	return &rfcQuery{segments: segments}, nil
+}
+
+// selectFrom returns the values of all nodes that match the query against a root
+// value, in the order that they were selected.
+func (q *rfcQuery) selectFrom(root any) []any {
+	nodes := selectSegments(q.segments, root, root)
+	if nodes == nil {
+		nodes = []any{}
+	}
+	return nodes
+}
+
+//------------------------------------------------------------------------------
+
+type segment struct {
+	descendant bool
+	selectors  []selector
+}
+
+type selector interface {
+	apply(root, node any, out []any) []any
+}
+
+func selectSegments(segments []segment, root, start any) []any {
+	nodes := []any{start}
+	for _, seg := range segments {
+		var next []any
+		for _, n := range nodes {
+			if seg.descendant {
+				walkDescendants(n, func(d any) {
+					for _, sel := range seg.selectors {
+						next = sel.apply(root, d, next)
+					}
+				})
+				continue
+			}
+			for _, sel := range seg.selectors {
+				next = sel.apply(root, n, next)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// sortedKeys returns the keys of an object in a deterministic order, RFC 9535
+// leaves the order of object members undefined.
+func sortedKeys(obj map[string]any) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func walkDescendants(node any, fn func(any)) {
+	fn(node)
+	switch t := node.(type) {
+	case []any:
+		for _, v := range t {
+			walkDescendants(v, fn)
+		}
+	case map[string]any:
+		for _, k := range sortedKeys(t) {
+			walkDescendants(t[k], fn)
+		}
+	}
+}
+
+type nameSelector string
+
+func (n nameSelector) apply(root, node any, out []any) []any {
+	if obj, ok := node.(map[string]any); ok {
+		if v, exists := obj[string(n)]; exists {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+type wildcardSelector struct{}
+
+func (wildcardSelector) apply(root, node any, out []any) []any {
+	switch t := node.(type) {
+	case []any:
+		out = append(out, t...)
+	case map[string]any:
+		for _, k := range sortedKeys(t) {
+			out = append(out, t[k])
+		}
+	}
+	return out
+}
+
+type indexSelector int64
+
+func (i indexSelector) apply(root, node any, out []any) []any {
+	arr, ok := node.([]any)
+	if !ok {
+		return out
+	}
+	index := int64(i)
+	if index < 0 {
+		index += int64(len(arr))
+	}
+	if index >= 0 && index < int64(len(arr)) {
+		out = append(out, arr[index])
+	}
+	return out
+}
+
+type sliceSelector struct {
+	start, end *int64
+	step       int64
+}
+
+func (s sliceSelector) apply(root, node any, out []any) []any {
+	arr, ok := node.([]any)
+	if !ok || s.step == 0 {
+		return out
+	}
+	length := int64(len(arr))
+
+	normalize := func(i int64) int64 {
+		if i < 0 {
+			return length + i
+		}
+		return i
+	}
+
+	if s.step > 0 {
+		start, end := int64(0), length
+		if s.start != nil {
+			start = normalize(*s.start)
+		}
+		if s.end != nil {
+			end = normalize(*s.end)
+		}
+		lower := min64(max64(start, 0), length)
+		upper := min64(max64(end, 0), length)
+		for i := lower; i < upper; i += s.step {
+			out = append(out, arr[i])
+		}
+		return out
+	}
+
+	start, end := length-1, -length-1
+	if s.start != nil {
+		start = normalize(*s.start)
+	}
+	if s.end != nil {
+		end = normalize(*s.end)
+	}
+	upper := min64(max64(start, -1), length-1)
+	lower := min64(max64(end, -1), length-1)
+	for i := upper; lower < i; i += s.step {
+		out = append(out, arr[i])
+	}
+	return out
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+type filterSelector struct {
+	expr logicalExpr
+}
+
+func (f filterSelector) apply(root, node any, out []any) []any {
+	switch t := node.(type) {
+	case []any:
+		for _, v := range t {
+			if f.expr.test(root, v) {
+				out = append(out, v)
+			}
+		}
+	case map[string]any:
+		for _, k := range sortedKeys(t) {
+			if v := t[k]; f.expr.test(root, v) {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+//------------------------------------------------------------------------------
+
+type logicalExpr interface {
+	test(root, current any) bool
+}
+
+// Comparable expressions produce a single value, or nothing.
+type comparableExpr interface {
+	value(root, current any) (any, bool)
+}
+
+type orExpr []logicalExpr
+
+func (o orExpr) test(root, current any) bool {
+	for _, e := range o {
+		if e.test(root, current) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []logicalExpr
+
+func (a andExpr) test(root, current any) bool {
+	for _, e := range a {
+		if !e.test(root, current) {
+			return false
+		}
+	}
+	return true
+}
+
+type notExpr struct {
+	expr logicalExpr
+}
+
+func (n notExpr) test(root, current any) bool {
+	return !n.expr.test(root, current)
+}
+
+type filterQuery struct {
+	relative bool
+	segments []segment
+}
+
+func (f filterQuery) nodes(root, current any) []any {
+	start := root
+	if f.relative {
+		start = current
+	}
+	return selectSegments(f.segments, root, start)
+}
+
+func (f filterQuery) isSingular() bool {
+	for _, seg := range f.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		switch seg.selectors[0].(type) {
+		case nameSelector, indexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// An existence test.
+func (f filterQuery) test(root, current any) bool {
+	return len(f.nodes(root, current)) > 0
+}
+
+// A singular query used as a comparable.
+func (f filterQuery) value(root, current any) (any, bool) {
+	nodes := f.nodes(root, current)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0], true
+}
+
+type literal struct {
+	v any
+}
+
+func (l literal) value(root, current any) (any, bool) {
+	return l.v, true
+}
+
+type comparisonExpr struct {
+	op          string
+	left, right comparableExpr
+}
+
+func (c comparisonExpr) test(root, current any) bool {
+	lv, lok := c.left.value(root, current)
+	rv, rok := c.right.value(root, current)
+
+	equal := func() bool {
+		if !lok || !rok {
+			return !lok && !rok
+		}
+		return valuesEqual(lv, rv)
+	}
+	less := func(l, r any, lok, rok bool) bool {
+		if !lok || !rok {
+			return false
+		}
+		if ln, ok := toNumber(l); ok {
+			if rn, ok := toNumber(r); ok {
+				return ln < rn
+			}
+			return false
+		}
+		if ls, ok := l.(string); ok {
+			if rs, ok := r.(string); ok {
+				return ls < rs
+			}
+		}
+		return false
+	}
+
+	switch c.op {
+	case "==":
+		return equal()
+	case "!=":
+		return !equal()
+	case "<":
+		return less(lv, rv, lok, rok)
+	case "<=":
+		return less(lv, rv, lok, rok) || equal()
+	case ">":
+		return less(rv, lv, rok, lok)
+	case ">=":
+		return less(rv, lv, rok, lok) || equal()
+	}
+	return false
+}
+
+func toNumber(v any) (float64, bool) {
+	switch t := v.(type) {
+	case int:
+		return float64(t), true
+	case int8:
+		return float64(t), true
+	case int16:
+		return float64(t), true
+	case int32:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case uint:
+		return float64(t), true
+	case uint8:
+		return float64(t), true
+	case uint16:
+		return float64(t), true
+	case uint32:
+		return float64(t), true
+	case uint64:
+		return float64(t), true
+	case float32:
+		return float64(t), true
+	case float64:
+		return t, true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func valuesEqual(l, r any) bool {
+	if ln, ok := toNumber(l); ok {
+		rn, ok := toNumber(r)
+		return ok && ln == rn
+	}
+	switch lt := l.(type) {
+	case []any:
+		rt, ok := r.([]any)
+		if !ok || len(lt) != len(rt) {
+			return false
+		}
+		for i := range lt {
+			if !valuesEqual(lt[i], rt[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		rt, ok := r.(map[string]any)
+		if !ok || len(lt) != len(rt) {
+			return false
+		}
+		for k, lv := range lt {
+			rv, exists := rt[k]
+			if !exists || !valuesEqual(lv, rv) {
+				return false
+			}
+		}
+		return true
+	case []byte:
+		if rs, ok := r.(string); ok {
+			return string(lt) == rs
+		}
+	case string:
+		if rb, ok := r.([]byte); ok {
+			return lt == string(rb)
+		}
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+//------------------------------------------------------------------------------
+
+type funcResultType int
+
+const (
+	valueResult funcResultType = iota
+	logicalResult
+)
+
+type funcArgType int
+
+const (
+	valueArg funcArgType = iota
+	nodesArg
+)
+
+type funcDef struct {
+	params []funcArgType
+	result funcResultType
+	fn     func(args []funcArgValue) (any, bool)
+}
+
+type funcArgValue struct {
+	value   any
+	nothing bool
+	nodes   []any
+}
+
+var regexpCache sync.Map
+
+func iRegexp(pattern string, full bool) (*regexp.Regexp, bool) {
+	key := pattern
+	if full {
+		key = "^(?:" + pattern + ")$"
+	}
+	if re, exists := regexpCache.Load(key); exists {
+		return re.(*regexp.Regexp), re.(*regexp.Regexp) != nil
+	}
+	re, err := regexp.Compile(key)
+	if err != nil {
+		re = nil
+	}
+	regexpCache.Store(key, re)
+	return re, re != nil
+}
+
+func regexpFunc(full bool) func(args []funcArgValue) (any, bool) {
+	return func(args []funcArgValue) (any, bool) {
+		if args[0].nothing || args[1].nothing {
+			return false, true
+		}
+		str, ok := args[0].value.(string)
+		if !ok {
+			return false, true
+		}
+		pattern, ok := args[1].value.(string)
+		if !ok {
+			return false, true
+		}
+		re, ok := iRegexp(pattern, full)
+		if !ok {
+			return false, true
+		}
+		return re.MatchString(str), true
+	}
+}
+
+var functions = map[string]funcDef{
+	"length": {
+		params: []funcArgType{valueArg},
+		result: valueResult,
+		fn: func(args []funcArgValue) (any, bool) {
+			if args[0].nothing {
+				return nil, false
+			}
+			switch t := args[0].value.(type) {
+			case string:
+				return int64(utf8.RuneCountInString(t)), true
+			case []any:
+				return int64(len(t)), true
+			case map[string]any:
+				return int64(len(t)), true
+			}
+			return nil, false
+		},
+	},
+	"count": {
+		params: []funcArgType{nodesArg},
+		result: valueResult,
+		fn: func(args []funcArgValue) (any, bool) {
+			return int64(len(args[0].nodes)), true
+		},
+	},
+	"match": {
+		params: []funcArgType{valueArg, valueArg},
+		result: logicalResult,
+		fn:     regexpFunc(true),
+	},
+	"search": {
+		params: []funcArgType{valueArg, valueArg},
+		result: logicalResult,
+		fn:     regexpFunc(false),
+	},
+	"value": {
+		params: []funcArgType{nodesArg},
+		result: valueResult,
+		fn: func(args []funcArgValue) (any, bool) {
+			if len(args[0].nodes) != 1 {
+				return nil, false
+			}
+			return args[0].nodes[0], true
+		},
+	},
+}
+
+type funcArg struct {
+	value comparableExpr
+	nodes *filterQuery
+}
+
+type funcCall struct {
+	name string
+	def  funcDef
+	args []funcArg
+}
+
+func (f funcCall) call(root, current any) (any, bool) {
+	values := make([]funcArgValue, len(f.args))
+	for i, arg := range f.args {
+		if arg.nodes != nil {
+			values[i].nodes = arg.nodes.nodes(root, current)
+			continue
+		}
+		v, ok := arg.value.value(root, current)
+		values[i] = funcArgValue{value: v, nothing: !ok}
+	}
+	return f.def.fn(values)
+}
+
+func (f funcCall) value(root, current any) (any, bool) {
+	return f.call(root, current)
+}
+
+func (f funcCall) test(root, current any) bool {
+	v, _ := f.call(root, current)
+	b, _ := v.(bool)
+	return b
+}
+
+//------------------------------------------------------------------------------
+
+type parser struct {
+	input []rune
+	pos   int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("char %v: %v", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) peek() rune {
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) peekAt(offset int) rune {
+	if p.pos+offset >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos+offset]
+}
+
+func (p *parser) consume(r rune) bool {
+	if p.peek() == r && p.pos < len(p.input) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) consumeStr(s string) bool {
+	rs := []rune(s)
+	if p.pos+len(rs) > len(p.input) {
+		return false
+	}
+	for i, r := range rs {
+		if p.input[p.pos+i] != r {
+			return false
+		}
+	}
+	p.pos += len(rs)
+	return true
+}
+
+func (p *parser) skipWhitespace() {
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) parseSegments() ([]segment, error) {
+	var segments []segment
+	for {
+		start := p.pos
+		p.skipWhitespace()
+
+		switch {
+		case p.peek() == '.' && p.peekAt(1) == '.':
+			p.pos += 2
+			seg, err := p.parseDotSegment()
+			if err != nil {
+				return nil, err
+			}
+			seg.descendant = true
+			segments = append(segments, seg)
+		case p.peek() == '.':
+			p.pos++
+			seg, err := p.parseDotSegment()
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, seg)
+		case p.peek() == '[':
+			selectors, err := p.parseBracketedSelection()
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment{selectors: selectors})
+		default:
+			p.pos = start
+			return segments, nil
+		}
+	}
+}
+
+// parseDotSegment parses the remainder of a segment after a `.` or `..`.
+func (p *parser) parseDotSegment() (segment, error) {
+	if p.consume('*') {
+		return segment{selectors: []selector{wildcardSelector{}}}, nil
+	}
+	if p.peek() == '[' {
+		if p.pos < 2 || p.input[p.pos-2] != '.' {
+			return segment{}, p.errorf("expected member name or wildcard")
+		}
+		selectors, err := p.parseBracketedSelection()
+		if err != nil {
+			return segment{}, err
+		}
+		return segment{selectors: selectors}, nil
+	}
+	name, ok := p.parseMemberName()
+	if !ok {
+		return segment{}, p.errorf("expected member name or wildcard")
+	}
+	return segment{selectors: []selector{nameSelector(name)}}, nil
+}
+
+func isNameFirst(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || r >= 0x80
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func (p *parser) parseMemberName() (string, bool) {
+	start := p.pos
+	if p.pos >= len(p.input) || !isNameFirst(p.input[p.pos]) {
+		return "", false
+	}
+	p.pos++
+	for p.pos < len(p.input) && (isNameFirst(p.input[p.pos]) || isDigit(p.input[p.pos])) {
+		p.pos++
+	}
+	return string(p.input[start:p.pos]), true
+}
+
+func (p *parser) parseBracketedSelection() ([]selector, error) {
+	if !p.consume('[') {
+		return nil, p.errorf("expected '['")
+	}
+	var selectors []selector
+	for {
+		p.skipWhitespace()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.skipWhitespace()
+		if p.consume(']') {
+			return selectors, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *parser) parseSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		s, err := p.parseStringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return nameSelector(s), nil
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '?':
+		p.pos++
+		p.skipWhitespace()
+		expr, err := p.parseLogicalOr()
+		if err != nil {
+			return nil, err
+		}
+		return filterSelector{expr: expr}, nil
+	case c == ':' || c == '-' || isDigit(c):
+		return p.parseIndexOrSlice()
+	}
+	return nil, p.errorf("expected selector")
+}
+
+const maxSafeInt = 1<<53 - 1
+
+func (p *parser) parseInt() (int64, error) {
+	start := p.pos
+	p.consume('-')
+	if !isDigit(p.peek()) {
+		return 0, p.errorf("expected integer")
+	}
+	if p.peek() == '0' {
+		p.pos++
+		if p.pos-start == 2 {
+			return 0, p.errorf("negative zero is not a valid integer")
+		}
+		if isDigit(p.peek()) {
+			return 0, p.errorf("integers must not contain leading zeros")
+		}
+		return 0, nil
+	}
+	for isDigit(p.peek()) {
+		p.pos++
+	}
+	i, err := strconv.ParseInt(string(p.input[start:p.pos]), 10, 64)
+	if err != nil || i > maxSafeInt || i < -maxSafeInt {
+		return 0, p.errorf("integer out of range")
+	}
+	return i, nil
+}
+
+func (p *parser) parseIndexOrSlice() (selector, error) {
+	var start *int64
+	if p.peek() != ':' {
+		i, err := p.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		p.skipWhitespace()
+		if p.peek() != ':' {
+			return indexSelector(i), nil
+		}
+		start = &i
+	}
+	p.pos++ // Consume ':'
+
+	s := sliceSelector{start: start, step: 1}
+
+	p.skipWhitespace()
+	if c := p.peek(); c == '-' || isDigit(c) {
+		end, err := p.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		s.end = &end
+		p.skipWhitespace()
+	}
+	if p.consume(':') {
+		p.skipWhitespace()
+		if c := p.peek(); c == '-' || isDigit(c) {
+			step, err := p.parseInt()
+			if err != nil {
+				return nil, err
+			}
+			s.step = step
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) parseStringLiteral() (string, error) {
+	quote := p.peek()
+	p.pos++
+
+	var sb strings.Builder
+	for {
+		if p.pos >= len(p.input) {
+			return "", p.errorf("unterminated string literal")
+		}
+		c := p.input[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return sb.String(), nil
+		case c == '\\':
+			if p.pos >= len(p.input) {
+				return "", p.errorf("unterminated string literal")
+			}
+			e := p.input[p.pos]
+			p.pos++
+			switch e {
+			case 'b':
+				sb.WriteRune('\b')
+			case 'f':
+				sb.WriteRune('\f')
+			case 'n':
+				sb.WriteRune('\n')
+			case 'r':
+				sb.WriteRune('\r')
+			case 't':
+				sb.WriteRune('\t')
+			case '/', '\\':
+				sb.WriteRune(e)
+			case '\'', '"':
+				if e != quote {
+					return "", p.errorf("invalid escape sequence")
+				}
+				sb.WriteRune(e)
+			case 'u':
+				r, err := p.parseUnicodeEscape()
+				if err != nil {
+					return "", err
+				}
+				sb.WriteRune(r)
+			default:
+				return "", p.errorf("invalid escape sequence")
+			}
+		case c < 0x20:
+			return "", p.errorf("control characters must be escaped within string literals")
+		default:
+			sb.WriteRune(c)
+		}
+	}
+}
+
+func (p *parser) parseHex4() (rune, error) {
+	if p.pos+4 > len(p.input) {
+		return 0, p.errorf("invalid unicode escape sequence")
+	}
+	v, err := strconv.ParseUint(string(p.input[p.pos:p.pos+4]), 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid unicode escape sequence")
+	}
+	p.pos += 4
+	return rune(v), nil
+}
+
+func (p *parser) parseUnicodeEscape() (rune, error) {
+	r, err := p.parseHex4()
+	if err != nil {
+		return 0, err
+	}
+	if utf16.IsSurrogate(r) {
+		if r >= 0xDC00 || !p.consumeStr(`\u`) {
+			return 0, p.errorf("invalid unicode surrogate pair")
+		}
+		low, err := p.parseHex4()
+		if err != nil {
+			return 0, err
+		}
+		if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+			return 0, p.errorf("invalid unicode surrogate pair")
+		}
+	}
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (p *parser) parseLogicalOr() (logicalExpr, error) {
+	var exprs orExpr
+	for {
+		e, err := p.parseLogicalAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+
+		start := p.pos
+		p.skipWhitespace()
+		if !p.consumeStr("||") {
+			p.pos = start
+			break
+		}
+		p.skipWhitespace()
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+func (p *parser) parseLogicalAnd() (logicalExpr, error) {
+	var exprs andExpr
+	for {
+		e, err := p.parseBasicExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+
+		start := p.pos
+		p.skipWhitespace()
+		if !p.consumeStr("&&") {
+			p.pos = start
+			break
+		}
+		p.skipWhitespace()
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+var errNotComparable = errors.New("not comparableExpr")
+
+func (p *parser) parseBasicExpr() (logicalExpr, error) {
+	if p.peek() == '!' && p.peekAt(1) != '=' {
+		p.pos++
+		p.skipWhitespace()
+		if p.consume('(') {
+			e, err := p.parseParenRemainder()
+			if err != nil {
+				return nil, err
+			}
+			return notExpr{e}, nil
+		}
+		e, err := p.parseTestExpr()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{e}, nil
+	}
+	if p.consume('(') {
+		return p.parseParenRemainder()
+	}
+
+	startPos := p.pos
+	left, operand, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	afterOperand := p.pos
+	p.skipWhitespace()
+	if op := p.parseComparisonOp(); op != "" {
+		lc, err := asComparable(left)
+		if err != nil {
+			p.pos = startPos
+			return nil, p.errorf("left side of comparison must be a literal, singular query or function that returns a value")
+		}
+		p.skipWhitespace()
+		rightPos := p.pos
+		right, _, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		rc, err := asComparable(right)
+		if err != nil {
+			p.pos = rightPos
+			return nil, p.errorf("right side of comparison must be a literal, singular query or function that returns a value")
+		}
+		return comparisonExpr{op: op, left: lc, right: rc}, nil
+	}
+	p.pos = afterOperand
+
+	switch t := left.(type) {
+	case filterQuery:
+		return t, nil
+	case funcCall:
+		if t.def.result == logicalResult {
+			return t, nil
+		}
+	}
+	p.pos = startPos
+	return nil, p.errorf("expected a logical expression but got %v", operand)
+}
+
+func (p *parser) parseParenRemainder() (logicalExpr, error) {
+	p.skipWhitespace()
+	e, err := p.parseLogicalOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipWhitespace()
+	if !p.consume(')') {
+		return nil, p.errorf("expected ')'")
+	}
+	return e, nil
+}
+
+func (p *parser) parseTestExpr() (logicalExpr, error) {
+	startPos := p.pos
+	operand, desc, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	switch t := operand.(type) {
+	case filterQuery:
+		return t, nil
+	case funcCall:
+		if t.def.result == logicalResult {
+			return t, nil
+		}
+	}
+	p.pos = startPos
+	return nil, p.errorf("expected a logical expression but got %v", desc)
+}
+
+func asComparable(v any) (comparableExpr, error) {
+	switch t := v.(type) {
+	case literal:
+		return t, nil
+	case filterQuery:
+		if t.isSingular() {
+			return t, nil
+		}
+	case funcCall:
+		if t.def.result == valueResult {
+			return t, nil
+		}
+	}
+	return nil, errNotComparable
+}
+
+func (p *parser) parseComparisonOp() string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consumeStr(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// parseOperand parses either a literal, a filter query or a function call,
+// along with a description of the operand for error messages.
+func (p *parser) parseOperand() (any, string, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		segments, err := p.parseSegments()
+		if err != nil {
+			return nil, "", err
+		}
+		return filterQuery{relative: c == '@', segments: segments}, "a query", nil
+	case c == '\'' || c == '"':
+		s, err := p.parseStringLiteral()
+		if err != nil {
+			return nil, "", err
+		}
+		return literal{s}, "a literal", nil
+	case c == '-' || isDigit(c):
+		n, err := p.parseNumberLiteral()
+		if err != nil {
+			return nil, "", err
+		}
+		return literal{n}, "a literal", nil
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		for c := p.peek(); (c >= 'a' && c <= 'z') || c == '_' || isDigit(c); c = p.peek() {
+			p.pos++
+		}
+		name := string(p.input[start:p.pos])
+		if p.peek() != '(' {
+			switch name {
+			case "true":
+				return literal{true}, "a literal", nil
+			case "false":
+				return literal{false}, "a literal", nil
+			case "null":
+				return literal{nil}, "a literal", nil
+			}
+			p.pos = start
+			return nil, "", p.errorf("expected literal, query or function")
+		}
+		call, err := p.parseFunctionCall(start, name)
+		if err != nil {
+			return nil, "", err
+		}
+		return call, "a function that returns a value", nil
+	}
+	return nil, "", p.errorf("expected literal, query or function")
+}
+
+func (p *parser) parseNumberLiteral() (any, error) {
+	start := p.pos
+	p.consume('-')
+	if !isDigit(p.peek()) {
+		return nil, p.errorf("expected number")
+	}
+	if p.consume('0') {
+		if isDigit(p.peek()) {
+			return nil, p.errorf("numbers must not contain leading zeros")
+		}
+	} else {
+		for isDigit(p.peek()) {
+			p.pos++
+		}
+	}
+	isFloat := false
+	if p.peek() == '.' {
+		isFloat = true
+		p.pos++
+		if !isDigit(p.peek()) {
+			return nil, p.errorf("expected fraction digits")
+		}
+		for isDigit(p.peek()) {
+			p.pos++
+		}
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		isFloat = true
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		if !isDigit(p.peek()) {
+			return nil, p.errorf("expected exponent digits")
+		}
+		for isDigit(p.peek()) {
+			p.pos++
+		}
+	}
+	str := string(p.input[start:p.pos])
+	if !isFloat {
+		if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsInf(f, 0) {
+		return nil, p.errorf("invalid number")
+	}
+	return f, nil
+}
+
+func (p *parser) parseFunctionCall(namePos int, name string) (funcCall, error) {
+	def, exists := functions[name]
+	if !exists {
+		p.pos = namePos
+		return funcCall{}, p.errorf("unknown function: %v", name)
+	}
+	p.pos++ // Consume '('
+
+	call := funcCall{name: name, def: def}
+	p.skipWhitespace()
+	if !p.consume(')') {
+		for {
+			argPos := p.pos
+			operand, _, err := p.parseOperand()
+			if err != nil {
+				return funcCall{}, err
+			}
+			if len(call.args) >= len(def.params) {
+				p.pos = argPos
+				return funcCall{}, p.errorf("too many arguments for function %v", name)
+			}
+
+			var arg funcArg
+			switch def.params[len(call.args)] {
+			case nodesArg:
+				q, ok := operand.(filterQuery)
+				if !ok {
+					p.pos = argPos
+					return funcCall{}, p.errorf("argument %v of function %v must be a query", len(call.args)+1, name)
+				}
+				arg.nodes = &q
+			case valueArg:
+				c, err := asComparable(operand)
+				if err != nil {
+					p.pos = argPos
+					return funcCall{}, p.errorf("argument %v of function %v must be a literal, singular query or function that returns a value", len(call.args)+1, name)
+				}
+				arg.value = c
+			}
+			call.args = append(call.args, arg)
+
+			p.skipWhitespace()
+			if p.consume(')') {
+				break
+			}
+			if !p.consume(',') {
+				return funcCall{}, p.errorf("expected ',' or ')'")
+			}
+			p.skipWhitespace()
+		}
+	}
+	if len(call.args) != len(def.params) {
+		p.pos = namePos
+		return funcCall{}, p.errorf("function %v expects %v arguments but received %v", name, len(def.params), len(call.args))
+	}
+	return call, nil
+}
@@ -0,0 +1,146 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rfcBookstore = `{
+  "store": {
+    "book": [
+      {"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+      {"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+      {"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+      {"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+    ],
+    "bicycle": {"color": "red", "price": 399}
+  }
+}`
+
+func parseTestJSON(t testing.TB, s string) any {
+	t.Helper()
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestRFCQuerySelect(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		query    string
+		expected string
+	}{
+		{name: "root", input: `{"a":1}`, query: `$`, expected: `[{"a":1}]`},
+		{name: "authors", input: rfcBookstore, query: `$.store.book[*].author`, expected: `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{name: "all authors", input: rfcBookstore, query: `$..author`, expected: `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{name: "store prices", input: rfcBookstore, query: `$.store..price`, expected: `[399,8.95,12.99,8.99,22.99]`},
+		{name: "third book", input: rfcBookstore, query: `$..book[2].author`, expected: `["Herman Melville"]`},
+		{name: "missing member", input: rfcBookstore, query: `$..book[2].publisher`, expected: `[]`},
+		{name: "last book", input: rfcBookstore, query: `$..book[-1].title`, expected: `["The Lord of the Rings"]`},
+		{name: "union", input: rfcBookstore, query: `$..book[0,1].title`, expected: `["Sayings of the Century","Sword of Honour"]`},
+		{name: "slice", input: rfcBookstore, query: `$..book[:2].title`, expected: `["Sayings of the Century","Sword of Honour"]`},
+		{name: "existence filter", input: rfcBookstore, query: `$..book[?@.isbn].title`, expected: `["Moby Dick","The Lord of the Rings"]`},
+		{name: "comparison filter", input: rfcBookstore, query: `$..book[?@.price<10].title`, expected: `["Sayings of the Century","Moby Dick"]`},
+		{name: "arithmetic is unsupported", input: rfcBookstore, query: `$..book[?@.price > $.store.bicycle.price / 100]`, expected: `error`},
+		{name: "quoted names", input: `{"o":{"j j":{"k.k":3}},"'":{"@":2}}`, query: `$.o['j j']['k.k']`, expected: `[3]`},
+		{name: "double quoted names", input: `{"o":{"j j":{"k.k":3}},"'":{"@":2}}`, query: `$["'"]["@"]`, expected: `[2]`},
+		{name: "escaped name", input: `{"a☺b":1}`, query: `$['a☺b']`, expected: `[1]`},
+		{name: "wildcard object", input: `{"o":{"j":1,"k":2},"a":[5,3]}`, query: `$.o[*]`, expected: `[1,2]`},
+		{name: "wildcard union", input: `{"o":{"j":1,"k":2},"a":[5,3]}`, query: `$.o[*, *]`, expected: `[1,2,1,2]`},
+		{name: "wildcard array", input: `{"o":{"j":1,"k":2},"a":[5,3]}`, query: `$.a[*]`, expected: `[5,3]`},
+		{name: "wildcard scalar", input: `{"a":1}`, query: `$.a.*`, expected: `[]`},
+		{name: "index out of range", input: `["a","b"]`, query: `$[2]`, expected: `[]`},
+		{name: "negative index", input: `["a","b"]`, query: `$[-2]`, expected: `["a"]`},
+		{name: "slice range", input: `["a","b","c","d","e","f","g"]`, query: `$[1:3]`, expected: `["b","c"]`},
+		{name: "slice open end", input: `["a","b","c","d","e","f","g"]`, query: `$[5:]`, expected: `["f","g"]`},
+		{name: "slice step", input: `["a","b","c","d","e","f","g"]`, query: `$[1:5:2]`, expected: `["b","d"]`},
+		{name: "slice negative step", input: `["a","b","c","d","e","f","g"]`, query: `$[5:1:-2]`, expected: `["f","d"]`},
+		{name: "slice reverse", input: `["a","b","c","d","e","f","g"]`, query: `$[::-1]`, expected: `["g","f","e","d","c","b","a"]`},
+		{name: "slice zero step", input: `["a","b","c"]`, query: `$[::0]`, expected: `[]`},
+		{name: "slice whitespace", input: `["a","b","c"]`, query: `$[ 1 : 2 ]`, expected: `["b"]`},
+		{name: "filter equals string", input: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]}`, query: `$.a[?@.b == 'kilo']`, expected: `[{"b":"kilo"}]`},
+		{name: "filter paren", input: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]}`, query: `$.a[?(@.b == 'kilo')]`, expected: `[{"b":"kilo"}]`},
+		{name: "filter current", input: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]}`, query: `$.a[?@>3.5]`, expected: `[5,4,6]`},
+		{name: "filter exists", input: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]}`, query: `$.a[?@.b]`, expected: `[{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]`},
+		{name: "filter nested", input: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}],"o":{"p":1,"q":2,"r":3,"s":5,"t":{"u":6}}}`, query: `$[?@.*]`, expected: `[[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}],{"p":1,"q":2,"r":3,"s":5,"t":{"u":6}}]`},
+		{name: "filter nested filter", input: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]}`, query: `$[?@[?@.b]]`, expected: `[[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]]`},
+		{name: "filter union", input: `{"o":[{"a":1},{"a":2},{"a":3}]}`, query: `$.o[?@.a<2, ?@.a>2]`, expected: `[{"a":1},{"a":3}]`},
+		{name: "filter or", input: `{"a":[1,2,3,4,5]}`, query: `$.a[?@<2 || @>4]`, expected: `[1,5]`},
+		{name: "filter and not", input: `{"a":[1,2,3,4,5]}`, query: `$.a[?@>1 && !(@>4)]`, expected: `[2,3,4]`},
+		{name: "filter not exists", input: `{"a":[{"b":1},{"c":2}]}`, query: `$.a[?!@.b]`, expected: `[{"c":2}]`},
+		{name: "filter object values", input: `{"o":{"p":1,"q":2,"r":3,"s":5,"t":{"u":6}}}`, query: `$.o[?@>1 && @<4]`, expected: `[2,3]`},
+		{name: "filter nothing equals nothing", input: `{"a":[{"b":1},{}]}`, query: `$.a[?@.x == @.y]`, expected: `[{"b":1},{}]`},
+		{name: "filter null", input: `{"a":[{"b":null},{"b":1},{}]}`, query: `$.a[?@.b == null]`, expected: `[{"b":null}]`},
+		{name: "filter structured equality", input: `{"a":[{"b":[1,2]},{"b":[1]},{"b":{"c":1}}]}`, query: `$.a[?@.b == $.a[0].b]`, expected: `[{"b":[1,2]}]`},
+		{name: "filter mixed types", input: `{"a":[1,"1",true]}`, query: `$.a[?@ <= 1]`, expected: `[1]`},
+		{name: "filter string ordering", input: `{"a":["a","b","c"]}`, query: `$.a[?@ > "a"]`, expected: `["b","c"]`},
+		{name: "filter exponent literal", input: `{"a":[10,100,1000]}`, query: `$.a[?@ == 1e2]`, expected: `[100]`},
+		{name: "descendant wildcard", input: `{"o":{"j":1,"k":2},"a":[5,3,[{"j":4},{"k":6}]]}`, query: `$..[*]`, expected: `[[5,3,[{"j":4},{"k":6}]],{"j":1,"k":2},5,3,[{"j":4},{"k":6}],{"j":4},{"k":6},4,6,1,2]`},
+		{name: "descendant index", input: `{"o":{"j":1,"k":2},"a":[5,3,[{"j":4},{"k":6}]]}`, query: `$..[0]`, expected: `[5,{"j":4}]`},
+		{name: "descendant name", input: `{"o":{"j":1,"k":2},"a":[5,3,[{"j":4},{"k":6}]]}`, query: `$..j`, expected: `[4,1]`},
+		{name: "function length", input: `{"a":["ab","abc",[1,2,3],{"a":1}]}`, query: `$.a[?length(@) == 3]`, expected: `["abc",[1,2,3]]`},
+		{name: "function length unicode", input: `{"a":["☺b"]}`, query: `$.a[?length(@) == 2]`, expected: `["☺b"]`},
+		{name: "function count", input: `{"a":[{"b":[1,2]},{"b":[1]}]}`, query: `$.a[?count(@.b[*]) > 1]`, expected: `[{"b":[1,2]}]`},
+		{name: "function match", input: `{"a":["2024-01-01","x2024-01-01","2024-01-01x"]}`, query: `$.a[?match(@, '[0-9]{4}-[0-9]{2}-[0-9]{2}')]`, expected: `["2024-01-01"]`},
+		{name: "function search", input: `{"a":["bob","rob","alice"]}`, query: `$.a[?search(@, 'o')]`, expected: `["bob","rob"]`},
+		{name: "function value", input: `{"a":[{"b":[{"c":"x"}]},{"b":[{"c":"y"},{"c":"x"}]}]}`, query: `$.a[?value(@.b[*].c) == 'x']`, expected: `[{"b":[{"c":"x"}]}]`},
+		{name: "function not", input: `{"a":["bob","rob","alice"]}`, query: `$.a[?!search(@, 'o')]`, expected: `["alice"]`},
+		{name: "whitespace", input: `{"a":{"b":[1,2]}}`, query: `$ .a [ 'b' ] [ 0 , 1 ]`, expected: `[1,2]`},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parseRFCQuery(test.query)
+			if test.expected == "error" {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			res := q.selectFrom(parseTestJSON(t, test.input))
+			resBytes, err := json.Marshal(res)
+			require.NoError(t, err)
+			assert.JSONEq(t, test.expected, string(resBytes))
+		})
+	}
+}
+
+func TestRFCQueryParseErrors(t *testing.T) {
+	for _, query := range []string{
+		``,
+		`a`,
+		`$.`,
+		`$[`,
+		`$[?]`,
+		`$[01]`,
+		`$[-0]`,
+		`$[9007199254740992]`,
+		`$.a b`,
+		`$['a]`,
+		`$['\a']`,
+		`$..`,
+		`$.a[?@.b == 1 &&]`,
+		`$[?@ == 01]`,
+		`$[?1]`,
+		`$[?'a' == 'b' == 'c']`,
+		`$[?@.* == 1]`,
+		`$[?@..a == 1]`,
+		`$[?length(@)]`,
+		`$[?match(@.a, 'b') == true]`,
+		`$[?count(1) == 1]`,
+		`$[?length(@.*) == 1]`,
+		`$[?nope(@)]`,
+		`$[?length(@, @) == 1]`,
+		`$[?count() == 1]`,
+		`$[?@.a == nope]`,
+		`$.a.[0]`,
+	} {
+		_, err := parseRFCQuery(query)
+		assert.Error(t, err, query)
+	}
+}
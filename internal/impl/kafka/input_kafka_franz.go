@@ -82,6 +82,11 @@ Finally, it's also possible to specify an explicit offset to consume from by add
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField()).
 		Field(service.NewBoolField("multi_header").Description("Decode headers into lists to allow handling of multiple values with the same key").Default(false).Advanced()).
+		Field(service.NewBoolField("propagate_trace_context").
+			Description(propagateTraceContextDesc).
+			Default(false).
+			Advanced().
+			Version("4.20.0")).
 		Field(service.NewBatchPolicyField("batching").
 			Description("Allows you to configure a [batching policy](/docs/configuration/batching) that applies to individual topic partitions in order to batch messages together before flushing them for processing. Batching can be beneficial for performance as well as useful for windowed processing, and doing so this way preserves the ordering of topic partitions.").
 			Advanced()).
@@ -130,6 +135,7 @@ type franzKafkaReader struct {
 	commitPeriod    time.Duration
 	regexPattern    bool
 	multiHeader     bool
+	propagateTrace  bool
 	batchPolicy     service.BatchPolicy
 
 	batchChan atomic.Value
@@ -221,6 +227,9 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, res *service.Reso
 	if f.multiHeader, err = conf.FieldBool("multi_header"); err != nil {
 		return nil, err
 	}
+	if f.propagateTrace, err = conf.FieldBool("propagate_trace_context"); err != nil {
+		return nil, err
+	}
 	if f.saslConfs, err = saslMechanismsFromConfig(conf); err != nil {
 		return nil, err
 	}
@@ -258,6 +267,10 @@ func (f *franzKafkaReader) recordToMessage(record *kgo.Record) *msgWithRecord {
 		}
 	}
 
+	if f.propagateTrace {
+		msg = msg.WithContext(extractFranzTraceContext(msg.Context(), record.Headers))
+	}
+
 	// The record lives on for checkpointing, but we don't need the contents
 	// going forward so discard these. This looked fine to me but could
 	// potentially be a source of problems so treat this as sus.
@@ -103,6 +103,7 @@ Unfortunately this error message will appear for a wide range of connection prob
 			).Advanced(),
 			docs.FieldInt("fetch_buffer_cap", "The maximum number of unprocessed messages to fetch at a given time.").Advanced(),
 			docs.FieldBool("multi_header", "Decode headers into lists to allow handling of multiple values with the same key").Advanced(),
+			docs.FieldBool("propagate_trace_context", propagateTraceContextDesc).Advanced().AtVersion("4.20.0"),
 			func() docs.FieldSpec {
 				b := policy.FieldSpec()
 				b.IsAdvanced = true
@@ -349,8 +350,11 @@ func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(conte
 	}
 }
 
-func dataToPart(highestOffset int64, data *sarama.ConsumerMessage, multiHeader bool) *message.Part {
+func dataToPart(highestOffset int64, data *sarama.ConsumerMessage, multiHeader, propagateTrace bool) *message.Part {
 	part := message.NewPart(data.Value)
+	if propagateTrace {
+		part = part.WithContext(extractSaramaTraceContext(part.GetContext(), data.Headers))
+	}
 
 	if multiHeader {
 		// in multi header mode we gather headers so we can encode them as lists
@@ -73,7 +73,7 @@ func (k *kafkaReader) ConsumeClaim(sess sarama.ConsumerGroupSession, claim saram
 			}
 
 			latestOffset = data.Offset
-			part := dataToPart(claim.HighWaterMarkOffset(), data, k.conf.MultiHeader)
+			part := dataToPart(claim.HighWaterMarkOffset(), data, k.conf.MultiHeader, k.conf.PropagateTrace)
 
 			if batchPolicy.Add(part) {
 				nextTimedBatchChan = nil
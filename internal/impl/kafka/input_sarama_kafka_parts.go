@@ -76,7 +76,7 @@ partMsgLoop:
 			k.log.Tracef("Received message from topic %v partition %v\n", topic, partition)
 
 			latestOffset = data.Offset
-			part := dataToPart(consumer.HighWaterMarkOffset(), data, k.conf.MultiHeader)
+			part := dataToPart(consumer.HighWaterMarkOffset(), data, k.conf.MultiHeader, k.conf.PropagateTrace)
 
 			if batchPolicy.Add(part) {
 				nextTimedBatchChan = nil
@@ -71,6 +71,11 @@ This output often out-performs the traditional ` + "`kafka`" + ` output as well
 			Advanced()).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField()).
+		Field(service.NewBoolField("propagate_trace_context").
+			Description(propagateTraceContextDesc).
+			Default(false).
+			Advanced().
+			Version("4.20.0")).
 		LintRule(`
 root = if this.partitioner == "manual" {
   if this.partition.or("") == "" {
@@ -118,6 +123,7 @@ type franzKafkaWriter struct {
 	timeout          time.Duration
 	produceMaxBytes  int32
 	compressionPrefs []kgo.CompressionCodec
+	propagateTrace   bool
 
 	client *kgo.Client
 
@@ -233,6 +239,9 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 	if f.saslConfs, err = saslMechanismsFromConfig(conf); err != nil {
 		return nil, err
 	}
+	if f.propagateTrace, err = conf.FieldBool("propagate_trace_context"); err != nil {
+		return nil, err
+	}
 
 	return &f, nil
 }
@@ -311,6 +320,9 @@ func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatc
 			})
 			return nil
 		})
+		if f.propagateTrace {
+			record.Headers = injectFranzTraceContext(msg.Context(), record.Headers)
+		}
 		records = append(records, record)
 	}
 
@@ -72,6 +72,7 @@ Unfortunately this error message will appear for a wide range of connection prob
 			docs.FieldString("static_headers", "An optional map of static headers that should be added to messages in addition to metadata.", map[string]string{"first-static-header": "value-1", "second-static-header": "value-2"}).Map(),
 			docs.FieldObject("metadata", "Specify criteria for which metadata values are sent with messages as headers.").WithChildren(metadata.ExcludeFilterFields()...),
 			output.InjectTracingSpanMappingDocs,
+			docs.FieldBool("propagate_trace_context", propagateTraceContextDesc).Advanced().AtVersion("4.20.0"),
 			docs.FieldInt("max_in_flight", "The maximum number of parallel message batches to have in flight at any given time."),
 			docs.FieldBool("ack_replicas", "Ensure that messages have been copied across all replicas before acknowledging receipt.").Advanced(),
 			docs.FieldInt("max_msg_bytes", "The maximum size in bytes of messages sent to the target topic.").Advanced(),
@@ -371,6 +372,9 @@ func (k *kafkaWriter) WriteBatch(ctx context.Context, msg message.Batch) error {
 			Headers:  append(k.buildSystemHeaders(p), userDefinedHeaders...),
 			Metadata: i, // Store the original index for later reference.
 		}
+		if k.conf.PropagateTrace && k.version.IsAtLeast(sarama.V0_11_0_0) {
+			nextMsg.Headers = injectSaramaTraceContext(p.GetContext(), nextMsg.Headers)
+		}
 		if len(key) > 0 {
 			nextMsg.Key = sarama.ByteEncoder(key)
 		}
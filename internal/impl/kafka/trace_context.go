@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/benthosdev/benthos/v4/internal/tracing"
+)
+
+const propagateTraceContextDesc = "Whether to propagate [W3C trace context](https://www.w3.org/TR/trace-context/) through the `traceparent` and `tracestate` record headers. When enabled consumed records containing these headers are traced as children of the remote span rather than as new roots, and produced records are given headers referencing the span of the message being written."
+
+func extractFranzTraceContext(ctx context.Context, headers []kgo.RecordHeader) context.Context {
+	c := propagation.MapCarrier{}
+	for _, hdr := range headers {
+		c[hdr.Key] = string(hdr.Value)
+	}
+	return tracing.ExtractW3CTraceContext(ctx, c)
+}
+
+func injectFranzTraceContext(ctx context.Context, headers []kgo.RecordHeader) []kgo.RecordHeader {
+	c := propagation.MapCarrier{}
+	tracing.InjectW3CTraceContext(ctx, c)
+	for k, v := range c {
+		replaced := false
+		for i, hdr := range headers {
+			if hdr.Key == k {
+				headers[i].Value = []byte(v)
+				replaced = true
+			}
+		}
+		if !replaced {
+			headers = append(headers, kgo.RecordHeader{Key: k, Value: []byte(v)})
+		}
+	}
+	return headers
+}
+
+func extractSaramaTraceContext(ctx context.Context, headers []*sarama.RecordHeader) context.Context {
+	c := propagation.MapCarrier{}
+	for _, hdr := range headers {
+		if hdr != nil {
+			c[string(hdr.Key)] = string(hdr.Value)
+		}
+	}
+	return tracing.ExtractW3CTraceContext(ctx, c)
+}
+
+func injectSaramaTraceContext(ctx context.Context, headers []sarama.RecordHeader) []sarama.RecordHeader {
+	c := propagation.MapCarrier{}
+	tracing.InjectW3CTraceContext(ctx, c)
+	for k, v := range c {
+		replaced := false
+		for i, hdr := range headers {
+			if string(hdr.Key) == k {
+				headers[i].Value = []byte(v)
+				replaced = true
+			}
+		}
+		if !replaced {
+			headers = append(headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+		}
+	}
+	return headers
+}
@@ -0,0 +1,47 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContextHeaders(t *testing.T) {
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	ctx := extractFranzTraceContext(context.Background(), []kgo.RecordHeader{
+		{Key: "foo", Value: []byte("bar")},
+		{Key: "traceparent", Value: []byte(traceParent)},
+	})
+	spanCtx := trace.SpanContextFromContext(ctx)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spanCtx.TraceID().String())
+	assert.True(t, spanCtx.IsRemote())
+
+	assert.Equal(t, []kgo.RecordHeader{
+		{Key: "foo", Value: []byte("bar")},
+		{Key: "traceparent", Value: []byte(traceParent)},
+	}, injectFranzTraceContext(ctx, []kgo.RecordHeader{
+		{Key: "foo", Value: []byte("bar")},
+		{Key: "traceparent", Value: []byte("old")},
+	}))
+
+	ctx = extractSaramaTraceContext(context.Background(), []*sarama.RecordHeader{
+		{Key: []byte("traceparent"), Value: []byte(traceParent)},
+	})
+	assert.Equal(t, "00f067aa0ba902b7", trace.SpanContextFromContext(ctx).SpanID().String())
+
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("foo"), Value: []byte("bar")},
+		{Key: []byte("traceparent"), Value: []byte(traceParent)},
+	}, injectSaramaTraceContext(ctx, []sarama.RecordHeader{
+		{Key: []byte("foo"), Value: []byte("bar")},
+	}))
+
+	// Without a valid span nothing is injected.
+	assert.Empty(t, injectSaramaTraceContext(context.Background(), nil))
+	assert.Equal(t, context.Background(), extractFranzTraceContext(context.Background(), nil))
+}
@@ -0,0 +1,172 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	kcFieldAPIURL    = "api_url"
+	kcFieldToken     = "token"
+	kcFieldTokenFile = "token_file"
+	kcFieldTLS       = "tls"
+
+	inClusterTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	inClusterCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+func kubeClientFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewStringField(kcFieldAPIURL).
+			Description("The URL of the Kubernetes API server. When empty the in-cluster configuration of the pod that Benthos is running within is used, consisting of the API server address, service account token and certificate authority.").
+			Default("").
+			Example("https://localhost:6443"),
+		service.NewStringField(kcFieldToken).
+			Description("A bearer token used to authenticate with the API server.").
+			Default("").
+			Secret().
+			Advanced(),
+		service.NewStringField(kcFieldTokenFile).
+			Description("A file containing a bearer token used to authenticate with the API server. The file is read for each request in order to support rotated tokens, and defaults to the service account token when using the in-cluster configuration.").
+			Default("").
+			Advanced(),
+		service.NewTLSField(kcFieldTLS),
+	}
+}
+
+// kubeClient performs requests against the Kubernetes API server.
+type kubeClient struct {
+	baseURL   string
+	token     string
+	tokenFile string
+
+	fs     fs.FS
+	client *http.Client
+}
+
+func newKubeClientFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*kubeClient, error) {
+	k := &kubeClient{fs: mgr.FS()}
+
+	var err error
+	if k.baseURL, err = conf.FieldString(kcFieldAPIURL); err != nil {
+		return nil, err
+	}
+	if k.token, err = conf.FieldString(kcFieldToken); err != nil {
+		return nil, err
+	}
+	if k.tokenFile, err = conf.FieldString(kcFieldTokenFile); err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS(kcFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+
+	if k.baseURL == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, errors.New("an api_url must be specified when not running within a Kubernetes cluster")
+		}
+		k.baseURL = "https://" + net.JoinHostPort(host, port)
+		if k.token == "" && k.tokenFile == "" {
+			k.tokenFile = inClusterTokenFile
+		}
+		if tlsConf.RootCAs == nil {
+			caBytes, err := fs.ReadFile(k.fs, inClusterCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read in-cluster certificate authority: %w", err)
+			}
+			tlsConf.RootCAs = x509.NewCertPool()
+			if !tlsConf.RootCAs.AppendCertsFromPEM(caBytes) {
+				return nil, errors.New("failed to parse in-cluster certificate authority")
+			}
+		}
+	}
+	k.baseURL = strings.TrimSuffix(k.baseURL, "/")
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	k.client = &http.Client{Transport: transport}
+	return k, nil
+}
+
+// get performs a GET request of an API path, returning an error for any
+// response status other than 200.
+func (k *kubeClient) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := k.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	token := k.token
+	if k.tokenFile != "" {
+		tokenBytes, err := fs.ReadFile(k.fs, k.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(tokenBytes))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, statusErrorFromResponse(res)
+	}
+	return res, nil
+}
+
+// kubeStatus is a Status object returned by the API server for failed
+// requests.
+type kubeStatus struct {
+	Code    int    `json:"code"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+func (s *kubeStatus) Error() string {
+	if s.Message != "" {
+		return fmt.Sprintf("api server returned status %v: %v", s.Code, s.Message)
+	}
+	return fmt.Sprintf("api server returned status %v", s.Code)
+}
+
+func statusErrorFromResponse(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1<<16))
+
+	status := &kubeStatus{}
+	if err := json.Unmarshal(body, status); err != nil || status.Code == 0 {
+		status.Code = res.StatusCode
+		status.Message = strings.TrimSpace(string(body))
+	}
+	return status
+}
+
+// isResourceExpired returns true if an error indicates that a resource version
+// is too old to watch from, in which case a list must be performed instead.
+func isResourceExpired(err error) bool {
+	var status *kubeStatus
+	return errors.As(err, &status) && status.Code == http.StatusGone
+}
@@ -0,0 +1,252 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// Watch event types sent by the API server.
+const (
+	watchAdded    = "ADDED"
+	watchModified = "MODIFIED"
+	watchDeleted  = "DELETED"
+	watchBookmark = "BOOKMARK"
+	watchError    = "ERROR"
+)
+
+type kubeObjectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	UID             string            `json:"uid"`
+	ResourceVersion string            `json:"resourceVersion"`
+	Labels          map[string]string `json:"labels"`
+}
+
+// kubeObject is a generic API object, only the metadata is parsed and the raw
+// object is kept for resource specific parsing.
+type kubeObject struct {
+	Metadata kubeObjectMeta `json:"metadata"`
+
+	raw json.RawMessage
+}
+
+func parseKubeObject(raw json.RawMessage) (*kubeObject, error) {
+	obj := &kubeObject{raw: raw}
+	if err := json.Unmarshal(raw, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// informerHandler is called for each change to a watched resource. The resume
+// version is a resource version from which the watch can be safely resumed once
+// this and all prior changes have been processed.
+type informerHandler func(ctx context.Context, eventType string, obj *kubeObject, resumeVersion string) error
+
+// informer maintains a cache of the objects of a resource by listing them and
+// then watching for changes, performing a new list only when a watch can no
+// longer be resumed from the most recent resource version.
+type informer struct {
+	client  *kubeClient
+	path    string
+	query   url.Values
+	handler informerHandler
+	log     *service.Logger
+
+	resourceVersion string
+	store           map[string]*kubeObject
+	retryPeriod     time.Duration
+}
+
+func newInformer(client *kubeClient, path string, query url.Values, log *service.Logger, handler informerHandler) *informer {
+	return &informer{
+		client:      client,
+		path:        path,
+		query:       query,
+		handler:     handler,
+		log:         log,
+		store:       map[string]*kubeObject{},
+		retryPeriod: time.Second * 5,
+	}
+}
+
+// run lists and watches the resource until the context is cancelled. When a
+// resource version is set prior to running the initial list is skipped.
+func (i *informer) run(ctx context.Context) error {
+	for {
+		var err error
+		if i.resourceVersion == "" {
+			err = i.relist(ctx)
+		}
+		if err == nil {
+			err = i.watch(ctx)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			continue
+		}
+		if isResourceExpired(err) {
+			i.log.Debugf("Resource version %v of %v has expired, listing objects again", i.resourceVersion, i.path)
+			i.resourceVersion = ""
+			continue
+		}
+
+		i.log.Errorf("Failed to watch %v: %v\n", i.path, err)
+		select {
+		case <-time.After(i.retryPeriod):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (i *informer) relist(ctx context.Context) error {
+	var items []*kubeObject
+	var listVersion string
+
+	query := url.Values{}
+	for k, v := range i.query {
+		query[k] = v
+	}
+	query.Set("limit", "500")
+	for {
+		res, err := i.client.get(ctx, i.path, query)
+		if err != nil {
+			return err
+		}
+
+		var list struct {
+			Metadata struct {
+				ResourceVersion string `json:"resourceVersion"`
+				Continue        string `json:"continue"`
+			} `json:"metadata"`
+			Items []json.RawMessage `json:"items"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&list)
+		res.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to parse list response: %w", err)
+		}
+
+		for _, raw := range list.Items {
+			obj, err := parseKubeObject(raw)
+			if err != nil {
+				return fmt.Errorf("failed to parse list item: %w", err)
+			}
+			items = append(items, obj)
+		}
+		listVersion = list.Metadata.ResourceVersion
+		if list.Metadata.Continue == "" {
+			break
+		}
+		query.Set("continue", list.Metadata.Continue)
+	}
+
+	// Only objects that are new or have changed since they were last seen
+	// are handled, and objects missing from the list are considered deleted.
+	type change struct {
+		eventType string
+		obj       *kubeObject
+	}
+	var changes []change
+
+	seen := map[string]struct{}{}
+	for _, obj := range items {
+		seen[obj.Metadata.UID] = struct{}{}
+		prev, exists := i.store[obj.Metadata.UID]
+		if !exists {
+			changes = append(changes, change{watchAdded, obj})
+		} else if prev.Metadata.ResourceVersion != obj.Metadata.ResourceVersion {
+			changes = append(changes, change{watchModified, obj})
+		}
+	}
+	for uid, obj := range i.store {
+		if _, exists := seen[uid]; !exists {
+			changes = append(changes, change{watchDeleted, obj})
+		}
+	}
+
+	for n, c := range changes {
+		// The list version can only be resumed from once every object of
+		// the list has been handled.
+		resumeVersion := i.resourceVersion
+		if n == len(changes)-1 {
+			resumeVersion = listVersion
+		}
+		if err := i.handler(ctx, c.eventType, c.obj, resumeVersion); err != nil {
+			return err
+		}
+		if c.eventType == watchDeleted {
+			delete(i.store, c.obj.Metadata.UID)
+		} else {
+			i.store[c.obj.Metadata.UID] = c.obj
+		}
+	}
+	i.resourceVersion = listVersion
+	return nil
+}
+
+func (i *informer) watch(ctx context.Context) error {
+	query := url.Values{}
+	for k, v := range i.query {
+		query[k] = v
+	}
+	query.Set("watch", "true")
+	query.Set("allowWatchBookmarks", "true")
+	query.Set("resourceVersion", i.resourceVersion)
+
+	res, err := i.client.get(ctx, i.path, query)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	dec := json.NewDecoder(res.Body)
+	for {
+		var event struct {
+			Type   string          `json:"type"`
+			Object json.RawMessage `json:"object"`
+		}
+		if err := dec.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				// The API server periodically closes watches, which are
+				// then resumed from the most recent resource version.
+				return nil
+			}
+			return err
+		}
+
+		if event.Type == watchError {
+			status := &kubeStatus{}
+			if err := json.Unmarshal(event.Object, status); err != nil {
+				return fmt.Errorf("failed to parse watch error: %w", err)
+			}
+			return status
+		}
+
+		obj, err := parseKubeObject(event.Object)
+		if err != nil {
+			return fmt.Errorf("failed to parse watch event: %w", err)
+		}
+		if event.Type != watchBookmark {
+			if err := i.handler(ctx, event.Type, obj, obj.Metadata.ResourceVersion); err != nil {
+				return err
+			}
+			if event.Type == watchDeleted {
+				delete(i.store, obj.Metadata.UID)
+			} else {
+				i.store[obj.Metadata.UID] = obj
+			}
+		}
+		i.resourceVersion = obj.Metadata.ResourceVersion
+	}
+}
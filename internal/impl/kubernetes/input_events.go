@@ -0,0 +1,323 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	keFieldNamespace      = "namespace"
+	keFieldLabelSelector  = "label_selector"
+	keFieldFieldSelector  = "field_selector"
+	keFieldIncludeDeleted = "include_deleted"
+	keFieldCache          = "checkpoint_cache"
+	keFieldCacheKey       = "checkpoint_key"
+)
+
+func eventsInputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.20.0").
+		Summary("Consumes events from a Kubernetes cluster by watching the API server.").
+		Description(`
+Events are listed when the input first starts and are then watched for changes, with each new or modified event emitted as a message containing the event object as JSON. When the watch can no longer be resumed, such as after a long period of disconnection, events are listed again and only those that have changed since they were last seen are emitted.
+
+The API server is authenticated with using a bearer token, which by default is the service account token of the pod that Benthos is running within. The service account requires permission to list and watch events within the namespace.
+
+### Checkpointing
+
+When a `+"`checkpoint_cache`"+` is configured the resource version of the most recent event that has been acknowledged, along with all events before it, is stored within the cache. When the input restarts it resumes watching from the stored resource version, and therefore events emitted whilst Benthos was not running are consumed without events that have already been delivered being emitted again. The API server only retains resource versions for a limited period of time, after which events are listed again in full.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- kubernetes_watch_event_type
+- kubernetes_namespace
+- kubernetes_name
+- kubernetes_resource_version
+- kubernetes_event_type
+- kubernetes_event_reason
+- kubernetes_involved_kind
+- kubernetes_involved_name
+`+"```"+`
+
+The field `+"`kubernetes_watch_event_type`"+` is either `+"`ADDED`, `MODIFIED` or `DELETED`"+`, and `+"`kubernetes_event_type`"+` is the type of the event itself, such as `+"`Normal` or `Warning`"+`.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).`).
+		Fields(kubeClientFields()...).
+		Fields(
+			service.NewStringField(keFieldNamespace).
+				Description("The namespace to consume events from, when empty events are consumed from all namespaces.").
+				Default("").
+				Example("default"),
+			service.NewStringField(keFieldLabelSelector).
+				Description("An optional [label selector](https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#label-selectors) to filter events by.").
+				Default(""),
+			service.NewStringField(keFieldFieldSelector).
+				Description("An optional [field selector](https://kubernetes.io/docs/concepts/overview/working-with-objects/field-selectors/) to filter events by.").
+				Default("").
+				Examples("type=Warning", "involvedObject.kind=Pod"),
+			service.NewBoolField(keFieldIncludeDeleted).
+				Description("Whether to emit events when they are deleted, which usually happens once they expire.").
+				Default(false).
+				Advanced(),
+			service.NewStringField(keFieldCache).
+				Description("An optional [cache resource](/docs/components/caches/about) used to store the resource version of the most recent acknowledged event, allowing the input to resume from it when restarted.").
+				Default(""),
+			service.NewStringField(keFieldCacheKey).
+				Description("The key under which the resource version is stored within the `checkpoint_cache`.").
+				Default("kubernetes_events_resource_version").
+				Advanced(),
+		).
+		Example(
+			"Ship Warning Events",
+			"In this example warning events from all namespaces are consumed and written to Elasticsearch, resuming from the most recent event written when restarted.",
+			`
+input:
+  kubernetes_events:
+    field_selector: type=Warning
+    checkpoint_cache: checkpoints
+
+output:
+  elasticsearch:
+    urls: [ http://localhost:9200 ]
+    index: k8s-events
+    id: ${! meta("kubernetes_resource_version") }
+
+cache_resources:
+  - label: checkpoints
+    redis:
+      url: redis://localhost:6379
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterInput("kubernetes_events", eventsInputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newEventsInputFromParsed(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type kubeEvent struct {
+	Type           string `json:"type"`
+	Reason         string `json:"reason"`
+	InvolvedObject struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"involvedObject"`
+}
+
+type eventMessage struct {
+	msg           *service.Message
+	resumeVersion string
+}
+
+type eventsInput struct {
+	client         *kubeClient
+	path           string
+	query          url.Values
+	includeDeleted bool
+	checkpointer   *service.CacheCheckpointer
+
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+
+	connMut sync.Mutex
+	msgChan chan eventMessage
+}
+
+func newEventsInputFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*eventsInput, error) {
+	e := &eventsInput{
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if e.client, err = newKubeClientFromParsed(conf, mgr); err != nil {
+		return nil, err
+	}
+
+	namespace, err := conf.FieldString(keFieldNamespace)
+	if err != nil {
+		return nil, err
+	}
+	e.path = "/api/v1/events"
+	if namespace != "" {
+		e.path = "/api/v1/namespaces/" + url.PathEscape(namespace) + "/events"
+	}
+	if e.query, err = selectorQuery(conf, keFieldLabelSelector, keFieldFieldSelector); err != nil {
+		return nil, err
+	}
+	if e.includeDeleted, err = conf.FieldBool(keFieldIncludeDeleted); err != nil {
+		return nil, err
+	}
+
+	cache, err := conf.FieldString(keFieldCache)
+	if err != nil {
+		return nil, err
+	}
+	if cache != "" {
+		cacheKey, err := conf.FieldString(keFieldCacheKey)
+		if err != nil {
+			return nil, err
+		}
+		if e.checkpointer, err = service.NewCacheCheckpointer(mgr, cache, cacheKey, 1024); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// selectorQuery returns the query parameters of the label and field selector
+// fields of a config.
+func selectorQuery(conf *service.ParsedConfig, labelField, fieldField string) (url.Values, error) {
+	query := url.Values{}
+	labelSelector, err := conf.FieldString(labelField)
+	if err != nil {
+		return nil, err
+	}
+	if labelSelector != "" {
+		query.Set("labelSelector", labelSelector)
+	}
+	fieldSelector, err := conf.FieldString(fieldField)
+	if err != nil {
+		return nil, err
+	}
+	if fieldSelector != "" {
+		query.Set("fieldSelector", fieldSelector)
+	}
+	return query, nil
+}
+
+func (e *eventsInput) Connect(ctx context.Context) error {
+	e.connMut.Lock()
+	defer e.connMut.Unlock()
+	if e.msgChan != nil {
+		return nil
+	}
+
+	var resourceVersion string
+	if e.checkpointer != nil {
+		cp, err := e.checkpointer.Get(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to obtain checkpoint: %w", err)
+		}
+		resourceVersion = string(cp)
+	}
+
+	msgChan := make(chan eventMessage)
+	inf := newInformer(e.client, e.path, e.query, e.log, func(ctx context.Context, eventType string, obj *kubeObject, resumeVersion string) error {
+		if eventType == watchDeleted && !e.includeDeleted {
+			return nil
+		}
+		msg, err := eventToMessage(eventType, obj)
+		if err != nil {
+			e.log.Errorf("Failed to parse event: %v\n", err)
+			return nil
+		}
+		select {
+		case msgChan <- eventMessage{msg: msg, resumeVersion: resumeVersion}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	})
+	inf.resourceVersion = resourceVersion
+
+	go func() {
+		defer e.shutSig.ShutdownComplete()
+
+		ctx, done := e.shutSig.CloseAtLeisureCtx(context.Background())
+		defer done()
+		_ = inf.run(ctx)
+	}()
+
+	e.msgChan = msgChan
+	e.log.Infof("Watching Kubernetes events from %v", e.path)
+	return nil
+}
+
+func eventToMessage(eventType string, obj *kubeObject) (*service.Message, error) {
+	var event kubeEvent
+	if err := json.Unmarshal(obj.raw, &event); err != nil {
+		return nil, err
+	}
+
+	msg := service.NewMessage(obj.raw)
+	msg.MetaSetMut("kubernetes_watch_event_type", eventType)
+	msg.MetaSetMut("kubernetes_namespace", obj.Metadata.Namespace)
+	msg.MetaSetMut("kubernetes_name", obj.Metadata.Name)
+	msg.MetaSetMut("kubernetes_resource_version", obj.Metadata.ResourceVersion)
+	msg.MetaSetMut("kubernetes_event_type", event.Type)
+	msg.MetaSetMut("kubernetes_event_reason", event.Reason)
+	msg.MetaSetMut("kubernetes_involved_kind", event.InvolvedObject.Kind)
+	msg.MetaSetMut("kubernetes_involved_name", event.InvolvedObject.Name)
+	return msg, nil
+}
+
+func (e *eventsInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	e.connMut.Lock()
+	msgChan := e.msgChan
+	e.connMut.Unlock()
+	if msgChan == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	var em eventMessage
+	select {
+	case em = <-msgChan:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	if e.checkpointer == nil {
+		return em.msg, func(context.Context, error) error {
+			// Nacks are handled by AutoRetryNacks.
+			return nil
+		}, nil
+	}
+
+	ackFn, err := e.checkpointer.Track(ctx, []byte(em.resumeVersion), 1, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return em.msg, ackFn, nil
+}
+
+func (e *eventsInput) Close(ctx context.Context) error {
+	go func() {
+		e.shutSig.CloseAtLeisure()
+		e.connMut.Lock()
+		if e.msgChan == nil {
+			// Indicates that we were never connected, so indicate shutdown is
+			// complete.
+			e.shutSig.ShutdownComplete()
+		}
+		e.connMut.Unlock()
+	}()
+	select {
+	case <-e.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
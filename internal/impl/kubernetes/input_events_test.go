@@ -0,0 +1,307 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// fakeAPIServer emulates the list, watch and log endpoints of an API server.
+// Watch events and log lines are consumed from channels so that tests can
+// control when they are sent, where an empty string ends the response.
+type fakeAPIServer struct {
+	*httptest.Server
+
+	mut     sync.Mutex
+	lists   map[string]string
+	queries []url.Values
+	tokens  []string
+	watches int
+
+	watch chan string
+	logs  map[string]chan string
+}
+
+func newFakeAPIServer(t *testing.T) *fakeAPIServer {
+	t.Helper()
+
+	s := &fakeAPIServer{
+		lists: map[string]string{},
+		watch: make(chan string),
+		logs:  map[string]chan string{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		query.Set("path", r.URL.Path)
+
+		s.mut.Lock()
+		s.queries = append(s.queries, query)
+		s.tokens = append(s.tokens, r.Header.Get("Authorization"))
+		list, hasList := s.lists[r.URL.Path]
+		s.mut.Unlock()
+
+		events := s.watch
+		if query.Get("follow") == "true" {
+			events = s.logStream(r.URL.Path)
+		} else if query.Get("watch") != "true" {
+			if !hasList {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(list))
+			return
+		}
+
+		if events == s.watch {
+			s.mut.Lock()
+			s.watches++
+			s.mut.Unlock()
+			defer func() {
+				s.mut.Lock()
+				s.watches--
+				s.mut.Unlock()
+			}()
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case event := <-events:
+				if event == "" {
+					return
+				}
+				_, _ = w.Write([]byte(event + "\n"))
+				w.(http.Flusher).Flush()
+				if strings.HasPrefix(event, `{"type":"ERROR"`) {
+					// The API server ends watches after errors.
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeAPIServer) setList(path, resourceVersion string, items ...string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	itemsStr := ""
+	for i, item := range items {
+		if i > 0 {
+			itemsStr += ","
+		}
+		itemsStr += item
+	}
+	s.lists[path] = fmt.Sprintf(`{"metadata":{"resourceVersion":%q},"items":[%v]}`, resourceVersion, itemsStr)
+}
+
+// logStream returns the channel of log lines of a pod log path.
+func (s *fakeAPIServer) logStream(path string) chan string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	c, exists := s.logs[path]
+	if !exists {
+		c = make(chan string)
+		s.logs[path] = c
+	}
+	return c
+}
+
+// requests returns the paths and queries of received requests, excluding log
+// requests.
+func (s *fakeAPIServer) requests() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	var reqs []string
+	for _, q := range s.queries {
+		if q.Get("follow") == "true" {
+			continue
+		}
+		req := q.Get("path")
+		if q.Get("watch") == "true" {
+			req += " watch " + q.Get("resourceVersion")
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+// waitForWatches waits until the number of open watches matches n.
+func (s *fakeAPIServer) waitForWatches(t *testing.T, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		return s.watches == n
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func (s *fakeAPIServer) send(t *testing.T, ch chan string, events ...string) {
+	t.Helper()
+	for _, e := range events {
+		select {
+		case ch <- e:
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out sending to fake api server")
+		}
+	}
+}
+
+func testEvent(name, rv, eventType string) string {
+	return fmt.Sprintf(`{"metadata":{"name":%q,"namespace":"default","uid":%q,"resourceVersion":%q},"type":%q,"reason":"BackOff","involvedObject":{"kind":"Pod","name":"foo"}}`, name, name, rv, eventType)
+}
+
+func testEventsInput(t *testing.T, mgr *service.Resources, confStr string) *eventsInput {
+	t.Helper()
+
+	pConf, err := eventsInputSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	e, err := newEventsInputFromParsed(pConf, mgr)
+	require.NoError(t, err)
+	return e
+}
+
+func readEvent(t *testing.T, e *eventsInput) (string, service.AckFunc) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, ackFn, err := e.Read(ctx)
+	require.NoError(t, err)
+
+	name, _ := msg.MetaGet("kubernetes_name")
+	watchType, _ := msg.MetaGet("kubernetes_watch_event_type")
+	rv, _ := msg.MetaGet("kubernetes_resource_version")
+	return watchType + " " + name + " " + rv, ackFn
+}
+
+func TestKubernetesEventsInput(t *testing.T) {
+	srv := newFakeAPIServer(t)
+	srv.setList("/api/v1/namespaces/default/events", "10",
+		testEvent("a", "8", "Warning"),
+		testEvent("b", "9", "Normal"),
+	)
+
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+	conf := fmt.Sprintf(`
+api_url: %v
+token: footoken
+namespace: default
+field_selector: type=Warning
+checkpoint_cache: foocache
+`, srv.URL)
+
+	e := testEventsInput(t, mgr, conf)
+	require.NoError(t, e.Connect(context.Background()))
+
+	var acks []service.AckFunc
+	for _, exp := range []string{"ADDED a 8", "ADDED b 9"} {
+		event, ackFn := readEvent(t, e)
+		assert.Equal(t, exp, event)
+		acks = append(acks, ackFn)
+	}
+
+	srv.send(t, srv.watch, `{"type":"MODIFIED","object":`+testEvent("a", "11", "Warning")+`}`)
+	event, ackFn := readEvent(t, e)
+	assert.Equal(t, "MODIFIED a 11", event)
+	acks = append(acks, ackFn)
+
+	// Deleted events are ignored by default, and bookmarks only update the
+	// resource version.
+	srv.send(t, srv.watch,
+		`{"type":"DELETED","object":`+testEvent("b", "12", "Normal")+`}`,
+		`{"type":"BOOKMARK","object":{"metadata":{"resourceVersion":"13"}}}`,
+		"",
+	)
+
+	// Acknowledging the final event of the list commits the list version.
+	require.NoError(t, acks[1](context.Background(), nil))
+	require.NoError(t, acks[0](context.Background(), nil))
+
+	getCheckpoint := func() string {
+		var v []byte
+		require.NoError(t, mgr.AccessCache(context.Background(), "foocache", func(c service.Cache) {
+			v, _ = c.Get(context.Background(), "kubernetes_events_resource_version")
+		}))
+		return string(v)
+	}
+	assert.Equal(t, "10", getCheckpoint())
+	require.NoError(t, acks[2](context.Background(), nil))
+	assert.Equal(t, "11", getCheckpoint())
+
+	// The watch resumes from the most recent version after the server closes
+	// it, and an expired version results in a new list.
+	srv.setList("/api/v1/namespaces/default/events", "20",
+		testEvent("a", "11", "Warning"),
+		testEvent("c", "19", "Warning"),
+	)
+	srv.send(t, srv.watch, `{"type":"ERROR","object":{"kind":"Status","code":410,"reason":"Expired","message":"too old resource version"}}`)
+
+	event, _ = readEvent(t, e)
+	assert.Equal(t, "ADDED c 19", event)
+
+	srv.send(t, srv.watch, `{"type":"ADDED","object":`+testEvent("d", "21", "Warning")+`}`)
+	event, _ = readEvent(t, e)
+	assert.Equal(t, "ADDED d 21", event)
+
+	require.NoError(t, e.Close(context.Background()))
+	srv.waitForWatches(t, 0)
+
+	assert.Equal(t, []string{
+		"/api/v1/namespaces/default/events",
+		"/api/v1/namespaces/default/events watch 10",
+		"/api/v1/namespaces/default/events watch 13",
+		"/api/v1/namespaces/default/events",
+		"/api/v1/namespaces/default/events watch 20",
+	}, srv.requests())
+
+	srv.mut.Lock()
+	for _, q := range srv.queries {
+		assert.Equal(t, "type=Warning", q.Get("fieldSelector"))
+	}
+	assert.Equal(t, "Bearer footoken", srv.tokens[0])
+	srv.mut.Unlock()
+
+	// A restarted input resumes from the checkpoint without listing.
+	e = testEventsInput(t, mgr, conf)
+	require.NoError(t, e.Connect(context.Background()))
+
+	srv.send(t, srv.watch, `{"type":"ADDED","object":`+testEvent("e", "12", "Warning")+`}`)
+	event, _ = readEvent(t, e)
+	assert.Equal(t, "ADDED e 12", event)
+	require.NoError(t, e.Close(context.Background()))
+
+	assert.Equal(t, "/api/v1/namespaces/default/events watch 11", srv.requests()[5])
+}
+
+func TestKubernetesClientInCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "")
+
+	pConf, err := eventsInputSpec().ParseYAML(`{}`, nil)
+	require.NoError(t, err)
+
+	_, err = newEventsInputFromParsed(pConf, service.MockResources())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "api_url")
+}
@@ -0,0 +1,477 @@
+package kubernetes
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	klFieldNamespace       = "namespace"
+	klFieldLabelSelector   = "label_selector"
+	klFieldFieldSelector   = "field_selector"
+	klFieldContainers      = "containers"
+	klFieldStartFromOldest = "start_from_oldest"
+)
+
+func logsInputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.20.0").
+		Summary("Tails the logs of the containers of Kubernetes pods that match a selector.").
+		Description(`
+Pods matching the selectors are listed and watched via the API server, and the logs of each running container of those pods are tailed, with each line of the logs emitted as a message. Containers that start after the input are tailed from the beginning of their logs, and tailing of a container resumes from the most recent line consumed when its log stream is interrupted or the container restarts.
+
+The API server is authenticated with using a bearer token, which by default is the service account token of the pod that Benthos is running within. The service account requires permission to list and watch pods, and to get the `+"`pods/log`"+` subresource, within the namespace.
+
+When running Benthos as a DaemonSet the field selector `+"`spec.nodeName=<node>`"+` can be used in order to tail only the pods of the node that each instance runs on, where the node name is obtained from an environment variable exposed via the downward API.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- kubernetes_namespace
+- kubernetes_pod
+- kubernetes_container
+- kubernetes_node
+- kubernetes_labels
+- kubernetes_timestamp
+`+"```"+`
+
+The field `+"`kubernetes_labels`"+` is an object containing the labels of the pod, and `+"`kubernetes_timestamp`"+` is the time the line was written formatted as RFC 3339.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).`).
+		Fields(kubeClientFields()...).
+		Fields(
+			service.NewStringField(klFieldNamespace).
+				Description("The namespace of the pods to tail, when empty pods from all namespaces are tailed.").
+				Default("").
+				Example("default"),
+			service.NewStringField(klFieldLabelSelector).
+				Description("A [label selector](https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#label-selectors) of the pods to tail.").
+				Default("").
+				Examples("app=nginx", "tier in (frontend, backend)"),
+			service.NewStringField(klFieldFieldSelector).
+				Description("An optional [field selector](https://kubernetes.io/docs/concepts/overview/working-with-objects/field-selectors/) of the pods to tail.").
+				Default("").
+				Example("spec.nodeName=${NODE_NAME}"),
+			service.NewStringListField(klFieldContainers).
+				Description("An optional list of container names to tail, when empty all containers of each pod are tailed.").
+				Default([]any{}),
+			service.NewBoolField(klFieldStartFromOldest).
+				Description("Whether to consume the logs of containers that are already running when the input starts from the beginning, otherwise only lines written after the input starts are consumed.").
+				Default(false).
+				Advanced(),
+		).
+		Example(
+			"Node Log Shipper",
+			"In this example Benthos runs as a DaemonSet and tails the logs of all pods on the same node, parsing JSON log lines and writing them to Elasticsearch.",
+			`
+input:
+  kubernetes_logs:
+    field_selector: spec.nodeName=${NODE_NAME}
+  processors:
+    - mapping: |
+        root = content().string().parse_json().catch({ "message": content().string() })
+        root.kubernetes.namespace = meta("kubernetes_namespace")
+        root.kubernetes.pod = meta("kubernetes_pod")
+        root.kubernetes.container = meta("kubernetes_container")
+        root.timestamp = meta("kubernetes_timestamp")
+
+output:
+  elasticsearch:
+    urls: [ http://localhost:9200 ]
+    index: logs
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterInput("kubernetes_logs", logsInputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newLogsInputFromParsed(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type kubePod struct {
+	Metadata kubeObjectMeta `json:"metadata"`
+	Spec     struct {
+		NodeName string `json:"nodeName"`
+	} `json:"spec"`
+	Status struct {
+		ContainerStatuses []struct {
+			Name  string `json:"name"`
+			State struct {
+				Running *struct{} `json:"running"`
+			} `json:"state"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+func (p *kubePod) runningContainers() []string {
+	var names []string
+	for _, c := range p.Status.ContainerStatuses {
+		if c.State.Running != nil {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+type containerKey struct {
+	namespace, pod, container string
+}
+
+// containerTail is the state of the tailing of a single container.
+type containerTail struct {
+	active   bool
+	lastLine time.Time
+}
+
+type logsInput struct {
+	client          *kubeClient
+	path            string
+	query           url.Values
+	containers      map[string]struct{}
+	startFromOldest bool
+
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+	nowFn   func() time.Time
+
+	retryPeriod time.Duration
+
+	tailsMut sync.Mutex
+	pods     map[containerKey]*kubePod
+	tails    map[containerKey]*containerTail
+	tailsWG  sync.WaitGroup
+
+	connMut sync.Mutex
+	msgChan chan *service.Message
+}
+
+func newLogsInputFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*logsInput, error) {
+	l := &logsInput{
+		log:         mgr.Logger(),
+		shutSig:     shutdown.NewSignaller(),
+		nowFn:       time.Now,
+		retryPeriod: time.Second,
+		pods:        map[containerKey]*kubePod{},
+		tails:       map[containerKey]*containerTail{},
+	}
+
+	var err error
+	if l.client, err = newKubeClientFromParsed(conf, mgr); err != nil {
+		return nil, err
+	}
+
+	namespace, err := conf.FieldString(klFieldNamespace)
+	if err != nil {
+		return nil, err
+	}
+	l.path = "/api/v1/pods"
+	if namespace != "" {
+		l.path = "/api/v1/namespaces/" + url.PathEscape(namespace) + "/pods"
+	}
+	if l.query, err = selectorQuery(conf, klFieldLabelSelector, klFieldFieldSelector); err != nil {
+		return nil, err
+	}
+
+	containers, err := conf.FieldStringList(klFieldContainers)
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) > 0 {
+		l.containers = map[string]struct{}{}
+		for _, c := range containers {
+			l.containers[c] = struct{}{}
+		}
+	}
+	if l.startFromOldest, err = conf.FieldBool(klFieldStartFromOldest); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *logsInput) Connect(ctx context.Context) error {
+	l.connMut.Lock()
+	defer l.connMut.Unlock()
+	if l.msgChan != nil {
+		return nil
+	}
+
+	msgChan := make(chan *service.Message)
+	runCtx, done := l.shutSig.CloseAtLeisureCtx(context.Background())
+
+	startedAt := l.nowFn()
+	initialList := true
+	inf := newInformer(l.client, l.path, l.query, l.log, func(ctx context.Context, eventType string, obj *kubeObject, resumeVersion string) error {
+		var pod kubePod
+		if err := json.Unmarshal(obj.raw, &pod); err != nil {
+			l.log.Errorf("Failed to parse pod: %v\n", err)
+			return nil
+		}
+
+		// Lines written before the input started are skipped for
+		// containers that were already running, unless starting from the
+		// oldest lines.
+		var since time.Time
+		if initialList && !l.startFromOldest {
+			since = startedAt
+		}
+		l.updatePod(runCtx, msgChan, eventType, &pod, since)
+		return nil
+	})
+
+	go func() {
+		defer func() {
+			done()
+			l.tailsWG.Wait()
+			l.shutSig.ShutdownComplete()
+		}()
+
+		// The initial list is performed before the watch so that the
+		// containers that were already running can be distinguished.
+		for {
+			err := inf.relist(runCtx)
+			if err == nil {
+				break
+			}
+			if runCtx.Err() != nil {
+				return
+			}
+			l.log.Errorf("Failed to list pods: %v\n", err)
+			select {
+			case <-time.After(inf.retryPeriod):
+			case <-runCtx.Done():
+				return
+			}
+		}
+		initialList = false
+		_ = inf.run(runCtx)
+	}()
+
+	l.msgChan = msgChan
+	l.log.Infof("Tailing logs of Kubernetes pods from %v", l.path)
+	return nil
+}
+
+// updatePod updates the cached state of a pod and starts tailing any running
+// containers of the pod that are not already being tailed.
+func (l *logsInput) updatePod(ctx context.Context, msgChan chan<- *service.Message, eventType string, pod *kubePod, since time.Time) {
+	l.tailsMut.Lock()
+	defer l.tailsMut.Unlock()
+
+	podKey := containerKey{namespace: pod.Metadata.Namespace, pod: pod.Metadata.Name}
+	if eventType == watchDeleted {
+		delete(l.pods, podKey)
+		for k, t := range l.tails {
+			if k.namespace == podKey.namespace && k.pod == podKey.pod && !t.active {
+				delete(l.tails, k)
+			}
+		}
+		return
+	}
+	l.pods[podKey] = pod
+
+	for _, name := range pod.runningContainers() {
+		if l.containers != nil {
+			if _, exists := l.containers[name]; !exists {
+				continue
+			}
+		}
+		key := containerKey{namespace: podKey.namespace, pod: podKey.pod, container: name}
+		t, exists := l.tails[key]
+		if !exists {
+			t = &containerTail{lastLine: since}
+			l.tails[key] = t
+		}
+		if t.active {
+			continue
+		}
+		t.active = true
+
+		l.tailsWG.Add(1)
+		go func() {
+			defer l.tailsWG.Done()
+			l.tailContainer(ctx, msgChan, key, t)
+		}()
+	}
+}
+
+// isRunning returns whether a container is running according to the most
+// recent state of its pod.
+func (l *logsInput) isRunning(key containerKey) (*kubePod, bool) {
+	pod, exists := l.pods[containerKey{namespace: key.namespace, pod: key.pod}]
+	if !exists {
+		return nil, false
+	}
+	for _, name := range pod.runningContainers() {
+		if name == key.container {
+			return pod, true
+		}
+	}
+	return pod, false
+}
+
+// tailContainer follows the logs of a container for as long as it is running.
+func (l *logsInput) tailContainer(ctx context.Context, msgChan chan<- *service.Message, key containerKey, t *containerTail) {
+	for {
+		l.tailsMut.Lock()
+		pod, running := l.isRunning(key)
+		if !running || ctx.Err() != nil {
+			t.active = false
+			if pod == nil {
+				delete(l.tails, key)
+			}
+			l.tailsMut.Unlock()
+			return
+		}
+		since := t.lastLine
+		l.tailsMut.Unlock()
+
+		err := l.streamLogs(ctx, msgChan, key, pod, since, func(ts time.Time) {
+			l.tailsMut.Lock()
+			t.lastLine = ts
+			l.tailsMut.Unlock()
+		})
+		if ctx.Err() != nil {
+			continue
+		}
+		if err != nil {
+			l.log.Errorf("Failed to tail logs of container %v of pod %v/%v: %v\n", key.container, key.namespace, key.pod, err)
+		}
+
+		// The log stream ends when the container stops, in which case the
+		// pod should soon be updated, otherwise the stream is resumed.
+		select {
+		case <-time.After(l.retryPeriod):
+		case <-ctx.Done():
+		}
+	}
+}
+
+func (l *logsInput) streamLogs(ctx context.Context, msgChan chan<- *service.Message, key containerKey, pod *kubePod, since time.Time, onLine func(ts time.Time)) error {
+	query := url.Values{}
+	query.Set("container", key.container)
+	query.Set("follow", "true")
+	query.Set("timestamps", "true")
+	if !since.IsZero() {
+		query.Set("sinceTime", since.UTC().Format(time.RFC3339))
+	}
+
+	res, err := l.client.get(ctx, "/api/v1/namespaces/"+url.PathEscape(key.namespace)+"/pods/"+url.PathEscape(key.pod)+"/log", query)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	labels := make(map[string]any, len(pod.Metadata.Labels))
+	for k, v := range pod.Metadata.Labels {
+		labels[k] = v
+	}
+
+	r := bufio.NewReader(res.Body)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			line = bytes.TrimSuffix(line, []byte("\n"))
+
+			// Each line is prefixed with its timestamp followed by a space.
+			var ts time.Time
+			if i := bytes.IndexByte(line, ' '); i > 0 {
+				if ts, err = time.Parse(time.RFC3339Nano, string(line[:i])); err == nil {
+					line = line[i+1:]
+				}
+			}
+
+			// The since time has a precision of seconds, and therefore lines
+			// that have already been consumed may be sent again.
+			if ts.IsZero() || ts.After(since) {
+				msg := service.NewMessage(line)
+				msg.MetaSetMut("kubernetes_namespace", key.namespace)
+				msg.MetaSetMut("kubernetes_pod", key.pod)
+				msg.MetaSetMut("kubernetes_container", key.container)
+				msg.MetaSetMut("kubernetes_node", pod.Spec.NodeName)
+				msg.MetaSetMut("kubernetes_labels", labels)
+				if !ts.IsZero() {
+					msg.MetaSetMut("kubernetes_timestamp", ts.Format(time.RFC3339Nano))
+				}
+
+				select {
+				case msgChan <- msg:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				if !ts.IsZero() {
+					since = ts
+					onLine(ts)
+				}
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (l *logsInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	l.connMut.Lock()
+	msgChan := l.msgChan
+	l.connMut.Unlock()
+	if msgChan == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	select {
+	case msg := <-msgChan:
+		return msg, func(context.Context, error) error {
+			// Nacks are handled by AutoRetryNacks.
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (l *logsInput) Close(ctx context.Context) error {
+	go func() {
+		l.shutSig.CloseAtLeisure()
+		l.connMut.Lock()
+		if l.msgChan == nil {
+			// Indicates that we were never connected, so indicate shutdown is
+			// complete.
+			l.shutSig.ShutdownComplete()
+		}
+		l.connMut.Unlock()
+	}()
+	select {
+	case <-l.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
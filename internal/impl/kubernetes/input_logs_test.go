@@ -0,0 +1,148 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testPod(name, rv string, running ...string) string {
+	statuses := ""
+	for i, c := range running {
+		if i > 0 {
+			statuses += ","
+		}
+		statuses += fmt.Sprintf(`{"name":%q,"state":{"running":{"startedAt":"2023-01-01T00:00:00Z"}}}`, c)
+	}
+	return fmt.Sprintf(`{"metadata":{"name":%q,"namespace":"default","uid":%q,"resourceVersion":%q,"labels":{"app":"foo"}},"spec":{"nodeName":"node1"},"status":{"containerStatuses":[%v]}}`, name, name, rv, statuses)
+}
+
+// sinceTimes returns the since time of each request for the logs of a pod.
+func (s *fakeAPIServer) sinceTimes(pod string) []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	var times []string
+	for _, q := range s.queries {
+		if q.Get("follow") == "true" && q.Get("path") == "/api/v1/namespaces/default/pods/"+pod+"/log" {
+			times = append(times, q.Get("container")+" "+q.Get("sinceTime"))
+		}
+	}
+	return times
+}
+
+func readLogLine(t *testing.T, l *logsInput) string {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, _, err := l.Read(ctx)
+	require.NoError(t, err)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+
+	pod, _ := msg.MetaGet("kubernetes_pod")
+	container, _ := msg.MetaGet("kubernetes_container")
+	return pod + "/" + container + ": " + string(b)
+}
+
+func TestKubernetesLogsInput(t *testing.T) {
+	srv := newFakeAPIServer(t)
+	srv.setList("/api/v1/namespaces/default/pods", "10", testPod("a", "9", "app", "sidecar"))
+
+	pConf, err := logsInputSpec().ParseYAML(fmt.Sprintf(`
+api_url: %v
+namespace: default
+label_selector: app=foo
+containers: [ app ]
+`, srv.URL), nil)
+	require.NoError(t, err)
+
+	l, err := newLogsInputFromParsed(pConf, service.MockResources())
+	require.NoError(t, err)
+	l.retryPeriod = time.Millisecond * 10
+	l.nowFn = func() time.Time {
+		return time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	require.NoError(t, l.Connect(context.Background()))
+
+	aLogs := srv.logStream("/api/v1/namespaces/default/pods/a/log")
+	srv.send(t, aLogs, "2023-01-01T00:00:01.5Z hello world", "2023-01-01T00:00:02Z second line")
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, _, err := l.Read(ctx)
+	require.NoError(t, err)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(b))
+
+	meta := map[string]any{}
+	_ = msg.MetaWalkMut(func(k string, v any) error {
+		meta[k] = v
+		return nil
+	})
+	assert.Equal(t, map[string]any{
+		"kubernetes_namespace": "default",
+		"kubernetes_pod":       "a",
+		"kubernetes_container": "app",
+		"kubernetes_node":      "node1",
+		"kubernetes_labels":    map[string]any{"app": "foo"},
+		"kubernetes_timestamp": "2023-01-01T00:00:01.5Z",
+	}, meta)
+
+	assert.Equal(t, "a/app: second line", readLogLine(t, l))
+
+	// When the stream ends whilst the container is running it is resumed, and
+	// lines that were already consumed are skipped.
+	srv.send(t, aLogs, "", "2023-01-01T00:00:02Z second line", "2023-01-01T00:00:03Z third line")
+	assert.Equal(t, "a/app: third line", readLogLine(t, l))
+
+	// Containers that start after the input are tailed from the beginning.
+	srv.waitForWatches(t, 1)
+	srv.send(t, srv.watch, `{"type":"ADDED","object":`+testPod("b", "11", "app")+`}`)
+	srv.send(t, srv.logStream("/api/v1/namespaces/default/pods/b/log"), "2022-12-31T23:00:00Z from b")
+	assert.Equal(t, "b/app: from b", readLogLine(t, l))
+
+	// Tailing stops once a pod is deleted.
+	srv.send(t, srv.watch, `{"type":"DELETED","object":`+testPod("a", "12")+`}`)
+	require.Eventually(t, func() bool {
+		l.tailsMut.Lock()
+		defer l.tailsMut.Unlock()
+		_, exists := l.pods[containerKey{namespace: "default", pod: "a"}]
+		return !exists
+	}, time.Second*5, time.Millisecond*10)
+	srv.send(t, aLogs, "")
+	require.Eventually(t, func() bool {
+		l.tailsMut.Lock()
+		defer l.tailsMut.Unlock()
+		_, exists := l.tails[containerKey{namespace: "default", pod: "a", container: "app"}]
+		return !exists
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, l.Close(context.Background()))
+
+	assert.Equal(t, []string{
+		"app 2023-01-01T00:00:00Z",
+		"app 2023-01-01T00:00:02Z",
+	}, srv.sinceTimes("a"))
+	assert.Equal(t, []string{"app "}, srv.sinceTimes("b"))
+
+	srv.mut.Lock()
+	for _, q := range srv.queries {
+		if q.Get("follow") != "true" {
+			assert.Equal(t, "app=foo", q.Get("labelSelector"))
+		}
+	}
+	srv.mut.Unlock()
+}
@@ -2,9 +2,14 @@ package lang
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
+	mathrand "math/rand"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
@@ -63,9 +68,12 @@ func init() {
 			"`email`, `mac_address`, `domain_name`, `url`, `username`, `ipv4`, `ipv6`, `password`, `jwt`, `word`, `sentence`, `paragraph`, "+
 			"`cc_type`, `cc_number`, `currency`, `amount_with_currency`, `title_male`, `title_female`, `first_name`, `first_name_male`, "+
 			"`first_name_female`, `last_name`, `name`, `gender`, `chinese_first_name`, `chinese_last_name`, `chinese_name`, `phone_number`, "+
-			"`toll_free_phone_number`, `e164_phone_number`, `uuid_hyphenated`, `uuid_digit`. Refer to the [faker](https://github.com/go-faker/faker) docs "+
-			"for details on these functions.").
+			"`toll_free_phone_number`, `e164_phone_number`, `uuid_hyphenated`, `uuid_digit`, `street_address`, `city`, `state`, `postal_code`. Refer to the [faker](https://github.com/go-faker/faker) docs "+
+			"for details on these functions.\n\n"+
+			"The functions `address`, `person` and `credit_card` generate objects where the fields are consistent with each other, e.g. the email address of a person is derived from their name.\n\n"+
+			"When a `seed` is provided the generated value is always the same for a given function and seed, which can be used to correlate fields across messages, such as giving each user ID a consistent name.").
 		Param(bloblang.NewStringParam("function").Description("The name of the function to use to generate the value.").Default("")).
+		Param(bloblang.NewAnyParam("seed").Description("An optional seed, which can be any value, that determines the generated value.").Optional()).
 		Example("Use `time_string` to generate a time in the format `00:00:00`:",
 			`root.time = fake("time_string")`).
 		Example("Use `email` to generate a string in email address format:",
@@ -73,7 +81,12 @@ func init() {
 		Example("Use `jwt` to generate a JWT token:",
 			`root.jwt = fake("jwt")`).
 		Example("Use `uuid_hyphenated` to generate a hypenated UUID:",
-			`root.uuid = fake("uuid_hyphenated")`)
+			`root.uuid = fake("uuid_hyphenated")`).
+		Example("Use `person` to generate an object describing a person with a name, email address and username:",
+			`root.customer = fake("person")`).
+		Example("Use a `seed` in order to generate the same values for the same user ID across messages:",
+			`root.user.name = fake(function: "name", seed: this.user_id)
+root.user.address = fake(function: "address", seed: this.user_id)`)
 
 	if err := bloblang.RegisterFunctionV2(
 		"fake", fakerSpec,
@@ -83,6 +96,17 @@ func init() {
 				return nil, err
 			}
 
+			seed, err := args.Get("seed")
+			if err != nil {
+				return nil, err
+			}
+			if seed != nil {
+				seedInt := seedToInt64(seed)
+				return func() (any, error) {
+					return getSeededFakeValue(functionKey, seedInt)
+				}, nil
+			}
+
 			return func() (any, error) {
 				return GetFakeValue(functionKey)
 			}, nil
@@ -91,6 +115,28 @@ func init() {
 		panic(err)
 	}
 
+	fakeChoiceSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.FunctionCategoryFakeData).
+		Version("4.20.0").
+		Description("Returns a random element of an array, where the likelihood of each element being chosen can optionally be weighted.").
+		Param(bloblang.NewAnyParam("values").Description("An array of values to choose from.")).
+		Param(bloblang.NewAnyParam("weights").Description("An optional array of non-negative numbers matching in size to `values`, where the likelihood of each value being chosen is proportional to its weight.").Optional()).
+		Param(bloblang.NewAnyParam("seed").Description("An optional seed, which can be any value, that determines the chosen value.").Optional()).
+		Example("Choose a status where most are successful:",
+			`root.status = fake_choice(["ok", "retry", "failed"], [90, 8, 2])`).
+		Example("Use a `seed` in order to choose the same value for the same user ID across messages:",
+			`root.plan = fake_choice(values: ["free", "pro", "enterprise"], weights: [70, 25, 5], seed: this.user_id)`)
+
+	if err := bloblang.RegisterFunctionV2(
+		"fake_choice", fakeChoiceSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Function, error) {
+			return fakeChoiceCtor(args)
+		},
+	); err != nil {
+		panic(err)
+	}
+
 	snowflakeidSpec := bloblang.NewPluginSpec().
 		Category(query.FunctionCategoryGeneral).
 		Description("Generate a new snowflake ID each time it is invoked and prints a string representation. I.e.: 1559229974454472704").
@@ -122,14 +168,146 @@ func init() {
 	}
 }
 
+// The faker package generates values from a package level random source, which
+// is swapped out for a seeded source whilst generating seeded values. Unseeded
+// values can be generated concurrently with each other and therefore only hold
+// a read lock, whereas seeded values require exclusive access to the source so
+// that the values are deterministic.
+var (
+	fakerSourceMut    sync.RWMutex
+	fakerUnseededRand = faker.NewSafeSource(mathrand.NewSource(time.Now().UnixNano()))
+	fakeRand          = mathrand.New(faker.NewSafeSource(mathrand.NewSource(time.Now().UnixNano())))
+)
+
+// seedToInt64 converts a seed of any type into a seed for a random source.
+func seedToInt64(seed any) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(query.IToString(seed)))
+	return int64(h.Sum64())
+}
+
 // GetFakeValue returns fake data generated by the faker function corresponding to the input string.
 func GetFakeValue(function string) (any, error) {
+	fakerSourceMut.RLock()
+	defer fakerSourceMut.RUnlock()
+	return getFakeValue(function, nil)
+}
+
+func getSeededFakeValue(function string, seed int64) (any, error) {
+	fakerSourceMut.Lock()
+	defer fakerSourceMut.Unlock()
+
+	seeded := mathrand.New(mathrand.NewSource(seed))
+	faker.SetRandomSource(seeded)
+	faker.SetCryptoSource(seeded)
+	defer func() {
+		faker.SetRandomSource(fakerUnseededRand)
+		faker.SetCryptoSource(rand.Reader)
+	}()
+	return getFakeValue(function, seeded)
+}
+
+var fakeCreditCards = []struct {
+	cardType string
+	length   int
+	prefixes []string
+}{
+	{"VISA", 16, []string{"4"}},
+	{"MasterCard", 16, []string{"51", "52", "53", "54", "55"}},
+	{"American Express", 15, []string{"34", "37"}},
+	{"Discover", 16, []string{"6011"}},
+	{"JCB", 16, []string{"3528", "3589"}},
+	{"Diners Club", 14, []string{"36", "38", "39"}},
+}
+
+// fakeCreditCard returns a credit card with a number that has a valid Luhn
+// check digit.
+func fakeCreditCard(r *mathrand.Rand) map[string]any {
+	card := fakeCreditCards[r.Intn(len(fakeCreditCards))]
+
+	digits := []byte(card.prefixes[r.Intn(len(card.prefixes))])
+	for len(digits) < card.length-1 {
+		digits = append(digits, byte('0'+r.Intn(10)))
+	}
+
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	digits = append(digits, byte('0'+(10-sum%10)%10))
+
+	cvvLen := 3
+	if card.cardType == "American Express" {
+		cvvLen = 4
+	}
+	cvv := make([]byte, cvvLen)
+	for i := range cvv {
+		cvv[i] = byte('0' + r.Intn(10))
+	}
+
+	return map[string]any{
+		"type":   card.cardType,
+		"number": string(digits),
+		"expiry": fmt.Sprintf("%02d/%02d", r.Intn(12)+1, (time.Now().Year()+1+r.Intn(5))%100),
+		"cvv":    string(cvv),
+	}
+}
+
+func fakeAddress() map[string]any {
+	addr := faker.GetRealAddress()
+	return map[string]any{
+		"street_address": addr.Address,
+		"city":           addr.City,
+		"state":          addr.State,
+		"postal_code":    addr.PostalCode,
+		"latitude":       addr.Coordinates.Latitude,
+		"longitude":      addr.Coordinates.Longitude,
+	}
+}
+
+func fakePerson() map[string]any {
+	firstName, lastName := faker.FirstName(), faker.LastName()
+	username := strings.ToLower(firstName + "." + lastName)
+	return map[string]any{
+		"first_name": firstName,
+		"last_name":  lastName,
+		"name":       firstName + " " + lastName,
+		"username":   username,
+		"email":      username + "@" + faker.DomainName(),
+		"phone":      faker.Phonenumber(),
+	}
+}
+
+// getFakeValue generates a value for a faker function, where seeded is the
+// random source of seeded values and nil otherwise.
+func getFakeValue(function string, seeded *mathrand.Rand) (any, error) {
+	r := seeded
+	if r == nil {
+		r = fakeRand
+	}
+
 	switch strings.ToLower(function) {
 	// Location functions
 	case "latitude":
 		return faker.Latitude(), nil
 	case "longitude":
 		return faker.Longitude(), nil
+	case "address":
+		return fakeAddress(), nil
+	case "street_address":
+		return faker.GetRealAddress().Address, nil
+	case "city":
+		return faker.GetRealAddress().City, nil
+	case "state":
+		return faker.GetRealAddress().State, nil
+	case "postal_code":
+		return faker.GetRealAddress().PostalCode, nil
 
 	// Date time functions
 	case "unix_time":
@@ -185,9 +363,17 @@ func GetFakeValue(function string) (any, error) {
 
 	// Payment
 	case "cc_type":
-		return faker.CCType(), nil
+		if seeded == nil {
+			return faker.CCType(), nil
+		}
+		return fakeCreditCard(seeded)["type"], nil
 	case "cc_number":
-		return faker.CCNumber(), nil
+		if seeded == nil {
+			return faker.CCNumber(), nil
+		}
+		return fakeCreditCard(seeded)["number"], nil
+	case "credit_card":
+		return fakeCreditCard(r), nil
 	case "currency":
 		return faker.Currency(), nil
 	case "amount_with_currency":
@@ -216,6 +402,8 @@ func GetFakeValue(function string) (any, error) {
 		return faker.ChineseLastName(), nil
 	case "chinese_name":
 		return faker.ChineseName(), nil
+	case "person":
+		return fakePerson(), nil
 
 	// Phone functions
 	case "phone_number":
@@ -240,6 +428,79 @@ func GetFakeValue(function string) (any, error) {
 	return "", fmt.Errorf("invalid faker function: %s", function)
 }
 
+func fakeChoiceCtor(args *bloblang.ParsedParams) (bloblang.Function, error) {
+	valuesV, err := args.Get("values")
+	if err != nil {
+		return nil, err
+	}
+	values, ok := valuesV.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected values to be an array, got %T", valuesV)
+	}
+	if len(values) == 0 {
+		return nil, errors.New("values must not be empty")
+	}
+
+	weightsV, err := args.Get("weights")
+	if err != nil {
+		return nil, err
+	}
+
+	// The cumulative weights of each value, used to choose a value with a
+	// binary search of a random number within the total weight.
+	var cumulative []float64
+	if weightsV != nil {
+		weights, ok := weightsV.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected weights to be an array, got %T", weightsV)
+		}
+		if len(weights) != len(values) {
+			return nil, fmt.Errorf("expected %v weights, got %v", len(values), len(weights))
+		}
+		cumulative = make([]float64, len(weights))
+		var total float64
+		for i, w := range weights {
+			f, err := query.IGetNumber(w)
+			if err != nil {
+				return nil, fmt.Errorf("weight %v: %w", i, err)
+			}
+			if f < 0 {
+				return nil, fmt.Errorf("weight %v must not be negative", i)
+			}
+			total += f
+			cumulative[i] = total
+		}
+		if total <= 0 {
+			return nil, errors.New("the sum of weights must be greater than zero")
+		}
+	}
+
+	seed, err := args.Get("seed")
+	if err != nil {
+		return nil, err
+	}
+
+	return func() (any, error) {
+		var r float64
+		if seed != nil {
+			r = mathrand.New(mathrand.NewSource(seedToInt64(seed))).Float64()
+		} else {
+			r = fakeRand.Float64()
+		}
+		if cumulative == nil {
+			return values[int(r*float64(len(values)))], nil
+		}
+		target := r * cumulative[len(cumulative)-1]
+		i := sort.Search(len(cumulative), func(i int) bool {
+			return cumulative[i] > target
+		})
+		if i == len(values) {
+			i--
+		}
+		return values[i], nil
+	}, nil
+}
+
 func registerULID() error {
 	encodings := []string{"crockford", "hex"}
 	randSources := []string{"secure_random", "fast_random"}
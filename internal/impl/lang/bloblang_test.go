@@ -1,6 +1,7 @@
 package lang
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -97,3 +98,126 @@ func TestULID_BadRandom(t *testing.T) {
 	require.ErrorContains(t, err, "invalid randomness source: not-very-random")
 	require.Nil(t, ex, "did not expect an executable mapping")
 }
+
+func TestFakeSeeded(t *testing.T) {
+	for _, function := range []string{"name", "email", "address", "person", "uuid_hyphenated", "credit_card"} {
+		ex, err := bloblang.Parse(`root = [ fake(function: "` + function + `", seed: this.id), fake(function: "` + function + `", seed: this.id) ]`)
+		require.NoError(t, err)
+
+		first, err := ex.Query(map[string]any{"id": "foo"})
+		require.NoError(t, err)
+
+		second, err := ex.Query(map[string]any{"id": "foo"})
+		require.NoError(t, err)
+
+		other, err := ex.Query(map[string]any{"id": "bar"})
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second, function)
+		assert.Equal(t, first.([]any)[0], first.([]any)[1], function)
+		assert.NotEqual(t, first, other, function)
+	}
+}
+
+func TestFakeSeededConcurrent(t *testing.T) {
+	seededEx, err := bloblang.Parse(`root = fake(function: "person", seed: this.id)`)
+	require.NoError(t, err)
+
+	unseededEx, err := bloblang.Parse(`root = [ fake("name"), fake("email"), fake("sentence") ]`)
+	require.NoError(t, err)
+
+	expected, err := seededEx.Query(map[string]any{"id": "foo"})
+	require.NoError(t, err)
+
+	// Seeded values remain deterministic whilst unseeded values are generated
+	// concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				res, err := seededEx.Query(map[string]any{"id": "foo"})
+				assert.NoError(t, err)
+				assert.Equal(t, expected, res)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, err := unseededEx.Query(nil)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFakePerson(t *testing.T) {
+	ex, err := bloblang.Parse(`root = fake("person")`)
+	require.NoError(t, err)
+
+	res, err := ex.Query(nil)
+	require.NoError(t, err)
+
+	person := res.(map[string]any)
+	assert.Equal(t, person["first_name"].(string)+" "+person["last_name"].(string), person["name"])
+	assert.Contains(t, person["email"], person["username"].(string)+"@")
+}
+
+func TestFakeChoice(t *testing.T) {
+	ex, err := bloblang.Parse(`root = fake_choice(["a", "b", "c"], [0, 1, 3])`)
+	require.NoError(t, err)
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		res, err := ex.Query(nil)
+		require.NoError(t, err)
+		counts[res.(string)]++
+	}
+	assert.Zero(t, counts["a"])
+	assert.Greater(t, counts["c"], counts["b"])
+
+	ex, err = bloblang.Parse(`root = range(0, 10).map_each(fake_choice(values: ["a", "b", "c"], seed: this))`)
+	require.NoError(t, err)
+
+	first, err := ex.Query(nil)
+	require.NoError(t, err)
+	second, err := ex.Query(nil)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	for _, mapping := range []string{
+		`root = fake_choice([])`,
+		`root = fake_choice("a")`,
+		`root = fake_choice(["a", "b"], [1])`,
+		`root = fake_choice(["a", "b"], [1, -1])`,
+		`root = fake_choice(["a", "b"], [0, 0])`,
+	} {
+		_, err := bloblang.Parse(mapping)
+		assert.Error(t, err, mapping)
+	}
+}
+
+func TestFakeCreditCardLuhn(t *testing.T) {
+	ex, err := bloblang.Parse(`root = fake(function: "cc_number", seed: this)`)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		res, err := ex.Query(i)
+		require.NoError(t, err)
+
+		number := res.(string)
+		sum := 0
+		for j := len(number) - 1; j >= 0; j-- {
+			d := int(number[j] - '0')
+			if (len(number)-1-j)%2 == 1 {
+				if d *= 2; d > 9 {
+					d -= 9
+				}
+			}
+			sum += d
+		}
+		assert.Zero(t, sum%10, number)
+	}
+}
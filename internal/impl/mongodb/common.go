@@ -230,7 +230,7 @@ const (
 func writeConcernDocs() docs.FieldSpec {
 	return docs.FieldObject(commonFieldWriteConcern, "The write concern settings for the mongo connection.").
 		WithChildren(
-			docs.FieldString(commonFieldWriteConcernW, "W requests acknowledgement that write operations propagate to the specified number of mongodb instances.").HasDefault(""),
+			docs.FieldString(commonFieldWriteConcernW, "W requests acknowledgement that write operations propagate to the specified number of mongodb instances, to the majority of instances with `majority`, or to the instances of a tag set.").HasDefault(""),
 			docs.FieldBool(commonFieldWriteConcernJ, "J requests acknowledgement from MongoDB that write operations are written to the journal.").HasDefault(false),
 			docs.FieldString(commonFieldWriteConcernWTimeout, "The write concern timeout.").HasDefault(""),
 		)
@@ -256,15 +256,23 @@ func writeConcernCollectionOptionFromParsed(pConf *service.ParsedConfig) (opt *o
 		}
 	}
 
+	// Without any fields set we defer to the default write concern of the
+	// client.
+	if w == "" && !j && wTimeout == 0 {
+		return options.Collection(), nil
+	}
+
 	writeConcern := writeconcern.New(
 		writeconcern.J(j),
 		writeconcern.WTimeout(wTimeout),
 	)
 
-	if wInt, err := strconv.Atoi(w); err != nil {
-		writeconcern.WTagSet(w)
-	} else {
+	if w == "majority" {
+		writeconcern.WMajority()(writeConcern)
+	} else if wInt, err := strconv.Atoi(w); err == nil {
 		writeconcern.W(wInt)(writeConcern)
+	} else if w != "" {
+		writeconcern.WTagSet(w)(writeConcern)
 	}
 
 	// This does some validation so we don't have to
@@ -319,6 +327,14 @@ type writeMaps struct {
 }
 
 func writeMapsFromParsed(conf *service.ParsedConfig, operation Operation) (maps writeMaps, err error) {
+	if maps, err = writeMapsFromParsedUnchecked(conf); err != nil {
+		return
+	}
+	err = maps.validate(operation)
+	return
+}
+
+func writeMapsFromParsedUnchecked(conf *service.ParsedConfig) (maps writeMaps, err error) {
 	if probeStr, _ := conf.FieldString(commonFieldFilterMap); probeStr != "" {
 		if maps.filterMap, err = conf.FieldBloblang(commonFieldFilterMap); err != nil {
 			return
@@ -334,41 +350,44 @@ func writeMapsFromParsed(conf *service.ParsedConfig, operation Operation) (maps
 			return
 		}
 	}
-	if maps.upsert, err = conf.FieldBool(commonFieldUpsert); err != nil {
-		return
+	maps.upsert, err = conf.FieldBool(commonFieldUpsert)
+	return
+}
+
+// checkRequired returns an error if a map required by the operation is
+// missing.
+func (w writeMaps) checkRequired(operation Operation) error {
+	if operation.isFilterAllowed() && w.filterMap == nil {
+		return errors.New("mongodb filter_map must be specified")
+	}
+	if operation.isDocumentAllowed() && w.documentMap == nil {
+		return errors.New("mongodb document_map must be specified")
 	}
+	return nil
+}
 
+func (w writeMaps) validate(operation Operation) error {
 	if operation.isFilterAllowed() {
-		if maps.filterMap == nil {
-			err = errors.New("mongodb filter_map must be specified")
-			return
+		if w.filterMap == nil {
+			return errors.New("mongodb filter_map must be specified")
 		}
-	} else if maps.filterMap != nil {
-		err = fmt.Errorf("mongodb filter_map not allowed for '%s' operation", operation)
-		return
+	} else if w.filterMap != nil {
+		return fmt.Errorf("mongodb filter_map not allowed for '%s' operation", operation)
 	}
-
 	if operation.isDocumentAllowed() {
-		if maps.documentMap == nil {
-			err = errors.New("mongodb document_map must be specified")
-			return
+		if w.documentMap == nil {
+			return errors.New("mongodb document_map must be specified")
 		}
-	} else if maps.documentMap != nil {
-		err = fmt.Errorf("mongodb document_map not allowed for '%s' operation", operation)
-		return
+	} else if w.documentMap != nil {
+		return fmt.Errorf("mongodb document_map not allowed for '%s' operation", operation)
 	}
-
-	if !operation.isHintAllowed() && maps.hintMap != nil {
-		err = fmt.Errorf("mongodb hint_map not allowed for '%s' operation", operation)
-		return
+	if !operation.isHintAllowed() && w.hintMap != nil {
+		return fmt.Errorf("mongodb hint_map not allowed for '%s' operation", operation)
 	}
-
-	if !operation.isUpsertAllowed() && maps.upsert {
-		err = fmt.Errorf("mongodb upsert not allowed for '%s' operation", operation)
-		return
+	if !operation.isUpsertAllowed() && w.upsert {
+		return fmt.Errorf("mongodb upsert not allowed for '%s' operation", operation)
 	}
-
-	return
+	return nil
 }
 
 func (w writeMaps) extractFromMessage(operation Operation, i int, batch service.MessageBatch) (
@@ -416,7 +435,7 @@ func (w writeMaps) extractFromMessage(operation Operation, i int, batch service.
 		}
 	}
 
-	if w.hintMap != nil {
+	if w.hintMap != nil && operation.isHintAllowed() {
 		hintVal, err = batch.BloblangQuery(i, w.hintMap)
 		if err != nil {
 			err = fmt.Errorf("failed to execute hint_map: %v", err)
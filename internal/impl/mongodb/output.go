@@ -15,9 +15,11 @@ import (
 )
 
 const (
-	moFieldCollection = "collection"
-	moFieldBatching   = "batching"
-	moFieldRetries    = "retries"
+	moFieldCollection           = "collection"
+	moFieldOperationMetadataKey = "operation_metadata_key"
+	moFieldOrdered              = "ordered"
+	moFieldBatching             = "batching"
+	moFieldRetries              = "retries"
 )
 
 func outputSpec() *service.ConfigSpec {
@@ -25,19 +27,55 @@ func outputSpec() *service.ConfigSpec {
 		Version("3.43.0").
 		Categories("Services").
 		Summary("Inserts items into a MongoDB collection.").
-		Description(output.Description(true, true, "")).
+		Description(output.Description(true, true, `
+Messages of a batch are written with a single bulk write per collection.
+
+### Operations
+
+The operation performed for each message can be selected from the metadata key named by the field `+"`operation_metadata_key`"+`, falling back to the field `+"`operation`"+` when the message does not have the metadata key. This allows a single output to apply a stream of changes containing a mix of inserts, updates, replacements and deletions, in which case the `+"`document_map` and `filter_map`"+` fields must cover all the operations and are only executed for the operations that use them.
+
+### Ordering
+
+By default the writes of a bulk write are executed in order and the first write to fail prevents the remaining writes from being executed. When `+"`ordered`"+` is set to `+"`false`"+` all writes are attempted regardless of failures, which is often faster. In both cases only the messages of failed or unattempted writes are reattempted.`)).
 		Fields(clientFields()...).
 		Fields(
 			service.NewStringField(moFieldCollection).
 				Description("The name of the target collection."),
 			service.NewInternalField(outputOperationDocs(OperationUpdateOne)),
+			service.NewStringField(moFieldOperationMetadataKey).
+				Description("An optional metadata key from which the operation of each message is read, which must be one of `insert-one`, `delete-one`, `delete-many`, `replace-one` or `update-one`. Messages without the metadata key use the `operation` field.").
+				Example("operation").
+				Default("").
+				Advanced(),
 			service.NewInternalField(writeConcernDocs()),
 		).
 		Fields(writeMapsFields()...).
 		Fields(
+			service.NewBoolField(moFieldOrdered).
+				Description("Whether the writes of a bulk write are executed in order, stopping at the first write that fails.").
+				Default(true).
+				Advanced(),
 			service.NewOutputMaxInFlightField(),
 			service.NewBatchPolicyField(moFieldBatching),
-		)
+		).
+		Example("Change Data Capture", "Apply a stream of changes to a collection, where the operation of each change is set as metadata by an earlier stage of the pipeline and documents are upserted by their ID.", `
+output:
+  mongodb:
+    url: mongodb://localhost:27017
+    database: shop
+    collection: orders
+    operation: update-one
+    operation_metadata_key: operation
+    upsert: true
+    ordered: false
+    filter_map: 'root._id = this.id'
+    document_map: 'root."$set" = this.without("id")'
+    write_concern:
+      w: majority
+    batching:
+      count: 100
+      period: 1s
+`)
 	for _, f := range pure.CommonRetryBackOffFields(3, "1s", "5s", "30s") {
 		spec = spec.Field(f.Deprecated())
 	}
@@ -73,7 +111,9 @@ type outputWriter struct {
 	database                     *mongo.Database
 	collection                   *service.InterpolatedString
 	writeConcernCollectionOption *options.CollectionOptions
+	bulkWriteOptions             *options.BulkWriteOptions
 	operation                    Operation
+	operationMetadataKey         string
 	writeMaps                    writeMaps
 
 	mu sync.Mutex
@@ -95,9 +135,24 @@ func newOutputWriter(conf *service.ParsedConfig, res *service.Resources) (db *ou
 	if db.operation, err = operationFromParsed(conf); err != nil {
 		return
 	}
-	if db.writeMaps, err = writeMapsFromParsed(conf, db.operation); err != nil {
+	if db.operationMetadataKey, err = conf.FieldString(moFieldOperationMetadataKey); err != nil {
+		return
+	}
+	if db.operationMetadataKey == "" {
+		db.writeMaps, err = writeMapsFromParsed(conf, db.operation)
+	} else {
+		// Operations are resolved per message and so the maps are only
+		// checked once we know which are required.
+		db.writeMaps, err = writeMapsFromParsedUnchecked(conf)
+	}
+	if err != nil {
+		return
+	}
+	var ordered bool
+	if ordered, err = conf.FieldBool(moFieldOrdered); err != nil {
 		return
 	}
+	db.bulkWriteOptions = options.BulkWrite().SetOrdered(ordered)
 	return db, nil
 }
 
@@ -127,8 +182,9 @@ func (m *outputWriter) WriteBatch(ctx context.Context, batch service.MessageBatc
 	}
 
 	writeModelsMap := map[string][]mongo.WriteModel{}
+	sourceIndexesMap := map[string][]int{}
 
-	err := batch.WalkWithBatchedErrors(func(i int, _ *service.Message) error {
+	err := batch.WalkWithBatchedErrors(func(i int, msg *service.Message) error {
 		var err error
 
 		collectionStr, err := batch.TryInterpolatedString(i, collection)
@@ -136,45 +192,19 @@ func (m *outputWriter) WriteBatch(ctx context.Context, batch service.MessageBatc
 			return fmt.Errorf("collection interpolation error: %w", err)
 		}
 
-		docJSON, filterJSON, hintJSON, err := m.writeMaps.extractFromMessage(m.operation, i, batch)
+		operation, err := m.messageOperation(msg)
 		if err != nil {
 			return err
 		}
 
-		var writeModel mongo.WriteModel
-		switch m.operation {
-		case OperationInsertOne:
-			writeModel = &mongo.InsertOneModel{
-				Document: docJSON,
-			}
-		case OperationDeleteOne:
-			writeModel = &mongo.DeleteOneModel{
-				Filter: filterJSON,
-				Hint:   hintJSON,
-			}
-		case OperationDeleteMany:
-			writeModel = &mongo.DeleteManyModel{
-				Filter: filterJSON,
-				Hint:   hintJSON,
-			}
-		case OperationReplaceOne:
-			writeModel = &mongo.ReplaceOneModel{
-				Upsert:      &m.writeMaps.upsert,
-				Filter:      filterJSON,
-				Replacement: docJSON,
-				Hint:        hintJSON,
-			}
-		case OperationUpdateOne:
-			writeModel = &mongo.UpdateOneModel{
-				Upsert: &m.writeMaps.upsert,
-				Filter: filterJSON,
-				Update: docJSON,
-				Hint:   hintJSON,
-			}
+		writeModel, err := m.writeModel(operation, i, batch)
+		if err != nil {
+			return err
 		}
 
 		if writeModel != nil {
 			writeModelsMap[collectionStr] = append(writeModelsMap[collectionStr], writeModel)
+			sourceIndexesMap[collectionStr] = append(sourceIndexesMap[collectionStr], i)
 		}
 		return nil
 	})
@@ -188,11 +218,11 @@ func (m *outputWriter) WriteBatch(ctx context.Context, batch service.MessageBatc
 	}
 
 	// Dispatch any documents which WalkWithBatchedErrors managed to process successfully
-	if len(writeModelsMap) > 0 {
-		for collectionStr, writeModels := range writeModelsMap {
-			// We should have at least one write model in the slice
-			collection := m.database.Collection(collectionStr, m.writeConcernCollectionOption)
-			if _, err := collection.BulkWrite(ctx, writeModels); err != nil {
+	for collectionStr, writeModels := range writeModelsMap {
+		// We should have at least one write model in the slice
+		collection := m.database.Collection(collectionStr, m.writeConcernCollectionOption)
+		if _, err := collection.BulkWrite(ctx, writeModels, m.bulkWriteOptions); err != nil {
+			if batchErr, err = m.bulkWriteBatchError(batch, batchErr, sourceIndexesMap[collectionStr], err); err != nil {
 				return err
 			}
 		}
@@ -205,6 +235,97 @@ func (m *outputWriter) WriteBatch(ctx context.Context, batch service.MessageBatc
 	return nil
 }
 
+func (m *outputWriter) messageOperation(msg *service.Message) (Operation, error) {
+	if m.operationMetadataKey == "" {
+		return m.operation, nil
+	}
+
+	operation := m.operation
+	if opStr, exists := msg.MetaGet(m.operationMetadataKey); exists {
+		if operation = NewOperation(opStr); operation == OperationInvalid || operation == OperationFindOne {
+			return OperationInvalid, fmt.Errorf("mongodb operation '%s' unknown: must be insert-one, delete-one, delete-many, replace-one or update-one", opStr)
+		}
+	}
+	if err := m.writeMaps.checkRequired(operation); err != nil {
+		return OperationInvalid, fmt.Errorf("%w for '%s' operation", err, operation)
+	}
+	return operation, nil
+}
+
+func (m *outputWriter) writeModel(operation Operation, i int, batch service.MessageBatch) (mongo.WriteModel, error) {
+	docJSON, filterJSON, hintJSON, err := m.writeMaps.extractFromMessage(operation, i, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	var writeModel mongo.WriteModel
+	switch operation {
+	case OperationInsertOne:
+		writeModel = &mongo.InsertOneModel{
+			Document: docJSON,
+		}
+	case OperationDeleteOne:
+		writeModel = &mongo.DeleteOneModel{
+			Filter: filterJSON,
+			Hint:   hintJSON,
+		}
+	case OperationDeleteMany:
+		writeModel = &mongo.DeleteManyModel{
+			Filter: filterJSON,
+			Hint:   hintJSON,
+		}
+	case OperationReplaceOne:
+		writeModel = &mongo.ReplaceOneModel{
+			Upsert:      &m.writeMaps.upsert,
+			Filter:      filterJSON,
+			Replacement: docJSON,
+			Hint:        hintJSON,
+		}
+	case OperationUpdateOne:
+		writeModel = &mongo.UpdateOneModel{
+			Upsert: &m.writeMaps.upsert,
+			Filter: filterJSON,
+			Update: docJSON,
+			Hint:   hintJSON,
+		}
+	}
+	return writeModel, nil
+}
+
+// bulkWriteBatchError attempts to attribute the errors of a bulk write to the
+// messages that the writes originated from. When the error cannot be
+// attributed to individual writes it is returned as is.
+func (m *outputWriter) bulkWriteBatchError(batch service.MessageBatch, batchErr *service.BatchError, sourceIndexes []int, err error) (*service.BatchError, error) {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return batchErr, err
+	}
+
+	if batchErr == nil {
+		batchErr = service.NewBatchError(batch, err)
+	}
+
+	lastFailed := 0
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Index < 0 || writeErr.Index >= len(sourceIndexes) {
+			return batchErr, err
+		}
+		batchErr.Failed(sourceIndexes[writeErr.Index], writeErr)
+		if writeErr.Index > lastFailed {
+			lastFailed = writeErr.Index
+		}
+	}
+
+	// Ordered bulk writes stop at the first failed write and therefore the
+	// remaining writes were never attempted.
+	if ordered := m.bulkWriteOptions.Ordered; ordered == nil || *ordered {
+		for _, i := range sourceIndexes[lastFailed+1:] {
+			batchErr.Failed(i, errors.New("write not attempted due to an earlier failed write"))
+		}
+	}
+	return batchErr, nil
+}
+
 func (m *outputWriter) Close(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -0,0 +1,165 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newOutputWriterFromYAML(t testing.TB, confStr string) *outputWriter {
+	t.Helper()
+
+	conf, err := outputSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	w, err := newOutputWriter(conf, service.MockResources())
+	require.NoError(t, err)
+	return w
+}
+
+func TestOutputOperationFromMetadata(t *testing.T) {
+	w := newOutputWriterFromYAML(t, `
+url: mongodb://localhost:27017
+database: foo
+collection: bar
+operation: insert-one
+operation_metadata_key: op
+upsert: true
+document_map: 'root.content = this.content'
+filter_map: 'root._id = this.id'
+`)
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"a","content":"hello"}`)),
+		service.NewMessage([]byte(`{"id":"b","content":"world"}`)),
+		service.NewMessage([]byte(`{"id":"c"}`)),
+		service.NewMessage([]byte(`{"id":"d"}`)),
+	}
+	batch[1].MetaSetMut("op", "update-one")
+	batch[2].MetaSetMut("op", "delete-one")
+	batch[3].MetaSetMut("op", "find-one")
+
+	var models []mongo.WriteModel
+	for i, msg := range batch[:3] {
+		op, err := w.messageOperation(msg)
+		require.NoError(t, err)
+
+		model, err := w.writeModel(op, i, batch)
+		require.NoError(t, err)
+		models = append(models, model)
+	}
+
+	upsert := true
+	assert.Equal(t, []mongo.WriteModel{
+		&mongo.InsertOneModel{Document: map[string]any{"content": "hello"}},
+		&mongo.UpdateOneModel{
+			Upsert: &upsert,
+			Filter: map[string]any{"_id": "b"},
+			Update: map[string]any{"content": "world"},
+		},
+		&mongo.DeleteOneModel{Filter: map[string]any{"_id": "c"}},
+	}, models)
+
+	_, err := w.messageOperation(batch[3])
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mongodb operation 'find-one' unknown")
+}
+
+func TestOutputOperationFromMetadataMissingMap(t *testing.T) {
+	w := newOutputWriterFromYAML(t, `
+url: mongodb://localhost:27017
+database: foo
+collection: bar
+operation: insert-one
+operation_metadata_key: op
+document_map: 'root = this'
+`)
+
+	msg := service.NewMessage([]byte(`{}`))
+	op, err := w.messageOperation(msg)
+	require.NoError(t, err)
+	assert.Equal(t, OperationInsertOne, op)
+
+	msg.MetaSetMut("op", "delete-one")
+	_, err = w.messageOperation(msg)
+	require.Error(t, err)
+	assert.Equal(t, "mongodb filter_map must be specified for 'delete-one' operation", err.Error())
+}
+
+func TestOutputBulkWriteBatchError(t *testing.T) {
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`a`)),
+		service.NewMessage([]byte(`b`)),
+		service.NewMessage([]byte(`c`)),
+		service.NewMessage([]byte(`d`)),
+		service.NewMessage([]byte(`e`)),
+	}
+
+	bulkErr := mongo.BulkWriteException{
+		WriteErrors: []mongo.BulkWriteError{
+			{WriteError: mongo.WriteError{Index: 1, Code: 11000, Message: "duplicate key"}},
+		},
+	}
+
+	for _, test := range []struct {
+		name    string
+		ordered bool
+		failed  []int
+	}{
+		{name: "ordered", ordered: true, failed: []int{2, 4}},
+		{name: "unordered", ordered: false, failed: []int{2}},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			orderedStr := "false"
+			if test.ordered {
+				orderedStr = "true"
+			}
+			w := newOutputWriterFromYAML(t, `
+url: mongodb://localhost:27017
+database: foo
+collection: bar
+operation: insert-one
+ordered: `+orderedStr+`
+document_map: 'root = this'
+`)
+
+			// Messages 0 and 3 went to a different collection.
+			batchErr, err := w.bulkWriteBatchError(batch, nil, []int{1, 2, 4}, bulkErr)
+			require.NoError(t, err)
+			require.NotNil(t, batchErr)
+
+			var failed []int
+			batchErr.WalkMessages(func(i int, m *service.Message, err error) bool {
+				if err != nil {
+					failed = append(failed, i)
+				}
+				return true
+			})
+			assert.Equal(t, test.failed, failed)
+		})
+	}
+
+	w := newOutputWriterFromYAML(t, `
+url: mongodb://localhost:27017
+database: foo
+collection: bar
+operation: insert-one
+document_map: 'root = this'
+`)
+
+	otherErr := errors.New("nope")
+	_, err := w.bulkWriteBatchError(batch, nil, []int{0}, otherErr)
+	assert.Equal(t, otherErr, err)
+
+	_, err = w.bulkWriteBatchError(batch, nil, []int{0}, mongo.BulkWriteException{
+		WriteConcernError: &mongo.WriteConcernError{Message: "timed out"},
+		WriteErrors:       bulkErr.WriteErrors,
+	})
+	assert.Error(t, err)
+}
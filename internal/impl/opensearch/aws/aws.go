@@ -0,0 +1,52 @@
+package aws
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	baws "github.com/benthosdev/benthos/v4/internal/impl/aws"
+	"github.com/benthosdev/benthos/v4/internal/impl/opensearch"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func init() {
+	opensearch.AWSSignerFn = func(conf *service.ParsedConfig) (opensearch.RequestSigner, error) {
+		if enabled, _ := conf.FieldBool(opensearch.OSOFieldAWSEnabled); !enabled {
+			return nil, nil
+		}
+
+		serviceName, err := conf.FieldString(opensearch.OSOFieldAWSService)
+		if err != nil {
+			return nil, err
+		}
+
+		tsess, err := baws.GetSession(conf)
+		if err != nil {
+			return nil, err
+		}
+
+		var region string
+		if tsess.Config.Region != nil {
+			region = *tsess.Config.Region
+		} else {
+			return nil, errors.New("unable to detect target AWS region, if you encounter this error please report it via: https://github.com/benthosdev/benthos/issues/new")
+		}
+
+		signer := v4.NewSigner(tsess.Config.Credentials)
+		return func(req *http.Request, body []byte) error {
+			// OpenSearch Serverless requires the payload hash to be provided
+			// as a header, which the signer then includes as-is.
+			payloadHash := sha256.Sum256(body)
+			req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+			_, err := signer.Sign(req, bytes.NewReader(body), serviceName, region, time.Now())
+			return err
+		}, nil
+	}
+}
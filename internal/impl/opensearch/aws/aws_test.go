@@ -0,0 +1,51 @@
+package aws_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/opensearch"
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/opensearch/aws"
+)
+
+func TestAWSSigner(t *testing.T) {
+	conf, err := service.NewConfigSpec().Field(opensearch.AWSField()).ParseYAML(`
+aws:
+  enabled: true
+  service: aoss
+  region: eu-west-1
+  credentials:
+    id: xxxxx
+    secret: xxxxx
+`, nil)
+	require.NoError(t, err)
+
+	signer, err := opensearch.AWSSignerFn(conf.Namespace("aws"))
+	require.NoError(t, err)
+	require.NotNil(t, signer)
+
+	req, err := http.NewRequest(http.MethodPost, "https://localhost:9200/_bulk", nil)
+	require.NoError(t, err)
+	require.NoError(t, signer(req, []byte(`{}`)))
+
+	assert.Equal(t, "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", req.Header.Get("X-Amz-Content-Sha256"))
+	assert.Contains(t, req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=xxxxx/")
+	assert.Contains(t, req.Header.Get("Authorization"), "/eu-west-1/aoss/aws4_request")
+}
+
+func TestAWSSignerDisabled(t *testing.T) {
+	conf, err := service.NewConfigSpec().Field(opensearch.AWSField()).ParseYAML(`
+aws:
+  enabled: false
+`, nil)
+	require.NoError(t, err)
+
+	signer, err := opensearch.AWSSignerFn(conf.Namespace("aws"))
+	require.NoError(t, err)
+	assert.Nil(t, signer)
+}
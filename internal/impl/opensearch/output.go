@@ -0,0 +1,647 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/httpclient"
+	"github.com/benthosdev/benthos/v4/internal/impl/aws/config"
+	"github.com/benthosdev/benthos/v4/internal/impl/pure"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	osoFieldURLs              = "urls"
+	osoFieldIndex             = "index"
+	osoFieldAction            = "action"
+	osoFieldID                = "id"
+	osoFieldPipeline          = "pipeline"
+	osoFieldRouting           = "routing"
+	osoFieldDataStream        = "data_stream"
+	osoFieldTemplate          = "index_template"
+	osoFieldTemplateName      = "name"
+	osoFieldTemplateBody      = "body"
+	osoFieldTemplateOverwrite = "overwrite"
+	osoFieldTimeout           = "timeout"
+	osoFieldTLS               = "tls"
+	osoFieldAuth              = "basic_auth"
+	osoFieldAuthEnabled       = "enabled"
+	osoFieldAuthUsername      = "username"
+	osoFieldAuthPassword      = "password"
+	osoFieldAWS               = "aws"
+	OSOFieldAWSEnabled        = "enabled"
+	OSOFieldAWSService        = "service"
+	osoFieldBatching          = "batching"
+)
+
+type osoConfig struct {
+	urls []string
+
+	httpClient  *http.Client
+	signer      RequestSigner
+	backoffCtor func() backoff.BackOff
+
+	authEnabled bool
+	username    string
+	password    string
+
+	dataStream bool
+
+	templateName      string
+	templateBody      []byte
+	templateOverwrite bool
+
+	actionStr   *service.InterpolatedString
+	idStr       *service.InterpolatedString
+	indexStr    *service.InterpolatedString
+	pipelineStr *service.InterpolatedString
+	routingStr  *service.InterpolatedString
+}
+
+func osoConfigFromParsed(pConf *service.ParsedConfig) (conf osoConfig, err error) {
+	var tmpURLs []string
+	if tmpURLs, err = pConf.FieldStringList(osoFieldURLs); err != nil {
+		return
+	}
+	for _, u := range tmpURLs {
+		for _, splitURL := range strings.Split(u, ",") {
+			if len(splitURL) > 0 {
+				conf.urls = append(conf.urls, strings.TrimSuffix(splitURL, "/"))
+			}
+		}
+	}
+	if len(conf.urls) == 0 {
+		err = errors.New("at least one url must be specified")
+		return
+	}
+
+	{
+		authConf := pConf.Namespace(osoFieldAuth)
+		if conf.authEnabled, _ = authConf.FieldBool(osoFieldAuthEnabled); conf.authEnabled {
+			if conf.username, err = authConf.FieldString(osoFieldAuthUsername); err != nil {
+				return
+			}
+			if conf.password, err = authConf.FieldString(osoFieldAuthPassword); err != nil {
+				return
+			}
+		}
+	}
+
+	var timeout time.Duration
+	if timeout, err = pConf.FieldDuration(osoFieldTimeout); err != nil {
+		return
+	}
+	conf.httpClient = &http.Client{Timeout: timeout}
+
+	var tlsConf *tls.Config
+	var tlsEnabled bool
+	if tlsConf, tlsEnabled, err = pConf.FieldTLSToggled(osoFieldTLS); err != nil {
+		return
+	} else if tlsEnabled {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConf
+		conf.httpClient.Transport = transport
+	}
+
+	if conf.signer, err = AWSSignerFn(pConf.Namespace(osoFieldAWS)); err != nil {
+		return
+	}
+
+	if conf.backoffCtor, err = pure.CommonRetryBackOffCtorFromParsed(pConf); err != nil {
+		return
+	}
+
+	if conf.dataStream, err = pConf.FieldBool(osoFieldDataStream); err != nil {
+		return
+	}
+
+	{
+		tConf := pConf.Namespace(osoFieldTemplate)
+		if conf.templateName, err = tConf.FieldString(osoFieldTemplateName); err != nil {
+			return
+		}
+		if conf.templateOverwrite, err = tConf.FieldBool(osoFieldTemplateOverwrite); err != nil {
+			return
+		}
+		var bodyStr string
+		if bodyStr, err = tConf.FieldString(osoFieldTemplateBody); err != nil {
+			return
+		}
+		if conf.templateName != "" {
+			body := map[string]any{}
+			if bodyStr != "" {
+				if err = json.Unmarshal([]byte(bodyStr), &body); err != nil {
+					err = fmt.Errorf("failed to parse index template body: %w", err)
+					return
+				}
+			}
+			if _, exists := body["data_stream"]; conf.dataStream && !exists {
+				body["data_stream"] = map[string]any{}
+			}
+			if conf.templateBody, err = json.Marshal(body); err != nil {
+				return
+			}
+		}
+	}
+
+	if conf.actionStr, err = pConf.FieldInterpolatedString(osoFieldAction); err != nil {
+		return
+	}
+	if conf.idStr, err = pConf.FieldInterpolatedString(osoFieldID); err != nil {
+		return
+	}
+	if conf.indexStr, err = pConf.FieldInterpolatedString(osoFieldIndex); err != nil {
+		return
+	}
+	if conf.pipelineStr, err = pConf.FieldInterpolatedString(osoFieldPipeline); err != nil {
+		return
+	}
+	if conf.routingStr, err = pConf.FieldInterpolatedString(osoFieldRouting); err != nil {
+		return
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// RequestSigner signs a request to OpenSearch before it is sent, the body of
+// the request is provided separately as it has already been serialised.
+type RequestSigner func(req *http.Request, body []byte) error
+
+func notImportedAWSSignerFn(conf *service.ParsedConfig) (RequestSigner, error) {
+	if enabled, _ := conf.FieldBool(OSOFieldAWSEnabled); !enabled {
+		return nil, nil
+	}
+	return nil, errors.New("unable to configure AWS authentication as this binary does not import components/aws")
+}
+
+// AWSSignerFn is populated with the child `aws` package when imported.
+var AWSSignerFn = notImportedAWSSignerFn
+
+// AWSField represents the aws block within an opensearch field. This is
+// exported in order to make unit testing easier within the aws subpackage.
+func AWSField() *service.ConfigField {
+	return service.NewObjectField(osoFieldAWS,
+		append([]*service.ConfigField{
+			service.NewBoolField(OSOFieldAWSEnabled).
+				Description("Whether to sign requests with AWS Signature Version 4.").
+				Default(false),
+			service.NewStringField(OSOFieldAWSService).
+				Description("The AWS service name to sign requests for, which is `es` for Amazon OpenSearch Service domains and `aoss` for Amazon OpenSearch Serverless collections.").
+				Default("es"),
+		}, config.SessionFields()...)...).
+		Description("Enables and customises connectivity to Amazon OpenSearch Service.").
+		Advanced()
+}
+
+//------------------------------------------------------------------------------
+
+// OutputSpec returns the config spec for an opensearch output writer.
+func OutputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Summary(`Publishes messages into an OpenSearch index or data stream using the bulk API.`).
+		Description(output.Description(true, true, `
+The `+"`index`, `id`, `action`, `pipeline` and `routing`"+` fields can be dynamically set using function interpolations described [here](/docs/configuration/interpolation#bloblang-queries). When sending batched messages these interpolations are performed per message part.
+
+### Retries
+
+Documents that are rejected with a status of 429 (too many requests) or 5XX are retried with the configured backoff, and entire bulk requests are retried in the same way when the cluster responds with a 429 or 503 status. Documents that are rejected for any other reason, such as a mapping conflict, are failed individually so that the remaining documents of a batch are not resent.
+
+### Data Streams
+
+When `+"`data_stream`"+` is set to `+"`true`"+` all documents are written with the `+"`create`"+` action, which is the only action supported by data streams, and the `+"`index`"+` field names the data stream. Documents written to a data stream must contain a `+"`@timestamp`"+` field.
+
+Data streams and rollover aliases allow Index State Management (ISM) policies to roll over and expire indexes without any changes to this output.
+
+### Index Templates
+
+When `+"`index_template.name`"+` is set the template is created when the output connects, unless a template of the same name already exists and `+"`index_template.overwrite`"+` is `+"`false`"+`. When `+"`data_stream`"+` is enabled a `+"`data_stream`"+` object is added to the template body if it is not already present.
+
+### AWS
+
+Requests can be signed with AWS Signature Version 4 for Amazon OpenSearch Service and Amazon OpenSearch Serverless using the `+"`aws`"+` fields.`)).
+		Fields(
+			service.NewStringListField(osoFieldURLs).
+				Description("A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.").
+				Example([]string{"http://localhost:9200"}),
+			service.NewInterpolatedStringField(osoFieldIndex).
+				Description("The index or data stream to place messages."),
+			service.NewInterpolatedStringField(osoFieldAction).
+				Description("The action to take on the document. This field must resolve to one of the following action types: `create`, `index`, `update`, `upsert` or `delete`. This field is ignored when `data_stream` is enabled.").
+				Default("index").
+				Advanced(),
+			service.NewInterpolatedStringField(osoFieldID).
+				Description("The ID for indexed messages. When empty the ID is generated by OpenSearch, which is recommended for append-only workloads such as data streams. The `update`, `upsert` and `delete` actions require an ID.").
+				Example(`${!counter()}-${!timestamp_unix()}`).
+				Default(""),
+			service.NewInterpolatedStringField(osoFieldPipeline).
+				Description("An optional pipeline id to preprocess incoming documents.").
+				Advanced().
+				Default(""),
+			service.NewInterpolatedStringField(osoFieldRouting).
+				Description("The routing key to use for the document.").
+				Advanced().
+				Default(""),
+			service.NewBoolField(osoFieldDataStream).
+				Description("Whether the `index` field names a data stream, in which case all documents are written with the `create` action.").
+				Default(false),
+			service.NewObjectField(osoFieldTemplate,
+				service.NewStringField(osoFieldTemplateName).
+					Description("The name of an index template to create when connecting. When empty no template is created.").
+					Default(""),
+				service.NewStringField(osoFieldTemplateBody).
+					Description("The body of the index template as a JSON object.").
+					Example(`{"index_patterns":["logs-*"],"template":{"settings":{"number_of_shards":1}}}`).
+					Default(""),
+				service.NewBoolField(osoFieldTemplateOverwrite).
+					Description("Whether to overwrite an existing template of the same name.").
+					Default(false),
+			).
+				Description("An optional composable index template to bootstrap when the output connects.").
+				Advanced(),
+			service.NewDurationField(osoFieldTimeout).
+				Description("The maximum time to wait before abandoning a request (and trying again).").
+				Advanced().
+				Default("5s"),
+			service.NewTLSToggledField(osoFieldTLS),
+			service.NewOutputMaxInFlightField(),
+		).
+		Fields(pure.CommonRetryBackOffFields(0, "1s", "5s", "30s")...).
+		Fields(
+			httpclient.BasicAuthField(),
+			service.NewBatchPolicyField(osoFieldBatching),
+			AWSField(),
+		).
+		Example("Data Stream", "Write logs into a data stream, bootstrapping an index template for it when connecting.", `
+output:
+  opensearch:
+    urls: [ https://localhost:9200 ]
+    index: logs-benthos
+    data_stream: true
+    index_template:
+      name: logs-benthos
+      body: '{"index_patterns":["logs-benthos*"],"priority":100}'
+    batching:
+      count: 100
+      period: 1s
+`).
+		Example("Amazon OpenSearch Serverless", "Write documents into a collection of Amazon OpenSearch Serverless with requests signed by AWS Signature Version 4.", `
+output:
+  opensearch:
+    urls: [ https://xxxxxxxx.us-east-1.aoss.amazonaws.com ]
+    index: things
+    aws:
+      enabled: true
+      service: aoss
+      region: us-east-1
+`)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("opensearch", OutputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if maxInFlight, err = conf.FieldMaxInFlight(); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy(osoFieldBatching); err != nil {
+				return
+			}
+			out, err = OutputFromParsed(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Output implements service.BatchOutput for opensearch.
+type Output struct {
+	log  *service.Logger
+	conf osoConfig
+
+	urlIndex  uint64
+	connected int32
+}
+
+// OutputFromParsed returns an opensearch output writer from a parsed config.
+func OutputFromParsed(pConf *service.ParsedConfig, mgr *service.Resources) (*Output, error) {
+	conf, err := osoConfigFromParsed(pConf)
+	if err != nil {
+		return nil, err
+	}
+	return &Output{
+		log:  mgr.Logger(),
+		conf: conf,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (o *Output) do(ctx context.Context, method, path string, body []byte) (int, []byte, error) {
+	baseURL := o.conf.urls[atomic.AddUint64(&o.urlIndex, 1)%uint64(len(o.conf.urls))]
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, bodyReader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		if path == "/_bulk" {
+			req.Header.Set("Content-Type", "application/x-ndjson")
+		} else {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+	if o.conf.authEnabled {
+		req.SetBasicAuth(o.conf.username, o.conf.password)
+	}
+	if o.conf.signer != nil {
+		if err := o.conf.signer(req, body); err != nil {
+			return 0, nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+
+	res, err := o.conf.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return res.StatusCode, resBody, nil
+}
+
+func (o *Output) bootstrapTemplate(ctx context.Context) error {
+	if o.conf.templateName == "" {
+		return nil
+	}
+
+	path := "/_index_template/" + url.PathEscape(o.conf.templateName)
+	if !o.conf.templateOverwrite {
+		status, _, err := o.do(ctx, http.MethodHead, path, nil)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case http.StatusOK:
+			o.log.Debugf("Index template '%v' already exists", o.conf.templateName)
+			return nil
+		case http.StatusNotFound:
+		default:
+			return fmt.Errorf("failed to check for index template '%v': status [%v]", o.conf.templateName, status)
+		}
+	}
+
+	status, resBody, err := o.do(ctx, http.MethodPut, path, o.conf.templateBody)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("failed to create index template '%v': status [%v]: %s", o.conf.templateName, status, resBody)
+	}
+	o.log.Infof("Created index template '%v'", o.conf.templateName)
+	return nil
+}
+
+func (o *Output) Connect(ctx context.Context) error {
+	if atomic.LoadInt32(&o.connected) == 1 {
+		return nil
+	}
+	if err := o.bootstrapTemplate(ctx); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&o.connected, 1)
+	o.log.Infof("Sending messages to OpenSearch at urls: %s\n", o.conf.urls)
+	return nil
+}
+
+func shouldRetry(s int) bool {
+	return s == http.StatusTooManyRequests || (s >= 500 && s <= 599)
+}
+
+func shouldRetryRequest(s int) bool {
+	return s == http.StatusTooManyRequests || s == http.StatusServiceUnavailable
+}
+
+type bulkResponse struct {
+	Errors bool                          `json:"errors"`
+	Items  []map[string]bulkResponseItem `json:"items"`
+}
+
+type bulkResponseItem struct {
+	ID     string `json:"_id"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+func (o *Output) buildBulkRequest(batch service.MessageBatch, i int) ([]byte, error) {
+	action := "create"
+	if !o.conf.dataStream {
+		var err error
+		if action, err = batch.TryInterpolatedString(i, o.conf.actionStr); err != nil {
+			return nil, fmt.Errorf("action interpolation error: %w", err)
+		}
+	}
+
+	index, err := batch.TryInterpolatedString(i, o.conf.indexStr)
+	if err != nil {
+		return nil, fmt.Errorf("index interpolation error: %w", err)
+	}
+	id, err := batch.TryInterpolatedString(i, o.conf.idStr)
+	if err != nil {
+		return nil, fmt.Errorf("id interpolation error: %w", err)
+	}
+	routing, err := batch.TryInterpolatedString(i, o.conf.routingStr)
+	if err != nil {
+		return nil, fmt.Errorf("routing interpolation error: %w", err)
+	}
+
+	meta := map[string]any{"_index": index}
+	if id != "" {
+		meta["_id"] = id
+	}
+	if routing != "" {
+		meta["routing"] = routing
+	}
+
+	var doc any
+	switch action {
+	case "index", "create":
+		pipeline, err := batch.TryInterpolatedString(i, o.conf.pipelineStr)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline interpolation error: %w", err)
+		}
+		if pipeline != "" {
+			meta["pipeline"] = pipeline
+		}
+		if doc, err = batch[i].AsStructured(); err != nil {
+			return nil, fmt.Errorf("failed to marshal message into JSON document: %w", err)
+		}
+	case "update", "upsert":
+		if id == "" {
+			return nil, fmt.Errorf("an id is required for the %v action", action)
+		}
+		structured, err := batch[i].AsStructured()
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal message into JSON document: %w", err)
+		}
+		updateDoc := map[string]any{"doc": structured}
+		if action == "upsert" {
+			updateDoc["doc_as_upsert"] = true
+			action = "update"
+		}
+		doc = updateDoc
+	case "delete":
+		if id == "" {
+			return nil, errors.New("an id is required for the delete action")
+		}
+	default:
+		return nil, fmt.Errorf("opensearch action '%s' is not allowed", action)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(map[string]any{action: meta}); err != nil {
+		return nil, err
+	}
+	if doc != nil {
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to marshal message into JSON document: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (o *Output) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	if atomic.LoadInt32(&o.connected) == 0 {
+		return component.ErrNotConnected
+	}
+
+	requests := make([][]byte, len(batch))
+	pending := make([]int, len(batch))
+	for i := range batch {
+		var err error
+		if requests[i], err = o.buildBulkRequest(batch, i); err != nil {
+			return err
+		}
+		pending[i] = i
+	}
+
+	var batchErr *service.BatchError
+	failed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = service.NewBatchError(batch, errors.New("one or more documents were rejected"))
+		}
+		batchErr.Failed(i, err)
+	}
+
+	boff := o.conf.backoffCtor()
+	for len(pending) > 0 {
+		var body []byte
+		for _, i := range pending {
+			body = append(body, requests[i]...)
+		}
+
+		status, resBody, err := o.do(ctx, http.MethodPost, "/_bulk", body)
+		if err != nil {
+			return err
+		}
+
+		var retries []int
+		var lastErr error
+		switch {
+		case shouldRetryRequest(status):
+			lastErr = fmt.Errorf("bulk request rejected with status [%v]", status)
+			o.log.Warnf("OpenSearch bulk request rejected with status [%v], retrying\n", status)
+			retries = pending
+		case status < 200 || status > 299:
+			return fmt.Errorf("bulk request failed with status [%v]: %s", status, resBody)
+		default:
+			var res bulkResponse
+			if err := json.Unmarshal(resBody, &res); err != nil {
+				return fmt.Errorf("failed to parse bulk response: %w", err)
+			}
+			if !res.Errors {
+				break
+			}
+			if len(res.Items) != len(pending) {
+				return fmt.Errorf("bulk response contained %v items, expected %v", len(res.Items), len(pending))
+			}
+			for j, resp := range res.Items {
+				for _, item := range resp {
+					if item.Status >= 200 && item.Status <= 299 {
+						continue
+					}
+
+					reason := "no reason given"
+					if item.Error != nil {
+						reason = fmt.Sprintf("%v: %v", item.Error.Type, item.Error.Reason)
+					}
+					itemErr := fmt.Errorf("status [%v]: %v", item.Status, reason)
+
+					// IMPORTANT: j exactly matches the index of our pending
+					// requests, which are the indexes of our source messages.
+					if !shouldRetry(item.Status) {
+						o.log.Errorf("OpenSearch message '%v' rejected with %v\n", item.ID, itemErr)
+						failed(pending[j], itemErr)
+						continue
+					}
+					lastErr = itemErr
+					retries = append(retries, pending[j])
+				}
+			}
+		}
+
+		if pending = retries; len(pending) == 0 {
+			break
+		}
+
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			for _, i := range pending {
+				failed(i, fmt.Errorf("retries exhausted, last error: %w", lastErr))
+			}
+			break
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (o *Output) Close(context.Context) error {
+	return nil
+}
@@ -0,0 +1,249 @@
+package opensearch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type testCluster struct {
+	mut       sync.Mutex
+	bulks     []string
+	templates map[string]string
+	bulkFn    func(n int, body string) (int, string)
+}
+
+func newTestCluster(t testing.TB, bulkFn func(n int, body string) (int, string)) (*testCluster, string) {
+	t.Helper()
+
+	c := &testCluster{
+		templates: map[string]string{},
+		bulkFn:    bulkFn,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		switch {
+		case r.URL.Path == "/_bulk" && r.Method == http.MethodPost:
+			assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+			c.bulks = append(c.bulks, string(body))
+			status, res := c.bulkFn(len(c.bulks), string(body))
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(res))
+		case strings.HasPrefix(r.URL.Path, "/_index_template/"):
+			name := strings.TrimPrefix(r.URL.Path, "/_index_template/")
+			switch r.Method {
+			case http.MethodHead:
+				if _, exists := c.templates[name]; !exists {
+					w.WriteHeader(http.StatusNotFound)
+				}
+			case http.MethodPut:
+				c.templates[name] = string(body)
+				_, _ = w.Write([]byte(`{"acknowledged":true}`))
+			}
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return c, ts.URL
+}
+
+func newTestOutput(t testing.TB, confStr string) *Output {
+	t.Helper()
+
+	conf, err := OutputSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	out, err := OutputFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+	require.NoError(t, out.Connect(context.Background()))
+	return out
+}
+
+func okBulk(n int, body string) (int, string) {
+	return http.StatusOK, `{"errors":false,"items":[]}`
+}
+
+func TestOutputBulkActions(t *testing.T) {
+	c, urlStr := newTestCluster(t, okBulk)
+
+	out := newTestOutput(t, `
+urls: [ `+urlStr+` ]
+index: ${! this.index }
+id: ${! this.id.or("") }
+action: ${! this.action }
+pipeline: ${! this.pipeline.or("") }
+routing: ${! this.routing.or("") }
+`)
+
+	require.NoError(t, out.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"index":"foo","action":"index","pipeline":"bar"}`)),
+		service.NewMessage([]byte(`{"index":"foo","action":"create","id":"1","routing":"baz"}`)),
+		service.NewMessage([]byte(`{"index":"foo","action":"update","id":"2"}`)),
+		service.NewMessage([]byte(`{"index":"foo","action":"upsert","id":"3"}`)),
+		service.NewMessage([]byte(`{"index":"foo","action":"delete","id":"4"}`)),
+	}))
+
+	require.Len(t, c.bulks, 1)
+	assert.Equal(t, `{"index":{"_index":"foo","pipeline":"bar"}}
+{"action":"index","index":"foo","pipeline":"bar"}
+{"create":{"_id":"1","_index":"foo","routing":"baz"}}
+{"action":"create","id":"1","index":"foo","routing":"baz"}
+{"update":{"_id":"2","_index":"foo"}}
+{"doc":{"action":"update","id":"2","index":"foo"}}
+{"update":{"_id":"3","_index":"foo"}}
+{"doc":{"action":"upsert","id":"3","index":"foo"},"doc_as_upsert":true}
+{"delete":{"_id":"4","_index":"foo"}}
+`, c.bulks[0])
+
+	err := out.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"index":"foo","action":"delete"}`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "an id is required for the delete action")
+
+	err = out.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"index":"foo","action":"nope"}`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "opensearch action 'nope' is not allowed")
+}
+
+func TestOutputDataStreamTemplate(t *testing.T) {
+	c, urlStr := newTestCluster(t, okBulk)
+	c.templates["existing"] = `{}`
+
+	out := newTestOutput(t, `
+urls: [ `+urlStr+` ]
+index: logs-foo
+action: index
+data_stream: true
+index_template:
+  name: logs-foo
+  body: '{"index_patterns":["logs-foo*"],"priority":100}'
+`)
+	assert.Equal(t, `{"data_stream":{},"index_patterns":["logs-foo*"],"priority":100}`, c.templates["logs-foo"])
+
+	require.NoError(t, out.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"@timestamp":"2022-01-01T00:00:00Z"}`)),
+	}))
+	require.Len(t, c.bulks, 1)
+	assert.Equal(t, `{"create":{"_index":"logs-foo"}}
+{"@timestamp":"2022-01-01T00:00:00Z"}
+`, c.bulks[0])
+
+	// Existing templates are left alone unless overwrite is set.
+	_ = newTestOutput(t, `
+urls: [ `+urlStr+` ]
+index: logs-foo
+index_template:
+  name: existing
+  body: '{"index_patterns":["bar*"]}'
+`)
+	assert.Equal(t, `{}`, c.templates["existing"])
+
+	_ = newTestOutput(t, `
+urls: [ `+urlStr+` ]
+index: logs-foo
+index_template:
+  name: existing
+  body: '{"index_patterns":["bar*"]}'
+  overwrite: true
+`)
+	assert.Equal(t, `{"index_patterns":["bar*"]}`, c.templates["existing"])
+}
+
+func TestOutputBulkRetries(t *testing.T) {
+	c, urlStr := newTestCluster(t, func(n int, body string) (int, string) {
+		switch n {
+		case 1:
+			return http.StatusServiceUnavailable, `{}`
+		case 2:
+			return http.StatusOK, `{"errors":true,"items":[
+	{"index":{"_id":"a","status":201}},
+	{"index":{"_id":"b","status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}}},
+	{"index":{"_id":"c","status":400,"error":{"type":"mapper_parsing_exception","reason":"bad field"}}}
+]}`
+		}
+		return http.StatusOK, `{"errors":false,"items":[{"index":{"_id":"b","status":201}}]}`
+	})
+
+	out := newTestOutput(t, `
+urls: [ `+urlStr+` ]
+index: foo
+id: ${! this }
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+`)
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`"a"`)),
+		service.NewMessage([]byte(`"b"`)),
+		service.NewMessage([]byte(`"c"`)),
+	}
+	err := out.WriteBatch(context.Background(), batch)
+	require.Error(t, err)
+
+	var batchErr *service.BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, 1, batchErr.IndexedErrors())
+	batchErr.WalkMessages(func(i int, m *service.Message, err error) bool {
+		if i == 2 {
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "mapper_parsing_exception")
+		} else {
+			assert.NoError(t, err)
+		}
+		return true
+	})
+
+	require.Len(t, c.bulks, 3)
+	assert.Equal(t, c.bulks[0], c.bulks[1])
+	assert.Equal(t, `{"index":{"_id":"b","_index":"foo"}}
+"b"
+`, c.bulks[2])
+}
+
+func TestOutputBulkRetriesExhausted(t *testing.T) {
+	_, urlStr := newTestCluster(t, func(n int, body string) (int, string) {
+		return http.StatusTooManyRequests, `{}`
+	})
+
+	out := newTestOutput(t, `
+urls: [ `+urlStr+` ]
+index: foo
+max_retries: 2
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+`)
+
+	err := out.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{}`)),
+	})
+	require.Error(t, err)
+
+	var batchErr *service.BatchError
+	require.True(t, errors.As(err, &batchErr))
+	batchErr.WalkMessages(func(i int, m *service.Message, err error) bool {
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "retries exhausted, last error: bulk request rejected with status [429]")
+		return true
+	})
+}
@@ -0,0 +1,464 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/benthosdev/benthos/v4/internal/cli"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	omFieldGRPC                = "grpc"
+	omFieldGRPCURL             = "url"
+	omFieldResourceAttributes  = "resource_attributes"
+	omFieldTemporality         = "temporality"
+	omFieldHistogramBoundaries = "histogram_boundaries"
+	omFieldFlushPeriod         = "flush_period"
+	omFieldTimeout             = "timeout"
+)
+
+func otlpMetricsSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.20.0").
+		Summary("Send metrics to an [Open Telemetry collector](https://opentelemetry.io/docs/collector/) using OTLP over gRPC.").
+		Description(`
+Metrics are aggregated in memory and pushed to each collector at the interval specified by `+"`flush_period`"+`. Counters are exported as monotonic sums, gauges as gauges, and timing metrics as histograms.
+
+### Timing Metrics
+
+Timing metrics are converted from nanoseconds into seconds before being added to histograms in order to better fit within bucket definitions, and are therefore exported with the unit `+"`s`"+`.
+
+### Temporality
+
+When `+"`temporality`"+` is set to `+"`cumulative`"+` (the default) counters and histograms report the total observed since Benthos started. When set to `+"`delta`"+` each export reports only the values observed since the previous export, and series that have not been updated since the previous export are omitted.`).
+		Fields(
+			service.NewObjectListField(omFieldGRPC,
+				service.NewURLField(omFieldGRPCURL).
+					Description("The URL of a collector to send metrics to.").
+					Default("localhost:4317"),
+			).Description("A list of grpc collectors."),
+			service.NewStringMapField(omFieldResourceAttributes).
+				Description("A map of attributes to add to the resource that metrics are exported from. If `service.name` is not specified it defaults to `benthos`.").
+				Example(map[string]any{"service.name": "ingest", "deployment.environment": "prod"}).
+				Default(map[string]any{}),
+			service.NewStringAnnotatedEnumField(omFieldTemporality, map[string]string{
+				"cumulative": "Counters and histograms report totals since the exporter started.",
+				"delta":      "Counters and histograms report the change since the previous export.",
+			}).
+				Description("The aggregation temporality of counters and histograms.").
+				Default("cumulative"),
+			service.NewFloatListField(omFieldHistogramBoundaries).
+				Description("The explicit bucket boundaries (in seconds) of timing histograms. If left empty a default set of boundaries ranging from 5 milliseconds to 10 seconds is used.").
+				Example([]float64{0.001, 0.01, 0.1, 1}).
+				Default([]any{}).
+				Advanced(),
+			service.NewDurationField(omFieldFlushPeriod).
+				Description("The period of time between each export of metrics.").
+				Default("10s").
+				Advanced(),
+			service.NewDurationField(omFieldTimeout).
+				Description("The maximum period of time to wait for an export to a collector to complete.").
+				Default("5s").
+				Advanced(),
+		)
+}
+
+func init() {
+	err := service.RegisterMetricsExporter("open_telemetry_collector", otlpMetricsSpec(),
+		func(conf *service.ParsedConfig, log *service.Logger) (service.MetricsExporter, error) {
+			om, err := newOtlpMetricsFromConfig(conf, log)
+			if err != nil {
+				return nil, err
+			}
+			om.start()
+			return om, nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Matches the default bucket boundaries of the Prometheus client in seconds.
+var defaultHistogramBoundaries = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type otlpMetricKind int
+
+const (
+	otlpCounter otlpMetricKind = iota
+	otlpGauge
+	otlpHistogram
+)
+
+type otlpSeries struct {
+	name  string
+	kind  otlpMetricKind
+	attrs []*commonpb.KeyValue
+
+	updated bool
+	value   int64
+
+	count        uint64
+	sum          float64
+	bucketCounts []uint64
+}
+
+type otlpMetrics struct {
+	conns   []*grpc.ClientConn
+	clients []colmetricspb.MetricsServiceClient
+
+	resource    *resourcepb.Resource
+	delta       bool
+	boundaries  []float64
+	flushPeriod time.Duration
+	timeout     time.Duration
+
+	mut       sync.Mutex
+	series    map[string]*otlpSeries
+	startTime time.Time
+
+	log    *service.Logger
+	ctx    context.Context
+	cancel func()
+	doneWG sync.WaitGroup
+}
+
+func newOtlpMetricsFromConfig(conf *service.ParsedConfig, log *service.Logger) (*otlpMetrics, error) {
+	om := &otlpMetrics{
+		series:    map[string]*otlpSeries{},
+		startTime: time.Now(),
+		log:       log,
+	}
+
+	collectorConfs, err := collectors(conf, omFieldGRPC)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs, err := conf.FieldStringMap(omFieldResourceAttributes)
+	if err != nil {
+		return nil, err
+	}
+	om.resource = otlpResource(stringMapToAny(attrs))
+
+	temporality, err := conf.FieldString(omFieldTemporality)
+	if err != nil {
+		return nil, err
+	}
+	om.delta = temporality == "delta"
+
+	if om.boundaries, err = conf.FieldFloatList(omFieldHistogramBoundaries); err != nil {
+		return nil, err
+	}
+	if len(om.boundaries) == 0 {
+		om.boundaries = defaultHistogramBoundaries
+	}
+	if !sort.Float64sAreSorted(om.boundaries) {
+		return nil, errors.New("histogram boundaries must be sorted in increasing order")
+	}
+
+	if om.flushPeriod, err = conf.FieldDuration(omFieldFlushPeriod); err != nil {
+		return nil, err
+	}
+	if om.flushPeriod <= 0 {
+		return nil, errors.New("flush period must be greater than zero")
+	}
+	if om.timeout, err = conf.FieldDuration(omFieldTimeout); err != nil {
+		return nil, err
+	}
+
+	for _, c := range collectorConfs {
+		conn, err := grpc.Dial(c.url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			om.closeConns()
+			return nil, fmt.Errorf("failed to create collector connection %v: %w", c.url, err)
+		}
+		om.conns = append(om.conns, conn)
+		om.clients = append(om.clients, colmetricspb.NewMetricsServiceClient(conn))
+	}
+	return om, nil
+}
+
+// otlpResource creates a resource from attributes, adding a default service
+// name and version when a service name isn't specified.
+func otlpResource(attrs map[string]any) *resourcepb.Resource {
+	res := &resourcepb.Resource{Attributes: otlpAttributes(attrs)}
+
+	if _, exists := attrs[string(semconv.ServiceNameKey)]; !exists {
+		res.Attributes = append(res.Attributes, otlpStringAttr(string(semconv.ServiceNameKey), "benthos"))
+
+		// Only set the default service version if the user doesn't provide
+		// a custom service name.
+		if _, exists := attrs[string(semconv.ServiceVersionKey)]; !exists {
+			res.Attributes = append(res.Attributes, otlpStringAttr(string(semconv.ServiceVersionKey), cli.Version))
+		}
+	}
+	return res
+}
+
+func stringMapToAny(m map[string]string) map[string]any {
+	res := make(map[string]any, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+func otlpStringAttr(k, v string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key: k,
+		Value: &commonpb.AnyValue{
+			Value: &commonpb.AnyValue_StringValue{StringValue: v},
+		},
+	}
+}
+
+func (o *otlpMetrics) start() {
+	o.ctx, o.cancel = context.WithCancel(context.Background())
+	o.doneWG.Add(1)
+	go o.loop()
+}
+
+func (o *otlpMetrics) getSeries(kind otlpMetricKind, name string, labelKeys, labelValues []string) *otlpSeries {
+	var id strings.Builder
+	id.WriteString(name)
+	for _, v := range labelValues {
+		id.WriteByte(0)
+		id.WriteString(v)
+	}
+
+	o.mut.Lock()
+	defer o.mut.Unlock()
+
+	if s, exists := o.series[id.String()]; exists {
+		return s
+	}
+
+	s := &otlpSeries{name: name, kind: kind}
+	for i, k := range labelKeys {
+		if i < len(labelValues) {
+			s.attrs = append(s.attrs, otlpStringAttr(k, labelValues[i]))
+		}
+	}
+	if kind == otlpHistogram {
+		s.bucketCounts = make([]uint64, len(o.boundaries)+1)
+	}
+	o.series[id.String()] = s
+	return s
+}
+
+type otlpStat struct {
+	root *otlpMetrics
+	s    *otlpSeries
+}
+
+func (o *otlpStat) Incr(count int64) {
+	o.root.mut.Lock()
+	o.s.value += count
+	o.s.updated = true
+	o.root.mut.Unlock()
+}
+
+func (o *otlpStat) Set(value int64) {
+	o.root.mut.Lock()
+	o.s.value = value
+	o.s.updated = true
+	o.root.mut.Unlock()
+}
+
+func (o *otlpStat) Timing(delta int64) {
+	secs := float64(delta) / float64(time.Second)
+	i := sort.SearchFloat64s(o.root.boundaries, secs)
+
+	o.root.mut.Lock()
+	o.s.count++
+	o.s.sum += secs
+	o.s.bucketCounts[i]++
+	o.s.updated = true
+	o.root.mut.Unlock()
+}
+
+func (o *otlpMetrics) NewCounterCtor(name string, labelKeys ...string) service.MetricsExporterCounterCtor {
+	return func(labelValues ...string) service.MetricsExporterCounter {
+		return &otlpStat{root: o, s: o.getSeries(otlpCounter, name, labelKeys, labelValues)}
+	}
+}
+
+func (o *otlpMetrics) NewTimerCtor(name string, labelKeys ...string) service.MetricsExporterTimerCtor {
+	return func(labelValues ...string) service.MetricsExporterTimer {
+		return &otlpStat{root: o, s: o.getSeries(otlpHistogram, name, labelKeys, labelValues)}
+	}
+}
+
+func (o *otlpMetrics) NewGaugeCtor(name string, labelKeys ...string) service.MetricsExporterGaugeCtor {
+	return func(labelValues ...string) service.MetricsExporterGauge {
+		return &otlpStat{root: o, s: o.getSeries(otlpGauge, name, labelKeys, labelValues)}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (o *otlpMetrics) loop() {
+	defer o.doneWG.Done()
+
+	ticker := time.NewTicker(o.flushPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-o.ctx.Done():
+			return
+		case <-ticker.C:
+			o.flush(o.ctx)
+		}
+	}
+}
+
+// collect builds an export request from the current state of all series. When
+// exporting with delta temporality the counters and histograms are reset.
+func (o *otlpMetrics) collect(now time.Time) *colmetricspb.ExportMetricsServiceRequest {
+	o.mut.Lock()
+	defer o.mut.Unlock()
+
+	temporality := metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	if o.delta {
+		temporality = metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	}
+	startNanos, nowNanos := uint64(o.startTime.UnixNano()), uint64(now.UnixNano())
+
+	ids := make([]string, 0, len(o.series))
+	for id := range o.series {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	metricsByName := map[string]*metricspb.Metric{}
+	var metrics []*metricspb.Metric
+	for _, id := range ids {
+		s := o.series[id]
+		if !s.updated {
+			continue
+		}
+
+		m, exists := metricsByName[s.name]
+		if !exists {
+			m = &metricspb.Metric{Name: s.name}
+			switch s.kind {
+			case otlpCounter:
+				m.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+					AggregationTemporality: temporality,
+					IsMonotonic:            true,
+				}}
+			case otlpGauge:
+				m.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{}}
+			case otlpHistogram:
+				m.Unit = "s"
+				m.Data = &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+					AggregationTemporality: temporality,
+				}}
+			}
+			metricsByName[s.name] = m
+			metrics = append(metrics, m)
+		}
+
+		switch d := m.Data.(type) {
+		case *metricspb.Metric_Sum:
+			d.Sum.DataPoints = append(d.Sum.DataPoints, &metricspb.NumberDataPoint{
+				Attributes:        s.attrs,
+				StartTimeUnixNano: startNanos,
+				TimeUnixNano:      nowNanos,
+				Value:             &metricspb.NumberDataPoint_AsInt{AsInt: s.value},
+			})
+		case *metricspb.Metric_Gauge:
+			d.Gauge.DataPoints = append(d.Gauge.DataPoints, &metricspb.NumberDataPoint{
+				Attributes:   s.attrs,
+				TimeUnixNano: nowNanos,
+				Value:        &metricspb.NumberDataPoint_AsInt{AsInt: s.value},
+			})
+		case *metricspb.Metric_Histogram:
+			sum := s.sum
+			d.Histogram.DataPoints = append(d.Histogram.DataPoints, &metricspb.HistogramDataPoint{
+				Attributes:        s.attrs,
+				StartTimeUnixNano: startNanos,
+				TimeUnixNano:      nowNanos,
+				Count:             s.count,
+				Sum:               &sum,
+				BucketCounts:      append([]uint64(nil), s.bucketCounts...),
+				ExplicitBounds:    o.boundaries,
+			})
+		}
+
+		if o.delta && s.kind != otlpGauge {
+			s.updated = false
+			s.value, s.count, s.sum = 0, 0, 0
+			for i := range s.bucketCounts {
+				s.bucketCounts[i] = 0
+			}
+		}
+	}
+	if o.delta {
+		o.startTime = now
+	}
+
+	if len(metrics) == 0 {
+		return nil
+	}
+	return &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource: o.resource,
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Scope: &commonpb.InstrumentationScope{
+					Name:    "benthos",
+					Version: cli.Version,
+				},
+				Metrics: metrics,
+			}},
+			SchemaUrl: semconv.SchemaURL,
+		}},
+	}
+}
+
+func (o *otlpMetrics) flush(ctx context.Context) {
+	req := o.collect(time.Now())
+	if req == nil {
+		return
+	}
+	for _, c := range o.clients {
+		exportCtx, done := context.WithTimeout(ctx, o.timeout)
+		if _, err := c.Export(exportCtx, req); err != nil {
+			o.log.Errorf("Failed to export metrics: %v", err)
+		}
+		done()
+	}
+}
+
+func (o *otlpMetrics) closeConns() {
+	for _, c := range o.conns {
+		_ = c.Close()
+	}
+}
+
+func (o *otlpMetrics) Close(ctx context.Context) error {
+	if o.cancel != nil {
+		o.cancel()
+		o.doneWG.Wait()
+	}
+	o.flush(ctx)
+	o.closeConns()
+	return nil
+}
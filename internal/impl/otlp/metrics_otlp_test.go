@@ -0,0 +1,175 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type mockMetricsCollector struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+
+	mut  sync.Mutex
+	reqs []*colmetricspb.ExportMetricsServiceRequest
+}
+
+func (m *mockMetricsCollector) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	m.mut.Lock()
+	m.reqs = append(m.reqs, req)
+	m.mut.Unlock()
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+func (m *mockMetricsCollector) pop() []*colmetricspb.ExportMetricsServiceRequest {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	reqs := m.reqs
+	m.reqs = nil
+	return reqs
+}
+
+func startMockCollector(t *testing.T) (*mockMetricsCollector, string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	mock := &mockMetricsCollector{}
+	srv := grpc.NewServer()
+	colmetricspb.RegisterMetricsServiceServer(srv, mock)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	return mock, lis.Addr().String()
+}
+
+func testOtlpMetrics(t *testing.T, confStr string) *otlpMetrics {
+	t.Helper()
+
+	conf, err := otlpMetricsSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	om, err := newOtlpMetricsFromConfig(conf, nil)
+	require.NoError(t, err)
+	return om
+}
+
+func metricsByName(req *colmetricspb.ExportMetricsServiceRequest) map[string]*metricspb.Metric {
+	m := map[string]*metricspb.Metric{}
+	for _, rm := range req.ResourceMetrics {
+		for _, sm := range rm.ScopeMetrics {
+			for _, metric := range sm.Metrics {
+				m[metric.Name] = metric
+			}
+		}
+	}
+	return m
+}
+
+func TestOtlpMetricsExport(t *testing.T) {
+	mock, addr := startMockCollector(t)
+
+	om := testOtlpMetrics(t, fmt.Sprintf(`
+grpc:
+  - url: %v
+resource_attributes:
+  service.name: foo
+histogram_boundaries: [ 0.1, 1 ]
+`, addr))
+
+	om.NewCounterCtor("counter_a", "label")("x").Incr(3)
+	om.NewCounterCtor("counter_a", "label")("x").Incr(2)
+	om.NewCounterCtor("counter_a", "label")("y").Incr(1)
+	om.NewGaugeCtor("gauge_a")().Set(10)
+	timer := om.NewTimerCtor("timer_a")()
+	timer.Timing(int64(time.Millisecond * 50))
+	timer.Timing(int64(time.Millisecond * 500))
+	timer.Timing(int64(time.Second * 5))
+
+	// Unused series aren't exported
+	_ = om.NewCounterCtor("counter_b")()
+
+	require.NoError(t, om.Close(context.Background()))
+
+	reqs := mock.pop()
+	require.Len(t, reqs, 1)
+
+	res := reqs[0].ResourceMetrics[0].Resource
+	require.Len(t, res.Attributes, 1)
+	assert.Equal(t, "service.name", res.Attributes[0].Key)
+	assert.Equal(t, "foo", res.Attributes[0].Value.GetStringValue())
+
+	metrics := metricsByName(reqs[0])
+	require.Len(t, metrics, 3)
+
+	sum := metrics["counter_a"].GetSum()
+	require.NotNil(t, sum)
+	assert.True(t, sum.IsMonotonic)
+	assert.Equal(t, metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE, sum.AggregationTemporality)
+	require.Len(t, sum.DataPoints, 2)
+	assert.Equal(t, "x", sum.DataPoints[0].Attributes[0].Value.GetStringValue())
+	assert.Equal(t, int64(5), sum.DataPoints[0].GetAsInt())
+	assert.Equal(t, "y", sum.DataPoints[1].Attributes[0].Value.GetStringValue())
+	assert.Equal(t, int64(1), sum.DataPoints[1].GetAsInt())
+
+	gauge := metrics["gauge_a"].GetGauge()
+	require.NotNil(t, gauge)
+	assert.Equal(t, int64(10), gauge.DataPoints[0].GetAsInt())
+
+	assert.Equal(t, "s", metrics["timer_a"].Unit)
+	hist := metrics["timer_a"].GetHistogram()
+	require.NotNil(t, hist)
+	require.Len(t, hist.DataPoints, 1)
+	assert.Equal(t, uint64(3), hist.DataPoints[0].Count)
+	assert.InDelta(t, 5.55, hist.DataPoints[0].GetSum(), 0.0001)
+	assert.Equal(t, []float64{0.1, 1}, hist.DataPoints[0].ExplicitBounds)
+	assert.Equal(t, []uint64{1, 1, 1}, hist.DataPoints[0].BucketCounts)
+}
+
+func TestOtlpMetricsDelta(t *testing.T) {
+	om := testOtlpMetrics(t, `
+temporality: delta
+`)
+
+	counter := om.NewCounterCtor("counter_a")()
+	gauge := om.NewGaugeCtor("gauge_a")()
+
+	counter.Incr(5)
+	gauge.Set(3)
+
+	metrics := metricsByName(om.collect(time.Now()))
+	require.Len(t, metrics, 2)
+	assert.Equal(t, metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA, metrics["counter_a"].GetSum().AggregationTemporality)
+	assert.Equal(t, int64(5), metrics["counter_a"].GetSum().DataPoints[0].GetAsInt())
+
+	// Counters without updates are omitted, gauges retain their value.
+	metrics = metricsByName(om.collect(time.Now()))
+	require.Len(t, metrics, 1)
+	assert.Equal(t, int64(3), metrics["gauge_a"].GetGauge().DataPoints[0].GetAsInt())
+
+	counter.Incr(2)
+	metrics = metricsByName(om.collect(time.Now()))
+	require.Len(t, metrics, 2)
+	assert.Equal(t, int64(2), metrics["counter_a"].GetSum().DataPoints[0].GetAsInt())
+}
+
+func TestOtlpMetricsConfigErrors(t *testing.T) {
+	conf, err := otlpMetricsSpec().ParseYAML(`histogram_boundaries: [ 1, 0.5 ]`, nil)
+	require.NoError(t, err)
+
+	_, err = newOtlpMetricsFromConfig(conf, service.MockResources().Logger())
+	require.EqualError(t, err, "histogram boundaries must be sorted in increasing order")
+}
@@ -0,0 +1,763 @@
+package otlp
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/benthosdev/benthos/v4/internal/cli"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	ooFieldURL                = "url"
+	ooFieldSignal             = "signal"
+	ooFieldHeaders            = "headers"
+	ooFieldResourceAttributes = "resource_attributes"
+	ooFieldTimeout            = "timeout"
+	ooFieldTLS                = "tls"
+	ooFieldBatching           = "batching"
+)
+
+func otlpOutputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.20.0").
+		Summary("Exports messages as OTLP logs, spans or metrics to an [Open Telemetry collector](https://opentelemetry.io/docs/collector/) over gRPC.").
+		Description(`
+Each message must be a structured object that is converted into a single log record, span or metric data point depending on the `+"`signal`"+` field. Timestamps may be either RFC 3339 strings or integers of nanoseconds since the unix epoch, and default to the time the message is written. Attribute values may be of any structured type, and are converted into the equivalent OTLP value.
+
+The messages of each batch are exported within a single request, under a resource containing the attributes of `+"`resource_attributes`"+`. Messages may also contain a `+"`resource`"+` object of attributes that are added to those of `+"`resource_attributes`"+`, in which case the messages are grouped by their resources.
+
+### Logs
+
+`+"```json"+`
+{
+  "body": "user logged in",
+  "severity_text": "INFO",
+  "attributes": { "user.id": "foo" },
+  "timestamp": "2023-01-01T00:00:00Z",
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174"
+}
+`+"```"+`
+
+The body may be of any structured type, and all other fields are optional. When `+"`severity_number`"+` is omitted it is derived from `+"`severity_text`"+` where it is a recognised level such as `+"`WARN`"+` or `+"`error`"+`.
+
+### Traces
+
+`+"```json"+`
+{
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174",
+  "parent_span_id": "eee19b7ec3c1b173",
+  "name": "GET /users",
+  "kind": "server",
+  "start_time": "2023-01-01T00:00:00Z",
+  "end_time": "2023-01-01T00:00:01Z",
+  "attributes": { "http.status_code": 200 },
+  "status": { "code": "ok", "message": "" }
+}
+`+"```"+`
+
+The fields `+"`trace_id`"+`, `+"`span_id`"+` and `+"`name`"+` are required. The field `+"`kind`"+` is one of `+"`internal`"+`, `+"`server`"+`, `+"`client`"+`, `+"`producer`"+` or `+"`consumer`"+`, and the status code is one of `+"`unset`"+`, `+"`ok`"+` or `+"`error`"+`.
+
+### Metrics
+
+`+"```json"+`
+{
+  "name": "http.server.requests",
+  "type": "sum",
+  "value": 10,
+  "attributes": { "http.method": "GET" },
+  "timestamp": "2023-01-01T00:00:00Z"
+}
+`+"```"+`
+
+The field `+"`type`"+` is either `+"`gauge`"+` (the default) or `+"`sum`"+`, where sums are exported as cumulative and monotonic unless `+"`monotonic`"+` is set to `+"`false`"+`. Values that are integers are exported as integer data points, and all other numbers as double data points. The optional fields `+"`description`"+`, `+"`unit`"+` and `+"`start_time`"+` are also supported.
+
+## Performance
+
+This output benefits from sending multiple messages in flight in parallel for improved performance. You can tune the max number of in flight messages (or message batches) with the field `+"`max_in_flight`"+`.
+
+This output benefits from sending messages as a batch for improved performance. Batches can be formed at both the input and output level. You can find out more [in this doc](/docs/configuration/batching).`).
+		Fields(
+			service.NewURLField(ooFieldURL).
+				Description("The address of a collector to export to.").
+				Default("localhost:4317"),
+			service.NewStringEnumField(ooFieldSignal, "logs", "traces", "metrics").
+				Description("The type of telemetry that messages are exported as.").
+				Default("logs"),
+			service.NewStringMapField(ooFieldHeaders).
+				Description("A map of headers to add to each export request as gRPC metadata, such as authentication tokens.").
+				Default(map[string]any{}).
+				Example(map[string]any{"authorization": "Bearer ${OTEL_TOKEN}"}),
+			service.NewStringMapField(ooFieldResourceAttributes).
+				Description("A map of attributes to add to the resource that telemetry is exported from. If `service.name` is not specified it defaults to `benthos`.").
+				Example(map[string]any{"service.name": "legacy-app", "deployment.environment": "prod"}).
+				Default(map[string]any{}),
+			service.NewDurationField(ooFieldTimeout).
+				Description("The maximum period of time to wait for an export to complete.").
+				Default("10s").
+				Advanced(),
+			service.NewTLSToggledField(ooFieldTLS),
+			service.NewOutputMaxInFlightField(),
+			service.NewBatchPolicyField(ooFieldBatching),
+		).
+		Example(
+			"Syslog to OTLP Logs",
+			"In this example syslog messages are converted into OTLP log records and exported to a collector.",
+			`
+input:
+  socket_server:
+    network: udp
+    address: 0.0.0.0:514
+  processors:
+    - parse_log:
+        format: syslog_rfc5424
+    - mapping: |
+        root.body = this.message
+        root.severity_text = [ "EMERG", "ALERT", "CRIT", "ERROR", "WARN", "NOTICE", "INFO", "DEBUG" ].index(this.severity)
+        root.timestamp = this.timestamp
+        root.attributes."host.name" = this.hostname
+        root.attributes."process.executable.name" = this.appname
+
+output:
+  open_telemetry_collector:
+    url: otel-collector:4317
+    signal: logs
+    resource_attributes:
+      service.name: syslog
+    batching:
+      count: 100
+      period: 1s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("open_telemetry_collector", otlpOutputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			out service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldMaxInFlight(); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy(ooFieldBatching); err != nil {
+				return
+			}
+			out, err = newOtlpOutputFromParsed(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type otlpOutput struct {
+	url     string
+	signal  string
+	headers metadata.MD
+	timeout time.Duration
+	creds   credentials.TransportCredentials
+	scope   *commonpb.InstrumentationScope
+
+	resourceAttrs map[string]any
+	resource      *resourcepb.Resource
+
+	nowFn func() time.Time
+
+	connMut       sync.Mutex
+	conn          *grpc.ClientConn
+	logsClient    collogspb.LogsServiceClient
+	tracesClient  coltracepb.TraceServiceClient
+	metricsClient colmetricspb.MetricsServiceClient
+}
+
+func newOtlpOutputFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*otlpOutput, error) {
+	o := &otlpOutput{
+		nowFn: time.Now,
+		scope: &commonpb.InstrumentationScope{
+			Name:    "benthos",
+			Version: cli.Version,
+		},
+	}
+
+	var err error
+	if o.url, err = conf.FieldString(ooFieldURL); err != nil {
+		return nil, err
+	}
+	if o.signal, err = conf.FieldString(ooFieldSignal); err != nil {
+		return nil, err
+	}
+	headers, err := conf.FieldStringMap(ooFieldHeaders)
+	if err != nil {
+		return nil, err
+	}
+	o.headers = metadata.New(headers)
+	attrs, err := conf.FieldStringMap(ooFieldResourceAttributes)
+	if err != nil {
+		return nil, err
+	}
+	o.resourceAttrs = stringMapToAny(attrs)
+	o.resource = otlpResource(o.resourceAttrs)
+	if o.timeout, err = conf.FieldDuration(ooFieldTimeout); err != nil {
+		return nil, err
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled(ooFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		o.creds = credentials.NewTLS(tlsConf)
+	} else {
+		o.creds = insecure.NewCredentials()
+	}
+	return o, nil
+}
+
+func (o *otlpOutput) Connect(ctx context.Context) error {
+	o.connMut.Lock()
+	defer o.connMut.Unlock()
+
+	if o.conn != nil {
+		return nil
+	}
+
+	conn, err := grpc.DialContext(ctx, o.url, grpc.WithTransportCredentials(o.creds))
+	if err != nil {
+		return fmt.Errorf("failed to create collector connection %v: %w", o.url, err)
+	}
+	o.conn = conn
+	switch o.signal {
+	case "logs":
+		o.logsClient = collogspb.NewLogsServiceClient(conn)
+	case "traces":
+		o.tracesClient = coltracepb.NewTraceServiceClient(conn)
+	case "metrics":
+		o.metricsClient = colmetricspb.NewMetricsServiceClient(conn)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func otlpAnyValue(v any) *commonpb.AnyValue {
+	switch t := v.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: t}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: t}}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: i}}
+		}
+		f, _ := t.Float64()
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: f}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: t}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(t)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: t}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: t}}
+	case []any:
+		arr := &commonpb.ArrayValue{}
+		for _, e := range t {
+			arr.Values = append(arr.Values, otlpAnyValue(e))
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: arr}}
+	case map[string]any:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{
+			Values: otlpAttributes(t),
+		}}}
+	case nil:
+		return &commonpb.AnyValue{}
+	}
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprintf("%v", v)}}
+}
+
+// otlpAttributes converts an object into attributes sorted by key.
+func otlpAttributes(obj map[string]any) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, &commonpb.KeyValue{Key: k, Value: otlpAnyValue(obj[k])})
+	}
+	return attrs
+}
+
+func fieldAttributes(obj map[string]any) ([]*commonpb.KeyValue, error) {
+	v, exists := obj["attributes"]
+	if !exists || v == nil {
+		return nil, nil
+	}
+	attrs, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected attributes object, got %T", v)
+	}
+	return otlpAttributes(attrs), nil
+}
+
+func fieldString(obj map[string]any, key string) (string, error) {
+	v, exists := obj[key]
+	if !exists || v == nil {
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("expected %v string, got %T", key, v)
+	}
+	return s, nil
+}
+
+// fieldTimestamp returns the nanoseconds since the unix epoch of an RFC 3339
+// string or integer field, or the given default when it is not set.
+func fieldTimestamp(obj map[string]any, key string, def time.Time) (uint64, error) {
+	switch t := obj[key].(type) {
+	case nil:
+		return uint64(def.UnixNano()), nil
+	case string:
+		ts, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return 0, fmt.Errorf("%v: %w", key, err)
+		}
+		return uint64(ts.UnixNano()), nil
+	case json.Number:
+		i, err := t.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("%v: %w", key, err)
+		}
+		return uint64(i), nil
+	case int64:
+		return uint64(t), nil
+	case float64:
+		return uint64(t), nil
+	default:
+		return 0, fmt.Errorf("expected %v timestamp, got %T", key, t)
+	}
+}
+
+// fieldID decodes a hex encoded trace or span ID of the given size in bytes.
+func fieldID(obj map[string]any, key string, size int, required bool) ([]byte, error) {
+	s, err := fieldString(obj, key)
+	if err != nil {
+		return nil, err
+	}
+	if s == "" {
+		if required {
+			return nil, fmt.Errorf("%v is required", key)
+		}
+		return nil, nil
+	}
+	id, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", key, err)
+	}
+	if len(id) != size {
+		return nil, fmt.Errorf("%v must be %v bytes, got %v", key, size, len(id))
+	}
+	return id, nil
+}
+
+var severityNumbers = map[string]logspb.SeverityNumber{
+	"TRACE":    logspb.SeverityNumber_SEVERITY_NUMBER_TRACE,
+	"DEBUG":    logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+	"INFO":     logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+	"NOTICE":   logspb.SeverityNumber_SEVERITY_NUMBER_INFO2,
+	"WARN":     logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+	"WARNING":  logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+	"ERROR":    logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	"ERR":      logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	"CRITICAL": logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+	"CRIT":     logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+	"ALERT":    logspb.SeverityNumber_SEVERITY_NUMBER_FATAL2,
+	"EMERG":    logspb.SeverityNumber_SEVERITY_NUMBER_FATAL3,
+	"FATAL":    logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+}
+
+func (o *otlpOutput) toLogRecord(obj map[string]any, now time.Time) (*logspb.LogRecord, error) {
+	rec := &logspb.LogRecord{
+		ObservedTimeUnixNano: uint64(now.UnixNano()),
+	}
+
+	var err error
+	if body, exists := obj["body"]; exists {
+		rec.Body = otlpAnyValue(body)
+	}
+	if rec.Attributes, err = fieldAttributes(obj); err != nil {
+		return nil, err
+	}
+	if rec.TimeUnixNano, err = fieldTimestamp(obj, "timestamp", now); err != nil {
+		return nil, err
+	}
+	if rec.TraceId, err = fieldID(obj, "trace_id", 16, false); err != nil {
+		return nil, err
+	}
+	if rec.SpanId, err = fieldID(obj, "span_id", 8, false); err != nil {
+		return nil, err
+	}
+	if rec.SeverityText, err = fieldString(obj, "severity_text"); err != nil {
+		return nil, err
+	}
+	if n, exists := obj["severity_number"]; exists && n != nil {
+		i, ok := asInt64(n)
+		if !ok || i < 0 || i > 24 {
+			return nil, fmt.Errorf("severity_number must be an integer between 0 and 24, got %v", n)
+		}
+		rec.SeverityNumber = logspb.SeverityNumber(i)
+	} else {
+		rec.SeverityNumber = severityNumbers[strings.ToUpper(rec.SeverityText)]
+	}
+	return rec, nil
+}
+
+var spanKinds = map[string]tracepb.Span_SpanKind{
+	"":         tracepb.Span_SPAN_KIND_UNSPECIFIED,
+	"internal": tracepb.Span_SPAN_KIND_INTERNAL,
+	"server":   tracepb.Span_SPAN_KIND_SERVER,
+	"client":   tracepb.Span_SPAN_KIND_CLIENT,
+	"producer": tracepb.Span_SPAN_KIND_PRODUCER,
+	"consumer": tracepb.Span_SPAN_KIND_CONSUMER,
+}
+
+var statusCodes = map[string]tracepb.Status_StatusCode{
+	"":      tracepb.Status_STATUS_CODE_UNSET,
+	"unset": tracepb.Status_STATUS_CODE_UNSET,
+	"ok":    tracepb.Status_STATUS_CODE_OK,
+	"error": tracepb.Status_STATUS_CODE_ERROR,
+}
+
+func (o *otlpOutput) toSpan(obj map[string]any, now time.Time) (*tracepb.Span, error) {
+	span := &tracepb.Span{}
+
+	var err error
+	if span.TraceId, err = fieldID(obj, "trace_id", 16, true); err != nil {
+		return nil, err
+	}
+	if span.SpanId, err = fieldID(obj, "span_id", 8, true); err != nil {
+		return nil, err
+	}
+	if span.ParentSpanId, err = fieldID(obj, "parent_span_id", 8, false); err != nil {
+		return nil, err
+	}
+	if span.Name, err = fieldString(obj, "name"); err != nil {
+		return nil, err
+	}
+	if span.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if span.Attributes, err = fieldAttributes(obj); err != nil {
+		return nil, err
+	}
+	if span.EndTimeUnixNano, err = fieldTimestamp(obj, "end_time", now); err != nil {
+		return nil, err
+	}
+	if span.StartTimeUnixNano, err = fieldTimestamp(obj, "start_time", time.Unix(0, int64(span.EndTimeUnixNano))); err != nil {
+		return nil, err
+	}
+
+	kindStr, err := fieldString(obj, "kind")
+	if err != nil {
+		return nil, err
+	}
+	var exists bool
+	if span.Kind, exists = spanKinds[strings.ToLower(kindStr)]; !exists {
+		return nil, fmt.Errorf("unrecognised span kind: %v", kindStr)
+	}
+
+	if s, exists := obj["status"]; exists && s != nil {
+		statusObj, ok := s.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected status object, got %T", s)
+		}
+		span.Status = &tracepb.Status{}
+		codeStr, err := fieldString(statusObj, "code")
+		if err != nil {
+			return nil, err
+		}
+		if span.Status.Code, exists = statusCodes[strings.ToLower(codeStr)]; !exists {
+			return nil, fmt.Errorf("unrecognised status code: %v", codeStr)
+		}
+		if span.Status.Message, err = fieldString(statusObj, "message"); err != nil {
+			return nil, err
+		}
+	}
+	return span, nil
+}
+
+func (o *otlpOutput) toMetric(obj map[string]any, now time.Time) (*metricspb.Metric, error) {
+	m := &metricspb.Metric{}
+
+	var err error
+	if m.Name, err = fieldString(obj, "name"); err != nil {
+		return nil, err
+	}
+	if m.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if m.Description, err = fieldString(obj, "description"); err != nil {
+		return nil, err
+	}
+	if m.Unit, err = fieldString(obj, "unit"); err != nil {
+		return nil, err
+	}
+
+	dp := &metricspb.NumberDataPoint{}
+	if dp.Attributes, err = fieldAttributes(obj); err != nil {
+		return nil, err
+	}
+	if dp.TimeUnixNano, err = fieldTimestamp(obj, "timestamp", now); err != nil {
+		return nil, err
+	}
+	if _, exists := obj["start_time"]; exists {
+		if dp.StartTimeUnixNano, err = fieldTimestamp(obj, "start_time", now); err != nil {
+			return nil, err
+		}
+	}
+
+	switch v := obj["value"].(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			dp.Value = &metricspb.NumberDataPoint_AsInt{AsInt: i}
+		} else if f, err := v.Float64(); err == nil {
+			dp.Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: f}
+		} else {
+			return nil, fmt.Errorf("value: %w", err)
+		}
+	case int64:
+		dp.Value = &metricspb.NumberDataPoint_AsInt{AsInt: v}
+	case float64:
+		dp.Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: v}
+	default:
+		return nil, fmt.Errorf("expected value number, got %T", v)
+	}
+
+	typeStr, err := fieldString(obj, "type")
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(typeStr) {
+	case "", "gauge":
+		m.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+			DataPoints: []*metricspb.NumberDataPoint{dp},
+		}}
+	case "sum":
+		monotonic := true
+		if mV, exists := obj["monotonic"]; exists && mV != nil {
+			if monotonic, exists = mV.(bool); !exists {
+				return nil, fmt.Errorf("expected monotonic bool, got %T", mV)
+			}
+		}
+		m.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			IsMonotonic:            monotonic,
+			DataPoints:             []*metricspb.NumberDataPoint{dp},
+		}}
+	default:
+		return nil, fmt.Errorf("unrecognised metric type: %v", typeStr)
+	}
+	return m, nil
+}
+
+//------------------------------------------------------------------------------
+
+// otlpResourceGroup contains the telemetry of a batch that belongs to a single
+// resource.
+type otlpResourceGroup struct {
+	resource *resourcepb.Resource
+	logs     []*logspb.LogRecord
+	spans    []*tracepb.Span
+	metrics  []*metricspb.Metric
+}
+
+// resourceKey returns an identifier of the resource of a message, which is
+// empty when the message doesn't specify resource attributes.
+func resourceKey(obj map[string]any) (string, map[string]any, error) {
+	v, exists := obj["resource"]
+	if !exists || v == nil {
+		return "", nil, nil
+	}
+	attrs, ok := v.(map[string]any)
+	if !ok {
+		return "", nil, fmt.Errorf("expected resource object, got %T", v)
+	}
+	keyBytes, err := json.Marshal(attrs)
+	if err != nil {
+		return "", nil, fmt.Errorf("resource: %w", err)
+	}
+	return string(keyBytes), attrs, nil
+}
+
+func (o *otlpOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	o.connMut.Lock()
+	conn := o.conn
+	o.connMut.Unlock()
+	if conn == nil {
+		return service.ErrNotConnected
+	}
+
+	now := o.nowFn()
+
+	groupsByKey := map[string]*otlpResourceGroup{}
+	var groups []*otlpResourceGroup
+
+	addToGroup := func(obj map[string]any) error {
+		key, attrs, err := resourceKey(obj)
+		if err != nil {
+			return err
+		}
+
+		var rec *logspb.LogRecord
+		var span *tracepb.Span
+		var m *metricspb.Metric
+		switch o.signal {
+		case "logs":
+			rec, err = o.toLogRecord(obj, now)
+		case "traces":
+			span, err = o.toSpan(obj, now)
+		case "metrics":
+			m, err = o.toMetric(obj, now)
+		}
+		if err != nil {
+			return err
+		}
+
+		g, exists := groupsByKey[key]
+		if !exists {
+			g = &otlpResourceGroup{resource: o.resource}
+			if attrs != nil {
+				merged := make(map[string]any, len(o.resourceAttrs)+len(attrs))
+				for k, v := range o.resourceAttrs {
+					merged[k] = v
+				}
+				for k, v := range attrs {
+					merged[k] = v
+				}
+				g.resource = otlpResource(merged)
+			}
+			groupsByKey[key] = g
+			groups = append(groups, g)
+		}
+		if rec != nil {
+			g.logs = append(g.logs, rec)
+		}
+		if span != nil {
+			g.spans = append(g.spans, span)
+		}
+		if m != nil {
+			g.metrics = append(g.metrics, m)
+		}
+		return nil
+	}
+
+	var batchErr *service.BatchError
+	for i, msg := range batch {
+		v, err := msg.AsStructured()
+		if err == nil {
+			if obj, ok := v.(map[string]any); ok {
+				err = addToGroup(obj)
+			} else {
+				err = fmt.Errorf("expected object, got %T", v)
+			}
+		}
+		if err != nil {
+			if batchErr == nil {
+				batchErr = service.NewBatchError(batch, fmt.Errorf("failed to convert messages to %v", o.signal))
+			}
+			batchErr.Failed(i, err)
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+
+	ctx, done := context.WithTimeout(ctx, o.timeout)
+	defer done()
+	if o.headers.Len() > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, o.headers)
+	}
+
+	var err error
+	switch o.signal {
+	case "logs":
+		req := &collogspb.ExportLogsServiceRequest{}
+		for _, g := range groups {
+			req.ResourceLogs = append(req.ResourceLogs, &logspb.ResourceLogs{
+				Resource:  g.resource,
+				ScopeLogs: []*logspb.ScopeLogs{{Scope: o.scope, LogRecords: g.logs}},
+				SchemaUrl: semconv.SchemaURL,
+			})
+		}
+		_, err = o.logsClient.Export(ctx, req)
+	case "traces":
+		req := &coltracepb.ExportTraceServiceRequest{}
+		for _, g := range groups {
+			req.ResourceSpans = append(req.ResourceSpans, &tracepb.ResourceSpans{
+				Resource:   g.resource,
+				ScopeSpans: []*tracepb.ScopeSpans{{Scope: o.scope, Spans: g.spans}},
+				SchemaUrl:  semconv.SchemaURL,
+			})
+		}
+		_, err = o.tracesClient.Export(ctx, req)
+	case "metrics":
+		req := &colmetricspb.ExportMetricsServiceRequest{}
+		for _, g := range groups {
+			req.ResourceMetrics = append(req.ResourceMetrics, &metricspb.ResourceMetrics{
+				Resource:     g.resource,
+				ScopeMetrics: []*metricspb.ScopeMetrics{{Scope: o.scope, Metrics: g.metrics}},
+				SchemaUrl:    semconv.SchemaURL,
+			})
+		}
+		_, err = o.metricsClient.Export(ctx, req)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export %v: %w", o.signal, err)
+	}
+	return nil
+}
+
+func (o *otlpOutput) Close(ctx context.Context) error {
+	o.connMut.Lock()
+	defer o.connMut.Unlock()
+
+	if o.conn == nil {
+		return nil
+	}
+	err := o.conn.Close()
+	o.conn = nil
+	return err
+}
@@ -0,0 +1,279 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type mockLogsTracesCollector struct {
+	collogspb.UnimplementedLogsServiceServer
+
+	mut      sync.Mutex
+	logReqs  []*collogspb.ExportLogsServiceRequest
+	spanReqs []*coltracepb.ExportTraceServiceRequest
+	auth     []string
+}
+
+func (m *mockLogsTracesCollector) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	m.mut.Lock()
+	m.logReqs = append(m.logReqs, req)
+	m.auth = append(m.auth, md.Get("authorization")...)
+	m.mut.Unlock()
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+type mockTraceService struct {
+	coltracepb.UnimplementedTraceServiceServer
+	m *mockLogsTracesCollector
+}
+
+func (s mockTraceService) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	s.m.mut.Lock()
+	s.m.spanReqs = append(s.m.spanReqs, req)
+	s.m.mut.Unlock()
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func startMockLogsTracesCollector(t *testing.T) (*mockLogsTracesCollector, string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	mock := &mockLogsTracesCollector{}
+	srv := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(srv, mock)
+	coltracepb.RegisterTraceServiceServer(srv, mockTraceService{m: mock})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	return mock, lis.Addr().String()
+}
+
+func testOtlpOutput(t *testing.T, confStr string) *otlpOutput {
+	t.Helper()
+
+	conf, err := otlpOutputSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	o, err := newOtlpOutputFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+	o.nowFn = func() time.Time {
+		return time.Unix(10, 0)
+	}
+
+	require.NoError(t, o.Connect(context.Background()))
+	t.Cleanup(func() {
+		require.NoError(t, o.Close(context.Background()))
+	})
+	return o
+}
+
+func TestOtlpOutputLogs(t *testing.T) {
+	mock, addr := startMockLogsTracesCollector(t)
+
+	o := testOtlpOutput(t, fmt.Sprintf(`
+url: %v
+headers:
+  authorization: Bearer foo
+resource_attributes:
+  service.name: legacy
+`, addr))
+
+	require.NoError(t, o.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"body":"hello world","severity_text":"warning","timestamp":"1970-01-01T00:00:05Z","attributes":{"a":1,"b":[true,"c"]},"trace_id":"5b8efff798038103d269b633813fc60c","span_id":"eee19b7ec3c1b174"}`)),
+		service.NewMessage([]byte(`{"body":{"structured":1.5},"severity_number":17,"timestamp":3000000000}`)),
+	}))
+
+	mock.mut.Lock()
+	defer mock.mut.Unlock()
+
+	require.Len(t, mock.logReqs, 1)
+	assert.Equal(t, []string{"Bearer foo"}, mock.auth)
+
+	rl := mock.logReqs[0].ResourceLogs[0]
+	assert.Equal(t, "service.name", rl.Resource.Attributes[0].Key)
+	assert.Equal(t, "legacy", rl.Resource.Attributes[0].Value.GetStringValue())
+
+	recs := rl.ScopeLogs[0].LogRecords
+	require.Len(t, recs, 2)
+
+	assert.Equal(t, "hello world", recs[0].Body.GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_WARN, recs[0].SeverityNumber)
+	assert.Equal(t, "warning", recs[0].SeverityText)
+	assert.Equal(t, uint64(5e9), recs[0].TimeUnixNano)
+	assert.Equal(t, uint64(10e9), recs[0].ObservedTimeUnixNano)
+	assert.Len(t, recs[0].TraceId, 16)
+	assert.Len(t, recs[0].SpanId, 8)
+	require.Len(t, recs[0].Attributes, 2)
+	assert.Equal(t, "a", recs[0].Attributes[0].Key)
+	assert.Equal(t, int64(1), recs[0].Attributes[0].Value.GetIntValue())
+	assert.Equal(t, "b", recs[0].Attributes[1].Key)
+	arr := recs[0].Attributes[1].Value.GetArrayValue().Values
+	require.Len(t, arr, 2)
+	assert.True(t, arr[0].GetBoolValue())
+	assert.Equal(t, "c", arr[1].GetStringValue())
+
+	kvs := recs[1].Body.GetKvlistValue().Values
+	require.Len(t, kvs, 1)
+	assert.Equal(t, 1.5, kvs[0].Value.GetDoubleValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, recs[1].SeverityNumber)
+	assert.Equal(t, uint64(3e9), recs[1].TimeUnixNano)
+}
+
+func TestOtlpOutputTraces(t *testing.T) {
+	mock, addr := startMockLogsTracesCollector(t)
+
+	o := testOtlpOutput(t, fmt.Sprintf(`
+url: %v
+signal: traces
+`, addr))
+
+	require.NoError(t, o.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"trace_id":"5b8efff798038103d269b633813fc60c","span_id":"eee19b7ec3c1b174","parent_span_id":"eee19b7ec3c1b173","name":"GET /","kind":"server","start_time":"1970-01-01T00:00:01Z","end_time":"1970-01-01T00:00:02Z","status":{"code":"error","message":"nope"}}`)),
+	}))
+
+	mock.mut.Lock()
+	defer mock.mut.Unlock()
+
+	require.Len(t, mock.spanReqs, 1)
+	spans := mock.spanReqs[0].ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 1)
+
+	assert.Equal(t, "GET /", spans[0].Name)
+	assert.Equal(t, tracepb.Span_SPAN_KIND_SERVER, spans[0].Kind)
+	assert.Equal(t, uint64(1e9), spans[0].StartTimeUnixNano)
+	assert.Equal(t, uint64(2e9), spans[0].EndTimeUnixNano)
+	assert.Len(t, spans[0].ParentSpanId, 8)
+	assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, spans[0].Status.Code)
+	assert.Equal(t, "nope", spans[0].Status.Message)
+}
+
+func TestOtlpOutputMetrics(t *testing.T) {
+	mock, addr := startMockCollector(t)
+
+	o := testOtlpOutput(t, fmt.Sprintf(`
+url: %v
+signal: metrics
+`, addr))
+
+	require.NoError(t, o.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"requests","type":"sum","value":10,"attributes":{"method":"GET"}}`)),
+		service.NewMessage([]byte(`{"name":"temperature","unit":"Cel","value":21.5}`)),
+	}))
+
+	reqs := mock.pop()
+	require.Len(t, reqs, 1)
+
+	metrics := metricsByName(reqs[0])
+	require.Len(t, metrics, 2)
+
+	sum := metrics["requests"].GetSum()
+	require.NotNil(t, sum)
+	assert.True(t, sum.IsMonotonic)
+	assert.Equal(t, int64(10), sum.DataPoints[0].GetAsInt())
+	assert.Equal(t, uint64(10e9), sum.DataPoints[0].TimeUnixNano)
+	assert.Equal(t, "GET", sum.DataPoints[0].Attributes[0].Value.GetStringValue())
+
+	gauge := metrics["temperature"].GetGauge()
+	require.NotNil(t, gauge)
+	assert.Equal(t, "Cel", metrics["temperature"].Unit)
+	assert.Equal(t, 21.5, gauge.DataPoints[0].GetAsDouble())
+}
+
+func TestOtlpOutputBadMessages(t *testing.T) {
+	mock, addr := startMockLogsTracesCollector(t)
+
+	o := testOtlpOutput(t, fmt.Sprintf(`
+url: %v
+signal: traces
+`, addr))
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`{"trace_id":"5b8efff798038103d269b633813fc60c","span_id":"eee19b7ec3c1b174","name":"foo"}`)),
+		service.NewMessage([]byte(`{"trace_id":"5b8e","span_id":"eee19b7ec3c1b174","name":"foo"}`)),
+		service.NewMessage([]byte(`{"trace_id":"5b8efff798038103d269b633813fc60c","span_id":"eee19b7ec3c1b174"}`)),
+		service.NewMessage([]byte(`{"trace_id":"5b8efff798038103d269b633813fc60c","span_id":"eee19b7ec3c1b174","name":"foo","kind":"nope"}`)),
+		service.NewMessage([]byte(`not structured`)),
+	}
+	indexer := batch.Index()
+
+	err := o.WriteBatch(context.Background(), batch)
+	require.Error(t, err)
+
+	var batchErr *service.BatchError
+	require.ErrorAs(t, err, &batchErr)
+
+	var failed []int
+	batchErr.WalkMessagesIndexedBy(indexer, func(i int, _ *service.Message, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1, 2, 3, 4}, failed)
+
+	mock.mut.Lock()
+	assert.Empty(t, mock.spanReqs)
+	mock.mut.Unlock()
+}
+
+func TestOtlpOutputResources(t *testing.T) {
+	mock, addr := startMockLogsTracesCollector(t)
+
+	o := testOtlpOutput(t, fmt.Sprintf(`
+url: %v
+resource_attributes:
+  deployment.environment: prod
+`, addr))
+
+	require.NoError(t, o.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"body":"a","resource":{"service.name":"foo","process.pid":10}}`)),
+		service.NewMessage([]byte(`{"body":"b"}`)),
+		service.NewMessage([]byte(`{"body":"c","resource":{"process.pid":10,"service.name":"foo"}}`)),
+	}))
+
+	mock.mut.Lock()
+	defer mock.mut.Unlock()
+
+	require.Len(t, mock.logReqs, 1)
+	rls := mock.logReqs[0].ResourceLogs
+	require.Len(t, rls, 2)
+
+	attrs := map[string]any{}
+	for _, kv := range rls[0].Resource.Attributes {
+		attrs[kv.Key] = kv.Value.Value
+	}
+	assert.Equal(t, "prod", attrs["deployment.environment"].(*commonpb.AnyValue_StringValue).StringValue)
+	assert.Equal(t, "foo", attrs["service.name"].(*commonpb.AnyValue_StringValue).StringValue)
+	assert.Equal(t, int64(10), attrs["process.pid"].(*commonpb.AnyValue_IntValue).IntValue)
+
+	var bodies []string
+	for _, rec := range rls[0].ScopeLogs[0].LogRecords {
+		bodies = append(bodies, rec.Body.GetStringValue())
+	}
+	assert.Equal(t, []string{"a", "c"}, bodies)
+
+	require.Len(t, rls[1].ScopeLogs[0].LogRecords, 1)
+	assert.Equal(t, "benthos", rls[1].Resource.Attributes[1].Value.GetStringValue())
+}
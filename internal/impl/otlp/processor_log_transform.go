@@ -0,0 +1,587 @@
+package otlp
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	oltFieldPreset            = "preset"
+	oltFieldTimestampFields   = "timestamp_fields"
+	oltFieldSeverityFields    = "severity_fields"
+	oltFieldBodyFields        = "body_fields"
+	oltFieldTraceIDFields     = "trace_id_fields"
+	oltFieldSpanIDFields      = "span_id_fields"
+	oltFieldResourceFields    = "resource_fields"
+	oltFieldFlattenAttributes = "flatten_attributes"
+)
+
+// logPreset describes where the fields of the OpenTelemetry log data model are
+// found within the logs of a particular format.
+type logPreset struct {
+	timestamp   []string
+	severity    []string
+	body        []string
+	traceID     []string
+	spanID      []string
+	traceParent []string
+
+	// Maps the paths of fields to the resource attributes they're moved to.
+	resource map[string]string
+
+	// Maps the paths of fields to the attributes they're renamed to.
+	attributes map[string]string
+
+	// Maps numeric severity levels to their names.
+	levels map[int64]string
+}
+
+var pinoLogPreset = logPreset{
+	timestamp: []string{"time"},
+	severity:  []string{"level"},
+	body:      []string{"msg"},
+	traceID:   []string{"trace_id", "traceId"},
+	spanID:    []string{"span_id", "spanId"},
+	resource: map[string]string{
+		"name":     "service.name",
+		"hostname": "host.name",
+		"pid":      "process.pid",
+	},
+	attributes: map[string]string{
+		"err.type":    "exception.type",
+		"err.message": "exception.message",
+		"err.stack":   "exception.stacktrace",
+	},
+	levels: map[int64]string{
+		10: "TRACE",
+		20: "DEBUG",
+		30: "INFO",
+		40: "WARN",
+		50: "ERROR",
+		60: "FATAL",
+	},
+}
+
+var logPresets = map[string]logPreset{
+	"generic": {
+		timestamp:   []string{"timestamp", "time", "ts", "@timestamp", "date", "datetime"},
+		severity:    []string{"level", "severity", "log.level", "loglevel", "log_level", "lvl"},
+		body:        []string{"message", "msg", "log", "text"},
+		traceID:     []string{"trace_id", "traceId", "traceid", "trace.id"},
+		spanID:      []string{"span_id", "spanId", "spanid", "span.id"},
+		traceParent: []string{"traceparent"},
+		resource: map[string]string{
+			"service":                "service.name",
+			"service_name":           "service.name",
+			"service.name":           "service.name",
+			"service.version":        "service.version",
+			"service.namespace":      "service.namespace",
+			"env":                    "deployment.environment",
+			"environment":            "deployment.environment",
+			"deployment.environment": "deployment.environment",
+			"host":                   "host.name",
+			"hostname":               "host.name",
+			"host.name":              "host.name",
+			"container.id":           "container.id",
+			"k8s.namespace.name":     "k8s.namespace.name",
+			"k8s.pod.name":           "k8s.pod.name",
+		},
+	},
+	"ecs": {
+		timestamp: []string{"@timestamp"},
+		severity:  []string{"log.level"},
+		body:      []string{"message"},
+		traceID:   []string{"trace.id"},
+		spanID:    []string{"span.id"},
+		resource: map[string]string{
+			"service.name":        "service.name",
+			"service.version":     "service.version",
+			"service.environment": "deployment.environment",
+			"host.name":           "host.name",
+			"host.hostname":       "host.name",
+			"container.id":        "container.id",
+			"cloud.provider":      "cloud.provider",
+			"cloud.region":        "cloud.region",
+			"process.pid":         "process.pid",
+		},
+		attributes: map[string]string{
+			"log.logger":        "logger.name",
+			"error.type":        "exception.type",
+			"error.message":     "exception.message",
+			"error.stack_trace": "exception.stacktrace",
+		},
+	},
+	"pino":   pinoLogPreset,
+	"bunyan": pinoLogPreset,
+	"zap": {
+		timestamp: []string{"ts"},
+		severity:  []string{"level"},
+		body:      []string{"msg"},
+		traceID:   []string{"trace_id", "traceId"},
+		spanID:    []string{"span_id", "spanId"},
+		attributes: map[string]string{
+			"logger":     "logger.name",
+			"stacktrace": "exception.stacktrace",
+		},
+	},
+	"logrus": {
+		timestamp: []string{"time"},
+		severity:  []string{"level"},
+		body:      []string{"msg"},
+		traceID:   []string{"trace_id", "traceId"},
+		spanID:    []string{"span_id", "spanId"},
+		attributes: map[string]string{
+			"error": "exception.message",
+		},
+	},
+}
+
+func logTransformSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Parsing", "Utility").
+		Version("4.20.0").
+		Summary("Normalises structured logs into the [OpenTelemetry log data model](https://opentelemetry.io/docs/specs/otel/logs/data-model/), in the format accepted by the `open_telemetry_collector` output.").
+		Description(`
+Each message is converted into an object of the form:
+
+`+"```json"+`
+{
+  "timestamp": "2023-01-01T00:00:00Z",
+  "severity_text": "WARN",
+  "severity_number": 13,
+  "body": "disk almost full",
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174",
+  "attributes": { "disk.free": 1024 },
+  "resource": { "service.name": "storage", "host.name": "foo" }
+}
+`+"```"+`
+
+Where fields that cannot be found are omitted. The fields of the original log that are used to populate each part of the data model are determined by the `+"`preset`"+`, and the fields of each part can be overridden individually. Field paths are dot separated, and match either a key containing dots or the equivalent nested fields, such that `+"`log.level`"+` matches both `+"`{\"log.level\":\"info\"}`"+` and `+"`{\"log\":{\"level\":\"info\"}}`"+`. Where multiple fields are listed the first one found is used.
+
+All fields of the original log that are not moved into another part of the data model are added as attributes, and when `+"`flatten_attributes`"+` is enabled nested objects are flattened into attributes with dot separated keys in accordance with semantic conventions. Some presets also rename attributes to their semantic convention equivalents, such as the error fields of ECS logs into `+"`exception.*`"+` attributes.
+
+Messages that are not structured are converted into log records with the raw contents of the message as the body.
+
+### Severity
+
+Severity levels are preserved as the `+"`severity_text`"+` and mapped onto a `+"`severity_number`"+` when they are a recognised level name such as `+"`warning`"+` or `+"`ERR`"+`. The numeric levels of pino and bunyan are converted into their names, and other numeric levels between 1 and 24 are assumed to already be OpenTelemetry severity numbers.
+
+### Timestamps
+
+Timestamps are converted into RFC 3339 strings in UTC. Numeric timestamps are interpreted as seconds, milliseconds, microseconds or nanoseconds since the unix epoch depending on their magnitude, and string timestamps may be RFC 3339 or common variants without a timezone, which are assumed to be UTC. Timestamps that cannot be parsed are left as attributes.
+
+### Trace Context
+
+Trace and span IDs must be hexadecimal, where 64-bit trace IDs are padded to 128 bits. When a W3C `+"`traceparent`"+` field is found (generic preset only) the trace and span IDs are extracted from it if not otherwise found.`).
+		Fields(
+			service.NewStringAnnotatedEnumField(oltFieldPreset, map[string]string{
+				"generic": "Common field names used by a wide variety of structured loggers.",
+				"ecs":     "The [Elastic Common Schema](https://www.elastic.co/guide/en/ecs/current/index.html).",
+				"pino":    "Logs of the [pino](https://getpino.io) Node.js logger.",
+				"bunyan":  "Logs of the [bunyan](https://github.com/trentm/node-bunyan) Node.js logger.",
+				"zap":     "Logs of the [zap](https://github.com/uber-go/zap) Go logger with its production config.",
+				"logrus":  "Logs of the [logrus](https://github.com/sirupsen/logrus) Go logger with its JSON formatter.",
+			}).
+				Description("A preset describing the names of the fields of logs.").
+				Default("generic"),
+			service.NewStringListField(oltFieldTimestampFields).
+				Description("Overrides the fields of the preset that contain the timestamp.").
+				Default([]any{}).
+				Advanced(),
+			service.NewStringListField(oltFieldSeverityFields).
+				Description("Overrides the fields of the preset that contain the severity level.").
+				Default([]any{}).
+				Advanced(),
+			service.NewStringListField(oltFieldBodyFields).
+				Description("Overrides the fields of the preset that contain the body.").
+				Default([]any{}).
+				Advanced(),
+			service.NewStringListField(oltFieldTraceIDFields).
+				Description("Overrides the fields of the preset that contain the trace ID.").
+				Default([]any{}).
+				Advanced(),
+			service.NewStringListField(oltFieldSpanIDFields).
+				Description("Overrides the fields of the preset that contain the span ID.").
+				Default([]any{}).
+				Advanced(),
+			service.NewStringMapField(oltFieldResourceFields).
+				Description("A map of field paths to the resource attributes that they are moved into, which are added to those of the preset.").
+				Default(map[string]any{}).
+				Example(map[string]any{"app": "service.name", "pod": "k8s.pod.name"}),
+			service.NewBoolField(oltFieldFlattenAttributes).
+				Description("Whether to flatten nested objects within attributes into dot separated keys.").
+				Default(true).
+				Advanced(),
+		).
+		Example(
+			"Legacy Logs to OpenTelemetry",
+			"In this example the JSON logs of a legacy application are normalised and exported to an OpenTelemetry collector.",
+			`
+input:
+  file:
+    paths: [ /var/log/app/*.log ]
+    codec: lines
+  processors:
+    - otel_log_transform:
+        resource_fields:
+          app: service.name
+
+output:
+  open_telemetry_collector:
+    url: otel-collector:4317
+    signal: logs
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor("otel_log_transform", logTransformSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newLogTransformFromParsed(conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type logTransform struct {
+	preset  logPreset
+	flatten bool
+}
+
+func newLogTransformFromParsed(conf *service.ParsedConfig) (*logTransform, error) {
+	presetStr, err := conf.FieldString(oltFieldPreset)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &logTransform{preset: logPresets[presetStr]}
+	for _, o := range []struct {
+		field  string
+		target *[]string
+	}{
+		{oltFieldTimestampFields, &t.preset.timestamp},
+		{oltFieldSeverityFields, &t.preset.severity},
+		{oltFieldBodyFields, &t.preset.body},
+		{oltFieldTraceIDFields, &t.preset.traceID},
+		{oltFieldSpanIDFields, &t.preset.spanID},
+	} {
+		paths, err := conf.FieldStringList(o.field)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) > 0 {
+			*o.target = paths
+		}
+	}
+
+	resourceFields, err := conf.FieldStringMap(oltFieldResourceFields)
+	if err != nil {
+		return nil, err
+	}
+	resource := make(map[string]string, len(t.preset.resource)+len(resourceFields))
+	for k, v := range t.preset.resource {
+		resource[k] = v
+	}
+	for k, v := range resourceFields {
+		resource[k] = v
+	}
+	t.preset.resource = resource
+
+	if t.flatten, err = conf.FieldBool(oltFieldFlattenAttributes); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// lookupPath finds the value of a dot separated path within an object, where
+// each segment of the path may match either a key containing dots or nested
+// objects. When remove is true the value is deleted along with any parent
+// objects that become empty.
+func lookupPath(obj map[string]any, path string, remove bool) (any, bool) {
+	if v, exists := obj[path]; exists {
+		if remove {
+			delete(obj, path)
+		}
+		return v, true
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		child, ok := obj[path[:i]].(map[string]any)
+		if !ok {
+			continue
+		}
+		if v, exists := lookupPath(child, path[i+1:], remove); exists {
+			if remove && len(child) == 0 {
+				delete(obj, path[:i])
+			}
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func asInt64(v any) (int64, bool) {
+	switch t := v.(type) {
+	case json.Number:
+		i, err := t.Int64()
+		return i, err == nil
+	case int64:
+		return t, true
+	case float64:
+		if t == math.Trunc(t) {
+			return int64(t), true
+		}
+	}
+	return 0, false
+}
+
+func asFloat64(v any) (float64, bool) {
+	switch t := v.(type) {
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case int64:
+		return float64(t), true
+	case float64:
+		return t, true
+	}
+	return 0, false
+}
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+func parseLogTimestamp(v any) (time.Time, bool) {
+	if s, ok := v.(string); ok {
+		for _, layout := range timestampLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.UTC(), true
+			}
+		}
+		if _, err := json.Number(s).Float64(); err != nil {
+			return time.Time{}, false
+		}
+		v = json.Number(s)
+	}
+
+	f, ok := asFloat64(v)
+	if !ok || f <= 0 {
+		return time.Time{}, false
+	}
+	switch {
+	case f < 1e11:
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+	case f < 1e14:
+		return time.UnixMicro(int64(f * 1e3)).UTC(), true
+	case f < 1e17:
+		return time.UnixMicro(int64(f)).UTC(), true
+	}
+	if i, ok := asInt64(v); ok {
+		return time.Unix(0, i).UTC(), true
+	}
+	return time.Unix(0, int64(f)).UTC(), true
+}
+
+// parseHexID returns a normalised hex ID of the given size in bytes, where
+// 64-bit trace IDs are padded to 128 bits.
+func parseHexID(v any, size int) (string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	s = strings.ToLower(s)
+	if size == 16 && len(s) == 16 {
+		s = strings.Repeat("0", 16) + s
+	}
+	if len(s) != size*2 || strings.Trim(s, "0") == "" {
+		return "", false
+	}
+	if _, err := hex.DecodeString(s); err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+var traceParentRegexp = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+func (t *logTransform) extractSeverity(rec, obj map[string]any) {
+	for _, path := range t.preset.severity {
+		v, exists := lookupPath(obj, path, false)
+		if !exists {
+			continue
+		}
+		if s, ok := v.(string); ok && s != "" {
+			rec["severity_text"] = s
+			if n, exists := severityNumbers[strings.ToUpper(s)]; exists {
+				rec["severity_number"] = int64(n)
+			}
+		} else if i, ok := asInt64(v); ok {
+			if name, exists := t.preset.levels[i]; exists {
+				rec["severity_text"] = name
+				rec["severity_number"] = int64(severityNumbers[name])
+			} else if i >= 1 && i <= 24 {
+				rec["severity_number"] = i
+			} else {
+				continue
+			}
+		} else {
+			continue
+		}
+		_, _ = lookupPath(obj, path, true)
+		return
+	}
+}
+
+func (t *logTransform) extractTimestamp(rec, obj map[string]any) {
+	for _, path := range t.preset.timestamp {
+		v, exists := lookupPath(obj, path, false)
+		if !exists {
+			continue
+		}
+		if ts, ok := parseLogTimestamp(v); ok {
+			rec["timestamp"] = ts.Format(time.RFC3339Nano)
+			_, _ = lookupPath(obj, path, true)
+			return
+		}
+	}
+}
+
+func (t *logTransform) extractID(rec, obj map[string]any, key string, paths []string, size int) {
+	for _, path := range paths {
+		v, exists := lookupPath(obj, path, false)
+		if !exists {
+			continue
+		}
+		if id, ok := parseHexID(v, size); ok {
+			rec[key] = id
+			_, _ = lookupPath(obj, path, true)
+			return
+		}
+	}
+}
+
+func (t *logTransform) extractTraceParent(rec, obj map[string]any) {
+	if _, exists := rec["trace_id"]; exists {
+		return
+	}
+	for _, path := range t.preset.traceParent {
+		v, exists := lookupPath(obj, path, false)
+		if !exists {
+			continue
+		}
+		s, _ := v.(string)
+		if matches := traceParentRegexp.FindStringSubmatch(s); matches != nil {
+			rec["trace_id"] = matches[1]
+			if _, exists := rec["span_id"]; !exists {
+				rec["span_id"] = matches[2]
+			}
+			_, _ = lookupPath(obj, path, true)
+			return
+		}
+	}
+}
+
+func flattenAttributes(prefix string, obj, into map[string]any) {
+	for k, v := range obj {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		if child, ok := v.(map[string]any); ok && len(child) > 0 {
+			flattenAttributes(k, child, into)
+			continue
+		}
+		into[k] = v
+	}
+}
+
+func (t *logTransform) transform(obj map[string]any) map[string]any {
+	rec := map[string]any{}
+
+	t.extractTimestamp(rec, obj)
+	t.extractSeverity(rec, obj)
+	t.extractID(rec, obj, "trace_id", t.preset.traceID, 16)
+	t.extractID(rec, obj, "span_id", t.preset.spanID, 8)
+	t.extractTraceParent(rec, obj)
+
+	for _, path := range t.preset.body {
+		if v, exists := lookupPath(obj, path, true); exists {
+			rec["body"] = v
+			break
+		}
+	}
+
+	resource := map[string]any{}
+	for path, key := range t.preset.resource {
+		if v, exists := lookupPath(obj, path, true); exists {
+			resource[key] = v
+		}
+	}
+	if len(resource) > 0 {
+		rec["resource"] = resource
+	}
+
+	renamed := map[string]any{}
+	for path, key := range t.preset.attributes {
+		if v, exists := lookupPath(obj, path, true); exists {
+			renamed[key] = v
+		}
+	}
+
+	attrs := obj
+	if t.flatten {
+		attrs = map[string]any{}
+		flattenAttributes("", obj, attrs)
+	}
+	for k, v := range renamed {
+		attrs[k] = v
+	}
+	if len(attrs) > 0 {
+		rec["attributes"] = attrs
+	}
+	return rec
+}
+
+func (t *logTransform) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	var rec map[string]any
+	if v, err := msg.AsStructuredMut(); err == nil {
+		if obj, ok := v.(map[string]any); ok {
+			rec = t.transform(obj)
+		}
+	}
+	if rec == nil {
+		b, err := msg.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		rec = map[string]any{"body": string(b)}
+	}
+
+	msg.SetStructuredMut(rec)
+	return service.MessageBatch{msg}, nil
+}
+
+func (t *logTransform) Close(ctx context.Context) error {
+	return nil
+}
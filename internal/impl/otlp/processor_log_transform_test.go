@@ -0,0 +1,133 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestLogTransform(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		input  string
+		output string
+	}{
+		{
+			name:   "generic",
+			config: `{}`,
+			input:  `{"time":"2023-01-01 10:00:00.5","level":"warning","msg":"disk almost full","service":"storage","host":"foo","trace_id":"5B8EFFF798038103D269B633813FC60C","spanId":"eee19b7ec3c1b174","disk":{"free":1024,"path":"/"},"tags":["a"]}`,
+			output: `{
+  "timestamp": "2023-01-01T10:00:00.5Z",
+  "severity_text": "warning",
+  "severity_number": 13,
+  "body": "disk almost full",
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174",
+  "attributes": {"disk.free": 1024, "disk.path": "/", "tags": ["a"]},
+  "resource": {"service.name": "storage", "host.name": "foo"}
+}`,
+		},
+		{
+			name:   "generic traceparent and unparseable fields",
+			config: `{}`,
+			input:  `{"timestamp":"yesterday","message":{"structured":true},"traceparent":"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01","trace_id":"nope"}`,
+			output: `{
+  "body": {"structured": true},
+  "trace_id": "0af7651916cd43dd8448eb211c80319c",
+  "span_id": "b7ad6b7169203331",
+  "attributes": {"timestamp": "yesterday", "trace_id": "nope"}
+}`,
+		},
+		{
+			name:   "ecs",
+			config: `preset: ecs`,
+			input:  `{"@timestamp":"2023-01-01T10:00:00Z","log":{"level":"ERROR","logger":"app.db"},"message":"query failed","service":{"name":"api","environment":"prod"},"error":{"message":"timeout","type":"TimeoutError"},"trace":{"id":"5b8efff798038103d269b633813fc60c"}}`,
+			output: `{
+  "timestamp": "2023-01-01T10:00:00Z",
+  "severity_text": "ERROR",
+  "severity_number": 17,
+  "body": "query failed",
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "attributes": {"logger.name": "app.db", "exception.message": "timeout", "exception.type": "TimeoutError"},
+  "resource": {"service.name": "api", "deployment.environment": "prod"}
+}`,
+		},
+		{
+			name:   "pino",
+			config: `preset: pino`,
+			input:  `{"level":30,"time":1672567200123,"pid":42,"hostname":"foo","name":"web","msg":"request completed","req":{"id":1}}`,
+			output: `{
+  "timestamp": "2023-01-01T10:00:00.123Z",
+  "severity_text": "INFO",
+  "severity_number": 9,
+  "body": "request completed",
+  "attributes": {"req.id": 1},
+  "resource": {"process.pid": 42, "host.name": "foo", "service.name": "web"}
+}`,
+		},
+		{
+			name:   "zap",
+			config: `preset: zap`,
+			input:  `{"level":"info","ts":1672567200.5,"logger":"main","caller":"main.go:10","msg":"started"}`,
+			output: `{
+  "timestamp": "2023-01-01T10:00:00.5Z",
+  "severity_text": "info",
+  "severity_number": 9,
+  "body": "started",
+  "attributes": {"logger.name": "main", "caller": "main.go:10"}
+}`,
+		},
+		{
+			name: "overrides without flattening",
+			config: `
+body_fields: [ event.text ]
+severity_fields: [ sev ]
+resource_fields:
+  app: service.name
+flatten_attributes: false
+`,
+			input: `{"event":{"text":"hello","id":5},"sev":21,"app":"foo","message":"not the body"}`,
+			output: `{
+  "severity_number": 21,
+  "body": "hello",
+  "attributes": {"event": {"id": 5}, "message": "not the body"},
+  "resource": {"service.name": "foo"}
+}`,
+		},
+		{
+			name:   "unstructured",
+			config: `{}`,
+			input:  `plain text log`,
+			output: `{"body": "plain text log"}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := logTransformSpec().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			proc, err := newLogTransformFromParsed(conf)
+			require.NoError(t, err)
+
+			batch, err := proc.Process(context.Background(), service.NewMessage([]byte(test.input)))
+			require.NoError(t, err)
+			require.Len(t, batch, 1)
+
+			b, err := batch[0].AsBytes()
+			require.NoError(t, err)
+
+			var expected, actual any
+			require.NoError(t, json.Unmarshal([]byte(test.output), &expected))
+			require.NoError(t, json.Unmarshal(b, &actual))
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
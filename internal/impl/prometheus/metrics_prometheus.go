@@ -38,10 +38,21 @@ The Push Gateway is useful for when Benthos instances are short lived. Do not
 include the "/metrics/jobs/..." path in the push URL.
 
 If the Push Gateway requires HTTP Basic Authentication it can be configured with
-` + "`push_basic_auth`.",
+` + "`push_basic_auth`." + `
+
+## Exemplars
+
+When ` + "`use_histogram_timing` and `add_exemplars`" + ` are both ` + "`true`" + ` latency histograms such as ` + "`input_latency_ns`, `processor_latency_ns` and `output_latency_ns`" + ` are annotated with exemplars containing the ` + "`trace_id`" + ` of a sampled message that was observed, allowing dashboards to link from a latency spike directly to a trace of an offending message. Exemplars are only exposed when metrics are scraped in the OpenMetrics format, which must be enabled within Prometheus with the flag ` + "`--enable-feature=exemplar-storage`" + `.
+
+## Native Histograms
+
+Setting ` + "`native_histogram_bucket_factor`" + ` to a value greater than one exports histogram timings as [native histograms](https://prometheus.io/docs/concepts/metric_types/#histogram) in addition to the classic buckets defined by ` + "`histogram_buckets`" + `. Native histograms are only exposed when metrics are scraped in the protobuf format, which must be enabled within Prometheus with the flag ` + "`--enable-feature=native-histograms`" + `.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldBool("use_histogram_timing", "Whether to export timing metrics as a histogram, if `false` a summary is used instead. When exporting histogram timings the delta values are converted from nanoseconds into seconds in order to better fit within bucket definitions. For more information on histograms and summaries refer to: https://prometheus.io/docs/practices/histograms/.").HasDefault(false).Advanced().AtVersion("3.63.0"),
 			docs.FieldFloat("histogram_buckets", "Timing metrics histogram buckets (in seconds). If left empty defaults to DefBuckets (https://pkg.go.dev/github.com/prometheus/client_golang/prometheus#pkg-variables)").Array().HasDefault([]any{}).Advanced().AtVersion("3.63.0"),
+			docs.FieldFloat("native_histogram_bucket_factor", "When greater than one histogram timings are also exported as [native histograms](#native-histograms), where the value determines the growth factor between the boundaries of consecutive buckets. A value of 1.1 results in buckets that are at most 10% wider than the previous. Requires `use_histogram_timing` to be `true`.").HasDefault(0).Advanced().AtVersion("4.20.0"),
+			docs.FieldInt("native_histogram_max_buckets", "The maximum number of buckets of a native histogram, once exceeded the resolution of the histogram is reduced. Set to zero in order to disable the limit.").HasDefault(160).Advanced().AtVersion("4.20.0"),
+			docs.FieldBool("add_exemplars", "Whether to annotate histogram timings with [exemplars](#exemplars) containing the trace ID of sampled messages. Requires `use_histogram_timing` to be `true`.").HasDefault(false).Advanced().AtVersion("4.20.0"),
 			docs.FieldBool("add_process_metrics", "Whether to export process metrics such as CPU and memory usage in addition to Benthos metrics.").Advanced().HasDefault(false),
 			docs.FieldBool("add_go_metrics", "Whether to export Go runtime metrics such as GC pauses in addition to Benthos metrics.").Advanced().HasDefault(false),
 			docs.FieldURL("push_url", "An optional [Push Gateway URL](#push-gateway) to push metrics to.").Advanced().HasDefault(""),
@@ -85,14 +96,27 @@ func (p *promCounter) Incr(count int64) {
 type promTiming struct {
 	sum       prometheus.Observer
 	asSeconds bool
+	exemplars bool
 }
 
-func (p *promTiming) Timing(val int64) {
+func (p *promTiming) value(val int64) float64 {
 	vFloat := float64(val)
 	if p.asSeconds {
 		vFloat /= 1_000_000_000
 	}
-	p.sum.Observe(vFloat)
+	return vFloat
+}
+
+func (p *promTiming) Timing(val int64) {
+	p.sum.Observe(p.value(val))
+}
+
+func (p *promTiming) TimingWithExemplar(val int64, exemplar map[string]string) {
+	if eo, ok := p.sum.(prometheus.ExemplarObserver); ok && p.exemplars {
+		eo.ObserveWithExemplar(p.value(val), exemplar)
+		return
+	}
+	p.sum.Observe(p.value(val))
 }
 
 //------------------------------------------------------------------------------
@@ -120,13 +144,15 @@ func (p *promTimingVec) With(labelValues ...string) metrics.StatTimer {
 }
 
 type promTimingHistVec struct {
-	sum   *prometheus.HistogramVec
-	count int
+	sum       *prometheus.HistogramVec
+	count     int
+	exemplars bool
 }
 
 func (p *promTimingHistVec) With(labelValues ...string) metrics.StatTimer {
 	return &promTiming{
 		asSeconds: true,
+		exemplars: p.exemplars,
 		sum:       p.sum.WithLabelValues(labelValues...),
 	}
 }
@@ -151,8 +177,11 @@ type prometheusMetrics struct {
 
 	fileOutputPath string
 
-	useHistogramTiming bool
-	histogramBuckets   []float64
+	useHistogramTiming          bool
+	histogramBuckets            []float64
+	nativeHistogramBucketFactor float64
+	nativeHistogramMaxBuckets   uint32
+	addExemplars                bool
 
 	pusher *push.Pusher
 	reg    *prometheus.Registry
@@ -167,17 +196,27 @@ type prometheusMetrics struct {
 
 func newPrometheus(config metrics.Config, nm bundle.NewManagement) (metrics.Type, error) {
 	promConf := config.Prometheus
+	if promConf.NativeHistogramBucketFactor != 0 && promConf.NativeHistogramBucketFactor <= 1 {
+		return nil, fmt.Errorf("native_histogram_bucket_factor must be greater than 1, got %v", promConf.NativeHistogramBucketFactor)
+	}
+	if promConf.NativeHistogramMaxBuckets < 0 {
+		return nil, fmt.Errorf("native_histogram_max_buckets must not be negative, got %v", promConf.NativeHistogramMaxBuckets)
+	}
+
 	p := &prometheusMetrics{
-		log:                nm.Logger(),
-		running:            1,
-		closedChan:         make(chan struct{}),
-		useHistogramTiming: promConf.UseHistogramTiming,
-		histogramBuckets:   promConf.HistogramBuckets,
-		reg:                prometheus.NewRegistry(),
-		counters:           map[string]*promCounterVec{},
-		gauges:             map[string]*promGaugeVec{},
-		timers:             map[string]*promTimingVec{},
-		timersHist:         map[string]*promTimingHistVec{},
+		log:                         nm.Logger(),
+		running:                     1,
+		closedChan:                  make(chan struct{}),
+		useHistogramTiming:          promConf.UseHistogramTiming,
+		histogramBuckets:            promConf.HistogramBuckets,
+		nativeHistogramBucketFactor: promConf.NativeHistogramBucketFactor,
+		nativeHistogramMaxBuckets:   uint32(promConf.NativeHistogramMaxBuckets),
+		addExemplars:                promConf.AddExemplars,
+		reg:                         prometheus.NewRegistry(),
+		counters:                    map[string]*promCounterVec{},
+		gauges:                      map[string]*promGaugeVec{},
+		timers:                      map[string]*promTimingVec{},
+		timersHist:                  map[string]*promTimingHistVec{},
 	}
 
 	if len(p.histogramBuckets) == 0 {
@@ -233,7 +272,10 @@ func newPrometheus(config metrics.Config, nm bundle.NewManagement) (metrics.Type
 
 func (p *prometheusMetrics) HandlerFunc() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		promhttp.HandlerFor(p.reg, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+		promhttp.HandlerFor(p.reg, promhttp.HandlerOpts{
+			// Exemplars are only exposed in the OpenMetrics format.
+			EnableOpenMetrics: p.addExemplars,
+		}).ServeHTTP(w, r)
 	}
 }
 
@@ -325,15 +367,18 @@ func (p *prometheusMetrics) getTimerHistVec(path string, labelNames ...string) m
 	var exists bool
 	if pv, exists = p.timersHist[path]; !exists {
 		tmr := prometheus.NewHistogramVec(prometheus.HistogramOpts{
-			Name:    path,
-			Help:    "Benthos Timing metric",
-			Buckets: p.histogramBuckets,
+			Name:                           path,
+			Help:                           "Benthos Timing metric",
+			Buckets:                        p.histogramBuckets,
+			NativeHistogramBucketFactor:    p.nativeHistogramBucketFactor,
+			NativeHistogramMaxBucketNumber: p.nativeHistogramMaxBuckets,
 		}, labelNames)
 		p.reg.MustRegister(tmr)
 
 		pv = &promTimingHistVec{
-			sum:   tmr,
-			count: len(labelNames),
+			sum:       tmr,
+			count:     len(labelNames),
+			exemplars: p.addExemplars,
 		}
 		p.timersHist[path] = pv
 	}
@@ -1,6 +1,7 @@
 package prometheus
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
@@ -167,6 +169,65 @@ func TestPrometheusHistMetrics(t *testing.T) {
 	assert.Contains(t, body, "\ntimertwo_sum{label3=\"value4\",label4=\"value5\"} 1.4e-08")
 }
 
+func TestPrometheusHistExemplars(t *testing.T) {
+	conf := metrics.NewConfig()
+	conf.Prometheus.UseHistogramTiming = true
+	conf.Prometheus.AddExemplars = true
+
+	nm, err := newPrometheus(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	tID, err := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	require.NoError(t, err)
+	sID, err := trace.SpanIDFromHex("0102030405060708")
+	require.NoError(t, err)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tID,
+		SpanID:     sID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	metrics.TimingWithContext(nm.GetTimer("timerone"), ctx, 13)
+	metrics.TimingWithContext(nm.GetTimer("timertwo"), context.Background(), 14)
+
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	w := httptest.NewRecorder()
+	nm.HandlerFunc()(w, req)
+
+	bodyBytes, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+	body := string(bodyBytes)
+
+	assert.Contains(t, body, `timerone_bucket{le="0.005"} 1 # {trace_id="0102030405060708090a0b0c0d0e0f10"} 1.3e-08`)
+	assert.Contains(t, body, `timertwo_bucket{le="0.005"} 1`+"\n")
+}
+
+func TestPrometheusNativeHistograms(t *testing.T) {
+	conf := metrics.NewConfig()
+	conf.Prometheus.UseHistogramTiming = true
+	conf.Prometheus.NativeHistogramBucketFactor = 1.1
+
+	nm, err := newPrometheus(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	nm.GetTimer("timerone").Timing(13)
+
+	families, err := nm.(*prometheusMetrics).reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+
+	hist := families[0].GetMetric()[0].GetHistogram()
+	assert.Equal(t, uint64(1), hist.GetSampleCount())
+	assert.Equal(t, int32(3), hist.GetSchema())
+	assert.NotEmpty(t, hist.GetPositiveSpan())
+
+	conf.Prometheus.NativeHistogramBucketFactor = 0.5
+	_, err = newPrometheus(conf, mock.NewManager())
+	require.EqualError(t, err, "native_histogram_bucket_factor must be greater than 1, got 0.5")
+}
+
 func TestPrometheusWithFileOutputPath(t *testing.T) {
 	config := metrics.NewConfig()
 	config.Prometheus.FileOutputPath = os.TempDir() + "/benthos_metrics.prom"
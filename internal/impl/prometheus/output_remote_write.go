@@ -0,0 +1,510 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/benthosdev/benthos/v4/internal/httpclient"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	prwFieldURL            = "url"
+	prwFieldHeaders        = "headers"
+	prwFieldExternalLabels = "external_labels"
+	prwFieldSanitize       = "sanitize_names"
+	prwFieldTimeout        = "timeout"
+	prwFieldTLS            = "tls"
+	prwFieldBatching       = "batching"
+)
+
+// staleNaN is the bit pattern of the NaN value that Prometheus uses in order
+// to mark a series as stale.
+const staleNaN uint64 = 0x7ff0000000000002
+
+func remoteWriteOutputSpec() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.20.0").
+		Summary("Writes samples to a Prometheus remote write endpoint, such as Mimir, Thanos, Cortex or VictoriaMetrics.").
+		Description(`
+Each message must be a structured sample, or an array of samples, of the form:
+
+`+"```json"+`
+{
+  "name": "http_requests_total",
+  "labels": { "method": "GET", "status": "200" },
+  "value": 1027,
+  "timestamp": 1690000000000
+}
+`+"```"+`
+
+Where `+"`timestamp`"+` is optional and is either a number of milliseconds since the unix epoch or an RFC 3339 timestamp, and defaults to the time the sample is written. A sample may instead set `+"`\"stale\": true`"+` without a value in order to write a [staleness marker](https://prometheus.io/docs/prometheus/latest/querying/basics/#staleness), which signals that the series has ended.
+
+The samples of each batch are grouped into series by their names and labels, and sent in a single remote write request encoded with protobuf and compressed with snappy. Samples are not written to a write-ahead log, and a batch that fails to be sent is instead retried by Benthos as any other output would.
+
+Metric and label names that are not valid within Prometheus are sanitised by replacing invalid characters with underscores, unless `+"`sanitize_names`"+` is disabled, in which case batches that contain invalid names are rejected.
+
+## Performance
+
+This output benefits from sending multiple messages in flight in parallel for improved performance. You can tune the max number of in flight messages (or message batches) with the field `+"`max_in_flight`"+`.
+
+This output benefits from sending messages as a batch for improved performance. Batches can be formed at both the input and output level. You can find out more [in this doc](/docs/configuration/batching).`).
+		Fields(
+			service.NewURLField(prwFieldURL).
+				Description("The URL of the remote write endpoint.").
+				Example("http://localhost:9009/api/v1/push"),
+			service.NewStringMapField(prwFieldHeaders).
+				Description("A map of headers to add to each request, such as a tenant ID.").
+				Default(map[string]any{}).
+				Example(map[string]any{"X-Scope-OrgID": "tenant-1"}),
+			service.NewStringMapField(prwFieldExternalLabels).
+				Description("A map of labels to add to every series, which do not override labels of the same name that are set by samples.").
+				Default(map[string]any{}).
+				Example(map[string]any{"cluster": "eu-west-1"}),
+			service.NewBoolField(prwFieldSanitize).
+				Description("Whether to replace characters that are not valid within metric and label names with underscores.").
+				Default(true).
+				Advanced(),
+			service.NewDurationField(prwFieldTimeout).
+				Description("The maximum period of time to wait for a request to complete.").
+				Default("30s").
+				Advanced(),
+			service.NewTLSToggledField(prwFieldTLS),
+		).
+		Fields(httpclient.AuthFieldSpecs()...).
+		Fields(
+			service.NewOutputMaxInFlightField(),
+			service.NewBatchPolicyField(prwFieldBatching),
+		).
+		Example(
+			"Metrics From Events",
+			"In this example the durations of HTTP requests within JSON access logs are written to Mimir as samples.",
+			`
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ access_logs ]
+  processors:
+    - mapping: |
+        root.name = "http_request_duration_seconds"
+        root.labels.method = this.method
+        root.labels.path = this.path
+        root.value = this.duration_ms / 1000
+        root.timestamp = this.time
+
+output:
+  prometheus_remote_write:
+    url: http://localhost:9009/api/v1/push
+    headers:
+      X-Scope-OrgID: benthos
+    batching:
+      count: 1000
+      period: 5s
+`,
+		)
+	return spec
+}
+
+func init() {
+	err := service.RegisterBatchOutput("prometheus_remote_write", remoteWriteOutputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			out service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldMaxInFlight(); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy(prwFieldBatching); err != nil {
+				return
+			}
+			out, err = newRemoteWriteOutputFromParsed(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type remoteWriteOutput struct {
+	url            string
+	headers        map[string]string
+	externalLabels map[string]string
+	sanitize       bool
+	timeout        time.Duration
+
+	client    *http.Client
+	reqSigner httpclient.RequestSigner
+	mgr       *service.Resources
+	nowFn     func() time.Time
+}
+
+func newRemoteWriteOutputFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*remoteWriteOutput, error) {
+	r := &remoteWriteOutput{
+		mgr:   mgr,
+		nowFn: time.Now,
+	}
+
+	var err error
+	if r.url, err = conf.FieldString(prwFieldURL); err != nil {
+		return nil, err
+	}
+	if r.headers, err = conf.FieldStringMap(prwFieldHeaders); err != nil {
+		return nil, err
+	}
+	if r.externalLabels, err = conf.FieldStringMap(prwFieldExternalLabels); err != nil {
+		return nil, err
+	}
+	if r.sanitize, err = conf.FieldBool(prwFieldSanitize); err != nil {
+		return nil, err
+	}
+	if r.timeout, err = conf.FieldDuration(prwFieldTimeout); err != nil {
+		return nil, err
+	}
+	for k := range r.externalLabels {
+		if _, err := r.labelName(k); err != nil {
+			return nil, fmt.Errorf("external label: %w", err)
+		}
+	}
+	if r.reqSigner, err = httpclient.AuthSignerFromParsed(conf); err != nil {
+		return nil, err
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled(prwFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsEnabled {
+		transport.TLSClientConfig = tlsConf
+	}
+	r.client = &http.Client{Transport: transport, Timeout: r.timeout}
+	return r, nil
+}
+
+func (r *remoteWriteOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type promLabel struct {
+	name, value string
+}
+
+type promSample struct {
+	value     float64
+	timestamp int64
+}
+
+type promSeries struct {
+	labels  []promLabel
+	samples []promSample
+}
+
+func isValidNameChar(r rune, i int, allowColon bool) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' ||
+		(allowColon && r == ':') || (i > 0 && r >= '0' && r <= '9')
+}
+
+// sanitizeName returns a valid metric or label name, where label names may
+// not contain colons.
+func sanitizeName(name string, allowColon bool) string {
+	var b strings.Builder
+	for i, r := range name {
+		if isValidNameChar(r, i, allowColon) {
+			b.WriteRune(r)
+		} else if i == 0 && r >= '0' && r <= '9' {
+			b.WriteRune('_')
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func (r *remoteWriteOutput) checkName(name string, allowColon bool) (string, error) {
+	if name == "" {
+		return "", errors.New("name must not be empty")
+	}
+	if r.sanitize {
+		return sanitizeName(name, allowColon), nil
+	}
+	for i, c := range name {
+		if !isValidNameChar(c, i, allowColon) {
+			return "", fmt.Errorf("name '%v' contains invalid characters", name)
+		}
+	}
+	return name, nil
+}
+
+func (r *remoteWriteOutput) metricName(name string) (string, error) {
+	return r.checkName(name, true)
+}
+
+func (r *remoteWriteOutput) labelName(name string) (string, error) {
+	if strings.HasPrefix(name, "__") {
+		return "", fmt.Errorf("label name '%v' is reserved", name)
+	}
+	return r.checkName(name, false)
+}
+
+func sampleNumber(v any) (float64, error) {
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case int64:
+		return float64(t), nil
+	case int:
+		return float64(t), nil
+	case uint64:
+		return float64(t), nil
+	case json.Number:
+		return t.Float64()
+	case string:
+		return strconv.ParseFloat(t, 64)
+	}
+	return 0, fmt.Errorf("expected number, got %T", v)
+}
+
+func (r *remoteWriteOutput) sampleTimestamp(v any) (int64, error) {
+	if v == nil {
+		return r.nowFn().UnixMilli(), nil
+	}
+	if s, ok := v.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t.UnixMilli(), nil
+		}
+	}
+	f, err := sampleNumber(v)
+	if err != nil {
+		return 0, fmt.Errorf("timestamp: %w", err)
+	}
+	return int64(f), nil
+}
+
+// parseSample parses a structured sample into its sorted labels, including
+// the metric name, and its value.
+func (r *remoteWriteOutput) parseSample(v any) ([]promLabel, promSample, error) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, promSample{}, fmt.Errorf("expected sample object, got %T", v)
+	}
+
+	nameV, _ := obj["name"].(string)
+	name, err := r.metricName(nameV)
+	if err != nil {
+		return nil, promSample{}, fmt.Errorf("metric %w", err)
+	}
+
+	labelsMap := map[string]string{}
+	for k, v := range r.externalLabels {
+		k, _ = r.labelName(k)
+		labelsMap[k] = v
+	}
+	if lV, exists := obj["labels"]; exists && lV != nil {
+		lObj, ok := lV.(map[string]any)
+		if !ok {
+			return nil, promSample{}, fmt.Errorf("expected labels object, got %T", lV)
+		}
+		for k, v := range lObj {
+			k, err := r.labelName(k)
+			if err != nil {
+				return nil, promSample{}, fmt.Errorf("label %w", err)
+			}
+			var vStr string
+			switch t := v.(type) {
+			case string:
+				vStr = t
+			case nil:
+				continue
+			default:
+				vStr = fmt.Sprintf("%v", t)
+			}
+			if vStr == "" {
+				continue
+			}
+			labelsMap[k] = vStr
+		}
+	}
+
+	labels := make([]promLabel, 0, len(labelsMap)+1)
+	labels = append(labels, promLabel{name: "__name__", value: name})
+	for k, v := range labelsMap {
+		labels = append(labels, promLabel{name: k, value: v})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+
+	var sample promSample
+	if sample.timestamp, err = r.sampleTimestamp(obj["timestamp"]); err != nil {
+		return nil, promSample{}, err
+	}
+	if stale, _ := obj["stale"].(bool); stale {
+		sample.value = math.Float64frombits(staleNaN)
+	} else if sample.value, err = sampleNumber(obj["value"]); err != nil {
+		return nil, promSample{}, fmt.Errorf("value: %w", err)
+	}
+	return labels, sample, nil
+}
+
+func seriesKey(labels []promLabel) string {
+	var b strings.Builder
+	for _, l := range labels {
+		b.WriteString(l.name)
+		b.WriteByte(0xff)
+		b.WriteString(l.value)
+		b.WriteByte(0xff)
+	}
+	return b.String()
+}
+
+// encodeWriteRequest encodes series as a remote write WriteRequest protobuf
+// message.
+func encodeWriteRequest(series []*promSeries) []byte {
+	var req []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+		for _, smp := range s.samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(smp.value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(smp.timestamp))
+
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sb)
+		}
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req
+}
+
+func (r *remoteWriteOutput) batchToSeries(batch service.MessageBatch) ([]*promSeries, error) {
+	seriesMap := map[string]*promSeries{}
+	var series []*promSeries
+
+	addSample := func(v any) error {
+		labels, sample, err := r.parseSample(v)
+		if err != nil {
+			return err
+		}
+		key := seriesKey(labels)
+		s, exists := seriesMap[key]
+		if !exists {
+			s = &promSeries{labels: labels}
+			seriesMap[key] = s
+			series = append(series, s)
+		}
+		s.samples = append(s.samples, sample)
+		return nil
+	}
+
+	var batchErr *service.BatchError
+	for i, msg := range batch {
+		v, err := msg.AsStructured()
+		if err == nil {
+			if arr, isArr := v.([]any); isArr {
+				for _, e := range arr {
+					if err = addSample(e); err != nil {
+						break
+					}
+				}
+			} else {
+				err = addSample(v)
+			}
+		}
+		if err != nil {
+			if batchErr == nil {
+				batchErr = service.NewBatchError(batch, errors.New("failed to parse samples"))
+			}
+			batchErr.Failed(i, err)
+		}
+	}
+	if batchErr != nil {
+		return nil, batchErr
+	}
+
+	// Samples within a series must be written in order.
+	for _, s := range series {
+		sort.SliceStable(s.samples, func(i, j int) bool {
+			return s.samples[i].timestamp < s.samples[j].timestamp
+		})
+	}
+	return series, nil
+}
+
+func (r *remoteWriteOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	series, err := r.batchToSeries(batch)
+	if err != nil {
+		return err
+	}
+	if len(series) == 0 {
+		return nil
+	}
+
+	body := snappy.Encode(nil, encodeWriteRequest(series))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if err := r.reqSigner(r.mgr.FS(), req); err != nil {
+		return err
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1<<12))
+		return fmt.Errorf("remote write returned status %v: %s", res.StatusCode, bytes.TrimSpace(resBody))
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	return nil
+}
+
+func (r *remoteWriteOutput) Close(ctx context.Context) error {
+	r.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,187 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// decodeWriteRequest decodes a WriteRequest into a string per sample of the
+// form `{labels} value@timestamp`.
+func decodeWriteRequest(t *testing.T, b []byte) []string {
+	t.Helper()
+
+	fields := func(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) int) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+			n = fn(num, typ, b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+		}
+	}
+
+	var samples []string
+	fields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		require.Equal(t, protowire.Number(1), num)
+		ts, n := protowire.ConsumeBytes(b)
+
+		var labels []string
+		fields(ts, func(num protowire.Number, typ protowire.Type, b []byte) int {
+			v, n := protowire.ConsumeBytes(b)
+			switch num {
+			case 1:
+				var name, value string
+				fields(v, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					s, n := protowire.ConsumeString(b)
+					if num == 1 {
+						name = s
+					} else {
+						value = s
+					}
+					return n
+				})
+				labels = append(labels, name+"="+value)
+			case 2:
+				var value float64
+				var timestamp int64
+				fields(v, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					if num == 1 {
+						f, n := protowire.ConsumeFixed64(b)
+						value = math.Float64frombits(f)
+						if f == staleNaN {
+							value = math.Inf(-1)
+						}
+						return n
+					}
+					i, n := protowire.ConsumeVarint(b)
+					timestamp = int64(i)
+					return n
+				})
+				samples = append(samples, fmt.Sprintf("{%v} %v@%v", strings.Join(labels, ","), value, timestamp))
+			}
+			return n
+		})
+		return n
+	})
+	return samples
+}
+
+func testRemoteWriteOutput(t *testing.T, url, confStr string) *remoteWriteOutput {
+	t.Helper()
+
+	pConf, err := remoteWriteOutputSpec().ParseYAML(fmt.Sprintf("url: %v\n", url)+confStr, nil)
+	require.NoError(t, err)
+
+	w, err := newRemoteWriteOutputFromParsed(pConf, service.MockResources())
+	require.NoError(t, err)
+	w.nowFn = func() time.Time {
+		return time.UnixMilli(5000)
+	}
+	t.Cleanup(func() {
+		require.NoError(t, w.Close(context.Background()))
+	})
+	return w
+}
+
+func TestRemoteWriteOutput(t *testing.T) {
+	var reqHeaders http.Header
+	var reqSamples []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqHeaders = r.Header.Clone()
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		decoded, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+
+		reqSamples = decodeWriteRequest(t, decoded)
+	}))
+	t.Cleanup(srv.Close)
+
+	w := testRemoteWriteOutput(t, srv.URL, `
+headers:
+  X-Scope-OrgID: foo
+external_labels:
+  cluster: eu
+  job: external
+`)
+	require.NoError(t, w.Connect(context.Background()))
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"requests.total","labels":{"method":"GET","job":"api"},"value":2,"timestamp":2000}`)),
+		service.NewMessage([]byte(`[
+  {"name":"requests.total","labels":{"job":"api","method":"GET"},"value":1,"timestamp":1000},
+  {"name":"up","labels":{"0instance":"a"},"value":"1","timestamp":"1970-01-01T00:00:03Z"}
+]`)),
+		service.NewMessage([]byte(`{"name":"up","labels":{"0instance":"b"},"stale":true}`)),
+	}))
+
+	assert.Equal(t, "snappy", reqHeaders.Get("Content-Encoding"))
+	assert.Equal(t, "application/x-protobuf", reqHeaders.Get("Content-Type"))
+	assert.Equal(t, "0.1.0", reqHeaders.Get("X-Prometheus-Remote-Write-Version"))
+	assert.Equal(t, "foo", reqHeaders.Get("X-Scope-OrgID"))
+
+	sort.Strings(reqSamples)
+	assert.Equal(t, []string{
+		"{_0instance=a,__name__=up,cluster=eu,job=external} 1@3000",
+		"{_0instance=b,__name__=up,cluster=eu,job=external} -Inf@5000",
+		"{__name__=requests_total,cluster=eu,job=api,method=GET} 1@1000",
+		"{__name__=requests_total,cluster=eu,job=api,method=GET} 2@2000",
+	}, reqSamples)
+}
+
+func TestRemoteWriteOutputErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("out of order sample\n"))
+	}))
+	t.Cleanup(srv.Close)
+
+	w := testRemoteWriteOutput(t, srv.URL, `sanitize_names: false`)
+
+	err := w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"up","value":1}`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 400: out of order sample")
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"up","value":1}`)),
+		service.NewMessage([]byte(`{"name":"up.total","value":1}`)),
+		service.NewMessage([]byte(`{"name":"up","value":"nope"}`)),
+		service.NewMessage([]byte(`{"name":"up","labels":{"__name__":"foo"},"value":1}`)),
+	}
+	indexer := batch.Index()
+
+	err = w.WriteBatch(context.Background(), batch)
+	require.Error(t, err)
+
+	var batchErr *service.BatchError
+	require.ErrorAs(t, err, &batchErr)
+
+	var failed []int
+	batchErr.WalkMessagesIndexedBy(indexer, func(i int, _ *service.Message, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1, 2, 3}, failed)
+}
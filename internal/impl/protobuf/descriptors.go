@@ -0,0 +1,116 @@
+package protobuf
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+)
+
+// RegistriesFromPath loads descriptors from a path, which is either a directory
+// of `.proto` files, a single `.proto` file (in which case the directory it
+// resides in is used for resolving imports), or a serialised
+// FileDescriptorSet. All files are read through the provided filesystem.
+func RegistriesFromPath(f ifs.FS, path string) (*protoregistry.Files, *protoregistry.Types, error) {
+	info, err := fs.Stat(f, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files *protoregistry.Files
+	var types *protoregistry.Types
+	switch {
+	case info.IsDir():
+		files, types, err = loadDescriptors(f, []string{path})
+	case filepath.Ext(path) == ".proto":
+		files, types, err = loadDescriptors(f, []string{filepath.Dir(path)})
+	default:
+		files, types, err = loadDescriptorSet(f, path)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load protobuf descriptors from '%v': %w", path, err)
+	}
+	return files, types, nil
+}
+
+// loadDescriptorSet parses a serialised FileDescriptorSet, as produced by
+// `protoc --descriptor_set_out`, into registries.
+func loadDescriptorSet(f ifs.FS, path string) (*protoregistry.Files, *protoregistry.Types, error) {
+	setBytes, err := ifs.ReadFile(f, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(setBytes, &set); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse descriptor set: %w", err)
+	}
+
+	// Descriptor sets are expected to be ordered such that dependencies are
+	// registered first, which is the case for those produced by protoc. Imports
+	// not included within the set (such as well-known types) are resolved from
+	// the global registry.
+	files := &protoregistry.Files{}
+	resolver := chainedResolver{files, protoregistry.GlobalFiles}
+	for _, fdp := range set.File {
+		fd, err := protodesc.NewFile(fdp, resolver)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse descriptor of file '%v': %w", fdp.GetName(), err)
+		}
+		if err := files.RegisterFile(fd); err != nil {
+			return nil, nil, fmt.Errorf("failed to register file '%v': %w", fdp.GetName(), err)
+		}
+	}
+
+	types := &protoregistry.Types{}
+	var registerMessages func(msgs protoreflect.MessageDescriptors) error
+	registerMessages = func(msgs protoreflect.MessageDescriptors) error {
+		for i := 0; i < msgs.Len(); i++ {
+			md := msgs.Get(i)
+			if err := types.RegisterMessage(dynamicpb.NewMessageType(md)); err != nil {
+				return fmt.Errorf("failed to register type '%v': %w", md.FullName(), err)
+			}
+			if err := registerMessages(md.Messages()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		err = registerMessages(fd.Messages())
+		return err == nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, types, nil
+}
+
+type chainedResolver []*protoregistry.Files
+
+func (c chainedResolver) FindFileByPath(path string) (fd protoreflect.FileDescriptor, err error) {
+	for _, f := range c {
+		if fd, err = f.FindFileByPath(path); err == nil {
+			return
+		}
+	}
+	return
+}
+
+func (c chainedResolver) FindDescriptorByName(name protoreflect.FullName) (d protoreflect.Descriptor, err error) {
+	for _, f := range c {
+		if d, err = f.FindDescriptorByName(name); err == nil {
+			return
+		}
+	}
+	return
+}
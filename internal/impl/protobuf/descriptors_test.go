@@ -0,0 +1,62 @@
+package protobuf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+)
+
+func TestRegistriesFromPath(t *testing.T) {
+	schemaPath := "../../../config/test/protobuf/schema"
+
+	for _, path := range []string{schemaPath, schemaPath + "/person.proto"} {
+		_, types, err := RegistriesFromPath(ifs.OS(), path)
+		require.NoError(t, err, path)
+
+		_, err = types.FindMessageByName("testing.Person")
+		assert.NoError(t, err, path)
+	}
+
+	_, _, err := RegistriesFromPath(ifs.OS(), "./does/not/exist")
+	require.Error(t, err)
+}
+
+func TestRegistriesFromPathDescriptorSet(t *testing.T) {
+	files, _, err := loadDescriptors(ifs.OS(), []string{"../../../config/test/protobuf/schema"})
+	require.NoError(t, err)
+
+	// Dependencies must come first, as they would when produced by protoc.
+	var set descriptorpb.FileDescriptorSet
+	for _, name := range []string{"person.proto", "house.proto", "envelope.proto"} {
+		fd, err := files.FindFileByPath(name)
+		require.NoError(t, err)
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+
+	setBytes, err := proto.Marshal(&set)
+	require.NoError(t, err)
+
+	setPath := filepath.Join(t.TempDir(), "schema.pb")
+	require.NoError(t, os.WriteFile(setPath, setBytes, 0o644))
+
+	_, types, err := RegistriesFromPath(ifs.OS(), setPath)
+	require.NoError(t, err)
+
+	for _, name := range []string{"testing.Person", "testing.House", "testing.Envelope"} {
+		_, err = types.FindMessageByName(protoreflect.FullName(name))
+		assert.NoError(t, err, name)
+	}
+
+	require.NoError(t, os.WriteFile(setPath, []byte("not a descriptor set"), 0o644))
+	_, _, err = RegistriesFromPath(ifs.OS(), setPath)
+	require.Error(t, err)
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
-	"os"
 	"path/filepath"
 
 	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
@@ -255,7 +254,7 @@ func loadDescriptors(f ifs.FS, importPaths []string) (*protoregistry.Files, *pro
 				if ferr != nil {
 					return fmt.Errorf("failed to get relative path: %v", ferr)
 				}
-				content, ferr := os.ReadFile(path)
+				content, ferr := ifs.ReadFile(f, path)
 				if ferr != nil {
 					return fmt.Errorf("failed to read import %v: %v", path, ferr)
 				}
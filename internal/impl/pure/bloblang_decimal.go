@@ -0,0 +1,477 @@
+package pure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+// decimalFrom converts a value into an arbitrary precision decimal. Floating
+// point numbers are converted using the shortest representation that round
+// trips, and therefore 0.1 becomes exactly 0.1 rather than its binary
+// approximation.
+func decimalFrom(v any) (decimal.Decimal, error) {
+	switch t := v.(type) {
+	case string:
+		return decimal.NewFromString(t)
+	case []byte:
+		return decimal.NewFromString(string(t))
+	case json.Number:
+		return decimal.NewFromString(t.String())
+	case int64:
+		return decimal.NewFromInt(t), nil
+	case int:
+		return decimal.NewFromInt(int64(t)), nil
+	case int32:
+		return decimal.NewFromInt(int64(t)), nil
+	case uint64:
+		return decimal.NewFromString(fmt.Sprintf("%d", t))
+	case uint32:
+		return decimal.NewFromInt(int64(t)), nil
+	case float64:
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return decimal.Decimal{}, fmt.Errorf("expected a finite number, got %v", t)
+		}
+		return decimal.NewFromFloat(t), nil
+	case float32:
+		if math.IsNaN(float64(t)) || math.IsInf(float64(t), 0) {
+			return decimal.Decimal{}, fmt.Errorf("expected a finite number, got %v", t)
+		}
+		return decimal.NewFromFloat32(t), nil
+	}
+	return decimal.Decimal{}, query.NewTypeError(v, query.ValueNumber, query.ValueString)
+}
+
+// decimalString formats a decimal without discarding trailing zeros of its
+// fractional part, so that the scale of amounts such as 1.50 is preserved.
+func decimalString(d decimal.Decimal) string {
+	if exp := d.Exponent(); exp < 0 {
+		return d.StringFixed(-exp)
+	}
+	return d.String()
+}
+
+var decimalRoundingModes = map[string]struct{}{
+	"half_up":   {},
+	"half_down": {},
+	"half_even": {},
+	"up":        {},
+	"down":      {},
+	"ceiling":   {},
+	"floor":     {},
+}
+
+const decimalRoundingDescription = "The rounding mode to use, one of `half_up` (ties away from zero), `half_down` (ties towards zero), `half_even` (ties towards the nearest even digit, also known as banker's rounding), `up` (away from zero), `down` (towards zero, truncating), `ceiling` (towards positive infinity) or `floor` (towards negative infinity)."
+
+func decimalRoundingModeFromArgs(args *bloblang.ParsedParams) (string, error) {
+	mode, err := args.GetString("rounding")
+	if err != nil {
+		return "", err
+	}
+	if _, exists := decimalRoundingModes[mode]; !exists {
+		return "", fmt.Errorf("unrecognised rounding mode: %v", mode)
+	}
+	return mode, nil
+}
+
+func decimalScaleFromArgs(args *bloblang.ParsedParams) (int32, error) {
+	scale, err := args.GetInt64("scale")
+	if err != nil {
+		return 0, err
+	}
+	if scale < 0 || scale > 1000 {
+		return 0, fmt.Errorf("scale must be between 0 and 1000, got %v", scale)
+	}
+	return int32(scale), nil
+}
+
+// decimalApplyRounding resolves a truncated value towards its rounded result.
+// The remainder cmp is the comparison between the discarded portion and half
+// of a unit at the scale, and negative indicates the sign of the exact value.
+func decimalApplyRounding(truncated decimal.Decimal, scale int32, mode string, cmp int, negative bool) decimal.Decimal {
+	away := truncated.Add(decimal.New(1, -scale))
+	if negative {
+		away = truncated.Sub(decimal.New(1, -scale))
+	}
+
+	var roundAway bool
+	switch mode {
+	case "up":
+		roundAway = true
+	case "ceiling":
+		roundAway = !negative
+	case "floor":
+		roundAway = negative
+	case "half_up":
+		roundAway = cmp >= 0
+	case "half_down":
+		roundAway = cmp > 0
+	case "half_even":
+		roundAway = cmp > 0 || (cmp == 0 && truncated.Shift(scale).BigInt().Bit(0) == 1)
+	}
+	if roundAway {
+		return away
+	}
+	return truncated
+}
+
+func decimalRound(d decimal.Decimal, scale int32, mode string) decimal.Decimal {
+	truncated := d.Truncate(scale)
+	rem := d.Sub(truncated)
+	if rem.IsZero() {
+		return truncated
+	}
+	cmp := rem.Abs().Shift(scale).Cmp(decimal.New(5, -1))
+	return decimalApplyRounding(truncated, scale, mode, cmp, d.IsNegative())
+}
+
+func decimalDiv(d, divisor decimal.Decimal, scale int32, mode string) decimal.Decimal {
+	quo, rem := d.QuoRem(divisor, scale)
+	if rem.IsZero() {
+		return quo
+	}
+	// The discarded fraction of a unit is |rem / divisor| * 10^scale, which is
+	// compared with one half.
+	cmp := rem.Abs().Shift(scale + 1).Cmp(divisor.Abs().Mul(decimal.New(5, 0)))
+	return decimalApplyRounding(quo, scale, mode, cmp, d.Sign()*divisor.Sign() < 0)
+}
+
+//------------------------------------------------------------------------------
+
+// numberSymbols describes how a locale formats numbers, which is derived from
+// the output of golang.org/x/text for a probe value. Deriving the symbols this
+// way allows us to format decimals of any precision, as golang.org/x/text
+// converts its input into a float64.
+type numberSymbols struct {
+	digits         [10]rune
+	group          string
+	decimal        string
+	primaryGroup   int
+	secondaryGroup int
+	negPrefix      string
+	negSuffix      string
+	posPrefix      string
+	posSuffix      string
+}
+
+var numberSymbolsCache sync.Map
+
+func numberSymbolsFor(tag language.Tag) (*numberSymbols, error) {
+	if v, exists := numberSymbolsCache.Load(tag); exists {
+		return v.(*numberSymbols), nil
+	}
+
+	p := message.NewPrinter(tag)
+
+	// The probe contains every digit, with 1 through 9 and then 0, and a
+	// single fractional digit of 5.
+	const probeDigits = "12345678905"
+	probe := []rune(p.Sprint(number.Decimal(-1234567890.5, number.Scale(1))))
+
+	first, last := -1, -1
+	for i, r := range probe {
+		if unicode.IsDigit(r) {
+			if first == -1 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first == -1 {
+		return nil, fmt.Errorf("unable to derive number format of locale %v", tag)
+	}
+
+	s := &numberSymbols{
+		negPrefix: string(probe[:first]),
+		negSuffix: string(probe[last+1:]),
+	}
+
+	var groupPositions []int
+	digitIndex := 0
+	for i := first; i <= last; i++ {
+		r := probe[i]
+		if unicode.IsDigit(r) {
+			d := probeDigits[digitIndex] - '0'
+			s.digits[d] = r
+			digitIndex++
+			continue
+		}
+		if digitIndex == len(probeDigits)-1 {
+			s.decimal += string(r)
+		} else {
+			s.group = string(r)
+			groupPositions = append(groupPositions, digitIndex)
+		}
+	}
+	if digitIndex != len(probeDigits) {
+		return nil, fmt.Errorf("unable to derive number format of locale %v", tag)
+	}
+
+	const intDigits = len(probeDigits) - 1
+	if n := len(groupPositions); n > 0 {
+		s.primaryGroup = intDigits - groupPositions[n-1]
+		s.secondaryGroup = s.primaryGroup
+		if n > 1 {
+			s.secondaryGroup = groupPositions[n-1] - groupPositions[n-2]
+		}
+	}
+
+	pos := []rune(p.Sprint(number.Decimal(1)))
+	for i, r := range pos {
+		if unicode.IsDigit(r) {
+			s.posPrefix = string(pos[:i])
+			s.posSuffix = string(pos[i+1:])
+			break
+		}
+	}
+
+	v, _ := numberSymbolsCache.LoadOrStore(tag, s)
+	return v.(*numberSymbols), nil
+}
+
+func (s *numberSymbols) format(d decimal.Decimal, grouping bool) string {
+	str := decimalString(d.Abs())
+	intPart, fracPart, _ := strings.Cut(str, ".")
+
+	var b strings.Builder
+	if d.IsNegative() {
+		b.WriteString(s.negPrefix)
+	} else {
+		b.WriteString(s.posPrefix)
+	}
+
+	for i, r := range intPart {
+		if i > 0 && grouping && s.primaryGroup > 0 {
+			remaining := len(intPart) - i
+			if remaining == s.primaryGroup ||
+				(remaining > s.primaryGroup && (remaining-s.primaryGroup)%s.secondaryGroup == 0) {
+				b.WriteString(s.group)
+			}
+		}
+		b.WriteRune(s.digits[r-'0'])
+	}
+	if fracPart != "" {
+		b.WriteString(s.decimal)
+		for _, r := range fracPart {
+			b.WriteRune(s.digits[r-'0'])
+		}
+	}
+
+	if d.IsNegative() {
+		b.WriteString(s.negSuffix)
+	} else {
+		b.WriteString(s.posSuffix)
+	}
+	return b.String()
+}
+
+//------------------------------------------------------------------------------
+
+func registerDecimalArithmeticMethod(name, verb, example, exampleIn, exampleOut string, fn func(a, b decimal.Decimal) decimal.Decimal) {
+	if err := bloblang.RegisterMethodV2(name,
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryNumbers).
+			Version("4.20.0").
+			Description(fmt.Sprintf("%v using arbitrary precision decimal arithmetic and returns the result as a string, avoiding the rounding errors of floating point numbers. Both the target and argument can be numbers or strings containing decimal numbers, and the scale of the result is the scale required to represent it exactly, where trailing zeros of the inputs are preserved.", verb)).
+			Param(bloblang.NewAnyParam("value").Description("The number to operate with.")).
+			Example("", example, [2]string{exampleIn, exampleOut}),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			v, err := args.Get("value")
+			if err != nil {
+				return nil, err
+			}
+			other, err := decimalFrom(v)
+			if err != nil {
+				return nil, fmt.Errorf("value: %w", err)
+			}
+			return func(v any) (any, error) {
+				d, err := decimalFrom(v)
+				if err != nil {
+					return nil, err
+				}
+				return decimalString(fn(d, other)), nil
+			}, nil
+		}); err != nil {
+		panic(err)
+	}
+}
+
+func init() {
+	registerDecimalArithmeticMethod("decimal_add", "Adds a number to the target",
+		`root.total = this.a.decimal_add(this.b)`,
+		`{"a":0.1,"b":0.2}`, `{"total":"0.3"}`,
+		func(a, b decimal.Decimal) decimal.Decimal { return a.Add(b) })
+
+	registerDecimalArithmeticMethod("decimal_sub", "Subtracts a number from the target",
+		`root.balance = this.balance.decimal_sub(this.withdrawn)`,
+		`{"balance":"100.10","withdrawn":"0.35"}`, `{"balance":"99.75"}`,
+		func(a, b decimal.Decimal) decimal.Decimal { return a.Sub(b) })
+
+	registerDecimalArithmeticMethod("decimal_mul", "Multiplies the target by a number",
+		`root.total = this.price.decimal_mul(this.quantity)`,
+		`{"price":"19.99","quantity":3}`, `{"total":"59.97"}`,
+		func(a, b decimal.Decimal) decimal.Decimal { return a.Mul(b) })
+
+	if err := bloblang.RegisterMethodV2("decimal_div",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryNumbers).
+			Version("4.20.0").
+			Description("Divides the target by a number using arbitrary precision decimal arithmetic and returns the result as a string rounded to a scale. Both the target and argument can be numbers or strings containing decimal numbers.").
+			Param(bloblang.NewAnyParam("value").Description("The number to divide by.")).
+			Param(bloblang.NewInt64Param("scale").Description("The number of decimal places of the result.").Default(16)).
+			Param(bloblang.NewStringParam("rounding").Description(decimalRoundingDescription).Default("half_even")).
+			Example("",
+				`root.share = this.total.decimal_div(value: 3, scale: 2)`,
+				[2]string{
+					`{"total":"100.00"}`,
+					`{"share":"33.33"}`,
+				},
+			),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			v, err := args.Get("value")
+			if err != nil {
+				return nil, err
+			}
+			divisor, err := decimalFrom(v)
+			if err != nil {
+				return nil, fmt.Errorf("value: %w", err)
+			}
+			if divisor.IsZero() {
+				return nil, errors.New("attempted to divide by zero")
+			}
+			scale, err := decimalScaleFromArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			mode, err := decimalRoundingModeFromArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			return func(v any) (any, error) {
+				d, err := decimalFrom(v)
+				if err != nil {
+					return nil, err
+				}
+				return decimalDiv(d, divisor, scale, mode).StringFixed(scale), nil
+			}, nil
+		}); err != nil {
+		panic(err)
+	}
+
+	if err := bloblang.RegisterMethodV2("decimal_round",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryNumbers).
+			Version("4.20.0").
+			Description("Rounds a number or string containing a decimal number to a scale using arbitrary precision decimal arithmetic, and returns the result as a string with exactly that number of decimal places.").
+			Param(bloblang.NewInt64Param("scale").Description("The number of decimal places of the result.")).
+			Param(bloblang.NewStringParam("rounding").Description(decimalRoundingDescription).Default("half_up")).
+			Example("",
+				`root.a = this.amount.decimal_round(2)
+root.b = this.amount.decimal_round(2, "half_even")
+root.c = this.amount.decimal_round(2, "floor")`,
+				[2]string{
+					`{"amount":"2.675"}`,
+					`{"a":"2.68","b":"2.68","c":"2.67"}`,
+				},
+				[2]string{
+					`{"amount":2.665}`,
+					`{"a":"2.67","b":"2.66","c":"2.66"}`,
+				},
+			),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			scale, err := decimalScaleFromArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			mode, err := decimalRoundingModeFromArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			return func(v any) (any, error) {
+				d, err := decimalFrom(v)
+				if err != nil {
+					return nil, err
+				}
+				return decimalRound(d, scale, mode).StringFixed(scale), nil
+			}, nil
+		}); err != nil {
+		panic(err)
+	}
+
+	if err := bloblang.RegisterMethodV2("format_number",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryNumbers).
+			Version("4.20.0").
+			Description("Formats a number or string containing a decimal number according to the conventions of a locale, including its digit grouping and decimal separator. Numbers are formatted with arbitrary precision, and can optionally be rounded to a scale.").
+			Param(bloblang.NewStringParam("locale").Description("A [BCP 47](https://www.rfc-editor.org/info/bcp47) language tag identifying the locale, such as `en-US`, `de` or `hi-IN`.").Default("en")).
+			Param(bloblang.NewInt64Param("scale").Description("An optional number of decimal places to round the number to.").Optional()).
+			Param(bloblang.NewStringParam("rounding").Description(decimalRoundingDescription).Default("half_up")).
+			Param(bloblang.NewBoolParam("grouping").Description("Whether to group the digits of the integer part of the number.").Default(true)).
+			Example("",
+				`root.en = this.amount.format_number()
+root.de = this.amount.format_number("de", 2)
+root.ch = this.amount.format_number("de-CH", 2)
+root.in = this.amount.format_number("en-IN")`,
+				[2]string{
+					`{"amount":"1234567.891"}`,
+					`{"ch":"1’234’567.89","de":"1.234.567,89","en":"1,234,567.891","in":"12,34,567.891"}`,
+				},
+			),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			localeStr, err := args.GetString("locale")
+			if err != nil {
+				return nil, err
+			}
+			tag, err := language.Parse(localeStr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse locale: %w", err)
+			}
+			symbols, err := numberSymbolsFor(tag)
+			if err != nil {
+				return nil, err
+			}
+			scalePtr, err := args.GetOptionalInt64("scale")
+			if err != nil {
+				return nil, err
+			}
+			if scalePtr != nil && (*scalePtr < 0 || *scalePtr > 1000) {
+				return nil, fmt.Errorf("scale must be between 0 and 1000, got %v", *scalePtr)
+			}
+			mode, err := decimalRoundingModeFromArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			grouping, err := args.GetBool("grouping")
+			if err != nil {
+				return nil, err
+			}
+			return func(v any) (any, error) {
+				d, err := decimalFrom(v)
+				if err != nil {
+					return nil, err
+				}
+				if scalePtr != nil {
+					scale := int32(*scalePtr)
+					d = decimalRound(d, scale, mode)
+					if exp := d.Exponent(); exp > -scale {
+						d = decimal.NewFromBigInt(d.Shift(scale).BigInt(), -scale)
+					}
+				}
+				return symbols.format(d, grouping), nil
+			}, nil
+		}); err != nil {
+		panic(err)
+	}
+}
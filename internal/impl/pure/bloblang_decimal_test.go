@@ -0,0 +1,176 @@
+package pure
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestDecimalMethods(t *testing.T) {
+	tests := []struct {
+		name               string
+		mapping            string
+		input              any
+		output             any
+		parseErrorContains string
+		execErrorContains  string
+	}{
+		{
+			name:    "add floats",
+			mapping: `root = this.a.decimal_add(this.b)`,
+			input:   map[string]any{"a": 0.1, "b": 0.2},
+			output:  "0.3",
+		},
+		{
+			name:    "add preserves scale",
+			mapping: `root = "1.10".decimal_add("2.20")`,
+			output:  "3.30",
+		},
+		{
+			name:    "add large values",
+			mapping: `root = "12345678901234567890.12345678901234567890".decimal_add(1)`,
+			output:  "12345678901234567891.12345678901234567890",
+		},
+		{
+			name:    "sub json number",
+			mapping: `root = this.decimal_sub("0.01")`,
+			input:   json.Number("10"),
+			output:  "9.99",
+		},
+		{
+			name:    "mul",
+			mapping: `root = "1.1".decimal_mul("1.1")`,
+			output:  "1.21",
+		},
+		{
+			name:              "arithmetic bad target",
+			mapping:           `root = this.decimal_add(1)`,
+			input:             "nope",
+			execErrorContains: "can't convert nope to decimal",
+		},
+		{
+			name:              "arithmetic infinite target",
+			mapping:           `root = "inf".number().decimal_add(1)`,
+			execErrorContains: "expected a finite number, got +Inf",
+		},
+		{
+			name:              "arithmetic nan argument",
+			mapping:           `root = "1".decimal_add(this)`,
+			input:             math.NaN(),
+			execErrorContains: "expected a finite number, got NaN",
+		},
+		{
+			name:               "arithmetic bad argument",
+			mapping:            `root = this.decimal_add(true)`,
+			parseErrorContains: "value: expected number or string value, got bool",
+		},
+		{
+			name:    "div default scale",
+			mapping: `root = "1".decimal_div(3)`,
+			output:  "0.3333333333333333",
+		},
+		{
+			name:    "div half even",
+			mapping: `root = ["0.125".decimal_div(1, 2), "0.135".decimal_div(1, 2), "-0.125".decimal_div(1, 2)]`,
+			output:  []any{"0.12", "0.14", "-0.12"},
+		},
+		{
+			name:    "div rounding modes",
+			mapping: `root = ["2".decimal_div(3, 0, "ceiling"), "-2".decimal_div(3, 0, "ceiling"), "-2".decimal_div(3, 0, "floor"), "2".decimal_div(-3, 1, "up"), "2".decimal_div(3, 1, "down")]`,
+			output:  []any{"1", "0", "-1", "-0.7", "0.6"},
+		},
+		{
+			name:               "div by zero",
+			mapping:            `root = this.decimal_div(0)`,
+			parseErrorContains: "attempted to divide by zero",
+		},
+		{
+			name:    "round modes",
+			mapping: `root = ["half_up", "half_down", "half_even", "up", "down", "ceiling", "floor"].map_each(m -> "-1.25".decimal_round(1, m))`,
+			output:  []any{"-1.3", "-1.2", "-1.2", "-1.3", "-1.2", "-1.2", "-1.3"},
+		},
+		{
+			name:    "round pads scale",
+			mapping: `root = [this.decimal_round(2), this.decimal_round(0)]`,
+			input:   int64(5),
+			output:  []any{"5.00", "5"},
+		},
+		{
+			name:    "round above half",
+			mapping: `root = "0.1251".decimal_round(2, "half_down")`,
+			output:  "0.13",
+		},
+		{
+			name:               "round bad mode",
+			mapping:            `root = this.decimal_round(2, "sideways")`,
+			parseErrorContains: "unrecognised rounding mode: sideways",
+		},
+		{
+			name:               "round bad scale",
+			mapping:            `root = this.decimal_round(-1)`,
+			parseErrorContains: "scale must be between 0 and 1000",
+		},
+		{
+			name:    "format english",
+			mapping: `root = [this.format_number(), this.format_number(scale: 2), this.format_number(grouping: false)]`,
+			input:   "-1234567.005",
+			output:  []any{"-1,234,567.005", "-1,234,567.01", "-1234567.005"},
+		},
+		{
+			name:    "format german",
+			mapping: `root = this.format_number("de", 2)`,
+			input:   1234.5,
+			output:  "1.234,50",
+		},
+		{
+			name:    "format indian grouping",
+			mapping: `root = this.format_number("en-IN", 0)`,
+			input:   "123456789012",
+			output:  "1,23,45,67,89,012",
+		},
+		{
+			name:    "format non latin digits",
+			mapping: `root = [this.format_number("ar-u-nu-arab"), this.format_number("fa")]`,
+			input:   "1234.5",
+			output:  []any{"١٬٢٣٤٫٥", "۱٬۲۳۴٫۵"},
+		},
+		{
+			name:    "format small number",
+			mapping: `root = this.format_number("en")`,
+			input:   "12",
+			output:  "12",
+		},
+		{
+			name:               "format bad locale",
+			mapping:            `root = this.format_number("not a locale")`,
+			parseErrorContains: "failed to parse locale",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			exec, err := bloblang.Parse(test.mapping)
+			if test.parseErrorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.parseErrorContains)
+				return
+			}
+			require.NoError(t, err)
+
+			res, err := exec.Query(test.input)
+			if test.execErrorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.execErrorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.output, res)
+		})
+	}
+}
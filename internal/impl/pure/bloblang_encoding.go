@@ -3,6 +3,7 @@ package pure
 import (
 	"bytes"
 	"compress/bzip2"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
@@ -64,8 +65,8 @@ root.b_len = $long_content.compress("gzip").length()
 	if err := bloblang.RegisterMethodV2("decompress",
 		bloblang.NewPluginSpec().
 			Category(query.MethodCategoryEncoding).
-			Description(`Decompresses a string or byte array value according to a specified algorithm. The result of decompression `).
-			Param(bloblang.NewStringParam("algorithm").Description("One of `gzip`, `pgzip`, `zlib`, `bzip2`, `flate`, `snappy`, `lz4`, `zstd`.")).
+			Description(`Decompresses a string or byte array value according to a specified algorithm. The result of decompression is a byte array, which can be coerced into a string with the `+"[`string`](#string)"+` method.`).
+			Param(bloblang.NewStringParam("algorithm").Description("One of `auto`, `gzip`, `pgzip`, `zlib`, `bzip2`, `flate`, `snappy`, `lz4`, `zstd`. The `auto` algorithm detects the format from the magic bytes at the beginning of the data, which is supported for `gzip`, `zlib`, `bzip2`, `lz4` and `zstd`.")).
 			Example("", `root = this.compressed.decode("base64").decompress("lz4")`,
 				[2]string{
 					`{"compressed":"BCJNGGRwuRgAAIBoZWxsbyB3b3JsZCBJIGxvdmUgc3BhY2UAAAAAGoETLg=="}`,
@@ -79,6 +80,19 @@ root.b_len = $long_content.compress("gzip").length()
 					`{"compressed":"BCJNGGRwuRgAAIBoZWxsbyB3b3JsZCBJIGxvdmUgc3BhY2UAAAAAGoETLg=="}`,
 					`{"result":"hello world I love space"}`,
 				},
+			).
+			Example(
+				"The `auto` algorithm can be used in order to decompress fields of a document where the format may vary.",
+				`root = this
+root.payload = this.payload.decode("base64").decompress("auto").string()`,
+				[2]string{
+					`{"id":"foo","payload":"H4sIAAAJbogA/wALAPT/aGVsbG8gd29ybGQDAIURSg0LAAAA"}`,
+					`{"id":"foo","payload":"hello world"}`,
+				},
+				[2]string{
+					`{"id":"bar","payload":"KLUv/QQAWQAAaGVsbG8gd29ybGRoaR6y"}`,
+					`{"id":"bar","payload":"hello world"}`,
+				},
 			),
 		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
 			algStr, err := args.GetString("algorithm")
@@ -124,22 +138,35 @@ func strToCompressor(str string) (CompressFunc, error) {
 	return fn, nil
 }
 
-var _ = AddCompressFunc("gzip", func(level int, b []byte) ([]byte, error) {
-	buf := &bytes.Buffer{}
-	w, err := gzip.NewWriterLevel(buf, level)
-	if err != nil {
-		return nil, err
-	}
+// CompressDictCtor creates a compression algorithm that uses a dictionary.
+type CompressDictCtor func(dict []byte) (CompressFunc, error)
 
-	if _, err = w.Write(b); err != nil {
-		w.Close()
-		return nil, err
+var compressDictImpls = map[string]CompressDictCtor{}
+
+// AddCompressDictCtor adds a compression algorithm that supports dictionaries
+// to components. The return struct serves no purpose other than allowing you to
+// call it within the global context as an assignment.
+func AddCompressDictCtor(name string, ctor CompressDictCtor) struct{} {
+	compressImplsLock.Lock()
+	compressDictImpls[name] = ctor
+	compressImplsLock.Unlock()
+	return struct{}{}
+}
+
+func strToDictCompressor(str string, dict []byte) (CompressFunc, error) {
+	ctor, exists := compressDictImpls[str]
+	if !exists {
+		return nil, fmt.Errorf("compression type %v does not support dictionaries", str)
 	}
-	// Must flush writer before calling buf.Bytes()
-	w.Close()
-	return buf.Bytes(), nil
+	return ctor(dict)
+}
+
+var gzipWriterPool = newCompressWriterPool(func(level int, w io.Writer) (resettableWriter, error) {
+	return gzip.NewWriterLevel(w, level)
 })
 
+var _ = AddCompressFunc("gzip", gzipWriterPool.compress)
+
 var _ = AddCompressFunc("pgzip", func(level int, b []byte) ([]byte, error) {
 	buf := &bytes.Buffer{}
 	w, err := pgzip.NewWriterLevel(buf, level)
@@ -156,38 +183,18 @@ var _ = AddCompressFunc("pgzip", func(level int, b []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 })
 
-var _ = AddCompressFunc("zlib", func(level int, b []byte) ([]byte, error) {
-	buf := &bytes.Buffer{}
-	w, err := zlib.NewWriterLevel(buf, level)
-	if err != nil {
-		return nil, err
-	}
-
-	if _, err = w.Write(b); err != nil {
-		w.Close()
-		return nil, err
-	}
-	// Must flush writer before calling buf.Bytes()
-	w.Close()
-	return buf.Bytes(), nil
+var zlibWriterPool = newCompressWriterPool(func(level int, w io.Writer) (resettableWriter, error) {
+	return zlib.NewWriterLevel(w, level)
 })
 
-var _ = AddCompressFunc("flate", func(level int, b []byte) ([]byte, error) {
-	buf := &bytes.Buffer{}
-	w, err := flate.NewWriter(buf, level)
-	if err != nil {
-		return nil, err
-	}
+var _ = AddCompressFunc("zlib", zlibWriterPool.compress)
 
-	if _, err = w.Write(b); err != nil {
-		w.Close()
-		return nil, err
-	}
-	// Must flush writer before calling buf.Bytes()
-	w.Close()
-	return buf.Bytes(), nil
+var flateWriterPool = newCompressWriterPool(func(level int, w io.Writer) (resettableWriter, error) {
+	return flate.NewWriter(w, level)
 })
 
+var _ = AddCompressFunc("flate", flateWriterPool.compress)
+
 var _ = AddCompressFunc("snappy", func(level int, b []byte) ([]byte, error) {
 	return snappy.Encode(nil, b), nil
 })
@@ -231,7 +238,49 @@ func AddDecompressFunc(name string, fn DecompressFunc) struct{} {
 	return struct{}{}
 }
 
+// decompressMagic lists formats that can be detected by the magic bytes at the
+// beginning of their data, in order of precedence.
+var decompressMagic = []struct {
+	name  string
+	match func(b []byte) bool
+}{
+	{name: "gzip", match: func(b []byte) bool {
+		return bytes.HasPrefix(b, []byte{0x1f, 0x8b})
+	}},
+	{name: "zstd", match: func(b []byte) bool {
+		return bytes.HasPrefix(b, []byte{0x28, 0xb5, 0x2f, 0xfd})
+	}},
+	{name: "lz4", match: func(b []byte) bool {
+		return bytes.HasPrefix(b, []byte{0x04, 0x22, 0x4d, 0x18})
+	}},
+	{name: "bzip2", match: func(b []byte) bool {
+		return bytes.HasPrefix(b, []byte("BZh"))
+	}},
+	{name: "zlib", match: func(b []byte) bool {
+		// A zlib header uses the deflate method and is a multiple of 31.
+		return len(b) >= 2 && b[0]&0x0f == 8 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0
+	}},
+}
+
+func detectDecompressor(b []byte) (DecompressFunc, error) {
+	for _, m := range decompressMagic {
+		if m.match(b) {
+			return strToDecompressor(m.name)
+		}
+	}
+	return nil, errors.New("unable to detect compression format")
+}
+
 func strToDecompressor(str string) (DecompressFunc, error) {
+	if str == "auto" {
+		return func(b []byte) ([]byte, error) {
+			fn, err := detectDecompressor(b)
+			if err != nil {
+				return nil, err
+			}
+			return fn(b)
+		}, nil
+	}
 	fn, exists := decompressImpls[str]
 	if !exists {
 		return nil, fmt.Errorf("decompression type not recognised: %v", str)
@@ -239,20 +288,39 @@ func strToDecompressor(str string) (DecompressFunc, error) {
 	return fn, nil
 }
 
-var _ = AddDecompressFunc("gzip", func(b []byte) ([]byte, error) {
-	r, err := gzip.NewReader(bytes.NewBuffer(b))
-	if err != nil {
-		return nil, err
-	}
+// DecompressDictCtor creates a decompression algorithm that uses a dictionary.
+type DecompressDictCtor func(dict []byte) (DecompressFunc, error)
 
-	outBuf := bytes.Buffer{}
-	if _, err = io.Copy(&outBuf, r); err != nil {
-		r.Close()
-		return nil, err
+var decompressDictImpls = map[string]DecompressDictCtor{}
+
+// AddDecompressDictCtor adds a decompression algorithm that supports
+// dictionaries to components. The return struct serves no purpose other than
+// allowing you to call it within the global context as an assignment.
+func AddDecompressDictCtor(name string, ctor DecompressDictCtor) struct{} {
+	decompressImplsLock.Lock()
+	decompressDictImpls[name] = ctor
+	decompressImplsLock.Unlock()
+	return struct{}{}
+}
+
+func strToDictDecompressor(str string, dict []byte) (DecompressFunc, error) {
+	ctor, exists := decompressDictImpls[str]
+	if !exists {
+		return nil, fmt.Errorf("decompression type %v does not support dictionaries", str)
 	}
-	r.Close()
-	return outBuf.Bytes(), nil
-})
+	return ctor(dict)
+}
+
+var gzipReaderPool = &decompressReaderPool{
+	newFn: func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	resetFn: func(rc io.ReadCloser, r io.Reader) error {
+		return rc.(*gzip.Reader).Reset(r)
+	},
+}
+
+var _ = AddDecompressFunc("gzip", gzipReaderPool.decompress)
 
 var _ = AddDecompressFunc("pgzip", func(b []byte) ([]byte, error) {
 	r, err := pgzip.NewReader(bytes.NewBuffer(b))
@@ -273,32 +341,27 @@ var _ = AddDecompressFunc("snappy", func(b []byte) ([]byte, error) {
 	return snappy.Decode(nil, b)
 })
 
-var _ = AddDecompressFunc("zlib", func(b []byte) ([]byte, error) {
-	r, err := zlib.NewReader(bytes.NewBuffer(b))
-	if err != nil {
-		return nil, err
-	}
+var zlibReaderPool = &decompressReaderPool{
+	newFn: func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	},
+	resetFn: func(rc io.ReadCloser, r io.Reader) error {
+		return rc.(zlib.Resetter).Reset(r, nil)
+	},
+}
 
-	outBuf := bytes.Buffer{}
-	if _, err = io.Copy(&outBuf, r); err != nil {
-		r.Close()
-		return nil, err
-	}
-	r.Close()
-	return outBuf.Bytes(), nil
-})
+var _ = AddDecompressFunc("zlib", zlibReaderPool.decompress)
 
-var _ = AddDecompressFunc("flate", func(b []byte) ([]byte, error) {
-	r := flate.NewReader(bytes.NewBuffer(b))
+var flateReaderPool = &decompressReaderPool{
+	newFn: func(r io.Reader) (io.ReadCloser, error) {
+		return flate.NewReader(r), nil
+	},
+	resetFn: func(rc io.ReadCloser, r io.Reader) error {
+		return rc.(flate.Resetter).Reset(r, nil)
+	},
+}
 
-	outBuf := bytes.Buffer{}
-	if _, err := io.Copy(&outBuf, r); err != nil {
-		r.Close()
-		return nil, err
-	}
-	r.Close()
-	return outBuf.Bytes(), nil
-})
+var _ = AddDecompressFunc("flate", flateReaderPool.decompress)
 
 var _ = AddDecompressFunc("bzip2", func(b []byte) ([]byte, error) {
 	r := bzip2.NewReader(bytes.NewBuffer(b))
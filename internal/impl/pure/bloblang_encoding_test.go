@@ -40,3 +40,28 @@ func TestCompressionDecompression(t *testing.T) {
 		assert.Equal(t, input, decompressed)
 	}
 }
+
+func TestDecompressAuto(t *testing.T) {
+	input := []byte("hello world this is a really long string")
+
+	for _, alg := range []string{`gzip`, `pgzip`, `lz4`, `zlib`} {
+		compressFn, err := strToCompressor(alg)
+		require.NoError(t, err)
+
+		compressed, err := compressFn(-1, input)
+		require.NoError(t, err)
+
+		exec, err := bloblang.Parse(`root = this.decompress("auto")`)
+		require.NoError(t, err)
+
+		decompressed, err := exec.Query(compressed)
+		require.NoError(t, err, alg)
+		assert.Equal(t, input, decompressed, alg)
+	}
+
+	exec, err := bloblang.Parse(`root = this.decompress("auto")`)
+	require.NoError(t, err)
+
+	_, err = exec.Query(input)
+	require.EqualError(t, err, "failed assignment (line 1): unable to detect compression format")
+}
@@ -0,0 +1,758 @@
+package pure
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// The mean radius of the earth in meters as defined by the IUGG.
+const earthRadiusMeters = 6371008.8
+
+var geoDistanceUnits = map[string]float64{
+	"m":   1,
+	"km":  1000,
+	"mi":  1609.344,
+	"nmi": 1852,
+}
+
+// geoPointFrom extracts a latitude and longitude from an object containing
+// the fields lat and lon (or lng), a GeoJSON point, or an array in GeoJSON
+// coordinate order [lon, lat].
+func geoPointFrom(v any) (lat, lon float64, err error) {
+	switch t := v.(type) {
+	case map[string]any:
+		if typ, _ := t["type"].(string); typ != "" {
+			if typ != "Point" {
+				return 0, 0, fmt.Errorf("expected GeoJSON geometry of type Point, got %v", typ)
+			}
+			return geoPointFrom(t["coordinates"])
+		}
+		latV, exists := t["lat"]
+		if !exists {
+			return 0, 0, errors.New("expected object to contain a field lat")
+		}
+		lonV, exists := t["lon"]
+		if !exists {
+			if lonV, exists = t["lng"]; !exists {
+				return 0, 0, errors.New("expected object to contain a field lon")
+			}
+		}
+		if lat, err = query.IGetNumber(latV); err != nil {
+			return 0, 0, fmt.Errorf("field lat: %w", err)
+		}
+		if lon, err = query.IGetNumber(lonV); err != nil {
+			return 0, 0, fmt.Errorf("field lon: %w", err)
+		}
+	case []any:
+		if len(t) < 2 {
+			return 0, 0, fmt.Errorf("expected array of [lon, lat] coordinates, got %v elements", len(t))
+		}
+		if lon, err = query.IGetNumber(t[0]); err != nil {
+			return 0, 0, fmt.Errorf("longitude: %w", err)
+		}
+		if lat, err = query.IGetNumber(t[1]); err != nil {
+			return 0, 0, fmt.Errorf("latitude: %w", err)
+		}
+	default:
+		return 0, 0, query.NewTypeError(v, query.ValueObject, query.ValueArray)
+	}
+	if lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("latitude %v is out of range [-90, 90]", lat)
+	}
+	if lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("longitude %v is out of range [-180, 180]", lon)
+	}
+	return lat, lon, nil
+}
+
+func geohashEncode(lat, lon float64, precision int) string {
+	latRange, lonRange := [2]float64{-90, 90}, [2]float64{-180, 180}
+
+	var b strings.Builder
+	var bits, ch int
+	even := true
+	for b.Len() < precision {
+		target, r := lon, &lonRange
+		if !even {
+			target, r = lat, &latRange
+		}
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+		if target >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+		if bits++; bits == 5 {
+			b.WriteByte(geohashAlphabet[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return b.String()
+}
+
+func geohashDecode(hash string) (latRange, lonRange [2]float64, err error) {
+	latRange, lonRange = [2]float64{-90, 90}, [2]float64{-180, 180}
+	if hash == "" {
+		return latRange, lonRange, errors.New("geohash is empty")
+	}
+
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		idx := strings.IndexRune(geohashAlphabet, c)
+		if idx < 0 {
+			return latRange, lonRange, fmt.Errorf("invalid geohash character: %q", c)
+		}
+		for i := 4; i >= 0; i-- {
+			r := &lonRange
+			if !even {
+				r = &latRange
+			}
+			mid := (r[0] + r[1]) / 2
+			if idx&(1<<i) != 0 {
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+	}
+	return latRange, lonRange, nil
+}
+
+func haversineMeters(latA, lonA, latB, lonB float64) float64 {
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+
+	dLat := toRad(latB - latA)
+	dLon := toRad(lonB - lonA)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(latA))*math.Cos(toRad(latB))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+//------------------------------------------------------------------------------
+
+// geoPolygon is a series of rings, where the first is the exterior boundary
+// and the remainder are holes.
+type geoPolygon [][][2]float64
+
+func geoRingFrom(v any) ([][2]float64, error) {
+	arr, ok := v.([]any)
+	if !ok {
+		return nil, query.NewTypeError(v, query.ValueArray)
+	}
+	ring := make([][2]float64, 0, len(arr))
+	for i, p := range arr {
+		lat, lon, err := geoPointFrom(p)
+		if err != nil {
+			return nil, fmt.Errorf("position %v: %w", i, err)
+		}
+		ring = append(ring, [2]float64{lon, lat})
+	}
+	if len(ring) < 3 {
+		return nil, fmt.Errorf("expected a ring of at least three positions, got %v", len(ring))
+	}
+	return ring, nil
+}
+
+func geoPolygonFrom(v any) (geoPolygon, error) {
+	arr, ok := v.([]any)
+	if !ok {
+		return nil, query.NewTypeError(v, query.ValueArray)
+	}
+	var p geoPolygon
+	for i, r := range arr {
+		ring, err := geoRingFrom(r)
+		if err != nil {
+			return nil, fmt.Errorf("ring %v: %w", i, err)
+		}
+		p = append(p, ring)
+	}
+	if len(p) == 0 {
+		return nil, errors.New("expected polygon to contain at least one ring")
+	}
+	return p, nil
+}
+
+// geoPolygonsFrom extracts polygons from a GeoJSON Polygon, MultiPolygon or
+// Feature, or from an array of positions describing a single ring.
+func geoPolygonsFrom(v any) ([]geoPolygon, error) {
+	switch t := v.(type) {
+	case map[string]any:
+		typ, _ := t["type"].(string)
+		switch typ {
+		case "Feature":
+			return geoPolygonsFrom(t["geometry"])
+		case "Polygon":
+			p, err := geoPolygonFrom(t["coordinates"])
+			if err != nil {
+				return nil, err
+			}
+			return []geoPolygon{p}, nil
+		case "MultiPolygon":
+			arr, ok := t["coordinates"].([]any)
+			if !ok {
+				return nil, query.NewTypeError(t["coordinates"], query.ValueArray)
+			}
+			var polys []geoPolygon
+			for i, pV := range arr {
+				p, err := geoPolygonFrom(pV)
+				if err != nil {
+					return nil, fmt.Errorf("polygon %v: %w", i, err)
+				}
+				polys = append(polys, p)
+			}
+			return polys, nil
+		}
+		return nil, fmt.Errorf("expected GeoJSON geometry of type Polygon or MultiPolygon, got %v", typ)
+	case []any:
+		ring, err := geoRingFrom(t)
+		if err != nil {
+			return nil, err
+		}
+		return []geoPolygon{{ring}}, nil
+	}
+	return nil, query.NewTypeError(v, query.ValueObject, query.ValueArray)
+}
+
+func geoRingContains(ring [][2]float64, lon, lat float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func (p geoPolygon) contains(lon, lat float64) bool {
+	if !geoRingContains(p[0], lon, lat) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if geoRingContains(hole, lon, lat) {
+			return false
+		}
+	}
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+var wktGeometryTypes = map[string]string{
+	"POINT":              "Point",
+	"LINESTRING":         "LineString",
+	"POLYGON":            "Polygon",
+	"MULTIPOINT":         "MultiPoint",
+	"MULTILINESTRING":    "MultiLineString",
+	"MULTIPOLYGON":       "MultiPolygon",
+	"GEOMETRYCOLLECTION": "GeometryCollection",
+}
+
+type wktParser struct {
+	tokens []string
+	i      int
+}
+
+func tokenizeWKT(s string) []string {
+	var tokens []string
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			j := i
+			for j < len(s) && !unicode.IsSpace(rune(s[j])) && s[j] != '(' && s[j] != ')' && s[j] != ',' {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+func (p *wktParser) peek() string {
+	if p.i >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.i]
+}
+
+func (p *wktParser) next() string {
+	t := p.peek()
+	p.i++
+	return t
+}
+
+func (p *wktParser) expect(tok string) error {
+	if got := p.next(); got != tok {
+		if got == "" {
+			return fmt.Errorf("expected '%v', got end of input", tok)
+		}
+		return fmt.Errorf("expected '%v', got '%v'", tok, got)
+	}
+	return nil
+}
+
+func (p *wktParser) position() ([]any, error) {
+	var pos []any
+	for {
+		f, err := strconv.ParseFloat(p.peek(), 64)
+		if err != nil {
+			break
+		}
+		p.i++
+		pos = append(pos, f)
+	}
+	if len(pos) < 2 {
+		return nil, fmt.Errorf("expected a position of at least two coordinates, got '%v'", p.peek())
+	}
+	return pos, nil
+}
+
+// list parses a parenthesised, comma separated list of elements.
+func (p *wktParser) list(elem func() (any, error)) ([]any, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var items []any
+	for {
+		v, err := elem()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+		if p.peek() != "," {
+			break
+		}
+		p.i++
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (p *wktParser) positionList() (any, error) {
+	return p.list(func() (any, error) { return p.position() })
+}
+
+func (p *wktParser) ringList() (any, error) {
+	return p.list(p.positionList)
+}
+
+func (p *wktParser) geometry() (map[string]any, error) {
+	word := strings.ToUpper(p.next())
+	typ, exists := wktGeometryTypes[word]
+	if !exists {
+		return nil, fmt.Errorf("unrecognised geometry type: %v", word)
+	}
+	switch strings.ToUpper(p.peek()) {
+	case "Z", "M", "ZM":
+		p.i++
+	}
+
+	if strings.ToUpper(p.peek()) == "EMPTY" {
+		p.i++
+		if typ == "GeometryCollection" {
+			return map[string]any{"type": typ, "geometries": []any{}}, nil
+		}
+		return map[string]any{"type": typ, "coordinates": []any{}}, nil
+	}
+
+	var coords any
+	var err error
+	switch typ {
+	case "Point":
+		if err = p.expect("("); err != nil {
+			return nil, err
+		}
+		if coords, err = p.position(); err != nil {
+			return nil, err
+		}
+		err = p.expect(")")
+	case "LineString":
+		coords, err = p.positionList()
+	case "Polygon", "MultiLineString":
+		coords, err = p.ringList()
+	case "MultiPoint":
+		// Both MULTIPOINT (1 2, 3 4) and MULTIPOINT ((1 2), (3 4)) are valid.
+		coords, err = p.list(func() (any, error) {
+			if p.peek() == "(" {
+				p.i++
+				pos, err := p.position()
+				if err != nil {
+					return nil, err
+				}
+				return pos, p.expect(")")
+			}
+			return p.position()
+		})
+	case "MultiPolygon":
+		coords, err = p.list(p.ringList)
+	case "GeometryCollection":
+		geoms, err := p.list(func() (any, error) { return p.geometry() })
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": typ, "geometries": geoms}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"type": typ, "coordinates": coords}, nil
+}
+
+func parseWKT(s string) (map[string]any, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(strings.ToUpper(s), "SRID=") {
+		if i := strings.IndexByte(s, ';'); i >= 0 {
+			s = s[i+1:]
+		}
+	}
+	p := &wktParser{tokens: tokenizeWKT(s)}
+	g, err := p.geometry()
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected trailing content: %v", p.peek())
+	}
+	return g, nil
+}
+
+func writeWKTPosition(b *strings.Builder, v any) error {
+	arr, ok := v.([]any)
+	if !ok {
+		return query.NewTypeError(v, query.ValueArray)
+	}
+	if len(arr) < 2 {
+		return fmt.Errorf("expected a position of at least two coordinates, got %v", len(arr))
+	}
+	for i, c := range arr {
+		f, err := query.IGetNumber(c)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+	}
+	return nil
+}
+
+// writeWKTList writes a parenthesised list of coordinates nested depth times,
+// where a depth of zero is a single position.
+func writeWKTList(b *strings.Builder, v any, depth int) error {
+	if depth == 0 {
+		return writeWKTPosition(b, v)
+	}
+	arr, ok := v.([]any)
+	if !ok {
+		return query.NewTypeError(v, query.ValueArray)
+	}
+	b.WriteByte('(')
+	for i, e := range arr {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if err := writeWKTList(b, e, depth-1); err != nil {
+			return err
+		}
+	}
+	b.WriteByte(')')
+	return nil
+}
+
+func writeWKT(b *strings.Builder, v any) error {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return query.NewTypeError(v, query.ValueObject)
+	}
+	typ, _ := obj["type"].(string)
+	if typ == "Feature" {
+		return writeWKT(b, obj["geometry"])
+	}
+
+	var word string
+	for k, t := range wktGeometryTypes {
+		if t == typ {
+			word = k
+		}
+	}
+	if word == "" {
+		return fmt.Errorf("unrecognised GeoJSON geometry type: %v", typ)
+	}
+	b.WriteString(word)
+
+	if typ == "GeometryCollection" {
+		geoms, ok := obj["geometries"].([]any)
+		if !ok {
+			return query.NewTypeError(obj["geometries"], query.ValueArray)
+		}
+		if len(geoms) == 0 {
+			b.WriteString(" EMPTY")
+			return nil
+		}
+		b.WriteString(" (")
+		for i, g := range geoms {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			if err := writeWKT(b, g); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(')')
+		return nil
+	}
+
+	coords, ok := obj["coordinates"].([]any)
+	if !ok {
+		return query.NewTypeError(obj["coordinates"], query.ValueArray)
+	}
+	if len(coords) == 0 {
+		b.WriteString(" EMPTY")
+		return nil
+	}
+	b.WriteByte(' ')
+
+	switch typ {
+	case "Point":
+		b.WriteByte('(')
+		if err := writeWKTPosition(b, coords); err != nil {
+			return err
+		}
+		b.WriteByte(')')
+		return nil
+	case "LineString", "MultiPoint":
+		return writeWKTList(b, coords, 1)
+	case "Polygon", "MultiLineString":
+		return writeWKTList(b, coords, 2)
+	}
+	return writeWKTList(b, coords, 3)
+}
+
+//------------------------------------------------------------------------------
+
+func init() {
+	if err := bloblang.RegisterMethodV2("geohash_encode",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryGeospatial).
+			Version("4.20.0").
+			Description("Encodes a geographic point as a [geohash](https://en.wikipedia.org/wiki/Geohash) string. Points can be objects containing the fields `lat` and `lon` (or `lng`), GeoJSON points, or arrays of the form `[lon, lat]`.").
+			Param(bloblang.NewInt64Param("precision").Description("The number of characters of the resulting geohash, between 1 and 12.").Default(12)).
+			Example("",
+				`root.hash = this.location.geohash_encode(7)`,
+				[2]string{
+					`{"location":{"lat":51.5007,"lon":-0.1246}}`,
+					`{"hash":"gcpuvpm"}`,
+				},
+			),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			precision, err := args.GetInt64("precision")
+			if err != nil {
+				return nil, err
+			}
+			if precision < 1 || precision > 12 {
+				return nil, fmt.Errorf("precision must be between 1 and 12, got %v", precision)
+			}
+			return func(v any) (any, error) {
+				lat, lon, err := geoPointFrom(v)
+				if err != nil {
+					return nil, err
+				}
+				return geohashEncode(lat, lon, int(precision)), nil
+			}, nil
+		}); err != nil {
+		panic(err)
+	}
+
+	if err := bloblang.RegisterMethodV2("geohash_decode",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryGeospatial).
+			Version("4.20.0").
+			Description("Decodes a [geohash](https://en.wikipedia.org/wiki/Geohash) string into an object containing the fields `lat` and `lon` located at the center of the geohash cell, and the field `bounds` describing the extents of the cell.").
+			Example("",
+				`root.location = this.hash.geohash_decode().without("bounds")`,
+				[2]string{
+					`{"hash":"u4pruydqqvj"}`,
+					`{"location":{"lat":57.64911063015461,"lon":10.407439693808556}}`,
+				},
+			),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			return bloblang.StringMethod(func(s string) (any, error) {
+				latRange, lonRange, err := geohashDecode(s)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{
+					"lat": (latRange[0] + latRange[1]) / 2,
+					"lon": (lonRange[0] + lonRange[1]) / 2,
+					"bounds": map[string]any{
+						"min_lat": latRange[0],
+						"max_lat": latRange[1],
+						"min_lon": lonRange[0],
+						"max_lon": lonRange[1],
+					},
+				}, nil
+			}), nil
+		}); err != nil {
+		panic(err)
+	}
+
+	if err := bloblang.RegisterMethodV2("geo_distance",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryGeospatial).
+			Version("4.20.0").
+			Description("Calculates the great-circle distance between two geographic points using the haversine formula. Points can be objects containing the fields `lat` and `lon` (or `lng`), GeoJSON points, or arrays of the form `[lon, lat]`.").
+			Param(bloblang.NewAnyParam("point").Description("The point to measure the distance to.")).
+			Param(bloblang.NewStringParam("unit").Description("The unit of the result, one of `m`, `km`, `mi` or `nmi`.").Default("m")).
+			Example("",
+				`root.distance = this.from.geo_distance(this.to, "km").round()`,
+				[2]string{
+					`{"from":{"lat":51.5007,"lon":-0.1246},"to":{"lat":48.8584,"lon":2.2945}}`,
+					`{"distance":341}`,
+				},
+			),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			pointV, err := args.Get("point")
+			if err != nil {
+				return nil, err
+			}
+			toLat, toLon, err := geoPointFrom(pointV)
+			if err != nil {
+				return nil, fmt.Errorf("point: %w", err)
+			}
+			unitStr, err := args.GetString("unit")
+			if err != nil {
+				return nil, err
+			}
+			unit, exists := geoDistanceUnits[unitStr]
+			if !exists {
+				return nil, fmt.Errorf("unrecognised distance unit: %v", unitStr)
+			}
+			return func(v any) (any, error) {
+				lat, lon, err := geoPointFrom(v)
+				if err != nil {
+					return nil, err
+				}
+				return haversineMeters(lat, lon, toLat, toLon) / unit, nil
+			}, nil
+		}); err != nil {
+		panic(err)
+	}
+
+	if err := bloblang.RegisterMethodV2("geo_within",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryGeospatial).
+			Version("4.20.0").
+			Description("Checks whether a geographic point lies within a polygon. The polygon can be a GeoJSON Polygon, MultiPolygon or Feature, in which case holes are respected, or an array of positions of the form `[lon, lat]` describing its boundary. Points are treated as planar coordinates and therefore polygons should not cross the antimeridian.").
+			Param(bloblang.NewAnyParam("polygon").Description("The polygon to check the point against.")).
+			Example("",
+				`root.in_zone = this.location.geo_within([[-0.2, 51.4], [0.0, 51.4], [0.0, 51.6], [-0.2, 51.6]])`,
+				[2]string{
+					`{"location":{"lat":51.5007,"lon":-0.1246}}`,
+					`{"in_zone":true}`,
+				},
+				[2]string{
+					`{"location":{"lat":48.8584,"lon":2.2945}}`,
+					`{"in_zone":false}`,
+				},
+			),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			polyV, err := args.Get("polygon")
+			if err != nil {
+				return nil, err
+			}
+			polys, err := geoPolygonsFrom(polyV)
+			if err != nil {
+				return nil, fmt.Errorf("polygon: %w", err)
+			}
+			return func(v any) (any, error) {
+				lat, lon, err := geoPointFrom(v)
+				if err != nil {
+					return nil, err
+				}
+				for _, p := range polys {
+					if p.contains(lon, lat) {
+						return true, nil
+					}
+				}
+				return false, nil
+			}, nil
+		}); err != nil {
+		panic(err)
+	}
+
+	if err := bloblang.RegisterMethodV2("parse_wkt",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryGeospatial).
+			Version("4.20.0").
+			Description("Parses a [Well-Known Text](https://en.wikipedia.org/wiki/Well-known_text_representation_of_geometry) geometry string into a GeoJSON geometry object. All geometry types are supported, and an `SRID` prefix as used by Extended Well-Known Text is ignored.").
+			Example("",
+				`root.geometry = this.wkt.parse_wkt()`,
+				[2]string{
+					`{"wkt":"POINT (30 10)"}`,
+					`{"geometry":{"coordinates":[30,10],"type":"Point"}}`,
+				},
+				[2]string{
+					`{"wkt":"POLYGON ((30 10, 40 40, 20 40, 30 10))"}`,
+					`{"geometry":{"coordinates":[[[30,10],[40,40],[20,40],[30,10]]],"type":"Polygon"}}`,
+				},
+			),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			return bloblang.StringMethod(func(s string) (any, error) {
+				g, err := parseWKT(s)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse WKT: %w", err)
+				}
+				return g, nil
+			}), nil
+		}); err != nil {
+		panic(err)
+	}
+
+	if err := bloblang.RegisterMethodV2("format_wkt",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryGeospatial).
+			Version("4.20.0").
+			Description("Formats a GeoJSON geometry or feature object as a [Well-Known Text](https://en.wikipedia.org/wiki/Well-known_text_representation_of_geometry) string.").
+			Example("",
+				`root.wkt = this.geometry.format_wkt()`,
+				[2]string{
+					`{"geometry":{"type":"LineString","coordinates":[[30,10],[10,30],[40,40]]}}`,
+					`{"wkt":"LINESTRING (30 10, 10 30, 40 40)"}`,
+				},
+			),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			return func(v any) (any, error) {
+				var b strings.Builder
+				if err := writeWKT(&b, v); err != nil {
+					return nil, fmt.Errorf("failed to format WKT: %w", err)
+				}
+				return b.String(), nil
+			}, nil
+		}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,230 @@
+package pure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestGeoMethods(t *testing.T) {
+	tests := []struct {
+		name               string
+		mapping            string
+		input              any
+		output             any
+		parseErrorContains string
+		execErrorContains  string
+	}{
+		{
+			name:    "geohash encode object",
+			mapping: `root = this.geohash_encode(11)`,
+			input:   map[string]any{"lat": 57.64911, "lon": 10.40744},
+			output:  "u4pruydqqvj",
+		},
+		{
+			name:    "geohash encode array",
+			mapping: `root = this.geohash_encode(5)`,
+			input:   []any{10.40744, 57.64911},
+			output:  "u4pru",
+		},
+		{
+			name:    "geohash encode geojson",
+			mapping: `root = this.geohash_encode(3)`,
+			input:   map[string]any{"type": "Point", "coordinates": []any{10.40744, 57.64911}},
+			output:  "u4p",
+		},
+		{
+			name:               "geohash encode bad precision",
+			mapping:            `root = this.geohash_encode(13)`,
+			parseErrorContains: "precision must be between 1 and 12",
+		},
+		{
+			name:              "geohash encode out of range",
+			mapping:           `root = this.geohash_encode()`,
+			input:             map[string]any{"lat": 91, "lon": 0},
+			execErrorContains: "latitude 91 is out of range",
+		},
+		{
+			name:              "geohash encode missing field",
+			mapping:           `root = this.geohash_encode()`,
+			input:             map[string]any{"lat": 1},
+			execErrorContains: "expected object to contain a field lon",
+		},
+		{
+			name:    "geohash decode bounds",
+			mapping: `root = this.geohash_decode().bounds`,
+			input:   "ezs42",
+			output: map[string]any{
+				"min_lat": 42.5830078125,
+				"max_lat": 42.626953125,
+				"min_lon": -5.625,
+				"max_lon": -5.5810546875,
+			},
+		},
+		{
+			name:              "geohash decode invalid",
+			mapping:           `root = this.geohash_decode()`,
+			input:             "abc",
+			execErrorContains: "invalid geohash character: 'a'",
+		},
+		{
+			name:    "geo distance",
+			mapping: `root = this.a.geo_distance(this.b, "nmi").round()`,
+			input: map[string]any{
+				"a": map[string]any{"lat": 51.5007, "lon": -0.1246},
+				"b": map[string]any{"lat": 40.6892, "lng": -74.0445},
+			},
+			output: int64(3010),
+		},
+		{
+			name:    "geo distance same point",
+			mapping: `root = this.geo_distance(this)`,
+			input:   map[string]any{"lat": 10, "lon": 10},
+			output:  float64(0),
+		},
+		{
+			name:               "geo distance bad unit",
+			mapping:            `root = this.geo_distance([0, 0], "furlongs")`,
+			parseErrorContains: "unrecognised distance unit: furlongs",
+		},
+		{
+			name:    "geo within polygon with hole",
+			mapping: `root = [this.a, this.b, this.c].map_each(p -> p.geo_within(this.poly))`,
+			input: map[string]any{
+				"a": []any{1.0, 1.0},
+				"b": []any{5.0, 5.0},
+				"c": []any{11.0, 5.0},
+				"poly": map[string]any{
+					"type": "Polygon",
+					"coordinates": []any{
+						[]any{[]any{0, 0}, []any{10, 0}, []any{10, 10}, []any{0, 10}, []any{0, 0}},
+						[]any{[]any{4, 4}, []any{6, 4}, []any{6, 6}, []any{4, 6}, []any{4, 4}},
+					},
+				},
+			},
+			output: []any{true, false, false},
+		},
+		{
+			name:    "geo within multipolygon feature",
+			mapping: `root = this.p.geo_within(this.f)`,
+			input: map[string]any{
+				"p": []any{21.0, 21.0},
+				"f": map[string]any{
+					"type": "Feature",
+					"geometry": map[string]any{
+						"type": "MultiPolygon",
+						"coordinates": []any{
+							[]any{[]any{[]any{0, 0}, []any{1, 0}, []any{1, 1}, []any{0, 0}}},
+							[]any{[]any{[]any{20, 20}, []any{22, 20}, []any{22, 22}, []any{20, 22}, []any{20, 20}}},
+						},
+					},
+				},
+			},
+			output: true,
+		},
+		{
+			name:               "geo within bad polygon",
+			mapping:            `root = this.geo_within([[0, 0], [1, 1]])`,
+			parseErrorContains: "expected a ring of at least three positions",
+		},
+		{
+			name:    "parse wkt multipolygon",
+			mapping: `root = this.parse_wkt()`,
+			input:   "SRID=4326;MULTIPOLYGON (((40 40, 20 45, 45 30, 40 40)), ((20 35, 10 30, 20 35), (30 20, 20 15, 30 20)))",
+			output: map[string]any{
+				"type": "MultiPolygon",
+				"coordinates": []any{
+					[]any{
+						[]any{[]any{40.0, 40.0}, []any{20.0, 45.0}, []any{45.0, 30.0}, []any{40.0, 40.0}},
+					},
+					[]any{
+						[]any{[]any{20.0, 35.0}, []any{10.0, 30.0}, []any{20.0, 35.0}},
+						[]any{[]any{30.0, 20.0}, []any{20.0, 15.0}, []any{30.0, 20.0}},
+					},
+				},
+			},
+		},
+		{
+			name:    "parse wkt multipoint both forms",
+			mapping: `root = [this.index(0).parse_wkt(), this.index(1).parse_wkt()]`,
+			input:   []any{"MULTIPOINT ((10 40), (40 30))", "multipoint (10 40, 40 30)"},
+			output: []any{
+				map[string]any{"type": "MultiPoint", "coordinates": []any{[]any{10.0, 40.0}, []any{40.0, 30.0}}},
+				map[string]any{"type": "MultiPoint", "coordinates": []any{[]any{10.0, 40.0}, []any{40.0, 30.0}}},
+			},
+		},
+		{
+			name:    "parse wkt collection",
+			mapping: `root = this.parse_wkt()`,
+			input:   "GEOMETRYCOLLECTION (POINT Z (40 10 5), LINESTRING EMPTY)",
+			output: map[string]any{
+				"type": "GeometryCollection",
+				"geometries": []any{
+					map[string]any{"type": "Point", "coordinates": []any{40.0, 10.0, 5.0}},
+					map[string]any{"type": "LineString", "coordinates": []any{}},
+				},
+			},
+		},
+		{
+			name:              "parse wkt unknown type",
+			mapping:           `root = this.parse_wkt()`,
+			input:             "CIRCLE (1 2)",
+			execErrorContains: "unrecognised geometry type: CIRCLE",
+		},
+		{
+			name:              "parse wkt unterminated",
+			mapping:           `root = this.parse_wkt()`,
+			input:             "LINESTRING (1 2, 3 4",
+			execErrorContains: "expected ')', got end of input",
+		},
+		{
+			name:    "wkt round trip",
+			mapping: `root = this.parse_wkt().format_wkt()`,
+			input:   "POLYGON ((35 10, 45 45, 15 40, 10 20, 35 10), (20 30, 35 35, 30 20, 20 30))",
+			output:  "POLYGON ((35 10, 45 45, 15 40, 10 20, 35 10), (20 30, 35 35, 30 20, 20 30))",
+		},
+		{
+			name:    "format wkt collection",
+			mapping: `root = this.format_wkt()`,
+			input: map[string]any{
+				"type": "GeometryCollection",
+				"geometries": []any{
+					map[string]any{"type": "Point", "coordinates": []any{1.5, 2}},
+					map[string]any{"type": "MultiPoint", "coordinates": []any{[]any{1, 2}, []any{3, 4}}},
+				},
+			},
+			output: "GEOMETRYCOLLECTION (POINT (1.5 2), MULTIPOINT (1 2, 3 4))",
+		},
+		{
+			name:              "format wkt bad type",
+			mapping:           `root = this.format_wkt()`,
+			input:             map[string]any{"type": "Circle", "coordinates": []any{}},
+			execErrorContains: "unrecognised GeoJSON geometry type: Circle",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			exec, err := bloblang.Parse(test.mapping)
+			if test.parseErrorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.parseErrorContains)
+				return
+			}
+			require.NoError(t, err)
+
+			res, err := exec.Query(test.input)
+			if test.execErrorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.execErrorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.output, res)
+		})
+	}
+}
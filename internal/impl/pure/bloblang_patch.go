@@ -0,0 +1,479 @@
+package pure
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffJSONPatch appends RFC 6902 operations that transform from into to.
+// Arrays are compared by index, with trailing elements added or removed.
+func diffJSONPatch(ops []any, path string, from, to any) []any {
+	switch f := from.(type) {
+	case map[string]any:
+		if t, ok := to.(map[string]any); ok {
+			for _, k := range sortedKeys(f) {
+				childPath := path + "/" + jsonPointerEscaper.Replace(k)
+				if tv, exists := t[k]; exists {
+					ops = diffJSONPatch(ops, childPath, f[k], tv)
+				} else {
+					ops = append(ops, map[string]any{"op": "remove", "path": childPath})
+				}
+			}
+			for _, k := range sortedKeys(t) {
+				if _, exists := f[k]; !exists {
+					ops = append(ops, map[string]any{
+						"op":    "add",
+						"path":  path + "/" + jsonPointerEscaper.Replace(k),
+						"value": query.IClone(t[k]),
+					})
+				}
+			}
+			return ops
+		}
+	case []any:
+		if t, ok := to.([]any); ok {
+			common := len(f)
+			if len(t) < common {
+				common = len(t)
+			}
+			for i := 0; i < common; i++ {
+				ops = diffJSONPatch(ops, path+"/"+strconv.Itoa(i), f[i], t[i])
+			}
+			for i := len(f) - 1; i >= common; i-- {
+				ops = append(ops, map[string]any{"op": "remove", "path": path + "/" + strconv.Itoa(i)})
+			}
+			for i := common; i < len(t); i++ {
+				ops = append(ops, map[string]any{
+					"op":    "add",
+					"path":  path + "/" + strconv.Itoa(i),
+					"value": query.IClone(t[i]),
+				})
+			}
+			return ops
+		}
+	}
+	if query.ITypeOf(from) == query.ITypeOf(to) && query.ICompare(from, to) {
+		return ops
+	}
+	return append(ops, map[string]any{"op": "replace", "path": path, "value": query.IClone(to)})
+}
+
+// diffMergePatch returns an RFC 7386 merge patch that transforms from into to,
+// and a boolean indicating whether the values differ at all.
+func diffMergePatch(from, to any) (any, bool) {
+	f, fok := from.(map[string]any)
+	t, tok := to.(map[string]any)
+	if !fok || !tok {
+		if query.ITypeOf(from) == query.ITypeOf(to) && query.ICompare(from, to) {
+			return nil, false
+		}
+		return query.IClone(to), true
+	}
+
+	patch := map[string]any{}
+	for k, fv := range f {
+		tv, exists := t[k]
+		if !exists {
+			patch[k] = nil
+			continue
+		}
+		if p, changed := diffMergePatch(fv, tv); changed {
+			patch[k] = p
+		}
+	}
+	for k, tv := range t {
+		if _, exists := f[k]; !exists {
+			patch[k] = query.IClone(tv)
+		}
+	}
+	return patch, len(patch) > 0
+}
+
+func applyMergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return query.IClone(patch)
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = applyMergePatch(t[k], v)
+	}
+	return t
+}
+
+//------------------------------------------------------------------------------
+
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer '%v': must be empty or begin with /", pointer)
+	}
+	segments := strings.Split(pointer[1:], "/")
+	for i, s := range segments {
+		segments[i] = jsonPointerUnescaper.Replace(s)
+	}
+	return segments, nil
+}
+
+func jsonPointerIndex(seg string, length int, allowEnd bool) (int, error) {
+	if allowEnd && seg == "-" {
+		return length, nil
+	}
+	if seg == "" || (len(seg) > 1 && seg[0] == '0') {
+		return 0, fmt.Errorf("invalid array index: %v", seg)
+	}
+	i, err := strconv.Atoi(seg)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid array index: %v", seg)
+	}
+	limit := length - 1
+	if allowEnd {
+		limit = length
+	}
+	if i > limit {
+		return 0, fmt.Errorf("array index %v out of bounds", i)
+	}
+	return i, nil
+}
+
+func jsonPointerGet(root any, segments []string) (any, error) {
+	current := root
+	for _, seg := range segments {
+		switch t := current.(type) {
+		case map[string]any:
+			v, exists := t[seg]
+			if !exists {
+				return nil, fmt.Errorf("key %v does not exist", seg)
+			}
+			current = v
+		case []any:
+			i, err := jsonPointerIndex(seg, len(t), false)
+			if err != nil {
+				return nil, err
+			}
+			current = t[i]
+		default:
+			return nil, fmt.Errorf("cannot index %v with %v", query.ITypeOf(current), seg)
+		}
+	}
+	return current, nil
+}
+
+// jsonPointerMutate resolves the parent of a pointer and replaces it with the
+// result of fn, which receives the parent container and the final segment.
+func jsonPointerMutate(root any, segments []string, fn func(parent any, seg string) (any, error)) (any, error) {
+	if len(segments) == 1 {
+		return fn(root, segments[0])
+	}
+	seg := segments[0]
+	switch t := root.(type) {
+	case map[string]any:
+		child, exists := t[seg]
+		if !exists {
+			return nil, fmt.Errorf("key %v does not exist", seg)
+		}
+		newChild, err := jsonPointerMutate(child, segments[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		t[seg] = newChild
+		return t, nil
+	case []any:
+		i, err := jsonPointerIndex(seg, len(t), false)
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := jsonPointerMutate(t[i], segments[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		t[i] = newChild
+		return t, nil
+	}
+	return nil, fmt.Errorf("cannot index %v with %v", query.ITypeOf(root), seg)
+}
+
+func jsonPatchAdd(root any, segments []string, value any) (any, error) {
+	if len(segments) == 0 {
+		return value, nil
+	}
+	return jsonPointerMutate(root, segments, func(parent any, seg string) (any, error) {
+		switch t := parent.(type) {
+		case map[string]any:
+			t[seg] = value
+			return t, nil
+		case []any:
+			i, err := jsonPointerIndex(seg, len(t), true)
+			if err != nil {
+				return nil, err
+			}
+			t = append(t, nil)
+			copy(t[i+1:], t[i:])
+			t[i] = value
+			return t, nil
+		}
+		return nil, fmt.Errorf("cannot add %v to %v", seg, query.ITypeOf(parent))
+	})
+}
+
+func jsonPatchRemove(root any, segments []string) (any, error) {
+	if len(segments) == 0 {
+		return nil, errors.New("cannot remove the root of a document")
+	}
+	return jsonPointerMutate(root, segments, func(parent any, seg string) (any, error) {
+		switch t := parent.(type) {
+		case map[string]any:
+			if _, exists := t[seg]; !exists {
+				return nil, fmt.Errorf("key %v does not exist", seg)
+			}
+			delete(t, seg)
+			return t, nil
+		case []any:
+			i, err := jsonPointerIndex(seg, len(t), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(t[:i], t[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("cannot remove %v from %v", seg, query.ITypeOf(parent))
+	})
+}
+
+func jsonPatchReplace(root any, segments []string, value any) (any, error) {
+	if len(segments) == 0 {
+		return value, nil
+	}
+	return jsonPointerMutate(root, segments, func(parent any, seg string) (any, error) {
+		switch t := parent.(type) {
+		case map[string]any:
+			if _, exists := t[seg]; !exists {
+				return nil, fmt.Errorf("key %v does not exist", seg)
+			}
+			t[seg] = value
+			return t, nil
+		case []any:
+			i, err := jsonPointerIndex(seg, len(t), false)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = value
+			return t, nil
+		}
+		return nil, fmt.Errorf("cannot replace %v of %v", seg, query.ITypeOf(parent))
+	})
+}
+
+func applyJSONPatchOp(root any, opV any) (any, error) {
+	op, ok := opV.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected operation to be an object, got %v", query.ITypeOf(opV))
+	}
+	opName, _ := op["op"].(string)
+	pathStr, ok := op["path"].(string)
+	if !ok {
+		return nil, errors.New("operation is missing a path")
+	}
+	path, err := parseJSONPointer(pathStr)
+	if err != nil {
+		return nil, err
+	}
+
+	fromPath := func() ([]string, error) {
+		fromStr, ok := op["from"].(string)
+		if !ok {
+			return nil, fmt.Errorf("%v operation is missing a from path", opName)
+		}
+		return parseJSONPointer(fromStr)
+	}
+	value := func() (any, error) {
+		v, exists := op["value"]
+		if !exists {
+			return nil, fmt.Errorf("%v operation is missing a value", opName)
+		}
+		return query.IClone(v), nil
+	}
+
+	switch opName {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return jsonPatchAdd(root, path, v)
+	case "remove":
+		return jsonPatchRemove(root, path)
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return jsonPatchReplace(root, path, v)
+	case "move":
+		from, err := fromPath()
+		if err != nil {
+			return nil, err
+		}
+		v, err := jsonPointerGet(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if root, err = jsonPatchRemove(root, from); err != nil {
+			return nil, err
+		}
+		return jsonPatchAdd(root, path, v)
+	case "copy":
+		from, err := fromPath()
+		if err != nil {
+			return nil, err
+		}
+		v, err := jsonPointerGet(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return jsonPatchAdd(root, path, query.IClone(v))
+	case "test":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		current, err := jsonPointerGet(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if query.ITypeOf(current) != query.ITypeOf(v) || !query.ICompare(current, v) {
+			return nil, fmt.Errorf("test of path %v failed", pathStr)
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unrecognised operation: %v", opName)
+}
+
+func applyJSONPatch(target any, patch []any) (any, error) {
+	root := query.IClone(target)
+	for i, op := range patch {
+		var err error
+		if root, err = applyJSONPatchOp(root, op); err != nil {
+			return nil, fmt.Errorf("operation %v: %w", i, err)
+		}
+	}
+	return root, nil
+}
+
+//------------------------------------------------------------------------------
+
+func init() {
+	if err := bloblang.RegisterMethodV2("diff",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryObjectAndArray).
+			Version("4.20.0").
+			Description("Compares the target value with another and returns a patch that transforms the target into the other value, which can be applied with the [`apply_patch`](#apply_patch) method. The format `json_patch` produces an array of [JSON Patch (RFC 6902)](https://datatracker.ietf.org/doc/html/rfc6902) operations, where arrays are compared element by element, and the format `merge_patch` produces a [JSON Merge Patch (RFC 7386)](https://datatracker.ietf.org/doc/html/rfc7386) object, where arrays are replaced entirely when they differ. Identical values result in an empty patch.").
+			Param(bloblang.NewAnyParam("other").Description("The value to compare against.")).
+			Param(bloblang.NewStringParam("format").Description("The format of the patch, either `json_patch` or `merge_patch`.").Default("json_patch")).
+			Example("",
+				`root = this.before.diff(this.after)`,
+				[2]string{
+					`{"before":{"name":"foo","tags":["a","b"],"age":10},"after":{"name":"bar","tags":["a"],"email":"bar@example.com"}}`,
+					`[{"op":"remove","path":"/age"},{"op":"replace","path":"/name","value":"bar"},{"op":"remove","path":"/tags/1"},{"op":"add","path":"/email","value":"bar@example.com"}]`,
+				},
+			).
+			Example("Changes can be detected by checking whether a patch is empty.",
+				`root.changes = this.before.diff(this.after, "merge_patch")
+root.changed = root.changes.length() > 0`,
+				[2]string{
+					`{"before":{"a":{"b":1,"c":2}},"after":{"a":{"b":1,"c":3}}}`,
+					`{"changed":true,"changes":{"a":{"c":3}}}`,
+				},
+				[2]string{
+					`{"before":{"a":{"b":1}},"after":{"a":{"b":1}}}`,
+					`{"changed":false,"changes":{}}`,
+				},
+			),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			other, err := args.Get("other")
+			if err != nil {
+				return nil, err
+			}
+			format, err := args.GetString("format")
+			if err != nil {
+				return nil, err
+			}
+			switch format {
+			case "json_patch":
+				return func(v any) (any, error) {
+					return diffJSONPatch([]any{}, "", v, other), nil
+				}, nil
+			case "merge_patch":
+				return func(v any) (any, error) {
+					patch, changed := diffMergePatch(v, other)
+					if !changed {
+						return map[string]any{}, nil
+					}
+					return patch, nil
+				}, nil
+			}
+			return nil, fmt.Errorf("unrecognised patch format: %v", format)
+		}); err != nil {
+		panic(err)
+	}
+
+	if err := bloblang.RegisterMethodV2("apply_patch",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryObjectAndArray).
+			Version("4.20.0").
+			Description("Applies a patch to the target value and returns the result, leaving the target unchanged. A patch that is an array is treated as a list of [JSON Patch (RFC 6902)](https://datatracker.ietf.org/doc/html/rfc6902) operations, where all operations including `test` are supported and any failing operation results in an error, otherwise the patch is applied as a [JSON Merge Patch (RFC 7386)](https://datatracker.ietf.org/doc/html/rfc7386).").
+			Param(bloblang.NewAnyParam("patch").Description("The patch to apply.")).
+			Example("",
+				`root = this.doc.apply_patch(this.patch)`,
+				[2]string{
+					`{"doc":{"name":"foo","tags":["a"]},"patch":[{"op":"replace","path":"/name","value":"bar"},{"op":"add","path":"/tags/-","value":"b"}]}`,
+					`{"name":"bar","tags":["a","b"]}`,
+				},
+				[2]string{
+					`{"doc":{"name":"foo","tags":["a"]},"patch":{"name":null,"age":10}}`,
+					`{"age":10,"tags":["a"]}`,
+				},
+			),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			patch, err := args.Get("patch")
+			if err != nil {
+				return nil, err
+			}
+			if ops, ok := patch.([]any); ok {
+				return func(v any) (any, error) {
+					return applyJSONPatch(v, ops)
+				}, nil
+			}
+			return func(v any) (any, error) {
+				return applyMergePatch(query.IClone(v), patch), nil
+			}, nil
+		}); err != nil {
+		panic(err)
+	}
+}
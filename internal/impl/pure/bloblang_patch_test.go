@@ -0,0 +1,190 @@
+package pure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestPatchMethods(t *testing.T) {
+	tests := []struct {
+		name               string
+		mapping            string
+		input              any
+		output             any
+		parseErrorContains string
+		execErrorContains  string
+	}{
+		{
+			name:    "diff identical",
+			mapping: `root = this.diff(this)`,
+			input:   map[string]any{"a": []any{1, 2}},
+			output:  []any{},
+		},
+		{
+			name:    "diff escapes pointers",
+			mapping: `root = this.a.diff(this.b)`,
+			input: map[string]any{
+				"a": map[string]any{"a/b": 1, "c~d": 1},
+				"b": map[string]any{"a/b": 2},
+			},
+			output: []any{
+				map[string]any{"op": "replace", "path": "/a~1b", "value": 2},
+				map[string]any{"op": "remove", "path": "/c~0d"},
+			},
+		},
+		{
+			name:    "diff arrays",
+			mapping: `root = this.a.diff(this.b)`,
+			input: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"b": []any{1, 5},
+			},
+			output: []any{
+				map[string]any{"op": "replace", "path": "/1", "value": 5},
+				map[string]any{"op": "remove", "path": "/3"},
+				map[string]any{"op": "remove", "path": "/2"},
+			},
+		},
+		{
+			name:    "diff type change",
+			mapping: `root = this.a.diff(this.b)`,
+			input: map[string]any{
+				"a": map[string]any{"x": "1"},
+				"b": map[string]any{"x": 1},
+			},
+			output: []any{
+				map[string]any{"op": "replace", "path": "/x", "value": 1},
+			},
+		},
+		{
+			name:    "diff root scalar",
+			mapping: `root = "foo".diff("bar")`,
+			output: []any{
+				map[string]any{"op": "replace", "path": "", "value": "bar"},
+			},
+		},
+		{
+			name:    "diff merge patch",
+			mapping: `root = this.a.diff(this.b, "merge_patch")`,
+			input: map[string]any{
+				"a": map[string]any{"x": 1, "y": map[string]any{"z": 1, "w": 2}, "arr": []any{1}},
+				"b": map[string]any{"y": map[string]any{"z": 1, "v": 3}, "arr": []any{1, 2}},
+			},
+			output: map[string]any{
+				"x":   nil,
+				"y":   map[string]any{"w": nil, "v": 3},
+				"arr": []any{1, 2},
+			},
+		},
+		{
+			name:               "diff bad format",
+			mapping:            `root = this.diff({}, "nope")`,
+			parseErrorContains: "unrecognised patch format: nope",
+		},
+		{
+			name:    "diff round trip",
+			mapping: `root = this.a.apply_patch(this.a.diff(this.b)) == this.b`,
+			input: map[string]any{
+				"a": map[string]any{"x": []any{1, map[string]any{"y": 2}}, "z": "foo"},
+				"b": map[string]any{"x": []any{3, map[string]any{"y": 4}, 5}, "w": true},
+			},
+			output: true,
+		},
+		{
+			name:    "merge patch round trip",
+			mapping: `root = this.a.apply_patch(this.a.diff(this.b, "merge_patch")) == this.b`,
+			input: map[string]any{
+				"a": map[string]any{"x": []any{1}, "y": map[string]any{"z": 1}},
+				"b": map[string]any{"x": []any{2}, "y": map[string]any{"q": 1}},
+			},
+			output: true,
+		},
+		{
+			name: "apply json patch operations",
+			mapping: `root = this.apply_patch([
+  {"op": "add", "path": "/arr/1", "value": "b"},
+  {"op": "move", "from": "/old", "path": "/new"},
+  {"op": "copy", "from": "/new", "path": "/copied"},
+  {"op": "test", "path": "/arr/0", "value": "a"},
+  {"op": "remove", "path": "/arr/2"},
+])`,
+			input: map[string]any{
+				"arr": []any{"a", "c"},
+				"old": map[string]any{"v": 1},
+			},
+			output: map[string]any{
+				"arr":    []any{"a", "b"},
+				"new":    map[string]any{"v": 1},
+				"copied": map[string]any{"v": 1},
+			},
+		},
+		{
+			name:    "apply patch does not mutate target",
+			mapping: `root = [this.apply_patch([{"op": "replace", "path": "/a", "value": 2}]), this]`,
+			input:   map[string]any{"a": 1},
+			output: []any{
+				map[string]any{"a": int64(2)},
+				map[string]any{"a": 1},
+			},
+		},
+		{
+			name:              "apply patch test fails",
+			mapping:           `root = this.apply_patch([{"op": "test", "path": "/a", "value": "2"}])`,
+			input:             map[string]any{"a": 2},
+			execErrorContains: "operation 0: test of path /a failed",
+		},
+		{
+			name:              "apply patch missing path",
+			mapping:           `root = this.apply_patch([{"op": "replace", "path": "/a/b", "value": 1}])`,
+			input:             map[string]any{},
+			execErrorContains: "operation 0: key a does not exist",
+		},
+		{
+			name:              "apply patch index out of bounds",
+			mapping:           `root = this.apply_patch([{"op": "add", "path": "/3", "value": 1}])`,
+			input:             []any{1},
+			execErrorContains: "operation 0: array index 3 out of bounds",
+		},
+		{
+			name:              "apply patch bad op",
+			mapping:           `root = this.apply_patch([{"op": "nope", "path": ""}])`,
+			input:             []any{1},
+			execErrorContains: "unrecognised operation: nope",
+		},
+		{
+			name:    "apply merge patch",
+			mapping: `root = this.apply_patch({"a": {"b": null, "c": {"d": 1}}, "e": [1]})`,
+			input:   map[string]any{"a": map[string]any{"b": 1, "x": 2}, "e": "foo"},
+			output: map[string]any{
+				"a": map[string]any{"x": 2, "c": map[string]any{"d": int64(1)}},
+				"e": []any{int64(1)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			exec, err := bloblang.Parse(test.mapping)
+			if test.parseErrorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.parseErrorContains)
+				return
+			}
+			require.NoError(t, err)
+
+			res, err := exec.Query(test.input)
+			if test.execErrorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.execErrorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.output, res)
+		})
+	}
+}
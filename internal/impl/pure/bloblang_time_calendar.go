@@ -0,0 +1,463 @@
+package pure
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	// Embed the IANA Time Zone database so that timezone conversions work
+	// consistently regardless of the host system.
+	_ "time/tzdata"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+type calendarUnit int
+
+const (
+	unitFixed calendarUnit = iota
+	unitDay
+	unitWeek
+	unitMonth
+	unitQuarter
+	unitYear
+	unitBusinessDay
+)
+
+var fixedUnits = map[string]time.Duration{
+	"nanosecond":  time.Nanosecond,
+	"microsecond": time.Microsecond,
+	"millisecond": time.Millisecond,
+	"second":      time.Second,
+	"minute":      time.Minute,
+	"hour":        time.Hour,
+}
+
+var calendarUnits = map[string]calendarUnit{
+	"day":          unitDay,
+	"week":         unitWeek,
+	"month":        unitMonth,
+	"quarter":      unitQuarter,
+	"year":         unitYear,
+	"business_day": unitBusinessDay,
+}
+
+const timeUnitsDescription = "One of `nanosecond`, `microsecond`, `millisecond`, `second`, `minute`, `hour`, `day`, `week`, `month`, `quarter`, `year` or `business_day`, where plurals are also accepted."
+
+func parseTimeUnit(s string) (calendarUnit, time.Duration, error) {
+	name := strings.TrimSuffix(strings.ToLower(s), "s")
+	if d, exists := fixedUnits[name]; exists {
+		return unitFixed, d, nil
+	}
+	if u, exists := calendarUnits[name]; exists {
+		return u, 0, nil
+	}
+	return 0, 0, fmt.Errorf("unrecognised time unit: %v", s)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// addMonths adds a number of calendar months to a timestamp, where the day of
+// the month is clamped to the last day of the resulting month.
+func addMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	total := int(m) - 1 + months
+	ty, tm := y+total/12, time.Month(total%12+1)
+	if total%12 < 0 {
+		ty, tm = y+total/12-1, time.Month(total%12+13)
+	}
+	if last := daysIn(ty, tm); d > last {
+		d = last
+	}
+	return time.Date(ty, tm, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func isWeekend(t time.Time) bool {
+	wd := t.Weekday()
+	return wd == time.Saturday || wd == time.Sunday
+}
+
+// addBusinessDays adds a number of business days to a timestamp. Once the
+// timestamp is on a weekday every five business days are exactly one week, and
+// therefore only the remainder is counted one day at a time.
+func addBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	weeks, rem := n/5, n%5
+	if rem == 0 && weeks > 0 {
+		// Counting at least one day individually ensures that the timestamp
+		// lands on a weekday before adding whole weeks.
+		weeks, rem = weeks-1, 5
+	}
+	for rem > 0 {
+		t = t.AddDate(0, 0, step)
+		if !isWeekend(t) {
+			rem--
+		}
+	}
+	return t.AddDate(0, 0, step*weeks*7)
+}
+
+// maxCalendarDays is the largest number of days that an amount of a calendar
+// unit may span, which keeps the results well within the range of time.Time.
+const maxCalendarDays = 1_000_000_000_000
+
+// scaleAmount multiplies an amount by a factor, returning an error when the
+// magnitude of the result would exceed a limit.
+func scaleAmount(count, factor, limit int64) (int64, error) {
+	if count > limit/factor || count < -limit/factor {
+		return 0, fmt.Errorf("amount %v is out of range", count)
+	}
+	return count * factor, nil
+}
+
+func tsAdd(t time.Time, count int64, unit calendarUnit, fixed time.Duration) (time.Time, error) {
+	switch unit {
+	case unitDay, unitWeek:
+		factor := int64(1)
+		if unit == unitWeek {
+			factor = 7
+		}
+		days, err := scaleAmount(count, factor, maxCalendarDays)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.AddDate(0, 0, int(days)), nil
+	case unitMonth, unitQuarter, unitYear:
+		factor := int64(1)
+		switch unit {
+		case unitQuarter:
+			factor = 3
+		case unitYear:
+			factor = 12
+		}
+		months, err := scaleAmount(count, factor, maxCalendarDays/31)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return addMonths(t, int(months)), nil
+	case unitBusinessDay:
+		// Every five business days span a week.
+		days, err := scaleAmount(count, 1, maxCalendarDays/7*5)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return addBusinessDays(t, int(days)), nil
+	}
+	d, err := scaleAmount(count, int64(fixed), math.MaxInt64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(time.Duration(d)), nil
+}
+
+// civilDays returns the number of calendar days between the dates of two
+// timestamps, ignoring the time of day.
+func civilDays(from, to time.Time) int {
+	fy, fm, fd := from.Date()
+	ty, tm, td := to.Date()
+	a := time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)
+	b := time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func wallClock(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second +
+		time.Duration(t.Nanosecond())
+}
+
+func tsDiff(from, to time.Time, unit calendarUnit, fixed time.Duration) int64 {
+	from = from.In(to.Location())
+
+	switch unit {
+	case unitDay, unitWeek:
+		days := civilDays(from, to)
+		if days > 0 && wallClock(to) < wallClock(from) {
+			days--
+		} else if days < 0 && wallClock(to) > wallClock(from) {
+			days++
+		}
+		if unit == unitWeek {
+			return int64(days / 7)
+		}
+		return int64(days)
+	case unitMonth, unitQuarter, unitYear:
+		months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+		if months > 0 && addMonths(from, months).After(to) {
+			months--
+		} else if months < 0 && addMonths(from, months).Before(to) {
+			months++
+		}
+		switch unit {
+		case unitQuarter:
+			return int64(months / 3)
+		case unitYear:
+			return int64(months / 12)
+		}
+		return int64(months)
+	case unitBusinessDay:
+		// Weekdays are counted over the dates after the earlier timestamp up
+		// to and including the later one, regardless of the order of the
+		// arguments, so that swapping them only negates the result.
+		sign := int64(1)
+		if civilDays(from, to) < 0 {
+			from, to, sign = to, from, -1
+		}
+		days := civilDays(from, to)
+		count := (days / 7) * 5
+		cursor := from.AddDate(0, 0, (days/7)*7)
+		for i := 0; i < days%7; i++ {
+			cursor = cursor.AddDate(0, 0, 1)
+			if !isWeekend(cursor) {
+				count++
+			}
+		}
+		return sign * int64(count)
+	}
+	return int64(to.Sub(from) / fixed)
+}
+
+func tsTruncate(t time.Time, unit string) (time.Time, error) {
+	loc := t.Location()
+	y, m, d := t.Date()
+
+	switch strings.ToLower(unit) {
+	case "day":
+		return time.Date(y, m, d, 0, 0, 0, 0, loc), nil
+	case "week":
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, loc), nil
+	case "month":
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc), nil
+	case "quarter":
+		return time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, loc), nil
+	case "year":
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, loc), nil
+	}
+
+	dur, exists := fixedUnits[strings.ToLower(unit)]
+	if !exists {
+		var err error
+		if dur, err = time.ParseDuration(unit); err != nil {
+			return time.Time{}, fmt.Errorf("expected a calendar unit or duration: %w", err)
+		}
+	}
+	if dur <= 0 {
+		return time.Time{}, errors.New("truncation duration must be greater than zero")
+	}
+
+	// Truncate relative to the wall clock of the timestamp rather than UTC so
+	// that timezones with offsets that aren't whole hours are aligned.
+	_, offset := t.Zone()
+	offsetDur := time.Duration(offset) * time.Second
+	return t.Add(offsetDur).Truncate(dur).Add(-offsetDur).In(loc), nil
+}
+
+func init() {
+	tsAddSpec := bloblang.NewPluginSpec().
+		Category(query.MethodCategoryTime).
+		Beta().
+		Static().
+		Version("4.20.0").
+		Description("Adds an amount of a time unit to a timestamp. Calendar units are applied to the wall clock time of the timestamp within its timezone, and are therefore correct across daylight saving transitions when the timestamp has been converted to a location with the [`ts_tz`](#ts_tz) method. Adding months, quarters or years clamps the day of the month to the last day of the resulting month, and adding business days skips Saturdays and Sundays. Negative amounts can be used in order to subtract.").
+		Param(bloblang.NewInt64Param("amount").Description("The amount of the unit to add.")).
+		Param(bloblang.NewStringParam("unit").Description("The unit of time to add. "+timeUnitsDescription)).
+		Example("",
+			`root.due = this.created_at.ts_add(1, "month")`,
+			[2]string{
+				`{"created_at":"2023-01-31T10:00:00Z"}`,
+				`{"due":"2023-02-28T10:00:00Z"}`,
+			},
+		).
+		Example("Business days skip weekends.",
+			`root.due = this.created_at.ts_add(3, "business_days")`,
+			[2]string{
+				`{"created_at":"2023-09-08T10:00:00Z"}`,
+				`{"due":"2023-09-13T10:00:00Z"}`,
+			},
+		).
+		Example("Days are added to the wall clock time, which respects daylight saving transitions.",
+			`root.next_day = this.created_at.ts_tz("America/New_York").ts_add(1, "day")`,
+			[2]string{
+				`{"created_at":"2023-03-11T12:00:00-05:00"}`,
+				`{"next_day":"2023-03-12T12:00:00-04:00"}`,
+			},
+		)
+
+	if err := bloblang.RegisterMethodV2("ts_add", tsAddSpec, func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		amount, err := args.GetInt64("amount")
+		if err != nil {
+			return nil, err
+		}
+		unitStr, err := args.GetString("unit")
+		if err != nil {
+			return nil, err
+		}
+		unit, fixed, err := parseTimeUnit(unitStr)
+		if err != nil {
+			return nil, err
+		}
+		return bloblang.TimestampMethod(func(t time.Time) (any, error) {
+			return tsAdd(t, amount, unit, fixed)
+		}), nil
+	}); err != nil {
+		panic(err)
+	}
+
+	tsDiffSpec := bloblang.NewPluginSpec().
+		Category(query.MethodCategoryTime).
+		Beta().
+		Static().
+		Version("4.20.0").
+		Description("Returns the number of whole units of time between a timestamp argument and the target timestamp, which is negative when the argument is after the target. Calendar units are measured within the timezone of the target timestamp. Business days count the weekdays between the dates of each timestamp, ignoring the time of day.").
+		Param(bloblang.NewAnyParam("other").Description("The timestamp to measure from.")).
+		Param(bloblang.NewStringParam("unit").Description("The unit of time to measure. "+timeUnitsDescription)).
+		Example("",
+			`root.age_years = this.now.ts_diff(this.born, "years")
+root.age_days = this.now.ts_diff(this.born, "days")`,
+			[2]string{
+				`{"born":"1990-06-15T00:00:00Z","now":"2023-06-14T00:00:00Z"}`,
+				`{"age_days":12052,"age_years":32}`,
+			},
+		).
+		Example("",
+			`root.working_days = this.closed_at.ts_diff(this.opened_at, "business_days")`,
+			[2]string{
+				`{"opened_at":"2023-09-08T10:00:00Z","closed_at":"2023-09-13T09:00:00Z"}`,
+				`{"working_days":3}`,
+			},
+		)
+
+	if err := bloblang.RegisterMethodV2("ts_diff", tsDiffSpec, func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		otherV, err := args.Get("other")
+		if err != nil {
+			return nil, err
+		}
+		other, err := query.IGetTimestamp(otherV)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse other timestamp: %w", err)
+		}
+		unitStr, err := args.GetString("unit")
+		if err != nil {
+			return nil, err
+		}
+		unit, fixed, err := parseTimeUnit(unitStr)
+		if err != nil {
+			return nil, err
+		}
+		return bloblang.TimestampMethod(func(t time.Time) (any, error) {
+			return tsDiff(other, t, unit, fixed), nil
+		}), nil
+	}); err != nil {
+		panic(err)
+	}
+
+	tsTruncateSpec := bloblang.NewPluginSpec().
+		Category(query.MethodCategoryTime).
+		Beta().
+		Static().
+		Version("4.20.0").
+		Description("Returns the result of rounding a timestamp down to either the start of a calendar unit or a multiple of a duration. Calendar units `day`, `week` (starting on Monday), `month`, `quarter` and `year` are truncated within the timezone of the timestamp. Otherwise the unit is parsed as a duration string such as `15m` or `6h`, and the timestamp is truncated relative to its wall clock time.").
+		Param(bloblang.NewStringParam("unit").Description("A calendar unit or duration to truncate to.")).
+		Example("",
+			`root.bucket = this.created_at.ts_truncate("15m")
+root.month = this.created_at.ts_truncate("month")`,
+			[2]string{
+				`{"created_at":"2023-08-14T05:54:23Z"}`,
+				`{"bucket":"2023-08-14T05:45:00Z","month":"2023-08-01T00:00:00Z"}`,
+			},
+		)
+
+	if err := bloblang.RegisterMethodV2("ts_truncate", tsTruncateSpec, func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		unit, err := args.GetString("unit")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tsTruncate(time.Time{}, unit); err != nil {
+			return nil, err
+		}
+		return bloblang.TimestampMethod(func(t time.Time) (any, error) {
+			return tsTruncate(t, unit)
+		}), nil
+	}); err != nil {
+		panic(err)
+	}
+
+	tsISOWeekSpec := bloblang.NewPluginSpec().
+		Category(query.MethodCategoryTime).
+		Beta().
+		Static().
+		Version("4.20.0").
+		Description("Returns the ISO 8601 week number of a timestamp within its timezone, between 1 and 53. Weeks start on a Monday and the first week of a year is the week containing its first Thursday, which means days near the start or end of a year may belong to a week of a different ISO year, use [`ts_iso_year`](#ts_iso_year) to obtain it.").
+		Example("",
+			`root.week = this.created_at.ts_iso_week()
+root.year = this.created_at.ts_iso_year()`,
+			[2]string{
+				`{"created_at":"2021-01-03T10:00:00Z"}`,
+				`{"week":53,"year":2020}`,
+			},
+		)
+
+	if err := bloblang.RegisterMethodV2("ts_iso_week", tsISOWeekSpec, func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		return bloblang.TimestampMethod(func(t time.Time) (any, error) {
+			_, week := t.ISOWeek()
+			return int64(week), nil
+		}), nil
+	}); err != nil {
+		panic(err)
+	}
+
+	tsISOYearSpec := bloblang.NewPluginSpec().
+		Category(query.MethodCategoryTime).
+		Beta().
+		Static().
+		Version("4.20.0").
+		Description("Returns the ISO 8601 week-numbering year of a timestamp within its timezone, which is the year of the week returned by [`ts_iso_week`](#ts_iso_week).").
+		Example("",
+			`root.year = this.created_at.ts_iso_year()`,
+			[2]string{
+				`{"created_at":"2024-12-30T10:00:00Z"}`,
+				`{"year":2025}`,
+			},
+		)
+
+	if err := bloblang.RegisterMethodV2("ts_iso_year", tsISOYearSpec, func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		return bloblang.TimestampMethod(func(t time.Time) (any, error) {
+			year, _ := t.ISOWeek()
+			return int64(year), nil
+		}), nil
+	}); err != nil {
+		panic(err)
+	}
+
+	tsQuarterSpec := bloblang.NewPluginSpec().
+		Category(query.MethodCategoryTime).
+		Beta().
+		Static().
+		Version("4.20.0").
+		Description("Returns the quarter of the year of a timestamp within its timezone, between 1 and 4.").
+		Example("",
+			`root.quarter = this.created_at.ts_quarter()`,
+			[2]string{
+				`{"created_at":"2023-08-14T05:54:23Z"}`,
+				`{"quarter":3}`,
+			},
+		)
+
+	if err := bloblang.RegisterMethodV2("ts_quarter", tsQuarterSpec, func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		return bloblang.TimestampMethod(func(t time.Time) (any, error) {
+			return int64((t.Month()-1)/3 + 1), nil
+		}), nil
+	}); err != nil {
+		panic(err)
+	}
+}
@@ -2,6 +2,7 @@ package pure
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -177,6 +178,145 @@ func TestTimestampMethods(t *testing.T) {
 			mapping: `root = 1677097265.ts_sub_iso8601("P1Y").ts_unix()`,
 			output:  int64(1645561265),
 		},
+		{
+			name:    "ts_add months clamps to end of month",
+			mapping: `root = "2024-01-31T10:00:00Z".ts_add(1, "month").string()`,
+			output:  "2024-02-29T10:00:00Z",
+		},
+		{
+			name:    "ts_add negative years",
+			mapping: `root = "2024-02-29T10:00:00Z".ts_add(-1, "years").string()`,
+			output:  "2023-02-28T10:00:00Z",
+		},
+		{
+			name:    "ts_add quarters across years",
+			mapping: `root = "2023-11-15T00:00:00Z".ts_add(-5, "quarters").string()`,
+			output:  "2022-08-15T00:00:00Z",
+		},
+		{
+			name:    "ts_add hours across daylight saving",
+			mapping: `root = "2023-03-11T12:00:00-05:00".ts_tz("America/New_York").ts_add(24, "hours").string()`,
+			output:  "2023-03-12T13:00:00-04:00",
+		},
+		{
+			name:    "ts_add business days from weekend",
+			mapping: `root = "2023-09-09T10:00:00Z".ts_add(1, "business_day").string()`,
+			output:  "2023-09-11T10:00:00Z",
+		},
+		{
+			name:    "ts_add negative business days",
+			mapping: `root = "2023-09-11T10:00:00Z".ts_add(-6, "business_days").string()`,
+			output:  "2023-09-01T10:00:00Z",
+		},
+		{
+			name:    "ts_add many business days",
+			mapping: `root = "2023-09-09T10:00:00Z".ts_add(5000000, "business_days").string()`,
+			output:  "21189-01-13T10:00:00Z",
+		},
+		{
+			name:              "ts_add business days out of range",
+			mapping:           `root = this.ts_add(9223372036854775807, "business_days")`,
+			input:             "2024-01-01T00:00:00Z",
+			execErrorContains: "amount 9223372036854775807 is out of range",
+		},
+		{
+			name:              "ts_add weeks out of range",
+			mapping:           `root = this.ts_add(-9223372036854775807, "weeks")`,
+			input:             "2024-01-01T00:00:00Z",
+			execErrorContains: "is out of range",
+		},
+		{
+			name:              "ts_add years out of range",
+			mapping:           `root = this.ts_add(9223372036854775807, "years")`,
+			input:             "2024-01-01T00:00:00Z",
+			execErrorContains: "is out of range",
+		},
+		{
+			name:              "ts_add hours out of range",
+			mapping:           `root = this.ts_add(9223372036854775807, "hours")`,
+			input:             "2024-01-01T00:00:00Z",
+			execErrorContains: "is out of range",
+		},
+		{
+			name:               "ts_add bad unit",
+			mapping:            `root = this.ts_add(1, "fortnights")`,
+			parseErrorContains: "unrecognised time unit: fortnights",
+		},
+		{
+			name:    "ts_diff seconds",
+			mapping: `root = "2023-01-01T00:01:30Z".ts_diff("2023-01-01T00:00:00Z", "seconds")`,
+			output:  int64(90),
+		},
+		{
+			name:    "ts_diff negative months",
+			mapping: `root = "2023-01-31T00:00:00Z".ts_diff("2023-03-30T00:00:00Z", "months")`,
+			output:  int64(-1),
+		},
+		{
+			name:    "ts_diff days across daylight saving",
+			mapping: `root = "2023-03-13T00:00:00-04:00".ts_tz("America/New_York").ts_diff("2023-03-12T00:00:00-05:00", "days")`,
+			output:  int64(1),
+		},
+		{
+			name:    "ts_diff partial day",
+			mapping: `root = "2023-01-02T09:00:00Z".ts_diff("2023-01-01T10:00:00Z", "days")`,
+			output:  int64(0),
+		},
+		{
+			name:    "ts_diff business days over weeks",
+			mapping: `root = "2023-09-25T00:00:00Z".ts_diff("2023-09-08T00:00:00Z", "business_days")`,
+			output:  int64(11),
+		},
+		{
+			name:    "ts_diff negative business days",
+			mapping: `root = "2023-09-08T00:00:00Z".ts_diff("2023-09-11T00:00:00Z", "business_days")`,
+			output:  int64(-1),
+		},
+		{
+			name:    "ts_diff business days from weekend",
+			mapping: `root = "2024-01-08T00:00:00Z".ts_diff("2024-01-06T00:00:00Z", "business_days")`,
+			output:  int64(1),
+		},
+		{
+			name:    "ts_diff business days to weekend",
+			mapping: `root = "2024-01-06T00:00:00Z".ts_diff("2024-01-08T00:00:00Z", "business_days")`,
+			output:  int64(-1),
+		},
+		{
+			name:    "ts_truncate week",
+			mapping: `root = "2023-09-10T15:00:00Z".ts_truncate("week").string()`,
+			output:  "2023-09-04T00:00:00Z",
+		},
+		{
+			name:    "ts_truncate quarter",
+			mapping: `root = "2023-09-10T15:00:00Z".ts_truncate("quarter").string()`,
+			output:  "2023-07-01T00:00:00Z",
+		},
+		{
+			name:    "ts_truncate hour with half hour offset",
+			mapping: `root = "2023-09-10T15:45:00+05:30".ts_truncate("hour").string()`,
+			output:  "2023-09-10T15:00:00+05:30",
+		},
+		{
+			name:    "ts_truncate day in timezone",
+			mapping: `root = "2023-09-10T02:00:00Z".ts_tz("America/New_York").ts_truncate("day").string()`,
+			output:  "2023-09-09T00:00:00-04:00",
+		},
+		{
+			name:               "ts_truncate bad duration",
+			mapping:            `root = this.ts_truncate("-5m")`,
+			parseErrorContains: "truncation duration must be greater than zero",
+		},
+		{
+			name:    "ts_iso_week",
+			mapping: `root = "2020-12-31T00:00:00Z".ts_iso_week()`,
+			output:  int64(53),
+		},
+		{
+			name:    "ts_quarter",
+			mapping: `root = "2020-12-31T00:00:00Z".ts_quarter()`,
+			output:  int64(4),
+		},
 	}
 
 	for _, test := range tests {
@@ -324,3 +464,38 @@ func TestTimestampMethodsOld(t *testing.T) {
 		})
 	}
 }
+
+func TestAddBusinessDaysMatchesCounting(t *testing.T) {
+	countBusinessDays := func(t time.Time, n int) time.Time {
+		step := 1
+		if n < 0 {
+			step, n = -1, -n
+		}
+		for n > 0 {
+			t = t.AddDate(0, 0, step)
+			if !isWeekend(t) {
+				n--
+			}
+		}
+		return t
+	}
+
+	start := time.Date(2023, 9, 4, 10, 0, 0, 0, time.UTC)
+	for d := 0; d < 7; d++ {
+		from := start.AddDate(0, 0, d)
+		for n := -30; n <= 30; n++ {
+			assert.Equal(t, countBusinessDays(from, n), addBusinessDays(from, n), "%v + %v", from.Weekday(), n)
+		}
+	}
+}
+
+func TestDiffBusinessDaysAntisymmetric(t *testing.T) {
+	start := time.Date(2023, 9, 4, 10, 0, 0, 0, time.UTC)
+	for d := 0; d < 7; d++ {
+		from := start.AddDate(0, 0, d)
+		for n := -30; n <= 30; n++ {
+			to := from.AddDate(0, 0, n)
+			assert.Equal(t, -tsDiff(from, to, unitBusinessDay, 0), tsDiff(to, from, unitBusinessDay, 0), "%v to %v", from.Weekday(), to.Weekday())
+		}
+	}
+}
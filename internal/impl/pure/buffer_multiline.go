@@ -0,0 +1,405 @@
+package pure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	mlbFieldKey                 = "key"
+	mlbFieldStartPattern        = "start_pattern"
+	mlbFieldContinuationPattern = "continuation_pattern"
+	mlbFieldSeparator           = "separator"
+	mlbFieldMaxLines            = "max_lines"
+	mlbFieldMaxBytes            = "max_bytes"
+	mlbFieldTimeout             = "timeout"
+)
+
+func multilineBufferConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.20.0").
+		Summary("Merges consecutive messages that belong to a single multiline record, such as a stack trace, into one message.").
+		Description(`
+Each message is considered to be a line that either begins a new record or continues the record of the preceding message with the same `+"`key`"+`. A message continues the pending record when it matches the `+"`continuation_pattern`"+`, or when it does not match the `+"`start_pattern`"+`, and when both patterns are set a message must match the continuation pattern and not match the start pattern. At least one of the patterns must be set.
+
+A record is emitted once a message that begins a new record with the same key arrives, once it would exceed `+"`max_lines`"+` or `+"`max_bytes`"+`, or once the `+"`timeout`"+` has elapsed since the record was last extended, regardless of whether further messages arrive. The merged message adopts the metadata of the first message of the record, and a metadata field `+"`multiline_count`"+` is added containing the number of messages merged.
+
+Records are emitted in the order that they are completed, and therefore the order of records with the same key is preserved, whereas records of different keys may be reordered relative to each other.
+
+## Delivery Guarantees
+
+This buffer honours the transaction model within Benthos, and the messages of a record are only acknowledged once the merged record has been delivered, or rejected if the delivery of the merged record fails. When the input of the stream ends all pending records are emitted regardless of whether they are complete, and if the stream is terminated before pending records can be emitted their messages are rejected so that they may be consumed again.`).
+		Fields(
+			service.NewInterpolatedStringField(mlbFieldKey).
+				Description("An optional key identifying the source of each message, where messages are only merged with preceding messages of the same key.").
+				Default("").
+				Example(`${! meta("docker_container_id") }`),
+			service.NewStringField(mlbFieldStartPattern).
+				Description("A regular expression that matches messages that begin a new record.").
+				Default("").
+				Examples(`^\d{4}-\d{2}-\d{2}`, `^\[`),
+			service.NewStringField(mlbFieldContinuationPattern).
+				Description("A regular expression that matches messages that continue the pending record.").
+				Default("").
+				Examples(`^\s+at `, `^\s`, `\\$`),
+			service.NewStringField(mlbFieldSeparator).
+				Description("A string inserted between the contents of merged messages.").
+				Default("\n"),
+			service.NewIntField(mlbFieldMaxLines).
+				Description("The maximum number of messages to merge into a single record. Set to `0` in order to disable the limit.").
+				Default(500),
+			service.NewIntField(mlbFieldMaxBytes).
+				Description("The maximum size in bytes of a merged record. Set to `0` in order to disable the limit.").
+				Default(0).
+				Advanced(),
+			service.NewDurationField(mlbFieldTimeout).
+				Description("The period of time after which a pending record that has not been extended is emitted.").
+				Default("5s"),
+		).
+		Example(
+			"Java Stack Traces",
+			"Merges the lines of Java stack traces tailed from Docker containers into the log line that precedes them.",
+			`
+input:
+  docker_logs: {}
+
+buffer:
+  multiline:
+    key: ${! meta("docker_container_id") }-${! meta("docker_stream") }
+    continuation_pattern: '^(\s+at |\s*\.\.\. \d+ more|Caused by: )'
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchBuffer(
+		"multiline", multilineBufferConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchBuffer, error) {
+			return newMultilineBufferFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type multilineRecord struct {
+	seq     uint64
+	parts   service.MessageBatch
+	acks    []service.AckFunc
+	size    int
+	updated time.Time
+}
+
+func (r *multilineRecord) ack(ctx context.Context, err error) {
+	for _, aFn := range r.acks {
+		_ = aFn(ctx, err)
+	}
+}
+
+var errMultilineRecordClosed = errors.New("message rejected as multiline record was not emitted before shutdown")
+
+type multilineBuffer struct {
+	key          *service.InterpolatedString
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+	separator    []byte
+	maxLines     int
+	maxBytes     int
+	timeout      time.Duration
+
+	nowFn func() time.Time
+
+	mut        sync.Mutex
+	seq        uint64
+	pending    map[string]*multilineRecord
+	completed  []*multilineRecord
+	endOfInput bool
+
+	// Closed and replaced whenever the state of the buffer changes in order to
+	// wake blocked readers and writers.
+	changed chan struct{}
+}
+
+func newMultilineBufferFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*multilineBuffer, error) {
+	m := &multilineBuffer{
+		nowFn:   time.Now,
+		pending: map[string]*multilineRecord{},
+		changed: make(chan struct{}),
+	}
+
+	var err error
+	if m.key, err = conf.FieldInterpolatedString(mlbFieldKey); err != nil {
+		return nil, err
+	}
+
+	startStr, err := conf.FieldString(mlbFieldStartPattern)
+	if err != nil {
+		return nil, err
+	}
+	if startStr != "" {
+		if m.start, err = regexp.Compile(startStr); err != nil {
+			return nil, fmt.Errorf("failed to parse start_pattern: %w", err)
+		}
+	}
+	contStr, err := conf.FieldString(mlbFieldContinuationPattern)
+	if err != nil {
+		return nil, err
+	}
+	if contStr != "" {
+		if m.continuation, err = regexp.Compile(contStr); err != nil {
+			return nil, fmt.Errorf("failed to parse continuation_pattern: %w", err)
+		}
+	}
+	if m.start == nil && m.continuation == nil {
+		return nil, errors.New("at least one of start_pattern or continuation_pattern must be set")
+	}
+
+	separator, err := conf.FieldString(mlbFieldSeparator)
+	if err != nil {
+		return nil, err
+	}
+	m.separator = []byte(separator)
+	if m.maxLines, err = conf.FieldInt(mlbFieldMaxLines); err != nil {
+		return nil, err
+	}
+	if m.maxBytes, err = conf.FieldInt(mlbFieldMaxBytes); err != nil {
+		return nil, err
+	}
+	if m.timeout, err = conf.FieldDuration(mlbFieldTimeout); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// signal wakes any blocked readers and writers, and must be called with the
+// lock held.
+func (m *multilineBuffer) signal() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+func (m *multilineBuffer) isContinuation(content []byte) bool {
+	if m.continuation != nil && !m.continuation.Match(content) {
+		return false
+	}
+	if m.start != nil && m.start.Match(content) {
+		return false
+	}
+	return true
+}
+
+func (m *multilineBuffer) merge(r *multilineRecord) (*service.Message, error) {
+	first := r.parts[0]
+	if len(r.parts) == 1 {
+		first.MetaSetMut("multiline_count", int64(1))
+		return first, nil
+	}
+
+	contents := make([][]byte, len(r.parts))
+	for i, p := range r.parts {
+		b, err := p.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		contents[i] = b
+	}
+
+	merged := first.Copy()
+	merged.SetBytes(bytes.Join(contents, m.separator))
+	merged.MetaSetMut("multiline_count", int64(len(r.parts)))
+	return merged, nil
+}
+
+// flushPending moves pending records that have not been extended within the
+// timeout, or all of them when force is true, into the completed records in
+// the order that they were started. Must be called with the lock held.
+func (m *multilineBuffer) flushPending(now time.Time, force bool) {
+	var expired []*multilineRecord
+	for k, r := range m.pending {
+		if force || now.Sub(r.updated) >= m.timeout {
+			expired = append(expired, r)
+			delete(m.pending, k)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].seq < expired[j].seq
+	})
+	m.completed = append(m.completed, expired...)
+}
+
+// nextExpiry returns the time at which the oldest pending record expires, or
+// false if there are no pending records. Must be called with the lock held.
+func (m *multilineBuffer) nextExpiry() (next time.Time, exists bool) {
+	for _, r := range m.pending {
+		if t := r.updated.Add(m.timeout); !exists || t.Before(next) {
+			next, exists = t, true
+		}
+	}
+	return
+}
+
+func (m *multilineBuffer) WriteBatch(ctx context.Context, msgBatch service.MessageBatch, aFn service.AckFunc) error {
+	keys := make([]string, len(msgBatch))
+	contents := make([][]byte, len(msgBatch))
+	for i, msg := range msgBatch {
+		var err error
+		if keys[i], err = msgBatch.TryInterpolatedString(i, m.key); err != nil {
+			return fmt.Errorf("key interpolation error: %w", err)
+		}
+		if contents[i], err = msg.AsBytes(); err != nil {
+			return err
+		}
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	// Apply back pressure until the records completed by prior writes have
+	// been consumed.
+	for len(m.completed) > 0 {
+		changed := m.changed
+		m.mut.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			m.mut.Lock()
+			return ctx.Err()
+		}
+		m.mut.Lock()
+	}
+
+	now := m.nowFn()
+	aggregatedAck := batch.NewCombinedAcker(batch.AckFunc(aFn))
+
+	for i, msg := range msgBatch {
+		key, content := keys[i], contents[i]
+		ackFn := service.AckFunc(aggregatedAck.Derive())
+
+		if r, exists := m.pending[key]; exists {
+			fits := (m.maxLines <= 0 || len(r.parts) < m.maxLines) &&
+				(m.maxBytes <= 0 || r.size+len(m.separator)+len(content) <= m.maxBytes)
+			if fits && m.isContinuation(content) {
+				r.parts = append(r.parts, msg)
+				r.acks = append(r.acks, ackFn)
+				r.size += len(m.separator) + len(content)
+				r.updated = now
+				continue
+			}
+			m.completed = append(m.completed, r)
+		}
+
+		m.seq++
+		m.pending[key] = &multilineRecord{
+			seq:     m.seq,
+			parts:   service.MessageBatch{msg},
+			acks:    []service.AckFunc{ackFn},
+			size:    len(content),
+			updated: now,
+		}
+	}
+
+	m.signal()
+	return nil
+}
+
+func (m *multilineBuffer) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	for {
+		m.flushPending(m.nowFn(), m.endOfInput)
+
+		if len(m.completed) > 0 {
+			completed := m.completed
+			m.completed = nil
+			m.signal()
+
+			out := make(service.MessageBatch, 0, len(completed))
+			for _, r := range completed {
+				merged, err := m.merge(r)
+				if err != nil {
+					r.ack(ctx, err)
+					continue
+				}
+				out = append(out, merged)
+			}
+			if len(out) == 0 {
+				continue
+			}
+			return out, func(ctx context.Context, err error) error {
+				for _, r := range completed {
+					r.ack(ctx, err)
+				}
+				return nil
+			}, nil
+		}
+
+		if m.endOfInput {
+			return nil, nil, service.ErrEndOfBuffer
+		}
+
+		var timer *time.Timer
+		var expiryChan <-chan time.Time
+		if next, exists := m.nextExpiry(); exists {
+			timer = time.NewTimer(next.Sub(m.nowFn()))
+			expiryChan = timer.C
+		}
+
+		changed := m.changed
+		m.mut.Unlock()
+		var err error
+		select {
+		case <-expiryChan:
+		case <-changed:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		m.mut.Lock()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+func (m *multilineBuffer) EndOfInput() {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if !m.endOfInput {
+		m.endOfInput = true
+		m.signal()
+	}
+}
+
+func (m *multilineBuffer) Close(ctx context.Context) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	// Reject the messages of records that were never emitted so that they can
+	// be consumed again.
+	for _, r := range m.completed {
+		r.ack(ctx, errMultilineRecordClosed)
+	}
+	for _, r := range m.pending {
+		r.ack(ctx, errMultilineRecordClosed)
+	}
+	m.completed = nil
+	m.pending = map[string]*multilineRecord{}
+	return nil
+}
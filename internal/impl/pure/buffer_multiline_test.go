@@ -0,0 +1,272 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testMultilineBuffer(t *testing.T, confStr string) *multilineBuffer {
+	t.Helper()
+
+	conf, err := multilineBufferConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	buf, err := newMultilineBufferFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = buf.Close(context.Background())
+	})
+	return buf
+}
+
+type multilineTestMsg struct {
+	key     string
+	content string
+}
+
+// multilineAcks records the acknowledgements of written batches.
+type multilineAcks struct {
+	mut  sync.Mutex
+	errs map[int]error
+}
+
+func (a *multilineAcks) fn(i int) service.AckFunc {
+	return func(ctx context.Context, err error) error {
+		a.mut.Lock()
+		defer a.mut.Unlock()
+		if a.errs == nil {
+			a.errs = map[int]error{}
+		}
+		a.errs[i] = err
+		return nil
+	}
+}
+
+func (a *multilineAcks) get() map[int]error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	res := map[int]error{}
+	for k, v := range a.errs {
+		res[k] = v
+	}
+	return res
+}
+
+func multilineWrite(t *testing.T, buf *multilineBuffer, aFn service.AckFunc, msgs ...multilineTestMsg) {
+	t.Helper()
+
+	var batch service.MessageBatch
+	for _, m := range msgs {
+		msg := service.NewMessage([]byte(m.content))
+		msg.MetaSetMut("source", m.key)
+		batch = append(batch, msg)
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+	require.NoError(t, buf.WriteBatch(ctx, batch, aFn))
+}
+
+func multilineRead(t *testing.T, buf *multilineBuffer, timeout time.Duration) ([]string, service.AckFunc) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), timeout)
+	defer done()
+
+	batch, aFn, err := buf.ReadBatch(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, nil
+	}
+	require.NoError(t, err)
+
+	var results []string
+	for _, m := range batch {
+		content, err := m.AsBytes()
+		require.NoError(t, err)
+		source, _ := m.MetaGet("source")
+		count, _ := m.MetaGetMut("multiline_count")
+		results = append(results, source+"|"+string(content)+"|"+string(rune('0'+count.(int64))))
+	}
+	return results, aFn
+}
+
+func TestMultilineStartPattern(t *testing.T) {
+	buf := testMultilineBuffer(t, `
+start_pattern: '^\d{4}'
+key: ${! meta("source") }
+timeout: 1m
+`)
+	var acks multilineAcks
+
+	multilineWrite(t, buf, acks.fn(0),
+		multilineTestMsg{"a", "2023 foo"},
+		multilineTestMsg{"b", "2023 bar"},
+		multilineTestMsg{"a", "  at a"},
+		multilineTestMsg{"b", "  at c"},
+		multilineTestMsg{"a", "  at b"},
+		multilineTestMsg{"a", "2023 baz"},
+	)
+
+	res, aFn := multilineRead(t, buf, time.Second)
+	assert.Equal(t, []string{"a|2023 foo\n  at a\n  at b|3"}, res)
+	require.NoError(t, aFn(context.Background(), nil))
+
+	// The batch contains messages of pending records and therefore must not be
+	// acknowledged yet.
+	assert.Empty(t, acks.get())
+
+	// Pending records are continued by subsequent batches.
+	multilineWrite(t, buf, acks.fn(1),
+		multilineTestMsg{"b", "  at d"},
+		multilineTestMsg{"b", "2023 buz"},
+		multilineTestMsg{"a", "2023 bev"},
+	)
+
+	res, aFn = multilineRead(t, buf, time.Second)
+	assert.Equal(t, []string{"b|2023 bar\n  at c\n  at d|3", "a|2023 baz|1"}, res)
+	require.NoError(t, aFn(context.Background(), nil))
+
+	assert.Equal(t, map[int]error{0: nil}, acks.get())
+}
+
+func TestMultilineContinuationPattern(t *testing.T) {
+	buf := testMultilineBuffer(t, `
+start_pattern: '^\s\['
+continuation_pattern: '^\s'
+separator: ' / '
+`)
+
+	multilineWrite(t, buf, func(context.Context, error) error { return nil },
+		multilineTestMsg{"", "foo"},
+		multilineTestMsg{"", "\tbar"},
+		multilineTestMsg{"", " [baz"},
+		multilineTestMsg{"", " qux"},
+		multilineTestMsg{"", "quz"},
+	)
+
+	res, _ := multilineRead(t, buf, time.Second)
+	assert.Equal(t, []string{"|foo / \tbar|2", "| [baz /  qux|2"}, res)
+}
+
+func TestMultilineLimits(t *testing.T) {
+	buf := testMultilineBuffer(t, `
+continuation_pattern: '^\s'
+max_lines: 3
+max_bytes: 12
+`)
+
+	multilineWrite(t, buf, func(context.Context, error) error { return nil },
+		multilineTestMsg{"", "a"},
+		multilineTestMsg{"", " b"},
+		multilineTestMsg{"", " c"},
+		multilineTestMsg{"", " d"},
+		multilineTestMsg{"", " eeeeeeee"},
+		multilineTestMsg{"", " f"},
+	)
+
+	res, _ := multilineRead(t, buf, time.Second)
+	assert.Equal(t, []string{"|a\n b\n c|3", "| d\n eeeeeeee|2"}, res)
+}
+
+func TestMultilineTimeout(t *testing.T) {
+	buf := testMultilineBuffer(t, `
+start_pattern: '^\S'
+key: ${! meta("source") }
+timeout: 50ms
+`)
+	var acks multilineAcks
+
+	multilineWrite(t, buf, acks.fn(0),
+		multilineTestMsg{"a", "foo"},
+		multilineTestMsg{"a", " bar"},
+	)
+
+	// The pending record is emitted once it expires without any further
+	// messages being written.
+	res, aFn := multilineRead(t, buf, time.Second)
+	assert.Equal(t, []string{"a|foo\n bar|2"}, res)
+	assert.Empty(t, acks.get())
+
+	require.NoError(t, aFn(context.Background(), nil))
+	assert.Equal(t, map[int]error{0: nil}, acks.get())
+}
+
+func TestMultilineNackPropagates(t *testing.T) {
+	buf := testMultilineBuffer(t, `
+start_pattern: '^\S'
+`)
+	var acks multilineAcks
+
+	multilineWrite(t, buf, acks.fn(0), multilineTestMsg{"", "foo"}, multilineTestMsg{"", " bar"})
+	multilineWrite(t, buf, acks.fn(1), multilineTestMsg{"", " baz"}, multilineTestMsg{"", "qux"})
+
+	res, aFn := multilineRead(t, buf, time.Second)
+	assert.Equal(t, []string{"|foo\n bar\n baz|3"}, res)
+
+	nackErr := errors.New("nope")
+	require.NoError(t, aFn(context.Background(), nackErr))
+
+	// The second batch also contains the pending record qux.
+	assert.Equal(t, map[int]error{0: nackErr}, acks.get())
+}
+
+func TestMultilineEndOfInput(t *testing.T) {
+	buf := testMultilineBuffer(t, `
+start_pattern: '^\S'
+key: ${! meta("source") }
+timeout: 1h
+`)
+	var acks multilineAcks
+
+	multilineWrite(t, buf, acks.fn(0),
+		multilineTestMsg{"a", "foo"},
+		multilineTestMsg{"b", "bar"},
+		multilineTestMsg{"a", " baz"},
+	)
+	buf.EndOfInput()
+
+	res, aFn := multilineRead(t, buf, time.Second)
+	assert.Equal(t, []string{"a|foo\n baz|2", "b|bar|1"}, res)
+	require.NoError(t, aFn(context.Background(), nil))
+	assert.Equal(t, map[int]error{0: nil}, acks.get())
+
+	_, _, err := buf.ReadBatch(context.Background())
+	assert.ErrorIs(t, err, service.ErrEndOfBuffer)
+}
+
+func TestMultilineCloseRejectsPending(t *testing.T) {
+	buf := testMultilineBuffer(t, `
+start_pattern: '^\S'
+timeout: 1h
+`)
+	var acks multilineAcks
+
+	multilineWrite(t, buf, acks.fn(0), multilineTestMsg{"", "foo"})
+	require.NoError(t, buf.Close(context.Background()))
+
+	errs := acks.get()
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], errMultilineRecordClosed)
+}
+
+func TestMultilineBadConfig(t *testing.T) {
+	for _, conf := range []string{
+		`{}`,
+		`start_pattern: '('`,
+		`continuation_pattern: '('`,
+	} {
+		pConf, err := multilineBufferConfig().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		_, err = newMultilineBufferFromParsed(pConf, service.MockResources())
+		assert.Error(t, err, conf)
+	}
+}
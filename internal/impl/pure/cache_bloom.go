@@ -0,0 +1,457 @@
+package pure
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/OneOfOne/xxhash"
+
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	bloomCacheFieldExpectedItems     = "expected_items"
+	bloomCacheFieldFalsePositiveRate = "false_positive_rate"
+	bloomCacheFieldWindow            = "window"
+	bloomCacheFieldPersistPath       = "persist_path"
+	bloomCacheFieldPersistInterval   = "persist_interval"
+)
+
+func bloomCacheConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.20.0").
+		Summary(`Stores the keys of items within a bloom filter held in memory, which is a probabilistic set that uses a small, fixed amount of memory regardless of the size of the keys.`).
+		Description(`
+This cache is intended for approximate deduplication over a number of keys that would be infeasible to hold in an exact cache, and is therefore best used with the `+"[`dedupe` processor](/docs/components/processors/dedupe)"+`. Only the presence of keys is recorded, and so values are discarded: a get returns an empty value when a key is probably present and fails otherwise, and deletes are not supported.
+
+The filter is sized from the fields `+"`expected_items` and `false_positive_rate`"+`, where a key that was never added is reported as present at approximately the configured rate once the expected number of items have been added, and more often beyond that. Keys that were added are always reported as present. The memory used is roughly `+"`-expected_items * ln(false_positive_rate) / ln(2)^2`"+` bits, for example a filter of one billion keys with a false positive rate of 0.1% uses approximately 1.8GB.
+
+### Rotation
+
+TTLs are not supported for individual items. Instead, when a `+"`window`"+` is configured the filter is rotated at the end of each window, where a fresh filter is started and the filter of the previous window is kept for lookups only. Keys are therefore remembered for at least one window and at most two, and the configured number of expected items applies to each window.
+
+### Persistence
+
+When a `+"`persist_path`"+` is configured the filter is loaded from the file at start up and written to it periodically, at each rotation and on shutdown, allowing the filter to survive restarts. A file written with a different number of expected items or false positive rate is ignored.`).
+		Field(service.NewIntField(bloomCacheFieldExpectedItems).
+			Description("The number of keys expected to be added to the filter, or to each window when rotation is enabled.").
+			Default(1000000)).
+		Field(service.NewFloatField(bloomCacheFieldFalsePositiveRate).
+			Description("The rate at which keys that were never added are reported as present once the expected number of items have been added.").
+			Default(0.001)).
+		Field(service.NewDurationField(bloomCacheFieldWindow).
+			Description("An optional period after which the filter is rotated, where keys are remembered for at least one window and at most two.").
+			Example("24h").
+			Optional()).
+		Field(service.NewStringField(bloomCacheFieldPersistPath).
+			Description("An optional path of a file to persist the filter to, which will be created if it does not already exist.").
+			Example("./dedupe.bloom").
+			Optional()).
+		Field(service.NewDurationField(bloomCacheFieldPersistInterval).
+			Description("The period of time between each write of the filter to the `persist_path`.").
+			Default("1m").
+			Advanced()).
+		Example(
+			"Approximate Deduplication",
+			"Here we deduplicate a high volume stream of messages by their ID over a period of at least one day, where a small rate of unique messages being dropped is acceptable.",
+			`
+pipeline:
+  processors:
+    - dedupe:
+        cache: seen_ids
+        key: ${! this.id }
+
+cache_resources:
+  - label: seen_ids
+    bloom:
+      expected_items: 100000000
+      false_positive_rate: 0.0001
+      window: 24h
+      persist_path: ./seen_ids.bloom
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterCache(
+		"bloom", bloomCacheConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Cache, error) {
+			return newBloomCacheFromConfig(conf, mgr.Logger())
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newBloomCacheFromConfig(conf *service.ParsedConfig, log *service.Logger) (*bloomCache, error) {
+	expectedItems, err := conf.FieldInt(bloomCacheFieldExpectedItems)
+	if err != nil {
+		return nil, err
+	}
+	if expectedItems <= 0 {
+		return nil, errors.New("expected_items must be greater than zero")
+	}
+
+	fpRate, err := conf.FieldFloat(bloomCacheFieldFalsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, errors.New("false_positive_rate must be between 0 and 1")
+	}
+
+	var window time.Duration
+	if conf.Contains(bloomCacheFieldWindow) {
+		if window, err = conf.FieldDuration(bloomCacheFieldWindow); err != nil {
+			return nil, err
+		}
+	}
+
+	var persistPath string
+	if conf.Contains(bloomCacheFieldPersistPath) {
+		if persistPath, err = conf.FieldString(bloomCacheFieldPersistPath); err != nil {
+			return nil, err
+		}
+	}
+
+	persistInterval, err := conf.FieldDuration(bloomCacheFieldPersistInterval)
+	if err != nil {
+		return nil, err
+	}
+	return newBloomCache(uint64(expectedItems), fpRate, window, persistPath, persistInterval, log)
+}
+
+//------------------------------------------------------------------------------
+
+// bloomFilter is a fixed size bloom filter where the bit positions of a key are
+// derived from two hashes by double hashing.
+type bloomFilter struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// bloomFilterParams returns the optimal number of bits and hash functions for
+// a filter with a number of expected items and false positive rate.
+func bloomFilterParams(n uint64, p float64) (m, k uint64) {
+	m = uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k = uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return
+}
+
+func newBloomFilter(m, k uint64) *bloomFilter {
+	return &bloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+func bloomHashes(key string) (h1, h2 uint64) {
+	b := []byte(key)
+	return xxhash.Checksum64S(b, 0), xxhash.Checksum64S(b, 1) | 1
+}
+
+func (f *bloomFilter) test(h1, h2 uint64) bool {
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// add sets the bits of a key and returns true if they were all already set.
+func (f *bloomFilter) add(h1, h2 uint64) bool {
+	present := true
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			present = false
+			f.bits[pos/64] |= 1 << (pos % 64)
+		}
+	}
+	return present
+}
+
+//------------------------------------------------------------------------------
+
+var bloomFileMagic = [8]byte{'B', 'N', 'T', 'H', 'B', 'L', 'M', '1'}
+
+type bloomCache struct {
+	m, k            uint64
+	window          time.Duration
+	persistPath     string
+	persistInterval time.Duration
+
+	mut       sync.Mutex
+	current   *bloomFilter
+	previous  *bloomFilter
+	rotatedAt time.Time
+	dirty     bool
+
+	nowFn   func() time.Time
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+}
+
+func newBloomCache(expectedItems uint64, fpRate float64, window time.Duration, persistPath string, persistInterval time.Duration, log *service.Logger) (*bloomCache, error) {
+	m, k := bloomFilterParams(expectedItems, fpRate)
+	c := &bloomCache{
+		m:               m,
+		k:               k,
+		window:          window,
+		persistPath:     persistPath,
+		persistInterval: persistInterval,
+		nowFn:           time.Now,
+		log:             log,
+		shutSig:         shutdown.NewSignaller(),
+	}
+	c.current = newBloomFilter(m, k)
+	c.rotatedAt = c.nowFn()
+
+	if persistPath != "" {
+		if err := c.load(); err != nil {
+			return nil, err
+		}
+	}
+	go c.persistLoop()
+	return c, nil
+}
+
+// rotate replaces the current filter when its window has ended, and must be
+// called whilst holding the mutex.
+func (c *bloomCache) rotate() {
+	if c.window <= 0 {
+		return
+	}
+	now := c.nowFn()
+	elapsed := now.Sub(c.rotatedAt)
+	if elapsed < c.window {
+		return
+	}
+	if elapsed < c.window*2 {
+		c.previous = c.current
+	} else {
+		c.previous = nil
+	}
+	c.current = newBloomFilter(c.m, c.k)
+	c.rotatedAt = c.rotatedAt.Add(elapsed.Truncate(c.window))
+	c.dirty = true
+}
+
+func (c *bloomCache) Get(ctx context.Context, key string) ([]byte, error) {
+	h1, h2 := bloomHashes(key)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.rotate()
+	if c.current.test(h1, h2) || (c.previous != nil && c.previous.test(h1, h2)) {
+		return []byte{}, nil
+	}
+	return nil, service.ErrKeyNotFound
+}
+
+func (c *bloomCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	h1, h2 := bloomHashes(key)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.rotate()
+	if !c.current.add(h1, h2) {
+		c.dirty = true
+	}
+	return nil
+}
+
+func (c *bloomCache) Add(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	h1, h2 := bloomHashes(key)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.rotate()
+	inPrevious := c.previous != nil && c.previous.test(h1, h2)
+
+	// Keys found in the previous window are also added to the current one so
+	// that they continue to be remembered after the next rotation.
+	inCurrent := c.current.add(h1, h2)
+	if !inCurrent {
+		c.dirty = true
+	}
+	if inCurrent || inPrevious {
+		return service.ErrKeyAlreadyExists
+	}
+	return nil
+}
+
+func (c *bloomCache) Delete(ctx context.Context, key string) error {
+	return errors.New("keys cannot be deleted from a bloom filter")
+}
+
+//------------------------------------------------------------------------------
+
+func (c *bloomCache) persistLoop() {
+	defer c.shutSig.ShutdownComplete()
+
+	if c.persistPath == "" {
+		<-c.shutSig.CloseAtLeisureChan()
+		return
+	}
+
+	var tickerChan <-chan time.Time
+	if c.persistInterval > 0 {
+		ticker := time.NewTicker(c.persistInterval)
+		defer ticker.Stop()
+		tickerChan = ticker.C
+	}
+
+	for {
+		select {
+		case <-tickerChan:
+			if err := c.persist(); err != nil {
+				c.log.Errorf("Failed to persist bloom filter: %v", err)
+			}
+		case <-c.shutSig.CloseAtLeisureChan():
+			if err := c.persist(); err != nil {
+				c.log.Errorf("Failed to persist bloom filter: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// persist writes the filters to a temporary file which then replaces the
+// persisted file, so that a partially written file is never loaded.
+func (c *bloomCache) persist() error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.rotate()
+	if !c.dirty {
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(c.persistPath), filepath.Base(c.persistPath)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+
+	if err = c.writeTo(f); err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, c.persistPath)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+func (c *bloomCache) writeTo(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	hasPrevious := uint64(0)
+	if c.previous != nil {
+		hasPrevious = 1
+	}
+	header := []any{bloomFileMagic, c.m, c.k, c.rotatedAt.UnixNano(), hasPrevious}
+	for _, v := range header {
+		if err := binary.Write(bw, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(bw, binary.LittleEndian, c.current.bits); err != nil {
+		return err
+	}
+	if c.previous != nil {
+		if err := binary.Write(bw, binary.LittleEndian, c.previous.bits); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// load reads the filters from the persisted file if it exists and was written
+// with the same parameters.
+func (c *bloomCache) load() error {
+	f, err := os.Open(c.persistPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	var magic [8]byte
+	var m, k, hasPrevious uint64
+	var rotatedAt int64
+	for _, v := range []any{&magic, &m, &k, &rotatedAt, &hasPrevious} {
+		if err := binary.Read(r, binary.LittleEndian, v); err != nil {
+			return fmt.Errorf("failed to read bloom filter file header: %w", err)
+		}
+	}
+	if magic != bloomFileMagic {
+		return fmt.Errorf("file %v is not a bloom filter", c.persistPath)
+	}
+	if m != c.m || k != c.k {
+		c.log.Warnf("Ignoring persisted bloom filter %v as it was written with a different expected number of items or false positive rate", c.persistPath)
+		return nil
+	}
+
+	current := newBloomFilter(m, k)
+	if err := binary.Read(r, binary.LittleEndian, current.bits); err != nil {
+		return fmt.Errorf("failed to read bloom filter: %w", err)
+	}
+	var previous *bloomFilter
+	if hasPrevious == 1 {
+		previous = newBloomFilter(m, k)
+		if err := binary.Read(r, binary.LittleEndian, previous.bits); err != nil {
+			return fmt.Errorf("failed to read bloom filter: %w", err)
+		}
+	}
+
+	c.current, c.previous = current, previous
+	c.rotatedAt = time.Unix(0, rotatedAt)
+	return nil
+}
+
+func (c *bloomCache) Close(ctx context.Context) error {
+	c.shutSig.CloseAtLeisure()
+	select {
+	case <-c.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
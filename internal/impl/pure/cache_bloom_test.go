@@ -0,0 +1,116 @@
+package pure
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestBloomCache(t *testing.T) {
+	defConf, err := bloomCacheConfig().ParseYAML(`expected_items: 1000`, nil)
+	require.NoError(t, err)
+
+	c, err := newBloomCacheFromConfig(defConf, service.MockResources().Logger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = c.Close(context.Background())
+	})
+
+	ctx := context.Background()
+
+	_, err = c.Get(ctx, "foo")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	require.NoError(t, c.Add(ctx, "foo", []byte("1"), nil))
+	assert.Equal(t, service.ErrKeyAlreadyExists, c.Add(ctx, "foo", []byte("1"), nil))
+
+	v, err := c.Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Empty(t, v)
+
+	require.NoError(t, c.Set(ctx, "bar", []byte("2"), nil))
+	assert.Equal(t, service.ErrKeyAlreadyExists, c.Add(ctx, "bar", []byte("2"), nil))
+
+	assert.Error(t, c.Delete(ctx, "foo"))
+}
+
+func TestBloomCacheFalsePositives(t *testing.T) {
+	c, err := newBloomCache(10000, 0.01, 0, "", 0, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 10000; i++ {
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("in-%v", i), nil, nil))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		_, err := c.Get(ctx, fmt.Sprintf("in-%v", i))
+		require.NoError(t, err)
+
+		if _, err := c.Get(ctx, fmt.Sprintf("out-%v", i)); err == nil {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 200)
+}
+
+func TestBloomCacheRotation(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	c, err := newBloomCache(1000, 0.001, time.Hour, "", 0, service.MockResources().Logger())
+	require.NoError(t, err)
+	c.nowFn = func() time.Time {
+		return now
+	}
+	c.rotatedAt = now
+
+	ctx := context.Background()
+	require.NoError(t, c.Add(ctx, "foo", nil, nil))
+	require.NoError(t, c.Add(ctx, "bar", nil, nil))
+
+	// Keys are remembered throughout the next window, and keys seen again are
+	// carried over into it.
+	now = now.Add(time.Hour + time.Minute)
+	assert.Equal(t, service.ErrKeyAlreadyExists, c.Add(ctx, "foo", nil, nil))
+
+	now = now.Add(time.Hour)
+	_, err = c.Get(ctx, "foo")
+	assert.NoError(t, err)
+	_, err = c.Get(ctx, "bar")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	// All keys are forgotten after two empty windows.
+	now = now.Add(time.Hour * 2)
+	_, err = c.Get(ctx, "foo")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+}
+
+func TestBloomCachePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.bloom")
+	ctx := context.Background()
+
+	c, err := newBloomCache(1000, 0.001, time.Hour, path, 0, service.MockResources().Logger())
+	require.NoError(t, err)
+	require.NoError(t, c.Add(ctx, "foo", nil, nil))
+	require.NoError(t, c.Close(ctx))
+
+	c, err = newBloomCache(1000, 0.001, time.Hour, path, 0, service.MockResources().Logger())
+	require.NoError(t, err)
+	assert.Equal(t, service.ErrKeyAlreadyExists, c.Add(ctx, "foo", nil, nil))
+	require.NoError(t, c.Add(ctx, "bar", nil, nil))
+	require.NoError(t, c.Close(ctx))
+
+	// Filters of different parameters are ignored.
+	c, err = newBloomCache(2000, 0.001, time.Hour, path, 0, service.MockResources().Logger())
+	require.NoError(t, err)
+	require.NoError(t, c.Add(ctx, "foo", nil, nil))
+	require.NoError(t, c.Close(ctx))
+}
@@ -0,0 +1,100 @@
+package pure
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+type resettableWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// compressWriterPool pools compression writers by compression level, as
+// constructing a writer allocates large internal buffers that would otherwise
+// dominate the allocations of compressing each message.
+type compressWriterPool struct {
+	pools sync.Map // map[int]*sync.Pool
+	newFn func(level int, w io.Writer) (resettableWriter, error)
+}
+
+func newCompressWriterPool(fn func(level int, w io.Writer) (resettableWriter, error)) *compressWriterPool {
+	return &compressWriterPool{newFn: fn}
+}
+
+func (p *compressWriterPool) get(level int, w io.Writer) (resettableWriter, error) {
+	if v, exists := p.pools.Load(level); exists {
+		if rw, ok := v.(*sync.Pool).Get().(resettableWriter); ok {
+			rw.Reset(w)
+			return rw, nil
+		}
+	}
+	return p.newFn(level, w)
+}
+
+// put returns a writer to the pool, which must only be done once the writer has
+// been closed successfully.
+func (p *compressWriterPool) put(level int, rw resettableWriter) {
+	v, _ := p.pools.LoadOrStore(level, &sync.Pool{})
+	v.(*sync.Pool).Put(rw)
+}
+
+// compress writes the compressed form of b to a new buffer using a pooled
+// writer.
+func (p *compressWriterPool) compress(level int, b []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := p.get(level, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(b); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	// Must flush writer before calling buf.Bytes()
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	p.put(level, w)
+	return buf.Bytes(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// decompressReaderPool pools decompression readers, which similarly to writers
+// allocate large internal buffers when constructed.
+type decompressReaderPool struct {
+	pool    sync.Pool
+	newFn   func(r io.Reader) (io.ReadCloser, error)
+	resetFn func(rc io.ReadCloser, r io.Reader) error
+}
+
+// decompress writes the decompressed form of b to a new buffer using a pooled
+// reader.
+func (p *decompressReaderPool) decompress(b []byte) ([]byte, error) {
+	var r io.ReadCloser
+	if pooled, ok := p.pool.Get().(io.ReadCloser); ok {
+		if err := p.resetFn(pooled, bytes.NewReader(b)); err != nil {
+			return nil, err
+		}
+		r = pooled
+	} else {
+		var err error
+		if r, err = p.newFn(bytes.NewReader(b)); err != nil {
+			return nil, err
+		}
+	}
+
+	outBuf := bytes.Buffer{}
+	outBuf.Grow(len(b) * 2)
+	if _, err := io.Copy(&outBuf, r); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	if err := r.Close(); err == nil {
+		p.pool.Put(r)
+	}
+	return outBuf.Bytes(), nil
+}
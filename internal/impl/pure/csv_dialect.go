@@ -0,0 +1,225 @@
+package pure
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// csvDialect describes the delimiter and quoting rules of a CSV document. Unlike
+// encoding/csv it supports custom quote characters and quotes escaped with an
+// escape character rather than by doubling them.
+type csvDialect struct {
+	delim      rune
+	quote      rune
+	escape     rune // Zero when quotes are escaped by doubling them.
+	lazyQuotes bool
+}
+
+func newCSVDialect(delim, quote, escape string, lazyQuotes bool) (*csvDialect, error) {
+	d := &csvDialect{lazyQuotes: lazyQuotes}
+
+	var err error
+	if d.delim, err = csvSingleRune("delimiter", delim); err != nil {
+		return nil, err
+	}
+	if d.quote, err = csvSingleRune("quote", quote); err != nil {
+		return nil, err
+	}
+	if escape != "" {
+		if d.escape, err = csvSingleRune("escape", escape); err != nil {
+			return nil, err
+		}
+		if d.escape == d.quote {
+			d.escape = 0
+		}
+	}
+	for _, r := range []rune{d.delim, d.quote, d.escape} {
+		if r == '\r' || r == '\n' {
+			return nil, errors.New("delimiter, quote and escape characters must not be line breaks")
+		}
+	}
+	if d.delim == d.quote || d.delim == d.escape {
+		return nil, errors.New("the delimiter must differ from the quote and escape characters")
+	}
+	return d, nil
+}
+
+func csvSingleRune(name, s string) (rune, error) {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError || size != len(s) {
+		return 0, fmt.Errorf("%v value must be exactly one character", name)
+	}
+	return r, nil
+}
+
+// read parses all records of a CSV document. Empty lines are skipped and both
+// LF and CRLF line endings are accepted.
+func (d *csvDialect) read(data string) ([][]string, error) {
+	const (
+		stateFieldStart = iota
+		stateUnquoted
+		stateQuoted
+		stateQuoteInQuoted
+	)
+
+	var (
+		records [][]string
+		record  []string
+		field   strings.Builder
+		state   = stateFieldStart
+		line    = 1
+	)
+
+	endField := func() {
+		record = append(record, field.String())
+		field.Reset()
+	}
+	endRecord := func() {
+		endField()
+		records = append(records, record)
+		record = nil
+	}
+
+	for i, w := 0, 0; i < len(data); i += w {
+		var r rune
+		r, w = utf8.DecodeRuneInString(data[i:])
+
+		// Treat CRLF as a single line break outside of quoted fields.
+		if r == '\r' && state != stateQuoted && strings.HasPrefix(data[i+w:], "\n") {
+			continue
+		}
+
+		switch state {
+		case stateFieldStart, stateUnquoted:
+			switch {
+			case r == d.delim:
+				endField()
+				state = stateFieldStart
+			case r == '\n':
+				if state == stateUnquoted || len(record) > 0 {
+					endRecord()
+				}
+				state = stateFieldStart
+				line++
+			case r == d.quote && state == stateFieldStart:
+				state = stateQuoted
+			case r == d.quote && !d.lazyQuotes:
+				return nil, fmt.Errorf("line %v: bare quote in unquoted field", line)
+			default:
+				field.WriteRune(r)
+				state = stateUnquoted
+			}
+		case stateQuoted:
+			switch {
+			case d.escape != 0 && r == d.escape && i+w < len(data):
+				next, nw := utf8.DecodeRuneInString(data[i+w:])
+				if next == d.quote || next == d.escape {
+					field.WriteRune(next)
+					w += nw
+				} else {
+					field.WriteRune(r)
+				}
+			case r == d.quote:
+				state = stateQuoteInQuoted
+			default:
+				if r == '\n' {
+					line++
+				}
+				field.WriteRune(r)
+			}
+		case stateQuoteInQuoted:
+			switch {
+			case r == d.quote && d.escape == 0:
+				field.WriteRune(r)
+				state = stateQuoted
+			case r == d.delim:
+				endField()
+				state = stateFieldStart
+			case r == '\n':
+				endRecord()
+				state = stateFieldStart
+				line++
+			case d.lazyQuotes:
+				field.WriteRune(d.quote)
+				field.WriteRune(r)
+				state = stateQuoted
+			default:
+				return nil, fmt.Errorf("line %v: extraneous or missing quote in quoted field", line)
+			}
+		}
+	}
+
+	switch {
+	case state == stateQuoted && !d.lazyQuotes:
+		return nil, fmt.Errorf("line %v: quoted field is not terminated", line)
+	case state != stateFieldStart || len(record) > 0:
+		endRecord()
+	}
+	return records, nil
+}
+
+// csvQuoteMode determines which fields are quoted when writing records.
+type csvQuoteMode int
+
+const (
+	csvQuoteMinimal csvQuoteMode = iota
+	csvQuoteAll
+	csvQuoteNonNumeric
+)
+
+// csvField is a value to be written along with whether it originated from a
+// numeric value, which is used by the non_numeric quote mode.
+type csvField struct {
+	value   string
+	numeric bool
+}
+
+func (d *csvDialect) needsQuotes(s string) bool {
+	if s == "" {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(s); unicode.IsSpace(r) {
+		return true
+	}
+	return strings.ContainsAny(s, "\r\n") ||
+		strings.ContainsRune(s, d.delim) ||
+		strings.ContainsRune(s, d.quote) ||
+		(d.escape != 0 && strings.ContainsRune(s, d.escape))
+}
+
+// writeRecord appends a record to a buffer without a trailing line break.
+func (d *csvDialect) writeRecord(buf *bytes.Buffer, fields []csvField, mode csvQuoteMode) {
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteRune(d.delim)
+		}
+
+		quote := d.needsQuotes(f.value)
+		switch mode {
+		case csvQuoteAll:
+			quote = true
+		case csvQuoteNonNumeric:
+			quote = quote || !f.numeric
+		}
+		if !quote {
+			buf.WriteString(f.value)
+			continue
+		}
+
+		buf.WriteRune(d.quote)
+		for _, r := range f.value {
+			switch {
+			case r == d.quote && d.escape == 0:
+				buf.WriteRune(d.quote)
+			case d.escape != 0 && (r == d.quote || r == d.escape):
+				buf.WriteRune(d.escape)
+			}
+			buf.WriteRune(r)
+		}
+		buf.WriteRune(d.quote)
+	}
+}
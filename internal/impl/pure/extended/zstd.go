@@ -3,6 +3,7 @@ package extended
 import (
 	"bytes"
 	"io"
+	"sync"
 
 	"github.com/klauspost/compress/zstd"
 
@@ -11,7 +12,11 @@ import (
 
 var _ = pure.AddCompressFunc("zstd", func(level int, b []byte) ([]byte, error) {
 	buf := &bytes.Buffer{}
-	w, err := zstd.NewWriter(buf)
+	var opts []zstd.EOption
+	if level > 0 {
+		opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	}
+	w, err := zstd.NewWriter(buf, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -39,3 +44,65 @@ var _ = pure.AddDecompressFunc("zstd", func(b []byte) ([]byte, error) {
 	r.Close()
 	return outBuf.Bytes(), nil
 })
+
+// zstdDictMagic is the prefix of dictionaries created with `zstd --train`,
+// other dictionaries are used as raw content.
+var zstdDictMagic = []byte{0x37, 0xa4, 0x30, 0xec}
+
+// zstdRawDictID is the ID given to raw content dictionaries, which is recorded
+// within frames compressed with them.
+const zstdRawDictID = 1
+
+func zstdEncoderDict(dict []byte) zstd.EOption {
+	if bytes.HasPrefix(dict, zstdDictMagic) {
+		return zstd.WithEncoderDict(dict)
+	}
+	return zstd.WithEncoderDictRaw(zstdRawDictID, dict)
+}
+
+func zstdDecoderDict(dict []byte) zstd.DOption {
+	if bytes.HasPrefix(dict, zstdDictMagic) {
+		return zstd.WithDecoderDicts(dict)
+	}
+	return zstd.WithDecoderDictRaw(zstdRawDictID, dict)
+}
+
+var _ = pure.AddCompressDictCtor("zstd", func(dict []byte) (pure.CompressFunc, error) {
+	// Validate the dictionary before any messages are compressed.
+	if _, err := zstd.NewWriter(nil, zstdEncoderDict(dict)); err != nil {
+		return nil, err
+	}
+
+	// Encoders are safe for concurrent use and expensive to create with a
+	// dictionary, so one is kept for each level.
+	var encodersMut sync.Mutex
+	encoders := map[int]*zstd.Encoder{}
+	return func(level int, b []byte) ([]byte, error) {
+		encodersMut.Lock()
+		enc, exists := encoders[level]
+		if !exists {
+			opts := []zstd.EOption{zstdEncoderDict(dict)}
+			if level > 0 {
+				opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+			}
+			var err error
+			if enc, err = zstd.NewWriter(nil, opts...); err != nil {
+				encodersMut.Unlock()
+				return nil, err
+			}
+			encoders[level] = enc
+		}
+		encodersMut.Unlock()
+		return enc.EncodeAll(b, nil), nil
+	}, nil
+})
+
+var _ = pure.AddDecompressDictCtor("zstd", func(dict []byte) (pure.DecompressFunc, error) {
+	dec, err := zstd.NewReader(nil, zstdDecoderDict(dict))
+	if err != nil {
+		return nil, err
+	}
+	return func(b []byte) ([]byte, error) {
+		return dec.DecodeAll(b, nil)
+	}, nil
+})
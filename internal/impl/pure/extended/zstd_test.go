@@ -1,11 +1,18 @@
 package extended
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
 )
 
@@ -29,3 +36,76 @@ func TestZstdCompressionDecompression(t *testing.T) {
 
 	assert.Equal(t, input, decompressed)
 }
+
+func TestZstdCompressionLevels(t *testing.T) {
+	input := []byte("hello world this is a really long string")
+
+	for _, level := range []int{-1, 1, 3, 11, 22} {
+		exec, err := bloblang.Parse(fmt.Sprintf(`root = this.compress(algorithm: "zstd", level: %v).decompress("auto")`, level))
+		require.NoError(t, err)
+
+		decompressed, err := exec.Query(input)
+		require.NoError(t, err, level)
+		assert.Equal(t, input, decompressed, level)
+	}
+}
+
+func TestZstdDictionaryProcessors(t *testing.T) {
+	dictPath := filepath.Join(t.TempDir(), "events.dict")
+	require.NoError(t, os.WriteFile(dictPath, []byte(`{"event":"page_view","user":{"id":"","name":""},"tags":["web","mobile"]}`), 0o644))
+
+	input := []byte(`{"event":"page_view","user":{"id":"123","name":"foo"},"tags":["web"]}`)
+
+	compConf := processor.NewConfig()
+	compConf.Type = "compress"
+	compConf.Compress.Algorithm = "zstd"
+	compConf.Compress.Level = 19
+	compConf.Compress.Dictionary = dictPath
+
+	comp, err := mock.NewManager().NewProcessor(compConf)
+	require.NoError(t, err)
+
+	msgs, res := comp.ProcessBatch(context.Background(), message.QuickBatch([][]byte{input}))
+	require.NoError(t, res)
+	require.Len(t, msgs, 1)
+	compressed := msgs[0].Get(0).AsBytes()
+
+	withoutDict, err := bloblang.Parse(`root = this.compress(algorithm: "zstd", level: 19)`)
+	require.NoError(t, err)
+	plain, err := withoutDict.Query(input)
+	require.NoError(t, err)
+	assert.Less(t, len(compressed), len(plain.([]byte)))
+
+	decompConf := processor.NewConfig()
+	decompConf.Type = "decompress"
+	decompConf.Decompress.Algorithm = "zstd"
+	decompConf.Decompress.Dictionary = dictPath
+
+	decomp, err := mock.NewManager().NewProcessor(decompConf)
+	require.NoError(t, err)
+
+	msgs, res = decomp.ProcessBatch(context.Background(), message.QuickBatch([][]byte{compressed}))
+	require.NoError(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, string(input), string(msgs[0].Get(0).AsBytes()))
+
+	// Decompressing without the dictionary fails.
+	exec, err := bloblang.Parse(`root = this.decompress("zstd")`)
+	require.NoError(t, err)
+	_, err = exec.Query(compressed)
+	require.Error(t, err)
+}
+
+func TestZstdDictionaryUnsupported(t *testing.T) {
+	dictPath := filepath.Join(t.TempDir(), "events.dict")
+	require.NoError(t, os.WriteFile(dictPath, []byte(`foo`), 0o644))
+
+	conf := processor.NewConfig()
+	conf.Type = "compress"
+	conf.Compress.Algorithm = "gzip"
+	conf.Compress.Dictionary = dictPath
+
+	_, err := mock.NewManager().NewProcessor(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support dictionaries")
+}
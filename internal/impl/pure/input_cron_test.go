@@ -0,0 +1,226 @@
+package pure
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type fakeCronClock struct {
+	mut    sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (f *fakeCronClock) Now() time.Time {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.now
+}
+
+func (f *fakeCronClock) Sleep(ctx context.Context, d time.Duration) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.sleeps = append(f.sleeps, d)
+	f.now = f.now.Add(d)
+	return nil
+}
+
+func testCronInput(t *testing.T, mgr *service.Resources, clock *fakeCronClock, confStr string) *cronInput {
+	t.Helper()
+
+	pConf, err := cronInputSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	c, err := newCronInputFromParsed(pConf, mgr)
+	require.NoError(t, err)
+	c.nowFn = clock.Now
+	c.sleepFn = clock.Sleep
+	return c
+}
+
+type cronRun struct {
+	Content   string
+	Scheduled string
+	CatchUp   bool
+}
+
+func readCronRun(t *testing.T, c *cronInput) (cronRun, service.AckFunc) {
+	t.Helper()
+
+	msg, ackFn, err := c.Read(context.Background())
+	require.NoError(t, err)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+
+	runID, _ := msg.MetaGet("cron_run_id")
+	assert.Len(t, runID, 36)
+
+	scheduled, _ := msg.MetaGet("cron_scheduled_time")
+	catchUp, _ := msg.MetaGetMut("cron_catch_up")
+	return cronRun{Content: string(b), Scheduled: scheduled, CatchUp: catchUp.(bool)}, ackFn
+}
+
+func TestCronInputSchedule(t *testing.T) {
+	clock := &fakeCronClock{now: time.Date(2023, 1, 1, 10, 20, 0, 0, time.UTC)}
+
+	c := testCronInput(t, service.MockResources(), clock, `
+schedule: '0 0 * * * *'
+timezone: America/New_York
+mapping: 'root = meta("cron_scheduled_time")'
+jitter: 10s
+`)
+	c.jitterFn = func(max time.Duration) time.Duration { return max / 2 }
+	require.NoError(t, c.Connect(context.Background()))
+
+	run, _ := readCronRun(t, c)
+	assert.Equal(t, cronRun{Content: "2023-01-01T06:00:00-05:00", Scheduled: "2023-01-01T06:00:00-05:00"}, run)
+	assert.Equal(t, []time.Duration{40*time.Minute + 5*time.Second}, clock.sleeps)
+
+	// A run that is overdue is triggered immediately, but further runs that
+	// elapsed in the meantime are skipped.
+	clock.now = clock.now.Add(150 * time.Minute)
+	run, _ = readCronRun(t, c)
+	assert.Equal(t, "2023-01-01T07:00:00-05:00", run.Scheduled)
+
+	run, _ = readCronRun(t, c)
+	assert.Equal(t, "2023-01-01T09:00:00-05:00", run.Scheduled)
+}
+
+func TestCronInputCatchUp(t *testing.T) {
+	clock := &fakeCronClock{now: time.Date(2023, 1, 1, 10, 20, 0, 0, time.UTC)}
+
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+	getCheckpoint := func() string {
+		var v []byte
+		require.NoError(t, mgr.AccessCache(context.Background(), "foocache", func(c service.Cache) {
+			v, _ = c.Get(context.Background(), "cron_last_run")
+		}))
+		return string(v)
+	}
+
+	conf := `
+schedule: '@hourly'
+checkpoint_cache: foocache
+max_catch_up_runs: 2
+`
+
+	// Without a checkpoint the schedule is followed.
+	c := testCronInput(t, mgr, clock, conf)
+	require.NoError(t, c.Connect(context.Background()))
+
+	run, ackFn := readCronRun(t, c)
+	assert.Equal(t, cronRun{Content: "{}", Scheduled: "2023-01-01T11:00:00Z"}, run)
+	assert.Equal(t, "", getCheckpoint())
+	require.NoError(t, ackFn(context.Background(), nil))
+	assert.Equal(t, "2023-01-01T11:00:00Z", getCheckpoint())
+
+	// After downtime the most recent missed runs are caught up.
+	clock.now = time.Date(2023, 1, 1, 15, 30, 0, 0, time.UTC)
+	c = testCronInput(t, mgr, clock, conf)
+	require.NoError(t, c.Connect(context.Background()))
+
+	runA, ackA := readCronRun(t, c)
+	runB, ackB := readCronRun(t, c)
+	runC, _ := readCronRun(t, c)
+	assert.Equal(t, []cronRun{
+		{Content: "{}", Scheduled: "2023-01-01T14:00:00Z", CatchUp: true},
+		{Content: "{}", Scheduled: "2023-01-01T15:00:00Z", CatchUp: true},
+		{Content: "{}", Scheduled: "2023-01-01T16:00:00Z"},
+	}, []cronRun{runA, runB, runC})
+	assert.Equal(t, []time.Duration{40 * time.Minute, 30 * time.Minute}, clock.sleeps)
+
+	// Checkpoints only move forwards.
+	require.NoError(t, ackB(context.Background(), nil))
+	require.NoError(t, ackA(context.Background(), nil))
+	assert.Equal(t, "2023-01-01T15:00:00Z", getCheckpoint())
+}
+
+type countingCronSchedule struct {
+	cron.Schedule
+	calls int
+}
+
+func (c *countingCronSchedule) Next(t time.Time) time.Time {
+	c.calls++
+	return c.Schedule.Next(t)
+}
+
+func TestCronInputMissedRuns(t *testing.T) {
+	now := time.Date(2023, 1, 8, 10, 20, 0, 500, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule string
+		last     time.Time
+		max      int
+		expected []string
+	}{
+		{
+			name:     "frequent schedule after long downtime",
+			schedule: "* * * * * *",
+			last:     now.Add(-7 * 24 * time.Hour),
+			max:      3,
+			expected: []string{"2023-01-08T10:19:58Z", "2023-01-08T10:19:59Z", "2023-01-08T10:20:00Z"},
+		},
+		{
+			name:     "fewer missed runs than the maximum",
+			schedule: "@hourly",
+			last:     time.Date(2023, 1, 8, 8, 0, 0, 0, time.UTC),
+			max:      24,
+			expected: []string{"2023-01-08T09:00:00Z", "2023-01-08T10:00:00Z"},
+		},
+		{
+			name:     "no missed runs",
+			schedule: "@hourly",
+			last:     time.Date(2023, 1, 8, 10, 0, 0, 0, time.UTC),
+			max:      24,
+		},
+		{
+			name:     "infrequent schedule",
+			schedule: "0 0 0 1 * *",
+			last:     time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			max:      2,
+			expected: []string{"2022-12-01T00:00:00Z", "2023-01-01T00:00:00Z"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			schedule, _, err := parseCronExpression(test.schedule)
+			require.NoError(t, err)
+
+			counting := &countingCronSchedule{Schedule: *schedule}
+			var runs []string
+			for _, r := range missedCronRuns(counting, test.last, now, test.max) {
+				runs = append(runs, r.Format(time.RFC3339))
+			}
+			assert.Equal(t, test.expected, runs)
+			assert.Less(t, counting.calls, 1000)
+		})
+	}
+}
+
+func TestCronInputBadConfig(t *testing.T) {
+	for _, conf := range []string{
+		`schedule: 'not a schedule'`,
+		`{ schedule: '@hourly', timezone: Nope/Nope }`,
+		`{ schedule: '@hourly', checkpoint_cache: nope }`,
+		`{ schedule: '@hourly', rate_limit: nope }`,
+	} {
+		pConf, err := cronInputSpec().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		_, err = newCronInputFromParsed(pConf, service.MockResources())
+		assert.Error(t, err, conf)
+	}
+}
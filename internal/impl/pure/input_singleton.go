@@ -0,0 +1,389 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	siFieldInput         = "input"
+	siFieldCache         = "cache"
+	siFieldKey           = "key"
+	siFieldTTL           = "ttl"
+	siFieldRenewPeriod   = "renew_period"
+	siFieldAcquirePeriod = "acquire_period"
+	siFieldInstanceID    = "instance_id"
+)
+
+func singletonInputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.20.0").
+		Summary(`Consumes from a child input only whilst holding a lease within a cache resource, allowing multiple replicas of a Benthos pipeline to run with only one of them (the leader) consuming at any given time.`).
+		Description(`
+This input is useful for running highly available pipelines that consume from inputs that are not partitioned and therefore cannot be consumed from multiple places at once, such as an `+"`sftp`"+` input or an `+"`http_client`"+` input that polls an endpoint.
+
+### Leader Election
+
+Each replica attempts to obtain a lease by adding the key `+"`key`"+` to the cache resource with a value unique to the replica (configured with `+"`instance_id`"+`) and a TTL of `+"`ttl`"+`. Since adding a key only succeeds when it does not already exist only one replica is able to obtain the lease, and that replica becomes the leader. All other replicas reattempt to obtain the lease every `+"`acquire_period`"+`.
+
+The leader renews the lease every `+"`renew_period`"+`, and if the lease is found to belong to another replica, or it fails to renew the lease before the TTL has passed, then the leader steps down by closing the child input and attempting to obtain the lease once more. When the leader shuts down gracefully the lease is released, which allows another replica to take over immediately. Otherwise another replica takes over once the TTL of the lease has passed.
+
+The child input is only created whilst the lease is held, and is closed whenever the lease is lost. Therefore it is possible for messages that were consumed by a previous leader and not yet acknowledged to be consumed again by the new leader, and the child input should be configured with this in mind.
+
+The cache resource must support TTLs, and renewing the lease is not an atomic operation, which means caches that offer stronger consistency guarantees (such as `+"`redis`"+`) are preferable.
+
+### Metrics
+
+The gauge `+"`singleton_leader`"+` is emitted with a value of `+"`1`"+` whilst the lease is held and `+"`0`"+` otherwise.`).
+		Fields(
+			service.NewInputField(siFieldInput).
+				Description("The child input to consume from whilst holding the lease."),
+			service.NewStringField(siFieldCache).
+				Description("The name of a cache resource to store the lease within."),
+			service.NewStringField(siFieldKey).
+				Description("The key of the lease, which must be shared by all replicas of the pipeline."),
+			service.NewDurationField(siFieldTTL).
+				Description("The TTL of the lease, which is the period of time after which another replica is able to take over should the leader fail to renew it.").
+				Default("30s"),
+			service.NewDurationField(siFieldRenewPeriod).
+				Description("The period of time between renewals of the lease whilst leader, which must be shorter than the TTL.").
+				Default("10s").
+				Advanced(),
+			service.NewDurationField(siFieldAcquirePeriod).
+				Description("The period of time between attempts to obtain the lease whilst not leader.").
+				Default("5s").
+				Advanced(),
+			service.NewStringField(siFieldInstanceID).
+				Description("A value unique to this replica stored within the lease. When left empty a random UUID is generated.").
+				Default("").
+				Advanced(),
+		).
+		Example(
+			"Highly Available SFTP Consumer",
+			"In this example we run multiple replicas of a pipeline that consumes files from an SFTP server, where only one replica consumes at a time and the lease is held within Redis.",
+			`
+input:
+  singleton:
+    cache: leases
+    key: sftp_consumer
+    ttl: 30s
+    input:
+      sftp:
+        address: localhost:22
+        paths: [ /data/*.csv ]
+        delete_on_finish: true
+
+cache_resources:
+  - label: leases
+    redis:
+      url: redis://localhost:6379
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput("singleton", singletonInputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return singletonInputFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type singletonInput struct {
+	conf *service.ParsedConfig
+	mgr  *service.Resources
+	log  *service.Logger
+
+	cacheName     string
+	key           string
+	ttl           time.Duration
+	renewPeriod   time.Duration
+	acquirePeriod time.Duration
+	instanceID    string
+
+	mLeader *service.MetricGauge
+
+	mut      sync.Mutex
+	child    *service.OwnedInput
+	lostChan chan struct{}
+
+	// Constructs the child input, overridden in tests.
+	newChild func() (*service.OwnedInput, error)
+
+	shutSig *shutdown.Signaller
+}
+
+func singletonInputFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*singletonInput, error) {
+	s := &singletonInput{
+		conf:    conf,
+		mgr:     mgr,
+		log:     mgr.Logger(),
+		mLeader: mgr.Metrics().NewGauge("singleton_leader"),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if s.cacheName, err = conf.FieldString(siFieldCache); err != nil {
+		return nil, err
+	}
+	if !mgr.HasCache(s.cacheName) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", s.cacheName)
+	}
+	if s.key, err = conf.FieldString(siFieldKey); err != nil {
+		return nil, err
+	}
+	if s.ttl, err = conf.FieldDuration(siFieldTTL); err != nil {
+		return nil, err
+	}
+	if s.renewPeriod, err = conf.FieldDuration(siFieldRenewPeriod); err != nil {
+		return nil, err
+	}
+	if s.renewPeriod <= 0 || s.renewPeriod >= s.ttl {
+		return nil, errors.New("renew_period must be greater than zero and shorter than the ttl")
+	}
+	if s.acquirePeriod, err = conf.FieldDuration(siFieldAcquirePeriod); err != nil {
+		return nil, err
+	}
+	if s.instanceID, err = conf.FieldString(siFieldInstanceID); err != nil {
+		return nil, err
+	}
+	if s.instanceID == "" {
+		u4, err := uuid.NewV4()
+		if err != nil {
+			return nil, err
+		}
+		s.instanceID = u4.String()
+	}
+
+	s.newChild = func() (*service.OwnedInput, error) {
+		return s.conf.FieldInput(siFieldInput)
+	}
+	s.mLeader.Set(0)
+	return s, nil
+}
+
+// tryAcquire attempts to obtain the lease, returning true if it is now held by
+// this instance.
+func (s *singletonInput) tryAcquire(ctx context.Context) (acquired bool, err error) {
+	if cerr := s.mgr.AccessCache(ctx, s.cacheName, func(c service.Cache) {
+		if err = c.Add(ctx, s.key, []byte(s.instanceID), &s.ttl); err == nil {
+			acquired = true
+			return
+		}
+		if !errors.Is(err, service.ErrKeyAlreadyExists) {
+			return
+		}
+
+		// If the lease already belongs to us (perhaps we restarted) then we
+		// simply take it back.
+		var holder []byte
+		if holder, err = c.Get(ctx, s.key); err != nil {
+			if errors.Is(err, service.ErrKeyNotFound) {
+				err = nil
+			}
+			return
+		}
+		if string(holder) == s.instanceID {
+			acquired = true
+			err = c.Set(ctx, s.key, []byte(s.instanceID), &s.ttl)
+		}
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+// renew attempts to extend the lease, returning false if the lease is held by
+// another instance.
+func (s *singletonInput) renew(ctx context.Context) (held bool, err error) {
+	if cerr := s.mgr.AccessCache(ctx, s.cacheName, func(c service.Cache) {
+		var holder []byte
+		if holder, err = c.Get(ctx, s.key); err != nil {
+			if errors.Is(err, service.ErrKeyNotFound) {
+				// The lease expired but nobody else has taken it yet.
+				err = c.Add(ctx, s.key, []byte(s.instanceID), &s.ttl)
+				held = err == nil
+				if errors.Is(err, service.ErrKeyAlreadyExists) {
+					err = nil
+				}
+			}
+			return
+		}
+		if string(holder) != s.instanceID {
+			return
+		}
+		held = true
+		err = c.Set(ctx, s.key, []byte(s.instanceID), &s.ttl)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+func (s *singletonInput) release(ctx context.Context) (err error) {
+	if cerr := s.mgr.AccessCache(ctx, s.cacheName, func(c service.Cache) {
+		var holder []byte
+		if holder, err = c.Get(ctx, s.key); err != nil {
+			if errors.Is(err, service.ErrKeyNotFound) {
+				err = nil
+			}
+			return
+		}
+		if string(holder) == s.instanceID {
+			err = c.Delete(ctx, s.key)
+		}
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+func (s *singletonInput) Connect(ctx context.Context) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.child != nil {
+		return nil
+	}
+
+	for {
+		acquired, err := s.tryAcquire(ctx)
+		if err != nil {
+			s.log.Errorf("Failed to obtain lease: %v", err)
+		}
+		if acquired {
+			break
+		}
+		select {
+		case <-time.After(s.acquirePeriod):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.shutSig.CloseAtLeisureChan():
+			return service.ErrEndOfInput
+		}
+	}
+
+	child, err := s.newChild()
+	if err != nil {
+		_ = s.release(ctx)
+		return err
+	}
+
+	s.log.Infof("Obtained lease '%v', consuming from child input", s.key)
+	s.mLeader.Set(1)
+	s.child = child
+	s.lostChan = make(chan struct{})
+	go s.renewLoop(child, s.lostChan)
+	return nil
+}
+
+func (s *singletonInput) renewLoop(child *service.OwnedInput, lostChan chan struct{}) {
+	lastRenewed := time.Now()
+	for {
+		select {
+		case <-time.After(s.renewPeriod):
+		case <-s.shutSig.CloseAtLeisureChan():
+			return
+		}
+
+		ctx, done := s.shutSig.CloseAtLeisureCtx(context.Background())
+		held, err := s.renew(ctx)
+		done()
+		if err == nil && held {
+			lastRenewed = time.Now()
+			continue
+		}
+		if err != nil {
+			if time.Since(lastRenewed) < s.ttl {
+				s.log.Warnf("Failed to renew lease: %v", err)
+				continue
+			}
+			s.log.Errorf("Failed to renew lease before it expired: %v", err)
+		}
+
+		s.log.Warnf("Lost lease '%v', closing child input", s.key)
+		s.stepDown(child, lostChan)
+		return
+	}
+}
+
+func (s *singletonInput) stepDown(child *service.OwnedInput, lostChan chan struct{}) {
+	s.mut.Lock()
+	if s.child == child {
+		s.child = nil
+		close(lostChan)
+		s.mLeader.Set(0)
+	}
+	s.mut.Unlock()
+
+	go func() {
+		ctx, done := context.WithTimeout(context.Background(), s.ttl)
+		defer done()
+		if err := child.Close(ctx); err != nil {
+			s.log.Errorf("Failed to close child input: %v", err)
+		}
+	}()
+}
+
+func (s *singletonInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	s.mut.Lock()
+	child, lostChan := s.child, s.lostChan
+	s.mut.Unlock()
+
+	if child == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	readCtx, done := context.WithCancel(ctx)
+	defer done()
+	go func() {
+		select {
+		case <-lostChan:
+			done()
+		case <-readCtx.Done():
+		}
+	}()
+
+	batch, ackFn, err := child.ReadBatch(readCtx)
+	if err != nil {
+		select {
+		case <-lostChan:
+			return nil, nil, service.ErrNotConnected
+		default:
+		}
+	}
+	return batch, ackFn, err
+}
+
+func (s *singletonInput) Close(ctx context.Context) error {
+	s.shutSig.CloseAtLeisure()
+
+	s.mut.Lock()
+	child := s.child
+	s.child = nil
+	s.mut.Unlock()
+
+	if child == nil {
+		return nil
+	}
+	err := child.Close(ctx)
+	if rerr := s.release(ctx); rerr != nil && err == nil {
+		err = rerr
+	}
+	s.mLeader.Set(0)
+	return err
+}
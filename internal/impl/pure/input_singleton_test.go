@@ -0,0 +1,134 @@
+package pure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testSingleton(t testing.TB, res *service.Resources, confStr string) *singletonInput {
+	t.Helper()
+
+	conf, err := singletonInputSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	s, err := singletonInputFromParsed(conf, res)
+	require.NoError(t, err)
+	return s
+}
+
+const singletonTestConf = `
+cache: foo
+key: lease
+ttl: 1s
+renew_period: 20ms
+acquire_period: 10ms
+input:
+  generate:
+    mapping: 'root = "hello world"'
+    interval: 1ms
+`
+
+func TestSingletonLeaderElection(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	res := service.MockResources(service.MockResourcesOptAddCache("foo"))
+
+	a := testSingleton(t, res, singletonTestConf)
+	b := testSingleton(t, res, singletonTestConf)
+
+	require.NoError(t, a.Connect(ctx))
+
+	batch, ackFn, err := a.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	mBytes, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(mBytes))
+	require.NoError(t, ackFn(ctx, nil))
+
+	// Whilst a holds the lease b is unable to connect.
+	shortCtx, shortDone := context.WithTimeout(ctx, time.Millisecond*100)
+	require.Error(t, b.Connect(shortCtx))
+	shortDone()
+
+	_, _, err = b.ReadBatch(ctx)
+	require.ErrorIs(t, err, service.ErrNotConnected)
+
+	// Closing a releases the lease so that b takes over.
+	require.NoError(t, a.Close(ctx))
+	require.NoError(t, b.Connect(ctx))
+
+	_, ackFn, err = b.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, ackFn(ctx, nil))
+
+	require.NoError(t, b.Close(ctx))
+}
+
+func TestSingletonLeaseLost(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	res := service.MockResources(service.MockResourcesOptAddCache("foo"))
+
+	a := testSingleton(t, res, singletonTestConf)
+	require.NoError(t, a.Connect(ctx))
+
+	// Another instance steals the lease.
+	require.NoError(t, res.AccessCache(ctx, "foo", func(c service.Cache) {
+		require.NoError(t, c.Set(ctx, "lease", []byte("someone else"), nil))
+	}))
+
+	require.Eventually(t, func() bool {
+		_, ackFn, err := a.ReadBatch(ctx)
+		if err == nil {
+			_ = ackFn(ctx, nil)
+		}
+		return err == service.ErrNotConnected
+	}, time.Second*5, time.Millisecond*10)
+
+	// The lease is not released on close as it belongs to someone else.
+	require.NoError(t, a.Close(ctx))
+	require.NoError(t, res.AccessCache(ctx, "foo", func(c service.Cache) {
+		holder, err := c.Get(ctx, "lease")
+		require.NoError(t, err)
+		assert.Equal(t, "someone else", string(holder))
+	}))
+}
+
+func TestSingletonBadConfig(t *testing.T) {
+	res := service.MockResources(service.MockResourcesOptAddCache("foo"))
+
+	conf, err := singletonInputSpec().ParseYAML(`
+cache: bar
+key: lease
+input:
+  generate:
+    mapping: 'root = "hello world"'
+`, nil)
+	require.NoError(t, err)
+
+	_, err = singletonInputFromParsed(conf, res)
+	require.Error(t, err)
+
+	conf, err = singletonInputSpec().ParseYAML(`
+cache: foo
+key: lease
+ttl: 5s
+renew_period: 10s
+input:
+  generate:
+    mapping: 'root = "hello world"'
+`, nil)
+	require.NoError(t, err)
+
+	_, err = singletonInputFromParsed(conf, res)
+	require.Error(t, err)
+}
@@ -0,0 +1,361 @@
+package pure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/interop"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	cbFieldOutput         = "output"
+	cbFieldFallback       = "fallback"
+	cbFieldFailureRatio   = "failure_ratio"
+	cbFieldMinRequests    = "min_requests"
+	cbFieldWindow         = "window"
+	cbFieldOpenDuration   = "open_duration"
+	cbFieldHalfOpenProbes = "half_open_probes"
+	cbFieldMaxInFlight    = "max_in_flight"
+)
+
+func circuitBreakerOutputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.20.0").
+		Summary(`Wraps a child output and tracks the rate of failed writes, opening a circuit when a threshold is crossed so that subsequent writes fail fast (or are routed to a fallback output) until the child has had time to recover.`).
+		Description(`
+The circuit breaker begins in a closed state where all messages are written to the child output. Within each `+"`window`"+` of time the number of successful and failed writes is tracked, and once at least `+"`min_requests`"+` writes have been attempted and the ratio of failures meets or exceeds `+"`failure_ratio`"+` the circuit opens.
+
+Whilst open all writes are rejected immediately without reaching the child output. If a `+"`fallback`"+` output is configured then messages are instead written to it, otherwise an error is returned and the message is dealt with according to the input (usually by being nacked and reattempted). The circuit remains open for `+"`open_duration`"+`, after which it becomes half-open.
+
+Whilst half-open a limited number of probe writes, determined by `+"`half_open_probes`"+`, are allowed through to the child output and all other writes are treated as though the circuit were open. If all probes succeed then the circuit closes, but if any probe fails the circuit opens once again.
+
+### Metrics
+
+The gauge `+"`circuit_breaker_state`"+` is emitted with a value of `+"`0`"+` when closed, `+"`1`"+` when half-open and `+"`2`"+` when open, and the counter `+"`circuit_breaker_rejected`"+` is incremented each time a write is rejected due to the circuit being open.
+
+### HTTP API
+
+When the output has a label the current state of the circuit breaker can be obtained by sending a GET request to the endpoint `+"`/circuit_breaker/{label}`"+`, where `+"`{label}`"+` is the label of the output. The response is a JSON object containing the state of the circuit along with the successes and failures counted within the current window. A POST request to the same endpoint resets the circuit to a closed state.`).
+		Fields(
+			service.NewOutputField(cbFieldOutput).
+				Description("The child output to wrap."),
+			service.NewOutputField(cbFieldFallback).
+				Description("An optional output to route messages to whilst the circuit is open. When omitted messages are rejected with an error instead.").
+				Optional(),
+			service.NewFloatField(cbFieldFailureRatio).
+				Description("The ratio of failed writes to total writes within a window that causes the circuit to open.").
+				Default(0.5),
+			service.NewIntField(cbFieldMinRequests).
+				Description("The minimum number of writes that must be attempted within a window before the circuit can open.").
+				Default(10),
+			service.NewDurationField(cbFieldWindow).
+				Description("The period of time over which successes and failures are counted, after which the counts are reset.").
+				Default("1m"),
+			service.NewDurationField(cbFieldOpenDuration).
+				Description("The period of time to keep the circuit open before allowing probe writes through.").
+				Default("30s"),
+			service.NewIntField(cbFieldHalfOpenProbes).
+				Description("The number of successful probe writes required whilst half-open in order to close the circuit.").
+				Default(1).
+				Advanced(),
+			service.NewIntField(cbFieldMaxInFlight).
+				Description("The maximum number of messages to have in flight at a given time.").
+				Default(64),
+		).
+		Example(
+			"Fail Over to a Queue",
+			"In this example we write messages to an HTTP endpoint, but if more than half of the requests within a minute fail we stop hammering the endpoint for thirty seconds and instead write messages to a Kafka topic to be dealt with later.",
+			`
+output:
+  label: api_breaker
+  circuit_breaker:
+    failure_ratio: 0.5
+    min_requests: 20
+    window: 1m
+    open_duration: 30s
+    output:
+      http_client:
+        url: http://example.com/post
+        verb: POST
+    fallback:
+      kafka:
+        addresses: [ localhost:9092 ]
+        topic: failed_posts
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("circuit_breaker", circuitBreakerOutputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if maxInFlight, err = conf.FieldInt(cbFieldMaxInFlight); err != nil {
+				return
+			}
+			var cb *circuitBreakerOutput
+			if cb, err = circuitBreakerOutputFromParsed(conf, mgr); err != nil {
+				return
+			}
+			if label := mgr.Label(); label != "" {
+				interop.UnwrapManagement(mgr).RegisterEndpoint(
+					"/circuit_breaker/"+label,
+					"Returns the current state of a circuit_breaker output as a JSON object, or resets it to a closed state with a POST request.",
+					cb.handleState,
+				)
+			}
+			out = cb
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+type cbState int
+
+const (
+	cbStateClosed cbState = iota
+	cbStateHalfOpen
+	cbStateOpen
+)
+
+func (s cbState) String() string {
+	switch s {
+	case cbStateClosed:
+		return "closed"
+	case cbStateHalfOpen:
+		return "half_open"
+	case cbStateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+type cbBatchWriter interface {
+	WriteBatch(ctx context.Context, b service.MessageBatch) error
+	Close(ctx context.Context) error
+}
+
+type circuitBreakerOutput struct {
+	out      cbBatchWriter
+	fallback cbBatchWriter
+	log      *service.Logger
+
+	failureRatio   float64
+	minRequests    int
+	window         time.Duration
+	openDuration   time.Duration
+	halfOpenProbes int
+
+	mState    *service.MetricGauge
+	mRejected *service.MetricCounter
+
+	mut            sync.Mutex
+	state          cbState
+	windowStart    time.Time
+	openedAt       time.Time
+	successes      int
+	failures       int
+	probesInFlight int
+	probeSuccesses int
+
+	nowFn func() time.Time
+}
+
+func circuitBreakerOutputFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*circuitBreakerOutput, error) {
+	c := &circuitBreakerOutput{
+		log:       mgr.Logger(),
+		mState:    mgr.Metrics().NewGauge("circuit_breaker_state"),
+		mRejected: mgr.Metrics().NewCounter("circuit_breaker_rejected"),
+		nowFn:     time.Now,
+	}
+
+	var err error
+	if c.failureRatio, err = conf.FieldFloat(cbFieldFailureRatio); err != nil {
+		return nil, err
+	}
+	if c.failureRatio <= 0 || c.failureRatio > 1 {
+		return nil, errors.New("failure_ratio must be greater than zero and no larger than one")
+	}
+	if c.minRequests, err = conf.FieldInt(cbFieldMinRequests); err != nil {
+		return nil, err
+	}
+	if c.window, err = conf.FieldDuration(cbFieldWindow); err != nil {
+		return nil, err
+	}
+	if c.openDuration, err = conf.FieldDuration(cbFieldOpenDuration); err != nil {
+		return nil, err
+	}
+	if c.halfOpenProbes, err = conf.FieldInt(cbFieldHalfOpenProbes); err != nil {
+		return nil, err
+	}
+	if c.halfOpenProbes < 1 {
+		return nil, errors.New("half_open_probes must be at least one")
+	}
+
+	if c.out, err = conf.FieldOutput(cbFieldOutput); err != nil {
+		return nil, err
+	}
+	if conf.Contains(cbFieldFallback) {
+		if c.fallback, err = conf.FieldOutput(cbFieldFallback); err != nil {
+			return nil, err
+		}
+	}
+
+	c.windowStart = c.nowFn()
+	c.mState.Set(int64(cbStateClosed))
+	return c, nil
+}
+
+// setState transitions the circuit breaker into a new state. Must be called
+// with the mutex held.
+func (c *circuitBreakerOutput) setState(s cbState, now time.Time) {
+	if c.state == s {
+		return
+	}
+	c.log.Infof("Circuit breaker transitioning from %v to %v", c.state, s)
+
+	c.state = s
+	c.probesInFlight = 0
+	c.probeSuccesses = 0
+	switch s {
+	case cbStateOpen:
+		c.openedAt = now
+	case cbStateClosed:
+		c.successes, c.failures = 0, 0
+		c.windowStart = now
+	}
+	c.mState.Set(int64(s))
+}
+
+// allow returns whether a write should be attempted against the child output,
+// and whether that write is a probe.
+func (c *circuitBreakerOutput) allow() (allowed, probe bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	now := c.nowFn()
+	if c.state == cbStateOpen && now.Sub(c.openedAt) >= c.openDuration {
+		c.setState(cbStateHalfOpen, now)
+	}
+
+	switch c.state {
+	case cbStateClosed:
+		return true, false
+	case cbStateHalfOpen:
+		if c.probesInFlight+c.probeSuccesses < c.halfOpenProbes {
+			c.probesInFlight++
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// record registers the outcome of a write against the child output.
+func (c *circuitBreakerOutput) record(probe, success bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	now := c.nowFn()
+	if probe {
+		if c.state != cbStateHalfOpen {
+			return
+		}
+		c.probesInFlight--
+		if !success {
+			c.setState(cbStateOpen, now)
+			return
+		}
+		if c.probeSuccesses++; c.probeSuccesses >= c.halfOpenProbes {
+			c.setState(cbStateClosed, now)
+		}
+		return
+	}
+
+	if c.state != cbStateClosed {
+		return
+	}
+	if now.Sub(c.windowStart) >= c.window {
+		c.successes, c.failures = 0, 0
+		c.windowStart = now
+	}
+	if success {
+		c.successes++
+		return
+	}
+	c.failures++
+
+	total := c.successes + c.failures
+	if total >= c.minRequests && float64(c.failures)/float64(total) >= c.failureRatio {
+		c.setState(cbStateOpen, now)
+	}
+}
+
+func (c *circuitBreakerOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (c *circuitBreakerOutput) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	allowed, probe := c.allow()
+	if !allowed {
+		c.mRejected.Incr(1)
+		if c.fallback != nil {
+			return c.fallback.WriteBatch(ctx, b)
+		}
+		return errCircuitOpen
+	}
+
+	err := c.out.WriteBatch(ctx, b)
+	if err != nil && ctx.Err() != nil {
+		// The write was abandoned rather than failed, so it's not counted.
+		if probe {
+			c.mut.Lock()
+			if c.state == cbStateHalfOpen && c.probesInFlight > 0 {
+				c.probesInFlight--
+			}
+			c.mut.Unlock()
+		}
+		return err
+	}
+	c.record(probe, err == nil)
+	return err
+}
+
+func (c *circuitBreakerOutput) handleState(w http.ResponseWriter, r *http.Request) {
+	c.mut.Lock()
+	if r.Method == http.MethodPost {
+		c.setState(cbStateClosed, c.nowFn())
+	}
+	resBytes, err := json.Marshal(map[string]any{
+		"state":     c.state.String(),
+		"successes": c.successes,
+		"failures":  c.failures,
+	})
+	c.mut.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resBytes)
+}
+
+func (c *circuitBreakerOutput) Close(ctx context.Context) error {
+	err := c.out.Close(ctx)
+	if c.fallback != nil {
+		if ferr := c.fallback.Close(ctx); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
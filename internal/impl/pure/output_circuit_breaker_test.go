@@ -0,0 +1,214 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type fnBatchWriter struct {
+	mut    sync.Mutex
+	fn     func() error
+	writes int
+}
+
+func (f *fnBatchWriter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.writes++
+	return f.fn()
+}
+
+func (f *fnBatchWriter) Close(ctx context.Context) error {
+	return nil
+}
+
+func (f *fnBatchWriter) setFn(fn func() error) {
+	f.mut.Lock()
+	f.fn = fn
+	f.mut.Unlock()
+}
+
+func (f *fnBatchWriter) writeCount() int {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.writes
+}
+
+func testCircuitBreaker(t testing.TB, confStr string) *circuitBreakerOutput {
+	t.Helper()
+
+	conf, err := circuitBreakerOutputSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	cb, err := circuitBreakerOutputFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+	return cb
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	cb := testCircuitBreaker(t, `
+output:
+  drop: {}
+failure_ratio: 0.5
+min_requests: 4
+window: 1m
+open_duration: 10s
+half_open_probes: 2
+`)
+
+	now := time.Unix(1000, 0)
+	cb.nowFn = func() time.Time { return now }
+	cb.windowStart = now
+
+	child := &fnBatchWriter{fn: func() error { return nil }}
+	cb.out = child
+
+	ctx := context.Background()
+	batch := service.MessageBatch{service.NewMessage([]byte("hello"))}
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, cb.WriteBatch(ctx, batch))
+	}
+
+	child.setFn(func() error { return errors.New("nope") })
+	for i := 0; i < 2; i++ {
+		require.EqualError(t, cb.WriteBatch(ctx, batch), "nope")
+	}
+	assert.Equal(t, cbStateOpen, cb.state)
+
+	// Whilst open the child is not called.
+	require.ErrorIs(t, cb.WriteBatch(ctx, batch), errCircuitOpen)
+	assert.Equal(t, 4, child.writeCount())
+
+	// After the open duration a probe is allowed, which fails.
+	now = now.Add(time.Second * 11)
+	require.EqualError(t, cb.WriteBatch(ctx, batch), "nope")
+	assert.Equal(t, cbStateOpen, cb.state)
+	assert.Equal(t, 5, child.writeCount())
+
+	// The next probes succeed and close the circuit.
+	child.setFn(func() error { return nil })
+	now = now.Add(time.Second * 11)
+	require.NoError(t, cb.WriteBatch(ctx, batch))
+	assert.Equal(t, cbStateHalfOpen, cb.state)
+	require.NoError(t, cb.WriteBatch(ctx, batch))
+	assert.Equal(t, cbStateClosed, cb.state)
+	assert.Equal(t, 7, child.writeCount())
+}
+
+func TestCircuitBreakerMinRequests(t *testing.T) {
+	cb := testCircuitBreaker(t, `
+output:
+  drop: {}
+min_requests: 5
+`)
+
+	child := &fnBatchWriter{fn: func() error { return errors.New("nope") }}
+	cb.out = child
+
+	ctx := context.Background()
+	batch := service.MessageBatch{service.NewMessage([]byte("hello"))}
+
+	for i := 0; i < 4; i++ {
+		require.EqualError(t, cb.WriteBatch(ctx, batch), "nope")
+		assert.Equal(t, cbStateClosed, cb.state)
+	}
+	require.EqualError(t, cb.WriteBatch(ctx, batch), "nope")
+	assert.Equal(t, cbStateOpen, cb.state)
+}
+
+func TestCircuitBreakerWindowReset(t *testing.T) {
+	cb := testCircuitBreaker(t, `
+output:
+  drop: {}
+min_requests: 2
+window: 10s
+`)
+
+	now := time.Unix(1000, 0)
+	cb.nowFn = func() time.Time { return now }
+	cb.windowStart = now
+
+	child := &fnBatchWriter{fn: func() error { return errors.New("nope") }}
+	cb.out = child
+
+	ctx := context.Background()
+	batch := service.MessageBatch{service.NewMessage([]byte("hello"))}
+
+	require.Error(t, cb.WriteBatch(ctx, batch))
+	now = now.Add(time.Second * 11)
+	require.Error(t, cb.WriteBatch(ctx, batch))
+	assert.Equal(t, cbStateClosed, cb.state)
+
+	require.Error(t, cb.WriteBatch(ctx, batch))
+	assert.Equal(t, cbStateOpen, cb.state)
+}
+
+func TestCircuitBreakerFallback(t *testing.T) {
+	cb := testCircuitBreaker(t, `
+output:
+  drop: {}
+fallback:
+  drop: {}
+min_requests: 1
+`)
+
+	child := &fnBatchWriter{fn: func() error { return errors.New("nope") }}
+	cb.out = child
+
+	fallback := &fnBatchWriter{fn: func() error { return nil }}
+	cb.fallback = fallback
+
+	ctx := context.Background()
+	batch := service.MessageBatch{service.NewMessage([]byte("hello"))}
+
+	require.Error(t, cb.WriteBatch(ctx, batch))
+	require.NoError(t, cb.WriteBatch(ctx, batch))
+	require.NoError(t, cb.WriteBatch(ctx, batch))
+
+	assert.Equal(t, 1, child.writeCount())
+	assert.Equal(t, 2, fallback.writeCount())
+}
+
+func TestCircuitBreakerHTTPState(t *testing.T) {
+	cb := testCircuitBreaker(t, `
+output:
+  drop: {}
+min_requests: 1
+`)
+
+	cb.out = &fnBatchWriter{fn: func() error { return errors.New("nope") }}
+	require.Error(t, cb.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("hello")),
+	}))
+
+	rec := httptest.NewRecorder()
+	cb.handleState(rec, httptest.NewRequest(http.MethodGet, "/circuit_breaker/foo", nil))
+	assert.JSONEq(t, `{"state":"open","successes":0,"failures":1}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	cb.handleState(rec, httptest.NewRequest(http.MethodPost, "/circuit_breaker/foo", nil))
+	assert.JSONEq(t, `{"state":"closed","successes":0,"failures":0}`, rec.Body.String())
+}
+
+func TestCircuitBreakerBadConfig(t *testing.T) {
+	conf, err := circuitBreakerOutputSpec().ParseYAML(`
+output:
+  drop: {}
+failure_ratio: 2
+`, nil)
+	require.NoError(t, err)
+
+	_, err = circuitBreakerOutputFromParsed(conf, service.MockResources())
+	require.Error(t, err)
+}
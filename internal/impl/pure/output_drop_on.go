@@ -11,6 +11,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/output/processors"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/events"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
@@ -25,7 +26,7 @@ func init() {
 		if err != nil {
 			return nil, err
 		}
-		return newDropOnWriter(c.DropOn.DropOnConditions, wrapped, nm.Logger())
+		return newDropOnWriter(c.DropOn.DropOnConditions, wrapped, nm.Logger(), events.FromManager(nm))
 	}), docs.ComponentSpec{
 		Name:        "drop_on",
 		Summary:     `Attempts to write messages to a child output and if the write fails for one of a list of configurable reasons the message is dropped instead of being reattempted.`,
@@ -80,7 +81,8 @@ output:
 //------------------------------------------------------------------------------
 
 type dropOnWriter struct {
-	log log.Modular
+	log    log.Modular
+	events events.Emitter
 
 	onError        bool
 	onBackpressure time.Duration
@@ -92,7 +94,7 @@ type dropOnWriter struct {
 	shutSig *shutdown.Signaller
 }
 
-func newDropOnWriter(conf output.DropOnConditions, wrapped output.Streamed, log log.Modular) (*dropOnWriter, error) {
+func newDropOnWriter(conf output.DropOnConditions, wrapped output.Streamed, log log.Modular, evts events.Emitter) (*dropOnWriter, error) {
 	var backPressure time.Duration
 	if len(conf.BackPressure) > 0 {
 		var err error
@@ -103,6 +105,7 @@ func newDropOnWriter(conf output.DropOnConditions, wrapped output.Streamed, log
 
 	return &dropOnWriter{
 		log:             log,
+		events:          evts,
 		wrapped:         wrapped,
 		transactionsOut: make(chan message.Transaction),
 
@@ -178,6 +181,7 @@ func (d *dropOnWriter) loop() {
 				}
 				if gotBackPressure {
 					d.log.Warnln("Message dropped due to back pressure.")
+					d.events.Emit(events.New(events.TypeBatchDropped, "Message dropped due to back pressure").WithField("size", ts.Payload.Len()))
 					if d.onError {
 						res = nil
 					} else {
@@ -205,6 +209,7 @@ func (d *dropOnWriter) loop() {
 
 		if res != nil && d.onError {
 			d.log.Warnf("Message dropped due to: %v\n", res)
+			d.events.Emit(events.New(events.TypeBatchDropped, "Message dropped due to an error").WithField("size", ts.Payload.Len()).WithField("error", res.Error()))
 			res = nil
 		}
 
@@ -3,17 +3,21 @@ package pure
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/output/processors"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/events"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
@@ -118,7 +122,35 @@ we want to avoid reapplying to the same message more than once in the pipeline.
 
 Rather than retrying the same output you may wish to retry the send using a
 different output target (a dead letter queue). In which case you should instead
-use the ` + "[`fallback`](/docs/components/outputs/fallback)" + ` output type.`,
+use the ` + "[`fallback`](/docs/components/outputs/fallback)" + ` output type.
+
+### Retry Policies
+
+Different classes of errors often warrant different retry behaviour, a rate
+limit response might deserve a long and patient back off whereas a timeout
+should be retried quickly and only a handful of times. The field ` + "`policies`" + `
+allows you to specify a list of retry policies, each with a
+[Bloblang query](/docs/guides/bloblang/about/) ` + "`check`" + ` that is executed
+against a failed message in order to determine whether the policy applies.
+
+During the check the error returned by the child output can be accessed with the
+` + "[`error()`](/docs/guides/bloblang/functions#error)" + ` function, and the
+metadata of the message (which some outputs populate with response details) is
+also available. The first policy that passes is used to determine the maximum
+retries and back off for the attempt, and when no policy matches the top level
+` + "`max_retries` and `backoff`" + ` fields are used instead. Each policy tracks its
+own attempt count and back off state per message.
+
+### Retry Budget
+
+When a downstream service is hard-down retrying every failed message
+indefinitely can amplify the load placed on it once it begins to recover. A
+retry budget can be configured with ` + "`budget.ratio`" + `, which limits the number
+of retries attempted within a sliding window to a ratio of the messages sent
+within it, plus a minimum number of retries per second that are always allowed.
+Once the budget is exhausted retries are abandoned and the message is rejected
+upstream, where it is dealt with according to the input (usually by being
+nacked and redelivered later).`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldInt("max_retries", "The maximum number of retries before giving up on the request. If set to zero there is no discrete limit.").HasDefault(0).Advanced(),
 			docs.FieldObject("backoff", "Control time intervals between retry attempts.").WithChildren(
@@ -126,6 +158,25 @@ use the ` + "[`fallback`](/docs/components/outputs/fallback)" + ` output type.`,
 				docs.FieldString("max_interval", "The maximum period to wait between retry attempts.").HasDefault("3s"),
 				docs.FieldString("max_elapsed_time", "The maximum period to wait before retry attempts are abandoned. If zero then no limit is used.").HasDefault("0s"),
 			).Advanced(),
+			docs.FieldObject("policies", "A list of retry policies that apply to specific classes of errors. The first policy where the `check` passes for a failed message determines the max retries and back off used for that attempt.").Array().WithChildren(
+				docs.FieldBloblang(
+					"check",
+					"A [Bloblang query](/docs/guides/bloblang/about/) that should return a boolean value indicating whether the policy applies to a failed message. The error returned by the child output can be accessed with the `error()` function.",
+					`error().contains("429")`,
+					`@http_status_code.number().catch(0) >= 500`,
+				).HasDefault(""),
+				docs.FieldInt("max_retries", "The maximum number of retries before giving up on the request. If set to zero there is no discrete limit.").HasDefault(0),
+				docs.FieldObject("backoff", "Control time intervals between retry attempts.").WithChildren(
+					docs.FieldString("initial_interval", "The initial period to wait between retry attempts.").HasDefault("500ms"),
+					docs.FieldString("max_interval", "The maximum period to wait between retry attempts.").HasDefault("3s"),
+					docs.FieldString("max_elapsed_time", "The maximum period to wait before retry attempts are abandoned. If zero then no limit is used.").HasDefault("0s"),
+				),
+			).HasDefault([]any{}).Advanced(),
+			docs.FieldObject("budget", "A retry budget that limits the overall rate of retries relative to the rate of messages being sent, shedding load from a downstream that is failing.").WithChildren(
+				docs.FieldFloat("ratio", "The ratio of retries to original sends permitted within the window, e.g. `0.2` allows one retry for every five messages sent. When set to zero the budget is disabled.").HasDefault(0),
+				docs.FieldFloat("min_retries_per_second", "A minimum number of retries per second that are permitted regardless of the ratio, ensuring low throughput streams are still able to retry.").HasDefault(10),
+				docs.FieldString("window", "The sliding window of time over which sends and retries are counted.").HasDefault("10s"),
+			).Advanced(),
 			docs.FieldOutput("output", "A child output."),
 		),
 		Categories: []string{
@@ -161,7 +212,121 @@ func retryOutputFromConfig(conf output.RetryConfig, mgr bundle.NewManagement) (o
 		return nil, err
 	}
 
-	return newIndefiniteRetry(mgr, boffCtor, wrapped)
+	r, err := newIndefiniteRetry(mgr, boffCtor, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, pConf := range conf.Policies {
+		var p retryPolicy
+		if pConf.Check != "" {
+			if p.check, err = mgr.BloblEnvironment().NewMapping(pConf.Check); err != nil {
+				return nil, fmt.Errorf("failed to parse policy '%v' check mapping: %v", i, err)
+			}
+		}
+		if p.backoffCtor, err = pConf.GetCtor(); err != nil {
+			return nil, fmt.Errorf("policy '%v': %w", i, err)
+		}
+		r.policies = append(r.policies, p)
+	}
+
+	if conf.Budget.Ratio > 0 {
+		window, err := time.ParseDuration(conf.Budget.Window)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse budget window: %v", err)
+		}
+		if window <= 0 {
+			return nil, errors.New("budget window must be greater than zero")
+		}
+		r.budget = newRetryBudget(conf.Budget.Ratio, conf.Budget.MinRetriesPerSecond, window)
+	}
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+
+type retryPolicy struct {
+	check       *mapping.Executor
+	backoffCtor func() backoff.BackOff
+}
+
+// retryBudgetBuckets is the number of buckets a budget window is divided into.
+const retryBudgetBuckets = 10
+
+// retryBudget tracks the number of sends and retries within a sliding window
+// and decides whether further retries are permitted.
+type retryBudget struct {
+	ratio     float64
+	minPerSec float64
+	window    time.Duration
+	bucketDur time.Duration
+
+	mut        sync.Mutex
+	sends      [retryBudgetBuckets]float64
+	retries    [retryBudgetBuckets]float64
+	lastBucket int64
+
+	nowFn func() time.Time
+}
+
+func newRetryBudget(ratio, minPerSec float64, window time.Duration) *retryBudget {
+	bucketDur := window / retryBudgetBuckets
+	if bucketDur <= 0 {
+		bucketDur = 1
+	}
+	return &retryBudget{
+		ratio:     ratio,
+		minPerSec: minPerSec,
+		window:    window,
+		bucketDur: bucketDur,
+		nowFn:     time.Now,
+	}
+}
+
+// rotate clears any buckets that have fallen out of the window and returns the
+// index of the current bucket. Must be called with the mutex held.
+func (b *retryBudget) rotate() int {
+	current := b.nowFn().UnixNano() / int64(b.bucketDur)
+	if diff := current - b.lastBucket; diff > 0 {
+		if diff > retryBudgetBuckets {
+			diff = retryBudgetBuckets
+		}
+		for i := int64(1); i <= diff; i++ {
+			idx := (b.lastBucket + i) % retryBudgetBuckets
+			b.sends[idx] = 0
+			b.retries[idx] = 0
+		}
+		b.lastBucket = current
+	}
+	return int(current % retryBudgetBuckets)
+}
+
+// recordSend registers an original (non-retry) send attempt.
+func (b *retryBudget) recordSend() {
+	b.mut.Lock()
+	b.sends[b.rotate()]++
+	b.mut.Unlock()
+}
+
+// tryRetry returns true and registers a retry if the budget permits it.
+func (b *retryBudget) tryRetry() bool {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	idx := b.rotate()
+
+	var sends, retries float64
+	for i := 0; i < retryBudgetBuckets; i++ {
+		sends += b.sends[i]
+		retries += b.retries[i]
+	}
+
+	allowed := sends*b.ratio + b.minPerSec*b.window.Seconds()
+	if retries >= allowed {
+		return false
+	}
+	b.retries[idx]++
+	return true
 }
 
 func newIndefiniteRetry(mgr bundle.NewManagement, backoffCtor func() backoff.BackOff, wrapped output.Streamed) (*indefiniteRetry, error) {
@@ -175,6 +340,8 @@ func newIndefiniteRetry(mgr bundle.NewManagement, backoffCtor func() backoff.Bac
 
 	return &indefiniteRetry{
 		log:             mgr.Logger(),
+		events:          events.FromManager(mgr),
+		mBudgetExceeded: mgr.Metrics().GetCounter("output_retry_budget_exhausted"),
 		wrapped:         wrapped,
 		backoffCtor:     backoffCtor,
 		transactionsOut: make(chan message.Transaction),
@@ -182,13 +349,48 @@ func newIndefiniteRetry(mgr bundle.NewManagement, backoffCtor func() backoff.Bac
 	}, nil
 }
 
+// policyFor returns the index of the first policy that applies to a batch that
+// failed with the given error, or -1 if none apply.
+func (r *indefiniteRetry) policyFor(batch message.Batch, res error) int {
+	if len(r.policies) == 0 {
+		return -1
+	}
+
+	errBatch := batch.ShallowCopy()
+	_ = errBatch.Iter(func(i int, p *message.Part) error {
+		p.ErrorSet(res)
+		return nil
+	})
+
+	for i, p := range r.policies {
+		if p.check == nil {
+			return i
+		}
+		for j := range errBatch {
+			test, err := p.check.QueryPart(j, errBatch)
+			if err != nil {
+				r.log.Errorf("Failed to test retry policy %v: %v\n", i, err)
+				continue
+			}
+			if test {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 // indefiniteRetry is an output type that continuously writes a message to a
 // child output until the send is successful.
 type indefiniteRetry struct {
 	wrapped     output.Streamed
 	backoffCtor func() backoff.BackOff
+	policies    []retryPolicy
+	budget      *retryBudget
 
-	log log.Modular
+	log             log.Modular
+	events          events.Emitter
+	mBudgetExceeded metrics.StatCounter
 
 	transactionsIn  <-chan message.Transaction
 	transactionsOut chan message.Transaction
@@ -237,6 +439,10 @@ func (r *indefiniteRetry) loop() {
 			return
 		}
 
+		if r.budget != nil {
+			r.budget.recordSend()
+		}
+
 		rChan := make(chan error)
 		select {
 		case r.transactionsOut <- message.NewTransaction(tran.Payload.ShallowCopy(), rChan):
@@ -246,7 +452,7 @@ func (r *indefiniteRetry) loop() {
 
 		wg.Add(1)
 		go func(ts message.Transaction, resChan chan error) {
-			var backOff backoff.BackOff
+			backOffs := map[int]backoff.BackOff{}
 			var resOut error
 			var inErrLoop bool
 
@@ -278,18 +484,32 @@ func (r *indefiniteRetry) loop() {
 						atomic.AddInt64(&errLooped, 1)
 					}
 
-					if backOff == nil {
-						backOff = r.backoffCtor()
+					pIndex := r.policyFor(ts.Payload, res)
+					backOff, exists := backOffs[pIndex]
+					if !exists {
+						if pIndex >= 0 {
+							backOff = r.policies[pIndex].backoffCtor()
+						} else {
+							backOff = r.backoffCtor()
+						}
+						backOffs[pIndex] = backOff
 					}
 
 					nextBackoff := backOff.NextBackOff()
 					if nextBackoff == backoff.Stop {
 						r.log.Errorf("Failed to send message: %v\n", res)
+						r.events.Emit(events.New(events.TypeRetriesExhausted, "Maximum number of retries has been met").WithField("error", res.Error()))
 						resOut = errors.New("message failed to reach a target destination")
 						break
-					} else {
-						r.log.Warnf("Failed to send message: %v\n", res)
 					}
+					if r.budget != nil && !r.budget.tryRetry() {
+						r.mBudgetExceeded.Incr(1)
+						r.log.Errorf("Failed to send message and retry budget is exhausted: %v\n", res)
+						r.events.Emit(events.New(events.TypeRetriesExhausted, "Retry budget is exhausted").WithField("error", res.Error()))
+						resOut = errors.New("message failed to reach a target destination and the retry budget is exhausted")
+						break
+					}
+					r.log.Warnf("Failed to send message: %v\n", res)
 					select {
 					case <-time.After(nextBackoff):
 					case <-r.shutSig.CloseNowChan():
@@ -401,3 +401,161 @@ func TestRetryMutations(t *testing.T) {
 		"moo":   "quack",
 	}, inStruct)
 }
+
+func TestRetryPolicies(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	conf := output.NewConfig()
+	conf.Type = "retry"
+
+	childConf := output.NewConfig()
+	conf.Retry.Output = &childConf
+	conf.Retry.Backoff.InitialInterval = "10us"
+	conf.Retry.Backoff.MaxInterval = "10us"
+
+	pConf := output.NewRetryPolicyConfig()
+	pConf.Check = `error().contains("bad request")`
+	pConf.MaxRetries = 2
+	pConf.Backoff.InitialInterval = "10us"
+	pConf.Backoff.MaxInterval = "10us"
+	conf.Retry.Policies = append(conf.Retry.Policies, pConf)
+
+	output, err := bundle.AllOutputs.Init(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	ret, ok := output.(*indefiniteRetry)
+	require.True(t, ok)
+
+	mOut := &mock.OutputChanneled{}
+	ret.wrapped = mOut
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, ret.Consume(tChan))
+
+	resChan := make(chan error)
+
+	// Errors that do not match the policy are retried indefinitely.
+	sendForRetry("first", tChan, resChan, t)
+	for i := 0; i < 5; i++ {
+		expectFromRetry(component.ErrFailedSend, mOut.TChan, t, "first")
+	}
+	expectFromRetry(nil, mOut.TChan, t, "first")
+	ackForRetry(nil, resChan, t)
+
+	// Errors that match the policy are limited to its max retries.
+	sendForRetry("second", tChan, resChan, t)
+	for i := 0; i < 3; i++ {
+		expectFromRetry(errors.New("bad request"), mOut.TChan, t, "second")
+	}
+
+	select {
+	case res := <-resChan:
+		require.Error(t, res)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	output.TriggerCloseNow()
+	require.NoError(t, output.WaitForClose(ctx))
+}
+
+func TestRetryPolicyBadCheck(t *testing.T) {
+	conf := output.NewConfig()
+	conf.Type = "retry"
+
+	childConf := output.NewConfig()
+	conf.Retry.Output = &childConf
+
+	pConf := output.NewRetryPolicyConfig()
+	pConf.Check = `error(`
+	conf.Retry.Policies = append(conf.Retry.Policies, pConf)
+
+	_, err := bundle.AllOutputs.Init(conf, mock.NewManager())
+	require.Error(t, err)
+}
+
+func TestRetryBudget(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	b := newRetryBudget(0.5, 0, time.Second*10)
+	b.nowFn = func() time.Time {
+		return now
+	}
+
+	assert.False(t, b.tryRetry())
+
+	for i := 0; i < 4; i++ {
+		b.recordSend()
+	}
+
+	assert.True(t, b.tryRetry())
+	assert.True(t, b.tryRetry())
+	assert.False(t, b.tryRetry())
+
+	// Once the window has passed the earlier sends and retries are forgotten.
+	now = now.Add(time.Second * 11)
+	assert.False(t, b.tryRetry())
+
+	b.recordSend()
+	b.recordSend()
+	assert.True(t, b.tryRetry())
+	assert.False(t, b.tryRetry())
+}
+
+func TestRetryBudgetMinPerSecond(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	b := newRetryBudget(0.1, 1, time.Second*2)
+	b.nowFn = func() time.Time {
+		return now
+	}
+
+	assert.True(t, b.tryRetry())
+	assert.True(t, b.tryRetry())
+	assert.False(t, b.tryRetry())
+}
+
+func TestRetryBudgetExhausted(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	conf := output.NewConfig()
+	conf.Type = "retry"
+
+	childConf := output.NewConfig()
+	conf.Retry.Output = &childConf
+	conf.Retry.Backoff.InitialInterval = "10us"
+	conf.Retry.Backoff.MaxInterval = "10us"
+	conf.Retry.Budget.Ratio = 1
+	conf.Retry.Budget.MinRetriesPerSecond = 0
+	conf.Retry.Budget.Window = "1h"
+
+	output, err := bundle.AllOutputs.Init(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	ret, ok := output.(*indefiniteRetry)
+	require.True(t, ok)
+
+	mOut := &mock.OutputChanneled{}
+	ret.wrapped = mOut
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, ret.Consume(tChan))
+
+	resChan := make(chan error)
+
+	sendForRetry("first", tChan, resChan, t)
+	expectFromRetry(component.ErrFailedSend, mOut.TChan, t, "first")
+	expectFromRetry(component.ErrFailedSend, mOut.TChan, t, "first")
+
+	select {
+	case res := <-resChan:
+		require.Error(t, res)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	output.TriggerCloseNow()
+	require.NoError(t, output.WaitForClose(ctx))
+}
@@ -0,0 +1,228 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func testRouterWriter(t *testing.T, mgr *mock.Manager, confStr string) *routerWriter {
+	t.Helper()
+
+	conf, err := routerOutputSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	r, err := newRouterWriterFromParsed(conf, mgr)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, r.Close(context.Background()))
+	})
+	return r
+}
+
+func routerTestBatch(targets ...string) message.Batch {
+	b := make(message.Batch, len(targets))
+	for i, target := range targets {
+		b[i] = message.NewPart([]byte(target + "-" + string(rune('a'+i))))
+		b[i].MetaSetMut("route", target)
+	}
+	return b
+}
+
+func TestRouterResources(t *testing.T) {
+	var mut sync.Mutex
+	received := map[string][]string{}
+
+	mgr := mock.NewManager()
+	for _, name := range []string{"foo", "bar"} {
+		name := name
+		mgr.Outputs[name] = func(ctx context.Context, tran message.Transaction) error {
+			mut.Lock()
+			for _, p := range tran.Payload {
+				received[name] = append(received[name], string(p.AsBytes()))
+			}
+			mut.Unlock()
+			return tran.Ack(ctx, nil)
+		}
+	}
+
+	r := testRouterWriter(t, mgr, `{}`)
+
+	require.NoError(t, r.WriteBatch(context.Background(), routerTestBatch("foo", "bar", "foo")))
+	require.NoError(t, r.WriteBatch(context.Background(), routerTestBatch("bar")))
+
+	assert.Equal(t, map[string][]string{
+		"foo": {"foo-a", "foo-c"},
+		"bar": {"bar-b", "bar-a"},
+	}, received)
+
+	err := r.WriteBatch(context.Background(), routerTestBatch("baz"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "output resource 'baz' was not found")
+}
+
+func TestRouterPartialFailure(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Outputs["foo"] = func(ctx context.Context, tran message.Transaction) error {
+		return tran.Ack(ctx, nil)
+	}
+	mgr.Outputs["bar"] = func(ctx context.Context, tran message.Transaction) error {
+		return tran.Ack(ctx, errors.New("nope"))
+	}
+
+	r := testRouterWriter(t, mgr, `{}`)
+
+	b := routerTestBatch("foo", "bar", "foo", "bar")
+	err := r.WriteBatch(context.Background(), b)
+
+	var bErr *batch.Error
+	require.ErrorAs(t, err, &bErr)
+
+	var failed []int
+	bErr.WalkPartsNaively(func(i int, _ *message.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1, 3}, failed)
+}
+
+func TestRouterTemplate(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["cache_a"] = map[string]mock.CacheItem{}
+	mgr.Caches["cache_b"] = map[string]mock.CacheItem{}
+	mgr.Outputs["resource_output"] = func(ctx context.Context, tran message.Transaction) error {
+		return tran.Ack(ctx, nil)
+	}
+
+	r := testRouterWriter(t, mgr, `
+template: |
+  root.cache.target = "cache_" + this.target
+  root.cache.key = "${! content() }"
+max_targets: 2
+`)
+
+	require.NoError(t, r.WriteBatch(context.Background(), routerTestBatch("a", "b", "a")))
+	require.NoError(t, r.WriteBatch(context.Background(), routerTestBatch("resource_output")))
+
+	assert.Contains(t, mgr.Caches["cache_a"], "a-a")
+	assert.Contains(t, mgr.Caches["cache_a"], "a-c")
+	assert.Contains(t, mgr.Caches["cache_b"], "b-b")
+	assert.Len(t, r.targets, 2)
+}
+
+func TestRouterTemplateEviction(t *testing.T) {
+	mgr := mock.NewManager()
+	for _, name := range []string{"a", "b", "c"} {
+		mgr.Caches["cache_"+name] = map[string]mock.CacheItem{}
+	}
+
+	r := testRouterWriter(t, mgr, `
+template: |
+  root.cache.target = "cache_" + this.target
+  root.cache.key = "${! content() }"
+max_targets: 2
+idle_timeout: 1h
+`)
+
+	now := time.Unix(0, 0)
+	setNow := func(t time.Time) {
+		r.mut.Lock()
+		now = t
+		r.mut.Unlock()
+	}
+	r.mut.Lock()
+	r.nowFn = func() time.Time { return now }
+	r.mut.Unlock()
+
+	activeTargets := func() []string {
+		r.mut.Lock()
+		defer r.mut.Unlock()
+		var names []string
+		for name := range r.targets {
+			names = append(names, name)
+		}
+		return names
+	}
+
+	require.NoError(t, r.WriteBatch(context.Background(), routerTestBatch("a")))
+	setNow(now.Add(time.Minute))
+	require.NoError(t, r.WriteBatch(context.Background(), routerTestBatch("b")))
+	setNow(now.Add(time.Minute))
+
+	// Target a is the least recently used and is closed to make room for c.
+	require.NoError(t, r.WriteBatch(context.Background(), routerTestBatch("c")))
+	assert.ElementsMatch(t, []string{"b", "c"}, activeTargets())
+
+	// Target b has been idle for longer than the timeout but c has not.
+	setNow(now.Add(time.Hour - time.Second))
+	r.evictIdle()
+	assert.ElementsMatch(t, []string{"c"}, activeTargets())
+
+	setNow(now.Add(time.Second))
+	r.evictIdle()
+	assert.Empty(t, activeTargets())
+
+	// Closed targets are created again when needed.
+	require.NoError(t, r.WriteBatch(context.Background(), routerTestBatch("a")))
+	assert.ElementsMatch(t, []string{"a"}, activeTargets())
+	assert.Contains(t, mgr.Caches["cache_a"], "a-a")
+	assert.Contains(t, mgr.Caches["cache_c"], "c-a")
+}
+
+func TestRouterTemplateBusyLimit(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["cache_a"] = map[string]mock.CacheItem{}
+
+	r := testRouterWriter(t, mgr, `
+template: |
+  root.cache.target = "cache_" + this.target
+  root.cache.key = "${! content() }"
+max_targets: 1
+`)
+
+	require.NoError(t, r.WriteBatch(context.Background(), routerTestBatch("a")))
+
+	// Simulate a write in flight to target a.
+	r.mut.Lock()
+	r.targets["a"].inFlight++
+	r.mut.Unlock()
+
+	err := r.WriteBatch(context.Background(), routerTestBatch("b"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "limit of 1 active targets")
+
+	r.mut.Lock()
+	r.targets["a"].inFlight--
+	r.mut.Unlock()
+}
+
+func TestRouterTemplateErrors(t *testing.T) {
+	r := testRouterWriter(t, mock.NewManager(), `
+template: |
+  root = if this.target == "bad" { deleted() } else { {"nope": {}} }
+`)
+
+	err := r.WriteBatch(context.Background(), routerTestBatch("bad"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deleted the output config")
+
+	err = r.WriteBatch(context.Background(), routerTestBatch("foo"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a valid output config")
+
+	b := message.QuickBatch([][]byte{[]byte("no route")})
+	err = r.WriteBatch(context.Background(), b)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "empty target")
+}
@@ -0,0 +1,265 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	brFieldKey               = "key"
+	brFieldKeyType           = "key_type"
+	brFieldDescending        = "descending"
+	brFieldMaxOutOfOrderness = "max_out_of_orderness"
+	brFieldLateMessages      = "late_messages"
+)
+
+func batchReorderProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.20.0").
+		Summary("Sorts the messages of a batch by a key obtained from each message, such as an event timestamp, and optionally detects messages that arrive later than a bounded out-of-orderness allows.").
+		Description(`
+Messages are sorted in ascending order of their `+"`key`"+` unless `+"`descending`"+` is set, and messages with equal keys retain their relative order. This processor only reorders messages within a batch, and therefore in order to sort messages that arrive within a window of time use a `+"[batching policy](/docs/configuration/batching)"+` with a `+"`period`"+` or a `+"[`system_window` buffer](/docs/components/buffers/system_window)"+` before it.
+
+If the key of a message cannot be obtained or parsed then the message is placed at the end of the batch and flagged with an error, which can be handled using the methods outlined [here](/docs/configuration/error_handling).
+
+### Out-of-Orderness
+
+When the `+"`key_type`"+` is `+"`timestamp`"+` and a `+"`max_out_of_orderness`"+` is configured the processor tracks a watermark across batches, which is the latest timestamp observed minus the `+"`max_out_of_orderness`"+`. Messages of a batch with a timestamp older than the watermark reached by prior batches have arrived too late to be sorted with their peers, and are handled according to `+"`late_messages`"+`:
+
+- `+"`keep`"+`: Late messages are sorted into the batch as normal.
+- `+"`drop`"+`: Late messages are removed from the batch.
+- `+"`error`"+`: Late messages are sorted into the batch and flagged with an error.
+
+### Metrics
+
+The counter `+"`batch_reorder_late`"+` is incremented for each late message, and the counter `+"`batch_reorder_key_failed`"+` for each message where the key could not be obtained.`).
+		Fields(
+			service.NewInterpolatedStringField(brFieldKey).
+				Description("The key to sort messages by.").
+				Example(`${! this.timestamp }`).
+				Example(`${! meta("kafka_offset") }`),
+			service.NewStringAnnotatedEnumField(brFieldKeyType, map[string]string{
+				"string":    "Keys are compared lexicographically.",
+				"number":    "Keys are parsed as numbers and compared numerically.",
+				"timestamp": "Keys are parsed as RFC 3339 timestamps and compared chronologically.",
+			}).
+				Description("The type of the key, which determines how keys are compared.").
+				Default("string"),
+			service.NewBoolField(brFieldDescending).
+				Description("Whether to sort messages in descending order of their keys.").
+				Default(false),
+			service.NewDurationField(brFieldMaxOutOfOrderness).
+				Description("The maximum period of time that a message can lag behind the latest timestamp observed before it is considered late. Only applies when the `key_type` is `timestamp`, and when omitted late messages are not detected.").
+				Example("10s").
+				Optional(),
+			service.NewStringEnumField(brFieldLateMessages, "keep", "drop", "error").
+				Description("What to do with messages that are considered late.").
+				Default("keep"),
+		).
+		Example(
+			"Sort Events Within a Window",
+			"In this example events are consumed from a source that provides no ordering guarantees, collected into batches over ten seconds, and sorted by their event timestamp before being written to a sink that expects them in order. Events that lag more than a minute behind are dropped.",
+			`
+input:
+  nats_jetstream:
+    urls: [ nats://localhost:4222 ]
+    subject: events
+    durable: benthos
+  processors:
+    - mapping: 'meta event_time = this.occurred_at'
+
+output:
+  http_client:
+    url: http://localhost:8080/events
+    verb: POST
+    batching:
+      period: 10s
+      processors:
+        - batch_reorder:
+            key: ${! meta("event_time") }
+            key_type: timestamp
+            max_out_of_orderness: 1m
+            late_messages: drop
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"batch_reorder", batchReorderProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newBatchReorderFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type batchReorderProc struct {
+	key               *service.InterpolatedString
+	keyType           string
+	descending        bool
+	detectLate        bool
+	maxOutOfOrderness time.Duration
+	lateMessages      string
+
+	mLate      *service.MetricCounter
+	mKeyFailed *service.MetricCounter
+
+	mut       sync.Mutex
+	maxSeen   time.Time
+	watermark time.Time
+}
+
+func newBatchReorderFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*batchReorderProc, error) {
+	p := &batchReorderProc{
+		mLate:      mgr.Metrics().NewCounter("batch_reorder_late"),
+		mKeyFailed: mgr.Metrics().NewCounter("batch_reorder_key_failed"),
+	}
+
+	var err error
+	if p.key, err = conf.FieldInterpolatedString(brFieldKey); err != nil {
+		return nil, err
+	}
+	if p.keyType, err = conf.FieldString(brFieldKeyType); err != nil {
+		return nil, err
+	}
+	if p.descending, err = conf.FieldBool(brFieldDescending); err != nil {
+		return nil, err
+	}
+	if p.lateMessages, err = conf.FieldString(brFieldLateMessages); err != nil {
+		return nil, err
+	}
+	if conf.Contains(brFieldMaxOutOfOrderness) {
+		if p.keyType != "timestamp" {
+			return nil, fmt.Errorf("field %v can only be used with a %v of timestamp", brFieldMaxOutOfOrderness, brFieldKeyType)
+		}
+		if p.maxOutOfOrderness, err = conf.FieldDuration(brFieldMaxOutOfOrderness); err != nil {
+			return nil, err
+		}
+		if p.maxOutOfOrderness < 0 {
+			return nil, fmt.Errorf("field %v must not be negative", brFieldMaxOutOfOrderness)
+		}
+		p.detectLate = true
+	}
+	return p, nil
+}
+
+var errBatchReorderLate = errors.New("message arrived later than the max out-of-orderness allows")
+
+type batchReorderKey struct {
+	str string
+	num float64
+	ts  time.Time
+	err error
+}
+
+func (p *batchReorderProc) parseKey(msg *service.Message) (k batchReorderKey) {
+	if k.str, k.err = p.key.TryString(msg); k.err != nil {
+		k.err = fmt.Errorf("key interpolation error: %w", k.err)
+		return
+	}
+	switch p.keyType {
+	case "number":
+		if k.num, k.err = strconv.ParseFloat(k.str, 64); k.err != nil {
+			k.err = fmt.Errorf("failed to parse key as number: %w", k.err)
+		}
+	case "timestamp":
+		if k.ts, k.err = time.Parse(time.RFC3339Nano, k.str); k.err != nil {
+			k.err = fmt.Errorf("failed to parse key as timestamp: %w", k.err)
+		}
+	}
+	return
+}
+
+func (p *batchReorderProc) less(a, b batchReorderKey) bool {
+	switch p.keyType {
+	case "number":
+		return a.num < b.num
+	case "timestamp":
+		return a.ts.Before(b.ts)
+	}
+	return a.str < b.str
+}
+
+func (p *batchReorderProc) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	type keyedMessage struct {
+		msg  *service.Message
+		key  batchReorderKey
+		late bool
+	}
+
+	p.mut.Lock()
+	watermark := p.watermark
+	msgs := make([]keyedMessage, 0, len(batch))
+	for _, msg := range batch {
+		km := keyedMessage{msg: msg, key: p.parseKey(msg)}
+		if km.key.err != nil {
+			p.mKeyFailed.Incr(1)
+			msgs = append(msgs, km)
+			continue
+		}
+		if p.detectLate {
+			if !watermark.IsZero() && km.key.ts.Before(watermark) {
+				km.late = true
+				p.mLate.Incr(1)
+			}
+			if km.key.ts.After(p.maxSeen) {
+				p.maxSeen = km.key.ts
+			}
+		}
+		if km.late && p.lateMessages == "drop" {
+			continue
+		}
+		msgs = append(msgs, km)
+	}
+	if p.detectLate && !p.maxSeen.IsZero() {
+		p.watermark = p.maxSeen.Add(-p.maxOutOfOrderness)
+	}
+	p.mut.Unlock()
+
+	sort.SliceStable(msgs, func(i, j int) bool {
+		a, b := msgs[i].key, msgs[j].key
+		if (a.err == nil) != (b.err == nil) {
+			return a.err == nil
+		}
+		if a.err != nil {
+			return false
+		}
+		if p.descending {
+			return p.less(b, a)
+		}
+		return p.less(a, b)
+	})
+
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+
+	sorted := make(service.MessageBatch, 0, len(msgs))
+	for _, km := range msgs {
+		switch {
+		case km.key.err != nil:
+			km.msg.SetError(km.key.err)
+		case km.late && p.lateMessages == "error":
+			km.msg.SetError(errBatchReorderLate)
+		}
+		sorted = append(sorted, km.msg)
+	}
+	return []service.MessageBatch{sorted}, nil
+}
+
+func (p *batchReorderProc) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,193 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testBatchReorderProc(t *testing.T, confStr string) *batchReorderProc {
+	t.Helper()
+
+	conf, err := batchReorderProcConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newBatchReorderFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+	return proc
+}
+
+func batchReorderContents(t *testing.T, batches []service.MessageBatch) (contents, errs []string) {
+	t.Helper()
+
+	require.Len(t, batches, 1)
+	for _, msg := range batches[0] {
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		contents = append(contents, string(b))
+		if err := msg.GetError(); err != nil {
+			errs = append(errs, string(b))
+		}
+	}
+	return
+}
+
+func batchReorderBatch(contents ...string) service.MessageBatch {
+	batch := make(service.MessageBatch, len(contents))
+	for i, c := range contents {
+		batch[i] = service.NewMessage([]byte(c))
+	}
+	return batch
+}
+
+func TestBatchReorderKeyTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   string
+		input  []string
+		output []string
+		errs   []string
+	}{
+		{
+			name:   "string",
+			conf:   `key: ${! this.k }`,
+			input:  []string{`{"k":"b"}`, `{"k":"c"}`, `{"k":"a"}`, `{"k":"10"}`},
+			output: []string{`{"k":"10"}`, `{"k":"a"}`, `{"k":"b"}`, `{"k":"c"}`},
+		},
+		{
+			name: "number stable",
+			conf: `
+key: ${! this.k }
+key_type: number
+`,
+			input:  []string{`{"k":10,"i":0}`, `{"k":2,"i":1}`, `{"k":10,"i":2}`, `{"k":-1.5,"i":3}`},
+			output: []string{`{"k":-1.5,"i":3}`, `{"k":2,"i":1}`, `{"k":10,"i":0}`, `{"k":10,"i":2}`},
+		},
+		{
+			name: "timestamp descending",
+			conf: `
+key: ${! this.k }
+key_type: timestamp
+descending: true
+`,
+			input: []string{
+				`{"k":"2023-01-01T00:00:01Z"}`,
+				`{"k":"2023-01-01T01:00:00+01:00"}`,
+				`{"k":"2023-01-01T00:00:00.5Z"}`,
+			},
+			output: []string{
+				`{"k":"2023-01-01T00:00:01Z"}`,
+				`{"k":"2023-01-01T00:00:00.5Z"}`,
+				`{"k":"2023-01-01T01:00:00+01:00"}`,
+			},
+		},
+		{
+			name: "failed keys last",
+			conf: `
+key: ${! this.k }
+key_type: number
+`,
+			input:  []string{`{"k":"nope"}`, `{"k":3}`, `{}`, `{"k":1}`},
+			output: []string{`{"k":1}`, `{"k":3}`, `{"k":"nope"}`, `{}`},
+			errs:   []string{`{"k":"nope"}`, `{}`},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			proc := testBatchReorderProc(t, test.conf)
+
+			res, err := proc.ProcessBatch(context.Background(), batchReorderBatch(test.input...))
+			require.NoError(t, err)
+
+			contents, errs := batchReorderContents(t, res)
+			assert.Equal(t, test.output, contents)
+			assert.Equal(t, test.errs, errs)
+		})
+	}
+}
+
+func TestBatchReorderLateMessages(t *testing.T) {
+	tests := []struct {
+		policy string
+		output []string
+		errs   []string
+	}{
+		{
+			policy: "keep",
+			output: []string{`"2023-01-01T00:00:05Z"`, `"2023-01-01T00:00:08Z"`, `"2023-01-01T00:00:09Z"`},
+		},
+		{
+			policy: "drop",
+			output: []string{`"2023-01-01T00:00:08Z"`, `"2023-01-01T00:00:09Z"`},
+		},
+		{
+			policy: "error",
+			output: []string{`"2023-01-01T00:00:05Z"`, `"2023-01-01T00:00:08Z"`, `"2023-01-01T00:00:09Z"`},
+			errs:   []string{`"2023-01-01T00:00:05Z"`},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.policy, func(t *testing.T) {
+			proc := testBatchReorderProc(t, `
+key: ${! this }
+key_type: timestamp
+max_out_of_orderness: 3s
+late_messages: `+test.policy+`
+`)
+
+			// Nothing is late within the first batch, and the watermark is
+			// advanced to 00:00:07.
+			res, err := proc.ProcessBatch(context.Background(), batchReorderBatch(
+				`"2023-01-01T00:00:10Z"`, `"2023-01-01T00:00:01Z"`,
+			))
+			require.NoError(t, err)
+			contents, errs := batchReorderContents(t, res)
+			assert.Equal(t, []string{`"2023-01-01T00:00:01Z"`, `"2023-01-01T00:00:10Z"`}, contents)
+			assert.Empty(t, errs)
+
+			res, err = proc.ProcessBatch(context.Background(), batchReorderBatch(
+				`"2023-01-01T00:00:09Z"`, `"2023-01-01T00:00:05Z"`, `"2023-01-01T00:00:08Z"`,
+			))
+			require.NoError(t, err)
+			contents, errs = batchReorderContents(t, res)
+			assert.Equal(t, test.output, contents)
+			assert.Equal(t, test.errs, errs)
+		})
+	}
+}
+
+func TestBatchReorderAllDropped(t *testing.T) {
+	proc := testBatchReorderProc(t, `
+key: ${! this }
+key_type: timestamp
+max_out_of_orderness: 0s
+late_messages: drop
+`)
+
+	_, err := proc.ProcessBatch(context.Background(), batchReorderBatch(`"2023-01-01T00:00:10Z"`))
+	require.NoError(t, err)
+
+	res, err := proc.ProcessBatch(context.Background(), batchReorderBatch(`"2023-01-01T00:00:09Z"`))
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func TestBatchReorderConfigErrors(t *testing.T) {
+	conf, err := batchReorderProcConfig().ParseYAML(`
+key: ${! this }
+max_out_of_orderness: 1s
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newBatchReorderFromParsed(conf, service.MockResources())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can only be used with a key_type of timestamp")
+}
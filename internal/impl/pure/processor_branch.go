@@ -18,6 +18,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/profile"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
@@ -207,6 +208,8 @@ type Branch struct {
 	mBatchSent     metrics.StatCounter
 	mError         metrics.StatCounter
 	mLatency       metrics.StatTimer
+
+	profile *profile.Processor
 }
 
 func newBranch(conf processor.BranchConfig, mgr bundle.NewManagement) (*Branch, error) {
@@ -235,6 +238,8 @@ func newBranch(conf processor.BranchConfig, mgr bundle.NewManagement) (*Branch,
 		mBatchSent:     stats.GetCounter("processor_batch_sent"),
 		mError:         stats.GetCounter("processor_error"),
 		mLatency:       stats.GetTimer("processor_latency_ns"),
+
+		profile: profile.FromManager(mgr, "branch"),
 	}
 
 	var err error
@@ -362,7 +367,9 @@ func (b *Branch) ProcessBatch(ctx context.Context, batch message.Batch) ([]messa
 		b.log.Errorf("Branch error: %v", e.err)
 	}
 
-	b.mLatency.Timing(time.Since(startedAt).Nanoseconds())
+	latency := time.Since(startedAt)
+	b.mLatency.Timing(latency.Nanoseconds())
+	b.profile.Record(batch.Len(), latency)
 	return []message.Batch{batch}, nil
 }
 
@@ -541,6 +548,7 @@ func alignBranchResult(length int, skipped, failed []int, result []message.Batch
 
 // Close blocks until the processor has closed down or the context is cancelled.
 func (b *Branch) Close(ctx context.Context) error {
+	b.profile.Deregister()
 	for _, child := range b.children {
 		if err := child.Close(ctx); err != nil {
 			return err
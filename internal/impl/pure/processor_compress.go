@@ -2,10 +2,12 @@ package pure
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -24,12 +26,21 @@ func init() {
 		},
 		Summary: `
 Compresses messages according to the selected algorithm. Supported compression
-algorithms are: gzip, pgzip, zlib, flate, snappy, lz4.`,
+algorithms are: gzip, pgzip, zlib, flate, snappy, lz4, zstd.`,
 		Description: `
-The 'level' field might not apply to all algorithms.`,
+The 'level' field might not apply to all algorithms.
+
+### Levels
+
+For gzip, pgzip, zlib and flate the level ranges from 1 (fastest) to 9 (smallest), with -1 selecting the default. For lz4 the level ranges from 1 to 9, where 0 or below selects the fastest compression. For zstd the level ranges from 1 to 22 and is mapped to the closest of the levels supported by the encoder, where 0 or below selects the default. The level is ignored by snappy.
+
+### Dictionaries
+
+Compressing small messages of a similar structure, such as JSON documents, can be made far more effective with a dictionary trained from sample messages, which can be created with the ` + "`zstd --train`" + ` command. Dictionaries are currently supported by the zstd algorithm only, and messages compressed with a dictionary must be decompressed with the same dictionary.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("algorithm", "The compression algorithm to use.").HasOptions("gzip", "pgzip", "zlib", "flate", "snappy", "lz4"),
+			docs.FieldString("algorithm", "The compression algorithm to use.").HasOptions("gzip", "pgzip", "zlib", "flate", "snappy", "lz4", "zstd"),
 			docs.FieldInt("level", "The level of compression to use. May not be applicable to all algorithms."),
+			docs.FieldString("dictionary", "An optional path to a dictionary file to compress messages with.", "./dicts/events.dict").Advanced().AtVersion("4.20.0"),
 		).ChildDefaultAndTypesFromStruct(processor.NewCompressConfig()),
 	})
 	if err != nil {
@@ -44,7 +55,17 @@ type compressProc struct {
 }
 
 func newCompress(conf processor.CompressConfig, mgr bundle.NewManagement) (*compressProc, error) {
-	cor, err := strToCompressor(conf.Algorithm)
+	var cor CompressFunc
+	var err error
+	if conf.Dictionary != "" {
+		var dict []byte
+		if dict, err = ifs.ReadFile(mgr.FS(), conf.Dictionary); err != nil {
+			return nil, fmt.Errorf("failed to read dictionary: %w", err)
+		}
+		cor, err = strToDictCompressor(conf.Algorithm, dict)
+	} else {
+		cor, err = strToCompressor(conf.Algorithm)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -299,3 +299,31 @@ func TestCompressLZ4(t *testing.T) {
 		t.Errorf("Unexpected output: %s != %s", act, exp)
 	}
 }
+
+func BenchmarkCompress(b *testing.B) {
+	content := bytes.Repeat([]byte(`{"id":"foo","message":"hello world","count":10} `), 20)
+
+	for _, algo := range []string{"gzip", "zlib", "flate", "lz4"} {
+		algo := algo
+		b.Run(algo, func(b *testing.B) {
+			conf := processor.NewConfig()
+			conf.Type = "compress"
+			conf.Compress.Algorithm = algo
+
+			proc, err := mock.NewManager().NewProcessor(conf)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				msgs, res := proc.ProcessBatch(context.Background(), message.QuickBatch([][]byte{content}))
+				if res != nil || len(msgs) != 1 {
+					b.Fatal(res)
+				}
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "msgs/s")
+		})
+	}
+}
@@ -0,0 +1,557 @@
+package pure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	cpFieldOperator   = "operator"
+	cpFieldDelimiter  = "delimiter"
+	cpFieldQuote      = "quote"
+	cpFieldEscape     = "escape"
+	cpFieldLazyQuotes = "lazy_quotes"
+	cpFieldHeaderRow  = "header_row"
+	cpFieldSchema     = "schema"
+	cpFieldSchemaName = "name"
+	cpFieldSchemaHdr  = "header"
+	cpFieldSchemaType = "type"
+	cpFieldRaggedRows = "ragged_rows"
+	cpFieldQuoteMode  = "quote_mode"
+	cpFieldRollKey    = "roll_key"
+)
+
+func csvProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Parsing").
+		Version("4.20.0").
+		Summary("Parses CSV documents into structured rows, or formats structured messages as CSV rows, with configurable delimiters, quoting and per column types.").
+		Description(`
+### Parsing
+
+When the `+"`operator`"+` is `+"`parse`"+` the contents of each message are parsed as a CSV document and each row becomes a new message. When `+"`header_row`"+` is `+"`true`"+` the first row of each document is used as the header and rows become objects keyed by it, otherwise rows become objects keyed by the `+"`schema`"+` column names in order, or arrays of strings when no schema is configured. Documents consisting only of a header row produce no messages.
+
+### Formatting
+
+When the `+"`operator`"+` is `+"`format`"+` each message is converted into a single CSV row without a trailing line break, which is suitable for writing with the `+"`lines`"+` codec. Objects are written with the values of the `+"`schema`"+` columns in order, and when no schema is configured the columns are the sorted keys of the object. Arrays are written with their elements in order.
+
+When `+"`header_row`"+` is `+"`true`"+` a header row is written before the first row of each batch. When a `+"`roll_key`"+` is set the header row is instead written each time the value of the key changes, which can be used to write a header at the start of each file when the key matches the path of an output.
+
+### Schemas
+
+Each column of a `+"`schema`"+` has a `+"`name`"+`, which is the key of the column within structured rows, and optionally a `+"`header`"+`, which is the name of the column within CSV header rows when it differs from the `+"`name`"+`. When parsing, values are coerced into the `+"`type`"+` of their column, where empty values of non string columns become `+"`null`"+`. Columns of a header row that are not in the schema are kept as strings under their header name.
+
+### Ragged Rows
+
+Rows with a different number of values than the header, schema or first row of a document are handled according to `+"`ragged_rows`"+`. With `+"`error`"+` the message is flagged as failed, with `+"`skip`"+` the row is dropped, and with `+"`fill`"+` missing values are set to `+"`null`"+` and extra values are dropped.`).
+		Fields(
+			service.NewStringAnnotatedEnumField(cpFieldOperator, map[string]string{
+				"parse":  "Parse CSV documents into a message per row.",
+				"format": "Format structured messages as CSV rows.",
+			}).Description("The operation to perform on messages."),
+			service.NewStringField(cpFieldDelimiter).
+				Description("The character that separates values within a row.").
+				Default(","),
+			service.NewStringField(cpFieldQuote).
+				Description("The character used for quoting values.").
+				Default(`"`).
+				Advanced(),
+			service.NewStringField(cpFieldEscape).
+				Description("A character used for escaping quotes within quoted values, such as `\\`. When empty quotes are escaped by doubling them, as described in RFC 4180.").
+				Default("").
+				Advanced(),
+			service.NewBoolField(cpFieldLazyQuotes).
+				Description("Whether to tolerate bare quotes within unquoted values and unescaped quotes within quoted values when parsing.").
+				Default(false).
+				Advanced(),
+			service.NewBoolField(cpFieldHeaderRow).
+				Description("Whether documents begin with a header row when parsing, or whether to write header rows when formatting.").
+				Default(true),
+			service.NewObjectListField(cpFieldSchema,
+				service.NewStringField(cpFieldSchemaName).
+					Description("The key of the column within structured rows."),
+				service.NewStringField(cpFieldSchemaHdr).
+					Description("The name of the column within header rows, which defaults to the `name`.").
+					Default(""),
+				service.NewStringEnumField(cpFieldSchemaType, "string", "int", "float", "bool").
+					Description("The type to coerce values of the column into when parsing.").
+					Default("string"),
+			).
+				Description("An optional list of columns describing the names and types of values.").
+				Default([]any{}),
+			service.NewStringEnumField(cpFieldRaggedRows, "error", "skip", "fill").
+				Description("How to handle rows with an unexpected number of values when parsing.").
+				Default("error").
+				Advanced(),
+			service.NewStringAnnotatedEnumField(cpFieldQuoteMode, map[string]string{
+				"minimal":     "Only quote values that contain delimiters, quotes, line breaks or leading whitespace.",
+				"all":         "Quote all values.",
+				"non_numeric": "Quote all values that are not numbers.",
+			}).
+				Description("Which values to quote when formatting.").
+				Default("minimal").
+				Advanced(),
+			service.NewInterpolatedStringField(cpFieldRollKey).
+				Description("An optional key that, when formatting, causes header rows to be written each time its value changes rather than for each batch.").
+				Example(`${! meta("path") }`).
+				Optional().
+				Advanced(),
+		).
+		Example(
+			"Typed Rows",
+			"Parse a semicolon delimited file where the header names differ from the fields we want, and the numeric columns are converted into numbers.",
+			`
+input:
+  file:
+    paths: [ ./prices.csv ]
+    codec: all-bytes
+
+pipeline:
+  processors:
+    - csv:
+        operator: parse
+        delimiter: ';'
+        schema:
+          - name: sku
+            header: Product Code
+          - name: price
+            header: Unit Price
+            type: float
+          - name: in_stock
+            header: Stock
+            type: int
+`,
+		).
+		Example(
+			"Header Per File",
+			"Write JSON documents as CSV rows into hourly files, where a header row is written at the start of each file.",
+			`
+pipeline:
+  processors:
+    - mapping: 'meta path = "./out/" + now().ts_format("2006-01-02-15") + ".csv"'
+    - csv:
+        operator: format
+        schema:
+          - name: id
+          - name: name
+          - name: created_at
+        roll_key: ${! meta("path") }
+
+output:
+  file:
+    path: ${! meta("path") }
+    codec: lines
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"csv", csvProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newCSVProcFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type csvColumn struct {
+	name   string
+	header string
+	coerce func(string) (any, error)
+}
+
+// valueCoercer returns a function that converts string values into a type,
+// where empty values of types other than string become nil.
+func valueCoercer(typeStr string) (func(string) (any, error), error) {
+	if typeStr == "string" {
+		return func(s string) (any, error) {
+			return s, nil
+		}, nil
+	}
+
+	var parse func(string) (any, error)
+	switch typeStr {
+	case "int":
+		parse = func(s string) (any, error) {
+			return strconv.ParseInt(s, 10, 64)
+		}
+	case "float":
+		parse = func(s string) (any, error) {
+			return strconv.ParseFloat(s, 64)
+		}
+	case "bool":
+		parse = func(s string) (any, error) {
+			return strconv.ParseBool(s)
+		}
+	default:
+		return nil, fmt.Errorf("type not recognised: %v", typeStr)
+	}
+	return func(s string) (any, error) {
+		if s = strings.TrimSpace(s); s == "" {
+			return nil, nil
+		}
+		return parse(s)
+	}, nil
+}
+
+var csvStringColumn, _ = valueCoercer("string")
+
+type csvProc struct {
+	parse      bool
+	dialect    *csvDialect
+	headerRow  bool
+	schema     []csvColumn
+	raggedRows string
+	quoteMode  csvQuoteMode
+	rollKey    *service.InterpolatedString
+	log        *service.Logger
+
+	mut         sync.Mutex
+	rolled      bool
+	lastRollKey string
+	columns     []csvColumn
+}
+
+func newCSVProcFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (p *csvProc, err error) {
+	p = &csvProc{log: mgr.Logger()}
+
+	var operator string
+	if operator, err = conf.FieldString(cpFieldOperator); err != nil {
+		return
+	}
+	switch operator {
+	case "parse":
+		p.parse = true
+	case "format":
+	default:
+		return nil, fmt.Errorf("operator not recognised: %v", operator)
+	}
+
+	var delim, quote, escape string
+	var lazyQuotes bool
+	if delim, err = conf.FieldString(cpFieldDelimiter); err != nil {
+		return
+	}
+	if quote, err = conf.FieldString(cpFieldQuote); err != nil {
+		return
+	}
+	if escape, err = conf.FieldString(cpFieldEscape); err != nil {
+		return
+	}
+	if lazyQuotes, err = conf.FieldBool(cpFieldLazyQuotes); err != nil {
+		return
+	}
+	if p.dialect, err = newCSVDialect(delim, quote, escape, lazyQuotes); err != nil {
+		return
+	}
+
+	if p.headerRow, err = conf.FieldBool(cpFieldHeaderRow); err != nil {
+		return
+	}
+
+	schemaConfs, err := conf.FieldObjectList(cpFieldSchema)
+	if err != nil {
+		return
+	}
+	for i, sConf := range schemaConfs {
+		var c csvColumn
+		if c.name, err = sConf.FieldString(cpFieldSchemaName); err != nil {
+			return
+		}
+		if c.header, err = sConf.FieldString(cpFieldSchemaHdr); err != nil {
+			return
+		}
+		if c.header == "" {
+			c.header = c.name
+		}
+		var typeStr string
+		if typeStr, err = sConf.FieldString(cpFieldSchemaType); err != nil {
+			return
+		}
+		if c.coerce, err = valueCoercer(typeStr); err != nil {
+			return nil, fmt.Errorf("schema column %v: %w", i, err)
+		}
+		p.schema = append(p.schema, c)
+	}
+
+	if p.raggedRows, err = conf.FieldString(cpFieldRaggedRows); err != nil {
+		return
+	}
+
+	var quoteMode string
+	if quoteMode, err = conf.FieldString(cpFieldQuoteMode); err != nil {
+		return
+	}
+	switch quoteMode {
+	case "minimal":
+		p.quoteMode = csvQuoteMinimal
+	case "all":
+		p.quoteMode = csvQuoteAll
+	case "non_numeric":
+		p.quoteMode = csvQuoteNonNumeric
+	default:
+		return nil, fmt.Errorf("quote mode not recognised: %v", quoteMode)
+	}
+
+	if conf.Contains(cpFieldRollKey) {
+		if p.rollKey, err = conf.FieldInterpolatedString(cpFieldRollKey); err != nil {
+			return
+		}
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// parseColumns returns the columns of a document, consuming the header row
+// from its records when configured.
+func (p *csvProc) parseColumns(records [][]string) ([]csvColumn, [][]string) {
+	if !p.headerRow {
+		return p.schema, records
+	}
+
+	header := records[0]
+	columns := make([]csvColumn, len(header))
+	for i, h := range header {
+		columns[i] = csvColumn{name: h, header: h, coerce: csvStringColumn}
+		for _, c := range p.schema {
+			if c.header == h {
+				columns[i] = c
+				break
+			}
+		}
+	}
+	return columns, records[1:]
+}
+
+func (p *csvProc) parseMessage(msg *service.Message) (service.MessageBatch, error) {
+	data, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := p.dialect.read(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns, records := p.parseColumns(records)
+
+	width := len(columns)
+	if len(columns) == 0 && len(records) > 0 {
+		width = len(records[0])
+	}
+
+	out := make(service.MessageBatch, 0, len(records))
+	for i, record := range records {
+		row := i + 1
+		if p.headerRow {
+			row++
+		}
+
+		if len(record) != width {
+			switch p.raggedRows {
+			case "skip":
+				p.log.Debugf("Skipping row %v with %v values, expected %v", row, len(record), width)
+				continue
+			case "fill":
+			default:
+				return nil, fmt.Errorf("row %v: expected %v values, got %v", row, width, len(record))
+			}
+		}
+
+		if len(columns) == 0 {
+			arr := make([]any, width)
+			for j := range arr {
+				if j < len(record) {
+					arr[j] = record[j]
+				}
+			}
+			part := msg.Copy()
+			part.SetStructuredMut(arr)
+			out = append(out, part)
+			continue
+		}
+
+		obj := make(map[string]any, len(columns))
+		for j, c := range columns {
+			if j >= len(record) {
+				obj[c.name] = nil
+				continue
+			}
+			if obj[c.name], err = c.coerce(record[j]); err != nil {
+				return nil, fmt.Errorf("row %v: column %v: %w", row, c.header, err)
+			}
+		}
+		part := msg.Copy()
+		part.SetStructuredMut(obj)
+		out = append(out, part)
+	}
+	return out, nil
+}
+
+//------------------------------------------------------------------------------
+
+func csvFormatValue(v any) (csvField, error) {
+	switch t := v.(type) {
+	case nil:
+		return csvField{}, nil
+	case string:
+		return csvField{value: t}, nil
+	case []byte:
+		return csvField{value: string(t)}, nil
+	case bool:
+		return csvField{value: strconv.FormatBool(t)}, nil
+	case json.Number:
+		return csvField{value: t.String(), numeric: true}, nil
+	case int:
+		return csvField{value: strconv.Itoa(t), numeric: true}, nil
+	case int64:
+		return csvField{value: strconv.FormatInt(t, 10), numeric: true}, nil
+	case uint64:
+		return csvField{value: strconv.FormatUint(t, 10), numeric: true}, nil
+	case float64:
+		return csvField{value: strconv.FormatFloat(t, 'f', -1, 64), numeric: true}, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return csvField{}, err
+	}
+	return csvField{value: string(b)}, nil
+}
+
+// formatColumns returns the columns to write an object with, which are the
+// schema when configured and otherwise the sorted keys of the object.
+func (p *csvProc) formatColumns(obj map[string]any) []csvColumn {
+	if len(p.schema) > 0 {
+		return p.schema
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	columns := make([]csvColumn, len(keys))
+	for i, k := range keys {
+		columns[i] = csvColumn{name: k, header: k}
+	}
+	return columns
+}
+
+func (p *csvProc) formatMessage(batch service.MessageBatch, i int) error {
+	v, err := batch[i].AsStructured()
+	if err != nil {
+		return fmt.Errorf("failed to parse message as structured data: %w", err)
+	}
+
+	writeHeader := false
+	if p.headerRow {
+		if p.rollKey == nil {
+			writeHeader = i == 0
+		} else {
+			key, err := batch.TryInterpolatedString(i, p.rollKey)
+			if err != nil {
+				return fmt.Errorf("roll key interpolation error: %w", err)
+			}
+			writeHeader = !p.rolled || key != p.lastRollKey
+			p.rolled, p.lastRollKey = true, key
+		}
+	}
+
+	var values []any
+	switch t := v.(type) {
+	case map[string]any:
+		if writeHeader || p.columns == nil || !p.headerRow {
+			p.columns = p.formatColumns(t)
+		}
+		values = make([]any, len(p.columns))
+		for j, c := range p.columns {
+			values[j] = t[c.name]
+		}
+	case []any:
+		values = t
+	default:
+		return fmt.Errorf("expected object or array, got %T", v)
+	}
+
+	fields := make([]csvField, len(values))
+	for j, e := range values {
+		if fields[j], err = csvFormatValue(e); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if writeHeader {
+		columns := p.columns
+		if _, isArr := v.([]any); isArr {
+			columns = p.schema
+		}
+		if len(columns) > 0 {
+			header := make([]csvField, len(columns))
+			for j, c := range columns {
+				header[j] = csvField{value: c.header}
+			}
+			p.dialect.writeRecord(&buf, header, p.quoteMode)
+			buf.WriteByte('\n')
+		}
+	}
+	p.dialect.writeRecord(&buf, fields, p.quoteMode)
+
+	batch[i].SetBytes(buf.Bytes())
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (p *csvProc) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	if p.parse {
+		var out service.MessageBatch
+		for _, msg := range batch {
+			rows, err := p.parseMessage(msg)
+			if err != nil {
+				p.log.Debugf("Failed to parse CSV: %v", err)
+				msg.SetError(err)
+				out = append(out, msg)
+				continue
+			}
+			out = append(out, rows...)
+		}
+		if len(out) == 0 {
+			return nil, nil
+		}
+		return []service.MessageBatch{out}, nil
+	}
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	for i := range batch {
+		if err := p.formatMessage(batch, i); err != nil {
+			p.log.Debugf("Failed to format CSV: %v", err)
+			batch[i].SetError(err)
+		}
+	}
+	return []service.MessageBatch{batch}, nil
+}
+
+func (p *csvProc) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,308 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testCSVProc(t *testing.T, confStr string) *csvProc {
+	t.Helper()
+
+	conf, err := csvProcConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newCSVProcFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+	return proc
+}
+
+func csvProcess(t *testing.T, proc *csvProc, contents ...string) service.MessageBatch {
+	t.Helper()
+
+	var batch service.MessageBatch
+	for _, c := range contents {
+		batch = append(batch, service.NewMessage([]byte(c)))
+	}
+
+	batches, err := proc.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+	if len(batches) == 0 {
+		return nil
+	}
+	require.Len(t, batches, 1)
+	return batches[0]
+}
+
+func csvStructured(t *testing.T, batch service.MessageBatch) []any {
+	t.Helper()
+
+	var res []any
+	for _, m := range batch {
+		require.NoError(t, m.GetError())
+		v, err := m.AsStructured()
+		require.NoError(t, err)
+		res = append(res, v)
+	}
+	return res
+}
+
+func csvContents(t *testing.T, batch service.MessageBatch) []string {
+	t.Helper()
+
+	var res []string
+	for _, m := range batch {
+		require.NoError(t, m.GetError())
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+		res = append(res, string(b))
+	}
+	return res
+}
+
+func TestCSVParseHeaderSchema(t *testing.T) {
+	proc := testCSVProc(t, `
+operator: parse
+schema:
+  - name: id
+    header: ID
+    type: int
+  - name: price
+    type: float
+  - name: active
+    type: bool
+`)
+
+	res := csvProcess(t, proc, "ID,name,price,active\r\n1,foo,1.5,true\r\n\r\n2,\"bar, baz\",,false\r\n")
+	assert.Equal(t, []any{
+		map[string]any{"id": int64(1), "name": "foo", "price": 1.5, "active": true},
+		map[string]any{"id": int64(2), "name": "bar, baz", "price": nil, "active": false},
+	}, csvStructured(t, res))
+
+	res = csvProcess(t, proc, "ID,price\nnope,1")
+	require.Len(t, res, 1)
+	require.Error(t, res[0].GetError())
+	assert.Contains(t, res[0].GetError().Error(), "row 2: column ID")
+}
+
+func TestCSVParseNoHeader(t *testing.T) {
+	proc := testCSVProc(t, `
+operator: parse
+header_row: false
+`)
+	assert.Equal(t, []any{
+		[]any{"a", "b"},
+		[]any{"c", "d"},
+	}, csvStructured(t, csvProcess(t, proc, "a,b\nc,d")))
+
+	proc = testCSVProc(t, `
+operator: parse
+header_row: false
+schema:
+  - name: first
+  - name: second
+    type: int
+`)
+	assert.Equal(t, []any{
+		map[string]any{"first": "a", "second": int64(1)},
+	}, csvStructured(t, csvProcess(t, proc, "a,1")))
+}
+
+func TestCSVParseDialects(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     string
+		input    string
+		expected []any
+		errStr   string
+	}{
+		{
+			name: "custom delimiter and quote",
+			conf: `
+delimiter: ';'
+quote: "'"
+`,
+			input:    "'a;b';'it''s'\n'multi\nline';c",
+			expected: []any{[]any{"a;b", "it's"}, []any{"multi\nline", "c"}},
+		},
+		{
+			name: "escape character",
+			conf: `
+delimiter: "\t"
+escape: '\'
+`,
+			input:    "\"say \\\"hi\\\"\"\t\"back\\\\slash\"\tplain",
+			expected: []any{[]any{`say "hi"`, `back\slash`, "plain"}},
+		},
+		{
+			name:   "bare quote",
+			input:  `a"b,c`,
+			errStr: "line 1: bare quote in unquoted field",
+		},
+		{
+			name:   "unterminated quote",
+			input:  "a,\"b\nc",
+			errStr: "line 2: quoted field is not terminated",
+		},
+		{
+			name:     "lazy quotes",
+			conf:     "lazy_quotes: true",
+			input:    `a"b,"c"d"`,
+			expected: []any{[]any{`a"b`, `c"d`}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			proc := testCSVProc(t, "operator: parse\nheader_row: false\n"+test.conf)
+			res := csvProcess(t, proc, test.input)
+			if test.errStr != "" {
+				require.Len(t, res, 1)
+				require.Error(t, res[0].GetError())
+				assert.Contains(t, res[0].GetError().Error(), test.errStr)
+				return
+			}
+			assert.Equal(t, test.expected, csvStructured(t, res))
+		})
+	}
+}
+
+func TestCSVParseRaggedRows(t *testing.T) {
+	input := "a,b\n1,2\n3\n4,5,6"
+
+	res := csvProcess(t, testCSVProc(t, "operator: parse"), input)
+	require.Len(t, res, 1)
+	assert.EqualError(t, res[0].GetError(), "row 3: expected 2 values, got 1")
+
+	assert.Equal(t, []any{
+		map[string]any{"a": "1", "b": "2"},
+	}, csvStructured(t, csvProcess(t, testCSVProc(t, "operator: parse\nragged_rows: skip"), input)))
+
+	assert.Equal(t, []any{
+		map[string]any{"a": "1", "b": "2"},
+		map[string]any{"a": "3", "b": nil},
+		map[string]any{"a": "4", "b": "5"},
+	}, csvStructured(t, csvProcess(t, testCSVProc(t, "operator: parse\nragged_rows: fill"), input)))
+}
+
+func TestCSVFormatHeaderPerBatch(t *testing.T) {
+	proc := testCSVProc(t, `
+operator: format
+schema:
+  - name: id
+    header: ID
+  - name: name
+`)
+
+	assert.Equal(t, []string{
+		"ID,name\n1,foo",
+		`2,"bar, ""baz"""`,
+		"3,",
+	}, csvContents(t, csvProcess(t, proc,
+		`{"id":1,"name":"foo"}`,
+		`{"id":2,"name":"bar, \"baz\"","ignored":true}`,
+		`{"id":3}`,
+	)))
+
+	assert.Equal(t, []string{
+		"ID,name\n4,qux",
+	}, csvContents(t, csvProcess(t, proc, `{"id":4,"name":"qux"}`)))
+}
+
+func TestCSVFormatNoSchema(t *testing.T) {
+	proc := testCSVProc(t, `
+operator: format
+delimiter: '|'
+quote_mode: non_numeric
+`)
+
+	assert.Equal(t, []string{
+		"\"a\"|\"b\"|\"c\"\n1.5|\"x\"|\"{\"\"d\"\":true}\"",
+		`2|"y"|""`,
+	}, csvContents(t, csvProcess(t, proc,
+		`{"b":"x","a":1.5,"c":{"d":true}}`,
+		`{"b":"y","a":2}`,
+	)))
+
+	proc = testCSVProc(t, `
+operator: format
+header_row: false
+escape: '\'
+quote_mode: all
+`)
+	assert.Equal(t, []string{
+		`"a","b \"c\""`,
+	}, csvContents(t, csvProcess(t, proc, `["a","b \"c\""]`)))
+}
+
+func TestCSVFormatRollKey(t *testing.T) {
+	proc := testCSVProc(t, `
+operator: format
+roll_key: ${! json("file") }
+`)
+
+	assert.Equal(t, []string{
+		"file,v\na,1",
+		"a,2",
+		"file,v\nb,3",
+	}, csvContents(t, csvProcess(t, proc,
+		`{"file":"a","v":1}`,
+		`{"file":"a","v":2}`,
+		`{"file":"b","v":3}`,
+	)))
+
+	assert.Equal(t, []string{
+		"b,4",
+		"file,v\na,5",
+	}, csvContents(t, csvProcess(t, proc,
+		`{"file":"b","v":4}`,
+		`{"file":"a","v":5}`,
+	)))
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	format := testCSVProc(t, `
+operator: format
+delimiter: ';'
+quote: "'"
+escape: '\'
+schema:
+  - name: text
+  - name: n
+`)
+	parse := testCSVProc(t, `
+operator: parse
+delimiter: ';'
+quote: "'"
+escape: '\'
+schema:
+  - name: n
+    type: int
+`)
+
+	rows := csvContents(t, csvProcess(t, format, `{"text":" it's; a\\ test\n","n":5}`))
+	require.Len(t, rows, 1)
+
+	assert.Equal(t, []any{
+		map[string]any{"text": " it's; a\\ test\n", "n": int64(5)},
+	}, csvStructured(t, csvProcess(t, parse, rows[0])))
+}
+
+func TestCSVConfigErrors(t *testing.T) {
+	for _, confStr := range []string{
+		"operator: parse\ndelimiter: ',,'",
+		"operator: parse\nquote: ','",
+		"operator: parse\nescape: \"\\n\"",
+	} {
+		conf, err := csvProcConfig().ParseYAML(confStr, nil)
+		require.NoError(t, err)
+
+		_, err = newCSVProcFromParsed(conf, service.MockResources())
+		assert.Error(t, err, confStr)
+	}
+}
@@ -308,3 +308,48 @@ func TestDecompressLZ4(t *testing.T) {
 		t.Errorf("Unexpected output: %s != %s", act, exp)
 	}
 }
+
+func BenchmarkDecompress(b *testing.B) {
+	rawContent := bytes.Repeat([]byte(`{"id":"foo","message":"hello world","count":10} `), 20)
+
+	var gzipBuf, zlibBuf bytes.Buffer
+	gw := gzip.NewWriter(&gzipBuf)
+	_, _ = gw.Write(rawContent)
+	_ = gw.Close()
+	zw := zlib.NewWriter(&zlibBuf)
+	_, _ = zw.Write(rawContent)
+	_ = zw.Close()
+
+	for _, test := range []struct {
+		algo    string
+		content []byte
+	}{
+		{algo: "gzip", content: gzipBuf.Bytes()},
+		{algo: "zlib", content: zlibBuf.Bytes()},
+	} {
+		test := test
+		b.Run(test.algo, func(b *testing.B) {
+			conf := processor.NewConfig()
+			conf.Type = "decompress"
+			conf.Decompress.Algorithm = test.algo
+
+			proc, err := mock.NewManager().NewProcessor(conf)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				msgs, res := proc.ProcessBatch(context.Background(), message.QuickBatch([][]byte{test.content}))
+				if res != nil || len(msgs) != 1 {
+					b.Fatal(res)
+				}
+				if !bytes.Equal(msgs[0].Get(0).AsBytes(), rawContent) {
+					b.Fatal("wrong result")
+				}
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "msgs/s")
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	emFieldMetrics            = "metrics"
+	emFieldName               = "name"
+	emFieldType               = "type"
+	emFieldCheck              = "check"
+	emFieldValue              = "value"
+	emFieldLabels             = "labels"
+	emFieldMaxCardinality     = "max_cardinality"
+	emFieldOverflowLabelValue = "overflow_label_value"
+)
+
+func extractMetricsProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.20.0").
+		Summary("Emits any number of custom metrics from each message by evaluating Bloblang queries against its contents, without modifying the message.").
+		Description(`
+For each message every metric is evaluated in turn. When a metric has a `+"`check`"+` it is only emitted for messages where the check resolves to `+"`true`"+`, and the `+"`value`"+` query is then executed in order to obtain the number to emit, where a query that results in `+"`deleted()`"+` skips the metric for that message.
+
+Messages are never modified by this processor, including when a query fails, in which case the error is logged and the metric is skipped for that message. Custom metrics are emitted along with Benthos internal metrics, for more information check out the [metrics docs here](/docs/components/metrics/about).
+
+### Types
+
+- `+"`counter`"+`: Increments a counter by one, the `+"`value`"+` is not required.
+- `+"`counter_by`"+`: Increments a counter by the `+"`value`"+`, which must be a non-negative integer.
+- `+"`gauge`"+`: Sets a gauge to the `+"`value`"+`, which must be an integer and can be negative.
+- `+"`timing`"+`: Records the `+"`value`"+` as a timing, which must either be a non-negative integer of nanoseconds or a duration string such as `+"`250ms`"+`.
+
+### Cardinality
+
+Labels with values derived from messages can easily produce more series than a metrics destination can cope with. When a metric has a `+"`max_cardinality`"+` the processor tracks the unique combinations of label values emitted, and once the limit is reached any new combination is emitted with all of its label values replaced by the `+"`overflow_label_value`"+`.`).
+		Fields(
+			service.NewObjectListField(emFieldMetrics,
+				service.NewStringField(emFieldName).
+					Description("The name of the metric, which must be unique across all Benthos components otherwise it will overwrite those other metrics."),
+				service.NewStringEnumField(emFieldType, "counter", "counter_by", "gauge", "timing").
+					Description("The type of the metric."),
+				service.NewBloblangField(emFieldCheck).
+					Description("An optional Bloblang query that should return a boolean value indicating whether the metric should be emitted for a message.").
+					Example(`this.type == "order"`).
+					Optional(),
+				service.NewBloblangField(emFieldValue).
+					Description("A Bloblang query that should return the value of the metric for a message. Required for all types other than `counter`.").
+					Example(`root = this.order.total`).
+					Example(`root = (timestamp_unix_nano() - this.created_at.ts_unix_nano())`).
+					Optional(),
+				service.NewInterpolatedStringMapField(emFieldLabels).
+					Description("A map of label names and values to add to the metric. Labels are not supported by some metric destinations, in which case the metrics series are combined.").
+					Example(map[string]any{
+						"type":  `${! this.type }`,
+						"topic": `${! meta("kafka_topic") }`,
+					}).
+					Optional(),
+				service.NewIntField(emFieldMaxCardinality).
+					Description("The maximum number of unique combinations of label values to emit for the metric, set to `0` for no limit.").
+					Default(0).
+					Advanced(),
+				service.NewStringField(emFieldOverflowLabelValue).
+					Description("The value given to all labels of a combination that exceeds the `max_cardinality`.").
+					Default("other").
+					Advanced(),
+			).Description("A list of metrics to emit."),
+		).
+		Example(
+			"Order Metrics",
+			"In this example we count orders by region, where the number of regions is limited to twenty, and record both the total value of each order and the time between it being created and processed.",
+			`
+pipeline:
+  processors:
+    - extract_metrics:
+        metrics:
+          - name: orders_total
+            type: counter
+            check: this.type == "order"
+            labels:
+              region: ${! this.region }
+            max_cardinality: 20
+          - name: orders_value
+            type: counter_by
+            check: this.type == "order"
+            value: root = this.total.round()
+          - name: orders_latency
+            type: timing
+            check: this.type == "order"
+            value: root = timestamp_unix_nano() - this.created_at.ts_parse("2006-01-02T15:04:05Z07:00").ts_unix_nano()
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"extract_metrics", extractMetricsProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newExtractMetricsFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type extractedMetric struct {
+	name  string
+	mType string
+	check *bloblang.Executor
+	value *bloblang.Executor
+
+	labelNames  []string
+	labelValues []*service.InterpolatedString
+
+	maxCardinality     int
+	overflowLabelValue string
+
+	cardMut        sync.Mutex
+	seen           map[string]struct{}
+	overflowLogged bool
+
+	counter *service.MetricCounter
+	gauge   *service.MetricGauge
+	timer   *service.MetricTimer
+}
+
+func extractedMetricFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (m *extractedMetric, err error) {
+	m = &extractedMetric{}
+	if m.name, err = conf.FieldString(emFieldName); err != nil {
+		return
+	}
+	if m.name == "" {
+		return nil, errors.New("metric name must not be empty")
+	}
+	if m.mType, err = conf.FieldString(emFieldType); err != nil {
+		return
+	}
+	if conf.Contains(emFieldCheck) {
+		if m.check, err = conf.FieldBloblang(emFieldCheck); err != nil {
+			return
+		}
+	}
+	if conf.Contains(emFieldValue) {
+		if m.value, err = conf.FieldBloblang(emFieldValue); err != nil {
+			return
+		}
+	} else if m.mType != "counter" {
+		return nil, fmt.Errorf("a value is required for metrics of type %v", m.mType)
+	}
+
+	if conf.Contains(emFieldLabels) {
+		labels, err := conf.FieldInterpolatedStringMap(emFieldLabels)
+		if err != nil {
+			return nil, err
+		}
+		for k := range labels {
+			m.labelNames = append(m.labelNames, k)
+		}
+		sort.Strings(m.labelNames)
+		for _, k := range m.labelNames {
+			m.labelValues = append(m.labelValues, labels[k])
+		}
+	}
+
+	if m.maxCardinality, err = conf.FieldInt(emFieldMaxCardinality); err != nil {
+		return
+	}
+	if m.maxCardinality > 0 {
+		m.seen = map[string]struct{}{}
+	}
+	if m.overflowLabelValue, err = conf.FieldString(emFieldOverflowLabelValue); err != nil {
+		return
+	}
+
+	switch m.mType {
+	case "counter", "counter_by":
+		m.counter = mgr.Metrics().NewCounter(m.name, m.labelNames...)
+	case "gauge":
+		m.gauge = mgr.Metrics().NewGauge(m.name, m.labelNames...)
+	case "timing":
+		m.timer = mgr.Metrics().NewTimer(m.name, m.labelNames...)
+	}
+	return m, nil
+}
+
+// guardCardinality returns the label values to emit, which are replaced with
+// the overflow value when they would exceed the max cardinality.
+func (m *extractedMetric) guardCardinality(values []string) (guarded []string, overflowed bool) {
+	if m.maxCardinality <= 0 {
+		return values, false
+	}
+
+	key := strings.Join(values, "\x00")
+
+	m.cardMut.Lock()
+	defer m.cardMut.Unlock()
+
+	if _, exists := m.seen[key]; exists {
+		return values, false
+	}
+	if len(m.seen) < m.maxCardinality {
+		m.seen[key] = struct{}{}
+		return values, false
+	}
+
+	guarded = make([]string, len(values))
+	for i := range guarded {
+		guarded[i] = m.overflowLabelValue
+	}
+	overflowed, m.overflowLogged = !m.overflowLogged, true
+	return guarded, overflowed
+}
+
+func (m *extractedMetric) queryValue(batch service.MessageBatch, i int) (v any, skip bool, err error) {
+	res, err := batch.BloblangQuery(i, m.value)
+	if err != nil {
+		return nil, false, fmt.Errorf("value query error: %w", err)
+	}
+	if res == nil {
+		return nil, true, nil
+	}
+	if v, err = res.AsStructured(); err != nil {
+		if vBytes, _ := res.AsBytes(); len(vBytes) > 0 {
+			return string(vBytes), false, nil
+		}
+		return nil, false, fmt.Errorf("value query error: %w", err)
+	}
+	return v, false, nil
+}
+
+func (m *extractedMetric) parseValue(v any) (int64, error) {
+	if m.mType == "timing" {
+		if s, ok := v.(string); ok {
+			if d, err := time.ParseDuration(s); err == nil {
+				v = d.Nanoseconds()
+			}
+		}
+	}
+
+	i, err := query.IToInt(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse value: %w", err)
+	}
+	if i < 0 && m.mType != "gauge" {
+		return 0, errors.New("value is negative")
+	}
+	return i, nil
+}
+
+func (m *extractedMetric) emit(batch service.MessageBatch, i int) (overflowed bool, err error) {
+	if m.check != nil {
+		res, err := batch.BloblangQuery(i, m.check)
+		if err != nil {
+			return false, fmt.Errorf("check query error: %w", err)
+		}
+		if res == nil {
+			return false, nil
+		}
+		v, err := res.AsStructured()
+		if err != nil {
+			return false, fmt.Errorf("check query error: %w", err)
+		}
+		pass, ok := v.(bool)
+		if !ok {
+			return false, fmt.Errorf("check query resulted in a non-boolean value: %T", v)
+		}
+		if !pass {
+			return false, nil
+		}
+	}
+
+	var value int64 = 1
+	if m.mType != "counter" {
+		v, skip, err := m.queryValue(batch, i)
+		if err != nil || skip {
+			return false, err
+		}
+		if value, err = m.parseValue(v); err != nil {
+			return false, err
+		}
+	}
+
+	var labelValues []string
+	if len(m.labelValues) > 0 {
+		labelValues = make([]string, len(m.labelValues))
+		for j, l := range m.labelValues {
+			if labelValues[j], err = batch.TryInterpolatedString(i, l); err != nil {
+				return false, fmt.Errorf("label %v interpolation error: %w", m.labelNames[j], err)
+			}
+		}
+		labelValues, overflowed = m.guardCardinality(labelValues)
+	}
+
+	switch m.mType {
+	case "counter", "counter_by":
+		m.counter.Incr(value, labelValues...)
+	case "gauge":
+		m.gauge.Set(value, labelValues...)
+	case "timing":
+		m.timer.Timing(value, labelValues...)
+	}
+	return overflowed, nil
+}
+
+type extractMetricsProc struct {
+	metrics []*extractedMetric
+	log     *service.Logger
+}
+
+func newExtractMetricsFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*extractMetricsProc, error) {
+	metricConfs, err := conf.FieldObjectList(emFieldMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &extractMetricsProc{log: mgr.Logger()}
+	for i, mConf := range metricConfs {
+		m, err := extractedMetricFromParsed(mConf, mgr)
+		if err != nil {
+			return nil, fmt.Errorf("metric %v: %w", i, err)
+		}
+		p.metrics = append(p.metrics, m)
+	}
+	return p, nil
+}
+
+func (p *extractMetricsProc) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	for i := range batch {
+		for _, m := range p.metrics {
+			overflowed, err := m.emit(batch, i)
+			if err != nil {
+				p.log.Errorf("Failed to emit metric %v: %v", m.name, err)
+				continue
+			}
+			if overflowed {
+				p.log.Warnf("Metric %v has reached its max cardinality of %v, new label values are being replaced with '%v'", m.name, m.maxCardinality, m.overflowLabelValue)
+			}
+		}
+	}
+	return []service.MessageBatch{batch}, nil
+}
+
+func (p *extractMetricsProc) Close(ctx context.Context) error {
+	return nil
+}
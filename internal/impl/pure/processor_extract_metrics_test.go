@@ -0,0 +1,122 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testExtractMetricsProc(t *testing.T, confStr string) (*extractMetricsProc, *metrics.Local) {
+	t.Helper()
+
+	conf, err := extractMetricsProcConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	proc, err := newExtractMetricsFromParsed(conf, service.MockResources(func(m *mock.Manager) {
+		m.M = stats
+	}))
+	require.NoError(t, err)
+	return proc, stats
+}
+
+func TestExtractMetrics(t *testing.T) {
+	proc, stats := testExtractMetricsProc(t, `
+metrics:
+  - name: orders
+    type: counter
+    check: this.type == "order"
+    labels:
+      region: ${! this.region }
+  - name: order_items
+    type: counter_by
+    value: root = this.items.or(deleted())
+  - name: stock
+    type: gauge
+    value: root = this.stock
+  - name: latency
+    type: timing
+    value: root = this.latency
+`)
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`{"type":"order","region":"eu","items":3,"stock":-2,"latency":"2ms"}`)),
+		service.NewMessage([]byte(`{"type":"order","region":"us","items":2,"stock":5,"latency":10}`)),
+		service.NewMessage([]byte(`{"type":"order","region":"eu","stock":"nope","latency":-1}`)),
+		service.NewMessage([]byte(`{"type":"refund","region":"eu","items":-1}`)),
+		service.NewMessage([]byte(`not structured`)),
+	}
+
+	res, err := proc.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 5)
+
+	for i, msg := range res[0] {
+		assert.NoError(t, msg.GetError(), i)
+	}
+	b, err := res[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"order","region":"eu","items":3,"stock":-2,"latency":"2ms"}`, string(b))
+
+	assert.Equal(t, map[string]int64{
+		`orders{region="eu"}`: 2,
+		`orders{region="us"}`: 1,
+		"order_items":         5,
+		"stock":               5,
+	}, stats.GetCounters())
+
+	timing := stats.GetTimings()["latency"]
+	require.NotNil(t, timing)
+	assert.Equal(t, int64(2), timing.Count())
+	assert.Equal(t, int64(2_000_000), timing.Max())
+	assert.Equal(t, int64(10), timing.Min())
+}
+
+func TestExtractMetricsCardinality(t *testing.T) {
+	proc, stats := testExtractMetricsProc(t, `
+metrics:
+  - name: users
+    type: counter
+    labels:
+      user: ${! this.user }
+      app: foo
+    max_cardinality: 2
+    overflow_label_value: overflow
+`)
+
+	batch := service.MessageBatch{}
+	for _, u := range []string{"a", "b", "c", "a", "d", "b"} {
+		batch = append(batch, service.NewMessage([]byte(`{"user":"`+u+`"}`)))
+	}
+
+	_, err := proc.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int64{
+		`users{app="foo",user="a"}`:             2,
+		`users{app="foo",user="b"}`:             2,
+		`users{app="overflow",user="overflow"}`: 2,
+	}, stats.GetCounters())
+}
+
+func TestExtractMetricsConfigErrors(t *testing.T) {
+	conf, err := extractMetricsProcConfig().ParseYAML(`
+metrics:
+  - name: foo
+    type: counter
+  - name: bar
+    type: gauge
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newExtractMetricsFromParsed(conf, service.MockResources())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "metric 1: a value is required for metrics of type gauge")
+}
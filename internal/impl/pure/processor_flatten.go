@@ -0,0 +1,244 @@
+package pure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	flFieldSeparator       = "separator"
+	flFieldPrefix          = "prefix"
+	flFieldArrays          = "arrays"
+	flFieldMaxDepth        = "max_depth"
+	flFieldIncludeEmpty    = "include_empty"
+	flFieldEncodeRemaining = "encode_remaining"
+)
+
+func flattenProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Mapping", "Utility").
+		Version("4.20.0").
+		Summary("Flattens a structured message into a single level object where each key is the path of a value within the original structure, which is useful before writing to column stores and flat formats such as CSV.").
+		Description(`
+Objects are flattened by joining the keys of nested fields with the `+"`separator`"+`, and arrays are flattened according to the `+"`arrays`"+` policy. For example, with the default settings the document `+"`{\"a\":{\"b\":1,\"c\":[\"x\",\"y\"]}}`"+` becomes `+"`{\"a.b\":1,\"a.c.0\":\"x\",\"a.c.1\":\"y\"}`"+`.
+
+Structured values that are not flattened, which includes arrays with the `+"`keep`"+` policy and values nested deeper than the `+"`max_depth`"+`, are kept as they are unless `+"`encode_remaining`"+` is set, in which case they are encoded as JSON strings so that every value of the resulting object is a scalar.
+
+If a flattened key collides with another, which can happen when keys of the original document contain the `+"`separator`"+`, the message is left unchanged and flagged with an error, which can be handled using the methods outlined [here](/docs/configuration/error_handling). Flattened documents can be restored with the `+"[`unflatten` processor](/docs/components/processors/unflatten)"+`.`).
+		Fields(
+			service.NewStringField(flFieldSeparator).
+				Description("The separator to join the keys of nested fields with.").
+				Default("."),
+			service.NewStringField(flFieldPrefix).
+				Description("A prefix to add to every flattened key.").
+				Example("event_").
+				Default(""),
+			service.NewStringAnnotatedEnumField(flFieldArrays, map[string]string{
+				"index":    "Array elements are flattened with their index as a key, e.g. `foo.0.bar`.",
+				"brackets": "Array elements are flattened with their index within square brackets, e.g. `foo[0].bar`.",
+				"keep":     "Arrays are not flattened and are kept as values.",
+			}).
+				Description("How to flatten arrays.").
+				Default("index"),
+			service.NewIntField(flFieldMaxDepth).
+				Description("The maximum number of path segments of a flattened key, where structured values nested any deeper are not flattened. Set to `0` for no limit.").
+				Default(0),
+			service.NewBoolField(flFieldIncludeEmpty).
+				Description("Whether to include empty objects and arrays as values of the flattened object, otherwise they are omitted.").
+				Default(false),
+			service.NewBoolField(flFieldEncodeRemaining).
+				Description("Whether to encode structured values that are not flattened as JSON strings.").
+				Default(false),
+		).
+		Example(
+			"Writing Nested Documents to a Column Store",
+			"In this example nested documents are flattened into column names with an underscore separator before being inserted into a ClickHouse table, where arrays of tags are kept as JSON strings within a single column.",
+			`
+pipeline:
+  processors:
+    - flatten:
+        separator: _
+        arrays: keep
+        encode_remaining: true
+
+output:
+  sql_insert:
+    driver: clickhouse
+    dsn: clickhouse://localhost:9000
+    table: events
+    columns: [ id, user_id, user_country, tags ]
+    args_mapping: |
+      root = [
+        this.id,
+        this.user_id,
+        this.user_country,
+        this.tags,
+      ]
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"flatten", flattenProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newFlattenFromParsed(conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type flattenProc struct {
+	separator       string
+	prefix          string
+	arrays          string
+	maxDepth        int
+	includeEmpty    bool
+	encodeRemaining bool
+}
+
+func newFlattenFromParsed(conf *service.ParsedConfig) (*flattenProc, error) {
+	f := &flattenProc{}
+
+	var err error
+	if f.separator, err = conf.FieldString(flFieldSeparator); err != nil {
+		return nil, err
+	}
+	if f.separator == "" {
+		return nil, fmt.Errorf("field %v must not be empty", flFieldSeparator)
+	}
+	if f.prefix, err = conf.FieldString(flFieldPrefix); err != nil {
+		return nil, err
+	}
+	if f.arrays, err = conf.FieldString(flFieldArrays); err != nil {
+		return nil, err
+	}
+	if f.maxDepth, err = conf.FieldInt(flFieldMaxDepth); err != nil {
+		return nil, err
+	}
+	if f.maxDepth < 0 {
+		return nil, fmt.Errorf("field %v must not be negative", flFieldMaxDepth)
+	}
+	if f.includeEmpty, err = conf.FieldBool(flFieldIncludeEmpty); err != nil {
+		return nil, err
+	}
+	if f.encodeRemaining, err = conf.FieldBool(flFieldEncodeRemaining); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *flattenProc) flatten(v any) (map[string]any, error) {
+	flat := map[string]any{}
+
+	add := func(path string, v any) error {
+		key := f.prefix + path
+		if _, exists := flat[key]; exists {
+			return fmt.Errorf("flattened key '%v' collides with another key", key)
+		}
+		if f.encodeRemaining {
+			switch v.(type) {
+			case map[string]any, []any:
+				b, err := json.Marshal(v)
+				if err != nil {
+					return err
+				}
+				v = string(b)
+			}
+		}
+		flat[key] = v
+		return nil
+	}
+
+	var walk func(path string, depth int, v any) error
+	walk = func(path string, depth int, v any) error {
+		if path != "" && f.maxDepth > 0 && depth >= f.maxDepth {
+			return add(path, v)
+		}
+		switch t := v.(type) {
+		case map[string]any:
+			if len(t) == 0 {
+				if path != "" && f.includeEmpty {
+					return add(path, t)
+				}
+				return nil
+			}
+			for k, child := range t {
+				childPath := k
+				if path != "" {
+					childPath = path + f.separator + k
+				}
+				if err := walk(childPath, depth+1, child); err != nil {
+					return err
+				}
+			}
+		case []any:
+			if f.arrays == "keep" {
+				if path == "" {
+					return errors.New("expected an object at the root of the message, got an array")
+				}
+				if len(t) > 0 || f.includeEmpty {
+					return add(path, t)
+				}
+				return nil
+			}
+			if len(t) == 0 {
+				if path != "" && f.includeEmpty {
+					return add(path, t)
+				}
+				return nil
+			}
+			for i, child := range t {
+				var childPath string
+				switch {
+				case f.arrays == "brackets":
+					childPath = path + "[" + strconv.Itoa(i) + "]"
+				case path == "":
+					childPath = strconv.Itoa(i)
+				default:
+					childPath = path + f.separator + strconv.Itoa(i)
+				}
+				if err := walk(childPath, depth+1, child); err != nil {
+					return err
+				}
+			}
+		default:
+			if path == "" {
+				return fmt.Errorf("expected an object or array at the root of the message, got %T", v)
+			}
+			return add(path, v)
+		}
+		return nil
+	}
+
+	if err := walk("", 0, v); err != nil {
+		return nil, err
+	}
+	return flat, nil
+}
+
+func (f *flattenProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	v, err := msg.AsStructured()
+	if err != nil {
+		return nil, err
+	}
+	flat, err := f.flatten(v)
+	if err != nil {
+		return nil, err
+	}
+	msg.SetStructuredMut(flat)
+	return service.MessageBatch{msg}, nil
+}
+
+func (f *flattenProc) Close(ctx context.Context) error {
+	return nil
+}
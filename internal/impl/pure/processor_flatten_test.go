@@ -0,0 +1,109 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name        string
+		conf        string
+		input       string
+		output      string
+		errContains string
+	}{
+		{
+			name:   "defaults",
+			input:  `{"a":{"b":1,"c":["x",{"d":"y"}],"e":{},"f":[]},"g":null}`,
+			output: `{"a.b":1,"a.c.0":"x","a.c.1.d":"y","g":null}`,
+		},
+		{
+			name: "include empty",
+			conf: `
+include_empty: true
+`,
+			input:  `{"a":{"e":{},"f":[]}}`,
+			output: `{"a.e":{},"a.f":[]}`,
+		},
+		{
+			name: "brackets with prefix and separator",
+			conf: `
+separator: _
+prefix: ev_
+arrays: brackets
+`,
+			input:  `{"a":{"c":[["x"],{"d":"y"}]}}`,
+			output: `{"ev_a_c[0][0]":"x","ev_a_c[1]_d":"y"}`,
+		},
+		{
+			name: "keep arrays encoded",
+			conf: `
+arrays: keep
+encode_remaining: true
+`,
+			input:  `{"a":{"tags":["x","y"],"b":true}}`,
+			output: `{"a.b":true,"a.tags":"[\"x\",\"y\"]"}`,
+		},
+		{
+			name: "max depth",
+			conf: `
+max_depth: 2
+`,
+			input:  `{"a":{"b":{"c":1},"d":[1,2]},"e":1}`,
+			output: `{"a.b":{"c":1},"a.d":[1,2],"e":1}`,
+		},
+		{
+			name:   "root array",
+			input:  `[{"a":1},{"a":2}]`,
+			output: `{"0.a":1,"1.a":2}`,
+		},
+		{
+			name: "root array kept",
+			conf: `
+arrays: keep
+`,
+			input:       `[{"a":1}]`,
+			errContains: "expected an object at the root of the message",
+		},
+		{
+			name:        "collision",
+			input:       `{"a.b":1,"a":{"b":2}}`,
+			errContains: "flattened key 'a.b' collides with another key",
+		},
+		{
+			name:        "scalar",
+			input:       `"nope"`,
+			errContains: "expected an object or array",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := flattenProcConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			proc, err := newFlattenFromParsed(conf)
+			require.NoError(t, err)
+
+			res, err := proc.Process(context.Background(), service.NewMessage([]byte(test.input)))
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+
+			b, err := res[0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(b))
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func parseEDIProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Parsing").
+		Version("4.20.0").
+		Summary("Parses X12, EDIFACT and HL7v2 messages into structured segments.").
+		Description(`
+Each message is parsed into an object with a `+"`segments`"+` array, where each segment has an `+"`id`"+`, such as `+"`ISA`"+`, `+"`UNH`"+` or `+"`PID`"+`, and an array of `+"`elements`"+`. Elements are numbered from one in specifications, and therefore the element `+"`PID-5`"+` of an HL7 message is found at the index `+"`4`"+` of the elements array.
+
+Elements without components are strings, and elements with components are arrays of their components. For HL7 a component with subcomponents is itself an array of subcomponents. Repeated elements are represented as an object with a single field `+"`repetitions`"+`, containing an array of each repetition.
+
+For example, the HL7 segment `+"`PID|1||123^^^HOSP~456^^^CLINIC||Doe^John`"+` is parsed into:
+
+`+"```json"+`
+{"id":"PID","elements":["1","",{"repetitions":[["123","","","HOSP"],["456","","","CLINIC"]]},"",["Doe","John"]]}
+`+"```"+`
+
+Delimiters are detected from the `+"`ISA`"+` segment of X12 interchanges, the `+"`UNA`"+` service string advice of EDIFACT interchanges and the `+"`MSH`"+` segment of HL7 messages, and the standard delimiters are used when an X12 or EDIFACT document does not begin with these. Release characters of EDIFACT and escape sequences of HL7 are resolved within values. The separators within the `+"`ISA`"+` and `+"`MSH`"+` segments are kept as plain strings, and the `+"`UNA`"+` service string advice is not included within the segments.`).
+		Field(service.NewStringAnnotatedEnumField("format", map[string]string{
+			"x12":     "ANSI ASC X12 interchanges.",
+			"edifact": "UN/EDIFACT interchanges.",
+			"hl7":     "HL7 version 2 messages.",
+		}).Description("The format of messages.")).
+		Example(
+			"Route HL7 Messages by Event",
+			"Parse HL7 messages and store the message type and trigger event from the `MSH-9` field as metadata, which can be used to route messages to different outputs.",
+			`
+pipeline:
+  processors:
+    - parse_edi:
+        format: hl7
+    - mapping: |
+        let msh = this.segments.index(0)
+        meta hl7_type = $msh.elements.index(8).index(0)
+        meta hl7_event = $msh.elements.index(8).index(1)
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"parse_edi", parseEDIProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			format, err := conf.FieldString("format")
+			if err != nil {
+				return nil, err
+			}
+			return newParseEDI(format)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ediDelims are the delimiters of a document, where optional delimiters are
+// zero when absent.
+type ediDelims struct {
+	segment      rune
+	element      rune
+	component    rune
+	repetition   rune
+	subcomponent rune
+	release      rune // EDIFACT only
+	escape       rune // HL7 only
+}
+
+type ediParser func(data string) ([]any, error)
+
+type parseEDIProc struct {
+	parse ediParser
+}
+
+func newParseEDI(format string) (*parseEDIProc, error) {
+	var p ediParser
+	switch format {
+	case "x12":
+		p = parseX12
+	case "edifact":
+		p = parseEDIFACT
+	case "hl7":
+		p = parseHL7
+	default:
+		return nil, fmt.Errorf("format not recognised: %v", format)
+	}
+	return &parseEDIProc{parse: p}, nil
+}
+
+func (p *parseEDIProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	data, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := p.parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	msg.SetStructuredMut(map[string]any{
+		"segments": segments,
+	})
+	return service.MessageBatch{msg}, nil
+}
+
+func (p *parseEDIProc) Close(ctx context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func parseX12(data string) ([]any, error) {
+	data = strings.TrimLeftFunc(data, unicode.IsSpace)
+
+	d := ediDelims{segment: '~', element: '*', component: ':'}
+	if strings.HasPrefix(data, "ISA") {
+		if len(data) < 106 {
+			return nil, errors.New("ISA segment is truncated")
+		}
+		d.element, d.component, d.segment = rune(data[3]), rune(data[104]), rune(data[105])
+		// The repetition separator is only present from version 00402, where
+		// earlier versions use a code in its place.
+		if rep := rune(data[82]); !unicode.IsLetter(rep) && !unicode.IsDigit(rep) {
+			d.repetition = rep
+		}
+	}
+
+	return d.parseSegments(data, func(id string, elements []string) []any {
+		if id == "ISA" {
+			return ediRawElements(elements)
+		}
+		return nil
+	})
+}
+
+func parseEDIFACT(data string) ([]any, error) {
+	data = strings.TrimLeftFunc(data, unicode.IsSpace)
+
+	d := ediDelims{segment: '\'', element: '+', component: ':', release: '?'}
+	if strings.HasPrefix(data, "UNA") {
+		una := []rune(data)
+		if len(una) < 9 {
+			return nil, errors.New("UNA service string advice is truncated")
+		}
+		d.component, d.element, d.release, d.segment = una[3], una[4], una[6], una[8]
+		if d.release == ' ' {
+			d.release = 0
+		}
+		data = string(una[9:])
+	}
+
+	return d.parseSegments(data, nil)
+}
+
+func parseHL7(data string) ([]any, error) {
+	data = strings.TrimLeftFunc(data, unicode.IsSpace)
+	data = strings.ReplaceAll(strings.ReplaceAll(data, "\r\n", "\r"), "\n", "\r")
+
+	if !strings.HasPrefix(data, "MSH") && !strings.HasPrefix(data, "FHS") && !strings.HasPrefix(data, "BHS") {
+		return nil, errors.New("message must begin with an MSH, FHS or BHS segment")
+	}
+	header := []rune(data)
+	if len(header) < 5 {
+		return nil, errors.New("header segment is truncated")
+	}
+
+	d := ediDelims{segment: '\r', element: header[3]}
+	for i, r := range header[4:] {
+		if r == d.element || r == d.segment {
+			break
+		}
+		switch i {
+		case 0:
+			d.component = r
+		case 1:
+			d.repetition = r
+		case 2:
+			d.escape = r
+		case 3:
+			d.subcomponent = r
+		}
+	}
+
+	return d.parseSegments(data, func(id string, elements []string) []any {
+		if id != "MSH" && id != "FHS" && id != "BHS" {
+			return nil
+		}
+		// The first element of header segments is the element separator itself,
+		// followed by the encoding characters, neither of which are parsed.
+		res := []any{string(d.element)}
+		if len(elements) > 0 {
+			res = append(res, elements[0])
+			res = append(res, d.parseElements(elements[1:])...)
+		}
+		return res
+	})
+}
+
+func ediRawElements(elements []string) []any {
+	res := make([]any, len(elements))
+	for i, e := range elements {
+		res[i] = e
+	}
+	return res
+}
+
+//------------------------------------------------------------------------------
+
+// split divides a string by a separator, ignoring separators preceded by the
+// release character, which are kept in place for further splitting.
+func (d *ediDelims) split(s string, sep rune) []string {
+	if sep == 0 {
+		return []string{s}
+	}
+
+	var parts []string
+	start := 0
+	for i, w := 0, 0; i < len(s); i += w {
+		var r rune
+		r, w = utf8.DecodeRuneInString(s[i:])
+		switch {
+		case d.release != 0 && r == d.release:
+			_, rw := utf8.DecodeRuneInString(s[i+w:])
+			w += rw
+		case r == sep:
+			parts = append(parts, s[start:i])
+			start = i + w
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unescape resolves release characters and escape sequences within a value.
+func (d *ediDelims) unescape(s string) string {
+	if d.release != 0 && strings.ContainsRune(s, d.release) {
+		var b strings.Builder
+		released := false
+		for _, r := range s {
+			if r == d.release && !released {
+				released = true
+				continue
+			}
+			released = false
+			b.WriteRune(r)
+		}
+		return b.String()
+	}
+
+	if d.escape != 0 && strings.ContainsRune(s, d.escape) {
+		esc := string(d.escape)
+		replacements := map[string]rune{
+			"F": d.element,
+			"S": d.component,
+			"T": d.subcomponent,
+			"R": d.repetition,
+			"E": d.escape,
+		}
+
+		var b strings.Builder
+		for {
+			start := strings.Index(s, esc)
+			if start == -1 {
+				break
+			}
+			end := strings.Index(s[start+len(esc):], esc)
+			if end == -1 {
+				break
+			}
+			seq := s[start+len(esc) : start+len(esc)+end]
+			b.WriteString(s[:start])
+			if r, exists := replacements[seq]; exists {
+				b.WriteRune(r)
+			} else {
+				// Formatting and hexadecimal sequences are left as they are.
+				b.WriteString(s[start : start+2*len(esc)+end])
+			}
+			s = s[start+2*len(esc)+end:]
+		}
+		b.WriteString(s)
+		return b.String()
+	}
+	return s
+}
+
+func (d *ediDelims) parseComponent(s string) any {
+	if d.subcomponent == 0 {
+		return d.unescape(s)
+	}
+	subs := d.split(s, d.subcomponent)
+	if len(subs) == 1 {
+		return d.unescape(s)
+	}
+	res := make([]any, len(subs))
+	for i, sub := range subs {
+		res[i] = d.unescape(sub)
+	}
+	return res
+}
+
+func (d *ediDelims) parseComposite(s string) any {
+	components := d.split(s, d.component)
+	if len(components) == 1 {
+		c := d.parseComponent(s)
+		if _, isArr := c.([]any); isArr {
+			return []any{c}
+		}
+		return c
+	}
+	res := make([]any, len(components))
+	for i, c := range components {
+		res[i] = d.parseComponent(c)
+	}
+	return res
+}
+
+func (d *ediDelims) parseElements(elements []string) []any {
+	res := make([]any, len(elements))
+	for i, e := range elements {
+		reps := d.split(e, d.repetition)
+		if len(reps) == 1 {
+			res[i] = d.parseComposite(e)
+			continue
+		}
+		values := make([]any, len(reps))
+		for j, r := range reps {
+			values[j] = d.parseComposite(r)
+		}
+		res[i] = map[string]any{"repetitions": values}
+	}
+	return res
+}
+
+// parseSegments splits a document into segments, where an optional special
+// function may return the elements of specific segments in place of the
+// standard parsing.
+func (d *ediDelims) parseSegments(data string, special func(id string, elements []string) []any) ([]any, error) {
+	var segments []any
+	for _, raw := range d.split(data, d.segment) {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+
+		parts := d.split(raw, d.element)
+		id := parts[0]
+		if id == "" {
+			return nil, fmt.Errorf("segment %v is missing an identifier", len(segments)+1)
+		}
+
+		var elements []any
+		if special != nil {
+			elements = special(id, parts[1:])
+		}
+		if elements == nil {
+			elements = d.parseElements(parts[1:])
+		}
+
+		segments = append(segments, map[string]any{
+			"id":       id,
+			"elements": elements,
+		})
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("no segments were found")
+	}
+	return segments, nil
+}
@@ -0,0 +1,117 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testParseEDI(t *testing.T, format, input string) any {
+	t.Helper()
+
+	proc, err := newParseEDI(format)
+	require.NoError(t, err)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(input)))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	v, err := batch[0].AsStructured()
+	require.NoError(t, err)
+	return v
+}
+
+func ediSeg(id string, elements ...any) any {
+	if elements == nil {
+		elements = []any{}
+	}
+	return map[string]any{"id": id, "elements": elements}
+}
+
+func TestParseEDIX12(t *testing.T) {
+	input := "ISA*00*          *00*          *ZZ*SENDER         *ZZ*RECEIVER       *230101*1200*^*00501*000000001*0*P*>~\n" +
+		"GS*PO*SENDER*RECEIVER*20230101*1200*1*X*005010~\n" +
+		"PO1*1*10*EA*9.99**BP*ABC>123*VP*X^Y~\n" +
+		"IEA*1*000000001~\n"
+
+	assert.Equal(t, map[string]any{
+		"segments": []any{
+			ediSeg("ISA", "00", "          ", "00", "          ", "ZZ", "SENDER         ", "ZZ", "RECEIVER       ", "230101", "1200", "^", "00501", "000000001", "0", "P", ">"),
+			ediSeg("GS", "PO", "SENDER", "RECEIVER", "20230101", "1200", "1", "X", "005010"),
+			ediSeg("PO1", "1", "10", "EA", "9.99", "", "BP", []any{"ABC", "123"}, "VP", map[string]any{"repetitions": []any{"X", "Y"}}),
+			ediSeg("IEA", "1", "000000001"),
+		},
+	}, testParseEDI(t, "x12", input))
+
+	assert.Equal(t, map[string]any{
+		"segments": []any{
+			ediSeg("ST", "850", []any{"0001", "A"}),
+			ediSeg("SE", "2", "0001"),
+		},
+	}, testParseEDI(t, "x12", "ST*850*0001:A~SE*2*0001~"))
+}
+
+func TestParseEDIEDIFACT(t *testing.T) {
+	input := "UNA:+.? '\r\nUNB+UNOA:1+SENDER+RECEIVER+230101:1200+1'\r\nFTX+AAA+++It?'s 5?+5?:1'\r\nUNZ+1+1'"
+
+	assert.Equal(t, map[string]any{
+		"segments": []any{
+			ediSeg("UNB", []any{"UNOA", "1"}, "SENDER", "RECEIVER", []any{"230101", "1200"}, "1"),
+			ediSeg("FTX", "AAA", "", "", "It's 5+5:1"),
+			ediSeg("UNZ", "1", "1"),
+		},
+	}, testParseEDI(t, "edifact", input))
+
+	assert.Equal(t, map[string]any{
+		"segments": []any{
+			ediSeg("UNH", "1", []any{"ORDERS", "D", "96A", "UN"}),
+		},
+	}, testParseEDI(t, "edifact", "UNH+1+ORDERS:D:96A:UN'"))
+}
+
+func TestParseEDIHL7(t *testing.T) {
+	input := "MSH|^~\\&|APP|FAC|||20230101||ADT^A01|123|P|2.5\r\n" +
+		"PID|1||123^^^HOSP~456^^^CLINIC||Doe^John||||||1 Main St\\F\\Apt 2^^Town&Region\r\n" +
+		"NTE\n"
+
+	assert.Equal(t, map[string]any{
+		"segments": []any{
+			ediSeg("MSH", "|", "^~\\&", "APP", "FAC", "", "", "20230101", "", []any{"ADT", "A01"}, "123", "P", "2.5"),
+			ediSeg("PID", "1", "",
+				map[string]any{"repetitions": []any{
+					[]any{"123", "", "", "HOSP"},
+					[]any{"456", "", "", "CLINIC"},
+				}},
+				"", []any{"Doe", "John"}, "", "", "", "", "",
+				[]any{"1 Main St|Apt 2", "", []any{"Town", "Region"}},
+			),
+			ediSeg("NTE"),
+		},
+	}, testParseEDI(t, "hl7", input))
+}
+
+func TestParseEDIErrors(t *testing.T) {
+	for _, test := range []struct {
+		format string
+		input  string
+		errStr string
+	}{
+		{format: "x12", input: "ISA*00*", errStr: "ISA segment is truncated"},
+		{format: "edifact", input: "  ", errStr: "no segments were found"},
+		{format: "hl7", input: "PID|1", errStr: "message must begin with an MSH, FHS or BHS segment"},
+		{format: "x12", input: "ST*850~*1~", errStr: "segment 2 is missing an identifier"},
+	} {
+		proc, err := newParseEDI(test.format)
+		require.NoError(t, err)
+
+		_, err = proc.Process(context.Background(), service.NewMessage([]byte(test.input)))
+		assert.EqualError(t, err, test.errStr, test.input)
+	}
+
+	_, err := newParseEDI("nope")
+	assert.Error(t, err)
+}
@@ -0,0 +1,207 @@
+package pure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	pfwFieldFields       = "fields"
+	pfwFieldFieldName    = "name"
+	pfwFieldFieldOffset  = "offset"
+	pfwFieldFieldLength  = "length"
+	pfwFieldFieldType    = "type"
+	pfwFieldTrim         = "trim"
+	pfwFieldSkipPrefixes = "skip_prefixes"
+)
+
+func parseFixedWidthProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Parsing").
+		Version("4.20.0").
+		Summary("Parses records of fixed width fields into structured objects, where each line of a message becomes a new message.").
+		Description(`
+Fields are extracted from each line by their position, which is measured in characters. The `+"`offset`"+` of a field defaults to the end of the previous field, so a contiguous layout only requires the length of each field. Fields that begin beyond the end of a short line are set to `+"`null`"+`, and fields that extend beyond the end of a line are truncated.
+
+Values are coerced into the `+"`type`"+` of their field, where empty values of fields that are not strings become `+"`null`"+`. Empty lines are skipped.`).
+		Fields(
+			service.NewObjectListField(pfwFieldFields,
+				service.NewStringField(pfwFieldFieldName).
+					Description("The key of the field within the resulting object."),
+				service.NewIntField(pfwFieldFieldOffset).
+					Description("The zero based position of the first character of the field, which defaults to the end of the previous field.").
+					Optional(),
+				service.NewIntField(pfwFieldFieldLength).
+					Description("The number of characters of the field."),
+				service.NewStringEnumField(pfwFieldFieldType, "string", "int", "float", "bool").
+					Description("The type to coerce values of the field into.").
+					Default("string"),
+			).
+				Description("The fields of each record."),
+			service.NewBoolField(pfwFieldTrim).
+				Description("Whether to remove leading and trailing whitespace from string values, which is commonly used to pad fields.").
+				Default(true),
+			service.NewStringListField(pfwFieldSkipPrefixes).
+				Description("Lines beginning with any of these prefixes are skipped, which is useful for ignoring header and trailer records.").
+				Default([]string{}).
+				Advanced(),
+		).
+		Example(
+			"Bank Statement Records",
+			"Parse a fixed width export where each detail record consists of an account number, a padded name and an amount in cents, skipping the header and trailer records.",
+			`
+pipeline:
+  processors:
+    - parse_fixed_width:
+        skip_prefixes: [ HDR, TRL ]
+        fields:
+          - name: account
+            length: 10
+          - name: name
+            length: 20
+          - name: amount_cents
+            length: 12
+            type: int
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"parse_fixed_width", parseFixedWidthProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newParseFixedWidthFromParsed(conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type fixedWidthField struct {
+	name   string
+	offset int
+	length int
+	coerce func(string) (any, error)
+}
+
+type parseFixedWidthProc struct {
+	fields       []fixedWidthField
+	trim         bool
+	skipPrefixes []string
+}
+
+func newParseFixedWidthFromParsed(conf *service.ParsedConfig) (*parseFixedWidthProc, error) {
+	fieldConfs, err := conf.FieldObjectList(pfwFieldFields)
+	if err != nil {
+		return nil, err
+	}
+	if len(fieldConfs) == 0 {
+		return nil, errors.New("at least one field must be specified")
+	}
+
+	p := &parseFixedWidthProc{}
+	nextOffset := 0
+	for _, fConf := range fieldConfs {
+		var f fixedWidthField
+		if f.name, err = fConf.FieldString(pfwFieldFieldName); err != nil {
+			return nil, err
+		}
+
+		f.offset = nextOffset
+		if fConf.Contains(pfwFieldFieldOffset) {
+			if f.offset, err = fConf.FieldInt(pfwFieldFieldOffset); err != nil {
+				return nil, err
+			}
+		}
+		if f.length, err = fConf.FieldInt(pfwFieldFieldLength); err != nil {
+			return nil, err
+		}
+		if f.offset < 0 || f.length < 1 {
+			return nil, fmt.Errorf("field %v: offset must not be negative and length must be at least 1", f.name)
+		}
+		nextOffset = f.offset + f.length
+
+		typeStr, err := fConf.FieldString(pfwFieldFieldType)
+		if err != nil {
+			return nil, err
+		}
+		if f.coerce, err = valueCoercer(typeStr); err != nil {
+			return nil, fmt.Errorf("field %v: %w", f.name, err)
+		}
+		p.fields = append(p.fields, f)
+	}
+
+	if p.trim, err = conf.FieldBool(pfwFieldTrim); err != nil {
+		return nil, err
+	}
+	if p.skipPrefixes, err = conf.FieldStringList(pfwFieldSkipPrefixes); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parseFixedWidthProc) parseLine(line []rune) (map[string]any, error) {
+	obj := make(map[string]any, len(p.fields))
+	for _, f := range p.fields {
+		if f.offset >= len(line) {
+			obj[f.name] = nil
+			continue
+		}
+		end := f.offset + f.length
+		if end > len(line) {
+			end = len(line)
+		}
+
+		v := string(line[f.offset:end])
+		if p.trim {
+			v = strings.TrimSpace(v)
+		}
+
+		var err error
+		if obj[f.name], err = f.coerce(v); err != nil {
+			return nil, fmt.Errorf("field %v: %w", f.name, err)
+		}
+	}
+	return obj, nil
+}
+
+func (p *parseFixedWidthProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	data, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var out service.MessageBatch
+lines:
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			continue
+		}
+		for _, prefix := range p.skipPrefixes {
+			if bytes.HasPrefix(line, []byte(prefix)) {
+				continue lines
+			}
+		}
+
+		obj, err := p.parseLine([]rune(string(line)))
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", i+1, err)
+		}
+
+		part := msg.Copy()
+		part.SetStructuredMut(obj)
+		out = append(out, part)
+	}
+	return out, nil
+}
+
+func (p *parseFixedWidthProc) Close(ctx context.Context) error {
+	return nil
+}
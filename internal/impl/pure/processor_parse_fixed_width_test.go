@@ -0,0 +1,71 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestParseFixedWidth(t *testing.T) {
+	conf, err := parseFixedWidthProcConfig().ParseYAML(`
+skip_prefixes: [ HDR ]
+fields:
+  - name: account
+    length: 6
+  - name: name
+    length: 8
+  - name: amount
+    length: 5
+    type: int
+  - name: flag
+    offset: 20
+    length: 1
+    type: bool
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newParseFixedWidthFromParsed(conf)
+	require.NoError(t, err)
+
+	input := "HDR 2023-01-01\r\n" +
+		"000001Jöhn    00150 t\r\n" +
+		"\r\n" +
+		"000002Jane     0020\n" +
+		"000003Al"
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(input)))
+	require.NoError(t, err)
+
+	var res []any
+	for _, m := range batch {
+		v, err := m.AsStructured()
+		require.NoError(t, err)
+		res = append(res, v)
+	}
+	assert.Equal(t, []any{
+		map[string]any{"account": "000001", "name": "Jöhn", "amount": int64(150), "flag": true},
+		map[string]any{"account": "000002", "name": "Jane", "amount": int64(20), "flag": nil},
+		map[string]any{"account": "000003", "name": "Al", "amount": nil, "flag": nil},
+	}, res)
+
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte("000001John    nope")))
+	assert.EqualError(t, err, `line 1: field amount: strconv.ParseInt: parsing "nope": invalid syntax`)
+}
+
+func TestParseFixedWidthConfigErrors(t *testing.T) {
+	for _, confStr := range []string{
+		`fields: []`,
+		`fields: [ { name: foo, length: 0 } ]`,
+		`fields: [ { name: foo, offset: -1, length: 2 } ]`,
+	} {
+		conf, err := parseFixedWidthProcConfig().ParseYAML(confStr, nil)
+		require.NoError(t, err)
+
+		_, err = newParseFixedWidthFromParsed(conf)
+		assert.Error(t, err, confStr)
+	}
+}
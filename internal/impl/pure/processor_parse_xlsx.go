@@ -0,0 +1,214 @@
+package pure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	pxFieldSheets        = "sheets"
+	pxFieldHeaderRow     = "header_row"
+	pxFieldSkipEmptyRows = "skip_empty_rows"
+	pxFieldFormulas      = "formulas"
+)
+
+func parseXLSXProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Parsing").
+		Version("4.20.0").
+		Summary("Parses Excel (XLSX) workbooks into a message per row.").
+		Description(`
+The contents of each message are read as an XLSX workbook, and each row of its sheets becomes a new message. When `+"`header_row`"+` is `+"`true`"+` the first row of each sheet is used as the header and rows become objects keyed by it, where columns without a header are keyed by their column letter. Otherwise rows become arrays of cell values.
+
+Cells are typed: numbers become integers when they have no fractional part and floats otherwise, booleans become booleans, cells formatted as dates or times become RFC 3339 timestamps in UTC, and empty cells become `+"`null`"+`. Error values such as `+"`#DIV/0!`"+` become strings.
+
+### Formulas
+
+Formulas are not evaluated by this processor. By default the value of a formula cell is the result calculated and stored by the application that last saved the workbook. When `+"`formulas`"+` is set to `+"`text`"+` formula cells are instead given the text of their formula prefixed with `+"`=`"+`, apart from cells that share a formula defined in another cell, which keep their stored result.
+
+### Metadata
+
+This processor adds the following metadata fields to each message:
+
+`+"```text"+`
+- xlsx_sheet
+- xlsx_row
+`+"```"+`
+
+Where `+"`xlsx_row`"+` is the number of the row within its sheet, starting from one.`).
+		Fields(
+			service.NewStringListField(pxFieldSheets).
+				Description("The names of sheets to read, in order. When empty all sheets are read in the order of the workbook.").
+				Default([]string{}),
+			service.NewBoolField(pxFieldHeaderRow).
+				Description("Whether the first row of each sheet is a header row.").
+				Default(true),
+			service.NewBoolField(pxFieldSkipEmptyRows).
+				Description("Whether to skip rows where all cells are empty.").
+				Default(true).
+				Advanced(),
+			service.NewStringAnnotatedEnumField(pxFieldFormulas, map[string]string{
+				"value": "Use the stored result of formulas.",
+				"text":  "Use the text of formulas.",
+			}).
+				Description("How to read cells containing formulas.").
+				Default("value").
+				Advanced(),
+		).
+		Example(
+			"Partner Spreadsheets",
+			"Read workbooks uploaded to a bucket and write the rows of the `Orders` sheet as JSON documents.",
+			`
+input:
+  aws_s3:
+    bucket: partner-uploads
+    prefix: orders/
+    codec: all-bytes
+
+pipeline:
+  processors:
+    - parse_xlsx:
+        sheets: [ Orders ]
+    - mapping: |
+        root = this
+        root.source = "%s:%d".format(@s3_key, @xlsx_row)
+
+output:
+  file:
+    path: ./orders.jsonl
+    codec: lines
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"parse_xlsx", parseXLSXProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newParseXLSXFromParsed(conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type parseXLSXProc struct {
+	sheets        []string
+	headerRow     bool
+	skipEmptyRows bool
+	formulas      bool
+}
+
+func newParseXLSXFromParsed(conf *service.ParsedConfig) (p *parseXLSXProc, err error) {
+	p = &parseXLSXProc{}
+	if p.sheets, err = conf.FieldStringList(pxFieldSheets); err != nil {
+		return
+	}
+	if p.headerRow, err = conf.FieldBool(pxFieldHeaderRow); err != nil {
+		return
+	}
+	if p.skipEmptyRows, err = conf.FieldBool(pxFieldSkipEmptyRows); err != nil {
+		return
+	}
+	var formulas string
+	if formulas, err = conf.FieldString(pxFieldFormulas); err != nil {
+		return
+	}
+	p.formulas = formulas == "text"
+	return
+}
+
+func xlsxRowIsEmpty(row []any) bool {
+	for _, v := range row {
+		if v != nil && v != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parseXLSXProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	data, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := newXLSXWorkbook(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sheets := w.sheets
+	if len(p.sheets) > 0 {
+		sheets = make([]xlsxSheetRef, len(p.sheets))
+		for i, name := range p.sheets {
+			var exists bool
+			if sheets[i], exists = w.sheet(name); !exists {
+				return nil, fmt.Errorf("sheet %v was not found", name)
+			}
+		}
+	}
+
+	var out service.MessageBatch
+	for _, s := range sheets {
+		nums, rows, err := w.rows(s, p.formulas)
+		if err != nil {
+			return nil, err
+		}
+
+		var header []string
+		for i, row := range rows {
+			if p.skipEmptyRows && xlsxRowIsEmpty(row) {
+				continue
+			}
+			if p.headerRow && header == nil {
+				header = make([]string, len(row))
+				for j, v := range row {
+					if v == nil || v == "" {
+						header[j] = xlsxColumnName(j)
+					} else {
+						header[j] = fmt.Sprintf("%v", v)
+					}
+				}
+				continue
+			}
+
+			part := msg.Copy()
+			part.MetaSetMut("xlsx_sheet", s.name)
+			part.MetaSetMut("xlsx_row", int64(nums[i]))
+
+			if !p.headerRow {
+				if row == nil {
+					row = []any{}
+				}
+				part.SetStructuredMut(row)
+				out = append(out, part)
+				continue
+			}
+
+			obj := make(map[string]any, len(header))
+			for j, key := range header {
+				if j < len(row) {
+					obj[key] = row[j]
+				} else {
+					obj[key] = nil
+				}
+			}
+			for j := len(header); j < len(row); j++ {
+				if row[j] != nil {
+					obj[xlsxColumnName(j)] = row[j]
+				}
+			}
+			part.SetStructuredMut(obj)
+			out = append(out, part)
+		}
+	}
+	return out, nil
+}
+
+func (p *parseXLSXProc) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,166 @@
+package pure
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testXLSX(t *testing.T) []byte {
+	t.Helper()
+
+	files := map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="Orders" sheetId="1" r:id="rId1"/>
+    <sheet name="Notes" sheetId="2" r:id="rId2"/>
+  </sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <si><t>id</t></si>
+  <si><t>item</t></si>
+  <si><t>price</t></si>
+  <si><r><t>Blue </t></r><r><t>widget</t></r></si>
+</sst>`,
+		"xl/styles.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd\ hh:mm"/></numFmts>
+  <cellXfs count="3">
+    <xf numFmtId="0"/>
+    <xf numFmtId="14"/>
+    <xf numFmtId="164"/>
+  </cellXfs>
+</styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="E1" t="inlineStr"><is><t>when</t></is></c></row>
+    <row r="2"><c r="A2"><v>1</v></c><c r="B2" t="s"><v>3</v></c><c r="C2"><v>2.5</v></c><c r="D2" t="b"><v>1</v></c><c r="E2" s="1"><v>45000</v></c></row>
+    <row r="4"><c r="A4"><v>2</v></c><c r="C4"><f>C2*2</f><v>5</v></c><c r="E4" s="2"><v>45000.5</v></c><c r="F4" t="e"><v>#DIV/0!</v></c></row>
+  </sheetData>
+</worksheet>`,
+		"xl/worksheets/sheet2.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row><c t="inlineStr"><is><t>hello</t></is></c><c t="str"><f>"wor"&amp;"ld"</f><v>world</v></c></row>
+    <row></row>
+    <row><c><v>-3</v></c></row>
+  </sheetData>
+</worksheet>`,
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		fw, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+type xlsxTestRow struct {
+	sheet string
+	row   int64
+	value any
+}
+
+func testParseXLSX(t *testing.T, confStr string, data []byte) ([]xlsxTestRow, error) {
+	t.Helper()
+
+	conf, err := parseXLSXProcConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newParseXLSXFromParsed(conf)
+	require.NoError(t, err)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var res []xlsxTestRow
+	for _, m := range batch {
+		var r xlsxTestRow
+		sheet, _ := m.MetaGetMut("xlsx_sheet")
+		r.sheet = sheet.(string)
+		row, _ := m.MetaGetMut("xlsx_row")
+		r.row = row.(int64)
+		r.value, err = m.AsStructured()
+		require.NoError(t, err)
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func TestParseXLSXHeaderRow(t *testing.T) {
+	rows, err := testParseXLSX(t, `sheets: [ Orders ]`, testXLSX(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, []xlsxTestRow{
+		{sheet: "Orders", row: 2, value: map[string]any{
+			"id": int64(1), "item": "Blue widget", "price": 2.5, "D": true, "when": "2023-03-15T00:00:00Z",
+		}},
+		{sheet: "Orders", row: 4, value: map[string]any{
+			"id": int64(2), "item": nil, "price": int64(5), "D": nil, "when": "2023-03-15T12:00:00Z", "F": "#DIV/0!",
+		}},
+	}, rows)
+}
+
+func TestParseXLSXArrays(t *testing.T) {
+	rows, err := testParseXLSX(t, `
+header_row: false
+skip_empty_rows: false
+formulas: text
+`, testXLSX(t))
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+
+	assert.Equal(t, xlsxTestRow{
+		sheet: "Orders", row: 4, value: []any{int64(2), nil, "=C2*2", nil, "2023-03-15T12:00:00Z", "#DIV/0!"},
+	}, rows[2])
+	assert.Equal(t, []xlsxTestRow{
+		{sheet: "Notes", row: 1, value: []any{"hello", `="wor"&"ld"`}},
+		{sheet: "Notes", row: 2, value: []any{}},
+		{sheet: "Notes", row: 3, value: []any{int64(-3)}},
+	}, rows[3:])
+}
+
+func TestParseXLSXErrors(t *testing.T) {
+	_, err := testParseXLSX(t, `sheets: [ Nope ]`, testXLSX(t))
+	assert.EqualError(t, err, "sheet Nope was not found")
+
+	_, err = testParseXLSX(t, `{}`, []byte("not a workbook"))
+	assert.Error(t, err)
+}
+
+func TestXLSXHelpers(t *testing.T) {
+	for _, name := range []string{"A", "Z", "AA", "AZ", "BA", "XFD"} {
+		col, err := xlsxColumn(name + "12")
+		require.NoError(t, err)
+		assert.Equal(t, name, xlsxColumnName(col))
+	}
+	_, err := xlsxColumn("ZZZZ1")
+	assert.Error(t, err)
+
+	assert.True(t, xlsxIsDateFormat(`[$-409]d-mmm-yy;@`))
+	assert.True(t, xlsxIsDateFormat(`h:mm:ss AM/PM`))
+	assert.False(t, xlsxIsDateFormat(`#,##0.00 "days"`))
+	assert.False(t, xlsxIsDateFormat(`[Red]0.00`))
+	assert.False(t, xlsxIsDateFormat(`General`))
+}
@@ -0,0 +1,443 @@
+package pure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/interop"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	spFieldRatio                  = "ratio"
+	spFieldAction                 = "action"
+	spFieldDeferDuration          = "defer_duration"
+	spFieldFeedback               = "feedback"
+	spFieldFeedbackTargetLatency  = "target_latency"
+	spFieldFeedbackURL            = "url"
+	spFieldFeedbackInterval       = "interval"
+	spFieldFeedbackTimeout        = "timeout"
+	spFieldFeedbackMinRatio       = "min_ratio"
+	spFieldFeedbackIncreaseStep   = "increase_step"
+	spFieldFeedbackDecreaseFactor = "decrease_factor"
+)
+
+func sampleProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.20.0").
+		Summary("Admits a ratio of messages and either drops or defers the remainder, where the ratio can be adjusted at runtime via the HTTP API or driven by a downstream latency signal in order to shed load gracefully.").
+		Description(`
+Each message is admitted with a probability equal to the current ratio, where admitted messages continue unchanged. Messages that are not admitted are either dropped (filtered from the pipeline and acknowledged), or deferred, where the processor waits for the `+"`defer_duration`"+` before passing them on, which throttles the pipeline and applies back pressure to the input.
+
+### Feedback
+
+When a `+"`feedback`"+` block is configured the ratio is adjusted automatically in response to latency signals. Each time a latency larger than the `+"`target_latency`"+` is observed the ratio is multiplied by the `+"`decrease_factor`"+`, down to the `+"`min_ratio`"+`, and each time a latency within the target is observed the ratio is increased by the `+"`increase_step`"+`, up to the configured `+"`ratio`"+`. Latency signals are obtained by periodically sending a GET request to a `+"`url`"+`, such as the health check endpoint of a downstream service, where a failed request counts as exceeding the target. Signals can also be pushed to the HTTP API.
+
+### HTTP API
+
+When the processor has a label the current state of the sampler can be obtained by sending a GET request to the endpoint `+"`/sample/{label}`"+`, where `+"`{label}`"+` is the label of the processor. A POST request to the same endpoint accepts the following query parameters:
+
+- `+"`ratio`"+`: Pins the ratio to a given value, which is not changed by feedback until reset.
+- `+"`latency`"+`: Records a latency signal as a duration string such as `+"`250ms`"+`, which is used in the same way as probe latencies when feedback is configured.
+- `+"`reset=true`"+`: Restores the configured ratio and resumes feedback.
+
+### Metrics
+
+The gauge `+"`sample_ratio_percent`"+` is emitted with the current ratio as a percentage, and the counters `+"`sample_dropped`"+` and `+"`sample_deferred`"+` are incremented for each message that is not admitted.`).
+		Fields(
+			service.NewFloatField(spFieldRatio).
+				Description("The ratio of messages to admit, where `1` admits all messages and `0` admits none. When feedback is configured this is the maximum ratio.").
+				Default(1.0),
+			service.NewStringEnumField(spFieldAction, "drop", "defer").
+				Description("What to do with messages that are not admitted.").
+				Default("drop"),
+			service.NewDurationField(spFieldDeferDuration).
+				Description("The period of time to defer messages that are not admitted for when the `action` is `defer`.").
+				Default("1s"),
+			service.NewObjectField(spFieldFeedback,
+				service.NewDurationField(spFieldFeedbackTargetLatency).
+					Description("The latency above which the ratio is decreased."),
+				service.NewURLField(spFieldFeedbackURL).
+					Description("An optional URL to periodically send GET requests to, where the latency of each request is used as a signal. When omitted signals are only obtained from the HTTP API.").
+					Optional(),
+				service.NewDurationField(spFieldFeedbackInterval).
+					Description("The period of time between each request to the `url`.").
+					Default("5s"),
+				service.NewDurationField(spFieldFeedbackTimeout).
+					Description("The maximum period of time to wait for a request to the `url`.").
+					Default("5s").
+					Advanced(),
+				service.NewFloatField(spFieldFeedbackMinRatio).
+					Description("The lowest ratio that feedback can reduce the ratio to.").
+					Default(0.1),
+				service.NewFloatField(spFieldFeedbackIncreaseStep).
+					Description("The amount to increase the ratio by for each signal within the target latency.").
+					Default(0.1).
+					Advanced(),
+				service.NewFloatField(spFieldFeedbackDecreaseFactor).
+					Description("The factor to multiply the ratio by for each signal exceeding the target latency.").
+					Default(0.5).
+					Advanced(),
+			).
+				Description("Adjusts the ratio automatically in response to latency signals.").
+				Optional(),
+		).
+		Example(
+			"Shed Load Under Downstream Pressure",
+			"In this example we probe the health endpoint of the service we write to every five seconds, and when it takes longer than half a second to respond we progressively drop more low priority messages until it recovers.",
+			`
+pipeline:
+  processors:
+    - switch:
+        - check: this.priority == "low"
+          processors:
+            - label: low_priority_sampler
+              sample:
+                ratio: 1
+                feedback:
+                  target_latency: 500ms
+                  url: http://example.com/health
+                  interval: 5s
+                  min_ratio: 0.05
+
+output:
+  http_client:
+    url: http://example.com/post
+    verb: POST
+`,
+		).
+		Example(
+			"Manual Throttling",
+			"During an incident the ratio can be pinned via the HTTP API, e.g. `curl -X POST 'http://localhost:4195/sample/throttle?ratio=0.25'`, where in this example three quarters of messages are held for a second before continuing, slowing consumption without losing data.",
+			`
+pipeline:
+  processors:
+    - label: throttle
+      sample:
+        action: defer
+        defer_duration: 1s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"sample", sampleProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			s, err := newSampleFromParsed(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			if label := mgr.Label(); label != "" {
+				interop.UnwrapManagement(mgr).RegisterEndpoint(
+					"/sample/"+label,
+					"Returns the current state of a sample processor as a JSON object, or adjusts it with a POST request.",
+					s.handleState,
+				)
+			}
+			s.start()
+			return s, nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type sampleFeedback struct {
+	targetLatency  time.Duration
+	url            string
+	interval       time.Duration
+	minRatio       float64
+	increaseStep   float64
+	decreaseFactor float64
+
+	client *http.Client
+}
+
+type sampleProc struct {
+	maxRatio      float64
+	deferMessages bool
+	deferDuration time.Duration
+	feedback      *sampleFeedback
+
+	log       *service.Logger
+	mRatio    *service.MetricGauge
+	mDropped  *service.MetricCounter
+	mDeferred *service.MetricCounter
+
+	mut         sync.Mutex
+	ratio       float64
+	pinned      bool
+	lastLatency time.Duration
+	randFn      func() float64
+
+	shutSig *shutdown.Signaller
+}
+
+func newSampleFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*sampleProc, error) {
+	s := &sampleProc{
+		log:       mgr.Logger(),
+		mRatio:    mgr.Metrics().NewGauge("sample_ratio_percent"),
+		mDropped:  mgr.Metrics().NewCounter("sample_dropped"),
+		mDeferred: mgr.Metrics().NewCounter("sample_deferred"),
+		randFn:    rand.Float64,
+		shutSig:   shutdown.NewSignaller(),
+	}
+
+	var err error
+	if s.maxRatio, err = conf.FieldFloat(spFieldRatio); err != nil {
+		return nil, err
+	}
+	if s.maxRatio < 0 || s.maxRatio > 1 {
+		return nil, errors.New("ratio must be between zero and one")
+	}
+
+	action, err := conf.FieldString(spFieldAction)
+	if err != nil {
+		return nil, err
+	}
+	s.deferMessages = action == "defer"
+	if s.deferDuration, err = conf.FieldDuration(spFieldDeferDuration); err != nil {
+		return nil, err
+	}
+
+	if conf.Contains(spFieldFeedback) {
+		fConf := conf.Namespace(spFieldFeedback)
+		f := &sampleFeedback{}
+		if f.targetLatency, err = fConf.FieldDuration(spFieldFeedbackTargetLatency); err != nil {
+			return nil, err
+		}
+		if fConf.Contains(spFieldFeedbackURL) {
+			if f.url, err = fConf.FieldString(spFieldFeedbackURL); err != nil {
+				return nil, err
+			}
+		}
+		if f.interval, err = fConf.FieldDuration(spFieldFeedbackInterval); err != nil {
+			return nil, err
+		}
+		timeout, err := fConf.FieldDuration(spFieldFeedbackTimeout)
+		if err != nil {
+			return nil, err
+		}
+		f.client = &http.Client{Timeout: timeout}
+		if tm, ok := interop.UnwrapManagement(mgr).(interface{ HTTPTransport() http.RoundTripper }); ok {
+			if rt := tm.HTTPTransport(); rt != nil {
+				f.client.Transport = rt
+			}
+		}
+		if f.minRatio, err = fConf.FieldFloat(spFieldFeedbackMinRatio); err != nil {
+			return nil, err
+		}
+		if f.minRatio < 0 || f.minRatio > s.maxRatio {
+			return nil, errors.New("min_ratio must be between zero and the configured ratio")
+		}
+		if f.increaseStep, err = fConf.FieldFloat(spFieldFeedbackIncreaseStep); err != nil {
+			return nil, err
+		}
+		if f.increaseStep <= 0 {
+			return nil, errors.New("increase_step must be greater than zero")
+		}
+		if f.decreaseFactor, err = fConf.FieldFloat(spFieldFeedbackDecreaseFactor); err != nil {
+			return nil, err
+		}
+		if f.decreaseFactor <= 0 || f.decreaseFactor >= 1 {
+			return nil, errors.New("decrease_factor must be greater than zero and less than one")
+		}
+		s.feedback = f
+	}
+
+	s.setRatio(s.maxRatio)
+	return s, nil
+}
+
+// setRatio changes the current ratio. Must be called with the mutex held or
+// before the processor is shared.
+func (s *sampleProc) setRatio(r float64) {
+	s.ratio = r
+	s.mRatio.Set(int64(math.Round(r * 100)))
+}
+
+// observe adjusts the ratio according to a latency signal, where a negative
+// latency indicates that the signal could not be obtained.
+func (s *sampleProc) observe(latency time.Duration) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.lastLatency = latency
+	if s.feedback == nil || s.pinned {
+		return
+	}
+
+	f := s.feedback
+	if latency < 0 || latency > f.targetLatency {
+		if r := math.Max(s.ratio*f.decreaseFactor, f.minRatio); r < s.ratio {
+			s.log.Debugf("Reducing sample ratio from %v to %v due to latency of %v", s.ratio, r, latency)
+			s.setRatio(r)
+		}
+		return
+	}
+	if r := math.Min(s.ratio+f.increaseStep, s.maxRatio); r > s.ratio {
+		s.setRatio(r)
+	}
+}
+
+func (s *sampleProc) probe(ctx context.Context) time.Duration {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.feedback.url, http.NoBody)
+	if err != nil {
+		s.log.Errorf("Failed to create feedback request: %v", err)
+		return -1
+	}
+
+	started := time.Now()
+	res, err := s.feedback.client.Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Warnf("Feedback request failed: %v", err)
+		}
+		return -1
+	}
+	res.Body.Close()
+	latency := time.Since(started)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		s.log.Warnf("Feedback request returned unexpected status code: %v", res.StatusCode)
+		return -1
+	}
+	return latency
+}
+
+func (s *sampleProc) start() {
+	if s.feedback == nil || s.feedback.url == "" {
+		s.shutSig.ShutdownComplete()
+		return
+	}
+	go func() {
+		defer s.shutSig.ShutdownComplete()
+
+		ctx, done := s.shutSig.CloseAtLeisureCtx(context.Background())
+		defer done()
+
+		ticker := time.NewTicker(s.feedback.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			if latency := s.probe(ctx); ctx.Err() == nil {
+				s.observe(latency)
+			}
+		}
+	}()
+}
+
+func (s *sampleProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	s.mut.Lock()
+	admit := s.ratio >= 1 || s.randFn() < s.ratio
+	s.mut.Unlock()
+	if admit {
+		return service.MessageBatch{msg}, nil
+	}
+
+	if !s.deferMessages {
+		s.mDropped.Incr(1)
+		return nil, nil
+	}
+
+	s.mDeferred.Incr(1)
+	select {
+	case <-time.After(s.deferDuration):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.shutSig.CloseAtLeisureChan():
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+func (s *sampleProc) handleState(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		if err := s.handleUpdate(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.mut.Lock()
+	state := map[string]any{
+		"ratio":  s.ratio,
+		"pinned": s.pinned,
+	}
+	if s.lastLatency != 0 {
+		if s.lastLatency < 0 {
+			state["last_latency"] = "failed"
+		} else {
+			state["last_latency"] = s.lastLatency.String()
+		}
+	}
+	s.mut.Unlock()
+
+	resBytes, err := json.Marshal(state)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resBytes)
+}
+
+func (s *sampleProc) handleUpdate(r *http.Request) error {
+	query := r.URL.Query()
+	if query.Get("reset") == "true" {
+		s.mut.Lock()
+		s.pinned = false
+		s.setRatio(s.maxRatio)
+		s.mut.Unlock()
+		s.log.Infof("Sample ratio reset to %v", s.maxRatio)
+	}
+	if ratioStr := query.Get("ratio"); ratioStr != "" {
+		ratio, err := strconv.ParseFloat(ratioStr, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse ratio: %w", err)
+		}
+		if ratio < 0 || ratio > 1 {
+			return errors.New("ratio must be between zero and one")
+		}
+		s.mut.Lock()
+		s.pinned = true
+		s.setRatio(ratio)
+		s.mut.Unlock()
+		s.log.Infof("Sample ratio pinned to %v", ratio)
+	}
+	if latencyStr := query.Get("latency"); latencyStr != "" {
+		latency, err := time.ParseDuration(latencyStr)
+		if err != nil {
+			return fmt.Errorf("failed to parse latency: %w", err)
+		}
+		s.observe(latency)
+	}
+	return nil
+}
+
+func (s *sampleProc) Close(ctx context.Context) error {
+	s.shutSig.CloseAtLeisure()
+	select {
+	case <-s.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
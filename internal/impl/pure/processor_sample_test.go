@@ -0,0 +1,176 @@
+package pure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testSampleProc(t *testing.T, confStr string) *sampleProc {
+	t.Helper()
+
+	conf, err := sampleProcConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newSampleFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	proc.start()
+	t.Cleanup(func() {
+		require.NoError(t, proc.Close(context.Background()))
+	})
+	return proc
+}
+
+func TestSampleDrop(t *testing.T) {
+	proc := testSampleProc(t, `ratio: 0.5`)
+
+	rolls := []float64{0.1, 0.6, 0.49, 0.5}
+	proc.randFn = func() float64 {
+		r := rolls[0]
+		rolls = rolls[1:]
+		return r
+	}
+
+	var admitted int
+	for i := 0; i < 4; i++ {
+		batch, err := proc.Process(context.Background(), service.NewMessage([]byte("hello")))
+		require.NoError(t, err)
+		admitted += len(batch)
+	}
+	assert.Equal(t, 2, admitted)
+}
+
+func TestSampleDefer(t *testing.T) {
+	proc := testSampleProc(t, `
+ratio: 0
+action: defer
+defer_duration: 10ms
+`)
+
+	started := time.Now()
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte("hello")))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.GreaterOrEqual(t, time.Since(started), time.Millisecond*10)
+
+	ctx, done := context.WithCancel(context.Background())
+	done()
+	proc.deferDuration = time.Hour
+	_, err = proc.Process(ctx, service.NewMessage([]byte("hello")))
+	require.Error(t, err)
+}
+
+func TestSampleFeedback(t *testing.T) {
+	proc := testSampleProc(t, `
+ratio: 0.8
+feedback:
+  target_latency: 100ms
+  min_ratio: 0.1
+`)
+
+	proc.observe(time.Millisecond * 200)
+	assert.InDelta(t, 0.4, proc.ratio, 0.0001)
+
+	proc.observe(-1)
+	assert.InDelta(t, 0.2, proc.ratio, 0.0001)
+
+	proc.observe(time.Second)
+	assert.InDelta(t, 0.1, proc.ratio, 0.0001)
+
+	proc.observe(time.Second)
+	assert.InDelta(t, 0.1, proc.ratio, 0.0001)
+
+	for i := 0; i < 10; i++ {
+		proc.observe(time.Millisecond * 50)
+	}
+	assert.InDelta(t, 0.8, proc.ratio, 0.0001)
+}
+
+func TestSampleHTTPAPI(t *testing.T) {
+	proc := testSampleProc(t, `
+feedback:
+  target_latency: 100ms
+`)
+
+	doReq := func(method, query string) map[string]any {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		proc.handleState(rec, httptest.NewRequest(method, "/sample/foo?"+query, http.NoBody))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var state map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+		return state
+	}
+
+	assert.Equal(t, map[string]any{"ratio": 1.0, "pinned": false}, doReq(http.MethodGet, ""))
+
+	assert.Equal(t, map[string]any{
+		"ratio":        0.5,
+		"pinned":       false,
+		"last_latency": "1s",
+	}, doReq(http.MethodPost, "latency=1s"))
+
+	// Feedback is ignored whilst pinned.
+	assert.Equal(t, map[string]any{"ratio": 0.25, "pinned": true, "last_latency": "1s"}, doReq(http.MethodPost, "ratio=0.25"))
+	assert.Equal(t, map[string]any{"ratio": 0.25, "pinned": true, "last_latency": "10ms"}, doReq(http.MethodPost, "latency=10ms"))
+
+	assert.Equal(t, map[string]any{"ratio": 1.0, "pinned": false, "last_latency": "10ms"}, doReq(http.MethodPost, "reset=true"))
+
+	rec := httptest.NewRecorder()
+	proc.handleState(rec, httptest.NewRequest(http.MethodPost, "/sample/foo?ratio=2", http.NoBody))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestSampleFeedbackProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	proc := testSampleProc(t, `
+feedback:
+  target_latency: 1s
+  url: `+srv.URL+`
+  interval: 5ms
+  min_ratio: 0.2
+`)
+
+	assert.Eventually(t, func() bool {
+		proc.mut.Lock()
+		defer proc.mut.Unlock()
+		return proc.ratio == 0.2
+	}, time.Second, time.Millisecond*5)
+}
+
+func TestSampleConfigErrors(t *testing.T) {
+	for _, confStr := range []string{
+		`ratio: 1.5`,
+		`
+ratio: 0.5
+feedback:
+  target_latency: 1s
+  min_ratio: 0.6
+`,
+		`
+feedback:
+  target_latency: 1s
+  decrease_factor: 1
+`,
+	} {
+		conf, err := sampleProcConfig().ParseYAML(confStr, nil)
+		require.NoError(t, err)
+
+		_, err = newSampleFromParsed(conf, service.MockResources())
+		assert.Error(t, err, confStr)
+	}
+}
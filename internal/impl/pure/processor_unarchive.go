@@ -27,6 +27,10 @@ When a message is unarchived the new messages replace the original message in th
 ## Metadata
 
 The metadata found on the messages handled by this processor will be copied into the resulting messages. For the unarchive formats that contain file information (tar, zip), a metadata field is also added to each message called ` + "`archive_filename`" + ` with the extracted filename.
+
+## Encrypted Zip Files
+
+Files within zip archives that are encrypted with either traditional PKWARE encryption (ZipCrypto) or WinZip AES encryption can be extracted by specifying the ` + "`password`" + ` of the archive. Files that are not encrypted are extracted regardless of the password.
 `).
 		Field(service.NewStringAnnotatedEnumField("format", map[string]string{
 			`tar`:            `Extract messages from a unix standard tape archive.`,
@@ -38,7 +42,13 @@ The metadata found on the messages handled by this processor will be copied into
 			`json_map`:       `Attempt to parse the message as a JSON map and for each element of the map expands its contents into a new message. A metadata field is added to each message called ` + "`archive_key`" + ` with the relevant key from the top-level map.`,
 			`csv`:            `Attempt to parse the message as a csv file (header required) and for each row in the file expands its contents into a json object in a new message.`,
 			`csv:x`:          `Attempt to parse the message as a csv file (header required) and for each row in the file expands its contents into a json object in a new message using a custom delimiter. The custom delimiter must be a single character, e.g. the format "csv:\t" would consume a tab delimited file.`,
-		}).Description("The unarchiving format to apply."))
+		}).Description("The unarchiving format to apply.")).
+		Field(service.NewStringField("password").
+			Description("An optional password for extracting encrypted files from zip archives.").
+			Secret().
+			Optional().
+			Advanced().
+			Version("4.20.0"))
 }
 
 func init() {
@@ -90,39 +100,36 @@ func tarUnarchive(part *service.Message) (service.MessageBatch, error) {
 	return newParts, nil
 }
 
-func zipUnarchive(part *service.Message) (service.MessageBatch, error) {
-	pBytes, err := part.AsBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	buf := bytes.NewReader(pBytes)
-	zr, err := zip.NewReader(buf, int64(buf.Len()))
-	if err != nil {
-		return nil, err
-	}
-
-	var newParts service.MessageBatch
-
-	// Iterate through the files in the archive.
-	for _, f := range zr.File {
-		fr, err := f.Open()
+func zipUnarchive(password []byte) unarchiveFunc {
+	return func(part *service.Message) (service.MessageBatch, error) {
+		pBytes, err := part.AsBytes()
 		if err != nil {
 			return nil, err
 		}
 
-		newPartBuf := bytes.Buffer{}
-		if _, err = newPartBuf.ReadFrom(fr); err != nil {
+		buf := bytes.NewReader(pBytes)
+		zr, err := zip.NewReader(buf, int64(buf.Len()))
+		if err != nil {
 			return nil, err
 		}
 
-		newPart := part.Copy()
-		newPart.SetBytes(newPartBuf.Bytes())
-		newPart.MetaSet("archive_filename", f.Name)
-		newParts = append(newParts, newPart)
-	}
+		var newParts service.MessageBatch
 
-	return newParts, nil
+		// Iterate through the files in the archive.
+		for _, f := range zr.File {
+			data, err := readZipFile(f, password)
+			if err != nil {
+				return nil, err
+			}
+
+			newPart := part.Copy()
+			newPart.SetBytes(data)
+			newPart.MetaSet("archive_filename", f.Name)
+			newParts = append(newParts, newPart)
+		}
+
+		return newParts, nil
+	}
 }
 
 func binaryUnarchive(part *service.Message) (service.MessageBatch, error) {
@@ -284,12 +291,16 @@ func csvUnarchive(customComma *rune) func(*service.Message) (service.MessageBatc
 	}
 }
 
-func strToUnarchiver(str string) (unarchiveFunc, error) {
+func strToUnarchiver(str string, password []byte) (unarchiveFunc, error) {
+	if password != nil && str != "zip" {
+		return nil, fmt.Errorf("a password is not supported by the %v format", str)
+	}
+
 	switch str {
 	case "tar":
 		return tarUnarchive, nil
 	case "zip":
-		return zipUnarchive, nil
+		return zipUnarchive(password), nil
 	case "binary":
 		return binaryUnarchive, nil
 	case "lines":
@@ -332,11 +343,19 @@ func newUnarchiveFromParsed(conf *service.ParsedConfig, mgr *service.Resources)
 	if err != nil {
 		return nil, err
 	}
-	return newUnarchive(mgr, formatStr)
+	var password []byte
+	if conf.Contains("password") {
+		passwordStr, err := conf.FieldString("password")
+		if err != nil {
+			return nil, err
+		}
+		password = []byte(passwordStr)
+	}
+	return newUnarchive(mgr, formatStr, password)
 }
 
-func newUnarchive(nm *service.Resources, format string) (*unarchiveProc, error) {
-	unarchiver, err := strToUnarchiver(format)
+func newUnarchive(nm *service.Resources, format string, password []byte) (*unarchiveProc, error) {
+	unarchiver, err := strToUnarchiver(format, password)
 	if err != nil {
 		return nil, err
 	}
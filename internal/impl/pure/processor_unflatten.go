@@ -0,0 +1,235 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func unflattenProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Mapping", "Utility").
+		Version("4.20.0").
+		Summary("Expands an object of keys that are paths, such as those produced by the `flatten` processor, back into a nested structure.").
+		Description(`
+Each key of the object is split by the `+"`separator`"+` into a path, and the value is placed at that path within the resulting document. For example, with the default settings the document `+"`{\"a.b\":1,\"a.c.0\":\"x\",\"a.c.1\":\"y\"}`"+` becomes `+"`{\"a\":{\"b\":1,\"c\":[\"x\",\"y\"]}}`"+`.
+
+Array indexes within paths are identified according to the `+"`arrays`"+` policy, and an array is only created when the indexes of its elements are contiguous and start from zero, otherwise an object with the indexes as keys is created instead.
+
+When a `+"`prefix`"+` is configured only keys that begin with it are expanded, with the prefix removed, and all other keys are kept as they are. If the paths of two keys conflict, such as `+"`a`"+` and `+"`a.b`"+`, the message is left unchanged and flagged with an error, which can be handled using the methods outlined [here](/docs/configuration/error_handling).`).
+		Fields(
+			service.NewStringField(flFieldSeparator).
+				Description("The separator that divides the segments of each path.").
+				Default("."),
+			service.NewStringField(flFieldPrefix).
+				Description("A prefix of keys to expand, which is removed from each expanded key.").
+				Example("event_").
+				Default(""),
+			service.NewStringAnnotatedEnumField(flFieldArrays, map[string]string{
+				"index":    "Path segments that are non-negative integers are array indexes, e.g. `foo.0.bar`.",
+				"brackets": "Integers within square brackets are array indexes, e.g. `foo[0].bar`.",
+				"none":     "Arrays are never created and all segments are object keys.",
+			}).
+				Description("How to identify array indexes within paths.").
+				Default("index"),
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"unflatten", unflattenProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newUnflattenFromParsed(conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type unflattenProc struct {
+	separator string
+	prefix    string
+	arrays    string
+}
+
+func newUnflattenFromParsed(conf *service.ParsedConfig) (*unflattenProc, error) {
+	u := &unflattenProc{}
+
+	var err error
+	if u.separator, err = conf.FieldString(flFieldSeparator); err != nil {
+		return nil, err
+	}
+	if u.separator == "" {
+		return nil, fmt.Errorf("field %v must not be empty", flFieldSeparator)
+	}
+	if u.prefix, err = conf.FieldString(flFieldPrefix); err != nil {
+		return nil, err
+	}
+	if u.arrays, err = conf.FieldString(flFieldArrays); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+type unflattenSegment struct {
+	key   string
+	index bool
+}
+
+func isArrayIndex(s string) bool {
+	i, err := strconv.Atoi(s)
+	return err == nil && i >= 0 && strconv.Itoa(i) == s
+}
+
+func (u *unflattenProc) segments(path string) []unflattenSegment {
+	var segs []unflattenSegment
+	for _, s := range strings.Split(path, u.separator) {
+		switch u.arrays {
+		case "index":
+			segs = append(segs, unflattenSegment{key: s, index: isArrayIndex(s)})
+			continue
+		case "brackets":
+			if indexSegs, ok := bracketSegments(s); ok {
+				segs = append(segs, indexSegs...)
+				continue
+			}
+		}
+		segs = append(segs, unflattenSegment{key: s})
+	}
+	return segs
+}
+
+// bracketSegments splits a segment such as `foo[0][1]` into a key followed by
+// its indexes, returning false if the segment contains no valid indexes.
+func bracketSegments(s string) ([]unflattenSegment, bool) {
+	i := strings.IndexByte(s, '[')
+	if i == -1 || !strings.HasSuffix(s, "]") {
+		return nil, false
+	}
+
+	var segs []unflattenSegment
+	if i > 0 {
+		segs = append(segs, unflattenSegment{key: s[:i]})
+	}
+	for _, idx := range strings.Split(s[i+1:len(s)-1], "][") {
+		if !isArrayIndex(idx) {
+			return nil, false
+		}
+		segs = append(segs, unflattenSegment{key: idx, index: true})
+	}
+	return segs, true
+}
+
+type unflattenNode struct {
+	leaf     bool
+	value    any
+	children map[string]*unflattenNode
+	indexes  int
+}
+
+func (n *unflattenNode) toValue() any {
+	if n.leaf {
+		return n.value
+	}
+	if n.indexes > 0 && n.indexes == len(n.children) {
+		arr := make([]any, len(n.children))
+		isArray := true
+		for k, child := range n.children {
+			i, _ := strconv.Atoi(k)
+			if i >= len(arr) {
+				isArray = false
+				break
+			}
+			arr[i] = child.toValue()
+		}
+		if isArray {
+			return arr
+		}
+	}
+	obj := make(map[string]any, len(n.children))
+	for k, child := range n.children {
+		obj[k] = child.toValue()
+	}
+	return obj
+}
+
+var errUnflattenConflict = errors.New("conflicts with another key")
+
+func (n *unflattenNode) set(segs []unflattenSegment, v any) error {
+	if n.leaf {
+		return errUnflattenConflict
+	}
+	if n.children == nil {
+		n.children = map[string]*unflattenNode{}
+	}
+
+	seg := segs[0]
+	child, exists := n.children[seg.key]
+	if !exists {
+		child = &unflattenNode{}
+		n.children[seg.key] = child
+		if seg.index {
+			n.indexes++
+		}
+	}
+	if len(segs) > 1 {
+		return child.set(segs[1:], v)
+	}
+	if exists {
+		return errUnflattenConflict
+	}
+	child.leaf, child.value = true, v
+	return nil
+}
+
+func (u *unflattenProc) unflatten(flat map[string]any) (any, error) {
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	root := &unflattenNode{}
+	for _, k := range keys {
+		segs := []unflattenSegment{{key: k}}
+		if strings.HasPrefix(k, u.prefix) {
+			segs = u.segments(strings.TrimPrefix(k, u.prefix))
+		}
+		if err := root.set(segs, flat[k]); err != nil {
+			return nil, fmt.Errorf("key '%v' %w", k, err)
+		}
+	}
+	if root.children == nil {
+		return map[string]any{}, nil
+	}
+	return root.toValue(), nil
+}
+
+func (u *unflattenProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	v, err := msg.AsStructured()
+	if err != nil {
+		return nil, err
+	}
+	flat, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", v)
+	}
+	res, err := u.unflatten(flat)
+	if err != nil {
+		return nil, err
+	}
+	msg.SetStructuredMut(res)
+	return service.MessageBatch{msg}, nil
+}
+
+func (u *unflattenProc) Close(ctx context.Context) error {
+	return nil
+}
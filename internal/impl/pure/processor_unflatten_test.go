@@ -0,0 +1,108 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestUnflatten(t *testing.T) {
+	tests := []struct {
+		name        string
+		conf        string
+		input       string
+		output      string
+		errContains string
+	}{
+		{
+			name:   "defaults",
+			input:  `{"a.b":1,"a.c.0":"x","a.c.1.d":"y","g":null}`,
+			output: `{"a":{"b":1,"c":["x",{"d":"y"}]},"g":null}`,
+		},
+		{
+			name:   "sparse indexes",
+			input:  `{"a.0":"x","a.2":"y","b.0":"z","b.c":"w"}`,
+			output: `{"a":{"0":"x","2":"y"},"b":{"0":"z","c":"w"}}`,
+		},
+		{
+			name: "brackets with prefix and separator",
+			conf: `
+separator: _
+prefix: ev_
+arrays: brackets
+`,
+			input:  `{"ev_a_c[0][0]":"x","ev_a_c[1]_d":"y","ev_a_0":"z","other_b":"w"}`,
+			output: `{"a":{"0":"z","c":[["x"],{"d":"y"}]},"other_b":"w"}`,
+		},
+		{
+			name: "no arrays",
+			conf: `
+arrays: none
+`,
+			input:  `{"a.0":"x","a.1":"y"}`,
+			output: `{"a":{"0":"x","1":"y"}}`,
+		},
+		{
+			name:        "conflict",
+			input:       `{"a":1,"a.b":2}`,
+			errContains: "key 'a.b' conflicts with another key",
+		},
+		{
+			name:        "not an object",
+			input:       `["a"]`,
+			errContains: "expected an object",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := unflattenProcConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			proc, err := newUnflattenFromParsed(conf)
+			require.NoError(t, err)
+
+			res, err := proc.Process(context.Background(), service.NewMessage([]byte(test.input)))
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+
+			b, err := res[0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(b))
+		})
+	}
+}
+
+func TestFlattenUnflattenRoundTrip(t *testing.T) {
+	for _, arrays := range []string{"index", "brackets"} {
+		flatConf, err := flattenProcConfig().ParseYAML(`arrays: `+arrays, nil)
+		require.NoError(t, err)
+		flatten, err := newFlattenFromParsed(flatConf)
+		require.NoError(t, err)
+
+		unflatConf, err := unflattenProcConfig().ParseYAML(`arrays: `+arrays, nil)
+		require.NoError(t, err)
+		unflatten, err := newUnflattenFromParsed(unflatConf)
+		require.NoError(t, err)
+
+		input := `{"a":{"b":[1,[2,3],{"c":"d"}],"e":"f"},"g":[{"h":true}]}`
+		res, err := flatten.Process(context.Background(), service.NewMessage([]byte(input)))
+		require.NoError(t, err)
+		res, err = unflatten.Process(context.Background(), res[0])
+		require.NoError(t, err)
+
+		b, err := res[0].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, input, string(b), arrays)
+	}
+}
@@ -1,8 +1,11 @@
 package pure
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"sort"
 	"sync"
 	"time"
@@ -10,19 +13,31 @@ import (
 	"github.com/Jeffail/gabs/v2"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/profile"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
 func init() {
 	err := bundle.AllProcessors.Add(func(conf processor.Config, mgr bundle.NewManagement) (processor.V1, error) {
 		p, err := NewWorkflow(conf.Workflow, mgr)
-		return p, err
+		if err != nil {
+			return nil, err
+		}
+		if label := mgr.Label(); label != "" {
+			mgr.RegisterEndpoint(
+				"/workflow/"+label,
+				"Returns the resolved DAG of a workflow processor as a JSON object, or in the Graphviz DOT format with the query parameter `format=dot`.",
+				p.handleDAG,
+			)
+		}
+		return p, nil
 	}, docs.ComponentSpec{
 		Name: "workflow",
 		Categories: []string{
@@ -113,7 +128,36 @@ For example, if our meta object is stored at the path ` + "`meta.workflow`" + `
 
 However, if structured metadata is disabled by setting the field ` + "`meta_path`" + ` to empty then the workflow processor instead adds a general error flag to messages when any executed branch fails. In this case it's possible to handle failures using [standard error handling patterns][configuration.error-handling].
 
+### Error Policies
+
+By default the failure of a branch is recorded and all other branches are still executed. This behaviour can be changed for individual branches with the field ` + "`error_policies`" + `, which is an object of policies keyed by branch names, where each policy has one of the following actions:
+
+- ` + "`continue`" + `: The default, the failure is recorded and all other branches are executed.
+- ` + "`skip_dependents`" + `: Branches that depend on the failed branch, either directly or transitively, are not executed for the message and are recorded as failed, which means they are reattempted when the message is replayed. Dependencies are inferred from the request and result mappings of branches in the same way as automatic ordering.
+- ` + "`fail_workflow`" + `: No branches of later tiers are executed for the message and they are recorded as failed. The message is also flagged with an error even when structured metadata is enabled.
+- ` + "`fallback`" + `: The ` + "`fallback_map`" + ` is applied to the message in place of the result of the branch, and the branch is recorded within the field ` + "`fallback`" + ` of the structured metadata rather than ` + "`failed`" + `. Branches recorded as fallbacks are reattempted when the message is replayed, but do not cause the message to be flagged with an error.
+
+For example, the following policies would stop the workflow when the branch ` + "`auth`" + ` fails, and provide a default result when the branch ` + "`geo`" + ` fails:
+
+` + "```yaml" + `
+pipeline:
+  processors:
+    - workflow:
+        error_policies:
+          auth:
+            action: fail_workflow
+          geo:
+            action: fallback
+            fallback_map: 'root.geo = { "country": "unknown" }'
+        # The branches auth and geo are omitted for brevity
+` + "```" + `
+
+## DAG Visualization
+
+When the workflow processor has a label the resolved DAG can be obtained by sending a GET request to the endpoint ` + "`/workflow/{label}`" + `, where ` + "`{label}`" + ` is the label of the processor. The DAG is returned as a JSON object describing the order of tiers and the dependencies and error policy of each branch, or in the [Graphviz DOT format][graphviz_dot] when the query parameter ` + "`format=dot`" + ` is provided, e.g. ` + "`curl -s 'http://localhost:4195/workflow/foo?format=dot' | dot -Tsvg > workflow.svg`" + `.
+
 [dag_wiki]: https://en.wikipedia.org/wiki/Directed_acyclic_graph
+[graphviz_dot]: https://graphviz.org/doc/info/lang.html
 [processors.switch]: /docs/components/processors/switch
 [processors.http]: /docs/components/processors/http
 [processors.aws_lambda]: /docs/components/processors/aws_lambda
@@ -257,6 +301,22 @@ processor_resources:
 				"branches",
 				"An object of named [`branch` processors](/docs/components/processors/branch) that make up the workflow. The order and parallelism in which branches are executed can either be made explicit with the field `order`, or if omitted an attempt is made to automatically resolve an ordering based on the mappings of each branch.",
 			).Map().WithChildren(branchFields...).HasDefault(map[string]any{}),
+			docs.FieldObject(
+				"error_policies",
+				"An object of [error policies](#error-policies) keyed by the names of branches, describing how the workflow should react when a branch fails for a message. Branches without a policy use the `continue` action.",
+			).Map().WithChildren(
+				docs.FieldString("action", "The action to take when the branch fails for a message.").HasAnnotatedOptions(
+					"continue", "Record the failure and execute all other branches.",
+					"skip_dependents", "Skip all branches that depend on the failed branch.",
+					"fail_workflow", "Skip all branches of later tiers and flag the message with an error.",
+					"fallback", "Apply the `fallback_map` to the message in place of the result of the branch.",
+				).HasDefault("continue"),
+				docs.FieldBloblang(
+					"fallback_map",
+					"A [Bloblang mapping](/docs/guides/bloblang/about) to apply to the message when the branch fails and the action is `fallback`.",
+					`root.enrichments.foo = {}`,
+				).HasDefault(""),
+			).Advanced().HasDefault(map[string]any{}),
 		),
 	})
 	if err != nil {
@@ -277,6 +337,9 @@ type Workflow struct {
 	allStages map[string]struct{}
 	metaPath  []string
 
+	errorPolicies     map[string]workflowErrorPolicy
+	hasSkipDependents bool
+
 	// Metrics
 	mReceived      metrics.StatCounter
 	mBatchReceived metrics.StatCounter
@@ -284,6 +347,8 @@ type Workflow struct {
 	mBatchSent     metrics.StatCounter
 	mError         metrics.StatCounter
 	mLatency       metrics.StatTimer
+
+	profile *profile.Processor
 }
 
 // NewWorkflow instanciates a new workflow processor.
@@ -302,6 +367,8 @@ func NewWorkflow(conf processor.WorkflowConfig, mgr bundle.NewManagement) (*Work
 		mBatchSent:     stats.GetCounter("processor_batch_sent"),
 		mError:         stats.GetCounter("processor_error"),
 		mLatency:       stats.GetTimer("processor_latency_ns"),
+
+		profile: profile.FromManager(mgr, "workflow"),
 	}
 	if len(conf.MetaPath) > 0 {
 		w.metaPath = gabs.DotPathToSlice(conf.MetaPath)
@@ -315,6 +382,15 @@ func NewWorkflow(conf processor.WorkflowConfig, mgr bundle.NewManagement) (*Work
 		w.allStages[k] = struct{}{}
 	}
 
+	if w.errorPolicies, err = workflowErrorPoliciesFromConfig(conf.ErrorPolicies, w.allStages, mgr); err != nil {
+		return nil, err
+	}
+	for _, p := range w.errorPolicies {
+		if p.action == "skip_dependents" {
+			w.hasSkipDependents = true
+		}
+	}
+
 	return w, nil
 }
 
@@ -323,12 +399,112 @@ func (w *Workflow) Flow() [][]string {
 	return w.children.dag
 }
 
+func (w *Workflow) handleDAG(rw http.ResponseWriter, r *http.Request) {
+	dag, children, unlock, err := w.children.Lock()
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	deps := branchDependencies(children)
+	unlock()
+
+	policyOf := func(id string) string {
+		if p, exists := w.errorPolicies[id]; exists {
+			return p.action
+		}
+		return "continue"
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		branches := make(map[string]any, len(deps))
+		for id, idDeps := range deps {
+			branches[id] = map[string]any{
+				"dependencies": idDeps,
+				"error_policy": policyOf(id),
+			}
+		}
+		resBytes, err := json.Marshal(map[string]any{
+			"order":    dag,
+			"branches": branches,
+		})
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write(resBytes)
+	case "dot":
+		var buf bytes.Buffer
+		buf.WriteString("digraph workflow {\n")
+		for _, layer := range dag {
+			buf.WriteString("  { rank=same;")
+			for _, id := range layer {
+				fmt.Fprintf(&buf, " %q;", id)
+			}
+			buf.WriteString(" }\n")
+		}
+		for _, layer := range dag {
+			for _, id := range layer {
+				if policy := policyOf(id); policy != "continue" {
+					fmt.Fprintf(&buf, "  %q [xlabel=%q];\n", id, policy)
+				}
+				for _, d := range deps[id] {
+					fmt.Fprintf(&buf, "  %q -> %q;\n", d, id)
+				}
+			}
+		}
+		buf.WriteString("}\n")
+		rw.Header().Set("Content-Type", "text/vnd.graphviz")
+		_, _ = rw.Write(buf.Bytes())
+	default:
+		http.Error(rw, fmt.Sprintf("format '%v' not recognised, expected json or dot", format), http.StatusBadRequest)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type workflowErrorPolicy struct {
+	action      string
+	fallbackMap *mapping.Executor
+}
+
+func workflowErrorPoliciesFromConfig(confs map[string]processor.WorkflowErrorPolicyConfig, stages map[string]struct{}, mgr bundle.NewManagement) (map[string]workflowErrorPolicy, error) {
+	policies := make(map[string]workflowErrorPolicy, len(confs))
+	for id, conf := range confs {
+		if _, exists := stages[id]; !exists {
+			return nil, fmt.Errorf("error policy specified for unknown branch '%v'", id)
+		}
+
+		p := workflowErrorPolicy{action: conf.Action}
+		switch conf.Action {
+		case "continue", "skip_dependents", "fail_workflow":
+			if conf.FallbackMap != "" {
+				return nil, fmt.Errorf("error policy of branch '%v' specifies a fallback_map but the action is %v", id, conf.Action)
+			}
+		case "fallback":
+			if conf.FallbackMap == "" {
+				return nil, fmt.Errorf("error policy of branch '%v' requires a fallback_map", id)
+			}
+			var err error
+			if p.fallbackMap, err = mgr.BloblEnvironment().NewMapping(conf.FallbackMap); err != nil {
+				return nil, fmt.Errorf("failed to parse fallback mapping of branch '%v': %w", id, err)
+			}
+		default:
+			return nil, fmt.Errorf("error policy action '%v' of branch '%v' not recognised", conf.Action, id)
+		}
+		policies[id] = p
+	}
+	return policies, nil
+}
+
 //------------------------------------------------------------------------------
 
 type resultTracker struct {
 	succeeded map[string]struct{}
 	skipped   map[string]struct{}
 	failed    map[string]string
+	fallback  map[string]string
 	sync.Mutex
 }
 
@@ -337,6 +513,7 @@ func trackerFromTree(tree [][]string) *resultTracker {
 		succeeded: map[string]struct{}{},
 		skipped:   map[string]struct{}{},
 		failed:    map[string]string{},
+		fallback:  map[string]string{},
 	}
 	for _, layer := range tree {
 		for _, k := range layer {
@@ -363,10 +540,28 @@ func (r *resultTracker) Failed(k, why string) {
 	r.Unlock()
 }
 
+func (r *resultTracker) Fallback(k, why string) {
+	r.Lock()
+	delete(r.succeeded, k)
+	delete(r.skipped, k)
+	delete(r.failed, k)
+
+	r.fallback[k] = why
+	r.Unlock()
+}
+
+func (r *resultTracker) FailedReason(k string) (string, bool) {
+	r.Lock()
+	why, failed := r.failed[k]
+	r.Unlock()
+	return why, failed
+}
+
 func (r *resultTracker) ToObject() map[string]any {
 	succeeded := make([]any, 0, len(r.succeeded))
 	skipped := make([]any, 0, len(r.skipped))
 	failed := make(map[string]any, len(r.failed))
+	fallback := make(map[string]any, len(r.fallback))
 
 	for k := range r.succeeded {
 		succeeded = append(succeeded, k)
@@ -383,6 +578,9 @@ func (r *resultTracker) ToObject() map[string]any {
 	for k, v := range r.failed {
 		failed[k] = v
 	}
+	for k, v := range r.fallback {
+		fallback[k] = v
+	}
 
 	m := map[string]any{}
 	if len(succeeded) > 0 {
@@ -394,6 +592,9 @@ func (r *resultTracker) ToObject() map[string]any {
 	if len(failed) > 0 {
 		m["failed"] = failed
 	}
+	if len(fallback) > 0 {
+		m["fallback"] = fallback
+	}
 	return m
 }
 
@@ -486,7 +687,28 @@ func (w *Workflow) ProcessBatch(ctx context.Context, msg message.Batch) ([]messa
 		records[i] = trackerFromTree(dag)
 	}
 
-	for _, layer := range dag {
+	// Branches that must not be executed for a message due to the error
+	// policies of failed branches, along with the reason.
+	blocked := make([]map[string]string, msg.Len())
+	block := func(index int, id, why string) {
+		if _, exists := skipOnMeta[index][id]; exists {
+			return
+		}
+		if blocked[index] == nil {
+			blocked[index] = map[string]string{}
+		}
+		if _, exists := blocked[index][id]; !exists {
+			blocked[index][id] = why
+		}
+	}
+	aborted := make([]error, msg.Len())
+
+	var dependents map[string][]string
+	if w.hasSkipDependents {
+		dependents = branchDependents(children)
+	}
+
+	for tier, layer := range dag {
 		results := make([][]*message.Part, len(layer))
 		errors := make([]error, len(layer))
 
@@ -500,7 +722,10 @@ func (w *Workflow) ProcessBatch(ctx context.Context, msg message.Batch) ([]messa
 					// Remove errors so that they aren't propagated into the
 					// branch.
 					part.ErrorSet(nil)
-					if _, exists := skipOnMeta[partIndex][id]; !exists {
+					if _, exists := skipOnMeta[partIndex][id]; exists {
+						return nil
+					}
+					if _, exists := blocked[partIndex][id]; !exists {
 						branchParts[partIndex] = part
 					}
 					return nil
@@ -542,6 +767,47 @@ func (w *Workflow) ProcessBatch(ctx context.Context, msg message.Batch) ([]messa
 				records[e.index].Failed(id, e.err.Error())
 			}
 		}
+
+		for _, id := range layer {
+			policy := w.errorPolicies[id]
+			for j := range records {
+				if why, isBlocked := blocked[j][id]; isBlocked {
+					records[j].Failed(id, why)
+					continue
+				}
+				why, failed := records[j].FailedReason(id)
+				if !failed {
+					continue
+				}
+				switch policy.action {
+				case "skip_dependents":
+					for _, d := range transitiveDependents(dependents, id) {
+						block(j, d, fmt.Sprintf("skipped due to failed dependency '%v'", id))
+					}
+				case "fail_workflow":
+					for _, laterLayer := range dag[tier+1:] {
+						for _, d := range laterLayer {
+							block(j, d, fmt.Sprintf("workflow aborted due to failed branch '%v'", id))
+						}
+					}
+					if aborted[j] == nil {
+						aborted[j] = fmt.Errorf("workflow aborted due to failed branch '%v': %v", id, why)
+					}
+				case "fallback":
+					newPart, err := policy.fallbackMap.MapOnto(msg.Get(j), j, msg)
+					if err != nil {
+						w.mError.Incr(1)
+						w.log.Debugf("Failed to map fallback of enrichment '%v': %v\n", id, err)
+						records[j].Failed(id, fmt.Sprintf("%v: fallback mapping failed: %v", why, err))
+						continue
+					}
+					if newPart != nil {
+						msg[j] = newPart
+					}
+					records[j].Fallback(id, why)
+				}
+			}
+		}
 	}
 
 	// Finally, set the meta records of each document.
@@ -564,10 +830,17 @@ func (w *Workflow) ProcessBatch(ctx context.Context, msg message.Batch) ([]messa
 			_, _ = gObj.Set(current, w.metaPath...)
 
 			p.SetStructuredMut(gObj.Data())
+			if aborted[i] != nil {
+				p.ErrorSet(aborted[i])
+			}
 			return nil
 		})
 	} else {
 		_ = msg.Iter(func(i int, p *message.Part) error {
+			if aborted[i] != nil {
+				p.ErrorSet(aborted[i])
+				return nil
+			}
 			if lf := len(records[i].failed); lf > 0 {
 				failed := make([]string, 0, lf)
 				for k := range records[i].failed {
@@ -584,11 +857,14 @@ func (w *Workflow) ProcessBatch(ctx context.Context, msg message.Batch) ([]messa
 
 	w.mSent.Incr(int64(msg.Len()))
 	w.mBatchSent.Incr(1)
-	w.mLatency.Timing(time.Since(startedAt).Nanoseconds())
+	latency := time.Since(startedAt)
+	w.mLatency.Timing(latency.Nanoseconds())
+	w.profile.Record(msg.Len(), latency)
 	return []message.Batch{msg}, nil
 }
 
 // Close shuts down the processor and stops processing requests.
 func (w *Workflow) Close(ctx context.Context) error {
+	w.profile.Deregister()
 	return w.children.Close(ctx)
 }
@@ -234,6 +234,54 @@ func getBranchDeps(id string, wanted [][]string, branches map[string]*Branch) []
 	return dependencies
 }
 
+// branchDependencies returns a sorted list of the branches that each branch
+// depends on according to their mappings.
+func branchDependencies(branches map[string]*Branch) map[string][]string {
+	deps := make(map[string][]string, len(branches))
+	for id, b := range branches {
+		unique := map[string]struct{}{}
+		for _, d := range getBranchDeps(id, b.targetsUsed(), branches) {
+			unique[d] = struct{}{}
+		}
+		idDeps := make([]string, 0, len(unique))
+		for d := range unique {
+			idDeps = append(idDeps, d)
+		}
+		sort.Strings(idDeps)
+		deps[id] = idDeps
+	}
+	return deps
+}
+
+// branchDependents returns a map of branches to the branches that directly
+// depend on them.
+func branchDependents(branches map[string]*Branch) map[string][]string {
+	dependents := map[string][]string{}
+	for id, deps := range branchDependencies(branches) {
+		for _, d := range deps {
+			dependents[d] = append(dependents[d], id)
+		}
+	}
+	return dependents
+}
+
+func transitiveDependents(dependents map[string][]string, id string) []string {
+	seen := map[string]struct{}{id: {}}
+	var result []string
+	remaining := append([]string{}, dependents[id]...)
+	for len(remaining) > 0 {
+		d := remaining[0]
+		remaining = remaining[1:]
+		if _, exists := seen[d]; exists {
+			continue
+		}
+		seen[d] = struct{}{}
+		result = append(result, d)
+		remaining = append(remaining, dependents[d]...)
+	}
+	return result
+}
+
 func verifyStaticBranchDAG(order [][]string, branches map[string]workflowBranch) error {
 	remaining := map[string]struct{}{}
 	seen := map[string]struct{}{}
@@ -3,6 +3,8 @@ package pure_test
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"sort"
 	"strconv"
 	"sync"
@@ -985,3 +987,194 @@ workflow:
 		},
 	}, tracer.ProcessorEvents())
 }
+
+func TestWorkflowErrorPolicies(t *testing.T) {
+	confStr := `
+workflow:
+  error_policies:
+    a:
+      action: %v
+      fallback_map: '%v'
+  branches:
+    a:
+      request_map: 'root.v = this.a.not_null()'
+      processors: [ { mapping: 'root = this' } ]
+      result_map: 'root.a_result = this.v'
+    b:
+      request_map: 'root.v = this.a_result'
+      processors: [ { mapping: 'root = this' } ]
+      result_map: 'root.b_result = this.v'
+    c:
+      request_map: 'root.v = this.b_result'
+      processors: [ { mapping: 'root = this' } ]
+      result_map: 'root.c_result = this.v'
+    d:
+      request_map: 'root.v = this.d'
+      processors: [ { mapping: 'root = this' } ]
+      result_map: 'root.d_result = this.v'
+`
+	aFailed := "request mapping failed: failed assignment (line 1): field `this.a`: value is null"
+
+	tests := []struct {
+		name        string
+		action      string
+		fallbackMap string
+		output      string
+		err         string
+	}{
+		{
+			name:   "skip dependents",
+			action: "skip_dependents",
+			output: `{"d":"x","d_result":"x","meta":{"workflow":{"failed":{"a":"` + aFailed + `","b":"skipped due to failed dependency 'a'","c":"skipped due to failed dependency 'a'"},"succeeded":["d"]}}}`,
+		},
+		{
+			name:   "fail workflow",
+			action: "fail_workflow",
+			output: `{"d":"x","d_result":"x","meta":{"workflow":{"failed":{"a":"` + aFailed + `","b":"workflow aborted due to failed branch 'a'","c":"workflow aborted due to failed branch 'a'"},"succeeded":["d"]}}}`,
+			err:    "workflow aborted due to failed branch 'a': " + aFailed,
+		},
+		{
+			name:        "fallback",
+			action:      "fallback",
+			fallbackMap: `root.a_result = "default"`,
+			output:      `{"a_result":"default","b_result":"default","c_result":"default","d":"x","d_result":"x","meta":{"workflow":{"fallback":{"a":"` + aFailed + `"},"succeeded":["b","c","d"]}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := parseYAMLConf(t, confStr, test.action, test.fallbackMap)
+
+			p, err := pure.NewWorkflow(conf.Workflow, mock.NewManager())
+			require.NoError(t, err)
+			msgs, res := p.ProcessBatch(context.Background(), message.QuickBatch([][]byte{
+				[]byte(`{"d":"x"}`),
+			}))
+			require.NoError(t, res)
+			require.Len(t, msgs, 1)
+			require.Equal(t, 1, msgs[0].Len())
+
+			assert.Equal(t, test.output, string(msgs[0].Get(0).AsBytes()))
+			if test.err != "" {
+				assert.EqualError(t, msgs[0].Get(0).ErrorGet(), test.err)
+			} else {
+				assert.NoError(t, msgs[0].Get(0).ErrorGet())
+			}
+
+			ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+			defer done()
+			assert.NoError(t, p.Close(ctx))
+		})
+	}
+}
+
+func TestWorkflowErrorPolicyConfigErrors(t *testing.T) {
+	tests := []struct {
+		policies string
+		err      string
+	}{
+		{
+			policies: `{ nope: { action: fail_workflow } }`,
+			err:      "error policy specified for unknown branch 'nope'",
+		},
+		{
+			policies: `{ a: { action: fallback } }`,
+			err:      "error policy of branch 'a' requires a fallback_map",
+		},
+		{
+			policies: `{ a: { action: skip_dependents, fallback_map: 'root = {}' } }`,
+			err:      "error policy of branch 'a' specifies a fallback_map but the action is skip_dependents",
+		},
+		{
+			policies: `{ a: { action: shrug } }`,
+			err:      "error policy action 'shrug' of branch 'a' not recognised",
+		},
+	}
+
+	for _, test := range tests {
+		conf := parseYAMLConf(t, `
+workflow:
+  error_policies: %v
+  branches:
+    a:
+      processors: [ { mapping: 'root = this' } ]
+`, test.policies)
+
+		_, err := pure.NewWorkflow(conf.Workflow, mock.NewManager())
+		require.Error(t, err, test.policies)
+		assert.Contains(t, err.Error(), test.err)
+	}
+}
+
+type workflowTestAPIReg map[string]http.HandlerFunc
+
+func (w workflowTestAPIReg) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
+	w[path] = h
+}
+
+func TestWorkflowDAGEndpoint(t *testing.T) {
+	apiReg := workflowTestAPIReg{}
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(apiReg))
+	require.NoError(t, err)
+
+	conf := parseYAMLConf(t, `
+label: foo
+workflow:
+  error_policies:
+    a:
+      action: skip_dependents
+  branches:
+    a:
+      request_map: 'root = this.a'
+      processors: [ { mapping: 'root = this' } ]
+      result_map: 'root.a_result = this'
+    b:
+      request_map: 'root = this.a_result'
+      processors: [ { mapping: 'root = this' } ]
+      result_map: 'root.b_result = this'
+    c:
+      request_map: 'root = [ this.a_result, this.b_result ]'
+      processors: [ { mapping: 'root = this' } ]
+`)
+
+	p, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+
+	handler, exists := apiReg["/workflow/foo"]
+	require.True(t, exists)
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/workflow/foo", http.NoBody))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+  "order": [["a"],["b"],["c"]],
+  "branches": {
+    "a": {"dependencies": [], "error_policy": "skip_dependents"},
+    "b": {"dependencies": ["a"], "error_policy": "continue"},
+    "c": {"dependencies": ["a","b"], "error_policy": "continue"}
+  }
+}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/workflow/foo?format=dot", http.NoBody))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `digraph workflow {
+  { rank=same; "a"; }
+  { rank=same; "b"; }
+  { rank=same; "c"; }
+  "a" [xlabel="skip_dependents"];
+  "a" -> "b";
+  "a" -> "c";
+  "b" -> "c";
+}
+`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/workflow/foo?format=png", http.NoBody))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
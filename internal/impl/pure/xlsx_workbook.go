@@ -0,0 +1,335 @@
+package pure
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xlsxWorkbook provides access to the sheets of an Office Open XML workbook.
+type xlsxWorkbook struct {
+	zr        *zip.Reader
+	sheets    []xlsxSheetRef
+	strings   []string
+	dateStyle []bool
+	date1904  bool
+}
+
+type xlsxSheetRef struct {
+	name string
+	path string
+}
+
+type xlsxRichString struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (s *xlsxRichString) String() string {
+	if len(s.Runs) == 0 {
+		return s.Text
+	}
+	var b strings.Builder
+	b.WriteString(s.Text)
+	for _, r := range s.Runs {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+type xlsxCell struct {
+	Ref     string          `xml:"r,attr"`
+	Type    string          `xml:"t,attr"`
+	Style   int             `xml:"s,attr"`
+	Formula *string         `xml:"f"`
+	Value   *string         `xml:"v"`
+	Inline  *xlsxRichString `xml:"is"`
+}
+
+type xlsxRow struct {
+	Num   int        `xml:"r,attr"`
+	Cells []xlsxCell `xml:"c"`
+}
+
+func xlsxReadXML(zr *zip.Reader, name string, v any) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%v: %w", name, err)
+	}
+	return nil
+}
+
+func newXLSXWorkbook(data []byte) (*xlsxWorkbook, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+	w := &xlsxWorkbook{zr: zr}
+
+	var wb struct {
+		Properties struct {
+			Date1904 bool `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xlsxReadXML(zr, "xl/workbook.xml", &wb); err != nil {
+		return nil, err
+	}
+	w.date1904 = wb.Properties.Date1904
+
+	var rels struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xlsxReadXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := map[string]string{}
+	for _, r := range rels.Rels {
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+	for _, s := range wb.Sheets {
+		p, exists := targets[s.RID]
+		if !exists {
+			return nil, fmt.Errorf("sheet %v does not have a relationship", s.Name)
+		}
+		w.sheets = append(w.sheets, xlsxSheetRef{name: s.Name, path: p})
+	}
+
+	// Shared strings and styles are optional parts of a workbook.
+	var sst struct {
+		Items []xlsxRichString `xml:"si"`
+	}
+	if err := xlsxReadXML(zr, "xl/sharedStrings.xml", &sst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for i := range sst.Items {
+		w.strings = append(w.strings, sst.Items[i].String())
+	}
+
+	var styles struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellXfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err := xlsxReadXML(zr, "xl/styles.xml", &styles); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	customDates := map[int]bool{}
+	for _, f := range styles.NumFmts {
+		customDates[f.ID] = xlsxIsDateFormat(f.Code)
+	}
+	for _, xf := range styles.CellXfs {
+		isDate, custom := customDates[xf.NumFmtID]
+		if !custom {
+			isDate = xlsxIsBuiltinDateFormat(xf.NumFmtID)
+		}
+		w.dateStyle = append(w.dateStyle, isDate)
+	}
+	return w, nil
+}
+
+func xlsxIsBuiltinDateFormat(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 27 && id <= 36) || (id >= 45 && id <= 47) || (id >= 50 && id <= 58)
+}
+
+// xlsxIsDateFormat returns whether a custom number format displays a date or
+// time, ignoring literal text and bracketed sections such as colours.
+func xlsxIsDateFormat(code string) bool {
+	inQuotes, inBrackets := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case inQuotes:
+			inQuotes = c != '"'
+		case inBrackets:
+			inBrackets = c != ']'
+		case c == '"':
+			inQuotes = true
+		case c == '[':
+			inBrackets = true
+		case c == '\\' || c == '_' || c == '*':
+			i++
+		case strings.IndexByte("yYmMdDhHsS", c) != -1:
+			return true
+		}
+	}
+	return false
+}
+
+const xlsxMaxColumns = 16384
+
+// xlsxColumn returns the zero based column index of a cell reference such as
+// `AB12`.
+func xlsxColumn(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref); i++ {
+		c := ref[i]
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		if col = col*26 + int(c-'A'+1); col > xlsxMaxColumns {
+			return 0, fmt.Errorf("cell reference exceeds the maximum column: %v", ref)
+		}
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("invalid cell reference: %v", ref)
+	}
+	return col - 1, nil
+}
+
+// xlsxColumnName returns the letters of a zero based column index.
+func xlsxColumnName(col int) string {
+	var b []byte
+	for col++; col > 0; col = (col - 1) / 26 {
+		b = append([]byte{byte('A' + (col-1)%26)}, b...)
+	}
+	return string(b)
+}
+
+func (w *xlsxWorkbook) sheet(name string) (xlsxSheetRef, bool) {
+	for _, s := range w.sheets {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return xlsxSheetRef{}, false
+}
+
+// rows returns the rows of a sheet, where each row is indexed by column and
+// missing cells are nil.
+func (w *xlsxWorkbook) rows(s xlsxSheetRef, formulas bool) ([]int, [][]any, error) {
+	var sheet struct {
+		Rows []xlsxRow `xml:"sheetData>row"`
+	}
+	if err := xlsxReadXML(w.zr, s.path, &sheet); err != nil {
+		return nil, nil, err
+	}
+
+	nums := make([]int, 0, len(sheet.Rows))
+	rows := make([][]any, 0, len(sheet.Rows))
+	for i, r := range sheet.Rows {
+		num := r.Num
+		if num == 0 {
+			num = 1
+			if i > 0 {
+				num = nums[i-1] + 1
+			}
+		}
+
+		var values []any
+		for _, c := range r.Cells {
+			col := len(values)
+			if c.Ref != "" {
+				var err error
+				if col, err = xlsxColumn(c.Ref); err != nil {
+					return nil, nil, fmt.Errorf("sheet %v row %v: %w", s.name, num, err)
+				}
+			}
+			v, err := w.cellValue(c, formulas)
+			if err != nil {
+				return nil, nil, fmt.Errorf("sheet %v cell %v%v: %w", s.name, xlsxColumnName(col), num, err)
+			}
+			for len(values) <= col {
+				values = append(values, nil)
+			}
+			values[col] = v
+		}
+
+		nums = append(nums, num)
+		rows = append(rows, values)
+	}
+	return nums, rows, nil
+}
+
+func (w *xlsxWorkbook) cellValue(c xlsxCell, formulas bool) (any, error) {
+	if formulas && c.Formula != nil && *c.Formula != "" {
+		return "=" + *c.Formula, nil
+	}
+
+	if c.Type == "inlineStr" {
+		if c.Inline == nil {
+			return nil, nil
+		}
+		return c.Inline.String(), nil
+	}
+	if c.Value == nil {
+		return nil, nil
+	}
+	v := *c.Value
+
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || i < 0 || i >= len(w.strings) {
+			return nil, fmt.Errorf("invalid shared string index: %v", v)
+		}
+		return w.strings[i], nil
+	case "b":
+		return v == "1", nil
+	case "str", "e", "d":
+		return v, nil
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number: %v", v)
+	}
+	if c.Style >= 0 && c.Style < len(w.dateStyle) && w.dateStyle[c.Style] {
+		return w.serialTime(f).Format(time.RFC3339), nil
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int64(f), nil
+	}
+	return f, nil
+}
+
+// serialTime converts a serial date number into a time, where the epoch of the
+// 1900 date system is offset in order to account for the nonexistent leap day
+// of 1900.
+func (w *xlsxWorkbook) serialTime(f float64) time.Time {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if w.date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(f)
+	secs := math.Round((f - days) * 86400)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(secs) * time.Second)
+}
@@ -0,0 +1,245 @@
+package pure
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/klauspost/compress/flate"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	zipFlagEncrypted      = 0x1
+	zipFlagDataDescriptor = 0x8
+	zipMethodWinZipAES    = 99
+	zipExtraWinZipAES     = 0x9901
+)
+
+var errZipPassword = errors.New("incorrect password")
+
+// readZipFile returns the contents of a file within a zip archive, decrypting
+// it with a password when it is encrypted with either traditional PKWARE
+// encryption (ZipCrypto) or WinZip AES encryption.
+func readZipFile(f *zip.File, password []byte) ([]byte, error) {
+	if f.Flags&zipFlagEncrypted == 0 {
+		fr, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer fr.Close()
+		return io.ReadAll(fr)
+	}
+	if password == nil {
+		return nil, fmt.Errorf("file %v is encrypted and a password was not provided", f.Name)
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	method, checkCRC := f.Method, true
+	if f.Method == zipMethodWinZipAES {
+		var ae2 bool
+		if data, method, ae2, err = zipAESDecrypt(f, data, password); err != nil {
+			return nil, fmt.Errorf("file %v: %w", f.Name, err)
+		}
+		// The CRC of AE-2 files is omitted as the authentication code serves
+		// the same purpose.
+		checkCRC = !ae2
+	} else if data, err = zipCryptoDecrypt(f, data, password); err != nil {
+		return nil, fmt.Errorf("file %v: %w", f.Name, err)
+	}
+
+	switch method {
+	case zip.Store:
+	case zip.Deflate:
+		fr := flate.NewReader(bytes.NewReader(data))
+		defer fr.Close()
+		if data, err = io.ReadAll(fr); err != nil {
+			return nil, fmt.Errorf("file %v: %w", f.Name, err)
+		}
+	default:
+		return nil, fmt.Errorf("file %v: %w", f.Name, zip.ErrAlgorithm)
+	}
+
+	if checkCRC && crc32.ChecksumIEEE(data) != f.CRC32 {
+		return nil, fmt.Errorf("file %v: %w", f.Name, zip.ErrChecksum)
+	}
+	return data, nil
+}
+
+//------------------------------------------------------------------------------
+
+// zipCryptoKeys implements the traditional PKWARE encryption cipher.
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password []byte) *zipCryptoKeys {
+	k := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for _, b := range password {
+		k.update(b)
+	}
+	return k
+}
+
+func zipCRC32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ (crc >> 8)
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = zipCRC32Update(k[0], b)
+	k[1] += k[0] & 0xff
+	k[1] = k[1]*134775813 + 1
+	k[2] = zipCRC32Update(k[2], byte(k[1]>>24))
+}
+
+func (k *zipCryptoKeys) streamByte() byte {
+	t := k[2] | 2
+	return byte((t * (t ^ 1)) >> 8)
+}
+
+func (k *zipCryptoKeys) decrypt(b []byte) {
+	for i, c := range b {
+		b[i] = c ^ k.streamByte()
+		k.update(b[i])
+	}
+}
+
+func zipCryptoDecrypt(f *zip.File, data, password []byte) ([]byte, error) {
+	if len(data) < 12 {
+		return nil, errors.New("encryption header is truncated")
+	}
+
+	k := newZipCryptoKeys(password)
+	k.decrypt(data)
+
+	// The last byte of the header is used to check the password, and is either
+	// the high byte of the CRC or of the modification time when the CRC is
+	// written after the file data.
+	check := byte(f.CRC32 >> 24)
+	if f.Flags&zipFlagDataDescriptor != 0 {
+		check = byte(f.ModifiedTime >> 8)
+	}
+	if data[11] != check {
+		return nil, errZipPassword
+	}
+	return data[12:], nil
+}
+
+//------------------------------------------------------------------------------
+
+// zipAESStream implements the counter mode used by WinZip AES encryption, where
+// the counter is little endian and begins at one.
+type zipAESStream struct {
+	block   cipher.Block
+	counter [aes.BlockSize]byte
+	stream  [aes.BlockSize]byte
+	used    int
+}
+
+func newZipAESStream(key []byte) (*zipAESStream, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &zipAESStream{block: block, used: aes.BlockSize}, nil
+}
+
+func (s *zipAESStream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.used == aes.BlockSize {
+			for j := range s.counter {
+				s.counter[j]++
+				if s.counter[j] != 0 {
+					break
+				}
+			}
+			s.block.Encrypt(s.stream[:], s.counter[:])
+			s.used = 0
+		}
+		dst[i] = src[i] ^ s.stream[s.used]
+		s.used++
+	}
+}
+
+// zipAESKeys derives the encryption key, authentication key and password
+// verification value of WinZip AES encryption.
+func zipAESKeys(password, salt []byte, keyLen int) (encKey, authKey, verify []byte) {
+	derived := pbkdf2.Key(password, salt, 1000, 2*keyLen+2, sha1.New)
+	return derived[:keyLen], derived[keyLen : 2*keyLen], derived[2*keyLen:]
+}
+
+// zipAESExtra parses the WinZip AES extra field of a file, returning the key
+// length, the compression method of the file and whether it is AE-2.
+func zipAESExtra(extra []byte) (keyLen int, method uint16, ae2 bool, err error) {
+	for len(extra) >= 4 {
+		id, size := binary.LittleEndian.Uint16(extra), int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		if id == zipExtraWinZipAES && size >= 7 {
+			field := extra[:size]
+			switch field[4] {
+			case 1:
+				keyLen = 16
+			case 2:
+				keyLen = 24
+			case 3:
+				keyLen = 32
+			default:
+				return 0, 0, false, fmt.Errorf("unsupported AES strength: %v", field[4])
+			}
+			return keyLen, binary.LittleEndian.Uint16(field[5:]), binary.LittleEndian.Uint16(field) == 2, nil
+		}
+		extra = extra[size:]
+	}
+	return 0, 0, false, errors.New("AES extra field is missing")
+}
+
+func zipAESDecrypt(f *zip.File, data, password []byte) ([]byte, uint16, bool, error) {
+	keyLen, method, ae2, err := zipAESExtra(f.Extra)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	saltLen := keyLen / 2
+	if len(data) < saltLen+2+10 {
+		return nil, 0, false, errors.New("encrypted data is truncated")
+	}
+	salt, verify := data[:saltLen], data[saltLen:saltLen+2]
+	content, authCode := data[saltLen+2:len(data)-10], data[len(data)-10:]
+
+	encKey, authKey, expVerify := zipAESKeys(password, salt, keyLen)
+	if subtle.ConstantTimeCompare(verify, expVerify) != 1 {
+		return nil, 0, false, errZipPassword
+	}
+
+	mac := hmac.New(sha1.New, authKey)
+	_, _ = mac.Write(content)
+	if !hmac.Equal(mac.Sum(nil)[:10], authCode) {
+		return nil, 0, false, errors.New("authentication code does not match")
+	}
+
+	stream, err := newZipAESStream(encKey)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	plain := make([]byte, len(content))
+	stream.XORKeyStream(plain, content)
+	return plain, method, ae2, nil
+}
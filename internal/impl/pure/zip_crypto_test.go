@@ -0,0 +1,192 @@
+package pure
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/klauspost/compress/flate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func deflateBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	require.NoError(t, err)
+	_, err = fw.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, fw.Close())
+	return buf.Bytes()
+}
+
+func writeZipCryptoFile(t *testing.T, zw *zip.Writer, name string, content, password []byte) {
+	t.Helper()
+
+	crc := crc32.ChecksumIEEE(content)
+	plain := append([]byte("0123456789a"), byte(crc>>24))
+	plain = append(plain, deflateBytes(t, content)...)
+
+	k := newZipCryptoKeys(password)
+	encrypted := make([]byte, len(plain))
+	for i, p := range plain {
+		encrypted[i] = p ^ k.streamByte()
+		k.update(p)
+	}
+
+	fw, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             zip.Deflate,
+		Flags:              zipFlagEncrypted,
+		CRC32:              crc,
+		CompressedSize64:   uint64(len(encrypted)),
+		UncompressedSize64: uint64(len(content)),
+	})
+	require.NoError(t, err)
+	_, err = fw.Write(encrypted)
+	require.NoError(t, err)
+}
+
+func writeZipAESFile(t *testing.T, zw *zip.Writer, name string, content, password []byte) {
+	t.Helper()
+
+	salt := []byte("0123456789abcdef")
+	encKey, authKey, verify := zipAESKeys(password, salt, 32)
+
+	compressed := deflateBytes(t, content)
+	stream, err := newZipAESStream(encKey)
+	require.NoError(t, err)
+	encrypted := make([]byte, len(compressed))
+	stream.XORKeyStream(encrypted, compressed)
+
+	mac := hmac.New(sha1.New, authKey)
+	_, _ = mac.Write(encrypted)
+
+	data := append(append([]byte{}, salt...), verify...)
+	data = append(data, encrypted...)
+	data = append(data, mac.Sum(nil)[:10]...)
+
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra, zipExtraWinZipAES)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], 2)
+	copy(extra[6:], "AE")
+	extra[8] = 3
+	binary.LittleEndian.PutUint16(extra[9:], zip.Deflate)
+
+	fw, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             zipMethodWinZipAES,
+		Flags:              zipFlagEncrypted,
+		Extra:              extra,
+		CompressedSize64:   uint64(len(data)),
+		UncompressedSize64: uint64(len(content)),
+	})
+	require.NoError(t, err)
+	_, err = fw.Write(data)
+	require.NoError(t, err)
+}
+
+func encryptedTestZip(t *testing.T, password string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	writeZipCryptoFile(t, zw, "zipcrypto.txt", []byte("hello from zipcrypto"), []byte(password))
+	writeZipAESFile(t, zw, "aes.txt", []byte("hello from aes"), []byte(password))
+
+	fw, err := zw.Create("plain.txt")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("hello from plain"))
+	require.NoError(t, err)
+
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestUnarchiveZipEncrypted(t *testing.T) {
+	archive := encryptedTestZip(t, "hunter2")
+
+	conf, err := unarchiveProcConfig().ParseYAML(`
+format: zip
+password: hunter2
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newUnarchiveFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	msgs, err := proc.Process(context.Background(), service.NewMessage(archive))
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+
+	for i, exp := range []string{"zipcrypto", "aes", "plain"} {
+		name, _ := msgs[i].MetaGet("archive_filename")
+		assert.Equal(t, exp+".txt", name)
+
+		b, err := msgs[i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, "hello from "+exp, string(b))
+	}
+}
+
+func TestUnarchiveZipEncryptedErrors(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		archive  []byte
+		password string
+		errStr   string
+	}{
+		{
+			name:     "wrong password zipcrypto",
+			archive:  encryptedTestZip(t, "hunter2"),
+			password: "nope",
+			errStr:   "zipcrypto.txt: incorrect password",
+		},
+		{
+			name:    "no password",
+			archive: encryptedTestZip(t, "hunter2"),
+			errStr:  "a password was not provided",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var password []byte
+			if test.password != "" {
+				password = []byte(test.password)
+			}
+			proc, err := newUnarchive(service.MockResources(), "zip", password)
+			require.NoError(t, err)
+
+			_, err = proc.Process(context.Background(), service.NewMessage(test.archive))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errStr)
+		})
+	}
+
+	// AES passwords are verified independently of ZipCrypto.
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	writeZipAESFile(t, zw, "aes.txt", []byte("hello"), []byte("hunter2"))
+	require.NoError(t, zw.Close())
+
+	proc, err := newUnarchive(service.MockResources(), "zip", []byte("nope"))
+	require.NoError(t, err)
+	_, err = proc.Process(context.Background(), service.NewMessage(buf.Bytes()))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "aes.txt: incorrect password")
+
+	// Passwords are only supported by zip.
+	_, err = newUnarchive(service.MockResources(), "tar", []byte("nope"))
+	require.Error(t, err)
+}
@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -35,6 +36,20 @@ func redisCacheConfig() *service.ConfigSpec {
 			Optional().
 			Advanced()).
 		Field(service.NewBackOffField("retries", false, retriesDefaults).
+			Advanced()).
+		Field(service.NewObjectField("client_side_cache",
+			service.NewBoolField("enabled").
+				Description("Whether to hold items in memory.").
+				Default(false),
+			service.NewIntField("max_keys").
+				Description("The maximum number of items to hold in memory, once reached an arbitrary item is evicted in order to make room for a new one. Set to 0 for no limit.").
+				Default(10000),
+			service.NewDurationField("ttl").
+				Description("The maximum period of time to hold an item in memory, which limits how long a stale item could be served should an invalidation message be missed.").
+				Default("1m"),
+		).
+			Description("Hold items read from Redis in memory, using the [client-side caching](https://redis.io/docs/manual/client-side-caching/) feature of Redis 6 and above in order to evict items once they are modified. Invalidation messages are received over a dedicated RESP3 connection, and items are only held in memory whilst that connection is healthy. Client-side caching is only supported when `kind` is `simple`.").
+			Version("4.20.0").
 			Advanced())
 
 	return spec
@@ -44,15 +59,20 @@ func init() {
 	err := service.RegisterCache(
 		"redis", redisCacheConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Cache, error) {
-			return newRedisCacheFromConfig(conf)
+			return newRedisCacheFromConfig(conf, mgr)
 		})
 	if err != nil {
 		panic(err)
 	}
 }
 
-func newRedisCacheFromConfig(conf *service.ParsedConfig) (*redisCache, error) {
-	client, err := getClient(conf)
+func newRedisCacheFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*redisCache, error) {
+	kind, opts, err := getClientOptions(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newClient(kind, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -77,16 +97,43 @@ func newRedisCacheFromConfig(conf *service.ParsedConfig) (*redisCache, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newRedisCache(ttl, prefix, client, backOff)
+
+	r, err := newRedisCache(ttl, prefix, client, backOff)
+	if err != nil {
+		return nil, err
+	}
+
+	cscConf := conf.Namespace("client_side_cache")
+	if enabled, err := cscConf.FieldBool("enabled"); err != nil || !enabled {
+		return r, err
+	}
+	if kind != "simple" {
+		return nil, fmt.Errorf("client_side_cache is not supported with a %v client", kind)
+	}
+
+	maxKeys, err := cscConf.FieldInt("max_keys")
+	if err != nil {
+		return nil, err
+	}
+	localTTL, err := cscConf.FieldDuration("ttl")
+	if err != nil {
+		return nil, err
+	}
+	r.enableClientSideCache(opts.Simple(), maxKeys, localTTL, mgr.Logger())
+	return r, nil
 }
 
 //------------------------------------------------------------------------------
 
 type redisCache struct {
 	client     redis.UniversalClient
+	clientMut  sync.RWMutex
 	defaultTTL time.Duration
 	prefix     string
 
+	local       *localCache
+	stopTracker context.CancelFunc
+
 	boffPool sync.Pool
 }
 
@@ -110,6 +157,43 @@ func newRedisCache(
 	}, nil
 }
 
+// enableClientSideCache begins tracking the keys read from Redis so that items
+// can be held in memory.
+func (r *redisCache) enableClientSideCache(opts *redis.Options, maxKeys int, ttl time.Duration, log *service.Logger) {
+	r.local = newLocalCache(maxKeys, ttl)
+
+	var ctx context.Context
+	ctx, r.stopTracker = context.WithCancel(context.Background())
+
+	tracker := &invalidationTracker{
+		opts:  opts,
+		cache: r.local,
+		log:   log,
+		onTracking: func(id int64) {
+			clientOpts := *opts
+			if id != 0 {
+				clientOpts.OnConnect = enableTracking(id)
+			}
+			r.swapClient(redis.NewClient(&clientOpts))
+		},
+	}
+	go tracker.run(ctx)
+}
+
+func (r *redisCache) getClient() redis.UniversalClient {
+	r.clientMut.RLock()
+	defer r.clientMut.RUnlock()
+	return r.client
+}
+
+func (r *redisCache) swapClient(client redis.UniversalClient) {
+	r.clientMut.Lock()
+	old := r.client
+	r.client = client
+	r.clientMut.Unlock()
+	_ = old.Close()
+}
+
 func (r *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
 	boff := r.boffPool.Get().(backoff.BackOff)
 	defer func() {
@@ -121,9 +205,20 @@ func (r *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
 		key = r.prefix + key
 	}
 
+	var fetchToken uint64
+	if r.local != nil {
+		if value, exists := r.local.get(key); exists {
+			return value, nil
+		}
+		fetchToken = r.local.beginFetch(key)
+	}
+
 	for {
-		res, err := r.client.Get(ctx, key).Result()
+		res, err := r.getClient().Get(ctx, key).Result()
 		if err == nil {
+			if r.local != nil {
+				r.local.finishFetch(key, fetchToken, []byte(res))
+			}
 			return []byte(res), nil
 		}
 
@@ -154,6 +249,10 @@ func (r *redisCache) Set(ctx context.Context, key string, value []byte, ttl *tim
 		key = r.prefix + key
 	}
 
+	if r.local != nil {
+		r.local.invalidate(key)
+	}
+
 	var t time.Duration
 	if ttl != nil {
 		t = *ttl
@@ -162,7 +261,7 @@ func (r *redisCache) Set(ctx context.Context, key string, value []byte, ttl *tim
 	}
 
 	for {
-		err := r.client.Set(ctx, key, value, t).Err()
+		err := r.getClient().Set(ctx, key, value, t).Err()
 		if err == nil {
 			return nil
 		}
@@ -190,6 +289,10 @@ func (r *redisCache) Add(ctx context.Context, key string, value []byte, ttl *tim
 		key = r.prefix + key
 	}
 
+	if r.local != nil {
+		r.local.invalidate(key)
+	}
+
 	var t time.Duration
 
 	if ttl != nil {
@@ -199,7 +302,7 @@ func (r *redisCache) Add(ctx context.Context, key string, value []byte, ttl *tim
 	}
 
 	for {
-		set, err := r.client.SetNX(ctx, key, value, t).Result()
+		set, err := r.getClient().SetNX(ctx, key, value, t).Result()
 		if err == nil {
 			if !set {
 				return service.ErrKeyAlreadyExists
@@ -230,8 +333,12 @@ func (r *redisCache) Delete(ctx context.Context, key string) error {
 		key = r.prefix + key
 	}
 
+	if r.local != nil {
+		r.local.invalidate(key)
+	}
+
 	for {
-		_, err := r.client.Del(ctx, key).Result()
+		_, err := r.getClient().Del(ctx, key).Result()
 		if err == nil {
 			return nil
 		}
@@ -249,5 +356,8 @@ func (r *redisCache) Delete(ctx context.Context, key string) error {
 }
 
 func (r *redisCache) Close(ctx context.Context) error {
-	return r.client.Close()
+	if r.stopTracker != nil {
+		r.stopTracker()
+	}
+	return r.getClient().Close()
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 func TestIntegrationRedisCache(t *testing.T) {
@@ -39,7 +40,7 @@ func TestIntegrationRedisCache(t *testing.T) {
 			return cErr
 		}
 
-		r, cErr := newRedisCacheFromConfig(pConf)
+		r, cErr := newRedisCacheFromConfig(pConf, service.MockResources())
 		if cErr != nil {
 			return cErr
 		}
@@ -66,6 +67,55 @@ cache_resources:
 		t, template,
 		integration.CacheTestOptPort(resource.GetPort("6379/tcp")),
 	)
+
+	t.Run("client side cache", func(t *testing.T) {
+		suite.Run(
+			t, template+`
+      client_side_cache:
+        enabled: true
+`,
+			integration.CacheTestOptPort(resource.GetPort("6379/tcp")),
+		)
+	})
+
+	t.Run("client side cache invalidation", func(t *testing.T) {
+		url := fmt.Sprintf("tcp://localhost:%v/1", resource.GetPort("6379/tcp"))
+
+		newCache := func(confStr string) *redisCache {
+			pConf, err := redisCacheConfig().ParseYAML(confStr, nil)
+			require.NoError(t, err)
+
+			r, err := newRedisCacheFromConfig(pConf, service.MockResources())
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = r.Close(context.Background())
+			})
+			return r
+		}
+
+		local := newCache(fmt.Sprintf(`
+url: %v
+client_side_cache:
+  enabled: true
+`, url))
+		remote := newCache(fmt.Sprintf(`url: %v`, url))
+
+		ctx := context.Background()
+		require.NoError(t, remote.Set(ctx, "csc_foo", []byte("first"), nil))
+
+		require.Eventually(t, func() bool {
+			_, _ = local.Get(ctx, "csc_foo")
+			_, exists := local.local.get("csc_foo")
+			return exists
+		}, time.Second*10, time.Millisecond*50)
+
+		require.NoError(t, remote.Set(ctx, "csc_foo", []byte("second"), nil))
+
+		assert.Eventually(t, func() bool {
+			v, err := local.Get(ctx, "csc_foo")
+			return err == nil && string(v) == "second"
+		}, time.Second*10, time.Millisecond*50)
+	})
 }
 
 func TestIntegrationRedisClusterCache(t *testing.T) {
@@ -131,7 +181,7 @@ kind: cluster
 			return cErr
 		}
 
-		r, cErr := newRedisCacheFromConfig(pConf)
+		r, cErr := newRedisCacheFromConfig(pConf, service.MockResources())
 		if cErr != nil {
 			return cErr
 		}
@@ -243,7 +293,7 @@ master: mymaster
 			return cErr
 		}
 
-		r, cErr := newRedisCacheFromConfig(pConf)
+		r, cErr := newRedisCacheFromConfig(pConf, service.MockResources())
 		if cErr != nil {
 			return cErr
 		}
@@ -0,0 +1,433 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// localCache holds copies of items read from Redis whilst their keys are
+// tracked by the server for client-side caching, and evicts them when the
+// server reports that they have been modified.
+type localCache struct {
+	maxKeys int
+	ttl     time.Duration
+	nowFn   func() time.Time
+
+	mut       sync.Mutex
+	enabled   bool
+	items     map[string]localCacheItem
+	pending   map[string]uint64
+	nextToken uint64
+}
+
+type localCacheItem struct {
+	value   []byte
+	expires time.Time
+}
+
+func newLocalCache(maxKeys int, ttl time.Duration) *localCache {
+	return &localCache{
+		maxKeys: maxKeys,
+		ttl:     ttl,
+		nowFn:   time.Now,
+		items:   map[string]localCacheItem{},
+		pending: map[string]uint64{},
+	}
+}
+
+// get returns the local copy of an item if one exists.
+func (l *localCache) get(key string) ([]byte, bool) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	item, exists := l.items[key]
+	if !exists {
+		return nil, false
+	}
+	if l.ttl > 0 && !l.nowFn().Before(item.expires) {
+		delete(l.items, key)
+		return nil, false
+	}
+	return item.value, true
+}
+
+// beginFetch marks a key as being read from the server and returns a token
+// that must be provided in order to store the result. A token of zero is
+// returned when the cache is disabled.
+func (l *localCache) beginFetch(key string) uint64 {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if !l.enabled {
+		return 0
+	}
+	l.nextToken++
+	l.pending[key] = l.nextToken
+	return l.nextToken
+}
+
+// finishFetch stores the value read from the server for a key, unless the key
+// was invalidated whilst it was being read.
+func (l *localCache) finishFetch(key string, token uint64, value []byte) {
+	if token == 0 {
+		return
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if l.pending[key] != token {
+		return
+	}
+	delete(l.pending, key)
+
+	if _, exists := l.items[key]; !exists && l.maxKeys > 0 && len(l.items) >= l.maxKeys {
+		for k := range l.items {
+			delete(l.items, k)
+			break
+		}
+	}
+	l.items[key] = localCacheItem{
+		value:   value,
+		expires: l.nowFn().Add(l.ttl),
+	}
+}
+
+// invalidate evicts keys from the cache, including those that are currently
+// being read from the server.
+func (l *localCache) invalidate(keys ...string) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	for _, k := range keys {
+		delete(l.items, k)
+		delete(l.pending, k)
+	}
+}
+
+// setEnabled evicts all keys from the cache and sets whether new items can be
+// stored.
+func (l *localCache) setEnabled(enabled bool) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.enabled = enabled
+	l.items = map[string]localCacheItem{}
+	l.pending = map[string]uint64{}
+}
+
+//------------------------------------------------------------------------------
+
+// respPush is a RESP3 push message, which is sent by the server out of band.
+type respPush []any
+
+// respError is an error reply.
+type respError string
+
+func (e respError) Error() string {
+	return string(e)
+}
+
+// readRESP reads a single RESP2 or RESP3 value, where aggregates are returned
+// as slices (maps as alternating keys and values) and attributes are
+// discarded.
+func readRESP(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed line: %q", line)
+	}
+	kind, line := line[0], line[1:len(line)-2]
+
+	readBlob := func() (string, bool, error) {
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return "", false, err
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return "", false, err
+		}
+		return string(b[:n]), true, nil
+	}
+
+	readAggregate := func(multiplier int) ([]any, error) {
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]any, 0, n*multiplier)
+		for i := 0; i < n*multiplier; i++ {
+			v, err := readRESP(r)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+
+	switch kind {
+	case '+', ',', '(':
+		return line, nil
+	case '-':
+		return respError(line), nil
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '#':
+		return line == "t", nil
+	case '_':
+		return nil, nil
+	case '$', '=':
+		s, exists, err := readBlob()
+		if err != nil || !exists {
+			return nil, err
+		}
+		if kind == '=' && len(s) >= 4 {
+			s = s[4:]
+		}
+		return s, nil
+	case '!':
+		s, _, err := readBlob()
+		if err != nil {
+			return nil, err
+		}
+		return respError(s), nil
+	case '*', '~':
+		values, err := readAggregate(1)
+		if err != nil || values == nil {
+			return nil, err
+		}
+		return values, nil
+	case '>':
+		values, err := readAggregate(1)
+		if err != nil {
+			return nil, err
+		}
+		return respPush(values), nil
+	case '%':
+		values, err := readAggregate(2)
+		if err != nil || values == nil {
+			return nil, err
+		}
+		return values, nil
+	case '|':
+		if _, err := readAggregate(2); err != nil {
+			return nil, err
+		}
+		return readRESP(r)
+	}
+	return nil, fmt.Errorf("unrecognised RESP type: %q", kind)
+}
+
+func writeRESPCommand(w *bufio.Writer, args ...string) error {
+	_, _ = w.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		_, _ = w.WriteString("$" + strconv.Itoa(len(a)) + "\r\n" + a + "\r\n")
+	}
+	return w.Flush()
+}
+
+//------------------------------------------------------------------------------
+
+const trackingPingInterval = time.Second * 30
+
+// invalidationTracker maintains a dedicated RESP3 connection to the server
+// that receives the invalidation messages of client-side caching, which are
+// redirected to it from the connections of a client.
+type invalidationTracker struct {
+	opts  *redis.Options
+	cache *localCache
+	log   *service.Logger
+
+	// Called with the ID of the tracking connection each time it is
+	// established, which should replace the client with one where each
+	// connection has tracking enabled with a redirect to the ID. When the
+	// tracking connection fails it is called with an ID of zero, and the client
+	// should be replaced with one that does not enable tracking, as connections
+	// with a broken redirect receive push messages that the client does not
+	// expect.
+	onTracking func(id int64)
+}
+
+// run maintains the tracking connection until the context is cancelled.
+func (t *invalidationTracker) run(ctx context.Context) {
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = time.Millisecond * 100
+	boff.MaxInterval = time.Second * 5
+	boff.MaxElapsedTime = 0
+
+	for {
+		start := time.Now()
+		established, err := t.track(ctx)
+		t.cache.setEnabled(false)
+		if ctx.Err() != nil {
+			return
+		}
+		if established {
+			t.onTracking(0)
+		}
+		if time.Since(start) > boff.MaxInterval {
+			boff.Reset()
+		}
+		t.log.Errorf("Client-side cache invalidation connection failed, local cache disabled: %v", err)
+
+		select {
+		case <-time.After(boff.NextBackOff()):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *invalidationTracker) dial(ctx context.Context) (net.Conn, error) {
+	if t.opts.TLSConfig != nil {
+		dialer := &tls.Dialer{Config: t.opts.TLSConfig}
+		return dialer.DialContext(ctx, "tcp", t.opts.Addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", t.opts.Addr)
+}
+
+// track establishes a tracking connection and processes invalidation messages
+// until it fails, and returns whether tracking was established.
+func (t *invalidationTracker) track(ctx context.Context) (established bool, err error) {
+	conn, err := t.dial(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	connCtx, done := context.WithCancel(ctx)
+	defer done()
+	go func() {
+		<-connCtx.Done()
+		_ = conn.Close()
+	}()
+
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+
+	var writeMut sync.Mutex
+	command := func(args ...string) error {
+		writeMut.Lock()
+		defer writeMut.Unlock()
+		_ = conn.SetWriteDeadline(time.Now().Add(trackingPingInterval))
+		return writeRESPCommand(w, args...)
+	}
+	reply := func() (any, error) {
+		for {
+			_ = conn.SetReadDeadline(time.Now().Add(trackingPingInterval * 2))
+			v, err := readRESP(r)
+			if err != nil {
+				return nil, err
+			}
+			if rErr, ok := v.(respError); ok {
+				return nil, rErr
+			}
+			if _, isPush := v.(respPush); !isPush {
+				return v, nil
+			}
+		}
+	}
+
+	hello := []string{"HELLO", "3"}
+	if t.opts.Password != "" {
+		user := t.opts.Username
+		if user == "" {
+			user = "default"
+		}
+		hello = append(hello, "AUTH", user, t.opts.Password)
+	}
+	if err := command(hello...); err != nil {
+		return false, err
+	}
+	if _, err := reply(); err != nil {
+		return false, fmt.Errorf("failed to negotiate RESP3, which is required for client-side caching: %w", err)
+	}
+
+	if err := command("CLIENT", "ID"); err != nil {
+		return false, err
+	}
+	v, err := reply()
+	if err != nil {
+		return false, err
+	}
+	id, ok := v.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected client ID reply: %T", v)
+	}
+
+	t.onTracking(id)
+	t.cache.setEnabled(true)
+
+	go func() {
+		ticker := time.NewTicker(trackingPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := command("PING"); err != nil {
+					done()
+					return
+				}
+			case <-connCtx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(trackingPingInterval * 2))
+		v, err := readRESP(r)
+		if err != nil {
+			return true, err
+		}
+		push, ok := v.(respPush)
+		if !ok || len(push) != 2 {
+			continue
+		}
+		if kind, _ := push[0].(string); kind != "invalidate" {
+			continue
+		}
+		// A null list of keys indicates that the database has been flushed.
+		keys, ok := push[1].([]any)
+		if !ok {
+			t.cache.setEnabled(true)
+			continue
+		}
+		strKeys := make([]string, 0, len(keys))
+		for _, k := range keys {
+			if s, ok := k.(string); ok {
+				strKeys = append(strKeys, s)
+			}
+		}
+		t.cache.invalidate(strKeys...)
+	}
+}
+
+// enableTracking returns a connection hook that enables tracking on each new
+// connection of a client with invalidation messages redirected to the
+// connection with the provided ID.
+func enableTracking(id int64) func(ctx context.Context, cn *redis.Conn) error {
+	return func(ctx context.Context, cn *redis.Conn) error {
+		cmd := redis.NewStatusCmd(ctx, "client", "tracking", "on", "redirect", id)
+		if err := cn.Process(ctx, cmd); err != nil {
+			return errors.New("failed to enable client tracking: " + err.Error())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,183 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestReadRESP(t *testing.T) {
+	input := "+OK\r\n" +
+		"-ERR nope\r\n" +
+		":42\r\n" +
+		"$5\r\nhello\r\n" +
+		"$-1\r\n" +
+		"_\r\n" +
+		"#t\r\n" +
+		"*2\r\n$1\r\na\r\n:1\r\n" +
+		"%1\r\n+proto\r\n:3\r\n" +
+		"|1\r\n+ttl\r\n:3600\r\n+attributed\r\n" +
+		">2\r\n$10\r\ninvalidate\r\n*1\r\n$3\r\nfoo\r\n" +
+		"=8\r\ntxt:text\r\n"
+
+	r := bufio.NewReader(strings.NewReader(input))
+	for _, exp := range []any{
+		"OK",
+		respError("ERR nope"),
+		int64(42),
+		"hello",
+		nil,
+		nil,
+		true,
+		[]any{"a", int64(1)},
+		[]any{"proto", int64(3)},
+		"attributed",
+		respPush{"invalidate", []any{"foo"}},
+		"text",
+	} {
+		v, err := readRESP(r)
+		require.NoError(t, err)
+		assert.Equal(t, exp, v)
+	}
+
+	_, err := readRESP(r)
+	require.Error(t, err)
+}
+
+func TestLocalCache(t *testing.T) {
+	now := time.Unix(100, 0)
+
+	l := newLocalCache(2, time.Minute)
+	l.nowFn = func() time.Time {
+		return now
+	}
+
+	// Nothing is stored whilst disabled.
+	l.finishFetch("foo", l.beginFetch("foo"), []byte("a"))
+	_, exists := l.get("foo")
+	assert.False(t, exists)
+
+	l.setEnabled(true)
+	l.finishFetch("foo", l.beginFetch("foo"), []byte("a"))
+	v, exists := l.get("foo")
+	require.True(t, exists)
+	assert.Equal(t, "a", string(v))
+
+	// A key invalidated whilst being read is not stored.
+	token := l.beginFetch("bar")
+	l.invalidate("bar")
+	l.finishFetch("bar", token, []byte("b"))
+	_, exists = l.get("bar")
+	assert.False(t, exists)
+
+	l.invalidate("foo")
+	_, exists = l.get("foo")
+	assert.False(t, exists)
+
+	// The number of items is capped.
+	for _, k := range []string{"a", "b", "c"} {
+		l.finishFetch(k, l.beginFetch(k), []byte(k))
+	}
+	assert.Len(t, l.items, 2)
+
+	// Items expire.
+	now = now.Add(time.Minute)
+	_, exists = l.get("c")
+	assert.False(t, exists)
+}
+
+func TestInvalidationTracker(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = lis.Close()
+	})
+
+	pushes := make(chan string)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for _, reply := range []string{
+			"%1\r\n+proto\r\n:3\r\n",
+			":42\r\n",
+		} {
+			if _, err := readRESP(r); err != nil {
+				return
+			}
+			if _, err := conn.Write([]byte(reply)); err != nil {
+				return
+			}
+		}
+		for p := range pushes {
+			if _, err := conn.Write([]byte(p)); err != nil {
+				return
+			}
+		}
+	}()
+
+	cache := newLocalCache(0, time.Minute)
+
+	var idMut sync.Mutex
+	var ids []int64
+	tracker := &invalidationTracker{
+		opts:  &redis.Options{Addr: lis.Addr().String()},
+		cache: cache,
+		log:   service.MockResources().Logger(),
+		onTracking: func(id int64) {
+			idMut.Lock()
+			ids = append(ids, id)
+			idMut.Unlock()
+		},
+	}
+
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	go tracker.run(ctx)
+
+	require.Eventually(t, func() bool {
+		idMut.Lock()
+		defer idMut.Unlock()
+		return len(ids) == 1
+	}, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, []int64{42}, ids)
+
+	for _, k := range []string{"foo", "bar"} {
+		cache.finishFetch(k, cache.beginFetch(k), []byte(k))
+	}
+
+	pushes <- ">2\r\n$10\r\ninvalidate\r\n*1\r\n$3\r\nfoo\r\n"
+	require.Eventually(t, func() bool {
+		_, exists := cache.get("foo")
+		return !exists
+	}, time.Second*5, time.Millisecond*10)
+
+	_, exists := cache.get("bar")
+	assert.True(t, exists)
+
+	// Breaking the connection disables the cache and tracking.
+	close(pushes)
+	require.Eventually(t, func() bool {
+		idMut.Lock()
+		defer idMut.Unlock()
+		return len(ids) == 2
+	}, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, []int64{42, 0}, ids)
+
+	_, exists = cache.get("bar")
+	assert.False(t, exists)
+}
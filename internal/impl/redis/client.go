@@ -28,7 +28,7 @@ Some cloud hosted instances of Redis (such as Azure Cache) might need some hand
 			Example("redis://localhost:6379/1").
 			Example("redis://localhost:6379/1,redis://localhost:6380/1"),
 		service.NewStringEnumField("kind", "simple", "cluster", "failover").
-			Description("Specifies a simple, cluster-aware, or failover-aware redis client.").
+			Description("Specifies a simple, cluster-aware, or failover-aware redis client. A cluster-aware client discovers the topology of the cluster, sends each command (including those of pipelined batches) to the node that owns the hash slot of its key, and follows MOVED and ASK redirects whilst slots are migrated between nodes.").
 			Default("simple").
 			Advanced(),
 		service.NewStringField("master").
@@ -41,24 +41,34 @@ Some cloud hosted instances of Redis (such as Azure Cache) might need some hand
 }
 
 func getClient(parsedConf *service.ParsedConfig) (redis.UniversalClient, error) {
-	urlStr, err := parsedConf.FieldString("url")
+	kind, opts, err := getClientOptions(parsedConf)
 	if err != nil {
 		return nil, err
 	}
+	return newClient(kind, opts)
+}
+
+// getClientOptions returns the kind of client to create along with its
+// options.
+func getClientOptions(parsedConf *service.ParsedConfig) (string, *redis.UniversalOptions, error) {
+	urlStr, err := parsedConf.FieldString("url")
+	if err != nil {
+		return "", nil, err
+	}
 
 	kind, err := parsedConf.FieldString("kind")
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
 	master, err := parsedConf.FieldString("master")
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
 	tlsConf, tlsEnabled, err := parsedConf.FieldTLSToggled("tls")
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 	if !tlsEnabled {
 		tlsConf = nil
@@ -73,7 +83,7 @@ func getClient(parsedConf *service.ParsedConfig) (redis.UniversalClient, error)
 	for _, v := range strings.Split(urlStr, ",") {
 		url, err := url.Parse(v)
 		if err != nil {
-			return nil, err
+			return "", nil, err
 		}
 
 		if url.Scheme == "tcp" {
@@ -82,7 +92,7 @@ func getClient(parsedConf *service.ParsedConfig) (redis.UniversalClient, error)
 
 		rurl, err := redis.ParseURL(url.String())
 		if err != nil {
-			return nil, err
+			return "", nil, err
 		}
 
 		addrs = append(addrs, rurl.Addr)
@@ -90,25 +100,28 @@ func getClient(parsedConf *service.ParsedConfig) (redis.UniversalClient, error)
 		pass = rurl.Password
 	}
 
-	var client redis.UniversalClient
 	opts := &redis.UniversalOptions{
 		Addrs:     addrs,
 		DB:        redisDB,
 		Password:  pass,
 		TLSConfig: tlsConf,
 	}
+	if kind == "failover" {
+		opts.MasterName = master
+	}
+	return kind, opts, nil
+}
 
+func newClient(kind string, opts *redis.UniversalOptions) (client redis.UniversalClient, err error) {
 	switch kind {
 	case "simple":
 		client = redis.NewClient(opts.Simple())
 	case "cluster":
 		client = redis.NewClusterClient(opts.Cluster())
 	case "failover":
-		opts.MasterName = master
 		client = redis.NewFailoverClient(opts.Failover())
 	default:
 		err = fmt.Errorf("invalid redis kind: %s", kind)
 	}
-
-	return client, err
+	return
 }
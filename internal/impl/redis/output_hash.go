@@ -17,13 +17,14 @@ const (
 	hoFieldWalkMetadata = "walk_metadata"
 	hoFieldWalkJSON     = "walk_json_object"
 	hoFieldFields       = "fields"
+	hoFieldBatching     = "batching"
 )
 
 func redisHashOutputConfig() *service.ConfigSpec {
 	return service.NewConfigSpec().
 		Stable().
 		Summary(`Sets Redis hash objects using the HMSET command.`).
-		Description(output.Description(true, false, `
+		Description(output.Description(true, true, `
 The field `+"`key`"+` supports [interpolation functions](/docs/configuration/interpolation#bloblang-queries), allowing you to create a unique key for each message.
 
 The field `+"`fields`"+` allows you to specify an explicit map of field names to interpolated values, also evaluated per message of a batch:
@@ -49,7 +50,9 @@ The order of hash field extraction is as follows:
 2. JSON object (if enabled)
 3. Explicit fields
 
-Where latter stages will overwrite matching field names of a former stage.`)).
+Where latter stages will overwrite matching field names of a former stage.
+
+When messages are batched the hash objects of a batch are set with a single pipeline of commands.`)).
 		Categories("Services").
 		Fields(clientFields()...).
 		Fields(
@@ -66,13 +69,17 @@ Where latter stages will overwrite matching field names of a former stage.`)).
 				Description("A map of key/value pairs to set as hash fields.").
 				Default(map[string]string{}),
 			service.NewOutputMaxInFlightField(),
+			service.NewBatchPolicyField(hoFieldBatching),
 		)
 }
 
 func init() {
-	err := service.RegisterOutput(
+	err := service.RegisterBatchOutput(
 		"redis_hash", redisHashOutputConfig(),
-		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.Output, maxInFlight int, err error) {
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPol service.BatchPolicy, maxInFlight int, err error) {
+			if batchPol, err = conf.FieldBatchPolicy(hoFieldBatching); err != nil {
+				return
+			}
 			if maxInFlight, err = conf.FieldMaxInFlight(); err != nil {
 				return
 			}
@@ -161,44 +168,59 @@ func walkForHashFields(msg *service.Message, fields map[string]any) error {
 	return nil
 }
 
-func (r *redisHashWriter) Write(ctx context.Context, msg *service.Message) error {
-	r.connMut.RLock()
-	client := r.client
-	r.connMut.RUnlock()
-
-	if client == nil {
-		return service.ErrNotConnected
+func (r *redisHashWriter) hashFields(batch service.MessageBatch, i int) (key string, fields map[string]any, err error) {
+	if key, err = batch.TryInterpolatedString(i, r.key); err != nil {
+		return "", nil, fmt.Errorf("key interpolation error: %w", err)
 	}
 
-	key, err := r.key.TryString(msg)
-	if err != nil {
-		return fmt.Errorf("key interpolation error: %w", err)
-	}
-	fields := map[string]any{}
+	fields = map[string]any{}
 	if r.walkMetadata {
-		_ = msg.MetaWalkMut(func(k string, v any) error {
+		_ = batch[i].MetaWalkMut(func(k string, v any) error {
 			fields[k] = v
 			return nil
 		})
 	}
 	if r.walkJSON {
-		if err := walkForHashFields(msg, fields); err != nil {
+		if err := walkForHashFields(batch[i], fields); err != nil {
 			err = fmt.Errorf("failed to walk JSON object: %v", err)
 			r.log.Errorf("HMSET error: %v\n", err)
-			return err
+			return "", nil, err
 		}
 	}
 	for k, v := range r.fields {
-		if fields[k], err = v.TryString(msg); err != nil {
-			return fmt.Errorf("field %v interpolation error: %w", k, err)
+		if fields[k], err = batch.TryInterpolatedString(i, v); err != nil {
+			return "", nil, fmt.Errorf("field %v interpolation error: %w", k, err)
 		}
 	}
-	if err := client.HMSet(ctx, key, fields).Err(); err != nil {
-		_ = r.disconnect()
-		r.log.Errorf("Error from redis: %v\n", err)
+	return key, fields, nil
+}
+
+func (r *redisHashWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	r.connMut.RLock()
+	client := r.client
+	r.connMut.RUnlock()
+
+	if client == nil {
 		return service.ErrNotConnected
 	}
-	return nil
+
+	if len(batch) == 1 {
+		key, fields, err := r.hashFields(batch, 0)
+		if err != nil {
+			return err
+		}
+		return handleWriteErr(client.HMSet(ctx, key, fields).Err(), r.log, r.disconnect)
+	}
+
+	pipe := client.Pipeline()
+	for i := range batch {
+		key, fields, err := r.hashFields(batch, i)
+		if err != nil {
+			return err
+		}
+		_ = pipe.HMSet(ctx, key, fields)
+	}
+	return execBatchPipeline(ctx, pipe, batch, r.log, r.disconnect)
 }
 
 func (r *redisHashWriter) disconnect() error {
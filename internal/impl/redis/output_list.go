@@ -114,12 +114,7 @@ func (r *redisListWriter) WriteBatch(ctx context.Context, batch service.MessageB
 			return err
 		}
 
-		if err := client.RPush(ctx, key, mBytes).Err(); err != nil {
-			_ = r.disconnect()
-			r.log.Errorf("Error from redis: %v\n", err)
-			return service.ErrNotConnected
-		}
-		return nil
+		return handleWriteErr(client.RPush(ctx, key, mBytes).Err(), r.log, r.disconnect)
 	}
 
 	pipe := client.Pipeline()
@@ -138,26 +133,7 @@ func (r *redisListWriter) WriteBatch(ctx context.Context, batch service.MessageB
 		_ = pipe.RPush(ctx, key, mBytes)
 	}
 
-	cmders, err := pipe.Exec(ctx)
-	if err != nil {
-		_ = r.disconnect()
-		r.log.Errorf("Error from redis: %v\n", err)
-		return service.ErrNotConnected
-	}
-
-	var batchErr *service.BatchError
-	for i, res := range cmders {
-		if res.Err() != nil {
-			if batchErr == nil {
-				batchErr = service.NewBatchError(batch, res.Err())
-			}
-			batchErr.Failed(i, res.Err())
-		}
-	}
-	if batchErr != nil {
-		return batchErr
-	}
-	return nil
+	return execBatchPipeline(ctx, pipe, batch, r.log, r.disconnect)
 }
 
 func (r *redisListWriter) disconnect() error {
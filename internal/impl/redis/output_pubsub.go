@@ -119,12 +119,7 @@ func (r *redisPubSubWriter) WriteBatch(ctx context.Context, batch service.Messag
 			return err
 		}
 
-		if err := client.Publish(ctx, channel, mBytes).Err(); err != nil {
-			_ = r.disconnect()
-			r.log.Errorf("Error from redis: %v\n", err)
-			return service.ErrNotConnected
-		}
-		return nil
+		return handleWriteErr(client.Publish(ctx, channel, mBytes).Err(), r.log, r.disconnect)
 	}
 
 	pipe := client.Pipeline()
@@ -143,26 +138,7 @@ func (r *redisPubSubWriter) WriteBatch(ctx context.Context, batch service.Messag
 		_ = pipe.Publish(ctx, channel, mBytes)
 	}
 
-	cmders, err := pipe.Exec(ctx)
-	if err != nil {
-		_ = r.disconnect()
-		r.log.Errorf("Error from redis: %v\n", err)
-		return service.ErrNotConnected
-	}
-
-	var batchErr *service.BatchError
-	for i, res := range cmders {
-		if res.Err() != nil {
-			if batchErr == nil {
-				batchErr = service.NewBatchError(batch, res.Err())
-			}
-			batchErr.Failed(i, res.Err())
-		}
-	}
-	if batchErr != nil {
-		return batchErr
-	}
-	return nil
+	return execBatchPipeline(ctx, pipe, batch, r.log, r.disconnect)
 }
 
 func (r *redisPubSubWriter) disconnect() error {
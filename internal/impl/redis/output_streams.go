@@ -154,18 +154,14 @@ func (r *redisStreamsWriter) WriteBatch(ctx context.Context, batch service.Messa
 			return err
 		}
 
-		if err := client.XAdd(ctx, &redis.XAddArgs{
+		err = client.XAdd(ctx, &redis.XAddArgs{
 			ID:     "*",
 			Stream: stream,
 			MaxLen: int64(r.maxLen),
 			Approx: true,
 			Values: values,
-		}).Err(); err != nil {
-			_ = r.disconnect()
-			r.log.Errorf("Error from redis: %v\n", err)
-			return service.ErrNotConnected
-		}
-		return nil
+		}).Err()
+		return handleWriteErr(err, r.log, r.disconnect)
 	}
 
 	pipe := client.Pipeline()
@@ -189,26 +185,7 @@ func (r *redisStreamsWriter) WriteBatch(ctx context.Context, batch service.Messa
 		})
 	}
 
-	cmders, err := pipe.Exec(ctx)
-	if err != nil {
-		_ = r.disconnect()
-		r.log.Errorf("Error from redis: %v\n", err)
-		return service.ErrNotConnected
-	}
-
-	var batchErr *service.BatchError
-	for i, res := range cmders {
-		if res.Err() != nil {
-			if batchErr == nil {
-				batchErr = service.NewBatchError(batch, res.Err())
-			}
-			batchErr.Failed(i, res.Err())
-		}
-	}
-	if batchErr != nil {
-		return batchErr
-	}
-	return nil
+	return execBatchPipeline(ctx, pipe, batch, r.log, r.disconnect)
 }
 
 func (r *redisStreamsWriter) disconnect() error {
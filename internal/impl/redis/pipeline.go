@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// isCommandErr returns true if an error was returned by Redis for a specific
+// command, such as a WRONGTYPE error, rather than being caused by a broken
+// connection.
+func isCommandErr(err error) bool {
+	var rErr redis.Error
+	return errors.As(err, &rErr)
+}
+
+// handleWriteErr returns the error of writing a single message, where errors
+// caused by a broken connection result in the client being disconnected.
+func handleWriteErr(err error, log *service.Logger, disconnect func() error) error {
+	if err == nil || isCommandErr(err) {
+		return err
+	}
+	_ = disconnect()
+	log.Errorf("Error from redis: %v\n", err)
+	return service.ErrNotConnected
+}
+
+// execBatchPipeline executes a pipeline where each command corresponds to the
+// message of a batch at the same index. Errors returned by Redis for
+// individual commands are returned as a batch error so that only the failed
+// messages are retried, whereas errors caused by a broken connection result in
+// the client being disconnected.
+//
+// When the client is cluster-aware the commands of the pipeline are sent to the
+// nodes that own the hash slots of their keys, and commands that are
+// redirected with MOVED or ASK errors are retried against the correct node.
+func execBatchPipeline(ctx context.Context, pipe redis.Pipeliner, batch service.MessageBatch, log *service.Logger, disconnect func() error) error {
+	cmders, err := pipe.Exec(ctx)
+	if err != nil && !isCommandErr(err) {
+		return handleWriteErr(err, log, disconnect)
+	}
+
+	var batchErr *service.BatchError
+	for i, res := range cmders {
+		if err := res.Err(); err != nil {
+			if !isCommandErr(err) {
+				return handleWriteErr(err, log, disconnect)
+			}
+			if batchErr == nil {
+				batchErr = service.NewBatchError(batch, err)
+			}
+			batchErr.Failed(i, err)
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
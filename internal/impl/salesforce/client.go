@@ -0,0 +1,350 @@
+package salesforce
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	grantTypeClientCredentials = "client_credentials"
+	grantTypePassword          = "password"
+)
+
+// apiError is an error returned by the Salesforce REST API, where the error
+// code is used to detect rate limiting.
+type apiError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *apiError) Error() string {
+	if e.code != "" {
+		return fmt.Sprintf("request returned status code %v: %v: %v", e.status, e.code, e.message)
+	}
+	return fmt.Sprintf("request returned status code %v: %v", e.status, e.message)
+}
+
+func (e *apiError) rateLimited() bool {
+	return e.status == http.StatusTooManyRequests || e.code == "REQUEST_LIMIT_EXCEEDED"
+}
+
+func parseAPIError(status int, body []byte) *apiError {
+	aErr := &apiError{status: status, message: strings.TrimSpace(string(body))}
+
+	var errs []struct {
+		ErrorCode string `json:"errorCode"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &errs); err == nil && len(errs) > 0 {
+		aErr.code, aErr.message = errs[0].ErrorCode, errs[0].Message
+		return aErr
+	}
+
+	var oErr struct {
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &oErr); err == nil && oErr.Error != "" {
+		aErr.code, aErr.message = oErr.Error, oErr.Description
+	}
+	return aErr
+}
+
+type clientConfig struct {
+	orgURL       string
+	apiVersion   string
+	grantType    string
+	clientID     string
+	clientSecret string
+	username     string
+	password     string
+	rateLimit    string
+	maxRetries   int
+	timeout      time.Duration
+}
+
+// client is a minimal Salesforce REST client that handles authentication,
+// rate limiting and the Bulk API 2.0 query lifecycle.
+type client struct {
+	conf clientConfig
+	log  *service.Logger
+	mgr  *service.Resources
+	http *http.Client
+
+	accessToken string
+	instanceURL string
+
+	retryBackoff time.Duration
+	sleepFn      func(ctx context.Context, d time.Duration) error
+}
+
+func newClient(conf clientConfig, mgr *service.Resources) *client {
+	return &client{
+		conf:         conf,
+		log:          mgr.Logger(),
+		mgr:          mgr,
+		http:         &http.Client{Timeout: conf.timeout},
+		retryBackoff: time.Second,
+		sleepFn:      sleepWithContext,
+	}
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// authenticate obtains a new access token from the OAuth2 token endpoint of
+// the org.
+func (c *client) authenticate(ctx context.Context) error {
+	form := url.Values{}
+	form.Set("grant_type", c.conf.grantType)
+	form.Set("client_id", c.conf.clientID)
+	form.Set("client_secret", c.conf.clientSecret)
+	if c.conf.grantType == grantTypePassword {
+		form.Set("username", c.conf.username)
+		form.Set("password", c.conf.password)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.conf.orgURL, "/")+"/services/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to authenticate: %w", parseAPIError(res.StatusCode, body))
+	}
+
+	var tRes struct {
+		AccessToken string `json:"access_token"`
+		InstanceURL string `json:"instance_url"`
+	}
+	if err := json.Unmarshal(body, &tRes); err != nil {
+		return fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if tRes.AccessToken == "" {
+		return errors.New("token response did not contain an access token")
+	}
+
+	c.accessToken = tRes.AccessToken
+	c.instanceURL = strings.TrimSuffix(tRes.InstanceURL, "/")
+	if c.instanceURL == "" {
+		c.instanceURL = strings.TrimSuffix(c.conf.orgURL, "/")
+	}
+	return nil
+}
+
+func (c *client) waitForAccess(ctx context.Context) error {
+	if c.conf.rateLimit == "" {
+		return nil
+	}
+	for {
+		var period time.Duration
+		var err error
+		if rerr := c.mgr.AccessRateLimit(ctx, c.conf.rateLimit, func(rl service.RateLimit) {
+			period, err = rl.Access(ctx)
+		}); rerr != nil {
+			err = rerr
+		}
+		if err != nil {
+			c.log.Errorf("Rate limit error: %v\n", err)
+			period = time.Second
+		}
+		if period <= 0 {
+			return nil
+		}
+		if err := c.sleepFn(ctx, period); err != nil {
+			return err
+		}
+	}
+}
+
+// retryDelay returns the period to wait before retrying a rate limited
+// request, preferring the Retry-After header when present.
+func (c *client) retryDelay(res *http.Response, attempt int) time.Duration {
+	if secs, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	delay := c.retryBackoff << attempt
+	if delay > time.Minute {
+		delay = time.Minute
+	}
+	return delay
+}
+
+// do performs an API request relative to the instance URL, authenticating
+// when required and retrying requests that were rejected due to rate limits.
+func (c *client) do(ctx context.Context, method, path string, reqBody any) (*http.Response, []byte, error) {
+	var bodyBytes []byte
+	if reqBody != nil {
+		var err error
+		if bodyBytes, err = json.Marshal(reqBody); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	reauthed, retries := false, 0
+	for {
+		if c.accessToken == "" {
+			if err := c.authenticate(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := c.waitForAccess(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, c.instanceURL+path, bytes.NewReader(bodyBytes))
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+		if reqBody != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		res, err := c.http.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		resBody, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		if res.StatusCode >= 200 && res.StatusCode < 300 {
+			return res, resBody, nil
+		}
+
+		// Salesforce access tokens do not advertise an expiry, and therefore
+		// an expired token is only detected by a rejected request.
+		if res.StatusCode == http.StatusUnauthorized && !reauthed {
+			c.accessToken = ""
+			reauthed = true
+			continue
+		}
+
+		aErr := parseAPIError(res.StatusCode, resBody)
+		if !aErr.rateLimited() {
+			return nil, nil, aErr
+		}
+		if retries >= c.conf.maxRetries {
+			return nil, nil, fmt.Errorf("request was rate limited and the maximum number of retries was reached: %w", aErr)
+		}
+		delay := c.retryDelay(res, retries)
+		retries++
+		c.log.Warnf("Request was rate limited, retrying after %v: %v", delay, aErr.message)
+		if err := c.sleepFn(ctx, delay); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+func (c *client) jobsPath(suffix string) string {
+	return "/services/data/" + c.conf.apiVersion + "/jobs/query" + suffix
+}
+
+// createQueryJob creates a Bulk API 2.0 query job and returns its ID.
+func (c *client) createQueryJob(ctx context.Context, soql string) (string, error) {
+	_, body, err := c.do(ctx, http.MethodPost, c.jobsPath(""), map[string]any{
+		"operation": "query",
+		"query":     soql,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create query job: %w", err)
+	}
+	var job struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &job); err != nil {
+		return "", fmt.Errorf("failed to parse query job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// jobState returns the state of a query job, and an error if the job failed.
+func (c *client) jobState(ctx context.Context, id string) (string, error) {
+	_, body, err := c.do(ctx, http.MethodGet, c.jobsPath("/"+id), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get query job: %w", err)
+	}
+	var job struct {
+		State        string `json:"state"`
+		ErrorMessage string `json:"errorMessage"`
+	}
+	if err := json.Unmarshal(body, &job); err != nil {
+		return "", fmt.Errorf("failed to parse query job: %w", err)
+	}
+	if job.State == "Failed" || job.State == "Aborted" {
+		return job.State, fmt.Errorf("query job %v: %v", strings.ToLower(job.State), job.ErrorMessage)
+	}
+	return job.State, nil
+}
+
+// jobResults returns a page of records from a completed query job, along with
+// the locator of the next page, which is empty when there are no more pages.
+func (c *client) jobResults(ctx context.Context, id, locator string, maxRecords int) ([]map[string]any, string, error) {
+	q := url.Values{}
+	q.Set("maxRecords", strconv.Itoa(maxRecords))
+	if locator != "" {
+		q.Set("locator", locator)
+	}
+	res, body, err := c.do(ctx, http.MethodGet, c.jobsPath("/"+id+"/results?"+q.Encode()), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get query job results: %w", err)
+	}
+
+	next := res.Header.Get("Sforce-Locator")
+	if next == "null" {
+		next = ""
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse query job results: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, next, nil
+	}
+
+	header := rows[0]
+	records := make([]map[string]any, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		record := make(map[string]any, len(header))
+		for i, k := range header {
+			if i < len(row) {
+				record[k] = row[i]
+			}
+		}
+		records = append(records, record)
+	}
+	return records, next, nil
+}
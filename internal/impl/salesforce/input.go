@@ -0,0 +1,490 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	sfiFieldOrgURL             = "org_url"
+	sfiFieldAPIVersion         = "api_version"
+	sfiFieldAuth               = "auth"
+	sfiFieldAuthGrantType      = "grant_type"
+	sfiFieldAuthClientID       = "client_id"
+	sfiFieldAuthClientSecret   = "client_secret"
+	sfiFieldAuthUsername       = "username"
+	sfiFieldAuthPassword       = "password"
+	sfiFieldSObject            = "sobject"
+	sfiFieldFields             = "fields"
+	sfiFieldWhere              = "where"
+	sfiFieldCursorField        = "cursor_field"
+	sfiFieldPollInterval       = "poll_interval"
+	sfiFieldPageSize           = "page_size"
+	sfiFieldCheckpointCache    = "checkpoint_cache"
+	sfiFieldCheckpointKey      = "checkpoint_key"
+	sfiFieldRateLimit          = "rate_limit"
+	sfiFieldMaxRetries         = "max_retries"
+	sfiFieldTimeout            = "timeout"
+	sfiDefaultJobCheckInterval = time.Second
+)
+
+func salesforceInputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.20.0").
+		Summary("Queries records of a Salesforce object using the Bulk API 2.0, optionally polling for records that have changed since the previous query.").
+		Description(`
+Records are queried by creating a [Bulk API 2.0 query job](https://developer.salesforce.com/docs/atlas.en-us.api_asynch.meta/api_asynch/queries.htm), waiting for the job to complete and then consuming its results page by page, where each page is emitted as a batch of messages and each record is a JSON object with a field for each of the queried `+"`fields`"+`. Since results are exported as CSV all values are strings, and empty values are empty strings.
+
+### Cursoring
+
+When a `+"`cursor_field`"+` is set, which must be a date time field such as `+"`SystemModstamp`"+` or `+"`LastModifiedDate`"+`, records are ordered by that field and subsequent queries only select records where the field is greater than the greatest value consumed so far. When `+"`poll_interval`"+` is set the object is queried again after each interval, otherwise the input shuts down after the first query has been consumed.
+
+The cursor is held in memory, and can be persisted across restarts by specifying a `+"`checkpoint_cache`"+`. The cursor of a query is only written to the cache once all messages of that query, and any prior queries, have been acknowledged, which results in at-least-once delivery.
+
+### Authentication
+
+Access tokens are obtained using the OAuth 2.0 `+"`client_credentials`"+` flow of a connected app, or the `+"`password`"+` flow with the username and password of a user, where the password must be suffixed with the security token of the user when required by the org. Tokens are obtained again automatically when they expire.
+
+### Rate Limits
+
+Requests rejected due to API request limits are retried with an exponential backoff, or after the period indicated by the `+"`Retry-After`"+` header of the response when present, up to `+"`max_retries`"+` times. Requests can also be throttled in advance with a `+"[`rate_limit`](/docs/components/rate_limits/about)"+` resource.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- salesforce_sobject
+- salesforce_job_id
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).`).
+		Fields(
+			service.NewStringField(sfiFieldOrgURL).
+				Description("The URL of the Salesforce org, which is used for obtaining access tokens.").
+				Example("https://example.my.salesforce.com"),
+			service.NewStringField(sfiFieldAPIVersion).
+				Description("The version of the Salesforce API to use.").
+				Default("v58.0").
+				Advanced(),
+			service.NewObjectField(sfiFieldAuth,
+				service.NewStringEnumField(sfiFieldAuthGrantType, grantTypeClientCredentials, grantTypePassword).
+					Description("The OAuth 2.0 flow used to obtain access tokens.").
+					Default(grantTypeClientCredentials),
+				service.NewStringField(sfiFieldAuthClientID).
+					Description("The consumer key of the connected app."),
+				service.NewStringField(sfiFieldAuthClientSecret).
+					Description("The consumer secret of the connected app.").
+					Secret(),
+				service.NewStringField(sfiFieldAuthUsername).
+					Description("The username to authenticate as when the grant type is `password`.").
+					Default(""),
+				service.NewStringField(sfiFieldAuthPassword).
+					Description("The password to authenticate with when the grant type is `password`.").
+					Default("").
+					Secret(),
+			).
+				Description("Authentication with the Salesforce org."),
+			service.NewStringField(sfiFieldSObject).
+				Description("The object to query records of.").
+				Example("Account").
+				Example("Opportunity"),
+			service.NewStringListField(sfiFieldFields).
+				Description("A list of fields to select for each record.").
+				Example([]string{"Id", "Name", "Industry"}),
+			service.NewStringField(sfiFieldWhere).
+				Description("An optional SOQL condition that records must match.").
+				Default("").
+				Example("Industry = 'Energy'"),
+			service.NewStringField(sfiFieldCursorField).
+				Description("An optional date time field used for consuming only records that have changed since the previous query. Set to an empty string in order to disable cursoring.").
+				Default("SystemModstamp"),
+			service.NewStringField(sfiFieldPollInterval).
+				Description("An optional interval at which to query for new records, when empty the object is queried once.").
+				Default("").
+				Example("5m"),
+			service.NewIntField(sfiFieldPageSize).
+				Description("The maximum number of records to consume within each page of results, which determines the maximum size of each batch.").
+				Default(1000).
+				Advanced(),
+			service.NewStringField(sfiFieldCheckpointCache).
+				Description("An optional [cache resource](/docs/components/caches/about) used for persisting the cursor across restarts.").
+				Default(""),
+			service.NewStringField(sfiFieldCheckpointKey).
+				Description("The key used for storing the cursor within the `checkpoint_cache`.").
+				Default("salesforce_cursor").
+				Advanced(),
+			service.NewStringField(sfiFieldRateLimit).
+				Description("An optional [`rate_limit`](/docs/components/rate_limits/about) resource to throttle API requests by.").
+				Default("").
+				Advanced(),
+			service.NewIntField(sfiFieldMaxRetries).
+				Description("The maximum number of times to retry a request that was rejected due to rate limiting before giving up.").
+				Default(5).
+				Advanced(),
+			service.NewDurationField(sfiFieldTimeout).
+				Description("The maximum period of time to wait for a request to complete.").
+				Default("30s").
+				Advanced(),
+		).
+		Example(
+			"Changed Opportunities",
+			"Poll for opportunities that have changed every five minutes, persisting the cursor in a Redis cache so that restarts do not consume all opportunities again.",
+			`
+input:
+  salesforce:
+    org_url: https://example.my.salesforce.com
+    auth:
+      client_id: ${SF_CLIENT_ID}
+      client_secret: ${SF_CLIENT_SECRET}
+    sobject: Opportunity
+    fields: [ Id, Name, StageName, Amount, SystemModstamp ]
+    where: IsDeleted = false
+    poll_interval: 5m
+    checkpoint_cache: cursors
+
+cache_resources:
+  - label: cursors
+    redis:
+      url: redis://localhost:6379
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput("salesforce", salesforceInputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			i, err := newSalesforceInput(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksBatched(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// queryCycle tracks the acknowledgement of the messages consumed from a single
+// query, such that its cursor is only persisted once they are all delivered.
+type queryCycle struct {
+	cursor      string
+	outstanding int
+	finished    bool
+}
+
+type salesforceInput struct {
+	cli *client
+	log *service.Logger
+	mgr *service.Resources
+
+	sobject          string
+	fields           []string
+	where            string
+	cursorField      string
+	pollInterval     time.Duration
+	pageSize         int
+	checkpointCache  string
+	checkpointKey    string
+	jobCheckInterval time.Duration
+
+	// Only accessed by the reading goroutine.
+	cursor       string
+	cursorLoaded bool
+	queried      bool
+	lastQuery    time.Time
+	jobID        string
+	locator      string
+	current      *queryCycle
+
+	cMut   sync.Mutex
+	cycles []*queryCycle
+}
+
+func newSalesforceInput(conf *service.ParsedConfig, mgr *service.Resources) (*salesforceInput, error) {
+	i := &salesforceInput{
+		log:              mgr.Logger(),
+		mgr:              mgr,
+		jobCheckInterval: sfiDefaultJobCheckInterval,
+	}
+
+	var cConf clientConfig
+	var err error
+	if cConf.orgURL, err = conf.FieldString(sfiFieldOrgURL); err != nil {
+		return nil, err
+	}
+	if cConf.apiVersion, err = conf.FieldString(sfiFieldAPIVersion); err != nil {
+		return nil, err
+	}
+	authConf := conf.Namespace(sfiFieldAuth)
+	if cConf.grantType, err = authConf.FieldString(sfiFieldAuthGrantType); err != nil {
+		return nil, err
+	}
+	if cConf.clientID, err = authConf.FieldString(sfiFieldAuthClientID); err != nil {
+		return nil, err
+	}
+	if cConf.clientSecret, err = authConf.FieldString(sfiFieldAuthClientSecret); err != nil {
+		return nil, err
+	}
+	if cConf.username, err = authConf.FieldString(sfiFieldAuthUsername); err != nil {
+		return nil, err
+	}
+	if cConf.password, err = authConf.FieldString(sfiFieldAuthPassword); err != nil {
+		return nil, err
+	}
+	if cConf.grantType == grantTypePassword && cConf.username == "" {
+		return nil, errors.New("a username must be specified when the grant type is password")
+	}
+	if cConf.rateLimit, err = conf.FieldString(sfiFieldRateLimit); err != nil {
+		return nil, err
+	}
+	if cConf.rateLimit != "" && !mgr.HasRateLimit(cConf.rateLimit) {
+		return nil, fmt.Errorf("rate limit resource '%v' was not found", cConf.rateLimit)
+	}
+	if cConf.maxRetries, err = conf.FieldInt(sfiFieldMaxRetries); err != nil {
+		return nil, err
+	}
+	if cConf.timeout, err = conf.FieldDuration(sfiFieldTimeout); err != nil {
+		return nil, err
+	}
+	i.cli = newClient(cConf, mgr)
+
+	if i.sobject, err = conf.FieldString(sfiFieldSObject); err != nil {
+		return nil, err
+	}
+	if i.fields, err = conf.FieldStringList(sfiFieldFields); err != nil {
+		return nil, err
+	}
+	if len(i.fields) == 0 {
+		return nil, errors.New("at least one field must be specified")
+	}
+	if i.where, err = conf.FieldString(sfiFieldWhere); err != nil {
+		return nil, err
+	}
+	if i.cursorField, err = conf.FieldString(sfiFieldCursorField); err != nil {
+		return nil, err
+	}
+	pollStr, err := conf.FieldString(sfiFieldPollInterval)
+	if err != nil {
+		return nil, err
+	}
+	if pollStr != "" {
+		if i.pollInterval, err = time.ParseDuration(pollStr); err != nil {
+			return nil, fmt.Errorf("failed to parse poll interval: %w", err)
+		}
+	}
+	if i.pageSize, err = conf.FieldInt(sfiFieldPageSize); err != nil {
+		return nil, err
+	}
+	if i.pageSize <= 0 {
+		return nil, errors.New("page size must be greater than zero")
+	}
+	if i.checkpointCache, err = conf.FieldString(sfiFieldCheckpointCache); err != nil {
+		return nil, err
+	}
+	if i.checkpointCache != "" {
+		if i.cursorField == "" {
+			return nil, errors.New("a cursor field must be specified in order to use a checkpoint cache")
+		}
+		if !mgr.HasCache(i.checkpointCache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", i.checkpointCache)
+		}
+	}
+	if i.checkpointKey, err = conf.FieldString(sfiFieldCheckpointKey); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// soql returns the query for records beyond the current cursor.
+func (i *salesforceInput) soql() string {
+	fields := i.fields
+	if i.cursorField != "" {
+		hasCursor := false
+		for _, f := range fields {
+			if strings.EqualFold(f, i.cursorField) {
+				hasCursor = true
+				break
+			}
+		}
+		if !hasCursor {
+			fields = append(append([]string{}, fields...), i.cursorField)
+		}
+	}
+
+	var conditions []string
+	if i.where != "" {
+		conditions = append(conditions, "("+i.where+")")
+	}
+	if i.cursorField != "" && i.cursor != "" {
+		conditions = append(conditions, i.cursorField+" > "+i.cursor)
+	}
+
+	q := "SELECT " + strings.Join(fields, ", ") + " FROM " + i.sobject
+	if len(conditions) > 0 {
+		q += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	if i.cursorField != "" {
+		q += " ORDER BY " + i.cursorField + " ASC"
+	}
+	return q
+}
+
+func (i *salesforceInput) Connect(ctx context.Context) error {
+	if !i.cursorLoaded && i.checkpointCache != "" {
+		var cursor []byte
+		var cErr error
+		if err := i.mgr.AccessCache(ctx, i.checkpointCache, func(c service.Cache) {
+			cursor, cErr = c.Get(ctx, i.checkpointKey)
+		}); err != nil {
+			return err
+		}
+		if cErr != nil && !errors.Is(cErr, service.ErrKeyNotFound) {
+			return fmt.Errorf("failed to read cursor from cache: %w", cErr)
+		}
+		i.cursor = string(cursor)
+	}
+	i.cursorLoaded = true
+
+	if err := i.cli.authenticate(ctx); err != nil {
+		return err
+	}
+	i.log.Infof("Querying Salesforce object %v", i.sobject)
+	return nil
+}
+
+// startQuery creates a query job and blocks until it has completed.
+func (i *salesforceInput) startQuery(ctx context.Context) error {
+	if i.queried {
+		if i.pollInterval <= 0 {
+			return service.ErrEndOfInput
+		}
+		if err := i.cli.sleepFn(ctx, time.Until(i.lastQuery.Add(i.pollInterval))); err != nil {
+			return err
+		}
+	}
+	i.lastQuery = time.Now()
+
+	soql := i.soql()
+	i.log.Debugf("Creating query job: %v", soql)
+
+	id, err := i.cli.createQueryJob(ctx, soql)
+	if err != nil {
+		return err
+	}
+	for {
+		state, err := i.cli.jobState(ctx, id)
+		if err != nil {
+			return err
+		}
+		if state == "JobComplete" {
+			break
+		}
+		if err := i.cli.sleepFn(ctx, i.jobCheckInterval); err != nil {
+			return err
+		}
+	}
+
+	i.queried = true
+	i.jobID, i.locator = id, ""
+	i.current = &queryCycle{cursor: i.cursor}
+
+	i.cMut.Lock()
+	i.cycles = append(i.cycles, i.current)
+	i.cMut.Unlock()
+	return nil
+}
+
+func (i *salesforceInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for {
+		if i.jobID == "" {
+			if err := i.startQuery(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		records, next, err := i.cli.jobResults(ctx, i.jobID, i.locator, i.pageSize)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		jobID, cycle := i.jobID, i.current
+		if next == "" {
+			i.jobID = ""
+		} else {
+			i.locator = next
+		}
+
+		batch := make(service.MessageBatch, 0, len(records))
+		for _, record := range records {
+			if i.cursorField != "" {
+				if v, _ := record[i.cursorField].(string); v > i.cursor {
+					i.cursor = v
+				}
+			}
+			msg := service.NewMessage(nil)
+			msg.SetStructuredMut(record)
+			msg.MetaSetMut("salesforce_sobject", i.sobject)
+			msg.MetaSetMut("salesforce_job_id", jobID)
+			batch = append(batch, msg)
+		}
+
+		i.cMut.Lock()
+		cycle.cursor = i.cursor
+		cycle.finished = next == ""
+		if len(batch) > 0 {
+			cycle.outstanding++
+		}
+		i.cMut.Unlock()
+
+		if len(batch) == 0 {
+			if err := i.commitCycles(ctx); err != nil {
+				i.log.Errorf("Failed to persist cursor: %v", err)
+			}
+			continue
+		}
+		return batch, func(ctx context.Context, err error) error {
+			// Nacks are handled by AutoRetryNacksBatched.
+			i.cMut.Lock()
+			cycle.outstanding--
+			i.cMut.Unlock()
+			return i.commitCycles(ctx)
+		}, nil
+	}
+}
+
+// commitCycles persists the cursor of the latest query where it, and all prior
+// queries, have been fully acknowledged.
+func (i *salesforceInput) commitCycles(ctx context.Context) error {
+	i.cMut.Lock()
+	defer i.cMut.Unlock()
+
+	var cursor string
+	for len(i.cycles) > 0 && i.cycles[0].finished && i.cycles[0].outstanding == 0 {
+		cursor = i.cycles[0].cursor
+		i.cycles = i.cycles[1:]
+	}
+	if cursor == "" || i.checkpointCache == "" {
+		return nil
+	}
+
+	var setErr error
+	if err := i.mgr.AccessCache(ctx, i.checkpointCache, func(c service.Cache) {
+		setErr = c.Set(ctx, i.checkpointKey, []byte(cursor), nil)
+	}); err != nil {
+		return err
+	}
+	return setErr
+}
+
+func (i *salesforceInput) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,279 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// fakeOrg emulates the token endpoint and Bulk API 2.0 query jobs of an org,
+// where each query job returns the pages of the next entry in results.
+type fakeOrg struct {
+	*httptest.Server
+
+	mut         sync.Mutex
+	queries     []string
+	results     [][]string
+	tokens      int
+	rateLimited int
+	expireToken bool
+}
+
+func newFakeOrg(t *testing.T, results ...[]string) *fakeOrg {
+	t.Helper()
+
+	o := &fakeOrg{results: results}
+	o.Server = httptest.NewServer(http.HandlerFunc(o.handle))
+	t.Cleanup(o.Close)
+	return o
+}
+
+func (o *fakeOrg) handle(w http.ResponseWriter, r *http.Request) {
+	o.mut.Lock()
+	defer o.mut.Unlock()
+
+	if r.URL.Path == "/services/oauth2/token" {
+		_ = r.ParseForm()
+		if r.Form.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"invalid client credentials"}`))
+			return
+		}
+		o.tokens++
+		_, _ = w.Write([]byte(`{"access_token":"token` + string(rune('0'+o.tokens)) + `","instance_url":"` + o.URL + `"}`))
+		return
+	}
+
+	if o.expireToken {
+		o.expireToken = false
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`[{"errorCode":"INVALID_SESSION_ID","message":"Session expired or invalid"}]`))
+		return
+	}
+	if o.rateLimited > 0 {
+		o.rateLimited--
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`[{"errorCode":"REQUEST_LIMIT_EXCEEDED","message":"TotalRequests Limit exceeded."}]`))
+		return
+	}
+
+	jobsPath := "/services/data/v58.0/jobs/query"
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == jobsPath:
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		o.queries = append(o.queries, body["query"])
+		_, _ = w.Write([]byte(`{"id":"job` + string(rune('0'+len(o.queries))) + `","state":"UploadComplete"}`))
+	case strings.HasSuffix(r.URL.Path, "/results"):
+		pages := o.results[0]
+		page := 0
+		if l := r.URL.Query().Get("locator"); l != "" {
+			page = int(l[0] - '0')
+		}
+		if page+1 < len(pages) {
+			w.Header().Set("Sforce-Locator", string(rune('0'+page+1)))
+		} else {
+			w.Header().Set("Sforce-Locator", "null")
+			o.results = o.results[1:]
+		}
+		_, _ = w.Write([]byte(pages[page]))
+	default:
+		_, _ = w.Write([]byte(`{"id":"job","state":"JobComplete"}`))
+	}
+}
+
+func (o *fakeOrg) receivedQueries() []string {
+	o.mut.Lock()
+	defer o.mut.Unlock()
+	return append([]string(nil), o.queries...)
+}
+
+func testSalesforceInput(t *testing.T, mgr *service.Resources, confStr string) *salesforceInput {
+	t.Helper()
+
+	pConf, err := salesforceInputSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	i, err := newSalesforceInput(pConf, mgr)
+	require.NoError(t, err)
+	i.cli.sleepFn = func(ctx context.Context, d time.Duration) error { return nil }
+	return i
+}
+
+func readRecords(t *testing.T, i *salesforceInput) ([]any, service.AckFunc) {
+	t.Helper()
+
+	batch, ackFn, err := i.ReadBatch(context.Background())
+	require.NoError(t, err)
+
+	var records []any
+	for _, msg := range batch {
+		v, err := msg.AsStructured()
+		require.NoError(t, err)
+		records = append(records, v)
+	}
+	return records, ackFn
+}
+
+func TestSalesforceInputCursoring(t *testing.T) {
+	org := newFakeOrg(t,
+		[]string{
+			"Id,Name,SystemModstamp\n1,foo,2023-01-01T00:00:00.000Z\n",
+			"Id,Name,SystemModstamp\n2,bar,2023-01-02T00:00:00.000Z\n",
+		},
+		[]string{"Id,Name,SystemModstamp\n"},
+		[]string{"Id,Name,SystemModstamp\n3,baz,2023-01-03T00:00:00.000Z\n"},
+	)
+
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+	i := testSalesforceInput(t, mgr, `
+org_url: `+org.URL+`
+auth:
+  client_id: foo
+  client_secret: secret
+sobject: Account
+fields: [ Id, Name ]
+where: Industry = 'Energy'
+poll_interval: 1s
+checkpoint_cache: foocache
+`)
+	require.NoError(t, i.Connect(context.Background()))
+
+	records, ackA := readRecords(t, i)
+	assert.Equal(t, []any{map[string]any{"Id": "1", "Name": "foo", "SystemModstamp": "2023-01-01T00:00:00.000Z"}}, records)
+	records, ackB := readRecords(t, i)
+	assert.Equal(t, []any{map[string]any{"Id": "2", "Name": "bar", "SystemModstamp": "2023-01-02T00:00:00.000Z"}}, records)
+
+	getCursor := func() string {
+		var v []byte
+		require.NoError(t, mgr.AccessCache(context.Background(), "foocache", func(c service.Cache) {
+			v, _ = c.Get(context.Background(), "salesforce_cursor")
+		}))
+		return string(v)
+	}
+
+	// The cursor is only persisted once all records of the query are acked.
+	require.NoError(t, ackB(context.Background(), nil))
+	assert.Equal(t, "", getCursor())
+	require.NoError(t, ackA(context.Background(), nil))
+	assert.Equal(t, "2023-01-02T00:00:00.000Z", getCursor())
+
+	// The second query is empty and the third returns a record.
+	records, ackC := readRecords(t, i)
+	assert.Equal(t, []any{map[string]any{"Id": "3", "Name": "baz", "SystemModstamp": "2023-01-03T00:00:00.000Z"}}, records)
+	require.NoError(t, ackC(context.Background(), nil))
+	assert.Equal(t, "2023-01-03T00:00:00.000Z", getCursor())
+
+	assert.Equal(t, []string{
+		"SELECT Id, Name, SystemModstamp FROM Account WHERE (Industry = 'Energy') ORDER BY SystemModstamp ASC",
+		"SELECT Id, Name, SystemModstamp FROM Account WHERE (Industry = 'Energy') AND SystemModstamp > 2023-01-02T00:00:00.000Z ORDER BY SystemModstamp ASC",
+		"SELECT Id, Name, SystemModstamp FROM Account WHERE (Industry = 'Energy') AND SystemModstamp > 2023-01-02T00:00:00.000Z ORDER BY SystemModstamp ASC",
+	}, org.receivedQueries())
+
+	// A new input resumes from the persisted cursor.
+	i = testSalesforceInput(t, mgr, `
+org_url: `+org.URL+`
+auth:
+  client_id: foo
+  client_secret: secret
+sobject: Account
+fields: [ Id ]
+checkpoint_cache: foocache
+`)
+	require.NoError(t, i.Connect(context.Background()))
+	assert.Equal(t, "SELECT Id, SystemModstamp FROM Account WHERE SystemModstamp > 2023-01-03T00:00:00.000Z ORDER BY SystemModstamp ASC", i.soql())
+}
+
+func TestSalesforceInputOnce(t *testing.T) {
+	org := newFakeOrg(t, []string{"Id\n1\n2\n"})
+
+	i := testSalesforceInput(t, service.MockResources(), `
+org_url: `+org.URL+`
+auth:
+  client_id: foo
+  client_secret: secret
+sobject: Account
+fields: [ Id ]
+cursor_field: ""
+`)
+	require.NoError(t, i.Connect(context.Background()))
+
+	batch, _, err := i.ReadBatch(context.Background())
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	jobID, _ := batch[0].MetaGet("salesforce_job_id")
+	assert.Equal(t, "job1", jobID)
+	sobject, _ := batch[0].MetaGet("salesforce_sobject")
+	assert.Equal(t, "Account", sobject)
+
+	_, _, err = i.ReadBatch(context.Background())
+	assert.ErrorIs(t, err, service.ErrEndOfInput)
+	assert.Equal(t, []string{"SELECT Id FROM Account"}, org.receivedQueries())
+}
+
+func TestSalesforceInputRateLimitsAndAuth(t *testing.T) {
+	org := newFakeOrg(t, []string{"Id\n1\n"})
+
+	i := testSalesforceInput(t, service.MockResources(), `
+org_url: `+org.URL+`
+auth:
+  client_id: foo
+  client_secret: secret
+sobject: Account
+fields: [ Id ]
+cursor_field: ""
+max_retries: 2
+`)
+	var sleeps []time.Duration
+	i.cli.sleepFn = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	require.NoError(t, i.Connect(context.Background()))
+
+	org.mut.Lock()
+	org.expireToken = true
+	org.rateLimited = 2
+	org.mut.Unlock()
+
+	records, _ := readRecords(t, i)
+	assert.Equal(t, []any{map[string]any{"Id": "1"}}, records)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, sleeps)
+
+	org.mut.Lock()
+	assert.Equal(t, 2, org.tokens)
+	org.mut.Unlock()
+
+	org.mut.Lock()
+	org.rateLimited = 3
+	org.mut.Unlock()
+	_, err := i.cli.createQueryJob(context.Background(), "SELECT Id FROM Account")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "maximum number of retries")
+}
+
+func TestSalesforceInputAuthError(t *testing.T) {
+	org := newFakeOrg(t)
+
+	i := testSalesforceInput(t, service.MockResources(), `
+org_url: `+org.URL+`
+auth:
+  client_id: foo
+  client_secret: wrong
+sobject: Account
+fields: [ Id ]
+`)
+	err := i.Connect(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid client credentials")
+}
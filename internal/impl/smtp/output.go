@@ -0,0 +1,654 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	soFieldAddress         = "address"
+	soFieldEncryption      = "encryption"
+	soFieldTLS             = "tls"
+	soFieldAuth            = "auth"
+	soFieldAuthMechanism   = "mechanism"
+	soFieldAuthUsername    = "username"
+	soFieldAuthPassword    = "password"
+	soFieldFrom            = "from"
+	soFieldTo              = "to"
+	soFieldCc              = "cc"
+	soFieldBcc             = "bcc"
+	soFieldSubject         = "subject"
+	soFieldHeaders         = "headers"
+	soFieldTextBody        = "text_body"
+	soFieldHTMLBody        = "html_body"
+	soFieldAttachments     = "attachments"
+	soFieldAttFilename     = "filename"
+	soFieldAttContentType  = "content_type"
+	soFieldAttContent      = "content"
+	soFieldTimeout         = "timeout"
+	soFieldBatching        = "batching"
+	soEncryptionSTARTTLS   = "starttls"
+	soEncryptionImplicit   = "implicit"
+	soEncryptionNone       = "none"
+	soAuthMechanismPlain   = "plain"
+	soAuthMechanismCRAMMD5 = "cram-md5"
+)
+
+func smtpOutputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.20.0").
+		Summary("Sends messages as emails via an SMTP server.").
+		Description(`
+Each message batch is sent as a single email, where the sender, recipients, subject, headers and bodies are resolved from the first message of the batch. By default batches consist of a single message, and therefore each message results in an email. A [batching policy](/docs/configuration/batching) can be configured in order to aggregate messages into a single email, which is useful for delivering reports.
+
+The fields `+"`to`"+`, `+"`cc`"+` and `+"`bcc`"+` are lists of [interpolated strings](/docs/configuration/interpolation#bloblang-queries), where each element can resolve to either a single address or a comma separated list of addresses. At least one recipient must be resolved for each email.
+
+When both a `+"`text_body`"+` and an `+"`html_body`"+` are resolved to non-empty values the email is sent with both as alternatives, allowing the mail client to choose which to display.
+
+### Attachments
+
+Each attachment configured within `+"`attachments`"+` is resolved against every message of the batch, and therefore a batch of N messages with one attachment configured results in an email with N attachments. Attachments with an empty content are omitted.
+
+### Encryption
+
+The field `+"`encryption`"+` determines how the connection to the server is secured. With `+"`starttls`"+` the connection is upgraded to TLS with the STARTTLS command, which must be supported by the server. With `+"`implicit`"+` the connection is established over TLS from the beginning, which is commonly used with port 465. Finally, `+"`none`"+` disables encryption entirely, and should only be used for local relays.`).
+		Fields(
+			service.NewStringField(soFieldAddress).
+				Description("The address of the SMTP server to connect to, including the port.").
+				Example("smtp.example.com:587").
+				Example("localhost:25"),
+			service.NewStringEnumField(soFieldEncryption, soEncryptionSTARTTLS, soEncryptionImplicit, soEncryptionNone).
+				Description("The method of securing the connection with the server.").
+				Default(soEncryptionSTARTTLS),
+			service.NewTLSField(soFieldTLS).
+				Description("Custom TLS settings used when `encryption` is set to `starttls` or `implicit`."),
+			service.NewObjectField(soFieldAuth,
+				service.NewStringEnumField(soFieldAuthMechanism, soAuthMechanismPlain, soAuthMechanismCRAMMD5).
+					Description("The authentication mechanism to use.").
+					Default(soAuthMechanismPlain),
+				service.NewStringField(soFieldAuthUsername).
+					Description("A username to authenticate with, when empty authentication is disabled.").
+					Default(""),
+				service.NewStringField(soFieldAuthPassword).
+					Description("A password to authenticate with.").
+					Default("").
+					Secret(),
+			).
+				Description("Optional authentication with the SMTP server."),
+			service.NewInterpolatedStringField(soFieldFrom).
+				Description("The address to send emails from.").
+				Example("Benthos Alerts <alerts@example.com>"),
+			service.NewInterpolatedStringListField(soFieldTo).
+				Description("A list of recipient addresses.").
+				Example([]string{"ops@example.com"}).
+				Example([]string{`${! meta("recipients") }`}),
+			service.NewInterpolatedStringListField(soFieldCc).
+				Description("A list of carbon copy recipient addresses.").
+				Default([]any{}).
+				Advanced(),
+			service.NewInterpolatedStringListField(soFieldBcc).
+				Description("A list of blind carbon copy recipient addresses, which are not included in the headers of the email.").
+				Default([]any{}).
+				Advanced(),
+			service.NewInterpolatedStringField(soFieldSubject).
+				Description("The subject of emails.").
+				Example(`Alert: ${! json("alert.name") }`),
+			service.NewInterpolatedStringMapField(soFieldHeaders).
+				Description("A map of additional headers to add to emails.").
+				Default(map[string]any{}).
+				Example(map[string]any{"Reply-To": "support@example.com"}).
+				Advanced(),
+			service.NewInterpolatedStringField(soFieldTextBody).
+				Description("The plain text body of emails.").
+				Default(`${! content() }`),
+			service.NewInterpolatedStringField(soFieldHTMLBody).
+				Description("An optional HTML body of emails.").
+				Default("").
+				Example(`<h1>${! json("alert.name") }</h1><p>${! json("alert.description") }</p>`),
+			service.NewObjectListField(soFieldAttachments,
+				service.NewInterpolatedStringField(soFieldAttFilename).
+					Description("The filename of the attachment.").
+					Example(`${! meta("filename") }`).
+					Example(`report_${! timestamp_unix() }.csv`),
+				service.NewInterpolatedStringField(soFieldAttContentType).
+					Description("The content type of the attachment.").
+					Default("application/octet-stream"),
+				service.NewInterpolatedStringField(soFieldAttContent).
+					Description("The content of the attachment.").
+					Default(`${! content() }`),
+			).
+				Description("A list of attachments to add to emails, each attachment is resolved against every message of a batch.").
+				Default([]any{}),
+			service.NewDurationField(soFieldTimeout).
+				Description("The maximum period of time to wait for an email to be sent.").
+				Default("30s").
+				Advanced(),
+			service.NewBatchPolicyField(soFieldBatching),
+			service.NewOutputMaxInFlightField(),
+		).
+		Example(
+			"Alerts",
+			"Send an email for each alert with a subject and HTML body rendered from the fields of the alert.",
+			`
+output:
+  smtp:
+    address: smtp.example.com:587
+    auth:
+      username: alerts@example.com
+      password: ${SMTP_PASSWORD}
+    from: Benthos Alerts <alerts@example.com>
+    to: [ '${! json("team").or("ops") }@example.com' ]
+    subject: 'Alert: ${! json("name") }'
+    text_body: '${! json("description") }'
+    html_body: '<h1>${! json("name") }</h1><p>${! json("description") }</p>'
+`,
+		).
+		Example(
+			"Daily Reports",
+			"Aggregate rows of a report into a single email every hour, attaching each row as a CSV file.",
+			`
+output:
+  smtp:
+    address: smtp.example.com:465
+    encryption: implicit
+    from: reports@example.com
+    to: [ reports@example.com ]
+    subject: 'Report for ${! now().ts_format("2006-01-02") }'
+    text_body: 'Please find attached the latest report.'
+    attachments:
+      - filename: '${! meta("region") }.csv'
+        content_type: text/csv
+    batching:
+      count: 1000
+      period: 1h
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("smtp", smtpOutputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			out service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldMaxInFlight(); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy(soFieldBatching); err != nil {
+				return
+			}
+			out, err = newSMTPWriterFromConfig(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type smtpAttachment struct {
+	filename    *service.InterpolatedString
+	contentType *service.InterpolatedString
+	content     *service.InterpolatedString
+}
+
+type smtpWriter struct {
+	log *service.Logger
+
+	address    string
+	host       string
+	encryption string
+	tlsConf    *tls.Config
+	auth       smtp.Auth
+	timeout    time.Duration
+
+	from        *service.InterpolatedString
+	to          []*service.InterpolatedString
+	cc          []*service.InterpolatedString
+	bcc         []*service.InterpolatedString
+	subject     *service.InterpolatedString
+	headers     map[string]*service.InterpolatedString
+	textBody    *service.InterpolatedString
+	htmlBody    *service.InterpolatedString
+	attachments []smtpAttachment
+
+	nowFn func() time.Time
+}
+
+func newSMTPWriterFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*smtpWriter, error) {
+	w := &smtpWriter{
+		log:   mgr.Logger(),
+		nowFn: time.Now,
+	}
+
+	var err error
+	if w.address, err = conf.FieldString(soFieldAddress); err != nil {
+		return nil, err
+	}
+	if w.host, _, err = net.SplitHostPort(w.address); err != nil {
+		return nil, fmt.Errorf("failed to parse address: %w", err)
+	}
+	if w.encryption, err = conf.FieldString(soFieldEncryption); err != nil {
+		return nil, err
+	}
+	if w.tlsConf, err = conf.FieldTLS(soFieldTLS); err != nil {
+		return nil, err
+	}
+	if w.tlsConf == nil {
+		w.tlsConf = &tls.Config{}
+	}
+	if w.tlsConf.ServerName == "" {
+		w.tlsConf.ServerName = w.host
+	}
+	if w.timeout, err = conf.FieldDuration(soFieldTimeout); err != nil {
+		return nil, err
+	}
+
+	authConf := conf.Namespace(soFieldAuth)
+	username, err := authConf.FieldString(soFieldAuthUsername)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" {
+		password, err := authConf.FieldString(soFieldAuthPassword)
+		if err != nil {
+			return nil, err
+		}
+		mechanism, err := authConf.FieldString(soFieldAuthMechanism)
+		if err != nil {
+			return nil, err
+		}
+		switch mechanism {
+		case soAuthMechanismPlain:
+			w.auth = smtp.PlainAuth("", username, password, w.host)
+		case soAuthMechanismCRAMMD5:
+			w.auth = smtp.CRAMMD5Auth(username, password)
+		default:
+			return nil, fmt.Errorf("unrecognised auth mechanism: %v", mechanism)
+		}
+	}
+
+	if w.from, err = conf.FieldInterpolatedString(soFieldFrom); err != nil {
+		return nil, err
+	}
+	if w.to, err = conf.FieldInterpolatedStringList(soFieldTo); err != nil {
+		return nil, err
+	}
+	if w.cc, err = conf.FieldInterpolatedStringList(soFieldCc); err != nil {
+		return nil, err
+	}
+	if w.bcc, err = conf.FieldInterpolatedStringList(soFieldBcc); err != nil {
+		return nil, err
+	}
+	if w.subject, err = conf.FieldInterpolatedString(soFieldSubject); err != nil {
+		return nil, err
+	}
+	if w.headers, err = conf.FieldInterpolatedStringMap(soFieldHeaders); err != nil {
+		return nil, err
+	}
+	if w.textBody, err = conf.FieldInterpolatedString(soFieldTextBody); err != nil {
+		return nil, err
+	}
+	if w.htmlBody, err = conf.FieldInterpolatedString(soFieldHTMLBody); err != nil {
+		return nil, err
+	}
+
+	attConfs, err := conf.FieldObjectList(soFieldAttachments)
+	if err != nil {
+		return nil, err
+	}
+	for i, aConf := range attConfs {
+		var att smtpAttachment
+		if att.filename, err = aConf.FieldInterpolatedString(soFieldAttFilename); err != nil {
+			return nil, fmt.Errorf("attachment %v: %w", i, err)
+		}
+		if att.contentType, err = aConf.FieldInterpolatedString(soFieldAttContentType); err != nil {
+			return nil, fmt.Errorf("attachment %v: %w", i, err)
+		}
+		if att.content, err = aConf.FieldInterpolatedString(soFieldAttContent); err != nil {
+			return nil, fmt.Errorf("attachment %v: %w", i, err)
+		}
+		w.attachments = append(w.attachments, att)
+	}
+	return w, nil
+}
+
+func (w *smtpWriter) dial(ctx context.Context) (*smtp.Client, error) {
+	dialer := net.Dialer{Timeout: w.timeout}
+
+	var conn net.Conn
+	var err error
+	if w.encryption == soEncryptionImplicit {
+		tlsDialer := tls.Dialer{NetDialer: &dialer, Config: w.tlsConf}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", w.address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", w.address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(w.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, w.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if w.encryption == soEncryptionSTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(w.tlsConf); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if w.auth != nil {
+		if err := client.Auth(w.auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+	return client, nil
+}
+
+func (w *smtpWriter) Connect(ctx context.Context) error {
+	client, err := w.dial(ctx)
+	if err != nil {
+		return err
+	}
+	_ = client.Quit()
+
+	w.log.Infof("Sending emails via SMTP server at %v", w.address)
+	return nil
+}
+
+func parseAddressList(batch service.MessageBatch, fields []*service.InterpolatedString) ([]*mail.Address, error) {
+	var addrs []*mail.Address
+	for _, f := range fields {
+		s, err := batch.TryInterpolatedString(0, f)
+		if err != nil {
+			return nil, err
+		}
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		parsed, err := mail.ParseAddressList(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse address list '%v': %w", s, err)
+		}
+		addrs = append(addrs, parsed...)
+	}
+	return addrs, nil
+}
+
+func joinAddresses(addrs []*mail.Address) string {
+	strs := make([]string, len(addrs))
+	for i, a := range addrs {
+		strs[i] = a.String()
+	}
+	return strings.Join(strs, ", ")
+}
+
+type smtpEmail struct {
+	from       *mail.Address
+	recipients []string
+	data       []byte
+}
+
+func randomBoundary() string {
+	var buf [16]byte
+	_, _ = io.ReadFull(rand.Reader, buf[:])
+	return fmt.Sprintf("%x", buf[:])
+}
+
+func writeQuotedPrintablePart(mw *multipart.Writer, contentType string, body []byte) error {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Transfer-Encoding", "quoted-printable")
+	pw, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	qw := quotedprintable.NewWriter(pw)
+	if _, err := qw.Write(body); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
+
+// buildEmail renders a batch into an email, where everything other than the
+// attachments is resolved from the first message.
+func (w *smtpWriter) buildEmail(batch service.MessageBatch) (*smtpEmail, error) {
+	fromStr, err := batch.TryInterpolatedString(0, w.from)
+	if err != nil {
+		return nil, fmt.Errorf("from interpolation: %w", err)
+	}
+	from, err := mail.ParseAddress(fromStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse from address '%v': %w", fromStr, err)
+	}
+
+	to, err := parseAddressList(batch, w.to)
+	if err != nil {
+		return nil, fmt.Errorf("to interpolation: %w", err)
+	}
+	cc, err := parseAddressList(batch, w.cc)
+	if err != nil {
+		return nil, fmt.Errorf("cc interpolation: %w", err)
+	}
+	bcc, err := parseAddressList(batch, w.bcc)
+	if err != nil {
+		return nil, fmt.Errorf("bcc interpolation: %w", err)
+	}
+
+	email := &smtpEmail{from: from}
+	for _, addrs := range [][]*mail.Address{to, cc, bcc} {
+		for _, a := range addrs {
+			email.recipients = append(email.recipients, a.Address)
+		}
+	}
+	if len(email.recipients) == 0 {
+		return nil, errors.New("no recipients were resolved")
+	}
+
+	subject, err := batch.TryInterpolatedString(0, w.subject)
+	if err != nil {
+		return nil, fmt.Errorf("subject interpolation: %w", err)
+	}
+	textBody, err := batch.TryInterpolatedBytes(0, w.textBody)
+	if err != nil {
+		return nil, fmt.Errorf("text body interpolation: %w", err)
+	}
+	htmlBody, err := batch.TryInterpolatedBytes(0, w.htmlBody)
+	if err != nil {
+		return nil, fmt.Errorf("html body interpolation: %w", err)
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(k, v string) {
+		buf.WriteString(k)
+		buf.WriteString(": ")
+		buf.WriteString(v)
+		buf.WriteString("\r\n")
+	}
+
+	writeHeader("From", from.String())
+	if len(to) > 0 {
+		writeHeader("To", joinAddresses(to))
+	}
+	if len(cc) > 0 {
+		writeHeader("Cc", joinAddresses(cc))
+	}
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", subject))
+	writeHeader("Date", w.nowFn().Format(time.RFC1123Z))
+	writeHeader("MIME-Version", "1.0")
+
+	headerKeys := make([]string, 0, len(w.headers))
+	for k := range w.headers {
+		headerKeys = append(headerKeys, k)
+	}
+	sort.Strings(headerKeys)
+	for _, k := range headerKeys {
+		v, err := batch.TryInterpolatedString(0, w.headers[k])
+		if err != nil {
+			return nil, fmt.Errorf("header '%v' interpolation: %w", k, err)
+		}
+		writeHeader(textproto.CanonicalMIMEHeaderKey(k), mime.QEncoding.Encode("utf-8", v))
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	writeHeader("Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	buf.WriteString("\r\n")
+
+	// The body of the email, either a single part or alternatives.
+	switch {
+	case len(textBody) > 0 && len(htmlBody) > 0:
+		altBoundary := randomBoundary()
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Type", "multipart/alternative; boundary="+altBoundary)
+		pw, err := mixed.CreatePart(h)
+		if err != nil {
+			return nil, err
+		}
+		alt := multipart.NewWriter(pw)
+		if err := alt.SetBoundary(altBoundary); err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintablePart(alt, "text/plain; charset=utf-8", textBody); err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintablePart(alt, "text/html; charset=utf-8", htmlBody); err != nil {
+			return nil, err
+		}
+		if err := alt.Close(); err != nil {
+			return nil, err
+		}
+	case len(htmlBody) > 0:
+		if err := writeQuotedPrintablePart(mixed, "text/html; charset=utf-8", htmlBody); err != nil {
+			return nil, err
+		}
+	default:
+		if err := writeQuotedPrintablePart(mixed, "text/plain; charset=utf-8", textBody); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range batch {
+		for _, att := range w.attachments {
+			content, err := batch.TryInterpolatedBytes(i, att.content)
+			if err != nil {
+				return nil, fmt.Errorf("attachment content interpolation: %w", err)
+			}
+			if len(content) == 0 {
+				continue
+			}
+			filename, err := batch.TryInterpolatedString(i, att.filename)
+			if err != nil {
+				return nil, fmt.Errorf("attachment filename interpolation: %w", err)
+			}
+			contentType, err := batch.TryInterpolatedString(i, att.contentType)
+			if err != nil {
+				return nil, fmt.Errorf("attachment content type interpolation: %w", err)
+			}
+
+			h := textproto.MIMEHeader{}
+			h.Set("Content-Type", contentType)
+			h.Set("Content-Transfer-Encoding", "base64")
+			h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+			pw, err := mixed.CreatePart(h)
+			if err != nil {
+				return nil, err
+			}
+			if err := writeBase64Lines(pw, content); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	email.data = buf.Bytes()
+	return email, nil
+}
+
+func (w *smtpWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	email, err := w.buildEmail(batch)
+	if err != nil {
+		return err
+	}
+
+	client, err := w.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Mail(email.from.Address); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	for _, r := range email.recipients {
+		if err := client.Rcpt(r); err != nil {
+			return fmt.Errorf("failed to add recipient '%v': %w", r, err)
+		}
+	}
+
+	dw, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := dw.Write(email.data); err != nil {
+		_ = dw.Close()
+		return err
+	}
+	if err := dw.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (w *smtpWriter) Close(ctx context.Context) error {
+	return nil
+}
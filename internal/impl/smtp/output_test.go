@@ -0,0 +1,293 @@
+package smtp
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type receivedEmail struct {
+	from       string
+	recipients []string
+	data       string
+}
+
+// fakeSMTPServer accepts unencrypted connections and records the emails that
+// are sent to it.
+type fakeSMTPServer struct {
+	ln net.Listener
+
+	mut    sync.Mutex
+	emails []receivedEmail
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeSMTPServer{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = io.WriteString(conn, line+"\r\n")
+	}
+
+	var email receivedEmail
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			email.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			email.recipients = append(email.recipients, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 Go ahead")
+			var data strings.Builder
+			for {
+				dLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dLine, "."))
+			}
+			email.data = data.String()
+			s.mut.Lock()
+			s.emails = append(s.emails, email)
+			s.mut.Unlock()
+			email = receivedEmail{}
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Unrecognised command")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) received() []receivedEmail {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]receivedEmail(nil), s.emails...)
+}
+
+func testSMTPWriter(t *testing.T, addr, confStr string) *smtpWriter {
+	t.Helper()
+
+	pConf, err := smtpOutputSpec().ParseYAML("address: "+addr+"\nencryption: none\n"+confStr, nil)
+	require.NoError(t, err)
+
+	w, err := newSMTPWriterFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+	return w
+}
+
+func TestSMTPOutputTextBody(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	w := testSMTPWriter(t, srv.ln.Addr().String(), `
+from: Benthos <benthos@example.com>
+to: [ '${! meta("to") }' ]
+bcc: [ audit@example.com ]
+subject: 'Hello ${! json("name") }'
+text_body: 'Dear ${! json("name") }, this is a test.'
+headers:
+  reply-to: support@example.com
+`)
+
+	ctx := context.Background()
+	require.NoError(t, w.Connect(ctx))
+
+	msg := service.NewMessage([]byte(`{"name":"Bob"}`))
+	msg.MetaSetMut("to", "bob@example.com, Alice <alice@example.com>")
+	require.NoError(t, w.WriteBatch(ctx, service.MessageBatch{msg}))
+
+	emails := srv.received()
+	require.Len(t, emails, 1)
+	assert.Equal(t, "benthos@example.com", emails[0].from)
+	assert.Equal(t, []string{"bob@example.com", "alice@example.com", "audit@example.com"}, emails[0].recipients)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(emails[0].data))
+	require.NoError(t, err)
+
+	assert.Equal(t, `"Benthos" <benthos@example.com>`, parsed.Header.Get("From"))
+	assert.Equal(t, `<bob@example.com>, "Alice" <alice@example.com>`, parsed.Header.Get("To"))
+	assert.Equal(t, "", parsed.Header.Get("Bcc"))
+	assert.Equal(t, "Hello Bob", parsed.Header.Get("Subject"))
+	assert.Equal(t, "support@example.com", parsed.Header.Get("Reply-To"))
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	part, err := mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", part.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(part)
+	require.NoError(t, err)
+	assert.Equal(t, "Dear Bob, this is a test.", string(body))
+
+	_, err = mr.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestSMTPOutputAlternativesAndAttachments(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	w := testSMTPWriter(t, srv.ln.Addr().String(), `
+from: benthos@example.com
+to: [ reports@example.com ]
+subject: 'Report'
+text_body: 'See attached.'
+html_body: '<p>See attached.</p>'
+attachments:
+  - filename: '${! meta("region") }.csv'
+    content_type: text/csv
+`)
+
+	ctx := context.Background()
+
+	msgA := service.NewMessage([]byte("a,b\n1,2\n"))
+	msgA.MetaSetMut("region", "eu")
+	msgB := service.NewMessage([]byte("c,d\n3,4\n"))
+	msgB.MetaSetMut("region", "us")
+	require.NoError(t, w.WriteBatch(ctx, service.MessageBatch{msgA, msgB}))
+
+	emails := srv.received()
+	require.Len(t, emails, 1)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(emails[0].data))
+	require.NoError(t, err)
+
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+
+	part, err := mr.NextPart()
+	require.NoError(t, err)
+
+	mediaType, altParams, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	altReader := multipart.NewReader(part, altParams["boundary"])
+	for _, exp := range []struct {
+		contentType string
+		body        string
+	}{
+		{contentType: "text/plain; charset=utf-8", body: "See attached."},
+		{contentType: "text/html; charset=utf-8", body: "<p>See attached.</p>"},
+	} {
+		altPart, err := altReader.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, exp.contentType, altPart.Header.Get("Content-Type"))
+		body, err := io.ReadAll(altPart)
+		require.NoError(t, err)
+		assert.Equal(t, exp.body, string(body))
+	}
+
+	for _, exp := range []struct {
+		filename string
+		content  string
+	}{
+		{filename: "eu.csv", content: "a,b\n1,2\n"},
+		{filename: "us.csv", content: "c,d\n3,4\n"},
+	} {
+		part, err = mr.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, "text/csv", part.Header.Get("Content-Type"))
+		assert.Equal(t, exp.filename, part.FileName())
+
+		// Parts with a base64 transfer encoding are not decoded automatically.
+		raw, err := io.ReadAll(part)
+		require.NoError(t, err)
+		assert.Equal(t, exp.content, string(mustDecodeBase64(t, string(raw))))
+	}
+
+	_, err = mr.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestSMTPOutputNoRecipients(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	w := testSMTPWriter(t, srv.ln.Addr().String(), `
+from: benthos@example.com
+to: [ '${! meta("to").or("") }' ]
+subject: 'Hello'
+`)
+
+	err := w.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte("hello"))})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no recipients")
+	assert.Empty(t, srv.received())
+}
+
+func TestSMTPOutputSTARTTLSRequired(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+
+	pConf, err := smtpOutputSpec().ParseYAML(`
+address: `+srv.ln.Addr().String()+`
+from: benthos@example.com
+to: [ ops@example.com ]
+subject: 'Hello'
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newSMTPWriterFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+
+	err = w.Connect(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "STARTTLS")
+}
+
+func mustDecodeBase64(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := base64.StdEncoding.DecodeString(strings.NewReplacer("\r", "", "\n", "").Replace(s))
+	require.NoError(t, err)
+	return b
+}
@@ -0,0 +1,154 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The maximum number of placeholders that a single statement may contain for
+// each driver, where drivers that are not listed have no practical limit.
+var driverMaxPlaceholders = map[string]int{
+	"postgres": 65535,
+	"mysql":    65535,
+	"mssql":    2099,
+	"sqlite":   32766,
+}
+
+// The maximum number of rows that a single multi-row insert may contain for
+// each driver, where drivers that are not listed have no practical limit.
+var driverMaxRows = map[string]int{
+	"mssql": 1000,
+}
+
+// maxRowsPerStatement returns the maximum number of rows that a multi-row
+// insert of the given number of columns may contain for a driver, or zero if
+// there is no limit.
+func maxRowsPerStatement(driver string, columns int) int {
+	maxRows := driverMaxRows[driver]
+	if maxParams := driverMaxPlaceholders[driver]; maxParams > 0 && columns > 0 {
+		if paramRows := maxParams / columns; maxRows == 0 || paramRows < maxRows {
+			maxRows = paramRows
+		}
+	}
+	return maxRows
+}
+
+// copyInQuery returns a COPY statement that the postgres driver executes in
+// bulk, by sending the arguments of each execution of the prepared statement
+// as a row.
+func copyInQuery(table string, columns []string) string {
+	return fmt.Sprintf("COPY %v (%v) FROM STDIN", table, strings.Join(columns, ", "))
+}
+
+//------------------------------------------------------------------------------
+
+var mysqlReaderHandlers struct {
+	sync.RWMutex
+	register   func(name string, fn func() io.Reader)
+	deregister func(name string)
+}
+
+// SetMySQLReaderHandlers sets the functions of the MySQL driver that register
+// the readers of LOAD DATA LOCAL INFILE statements. This is called by the
+// public sql components package when the driver is imported, as the base
+// components do not import any drivers.
+func SetMySQLReaderHandlers(register func(name string, fn func() io.Reader), deregister func(name string)) {
+	mysqlReaderHandlers.Lock()
+	mysqlReaderHandlers.register = register
+	mysqlReaderHandlers.deregister = deregister
+	mysqlReaderHandlers.Unlock()
+}
+
+var loadDataReaderID uint64
+
+// loadDataLocal executes a LOAD DATA LOCAL INFILE statement that reads the
+// provided tab separated rows.
+func loadDataLocal(ctx context.Context, db *sql.DB, table string, columns []string, data []byte) error {
+	mysqlReaderHandlers.RLock()
+	register, deregister := mysqlReaderHandlers.register, mysqlReaderHandlers.deregister
+	mysqlReaderHandlers.RUnlock()
+	if register == nil {
+		return errors.New("load_data mode requires the mysql driver to be imported via the public sql components package")
+	}
+
+	name := fmt.Sprintf("benthos_sql_insert_%v", atomic.AddUint64(&loadDataReaderID, 1))
+	register(name, func() io.Reader {
+		return bytes.NewReader(data)
+	})
+	defer deregister(name)
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(
+		"LOAD DATA LOCAL INFILE 'Reader::%v' INTO TABLE %v CHARACTER SET utf8mb4 (%v)",
+		name, table, strings.Join(columns, ", "),
+	))
+	return err
+}
+
+var loadDataEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\t", `\t`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x00", `\0`,
+)
+
+// appendLoadDataRow appends a row of values to a buffer in the default format
+// of LOAD DATA statements, where fields are tab separated, rows are newline
+// separated, special characters are escaped with backslashes and NULL values
+// are written as \N.
+func appendLoadDataRow(buf *bytes.Buffer, args []any) error {
+	for i, arg := range args {
+		if i > 0 {
+			_ = buf.WriteByte('\t')
+		}
+
+		var str string
+		switch t := arg.(type) {
+		case nil:
+			_, _ = buf.WriteString(`\N`)
+			continue
+		case string:
+			str = t
+		case []byte:
+			str = string(t)
+		case bool:
+			str = "0"
+			if t {
+				str = "1"
+			}
+		case json.Number:
+			str = t.String()
+		case int64:
+			str = strconv.FormatInt(t, 10)
+		case uint64:
+			str = strconv.FormatUint(t, 10)
+		case int:
+			str = strconv.Itoa(t)
+		case float64:
+			str = strconv.FormatFloat(t, 'f', -1, 64)
+		case time.Time:
+			str = t.Format("2006-01-02 15:04:05.999999")
+		case map[string]any, []any:
+			b, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			str = string(b)
+		default:
+			return fmt.Errorf("unsupported argument type for load_data: %T", arg)
+		}
+		_, _ = loadDataEscaper.WriteString(buf, str)
+	}
+	_ = buf.WriteByte('\n')
+	return nil
+}
@@ -0,0 +1,273 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// SQLiteCacheConfig returns a config spec for an SQLite cache.
+func SQLiteCacheConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.20.0").
+		Summary("Stores key/value pairs in an embedded SQLite database on disk, allowing the items of a cache to survive restarts without any external infrastructure.").
+		Description(`
+This cache is useful for persisting state such as the keys of a deduplication processor on single node deployments. The database is accessed in [WAL mode](https://www.sqlite.org/wal.html) and should not be shared between multiple Benthos processes.
+
+### Compaction
+
+Expired items are never returned, but are only deleted from the database periodically at an interval specified by the field `+"`compaction_interval`"+`. During each compaction the items that were least recently set are also deleted when the number of items exceeds `+"`max_keys`"+`, and the free pages of the database are returned to the filesystem. Note that pages are only returned to the filesystem for databases created by this cache, as they must be created with [incremental vacuuming](https://www.sqlite.org/pragma.html#pragma_auto_vacuum) enabled.`).
+		Field(service.NewStringField("path").
+			Description("The path of the database file, which will be created if it does not already exist.").
+			Example("./cache.db")).
+		Field(service.NewDurationField("default_ttl").
+			Description("An optional default TTL to set for items, calculated from the moment the item is cached.").
+			Optional()).
+		Field(service.NewIntField("max_keys").
+			Description("The maximum number of items to keep, where the items that were least recently set are deleted during each compaction once exceeded. Set to 0 for no limit.").
+			Default(0)).
+		Field(service.NewDurationField("compaction_interval").
+			Description("The period of time between each compaction, where expired items are deleted and the size of the database is reduced.").
+			Default("1m").
+			Advanced()).
+		Example(
+			"Persistent Deduplication",
+			"Here we deduplicate messages by their ID over a period of one hour, storing the IDs in an SQLite database so that they survive restarts.",
+			`
+pipeline:
+  processors:
+    - dedupe:
+        cache: dedupe_ids
+        key: ${! this.id }
+
+cache_resources:
+  - label: dedupe_ids
+    sqlite:
+      path: ./dedupe.db
+      default_ttl: 1h
+      max_keys: 1000000
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterCache(
+		"sqlite", SQLiteCacheConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Cache, error) {
+			return newSQLiteCacheFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newSQLiteCacheFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*sqliteCache, error) {
+	path, err := conf.FieldString("path")
+	if err != nil {
+		return nil, err
+	}
+
+	var defaultTTL time.Duration
+	if conf.Contains("default_ttl") {
+		if defaultTTL, err = conf.FieldDuration("default_ttl"); err != nil {
+			return nil, err
+		}
+	}
+
+	maxKeys, err := conf.FieldInt("max_keys")
+	if err != nil {
+		return nil, err
+	}
+	if maxKeys < 0 {
+		return nil, errors.New("max_keys must not be negative")
+	}
+
+	compactionInterval, err := conf.FieldDuration("compaction_interval")
+	if err != nil {
+		return nil, err
+	}
+	return newSQLiteCache(path, defaultTTL, maxKeys, compactionInterval, mgr.Logger())
+}
+
+//------------------------------------------------------------------------------
+
+type sqliteCache struct {
+	db         *sql.DB
+	defaultTTL time.Duration
+	maxKeys    int
+
+	nowFn   func() time.Time
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+}
+
+// sqliteCacheDSN returns a `file:` URI of a database path with the pragmas of
+// the cache as query parameters, escaping any characters of the path that
+// would otherwise be interpreted as part of the URI.
+func sqliteCacheDSN(path string) string {
+	u := url.URL{
+		Scheme: "file",
+		Opaque: (&url.URL{Path: filepath.ToSlash(path)}).EscapedPath(),
+		RawQuery: url.Values{
+			"_pragma": []string{"busy_timeout(10000)", "journal_mode(WAL)", "synchronous(NORMAL)"},
+		}.Encode(),
+	}
+	return u.String()
+}
+
+func newSQLiteCache(path string, defaultTTL time.Duration, maxKeys int, compactionInterval time.Duration, log *service.Logger) (*sqliteCache, error) {
+	db, err := sql.Open("sqlite", sqliteCacheDSN(path))
+	if err != nil {
+		return nil, err
+	}
+
+	// Incremental vacuuming can only be enabled before the first table of a
+	// database is created, and is otherwise ignored.
+	if _, err = db.Exec(`
+PRAGMA auto_vacuum = INCREMENTAL;
+
+CREATE TABLE IF NOT EXISTS cache (
+  key      TEXT PRIMARY KEY,
+  value    BLOB NOT NULL,
+  expires  INTEGER,
+  updated  INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS cache_expires ON cache (expires);
+CREATE INDEX IF NOT EXISTS cache_updated ON cache (updated);
+`); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	c := &sqliteCache{
+		db:         db,
+		defaultTTL: defaultTTL,
+		maxKeys:    maxKeys,
+		nowFn:      time.Now,
+		log:        log,
+		shutSig:    shutdown.NewSignaller(),
+	}
+	go c.compactionLoop(compactionInterval)
+	return c, nil
+}
+
+func (c *sqliteCache) compactionLoop(interval time.Duration) {
+	defer c.shutSig.ShutdownComplete()
+
+	if interval <= 0 {
+		<-c.shutSig.CloseAtLeisureChan()
+		return
+	}
+
+	ctx, done := c.shutSig.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.compact(ctx); err != nil && ctx.Err() == nil {
+				c.log.Errorf("Failed to compact cache: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// compact deletes expired items, followed by the least recently set items when
+// the number of items exceeds the maximum, and then returns free pages to the
+// filesystem.
+func (c *sqliteCache) compact(ctx context.Context) error {
+	if _, err := c.db.ExecContext(ctx, `DELETE FROM cache WHERE expires <= ?`, c.nowFn().UnixNano()); err != nil {
+		return err
+	}
+
+	if c.maxKeys > 0 {
+		if _, err := c.db.ExecContext(ctx, `
+DELETE FROM cache WHERE rowid IN (
+  SELECT rowid FROM cache ORDER BY updated ASC, rowid ASC
+  LIMIT max(0, (SELECT COUNT(*) FROM cache) - ?)
+)`, c.maxKeys); err != nil {
+			return err
+		}
+	}
+
+	_, err := c.db.ExecContext(ctx, `PRAGMA incremental_vacuum`)
+	return err
+}
+
+func (c *sqliteCache) expiresAt(ttl *time.Duration) any {
+	t := c.defaultTTL
+	if ttl != nil {
+		t = *ttl
+	}
+	if t <= 0 {
+		return nil
+	}
+	return c.nowFn().Add(t).UnixNano()
+}
+
+func (c *sqliteCache) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := c.db.QueryRowContext(ctx,
+		`SELECT value FROM cache WHERE key = ? AND (expires IS NULL OR expires > ?)`,
+		key, c.nowFn().UnixNano(),
+	).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, service.ErrKeyNotFound
+	}
+	return value, err
+}
+
+func (c *sqliteCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	_, err := c.db.ExecContext(ctx, `
+INSERT INTO cache (key, value, expires, updated) VALUES (?, ?, ?, ?)
+ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires = excluded.expires, updated = excluded.updated`,
+		key, value, c.expiresAt(ttl), c.nowFn().UnixNano(),
+	)
+	return err
+}
+
+func (c *sqliteCache) Add(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	// An existing item is only replaced when it has expired.
+	res, err := c.db.ExecContext(ctx, `
+INSERT INTO cache (key, value, expires, updated) VALUES (?, ?, ?, ?)
+ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires = excluded.expires, updated = excluded.updated
+WHERE cache.expires IS NOT NULL AND cache.expires <= excluded.updated`,
+		key, value, c.expiresAt(ttl), c.nowFn().UnixNano(),
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return service.ErrKeyAlreadyExists
+	}
+	return nil
+}
+
+func (c *sqliteCache) Delete(ctx context.Context, key string) error {
+	_, err := c.db.ExecContext(ctx, `DELETE FROM cache WHERE key = ?`, key)
+	return err
+}
+
+func (c *sqliteCache) Close(ctx context.Context) error {
+	c.shutSig.CloseAtLeisure()
+	select {
+	case <-c.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return c.db.Close()
+}
@@ -0,0 +1,129 @@
+package sql
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLiteCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	ctx := context.Background()
+
+	c, err := newSQLiteCache(path, 0, 0, 0, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	_, err = c.Get(ctx, "foo")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	require.NoError(t, c.Set(ctx, "foo", []byte("first"), nil))
+	require.NoError(t, c.Set(ctx, "foo", []byte("second"), nil))
+	assert.Equal(t, service.ErrKeyAlreadyExists, c.Add(ctx, "foo", []byte("third"), nil))
+	require.NoError(t, c.Add(ctx, "bar", []byte("barv"), nil))
+
+	v, err := c.Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(v))
+
+	require.NoError(t, c.Delete(ctx, "bar"))
+	_, err = c.Get(ctx, "bar")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	require.NoError(t, c.Close(ctx))
+
+	// Items survive a restart.
+	c, err = newSQLiteCache(path, 0, 0, 0, service.MockResources().Logger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = c.Close(ctx)
+	})
+
+	v, err = c.Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(v))
+}
+
+func TestSQLiteCacheSpecialPath(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "foo bar%20")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	path := filepath.Join(dir, "cache?mode=memory#baz.db")
+	ctx := context.Background()
+
+	c, err := newSQLiteCache(path, 0, 0, 0, service.MockResources().Logger())
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, "foo", []byte("bar"), nil))
+	require.NoError(t, c.Close(ctx))
+
+	// The database is written to the exact path, and pragmas are applied.
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	c, err = newSQLiteCache(path, 0, 0, 0, service.MockResources().Logger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = c.Close(ctx)
+	})
+
+	v, err := c.Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(v))
+
+	var journalMode string
+	require.NoError(t, c.db.QueryRowContext(ctx, `PRAGMA journal_mode`).Scan(&journalMode))
+	assert.Equal(t, "wal", journalMode)
+}
+
+func TestSQLiteCacheTTLAndCompaction(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := newSQLiteCache(filepath.Join(t.TempDir(), "cache.db"), time.Minute, 2, 0, service.MockResources().Logger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = c.Close(ctx)
+	})
+
+	now := time.Unix(1000, 0)
+	c.nowFn = func() time.Time {
+		return now
+	}
+
+	long := time.Hour
+	require.NoError(t, c.Set(ctx, "a", []byte("a"), nil))
+	require.NoError(t, c.Set(ctx, "b", []byte("b"), &long))
+	require.NoError(t, c.Set(ctx, "c", []byte("c"), &long))
+	require.NoError(t, c.Set(ctx, "d", []byte("d"), &long))
+
+	now = now.Add(time.Minute)
+
+	// Expired items are not returned and can be added again.
+	_, err = c.Get(ctx, "a")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+	require.NoError(t, c.Add(ctx, "a", []byte("a2"), &long))
+
+	v, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "a2", string(v))
+
+	// Compaction deletes the oldest items beyond the limit.
+	require.NoError(t, c.compact(ctx))
+
+	var keys []string
+	rows, err := c.db.Query(`SELECT key FROM cache ORDER BY key`)
+	require.NoError(t, err)
+	for rows.Next() {
+		var k string
+		require.NoError(t, rows.Scan(&k))
+		keys = append(keys, k)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"a", "d"}, keys)
+}
@@ -1,10 +1,14 @@
 package sql
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 
@@ -18,7 +22,15 @@ func sqlSelectInputConfig() *service.ConfigSpec {
 		Beta().
 		Categories("Services").
 		Summary("Executes a select query and creates a message for each row received.").
-		Description(`Once the rows from the query are exhausted this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a [sequence](/docs/components/inputs/sequence) to execute).`).
+		Description(`Once the rows from the query are exhausted this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a [sequence](/docs/components/inputs/sequence) to execute).
+
+### Incremental Polling
+
+When the ` + "`incremental`" + ` field is set the input instead polls the table periodically for rows where the value of the tracked column is greater than the highest value consumed so far (the high-water mark), ordered by that column. This allows a table to be streamed as rows are added to it without change data capture infrastructure, as long as the tracked column is an incrementing value such as an auto incrementing ID or a last updated timestamp.
+
+The high-water mark is only ever advanced in the ` + "`checkpoint_cache`" + ` once the messages of all rows up to it have been acknowledged, and therefore when the input restarts it resumes from the last delivered row. Without a ` + "`checkpoint_cache`" + ` the high-water mark is held in memory only and the table is consumed from the ` + "`initial_value`" + ` each time the input starts.
+
+Rows are missed when they are committed with a tracked column value lower than one that has already been consumed, such as when concurrent transactions commit out of order, and therefore the column should be assigned in commit order. Similarly, when a ` + "`limit`" + ` is set rows that share the tracked column value of the last row of a page may be skipped, and therefore the column should be unique.`).
 		Field(driverField).
 		Field(dsnField).
 		Field(service.NewStringField("table").
@@ -44,7 +56,34 @@ func sqlSelectInputConfig() *service.ConfigSpec {
 		Field(service.NewStringField("suffix").
 			Description("An optional suffix to append to the select query.").
 			Optional().
-			Advanced())
+			Advanced()).
+		Field(service.NewObjectField("incremental",
+			service.NewStringField("column").
+				Description("The column to track, which must increment for each new or updated row. The column must be included in the selected `columns`.").
+				Example("id").
+				Example("updated_at"),
+			service.NewDurationField("poll_interval").
+				Description("The period to wait before polling the table for new rows once the rows of the previous poll are exhausted.").
+				Default("10s"),
+			service.NewAnyField("initial_value").
+				Description("An optional value of the tracked column to begin from when no high-water mark has been stored, where only rows with a greater value are consumed. When omitted all rows are consumed.").
+				Example(0).
+				Example("2023-01-01 00:00:00").
+				Optional(),
+			service.NewStringField("checkpoint_cache").
+				Description("An optional [cache resource](/docs/components/caches/about) to store the high-water mark within, allowing the input to resume from the last acknowledged row when restarted.").
+				Optional(),
+			service.NewStringField("checkpoint_key").
+				Description("The key under which the high-water mark is stored within the `checkpoint_cache`. Defaults to `sql_select_` followed by the table name.").
+				Optional().
+				Advanced(),
+			service.NewIntField("limit").
+				Description("The maximum number of rows to select with each poll, where subsequent polls are made immediately whilst the limit is reached. Set to 0 to disable the limit. The limit is applied with a LIMIT clause and therefore is not supported by drivers that lack it, such as mssql and oracle.").
+				Default(0).
+				Advanced(),
+		).
+			Description("Optional configuration for polling the table incrementally rather than running the query once.").
+			Optional())
 
 	for _, f := range connFields() {
 		spec = spec.Field(f)
@@ -67,6 +106,27 @@ input:
       root = [
         now().ts_unix() - 3600
       ]
+`,
+		).
+		Example("Stream New Rows (MySQL)",
+			`
+Here we poll a table every five seconds for rows with an "id" greater than any consumed so far, storing the highest acknowledged "id" in Redis so that the input resumes from where it left off when restarted:`,
+			`
+input:
+  sql_select:
+    driver: mysql
+    dsn: foouser:foopassword@tcp(localhost:3306)/foodb
+    table: events
+    columns: [ '*' ]
+    incremental:
+      column: id
+      poll_interval: 5s
+      checkpoint_cache: checkpoints
+
+cache_resources:
+  - label: checkpoints
+    redis:
+      url: redis://localhost:6379
 `,
 		)
 	return spec
@@ -102,6 +162,8 @@ type sqlSelectInput struct {
 
 	connSettings *connSettings
 
+	incremental *sqlSelectIncremental
+
 	logger  *service.Logger
 	shutSig *shutdown.Signaller
 }
@@ -144,12 +206,24 @@ func newSQLSelectInputFromConfig(conf *service.ParsedConfig, mgr *service.Resour
 		}
 	}
 
+	if conf.Contains("incremental") {
+		if s.incremental, err = sqlSelectIncrementalFromParsed(conf.Namespace("incremental"), mgr, tableStr); err != nil {
+			return nil, err
+		}
+	}
+
 	s.builder = squirrel.Select(columns...).From(tableStr)
 	if s.driver == "postgres" || s.driver == "clickhouse" {
 		s.builder = s.builder.PlaceholderFormat(squirrel.Dollar)
 	} else if s.driver == "oracle" {
 		s.builder = s.builder.PlaceholderFormat(squirrel.Colon)
 	}
+	if s.incremental != nil {
+		s.builder = s.builder.OrderBy(s.incremental.column + " ASC")
+		if s.incremental.limit > 0 {
+			s.builder = s.builder.Limit(uint64(s.incremental.limit))
+		}
+	}
 
 	if conf.Contains("prefix") {
 		prefixStr, err := conf.FieldString("prefix")
@@ -173,37 +247,135 @@ func newSQLSelectInputFromConfig(conf *service.ParsedConfig, mgr *service.Resour
 	return s, nil
 }
 
-func (s *sqlSelectInput) Connect(ctx context.Context) (err error) {
-	s.dbMut.Lock()
-	defer s.dbMut.Unlock()
+//------------------------------------------------------------------------------
 
-	if s.db != nil {
-		return nil
+type sqlSelectIncremental struct {
+	column       string
+	pollInterval time.Duration
+	limit        int
+	checkpointer *service.CacheCheckpointer
+
+	// The highest value of the tracked column consumed so far, which is nil
+	// until either a row is consumed or a starting value is known.
+	watermark    any
+	hasWatermark bool
+
+	// The time of the next poll and the number of rows consumed by the
+	// current one.
+	nextPoll time.Time
+	polled   int
+}
+
+func sqlSelectIncrementalFromParsed(conf *service.ParsedConfig, mgr *service.Resources, table string) (*sqlSelectIncremental, error) {
+	i := &sqlSelectIncremental{}
+
+	var err error
+	if i.column, err = conf.FieldString("column"); err != nil {
+		return nil, err
+	}
+	if i.pollInterval, err = conf.FieldDuration("poll_interval"); err != nil {
+		return nil, err
+	}
+	if i.limit, err = conf.FieldInt("limit"); err != nil {
+		return nil, err
+	}
+	if i.limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative, got %v", i.limit)
 	}
 
-	var db *sql.DB
-	if db, err = sqlOpenWithReworks(s.logger, s.driver, s.dsn); err != nil {
-		return
+	if conf.Contains("initial_value") {
+		if i.watermark, err = conf.FieldAny("initial_value"); err != nil {
+			return nil, err
+		}
+		i.hasWatermark = true
 	}
-	defer func() {
+
+	if conf.Contains("checkpoint_cache") {
+		cache, err := conf.FieldString("checkpoint_cache")
 		if err != nil {
-			_ = db.Close()
+			return nil, err
 		}
-	}()
+		key := "sql_select_" + table
+		if conf.Contains("checkpoint_key") {
+			if key, err = conf.FieldString("checkpoint_key"); err != nil {
+				return nil, err
+			}
+		}
+		if i.checkpointer, err = service.NewCacheCheckpointer(mgr, cache, key, 1024); err != nil {
+			return nil, err
+		}
+	}
+	return i, nil
+}
 
-	s.connSettings.apply(ctx, db, s.logger)
+// encodeWatermark serialises a value of the tracked column as JSON, where
+// timestamps are wrapped in an object so that they can be decoded back into
+// timestamps rather than strings.
+func encodeWatermark(v any) ([]byte, error) {
+	if t, ok := v.(time.Time); ok {
+		return json.Marshal(map[string]any{"time": t.Format(time.RFC3339Nano)})
+	}
+	return json.Marshal(v)
+}
+
+// decodeWatermark parses a value of the tracked column serialised with
+// encodeWatermark.
+func decodeWatermark(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	case map[string]any:
+		str, ok := t["time"].(string)
+		if !ok {
+			return nil, errors.New("expected a time field")
+		}
+		return time.Parse(time.RFC3339Nano, str)
+	}
+	return v, nil
+}
+
+// resume sets the watermark from the checkpoint cache, if one has been stored.
+func (i *sqlSelectIncremental) resume(ctx context.Context) error {
+	if i.checkpointer == nil {
+		return nil
+	}
+	cp, err := i.checkpointer.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if cp == nil {
+		return nil
+	}
+	if i.watermark, err = decodeWatermark(cp); err != nil {
+		return fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	i.hasWatermark = true
+	return nil
+}
+
+//------------------------------------------------------------------------------
 
+func (s *sqlSelectInput) query(db *sql.DB) (*sql.Rows, error) {
 	var args []any
 	if s.argsMapping != nil {
-		var iargs any
-		if iargs, err = s.argsMapping.Query(nil); err != nil {
-			return
+		iargs, err := s.argsMapping.Query(nil)
+		if err != nil {
+			return nil, err
 		}
 
 		var ok bool
 		if args, ok = iargs.([]any); !ok {
-			err = fmt.Errorf("mapping returned non-array result: %T", iargs)
-			return
+			return nil, fmt.Errorf("mapping returned non-array result: %T", iargs)
 		}
 	}
 
@@ -211,8 +383,40 @@ func (s *sqlSelectInput) Connect(ctx context.Context) (err error) {
 	if s.where != "" {
 		queryBuilder = queryBuilder.Where(s.where, args...)
 	}
+	if s.incremental != nil && s.incremental.hasWatermark {
+		queryBuilder = queryBuilder.Where(s.incremental.column+" > ?", s.incremental.watermark)
+	}
+	return queryBuilder.RunWith(db).Query()
+}
+
+func (s *sqlSelectInput) Connect(ctx context.Context) (err error) {
+	s.dbMut.Lock()
+	defer s.dbMut.Unlock()
+
+	if s.db != nil {
+		return nil
+	}
+
+	if s.incremental != nil && !s.incremental.hasWatermark {
+		if err = s.incremental.resume(ctx); err != nil {
+			return
+		}
+	}
+
+	var db *sql.DB
+	if db, err = sqlOpenWithReworks(s.logger, s.driver, s.dsn); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = db.Close()
+		}
+	}()
+
+	s.connSettings.apply(ctx, db, s.logger)
+
 	var rows *sql.Rows
-	if rows, err = queryBuilder.RunWith(db).Query(); err != nil {
+	if rows, err = s.query(db); err != nil {
 		return
 	}
 
@@ -237,6 +441,38 @@ func (s *sqlSelectInput) Connect(ctx context.Context) (err error) {
 	return nil
 }
 
+// poll waits until the next poll is due and then queries for rows beyond the
+// watermark. The lock must be held when called, and is released whilst
+// waiting.
+func (s *sqlSelectInput) poll(ctx context.Context) error {
+	if wait := time.Until(s.incremental.nextPoll); wait > 0 {
+		s.dbMut.Unlock()
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			s.dbMut.Lock()
+			return ctx.Err()
+		case <-s.shutSig.CloseNowChan():
+			s.dbMut.Lock()
+			return service.ErrEndOfInput
+		}
+		s.dbMut.Lock()
+	}
+	if s.shutSig.ShouldCloseNow() {
+		return service.ErrEndOfInput
+	}
+
+	rows, err := s.query(s.db)
+	if err != nil {
+		// Avoid hammering the database when queries are failing.
+		s.incremental.nextPoll = time.Now().Add(s.incremental.pollInterval)
+		return err
+	}
+	s.rows = rows
+	s.incremental.polled = 0
+	return nil
+}
+
 func (s *sqlSelectInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
 	s.dbMut.Lock()
 	defer s.dbMut.Unlock()
@@ -245,18 +481,36 @@ func (s *sqlSelectInput) Read(ctx context.Context) (*service.Message, service.Ac
 		return nil, nil, service.ErrNotConnected
 	}
 
-	if s.rows == nil {
-		return nil, nil, service.ErrEndOfInput
-	}
+	for {
+		if s.rows == nil {
+			if s.incremental == nil {
+				return nil, nil, service.ErrEndOfInput
+			}
+			if err := s.poll(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
 
-	if !s.rows.Next() {
-		err := s.rows.Err()
-		if err == nil {
-			err = service.ErrEndOfInput
+		if s.rows.Next() {
+			break
 		}
+
+		err := s.rows.Err()
 		_ = s.rows.Close()
 		s.rows = nil
-		return nil, nil, err
+		if err != nil {
+			return nil, nil, err
+		}
+		if s.incremental == nil {
+			return nil, nil, service.ErrEndOfInput
+		}
+
+		// Only wait for the next poll when the last one had fewer rows than
+		// the limit, otherwise there may be more rows ready to consume.
+		s.incremental.nextPoll = time.Now()
+		if s.incremental.limit == 0 || s.incremental.polled < s.incremental.limit {
+			s.incremental.nextPoll = s.incremental.nextPoll.Add(s.incremental.pollInterval)
+		}
 	}
 
 	obj, err := sqlRowToMap(s.rows)
@@ -268,11 +522,37 @@ func (s *sqlSelectInput) Read(ctx context.Context) (*service.Message, service.Ac
 
 	msg := service.NewMessage(nil)
 	msg.SetStructuredMut(obj)
-	return msg, func(ctx context.Context, err error) error {
+
+	ackFn := func(ctx context.Context, err error) error {
 		// Nacks are handled by AutoRetryNacks because we don't have an explicit
 		// ack mechanism right now.
 		return nil
-	}, nil
+	}
+
+	if s.incremental != nil {
+		s.incremental.polled++
+
+		v, exists := obj[s.incremental.column]
+		if !exists {
+			return nil, nil, fmt.Errorf("tracked column %v was not found in the selected row", s.incremental.column)
+		}
+		if v == nil {
+			return msg, ackFn, nil
+		}
+		s.incremental.watermark = v
+		s.incremental.hasWatermark = true
+
+		if s.incremental.checkpointer != nil {
+			cp, err := encodeWatermark(v)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to encode checkpoint: %w", err)
+			}
+			if ackFn, err = s.incremental.checkpointer.Track(ctx, cp, 1, nil); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return msg, ackFn, nil
 }
 
 func (s *sqlSelectInput) Close(ctx context.Context) error {
@@ -2,11 +2,18 @@ package sql
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "modernc.org/sqlite"
 )
 
 func TestSQLSelectInputEmptyShutdown(t *testing.T) {
@@ -29,3 +36,141 @@ args_mapping: 'root = [ this.id ]'
 	require.NoError(t, err)
 	require.NoError(t, selectInput.Close(context.Background()))
 }
+
+func testSQLiteSelectIncremental(t *testing.T, mgr *service.Resources, dsn, conf string) *sqlSelectInput {
+	t.Helper()
+
+	selectConfig, err := sqlSelectInputConfig().ParseYAML(fmt.Sprintf(`
+driver: sqlite
+dsn: %v
+table: footable
+columns: [ id, foo ]
+where: foo != ?
+args_mapping: 'root = [ "skip" ]'
+incremental:
+  column: id
+  poll_interval: 10ms
+`, dsn)+conf, nil)
+	require.NoError(t, err)
+
+	in, err := newSQLSelectInputFromConfig(selectConfig, mgr)
+	require.NoError(t, err)
+	require.NoError(t, in.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = in.Close(context.Background())
+	})
+	return in
+}
+
+func readSelectRows(t *testing.T, in *sqlSelectInput, n int, ack bool) (rows []any) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	for i := 0; i < n; i++ {
+		msg, ackFn, err := in.Read(ctx)
+		require.NoError(t, err)
+
+		v, err := msg.AsStructured()
+		require.NoError(t, err)
+		rows = append(rows, v)
+
+		if ack {
+			require.NoError(t, ackFn(ctx, nil))
+		}
+	}
+	return
+}
+
+func TestSQLSelectInputIncremental(t *testing.T) {
+	// WAL mode allows rows to be inserted whilst the input holds a cursor.
+	dsn := "file:" + filepath.Join(t.TempDir(), "foo.db") + "?_pragma=journal_mode(WAL)"
+
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = db.Exec(`create table footable (
+  "id" integer not null,
+  "foo" varchar(50) not null,
+  primary key ("id")
+);
+insert into footable (id, foo) values (2, 'b'), (1, 'a'), (3, 'skip'), (4, 'c');`)
+	require.NoError(t, err)
+
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+
+	in := testSQLiteSelectIncremental(t, mgr, dsn, `
+  checkpoint_cache: foocache
+  limit: 2
+`)
+
+	assert.Equal(t, []any{
+		map[string]any{"id": int64(1), "foo": "a"},
+		map[string]any{"id": int64(2), "foo": "b"},
+		map[string]any{"id": int64(4), "foo": "c"},
+	}, readSelectRows(t, in, 3, true))
+
+	_, err = db.Exec(`insert into footable (id, foo) values (5, 'd'), (6, 'e');`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []any{
+		map[string]any{"id": int64(5), "foo": "d"},
+		map[string]any{"id": int64(6), "foo": "e"},
+	}, readSelectRows(t, in, 2, false))
+	require.NoError(t, in.Close(context.Background()))
+
+	// Rows that were not acknowledged are consumed again after a restart.
+	in = testSQLiteSelectIncremental(t, mgr, dsn, `
+  checkpoint_cache: foocache
+`)
+	assert.Equal(t, []any{
+		map[string]any{"id": int64(5), "foo": "d"},
+		map[string]any{"id": int64(6), "foo": "e"},
+	}, readSelectRows(t, in, 2, true))
+}
+
+func TestSQLSelectInputIncrementalInitialValue(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "foo.db")
+
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = db.Exec(`create table footable (
+  "id" integer not null,
+  "foo" varchar(50) not null,
+  primary key ("id")
+);
+insert into footable (id, foo) values (1, 'a'), (2, 'b'), (3, 'c');`)
+	require.NoError(t, err)
+
+	in := testSQLiteSelectIncremental(t, service.MockResources(), dsn, `
+  initial_value: 1
+`)
+	assert.Equal(t, []any{
+		map[string]any{"id": int64(2), "foo": "b"},
+		map[string]any{"id": int64(3), "foo": "c"},
+	}, readSelectRows(t, in, 2, true))
+}
+
+func TestSQLSelectWatermarkEncoding(t *testing.T) {
+	for _, v := range []any{
+		int64(10),
+		1.5,
+		"2023-01-01 10:00:00",
+		time.Date(2023, 1, 1, 10, 0, 0, 500, time.UTC),
+	} {
+		b, err := encodeWatermark(v)
+		require.NoError(t, err)
+
+		decoded, err := decodeWatermark(b)
+		require.NoError(t, err)
+		assert.Equal(t, v, decoded, string(b))
+	}
+}
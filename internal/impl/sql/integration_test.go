@@ -344,16 +344,21 @@ var testDeprecatedProcessorsBasic = testRawDeprecatedProcessors("deprecated", fu
 })
 
 func testBatchInputOutputBatch(t *testing.T, driver, dsn, table string) {
+	testBatchInputOutputBatchMode(t, driver, dsn, table, "auto")
+}
+
+func testBatchInputOutputBatchMode(t *testing.T, driver, dsn, table, mode string) {
 	colList := `[ "foo", "bar", "baz" ]`
 	if driver == "oracle" {
 		colList = `[ "\"foo\"", "\"bar\"", "\"baz\"" ]`
 	}
-	t.Run("batch_input_output", func(t *testing.T) {
+	t.Run("batch_input_output_"+mode, func(t *testing.T) {
 		confReplacer := strings.NewReplacer(
 			"$driver", driver,
 			"$dsn", dsn,
 			"$table", table,
 			"$columnlist", colList,
+			"$mode", mode,
 		)
 
 		outputConf := confReplacer.Replace(`
@@ -362,6 +367,7 @@ sql_insert:
   dsn: $dsn
   table: $table
   columns: $columnlist
+  mode: $mode
   args_mapping: 'root = [ this.foo, this.bar.floor(), this.baz ]'
 `)
 
@@ -716,6 +722,10 @@ func TestIntegrationPostgres(t *testing.T) {
 	}))
 
 	testSuite(t, "postgres", dsn, createTable)
+
+	copyTable, err := createTable("copytable")
+	require.NoError(t, err)
+	testBatchInputOutputBatchMode(t, "postgres", dsn, copyTable, "copy")
 }
 
 func TestIntegrationMySQL(t *testing.T) {
@@ -733,6 +743,7 @@ func TestIntegrationMySQL(t *testing.T) {
 		ExposedPorts: []string{"3306/tcp"},
 		Cmd: []string{
 			"--sql_mode=ANSI_QUOTES",
+			"--local-infile=1",
 		},
 		Env: []string{
 			"MYSQL_USER=testuser",
@@ -780,6 +791,10 @@ func TestIntegrationMySQL(t *testing.T) {
 	}))
 
 	testSuite(t, "mysql", dsn, createTable)
+
+	loadDataTable, err := createTable("loaddatatable")
+	require.NoError(t, err)
+	testBatchInputOutputBatchMode(t, "mysql", dsn, loadDataTable, "load_data")
 }
 
 func TestIntegrationMSSQL(t *testing.T) {
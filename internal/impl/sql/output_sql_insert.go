@@ -1,8 +1,10 @@
 package sql
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -18,7 +20,12 @@ func sqlInsertOutputConfig() *service.ConfigSpec {
 		Stable().
 		Categories("Services").
 		Summary("Inserts a row into an SQL database for each message.").
-		Description(``).
+		Description(`
+### Bulk Inserts
+
+By default the rows of each batch are inserted with a single INSERT statement containing multiple rows, or by executing a prepared statement for each row within a transaction for drivers that do not support multi-row inserts. Large batches are inserted significantly faster by the ` + "`copy`" + ` mode with the ` + "`postgres`" + ` driver, or the ` + "`load_data`" + ` mode with the ` + "`mysql`" + ` driver, both of which stream the rows of a batch to the database with a single statement.
+
+When a batch fails to be inserted the entire batch is rejected by default, which can be changed with ` + "`isolate_row_errors`" + ` in order to reject only the messages of rows that fail to be inserted.`).
 		Field(driverField).
 		Field(dsnField).
 		Field(service.NewStringField("table").
@@ -40,6 +47,27 @@ func sqlInsertOutputConfig() *service.ConfigSpec {
 			Optional().
 			Advanced().
 			Example("ON CONFLICT (name) DO NOTHING")).
+		Field(service.NewStringAnnotatedEnumField("mode", map[string]string{
+			"auto":      "Uses `row` for the `clickhouse` and `oracle` drivers, and `multi_row` for all others.",
+			"multi_row": "Inserts the rows of a batch with INSERT statements containing multiple rows. Batches that exceed `max_rows_per_statement` are split across multiple statements within a transaction.",
+			"row":       "Inserts each row of a batch by executing a prepared statement within a transaction.",
+			"copy":      "Inserts the rows of a batch with a `COPY ... FROM STDIN` statement, which is only supported by the `postgres` driver.",
+			"load_data": "Inserts the rows of a batch with a `LOAD DATA LOCAL INFILE` statement, which is only supported by the `mysql` driver and requires the server to be configured with `local_infile` enabled.",
+		}).
+			Description("The method used to insert the rows of each batch. The `copy` and `load_data` modes are significantly faster than other modes for large batches, but do not support `prefix` or `suffix`.").
+			Default("auto").
+			Advanced().
+			Version("4.20.0")).
+		Field(service.NewIntField("max_rows_per_statement").
+			Description("The maximum number of rows to insert with a single statement when using the `multi_row` mode. When set to `0` batches are only split when they would exceed the maximum number of placeholders, or rows, that a statement of the driver may contain.").
+			Default(0).
+			Advanced().
+			Version("4.20.0")).
+		Field(service.NewBoolField("isolate_row_errors").
+			Description("Whether to insert the rows of a batch individually when inserting the batch as a whole fails, in which case only the messages of rows that fail to be inserted are rejected, rather than the entire batch. Messages that fail to be mapped into arguments are also rejected individually. Note that the rows of a batch that fail in `multi_row`, `copy` or `load_data` modes are retried individually with INSERT statements.").
+			Default(false).
+			Advanced().
+			Version("4.20.0")).
 		Field(service.NewIntField("max_in_flight").
 			Description("The maximum number of inserts to run in parallel.").
 			Default(64))
@@ -98,8 +126,13 @@ type sqlInsertOutput struct {
 	builder squirrel.InsertBuilder
 	dbMut   sync.RWMutex
 
-	useTxStmt   bool
-	argsMapping *bloblang.Executor
+	table            string
+	columns          []string
+	mode             string
+	rowSQL           string
+	maxRows          int
+	isolateRowErrors bool
+	argsMapping      *bloblang.Executor
 
 	connSettings *connSettings
 
@@ -118,24 +151,16 @@ func newSQLInsertOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resou
 	if s.driver, err = conf.FieldString("driver"); err != nil {
 		return nil, err
 	}
-	if _, in := map[string]struct{}{
-		"clickhouse": {},
-		"oracle":     {},
-	}[s.driver]; in {
-		s.useTxStmt = true
-	}
 
 	if s.dsn, err = conf.FieldString("dsn"); err != nil {
 		return nil, err
 	}
 
-	tableStr, err := conf.FieldString("table")
-	if err != nil {
+	if s.table, err = conf.FieldString("table"); err != nil {
 		return nil, err
 	}
 
-	columns, err := conf.FieldStringList("columns")
-	if err != nil {
+	if s.columns, err = conf.FieldStringList("columns"); err != nil {
 		return nil, err
 	}
 
@@ -145,22 +170,47 @@ func newSQLInsertOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resou
 		}
 	}
 
-	s.builder = squirrel.Insert(tableStr).Columns(columns...)
+	if s.mode, err = conf.FieldString("mode"); err != nil {
+		return nil, err
+	}
+	switch s.mode {
+	case "auto":
+		s.mode = "multi_row"
+		if s.driver == "clickhouse" || s.driver == "oracle" {
+			s.mode = "row"
+		}
+	case "copy":
+		if s.driver != "postgres" {
+			return nil, fmt.Errorf("mode copy is not supported by driver %v", s.driver)
+		}
+	case "load_data":
+		if s.driver != "mysql" {
+			return nil, fmt.Errorf("mode load_data is not supported by driver %v", s.driver)
+		}
+	}
+
+	if s.maxRows, err = conf.FieldInt("max_rows_per_statement"); err != nil {
+		return nil, err
+	}
+	if s.maxRows <= 0 {
+		s.maxRows = maxRowsPerStatement(s.driver, len(s.columns))
+	}
+
+	if s.isolateRowErrors, err = conf.FieldBool("isolate_row_errors"); err != nil {
+		return nil, err
+	}
+
+	s.builder = squirrel.Insert(s.table).Columns(s.columns...)
 	if s.driver == "postgres" || s.driver == "clickhouse" {
 		s.builder = s.builder.PlaceholderFormat(squirrel.Dollar)
 	} else if s.driver == "oracle" {
 		s.builder = s.builder.PlaceholderFormat(squirrel.Colon)
 	}
 
-	if s.useTxStmt {
-		values := make([]any, 0, len(columns))
-		for _, c := range columns {
-			values = append(values, c)
-		}
-		s.builder = s.builder.Values(values...)
-	}
-
 	if conf.Contains("prefix") {
+		if s.mode == "copy" || s.mode == "load_data" {
+			return nil, fmt.Errorf("prefix is not supported by mode %v", s.mode)
+		}
 		prefixStr, err := conf.FieldString("prefix")
 		if err != nil {
 			return nil, err
@@ -169,6 +219,9 @@ func newSQLInsertOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resou
 	}
 
 	if conf.Contains("suffix") {
+		if s.mode == "copy" || s.mode == "load_data" {
+			return nil, fmt.Errorf("suffix is not supported by mode %v", s.mode)
+		}
 		suffixStr, err := conf.FieldString("suffix")
 		if err != nil {
 			return nil, err
@@ -176,6 +229,14 @@ func newSQLInsertOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resou
 		s.builder = s.builder.Suffix(suffixStr)
 	}
 
+	values := make([]any, 0, len(s.columns))
+	for _, c := range s.columns {
+		values = append(values, c)
+	}
+	if s.rowSQL, _, err = s.builder.Values(values...).ToSql(); err != nil {
+		return nil, err
+	}
+
 	if s.connSettings, err = connSettingsFromParsed(conf, mgr); err != nil {
 		return nil, err
 	}
@@ -209,63 +270,165 @@ func (s *sqlInsertOutput) Connect(ctx context.Context) error {
 	return nil
 }
 
-func (s *sqlInsertOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
-	s.dbMut.RLock()
-	defer s.dbMut.RUnlock()
+func (s *sqlInsertOutput) rowArgs(batch service.MessageBatch, i int) ([]any, error) {
+	if s.argsMapping == nil {
+		return nil, nil
+	}
+
+	resMsg, err := batch.BloblangQuery(i, s.argsMapping)
+	if err != nil {
+		return nil, err
+	}
 
-	insertBuilder := s.builder
+	iargs, err := resMsg.AsStructured()
+	if err != nil {
+		return nil, err
+	}
 
-	var tx *sql.Tx
-	var stmt *sql.Stmt
-	if s.useTxStmt {
-		var err error
-		if tx, err = s.db.Begin(); err != nil {
-			return err
-		}
-		sqlStr, _, err := insertBuilder.ToSql()
+	args, ok := iargs.([]any)
+	if !ok {
+		return nil, fmt.Errorf("mapping returned non-array result: %T", iargs)
+	}
+	return args, nil
+}
+
+// insertTx executes fn within a transaction, which is committed if fn
+// succeeds and rolled back otherwise.
+func (s *sqlInsertOutput) insertTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertPrepared executes a prepared statement within a transaction with the
+// arguments of each row.
+func (s *sqlInsertOutput) insertPrepared(ctx context.Context, query string, rows [][]any, flush bool) error {
+	return s.insertTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, query)
 		if err != nil {
 			return err
 		}
-		if stmt, err = tx.Prepare(sqlStr); err != nil {
-			_ = tx.Rollback()
-			return err
+		defer stmt.Close()
+
+		for _, args := range rows {
+			if _, err := stmt.ExecContext(ctx, args...); err != nil {
+				return err
+			}
+		}
+		if flush {
+			_, err = stmt.ExecContext(ctx)
+		}
+		return err
+	})
+}
+
+func (s *sqlInsertOutput) insertMultiRow(ctx context.Context, rows [][]any) error {
+	chunkSize := len(rows)
+	if s.maxRows > 0 && s.maxRows < chunkSize {
+		chunkSize = s.maxRows
+	}
+
+	execChunk := func(runner squirrel.BaseRunner, chunk [][]any) error {
+		insertBuilder := s.builder
+		for _, args := range chunk {
+			insertBuilder = insertBuilder.Values(args...)
 		}
+		_, err := insertBuilder.RunWith(runner).ExecContext(ctx)
+		return err
 	}
 
-	for i := range batch {
-		var args []any
-		if s.argsMapping != nil {
-			resMsg, err := batch.BloblangQuery(i, s.argsMapping)
-			if err != nil {
+	if chunkSize == len(rows) {
+		return execChunk(s.db, rows)
+	}
+	return s.insertTx(ctx, func(tx *sql.Tx) error {
+		for i := 0; i < len(rows); i += chunkSize {
+			end := i + chunkSize
+			if end > len(rows) {
+				end = len(rows)
+			}
+			if err := execChunk(tx, rows[i:end]); err != nil {
 				return err
 			}
+		}
+		return nil
+	})
+}
 
-			iargs, err := resMsg.AsStructured()
-			if err != nil {
+func (s *sqlInsertOutput) insert(ctx context.Context, mode string, rows [][]any) error {
+	switch mode {
+	case "row":
+		return s.insertPrepared(ctx, s.rowSQL, rows, false)
+	case "copy":
+		return s.insertPrepared(ctx, copyInQuery(s.table, s.columns), rows, true)
+	case "load_data":
+		var buf bytes.Buffer
+		for _, args := range rows {
+			if err := appendLoadDataRow(&buf, args); err != nil {
 				return err
 			}
+		}
+		return loadDataLocal(ctx, s.db, s.table, s.columns, buf.Bytes())
+	}
+	return s.insertMultiRow(ctx, rows)
+}
+
+func (s *sqlInsertOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	s.dbMut.RLock()
+	defer s.dbMut.RUnlock()
+
+	var batchErr *service.BatchError
+	rowFailed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = service.NewBatchError(batch, errors.New("failed to insert rows"))
+		}
+		batchErr.Failed(i, err)
+	}
 
-			var ok bool
-			if args, ok = iargs.([]any); !ok {
-				return fmt.Errorf("mapping returned non-array result: %T", iargs)
+	rows := make([][]any, 0, len(batch))
+	indexes := make([]int, 0, len(batch))
+	for i := range batch {
+		args, err := s.rowArgs(batch, i)
+		if err != nil {
+			if !s.isolateRowErrors {
+				return err
 			}
+			rowFailed(i, err)
+			continue
 		}
+		rows = append(rows, args)
+		indexes = append(indexes, i)
+	}
 
-		if tx == nil {
-			insertBuilder = insertBuilder.Values(args...)
-		} else if _, err := stmt.Exec(args...); err != nil {
-			_ = tx.Rollback()
-			return err
+	if len(rows) > 0 {
+		if err := s.insert(ctx, s.mode, rows); err != nil {
+			if !s.isolateRowErrors {
+				return err
+			}
+
+			s.logger.Warnf("Failed to insert batch of %v rows, inserting rows individually: %v", len(rows), err)
+
+			rowMode := "multi_row"
+			if s.mode == "row" {
+				rowMode = "row"
+			}
+			for j, args := range rows {
+				if err := s.insert(ctx, rowMode, [][]any{args}); err != nil {
+					rowFailed(indexes[j], err)
+				}
+			}
 		}
 	}
 
-	var err error
-	if tx == nil {
-		_, err = insertBuilder.RunWith(s.db).ExecContext(ctx)
-	} else {
-		err = tx.Commit()
+	if batchErr != nil {
+		return batchErr
 	}
-	return err
+	return nil
 }
 
 func (s *sqlInsertOutput) Close(ctx context.Context) error {
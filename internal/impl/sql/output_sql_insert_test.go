@@ -1,12 +1,21 @@
 package sql
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "modernc.org/sqlite"
 )
 
 func TestSQLInsertOutputEmptyShutdown(t *testing.T) {
@@ -28,3 +37,169 @@ args_mapping: 'root = [ this.id ]'
 	require.NoError(t, err)
 	require.NoError(t, insertOutput.Close(context.Background()))
 }
+
+func testSQLiteInsertOutput(t *testing.T, conf string) (*sqlInsertOutput, *sql.DB) {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "foo.db")
+
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = db.Exec(`create table footable (
+  "foo" varchar(50) not null,
+  "bar" integer not null,
+  primary key ("foo")
+)`)
+	require.NoError(t, err)
+
+	insertConfig, err := sqlInsertOutputConfig().ParseYAML(fmt.Sprintf(`
+driver: sqlite
+dsn: %v
+table: footable
+columns: [ foo, bar ]
+args_mapping: 'root = [ this.foo, this.bar ]'
+`, dsn)+conf, nil)
+	require.NoError(t, err)
+
+	out, err := newSQLInsertOutputFromConfig(insertConfig, service.MockResources())
+	require.NoError(t, err)
+	require.NoError(t, out.Connect(context.Background()))
+	t.Cleanup(func() {
+		require.NoError(t, out.Close(context.Background()))
+	})
+	return out, db
+}
+
+func selectFooRows(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+
+	rows, err := db.Query(`select foo, bar from footable order by bar`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var foo string
+		var bar int
+		require.NoError(t, rows.Scan(&foo, &bar))
+		res = append(res, fmt.Sprintf("%v:%v", foo, bar))
+	}
+	require.NoError(t, rows.Err())
+	return res
+}
+
+func fooBatch(docs ...string) service.MessageBatch {
+	var batch service.MessageBatch
+	for _, d := range docs {
+		batch = append(batch, service.NewMessage([]byte(d)))
+	}
+	return batch
+}
+
+func TestSQLInsertOutputModes(t *testing.T) {
+	for _, conf := range []string{
+		`mode: multi_row`,
+		"mode: multi_row\nmax_rows_per_statement: 2",
+		`mode: row`,
+	} {
+		t.Run(conf, func(t *testing.T) {
+			out, db := testSQLiteInsertOutput(t, conf)
+
+			require.NoError(t, out.WriteBatch(context.Background(), fooBatch(
+				`{"foo":"a","bar":1}`,
+				`{"foo":"b","bar":2}`,
+				`{"foo":"c","bar":3}`,
+				`{"foo":"d","bar":4}`,
+				`{"foo":"e","bar":5}`,
+			)))
+			assert.Equal(t, []string{"a:1", "b:2", "c:3", "d:4", "e:5"}, selectFooRows(t, db))
+
+			// Batches are inserted atomically, even when split into several
+			// statements.
+			require.Error(t, out.WriteBatch(context.Background(), fooBatch(
+				`{"foo":"f","bar":6}`,
+				`{"foo":"g","bar":7}`,
+				`{"foo":"a","bar":8}`,
+			)))
+			assert.Equal(t, []string{"a:1", "b:2", "c:3", "d:4", "e:5"}, selectFooRows(t, db))
+		})
+	}
+}
+
+func TestSQLInsertOutputIsolateRowErrors(t *testing.T) {
+	for _, conf := range []string{
+		`mode: multi_row`,
+		`mode: row`,
+	} {
+		t.Run(conf, func(t *testing.T) {
+			out, db := testSQLiteInsertOutput(t, conf+"\nisolate_row_errors: true")
+
+			require.NoError(t, out.WriteBatch(context.Background(), fooBatch(`{"foo":"a","bar":1}`)))
+
+			batch := fooBatch(
+				`{"foo":"b","bar":2}`,
+				`{"foo":"a","bar":3}`,
+				`not json`,
+				`{"foo":"c","bar":4}`,
+			)
+			indexer := batch.Index()
+
+			err := out.WriteBatch(context.Background(), batch)
+			require.Error(t, err)
+
+			var batchErr *service.BatchError
+			require.ErrorAs(t, err, &batchErr)
+
+			var failed []int
+			batchErr.WalkMessagesIndexedBy(indexer, func(i int, _ *service.Message, err error) bool {
+				if err != nil {
+					failed = append(failed, i)
+				}
+				return true
+			})
+			assert.Equal(t, []int{1, 2}, failed)
+			assert.Equal(t, []string{"a:1", "b:2", "c:4"}, selectFooRows(t, db))
+		})
+	}
+}
+
+func TestSQLInsertOutputBadModes(t *testing.T) {
+	for _, conf := range []string{
+		"driver: mysql\nmode: copy",
+		"driver: postgres\nmode: load_data",
+		"driver: postgres\nmode: copy\nsuffix: ON CONFLICT DO NOTHING",
+	} {
+		insertConfig, err := sqlInsertOutputConfig().ParseYAML(conf+`
+dsn: foo
+table: footable
+columns: [ foo ]
+args_mapping: 'root = [ this.foo ]'
+`, nil)
+		require.NoError(t, err)
+
+		_, err = newSQLInsertOutputFromConfig(insertConfig, service.MockResources())
+		assert.Error(t, err, conf)
+	}
+}
+
+func TestMaxRowsPerStatement(t *testing.T) {
+	assert.Equal(t, 21845, maxRowsPerStatement("postgres", 3))
+	assert.Equal(t, 1000, maxRowsPerStatement("mssql", 2))
+	assert.Equal(t, 209, maxRowsPerStatement("mssql", 10))
+	assert.Equal(t, 0, maxRowsPerStatement("snowflake", 10))
+}
+
+func TestBulkInsertQueries(t *testing.T) {
+	assert.Equal(t, "COPY foo (a, b) FROM STDIN", copyInQuery("foo", []string{"a", "b"}))
+
+	var buf bytes.Buffer
+	require.NoError(t, appendLoadDataRow(&buf, []any{"a\tb\\c\nd", nil, true, int64(10), 1.5, json.Number("20"), []any{"x"}}))
+	require.NoError(t, appendLoadDataRow(&buf, []any{time.Date(2023, 1, 2, 3, 4, 5, 6000, time.UTC), `\N`}))
+	assert.Equal(t, "a\\tb\\\\c\\nd\t\\N\t1\t10\t1.5\t20\t[\"x\"]\n2023-01-02 03:04:05.000006\t\\\\N\n", buf.String())
+
+	assert.Error(t, appendLoadDataRow(&buf, []any{struct{}{}}))
+}
@@ -0,0 +1,81 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestSQLRawOutputQueriesTransaction(t *testing.T) {
+	dsn, db := testSQLiteRawDB(t)
+
+	outConf, err := sqlRawOutputConfig().ParseYAML(fmt.Sprintf(`
+driver: sqlite
+dsn: %v
+queries:
+  - query: "INSERT INTO footable (foo, bar) VALUES (:foo, :bar);"
+    args_mapping: |
+      root.foo = this.foo
+      root.bar = this.bar
+  - query: "UPDATE footable SET bar = bar * 10 WHERE foo = ?;"
+    args_mapping: 'root = [ this.foo ]'
+`, dsn), nil)
+	require.NoError(t, err)
+
+	out, err := newSQLRawOutputFromConfig(outConf, service.MockResources())
+	require.NoError(t, err)
+	require.NoError(t, out.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = out.Close(context.Background())
+	})
+
+	require.NoError(t, out.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"foo":"a","bar":1}`)),
+		service.NewMessage([]byte(`{"foo":"b","bar":2}`)),
+	}))
+
+	// A failed statement rolls back the whole batch.
+	require.Error(t, out.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"foo":"c","bar":3}`)),
+		service.NewMessage([]byte(`{"foo":"a","bar":4}`)),
+	}))
+
+	rows, err := db.Query("SELECT foo, bar FROM footable ORDER BY foo")
+	require.NoError(t, err)
+	res, err := sqlRowsToArray(rows)
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		map[string]any{"foo": "a", "bar": int64(10)},
+		map[string]any{"foo": "b", "bar": int64(20)},
+	}, res)
+}
+
+func TestSQLRawOutputConfigErrors(t *testing.T) {
+	for _, conf := range []string{
+		``,
+		`
+query: "SELECT 1"
+queries:
+  - query: "SELECT 2"
+`,
+		`
+queries: []
+`,
+		`
+unsafe_dynamic_query: true
+queries:
+  - query: "SELECT 1"
+`,
+	} {
+		outConf, err := sqlRawOutputConfig().ParseYAML("driver: sqlite\ndsn: foo\n"+conf, nil)
+		require.NoError(t, err)
+
+		_, err = newSQLRawOutputFromConfig(outConf, service.MockResources())
+		require.Error(t, err, conf)
+	}
+}
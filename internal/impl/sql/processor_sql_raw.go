@@ -7,7 +7,6 @@ import (
 	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
-	"github.com/benthosdev/benthos/v4/public/bloblang"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
@@ -18,21 +17,39 @@ func RawProcessorConfig() *service.ConfigSpec {
 		Categories("Integration").
 		Summary("Runs an arbitrary SQL query against a database and (optionally) returns the result as an array of objects, one for each row returned.").
 		Description(`
-If the query fails to execute then the message will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).`).
+If the query fails to execute then the message will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).
+
+### Scripts
+
+A list of statements can be executed for each message with the field ` + "`queries`" + `, in which case all statements of a batch are executed within a single transaction. If any statement fails then the transaction is rolled back and all messages of the batch remain unchanged and are flagged with the error. When ` + "`exec_only`" + ` is ` + "`false`" + ` each message is replaced with the rows returned by the last statement.
+
+### Metadata
+
+When ` + "`exec_only`" + ` is ` + "`true`" + ` the following metadata fields are added to each message:
+
+` + "```text" + `
+- sql_rows_affected
+- sql_last_insert_id
+` + "```" + `
+
+The field ` + "`sql_rows_affected`" + ` is the total number of rows affected by the statements of the message, and ` + "`sql_last_insert_id`" + ` is the most recent generated key of an insert, which is only added for drivers that support it such as ` + "`mysql`" + ` and ` + "`sqlite`" + `. For other drivers, such as ` + "`postgres`" + `, generated keys can be obtained by setting ` + "`exec_only`" + ` to ` + "`false`" + ` and using a ` + "`RETURNING`" + ` clause.`).
 		Field(driverField).
 		Field(dsnField).
 		Field(rawQueryField().
 			Example("INSERT INTO footable (foo, bar, baz) VALUES (?, ?, ?);").
-			Example("SELECT * FROM footable WHERE user_id = $1;")).
+			Example("SELECT * FROM footable WHERE user_id = $1;").
+			Optional()).
 		Field(service.NewBoolField("unsafe_dynamic_query").
 			Description("Whether to enable [interpolation functions](/docs/configuration/interpolation/#bloblang-queries) in the query. Great care should be made to ensure your queries are defended against injection attacks.").
 			Advanced().
 			Default(false)).
 		Field(service.NewBloblangField("args_mapping").
-			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an array of values matching in size to the number of placeholder arguments in the field `query`.").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an array of values matching in size to the number of placeholder arguments in the field `query`." + namedArgsDescription).
 			Example("root = [ this.cat.meow, this.doc.woofs[0] ]").
 			Example(`root = [ meta("user.id") ]`).
+			Example("root.id = this.user.id\nroot.name = this.user.name").
 			Optional()).
+		Field(rawQueriesField()).
 		Field(service.NewBoolField("exec_only").
 			Description("Whether the query result should be discarded. When set to `true` the message contents will remain unchanged, which is useful in cases where you are executing inserts, updates, etc.").
 			Default(false))
@@ -70,6 +87,23 @@ pipeline:
               query: "SELECT * FROM footable WHERE user_id = $1;"
               args_mapping: '[ this.user.id ]'
         result_map: 'root.foo_rows = this'
+`,
+		).
+		Example(
+			"Named Arguments (SQLite)",
+			`Here we insert a row using named arguments extracted from the message, and add the generated key of the row to the message using the metadata field `+"`sql_last_insert_id`"+`.`,
+			`
+pipeline:
+  processors:
+    - sql_raw:
+        driver: sqlite
+        dsn: file:./foo.db
+        query: "INSERT INTO users (name, email) VALUES (:name, :email);"
+        args_mapping: |
+          root.name = this.user.name
+          root.email = this.user.email
+        exec_only: true
+    - mutation: 'root.user.id = meta("sql_last_insert_id").number()'
 `,
 		)
 	return spec
@@ -89,14 +123,13 @@ func init() {
 //------------------------------------------------------------------------------
 
 type sqlRawProcessor struct {
-	db    *sql.DB
-	dbMut sync.RWMutex
+	driver string
+	db     *sql.DB
+	dbMut  sync.RWMutex
 
-	queryStatic string
-	queryDyn    *service.InterpolatedString
-	onlyExec    bool
-
-	argsMapping *bloblang.Executor
+	queries  []rawQuery
+	useTx    bool
+	onlyExec bool
 
 	logger  *service.Logger
 	shutSig *shutdown.Signaller
@@ -115,55 +148,38 @@ func NewSQLRawProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Resou
 		return nil, err
 	}
 
-	queryStatic, err := conf.FieldString("query")
+	queries, useTx, err := rawQueriesFromParsed(conf)
 	if err != nil {
 		return nil, err
 	}
 
-	var queryDyn *service.InterpolatedString
-	if unsafeDyn, err := conf.FieldBool("unsafe_dynamic_query"); err != nil {
-		return nil, err
-	} else if unsafeDyn {
-		if queryDyn, err = conf.FieldInterpolatedString("query"); err != nil {
-			return nil, err
-		}
-	}
-
 	onlyExec, err := conf.FieldBool("exec_only")
 	if err != nil {
 		return nil, err
 	}
 
-	var argsMapping *bloblang.Executor
-	if conf.Contains("args_mapping") {
-		if argsMapping, err = conf.FieldBloblang("args_mapping"); err != nil {
-			return nil, err
-		}
-	}
-
 	connSettings, err := connSettingsFromParsed(conf, mgr)
 	if err != nil {
 		return nil, err
 	}
-	return newSQLRawProcessor(mgr.Logger(), driverStr, dsnStr, queryStatic, queryDyn, onlyExec, argsMapping, connSettings)
+	return newSQLRawProcessor(mgr.Logger(), driverStr, dsnStr, queries, useTx, onlyExec, connSettings)
 }
 
 func newSQLRawProcessor(
 	logger *service.Logger,
 	driverStr, dsnStr string,
-	queryStatic string,
-	queryDyn *service.InterpolatedString,
+	queries []rawQuery,
+	useTx bool,
 	onlyExec bool,
-	argsMapping *bloblang.Executor,
 	connSettings *connSettings,
 ) (*sqlRawProcessor, error) {
 	s := &sqlRawProcessor{
-		logger:      logger,
-		shutSig:     shutdown.NewSignaller(),
-		queryStatic: queryStatic,
-		queryDyn:    queryDyn,
-		onlyExec:    onlyExec,
-		argsMapping: argsMapping,
+		logger:   logger,
+		shutSig:  shutdown.NewSignaller(),
+		driver:   driverStr,
+		queries:  queries,
+		useTx:    useTx,
+		onlyExec: onlyExec,
 	}
 
 	var err error
@@ -184,67 +200,91 @@ func newSQLRawProcessor(
 	return s, nil
 }
 
-func (s *sqlRawProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
-	s.dbMut.RLock()
-	defer s.dbMut.RUnlock()
+// processMessage executes the queries of a message of a batch, and returns a
+// function that applies the result to the message.
+func (s *sqlRawProcessor) processMessage(ctx context.Context, db sqlExecQueryer, batch service.MessageBatch, i int) (func(msg *service.Message), error) {
+	var rowsAffected int64
+	var lastInsertID *int64
+	var jArray []any
 
-	batch = batch.Copy()
-	for i, msg := range batch {
-		var args []any
-		if s.argsMapping != nil {
-			resMsg, err := batch.BloblangQuery(i, s.argsMapping)
-			if err != nil {
-				s.logger.Debugf("Arguments mapping failed: %v", err)
-				msg.SetError(err)
-				continue
-			}
+	for qi, q := range s.queries {
+		queryStr, args, err := q.resolve(s.driver, batch, i)
+		if err != nil {
+			return nil, err
+		}
 
-			iargs, err := resMsg.AsStructured()
+		if s.onlyExec || qi < len(s.queries)-1 {
+			res, err := db.ExecContext(ctx, queryStr, args...)
 			if err != nil {
-				s.logger.Debugf("Mapping returned non-structured result: %v", err)
-				msg.SetError(fmt.Errorf("mapping returned non-structured result: %w", err))
-				continue
+				return nil, err
 			}
-
-			var ok bool
-			if args, ok = iargs.([]any); !ok {
-				s.logger.Debugf("Mapping returned non-array result: %T", iargs)
-				msg.SetError(fmt.Errorf("mapping returned non-array result: %T", iargs))
-				continue
+			if n, err := res.RowsAffected(); err == nil {
+				rowsAffected += n
 			}
+			if id, err := res.LastInsertId(); err == nil && id != 0 {
+				lastInsertID = &id
+			}
+			continue
 		}
 
-		queryStr := s.queryStatic
-		if s.queryDyn != nil {
-			var err error
-			if queryStr, err = batch.TryInterpolatedString(i, s.queryDyn); err != nil {
-				s.logger.Errorf("Query interoplation error: %v", err)
-				msg.SetError(fmt.Errorf("query interpolation error: %w", err))
-				continue
-			}
+		rows, err := db.QueryContext(ctx, queryStr, args...)
+		if err != nil {
+			return nil, err
+		}
+		if jArray, err = sqlRowsToArray(rows); err != nil {
+			return nil, err
 		}
+	}
 
-		if s.onlyExec {
-			if _, err := s.db.ExecContext(ctx, queryStr, args...); err != nil {
-				s.logger.Debugf("Failed to run query: %v", err)
-				msg.SetError(err)
-				continue
-			}
-		} else {
-			rows, err := s.db.QueryContext(ctx, queryStr, args...)
+	return func(msg *service.Message) {
+		if !s.onlyExec {
+			msg.SetStructuredMut(jArray)
+			return
+		}
+		msg.MetaSetMut("sql_rows_affected", rowsAffected)
+		if lastInsertID != nil {
+			msg.MetaSetMut("sql_last_insert_id", *lastInsertID)
+		}
+	}, nil
+}
+
+func (s *sqlRawProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	s.dbMut.RLock()
+	defer s.dbMut.RUnlock()
+
+	batch = batch.Copy()
+	if !s.useTx {
+		for i, msg := range batch {
+			applyFn, err := s.processMessage(ctx, s.db, batch, i)
 			if err != nil {
 				s.logger.Debugf("Failed to run query: %v", err)
 				msg.SetError(err)
 				continue
 			}
+			applyFn(msg)
+		}
+		return []service.MessageBatch{batch}, nil
+	}
 
-			if jArray, err := sqlRowsToArray(rows); err != nil {
-				s.logger.Debugf("Failed to convert rows: %v", err)
-				msg.SetError(err)
-			} else {
-				msg.SetStructuredMut(jArray)
+	applyFns := make([]func(msg *service.Message), len(batch))
+	if err := execInTx(ctx, s.db, func(tx *sql.Tx) error {
+		for i := range batch {
+			var err error
+			if applyFns[i], err = s.processMessage(ctx, tx, batch, i); err != nil {
+				return fmt.Errorf("message %v: %w", i, err)
 			}
 		}
+		return nil
+	}); err != nil {
+		s.logger.Debugf("Failed to run transaction: %v", err)
+		for _, msg := range batch {
+			msg.SetError(err)
+		}
+		return []service.MessageBatch{batch}, nil
+	}
+
+	for i, msg := range batch {
+		applyFns[i](msg)
 	}
 	return []service.MessageBatch{batch}, nil
 }
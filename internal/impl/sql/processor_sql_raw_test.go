@@ -0,0 +1,132 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "modernc.org/sqlite"
+)
+
+func testSQLiteRawDB(t *testing.T) (string, *sql.DB) {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "foo.db")
+
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = db.Exec(`create table footable (
+  "id" integer primary key autoincrement,
+  "foo" varchar(50) not null unique,
+  "bar" integer not null
+)`)
+	require.NoError(t, err)
+	return dsn, db
+}
+
+func testSQLiteRawProcessor(t *testing.T, dsn, conf string) *sqlRawProcessor {
+	t.Helper()
+
+	pConf, err := RawProcessorConfig().ParseYAML(fmt.Sprintf(`
+driver: sqlite
+dsn: %v
+`, dsn)+conf, nil)
+	require.NoError(t, err)
+
+	proc, err := NewSQLRawProcessorFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = proc.Close(context.Background())
+	})
+	return proc
+}
+
+func TestSQLRawProcessorNamedArgsMetadata(t *testing.T) {
+	dsn, _ := testSQLiteRawDB(t)
+
+	proc := testSQLiteRawProcessor(t, dsn, `
+query: "INSERT INTO footable (foo, bar) VALUES (:foo, :bar);"
+args_mapping: |
+  root.foo = this.foo
+  root.bar = this.bar
+exec_only: true
+`)
+
+	batches, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"foo":"a","bar":1}`)),
+		service.NewMessage([]byte(`{"foo":"b","bar":2}`)),
+		service.NewMessage([]byte(`{"foo":"c"}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 3)
+
+	for i, msg := range batches[0][:2] {
+		require.NoError(t, msg.GetError())
+
+		v, exists := msg.MetaGetMut("sql_rows_affected")
+		require.True(t, exists)
+		assert.Equal(t, int64(1), v)
+
+		v, exists = msg.MetaGetMut("sql_last_insert_id")
+		require.True(t, exists)
+		assert.Equal(t, int64(i+1), v)
+	}
+	require.Error(t, batches[0][2].GetError())
+}
+
+func TestSQLRawProcessorQueriesTransaction(t *testing.T) {
+	dsn, db := testSQLiteRawDB(t)
+
+	proc := testSQLiteRawProcessor(t, dsn, `
+queries:
+  - query: "INSERT INTO footable (foo, bar) VALUES (?, ?);"
+    args_mapping: 'root = [ this.foo, this.bar ]'
+  - query: "SELECT foo, bar FROM footable WHERE foo = :foo;"
+    args_mapping: 'root.foo = this.foo'
+`)
+
+	batches, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"foo":"a","bar":1}`)),
+		service.NewMessage([]byte(`{"foo":"b","bar":2}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+
+	for i, exp := range []string{`[{"bar":1,"foo":"a"}]`, `[{"bar":2,"foo":"b"}]`} {
+		require.NoError(t, batches[0][i].GetError())
+		b, err := batches[0][i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(b))
+	}
+
+	// A failed statement rolls back the whole batch.
+	batches, err = proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"foo":"c","bar":3}`)),
+		service.NewMessage([]byte(`{"foo":"a","bar":4}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+
+	for _, msg := range batches[0] {
+		require.Error(t, msg.GetError())
+	}
+	b, err := batches[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"c","bar":3}`, string(b))
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM footable").Scan(&count))
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,238 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const namedArgsDescription = " When the mapping evaluates to an object the query may instead reference its fields with named placeholders of the form `:name`, which are converted into the placeholder style of the driver."
+
+func rawQueriesField() *service.ConfigField {
+	return service.NewObjectListField("queries",
+		service.NewStringField("query").
+			Description("The query to execute, using the same placeholder style as the `query` field."),
+		service.NewBloblangField("args_mapping").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an array of values matching in size to the number of placeholder arguments in the field `query`."+namedArgsDescription).
+			Optional(),
+	).
+		Description("A list of queries to execute in order for each message of a batch, where all queries of a batch are executed within a single transaction that is rolled back when any of them fail. This field cannot be combined with `query`, `args_mapping` or `unsafe_dynamic_query`.").
+		Example([]any{
+			map[string]any{
+				"query":        "INSERT INTO orders (id, total) VALUES (:id, :total);",
+				"args_mapping": "root.id = this.id\nroot.total = this.total",
+			},
+			map[string]any{
+				"query":        "UPDATE customers SET order_count = order_count + 1 WHERE id = ?;",
+				"args_mapping": "root = [ this.customer_id ]",
+			},
+		}).
+		Optional().
+		Advanced()
+}
+
+// rawQuery is a single statement executed by the sql_raw output and processor
+// for each message.
+type rawQuery struct {
+	static      string
+	dynamic     *service.InterpolatedString
+	argsMapping *bloblang.Executor
+}
+
+// rawQueriesFromParsed parses the statements of an sql_raw component, which are
+// either configured with the fields query, args_mapping and
+// unsafe_dynamic_query, or as a list with the field queries. The returned bool
+// is true when the statements should be executed within a transaction.
+func rawQueriesFromParsed(conf *service.ParsedConfig) ([]rawQuery, bool, error) {
+	var qConfs []*service.ParsedConfig
+	if conf.Contains("queries") {
+		var err error
+		if qConfs, err = conf.FieldObjectList("queries"); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if len(qConfs) > 0 {
+		if conf.Contains("query") || conf.Contains("args_mapping") {
+			return nil, false, errors.New("the field queries cannot be combined with query or args_mapping")
+		}
+		if unsafeDyn, err := conf.FieldBool("unsafe_dynamic_query"); err != nil {
+			return nil, false, err
+		} else if unsafeDyn {
+			return nil, false, errors.New("the field queries cannot be combined with unsafe_dynamic_query")
+		}
+
+		queries := make([]rawQuery, 0, len(qConfs))
+		for _, qConf := range qConfs {
+			var q rawQuery
+			var err error
+			if q.static, err = qConf.FieldString("query"); err != nil {
+				return nil, false, err
+			}
+			if qConf.Contains("args_mapping") {
+				if q.argsMapping, err = qConf.FieldBloblang("args_mapping"); err != nil {
+					return nil, false, err
+				}
+			}
+			queries = append(queries, q)
+		}
+		return queries, true, nil
+	}
+
+	if !conf.Contains("query") {
+		return nil, false, errors.New("either the field query or queries must be specified")
+	}
+
+	var q rawQuery
+	var err error
+	if q.static, err = conf.FieldString("query"); err != nil {
+		return nil, false, err
+	}
+	if unsafeDyn, err := conf.FieldBool("unsafe_dynamic_query"); err != nil {
+		return nil, false, err
+	} else if unsafeDyn {
+		if q.dynamic, err = conf.FieldInterpolatedString("query"); err != nil {
+			return nil, false, err
+		}
+	}
+	if conf.Contains("args_mapping") {
+		if q.argsMapping, err = conf.FieldBloblang("args_mapping"); err != nil {
+			return nil, false, err
+		}
+	}
+	return []rawQuery{q}, false, nil
+}
+
+// resolve returns the query and arguments of the statement for a message of a
+// batch.
+func (r *rawQuery) resolve(driver string, batch service.MessageBatch, i int) (string, []any, error) {
+	queryStr := r.static
+	if r.dynamic != nil {
+		var err error
+		if queryStr, err = batch.TryInterpolatedString(i, r.dynamic); err != nil {
+			return "", nil, fmt.Errorf("query interpolation error: %w", err)
+		}
+	}
+
+	if r.argsMapping == nil {
+		return queryStr, nil, nil
+	}
+
+	resMsg, err := batch.BloblangQuery(i, r.argsMapping)
+	if err != nil {
+		return "", nil, err
+	}
+
+	iargs, err := resMsg.AsStructured()
+	if err != nil {
+		return "", nil, fmt.Errorf("mapping returned non-structured result: %w", err)
+	}
+
+	switch t := iargs.(type) {
+	case []any:
+		return queryStr, t, nil
+	case map[string]any:
+		return bindNamedArgs(driver, queryStr, t)
+	}
+	return "", nil, fmt.Errorf("mapping returned non-array result: %T", iargs)
+}
+
+// bindNamedArgs replaces the named placeholders (:name) of a query with the
+// positional placeholders of a driver, and returns the query along with the
+// values of each placeholder in order. Placeholders within quotes and comments
+// are ignored, as are postgres style casts (::type).
+func bindNamedArgs(driver, query string, values map[string]any) (string, []any, error) {
+	var b strings.Builder
+	var args []any
+
+	isNameStart := func(c byte) bool {
+		return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+	}
+	isNameChar := func(c byte) bool {
+		return isNameStart(c) || (c >= '0' && c <= '9')
+	}
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(query[i+1:], c)
+			if end == -1 {
+				end = len(query) - i - 2
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 1
+			continue
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end == -1 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end - 1
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i:], "*/")
+			if end == -1 {
+				end = len(query) - i - 2
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 1
+			continue
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			b.WriteString("::")
+			i++
+			continue
+		case c == ':' && i+1 < len(query) && isNameStart(query[i+1]):
+			j := i + 1
+			for j < len(query) && isNameChar(query[j]) {
+				j++
+			}
+			name := query[i+1 : j]
+			v, exists := values[name]
+			if !exists {
+				return "", nil, fmt.Errorf("named argument %v was not found in the mapping result", name)
+			}
+			args = append(args, v)
+			switch driver {
+			case "postgres", "clickhouse":
+				b.WriteString("$" + strconv.Itoa(len(args)))
+			case "oracle":
+				b.WriteString(":" + strconv.Itoa(len(args)))
+			default:
+				b.WriteByte('?')
+			}
+			i = j - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), args, nil
+}
+
+//------------------------------------------------------------------------------
+
+type sqlExecQueryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// execInTx runs a function within a transaction, which is committed when the
+// function succeeds and rolled back otherwise.
+func execInTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
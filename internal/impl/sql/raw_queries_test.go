@@ -0,0 +1,69 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindNamedArgs(t *testing.T) {
+	values := map[string]any{"id": 1, "name": "foo", "user_2": true}
+
+	tests := []struct {
+		name     string
+		driver   string
+		query    string
+		expected string
+		args     []any
+	}{
+		{
+			name:     "question marks",
+			driver:   "mysql",
+			query:    "INSERT INTO foo (id, name) VALUES (:id, :name);",
+			expected: "INSERT INTO foo (id, name) VALUES (?, ?);",
+			args:     []any{1, "foo"},
+		},
+		{
+			name:     "dollar signs with repeats and casts",
+			driver:   "postgres",
+			query:    "UPDATE foo SET name = :name::text WHERE id = :id OR parent = :id",
+			expected: "UPDATE foo SET name = $1::text WHERE id = $2 OR parent = $3",
+			args:     []any{"foo", 1, 1},
+		},
+		{
+			name:     "colons",
+			driver:   "oracle",
+			query:    "SELECT * FROM foo WHERE a = :user_2 AND b = :id",
+			expected: "SELECT * FROM foo WHERE a = :1 AND b = :2",
+			args:     []any{true, 1},
+		},
+		{
+			name:     "quotes and comments",
+			driver:   "sqlite",
+			query:    "SELECT ':nope', \":nope\" FROM foo -- :nope\nWHERE id = :id /* :nope */ AND t = '10:30'",
+			expected: "SELECT ':nope', \":nope\" FROM foo -- :nope\nWHERE id = ? /* :nope */ AND t = '10:30'",
+			args:     []any{1},
+		},
+		{
+			name:     "unterminated quote",
+			driver:   "sqlite",
+			query:    "SELECT :id, ':nope",
+			expected: "SELECT ?, ':nope",
+			args:     []any{1},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			query, args, err := bindNamedArgs(test.driver, test.query, values)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, query)
+			assert.Equal(t, test.args, args)
+		})
+	}
+
+	_, _, err := bindNamedArgs("mysql", "SELECT :missing", values)
+	require.EqualError(t, err, "named argument missing was not found in the mapping result")
+}
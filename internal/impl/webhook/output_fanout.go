@@ -0,0 +1,574 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	wfoFieldSubscribers        = "subscribers"
+	wfoFieldSubscribersCache   = "subscribers_cache"
+	wfoFieldSubscribersKey     = "subscribers_key"
+	wfoFieldVerb               = "verb"
+	wfoFieldHeaders            = "headers"
+	wfoFieldTimeout            = "timeout"
+	wfoFieldMaxRetries         = "max_retries"
+	wfoFieldBackoff            = "backoff"
+	wfoFieldSuspendAfter       = "suspend_after"
+	wfoFieldSuspendPeriod      = "suspend_period"
+	wfoFieldDeadLetter         = "dead_letter"
+	wfoFieldBatching           = "batching"
+	wfoDefaultContentType      = "application/json"
+	wfoMetaEndpoint            = "webhook_endpoint"
+	wfoMetaError               = "webhook_error"
+	wfoMetaStatusCode          = "webhook_status_code"
+	wfoMetaAttempts            = "webhook_attempts"
+	wfoMetricDelivered         = "webhook_fanout_delivered"
+	wfoMetricFailed            = "webhook_fanout_failed"
+	wfoMetricDeadLettered      = "webhook_fanout_dead_lettered"
+	wfoMetricSuspended         = "webhook_fanout_suspended"
+	wfoMetricLabelEndpointHost = "endpoint"
+)
+
+func webhookFanoutOutputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Network").
+		Version("4.20.0").
+		Summary("Delivers each message to a dynamic list of subscriber webhook URLs, tracking the delivery state of each subscriber independently.").
+		Description(`
+The subscribers of each message are resolved either with the `+"`subscribers`"+` mapping, or by looking up the `+"`subscribers_key`"+` within the `+"`subscribers_cache`"+`, where the result is an array of subscribers. Each subscriber is either a URL string or an object with a `+"`url`"+` field and an optional `+"`headers`"+` object of additional headers. A message that resolves to no subscribers is dropped.
+
+The message is delivered to all of its subscribers in parallel, and each delivery is retried independently with the `+"`backoff`"+` policy up to `+"`max_retries`"+` times. Requests that fail due to a connection error, a 429 status code or a 5xx status code are retried, whereas any other non 2XX status code fails the delivery immediately.
+
+### Dead Letters
+
+When a `+"`dead_letter`"+` output is configured each failed delivery is written to it as a copy of the message with the metadata fields `+"`webhook_endpoint`"+`, `+"`webhook_error`"+`, `+"`webhook_attempts`"+` and, when a response was received, `+"`webhook_status_code`"+`, and the message is acknowledged once all deliveries have either succeeded or been dead lettered. This allows a single failing subscriber to be isolated without holding back the others.
+
+Without a `+"`dead_letter`"+` output a message with any failed deliveries is rejected, and therefore retried to all of its subscribers, which results in duplicate deliveries for the subscribers that succeeded.
+
+### Suspending Endpoints
+
+The number of consecutive failed deliveries is tracked for each subscriber URL. When `+"`suspend_after`"+` is greater than zero and a subscriber reaches that many consecutive failures, subsequent deliveries to it fail immediately without being attempted until the `+"`suspend_period`"+` has passed, after which a single successful delivery resumes it. This prevents a dead subscriber from consuming the retry budget of every message.
+
+### Metrics
+
+The counters `+"`webhook_fanout_delivered`"+`, `+"`webhook_fanout_failed`"+`, `+"`webhook_fanout_dead_lettered`"+` and `+"`webhook_fanout_suspended`"+` are emitted with an `+"`endpoint`"+` label containing the host of the subscriber URL.`).
+		Fields(
+			service.NewBloblangField(wfoFieldSubscribers).
+				Description("A [Bloblang mapping](/docs/guides/bloblang/about) executed on each message that resolves the array of subscribers to deliver the message to.").
+				Optional().
+				Example(`root = this.subscribers`).
+				Example(`root = meta("subscribers").split(",")`),
+			service.NewStringField(wfoFieldSubscribersCache).
+				Description("A [cache resource](/docs/components/caches/about) containing JSON arrays of subscribers, used as an alternative to `subscribers`.").
+				Default(""),
+			service.NewInterpolatedStringField(wfoFieldSubscribersKey).
+				Description("The key of the subscribers of each message within the `subscribers_cache`.").
+				Default("").
+				Example(`${! json("event_type") }`),
+			service.NewStringField(wfoFieldVerb).
+				Description("The HTTP verb to deliver messages with.").
+				Default("POST").
+				Advanced(),
+			service.NewInterpolatedStringMapField(wfoFieldHeaders).
+				Description("A map of headers to add to each request.").
+				Default(map[string]any{"Content-Type": wfoDefaultContentType}).
+				Example(map[string]any{"X-Event-Type": `${! meta("event_type") }`}),
+			service.NewDurationField(wfoFieldTimeout).
+				Description("The maximum period of time to wait for a request to complete.").
+				Default("5s"),
+			service.NewIntField(wfoFieldMaxRetries).
+				Description("The maximum number of times to retry a failed delivery to a subscriber.").
+				Default(3),
+			service.NewBackOffField(wfoFieldBackoff, false, &backoff.ExponentialBackOff{
+				InitialInterval: 500 * time.Millisecond,
+				MaxInterval:     10 * time.Second,
+				MaxElapsedTime:  time.Minute,
+			}).
+				Advanced(),
+			service.NewIntField(wfoFieldSuspendAfter).
+				Description("The number of consecutive failed deliveries after which a subscriber is suspended, set to zero in order to disable suspensions.").
+				Default(0).
+				Advanced(),
+			service.NewDurationField(wfoFieldSuspendPeriod).
+				Description("The period of time a subscriber remains suspended for.").
+				Default("1m").
+				Advanced(),
+			service.NewOutputField(wfoFieldDeadLetter).
+				Description("An optional output to write failed deliveries to.").
+				Optional(),
+			service.NewBatchPolicyField(wfoFieldBatching),
+			service.NewOutputMaxInFlightField(),
+		).
+		Example(
+			"Event Subscriptions",
+			"Deliver events to the subscribers of their event type, which are stored within a Redis cache, and write failed deliveries to a file for later inspection.",
+			`
+output:
+  webhook_fanout:
+    subscribers_cache: subscriptions
+    subscribers_key: ${! json("type") }
+    headers:
+      Content-Type: application/json
+      X-Event-Type: ${! json("type") }
+    suspend_after: 10
+    dead_letter:
+      file:
+        path: ./failed_deliveries.jsonl
+        codec: lines
+
+cache_resources:
+  - label: subscriptions
+    redis:
+      url: redis://localhost:6379
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("webhook_fanout", webhookFanoutOutputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			out service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldMaxInFlight(); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy(wfoFieldBatching); err != nil {
+				return
+			}
+			out, err = newWebhookFanoutWriter(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// subscriber is a single destination of a message.
+type subscriber struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+}
+
+// endpointState tracks the delivery state of a subscriber URL across
+// messages.
+type endpointState struct {
+	consecutiveFailures int
+	suspendedUntil      time.Time
+}
+
+// batchWriter is implemented by the dead letter output, and allows it to be
+// swapped out in tests.
+type batchWriter interface {
+	WriteBatch(ctx context.Context, b service.MessageBatch) error
+	Close(ctx context.Context) error
+}
+
+type webhookFanoutWriter struct {
+	log *service.Logger
+	mgr *service.Resources
+
+	subscribers      *bloblang.Executor
+	subscribersCache string
+	subscribersKey   *service.InterpolatedString
+	verb             string
+	headers          map[string]*service.InterpolatedString
+	maxRetries       int
+	backoff          *backoff.ExponentialBackOff
+	suspendAfter     int
+	suspendPeriod    time.Duration
+	deadLetter       batchWriter
+
+	client  *http.Client
+	sleepFn func(ctx context.Context, d time.Duration) error
+	nowFn   func() time.Time
+
+	mDelivered    *service.MetricCounter
+	mFailed       *service.MetricCounter
+	mDeadLettered *service.MetricCounter
+	mSuspended    *service.MetricCounter
+
+	stateMut sync.Mutex
+	states   map[string]*endpointState
+}
+
+func newWebhookFanoutWriter(conf *service.ParsedConfig, mgr *service.Resources) (*webhookFanoutWriter, error) {
+	w := &webhookFanoutWriter{
+		log:           mgr.Logger(),
+		mgr:           mgr,
+		sleepFn:       sleepWithContext,
+		nowFn:         time.Now,
+		mDelivered:    mgr.Metrics().NewCounter(wfoMetricDelivered, wfoMetricLabelEndpointHost),
+		mFailed:       mgr.Metrics().NewCounter(wfoMetricFailed, wfoMetricLabelEndpointHost),
+		mDeadLettered: mgr.Metrics().NewCounter(wfoMetricDeadLettered, wfoMetricLabelEndpointHost),
+		mSuspended:    mgr.Metrics().NewCounter(wfoMetricSuspended, wfoMetricLabelEndpointHost),
+		states:        map[string]*endpointState{},
+	}
+
+	var err error
+	if conf.Contains(wfoFieldSubscribers) {
+		if w.subscribers, err = conf.FieldBloblang(wfoFieldSubscribers); err != nil {
+			return nil, err
+		}
+	}
+	if w.subscribersCache, err = conf.FieldString(wfoFieldSubscribersCache); err != nil {
+		return nil, err
+	}
+	if (w.subscribers == nil) == (w.subscribersCache == "") {
+		return nil, errors.New("exactly one of subscribers or subscribers_cache must be specified")
+	}
+	if w.subscribersCache != "" && !mgr.HasCache(w.subscribersCache) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", w.subscribersCache)
+	}
+	if w.subscribersKey, err = conf.FieldInterpolatedString(wfoFieldSubscribersKey); err != nil {
+		return nil, err
+	}
+	if w.verb, err = conf.FieldString(wfoFieldVerb); err != nil {
+		return nil, err
+	}
+	if w.headers, err = conf.FieldInterpolatedStringMap(wfoFieldHeaders); err != nil {
+		return nil, err
+	}
+	timeout, err := conf.FieldDuration(wfoFieldTimeout)
+	if err != nil {
+		return nil, err
+	}
+	w.client = &http.Client{Timeout: timeout}
+	if w.maxRetries, err = conf.FieldInt(wfoFieldMaxRetries); err != nil {
+		return nil, err
+	}
+	if w.backoff, err = conf.FieldBackOff(wfoFieldBackoff); err != nil {
+		return nil, err
+	}
+	if w.suspendAfter, err = conf.FieldInt(wfoFieldSuspendAfter); err != nil {
+		return nil, err
+	}
+	if w.suspendPeriod, err = conf.FieldDuration(wfoFieldSuspendPeriod); err != nil {
+		return nil, err
+	}
+	if conf.Contains(wfoFieldDeadLetter) {
+		if w.deadLetter, err = conf.FieldOutput(wfoFieldDeadLetter); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// parseSubscribers converts a structured list of subscribers, where each
+// subscriber is either a URL string or an object.
+func parseSubscribers(v any) ([]subscriber, error) {
+	arr, ok := v.([]any)
+	if !ok {
+		if v == nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("expected subscribers to be an array, got %T", v)
+	}
+
+	subs := make([]subscriber, 0, len(arr))
+	for i, ele := range arr {
+		switch t := ele.(type) {
+		case string:
+			subs = append(subs, subscriber{URL: t})
+		case map[string]any:
+			b, err := json.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			var s subscriber
+			if err := json.Unmarshal(b, &s); err != nil {
+				return nil, fmt.Errorf("subscriber %v: %w", i, err)
+			}
+			if s.URL == "" {
+				return nil, fmt.Errorf("subscriber %v: missing url", i)
+			}
+			subs = append(subs, s)
+		default:
+			return nil, fmt.Errorf("subscriber %v: expected string or object, got %T", i, ele)
+		}
+	}
+	return subs, nil
+}
+
+func (w *webhookFanoutWriter) resolveSubscribers(ctx context.Context, batch service.MessageBatch, i int) ([]subscriber, error) {
+	if w.subscribers != nil {
+		res, err := batch.BloblangQuery(i, w.subscribers)
+		if err != nil {
+			return nil, fmt.Errorf("subscribers mapping: %w", err)
+		}
+		if res == nil {
+			return nil, nil
+		}
+		v, err := res.AsStructured()
+		if err != nil {
+			return nil, fmt.Errorf("subscribers mapping: %w", err)
+		}
+		return parseSubscribers(v)
+	}
+
+	key, err := batch.TryInterpolatedString(i, w.subscribersKey)
+	if err != nil {
+		return nil, fmt.Errorf("subscribers key interpolation: %w", err)
+	}
+	var raw []byte
+	var cErr error
+	if err := w.mgr.AccessCache(ctx, w.subscribersCache, func(c service.Cache) {
+		raw, cErr = c.Get(ctx, key)
+	}); err != nil {
+		return nil, err
+	}
+	if errors.Is(cErr, service.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if cErr != nil {
+		return nil, fmt.Errorf("failed to read subscribers from cache: %w", cErr)
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse subscribers from cache: %w", err)
+	}
+	return parseSubscribers(v)
+}
+
+// deliveryError describes a failed delivery to a subscriber.
+type deliveryError struct {
+	err        error
+	statusCode int
+	attempts   int
+}
+
+func (e *deliveryError) Error() string {
+	return e.err.Error()
+}
+
+func endpointHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "unknown"
+}
+
+// suspended returns whether a subscriber URL is currently suspended.
+func (w *webhookFanoutWriter) suspended(rawURL string) bool {
+	if w.suspendAfter <= 0 {
+		return false
+	}
+	w.stateMut.Lock()
+	defer w.stateMut.Unlock()
+
+	s, exists := w.states[rawURL]
+	return exists && w.nowFn().Before(s.suspendedUntil)
+}
+
+func (w *webhookFanoutWriter) recordResult(rawURL string, failed bool) {
+	w.stateMut.Lock()
+	defer w.stateMut.Unlock()
+
+	s, exists := w.states[rawURL]
+	if !failed {
+		if exists {
+			delete(w.states, rawURL)
+		}
+		return
+	}
+	if !exists {
+		s = &endpointState{}
+		w.states[rawURL] = s
+	}
+	s.consecutiveFailures++
+	if w.suspendAfter > 0 && s.consecutiveFailures >= w.suspendAfter {
+		s.suspendedUntil = w.nowFn().Add(w.suspendPeriod)
+		w.log.Warnf("Suspending webhook subscriber %v for %v after %v consecutive failures", endpointHost(rawURL), w.suspendPeriod, s.consecutiveFailures)
+	}
+}
+
+// attempt performs a single request, returning whether a failure is
+// retryable.
+func (w *webhookFanoutWriter) attempt(ctx context.Context, sub subscriber, body []byte, headers map[string]string) (statusCode int, retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, w.verb, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range sub.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res.StatusCode, false, nil
+	}
+	retryable = res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+	return res.StatusCode, retryable, fmt.Errorf("request returned unexpected status code %v: %s", res.StatusCode, resBody)
+}
+
+// deliver sends a message body to a subscriber, retrying failures.
+func (w *webhookFanoutWriter) deliver(ctx context.Context, sub subscriber, body []byte, headers map[string]string) *deliveryError {
+	host := endpointHost(sub.URL)
+	if w.suspended(sub.URL) {
+		w.mSuspended.Incr(1, host)
+		return &deliveryError{err: errors.New("subscriber is suspended due to consecutive failures")}
+	}
+
+	boff := *w.backoff
+	boff.Reset()
+
+	var dErr *deliveryError
+	for attempts := 1; ; attempts++ {
+		statusCode, retryable, err := w.attempt(ctx, sub, body, headers)
+		if err == nil {
+			w.recordResult(sub.URL, false)
+			w.mDelivered.Incr(1, host)
+			return nil
+		}
+		dErr = &deliveryError{err: err, statusCode: statusCode, attempts: attempts}
+		if !retryable || attempts > w.maxRetries {
+			break
+		}
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			break
+		}
+		if err := w.sleepFn(ctx, wait); err != nil {
+			dErr.err = err
+			break
+		}
+	}
+
+	w.recordResult(sub.URL, true)
+	w.mFailed.Incr(1, host)
+	return dErr
+}
+
+func (w *webhookFanoutWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (w *webhookFanoutWriter) writeMessage(ctx context.Context, batch service.MessageBatch, i int) error {
+	subs, err := w.resolveSubscribers(ctx, batch, i)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	body, err := batch[i].AsBytes()
+	if err != nil {
+		return err
+	}
+	headers := make(map[string]string, len(w.headers))
+	for k, v := range w.headers {
+		if headers[k], err = batch.TryInterpolatedString(i, v); err != nil {
+			return fmt.Errorf("header %v interpolation: %w", k, err)
+		}
+	}
+
+	errs := make([]*deliveryError, len(subs))
+	var wg sync.WaitGroup
+	for j, sub := range subs {
+		wg.Add(1)
+		go func(j int, sub subscriber) {
+			defer wg.Done()
+			errs[j] = w.deliver(ctx, sub, body, headers)
+		}(j, sub)
+	}
+	wg.Wait()
+
+	var deadLetters service.MessageBatch
+	var firstErr error
+	for j, dErr := range errs {
+		if dErr == nil {
+			continue
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("delivery to %v failed: %w", endpointHost(subs[j].URL), dErr)
+		}
+		if w.deadLetter == nil {
+			continue
+		}
+		msg := batch[i].Copy()
+		msg.MetaSetMut(wfoMetaEndpoint, subs[j].URL)
+		msg.MetaSetMut(wfoMetaError, dErr.Error())
+		msg.MetaSetMut(wfoMetaAttempts, int64(dErr.attempts))
+		if dErr.statusCode > 0 {
+			msg.MetaSetMut(wfoMetaStatusCode, int64(dErr.statusCode))
+		}
+		deadLetters = append(deadLetters, msg)
+	}
+	if firstErr == nil {
+		return nil
+	}
+	if w.deadLetter == nil {
+		return firstErr
+	}
+	if err := w.deadLetter.WriteBatch(ctx, deadLetters); err != nil {
+		return fmt.Errorf("failed to write dead letters: %w", err)
+	}
+	for j, dErr := range errs {
+		if dErr != nil {
+			w.mDeadLettered.Incr(1, endpointHost(subs[j].URL))
+		}
+	}
+	return nil
+}
+
+func (w *webhookFanoutWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	var batchErr *service.BatchError
+	for i := range batch {
+		if err := w.writeMessage(ctx, batch, i); err != nil {
+			if len(batch) == 1 {
+				return err
+			}
+			if batchErr == nil {
+				batchErr = service.NewBatchError(batch, err)
+			}
+			batchErr.Failed(i, err)
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (w *webhookFanoutWriter) Close(ctx context.Context) error {
+	if w.deadLetter != nil {
+		return w.deadLetter.Close(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type subscriberServer struct {
+	*httptest.Server
+
+	mut      sync.Mutex
+	bodies   []string
+	headers  []http.Header
+	statuses []int
+}
+
+// newSubscriberServer creates a subscriber that responds with the given
+// status codes in order, and with 200 once they are exhausted.
+func newSubscriberServer(t *testing.T, statuses ...int) *subscriberServer {
+	t.Helper()
+
+	s := &subscriberServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+
+		s.mut.Lock()
+		s.bodies = append(s.bodies, string(b))
+		s.headers = append(s.headers, r.Header.Clone())
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mut.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *subscriberServer) received() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+type fakeDeadLetter struct {
+	mut  sync.Mutex
+	msgs service.MessageBatch
+}
+
+func (f *fakeDeadLetter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	f.mut.Lock()
+	f.msgs = append(f.msgs, b...)
+	f.mut.Unlock()
+	return nil
+}
+
+func (f *fakeDeadLetter) Close(ctx context.Context) error {
+	return nil
+}
+
+func testFanoutWriter(t *testing.T, mgr *service.Resources, confStr string) *webhookFanoutWriter {
+	t.Helper()
+
+	pConf, err := webhookFanoutOutputSpec().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	w, err := newWebhookFanoutWriter(pConf, mgr)
+	require.NoError(t, err)
+	w.sleepFn = func(ctx context.Context, d time.Duration) error { return nil }
+	return w
+}
+
+func TestWebhookFanoutMapping(t *testing.T) {
+	subA := newSubscriberServer(t)
+	subB := newSubscriberServer(t, http.StatusServiceUnavailable)
+
+	w := testFanoutWriter(t, service.MockResources(), `
+subscribers: 'root = this.subs'
+headers:
+  X-Event: '${! json("event") }'
+`)
+
+	msg := service.NewMessage([]byte(`{"event":"created","subs":["` + subA.URL + `",{"url":"` + subB.URL + `","headers":{"X-Token":"foo"}}]}`))
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{msg}))
+
+	raw, _ := msg.AsBytes()
+	assert.Equal(t, []string{string(raw)}, subA.received())
+	assert.Equal(t, []string{string(raw), string(raw)}, subB.received())
+
+	assert.Equal(t, "created", subA.headers[0].Get("X-Event"))
+	assert.Equal(t, "", subA.headers[0].Get("X-Token"))
+	assert.Equal(t, "foo", subB.headers[0].Get("X-Token"))
+
+	// Messages without subscribers are dropped.
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"event":"created","subs":[]}`)),
+	}))
+}
+
+func TestWebhookFanoutCache(t *testing.T) {
+	sub := newSubscriberServer(t)
+
+	mgr := service.MockResources(service.MockResourcesOptAddCache("subs"))
+	require.NoError(t, mgr.AccessCache(context.Background(), "subs", func(c service.Cache) {
+		require.NoError(t, c.Set(context.Background(), "created", []byte(`["`+sub.URL+`"]`), nil))
+	}))
+
+	w := testFanoutWriter(t, mgr, `
+subscribers_cache: subs
+subscribers_key: '${! json("event") }'
+`)
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"event":"created"}`)),
+		service.NewMessage([]byte(`{"event":"deleted"}`)),
+	}))
+	assert.Equal(t, []string{`{"event":"created"}`}, sub.received())
+}
+
+func TestWebhookFanoutFailures(t *testing.T) {
+	subA := newSubscriberServer(t)
+	subB := newSubscriberServer(t, http.StatusBadRequest)
+	subC := newSubscriberServer(t, 500, 500, 500)
+
+	w := testFanoutWriter(t, service.MockResources(), `
+subscribers: 'root = this.subs'
+max_retries: 2
+`)
+
+	msgA := service.NewMessage([]byte(`{"subs":["` + subA.URL + `","` + subB.URL + `"]}`))
+	msgB := service.NewMessage([]byte(`{"subs":["` + subA.URL + `"]}`))
+	msgC := service.NewMessage([]byte(`{"subs":["` + subC.URL + `"]}`))
+
+	// Without a dead letter output the messages with failed deliveries are
+	// rejected.
+	err := w.WriteBatch(context.Background(), service.MessageBatch{msgA, msgB, msgC})
+	require.Error(t, err)
+
+	var bErr *service.BatchError
+	require.ErrorAs(t, err, &bErr)
+
+	var failed []int
+	bErr.WalkMessages(func(i int, m *service.Message, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{0, 2}, failed)
+	assert.Len(t, subB.received(), 1)
+	assert.Len(t, subC.received(), 3)
+
+	// With a dead letter output failed deliveries are written to it.
+	subD := newSubscriberServer(t, http.StatusNotFound)
+	dl := &fakeDeadLetter{}
+	w.deadLetter = dl
+
+	msgD := service.NewMessage([]byte(`{"subs":["` + subA.URL + `","` + subD.URL + `"]}`))
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{msgD}))
+
+	require.Len(t, dl.msgs, 1)
+	endpoint, _ := dl.msgs[0].MetaGetMut("webhook_endpoint")
+	assert.Equal(t, subD.URL, endpoint)
+	status, _ := dl.msgs[0].MetaGetMut("webhook_status_code")
+	assert.Equal(t, int64(404), status)
+	attempts, _ := dl.msgs[0].MetaGetMut("webhook_attempts")
+	assert.Equal(t, int64(1), attempts)
+	errStr, _ := dl.msgs[0].MetaGet("webhook_error")
+	assert.Contains(t, errStr, "404")
+
+	_, exists := msgD.MetaGetMut("webhook_endpoint")
+	assert.False(t, exists)
+}
+
+func TestWebhookFanoutSuspend(t *testing.T) {
+	sub := newSubscriberServer(t, 500, 500)
+
+	w := testFanoutWriter(t, service.MockResources(), `
+subscribers: 'root = [ "`+sub.URL+`" ]'
+max_retries: 0
+suspend_after: 2
+suspend_period: 1m
+`)
+	now := time.Now()
+	w.nowFn = func() time.Time { return now }
+
+	msg := service.NewMessage([]byte("hello"))
+	for i := 0; i < 3; i++ {
+		require.Error(t, w.WriteBatch(context.Background(), service.MessageBatch{msg}))
+	}
+	assert.Len(t, sub.received(), 2)
+
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{msg}))
+	assert.Len(t, sub.received(), 3)
+}
@@ -22,6 +22,8 @@ Attempts to parse a string as an XML document and returns a structured result, w
 - XML comments, directives, and process instructions are ignored.
 - When elements are repeated the resulting JSON value is an array.
 - If cast is true, try to cast values to numbers and booleans instead of returning strings.
+
+The parameters `+"`attribute_prefix`"+`, `+"`text_key`"+`, `+"`namespaces`"+`, `+"`arrays`"+` and `+"`force_array`"+` can be used in order to customise how attributes, namespaced names and repeated elements are represented.
 `).
 			Example("", `root.doc = this.doc.parse_xml()`, [2]string{
 				`{"doc":"<root><title>This is a title</title><content>This is some content</content></root>"}`,
@@ -35,20 +37,39 @@ Attempts to parse a string as an XML document and returns a structured result, w
 				`{"doc":"<root><title>This is a title</title><number id=99>123</number><bool>True</bool></root>"}`,
 				`{"doc":{"root":{"bool":true,"number":{"#text":123,"-id":99},"title":"This is a title"}}}`,
 			}).
+			Example("Namespace prefixes can be preserved, and elements can be forced into arrays even when they only appear once.", `root.doc = this.doc.parse_xml(namespaces: "prefix", force_array: ["item"])`, [2]string{
+				`{"doc":"<rss xmlns:dc=\"http://purl.org/dc/elements/1.1/\"><item><dc:creator>Bob</dc:creator></item></rss>"}`,
+				`{"doc":{"rss":{"-xmlns:dc":"http://purl.org/dc/elements/1.1/","item":[{"dc:creator":"Bob"}]}}}`,
+			}).
+			Example("Attributes can be given a custom prefix, or merged with child elements by setting an empty prefix.", `root.doc = this.doc.parse_xml(attribute_prefix: "", text_key: "value")`, [2]string{
+				`{"doc":"<root><number id=\"99\">123</number></root>"}`,
+				`{"doc":{"root":{"number":{"id":"99","value":"123"}}}}`,
+			}).
 			Param(bloblang.NewBoolParam("cast").
 				Description("whether to try to cast values that are numbers and booleans to the right type.").
-				Optional().Default(false)),
+				Optional().Default(false)).
+			Param(bloblang.NewStringParam("attribute_prefix").
+				Description("A prefix added to the keys of attributes in order to distinguish them from child elements.").
+				Default("-")).
+			Param(bloblang.NewStringParam("text_key").
+				Description("The key given to the text of an element when it also contains attributes or child elements.").
+				Default("#text")).
+			Param(bloblang.NewStringParam("namespaces").
+				Description("How to represent the names of elements and attributes that are within a namespace. Use `strip` to only include the local name, `prefix` to keep the prefix as it appears in the document (e.g. `soap:Body`), or `uri` to replace the prefix with the namespace URI (e.g. `{http://schemas.xmlsoap.org/soap/envelope/}Body`).").
+				Default(string(NamespaceModeStrip))).
+			Param(bloblang.NewStringParam("arrays").
+				Description("When to represent child elements as arrays. Use `repeated` to only create arrays for elements that appear more than once within their parent, or `always` to create arrays for all child elements.").
+				Default(string(ArrayModeRepeated))).
+			Param(bloblang.NewAnyParam("force_array").
+				Description("An array of element names that should always be represented as arrays, even when they only appear once.").
+				Default([]any{})),
 		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
-			castOpt, err := args.GetOptionalBool("cast")
+			opts, err := parseOptionsFromArgs(args)
 			if err != nil {
 				return nil, err
 			}
-			cast := false
-			if castOpt != nil {
-				cast = *castOpt
-			}
 			return bloblang.BytesMethod(func(xmlBytes []byte) (any, error) {
-				xmlObj, err := ToMap(xmlBytes, cast)
+				xmlObj, err := ToMapWithOptions(xmlBytes, opts)
 				if err != nil {
 					return nil, fmt.Errorf("failed to parse value as XML: %w", err)
 				}
@@ -122,4 +143,114 @@ Serializes a target value into an XML byte array.
 		}); err != nil {
 		panic(err)
 	}
+
+	if err := bloblang.RegisterMethodV2("xpath",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryParsing).
+			Version("4.20.0").
+			Description(`
+Parses a string as an XML document and executes an [XPath 1.0](https://www.w3.org/TR/1999/REC-xpath-19991116/) expression against it. Expressions that result in a node-set return an array of the string values of each node in document order, other expressions return a string, number or boolean.
+
+Names within the expression that have a namespace prefix are resolved using the `+"`namespaces`"+` parameter. As per the XPath specification, names without a prefix only match elements that are not within a namespace, and therefore elements within a default namespace must be matched using a prefix.`).
+			Example("", `root.titles = this.doc.xpath("//book[@lang='en']/title")
+root.count = this.doc.xpath("count(//book)")`, [2]string{
+				`{"doc":"<library><book lang=\"en\"><title>Dune</title></book><book lang=\"fr\"><title>Vendredi</title></book></library>"}`,
+				`{"count":2,"titles":["Dune"]}`,
+			}).
+			Example("Namespaces", `root.body = this.doc.xpath("/s:Envelope/s:Body/*/text()", {"s": "http://schemas.xmlsoap.org/soap/envelope/"})`, [2]string{
+				`{"doc":"<soap:Envelope xmlns:soap=\"http://schemas.xmlsoap.org/soap/envelope/\"><soap:Body><Message>hello</Message></soap:Body></soap:Envelope>"}`,
+				`{"body":["hello"]}`,
+			}).
+			Param(bloblang.NewStringParam("expression").Description("The XPath expression to execute.")).
+			Param(bloblang.NewAnyParam("namespaces").Description("An object mapping namespace prefixes used within the expression to namespace URIs.").Default(map[string]any{})),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			expr, err := args.GetString("expression")
+			if err != nil {
+				return nil, err
+			}
+			nsArg, err := args.Get("namespaces")
+			if err != nil {
+				return nil, err
+			}
+			nsObj, ok := nsArg.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("expected namespaces to be an object, got %T", nsArg)
+			}
+			namespaces := make(map[string]string, len(nsObj))
+			for k, v := range nsObj {
+				uri, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected namespace '%v' to be a string, got %T", k, v)
+				}
+				namespaces[k] = uri
+			}
+			xpath, err := CompileXPath(expr, namespaces)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse xpath expression: %w", err)
+			}
+			return bloblang.BytesMethod(func(xmlBytes []byte) (any, error) {
+				return xpath.Evaluate(xmlBytes)
+			}), nil
+		}); err != nil {
+		panic(err)
+	}
+}
+
+func parseOptionsFromArgs(args *bloblang.ParsedParams) (opts ParseOptions, err error) {
+	opts = DefaultParseOptions()
+
+	var castOpt *bool
+	if castOpt, err = args.GetOptionalBool("cast"); err != nil {
+		return
+	}
+	if castOpt != nil {
+		opts.Cast = *castOpt
+	}
+	if opts.AttributePrefix, err = args.GetString("attribute_prefix"); err != nil {
+		return
+	}
+	if opts.TextKey, err = args.GetString("text_key"); err != nil {
+		return
+	}
+
+	var nsMode string
+	if nsMode, err = args.GetString("namespaces"); err != nil {
+		return
+	}
+	switch opts.Namespaces = NamespaceMode(nsMode); opts.Namespaces {
+	case NamespaceModeStrip, NamespaceModePrefix, NamespaceModeURI:
+	default:
+		err = fmt.Errorf("unrecognised namespaces mode: %v", nsMode)
+		return
+	}
+
+	var arrayMode string
+	if arrayMode, err = args.GetString("arrays"); err != nil {
+		return
+	}
+	switch opts.Arrays = ArrayMode(arrayMode); opts.Arrays {
+	case ArrayModeRepeated, ArrayModeAlways:
+	default:
+		err = fmt.Errorf("unrecognised arrays mode: %v", arrayMode)
+		return
+	}
+
+	var forceArray any
+	if forceArray, err = args.Get("force_array"); err != nil {
+		return
+	}
+	forceArr, ok := forceArray.([]any)
+	if !ok {
+		err = fmt.Errorf("expected force_array to be an array, got %T", forceArray)
+		return
+	}
+	for _, v := range forceArr {
+		name, ok := v.(string)
+		if !ok {
+			err = fmt.Errorf("expected force_array to contain strings, got %T", v)
+			return
+		}
+		opts.ForceArray = append(opts.ForceArray, name)
+	}
+	return
 }
@@ -39,6 +39,52 @@ func TestParseXML(t *testing.T) {
 			args:   []any{true},
 			exp:    map[string]any{"root": map[string]any{"bool": true, "number": map[string]any{"#text": float64(123), "-id": float64(99)}, "title": "This is a title"}},
 		},
+		{
+			name:   "custom attribute prefix and text key",
+			method: "parse_xml",
+			target: `<root><number id="99">123</number></root>`,
+			args:   []any{false, "@", "_value"},
+			exp:    map[string]any{"root": map[string]any{"number": map[string]any{"_value": "123", "@id": "99"}}},
+		},
+		{
+			name:   "namespace prefixes",
+			method: "parse_xml",
+			target: `<s:Envelope xmlns:s="http://example.com/s" s:id="1"><s:Body>foo</s:Body></s:Envelope>`,
+			args:   []any{false, "-", "#text", "prefix"},
+			exp:    map[string]any{"s:Envelope": map[string]any{"-xmlns:s": "http://example.com/s", "-s:id": "1", "s:Body": "foo"}},
+		},
+		{
+			name:   "namespace uris",
+			method: "parse_xml",
+			target: `<s:Envelope xmlns:s="http://example.com/s"><s:Body>foo</s:Body><Other xmlns="http://example.com/o">bar</Other></s:Envelope>`,
+			args:   []any{false, "-", "#text", "uri"},
+			exp: map[string]any{"{http://example.com/s}Envelope": map[string]any{
+				"-xmlns:s":                    "http://example.com/s",
+				"{http://example.com/s}Body":  "foo",
+				"{http://example.com/o}Other": map[string]any{"-xmlns": "http://example.com/o", "#text": "bar"},
+			}},
+		},
+		{
+			name:   "always arrays",
+			method: "parse_xml",
+			target: `<root><a>1</a><b>2</b><b>3</b></root>`,
+			args:   []any{false, "-", "#text", "strip", "always"},
+			exp:    map[string]any{"root": map[string]any{"a": []any{"1"}, "b": []any{"2", "3"}}},
+		},
+		{
+			name:   "forced arrays",
+			method: "parse_xml",
+			target: `<root><a>1</a><b>2</b><b>3</b></root>`,
+			args:   []any{true, "-", "#text", "strip", "repeated", []any{"a"}},
+			exp:    map[string]any{"root": map[string]any{"a": []any{float64(1)}, "b": []any{float64(2), float64(3)}}},
+		},
+		{
+			name:   "mixed content",
+			method: "parse_xml",
+			target: `<root>foo<a/>bar</root>`,
+			args:   []any{},
+			exp:    map[string]any{"root": map[string]any{"a": "", "#text": "foo"}},
+		},
 	}
 
 	for _, test := range testCases {
@@ -0,0 +1,221 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// NamespaceMode determines how namespaced element and attribute names are
+// represented when converting XML documents into structured values.
+type NamespaceMode string
+
+// Supported namespace modes.
+const (
+	// NamespaceModeStrip uses only the local part of names.
+	NamespaceModeStrip NamespaceMode = "strip"
+	// NamespaceModePrefix keeps names as they appear within the document,
+	// including their prefix, e.g. `soap:Body`.
+	NamespaceModePrefix NamespaceMode = "prefix"
+	// NamespaceModeURI replaces prefixes with the resolved namespace URI in
+	// Clark notation, e.g. `{http://schemas.xmlsoap.org/soap/envelope/}Body`.
+	NamespaceModeURI NamespaceMode = "uri"
+)
+
+// ArrayMode determines when elements are represented as arrays.
+type ArrayMode string
+
+// Supported array modes.
+const (
+	// ArrayModeRepeated creates arrays only for elements that are repeated.
+	ArrayModeRepeated ArrayMode = "repeated"
+	// ArrayModeAlways creates arrays for all child elements.
+	ArrayModeAlways ArrayMode = "always"
+)
+
+// ParseOptions customises the conversion of XML documents into structured
+// values.
+type ParseOptions struct {
+	Cast            bool
+	AttributePrefix string
+	TextKey         string
+	Namespaces      NamespaceMode
+	Arrays          ArrayMode
+	ForceArray      []string
+}
+
+// DefaultParseOptions returns options that result in the same structure as
+// ToMap.
+func DefaultParseOptions() ParseOptions {
+	return ParseOptions{
+		AttributePrefix: "-",
+		TextKey:         "#text",
+		Namespaces:      NamespaceModeStrip,
+		Arrays:          ArrayModeRepeated,
+	}
+}
+
+const trimRunes = "\t\r\b\n "
+
+func newDecoder(xmlBytes []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(xmlBytes))
+	dec.Strict = false
+	dec.CharsetReader = charset.NewReaderLabel
+	return dec
+}
+
+type mapDecoder struct {
+	dec        *xml.Decoder
+	opts       ParseOptions
+	forceArray map[string]struct{}
+}
+
+// ToMapWithOptions parses a byte slice as XML and returns a generic structure
+// that can be serialized to JSON, customised with options.
+func ToMapWithOptions(xmlBytes []byte, opts ParseOptions) (map[string]any, error) {
+	d := mapDecoder{
+		dec:        newDecoder(xmlBytes),
+		opts:       opts,
+		forceArray: map[string]struct{}{},
+	}
+	for _, k := range opts.ForceArray {
+		d.forceArray[k] = struct{}{}
+	}
+
+	for {
+		t, err := d.token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := t.(xml.StartElement); ok {
+			key := d.name(start.Name)
+			v, err := d.element(start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{key: v}, nil
+		}
+	}
+}
+
+func (d *mapDecoder) token() (xml.Token, error) {
+	if d.opts.Namespaces == NamespaceModePrefix {
+		return d.dec.RawToken()
+	}
+	return d.dec.Token()
+}
+
+func (d *mapDecoder) name(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	switch d.opts.Namespaces {
+	case NamespaceModePrefix:
+		return n.Space + ":" + n.Local
+	case NamespaceModeURI:
+		if n.Space == "xmlns" {
+			return "xmlns:" + n.Local
+		}
+		return "{" + n.Space + "}" + n.Local
+	}
+	return n.Local
+}
+
+func (d *mapDecoder) cast(s string) any {
+	if !d.opts.Cast {
+		return s
+	}
+	switch strings.ToLower(s) {
+	case "nan", "inf", "-inf":
+		return s
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	if len(s) > 0 && len(s) < 6 {
+		switch s[:1] {
+		case "t", "T", "f", "F":
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+	}
+	return s
+}
+
+func (d *mapDecoder) isArray(key string) bool {
+	if d.opts.Arrays == ArrayModeAlways {
+		return true
+	}
+	_, exists := d.forceArray[key]
+	return exists
+}
+
+// element consumes tokens until the end of an element and returns its value,
+// following the same rules as github.com/clbanning/mxj.
+func (d *mapDecoder) element(start xml.StartElement) (any, error) {
+	var value any
+	children := map[string]any{}
+	for _, a := range start.Attr {
+		key := d.opts.AttributePrefix + d.name(a.Name)
+		children[key] = d.cast(a.Value)
+	}
+
+	for {
+		t, err := d.token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		switch tt := t.(type) {
+		case xml.StartElement:
+			key := d.name(tt.Name)
+			v, err := d.element(tt)
+			if err != nil {
+				return nil, err
+			}
+			existing, exists := children[key]
+			switch {
+			case exists:
+				arr, isArr := existing.([]any)
+				if !isArr {
+					arr = []any{existing}
+				}
+				children[key] = append(arr, v)
+			case d.isArray(key):
+				children[key] = []any{v}
+			default:
+				children[key] = v
+			}
+		case xml.EndElement:
+			if value == nil {
+				if len(children) > 0 {
+					return children, nil
+				}
+				return "", nil
+			}
+			if len(children) > 0 {
+				children[d.opts.TextKey] = value
+				return children, nil
+			}
+			return value, nil
+		case xml.CharData:
+			text := strings.Trim(string(tt), trimRunes)
+			if text == "" {
+				continue
+			}
+			if len(children) > 0 {
+				children[d.opts.TextKey] = d.cast(text)
+			} else {
+				value = d.cast(text)
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const xpathTestDoc = `<?xml version="1.0"?>
+<library xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <!-- a comment -->
+  <book id="1" lang="en">
+    <dc:title>Dune</dc:title>
+    <price>9.99</price>
+  </book>
+  <book id="2" lang="fr">
+    <dc:title>Vendredi</dc:title>
+    <price>12.50</price>
+  </book>
+  <book id="3" lang="en">
+    <dc:title>Emma</dc:title>
+    <price>5</price>
+  </book>
+</library>`
+
+func TestXPathEvaluate(t *testing.T) {
+	namespaces := map[string]string{
+		"dc": "http://purl.org/dc/elements/1.1/",
+	}
+
+	tests := []struct {
+		expr     string
+		expected any
+	}{
+		{expr: `/library/book/@id`, expected: []any{"1", "2", "3"}},
+		{expr: `//book[@lang='en']/dc:title`, expected: []any{"Dune", "Emma"}},
+		{expr: `//book[2]/dc:title/text()`, expected: []any{"Vendredi"}},
+		{expr: `//book[last()]/@id`, expected: []any{"3"}},
+		{expr: `//book[position() < 3]/@id`, expected: []any{"1", "2"}},
+		{expr: `//book[price > 9]/@id`, expected: []any{"1", "2"}},
+		{expr: `//book[not(@lang = 'fr')]/@id`, expected: []any{"1", "3"}},
+		{expr: `//book[@lang = 'en' and price < 6]/@id`, expected: []any{"3"}},
+		{expr: `//book[@id = 1 or @id = 3]/@id`, expected: []any{"1", "3"}},
+		{expr: `//dc:title[starts-with(., 'V')]/../@id`, expected: []any{"2"}},
+		{expr: `//dc:title[contains(., 'mm')]/parent::book/@id`, expected: []any{"3"}},
+		{expr: `//book[1]/following-sibling::book/@id`, expected: []any{"2", "3"}},
+		{expr: `//book[3]/preceding-sibling::book[1]/@id`, expected: []any{"2"}},
+		{expr: `//price/ancestor::library/@*`, expected: []any{}},
+		{expr: `//book/@id | //book/@lang`, expected: []any{"1", "en", "2", "fr", "3", "en"}},
+		{expr: `/library/*[1]/*`, expected: []any{"Dune", "9.99"}},
+		{expr: `//title`, expected: []any{}},
+		{expr: `//comment()`, expected: []any{" a comment "}},
+		{expr: `count(//book)`, expected: float64(3)},
+		{expr: `sum(//price)`, expected: 27.49},
+		{expr: `sum(//price) div count(//price) > 9`, expected: true},
+		{expr: `-2 + 3 * 4 mod 5`, expected: float64(0)},
+		{expr: `string(//book[2]/@lang)`, expected: "fr"},
+		{expr: `name(//dc:title)`, expected: "dc:title"},
+		{expr: `local-name(//dc:title)`, expected: "title"},
+		{expr: `namespace-uri(//dc:title)`, expected: "http://purl.org/dc/elements/1.1/"},
+		{expr: `concat(//book[1]/dc:title, ' - ', //book[1]/price)`, expected: "Dune - 9.99"},
+		{expr: `normalize-space('  a   b ')`, expected: "a b"},
+		{expr: `substring('12345', 1.5, 2.6)`, expected: "234"},
+		{expr: `substring-before('2023-01-01', '-')`, expected: "2023"},
+		{expr: `substring-after('2023-01-01', '-')`, expected: "01-01"},
+		{expr: `translate('bar', 'abc', 'ABC')`, expected: "BAr"},
+		{expr: `string-length(//book[1]/dc:title)`, expected: float64(4)},
+		{expr: `round(2.5) + floor(2.5) + ceiling(2.5)`, expected: float64(8)},
+		{expr: `//book/price = 5`, expected: true},
+		{expr: `//book/price != 5`, expected: true},
+		{expr: `boolean(//nope)`, expected: false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.expr, func(t *testing.T) {
+			x, err := CompileXPath(test.expr, namespaces)
+			require.NoError(t, err)
+
+			res, err := x.Evaluate([]byte(xpathTestDoc))
+			require.NoError(t, err)
+			if f, ok := test.expected.(float64); ok {
+				assert.InDelta(t, f, res, 0.0001)
+				return
+			}
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestXPathDefaultNamespace(t *testing.T) {
+	doc := []byte(`<feed xmlns="http://www.w3.org/2005/Atom"><entry><title>foo</title></entry></feed>`)
+
+	x, err := CompileXPath(`//title`, nil)
+	require.NoError(t, err)
+
+	res, err := x.Evaluate(doc)
+	require.NoError(t, err)
+	assert.Equal(t, []any{}, res)
+
+	x, err = CompileXPath(`/a:feed/a:entry/a:title`, map[string]string{"a": "http://www.w3.org/2005/Atom"})
+	require.NoError(t, err)
+
+	res, err = x.Evaluate(doc)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"foo"}, res)
+}
+
+func TestXPathErrors(t *testing.T) {
+	for _, expr := range []string{
+		`//`,
+		`/library/`,
+		`//book[`,
+		`nope()`,
+		`count()`,
+		`//x:title`,
+		`foo::bar`,
+		`$var`,
+		`'unterminated`,
+		`1 !`,
+	} {
+		_, err := CompileXPath(expr, nil)
+		assert.Error(t, err, expr)
+	}
+
+	x, err := CompileXPath(`//book`, nil)
+	require.NoError(t, err)
+
+	_, err = x.Evaluate([]byte(`not xml`))
+	require.Error(t, err)
+}
@@ -0,0 +1,223 @@
+// Package lineage provides a mechanism for recording which components handle
+// sampled messages, from the input that consumed them through to the outputs
+// they were delivered to, and emitting that record as a structured event once
+// the message has been acknowledged.
+package lineage
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/benthosdev/benthos/v4/internal/events"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// Step describes a component that handled a message.
+type Step struct {
+	Kind     string
+	Type     string
+	Label    string
+	Path     string
+	At       time.Time
+	Duration time.Duration
+	Error    string
+}
+
+func (s Step) asMap() map[string]any {
+	m := map[string]any{
+		"kind": s.Kind,
+		"type": s.Type,
+		"at":   s.At.Format(time.RFC3339Nano),
+	}
+	if s.Label != "" {
+		m["label"] = s.Label
+	}
+	if s.Path != "" {
+		m["path"] = s.Path
+	}
+	if s.Duration > 0 {
+		m["duration"] = s.Duration.String()
+		m["duration_ns"] = s.Duration.Nanoseconds()
+	}
+	if s.Error != "" {
+		m["error"] = s.Error
+	}
+	return m
+}
+
+// Record contains the lineage of a single sampled message. A record is shared
+// by all copies of the message and is therefore safe for concurrent use.
+type Record struct {
+	ID string
+
+	mut        sync.Mutex
+	steps      []Step
+	deliveries []Step
+}
+
+// Steps returns the components that have handled the message so far, in the
+// order that they handled it.
+func (r *Record) Steps() []Step {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return append([]Step(nil), r.steps...)
+}
+
+// Deliveries returns the outputs that the message has been written to so far.
+func (r *Record) Deliveries() []Step {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return append([]Step(nil), r.deliveries...)
+}
+
+func (r *Record) add(s Step, delivery bool) {
+	r.mut.Lock()
+	if delivery {
+		r.deliveries = append(r.deliveries, s)
+	} else {
+		r.steps = append(r.steps, s)
+	}
+	r.mut.Unlock()
+}
+
+type recordKey struct{}
+
+// FromPart returns the lineage record of a message, or nil if the message is
+// not being sampled.
+func FromPart(p *message.Part) *Record {
+	if p == nil {
+		return nil
+	}
+	r, _ := p.GetContext().Value(recordKey{}).(*Record)
+	return r
+}
+
+//------------------------------------------------------------------------------
+
+// Recorder records the lineage of messages handled by a component. A nil
+// Recorder is valid and records nothing, which is the case when lineage is
+// disabled.
+type Recorder struct {
+	Ratio float64
+
+	// The stream, label and path of the component.
+	Stream string
+	Label  string
+	Path   string
+
+	Emitter events.Emitter
+}
+
+func (r *Recorder) step(kind, typeStr string, at time.Time, dur time.Duration, err error) Step {
+	s := Step{
+		Kind:     kind,
+		Type:     typeStr,
+		Label:    r.Label,
+		Path:     r.Path,
+		At:       at,
+		Duration: dur,
+	}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	return s
+}
+
+// Start samples the messages of a batch consumed by an input, attaching a new
+// lineage record to each message selected.
+func (r *Recorder) Start(batch message.Batch, typeStr string) {
+	if r == nil {
+		return
+	}
+	now := time.Now()
+	for i, p := range batch {
+		if FromPart(p) != nil || rand.Float64() >= r.Ratio {
+			continue
+		}
+		rec := &Record{ID: uuid.Must(uuid.NewV4()).String()}
+		rec.add(r.step("input", typeStr, now, 0, nil), false)
+		batch[i] = p.WithContext(context.WithValue(p.GetContext(), recordKey{}, rec))
+	}
+}
+
+// Processed records that the messages of a batch were handled by a processor
+// that began processing at a given time.
+func (r *Recorder) Processed(batch message.Batch, typeStr string, started time.Time) {
+	if r == nil {
+		return
+	}
+	dur := time.Since(started)
+	for _, p := range batch {
+		if rec := FromPart(p); rec != nil {
+			rec.add(r.step("processor", typeStr, started, dur, p.ErrorGet()), false)
+		}
+	}
+}
+
+// Delivered records an attempt to write the messages of a batch to an output,
+// where err is the outcome of the attempt.
+func (r *Recorder) Delivered(batch message.Batch, typeStr string, started time.Time, err error) {
+	if r == nil {
+		return
+	}
+	dur := time.Since(started)
+	for _, p := range batch {
+		if rec := FromPart(p); rec != nil {
+			rec.add(r.step("output", typeStr, started, dur, err), true)
+		}
+	}
+}
+
+// Complete emits a lineage event for each sampled message of a batch that has
+// been acknowledged, where err is the result of the acknowledgement.
+func (r *Recorder) Complete(batch message.Batch, err error) {
+	if r == nil {
+		return
+	}
+	for _, p := range batch {
+		rec := FromPart(p)
+		if rec == nil {
+			continue
+		}
+
+		rec.mut.Lock()
+		steps := make([]any, 0, len(rec.steps))
+		for _, s := range rec.steps {
+			steps = append(steps, s.asMap())
+		}
+		deliveries := make([]any, 0, len(rec.deliveries))
+		for _, s := range rec.deliveries {
+			deliveries = append(deliveries, s.asMap())
+		}
+		rec.mut.Unlock()
+
+		outcome, msg := "delivered", "Message delivered"
+		if err != nil {
+			outcome, msg = "failed", "Message failed to be delivered"
+		}
+
+		e := events.New(events.TypeMessageLineage, msg).
+			WithField("lineage_id", rec.ID).
+			WithField("outcome", outcome).
+			WithField("steps", steps).
+			WithField("deliveries", deliveries)
+		if err != nil {
+			e = e.WithField("error", err.Error())
+		}
+		e.Stream, e.Label, e.Path = r.Stream, r.Label, r.Path
+		r.Emitter.Emit(e)
+	}
+}
+
+// FromManager returns the lineage recorder of a manager, or nil if the manager
+// does not support lineage or it is disabled.
+func FromManager(mgr any) *Recorder {
+	if lm, ok := mgr.(interface{ Lineage() *Recorder }); ok {
+		return lm.Lineage()
+	}
+	return nil
+}
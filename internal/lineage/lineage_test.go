@@ -0,0 +1,94 @@
+package lineage_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/events"
+	"github.com/benthosdev/benthos/v4/internal/lineage"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestLineageRecorder(t *testing.T) {
+	var emitted []events.Event
+	emitter := events.EmitterFunc(func(e events.Event) {
+		emitted = append(emitted, e)
+	})
+
+	input := &lineage.Recorder{Ratio: 1, Stream: "foo", Label: "in", Path: "root.input", Emitter: emitter}
+	proc := &lineage.Recorder{Ratio: 1, Label: "mapper", Path: "root.pipeline.processors.0", Emitter: emitter}
+	out := &lineage.Recorder{Ratio: 1, Label: "out", Path: "root.output", Emitter: emitter}
+
+	batch := message.QuickBatch([][]byte{[]byte("a"), []byte("b")})
+	input.Start(batch, "generate")
+
+	rec := lineage.FromPart(batch[0])
+	require.NotNil(t, rec)
+	assert.NotEmpty(t, rec.ID)
+	assert.NotEqual(t, rec.ID, lineage.FromPart(batch[1]).ID)
+
+	// Copies of a message share the same record.
+	copied := batch[0].ShallowCopy()
+	proc.Processed(message.Batch{copied}, "mapping", time.Now().Add(-time.Millisecond))
+	out.Delivered(message.Batch{copied}, "stdout", time.Now(), nil)
+
+	steps := rec.Steps()
+	require.Len(t, steps, 2)
+	assert.Equal(t, "input", steps[0].Kind)
+	assert.Equal(t, "generate", steps[0].Type)
+	assert.Equal(t, "in", steps[0].Label)
+	assert.Equal(t, "processor", steps[1].Kind)
+	assert.Equal(t, "mapper", steps[1].Label)
+	assert.Greater(t, steps[1].Duration, time.Duration(0))
+
+	deliveries := rec.Deliveries()
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "stdout", deliveries[0].Type)
+	assert.Equal(t, "root.output", deliveries[0].Path)
+
+	input.Complete(batch, nil)
+	require.Len(t, emitted, 2)
+
+	e := emitted[0]
+	assert.Equal(t, events.TypeMessageLineage, e.Type)
+	assert.Equal(t, "foo", e.Stream)
+	assert.Equal(t, "in", e.Label)
+	assert.Equal(t, rec.ID, e.Fields["lineage_id"])
+	assert.Equal(t, "delivered", e.Fields["outcome"])
+	assert.Len(t, e.Fields["steps"], 2)
+	assert.Len(t, e.Fields["deliveries"], 1)
+
+	assert.Len(t, emitted[1].Fields["steps"], 1)
+	assert.Len(t, emitted[1].Fields["deliveries"], 0)
+
+	emitted = nil
+	input.Complete(batch[:1], errors.New("nope"))
+	require.Len(t, emitted, 1)
+	assert.Equal(t, "failed", emitted[0].Fields["outcome"])
+	assert.Equal(t, "nope", emitted[0].Fields["error"])
+}
+
+func TestLineageNotSampled(t *testing.T) {
+	var emitted []events.Event
+	r := &lineage.Recorder{Ratio: 0, Emitter: events.EmitterFunc(func(e events.Event) {
+		emitted = append(emitted, e)
+	})}
+
+	batch := message.QuickBatch([][]byte{[]byte("a")})
+	r.Start(batch, "generate")
+	assert.Nil(t, lineage.FromPart(batch[0]))
+
+	r.Complete(batch, nil)
+	assert.Empty(t, emitted)
+
+	var nilRecorder *lineage.Recorder
+	nilRecorder.Start(batch, "generate")
+	nilRecorder.Processed(batch, "mapping", time.Now())
+	nilRecorder.Delivered(batch, "stdout", time.Now(), nil)
+	nilRecorder.Complete(batch, nil)
+	assert.Nil(t, lineage.FromPart(batch[0]))
+}
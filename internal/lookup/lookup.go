@@ -0,0 +1,335 @@
+// Package lookup provides tables of reference data that are loaded from files
+// or remote sources and refreshed periodically, which can be queried by key
+// from Bloblang mappings.
+package lookup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// Config describes a lookup table resource.
+type Config struct {
+	Label           string `json:"label" yaml:"label"`
+	Path            string `json:"path" yaml:"path"`
+	Format          string `json:"format" yaml:"format"`
+	Key             string `json:"key" yaml:"key"`
+	RefreshInterval string `json:"refresh_interval" yaml:"refresh_interval"`
+}
+
+// NewConfig returns a Config with default values.
+func NewConfig() Config {
+	return Config{
+		RefreshInterval: "1m",
+	}
+}
+
+// Spec returns the field specs of a lookup table resource.
+func Spec() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldString("label", "A unique label for the lookup table, which is used for referencing it from Bloblang mappings.").HasDefault(""),
+		docs.FieldString(
+			"path", "The location to load the table from. Plain paths are read from the local filesystem, and other locations are specified as a URL with a scheme such as `https://` or `s3://`.",
+			"./countries.csv", "https://example.com/countries.json", "s3://my-bucket/reference/countries.csv",
+		).HasDefault(""),
+		docs.FieldString("format", "The format of the table. If empty the format is inferred from the extension of the path.").HasOptions("csv", "json").HasDefault(""),
+		docs.FieldString("key", "The column or field of each row to use as its key. This is required for CSV tables and JSON arrays, and ignored for JSON objects, where the keys of the object are used.").HasDefault(""),
+		docs.FieldString("refresh_interval", "The period of time between attempts to reload the table. The table is only replaced when its contents have changed. Set to `0s` in order to only load the table once.").HasDefault("1m"),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Source obtains the contents of a lookup table from a path.
+type Source func(ctx context.Context, path string) ([]byte, error)
+
+var (
+	sources    = map[string]Source{}
+	sourcesMut sync.RWMutex
+)
+
+// RegisterSource adds a source of lookup tables for paths with a given URL
+// scheme, such as `s3`. Registering a source for an existing scheme replaces
+// it.
+func RegisterSource(scheme string, s Source) {
+	sourcesMut.Lock()
+	sources[scheme] = s
+	sourcesMut.Unlock()
+}
+
+func init() {
+	httpSource := func(ctx context.Context, path string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, http.NoBody)
+		if err != nil {
+			return nil, err
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return nil, fmt.Errorf("unexpected status code: %v", res.StatusCode)
+		}
+		return io.ReadAll(res.Body)
+	}
+	RegisterSource("http", httpSource)
+	RegisterSource("https", httpSource)
+}
+
+func getSource(path string, fs ifs.FS) (Source, error) {
+	scheme, _, isURL := strings.Cut(path, "://")
+	if !isURL {
+		return func(ctx context.Context, path string) ([]byte, error) {
+			return ifs.ReadFile(fs, path)
+		}, nil
+	}
+
+	sourcesMut.RLock()
+	s, exists := sources[scheme]
+	sourcesMut.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("lookup tables cannot be loaded from paths with the scheme '%v'", scheme)
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Table is a collection of rows keyed by a string, which is reloaded from its
+// source periodically.
+type Table struct {
+	label    string
+	path     string
+	format   string
+	key      string
+	interval time.Duration
+
+	source Source
+	log    log.Modular
+
+	mut    sync.RWMutex
+	rows   map[string]any
+	digest [sha256.Size]byte
+
+	shutSig *shutdown.Signaller
+}
+
+// New attempts to create a lookup table from a config, returning an error if
+// the table cannot be loaded.
+func New(conf Config, fs ifs.FS, logger log.Modular) (*Table, error) {
+	if conf.Path == "" {
+		return nil, errors.New("a path must be specified")
+	}
+
+	t := &Table{
+		label:   conf.Label,
+		path:    conf.Path,
+		format:  conf.Format,
+		key:     conf.Key,
+		log:     logger,
+		shutSig: shutdown.NewSignaller(),
+	}
+	if t.format == "" {
+		t.format = strings.TrimPrefix(strings.ToLower(filepath.Ext(conf.Path)), ".")
+	}
+	if t.format != "csv" && t.format != "json" {
+		return nil, fmt.Errorf("unable to infer format from path '%v', expected csv or json", conf.Path)
+	}
+	if t.format == "csv" && t.key == "" {
+		return nil, errors.New("a key must be specified for csv tables")
+	}
+
+	if conf.RefreshInterval != "" {
+		var err error
+		if t.interval, err = time.ParseDuration(conf.RefreshInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse refresh_interval: %w", err)
+		}
+	}
+
+	var err error
+	if t.source, err = getSource(conf.Path, fs); err != nil {
+		return nil, err
+	}
+	if err := t.Refresh(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to load table: %w", err)
+	}
+
+	go t.loop()
+	return t, nil
+}
+
+func (t *Table) loop() {
+	defer t.shutSig.ShutdownComplete()
+	if t.interval <= 0 {
+		<-t.shutSig.CloseAtLeisureChan()
+		return
+	}
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	ctx, done := t.shutSig.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if err := t.Refresh(ctx); err != nil && ctx.Err() == nil {
+			t.log.Errorf("Failed to refresh lookup table '%v', continuing with previous contents: %v", t.label, err)
+		}
+	}
+}
+
+// Refresh reloads the table from its source, replacing the current rows if
+// the contents have changed.
+func (t *Table) Refresh(ctx context.Context) error {
+	data, err := t.source(ctx, t.path)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(data)
+	t.mut.RLock()
+	unchanged := t.rows != nil && digest == t.digest
+	t.mut.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	var rows map[string]any
+	if t.format == "csv" {
+		rows, err = parseCSV(data, t.key)
+	} else {
+		rows, err = parseJSON(data, t.key)
+	}
+	if err != nil {
+		return err
+	}
+
+	t.mut.Lock()
+	t.rows, t.digest = rows, digest
+	t.mut.Unlock()
+
+	t.log.Debugf("Loaded %v rows into lookup table '%v'", len(rows), t.label)
+	return nil
+}
+
+// Get returns the row of a key, and a boolean indicating whether it exists.
+// The returned value must not be mutated.
+func (t *Table) Get(key string) (any, bool) {
+	t.mut.RLock()
+	v, exists := t.rows[key]
+	t.mut.RUnlock()
+	return v, exists
+}
+
+// Len returns the number of rows within the table.
+func (t *Table) Len() int {
+	t.mut.RLock()
+	defer t.mut.RUnlock()
+	return len(t.rows)
+}
+
+// Close stops the table from refreshing.
+func (t *Table) Close(ctx context.Context) error {
+	t.shutSig.CloseAtLeisure()
+	select {
+	case <-t.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func parseCSV(data []byte, key string) (map[string]any, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("csv table does not contain a header row")
+	}
+
+	header, keyIndex := records[0], -1
+	for i, name := range header {
+		if name == key {
+			keyIndex = i
+			break
+		}
+	}
+	if keyIndex == -1 {
+		return nil, fmt.Errorf("key column '%v' was not found in csv header", key)
+	}
+
+	rows := make(map[string]any, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]any, len(header))
+		for i, name := range header {
+			row[name] = record[i]
+		}
+		rows[record[keyIndex]] = row
+	}
+	return rows, nil
+}
+
+func parseJSON(data []byte, key string) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	switch t := v.(type) {
+	case map[string]any:
+		return t, nil
+	case []any:
+		if key == "" {
+			return nil, errors.New("a key must be specified for json arrays")
+		}
+		rows := make(map[string]any, len(t))
+		for i, e := range t {
+			obj, ok := e.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("element %v: expected object, got %T", i, e)
+			}
+			k, exists := obj[key]
+			if !exists {
+				return nil, fmt.Errorf("element %v: key field '%v' was not found", i, key)
+			}
+			switch kt := k.(type) {
+			case string:
+				rows[kt] = obj
+			case json.Number:
+				rows[kt.String()] = obj
+			default:
+				return nil, fmt.Errorf("element %v: expected key field '%v' to be a string or number, got %T", i, key, k)
+			}
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("expected json object or array, got %T", v)
+}
@@ -0,0 +1,160 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func testTable(t *testing.T, conf Config) *Table {
+	t.Helper()
+
+	table, err := New(conf, ifs.OS(), log.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, table.Close(context.Background()))
+	})
+	return table
+}
+
+func TestLookupCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "countries.csv")
+	require.NoError(t, os.WriteFile(path, []byte("code,name\nGB,United Kingdom\nFR,France\n"), 0o644))
+
+	conf := NewConfig()
+	conf.Path = path
+	conf.Key = "code"
+	conf.RefreshInterval = "0s"
+
+	table := testTable(t, conf)
+	assert.Equal(t, 2, table.Len())
+
+	v, exists := table.Get("GB")
+	require.True(t, exists)
+	assert.Equal(t, map[string]any{"code": "GB", "name": "United Kingdom"}, v)
+
+	_, exists = table.Get("DE")
+	assert.False(t, exists)
+
+	require.NoError(t, os.WriteFile(path, []byte("code,name\nDE,Germany\n"), 0o644))
+	require.NoError(t, table.Refresh(context.Background()))
+
+	v, exists = table.Get("DE")
+	require.True(t, exists)
+	assert.Equal(t, map[string]any{"code": "DE", "name": "Germany"}, v)
+	assert.Equal(t, 1, table.Len())
+
+	// A failed refresh retains the previous rows.
+	require.NoError(t, os.WriteFile(path, []byte("nope\n"), 0o644))
+	require.Error(t, table.Refresh(context.Background()))
+	assert.Equal(t, 1, table.Len())
+}
+
+func TestLookupJSON(t *testing.T) {
+	var mut sync.Mutex
+	body := `[{"id":1,"name":"foo"},{"id":"two","name":"bar"}]`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	conf := NewConfig()
+	conf.Path = srv.URL + "/things"
+	conf.Format = "json"
+	conf.Key = "id"
+	conf.RefreshInterval = "0s"
+
+	table := testTable(t, conf)
+
+	v, exists := table.Get("1")
+	require.True(t, exists)
+	assert.Equal(t, map[string]any{"id": json.Number("1"), "name": "foo"}, v)
+
+	v, exists = table.Get("two")
+	require.True(t, exists)
+	assert.Equal(t, map[string]any{"id": "two", "name": "bar"}, v)
+
+	mut.Lock()
+	body = `{"a":{"name":"baz"},"b":"buz"}`
+	mut.Unlock()
+	require.NoError(t, table.Refresh(context.Background()))
+
+	v, exists = table.Get("a")
+	require.True(t, exists)
+	assert.Equal(t, map[string]any{"name": "baz"}, v)
+
+	v, exists = table.Get("b")
+	require.True(t, exists)
+	assert.Equal(t, "buz", v)
+}
+
+func TestLookupSource(t *testing.T) {
+	RegisterSource("testlookup", func(ctx context.Context, path string) ([]byte, error) {
+		assert.Equal(t, "testlookup://foo/bar.csv", path)
+		return []byte("k,v\na,1\n"), nil
+	})
+
+	conf := NewConfig()
+	conf.Path = "testlookup://foo/bar.csv"
+	conf.Key = "k"
+
+	table := testTable(t, conf)
+	v, exists := table.Get("a")
+	require.True(t, exists)
+	assert.Equal(t, map[string]any{"k": "a", "v": "1"}, v)
+}
+
+func TestLookupConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		conf   Config
+		errStr string
+	}{
+		{
+			name:   "no path",
+			conf:   Config{},
+			errStr: "a path must be specified",
+		},
+		{
+			name:   "unknown format",
+			conf:   Config{Path: "./foo.txt"},
+			errStr: "unable to infer format",
+		},
+		{
+			name:   "csv without key",
+			conf:   Config{Path: "./foo.csv"},
+			errStr: "a key must be specified",
+		},
+		{
+			name:   "unknown scheme",
+			conf:   Config{Path: "nope://foo.csv", Key: "k"},
+			errStr: "scheme 'nope'",
+		},
+		{
+			name:   "missing file",
+			conf:   Config{Path: "./does_not_exist.json"},
+			errStr: "failed to load table",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.conf, ifs.OS(), log.Noop())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errStr)
+		})
+	}
+}
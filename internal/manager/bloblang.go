@@ -0,0 +1,258 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/lookup"
+)
+
+// Functions that access resources are registered globally so that they're
+// documented and can be parsed by linters, but can only be executed from
+// within an environment bound to a manager.
+var resourceFunctionSpecs = []query.FunctionSpec{
+	query.NewFunctionSpec(
+		query.FunctionCategoryEnvironment, "cache_get",
+		"Returns the value of a key from a [cache resource](/docs/components/caches/about) as bytes. If the key does not exist an error is returned, which can be caught in order to provide a fallback value.",
+		query.NewExampleSpec("",
+			`root.user = cache_get("users", this.user_id).parse_json().catch(null)`,
+		),
+	).MarkImpure().AtVersion("4.20.0").
+		Param(query.ParamString("resource", "The name of the cache resource.")).
+		Param(query.ParamString("key", "The key to obtain.")),
+
+	query.NewFunctionSpec(
+		query.FunctionCategoryEnvironment, "cache_set",
+		"Sets the value of a key within a [cache resource](/docs/components/caches/about) and returns the value that was set. Non-bytes values are serialised before being stored.",
+		query.NewExampleSpec("",
+			`root = this
+root.stored = cache_set("users", this.user_id, this.user.format_json())`,
+		),
+		query.NewExampleSpec("A TTL can be specified for caches that support them.",
+			`root.session = cache_set(resource: "sessions", key: this.id, value: this.session, ttl: "1h")`,
+		),
+	).MarkImpure().AtVersion("4.20.0").
+		Param(query.ParamString("resource", "The name of the cache resource.")).
+		Param(query.ParamString("key", "The key to set.")).
+		Param(query.ParamAny("value", "The value to set.")).
+		Param(query.ParamString("ttl", "An optional TTL to set for the key, if supported by the cache.").Optional()),
+
+	query.NewFunctionSpec(
+		query.FunctionCategoryEnvironment, "counter",
+		"Increments an integer counter stored at a key within a [cache resource](/docs/components/caches/about) and returns the new value. If the key does not exist the counter starts from zero. Increments are serialised within a Benthos instance, but not across multiple instances sharing the same cache.",
+		query.NewExampleSpec("",
+			`root = this
+root.sequence = counter("sequences", "orders")`,
+		),
+		query.NewExampleSpec("Counters can be scoped dynamically and incremented by arbitrary amounts.",
+			`root.total = counter(resource: "totals", key: this.customer_id, delta: this.items.length())`,
+		),
+	).MarkImpure().AtVersion("4.20.0").
+		Param(query.ParamString("resource", "The name of the cache resource.")).
+		Param(query.ParamString("key", "The key of the counter.")).
+		Param(query.ParamInt64("delta", "The amount to increment the counter by.").Default(1)),
+
+	query.NewFunctionSpec(
+		query.FunctionCategoryEnvironment, "lookup",
+		"Returns the row of a key from a [lookup table](/docs/configuration/resources#lookup-tables) resource. Rows of CSV tables are objects of the columns of the row, and rows of JSON tables are the values of the table. If the key does not exist an error is returned, which can be caught in order to provide a fallback value.",
+		query.NewExampleSpec("",
+			`root = this
+root.country = lookup("countries", this.country_code).name.catch("unknown")`,
+		),
+	).MarkImpure().AtVersion("4.20.0").
+		Param(query.ParamString("resource", "The label of the lookup table.")).
+		Param(query.ParamString("key", "The key to obtain.")),
+}
+
+func init() {
+	env := bloblang.GlobalEnvironment()
+	binders := resourceFunctionBinderCtors()
+	for _, spec := range resourceFunctionSpecs {
+		name := spec.Name
+		if err := env.RegisterFunction(spec, func(args *query.ParsedParams) (query.Function, error) {
+			return query.ClosureFunction("function "+name, func(ctx query.FunctionContext) (any, error) {
+				return nil, fmt.Errorf("function %v requires access to resources and cannot be used in this context", name)
+			}, nil), nil
+		}); err != nil {
+			panic(err)
+		}
+		bind := binders[name]
+		env.SetResourceFunctionBinder(name, func(mgr any) query.FunctionCtor {
+			return bind(mgr.(bundle.NewManagement))
+		})
+	}
+}
+
+func resourceFunctionBinderCtors() map[string]func(mgr bundle.NewManagement) query.FunctionCtor {
+	return map[string]func(mgr bundle.NewManagement) query.FunctionCtor{
+		"cache_get": func(mgr bundle.NewManagement) query.FunctionCtor {
+			return cacheGetCtor(mgr)
+		},
+		"cache_set": func(mgr bundle.NewManagement) query.FunctionCtor {
+			return cacheSetCtor(mgr)
+		},
+		"counter": func(mgr bundle.NewManagement) query.FunctionCtor {
+			// Counters are serialised within each manager.
+			var counterMut sync.Mutex
+			return counterCtor(mgr, &counterMut)
+		},
+		"lookup": func(mgr bundle.NewManagement) query.FunctionCtor {
+			return lookupCtor(mgr)
+		},
+	}
+}
+
+func cacheGetCtor(mgr bundle.NewManagement) query.FunctionCtor {
+	return func(args *query.ParsedParams) (query.Function, error) {
+		resource, err := args.FieldString("resource")
+		if err != nil {
+			return nil, err
+		}
+		key, err := args.FieldString("key")
+		if err != nil {
+			return nil, err
+		}
+		return query.ClosureFunction("function cache_get", func(ctx query.FunctionContext) (any, error) {
+			var value []byte
+			var cerr error
+			if err := mgr.AccessCache(context.Background(), resource, func(c cache.V1) {
+				value, cerr = c.Get(context.Background(), key)
+			}); err != nil {
+				return nil, err
+			}
+			if cerr != nil {
+				return nil, cerr
+			}
+			return value, nil
+		}, nil), nil
+	}
+}
+
+func cacheSetCtor(mgr bundle.NewManagement) query.FunctionCtor {
+	return func(args *query.ParsedParams) (query.Function, error) {
+		resource, err := args.FieldString("resource")
+		if err != nil {
+			return nil, err
+		}
+		key, err := args.FieldString("key")
+		if err != nil {
+			return nil, err
+		}
+		value, err := args.Field("value")
+		if err != nil {
+			return nil, err
+		}
+		ttlStr, err := args.FieldOptionalString("ttl")
+		if err != nil {
+			return nil, err
+		}
+		var ttl *time.Duration
+		if ttlStr != nil {
+			d, err := time.ParseDuration(*ttlStr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse ttl: %w", err)
+			}
+			ttl = &d
+		}
+		return query.ClosureFunction("function cache_set", func(ctx query.FunctionContext) (any, error) {
+			var cerr error
+			if err := mgr.AccessCache(context.Background(), resource, func(c cache.V1) {
+				cerr = c.Set(context.Background(), key, query.IToBytes(value), ttl)
+			}); err != nil {
+				return nil, err
+			}
+			if cerr != nil {
+				return nil, cerr
+			}
+			return value, nil
+		}, nil), nil
+	}
+}
+
+func counterCtor(mgr bundle.NewManagement, counterMut *sync.Mutex) query.FunctionCtor {
+	return func(args *query.ParsedParams) (query.Function, error) {
+		resource, err := args.FieldString("resource")
+		if err != nil {
+			return nil, err
+		}
+		key, err := args.FieldString("key")
+		if err != nil {
+			return nil, err
+		}
+		delta, err := args.FieldInt64("delta")
+		if err != nil {
+			return nil, err
+		}
+		return query.ClosureFunction("function counter", func(ctx query.FunctionContext) (any, error) {
+			counterMut.Lock()
+			defer counterMut.Unlock()
+
+			var count int64
+			var cerr error
+			if err := mgr.AccessCache(context.Background(), resource, func(c cache.V1) {
+				var current []byte
+				if current, cerr = c.Get(context.Background(), key); cerr != nil {
+					if !errors.Is(cerr, component.ErrKeyNotFound) {
+						return
+					}
+					cerr = nil
+				} else if count, cerr = strconv.ParseInt(string(current), 10, 64); cerr != nil {
+					cerr = fmt.Errorf("failed to parse existing counter value: %w", cerr)
+					return
+				}
+				count += delta
+				cerr = c.Set(context.Background(), key, []byte(strconv.FormatInt(count, 10)), nil)
+			}); err != nil {
+				return nil, err
+			}
+			if cerr != nil {
+				return nil, cerr
+			}
+			return count, nil
+		}, nil), nil
+	}
+}
+
+type lookupAccessor interface {
+	AccessLookup(name string, fn func(*lookup.Table)) error
+}
+
+func lookupCtor(mgr bundle.NewManagement) query.FunctionCtor {
+	return func(args *query.ParsedParams) (query.Function, error) {
+		resource, err := args.FieldString("resource")
+		if err != nil {
+			return nil, err
+		}
+		key, err := args.FieldString("key")
+		if err != nil {
+			return nil, err
+		}
+		return query.ClosureFunction("function lookup", func(ctx query.FunctionContext) (any, error) {
+			accessor, ok := mgr.(lookupAccessor)
+			if !ok {
+				return nil, errors.New("lookup tables are not supported in this context")
+			}
+			var value any
+			var exists bool
+			if err := accessor.AccessLookup(resource, func(t *lookup.Table) {
+				value, exists = t.Get(key)
+			}); err != nil {
+				return nil, err
+			}
+			if !exists {
+				return nil, fmt.Errorf("key '%v' was not found in lookup table '%v'", key, resource)
+			}
+			// Rows are shared between executions and must not be mutated.
+			return query.IClone(value), nil
+		}, nil), nil
+	}
+}
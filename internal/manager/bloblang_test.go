@@ -0,0 +1,91 @@
+package manager_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/lookup"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestManagerBloblangResourceFunctions(t *testing.T) {
+	conf := manager.NewResourceConfig()
+
+	fooCache := cache.NewConfig()
+	fooCache.Label = "foo"
+	fooCache.Type = "memory"
+	conf.ResourceCaches = append(conf.ResourceCaches, fooCache)
+
+	mgr, err := manager.New(conf)
+	require.NoError(t, err)
+
+	exec, err := mgr.BloblEnvironment().NewMapping(`
+root.set = cache_set("foo", this.key, this.value)
+root.got = cache_get("foo", this.key).string()
+root.missing = cache_get("foo", "nope").catch("default")
+root.count_a = counter("foo", "seq")
+root.count_b = counter(resource: "foo", key: "seq", delta: 10)
+`)
+	require.NoError(t, err)
+
+	res, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"key":"bar","value":"baz"}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"count_a":1,"count_b":11,"got":"baz","missing":"default","set":"baz"}`, string(res.AsBytes()))
+
+	exec, err = mgr.BloblEnvironment().NewMapping(`root = cache_get("bar", "nope")`)
+	require.NoError(t, err)
+
+	_, err = exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+	require.Error(t, err)
+}
+
+func TestManagerBloblangResourceFunctionsUnbound(t *testing.T) {
+	exec, err := bloblang.GlobalEnvironment().NewMapping(`root = counter("foo", "bar")`)
+	require.NoError(t, err)
+
+	_, err = exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires access to resources")
+}
+
+func TestManagerBloblangLookup(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "countries.csv"), []byte("code,name\nGB,United Kingdom\nFR,France\n"), 0o644))
+
+	conf := manager.NewResourceConfig()
+	lConf := lookup.NewConfig()
+	lConf.Label = "countries"
+	lConf.Path = filepath.Join(dir, "countries.csv")
+	lConf.Key = "code"
+	conf.ResourceLookups = append(conf.ResourceLookups, lConf)
+
+	mgr, err := manager.New(conf)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, mgr.WaitForClose(context.Background()))
+	})
+
+	exec, err := mgr.BloblEnvironment().NewMapping(`
+root.found = lookup("countries", this.code).name
+root.missing = lookup("countries", "nope").name.catch("unknown")
+`)
+	require.NoError(t, err)
+
+	res, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"code":"FR"}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"found":"France","missing":"unknown"}`, string(res.AsBytes()))
+
+	exec, err = mgr.BloblEnvironment().NewMapping(`root = lookup("nope", "GB")`)
+	require.NoError(t, err)
+
+	_, err = exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+	require.Error(t, err)
+}
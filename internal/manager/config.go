@@ -6,6 +6,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
+	"github.com/benthosdev/benthos/v4/internal/lookup"
 )
 
 // ResourceConfig contains fields for specifying resource components at the root
@@ -16,6 +17,7 @@ type ResourceConfig struct {
 	ResourceOutputs    []output.Config    `json:"output_resources,omitempty" yaml:"output_resources,omitempty"`
 	ResourceCaches     []cache.Config     `json:"cache_resources,omitempty" yaml:"cache_resources,omitempty"`
 	ResourceRateLimits []ratelimit.Config `json:"rate_limit_resources,omitempty" yaml:"rate_limit_resources,omitempty"`
+	ResourceLookups    []lookup.Config    `json:"lookup_resources,omitempty" yaml:"lookup_resources,omitempty"`
 }
 
 // NewResourceConfig creates a ResourceConfig with default values.
@@ -26,6 +28,7 @@ func NewResourceConfig() ResourceConfig {
 		ResourceOutputs:    []output.Config{},
 		ResourceCaches:     []cache.Config{},
 		ResourceRateLimits: []ratelimit.Config{},
+		ResourceLookups:    []lookup.Config{},
 	}
 }
 
@@ -37,5 +40,6 @@ func (r *ResourceConfig) AddFrom(extra *ResourceConfig) error {
 	r.ResourceOutputs = append(r.ResourceOutputs, extra.ResourceOutputs...)
 	r.ResourceCaches = append(r.ResourceCaches, extra.ResourceCaches...)
 	r.ResourceRateLimits = append(r.ResourceRateLimits, extra.ResourceRateLimits...)
+	r.ResourceLookups = append(r.ResourceLookups, extra.ResourceLookups...)
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/Jeffail/gabs/v2"
 
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/lookup"
 )
 
 func lintResource(ctx docs.LintContext, line, col int, v any) []docs.Lint {
@@ -42,5 +43,9 @@ func Spec() docs.FieldSpecs {
 		docs.FieldRateLimit(
 			"rate_limit_resources", "A list of rate limit resources, each must have a unique label.",
 		).Array().LinterFunc(lintResource).HasDefault([]any{}),
+
+		docs.FieldObject(
+			"lookup_resources", "A list of [lookup tables](/docs/configuration/resources#lookup-tables), each must have a unique label.",
+		).WithChildren(lookup.Spec()...).Array().LinterFunc(lintResource).HasDefault([]any{}).AtVersion("4.20.0"),
 	}
 }
@@ -21,10 +21,17 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/events"
 	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+	"github.com/benthosdev/benthos/v4/internal/health"
+	"github.com/benthosdev/benthos/v4/internal/lineage"
 	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/lookup"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/profile"
+	"github.com/benthosdev/benthos/v4/internal/replay"
+	"github.com/benthosdev/benthos/v4/internal/tap"
 )
 
 // ErrResourceNotFound represents an error where a named resource could not be
@@ -75,15 +82,30 @@ type Type struct {
 	processors   map[string]processor.V1
 	outputs      map[string]*outputWrapper
 	rateLimits   map[string]ratelimit.V1
+	lookups      map[string]*lookup.Table
 	resourceLock *sync.RWMutex
 
 	// Collections of component constructors
 	env      *bundle.Environment
 	bloblEnv *bloblang.Environment
 
-	logger log.Modular
-	stats  *metrics.Namespaced
-	tracer trace.TracerProvider
+	logger   log.Modular
+	stats    *metrics.Namespaced
+	tracer   trace.TracerProvider
+	events   events.Emitter
+	health   *health.Registry
+	profiles *profile.Registry
+	taps     *tap.Registry
+
+	lineageRatio float64
+
+	replayCache       string
+	replayPrefix      string
+	replayMaxEntries  int
+	replayAckCaptured bool
+	replayStore       *replay.Store
+
+	httpTransport http.RoundTripper
 
 	pipes    map[string]<-chan message.Transaction
 	pipeLock *sync.RWMutex
@@ -132,6 +154,45 @@ func OptSetTracer(tracer trace.TracerProvider) OptFunc {
 	}
 }
 
+// OptSetEventEmitter sets the emitter to which components of the manager send
+// structured events.
+func OptSetEventEmitter(e events.Emitter) OptFunc {
+	return func(t *Type) {
+		t.events = e
+	}
+}
+
+// OptSetLineageSampleRatio enables the recording of message lineage for a ratio
+// of messages consumed by inputs of the manager, which is emitted as
+// structured events. A ratio of zero disables lineage.
+func OptSetLineageSampleRatio(ratio float64) OptFunc {
+	return func(t *Type) {
+		t.lineageRatio = ratio
+	}
+}
+
+// OptSetReplayStore enables the capturing of batches rejected by the inputs of
+// the manager, which are stored within a cache resource so that they can be
+// re-injected later. When ackCaptured is true batches that are captured
+// successfully are acknowledged rather than rejected.
+func OptSetReplayStore(cacheName, keyPrefix string, maxEntries int, ackCaptured bool) OptFunc {
+	return func(t *Type) {
+		t.replayCache = cacheName
+		t.replayPrefix = keyPrefix
+		t.replayMaxEntries = maxEntries
+		t.replayAckCaptured = ackCaptured
+	}
+}
+
+// OptSetHTTPTransport overrides the transport used by the HTTP clients of
+// components of the manager, which is intended for mocking HTTP endpoints
+// within tests.
+func OptSetHTTPTransport(rt http.RoundTripper) OptFunc {
+	return func(t *Type) {
+		t.httpTransport = rt
+	}
+}
+
 // OptSetEnvironment determines the environment from which the manager
 // initializes components and resources. This option is for internal use only.
 func OptSetEnvironment(e *bundle.Environment) OptFunc {
@@ -178,15 +239,20 @@ func New(conf ResourceConfig, opts ...OptFunc) (*Type, error) {
 		processors:   map[string]processor.V1{},
 		outputs:      map[string]*outputWrapper{},
 		rateLimits:   map[string]ratelimit.V1{},
+		lookups:      map[string]*lookup.Table{},
 		resourceLock: &sync.RWMutex{},
 
 		// Environment defaults to global (everything that was imported).
 		env:      bundle.GlobalEnvironment,
 		bloblEnv: bloblang.GlobalEnvironment(),
 
-		logger: log.Noop(),
-		stats:  metrics.Noop(),
-		tracer: trace.NewNoopTracerProvider(),
+		logger:   log.Noop(),
+		stats:    metrics.Noop(),
+		tracer:   trace.NewNoopTracerProvider(),
+		events:   events.Noop(),
+		health:   health.NewRegistry(),
+		profiles: profile.NewRegistry(),
+		taps:     tap.NewRegistry(),
 
 		fs: ifs.OS(),
 
@@ -197,6 +263,7 @@ func New(conf ResourceConfig, opts ...OptFunc) (*Type, error) {
 	for _, opt := range opts {
 		opt(t)
 	}
+	t.bloblEnv = t.bloblEnv.WithResourcesBound(t)
 
 	seen := map[string]struct{}{}
 
@@ -246,8 +313,28 @@ func New(conf ResourceConfig, opts ...OptFunc) (*Type, error) {
 		}
 		t.rateLimits[c.Label] = nil
 	}
+	for _, c := range conf.ResourceLookups {
+		if err := checkLabel("lookup", c.Label); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.replayCache != "" {
+		if _, exists := t.caches[t.replayCache]; !exists {
+			return nil, fmt.Errorf("replay store cache resource '%v' was not found", t.replayCache)
+		}
+		t.replayStore = replay.NewStore(t, t.replayCache, t.replayPrefix, t.replayMaxEntries)
+	}
 
 	// Labels validated, begin construction
+	for _, conf := range conf.ResourceLookups {
+		table, err := lookup.New(conf, t.fs, t.logger.WithFields(map[string]string{"lookup": conf.Label}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lookup resource '%v': %w", conf.Label, err)
+		}
+		t.lookups[conf.Label] = table
+	}
+
 	for _, conf := range conf.ResourceRateLimits {
 		if err := t.StoreRateLimit(context.Background(), conf.Label, conf); err != nil {
 			return nil, err
@@ -347,6 +434,13 @@ func (t *Type) Path() []string {
 	return t.componentPath
 }
 
+func (t *Type) pathString() string {
+	if len(t.componentPath) == 0 {
+		return ""
+	}
+	return "root." + query.SliceToDotPath(t.componentPath...)
+}
+
 // Label returns the current component label held by a manager.
 func (t *Type) Label() string {
 	return t.label
@@ -431,6 +525,99 @@ func (t *Type) Tracer() trace.TracerProvider {
 	return t.tracer
 }
 
+// Events returns an emitter of structured events, where events emitted are
+// annotated with the stream, label and component path of the manager.
+func (t *Type) Events() events.Emitter {
+	pathStr := t.pathString()
+	return events.EmitterFunc(func(e events.Event) {
+		if e.Stream == "" {
+			e.Stream = t.stream
+		}
+		if e.Label == "" {
+			e.Label = t.label
+		}
+		if e.Path == "" {
+			e.Path = pathStr
+		}
+		t.events.Emit(e)
+	})
+}
+
+// Lineage returns a recorder of message lineage annotated with the stream,
+// label and component path of the manager, or nil if lineage is disabled.
+func (t *Type) Lineage() *lineage.Recorder {
+	if t.lineageRatio <= 0 {
+		return nil
+	}
+	return &lineage.Recorder{
+		Ratio:   t.lineageRatio,
+		Stream:  t.stream,
+		Label:   t.label,
+		Path:    t.pathString(),
+		Emitter: t.events,
+	}
+}
+
+// Replay returns a recorder of rejected batches annotated with the stream,
+// label and component path of the manager, or nil if the replay store is
+// disabled.
+func (t *Type) Replay() *replay.Recorder {
+	if t.replayStore == nil {
+		return nil
+	}
+	return &replay.Recorder{
+		Store:       t.replayStore,
+		AckCaptured: t.replayAckCaptured,
+		Stream:      t.stream,
+		Label:       t.label,
+		Path:        t.pathString(),
+	}
+}
+
+// HTTPTransport returns a transport that HTTP clients of components should use
+// in place of their own, or nil if it has not been overridden.
+func (t *Type) HTTPTransport() http.RoundTripper {
+	return t.httpTransport
+}
+
+// RegisterHealth registers an input or output with the health registry of the
+// manager, which is annotated with the stream, label and component path of the
+// manager.
+func (t *Type) RegisterHealth(kind, typeStr string, connected func() bool) *health.Component {
+	return t.health.Register(t.stream, t.label, t.pathString(), kind, typeStr, connected)
+}
+
+// RegisterProfile registers a processor with the profile registry of the
+// manager, which is annotated with the stream, label and component path of the
+// manager.
+func (t *Type) RegisterProfile(typeStr string) *profile.Processor {
+	return t.profiles.Register(t.stream, t.label, t.pathString(), typeStr)
+}
+
+// ProcessorProfiles returns the profile of each processor registered to the
+// stream of the manager, and optionally resets them.
+func (t *Type) ProcessorProfiles(reset bool) []profile.Profile {
+	return t.profiles.Profiles(t.stream, reset)
+}
+
+// RegisterTap registers a component with the tap registry of the manager,
+// which is annotated with the stream, label and component path of the manager.
+func (t *Type) RegisterTap(kind, typeStr string) *tap.Point {
+	return t.taps.Register(t.stream, t.label, t.pathString(), kind, typeStr)
+}
+
+// OpenTap opens a session that samples the messages of components of the
+// stream of the manager with a label or path matching the component argument.
+func (t *Type) OpenTap(component string, opts tap.Options) (*tap.Session, error) {
+	return t.taps.Open(t.stream, component, opts)
+}
+
+// HealthStatuses returns the health of each input and output registered to the
+// stream of the manager.
+func (t *Type) HealthStatuses() []health.Status {
+	return t.health.Statuses(t.stream)
+}
+
 // Environment returns a bundle environment used by the manager. This is for
 // internal use only.
 func (t *Type) Environment() *bundle.Environment {
@@ -894,6 +1081,31 @@ func (t *Type) RemoveRateLimit(ctx context.Context, name string) error {
 
 //------------------------------------------------------------------------------
 
+// ProbeLookup returns true if a lookup table resource exists under the
+// provided name.
+func (t *Type) ProbeLookup(name string) bool {
+	t.resourceLock.RLock()
+	defer t.resourceLock.RUnlock()
+	_, exists := t.lookups[name]
+	return exists
+}
+
+// AccessLookup attempts to access a lookup table resource by a unique
+// identifier and executes a closure function with the table as an argument.
+// Returns an error if the table does not exist.
+func (t *Type) AccessLookup(name string, fn func(*lookup.Table)) error {
+	t.resourceLock.RLock()
+	defer t.resourceLock.RUnlock()
+	l, ok := t.lookups[name]
+	if !ok || l == nil {
+		return ErrResourceNotFound(name)
+	}
+	fn(l)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
 // CloseObservability attempts to clean up observability (metrics, tracing, etc)
 // components owned by the manager. This should only be called when the manager
 // itself has finished shutting down and when it is the sole owner of the
@@ -988,5 +1200,13 @@ func (t *Type) WaitForClose(ctx context.Context) error {
 			delete(t.outputs, k)
 		})
 	}
+	for k, l := range t.lookups {
+		if err := l.Close(ctx); err != nil {
+			return fmt.Errorf("resource '%s' failed to cleanly shutdown: %v", k, err)
+		}
+		t.swapReadWithWriteLock(func() {
+			delete(t.lookups, k)
+		})
+	}
 	return nil
 }
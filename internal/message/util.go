@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -59,15 +60,42 @@ func decodeJSON(rawBytes []byte) (structured any, err error) {
 	return
 }
 
+type jsonEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+// Encoders are pooled in order to reuse their buffers, which means the encoded
+// result has to be copied out, but a single allocation of the exact size is
+// cheaper than repeatedly growing a new buffer.
+var jsonEncoderPool = sync.Pool{
+	New: func() any {
+		e := &jsonEncoder{}
+		e.enc = json.NewEncoder(&e.buf)
+		e.enc.SetEscapeHTML(false)
+		return e
+	},
+}
+
+// Buffers that have grown beyond this size are not returned to the pool in
+// order to avoid retaining large allocations indefinitely.
+const maxPooledJSONBufferSize = 64 * 1024
+
 func encodeJSON(d any) (rawBytes []byte) {
-	var buf bytes.Buffer
-	enc := json.NewEncoder(&buf)
-	enc.SetEscapeHTML(false)
-	if err := enc.Encode(d); err != nil {
+	e := jsonEncoderPool.Get().(*jsonEncoder)
+	defer func() {
+		if e.buf.Cap() <= maxPooledJSONBufferSize {
+			e.buf.Reset()
+			jsonEncoderPool.Put(e)
+		}
+	}()
+
+	if err := e.enc.Encode(d); err != nil {
 		return nil
 	}
-	if buf.Len() > 1 {
-		rawBytes = buf.Bytes()[:buf.Len()-1]
+	if e.buf.Len() > 1 {
+		rawBytes = make([]byte, e.buf.Len()-1)
+		copy(rawBytes, e.buf.Bytes())
 	}
 	return
 }
@@ -182,3 +182,38 @@ func BenchmarkCloneJSON(b *testing.B) {
 }
 
 //------------------------------------------------------------------------------
+
+func BenchmarkEncodeJSON(b *testing.B) {
+	var generic any
+	err := json.Unmarshal([]byte(`{
+		"root":{
+			"first":{
+				"value1": 1,
+				"value2": 1.2,
+				"value3": false,
+				"value4": "hello world"
+			},
+			"second": [
+				1,
+				1.2,
+				false,
+				"hello world"
+			]
+		}
+	}`), &generic)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	var encoded []byte
+	for i := 0; i < b.N; i++ {
+		encoded = encodeJSON(generic)
+	}
+	b.StopTimer()
+
+	if len(encoded) == 0 {
+		b.Error("Empty result")
+	}
+}
@@ -0,0 +1,38 @@
+//go:build linux
+
+package pipeline
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+const cpuPinningSupported = true
+
+// pinThreadToCPU restricts the OS thread of the calling goroutine to the nth
+// CPU that the process is permitted to run on, wrapping around when n exceeds
+// the number of permitted CPUs. The calling goroutine must be locked to its OS
+// thread.
+func pinThreadToCPU(n int) error {
+	var allowed unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &allowed); err != nil {
+		return err
+	}
+	count := allowed.Count()
+	if count == 0 {
+		return errors.New("no permitted CPUs found")
+	}
+	n %= count
+	for cpu := 0; ; cpu++ {
+		if !allowed.IsSet(cpu) {
+			continue
+		}
+		if n == 0 {
+			var target unix.CPUSet
+			target.Set(cpu)
+			return unix.SchedSetaffinity(0, &target)
+		}
+		n--
+	}
+}
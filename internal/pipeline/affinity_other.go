@@ -0,0 +1,11 @@
+//go:build !linux
+
+package pipeline
+
+import "errors"
+
+const cpuPinningSupported = false
+
+func pinThreadToCPU(n int) error {
+	return errors.New("cpu pinning is not supported on this platform")
+}
@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
@@ -17,6 +18,7 @@ import (
 // threads, or use a memory buffer.
 type Config struct {
 	Threads    int                `json:"threads" yaml:"threads"`
+	Sharding   ShardingConfig     `json:"sharding" yaml:"sharding"`
 	Processors []processor.Config `json:"processors" yaml:"processors"`
 }
 
@@ -24,10 +26,30 @@ type Config struct {
 func NewConfig() Config {
 	return Config{
 		Threads:    -1,
+		Sharding:   NewShardingConfig(),
 		Processors: []processor.Config{},
 	}
 }
 
+// ShardingConfig describes how messages are distributed across the threads of
+// a pipeline. When a key is set each message batch is dispatched to a thread
+// determined by a hash of the key, with each thread consuming from a dedicated
+// queue rather than all threads sharing a single queue.
+type ShardingConfig struct {
+	Key        string `json:"key" yaml:"key"`
+	QueueSize  int    `json:"queue_size" yaml:"queue_size"`
+	PinThreads bool   `json:"pin_threads" yaml:"pin_threads"`
+}
+
+// NewShardingConfig returns a ShardingConfig with default values.
+func NewShardingConfig() ShardingConfig {
+	return ShardingConfig{
+		Key:        "",
+		QueueSize:  64,
+		PinThreads: false,
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // New creates an input type based on an input configuration.
@@ -44,5 +66,12 @@ func New(conf Config, mgr bundle.NewManagement) (processor.Pipeline, error) {
 	if conf.Threads == 1 {
 		return NewProcessor(processors...), nil
 	}
+	if conf.Sharding.Key != "" {
+		key, err := mgr.BloblEnvironment().NewField(conf.Sharding.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sharding key: %w", err)
+		}
+		return NewShardedPool(conf.Threads, conf.Sharding.QueueSize, key, conf.Sharding.PinThreads, mgr.Logger(), processors...)
+	}
 	return NewPool(conf.Threads, mgr.Logger(), processors...)
 }
@@ -2,10 +2,12 @@ package pipeline
 
 import (
 	"context"
+	"hash/maphash"
 	"runtime"
 	"sync"
 	"sync/atomic"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -21,6 +23,12 @@ type Pool struct {
 
 	log log.Modular
 
+	// When set each worker consumes from a dedicated queue and transactions
+	// are dispatched to them by a hash of this key.
+	shardKey       *field.Expression
+	shardQueueSize int
+	hashSeed       maphash.Seed
+
 	messagesIn  <-chan message.Transaction
 	messagesOut chan message.Transaction
 
@@ -47,8 +55,75 @@ func NewPool(threads int, log log.Modular, msgProcessors ...processor.V1) (*Pool
 	return p, nil
 }
 
+// NewShardedPool creates a new processing pool where message batches are
+// dispatched to workers by a hash of a key resolved from the first message of
+// each batch, and each worker consumes from a dedicated queue of a given size.
+// This reduces contention between workers on machines with many cores, and
+// guarantees that batches of the same key are processed by the same worker.
+// When pinned each worker is locked to an OS thread that is pinned to a
+// dedicated CPU where the platform supports it.
+func NewShardedPool(threads, queueSize int, key *field.Expression, pin bool, log log.Modular, msgProcessors ...processor.V1) (*Pool, error) {
+	p, err := NewPool(threads, log, msgProcessors...)
+	if err != nil {
+		return nil, err
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p.shardKey = key
+	p.shardQueueSize = queueSize
+	p.hashSeed = maphash.MakeSeed()
+
+	if pin {
+		if !cpuPinningSupported {
+			log.Warnln("CPU pinning of pipeline threads is not supported on this platform and will be ignored")
+		} else {
+			for i, w := range p.workers {
+				w.(*Processor).pinCPU = i
+			}
+		}
+	}
+	return p, nil
+}
+
 //------------------------------------------------------------------------------
 
+func (p *Pool) shardFor(batch message.Batch) int {
+	key, err := p.shardKey.String(0, batch)
+	if err != nil {
+		p.log.Debugf("Failed to resolve sharding key: %v", err)
+	}
+	return int(maphash.String(p.hashSeed, key) % uint64(len(p.workers)))
+}
+
+// dispatchShards routes transactions from the input of the pool to the queues
+// of each worker.
+func (p *Pool) dispatchShards(queues []chan message.Transaction) {
+	defer func() {
+		for _, q := range queues {
+			close(q)
+		}
+	}()
+	for {
+		var t message.Transaction
+		var open bool
+		select {
+		case t, open = <-p.messagesIn:
+			if !open {
+				return
+			}
+		case <-p.shutSig.CloseNowChan():
+			return
+		}
+		select {
+		case queues[p.shardFor(t.Payload)] <- t:
+		case <-p.shutSig.CloseNowChan():
+			return
+		}
+	}
+}
+
 // loop is the processing loop of this pipeline.
 func (p *Pool) loop() {
 	// Note this is currently kept open as we only have our children as a
@@ -74,8 +149,22 @@ func (p *Pool) loop() {
 
 	var closeInternalOnce sync.Once
 
-	for _, worker := range p.workers {
-		if err := worker.Consume(p.messagesIn); err != nil {
+	workerInputs := make([]<-chan message.Transaction, len(p.workers))
+	if p.shardKey != nil {
+		queues := make([]chan message.Transaction, len(p.workers))
+		for i := range queues {
+			queues[i] = make(chan message.Transaction, p.shardQueueSize)
+			workerInputs[i] = queues[i]
+		}
+		go p.dispatchShards(queues)
+	} else {
+		for i := range workerInputs {
+			workerInputs[i] = p.messagesIn
+		}
+	}
+
+	for i, worker := range p.workers {
+		if err := worker.Consume(workerInputs[i]); err != nil {
 			p.log.Errorf("Failed to start pipeline worker: %v\n", err)
 			atomic.AddInt64(&remainingWorkers, -1)
 			continue
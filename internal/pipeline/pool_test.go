@@ -276,3 +276,86 @@ func TestPoolMultiNaturalClose(t *testing.T) {
 	close(tChan)
 	require.NoError(t, proc.WaitForClose(context.Background()))
 }
+
+func TestPoolShardedOrdering(t *testing.T) {
+	for _, pin := range []bool{false, true} {
+		pin := pin
+		t.Run(fmt.Sprintf("pin %v", pin), func(t *testing.T) {
+			ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+			defer done()
+
+			conf := pipeline.NewConfig()
+			conf.Threads = 4
+			conf.Sharding.Key = `${! meta("key") }`
+			conf.Sharding.QueueSize = 2
+			conf.Sharding.PinThreads = pin
+			conf.Processors = append(conf.Processors, processor.NewConfig())
+
+			proc, err := pipeline.New(conf, mock.NewManager())
+			require.NoError(t, err)
+
+			tChan := make(chan message.Transaction)
+			require.NoError(t, proc.Consume(tChan))
+
+			const keys, total = 5, 200
+
+			resChan := make(chan error, total)
+			go func() {
+				for i := 0; i < total; i++ {
+					part := message.NewPart([]byte(fmt.Sprintf("%v", i)))
+					part.MetaSetMut("key", fmt.Sprintf("key%v", i%keys))
+					select {
+					case tChan <- message.NewTransaction(message.Batch{part}, resChan):
+					case <-ctx.Done():
+						return
+					}
+				}
+				close(tChan)
+			}()
+
+			lastByKey := map[string]int{}
+			for i := 0; i < total; i++ {
+				var procT message.Transaction
+				select {
+				case procT = <-proc.TransactionChan():
+				case <-ctx.Done():
+					t.Fatal("Timed out")
+				}
+
+				require.Len(t, procT.Payload, 1)
+				key, _ := procT.Payload.Get(0).MetaGetMut("key")
+
+				var index int
+				_, err := fmt.Sscanf(string(procT.Payload.Get(0).AsBytes()), "%d", &index)
+				require.NoError(t, err)
+
+				if last, exists := lastByKey[key.(string)]; exists {
+					assert.Greater(t, index, last, "key %v", key)
+				}
+				lastByKey[key.(string)] = index
+				require.NoError(t, procT.Ack(ctx, nil))
+			}
+			assert.Len(t, lastByKey, keys)
+
+			for i := 0; i < total; i++ {
+				select {
+				case res := <-resChan:
+					require.NoError(t, res)
+				case <-ctx.Done():
+					t.Fatal("Timed out")
+				}
+			}
+			require.NoError(t, proc.WaitForClose(ctx))
+		})
+	}
+}
+
+func TestPoolShardedBadKey(t *testing.T) {
+	conf := pipeline.NewConfig()
+	conf.Threads = 2
+	conf.Sharding.Key = `${! meta("key" }`
+
+	_, err := pipeline.New(conf, mock.NewManager())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sharding key")
+}
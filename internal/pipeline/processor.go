@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"context"
+	"runtime"
 	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -22,6 +23,10 @@ type Processor struct {
 
 	messagesIn <-chan message.Transaction
 
+	// When non-negative the processing loop is locked to an OS thread that is
+	// pinned to the nth permitted CPU.
+	pinCPU int
+
 	shutSig *shutdown.Signaller
 }
 
@@ -31,6 +36,7 @@ func NewProcessor(msgProcessors ...processor.V1) *Processor {
 		msgProcessors: msgProcessors,
 		messagesOut:   make(chan message.Transaction),
 		responsesIn:   make(chan error),
+		pinCPU:        -1,
 		shutSig:       shutdown.NewSignaller(),
 	}
 }
@@ -39,6 +45,14 @@ func NewProcessor(msgProcessors ...processor.V1) *Processor {
 
 // loop is the processing loop of this pipeline.
 func (p *Processor) loop() {
+	if p.pinCPU >= 0 {
+		// The thread is deliberately never unlocked as its affinity has been
+		// changed, which means it is terminated when this goroutine exits
+		// rather than being returned to the scheduler.
+		runtime.LockOSThread()
+		_ = pinThreadToCPU(p.pinCPU)
+	}
+
 	closeNowCtx, cnDone := p.shutSig.CloseNowCtx(context.Background())
 	defer cnDone()
 
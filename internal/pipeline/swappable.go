@@ -0,0 +1,205 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// Swappable is a pipeline that wraps another pipeline and allows it to be
+// replaced at runtime. When a pipeline is swapped the new pipeline begins
+// consuming transactions immediately whilst the old pipeline is drained of any
+// transactions it has in flight before being closed.
+type Swappable struct {
+	messagesIn  <-chan message.Transaction
+	messagesOut chan message.Transaction
+
+	swapChan chan swapRequest
+	relayWG  sync.WaitGroup
+
+	pipesMut sync.Mutex
+	current  processor.Pipeline
+	active   map[processor.Pipeline]struct{}
+
+	shutSig *shutdown.Signaller
+}
+
+type swapRequest struct {
+	in   chan message.Transaction
+	done chan struct{}
+}
+
+// NewSwappable returns a new swappable pipeline wrapping an initial pipeline.
+func NewSwappable(initial processor.Pipeline) *Swappable {
+	return &Swappable{
+		messagesOut: make(chan message.Transaction),
+		swapChan:    make(chan swapRequest),
+		current:     initial,
+		active:      map[processor.Pipeline]struct{}{},
+		shutSig:     shutdown.NewSignaller(),
+	}
+}
+
+// startRelay begins forwarding transactions from a pipeline to the output
+// channel until the pipeline closes.
+func (s *Swappable) startRelay(p processor.Pipeline) {
+	s.pipesMut.Lock()
+	s.active[p] = struct{}{}
+	s.pipesMut.Unlock()
+
+	s.relayWG.Add(1)
+	go s.relay(p)
+}
+
+func (s *Swappable) relay(p processor.Pipeline) {
+	defer func() {
+		s.pipesMut.Lock()
+		delete(s.active, p)
+		s.pipesMut.Unlock()
+		s.relayWG.Done()
+	}()
+	for {
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-p.TransactionChan():
+			if !open {
+				return
+			}
+		case <-s.shutSig.CloseNowChan():
+			return
+		}
+		select {
+		case s.messagesOut <- tran:
+		case <-s.shutSig.CloseNowChan():
+			return
+		}
+	}
+}
+
+func (s *Swappable) loop(currentIn chan message.Transaction) {
+	defer func() {
+		close(currentIn)
+		s.relayWG.Wait()
+		close(s.messagesOut)
+		s.shutSig.ShutdownComplete()
+	}()
+
+	for {
+		select {
+		case tran, open := <-s.messagesIn:
+			if !open {
+				return
+			}
+			select {
+			case currentIn <- tran:
+			case <-s.shutSig.CloseNowChan():
+				return
+			}
+		case req := <-s.swapChan:
+			close(currentIn)
+			currentIn = req.in
+			close(req.done)
+		case <-s.shutSig.CloseNowChan():
+			return
+		}
+	}
+}
+
+// Swap replaces the active pipeline with a new one. The new pipeline begins
+// consuming immediately, and this call blocks until the previous pipeline has
+// finished processing its in flight transactions and closed. If the context is
+// cancelled before the previous pipeline has closed then it is forcefully
+// terminated. An error is only returned when the swap did not take effect, in
+// which case the new pipeline is closed. Swap must not be called concurrently.
+func (s *Swappable) Swap(ctx context.Context, p processor.Pipeline) error {
+	if s.messagesIn == nil {
+		return component.ErrNotConnected
+	}
+
+	newIn := make(chan message.Transaction)
+	if err := p.Consume(newIn); err != nil {
+		return err
+	}
+
+	s.startRelay(p)
+
+	req := swapRequest{in: newIn, done: make(chan struct{})}
+	select {
+	case s.swapChan <- req:
+	case <-s.shutSig.CloseNowChan():
+		p.TriggerCloseNow()
+		return component.ErrTypeClosed
+	case <-s.shutSig.HasClosedChan():
+		p.TriggerCloseNow()
+		return component.ErrTypeClosed
+	case <-ctx.Done():
+		close(newIn)
+		return ctx.Err()
+	}
+	<-req.done
+
+	s.pipesMut.Lock()
+	old := s.current
+	s.current = p
+	s.pipesMut.Unlock()
+
+	if err := old.WaitForClose(ctx); err != nil {
+		old.TriggerCloseNow()
+	}
+	return nil
+}
+
+// TransactionChan returns the channel used for consuming messages from this
+// pipeline.
+func (s *Swappable) TransactionChan() <-chan message.Transaction {
+	return s.messagesOut
+}
+
+// Consume assigns a messages channel for the pipeline to read.
+func (s *Swappable) Consume(msgs <-chan message.Transaction) error {
+	if s.messagesIn != nil {
+		return component.ErrAlreadyStarted
+	}
+
+	currentIn := make(chan message.Transaction)
+	if err := s.current.Consume(currentIn); err != nil {
+		return err
+	}
+	s.messagesIn = msgs
+
+	s.startRelay(s.current)
+	go s.loop(currentIn)
+	return nil
+}
+
+// TriggerCloseNow signals that the pipeline should close immediately.
+func (s *Swappable) TriggerCloseNow() {
+	s.shutSig.CloseNow()
+
+	s.pipesMut.Lock()
+	s.current.TriggerCloseNow()
+	for p := range s.active {
+		p.TriggerCloseNow()
+	}
+	s.pipesMut.Unlock()
+}
+
+// WaitForClose blocks until the pipeline has closed down or the context is
+// cancelled.
+func (s *Swappable) WaitForClose(ctx context.Context) error {
+	select {
+	case <-s.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.pipesMut.Lock()
+	current := s.current
+	s.pipesMut.Unlock()
+	return current.WaitForClose(ctx)
+}
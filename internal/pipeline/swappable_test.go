@@ -0,0 +1,160 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/pipeline"
+)
+
+type setContentProcessor struct {
+	content string
+	closed  chan struct{}
+}
+
+func newSetContentProcessor(content string) *setContentProcessor {
+	return &setContentProcessor{content: content, closed: make(chan struct{})}
+}
+
+func (s *setContentProcessor) ProcessBatch(ctx context.Context, b message.Batch) ([]message.Batch, error) {
+	newB := b.ShallowCopy()
+	for i := range newB {
+		newB[i].SetBytes([]byte(s.content))
+	}
+	return []message.Batch{newB}, nil
+}
+
+func (s *setContentProcessor) Close(ctx context.Context) error {
+	close(s.closed)
+	return nil
+}
+
+func sendAndReceive(t *testing.T, tChan chan message.Transaction, outChan <-chan message.Transaction, content string) string {
+	t.Helper()
+
+	resChan := make(chan error)
+	select {
+	case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(content)}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	var tran message.Transaction
+	select {
+	case tran = <-outChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	result := string(tran.Payload.Get(0).AsBytes())
+
+	go func() {
+		_ = tran.Ack(context.Background(), nil)
+	}()
+	select {
+	case err := <-resChan:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	return result
+}
+
+func TestSwappablePipeline(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	procA := newSetContentProcessor("from a")
+	procB := newSetContentProcessor("from b")
+
+	swappable := pipeline.NewSwappable(pipeline.NewProcessor(procA))
+	require.Error(t, swappable.Swap(ctx, pipeline.NewProcessor(procB)))
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, swappable.Consume(tChan))
+	require.Error(t, swappable.Consume(tChan))
+
+	assert.Equal(t, "from a", sendAndReceive(t, tChan, swappable.TransactionChan(), "hello"))
+
+	require.NoError(t, swappable.Swap(ctx, pipeline.NewProcessor(procB)))
+	select {
+	case <-procA.closed:
+	case <-ctx.Done():
+		t.Fatal("previous pipeline was not closed")
+	}
+
+	assert.Equal(t, "from b", sendAndReceive(t, tChan, swappable.TransactionChan(), "hello"))
+
+	close(tChan)
+	require.NoError(t, swappable.WaitForClose(ctx))
+
+	select {
+	case <-procB.closed:
+	case <-ctx.Done():
+		t.Fatal("current pipeline was not closed")
+	}
+
+	_, open := <-swappable.TransactionChan()
+	assert.False(t, open)
+}
+
+func TestSwappablePipelineInFlight(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	procA := newSetContentProcessor("from a")
+	procB := newSetContentProcessor("from b")
+
+	swappable := pipeline.NewSwappable(pipeline.NewProcessor(procA))
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, swappable.Consume(tChan))
+
+	resChan := make(chan error)
+	select {
+	case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), resChan):
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	// The in flight transaction must be delivered and acknowledged before the
+	// previous pipeline is able to close and the swap completes.
+	swapErr := make(chan error)
+	go func() {
+		swapErr <- swappable.Swap(ctx, pipeline.NewProcessor(procB))
+	}()
+
+	var tran message.Transaction
+	select {
+	case tran = <-swappable.TransactionChan():
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, "from a", string(tran.Payload.Get(0).AsBytes()))
+
+	go func() {
+		_ = tran.Ack(ctx, nil)
+	}()
+	select {
+	case err := <-resChan:
+		require.NoError(t, err)
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	select {
+	case err := <-swapErr:
+		require.NoError(t, err)
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	assert.Equal(t, "from b", sendAndReceive(t, tChan, swappable.TransactionChan(), "hello"))
+
+	swappable.TriggerCloseNow()
+	require.NoError(t, swappable.WaitForClose(ctx))
+}
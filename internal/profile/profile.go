@@ -0,0 +1,345 @@
+// Package profile provides a registry for tracking the execution time and
+// batch sizes of individual processors, which is used in order to identify the
+// processors that dominate the latency of a pipeline without the need for
+// tracing.
+package profile
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Registry keeps track of the profiles of processors.
+type Registry struct {
+	mut        sync.Mutex
+	processors map[*Processor]struct{}
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		processors: map[*Processor]struct{}{},
+	}
+}
+
+// Register a new processor with the registry. The returned processor should be
+// deregistered once it is closed.
+func (r *Registry) Register(stream, label, path, typeStr string) *Processor {
+	p := NewUnregistered(typeStr)
+	p.reg = r
+	p.stream = stream
+	p.label = label
+	p.path = path
+
+	r.mut.Lock()
+	r.processors[p] = struct{}{}
+	r.mut.Unlock()
+	return p
+}
+
+// Profiles returns the profile of each registered processor of a given stream,
+// sorted by path and label. When reset is true the profiles are reset after
+// being read, which allows callers to observe distinct periods of time.
+func (r *Registry) Profiles(stream string, reset bool) []Profile {
+	r.mut.Lock()
+	profiles := make([]Profile, 0, len(r.processors))
+	for p := range r.processors {
+		if p.stream == stream {
+			profiles = append(profiles, p.Profile(reset))
+		}
+	}
+	r.mut.Unlock()
+
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Path != profiles[j].Path {
+			return profiles[i].Path < profiles[j].Path
+		}
+		return profiles[i].Label < profiles[j].Label
+	})
+	return profiles
+}
+
+//------------------------------------------------------------------------------
+
+// Processor tracks the profile of a single processor. All methods are safe to
+// call concurrently.
+type Processor struct {
+	reg *Registry
+
+	stream  string
+	label   string
+	path    string
+	typeStr string
+
+	latency   *histogram
+	batchSize *histogram
+}
+
+// NewUnregistered returns a processor that tracks a profile but is not
+// registered to any registry.
+func NewUnregistered(typeStr string) *Processor {
+	return &Processor{
+		typeStr:   typeStr,
+		latency:   newHistogram(latencyBounds),
+		batchSize: newHistogram(batchSizeBounds),
+	}
+}
+
+// Record a batch of a given size that took a duration to process.
+func (p *Processor) Record(batchSize int, d time.Duration) {
+	if p == nil {
+		return
+	}
+	p.latency.record(d.Nanoseconds())
+	p.batchSize.record(int64(batchSize))
+}
+
+// Deregister removes the processor from its registry.
+func (p *Processor) Deregister() {
+	if p == nil || p.reg == nil {
+		return
+	}
+	p.reg.mut.Lock()
+	delete(p.reg.processors, p)
+	p.reg.mut.Unlock()
+}
+
+// Profile returns the current profile of the processor, and optionally resets
+// it.
+func (p *Processor) Profile(reset bool) Profile {
+	latency, batchSize := p.latency.snapshot(reset), p.batchSize.snapshot(reset)
+
+	prof := Profile{
+		Label:   p.label,
+		Path:    p.path,
+		Type:    p.typeStr,
+		Batches: latency.count,
+		Latency: LatencyProfile{
+			Total: time.Duration(latency.sum).String(),
+			Mean:  time.Duration(latency.mean()).String(),
+			P50:   time.Duration(latency.quantile(0.5)).String(),
+			P90:   time.Duration(latency.quantile(0.9)).String(),
+			P99:   time.Duration(latency.quantile(0.99)).String(),
+			Max:   time.Duration(latency.max).String(),
+		},
+		BatchSize: BatchSizeProfile{
+			Mean: batchSize.mean(),
+			P50:  batchSize.quantile(0.5),
+			P90:  batchSize.quantile(0.9),
+			P99:  batchSize.quantile(0.99),
+			Max:  batchSize.max,
+		},
+		Messages: batchSize.sum,
+	}
+	for i, c := range latency.counts {
+		if c > 0 {
+			prof.Latency.Buckets = append(prof.Latency.Buckets, LatencyBucket{
+				LessOrEqual: boundString(latencyBounds[i], func(v int64) string {
+					return time.Duration(v).String()
+				}),
+				Count: c,
+			})
+		}
+	}
+	for i, c := range batchSize.counts {
+		if c > 0 {
+			prof.BatchSize.Buckets = append(prof.BatchSize.Buckets, BatchSizeBucket{
+				LessOrEqual: boundString(batchSizeBounds[i], func(v int64) string {
+					return strconv.FormatInt(v, 10)
+				}),
+				Count: c,
+			})
+		}
+	}
+	return prof
+}
+
+// Profile is a snapshot of the execution time and batch sizes of a processor.
+// Percentiles are estimated from the upper bounds of histogram buckets.
+type Profile struct {
+	Label     string           `json:"label,omitempty"`
+	Path      string           `json:"path"`
+	Type      string           `json:"type"`
+	Batches   int64            `json:"batches"`
+	Messages  int64            `json:"messages"`
+	Latency   LatencyProfile   `json:"latency"`
+	BatchSize BatchSizeProfile `json:"batch_size"`
+}
+
+// LatencyProfile describes the execution time of batches by a processor.
+type LatencyProfile struct {
+	Total   string          `json:"total"`
+	Mean    string          `json:"mean"`
+	P50     string          `json:"p50"`
+	P90     string          `json:"p90"`
+	P99     string          `json:"p99"`
+	Max     string          `json:"max"`
+	Buckets []LatencyBucket `json:"buckets,omitempty"`
+}
+
+// LatencyBucket is the number of batches that took no longer than a duration,
+// and longer than the duration of the previous bucket.
+type LatencyBucket struct {
+	LessOrEqual string `json:"le"`
+	Count       int64  `json:"count"`
+}
+
+// BatchSizeProfile describes the sizes of batches received by a processor.
+type BatchSizeProfile struct {
+	Mean    float64           `json:"mean"`
+	P50     int64             `json:"p50"`
+	P90     int64             `json:"p90"`
+	P99     int64             `json:"p99"`
+	Max     int64             `json:"max"`
+	Buckets []BatchSizeBucket `json:"buckets,omitempty"`
+}
+
+// BatchSizeBucket is the number of batches that were no larger than a size,
+// and larger than the size of the previous bucket.
+type BatchSizeBucket struct {
+	LessOrEqual string `json:"le"`
+	Count       int64  `json:"count"`
+}
+
+//------------------------------------------------------------------------------
+
+// Latency buckets double from one microsecond up to roughly a minute, and batch
+// size buckets double from one up to 65536. Values beyond the last bound are
+// counted in a final bucket with an unbounded size.
+var (
+	latencyBounds   = exponentialBounds(int64(time.Microsecond), 27)
+	batchSizeBounds = exponentialBounds(1, 17)
+)
+
+func exponentialBounds(start int64, n int) []int64 {
+	bounds := make([]int64, n, n+1)
+	for i := range bounds {
+		bounds[i] = start << i
+	}
+	return append(bounds, math.MaxInt64)
+}
+
+func boundString(v int64, format func(int64) string) string {
+	if v == math.MaxInt64 {
+		return "+Inf"
+	}
+	return format(v)
+}
+
+type histogram struct {
+	bounds []int64
+	counts []int64
+	count  int64
+	sum    int64
+	max    int64
+}
+
+func newHistogram(bounds []int64) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]int64, len(bounds)),
+	}
+}
+
+func (h *histogram) record(v int64) {
+	i := sort.Search(len(h.bounds), func(i int) bool {
+		return h.bounds[i] >= v
+	})
+	atomic.AddInt64(&h.counts[i], 1)
+	atomic.AddInt64(&h.count, 1)
+	atomic.AddInt64(&h.sum, v)
+	for {
+		current := atomic.LoadInt64(&h.max)
+		if v <= current || atomic.CompareAndSwapInt64(&h.max, current, v) {
+			return
+		}
+	}
+}
+
+type histogramSnapshot struct {
+	bounds []int64
+	counts []int64
+	count  int64
+	sum    int64
+	max    int64
+}
+
+// snapshot reads the histogram without blocking writers, and therefore the
+// fields of a snapshot may be marginally inconsistent with one another.
+func (h *histogram) snapshot(reset bool) histogramSnapshot {
+	load := atomic.LoadInt64
+	if reset {
+		load = func(addr *int64) int64 {
+			return atomic.SwapInt64(addr, 0)
+		}
+	}
+	s := histogramSnapshot{
+		bounds: h.bounds,
+		counts: make([]int64, len(h.counts)),
+		count:  load(&h.count),
+		sum:    load(&h.sum),
+		max:    load(&h.max),
+	}
+	for i := range h.counts {
+		s.counts[i] = load(&h.counts[i])
+	}
+	return s
+}
+
+func (s histogramSnapshot) mean() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return float64(s.sum) / float64(s.count)
+}
+
+// quantile returns the upper bound of the bucket containing a given quantile,
+// capped at the maximum value observed.
+func (s histogramSnapshot) quantile(q float64) int64 {
+	var total int64
+	for _, c := range s.counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(q * float64(total)))
+	var seen int64
+	for i, c := range s.counts {
+		if seen += c; seen >= target {
+			if s.bounds[i] > s.max {
+				return s.max
+			}
+			return s.bounds[i]
+		}
+	}
+	return s.max
+}
+
+//------------------------------------------------------------------------------
+
+// FromManager registers a processor with the profile registry of a manager, or
+// returns an unregistered processor if the manager does not support profiling.
+func FromManager(mgr any, typeStr string) *Processor {
+	if pm, ok := mgr.(interface {
+		RegisterProfile(typeStr string) *Processor
+	}); ok {
+		return pm.RegisterProfile(typeStr)
+	}
+	return NewUnregistered(typeStr)
+}
+
+// ProfilesFromManager returns the profile of each processor registered to the
+// stream of a manager, or nil if the manager does not support profiling.
+func ProfilesFromManager(mgr any, reset bool) []Profile {
+	if pm, ok := mgr.(interface {
+		ProcessorProfiles(reset bool) []Profile
+	}); ok {
+		return pm.ProcessorProfiles(reset)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package profile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryProfiles(t *testing.T) {
+	reg := NewRegistry()
+
+	b := reg.Register("foo", "b", "root.pipeline.processors.1", "http")
+	a := reg.Register("foo", "a", "root.pipeline.processors.0", "mapping")
+	other := reg.Register("bar", "", "root.pipeline.processors.0", "mapping")
+
+	for i := 0; i < 98; i++ {
+		a.Record(1, time.Microsecond*3)
+	}
+	a.Record(4, time.Millisecond)
+	a.Record(4, time.Millisecond*2)
+	b.Record(10, time.Second)
+
+	profiles := reg.Profiles("foo", false)
+	require.Len(t, profiles, 2)
+
+	assert.Equal(t, "a", profiles[0].Label)
+	assert.Equal(t, "mapping", profiles[0].Type)
+	assert.Equal(t, int64(100), profiles[0].Batches)
+	assert.Equal(t, int64(106), profiles[0].Messages)
+	assert.Equal(t, "4µs", profiles[0].Latency.P50)
+	assert.Equal(t, "4µs", profiles[0].Latency.P90)
+	assert.Equal(t, "1.024ms", profiles[0].Latency.P99)
+	assert.Equal(t, "2ms", profiles[0].Latency.Max)
+	assert.Equal(t, "3.294ms", profiles[0].Latency.Total)
+	assert.Equal(t, []LatencyBucket{
+		{LessOrEqual: "4µs", Count: 98},
+		{LessOrEqual: "1.024ms", Count: 1},
+		{LessOrEqual: "2.048ms", Count: 1},
+	}, profiles[0].Latency.Buckets)
+	assert.InDelta(t, 1.06, profiles[0].BatchSize.Mean, 0.001)
+	assert.Equal(t, int64(1), profiles[0].BatchSize.P90)
+	assert.Equal(t, int64(4), profiles[0].BatchSize.P99)
+	assert.Equal(t, int64(4), profiles[0].BatchSize.Max)
+
+	assert.Equal(t, "b", profiles[1].Label)
+	assert.Equal(t, "1s", profiles[1].Latency.P50)
+	assert.Equal(t, []BatchSizeBucket{
+		{LessOrEqual: "16", Count: 1},
+	}, profiles[1].BatchSize.Buckets)
+
+	// Reading with a reset clears the profiles.
+	require.Len(t, reg.Profiles("foo", true), 2)
+	profiles = reg.Profiles("foo", false)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, int64(0), profiles[0].Batches)
+	assert.Empty(t, profiles[0].Latency.Buckets)
+
+	a.Deregister()
+	b.Deregister()
+	assert.Empty(t, reg.Profiles("foo", false))
+	assert.Len(t, reg.Profiles("bar", false), 1)
+
+	other.Deregister()
+	assert.Empty(t, reg.Profiles("bar", false))
+}
+
+func TestHistogramOverflow(t *testing.T) {
+	p := NewUnregistered("foo")
+	p.Record(100000, time.Hour)
+
+	prof := p.Profile(false)
+	assert.Equal(t, "1h0m0s", prof.Latency.P99)
+	assert.Equal(t, []LatencyBucket{{LessOrEqual: "+Inf", Count: 1}}, prof.Latency.Buckets)
+	assert.Equal(t, []BatchSizeBucket{{LessOrEqual: "+Inf", Count: 1}}, prof.BatchSize.Buckets)
+}
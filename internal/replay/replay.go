@@ -0,0 +1,294 @@
+// Package replay provides a store for batches of messages that were rejected
+// by a pipeline, which allows them to be inspected and re-injected into the
+// pipeline once the cause of the failure has been fixed.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// ErrNotFound is returned when an entry does not exist within a store.
+var ErrNotFound = errors.New("replay entry not found")
+
+// Message is a stored copy of a message.
+type Message struct {
+	Content  []byte         `json:"content"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// Summary describes a stored batch without its contents, including the
+// position of the input component that consumed it.
+type Summary struct {
+	ID         string    `json:"id"`
+	Stream     string    `json:"stream,omitempty"`
+	Label      string    `json:"label,omitempty"`
+	Path       string    `json:"path"`
+	Type       string    `json:"type"`
+	Error      string    `json:"error"`
+	CapturedAt time.Time `json:"captured_at"`
+	Messages   int       `json:"messages"`
+}
+
+// Entry is a stored batch.
+type Entry struct {
+	Summary
+	Batch []Message `json:"batch"`
+}
+
+// ToBatch converts the stored messages of an entry into a batch.
+func (e Entry) ToBatch() message.Batch {
+	batch := make(message.Batch, len(e.Batch))
+	for i, m := range e.Batch {
+		part := message.NewPart(m.Content)
+		for k, v := range m.Metadata {
+			part.MetaSetMut(k, v)
+		}
+		batch[i] = part
+	}
+	return batch
+}
+
+//------------------------------------------------------------------------------
+
+// CacheAccessor provides access to cache resources.
+type CacheAccessor interface {
+	AccessCache(ctx context.Context, name string, fn func(cache.V1)) error
+}
+
+// Store persists entries within a cache resource, where each entry is stored
+// under a key consisting of the prefix followed by the entry ID. Since caches
+// do not support listing keys an index of entry summaries is also maintained
+// under the key `<prefix>index`.
+//
+// Writes to the index are serialised within a process, but are not atomic, and
+// therefore when multiple instances share a store the last write wins.
+type Store struct {
+	mgr        CacheAccessor
+	cacheName  string
+	prefix     string
+	maxEntries int
+
+	mut sync.Mutex
+}
+
+// NewStore returns a store that persists entries within a cache resource. When
+// the number of entries exceeds maxEntries the oldest entries are deleted,
+// where zero means the number of entries is unlimited.
+func NewStore(mgr CacheAccessor, cacheName, prefix string, maxEntries int) *Store {
+	return &Store{
+		mgr:        mgr,
+		cacheName:  cacheName,
+		prefix:     prefix,
+		maxEntries: maxEntries,
+	}
+}
+
+func (s *Store) indexKey() string {
+	return s.prefix + "index"
+}
+
+func (s *Store) entryKey(id string) string {
+	return s.prefix + "entry_" + id
+}
+
+func (s *Store) readIndex(ctx context.Context, c cache.V1) ([]Summary, error) {
+	indexBytes, err := c.Get(ctx, s.indexKey())
+	if err != nil {
+		if errors.Is(err, component.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var index []Summary
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse replay store index: %w", err)
+	}
+	return index, nil
+}
+
+func (s *Store) writeIndex(ctx context.Context, c cache.V1, index []Summary) error {
+	indexBytes, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, s.indexKey(), indexBytes, nil)
+}
+
+func (s *Store) access(ctx context.Context, fn func(c cache.V1) error) (err error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if cerr := s.mgr.AccessCache(ctx, s.cacheName, func(c cache.V1) {
+		err = fn(c)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+// Put adds an entry to the store.
+func (s *Store) Put(ctx context.Context, e Entry) error {
+	entryBytes, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.access(ctx, func(c cache.V1) error {
+		if err := c.Set(ctx, s.entryKey(e.ID), entryBytes, nil); err != nil {
+			return err
+		}
+		index, err := s.readIndex(ctx, c)
+		if err != nil {
+			return err
+		}
+		index = append(index, e.Summary)
+		if s.maxEntries > 0 && len(index) > s.maxEntries {
+			for _, old := range index[:len(index)-s.maxEntries] {
+				if err := c.Delete(ctx, s.entryKey(old.ID)); err != nil && !errors.Is(err, component.ErrKeyNotFound) {
+					return err
+				}
+			}
+			index = index[len(index)-s.maxEntries:]
+		}
+		return s.writeIndex(ctx, c, index)
+	})
+}
+
+// List returns the summaries of entries captured by a given stream, in the
+// order that they were captured.
+func (s *Store) List(ctx context.Context, stream string) (summaries []Summary, err error) {
+	err = s.access(ctx, func(c cache.V1) error {
+		index, err := s.readIndex(ctx, c)
+		if err != nil {
+			return err
+		}
+		summaries = []Summary{}
+		for _, sum := range index {
+			if sum.Stream == stream {
+				summaries = append(summaries, sum)
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// Get returns an entry by its ID, or ErrNotFound if it does not exist.
+func (s *Store) Get(ctx context.Context, id string) (e Entry, err error) {
+	err = s.access(ctx, func(c cache.V1) error {
+		entryBytes, err := c.Get(ctx, s.entryKey(id))
+		if err != nil {
+			if errors.Is(err, component.ErrKeyNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := json.Unmarshal(entryBytes, &e); err != nil {
+			return fmt.Errorf("failed to parse replay entry: %w", err)
+		}
+		return nil
+	})
+	return
+}
+
+// Delete removes an entry from the store, or returns ErrNotFound if it does not
+// exist.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	return s.access(ctx, func(c cache.V1) error {
+		index, err := s.readIndex(ctx, c)
+		if err != nil {
+			return err
+		}
+		newIndex := make([]Summary, 0, len(index))
+		for _, sum := range index {
+			if sum.ID != id {
+				newIndex = append(newIndex, sum)
+			}
+		}
+		if err := c.Delete(ctx, s.entryKey(id)); err != nil && !errors.Is(err, component.ErrKeyNotFound) {
+			return err
+		}
+		if len(newIndex) == len(index) {
+			return ErrNotFound
+		}
+		return s.writeIndex(ctx, c, newIndex)
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// Recorder captures the batches of an input that are rejected. A nil Recorder
+// is valid and captures nothing, which is the case when the replay store is
+// disabled.
+type Recorder struct {
+	Store *Store
+
+	// Whether batches that are captured successfully should be acknowledged
+	// rather than rejected.
+	AckCaptured bool
+
+	// The stream, label and path of the component.
+	Stream string
+	Label  string
+	Path   string
+}
+
+// Capture stores a batch of an input that was rejected with an error, and
+// returns the error that the batch should be acknowledged with, which is nil
+// when the batch was captured and AckCaptured is true.
+func (r *Recorder) Capture(ctx context.Context, batch message.Batch, typeStr string, cause error) error {
+	if r == nil || cause == nil {
+		return cause
+	}
+
+	e := Entry{
+		Summary: Summary{
+			ID:         uuid.Must(uuid.NewV4()).String(),
+			Stream:     r.Stream,
+			Label:      r.Label,
+			Path:       r.Path,
+			Type:       typeStr,
+			Error:      cause.Error(),
+			CapturedAt: time.Now(),
+			Messages:   len(batch),
+		},
+		Batch: make([]Message, len(batch)),
+	}
+	for i, p := range batch {
+		m := Message{Content: p.AsBytes()}
+		_ = p.MetaIterMut(func(k string, v any) error {
+			if m.Metadata == nil {
+				m.Metadata = map[string]any{}
+			}
+			m.Metadata[k] = message.CopyJSON(v)
+			return nil
+		})
+		e.Batch[i] = m
+	}
+
+	if err := r.Store.Put(ctx, e); err != nil {
+		return fmt.Errorf("%w (failed to capture batch for replay: %v)", cause, err)
+	}
+	if r.AckCaptured {
+		return nil
+	}
+	return cause
+}
+
+// FromManager returns the replay recorder of a manager, or nil if the manager
+// does not support replays or the replay store is disabled.
+func FromManager(mgr any) *Recorder {
+	if rm, ok := mgr.(interface{ Replay() *Recorder }); ok {
+		return rm.Replay()
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package replay_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/replay"
+)
+
+func TestStoreMaxEntries(t *testing.T) {
+	ctx := context.Background()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foo"] = map[string]mock.CacheItem{}
+
+	s := replay.NewStore(mgr, "foo", "test_", 2)
+
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, s.Put(ctx, replay.Entry{
+			Summary: replay.Summary{ID: id, Messages: 1},
+			Batch:   []replay.Message{{Content: []byte(id)}},
+		}))
+	}
+	require.NoError(t, s.Put(ctx, replay.Entry{
+		Summary: replay.Summary{ID: "d", Stream: "other"},
+	}))
+
+	summaries, err := s.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "c", summaries[0].ID)
+
+	summaries, err = s.List(ctx, "other")
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "d", summaries[0].ID)
+
+	_, err = s.Get(ctx, "a")
+	assert.ErrorIs(t, err, replay.ErrNotFound)
+
+	_, exists := mgr.Caches["foo"]["test_entry_b"]
+	assert.False(t, exists)
+
+	e, err := s.Get(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(e.Batch[0].Content))
+
+	require.NoError(t, s.Delete(ctx, "c"))
+	assert.ErrorIs(t, s.Delete(ctx, "c"), replay.ErrNotFound)
+
+	summaries, err = s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, summaries)
+}
+
+func TestRecorderCapture(t *testing.T) {
+	ctx := context.Background()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foo"] = map[string]mock.CacheItem{}
+
+	rec := &replay.Recorder{
+		Store:  replay.NewStore(mgr, "foo", "", 0),
+		Stream: "bar",
+		Label:  "baz",
+		Path:   "root.input",
+	}
+
+	part := message.NewPart([]byte("hello world"))
+	part.MetaSetMut("a", "b")
+	part.MetaSetMut("c", int64(10))
+	batch := message.Batch{part}
+
+	cause := errors.New("nope")
+	assert.Equal(t, cause, rec.Capture(ctx, batch, "generate", cause))
+	assert.NoError(t, rec.Capture(ctx, batch, "generate", nil))
+
+	rec.AckCaptured = true
+	assert.NoError(t, rec.Capture(ctx, batch, "generate", cause))
+
+	summaries, err := rec.Store.List(ctx, "bar")
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+
+	s := summaries[0]
+	assert.Equal(t, "bar", s.Stream)
+	assert.Equal(t, "baz", s.Label)
+	assert.Equal(t, "root.input", s.Path)
+	assert.Equal(t, "generate", s.Type)
+	assert.Equal(t, "nope", s.Error)
+	assert.Equal(t, 1, s.Messages)
+
+	e, err := rec.Store.Get(ctx, s.ID)
+	require.NoError(t, err)
+
+	replayed := e.ToBatch()
+	require.Len(t, replayed, 1)
+	assert.Equal(t, "hello world", string(replayed[0].AsBytes()))
+	v, _ := replayed[0].MetaGetMut("a")
+	assert.Equal(t, "b", v)
+
+	// Captures that fail must still reject the batch.
+	delete(mgr.Caches, "foo")
+	err = rec.Capture(ctx, batch, "generate", cause)
+	assert.ErrorIs(t, err, cause)
+
+	var nilRec *replay.Recorder
+	assert.Equal(t, cause, nilRec.Capture(ctx, batch, "generate", cause))
+}
@@ -0,0 +1,188 @@
+// Package secrets provides a mechanism for resolving references to secrets
+// within configs, of the form `${secret:provider:path#key}`, from pluggable
+// secret providers such as HashiCorp Vault.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Provider resolves the value of a secret at a given path, where the format of
+// the path is specific to the provider.
+type Provider interface {
+	Resolve(ctx context.Context, path string) (string, error)
+}
+
+// ProviderFunc is a closure that implements Provider.
+type ProviderFunc func(ctx context.Context, path string) (string, error)
+
+// Resolve calls the closure with the path.
+func (f ProviderFunc) Resolve(ctx context.Context, path string) (string, error) {
+	return f(ctx, path)
+}
+
+var (
+	providers    = map[string]Provider{}
+	providersMut sync.RWMutex
+)
+
+// RegisterProvider adds a secret provider under a name, which is then used for
+// resolving references of the form `${secret:name:path}`. Registering a
+// provider under an existing name replaces it.
+func RegisterProvider(name string, p Provider) {
+	providersMut.Lock()
+	providers[name] = p
+	providersMut.Unlock()
+}
+
+// ProviderNames returns the sorted names of all registered providers.
+func ProviderNames() []string {
+	providersMut.RLock()
+	names := make([]string, 0, len(providers))
+	for k := range providers {
+		names = append(names, k)
+	}
+	providersMut.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+func getProvider(name string) (Provider, bool) {
+	providersMut.RLock()
+	p, exists := providers[name]
+	providersMut.RUnlock()
+	return p, exists
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	refRegex      = regexp.MustCompile(`\${secret:([0-9A-Za-z_]+):([^}#]+)(#([^}]+))?}`)
+	exactRefRegex = regexp.MustCompile(`^` + refRegex.String() + `$`)
+)
+
+// IsReference returns true if the provided bytes are exactly a secret
+// reference.
+func IsReference(b []byte) bool {
+	return exactRefRegex.Match(b)
+}
+
+// Reference describes a secret referenced within a config.
+type Reference struct {
+	Provider string
+	Path     string
+	Key      string
+}
+
+// String returns the reference in its config form.
+func (r Reference) String() string {
+	s := "${secret:" + r.Provider + ":" + r.Path
+	if r.Key != "" {
+		s += "#" + r.Key
+	}
+	return s + "}"
+}
+
+// ParseReferences returns all unique secret references found within a blob of
+// config data, in the order in which they first appear.
+func ParseReferences(inBytes []byte) []Reference {
+	var refs []Reference
+	seen := map[Reference]struct{}{}
+	for _, m := range refRegex.FindAllSubmatch(inBytes, -1) {
+		ref := Reference{
+			Provider: string(m[1]),
+			Path:     string(m[2]),
+			Key:      string(m[4]),
+		}
+		if _, exists := seen[ref]; exists {
+			continue
+		}
+		seen[ref] = struct{}{}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// Resolve the value of a secret reference. When the reference contains a key
+// the secret is expected to be a JSON object and the value of that key is
+// returned.
+func Resolve(ctx context.Context, ref Reference) (string, error) {
+	p, exists := getProvider(ref.Provider)
+	if !exists {
+		return "", fmt.Errorf("secret provider %v not recognised, expected one of: %v", ref.Provider, ProviderNames())
+	}
+
+	value, err := p.Resolve(ctx, ref.Path)
+	if err != nil {
+		return "", err
+	}
+	if ref.Key == "" {
+		return value, nil
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(value), &obj); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object and therefore key %v cannot be extracted: %w", ref.Key, err)
+	}
+	v, exists := obj[ref.Key]
+	if !exists {
+		return "", fmt.Errorf("key %v not found in secret", ref.Key)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// ResolveAll resolves a slice of references, returning the values of each
+// keyed by their config form.
+func ResolveAll(ctx context.Context, refs []Reference) (map[string]string, error) {
+	values := make(map[string]string, len(refs))
+	var errs []error
+	for _, ref := range refs {
+		v, err := Resolve(ctx, ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to resolve secret %v: %w", ref, err))
+			continue
+		}
+		values[ref.String()] = v
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return values, nil
+}
+
+// Replace resolves all secret references found within a blob of config data
+// and replaces them with their values. The resolved values are also returned
+// keyed by the reference, which can be used in order to detect when secrets
+// have changed.
+func Replace(ctx context.Context, inBytes []byte) ([]byte, map[string]string, error) {
+	refs := ParseReferences(inBytes)
+	if len(refs) == 0 {
+		return inBytes, nil, nil
+	}
+
+	values, err := ResolveAll(ctx, refs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	replaced := refRegex.ReplaceAllFunc(inBytes, func(content []byte) []byte {
+		// Escape newlines, otherwise there's no way that they would work
+		// within a config.
+		return []byte(strings.ReplaceAll(values[string(content)], "\n", "\\n"))
+	})
+	return replaced, values, nil
+}
@@ -0,0 +1,125 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReferences(t *testing.T) {
+	refs := ParseReferences([]byte(`
+a: ${secret:vault:kv/data/kafka#password}
+b: ${secret:aws:prod/db}
+c: ${secret:vault:kv/data/kafka#password}
+d: ${FOO:bar}
+e: ${secret:nope}
+`))
+	assert.Equal(t, []Reference{
+		{Provider: "vault", Path: "kv/data/kafka", Key: "password"},
+		{Provider: "aws", Path: "prod/db"},
+	}, refs)
+	assert.Equal(t, "${secret:vault:kv/data/kafka#password}", refs[0].String())
+	assert.Equal(t, "${secret:aws:prod/db}", refs[1].String())
+
+	assert.True(t, IsReference([]byte("${secret:aws:prod/db}")))
+	assert.False(t, IsReference([]byte("${secret:nope}")))
+	assert.False(t, IsReference([]byte("foo ${secret:aws:prod/db}")))
+}
+
+func TestReplace(t *testing.T) {
+	RegisterProvider("secrets_test", ProviderFunc(func(ctx context.Context, path string) (string, error) {
+		switch path {
+		case "plain":
+			return "hello\nworld", nil
+		case "obj":
+			return `{"user":"foo","port":1234}`, nil
+		}
+		return "", errors.New("not found")
+	}))
+
+	tests := []struct {
+		name        string
+		input       string
+		output      string
+		errContains string
+	}{
+		{name: "no refs", input: "foo: ${BAR}", output: "foo: ${BAR}"},
+		{name: "plain", input: "foo: ${secret:secrets_test:plain}", output: `foo: hello\nworld`},
+		{name: "keys", input: "foo: ${secret:secrets_test:obj#user}:${secret:secrets_test:obj#port}", output: "foo: foo:1234"},
+		{name: "missing key", input: "foo: ${secret:secrets_test:obj#nope}", errContains: "key nope not found in secret"},
+		{name: "not an object", input: "foo: ${secret:secrets_test:plain#nope}", errContains: "secret is not a JSON object"},
+		{name: "provider error", input: "foo: ${secret:secrets_test:nope}", errContains: "failed to resolve secret ${secret:secrets_test:nope}: not found"},
+		{name: "unknown provider", input: "foo: ${secret:secrets_nope:nope}", errContains: "secret provider secrets_nope not recognised"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			out, _, err := Replace(context.Background(), []byte(test.input))
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(out))
+		})
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "footoken" {
+			http.Error(w, "nope", http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "ns1", r.Header.Get("X-Vault-Namespace"))
+		switch r.URL.Path {
+		case "/v1/kv/data/kafka":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"foopass"},"metadata":{"version":1}}}`))
+		case "/v1/secret/kafka":
+			_, _ = w.Write([]byte(`{"data":{"password":"barpass"}}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	env := map[string]string{
+		"VAULT_ADDR":      server.URL,
+		"VAULT_TOKEN":     "footoken",
+		"VAULT_NAMESPACE": "ns1",
+	}
+	RegisterProvider("vault_test", &vaultProvider{
+		client: server.Client(),
+		lookupEnv: func(k string) (string, bool) {
+			v, ok := env[k]
+			return v, ok
+		},
+		homeDir: func() (string, error) {
+			return t.TempDir(), nil
+		},
+	})
+
+	v, err := Resolve(context.Background(), Reference{Provider: "vault_test", Path: "kv/data/kafka", Key: "password"})
+	require.NoError(t, err)
+	assert.Equal(t, "foopass", v)
+
+	v, err = Resolve(context.Background(), Reference{Provider: "vault_test", Path: "secret/kafka", Key: "password"})
+	require.NoError(t, err)
+	assert.Equal(t, "barpass", v)
+
+	_, err = Resolve(context.Background(), Reference{Provider: "vault_test", Path: "kv/data/nope"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "vault responded with status 404")
+
+	env["VAULT_TOKEN"] = ""
+	_, err = Resolve(context.Background(), Reference{Provider: "vault_test", Path: "kv/data/kafka"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a token must be provided")
+}
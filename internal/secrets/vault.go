@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterProvider("vault", &vaultProvider{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		lookupEnv: os.LookupEnv,
+		homeDir:   os.UserHomeDir,
+	})
+}
+
+// vaultProvider resolves secrets from the HTTP API of a HashiCorp Vault server,
+// where the path is that of the secret, e.g. `kv/data/foo` for the KV version 2
+// secrets engine mounted at `kv`.
+//
+// The address of the server, the token and the namespace are obtained from the
+// standard environment variables `VAULT_ADDR`, `VAULT_TOKEN` and
+// `VAULT_NAMESPACE`. When `VAULT_TOKEN` is not set the token is read from the
+// file `~/.vault-token`, which is where the Vault CLI and agent write it.
+type vaultProvider struct {
+	client    *http.Client
+	lookupEnv func(string) (string, bool)
+	homeDir   func() (string, error)
+}
+
+func (v *vaultProvider) token() (string, error) {
+	if t, _ := v.lookupEnv("VAULT_TOKEN"); t != "" {
+		return t, nil
+	}
+	home, err := v.homeDir()
+	if err != nil {
+		return "", errors.New("a token must be provided with VAULT_TOKEN or ~/.vault-token")
+	}
+	b, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+	if err != nil {
+		return "", errors.New("a token must be provided with VAULT_TOKEN or ~/.vault-token")
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (v *vaultProvider) Resolve(ctx context.Context, path string) (string, error) {
+	addr, _ := v.lookupEnv("VAULT_ADDR")
+	if addr == "" {
+		addr = "https://127.0.0.1:8200"
+	}
+	token, err := v.token()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns, _ := v.lookupEnv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault responded with status %v: %s", res.StatusCode, resBytes)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(resBytes, &body); err != nil {
+		return "", fmt.Errorf("failed to parse vault response: %w", err)
+	}
+
+	// Secrets of the KV version 2 engine are nested within a further data
+	// field alongside metadata.
+	data := body.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, hasMeta := data["metadata"]; hasMeta {
+			data = nested
+		}
+	}
+	if data == nil {
+		return "", errors.New("vault response did not contain any data")
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
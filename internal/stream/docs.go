@@ -11,6 +11,11 @@ func Spec() docs.FieldSpecs {
 		docs.FieldBuffer("buffer", "An optional buffer to store messages during transit.").Optional(),
 		docs.FieldObject("pipeline", "Describes optional processing pipelines used for mutating messages.").WithChildren(
 			docs.FieldInt("threads", "The number of threads to execute processing pipelines across.").HasDefault(-1),
+			docs.FieldObject("sharding", "Distribute message batches across the threads of the pipeline by a hash of a key, where each thread consumes from a dedicated queue. Batches that resolve to the same key are always processed by the same thread and therefore in order, and on machines with many cores sharding reduces the contention of threads consuming from a single shared queue.").WithChildren(
+				docs.FieldInterpolatedString("key", "An interpolated key resolved from the first message of each batch, when empty sharding is disabled.", `${! meta("kafka_key") }`, `${! json("user.id") }`).HasDefault(""),
+				docs.FieldInt("queue_size", "The number of message batches that can be queued for each thread before the dispatching of further batches is blocked.").HasDefault(64),
+				docs.FieldBool("pin_threads", "Whether each thread should be locked to an OS thread that is pinned to a dedicated CPU. This is only supported on Linux and is ignored on other platforms.").HasDefault(false),
+			).Advanced().AtVersion("4.20.0"),
 			docs.FieldProcessor("processors", "A list of processors to apply to messages.").Array().HasDefault([]any{}),
 		),
 		docs.FieldOutput("output", "An output to sink messages to.").Optional(),
@@ -0,0 +1,180 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// OptDrainTimeouts sets the maximum periods of time to wait for each phase of
+// a graceful shutdown of the stream, where the input phase stops inputs from
+// consuming and the flush phase waits for buffers, processors and outputs to
+// deliver any messages in flight. A timeout of zero means the phase is limited
+// only by the context provided to the shutdown.
+func OptDrainTimeouts(input, flush time.Duration) func(*Type) {
+	return func(t *Type) {
+		t.drainInputTimeout = input
+		t.drainFlushTimeout = flush
+	}
+}
+
+// inFlightCounter forwards transactions from an input, along with any
+// transactions injected into the stream, whilst counting the messages that
+// have been consumed but not yet acknowledged. The forwarding is also the point
+// at which rejected batches are captured for replay and replayed batches are
+// injected, and so it takes place whether or not a drain is requested.
+//
+// Buffers acknowledge messages once they've been written, and therefore
+// messages held within a buffer are not counted. Buffers such as windows can
+// combine many messages into one, and so their contents cannot be counted by
+// the messages that come out of them either.
+type inFlightCounter struct {
+	count  int64
+	inject chan message.Transaction
+
+	// onReject is called with batches from the input that are rejected, and
+	// returns the error that the input should receive in their place.
+	onReject func(ctx context.Context, batch message.Batch, err error) error
+
+	closeCh chan struct{}
+	doneCh  chan struct{}
+}
+
+func newInFlightCounter() *inFlightCounter {
+	return &inFlightCounter{
+		inject:  make(chan message.Transaction),
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+}
+
+func (f *inFlightCounter) Count() int64 {
+	return atomic.LoadInt64(&f.count)
+}
+
+func (f *inFlightCounter) track(in <-chan message.Transaction) <-chan message.Transaction {
+	out := make(chan message.Transaction)
+	go func() {
+		defer close(f.doneCh)
+		defer close(out)
+		for {
+			var tran message.Transaction
+			var open, injected bool
+			select {
+			case tran, open = <-in:
+				if !open {
+					return
+				}
+			case tran = <-f.inject:
+				injected = true
+			case <-f.closeCh:
+				return
+			}
+
+			n := int64(tran.Payload.Len())
+			atomic.AddInt64(&f.count, n)
+
+			ackFn, payload := tran.Ack, tran.Payload
+			tracked := message.NewTransactionFunc(payload, func(ctx context.Context, err error) error {
+				atomic.AddInt64(&f.count, -n)
+				if err != nil && !injected && f.onReject != nil {
+					err = f.onReject(ctx, payload, err)
+				}
+				return ackFn(ctx, err)
+			})
+
+			select {
+			case out <- *tracked.WithContext(tran.Context()):
+			case <-f.closeCh:
+				atomic.AddInt64(&f.count, -n)
+				_ = tran.Ack(context.Background(), context.Canceled)
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// closeNow stops forwarding transactions, which is only necessary when the
+// downstream components are being closed ungracefully.
+func (f *inFlightCounter) closeNow() {
+	select {
+	case <-f.closeCh:
+	default:
+		close(f.closeCh)
+	}
+}
+
+// InFlight returns the number of messages that have been consumed by the input
+// of the stream but have not yet been acknowledged. Messages are acknowledged
+// by a buffer once they've been written to it, and so messages held within a
+// buffer are not included.
+func (t *Type) InFlight() int64 {
+	return t.inFlight.Count()
+}
+
+//------------------------------------------------------------------------------
+
+// DrainResult describes the outcome of draining a stream, where Unflushed is
+// the number of messages returned by InFlight once the drain has finished.
+type DrainResult struct {
+	Drained   bool   `json:"drained"`
+	Unflushed int64  `json:"unflushed"`
+	Duration  string `json:"duration"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Drain stops the input of the stream from consuming and waits for all
+// messages in flight to be flushed through the buffer, pipeline and output
+// layers, after which the stream terminates. Unlike Stop the remaining
+// components are not closed forcefully when the context is cancelled.
+func (t *Type) Drain(ctx context.Context) DrainResult {
+	started := time.Now()
+	err := t.StopGracefully(ctx)
+	res := DrainResult{
+		Drained:   err == nil,
+		Unflushed: t.InFlight(),
+		Duration:  time.Since(started).String(),
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+func (t *Type) drainHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed, a drain must be a POST request", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	if toutStr := r.URL.Query().Get("timeout"); toutStr != "" {
+		tout, err := time.ParseDuration(toutStr)
+		if err != nil {
+			http.Error(w, "Failed to parse timeout: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var done func()
+		ctx, done = context.WithTimeout(ctx, tout)
+		defer done()
+	}
+
+	t.manager.Logger().Infoln("Draining stream due to a request to the /drain endpoint")
+	res := t.Drain(ctx)
+	if res.Drained {
+		t.manager.Logger().Infof("Stream drained in %v", res.Duration)
+	} else {
+		t.manager.Logger().Warnf("Stream failed to drain with %v messages unflushed: %v", res.Unflushed, res.Error)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !res.Drained {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(res)
+}
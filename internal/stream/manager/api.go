@@ -30,7 +30,7 @@ import (
 func (m *Type) registerEndpoints(enableCrud bool) {
 	m.manager.RegisterEndpoint(
 		"/ready",
-		"Returns 200 OK if the inputs and outputs of all running streams are connected, otherwise a 503 is returned. If there are no active streams 200 is returned.",
+		"Returns 200 OK if the inputs and outputs of all running streams are connected, otherwise a 503 is returned. If there are no active streams 200 is returned. When the query parameter `detailed=true` is set a JSON object is returned describing the readiness of each stream along with the connection state, last error and time since the last successful message of each of their inputs and outputs.",
 		m.HandleStreamReady,
 	)
 	if !enableCrud {
@@ -41,6 +41,11 @@ func (m *Type) registerEndpoints(enableCrud bool) {
 		"POST: Create or replace a given resource configuration of a specified type. Types supported are `cache`, `input`, `output`, `processor` and `rate_limit`.",
 		m.HandleResourceCRUD,
 	)
+	m.manager.RegisterEndpoint(
+		"/streams/{id}/ready",
+		"GET a structured JSON object describing the readiness of the stream along with the connection state, last error and time since the last successful message of each of its inputs and outputs. Returns a 503 if the stream is not ready.",
+		m.HandleStreamReadyByID,
+	)
 	m.manager.RegisterEndpoint(
 		"/streams/{id}/stats",
 		"GET a structured JSON object containing metrics for the stream.",
@@ -60,6 +65,9 @@ func (m *Type) registerEndpoints(enableCrud bool) {
 			" streams will be replaced by this new set.",
 		m.HandleStreamsCRUD,
 	)
+	if m.dashboard {
+		m.registerDashboardEndpoints()
+	}
 }
 
 // ConfigSet is a map of stream configurations mapped by ID, which can be YAML
@@ -90,6 +98,7 @@ type lintErrors struct {
 func (m *Type) lintCtx() docs.LintContext {
 	lConf := docs.NewLintConfig()
 	lConf.BloblangEnv = bloblang.XWrapEnvironment(m.manager.BloblEnvironment()).Deactivated()
+	lConf.Rules = m.lintRules
 	return docs.NewLintContext(lConf)
 }
 
@@ -186,11 +195,24 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Singleton streams run by other instances also exist.
+	existing := map[string]struct{}{}
+	for id := range infos {
+		existing[id] = struct{}{}
+	}
+	var singletonIDs []string
+	if singletonIDs, serverErr = m.storedSingletonIDs(r.Context()); serverErr != nil {
+		return
+	}
+	for _, id := range singletonIDs {
+		existing[id] = struct{}{}
+	}
+
 	toDelete := []string{}
 	toUpdate := map[string]stream.Config{}
 	toCreate := map[string]stream.Config{}
 
-	for id := range infos {
+	for id := range existing {
 		if newConf, exists := newSet[id]; !exists {
 			toDelete = append(toDelete, id)
 		} else {
@@ -198,7 +220,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	for id, conf := range newSet {
-		if _, exists := infos[id]; !exists {
+		if _, exists := existing[id]; !exists {
 			toCreate[id] = conf
 		}
 	}
@@ -214,7 +236,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 
 	for i, id := range toDelete {
 		go func(sid string, j int) {
-			errDelete[j] = m.Delete(r.Context(), sid)
+			errDelete[j] = m.deleteStream(r.Context(), sid)
 			wg.Done()
 		}(id, i)
 	}
@@ -222,7 +244,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	for id, conf := range toUpdate {
 		newConf := conf
 		go func(sid string, sconf *stream.Config, j int) {
-			errUpdate[j] = m.Update(r.Context(), sid, *sconf)
+			errUpdate[j] = m.updateStream(r.Context(), sid, *sconf)
 			wg.Done()
 		}(id, &newConf, i)
 		i++
@@ -231,7 +253,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	for id, conf := range toCreate {
 		newConf := conf
 		go func(sid string, sconf *stream.Config, j int) {
-			errCreate[j] = m.Create(sid, *sconf)
+			errCreate[j] = m.createStream(r.Context(), sid, *sconf)
 			wg.Done()
 		}(id, &newConf, i)
 		i++
@@ -371,7 +393,7 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			_, _ = w.Write(errBytes)
 			return
 		}
-		serverErr = m.Create(id, conf)
+		serverErr = m.createStream(r.Context(), id, conf)
 	case "GET":
 		var info *StreamStatus
 		if info, serverErr = m.Read(id); serverErr == nil {
@@ -408,16 +430,16 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			_, _ = w.Write(errBytes)
 			return
 		}
-		serverErr = m.Update(r.Context(), id, conf)
+		serverErr = m.updateStream(r.Context(), id, conf)
 	case "DELETE":
-		serverErr = m.Delete(r.Context(), id)
+		serverErr = m.deleteStream(r.Context(), id)
 	case "PATCH":
 		var info *StreamStatus
 		if info, serverErr = m.Read(id); serverErr == nil {
 			if conf, requestErr = patchConfig(info.Config()); requestErr != nil {
 				return
 			}
-			serverErr = m.Update(r.Context(), id, conf)
+			serverErr = m.updateStream(r.Context(), id, conf)
 		}
 	default:
 		requestErr = fmt.Errorf("verb not supported: %v", r.Method)
@@ -629,9 +651,19 @@ func (m *Type) HandleStreamStats(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type streamReadyStatus struct {
+	Running bool `json:"running"`
+	stream.ReadyStatus
+}
+
 // HandleStreamReady is an http.HandleFunc for providing a ready check across
 // all streams.
 func (m *Type) HandleStreamReady(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("detailed") == "true" {
+		m.handleStreamReadyDetailed(w)
+		return
+	}
+
 	var notReady []string
 
 	m.lock.Lock()
@@ -650,3 +682,64 @@ func (m *Type) HandleStreamReady(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusServiceUnavailable)
 	fmt.Fprintf(w, "streams %v are not connected\n", strings.Join(notReady, ", "))
 }
+
+func (m *Type) handleStreamReadyDetailed(w http.ResponseWriter) {
+	ready := true
+	streams := map[string]streamReadyStatus{}
+
+	m.lock.Lock()
+	for k, v := range m.streams {
+		status := streamReadyStatus{
+			Running:     v.IsRunning(),
+			ReadyStatus: v.ReadyStatus(),
+		}
+		if !status.Ready && status.Running {
+			ready = false
+		}
+		streams[k] = status
+	}
+	m.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ready":   ready,
+		"streams": streams,
+	})
+}
+
+// HandleStreamReadyByID is an http.HandleFunc for providing a detailed ready
+// check of a single stream.
+func (m *Type) HandleStreamReadyByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not supported", http.StatusBadRequest)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		http.Error(w, "Var `id` must be set", http.StatusBadRequest)
+		return
+	}
+
+	m.lock.Lock()
+	strm, exists := m.streams[id]
+	m.lock.Unlock()
+	if !exists {
+		http.Error(w, "Stream not found", http.StatusNotFound)
+		return
+	}
+
+	status := streamReadyStatus{
+		Running:     strm.IsRunning(),
+		ReadyStatus: strm.ReadyStatus(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(status)
+}
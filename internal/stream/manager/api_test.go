@@ -20,11 +20,13 @@ import (
 	"github.com/stretchr/testify/require"
 	yaml "gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	bmanager "github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 	"github.com/benthosdev/benthos/v4/internal/stream/manager"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
 
 	_ "github.com/benthosdev/benthos/v4/public/components/io"
 	_ "github.com/benthosdev/benthos/v4/public/components/pure"
@@ -36,7 +38,12 @@ func router(m *manager.Type) *mux.Router {
 	router.HandleFunc("/streams", m.HandleStreamsCRUD)
 	router.HandleFunc("/streams/{id}", m.HandleStreamCRUD)
 	router.HandleFunc("/streams/{id}/stats", m.HandleStreamStats)
+	router.HandleFunc("/streams/{id}/ready", m.HandleStreamReadyByID)
 	router.HandleFunc("/resources/{type}/{id}", m.HandleResourceCRUD)
+	router.HandleFunc("/dashboard", m.HandleDashboard)
+	router.HandleFunc("/dashboard/state", m.HandleDashboardState)
+	router.HandleFunc("/dashboard/streams/{id}/config", m.HandleDashboardConfig)
+	router.HandleFunc("/dashboard/lint", m.HandleDashboardLint)
 	return router
 }
 
@@ -703,6 +710,45 @@ func TestTypeAPILinting(t *testing.T) {
 	assert.Equal(t, http.StatusOK, response.Code)
 }
 
+func TestTypeAPILintRules(t *testing.T) {
+	res, err := bmanager.New(bmanager.NewResourceConfig())
+	require.NoError(t, err)
+
+	rules, err := docs.ParseLintRules(bloblang.GlobalEnvironment(), []byte(`
+rules:
+  - name: labelled_outputs
+    description: outputs must be labelled
+    type: output
+    check: this.label.or("") != ""
+`))
+	require.NoError(t, err)
+
+	mgr := manager.New(res, manager.OptLintRules(rules))
+
+	r := router(mgr)
+
+	body := []byte(`{
+	"input": {
+		"generate": {
+			"mapping": "root = deleted()"
+		}
+	},
+	"output": {
+		"drop": {}
+	}
+}`)
+
+	request, err := http.NewRequest("POST", "/streams/foo", bytes.NewReader(body))
+	require.NoError(t, err)
+
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	expLints := `{"lint_errors":["(7,1) rule labelled_outputs failed: outputs must be labelled"]}`
+	assert.Equal(t, expLints, response.Body.String())
+}
+
 func TestResourceAPILinting(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -842,6 +888,106 @@ func TestTypeAPIGetStats(t *testing.T) {
 	assert.Greater(t, len(stats.ChildrenMap()), 0, response.Body.String())
 }
 
+func TestTypeAPIDashboardEnabled(t *testing.T) {
+	r := &endpointReg{endpoints: map[string]http.HandlerFunc{}}
+	rMgr, err := bmanager.New(bmanager.NewResourceConfig(), bmanager.OptSetAPIReg(r))
+	require.NoError(t, err)
+
+	_ = manager.New(rMgr, manager.OptAPIEnabled(true))
+	assert.NotContains(t, r.endpoints, "/dashboard")
+
+	r = &endpointReg{endpoints: map[string]http.HandlerFunc{}}
+	rMgr, err = bmanager.New(bmanager.NewResourceConfig(), bmanager.OptSetAPIReg(r))
+	require.NoError(t, err)
+
+	_ = manager.New(rMgr, manager.OptAPIEnabled(true), manager.OptDashboard(true))
+	assert.Contains(t, r.endpoints, "/dashboard")
+	assert.Contains(t, r.endpoints, "/dashboard/state")
+
+	r = &endpointReg{endpoints: map[string]http.HandlerFunc{}}
+	rMgr, err = bmanager.New(bmanager.NewResourceConfig(), bmanager.OptSetAPIReg(r))
+	require.NoError(t, err)
+
+	_ = manager.New(rMgr, manager.OptAPIEnabled(false), manager.OptDashboard(true))
+	assert.NotContains(t, r.endpoints, "/dashboard")
+}
+
+func TestTypeAPIDashboard(t *testing.T) {
+	mgr, err := bmanager.New(bmanager.NewResourceConfig())
+	require.NoError(t, err)
+
+	smgr := manager.New(mgr)
+
+	r := router(smgr)
+
+	origConf := stream.NewConfig()
+	origConf.Input.Type = "generate"
+	origConf.Input.Generate.Mapping = "root = deleted()"
+	origConf.Output.Type = "drop"
+
+	require.NoError(t, smgr.Create("foo", origConf))
+
+	request := genRequest("GET", "/dashboard", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "<html")
+
+	request = genRequest("GET", "/dashboard/state", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code)
+
+	state, err := gabs.ParseJSON(response.Body.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, true, state.S("streams", "foo", "active").Data(), response.Body.String())
+	assert.NotNil(t, state.S("streams", "foo", "counts", "received").Data(), response.Body.String())
+
+	request = genRequest("GET", "/dashboard/streams/not_exist/config", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	request = genRequest("GET", "/dashboard/streams/foo/config", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var conf map[string]any
+	require.NoError(t, yaml.Unmarshal(response.Body.Bytes(), &conf))
+	assert.Equal(t, "root = deleted()", gabs.Wrap(conf).S("input", "generate", "mapping").Data())
+
+	request = genRequest("POST", "/dashboard/lint", `
+input:
+  generate:
+    mapping: root = deleted()
+output:
+  drop: {}
+`)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, `{"lint_errors":[]}`, strings.TrimSpace(response.Body.String()))
+
+	request = genRequest("POST", "/dashboard/lint", `
+input:
+  generate:
+    mapping: root = deleted()
+output:
+  drop: {}
+  nope: true
+`)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "field nope")
+
+	request = genRequest("GET", "/dashboard/lint", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
 func TestTypeAPISetResources(t *testing.T) {
 	bmgr, err := bmanager.New(bmanager.NewResourceConfig())
 	require.NoError(t, err)
@@ -980,3 +1126,121 @@ output:
 		return response.Code == http.StatusServiceUnavailable
 	}, time.Second*10, time.Millisecond*50)
 }
+
+func TestAPIReadyDetailed(t *testing.T) {
+	res, err := bmanager.New(bmanager.NewResourceConfig())
+	require.NoError(t, err)
+
+	mgr := manager.New(res)
+
+	r := router(mgr)
+
+	request := genRequest("POST", "/streams/foo", `
+input:
+  label: foo_in
+  generate:
+    mapping: 'root = {}'
+    interval: 1ms
+
+output:
+  label: foo_out
+  drop: {}
+`)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	request = genRequest("POST", "/streams/bar", `
+input:
+  generate:
+    mapping: 'root = {}'
+    interval: 1ms
+
+output:
+  websocket:
+    url: not**a**valid**url
+`)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	type componentStatus struct {
+		Label            string `json:"label"`
+		Path             string `json:"path"`
+		Kind             string `json:"kind"`
+		Type             string `json:"type"`
+		Connected        bool   `json:"connected"`
+		LastError        string `json:"last_error"`
+		SinceLastSuccess string `json:"since_last_success"`
+	}
+	type streamStatus struct {
+		Ready      bool              `json:"ready"`
+		Running    bool              `json:"running"`
+		Components []componentStatus `json:"components"`
+	}
+
+	var fooStatus streamStatus
+	assert.Eventually(t, func() bool {
+		request = genRequest("GET", "/streams/foo/ready", nil)
+		response = httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+		if response.Code != http.StatusOK {
+			return false
+		}
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &fooStatus))
+		for _, c := range fooStatus.Components {
+			if c.SinceLastSuccess == "" {
+				return false
+			}
+		}
+		return true
+	}, time.Second*10, time.Millisecond*50)
+
+	require.Len(t, fooStatus.Components, 2)
+	assert.True(t, fooStatus.Ready)
+	assert.True(t, fooStatus.Running)
+	assert.Equal(t, "foo_in", fooStatus.Components[0].Label)
+	assert.Equal(t, "root.input", fooStatus.Components[0].Path)
+	assert.Equal(t, "input", fooStatus.Components[0].Kind)
+	assert.Equal(t, "generate", fooStatus.Components[0].Type)
+	assert.True(t, fooStatus.Components[0].Connected)
+	assert.Equal(t, "foo_out", fooStatus.Components[1].Label)
+	assert.Equal(t, "root.output", fooStatus.Components[1].Path)
+	assert.Equal(t, "output", fooStatus.Components[1].Kind)
+	assert.Equal(t, "drop", fooStatus.Components[1].Type)
+
+	var allStatus struct {
+		Ready   bool                    `json:"ready"`
+		Streams map[string]streamStatus `json:"streams"`
+	}
+	assert.Eventually(t, func() bool {
+		request = genRequest("GET", "/ready?detailed=true", nil)
+		response = httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+		if response.Code != http.StatusServiceUnavailable {
+			return false
+		}
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &allStatus))
+		for _, c := range allStatus.Streams["bar"].Components {
+			if c.Kind == "output" && c.LastError != "" {
+				return true
+			}
+		}
+		return false
+	}, time.Second*10, time.Millisecond*50)
+
+	assert.False(t, allStatus.Ready)
+	assert.True(t, allStatus.Streams["foo"].Ready)
+	assert.False(t, allStatus.Streams["bar"].Ready)
+	for _, c := range allStatus.Streams["bar"].Components {
+		if c.Kind == "output" {
+			assert.False(t, c.Connected)
+			assert.Equal(t, "websocket", c.Type)
+		}
+	}
+
+	request = genRequest("GET", "/streams/baz/ready", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
@@ -0,0 +1,187 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/health"
+
+	_ "embed"
+)
+
+//go:embed resources/dashboard.html
+var dashboardPage []byte
+
+func (m *Type) registerDashboardEndpoints() {
+	m.manager.RegisterEndpoint(
+		"/dashboard",
+		"A web dashboard for viewing the status, throughput and errors of streams, and for editing their configs.",
+		m.HandleDashboard,
+	)
+	m.manager.RegisterEndpoint(
+		"/dashboard/state",
+		"GET a structured JSON object describing the status, message counts and component health of all streams, which is used by the dashboard.",
+		m.HandleDashboardState,
+	)
+	m.manager.RegisterEndpoint(
+		"/dashboard/streams/{id}/config",
+		"GET the config of a stream as YAML, which is used by the dashboard.",
+		m.HandleDashboardConfig,
+	)
+	m.manager.RegisterEndpoint(
+		"/dashboard/lint",
+		"POST a stream config as YAML and receive a JSON object containing any lint errors, without creating or modifying a stream.",
+		m.HandleDashboardLint,
+	)
+}
+
+// HandleDashboard is an http.HandleFunc that serves the dashboard page.
+func (m *Type) HandleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not supported", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(dashboardPage)
+}
+
+type dashboardCounts struct {
+	Received        int64 `json:"received"`
+	Sent            int64 `json:"sent"`
+	OutputErrors    int64 `json:"output_errors"`
+	ProcessorErrors int64 `json:"processor_errors"`
+}
+
+type dashboardStream struct {
+	Active     bool            `json:"active"`
+	Ready      bool            `json:"ready"`
+	Uptime     float64         `json:"uptime"`
+	UptimeStr  string          `json:"uptime_str"`
+	Counts     dashboardCounts `json:"counts"`
+	Components []health.Status `json:"components"`
+}
+
+// dashboardCountsFrom sums the counters of a stream by their name, ignoring
+// labels.
+func dashboardCountsFrom(counters map[string]int64) (c dashboardCounts) {
+	for k, v := range counters {
+		name, _, _ := strings.Cut(k, "{")
+		switch name {
+		case "input_received":
+			c.Received += v
+		case "output_sent":
+			c.Sent += v
+		case "output_error":
+			c.OutputErrors += v
+		case "processor_error":
+			c.ProcessorErrors += v
+		}
+	}
+	return
+}
+
+// HandleDashboardState is an http.HandleFunc that returns the status, message
+// counts and component health of all streams.
+func (m *Type) HandleDashboardState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not supported", http.StatusBadRequest)
+		return
+	}
+
+	streams := map[string]dashboardStream{}
+
+	m.lock.Lock()
+	for id, s := range m.streams {
+		ready := s.ReadyStatus()
+		streams[id] = dashboardStream{
+			Active:     s.IsRunning(),
+			Ready:      ready.Ready,
+			Uptime:     s.Uptime().Seconds(),
+			UptimeStr:  s.Uptime().String(),
+			Counts:     dashboardCountsFrom(s.Metrics().GetCounters()),
+			Components: ready.Components,
+		}
+	}
+	m.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"streams": streams,
+	})
+}
+
+// HandleDashboardConfig is an http.HandleFunc that returns the config of a
+// stream as YAML.
+func (m *Type) HandleDashboardConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not supported", http.StatusBadRequest)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		http.Error(w, "Var `id` must be set", http.StatusBadRequest)
+		return
+	}
+
+	info, err := m.Read(id)
+	if err == ErrStreamDoesNotExist {
+		http.Error(w, "Stream not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	sanit, err := info.Config().Sanitised()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	confBytes, err := yaml.Marshal(sanit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(confBytes)
+}
+
+// HandleDashboardLint is an http.HandleFunc that lints a stream config without
+// applying it.
+func (m *Type) HandleDashboardLint(w http.ResponseWriter, r *http.Request) {
+	if r.Body != nil {
+		defer r.Body.Close()
+	}
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not supported", http.StatusBadRequest)
+		return
+	}
+
+	confBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	lints := []string{}
+	var node yaml.Node
+	if err := yaml.Unmarshal(confBytes, &node); err != nil {
+		lints = append(lints, err.Error())
+	} else {
+		lints = append(lints, m.lintStreamConfigNode(&node)...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(lintErrors{LintErrs: lints})
+}
@@ -0,0 +1,346 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+	"github.com/benthosdev/benthos/v4/internal/stream/manager/managementpb"
+)
+
+const grpcDefaultStatsInterval = time.Second
+
+// GRPCServer implements the gRPC management service of a stream manager, which
+// mirrors the streams, stats and ready endpoints of the HTTP API.
+type GRPCServer struct {
+	managementpb.UnimplementedManagementServer
+
+	m *Type
+}
+
+// NewGRPCServer returns a gRPC management service for a stream manager.
+func NewGRPCServer(m *Type) *GRPCServer {
+	return &GRPCServer{m: m}
+}
+
+func grpcErr(err error) error {
+	switch {
+	case errors.Is(err, ErrStreamDoesNotExist):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrStreamExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, component.ErrTypeClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// requireAPI returns a PERMISSION_DENIED error when the streams API is
+// disabled, in which case only the readiness of streams is served, mirroring
+// the HTTP API.
+func (s *GRPCServer) requireAPI() error {
+	if !s.m.apiEnabled {
+		return status.Error(codes.PermissionDenied, "the streams API is disabled")
+	}
+	return nil
+}
+
+func grpcRequireID(id string) error {
+	if id == "" {
+		return status.Error(codes.InvalidArgument, "field `id` must be set")
+	}
+	return nil
+}
+
+// readConfig parses the config of a stream from a request, returning an
+// INVALID_ARGUMENT error with the lints as details if it has linting errors and
+// chilled is false.
+func (s *GRPCServer) readConfig(id, confStr string, chilled bool) (stream.Config, error) {
+	conf := stream.NewConfig()
+
+	confBytes, err := config.ReplaceEnvVariables([]byte(confStr), os.LookupEnv)
+	if err != nil {
+		var errEnvMissing *config.ErrMissingEnvVars
+		if !chilled || !errors.As(err, &errEnvMissing) {
+			return conf, status.Error(codes.InvalidArgument, err.Error())
+		}
+		confBytes = errEnvMissing.BestAttempt
+	}
+
+	if !chilled {
+		var node yaml.Node
+		if err := yaml.Unmarshal(confBytes, &node); err != nil {
+			return conf, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if lints := s.m.lintStreamConfigNode(&node); len(lints) > 0 {
+			badReq := &errdetails.BadRequest{}
+			for _, l := range lints {
+				s.m.manager.Logger().Infof("Stream '%v' config: %v\n", id, l)
+				badReq.FieldViolations = append(badReq.FieldViolations, &errdetails.BadRequest_FieldViolation{
+					Field:       "config",
+					Description: l,
+				})
+			}
+			st := status.New(codes.InvalidArgument, fmt.Sprintf("config has %v linting errors", len(lints)))
+			if withDetails, err := st.WithDetails(badReq); err == nil {
+				st = withDetails
+			}
+			return conf, st.Err()
+		}
+	}
+
+	if err := yaml.Unmarshal(confBytes, &conf); err != nil {
+		return conf, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return conf, nil
+}
+
+// ListStreams returns the status of all streams.
+func (s *GRPCServer) ListStreams(ctx context.Context, req *managementpb.ListStreamsRequest) (*managementpb.ListStreamsResponse, error) {
+	if err := s.requireAPI(); err != nil {
+		return nil, err
+	}
+	res := &managementpb.ListStreamsResponse{}
+
+	s.m.lock.Lock()
+	for id, info := range s.m.streams {
+		res.Streams = append(res.Streams, &managementpb.StreamStatus{
+			Id:       id,
+			Active:   info.IsRunning(),
+			UptimeNs: info.Uptime().Nanoseconds(),
+		})
+	}
+	s.m.lock.Unlock()
+
+	sort.Slice(res.Streams, func(i, j int) bool {
+		return res.Streams[i].Id < res.Streams[j].Id
+	})
+	return res, nil
+}
+
+// GetStream returns the status and config of a stream.
+func (s *GRPCServer) GetStream(ctx context.Context, req *managementpb.GetStreamRequest) (*managementpb.GetStreamResponse, error) {
+	if err := s.requireAPI(); err != nil {
+		return nil, err
+	}
+	if err := grpcRequireID(req.Id); err != nil {
+		return nil, err
+	}
+
+	info, err := s.m.Read(req.Id)
+	if err != nil {
+		return nil, grpcErr(err)
+	}
+
+	sanit, err := info.Config().Sanitised()
+	if err != nil {
+		return nil, grpcErr(err)
+	}
+	confBytes, err := yaml.Marshal(sanit)
+	if err != nil {
+		return nil, grpcErr(err)
+	}
+
+	return &managementpb.GetStreamResponse{
+		Status: &managementpb.StreamStatus{
+			Id:       req.Id,
+			Active:   info.IsRunning(),
+			UptimeNs: info.Uptime().Nanoseconds(),
+		},
+		Config: string(confBytes),
+	}, nil
+}
+
+// CreateStream creates a new stream.
+func (s *GRPCServer) CreateStream(ctx context.Context, req *managementpb.CreateStreamRequest) (*managementpb.CreateStreamResponse, error) {
+	if err := s.requireAPI(); err != nil {
+		return nil, err
+	}
+	if err := grpcRequireID(req.Id); err != nil {
+		return nil, err
+	}
+
+	conf, err := s.readConfig(req.Id, req.Config, req.Chilled)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.m.createStream(ctx, req.Id, conf); err != nil {
+		return nil, grpcErr(err)
+	}
+	return &managementpb.CreateStreamResponse{}, nil
+}
+
+// UpdateStream replaces the config of an existing stream.
+func (s *GRPCServer) UpdateStream(ctx context.Context, req *managementpb.UpdateStreamRequest) (*managementpb.UpdateStreamResponse, error) {
+	if err := s.requireAPI(); err != nil {
+		return nil, err
+	}
+	if err := grpcRequireID(req.Id); err != nil {
+		return nil, err
+	}
+
+	conf, err := s.readConfig(req.Id, req.Config, req.Chilled)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.m.updateStream(ctx, req.Id, conf); err != nil {
+		return nil, grpcErr(err)
+	}
+	return &managementpb.UpdateStreamResponse{}, nil
+}
+
+// DeleteStream stops and removes a stream.
+func (s *GRPCServer) DeleteStream(ctx context.Context, req *managementpb.DeleteStreamRequest) (*managementpb.DeleteStreamResponse, error) {
+	if err := s.requireAPI(); err != nil {
+		return nil, err
+	}
+	if err := grpcRequireID(req.Id); err != nil {
+		return nil, err
+	}
+
+	if err := s.m.deleteStream(ctx, req.Id); err != nil {
+		return nil, grpcErr(err)
+	}
+	return &managementpb.DeleteStreamResponse{}, nil
+}
+
+func (s *GRPCServer) streamStats(id string) (*managementpb.StreamStats, error) {
+	info, err := s.m.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &managementpb.StreamStats{
+		Id:       id,
+		Counters: info.metrics.GetCounters(),
+		Timings:  map[string]*managementpb.Timing{},
+		UptimeNs: info.Uptime().Nanoseconds(),
+	}
+	for k, v := range info.metrics.GetTimings() {
+		ps := v.Percentiles([]float64{0.5, 0.9, 0.99})
+		stats.Timings[k] = &managementpb.Timing{
+			P50: ps[0],
+			P90: ps[1],
+			P99: ps[2],
+		}
+	}
+	return stats, nil
+}
+
+// GetStreamStats returns the metrics of a stream.
+func (s *GRPCServer) GetStreamStats(ctx context.Context, req *managementpb.GetStreamStatsRequest) (*managementpb.StreamStats, error) {
+	if err := s.requireAPI(); err != nil {
+		return nil, err
+	}
+	if err := grpcRequireID(req.Id); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.streamStats(req.Id)
+	if err != nil {
+		return nil, grpcErr(err)
+	}
+	return stats, nil
+}
+
+// WatchStreamStats sends the metrics of a stream periodically until the stream
+// is deleted or the call is cancelled.
+func (s *GRPCServer) WatchStreamStats(req *managementpb.WatchStreamStatsRequest, srv managementpb.Management_WatchStreamStatsServer) error {
+	if err := s.requireAPI(); err != nil {
+		return err
+	}
+	if err := grpcRequireID(req.Id); err != nil {
+		return err
+	}
+
+	interval := grpcDefaultStatsInterval
+	if req.IntervalMs > 0 {
+		interval = time.Duration(req.IntervalMs) * time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for first := true; ; first = false {
+		if !first {
+			select {
+			case <-ticker.C:
+			case <-srv.Context().Done():
+				return nil
+			}
+		}
+
+		stats, err := s.streamStats(req.Id)
+		if err != nil {
+			if !first && errors.Is(err, ErrStreamDoesNotExist) {
+				return nil
+			}
+			return grpcErr(err)
+		}
+		if err := srv.Send(stats); err != nil {
+			return err
+		}
+	}
+}
+
+func readyStatusToProto(running bool, rs stream.ReadyStatus) *managementpb.StreamReadyStatus {
+	pStatus := &managementpb.StreamReadyStatus{
+		Running: running,
+		Ready:   rs.Ready,
+	}
+	for _, c := range rs.Components {
+		pStatus.Components = append(pStatus.Components, &managementpb.ComponentStatus{
+			Label:            c.Label,
+			Path:             c.Path,
+			Kind:             c.Kind,
+			Type:             c.Type,
+			Connected:        c.Connected,
+			LastError:        c.LastError,
+			LastErrorAt:      c.LastErrorAt,
+			LastSuccessAt:    c.LastSuccessAt,
+			SinceLastSuccess: c.SinceLastSuccess,
+		})
+	}
+	return pStatus
+}
+
+// Ready returns the readiness of streams along with the health of each of
+// their inputs and outputs.
+func (s *GRPCServer) Ready(ctx context.Context, req *managementpb.ReadyRequest) (*managementpb.ReadyResponse, error) {
+	res := &managementpb.ReadyResponse{
+		Ready:   true,
+		Streams: map[string]*managementpb.StreamReadyStatus{},
+	}
+
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+
+	for id, info := range s.m.streams {
+		if req.Id != "" && req.Id != id {
+			continue
+		}
+		pStatus := readyStatusToProto(info.IsRunning(), info.ReadyStatus())
+		if !pStatus.Ready && pStatus.Running {
+			res.Ready = false
+		}
+		res.Streams[id] = pStatus
+	}
+
+	if req.Id != "" && len(res.Streams) == 0 {
+		return nil, grpcErr(ErrStreamDoesNotExist)
+	}
+	return res, nil
+}
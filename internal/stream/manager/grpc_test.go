@@ -0,0 +1,246 @@
+package manager_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	bmanager "github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream/manager/managementpb"
+)
+
+func grpcTestClient(t *testing.T, mgr *manager.Type) managementpb.ManagementClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	managementpb.RegisterManagementServer(server, manager.NewGRPCServer(mgr))
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return managementpb.NewManagementClient(conn)
+}
+
+func TestGRPCStreamsCRUD(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	res, err := bmanager.New(bmanager.NewResourceConfig())
+	require.NoError(t, err)
+
+	mgr := manager.New(res)
+	t.Cleanup(func() {
+		_ = mgr.Stop(context.Background())
+	})
+
+	client := grpcTestClient(t, mgr)
+
+	conf := `
+input:
+  generate:
+    interval: 1h
+    mapping: 'root = "hello world"'
+output:
+  drop: {}
+`
+
+	_, err = client.CreateStream(ctx, &managementpb.CreateStreamRequest{Id: "foo", Config: conf})
+	require.NoError(t, err)
+
+	_, err = client.CreateStream(ctx, &managementpb.CreateStreamRequest{Id: "foo", Config: conf})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	list, err := client.ListStreams(ctx, &managementpb.ListStreamsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Streams, 1)
+	assert.Equal(t, "foo", list.Streams[0].Id)
+	assert.True(t, list.Streams[0].Active)
+
+	get, err := client.GetStream(ctx, &managementpb.GetStreamRequest{Id: "foo"})
+	require.NoError(t, err)
+	assert.Contains(t, get.Config, "interval: 1h")
+
+	_, err = client.UpdateStream(ctx, &managementpb.UpdateStreamRequest{Id: "foo", Config: `
+input:
+  generate:
+    interval: 2h
+    mapping: 'root = "hello world"'
+output:
+  drop: {}
+`})
+	require.NoError(t, err)
+
+	get, err = client.GetStream(ctx, &managementpb.GetStreamRequest{Id: "foo"})
+	require.NoError(t, err)
+	assert.Contains(t, get.Config, "interval: 2h")
+
+	stats, err := client.GetStreamStats(ctx, &managementpb.GetStreamStatsRequest{Id: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, "foo", stats.Id)
+	assert.Greater(t, stats.UptimeNs, int64(0))
+
+	ready, err := client.Ready(ctx, &managementpb.ReadyRequest{})
+	require.NoError(t, err)
+	require.Contains(t, ready.Streams, "foo")
+	assert.True(t, ready.Streams["foo"].Running)
+
+	_, err = client.DeleteStream(ctx, &managementpb.DeleteStreamRequest{Id: "foo"})
+	require.NoError(t, err)
+
+	_, err = client.GetStream(ctx, &managementpb.GetStreamRequest{Id: "foo"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Ready(ctx, &managementpb.ReadyRequest{Id: "foo"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.DeleteStream(ctx, &managementpb.DeleteStreamRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCStreamsLinting(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	res, err := bmanager.New(bmanager.NewResourceConfig())
+	require.NoError(t, err)
+
+	mgr := manager.New(res)
+	t.Cleanup(func() {
+		_ = mgr.Stop(context.Background())
+	})
+
+	client := grpcTestClient(t, mgr)
+
+	conf := `
+input:
+  generate:
+    interval: 1h
+    mapping: 'root = "hello world"'
+output:
+  drop: {}
+  nope: nah
+`
+
+	_, err = client.CreateStream(ctx, &managementpb.CreateStreamRequest{Id: "foo", Config: conf})
+	require.Error(t, err)
+
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	badReq, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, badReq.FieldViolations, 1)
+	assert.Equal(t, "(8,1) field nope is invalid when the component type is drop (output)", badReq.FieldViolations[0].Description)
+
+	_, err = client.CreateStream(ctx, &managementpb.CreateStreamRequest{Id: "foo", Config: conf, Chilled: true})
+	require.NoError(t, err)
+}
+
+func TestGRPCWatchStreamStats(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	res, err := bmanager.New(bmanager.NewResourceConfig())
+	require.NoError(t, err)
+
+	mgr := manager.New(res)
+	t.Cleanup(func() {
+		_ = mgr.Stop(context.Background())
+	})
+
+	client := grpcTestClient(t, mgr)
+
+	_, err = client.CreateStream(ctx, &managementpb.CreateStreamRequest{Id: "foo", Config: `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "hello world"'
+output:
+  drop: {}
+`})
+	require.NoError(t, err)
+
+	watch, err := client.WatchStreamStats(ctx, &managementpb.WatchStreamStatsRequest{Id: "foo", IntervalMs: 10})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		stats, err := watch.Recv()
+		require.NoError(t, err)
+		for k, v := range stats.Counters {
+			if strings.HasPrefix(k, "output_sent") && v > 0 {
+				return true
+			}
+		}
+		return false
+	}, time.Second*10, time.Millisecond)
+
+	_, err = client.DeleteStream(ctx, &managementpb.DeleteStreamRequest{Id: "foo"})
+	require.NoError(t, err)
+
+	for {
+		if _, err = watch.Recv(); err != nil {
+			break
+		}
+	}
+	assert.ErrorIs(t, err, io.EOF)
+
+	watch, err = client.WatchStreamStats(ctx, &managementpb.WatchStreamStatsRequest{Id: "bar"})
+	require.NoError(t, err)
+
+	_, err = watch.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCStreamsAPIDisabled(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	res, err := bmanager.New(bmanager.NewResourceConfig())
+	require.NoError(t, err)
+
+	mgr := manager.New(res, manager.OptAPIEnabled(false))
+	t.Cleanup(func() {
+		_ = mgr.Stop(context.Background())
+	})
+
+	client := grpcTestClient(t, mgr)
+
+	_, err = client.CreateStream(ctx, &managementpb.CreateStreamRequest{Id: "foo", Config: "output: { drop: {} }"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.ListStreams(ctx, &managementpb.ListStreamsRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.DeleteStream(ctx, &managementpb.DeleteStreamRequest{Id: "foo"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ready, err := client.Ready(ctx, &managementpb.ReadyRequest{})
+	require.NoError(t, err)
+	assert.True(t, ready.Ready)
+}
@@ -0,0 +1,5 @@
+// Package managementpb contains the protobuf definitions and generated code of
+// the gRPC management API of streams mode.
+package managementpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative management.proto
@@ -0,0 +1,354 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+)
+
+// Store is a persistent location for stream configs that can be shared
+// between multiple instances of Benthos running in streams mode. Configs are
+// stored as YAML documents keyed by their stream ID.
+type Store interface {
+	// List returns all stream configs within the store keyed by their ID.
+	List(ctx context.Context) (map[string][]byte, error)
+
+	// Set creates or replaces the config of a stream.
+	Set(ctx context.Context, id string, conf []byte) error
+
+	// Delete removes the config of a stream, deleting a stream that does not
+	// exist is not an error.
+	Delete(ctx context.Context, id string) error
+
+	// Close the store and clean up any underlying resources.
+	Close(ctx context.Context) error
+}
+
+// Elector is implemented by stores that support leader election, which is used
+// in order to run singleton streams on only one instance at a time.
+type Elector interface {
+	// Elect attempts to acquire or renew the leadership of a stream for an
+	// instance, which lasts for the duration of a TTL unless renewed. Returns
+	// true if the instance is the leader.
+	Elect(ctx context.Context, id, instance string, ttl time.Duration) (bool, error)
+
+	// Resign releases the leadership of a stream if it is held by an instance.
+	Resign(ctx context.Context, id, instance string) error
+}
+
+//------------------------------------------------------------------------------
+
+type cacheStore struct {
+	mgr       bundle.NewManagement
+	cacheName string
+	prefix    string
+}
+
+// NewCacheStore returns a stream store that persists stream configs within a
+// cache resource, where each config is stored under a key consisting of the
+// prefix followed by the stream ID. Since caches do not support listing keys
+// an index of stream IDs is also maintained under the key `<prefix>index`.
+//
+// Writes to the index are not atomic, and therefore when multiple instances
+// modify the store concurrently the last write wins.
+func NewCacheStore(mgr bundle.NewManagement, cacheName, prefix string) (Store, error) {
+	if !mgr.ProbeCache(cacheName) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", cacheName)
+	}
+	return &cacheStore{
+		mgr:       mgr,
+		cacheName: cacheName,
+		prefix:    prefix,
+	}, nil
+}
+
+func (c *cacheStore) indexKey() string {
+	return c.prefix + "index"
+}
+
+func (c *cacheStore) streamKey(id string) string {
+	return c.prefix + "stream_" + id
+}
+
+func (c *cacheStore) readIndex(ctx context.Context, ca cache.V1) ([]string, error) {
+	indexBytes, err := ca.Get(ctx, c.indexKey())
+	if err != nil {
+		if errors.Is(err, component.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ids []string
+	if err := json.Unmarshal(indexBytes, &ids); err != nil {
+		return nil, fmt.Errorf("failed to parse stream store index: %w", err)
+	}
+	return ids, nil
+}
+
+func (c *cacheStore) writeIndex(ctx context.Context, ca cache.V1, ids []string) error {
+	sort.Strings(ids)
+	indexBytes, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return ca.Set(ctx, c.indexKey(), indexBytes, nil)
+}
+
+func (c *cacheStore) List(ctx context.Context) (confs map[string][]byte, err error) {
+	if cerr := c.mgr.AccessCache(ctx, c.cacheName, func(ca cache.V1) {
+		var ids []string
+		if ids, err = c.readIndex(ctx, ca); err != nil {
+			return
+		}
+		confs = make(map[string][]byte, len(ids))
+		for _, id := range ids {
+			var confBytes []byte
+			if confBytes, err = ca.Get(ctx, c.streamKey(id)); err != nil {
+				if errors.Is(err, component.ErrKeyNotFound) {
+					// The index is stale, which is tolerated.
+					err = nil
+					continue
+				}
+				return
+			}
+			confs[id] = confBytes
+		}
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+func (c *cacheStore) Set(ctx context.Context, id string, conf []byte) (err error) {
+	if cerr := c.mgr.AccessCache(ctx, c.cacheName, func(ca cache.V1) {
+		if err = ca.Set(ctx, c.streamKey(id), conf, nil); err != nil {
+			return
+		}
+		var ids []string
+		if ids, err = c.readIndex(ctx, ca); err != nil {
+			return
+		}
+		for _, existing := range ids {
+			if existing == id {
+				return
+			}
+		}
+		err = c.writeIndex(ctx, ca, append(ids, id))
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+func (c *cacheStore) Delete(ctx context.Context, id string) (err error) {
+	if cerr := c.mgr.AccessCache(ctx, c.cacheName, func(ca cache.V1) {
+		var ids []string
+		if ids, err = c.readIndex(ctx, ca); err != nil {
+			return
+		}
+		newIDs := make([]string, 0, len(ids))
+		for _, existing := range ids {
+			if existing != id {
+				newIDs = append(newIDs, existing)
+			}
+		}
+		if len(newIDs) != len(ids) {
+			if err = c.writeIndex(ctx, ca, newIDs); err != nil {
+				return
+			}
+		}
+		if err = ca.Delete(ctx, c.streamKey(id)); errors.Is(err, component.ErrKeyNotFound) {
+			err = nil
+		}
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+func (c *cacheStore) Close(ctx context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type sqlStore struct {
+	db         *sql.DB
+	table      string
+	leaseTable string
+	builder    squirrel.StatementBuilderType
+}
+
+var sqlIdentifierRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// sqlStoreTableStatements returns the statements that create the tables of an
+// SQL store for a driver when they do not already exist.
+func sqlStoreTableStatements(driver, table, leaseTable string) ([]string, error) {
+	switch driver {
+	case "postgres", "sqlite":
+		return []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v (id VARCHAR(255) PRIMARY KEY, config TEXT NOT NULL)", table),
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v (id VARCHAR(255) PRIMARY KEY, holder VARCHAR(255) NOT NULL, expires BIGINT NOT NULL)", leaseTable),
+		}, nil
+	case "mysql":
+		return []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v (id VARCHAR(255) PRIMARY KEY, config MEDIUMTEXT NOT NULL)", table),
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v (id VARCHAR(255) PRIMARY KEY, holder VARCHAR(255) NOT NULL, expires BIGINT NOT NULL)", leaseTable),
+		}, nil
+	case "mssql":
+		return []string{
+			fmt.Sprintf("IF OBJECT_ID(N'%v', N'U') IS NULL CREATE TABLE %v (id NVARCHAR(255) PRIMARY KEY, config NVARCHAR(MAX) NOT NULL)", table, table),
+			fmt.Sprintf("IF OBJECT_ID(N'%v', N'U') IS NULL CREATE TABLE %v (id NVARCHAR(255) PRIMARY KEY, holder NVARCHAR(255) NOT NULL, expires BIGINT NOT NULL)", leaseTable, leaseTable),
+		}, nil
+	case "oracle":
+		// Oracle does not support IF NOT EXISTS, so instead we ignore the error
+		// raised when the table already exists (ORA-00955).
+		createOrIgnore := "BEGIN EXECUTE IMMEDIATE '%v'; EXCEPTION WHEN OTHERS THEN IF SQLCODE != -955 THEN RAISE; END IF; END;"
+		return []string{
+			fmt.Sprintf(createOrIgnore, fmt.Sprintf("CREATE TABLE %v (id VARCHAR2(255) PRIMARY KEY, config CLOB NOT NULL)", table)),
+			fmt.Sprintf(createOrIgnore, fmt.Sprintf("CREATE TABLE %v (id VARCHAR2(255) PRIMARY KEY, holder VARCHAR2(255) NOT NULL, expires NUMBER(19) NOT NULL)", leaseTable)),
+		}, nil
+	}
+	return nil, fmt.Errorf("driver '%v' is not supported by the SQL store, supported drivers are mysql, postgres, sqlite, mssql and oracle", driver)
+}
+
+// NewSQLStore returns a stream store that persists stream configs within an
+// SQL table consisting of the columns `id` and `config`. Leaders of singleton
+// streams are elected with a second table of the same name suffixed with
+// `_leases`. The tables are created if they do not already exist. The driver
+// must already be registered, which is the case for the drivers supported by
+// the `sql` components when they are imported.
+func NewSQLStore(driver, dsn, table string) (Store, error) {
+	if !sqlIdentifierRegexp.MatchString(table) {
+		return nil, fmt.Errorf("table name '%v' is not a valid identifier, it must match the regular expression /%v/", table, sqlIdentifierRegexp.String())
+	}
+	leaseTable := table + "_leases"
+
+	stmts, err := sqlStoreTableStatements(driver, table, leaseTable)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := squirrel.StatementBuilder
+	if driver == "postgres" {
+		builder = builder.PlaceholderFormat(squirrel.Dollar)
+	} else if driver == "oracle" {
+		builder = builder.PlaceholderFormat(squirrel.Colon)
+	}
+
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to create stream store table: %w", err)
+		}
+	}
+	return &sqlStore{
+		db:         db,
+		table:      table,
+		leaseTable: leaseTable,
+		builder:    builder,
+	}, nil
+}
+func (s *sqlStore) List(ctx context.Context) (map[string][]byte, error) {
+	rows, err := s.builder.Select("id", "config").From(s.table).RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	confs := map[string][]byte{}
+	for rows.Next() {
+		var id, conf string
+		if err := rows.Scan(&id, &conf); err != nil {
+			return nil, err
+		}
+		confs[id] = []byte(conf)
+	}
+	return confs, rows.Err()
+}
+
+func (s *sqlStore) Set(ctx context.Context, id string, conf []byte) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := s.builder.Delete(s.table).Where(squirrel.Eq{"id": id}).RunWith(tx).ExecContext(ctx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := s.builder.Insert(s.table).Columns("id", "config").Values(id, string(conf)).RunWith(tx).ExecContext(ctx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStore) Delete(ctx context.Context, id string) error {
+	_, err := s.builder.Delete(s.table).Where(squirrel.Eq{"id": id}).RunWith(s.db).ExecContext(ctx)
+	return err
+}
+
+func (s *sqlStore) Elect(ctx context.Context, id, instance string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	expires := now.Add(ttl).UnixMilli()
+
+	// Take over the lease when it's either held by this instance or expired.
+	res, err := s.builder.Update(s.leaseTable).
+		Set("holder", instance).
+		Set("expires", expires).
+		Where(squirrel.And{
+			squirrel.Eq{"id": id},
+			squirrel.Or{squirrel.Eq{"holder": instance}, squirrel.Lt{"expires": now.UnixMilli()}},
+		}).
+		RunWith(s.db).ExecContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		return true, nil
+	}
+
+	// Otherwise attempt to create the lease, which fails when it's already
+	// held due to the primary key.
+	_, insertErr := s.builder.Insert(s.leaseTable).
+		Columns("id", "holder", "expires").
+		Values(id, instance, expires).
+		RunWith(s.db).ExecContext(ctx)
+	if insertErr == nil {
+		return true, nil
+	}
+
+	var holder string
+	if err := s.builder.Select("holder").From(s.leaseTable).Where(squirrel.Eq{"id": id}).
+		RunWith(s.db).QueryRowContext(ctx).Scan(&holder); err != nil {
+		return false, insertErr
+	}
+	return holder == instance, nil
+}
+
+func (s *sqlStore) Resign(ctx context.Context, id, instance string) error {
+	_, err := s.builder.Delete(s.leaseTable).
+		Where(squirrel.Eq{"id": id, "holder": instance}).
+		RunWith(s.db).ExecContext(ctx)
+	return err
+}
+
+func (s *sqlStore) Close(ctx context.Context) error {
+	return s.db.Close()
+}
@@ -0,0 +1,337 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type etcdStore struct {
+	client   *http.Client
+	endpoint string
+	user     string
+	password string
+	prefix   string
+
+	tokenMut sync.Mutex
+	token    string
+}
+
+// NewEtcdStore returns a stream store that persists stream configs within
+// etcd, where each config is stored under a key consisting of the prefix
+// followed by `streams/` and the stream ID. The store communicates with the
+// JSON gateway of the etcd v3 API at an endpoint such as
+// `http://localhost:2379`, and credentials specified as user info of the
+// endpoint URL are used for authentication.
+//
+// Leaders of singleton streams are elected with keys consisting of the prefix
+// followed by `leaders/` and the stream ID, which are attached to leases that
+// expire unless renewed.
+func NewEtcdStore(endpoint, prefix string) (Store, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse etcd endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("etcd endpoint '%v' must use the scheme http or https", endpoint)
+	}
+
+	e := &etcdStore{
+		client: &http.Client{},
+		prefix: prefix,
+	}
+	if u.User != nil {
+		e.user = u.User.Username()
+		e.password, _ = u.User.Password()
+		u.User = nil
+	}
+	e.endpoint = strings.TrimSuffix(u.String(), "/")
+	return e, nil
+}
+
+func (e *etcdStore) streamKey(id string) string {
+	return e.prefix + "streams/" + id
+}
+
+func (e *etcdStore) leaderKey(id string) string {
+	return e.prefix + "leaders/" + id
+}
+
+// etcdPrefixEnd returns the end of the range of keys that begin with a prefix.
+func etcdPrefixEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// The prefix consists entirely of 0xff bytes, and so the range extends to
+	// the end of the key space.
+	return []byte{0}
+}
+
+type etcdKV struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+	Lease int64  `json:"lease,string"`
+}
+
+type etcdRangeResponse struct {
+	Kvs []etcdKV `json:"kvs"`
+}
+
+type etcdTxnResponse struct {
+	Succeeded bool `json:"succeeded"`
+	Responses []struct {
+		ResponseRange etcdRangeResponse `json:"response_range"`
+	} `json:"responses"`
+}
+
+type etcdLeaseGrantResponse struct {
+	ID int64 `json:"ID,string"`
+}
+
+// authToken returns a token for authenticating requests, which is obtained
+// when there isn't one already or when renew is true.
+func (e *etcdStore) authToken(ctx context.Context, renew bool) (string, error) {
+	e.tokenMut.Lock()
+	defer e.tokenMut.Unlock()
+
+	if e.token != "" && !renew {
+		return e.token, nil
+	}
+
+	var res struct {
+		Token string `json:"token"`
+	}
+	if err := e.do(ctx, "/v3/auth/authenticate", "", map[string]any{
+		"name":     e.user,
+		"password": e.password,
+	}, &res); err != nil {
+		return "", fmt.Errorf("failed to authenticate with etcd: %w", err)
+	}
+	e.token = res.Token
+	return e.token, nil
+}
+
+var errEtcdUnauthenticated = errors.New("unauthenticated")
+
+func (e *etcdStore) do(ctx context.Context, path, token string, reqBody, resBody any) error {
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+path, bytes.NewReader(reqBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%w: %s", errEtcdUnauthenticated, bytes.TrimSpace(resBytes))
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("etcd request %v returned status %v: %s", path, res.StatusCode, bytes.TrimSpace(resBytes))
+	}
+	if resBody == nil {
+		return nil
+	}
+	return json.Unmarshal(resBytes, resBody)
+}
+
+// call performs a request against the etcd gateway, authenticating first when
+// credentials are configured and renewing the token once if it was rejected.
+func (e *etcdStore) call(ctx context.Context, path string, reqBody, resBody any) error {
+	if e.user == "" {
+		return e.do(ctx, path, "", reqBody, resBody)
+	}
+
+	token, err := e.authToken(ctx, false)
+	if err != nil {
+		return err
+	}
+	if err = e.do(ctx, path, token, reqBody, resBody); !errors.Is(err, errEtcdUnauthenticated) {
+		return err
+	}
+	if token, err = e.authToken(ctx, true); err != nil {
+		return err
+	}
+	return e.do(ctx, path, token, reqBody, resBody)
+}
+
+func (e *etcdStore) List(ctx context.Context) (map[string][]byte, error) {
+	keyPrefix := e.streamKey("")
+
+	var res etcdRangeResponse
+	if err := e.call(ctx, "/v3/kv/range", map[string]any{
+		"key":       []byte(keyPrefix),
+		"range_end": etcdPrefixEnd(keyPrefix),
+	}, &res); err != nil {
+		return nil, err
+	}
+
+	confs := make(map[string][]byte, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		confs[strings.TrimPrefix(string(kv.Key), keyPrefix)] = kv.Value
+	}
+	return confs, nil
+}
+
+func (e *etcdStore) Set(ctx context.Context, id string, conf []byte) error {
+	return e.call(ctx, "/v3/kv/put", map[string]any{
+		"key":   []byte(e.streamKey(id)),
+		"value": conf,
+	}, nil)
+}
+
+func (e *etcdStore) Delete(ctx context.Context, id string) error {
+	return e.call(ctx, "/v3/kv/deleterange", map[string]any{
+		"key": []byte(e.streamKey(id)),
+	}, nil)
+}
+
+func (e *etcdStore) grantLease(ctx context.Context, ttl time.Duration) (int64, error) {
+	var res etcdLeaseGrantResponse
+	if err := e.call(ctx, "/v3/lease/grant", map[string]any{
+		"TTL": strconv.FormatInt(int64(math.Ceil(ttl.Seconds())), 10),
+	}, &res); err != nil {
+		return 0, fmt.Errorf("failed to grant lease: %w", err)
+	}
+	return res.ID, nil
+}
+
+func (e *etcdStore) revokeLease(ctx context.Context, id int64) {
+	_ = e.call(ctx, "/v3/lease/revoke", map[string]any{
+		"ID": strconv.FormatInt(id, 10),
+	}, nil)
+}
+
+func (e *etcdStore) Elect(ctx context.Context, id, instance string, ttl time.Duration) (bool, error) {
+	key := []byte(e.leaderKey(id))
+
+	leaseID, err := e.grantLease(ctx, ttl)
+	if err != nil {
+		return false, err
+	}
+	put := map[string]any{
+		"request_put": map[string]any{
+			"key":   key,
+			"value": []byte(instance),
+			"lease": strconv.FormatInt(leaseID, 10),
+		},
+	}
+
+	// Claim the key when it doesn't exist, otherwise read the current holder.
+	var res etcdTxnResponse
+	if err := e.call(ctx, "/v3/kv/txn", map[string]any{
+		"compare": []any{map[string]any{
+			"key":             key,
+			"target":          "CREATE",
+			"result":          "EQUAL",
+			"create_revision": "0",
+		}},
+		"success": []any{put},
+		"failure": []any{map[string]any{
+			"request_range": map[string]any{"key": key},
+		}},
+	}, &res); err != nil {
+		e.revokeLease(ctx, leaseID)
+		return false, err
+	}
+	if res.Succeeded {
+		return true, nil
+	}
+
+	var holder etcdKV
+	if len(res.Responses) > 0 && len(res.Responses[0].ResponseRange.Kvs) > 0 {
+		holder = res.Responses[0].ResponseRange.Kvs[0]
+	}
+	if string(holder.Value) != instance {
+		e.revokeLease(ctx, leaseID)
+		return false, nil
+	}
+
+	// Renew the leadership by attaching the key to the new lease, as long as
+	// it's still held by this instance.
+	var renewRes etcdTxnResponse
+	if err := e.call(ctx, "/v3/kv/txn", map[string]any{
+		"compare": []any{map[string]any{
+			"key":    key,
+			"target": "VALUE",
+			"result": "EQUAL",
+			"value":  []byte(instance),
+		}},
+		"success": []any{put},
+	}, &renewRes); err != nil {
+		e.revokeLease(ctx, leaseID)
+		return false, err
+	}
+	if !renewRes.Succeeded {
+		e.revokeLease(ctx, leaseID)
+		return false, nil
+	}
+	if holder.Lease != 0 {
+		e.revokeLease(ctx, holder.Lease)
+	}
+	return true, nil
+}
+
+func (e *etcdStore) Resign(ctx context.Context, id, instance string) error {
+	key := []byte(e.leaderKey(id))
+
+	var res etcdTxnResponse
+	if err := e.call(ctx, "/v3/kv/txn", map[string]any{
+		"compare": []any{map[string]any{
+			"key":    key,
+			"target": "VALUE",
+			"result": "EQUAL",
+			"value":  []byte(instance),
+		}},
+		"success": []any{
+			map[string]any{"request_range": map[string]any{"key": key}},
+			map[string]any{"request_delete_range": map[string]any{"key": key}},
+		},
+	}, &res); err != nil {
+		return err
+	}
+
+	// The lease of the key is no longer needed.
+	if res.Succeeded && len(res.Responses) > 0 {
+		for _, kv := range res.Responses[0].ResponseRange.Kvs {
+			if kv.Lease != 0 {
+				e.revokeLease(ctx, kv.Lease)
+			}
+		}
+	}
+	return nil
+}
+
+func (e *etcdStore) Close(ctx context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,538 @@
+package manager_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	bmanager "github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream/manager"
+)
+
+func testStoreOperations(t *testing.T, store manager.Store) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	confs, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, confs)
+
+	require.NoError(t, store.Set(ctx, "foo", []byte("foo config")))
+	require.NoError(t, store.Set(ctx, "bar", []byte("bar config")))
+	require.NoError(t, store.Set(ctx, "foo", []byte("new foo config")))
+
+	confs, err = store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"foo": []byte("new foo config"),
+		"bar": []byte("bar config"),
+	}, confs)
+
+	require.NoError(t, store.Delete(ctx, "foo"))
+	require.NoError(t, store.Delete(ctx, "baz"))
+
+	confs, err = store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"bar": []byte("bar config"),
+	}, confs)
+
+	require.NoError(t, store.Close(ctx))
+}
+
+func testElectorOperations(t *testing.T, store manager.Store) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	elector, ok := store.(manager.Elector)
+	require.True(t, ok)
+
+	leader, err := elector.Elect(ctx, "foo", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	leader, err = elector.Elect(ctx, "foo", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, leader)
+
+	leader, err = elector.Elect(ctx, "bar", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	// Renewals by the leader succeed.
+	leader, err = elector.Elect(ctx, "foo", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	// Resigning is a no-op for instances that aren't the leader.
+	require.NoError(t, elector.Resign(ctx, "foo", "b"))
+	leader, err = elector.Elect(ctx, "foo", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, leader)
+
+	require.NoError(t, elector.Resign(ctx, "foo", "a"))
+	leader, err = elector.Elect(ctx, "foo", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	leader, err = elector.Elect(ctx, "foo", "a", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, leader)
+
+	require.NoError(t, store.Close(ctx))
+}
+
+func newCacheResources(t *testing.T) *bmanager.Type {
+	t.Helper()
+
+	conf := bmanager.NewResourceConfig()
+	cConf := cache.NewConfig()
+	cConf.Label = "foo"
+	cConf.Type = "memory"
+	conf.ResourceCaches = append(conf.ResourceCaches, cConf)
+
+	res, err := bmanager.New(conf)
+	require.NoError(t, err)
+	return res
+}
+
+func TestCacheStore(t *testing.T) {
+	res := newCacheResources(t)
+
+	_, err := manager.NewCacheStore(res, "bar", "")
+	require.Error(t, err)
+
+	store, err := manager.NewCacheStore(res, "foo", "streams_")
+	require.NoError(t, err)
+
+	testStoreOperations(t, store)
+}
+
+func TestSQLStore(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "streams.db")
+
+	store, err := manager.NewSQLStore("sqlite", dsn, "benthos_streams")
+	require.NoError(t, err)
+
+	testStoreOperations(t, store)
+}
+
+func TestSQLStoreElector(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "streams.db")
+
+	store, err := manager.NewSQLStore("sqlite", dsn, "benthos_streams")
+	require.NoError(t, err)
+
+	testElectorOperations(t, store)
+}
+
+func TestSQLStoreElectorExpiry(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "streams.db")
+
+	store, err := manager.NewSQLStore("sqlite", dsn, "benthos_streams")
+	require.NoError(t, err)
+	elector := store.(manager.Elector)
+
+	leader, err := elector.Elect(ctx, "foo", "a", time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	time.Sleep(time.Millisecond * 10)
+
+	leader, err = elector.Elect(ctx, "foo", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	require.NoError(t, store.Close(ctx))
+}
+
+func TestSQLStoreBadConfig(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "streams.db")
+
+	for _, table := range []string{
+		"",
+		"foo bar",
+		"foo; DROP TABLE bar",
+		"1foo",
+		"foo.bar.baz",
+		`"foo"`,
+	} {
+		_, err := manager.NewSQLStore("sqlite", dsn, table)
+		assert.Error(t, err, table)
+	}
+
+	_, err := manager.NewSQLStore("clickhouse", dsn, "benthos_streams")
+	assert.ErrorContains(t, err, "not supported")
+}
+
+// fakeEtcd emulates the subset of the etcd v3 JSON gateway used by the store.
+type fakeEtcd struct {
+	user, password string
+
+	mut       sync.Mutex
+	kvs       map[string]fakeEtcdKV
+	leases    map[int64]time.Time
+	nextLease int64
+	tokens    map[string]struct{}
+}
+
+type fakeEtcdKV struct {
+	value []byte
+	lease int64
+}
+
+type fakeEtcdRequest struct {
+	Name     string      `json:"name"`
+	Password string      `json:"password"`
+	Key      []byte      `json:"key"`
+	RangeEnd []byte      `json:"range_end"`
+	Value    []byte      `json:"value"`
+	Lease    json.Number `json:"lease"`
+	ID       json.Number `json:"ID"`
+	TTL      json.Number `json:"TTL"`
+	Compare  []struct {
+		Key            []byte      `json:"key"`
+		Target         string      `json:"target"`
+		CreateRevision json.Number `json:"create_revision"`
+		Value          []byte      `json:"value"`
+	} `json:"compare"`
+	Success []fakeEtcdOp `json:"success"`
+	Failure []fakeEtcdOp `json:"failure"`
+}
+
+type fakeEtcdOp struct {
+	RequestPut         *fakeEtcdRequest `json:"request_put"`
+	RequestRange       *fakeEtcdRequest `json:"request_range"`
+	RequestDeleteRange *fakeEtcdRequest `json:"request_delete_range"`
+}
+
+func (f *fakeEtcd) rangeKVs(req *fakeEtcdRequest) []any {
+	kvs := []any{}
+	for k, kv := range f.kvs {
+		if k == string(req.Key) || (len(req.RangeEnd) > 0 && k >= string(req.Key) && k < string(req.RangeEnd)) {
+			kvs = append(kvs, map[string]any{
+				"key":   []byte(k),
+				"value": kv.value,
+				"lease": strconv.FormatInt(kv.lease, 10),
+			})
+		}
+	}
+	return kvs
+}
+
+func (f *fakeEtcd) applyOps(ops []fakeEtcdOp) []any {
+	responses := []any{}
+	for _, op := range ops {
+		switch {
+		case op.RequestPut != nil:
+			lease, _ := op.RequestPut.Lease.Int64()
+			f.kvs[string(op.RequestPut.Key)] = fakeEtcdKV{value: op.RequestPut.Value, lease: lease}
+			responses = append(responses, map[string]any{"response_put": map[string]any{}})
+		case op.RequestRange != nil:
+			responses = append(responses, map[string]any{"response_range": map[string]any{"kvs": f.rangeKVs(op.RequestRange)}})
+		case op.RequestDeleteRange != nil:
+			delete(f.kvs, string(op.RequestDeleteRange.Key))
+			responses = append(responses, map[string]any{"response_delete_range": map[string]any{}})
+		}
+	}
+	return responses
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	var req fakeEtcdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if f.user != "" {
+		if r.URL.Path == "/v3/auth/authenticate" {
+			if req.Name != f.user || req.Password != f.password {
+				http.Error(w, "authentication failed", http.StatusBadRequest)
+				return
+			}
+			token := "token" + strconv.Itoa(len(f.tokens))
+			f.tokens[token] = struct{}{}
+			_ = json.NewEncoder(w).Encode(map[string]any{"token": token})
+			return
+		}
+		if _, exists := f.tokens[r.Header.Get("Authorization")]; !exists {
+			http.Error(w, "invalid auth token", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	for k, kv := range f.kvs {
+		if exp, exists := f.leases[kv.lease]; kv.lease != 0 && (!exists || time.Now().After(exp)) {
+			delete(f.kvs, k)
+		}
+	}
+
+	var res any = map[string]any{}
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		res = map[string]any{"kvs": f.rangeKVs(&req)}
+	case "/v3/kv/put":
+		lease, _ := req.Lease.Int64()
+		f.kvs[string(req.Key)] = fakeEtcdKV{value: req.Value, lease: lease}
+	case "/v3/kv/deleterange":
+		delete(f.kvs, string(req.Key))
+	case "/v3/kv/txn":
+		succeeded := true
+		for _, c := range req.Compare {
+			kv, exists := f.kvs[string(c.Key)]
+			switch c.Target {
+			case "CREATE":
+				succeeded = succeeded && !exists && c.CreateRevision == "0"
+			case "VALUE":
+				succeeded = succeeded && exists && bytes.Equal(kv.value, c.Value)
+			default:
+				http.Error(w, "unsupported compare target", http.StatusBadRequest)
+				return
+			}
+		}
+		ops := req.Failure
+		if succeeded {
+			ops = req.Success
+		}
+		txnRes := map[string]any{"responses": f.applyOps(ops)}
+		if succeeded {
+			txnRes["succeeded"] = true
+		}
+		res = txnRes
+	case "/v3/lease/grant":
+		ttl, _ := req.TTL.Int64()
+		f.nextLease++
+		f.leases[f.nextLease] = time.Now().Add(time.Duration(ttl) * time.Second)
+		res = map[string]any{"ID": strconv.FormatInt(f.nextLease, 10), "TTL": req.TTL}
+	case "/v3/lease/revoke":
+		id, _ := req.ID.Int64()
+		delete(f.leases, id)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func newFakeEtcd(t *testing.T, user, password string) (*fakeEtcd, string) {
+	t.Helper()
+
+	f := &fakeEtcd{
+		user:     user,
+		password: password,
+		kvs:      map[string]fakeEtcdKV{},
+		leases:   map[int64]time.Time{},
+		tokens:   map[string]struct{}{},
+	}
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+	return f, ts.URL
+}
+
+func TestEtcdStore(t *testing.T) {
+	f, urlStr := newFakeEtcd(t, "", "")
+
+	store, err := manager.NewEtcdStore(urlStr, "/benthos/")
+	require.NoError(t, err)
+
+	testStoreOperations(t, store)
+
+	// Keys outside of the prefix are ignored.
+	f.kvs["/benthos/streamsfoo"] = fakeEtcdKV{value: []byte("nope")}
+	f.kvs["/other/streams/foo"] = fakeEtcdKV{value: []byte("nope")}
+	confs, err := store.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"bar": []byte("bar config")}, confs)
+}
+
+func TestEtcdStoreElector(t *testing.T) {
+	f, urlStr := newFakeEtcd(t, "", "")
+
+	store, err := manager.NewEtcdStore(urlStr, "/benthos/")
+	require.NoError(t, err)
+
+	testElectorOperations(t, store)
+
+	// Renewed leadership is moved to new leases, and unused leases are revoked.
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	for k, kv := range f.kvs {
+		assert.True(t, strings.HasPrefix(k, "/benthos/leaders/"), k)
+		assert.Contains(t, f.leases, kv.lease)
+	}
+	assert.Len(t, f.leases, 2)
+}
+
+func TestEtcdStoreAuth(t *testing.T) {
+	f, urlStr := newFakeEtcd(t, "foo", "bar")
+
+	_, err := manager.NewEtcdStore("localhost:2379", "")
+	require.Error(t, err)
+
+	badStore, err := manager.NewEtcdStore(strings.Replace(urlStr, "http://", "http://foo:nope@", 1), "")
+	require.NoError(t, err)
+	_, err = badStore.List(context.Background())
+	require.Error(t, err)
+
+	store, err := manager.NewEtcdStore(strings.Replace(urlStr, "http://", "http://foo:bar@", 1), "")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set(context.Background(), "foo", []byte("foo config")))
+
+	// Expired tokens are renewed.
+	f.mut.Lock()
+	f.tokens = map[string]struct{}{}
+	f.mut.Unlock()
+
+	confs, err := store.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"foo": []byte("foo config")}, confs)
+}
+
+func TestStoreSyncBetweenManagers(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	res := newCacheResources(t)
+
+	storeA, err := manager.NewCacheStore(res, "foo", "")
+	require.NoError(t, err)
+	mgrA := manager.New(res, manager.OptStore(storeA, time.Millisecond*10))
+
+	storeB, err := manager.NewCacheStore(res, "foo", "")
+	require.NoError(t, err)
+	mgrB := manager.New(res, manager.OptStore(storeB, time.Millisecond*10))
+
+	r := router(mgrA)
+
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, genYAMLRequest("POST", "/streams/foo", harmlessConf()))
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	assert.Eventually(t, func() bool {
+		info, err := mgrB.Read("foo")
+		return err == nil && info.IsRunning()
+	}, time.Second*5, time.Millisecond*10)
+
+	// Streams that already exist within a manager and were written by it are
+	// not restarted by a sync.
+	infoA, err := mgrA.Read("foo")
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond * 50)
+	infoAfter, err := mgrA.Read("foo")
+	require.NoError(t, err)
+	assert.True(t, infoA == infoAfter)
+
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, genRequest("DELETE", "/streams/foo", nil))
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	assert.Eventually(t, func() bool {
+		_, err := mgrB.Read("foo")
+		return err != nil
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, mgrA.Stop(ctx))
+	require.NoError(t, mgrB.Stop(ctx))
+}
+
+func TestStoreSingletonStreams(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	res := newCacheResources(t)
+	dsn := "file:" + filepath.Join(t.TempDir(), "streams.db") + "?_pragma=busy_timeout(5000)"
+
+	storeA, err := manager.NewSQLStore("sqlite", dsn, "benthos_streams")
+	require.NoError(t, err)
+	mgrA := manager.New(res, manager.OptStore(storeA, time.Millisecond*200), manager.OptSingletons([]string{"single_*"}))
+
+	storeB, err := manager.NewSQLStore("sqlite", dsn, "benthos_streams")
+	require.NoError(t, err)
+	mgrB := manager.New(res, manager.OptStore(storeB, time.Millisecond*200), manager.OptSingletons([]string{"single_*"}))
+
+	r := router(mgrA)
+
+	for _, id := range []string{"foo", "single_foo"} {
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, genYAMLRequest("POST", "/streams/"+id, harmlessConf()))
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	}
+
+	running := func(m *manager.Type, id string) bool {
+		info, err := m.Read(id)
+		return err == nil && info.IsRunning()
+	}
+
+	// Regular streams run on both instances, singleton streams on only one.
+	assert.Eventually(t, func() bool {
+		return running(mgrA, "foo") && running(mgrB, "foo") &&
+			(running(mgrA, "single_foo") || running(mgrB, "single_foo"))
+	}, time.Second*5, time.Millisecond*10)
+
+	leader, follower := mgrA, mgrB
+	if running(mgrB, "single_foo") {
+		leader, follower = mgrB, mgrA
+	}
+	for i := 0; i < 10; i++ {
+		assert.True(t, running(leader, "single_foo"))
+		assert.False(t, running(follower, "single_foo"))
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// Creating a singleton stream that's run elsewhere is rejected.
+	response := httptest.NewRecorder()
+	router(follower).ServeHTTP(response, genYAMLRequest("POST", "/streams/single_foo", harmlessConf()))
+	require.NotEqual(t, http.StatusOK, response.Code, response.Body.String())
+
+	// Another instance takes over when the leader stops.
+	require.NoError(t, leader.Stop(ctx))
+	assert.Eventually(t, func() bool {
+		return running(follower, "single_foo")
+	}, time.Second*5, time.Millisecond*10)
+
+	// Singleton streams run elsewhere can be deleted.
+	storeC, err := manager.NewSQLStore("sqlite", dsn, "benthos_streams")
+	require.NoError(t, err)
+	mgrC := manager.New(res, manager.OptStore(storeC, time.Millisecond*200), manager.OptSingletons([]string{"single_*"}))
+	assert.Eventually(t, func() bool {
+		return running(mgrC, "foo")
+	}, time.Second*5, time.Millisecond*10)
+
+	response = httptest.NewRecorder()
+	router(mgrC).ServeHTTP(response, genRequest("DELETE", "/streams/single_foo", nil))
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	assert.Eventually(t, func() bool {
+		return !running(follower, "single_foo")
+	}, time.Second*5, time.Millisecond*10)
+	assert.False(t, running(mgrC, "single_foo"))
+
+	require.NoError(t, follower.Stop(ctx))
+	require.NoError(t, mgrC.Stop(ctx))
+}
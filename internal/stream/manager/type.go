@@ -1,23 +1,32 @@
 package manager
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/gofrs/uuid"
+	"gopkg.in/yaml.v3"
+
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
 
 // StreamStatus tracks a stream along with information regarding its internals.
 type StreamStatus struct {
 	stoppedAfter int64
+	confMut      sync.Mutex
 	config       stream.Config
 	strm         *stream.Type
 	metrics      *metrics.Local
@@ -48,6 +57,12 @@ func (s *StreamStatus) IsReady() bool {
 	return s.strm.IsReady()
 }
 
+// ReadyStatus returns the readiness of the stream along with the health of each
+// of its inputs and outputs.
+func (s *StreamStatus) ReadyStatus() stream.ReadyStatus {
+	return s.strm.ReadyStatus()
+}
+
 // Uptime returns a time.Duration indicating the current uptime of the stream.
 func (s *StreamStatus) Uptime() time.Duration {
 	if stoppedAfter := atomic.LoadInt64(&s.stoppedAfter); stoppedAfter > 0 {
@@ -58,9 +73,17 @@ func (s *StreamStatus) Uptime() time.Duration {
 
 // Config returns the configuration of the stream.
 func (s *StreamStatus) Config() stream.Config {
+	s.confMut.Lock()
+	defer s.confMut.Unlock()
 	return s.config
 }
 
+func (s *StreamStatus) setConfig(conf stream.Config) {
+	s.confMut.Lock()
+	s.config = conf
+	s.confMut.Unlock()
+}
+
 // Metrics returns a metrics aggregator of the stream.
 func (s *StreamStatus) Metrics() *metrics.Local {
 	return s.metrics
@@ -87,6 +110,19 @@ type Type struct {
 
 	manager    bundle.NewManagement
 	apiEnabled bool
+	dashboard  bool
+	hotReload  bool
+	lintRules  []docs.LintRule
+
+	store        Store
+	storeSync    time.Duration
+	storeMut     sync.Mutex
+	storedConfs  map[string][]byte
+	storeShutSig *shutdown.Signaller
+
+	elector    Elector
+	singletons []string
+	instanceID string
 
 	lock sync.Mutex
 }
@@ -94,14 +130,28 @@ type Type struct {
 // New creates a new stream manager.Type.
 func New(mgr bundle.NewManagement, opts ...func(*Type)) *Type {
 	t := &Type{
-		streams:    map[string]*StreamStatus{},
-		apiEnabled: true,
-		manager:    mgr,
+		streams:      map[string]*StreamStatus{},
+		apiEnabled:   true,
+		manager:      mgr,
+		storedConfs:  map[string][]byte{},
+		storeShutSig: shutdown.NewSignaller(),
 	}
 	for _, opt := range opts {
 		opt(t)
 	}
+	if len(t.singletons) > 0 {
+		if t.elector, _ = t.store.(Elector); t.elector == nil || t.storeSync <= 0 {
+			mgr.Logger().Errorln("Singleton streams require a store that supports leader election and a non-zero sync interval, they will be run on every instance instead")
+			t.elector = nil
+		}
+		t.instanceID = newInstanceID()
+	}
 	t.registerEndpoints(t.apiEnabled)
+	if t.store != nil {
+		go t.storeSyncLoop()
+	} else {
+		t.storeShutSig.ShutdownComplete()
+	}
 	return t
 }
 
@@ -115,6 +165,69 @@ func OptAPIEnabled(b bool) func(*Type) {
 	}
 }
 
+// OptDashboard sets whether the stream manager serves a web dashboard for
+// viewing and editing streams, which requires the API to be enabled. This is
+// disabled by default.
+func OptDashboard(b bool) func(*Type) {
+	return func(t *Type) {
+		t.dashboard = b
+	}
+}
+
+// OptLintRules sets custom linting rules that are checked against stream
+// configs submitted via the HTTP API, in addition to the standard linting
+// rules.
+func OptLintRules(rules []docs.LintRule) func(*Type) {
+	return func(t *Type) {
+		t.lintRules = rules
+	}
+}
+
+// OptHotReload sets whether streams are created with support for having their
+// pipeline sections replaced in place. When enabled an update to a stream that
+// only modifies its pipeline section is applied without restarting the stream.
+// This is disabled by default.
+func OptHotReload(b bool) func(*Type) {
+	return func(t *Type) {
+		t.hotReload = b
+	}
+}
+
+// OptStore sets a persistent store for stream configs. Streams that are
+// created, updated or deleted via the HTTP API are written to the store, and
+// the streams within the store are periodically synchronised into the manager
+// at the provided interval, which allows multiple instances of Benthos to share
+// the same set of streams. An interval of zero means the store is only read
+// once at start up.
+func OptStore(s Store, syncInterval time.Duration) func(*Type) {
+	return func(t *Type) {
+		t.store = s
+		t.storeSync = syncInterval
+	}
+}
+
+// OptSingletons sets patterns of stream IDs, in the syntax of path.Match, that
+// are run on only one instance at a time out of all instances sharing a store.
+// The instance that runs each singleton stream is elected using the store,
+// which must support leader election, and leadership is renewed each time the
+// store is synchronised, which must therefore happen at a non-zero interval.
+// Should an instance fail to renew its leadership for three sync intervals
+// then another instance takes over.
+func OptSingletons(patterns []string) func(*Type) {
+	return func(t *Type) {
+		t.singletons = patterns
+	}
+}
+
+func newInstanceID() string {
+	hostname, _ := os.Hostname()
+	id, err := uuid.NewV4()
+	if err != nil {
+		return hostname
+	}
+	return hostname + "-" + id.String()
+}
+
 //------------------------------------------------------------------------------
 
 // Errors specifically returned by a stream manager.
@@ -148,7 +261,7 @@ func (m *Type) Create(id string, conf stream.Config) error {
 	// This seems a bit wonky but we can't rule out a race condition between
 	// the stream terminating and setClosed and actually initialising a status.
 	wrapper := newStreamStatus(conf, strmFlatMetrics)
-	strm, err := stream.New(conf, sMgr, stream.OptOnClose(func() {
+	strm, err := stream.New(conf, sMgr, stream.OptHotReload(m.hotReload), stream.OptOnClose(func() {
 		wrapper.setClosed()
 	}))
 	if err != nil {
@@ -179,10 +292,11 @@ func (m *Type) Read(id string) (*StreamStatus, error) {
 }
 
 // Update attempts to stop an existing stream and replace it with a new version
-// of the same stream.
+// of the same stream. When hot reloading is enabled and the changes are limited
+// to the pipeline section of the stream they are instead applied in place.
 func (m *Type) Update(ctx context.Context, id string, conf stream.Config) error {
 	m.lock.Lock()
-	_, exists := m.streams[id]
+	wrapper, exists := m.streams[id]
 	closed := m.closed
 	m.lock.Unlock()
 
@@ -193,6 +307,16 @@ func (m *Type) Update(ctx context.Context, id string, conf stream.Config) error
 		return ErrStreamDoesNotExist
 	}
 
+	if m.hotReload {
+		applied, err := wrapper.strm.UpdateInPlace(ctx, conf)
+		if err != nil {
+			m.manager.Logger().Warnf("Failed to apply changes to stream %v in place, restarting it instead: %v", id, err)
+		} else if applied {
+			wrapper.setConfig(conf)
+			return nil
+		}
+	}
+
 	if err := m.Delete(ctx, id); err != nil {
 		return err
 	}
@@ -228,9 +352,326 @@ func (m *Type) Delete(ctx context.Context, id string) error {
 
 //------------------------------------------------------------------------------
 
+func (m *Type) storeSyncLoop() {
+	defer m.storeShutSig.ShutdownComplete()
+
+	ctx, done := m.storeShutSig.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	for {
+		if err := m.syncFromStore(ctx); err != nil && ctx.Err() == nil {
+			m.manager.Logger().Errorf("Failed to synchronise streams from store: %v", err)
+		}
+		if m.storeSync <= 0 {
+			return
+		}
+		select {
+		case <-time.After(m.storeSync):
+		case <-m.storeShutSig.CloseAtLeisureChan():
+			return
+		}
+	}
+}
+
+// syncFromStore reads all stream configs from the store and creates, updates
+// or deletes streams within the manager in order to match. Only streams that
+// originated from the store are deleted.
+func (m *Type) syncFromStore(ctx context.Context) error {
+	confs, err := m.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.storeMut.Lock()
+	defer m.storeMut.Unlock()
+
+	for id, confBytes := range confs {
+		if err := m.applyStored(ctx, id, confBytes); err != nil {
+			m.manager.Logger().Errorf("Failed to apply stream '%v' config from store: %v", id, err)
+		}
+	}
+
+	for id := range m.storedConfs {
+		if _, exists := confs[id]; exists {
+			continue
+		}
+		if err := m.stopStored(ctx, id); err != nil {
+			m.manager.Logger().Errorf("Failed to delete stream '%v' removed from store: %v", id, err)
+			continue
+		}
+		m.resign(ctx, id)
+	}
+	return nil
+}
+
+// isSingleton returns true if a stream ID matches the singleton patterns and
+// is therefore only run by the instance elected as its leader.
+func (m *Type) isSingleton(id string) bool {
+	if m.elector == nil {
+		return false
+	}
+	for _, pattern := range m.singletons {
+		if matched, _ := path.Match(pattern, id); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// applyStored creates or updates a stream from a config read from the store,
+// unless it's a singleton stream for which this instance isn't the leader, in
+// which case any local version of the stream is deleted. Must be called with
+// storeMut held.
+func (m *Type) applyStored(ctx context.Context, id string, confBytes []byte) error {
+	if m.isSingleton(id) {
+		leader, err := m.elector.Elect(ctx, id, m.instanceID, 3*m.storeSync)
+		if err != nil || !leader {
+			// Without confirmation of leadership the stream is stopped in order
+			// to avoid running it on multiple instances.
+			if stopErr := m.stopStored(ctx, id); stopErr != nil {
+				m.manager.Logger().Errorf("Failed to stop singleton stream '%v': %v", id, stopErr)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to elect leader: %w", err)
+			}
+			return nil
+		}
+	}
+
+	if prev, exists := m.storedConfs[id]; exists && bytes.Equal(prev, confBytes) {
+		return nil
+	}
+
+	conf := stream.NewConfig()
+	if err := yaml.Unmarshal(confBytes, &conf); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	err := m.Update(ctx, id, conf)
+	if errors.Is(err, ErrStreamDoesNotExist) {
+		err = m.Create(id, conf)
+	}
+	if err != nil {
+		return err
+	}
+	m.storedConfs[id] = confBytes
+	return nil
+}
+
+// stopStored deletes a stream that originated from the store from the manager.
+// Must be called with storeMut held.
+func (m *Type) stopStored(ctx context.Context, id string) error {
+	if _, exists := m.storedConfs[id]; !exists {
+		return nil
+	}
+	if err := m.Delete(ctx, id); err != nil && !errors.Is(err, ErrStreamDoesNotExist) {
+		return err
+	}
+	delete(m.storedConfs, id)
+	return nil
+}
+
+// resign releases the leadership of a singleton stream held by this instance.
+func (m *Type) resign(ctx context.Context, id string) {
+	if !m.isSingleton(id) {
+		return
+	}
+	if err := m.elector.Resign(ctx, id, m.instanceID); err != nil {
+		m.manager.Logger().Errorf("Failed to resign leadership of singleton stream '%v': %v", id, err)
+	}
+}
+
+func storeConfBytes(conf stream.Config) ([]byte, error) {
+	sanit, err := conf.Sanitised()
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(sanit)
+}
+
+// storeSet writes a stream config to the store, if one is configured.
+func (m *Type) storeSet(ctx context.Context, id string, conf stream.Config) error {
+	if m.store == nil {
+		return nil
+	}
+
+	confBytes, err := storeConfBytes(conf)
+	if err != nil {
+		return err
+	}
+
+	m.storeMut.Lock()
+	defer m.storeMut.Unlock()
+
+	if err := m.store.Set(ctx, id, confBytes); err != nil {
+		return fmt.Errorf("failed to write stream to store: %w", err)
+	}
+	m.storedConfs[id] = confBytes
+	return nil
+}
+
+// storeDelete removes a stream config from the store, if one is configured.
+func (m *Type) storeDelete(ctx context.Context, id string) error {
+	if m.store == nil {
+		return nil
+	}
+
+	m.storeMut.Lock()
+	defer m.storeMut.Unlock()
+
+	if err := m.store.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete stream from store: %w", err)
+	}
+	delete(m.storedConfs, id)
+	return nil
+}
+
+// createStream creates a stream and writes it to the store. Singleton streams
+// are instead only written to the store and are then run by the instance that
+// is elected as their leader.
+func (m *Type) createStream(ctx context.Context, id string, conf stream.Config) error {
+	if !m.isSingleton(id) {
+		if err := m.Create(id, conf); err != nil {
+			return err
+		}
+		return m.storeSet(ctx, id, conf)
+	}
+
+	m.storeMut.Lock()
+	defer m.storeMut.Unlock()
+
+	if exists, err := m.storeHas(ctx, id); err != nil {
+		return err
+	} else if exists {
+		return ErrStreamExists
+	}
+	return m.storeSingleton(ctx, id, conf)
+}
+
+// updateStream updates a stream and writes it to the store. Singleton streams
+// are instead only written to the store and are then run by the instance that
+// is elected as their leader.
+func (m *Type) updateStream(ctx context.Context, id string, conf stream.Config) error {
+	if !m.isSingleton(id) {
+		if err := m.Update(ctx, id, conf); err != nil {
+			return err
+		}
+		return m.storeSet(ctx, id, conf)
+	}
+
+	m.storeMut.Lock()
+	defer m.storeMut.Unlock()
+
+	if exists, err := m.storeHas(ctx, id); err != nil {
+		return err
+	} else if !exists {
+		return ErrStreamDoesNotExist
+	}
+	return m.storeSingleton(ctx, id, conf)
+}
+
+// deleteStream deletes a stream and removes it from the store, singleton
+// streams are deleted regardless of which instance is running them.
+func (m *Type) deleteStream(ctx context.Context, id string) error {
+	if !m.isSingleton(id) {
+		if err := m.Delete(ctx, id); err != nil {
+			return err
+		}
+		return m.storeDelete(ctx, id)
+	}
+
+	m.storeMut.Lock()
+	defer m.storeMut.Unlock()
+
+	if exists, err := m.storeHas(ctx, id); err != nil {
+		return err
+	} else if !exists {
+		return ErrStreamDoesNotExist
+	}
+	if err := m.store.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete stream from store: %w", err)
+	}
+	if err := m.stopStored(ctx, id); err != nil {
+		return err
+	}
+	m.resign(ctx, id)
+	return nil
+}
+
+// storeHas returns true if a stream exists within the store.
+func (m *Type) storeHas(ctx context.Context, id string) (bool, error) {
+	confs, err := m.store.List(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to read streams from store: %w", err)
+	}
+	_, exists := confs[id]
+	return exists, nil
+}
+
+// storeSingleton writes the config of a singleton stream to the store and then
+// applies it when this instance is elected as its leader. Must be called with
+// storeMut held.
+func (m *Type) storeSingleton(ctx context.Context, id string, conf stream.Config) error {
+	confBytes, err := storeConfBytes(conf)
+	if err != nil {
+		return err
+	}
+	if err := m.store.Set(ctx, id, confBytes); err != nil {
+		return fmt.Errorf("failed to write stream to store: %w", err)
+	}
+	return m.applyStored(ctx, id, confBytes)
+}
+
+// storedSingletonIDs returns the IDs of all singleton streams within the
+// store, including those run by other instances.
+func (m *Type) storedSingletonIDs(ctx context.Context) ([]string, error) {
+	if m.elector == nil {
+		return nil, nil
+	}
+	confs, err := m.store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read streams from store: %w", err)
+	}
+	var ids []string
+	for id := range confs {
+		if m.isSingleton(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// closeStore resigns the leadership of any singleton streams held by this
+// instance and closes the store.
+func (m *Type) closeStore(ctx context.Context) {
+	m.storeMut.Lock()
+	for id := range m.storedConfs {
+		m.resign(ctx, id)
+	}
+	m.storeMut.Unlock()
+
+	if err := m.store.Close(ctx); err != nil {
+		m.manager.Logger().Errorf("Failed to close stream store: %v", err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
 // Stop attempts to gracefully shut down all active streams and close the
 // stream manager.
 func (m *Type) Stop(ctx context.Context) error {
+	m.storeShutSig.CloseAtLeisure()
+	select {
+	case <-m.storeShutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if m.store != nil {
+		// The store is closed once streams are stopped in order to avoid other
+		// instances running singleton streams before they've stopped here.
+		defer m.closeStore(ctx)
+	}
+
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	bmanager "github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
@@ -83,6 +84,42 @@ func TestTypeBasicOperations(t *testing.T) {
 	}
 }
 
+func TestTypeHotReloadUpdate(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	res, err := bmanager.New(bmanager.NewResourceConfig())
+	require.NoError(t, err)
+
+	mgr := New(res, OptHotReload(true))
+	require.NoError(t, mgr.Create("foo", harmlessConf()))
+
+	before, err := mgr.Read("foo")
+	require.NoError(t, err)
+
+	pipeConf := harmlessConf()
+	pipeConf.Pipeline.Processors = []processor.Config{processor.NewConfig()}
+	require.NoError(t, mgr.Update(ctx, "foo", pipeConf))
+
+	after, err := mgr.Read("foo")
+	require.NoError(t, err)
+	require.True(t, before == after, "expected stream to be updated in place")
+	require.True(t, after.IsRunning())
+	require.Equal(t, pipeConf, after.Config())
+
+	bufConf := pipeConf
+	bufConf.Buffer.Type = "memory"
+	require.NoError(t, mgr.Update(ctx, "foo", bufConf))
+
+	after, err = mgr.Read("foo")
+	require.NoError(t, err)
+	require.False(t, before == after, "expected stream to be restarted")
+	require.True(t, after.IsRunning())
+	require.Equal(t, bufConf, after.Config())
+
+	require.NoError(t, mgr.Stop(ctx))
+}
+
 func TestTypeBasicClose(t *testing.T) {
 	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
 	defer done()
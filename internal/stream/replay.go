@@ -0,0 +1,239 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/replay"
+)
+
+const replayDefaultTimeout = 30 * time.Second
+
+// captureRejected returns a function that captures batches rejected after
+// being consumed by the input layer. Batches are captured once they've passed
+// through the processors of the input, which is also the point at which they
+// are injected when replayed, and therefore a replayed batch reaches the
+// buffer and pipeline in the same shape as the original.
+func (t *Type) captureRejected(rec *replay.Recorder) func(context.Context, message.Batch, error) error {
+	rec.Label = t.conf.Input.Label
+	return func(ctx context.Context, batch message.Batch, err error) error {
+		if err = rec.Capture(ctx, batch, t.conf.Input.Type, err); err == nil {
+			t.manager.Logger().Debugf("Acknowledging rejected batch as it was captured for replay")
+		}
+		return err
+	}
+}
+
+// Inject sends a batch into the stream as if it had been consumed by the input,
+// where it passes through the buffer, pipeline and output layers, and blocks
+// until the batch is acknowledged. Returns the error that the batch was
+// rejected with, if any.
+func (t *Type) Inject(ctx context.Context, batch message.Batch) error {
+	resChan, err := t.inject(ctx, batch)
+	if err != nil {
+		return err
+	}
+	select {
+	case err := <-resChan:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Type) inject(ctx context.Context, batch message.Batch) (<-chan error, error) {
+	resChan := make(chan error, 1)
+	select {
+	case t.inFlight.inject <- message.NewTransaction(batch, resChan):
+	case <-t.inFlight.doneCh:
+		return nil, component.ErrTypeClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return resChan, nil
+}
+
+var errReplayInProgress = errors.New("replay entry is already being re-injected")
+
+// Replay re-injects a batch from the replay store into the stream, and deletes
+// it from the store once it has been delivered successfully.
+//
+// If the context is cancelled after the batch has been injected then the
+// batch continues to be delivered in the background and is deleted from the
+// store once delivered, and until then further replays of it are rejected in
+// order to prevent duplicates.
+func (t *Type) Replay(ctx context.Context, rec *replay.Recorder, id string) error {
+	t.replayMut.Lock()
+	if _, exists := t.replayPending[id]; exists {
+		t.replayMut.Unlock()
+		return errReplayInProgress
+	}
+	if t.replayPending == nil {
+		t.replayPending = map[string]struct{}{}
+	}
+	t.replayPending[id] = struct{}{}
+	t.replayMut.Unlock()
+
+	release := func() {
+		t.replayMut.Lock()
+		delete(t.replayPending, id)
+		t.replayMut.Unlock()
+	}
+
+	e, err := rec.Store.Get(ctx, id)
+	if err == nil && e.Stream != rec.Stream {
+		err = replay.ErrNotFound
+	}
+	if err != nil {
+		release()
+		return err
+	}
+
+	resChan, err := t.inject(ctx, e.ToBatch())
+	if err != nil {
+		release()
+		return err
+	}
+	select {
+	case err := <-resChan:
+		defer release()
+		if err != nil {
+			return err
+		}
+		return rec.Store.Delete(ctx, id)
+	case <-ctx.Done():
+		go func() {
+			defer release()
+			if err := <-resChan; err == nil {
+				_ = rec.Store.Delete(context.Background(), id)
+			}
+		}()
+		return ctx.Err()
+	}
+}
+
+// ReplayResult describes the outcome of re-injecting batches from the replay
+// store.
+type ReplayResult struct {
+	Replayed []string          `json:"replayed"`
+	Failed   map[string]string `json:"failed"`
+}
+
+func (t *Type) replayHandler(w http.ResponseWriter, r *http.Request) {
+	rec := replay.FromManager(t.manager)
+	if rec == nil {
+		http.Error(w, "Replay store is not enabled", http.StatusNotFound)
+		return
+	}
+
+	ctx := r.Context()
+	id := r.URL.Query().Get("id")
+
+	writeErr := func(err error) {
+		if errors.Is(err, replay.ErrNotFound) {
+			http.Error(w, "Replay entry not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, errReplayInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		var res any
+		if id == "" {
+			summaries, err := rec.Store.List(ctx, rec.Stream)
+			if err != nil {
+				writeErr(err)
+				return
+			}
+			res = map[string]any{"entries": summaries}
+		} else {
+			e, err := rec.Store.Get(ctx, id)
+			if err == nil && e.Stream != rec.Stream {
+				err = replay.ErrNotFound
+			}
+			if err != nil {
+				writeErr(err)
+				return
+			}
+			res = e
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+
+	case http.MethodPost:
+		timeout := replayDefaultTimeout
+		if toutStr := r.URL.Query().Get("timeout"); toutStr != "" {
+			var err error
+			if timeout, err = time.ParseDuration(toutStr); err != nil {
+				http.Error(w, "Failed to parse timeout: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		ctx, done := context.WithTimeout(ctx, timeout)
+		defer done()
+
+		ids := []string{id}
+		if id == "" {
+			summaries, err := rec.Store.List(ctx, rec.Stream)
+			if err != nil {
+				writeErr(err)
+				return
+			}
+			ids = ids[:0]
+			for _, s := range summaries {
+				ids = append(ids, s.ID)
+			}
+		}
+
+		res := ReplayResult{Replayed: []string{}, Failed: map[string]string{}}
+		for _, replayID := range ids {
+			if err := t.Replay(ctx, rec, replayID); err != nil {
+				if id != "" {
+					writeErr(err)
+					return
+				}
+				res.Failed[replayID] = err.Error()
+				continue
+			}
+			res.Replayed = append(res.Replayed, replayID)
+		}
+		t.manager.Logger().Infof("Replayed %v batches with %v failures", len(res.Replayed), len(res.Failed))
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(res.Failed) > 0 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		_ = json.NewEncoder(w).Encode(res)
+
+	case http.MethodDelete:
+		if id == "" {
+			http.Error(w, "Query parameter `id` must be set", http.StatusBadRequest)
+			return
+		}
+		e, err := rec.Store.Get(ctx, id)
+		if err == nil && e.Stream != rec.Stream {
+			err = replay.ErrNotFound
+		}
+		if err == nil {
+			err = rec.Store.Delete(ctx, id)
+		}
+		if err != nil {
+			writeErr(err)
+			return
+		}
+		_, _ = w.Write([]byte("OK"))
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
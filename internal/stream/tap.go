@@ -0,0 +1,193 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/benthosdev/benthos/v4/internal/tap"
+)
+
+const (
+	tapDefaultDuration    = 10 * time.Second
+	tapMaxDuration        = 5 * time.Minute
+	tapDefaultMaxMessages = 100
+)
+
+// tapListParam returns the values of a query parameter that may be specified
+// multiple times, or as a comma separated list.
+func tapListParam(r *http.Request, key string) (values []string) {
+	for _, v := range r.URL.Query()[key] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return
+}
+
+func tapOptionsFromRequest(r *http.Request) (opts tap.Options, duration time.Duration, err error) {
+	query := r.URL.Query()
+
+	duration = tapDefaultDuration
+	if v := query.Get("duration"); v != "" {
+		if duration, err = time.ParseDuration(v); err != nil {
+			err = fmt.Errorf("failed to parse duration: %w", err)
+			return
+		}
+		if duration <= 0 || duration > tapMaxDuration {
+			err = fmt.Errorf("duration must be greater than zero and no greater than %v", tapMaxDuration)
+			return
+		}
+	}
+
+	opts.MaxMessages = tapDefaultMaxMessages
+	if v := query.Get("max_messages"); v != "" {
+		if opts.MaxMessages, err = strconv.ParseInt(v, 10, 64); err != nil {
+			err = fmt.Errorf("failed to parse max_messages: %w", err)
+			return
+		}
+		if opts.MaxMessages < 0 {
+			err = errors.New("max_messages must not be negative")
+			return
+		}
+	}
+
+	opts.SampleRatio = 1
+	if v := query.Get("sample_ratio"); v != "" {
+		if opts.SampleRatio, err = strconv.ParseFloat(v, 64); err != nil {
+			err = fmt.Errorf("failed to parse sample_ratio: %w", err)
+			return
+		}
+		if opts.SampleRatio <= 0 || opts.SampleRatio > 1 {
+			err = errors.New("sample_ratio must be greater than 0 and no greater than 1")
+			return
+		}
+	}
+
+	opts.RedactFields = tapListParam(r, "redact")
+	opts.RedactMetadata = tapListParam(r, "redact_meta")
+	return
+}
+
+// tapHandler streams samples of the messages flowing through a component for a
+// limited period of time, either as newline delimited JSON or, when the request
+// is a websocket upgrade, as a JSON websocket message per sample.
+func (t *Type) tapHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	component := r.URL.Query().Get("component")
+	if component == "" {
+		http.Error(w, "Query parameter `component` must be set to the label or path of a component", http.StatusBadRequest)
+		return
+	}
+
+	opts, duration, err := tapOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	session, err := tap.OpenFromManager(t.manager, component, opts)
+	if errors.Is(err, tap.ErrComponentNotFound) {
+		http.Error(w, fmt.Sprintf("Component %v not found", component), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer func() {
+		session.Close()
+		if dropped := session.Dropped(); dropped > 0 {
+			t.manager.Logger().Warnf("Tap on component %v dropped %v samples as they were not read quickly enough", component, dropped)
+		}
+	}()
+
+	t.manager.Logger().Infof("Opened a tap on component %v for %v", component, duration)
+
+	var write func(s tap.Sample) error
+	var closed <-chan struct{}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		upgrader := websocket.Upgrader{}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.manager.Logger().Warnf("Tap websocket request failed: %v", err)
+			return
+		}
+		defer ws.Close()
+
+		// Reads are required in order to process control messages, and
+		// detect when the client has gone away.
+		wsClosed := make(chan struct{})
+		go func() {
+			defer close(wsClosed)
+			for {
+				if _, _, err := ws.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+		closed = wsClosed
+
+		write = func(s tap.Sample) error {
+			return ws.WriteJSON(s)
+		}
+		defer func() {
+			_ = ws.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, "tap finished"),
+				time.Now().Add(time.Second),
+			)
+		}()
+	} else {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Server does not support streaming responses", http.StatusInternalServerError)
+			return
+		}
+		closed = r.Context().Done()
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		enc := json.NewEncoder(w)
+		write = func(s tap.Sample) error {
+			if err := enc.Encode(s); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		}
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	var sent int64
+	for opts.MaxMessages == 0 || sent < opts.MaxMessages {
+		select {
+		case s := <-session.Samples():
+			if err := write(s); err != nil {
+				return
+			}
+			sent++
+		case <-timer.C:
+			return
+		case <-closed:
+			return
+		}
+	}
+}
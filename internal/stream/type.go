@@ -3,19 +3,27 @@ package stream
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"runtime/pprof"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/buffer"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/events"
+	"github.com/benthosdev/benthos/v4/internal/health"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/pipeline"
+	"github.com/benthosdev/benthos/v4/internal/profile"
+	"github.com/benthosdev/benthos/v4/internal/replay"
 )
 
 // Type creates and manages the lifetime of a Benthos stream.
@@ -27,10 +35,21 @@ type Type struct {
 	pipelineLayer processor.Pipeline
 	outputLayer   output.Streamed
 
+	swappablePipeline *pipeline.Swappable
+	updateMut         sync.Mutex
+
 	manager bundle.NewManagement
 
-	onClose func()
-	closed  uint32
+	hotReload bool
+	onClose   func()
+	closed    uint32
+
+	inFlight          *inFlightCounter
+	drainInputTimeout time.Duration
+	drainFlushTimeout time.Duration
+
+	replayMut     sync.Mutex
+	replayPending map[string]struct{}
 }
 
 // New creates a new stream.Type.
@@ -40,6 +59,8 @@ func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, err
 		manager: mgr,
 		onClose: func() {},
 		closed:  0,
+
+		inFlight: newInFlightCounter(),
 	}
 	for _, opt := range opts {
 		opt(t)
@@ -57,6 +78,16 @@ func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, err
 			return
 		}
 
+		if r.URL.Query().Get("detailed") == "true" {
+			status := t.ReadyStatus()
+			w.Header().Set("Content-Type", "application/json")
+			if !status.Ready {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			_ = json.NewEncoder(w).Encode(status)
+			return
+		}
+
 		if inputConnected && outputConnected {
 			_, _ = w.Write([]byte("OK"))
 			return
@@ -72,9 +103,31 @@ func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, err
 	}
 	t.manager.RegisterEndpoint(
 		"/ready",
-		"Returns 200 OK if all inputs and outputs are connected, otherwise a 503 is returned.",
+		"Returns 200 OK if all inputs and outputs are connected, otherwise a 503 is returned. When the query parameter `detailed=true` is set a JSON object is returned describing the connection state, last error and time since the last successful message of each input and output.",
 		healthCheck,
 	)
+	t.manager.RegisterEndpoint(
+		"/drain",
+		"Gracefully drains the stream when called with a POST request, by stopping the input from consuming and waiting for all messages in flight to be flushed through to the output, after which the stream terminates. The optional query parameter `timeout` limits how long to wait. Returns a JSON object describing whether the drain completed and the number of messages left unflushed, with a 503 status code if it did not complete. Messages held within a buffer have already been acknowledged and are therefore not counted as unflushed.",
+		t.drainHandler,
+	)
+	t.manager.RegisterEndpoint(
+		"/processors/profile",
+		"Returns a JSON object describing the execution time and batch sizes of each processor of the stream as histograms, along with estimated percentiles. When the query parameter `reset=true` is set the profiles are reset after being returned.",
+		t.profileHandler,
+	)
+	t.manager.RegisterEndpoint(
+		"/tap",
+		"Streams copies of the messages flowing through a component, identified by the query parameter `component` as either its label or path, as newline delimited JSON or websocket messages. The optional query parameters `duration` (default `10s`, up to `5m`), `max_messages` (default `100`, where `0` is unlimited) and `sample_ratio` (default `1`) limit the messages sampled, and `redact` and `redact_meta` list the dot paths of fields and metadata keys to redact, which may contain glob wildcards.",
+		t.tapHandler,
+	)
+	if replay.FromManager(t.manager) != nil {
+		t.manager.RegisterEndpoint(
+			"/replay",
+			"Manages batches captured by the replay store after being rejected. A GET request lists the captured batches of the stream, or returns a single batch when the query parameter `id` is set. A POST request re-injects the batch identified by `id`, or all captured batches when `id` is omitted, into the stream and deletes them once delivered, where the query parameter `timeout` (default `30s`) limits how long to wait. A DELETE request deletes the batch identified by `id`.",
+			t.replayHandler,
+		)
+	}
 	return t, nil
 }
 
@@ -87,6 +140,15 @@ func OptOnClose(onClose func()) func(*Type) {
 	}
 }
 
+// OptHotReload sets whether the pipeline layer of the stream should support
+// being replaced in place with UpdateInPlace. This adds a small overhead to the
+// pipeline layer and therefore is disabled by default.
+func OptHotReload(b bool) func(*Type) {
+	return func(t *Type) {
+		t.hotReload = b
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // IsReady returns a boolean indicating whether both the input and output layers
@@ -95,6 +157,47 @@ func (t *Type) IsReady() bool {
 	return t.inputLayer.Connected() && t.outputLayer.Connected()
 }
 
+// ReadyStatus describes the readiness of a stream along with the health of
+// each of its inputs and outputs.
+type ReadyStatus struct {
+	Ready      bool            `json:"ready"`
+	Components []health.Status `json:"components"`
+}
+
+// ReadyStatus returns the readiness of the stream along with the health of each
+// of its inputs and outputs.
+func (t *Type) ReadyStatus() ReadyStatus {
+	components := health.StatusesFromManager(t.manager)
+	if components == nil {
+		components = []health.Status{}
+	}
+	return ReadyStatus{
+		Ready:      t.IsReady(),
+		Components: components,
+	}
+}
+
+// ProcessorProfiles returns the execution time and batch sizes of each
+// processor of the stream, and optionally resets them.
+func (t *Type) ProcessorProfiles(reset bool) []profile.Profile {
+	profiles := profile.ProfilesFromManager(t.manager, reset)
+	if profiles == nil {
+		profiles = []profile.Profile{}
+	}
+	return profiles
+}
+
+func (t *Type) profileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"processors": t.ProcessorProfiles(r.URL.Query().Get("reset") == "true"),
+	})
+}
+
 func (t *Type) start() (err error) {
 	// Constructors
 	iMgr := t.manager.IntoPath("input")
@@ -107,11 +210,15 @@ func (t *Type) start() (err error) {
 			return
 		}
 	}
-	if tLen := len(t.conf.Pipeline.Processors); tLen > 0 {
+	if tLen := len(t.conf.Pipeline.Processors); tLen > 0 || t.hotReload {
 		pMgr := t.manager.IntoPath("pipeline")
 		if t.pipelineLayer, err = pipeline.New(t.conf.Pipeline, pMgr); err != nil {
 			return
 		}
+		if t.hotReload {
+			t.swappablePipeline = pipeline.NewSwappable(t.pipelineLayer)
+			t.pipelineLayer = t.swappablePipeline
+		}
 	}
 	oMgr := t.manager.IntoPath("output")
 	if t.outputLayer, err = oMgr.NewOutput(t.conf.Output); err != nil {
@@ -121,7 +228,10 @@ func (t *Type) start() (err error) {
 	// Start chaining components
 	var nextTranChan <-chan message.Transaction
 
-	nextTranChan = t.inputLayer.TransactionChan()
+	if rec := replay.FromManager(iMgr); rec != nil {
+		t.inFlight.onReject = t.captureRejected(rec)
+	}
+	nextTranChan = t.inFlight.track(t.inputLayer.TransactionChan())
 	if t.bufferLayer != nil {
 		if err = t.bufferLayer.Consume(nextTranChan); err != nil {
 			return
@@ -138,9 +248,13 @@ func (t *Type) start() (err error) {
 		return
 	}
 
+	evts := events.FromManager(t.manager)
+	evts.Emit(events.New(events.TypeStreamStarted, "Stream started"))
+
 	go func(out output.Streamed) {
 		for {
 			if err := out.WaitForClose(context.Background()); err == nil {
+				evts.Emit(events.New(events.TypeStreamStopped, "Stream stopped").WithField("unflushed", t.inFlight.Count()))
 				t.onClose()
 				atomic.StoreUint32(&t.closed, 1)
 				return
@@ -151,16 +265,89 @@ func (t *Type) start() (err error) {
 	return nil
 }
 
+// UpdateInPlace attempts to apply a new config to the stream without
+// restarting it. Returns true if the new config was applied, which is the case
+// when the config is unchanged or when only the pipeline section has changed
+// and the stream was created with hot reloading enabled. When false is returned
+// the stream is left unchanged and must be restarted in order to apply the new
+// config.
+//
+// Resources are not part of a stream config and are therefore not updated by
+// this method. The config reader replaces resources whose config has changed
+// within the manager and keeps those that are unchanged.
+func (t *Type) UpdateInPlace(ctx context.Context, conf Config) (bool, error) {
+	t.updateMut.Lock()
+	defer t.updateMut.Unlock()
+
+	sameInput, err := sameYAML(t.conf.Input, conf.Input)
+	if err != nil || !sameInput {
+		return false, err
+	}
+	sameBuffer, err := sameYAML(t.conf.Buffer, conf.Buffer)
+	if err != nil || !sameBuffer {
+		return false, err
+	}
+	sameOutput, err := sameYAML(t.conf.Output, conf.Output)
+	if err != nil || !sameOutput {
+		return false, err
+	}
+	samePipeline, err := sameYAML(t.conf.Pipeline, conf.Pipeline)
+	if err != nil {
+		return false, err
+	}
+	if samePipeline {
+		return true, nil
+	}
+	if t.swappablePipeline == nil {
+		return false, nil
+	}
+
+	newPipeline, err := pipeline.New(conf.Pipeline, t.manager.IntoPath("pipeline"))
+	if err != nil {
+		return false, err
+	}
+	if err := t.swappablePipeline.Swap(ctx, newPipeline); err != nil {
+		return false, err
+	}
+	t.conf.Pipeline = conf.Pipeline
+	return true, nil
+}
+
+func sameYAML(a, b any) (bool, error) {
+	aBytes, err := yaml.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	bBytes, err := yaml.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aBytes, bBytes), nil
+}
+
 // StopGracefully attempts to close the stream in the most graceful way by only
 // closing the input layer and waiting for all other layers to terminate by
 // proxy. This should guarantee that all in-flight and buffered data is resolved
 // before shutting down.
 func (t *Type) StopGracefully(ctx context.Context) (err error) {
+	inputCtx := ctx
+	if t.drainInputTimeout > 0 {
+		var done func()
+		inputCtx, done = context.WithTimeout(ctx, t.drainInputTimeout)
+		defer done()
+	}
+
 	t.inputLayer.TriggerStopConsuming()
-	if err = t.inputLayer.WaitForClose(ctx); err != nil {
+	if err = t.inputLayer.WaitForClose(inputCtx); err != nil {
 		return
 	}
 
+	if t.drainFlushTimeout > 0 {
+		var done func()
+		ctx, done = context.WithTimeout(ctx, t.drainFlushTimeout)
+		defer done()
+	}
+
 	// If we have a buffer then wait right here. We want to try and allow the
 	// buffer to empty out before prompting the other layers to shut down.
 	if t.bufferLayer != nil {
@@ -188,6 +375,7 @@ func (t *Type) StopGracefully(ctx context.Context) (err error) {
 // should only be attempted if both stopGracefully and stopOrdered failed.
 func (t *Type) StopUnordered(ctx context.Context) (err error) {
 	t.inputLayer.TriggerCloseNow()
+	t.inFlight.closeNow()
 	if t.bufferLayer != nil {
 		t.bufferLayer.TriggerCloseNow()
 	}
@@ -249,6 +437,9 @@ func (t *Type) Stop(ctx context.Context) error {
 	if err == nil {
 		return nil
 	}
+	if n := t.inFlight.Count(); n > 0 {
+		t.manager.Logger().Warnf("Failed to flush %v messages in flight within the shutdown timeout, these messages have not been acknowledged and will be redelivered if the input supports it", n)
+	}
 	if !(errors.Is(err, context.Canceled) && errors.Is(err, context.DeadlineExceeded)) {
 		t.manager.Logger().Errorf("Encountered error whilst attempting to shut down gracefully: %v\n", err)
 	}
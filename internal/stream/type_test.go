@@ -2,18 +2,24 @@ package stream_test
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/profile"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 
 	_ "github.com/benthosdev/benthos/v4/public/components/pure"
@@ -144,10 +150,11 @@ func TestTypeCloseUnordered(t *testing.T) {
 
 type mockAPIReg struct {
 	server *httptest.Server
+	mux    *http.ServeMux
 }
 
 func (ar mockAPIReg) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
-	ar.server.Config.Handler = h
+	ar.mux.HandleFunc(path, h)
 }
 
 func (ar mockAPIReg) Close() {
@@ -155,8 +162,10 @@ func (ar mockAPIReg) Close() {
 }
 
 func newMockAPIReg() mockAPIReg {
+	mux := http.NewServeMux()
 	return mockAPIReg{
-		server: httptest.NewServer(nil),
+		server: httptest.NewServer(mux),
+		mux:    mux,
 	}
 }
 
@@ -206,3 +215,646 @@ func TestHealthCheck(t *testing.T) {
 
 	validateHealthCheckResponse(t, mockAPIReg.server.URL, "Stream terminated\n")
 }
+
+func TestTypeDrainEndpoint(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = "root = {}"
+	conf.Input.Generate.Interval = "1ms"
+	conf.Output.Type = "drop"
+
+	mockAPIReg := newMockAPIReg()
+	defer mockAPIReg.Close()
+
+	newMgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(&mockAPIReg))
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	res, err := http.Get(mockAPIReg.server.URL + "/drain")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	res, err = http.Post(mockAPIReg.server.URL+"/drain?timeout=10s", "", http.NoBody)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var result stream.DrainResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	assert.True(t, result.Drained)
+	assert.Equal(t, int64(0), result.Unflushed)
+	assert.Equal(t, int64(0), strm.InFlight())
+
+	validateHealthCheckResponse(t, mockAPIReg.server.URL, "Stream terminated\n")
+}
+
+func TestTypeDrainUnflushed(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = "root = {}"
+	conf.Input.Generate.Interval = "1ms"
+	conf.Output.Type = "inproc"
+	conf.Output.Inproc = "nobody_is_listening"
+
+	newMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr, stream.OptDrainTimeouts(50*time.Millisecond, 50*time.Millisecond))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return strm.InFlight() > 0
+	}, time.Second, time.Millisecond)
+
+	ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+	defer done()
+
+	result := strm.Drain(ctx)
+	assert.False(t, result.Drained)
+	assert.Greater(t, result.Unflushed, int64(0))
+	assert.NotEmpty(t, result.Error)
+
+	require.NoError(t, strm.StopUnordered(ctx))
+}
+
+func TestTypeDrainBuffered(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = "root = {}"
+	conf.Input.Generate.Interval = "1ms"
+	conf.Buffer.Type = "memory"
+	conf.Output.Type = "inproc"
+	conf.Output.Inproc = "nobody_is_listening"
+
+	newMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr, stream.OptDrainTimeouts(50*time.Millisecond, 50*time.Millisecond))
+	require.NoError(t, err)
+
+	// Let the buffer fill up with messages that the output cannot deliver.
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+	defer done()
+
+	// The drain fails as the buffer cannot be emptied, but the messages it
+	// holds were acknowledged when written and are not counted as unflushed.
+	result := strm.Drain(ctx)
+	assert.False(t, result.Drained)
+	assert.NotEmpty(t, result.Error)
+	assert.Equal(t, int64(0), result.Unflushed)
+
+	require.NoError(t, strm.StopUnordered(ctx))
+}
+
+func TestTypeUpdateInPlace(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "1ms"
+	conf.Output.Type = "inproc"
+	conf.Output.Inproc = "foo"
+
+	blobConf := processor.NewConfig()
+	blobConf.Type = "bloblang"
+	blobConf.Bloblang = `root = "first"`
+	conf.Pipeline.Processors = []processor.Config{blobConf}
+
+	newMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr, stream.OptHotReload(true))
+	require.NoError(t, err)
+
+	tChan, err := newMgr.GetPipe("foo")
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	readUntil := func(expected string) {
+		t.Helper()
+		for {
+			var tran message.Transaction
+			select {
+			case tran = <-tChan:
+			case <-ctx.Done():
+				t.Fatal(ctx.Err())
+			}
+			content := string(tran.Payload.Get(0).AsBytes())
+			require.NoError(t, tran.Ack(ctx, nil))
+			if content == expected {
+				return
+			}
+		}
+	}
+	readUntil("first")
+
+	sameConf := conf
+	applied, err := strm.UpdateInPlace(ctx, sameConf)
+	require.NoError(t, err)
+	assert.True(t, applied)
+
+	newConf := conf
+	newBlobConf := processor.NewConfig()
+	newBlobConf.Type = "bloblang"
+	newBlobConf.Bloblang = `root = "second"`
+	newConf.Pipeline.Processors = []processor.Config{newBlobConf}
+
+	// The previous pipeline can only finish once its in flight messages are
+	// consumed, so we read until messages from the new pipeline arrive.
+	type updateRes struct {
+		applied bool
+		err     error
+	}
+	resChan := make(chan updateRes, 1)
+	go func() {
+		applied, err := strm.UpdateInPlace(ctx, newConf)
+		resChan <- updateRes{applied: applied, err: err}
+	}()
+	readUntil("second")
+
+	res := <-resChan
+	require.NoError(t, res.err)
+	assert.True(t, res.applied)
+
+	go func() {
+		for {
+			select {
+			case tran := <-tChan:
+				_ = tran.Ack(ctx, nil)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	badConf := newConf
+	badBlobConf := processor.NewConfig()
+	badBlobConf.Type = "bloblang"
+	badBlobConf.Bloblang = `root = nope(`
+	badConf.Pipeline.Processors = []processor.Config{badBlobConf}
+
+	applied, err = strm.UpdateInPlace(ctx, badConf)
+	require.Error(t, err)
+	assert.False(t, applied)
+
+	outConf := newConf
+	outConf.Output.Inproc = "bar"
+
+	applied, err = strm.UpdateInPlace(ctx, outConf)
+	require.NoError(t, err)
+	assert.False(t, applied)
+
+	require.NoError(t, strm.Stop(ctx))
+}
+
+func TestTypeUpdateInPlaceDisabled(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = "root = {}"
+	conf.Output.Type = "drop"
+
+	newMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	applied, err := strm.UpdateInPlace(ctx, conf)
+	require.NoError(t, err)
+	assert.True(t, applied)
+
+	conf.Pipeline.Processors = []processor.Config{processor.NewConfig()}
+
+	applied, err = strm.UpdateInPlace(ctx, conf)
+	require.NoError(t, err)
+	assert.False(t, applied)
+
+	require.NoError(t, strm.Stop(ctx))
+}
+
+func TestTypeProcessorProfileEndpoint(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = "root = {}"
+	conf.Input.Generate.Interval = "1ms"
+	conf.Output.Type = "drop"
+
+	procConf := processor.NewConfig()
+	procConf.Type = "mapping"
+	procConf.Plugin = &yaml.Node{Kind: yaml.ScalarNode, Value: "root = this"}
+	procConf.Label = "foo"
+	conf.Pipeline.Processors = []processor.Config{procConf}
+
+	mockAPIReg := newMockAPIReg()
+	defer mockAPIReg.Close()
+
+	newMgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(&mockAPIReg))
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		profiles := strm.ProcessorProfiles(false)
+		return len(profiles) == 1 && profiles[0].Batches > 0
+	}, time.Second, time.Millisecond)
+
+	res, err := http.Get(mockAPIReg.server.URL + "/processors/profile?reset=true")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var result struct {
+		Processors []profile.Profile `json:"processors"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	require.Len(t, result.Processors, 1)
+
+	prof := result.Processors[0]
+	assert.Equal(t, "foo", prof.Label)
+	assert.Equal(t, "root.pipeline.processors.0", prof.Path)
+	assert.Equal(t, "mapping", prof.Type)
+	assert.Greater(t, prof.Batches, int64(0))
+	assert.Equal(t, prof.Batches, prof.Messages)
+	assert.NotEmpty(t, prof.Latency.Buckets)
+	assert.Equal(t, int64(1), prof.BatchSize.Max)
+
+	ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+	defer done()
+	require.NoError(t, strm.StopUnordered(ctx))
+
+	assert.Empty(t, strm.ProcessorProfiles(false))
+}
+
+func TestTypeTapEndpoint(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = {"name":"foo","password":"hunter2"}`
+	conf.Input.Generate.Interval = "1ms"
+	conf.Output.Type = "drop"
+
+	procConf := processor.NewConfig()
+	procConf.Type = "mapping"
+	procConf.Plugin = &yaml.Node{Kind: yaml.ScalarNode, Value: `root = this
+meta token = "abc"`}
+	procConf.Label = "foo"
+	conf.Pipeline.Processors = []processor.Config{procConf}
+
+	mockAPIReg := newMockAPIReg()
+	defer mockAPIReg.Close()
+
+	newMgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(&mockAPIReg))
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	res, err := http.Get(mockAPIReg.server.URL + "/tap?component=nope")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, err = http.Get(mockAPIReg.server.URL + "/tap?component=foo&duration=1h")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, err = http.Get(mockAPIReg.server.URL + "/tap?component=foo&max_messages=3&redact=password&redact_meta=token")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+
+	dec := json.NewDecoder(res.Body)
+	var samples []map[string]any
+	for {
+		var s map[string]any
+		if err := dec.Decode(&s); err == io.EOF {
+			break
+		} else {
+			require.NoError(t, err)
+		}
+		samples = append(samples, s)
+	}
+	require.Len(t, samples, 3)
+	for _, s := range samples {
+		assert.Equal(t, "foo", s["label"])
+		assert.Equal(t, "root.pipeline.processors.0", s["path"])
+		assert.Equal(t, "processor", s["kind"])
+		assert.Equal(t, "mapping", s["type"])
+		assert.JSONEq(t, `{"name":"foo","password":"[REDACTED]"}`, s["content"].(string))
+		assert.Equal(t, map[string]any{"token": "[REDACTED]"}, s["metadata"])
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+	defer done()
+	require.NoError(t, strm.StopUnordered(ctx))
+}
+
+func TestTypeTapWebsocket(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "1ms"
+	conf.Input.Label = "foo"
+	conf.Output.Type = "drop"
+
+	mockAPIReg := newMockAPIReg()
+	defer mockAPIReg.Close()
+
+	newMgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(&mockAPIReg))
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	wsURL := "ws" + strings.TrimPrefix(mockAPIReg.server.URL, "http") + "/tap?component=foo&max_messages=2"
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	for i := 0; i < 2; i++ {
+		var s map[string]any
+		require.NoError(t, ws.ReadJSON(&s))
+		assert.Equal(t, "input", s["kind"])
+		assert.Equal(t, "generate", s["type"])
+		assert.Equal(t, "hello world", s["content"])
+	}
+
+	_, _, err = ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), err)
+
+	ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+	defer done()
+	require.NoError(t, strm.StopUnordered(ctx))
+}
+
+func TestTypeReplayEndpoint(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "1h"
+	conf.Input.Label = "foo"
+
+	procConf := processor.NewConfig()
+	procConf.Type = "mapping"
+	procConf.Plugin = &yaml.Node{Kind: yaml.ScalarNode, Value: `meta fail = cache_get("replay", "fail").catch("false") == "true"`}
+	conf.Pipeline.Processors = []processor.Config{procConf}
+
+	require.NoError(t, yaml.Unmarshal([]byte(`
+switch:
+  cases:
+    - check: '@fail'
+      output:
+        reject: 'failed on purpose'
+    - output:
+        drop: {}
+`), &conf.Output))
+
+	resConf := manager.NewResourceConfig()
+	cacheConf := cache.NewConfig()
+	cacheConf.Label = "replay"
+	cacheConf.Type = "memory"
+	resConf.ResourceCaches = append(resConf.ResourceCaches, cacheConf)
+
+	mockAPIReg := newMockAPIReg()
+	defer mockAPIReg.Close()
+
+	newMgr, err := manager.New(resConf,
+		manager.OptSetAPIReg(&mockAPIReg),
+		manager.OptSetReplayStore("replay", "test_", 10, true),
+	)
+	require.NoError(t, err)
+
+	setFail := func(v string) {
+		require.NoError(t, newMgr.AccessCache(context.Background(), "replay", func(c cache.V1) {
+			require.NoError(t, c.Set(context.Background(), "fail", []byte(v), nil))
+		}))
+	}
+	setFail("true")
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	var entries []map[string]any
+	require.Eventually(t, func() bool {
+		res, err := http.Get(mockAPIReg.server.URL + "/replay")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var body struct {
+			Entries []map[string]any `json:"entries"`
+		}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		entries = body.Entries
+		return len(entries) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	id := entries[0]["id"].(string)
+	assert.Equal(t, "foo", entries[0]["label"])
+	assert.Equal(t, "root.input", entries[0]["path"])
+	assert.Equal(t, "generate", entries[0]["type"])
+	assert.Equal(t, "failed on purpose", entries[0]["error"])
+
+	res, err := http.Get(mockAPIReg.server.URL + "/replay?id=" + id)
+	require.NoError(t, err)
+	var entry struct {
+		Batch []struct {
+			Content []byte `json:"content"`
+		} `json:"batch"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&entry))
+	res.Body.Close()
+	require.Len(t, entry.Batch, 1)
+	assert.Equal(t, "hello world", string(entry.Batch[0].Content))
+
+	res, err = http.Post(mockAPIReg.server.URL+"/replay?id=nope", "", http.NoBody)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	setFail("false")
+
+	res, err = http.Post(mockAPIReg.server.URL+"/replay", "", http.NoBody)
+	require.NoError(t, err)
+	var result stream.ReplayResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []string{id}, result.Replayed)
+	assert.Empty(t, result.Failed)
+
+	res, err = http.Get(mockAPIReg.server.URL + "/replay?id=" + id)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+	defer done()
+	require.NoError(t, strm.StopUnordered(ctx))
+}
+
+func newReplayTestStream(t *testing.T, inputYAML, pipelineYAML string) (*mockAPIReg, *manager.Type) {
+	t.Helper()
+
+	conf := stream.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(inputYAML), &conf.Input))
+	require.NoError(t, yaml.Unmarshal([]byte(pipelineYAML), &conf.Pipeline))
+	require.NoError(t, yaml.Unmarshal([]byte(`
+switch:
+  cases:
+    - check: '@fail'
+      output:
+        reject: 'failed on purpose'
+    - output:
+        cache:
+          target: replay
+          key: delivered
+`), &conf.Output))
+
+	resConf := manager.NewResourceConfig()
+	cacheConf := cache.NewConfig()
+	cacheConf.Label = "replay"
+	cacheConf.Type = "memory"
+	resConf.ResourceCaches = append(resConf.ResourceCaches, cacheConf)
+
+	mockAPIReg := newMockAPIReg()
+	t.Cleanup(mockAPIReg.Close)
+
+	newMgr, err := manager.New(resConf,
+		manager.OptSetAPIReg(&mockAPIReg),
+		manager.OptSetReplayStore("replay", "test_", 10, true),
+	)
+	require.NoError(t, err)
+
+	setReplayCacheKey(t, newMgr, "fail", "true")
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		require.NoError(t, strm.StopUnordered(ctx))
+	})
+	return &mockAPIReg, newMgr
+}
+
+func setReplayCacheKey(t *testing.T, mgr *manager.Type, key, value string) {
+	t.Helper()
+	require.NoError(t, mgr.AccessCache(context.Background(), "replay", func(c cache.V1) {
+		require.NoError(t, c.Set(context.Background(), key, []byte(value), nil))
+	}))
+}
+
+func waitForReplayEntry(t *testing.T, serverURL string) string {
+	t.Helper()
+
+	var entries []map[string]any
+	require.Eventually(t, func() bool {
+		res, err := http.Get(serverURL + "/replay")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var body struct {
+			Entries []map[string]any `json:"entries"`
+		}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		entries = body.Entries
+		return len(entries) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	return entries[0]["id"].(string)
+}
+
+func TestTypeReplayInputProcessors(t *testing.T) {
+	mockAPIReg, mgr := newReplayTestStream(t, `
+label: foo
+generate:
+  mapping: 'root = "hello world"'
+  interval: 1h
+processors:
+  - mapping: 'root = content().string() + "!"'
+`, `
+processors:
+  - mapping: 'meta fail = cache_get("replay", "fail").catch("false") == "true"'
+`)
+
+	id := waitForReplayEntry(t, mockAPIReg.server.URL)
+
+	// The batch is captured after the processors of the input.
+	res, err := http.Get(mockAPIReg.server.URL + "/replay?id=" + id)
+	require.NoError(t, err)
+	var entry struct {
+		Batch []struct {
+			Content []byte `json:"content"`
+		} `json:"batch"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&entry))
+	res.Body.Close()
+	require.Len(t, entry.Batch, 1)
+	assert.Equal(t, "hello world!", string(entry.Batch[0].Content))
+
+	setReplayCacheKey(t, mgr, "fail", "false")
+
+	res, err = http.Post(mockAPIReg.server.URL+"/replay?id="+id, "", http.NoBody)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = http.Get(mockAPIReg.server.URL + "/replay?id=" + id)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// The replayed batch reaches the output in the same shape as the original,
+	// without the input processors being applied again.
+	require.NoError(t, mgr.AccessCache(context.Background(), "replay", func(c cache.V1) {
+		delivered, err := c.Get(context.Background(), "delivered")
+		require.NoError(t, err)
+		assert.Equal(t, "hello world!", string(delivered))
+	}))
+}
+
+func TestTypeReplayTimeout(t *testing.T) {
+	mockAPIReg, mgr := newReplayTestStream(t, `
+generate:
+  mapping: 'root = "hello world"'
+  interval: 1h
+`, `
+processors:
+  - sleep:
+      duration: 500ms
+  - mapping: 'meta fail = cache_get("replay", "fail").catch("false") == "true"'
+`)
+
+	id := waitForReplayEntry(t, mockAPIReg.server.URL)
+	setReplayCacheKey(t, mgr, "fail", "false")
+
+	res, err := http.Post(mockAPIReg.server.URL+"/replay?timeout=50ms&id="+id, "", http.NoBody)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+
+	// The injected batch is still being delivered, and therefore replaying it
+	// again is rejected rather than duplicating it.
+	res, err = http.Post(mockAPIReg.server.URL+"/replay?id="+id, "", http.NoBody)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+	// Once delivered the entry is deleted.
+	assert.Eventually(t, func() bool {
+		res, err := http.Get(mockAPIReg.server.URL + "/replay?id=" + id)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode == http.StatusNotFound
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,358 @@
+// Package tap provides a registry of points within a pipeline where copies of
+// messages can be sampled on demand, which allows the messages flowing through
+// a component to be observed without modifying the config of a stream.
+package tap
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"path"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// ErrComponentNotFound is returned when a tap is opened for a component that
+// does not exist.
+var ErrComponentNotFound = errors.New("component not found")
+
+// Redacted is the value that redacted fields and metadata are replaced with.
+const Redacted = "[REDACTED]"
+
+// Registry keeps track of the tap points of components.
+type Registry struct {
+	mut    sync.Mutex
+	points map[*Point]struct{}
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		points: map[*Point]struct{}{},
+	}
+}
+
+// Register a new component with the registry. The returned point should be
+// deregistered once the component is closed.
+func (r *Registry) Register(stream, label, path, kind, typeStr string) *Point {
+	p := &Point{
+		reg:      r,
+		stream:   stream,
+		label:    label,
+		path:     path,
+		kind:     kind,
+		typeStr:  typeStr,
+		sessions: map[*Session]struct{}{},
+	}
+	r.mut.Lock()
+	r.points[p] = struct{}{}
+	r.mut.Unlock()
+	return p
+}
+
+// Open a session that samples the messages of each component of a given stream
+// where either the label or path matches the component argument. Returns
+// ErrComponentNotFound if no components match. The session must be closed once
+// it is no longer needed.
+func (r *Registry) Open(stream, component string, opts Options) (*Session, error) {
+	s := newSession(opts)
+
+	r.mut.Lock()
+	for p := range r.points {
+		if p.stream != stream || (p.label != component && p.path != component) {
+			continue
+		}
+		p.attach(s)
+		s.points = append(s.points, p)
+	}
+	r.mut.Unlock()
+
+	if len(s.points) == 0 {
+		return nil, ErrComponentNotFound
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Point is a location within a pipeline where messages can be sampled. All
+// methods are safe to call concurrently, and a nil point is valid and samples
+// nothing.
+type Point struct {
+	reg *Registry
+
+	stream  string
+	label   string
+	path    string
+	kind    string
+	typeStr string
+
+	active   int32
+	mut      sync.RWMutex
+	sessions map[*Session]struct{}
+}
+
+func (p *Point) attach(s *Session) {
+	p.mut.Lock()
+	p.sessions[s] = struct{}{}
+	atomic.StoreInt32(&p.active, int32(len(p.sessions)))
+	p.mut.Unlock()
+}
+
+func (p *Point) detach(s *Session) {
+	p.mut.Lock()
+	delete(p.sessions, s)
+	atomic.StoreInt32(&p.active, int32(len(p.sessions)))
+	p.mut.Unlock()
+}
+
+// Observe offers the messages of a batch to any open sessions of the point.
+// This never blocks, and when no sessions are open it costs a single atomic
+// load.
+func (p *Point) Observe(batch message.Batch) {
+	if p == nil || atomic.LoadInt32(&p.active) == 0 {
+		return
+	}
+	now := time.Now()
+
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	for s := range p.sessions {
+		for _, part := range batch {
+			s.offer(p, part, now)
+		}
+	}
+}
+
+// Deregister removes the point from its registry.
+func (p *Point) Deregister() {
+	if p == nil || p.reg == nil {
+		return
+	}
+	p.reg.mut.Lock()
+	delete(p.reg.points, p)
+	p.reg.mut.Unlock()
+}
+
+//------------------------------------------------------------------------------
+
+// Options determine which messages are sampled by a session and how they are
+// redacted.
+type Options struct {
+	// The ratio of messages to sample, between 0 and 1.
+	SampleRatio float64
+
+	// The maximum number of messages to sample, where zero is unlimited.
+	MaxMessages int64
+
+	// Dot separated paths of fields to redact from the contents of messages,
+	// where each segment may contain glob wildcards. When set the contents of
+	// messages that are not valid JSON are redacted in full.
+	RedactFields []string
+
+	// Glob patterns of metadata keys to redact.
+	RedactMetadata []string
+}
+
+// Sample is a copy of a message observed by a component.
+type Sample struct {
+	Label    string         `json:"label,omitempty"`
+	Path     string         `json:"path"`
+	Kind     string         `json:"kind"`
+	Type     string         `json:"type"`
+	At       time.Time      `json:"at"`
+	Content  string         `json:"content"`
+	Metadata map[string]any `json:"metadata"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// Session receives samples of messages from the components it was opened for.
+type Session struct {
+	opts         Options
+	redactFields [][]string
+
+	points    []*Point
+	samples   chan Sample
+	remaining int64
+	dropped   int64
+	closeOnce sync.Once
+}
+
+// The number of samples a session buffers before further samples are dropped
+// until the buffer is read.
+const sessionBufferSize = 256
+
+func newSession(opts Options) *Session {
+	s := &Session{
+		opts:      opts,
+		samples:   make(chan Sample, sessionBufferSize),
+		remaining: opts.MaxMessages,
+	}
+	for _, f := range opts.RedactFields {
+		s.redactFields = append(s.redactFields, splitPath(f))
+	}
+	return s
+}
+
+// Samples returns a channel of sampled messages.
+func (s *Session) Samples() <-chan Sample {
+	return s.samples
+}
+
+// Dropped returns the number of samples that were dropped because they were
+// not read quickly enough.
+func (s *Session) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
+
+// Close detaches the session from its components, after which no further
+// samples are received.
+func (s *Session) Close() {
+	s.closeOnce.Do(func() {
+		for _, p := range s.points {
+			p.detach(s)
+		}
+	})
+}
+
+func (s *Session) offer(p *Point, part *message.Part, at time.Time) {
+	if s.opts.SampleRatio < 1 && rand.Float64() >= s.opts.SampleRatio {
+		return
+	}
+	if s.opts.MaxMessages > 0 && atomic.AddInt64(&s.remaining, -1) < 0 {
+		return
+	}
+
+	sample := Sample{
+		Label:    p.label,
+		Path:     p.path,
+		Kind:     p.kind,
+		Type:     p.typeStr,
+		At:       at,
+		Content:  s.content(part),
+		Metadata: s.metadata(part),
+	}
+	if err := part.ErrorGet(); err != nil {
+		sample.Error = err.Error()
+	}
+
+	select {
+	case s.samples <- sample:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+}
+
+func (s *Session) content(part *message.Part) string {
+	raw := part.AsBytes()
+	if len(s.redactFields) == 0 {
+		return string(raw)
+	}
+
+	// The raw bytes are parsed rather than the structured form of the part in
+	// order to avoid changing the state of a message that is still in flight.
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return Redacted
+	}
+	for _, f := range s.redactFields {
+		v = redactPath(v, f)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return Redacted
+	}
+	return string(b)
+}
+
+func (s *Session) metadata(part *message.Part) map[string]any {
+	meta := map[string]any{}
+	_ = part.MetaIterMut(func(k string, v any) error {
+		if matchAny(s.opts.RedactMetadata, k) {
+			meta[k] = Redacted
+		} else {
+			meta[k] = message.CopyJSON(v)
+		}
+		return nil
+	})
+	return meta
+}
+
+//------------------------------------------------------------------------------
+
+func splitPath(p string) []string {
+	var segments []string
+	start := 0
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			i++
+		case '.':
+			segments = append(segments, p[start:i])
+			start = i + 1
+		}
+	}
+	return append(segments, p[start:])
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if matched, _ := path.Match(p, s); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// redactPath replaces the values of a structured document found at a path,
+// where each segment of the path may contain glob wildcards that match object
+// keys or array indexes.
+func redactPath(v any, segments []string) any {
+	if len(segments) == 0 {
+		return Redacted
+	}
+	seg := segments[0]
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if matched, _ := path.Match(seg, k); matched {
+				t[k] = redactPath(child, segments[1:])
+			}
+		}
+	case []any:
+		for i, child := range t {
+			if matched, _ := path.Match(seg, strconv.Itoa(i)); matched {
+				t[i] = redactPath(child, segments[1:])
+			}
+		}
+	}
+	return v
+}
+
+//------------------------------------------------------------------------------
+
+// FromManager registers a component with the tap registry of a manager, or
+// returns nil if the manager does not support taps.
+func FromManager(mgr any, kind, typeStr string) *Point {
+	if tm, ok := mgr.(interface {
+		RegisterTap(kind, typeStr string) *Point
+	}); ok {
+		return tm.RegisterTap(kind, typeStr)
+	}
+	return nil
+}
+
+// OpenFromManager opens a session for a component of the stream of a manager,
+// or returns ErrComponentNotFound if the manager does not support taps.
+func OpenFromManager(mgr any, component string, opts Options) (*Session, error) {
+	if tm, ok := mgr.(interface {
+		OpenTap(component string, opts Options) (*Session, error)
+	}); ok {
+		return tm.OpenTap(component, opts)
+	}
+	return nil, ErrComponentNotFound
+}
@@ -0,0 +1,127 @@
+package tap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func drainSamples(s *Session) (samples []Sample) {
+	for {
+		select {
+		case sample := <-s.Samples():
+			samples = append(samples, sample)
+		default:
+			return
+		}
+	}
+}
+
+func TestRegistryOpen(t *testing.T) {
+	reg := NewRegistry()
+
+	in := reg.Register("foo", "in", "root.input", "input", "generate")
+	proc := reg.Register("foo", "", "root.pipeline.processors.0", "processor", "mapping")
+	other := reg.Register("bar", "in", "root.input", "input", "generate")
+
+	_, err := reg.Open("foo", "nope", Options{SampleRatio: 1})
+	assert.ErrorIs(t, err, ErrComponentNotFound)
+
+	byLabel, err := reg.Open("foo", "in", Options{SampleRatio: 1})
+	require.NoError(t, err)
+
+	byPath, err := reg.Open("foo", "root.pipeline.processors.0", Options{SampleRatio: 1})
+	require.NoError(t, err)
+
+	in.Observe(message.QuickBatch([][]byte{[]byte("a"), []byte("b")}))
+	proc.Observe(message.QuickBatch([][]byte{[]byte("c")}))
+	other.Observe(message.QuickBatch([][]byte{[]byte("d")}))
+
+	samples := drainSamples(byLabel)
+	require.Len(t, samples, 2)
+	assert.Equal(t, "a", samples[0].Content)
+	assert.Equal(t, "b", samples[1].Content)
+	assert.Equal(t, "in", samples[0].Label)
+	assert.Equal(t, "root.input", samples[0].Path)
+	assert.Equal(t, "input", samples[0].Kind)
+	assert.Equal(t, "generate", samples[0].Type)
+
+	samples = drainSamples(byPath)
+	require.Len(t, samples, 1)
+	assert.Equal(t, "c", samples[0].Content)
+
+	byLabel.Close()
+	in.Observe(message.QuickBatch([][]byte{[]byte("e")}))
+	assert.Empty(t, drainSamples(byLabel))
+
+	in.Deregister()
+	_, err = reg.Open("foo", "in", Options{SampleRatio: 1})
+	assert.ErrorIs(t, err, ErrComponentNotFound)
+}
+
+func TestSessionLimits(t *testing.T) {
+	reg := NewRegistry()
+	p := reg.Register("", "", "root.output", "output", "drop")
+
+	s, err := reg.Open("", "root.output", Options{SampleRatio: 1, MaxMessages: 3})
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		p.Observe(message.QuickBatch([][]byte{[]byte("a")}))
+	}
+	assert.Len(t, drainSamples(s), 3)
+
+	s, err = reg.Open("", "root.output", Options{SampleRatio: 1})
+	require.NoError(t, err)
+
+	for i := 0; i < sessionBufferSize+10; i++ {
+		p.Observe(message.QuickBatch([][]byte{[]byte("a")}))
+	}
+	assert.Len(t, drainSamples(s), sessionBufferSize)
+	assert.Equal(t, int64(10), s.Dropped())
+
+	var nilPoint *Point
+	nilPoint.Observe(message.QuickBatch([][]byte{[]byte("a")}))
+	nilPoint.Deregister()
+}
+
+func TestSessionRedaction(t *testing.T) {
+	reg := NewRegistry()
+	p := reg.Register("", "proc", "root.pipeline.processors.0", "processor", "mapping")
+
+	s, err := reg.Open("", "proc", Options{
+		SampleRatio:    1,
+		RedactFields:   []string{"user.email", "cards.*.number", "tok*"},
+		RedactMetadata: []string{"authorization", "x_secret_*"},
+	})
+	require.NoError(t, err)
+
+	part := message.NewPart([]byte(`{"user":{"name":"foo","email":"foo@example.com"},"cards":[{"number":"1234","type":"visa"}],"token":"abc"}`))
+	part.MetaSetMut("authorization", "Bearer abc")
+	part.MetaSetMut("x_secret_key", "abc")
+	part.MetaSetMut("kafka_key", "bar")
+	part.ErrorSet(errors.New("nope"))
+
+	p.Observe(message.Batch{part, message.NewPart([]byte(`not json`))})
+
+	samples := drainSamples(s)
+	require.Len(t, samples, 2)
+
+	assert.JSONEq(t, `{"user":{"name":"foo","email":"[REDACTED]"},"cards":[{"number":"[REDACTED]","type":"visa"}],"token":"[REDACTED]"}`, samples[0].Content)
+	assert.Equal(t, map[string]any{
+		"authorization": "[REDACTED]",
+		"x_secret_key":  "[REDACTED]",
+		"kafka_key":     "bar",
+	}, samples[0].Metadata)
+	assert.Equal(t, "nope", samples[0].Error)
+	assert.Equal(t, "[REDACTED]", samples[1].Content)
+
+	// The original message must not be modified.
+	assert.Contains(t, string(part.AsBytes()), "foo@example.com")
+	v, _ := part.MetaGetMut("authorization")
+	assert.Equal(t, "Bearer abc", v)
+}
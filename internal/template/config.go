@@ -4,13 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/nsf/jsondiff"
 	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
@@ -19,19 +22,24 @@ import (
 
 // FieldConfig describes a configuration field used in the template.
 type FieldConfig struct {
-	Name        string  `yaml:"name"`
-	Description string  `yaml:"description"`
-	Type        *string `yaml:"type,omitempty"`
-	Kind        *string `yaml:"kind,omitempty"`
-	Default     *any    `yaml:"default,omitempty"`
-	Advanced    bool    `yaml:"advanced"`
+	Name           string   `yaml:"name"`
+	Description    string   `yaml:"description"`
+	Type           *string  `yaml:"type,omitempty"`
+	Kind           *string  `yaml:"kind,omitempty"`
+	Default        *any     `yaml:"default,omitempty"`
+	DefaultMapping string   `yaml:"default_mapping,omitempty"`
+	Options        []any    `yaml:"options,omitempty"`
+	Minimum        *float64 `yaml:"minimum,omitempty"`
+	Maximum        *float64 `yaml:"maximum,omitempty"`
+	Advanced       bool     `yaml:"advanced"`
 }
 
 // TestConfig defines a unit test for the template.
 type TestConfig struct {
-	Name     string    `yaml:"name"`
-	Config   yaml.Node `yaml:"config"`
-	Expected yaml.Node `yaml:"expected,omitempty"`
+	Name          string    `yaml:"name"`
+	Config        yaml.Node `yaml:"config"`
+	Expected      yaml.Node `yaml:"expected,omitempty"`
+	ExpectedError string    `yaml:"expected_error,omitempty"`
 }
 
 // Config describes a Benthos component template.
@@ -70,9 +78,87 @@ func (c FieldConfig) FieldSpec() (docs.FieldSpec, error) {
 			return f, fmt.Errorf("unrecognised scalar type: %v", *c.Kind)
 		}
 	}
+	if c.DefaultMapping != "" {
+		if c.Default != nil {
+			return f, errors.New("fields cannot specify both a default and a default_mapping")
+		}
+		f = f.Optional()
+	}
+	if len(c.Options) > 0 {
+		options := make([]string, len(c.Options))
+		for i, o := range c.Options {
+			options[i] = fmt.Sprintf("%v", o)
+		}
+		f = f.HasOptions(options...)
+	}
+	if len(c.Options) > 0 || c.Minimum != nil || c.Maximum != nil {
+		f = f.LinterFunc(func(ctx docs.LintContext, line, col int, value any) []docs.Lint {
+			switch value.(type) {
+			case []any, map[string]any:
+				// Linters are also called with each element of lists and maps.
+				return nil
+			}
+			if err := c.checkScalar(value); err != nil {
+				return []docs.Lint{docs.NewLintError(line, docs.LintCustom, err.Error())}
+			}
+			return nil
+		})
+	}
 	return f, nil
 }
 
+func (c FieldConfig) checkScalar(value any) error {
+	if len(c.Options) > 0 {
+		valueStr := fmt.Sprintf("%v", value)
+		var matched bool
+		for _, o := range c.Options {
+			if fmt.Sprintf("%v", o) == valueStr {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("value %v is not a valid option, expected one of %v", value, c.Options)
+		}
+	}
+	if c.Minimum == nil && c.Maximum == nil {
+		return nil
+	}
+	n, err := query.IGetNumber(value)
+	if err != nil {
+		return err
+	}
+	if c.Minimum != nil && n < *c.Minimum {
+		return fmt.Errorf("value %v is less than the minimum of %v", value, *c.Minimum)
+	}
+	if c.Maximum != nil && n > *c.Maximum {
+		return fmt.Errorf("value %v is greater than the maximum of %v", value, *c.Maximum)
+	}
+	return nil
+}
+
+// checkValue checks a value against the constraints of the field, where the
+// elements of list and map kinds are checked individually.
+func (c FieldConfig) checkValue(value any) error {
+	switch t := value.(type) {
+	case []any:
+		for i, v := range t {
+			if err := c.checkValue(v); err != nil {
+				return fmt.Errorf("index %v: %w", i, err)
+			}
+		}
+		return nil
+	case map[string]any:
+		for k, v := range t {
+			if err := c.checkValue(v); err != nil {
+				return fmt.Errorf("key %v: %w", k, err)
+			}
+		}
+		return nil
+	}
+	return c.checkScalar(value)
+}
+
 // ComponentSpec creates a documentation component spec from a template config.
 func (c Config) ComponentSpec() (docs.ComponentSpec, error) {
 	fields := make([]docs.FieldSpec, len(c.Fields))
@@ -101,26 +187,44 @@ func (c Config) ComponentSpec() (docs.ComponentSpec, error) {
 	}, nil
 }
 
+func parseMapping(blobl string) (*mapping.Executor, error) {
+	m, err := bloblang.GlobalEnvironment().NewMapping(blobl)
+	if err != nil {
+		var perr *parser.Error
+		if errors.As(err, &perr) {
+			return nil, errors.New(perr.ErrorAtPositionStructured("", []rune(blobl)))
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c Config) compile() (*compiled, error) {
 	spec, err := c.ComponentSpec()
 	if err != nil {
 		return nil, err
 	}
-	mapping, err := bloblang.GlobalEnvironment().NewMapping(c.Mapping)
+	mapping, err := parseMapping(c.Mapping)
 	if err != nil {
-		var perr *parser.Error
-		if errors.As(err, &perr) {
-			return nil, fmt.Errorf("parse mapping: %v", perr.ErrorAtPositionStructured("", []rune(c.Mapping)))
-		}
 		return nil, fmt.Errorf("parse mapping: %w", err)
 	}
+	fields := make([]compiledField, len(c.Fields))
+	for i, f := range c.Fields {
+		fields[i].FieldConfig = f
+		if f.DefaultMapping == "" {
+			continue
+		}
+		if fields[i].defaultMapping, err = parseMapping(f.DefaultMapping); err != nil {
+			return nil, fmt.Errorf("field %v: parse default_mapping: %w", f.Name, err)
+		}
+	}
 	var metricsMapping *metrics.Mapping
 	if c.MetricsMapping != "" {
 		if metricsMapping, err = metrics.NewMapping(c.MetricsMapping, log.Noop()); err != nil {
 			return nil, fmt.Errorf("parse metrics mapping: %w", err)
 		}
 	}
-	return &compiled{spec: spec, mapping: mapping, metricsMapping: metricsMapping}, nil
+	return &compiled{spec: spec, fields: fields, mapping: mapping, metricsMapping: metricsMapping}, nil
 }
 
 func diffYAMLNodesAsJSON(expNode, actNode *yaml.Node) (string, error) {
@@ -149,22 +253,51 @@ func diffYAMLNodesAsJSON(expNode, actNode *yaml.Node) (string, error) {
 	return "", nil
 }
 
-// Test ensures that the template compiles, and executes any unit test
-// definitions within the config.
-func (c Config) Test() ([]string, error) {
+// TestResult describes the outcome of a unit test of a template.
+type TestResult struct {
+	Name     string
+	Failures []string
+}
+
+// RunTests compiles the template and executes its unit test definitions,
+// returning the result of each test. Any other templates provided can be
+// nested within this template.
+func (c Config) RunTests(others ...Config) ([]TestResult, error) {
+	reg := newRegistry()
+	for _, o := range others {
+		tmpl, err := o.compile()
+		if err != nil {
+			return nil, fmt.Errorf("template %v: %w", o.Name, err)
+		}
+		reg.add(tmpl)
+	}
+
 	compiled, err := c.compile()
 	if err != nil {
 		return nil, err
 	}
+	reg.add(compiled)
+
+	results := make([]TestResult, len(c.Tests))
+	for i, test := range c.Tests {
+		results[i].Name = test.Name
 
-	var failures []string
-	for _, test := range c.Tests {
 		outConf, err := compiled.ExpandToNode(&test.Config)
+		if test.ExpectedError != "" {
+			if err == nil {
+				results[i].Failures = append(results[i].Failures, fmt.Sprintf("expected error containing '%v', but the template expanded successfully", test.ExpectedError))
+			} else if !strings.Contains(err.Error(), test.ExpectedError) {
+				results[i].Failures = append(results[i].Failures, fmt.Sprintf("expected error containing '%v', got: %v", test.ExpectedError, err))
+			}
+			continue
+		}
 		if err != nil {
-			return nil, fmt.Errorf("test '%v': %w", test.Name, err)
+			results[i].Failures = append(results[i].Failures, err.Error())
+			continue
 		}
+
 		for _, lint := range docs.LintYAML(docs.NewLintContext(docs.NewLintConfig()), docs.Type(c.Type), outConf) {
-			failures = append(failures, fmt.Sprintf("test '%v': lint error in resulting config: %v", test.Name, lint.Error()))
+			results[i].Failures = append(results[i].Failures, fmt.Sprintf("lint error in resulting config: %v", lint.Error()))
 		}
 		if len(test.Expected.Content) > 0 {
 			diff, err := diffYAMLNodesAsJSON(&test.Expected, outConf)
@@ -173,10 +306,28 @@ func (c Config) Test() ([]string, error) {
 			}
 			if diff != "" {
 				diff = color.New(color.Reset).SprintFunc()(diff)
-				return nil, fmt.Errorf("test '%v': mismatch between expected and actual resulting config: %v", test.Name, diff)
+				results[i].Failures = append(results[i].Failures, fmt.Sprintf("mismatch between expected and actual resulting config: %v", diff))
 			}
 		}
 	}
+	return results, nil
+}
+
+// Test ensures that the template compiles, and executes any unit test
+// definitions within the config. Any other templates provided can be nested
+// within this template.
+func (c Config) Test(others ...Config) ([]string, error) {
+	results, err := c.RunTests(others...)
+	if err != nil {
+		return nil, err
+	}
+
+	var failures []string
+	for _, res := range results {
+		for _, f := range res.Failures {
+			failures = append(failures, fmt.Sprintf("test '%v': %v", res.Name, f))
+		}
+	}
 	return failures, nil
 }
 
@@ -223,6 +374,10 @@ func FieldConfigSpec() docs.FieldSpecs {
 			"scalar", "map", "list",
 		).HasDefault("scalar"),
 		docs.FieldAnything("default", "An optional default value for the field. If a default value is not specified then a configuration without the field is considered incorrect.").Optional(),
+		docs.FieldBloblang("default_mapping", "An optional [Bloblang](/docs/guides/bloblang/about) mapping that computes a default value for the field when it is not specified. The mapping is executed on an object containing the fields of the template, including any defaults computed by fields listed before it, and the field is omitted when the mapping deletes the root. This field cannot be combined with `default`.").HasDefault(""),
+		docs.FieldAnything("options", "An optional list of values that the field is restricted to, where configs specifying any other value fail linting and cannot be expanded.").Array().Optional(),
+		docs.FieldFloat("minimum", "An optional minimum value of a numerical field, where configs specifying a lower value fail linting and cannot be expanded.").Optional(),
+		docs.FieldFloat("maximum", "An optional maximum value of a numerical field, where configs specifying a higher value fail linting and cannot be expanded.").Optional(),
 		docs.FieldBool("advanced", "Whether this field is considered advanced.").HasDefault(false),
 	}
 }
@@ -258,15 +413,16 @@ func ConfigSpec() docs.FieldSpecs {
 		docs.FieldString("description", "A longer form description of the component and how to use it.").HasDefault(""),
 		docs.FieldObject("fields", "The configuration fields of the template, fields specified here will be parsed from a Benthos config and will be accessible from the template mapping.").Array().WithChildren(FieldConfigSpec()...),
 		docs.FieldBloblang(
-			"mapping", "A [Bloblang](/docs/guides/bloblang/about) mapping that translates the fields of the template into a valid Benthos configuration for the target component type.",
+			"mapping", "A [Bloblang](/docs/guides/bloblang/about) mapping that translates the fields of the template into a valid Benthos configuration for the target component type. The resulting config may use another template of the same component type, which is expanded in turn.",
 		),
 		templateMetricsMappingDocs(),
 		docs.FieldObject(
-			"tests", "Optional unit test definitions for the template that verify certain configurations produce valid configs. These tests are executed with the command `benthos template lint`.",
+			"tests", "Optional unit test definitions for the template that verify certain configurations produce valid configs. These tests are executed with the commands `benthos template lint` and `benthos template test`.",
 		).Array().WithChildren(
 			docs.FieldString("name", "A name to identify the test."),
 			docs.FieldObject("config", "A configuration to run this test with, the config resulting from applying the template with this config will be linted."),
 			docs.FieldObject("expected", "An optional configuration describing the expected result of applying the template, when specified the result will be diffed and any mismatching fields will be reported as a test error.").Optional(),
+			docs.FieldString("expected_error", "An optional string that applying the template to the config is expected to fail with an error containing, which is useful for testing the constraints of fields.").Optional(),
 		).HasDefault([]any{}),
 	}
 }
@@ -1,7 +1,10 @@
 package template
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 
@@ -45,41 +48,214 @@ func InitTemplates(templatesPaths ...string) ([]string, error) {
 
 //------------------------------------------------------------------------------
 
+// maxNestingDepth is the maximum number of templates that can be nested
+// within each other.
+const maxNestingDepth = 10
+
+// registry contains the compiled templates of an environment, which allows
+// templates to be expanded within other templates.
+type registry struct {
+	mut       sync.RWMutex
+	templates map[docs.Type]map[string]*compiled
+}
+
+func newRegistry() *registry {
+	return &registry{templates: map[docs.Type]map[string]*compiled{}}
+}
+
+func (r *registry) add(tmpl *compiled) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	tmpl.reg = r
+	if r.templates[tmpl.spec.Type] == nil {
+		r.templates[tmpl.spec.Type] = map[string]*compiled{}
+	}
+	r.templates[tmpl.spec.Type][tmpl.spec.Name] = tmpl
+}
+
+func (r *registry) get(cType docs.Type, name string) *compiled {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	return r.templates[cType][name]
+}
+
+var (
+	envRegistriesMut sync.Mutex
+	envRegistries    = map[*bundle.Environment]*registry{}
+)
+
+func registryFor(env *bundle.Environment) *registry {
+	envRegistriesMut.Lock()
+	defer envRegistriesMut.Unlock()
+
+	reg, exists := envRegistries[env]
+	if !exists {
+		reg = newRegistry()
+		envRegistries[env] = reg
+	}
+	return reg
+}
+
+//------------------------------------------------------------------------------
+
+type compiledField struct {
+	FieldConfig
+	defaultMapping *mapping.Executor
+}
+
 // Compiled is a template that has been compiled from a config.
 type compiled struct {
 	spec           docs.ComponentSpec
+	fields         []compiledField
 	mapping        *mapping.Executor
 	metricsMapping *metrics.Mapping
+	reg            *registry
+}
+
+func execMapping(m *mapping.Executor, generic any) (any, bool, error) {
+	part := message.NewPart(nil)
+	part.SetStructuredMut(generic)
+
+	newPart, err := m.MapPart(0, message.Batch{part})
+	if err != nil {
+		return nil, false, err
+	}
+	if newPart == nil {
+		return nil, false, nil
+	}
+
+	v, err := newPart.AsStructured()
+	if err != nil {
+		return nil, false, err
+	}
+	return v, true, nil
+}
+
+// applyFields computes the defaults of fields that have a default mapping and
+// checks the values of fields against their constraints.
+func (c *compiled) applyFields(conf map[string]any) error {
+	for _, f := range c.fields {
+		if _, exists := conf[f.Name]; !exists && f.defaultMapping != nil {
+			v, ok, err := execMapping(f.defaultMapping, conf)
+			if err != nil {
+				return fmt.Errorf("field %v: default mapping failed: %w", f.Name, err)
+			}
+			if ok {
+				conf[f.Name] = v
+			}
+		}
+		if v, exists := conf[f.Name]; exists {
+			if err := f.checkValue(v); err != nil {
+				return fmt.Errorf("field %v: %w", f.Name, err)
+			}
+		}
+	}
+	return nil
 }
 
 // ExpandToNode attempts to apply the template to a provided YAML node and
 // returns the new expanded configuration.
 func (c *compiled) ExpandToNode(node *yaml.Node) (*yaml.Node, error) {
+	resultGeneric, err := c.expand(node, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resultNode yaml.Node
+	if err := resultNode.Encode(resultGeneric); err != nil {
+		return nil, fmt.Errorf("mapping for template component resulted in invalid yaml: %w", err)
+	}
+
+	return &resultNode, nil
+}
+
+func (c *compiled) expand(node *yaml.Node, stack []string) (any, error) {
 	generic, err := c.spec.Config.Children.YAMLToMap(node, docs.ToValueConfig{})
 	if err != nil {
 		return nil, fmt.Errorf("invalid config for template component: %w", err)
 	}
+	if generic == nil {
+		generic = map[string]any{}
+	}
+	if err := c.applyFields(generic); err != nil {
+		return nil, fmt.Errorf("invalid config for template component: %w", err)
+	}
 
-	part := message.NewPart(nil)
-	part.SetStructuredMut(generic)
-	msg := message.Batch{part}
-
-	newPart, err := c.mapping.MapPart(0, msg)
+	resultGeneric, ok, err := execMapping(c.mapping, generic)
 	if err != nil {
 		return nil, fmt.Errorf("mapping failed for template component: %w", err)
 	}
+	if !ok {
+		return nil, errors.New("mapping for template component resulted in invalid config: root was deleted")
+	}
+	return c.expandNested(resultGeneric, append(stack, c.spec.Name))
+}
 
-	resultGeneric, err := newPart.AsStructured()
-	if err != nil {
-		return nil, fmt.Errorf("mapping for template component resulted in invalid config: %w", err)
+// expandNested expands the result of a template when it is a config for
+// another template of the same component type, where the label and processors
+// of the result are combined with those of the nested template.
+func (c *compiled) expandNested(result any, stack []string) (any, error) {
+	resMap, ok := result.(map[string]any)
+	if !ok || c.reg == nil {
+		return result, nil
 	}
 
-	var resultNode yaml.Node
-	if err := resultNode.Encode(resultGeneric); err != nil {
+	var name string
+	for k := range resMap {
+		if k == "label" || k == "processors" {
+			continue
+		}
+		if name != "" {
+			// Configs with multiple component types are caught by linting.
+			return result, nil
+		}
+		name = k
+	}
+
+	nested := c.reg.get(c.spec.Type, name)
+	if nested == nil {
+		return result, nil
+	}
+	for _, s := range stack {
+		if s == name {
+			return nil, fmt.Errorf("template %v is nested within itself: %v", name, strings.Join(append(stack, name), " -> "))
+		}
+	}
+	if len(stack) >= maxNestingDepth {
+		return nil, fmt.Errorf("templates cannot be nested more than %v levels deep: %v", maxNestingDepth, strings.Join(append(stack, name), " -> "))
+	}
+
+	var nestedNode yaml.Node
+	if err := nestedNode.Encode(resMap[name]); err != nil {
 		return nil, fmt.Errorf("mapping for template component resulted in invalid yaml: %w", err)
 	}
+	nestedResult, err := nested.expand(&nestedNode, stack)
+	if err != nil {
+		return nil, fmt.Errorf("nested template %v: %w", name, err)
+	}
 
-	return &resultNode, nil
+	nestedMap, ok := nestedResult.(map[string]any)
+	if !ok {
+		return nestedResult, nil
+	}
+	if label, exists := resMap["label"]; exists {
+		if _, exists := nestedMap["label"]; !exists {
+			nestedMap["label"] = label
+		}
+	}
+	if procs, _ := resMap["processors"].([]any); len(procs) > 0 {
+		nestedProcs, _ := nestedMap["processors"].([]any)
+		switch c.spec.Type {
+		case docs.TypeInput:
+			// Processors of the outer template are applied after those of
+			// the nested template.
+			nestedMap["processors"] = append(nestedProcs, procs...)
+		case docs.TypeOutput:
+			nestedMap["processors"] = append(procs, nestedProcs...)
+		}
+	}
+	return nestedMap, nil
 }
 
 //------------------------------------------------------------------------------
@@ -103,6 +279,7 @@ func RegisterTemplateYAML(env *bundle.Environment, template []byte) error {
 // RegisterTemplate attempts to add a template component to the global list of
 // component types.
 func registerTemplate(env *bundle.Environment, tmpl *compiled) error {
+	registryFor(env).add(tmpl)
 	switch tmpl.spec.Type {
 	case docs.TypeCache:
 		return registerCacheTemplate(tmpl, env)
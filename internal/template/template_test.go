@@ -0,0 +1,165 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/template"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
+)
+
+func readTemplate(t *testing.T, yamlStr string) template.Config {
+	t.Helper()
+	conf, lints, err := template.ReadConfigYAML([]byte(yamlStr))
+	require.NoError(t, err)
+	require.Empty(t, lints)
+	return conf
+}
+
+func TestTemplateFieldConstraints(t *testing.T) {
+	conf := readTemplate(t, `
+name: test_constrained
+type: processor
+fields:
+  - name: mode
+    type: string
+    options: [ upper, lower ]
+  - name: count
+    type: int
+    minimum: 1
+    maximum: 10
+    default_mapping: 'root = if this.mode == "upper" { 5 } else { 2 }'
+  - name: ids
+    type: int
+    kind: list
+    minimum: 0
+    default: []
+mapping: |
+  root.mapping = "root = %q".format("%v %v".format(this.mode, this.count))
+tests:
+  - name: computed default
+    config:
+      mode: upper
+    expected:
+      mapping: 'root = "upper 5"'
+  - name: explicit value
+    config:
+      mode: lower
+      count: 10
+    expected:
+      mapping: 'root = "lower 10"'
+  - name: invalid option
+    config:
+      mode: nope
+    expected_error: 'value nope is not a valid option'
+  - name: above maximum
+    config:
+      mode: lower
+      count: 11
+    expected_error: 'greater than the maximum of 10'
+  - name: list element below minimum
+    config:
+      mode: lower
+      ids: [ 1, -1 ]
+    expected_error: 'field ids: index 1: value -1 is less than the minimum of 0'
+  - name: wrong expected error
+    config:
+      mode: lower
+    expected_error: 'nope'
+`)
+
+	results, err := conf.RunTests()
+	require.NoError(t, err)
+	require.Len(t, results, 6)
+	for _, res := range results[:5] {
+		assert.Empty(t, res.Failures, res.Name)
+	}
+	assert.Equal(t, []string{
+		"expected error containing 'nope', but the template expanded successfully",
+	}, results[5].Failures)
+}
+
+func TestTemplateDefaultConflict(t *testing.T) {
+	conf := readTemplate(t, `
+name: test_conflict
+type: processor
+fields:
+  - name: foo
+    type: string
+    default: bar
+    default_mapping: 'root = "baz"'
+mapping: 'root.noop = {}'
+`)
+
+	_, err := conf.RunTests()
+	require.ErrorContains(t, err, "cannot specify both a default and a default_mapping")
+}
+
+func TestTemplateNested(t *testing.T) {
+	inner := readTemplate(t, `
+name: test_inner
+type: input
+fields:
+  - name: text
+    type: string
+mapping: |
+  root.generate.mapping = "root = %q".format(this.text)
+  root.processors = [ { "mapping": "root = content().uppercase()" } ]
+`)
+
+	outer := readTemplate(t, `
+name: test_outer
+type: input
+fields:
+  - name: text
+    type: string
+mapping: |
+  root.label = "foo"
+  root.test_inner.text = this.text + " world"
+  root.processors = [ { "mapping": "root = content() + \"!\"" } ]
+tests:
+  - name: nested
+    config:
+      text: hello
+    expected:
+      label: foo
+      generate:
+        mapping: 'root = "hello world"'
+      processors:
+        - mapping: root = content().uppercase()
+        - mapping: root = content() + "!"
+`)
+
+	results, err := outer.RunTests(inner)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Empty(t, results[0].Failures)
+
+	failures, err := outer.Test()
+	require.NoError(t, err)
+	assert.NotEmpty(t, failures, "the inner template is not provided")
+}
+
+func TestTemplateNestedCycle(t *testing.T) {
+	a := readTemplate(t, `
+name: test_cycle_a
+type: processor
+mapping: 'root.test_cycle_b = {}'
+tests:
+  - name: cycle
+    config: {}
+    expected_error: 'template test_cycle_a is nested within itself: test_cycle_a -> test_cycle_b -> test_cycle_a'
+`)
+	b := readTemplate(t, `
+name: test_cycle_b
+type: processor
+mapping: 'root.test_cycle_a = {}'
+`)
+
+	failures, err := a.Test(b)
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+}
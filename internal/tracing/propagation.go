@@ -0,0 +1,27 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// The W3C trace context propagator is used explicitly for propagating spans
+// through protocol headers, as the format is defined by the protocol rather
+// than the service wide tracer.
+var w3cPropagator = propagation.TraceContext{}
+
+// ExtractW3CTraceContext reads the W3C traceparent and tracestate values from a
+// carrier and returns a context with the remote span attached as a parent. If
+// the carrier does not contain a valid trace context then the provided context
+// is returned unchanged.
+func ExtractW3CTraceContext(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return w3cPropagator.Extract(ctx, carrier)
+}
+
+// InjectW3CTraceContext writes the W3C traceparent and tracestate values of
+// the span within a context to a carrier. If the context does not contain a
+// valid span then the carrier is unchanged.
+func InjectW3CTraceContext(ctx context.Context, carrier propagation.TextMapCarrier) {
+	w3cPropagator.Inject(ctx, carrier)
+}
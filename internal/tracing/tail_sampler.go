@@ -0,0 +1,299 @@
+package tracing
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// TailSamplingDecider returns the ratio (between 0 and 1) at which the trace of
+// a message should be sampled once the batch it belongs to has completed. The
+// label is that of the component that completed the batch, and errored
+// indicates whether the message failed to be delivered or had errors recorded
+// against its trace.
+type TailSamplingDecider func(label string, part *message.Part, errored bool) float64
+
+// TailSampler wraps a tracer provider such that the spans of a trace are held
+// back from the underlying provider until the trace is completed, at which
+// point a sampling decision is made. Spans of traces that are not sampled are
+// never ended and therefore never exported.
+type TailSampler struct {
+	prov         trace.TracerProvider
+	decider      TailSamplingDecider
+	defaultRatio float64
+	maxPending   int
+
+	mut          sync.Mutex
+	pending      map[trace.TraceID]*pendingTrace
+	pendingOrder *list.List
+	decided      map[trace.TraceID]bool
+	decidedOrder *list.List
+}
+
+type pendingTrace struct {
+	elem    *list.Element
+	errored bool
+	ended   []func()
+}
+
+// NewTailSampler wraps a tracer provider with tail based sampling. Traces that
+// are still pending once maxPending traces are buffered are evicted, oldest
+// first, and sampled at the default ratio.
+func NewTailSampler(prov trace.TracerProvider, decider TailSamplingDecider, defaultRatio float64, maxPending int) *TailSampler {
+	if maxPending <= 0 {
+		maxPending = 1
+	}
+	return &TailSampler{
+		prov:         prov,
+		decider:      decider,
+		defaultRatio: defaultRatio,
+		maxPending:   maxPending,
+		pending:      map[trace.TraceID]*pendingTrace{},
+		pendingOrder: list.New(),
+		decided:      map[trace.TraceID]bool{},
+		decidedOrder: list.New(),
+	}
+}
+
+// Tracer returns a tracer that creates spans subject to tail sampling.
+func (t *TailSampler) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return &tailTracer{s: t, t: t.prov.Tracer(name, opts...)}
+}
+
+// Shutdown the underlying tracer provider, if supported. Spans of traces that
+// are still pending a decision are discarded.
+func (t *TailSampler) Shutdown(ctx context.Context) error {
+	if shutter, ok := t.prov.(interface {
+		Shutdown(context.Context) error
+	}); ok {
+		return shutter.Shutdown(ctx)
+	}
+	return nil
+}
+
+// CompleteBatch makes a sampling decision for the traces of each message of a
+// completed batch, where err is the outcome of the batch. Traces that have
+// already been decided are unaffected.
+func (t *TailSampler) CompleteBatch(label string, batch message.Batch, err error) {
+	for _, p := range batch {
+		if p == nil {
+			continue
+		}
+		tID := trace.SpanContextFromContext(p.GetContext()).TraceID()
+		if !tID.IsValid() {
+			continue
+		}
+
+		t.mut.Lock()
+		_, isDecided := t.decided[tID]
+		errored := err != nil
+		if pt, exists := t.pending[tID]; exists && pt.errored {
+			errored = true
+		}
+		t.mut.Unlock()
+		if isDecided {
+			continue
+		}
+
+		t.decide(tID, t.decider(label, p, errored))
+	}
+}
+
+func sampleTraceID(tID trace.TraceID, ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	}
+	if ratio <= 0 {
+		return false
+	}
+	// Matches the approach of the otel ratio based sampler so that decisions
+	// are consistent across instances for the same trace.
+	return binary.BigEndian.Uint64(tID[8:16])>>1 < uint64(ratio*(1<<63))
+}
+
+func (t *TailSampler) decide(tID trace.TraceID, ratio float64) {
+	t.mut.Lock()
+	fns := t.decideLocked(tID, ratio)
+	t.mut.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// Must be called with the mutex held, returns span end functions that should
+// be called once the mutex is released.
+func (t *TailSampler) decideLocked(tID trace.TraceID, ratio float64) []func() {
+	if _, exists := t.decided[tID]; exists {
+		return nil
+	}
+
+	keep := sampleTraceID(tID, ratio)
+	t.decided[tID] = keep
+	t.decidedOrder.PushBack(tID)
+	for t.decidedOrder.Len() > t.maxPending {
+		delete(t.decided, t.decidedOrder.Remove(t.decidedOrder.Front()).(trace.TraceID))
+	}
+
+	pt, exists := t.pending[tID]
+	if !exists {
+		return nil
+	}
+	delete(t.pending, tID)
+	t.pendingOrder.Remove(pt.elem)
+	if !keep {
+		return nil
+	}
+	return pt.ended
+}
+
+// Must be called with the mutex held, returns a pending trace or nil if the
+// trace has already been decided, along with any span end functions of evicted
+// traces that should be called once the mutex is released.
+func (t *TailSampler) pendingLocked(tID trace.TraceID) (pt *pendingTrace, fns []func()) {
+	if _, decided := t.decided[tID]; decided {
+		return nil, nil
+	}
+	if pt = t.pending[tID]; pt != nil {
+		return
+	}
+
+	pt = &pendingTrace{}
+	pt.elem = t.pendingOrder.PushBack(tID)
+	t.pending[tID] = pt
+
+	for t.pendingOrder.Len() > t.maxPending {
+		evictID := t.pendingOrder.Front().Value.(trace.TraceID)
+		fns = append(fns, t.decideLocked(evictID, t.defaultRatio)...)
+	}
+	return
+}
+
+func (t *TailSampler) started(tID trace.TraceID) {
+	t.mut.Lock()
+	_, fns := t.pendingLocked(tID)
+	t.mut.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+func (t *TailSampler) markErrored(tID trace.TraceID) {
+	t.mut.Lock()
+	pt, fns := t.pendingLocked(tID)
+	if pt != nil {
+		pt.errored = true
+	}
+	t.mut.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+func (t *TailSampler) ended(tID trace.TraceID, fn func()) {
+	t.mut.Lock()
+	pt, fns := t.pendingLocked(tID)
+	if pt != nil {
+		pt.ended = append(pt.ended, fn)
+	} else if t.decided[tID] {
+		fns = append(fns, fn)
+	}
+	t.mut.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type tailTracer struct {
+	s *TailSampler
+	t trace.Tracer
+}
+
+func (t *tailTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	ctx, span := t.t.Start(ctx, spanName, opts...)
+	tID := span.SpanContext().TraceID()
+	if !tID.IsValid() || !span.IsRecording() {
+		return ctx, span
+	}
+
+	t.s.started(tID)
+	wrapped := &tailSpan{Span: span, s: t.s, tID: tID}
+	return trace.ContextWithSpan(ctx, wrapped), wrapped
+}
+
+type tailSpan struct {
+	trace.Span
+	s   *TailSampler
+	tID trace.TraceID
+}
+
+func (t *tailSpan) End(options ...trace.SpanEndOption) {
+	// The end timestamp is captured now as the span may be ended by the
+	// underlying provider much later.
+	if conf := trace.NewSpanEndConfig(options...); conf.Timestamp().IsZero() {
+		options = append(options, trace.WithTimestamp(time.Now()))
+	}
+	t.s.ended(t.tID, func() {
+		t.Span.End(options...)
+	})
+}
+
+func (t *tailSpan) AddEvent(name string, options ...trace.EventOption) {
+	conf := trace.NewEventConfig(options...)
+	for _, kv := range conf.Attributes() {
+		if kv.Key == "event" && kv.Value.AsString() == "error" {
+			t.s.markErrored(t.tID)
+		}
+	}
+	t.Span.AddEvent(name, options...)
+}
+
+func (t *tailSpan) RecordError(err error, options ...trace.EventOption) {
+	t.s.markErrored(t.tID)
+	t.Span.RecordError(err, options...)
+}
+
+func (t *tailSpan) SetStatus(code codes.Code, description string) {
+	if code == codes.Error {
+		t.s.markErrored(t.tID)
+	}
+	t.Span.SetStatus(code, description)
+}
+
+func (t *tailSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		if a.Key == "error" && a.Value.AsString() == "true" {
+			t.s.markErrored(t.tID)
+		}
+	}
+	t.Span.SetAttributes(kv...)
+}
+
+func (t *tailSpan) TracerProvider() trace.TracerProvider {
+	return t.s
+}
+
+//------------------------------------------------------------------------------
+
+// CompleteSpans finishes the spans of a batch of messages and, when the tracer
+// provider performs tail sampling, makes a sampling decision for their traces
+// based on the outcome of the batch.
+func CompleteSpans(prov trace.TracerProvider, label string, batch message.Batch, err error) {
+	FinishSpans(batch)
+	if s, ok := prov.(*TailSampler); ok {
+		s.CompleteBatch(label, batch, err)
+	}
+}
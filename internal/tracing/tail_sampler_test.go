@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func endedSpanNames(rec *tracetest.SpanRecorder) (names []string) {
+	for _, s := range rec.Ended() {
+		names = append(names, s.Name())
+	}
+	return
+}
+
+func TestTailSamplerOutcome(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prov := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(rec))
+
+	sampler := NewTailSampler(prov, func(label string, part *message.Part, errored bool) float64 {
+		assert.Equal(t, "foo", label)
+		if errored {
+			return 1
+		}
+		return 0
+	}, 1, 100)
+
+	okBatch := message.Batch{message.NewPart([]byte("ok"))}
+	InitSpans(sampler, "input_ok", okBatch)
+	_, spans := WithChildSpans(sampler, "processor_ok", okBatch)
+	spans[0].Finish()
+
+	errBatch := message.Batch{message.NewPart([]byte("err"))}
+	InitSpans(sampler, "input_err", errBatch)
+	_, spans = WithChildSpans(sampler, "processor_err", errBatch)
+	spans[0].SetTag("error", "true")
+	spans[0].Finish()
+
+	deliveryErrBatch := message.Batch{message.NewPart([]byte("delivery_err"))}
+	InitSpans(sampler, "input_delivery_err", deliveryErrBatch)
+
+	// Nothing is exported until a decision is made.
+	assert.Empty(t, endedSpanNames(rec))
+
+	CompleteSpans(sampler, "foo", okBatch, nil)
+	CompleteSpans(sampler, "foo", errBatch, nil)
+	CompleteSpans(sampler, "foo", deliveryErrBatch, errors.New("nope"))
+
+	assert.Equal(t, []string{"processor_err", "input_err", "input_delivery_err"}, endedSpanNames(rec))
+
+	// Spans that end after a decision follow it.
+	_, spans = WithChildSpans(sampler, "late_ok", okBatch)
+	spans[0].Finish()
+	_, spans = WithChildSpans(sampler, "late_err", errBatch)
+	spans[0].Finish()
+
+	assert.Equal(t, []string{"processor_err", "input_err", "input_delivery_err", "late_err"}, endedSpanNames(rec))
+}
+
+func TestTailSamplerEviction(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prov := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(rec))
+
+	sampler := NewTailSampler(prov, func(label string, part *message.Part, errored bool) float64 {
+		return 0
+	}, 1, 2)
+
+	var batches []message.Batch
+	for _, name := range []string{"a", "b", "c"} {
+		b := message.Batch{message.NewPart(nil)}
+		InitSpans(sampler, name, b)
+		FinishSpans(b)
+		batches = append(batches, b)
+	}
+
+	// The oldest trace is evicted and sampled at the default ratio.
+	assert.Equal(t, []string{"a"}, endedSpanNames(rec))
+
+	for _, b := range batches {
+		sampler.CompleteBatch("", b, nil)
+	}
+	assert.Equal(t, []string{"a"}, endedSpanNames(rec))
+}
+
+func TestSampleTraceIDRatio(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prov := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(rec))
+
+	sampler := NewTailSampler(prov, func(label string, part *message.Part, errored bool) float64 {
+		return 0.25
+	}, 1, 10000)
+
+	for i := 0; i < 1000; i++ {
+		b := message.Batch{message.NewPart(nil)}
+		InitSpans(sampler, "foo", b)
+		CompleteSpans(sampler, "", b, nil)
+	}
+
+	sampled := len(rec.Ended())
+	require.Greater(t, sampled, 150)
+	require.Less(t, sampled, 350)
+}
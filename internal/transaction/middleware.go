@@ -0,0 +1,128 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// ErrNextCalledTwice is returned from the next function of a middleware when it
+// is called more than once, or after the middleware has returned.
+var ErrNextCalledTwice = errors.New("middleware called next more than once or after returning")
+
+// Middleware intercepts the batch of a transaction. Calling next sends a batch
+// downstream and blocks until it is acknowledged, returning the result, and the
+// error returned by the middleware is used in order to acknowledge the
+// transaction. A middleware that returns without calling next acknowledges the
+// transaction without sending it downstream.
+type Middleware func(ctx context.Context, batch message.Batch, next func(context.Context, message.Batch) error) error
+
+// Interceptor forwards transactions from one channel to another through a
+// middleware, where the order of transactions is preserved and the
+// acknowledgement of a transaction does not block the transactions after it.
+type Interceptor struct {
+	mw Middleware
+	wg sync.WaitGroup
+
+	outMut    sync.RWMutex
+	outClosed bool
+}
+
+// NewInterceptor returns an interceptor that applies a middleware.
+func NewInterceptor(mw Middleware) *Interceptor {
+	return &Interceptor{mw: mw}
+}
+
+// Forward reads transactions from a channel and forwards them through the
+// middleware until either the channel is closed or the context is cancelled,
+// at which point the out channel is closed. Transactions that are still
+// awaiting acknowledgement once Forward returns can be waited on with Wait.
+func (i *Interceptor) Forward(ctx context.Context, in <-chan message.Transaction, out chan<- message.Transaction) {
+	defer func() {
+		i.outMut.Lock()
+		i.outClosed = true
+		close(out)
+		i.outMut.Unlock()
+	}()
+
+	for {
+		var t message.Transaction
+		var open bool
+		select {
+		case t, open = <-in:
+			if !open {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		// Wait until the transaction is either sent downstream or abandoned by
+		// the middleware before reading the next, which preserves ordering.
+		sent := make(chan struct{})
+		var claimed int32
+
+		i.wg.Add(1)
+		go func() {
+			defer i.wg.Done()
+
+			err := i.mw(ctx, t.Payload, func(nextCtx context.Context, b message.Batch) error {
+				if !atomic.CompareAndSwapInt32(&claimed, 0, 1) {
+					return ErrNextCalledTwice
+				}
+
+				resChan := make(chan error, 1)
+				if err := i.send(ctx, nextCtx, out, message.NewTransaction(b, resChan)); err != nil {
+					close(sent)
+					return err
+				}
+				close(sent)
+
+				select {
+				case err := <-resChan:
+					return err
+				case <-nextCtx.Done():
+					return nextCtx.Err()
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+			if atomic.CompareAndSwapInt32(&claimed, 0, 1) {
+				close(sent)
+			}
+			_ = t.Ack(ctx, err)
+		}()
+
+		select {
+		case <-sent:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (i *Interceptor) send(ctx, nextCtx context.Context, out chan<- message.Transaction, t message.Transaction) error {
+	i.outMut.RLock()
+	defer i.outMut.RUnlock()
+	if i.outClosed {
+		return component.ErrTypeClosed
+	}
+	select {
+	case out <- t:
+	case <-nextCtx.Done():
+		return nextCtx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Wait blocks until all transactions forwarded by the interceptor have been
+// acknowledged.
+func (i *Interceptor) Wait() {
+	i.wg.Wait()
+}
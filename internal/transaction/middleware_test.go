@@ -0,0 +1,72 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestInterceptorOrdering(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	in := make(chan message.Transaction)
+	out := make(chan message.Transaction)
+
+	i := NewInterceptor(func(ctx context.Context, batch message.Batch, next func(context.Context, message.Batch) error) error {
+		switch string(batch[0].AsBytes()) {
+		case "skip":
+			return nil
+		case "twice":
+			require.NoError(t, next(ctx, batch))
+			return next(ctx, batch)
+		}
+		return next(ctx, batch)
+	})
+	go i.Forward(ctx, in, out)
+
+	inputs := []string{"a", "skip", "b", "twice", "c"}
+	resChans := make([]chan error, len(inputs))
+	go func() {
+		for j, v := range inputs {
+			resChans[j] = make(chan error, 1)
+			select {
+			case in <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(v)}), resChans[j]):
+			case <-ctx.Done():
+				t.Error(ctx.Err())
+			}
+		}
+		close(in)
+	}()
+
+	// Read all transactions before acknowledging any of them, which shows that
+	// acknowledgements do not block the transactions after them.
+	var received []message.Transaction
+	for tran := range out {
+		received = append(received, tran)
+	}
+	require.Len(t, received, 4)
+	for j, exp := range []string{"a", "b", "twice", "c"} {
+		assert.Equal(t, exp, string(received[j].Payload[0].AsBytes()))
+	}
+	for j := len(received) - 1; j >= 0; j-- {
+		var err error
+		if j == 1 {
+			err = errors.New("nope")
+		}
+		require.NoError(t, received[j].Ack(ctx, err))
+	}
+	i.Wait()
+
+	assert.NoError(t, <-resChans[0])
+	assert.NoError(t, <-resChans[1])
+	assert.EqualError(t, <-resChans[2], "nope")
+	assert.ErrorIs(t, <-resChans[3], ErrNextCalledTwice)
+	assert.NoError(t, <-resChans[4])
+}
@@ -2,6 +2,7 @@ package bloblang
 
 import (
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 )
 
 type environmentUnwrapper struct {
@@ -24,3 +25,8 @@ func XWrapEnvironment(v any) *Environment {
 	}
 	return NewEnvironment()
 }
+
+// XWrapParsedParams is for internal use only, do not use this.
+func XWrapParsedParams(v *query.ParsedParams) *ParsedParams {
+	return &ParsedParams{par: v}
+}
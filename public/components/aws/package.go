@@ -5,4 +5,5 @@ import (
 	_ "github.com/benthosdev/benthos/v4/internal/impl/aws"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/elasticsearch/aws"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/kafka/aws"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/opensearch/aws"
 )
@@ -0,0 +1,6 @@
+package imap
+
+import (
+	// Bring in the internal plugin definitions.
+	_ "github.com/benthosdev/benthos/v4/internal/impl/imap"
+)
@@ -0,0 +1,12 @@
+package sql
+
+import (
+	"github.com/go-sql-driver/mysql"
+
+	isql "github.com/benthosdev/benthos/v4/internal/impl/sql"
+)
+
+func init() {
+	// Allows the sql_insert output to stream rows with LOAD DATA statements.
+	isql.SetMySQLReaderHandlers(mysql.RegisterReaderHandler, mysql.DeregisterReaderHandler)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+// BloblangContext is provided to Bloblang plugins registered with access to
+// resources each time they are executed.
+type BloblangContext struct {
+	ctx context.Context
+	res *Resources
+}
+
+// Context returns the context of the message being mapped, which carries
+// values such as tracing spans. When the mapping is not being executed on a
+// message the background context is returned.
+func (b *BloblangContext) Context() context.Context {
+	return b.ctx
+}
+
+// Resources returns access to the resources (caches, rate limits, etc) of the
+// stream that is executing the mapping.
+func (b *BloblangContext) Resources() *Resources {
+	return b.res
+}
+
+func newBloblangContext(ctx query.FunctionContext, res *Resources) *BloblangContext {
+	bCtx := context.Background()
+	if ctx.MsgBatch != nil && ctx.Index >= 0 && ctx.Index < ctx.MsgBatch.Len() {
+		bCtx = ctx.MsgBatch.Get(ctx.Index).GetContext()
+	}
+	return &BloblangContext{ctx: bCtx, res: res}
+}
+
+// BloblangResourceFunction defines a Bloblang function with access to the
+// context of its execution.
+//
+// The function may be called concurrently from multiple goroutines, as the
+// same mapping can be executed by multiple processing threads, and must
+// therefore be safe for concurrent use. The resources provided by the context
+// are safe for concurrent access.
+type BloblangResourceFunction func(ctx *BloblangContext) (any, error)
+
+// BloblangResourceFunctionConstructor defines a constructor for a Bloblang
+// function with access to resources, where parameters are parsed using a
+// PluginSpec provided when registering the function.
+//
+// When a function is parsed from a mapping with static arguments the
+// constructor will be called only once at parse time. When a function is parsed
+// with dynamic arguments, such as a value derived from the mapping input, the
+// constructor will be called on each invocation of the mapping with the derived
+// arguments.
+type BloblangResourceFunctionConstructor func(args *bloblang.ParsedParams) (BloblangResourceFunction, error)
+
+// BloblangResourceMethod defines a Bloblang method with access to the context
+// of its execution.
+//
+// The method may be called concurrently from multiple goroutines, as the same
+// mapping can be executed by multiple processing threads, and must therefore be
+// safe for concurrent use. The resources provided by the context are safe for
+// concurrent access.
+type BloblangResourceMethod func(ctx *BloblangContext, v any) (any, error)
+
+// BloblangResourceMethodConstructor defines a constructor for a Bloblang
+// method with access to resources, where parameters are parsed using a
+// PluginSpec provided when registering the method.
+//
+// When a method is parsed from a mapping with static arguments the constructor
+// will be called only once at parse time. When a method is parsed with dynamic
+// arguments, such as a value derived from the mapping input, the constructor
+// will be called on each invocation of the mapping with the derived arguments.
+type BloblangResourceMethodConstructor func(args *bloblang.ParsedParams) (BloblangResourceMethod, error)
+
+// RegisterBloblangFunctionWithResources adds a new Bloblang function to the
+// environment that is able to access the resources of the stream executing it,
+// such as caches and rate limits.
+//
+// Functions registered this way are always marked as impure, and can only be
+// executed by mappings within components created from this environment. When
+// executed without access to resources, such as by standalone mappings parsed
+// directly from a Bloblang environment, the function returns an error.
+//
+// Plugin names must match the regular expression /^[a-z0-9]+(_[a-z0-9]+)*$/
+// (snake case).
+func (e *Environment) RegisterBloblangFunctionWithResources(name string, spec *bloblang.PluginSpec, ctor BloblangResourceFunctionConstructor) error {
+	if err := e.bloblangEnv.RegisterFunctionV2(name, spec.Impure(), func(args *bloblang.ParsedParams) (bloblang.Function, error) {
+		return func() (any, error) {
+			return nil, fmt.Errorf("function %v requires access to resources and cannot be used in this context", name)
+		}, nil
+	}); err != nil {
+		return err
+	}
+
+	e.getBloblangParserEnv().SetResourceFunctionBinder(name, func(mgr any) query.FunctionCtor {
+		res := newResourcesFromManager(mgr.(bundle.NewManagement))
+		return func(args *query.ParsedParams) (query.Function, error) {
+			fn, err := ctor(bloblang.XWrapParsedParams(args))
+			if err != nil {
+				return nil, err
+			}
+			return query.ClosureFunction("function "+name, func(ctx query.FunctionContext) (any, error) {
+				return fn(newBloblangContext(ctx, res))
+			}, nil), nil
+		}
+	})
+	return nil
+}
+
+// RegisterBloblangMethodWithResources adds a new Bloblang method to the
+// environment that is able to access the resources of the stream executing it,
+// such as caches and rate limits.
+//
+// Methods registered this way are always marked as impure, and can only be
+// executed by mappings within components created from this environment. When
+// executed without access to resources, such as by standalone mappings parsed
+// directly from a Bloblang environment, the method returns an error.
+//
+// Plugin names must match the regular expression /^[a-z0-9]+(_[a-z0-9]+)*$/
+// (snake case).
+func (e *Environment) RegisterBloblangMethodWithResources(name string, spec *bloblang.PluginSpec, ctor BloblangResourceMethodConstructor) error {
+	if err := e.bloblangEnv.RegisterMethodV2(name, spec.Impure(), func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		return func(v any) (any, error) {
+			return nil, fmt.Errorf("method %v requires access to resources and cannot be used in this context", name)
+		}, nil
+	}); err != nil {
+		return err
+	}
+
+	e.getBloblangParserEnv().SetResourceMethodBinder(name, func(mgr any) query.MethodCtor {
+		res := newResourcesFromManager(mgr.(bundle.NewManagement))
+		return func(target query.Function, args *query.ParsedParams) (query.Function, error) {
+			fn, err := ctor(bloblang.XWrapParsedParams(args))
+			if err != nil {
+				return nil, err
+			}
+			return query.ClosureFunction("method "+name, func(ctx query.FunctionContext) (any, error) {
+				v, err := target.Exec(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return fn(newBloblangContext(ctx, res), v)
+			}, target.QueryTargets), nil
+		}
+	})
+	return nil
+}
+
+// RegisterBloblangFunctionWithResources adds a new Bloblang function to the
+// global environment that is able to access the resources of the stream
+// executing it, such as caches and rate limits.
+//
+// Functions registered this way are always marked as impure, and when executed
+// without access to resources, such as by standalone mappings parsed directly
+// from a Bloblang environment, the function returns an error.
+func RegisterBloblangFunctionWithResources(name string, spec *bloblang.PluginSpec, ctor BloblangResourceFunctionConstructor) error {
+	return globalEnvironment.RegisterBloblangFunctionWithResources(name, spec, ctor)
+}
+
+// RegisterBloblangMethodWithResources adds a new Bloblang method to the global
+// environment that is able to access the resources of the stream executing it,
+// such as caches and rate limits.
+//
+// Methods registered this way are always marked as impure, and when executed
+// without access to resources, such as by standalone mappings parsed directly
+// from a Bloblang environment, the method returns an error.
+func RegisterBloblangMethodWithResources(name string, spec *bloblang.PluginSpec, ctor BloblangResourceMethodConstructor) error {
+	return globalEnvironment.RegisterBloblangMethodWithResources(name, spec, ctor)
+}
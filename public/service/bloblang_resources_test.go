@@ -0,0 +1,167 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestBloblangPluginsWithResources(t *testing.T) {
+	bEnv := bloblang.NewEnvironment()
+	env := service.NewEnvironment()
+	env.UseBloblangEnvironment(bEnv)
+
+	require.NoError(t, env.RegisterBloblangFunctionWithResources("test_res_lookup",
+		bloblang.NewPluginSpec().Param(bloblang.NewStringParam("key")),
+		func(args *bloblang.ParsedParams) (service.BloblangResourceFunction, error) {
+			key, err := args.GetString("key")
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx *service.BloblangContext) (any, error) {
+				var value []byte
+				var cErr error
+				if err := ctx.Resources().AccessCache(ctx.Context(), "things", func(c service.Cache) {
+					value, cErr = c.Get(ctx.Context(), key)
+				}); err != nil {
+					return nil, err
+				}
+				if cErr != nil {
+					return nil, cErr
+				}
+				return string(value), nil
+			}, nil
+		}))
+
+	require.NoError(t, env.RegisterBloblangMethodWithResources("test_res_store",
+		bloblang.NewPluginSpec().Param(bloblang.NewStringParam("key")),
+		func(args *bloblang.ParsedParams) (service.BloblangResourceMethod, error) {
+			key, err := args.GetString("key")
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx *service.BloblangContext, v any) (any, error) {
+				str, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected string value, got %T", v)
+				}
+				var cErr error
+				if err := ctx.Resources().AccessCache(ctx.Context(), "things", func(c service.Cache) {
+					cErr = c.Set(ctx.Context(), key, []byte(str), nil)
+				}); err != nil {
+					return nil, err
+				}
+				return str, cErr
+			}, nil
+		}))
+
+	b := env.NewStreamBuilder()
+	require.NoError(t, b.SetLoggerYAML("level: NONE"))
+	require.NoError(t, b.AddResourcesYAML(`
+cache_resources:
+  - label: things
+    memory: {}
+`))
+	require.NoError(t, b.AddProcessorYAML(`
+mapping: |
+  root.stored = this.value.test_res_store(this.key)
+  root.looked_up = test_res_lookup(this.key)
+`))
+
+	pushFn, err := b.AddProducerFunc()
+	require.NoError(t, err)
+
+	var outMut sync.Mutex
+	var outMsgs []string
+	require.NoError(t, b.AddConsumerFunc(func(_ context.Context, m *service.Message) error {
+		outMut.Lock()
+		defer outMut.Unlock()
+
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+		outMsgs = append(outMsgs, string(b))
+		return nil
+	}))
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+
+	go func() {
+		assert.NoError(t, pushFn(context.Background(), service.NewMessage([]byte(`{"key":"foo","value":"bar"}`))))
+		assert.NoError(t, strm.Stop(context.Background()))
+	}()
+	require.NoError(t, strm.Run(context.Background()))
+
+	outMut.Lock()
+	assert.Equal(t, []string{`{"looked_up":"bar","stored":"bar"}`}, outMsgs)
+	outMut.Unlock()
+
+	// Executing outside of a stream returns an error.
+	exec, err := bEnv.Parse(`root = test_res_lookup("foo")`)
+	require.NoError(t, err)
+
+	_, err = exec.Query(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "function test_res_lookup requires access to resources")
+}
+
+func TestBloblangPluginsWithResourcesIsolated(t *testing.T) {
+	newEnv := func(value string) *service.Environment {
+		env := service.NewEnvironment()
+		env.UseBloblangEnvironment(bloblang.NewEnvironment())
+		require.NoError(t, env.RegisterBloblangFunctionWithResources("test_res_isolated",
+			bloblang.NewPluginSpec(),
+			func(args *bloblang.ParsedParams) (service.BloblangResourceFunction, error) {
+				return func(ctx *service.BloblangContext) (any, error) {
+					return value, nil
+				}, nil
+			}))
+		return env
+	}
+
+	fooEnv, barEnv := newEnv("foo"), newEnv("bar")
+	for env, exp := range map[*service.Environment]string{fooEnv: "foo", barEnv: "bar"} {
+		b := env.NewStreamBuilder()
+		require.NoError(t, b.SetLoggerYAML("level: NONE"))
+		require.NoError(t, b.AddProcessorYAML(`mapping: 'root = test_res_isolated()'`))
+
+		pushFn, err := b.AddProducerFunc()
+		require.NoError(t, err)
+
+		var outMut sync.Mutex
+		var outMsgs []string
+		require.NoError(t, b.AddConsumerFunc(func(_ context.Context, m *service.Message) error {
+			outMut.Lock()
+			defer outMut.Unlock()
+
+			b, err := m.AsBytes()
+			require.NoError(t, err)
+			outMsgs = append(outMsgs, string(b))
+			return nil
+		}))
+
+		strm, err := b.Build()
+		require.NoError(t, err)
+
+		go func() {
+			assert.NoError(t, pushFn(context.Background(), service.NewMessage(nil)))
+			assert.NoError(t, strm.Stop(context.Background()))
+		}()
+		require.NoError(t, strm.Run(context.Background()))
+
+		outMut.Lock()
+		assert.Equal(t, []string{exp}, outMsgs)
+		outMut.Unlock()
+	}
+
+	// The plugins are not bound within the global environment.
+	b := service.NewStreamBuilder()
+	require.Error(t, b.AddProcessorYAML(`mapping: 'root = test_res_isolated()'`))
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+)
+
+// CacheCheckpointer tracks the progress of an input that consumes from a
+// source without a native mechanism for committing offsets, such as a file,
+// paginated HTTP endpoint or change data capture feed, and persists that
+// progress into a cache resource.
+//
+// Checkpoints are tracked in the order that messages are consumed, and a
+// checkpoint is only written to the cache once the message it belongs to and
+// all messages consumed before it have been acknowledged. This means that an
+// input restarted from the persisted checkpoint never skips messages that were
+// not delivered, although messages that were delivered after the checkpoint may
+// be consumed again.
+type CacheCheckpointer struct {
+	res       *Resources
+	cacheName string
+	key       string
+
+	tracker   *checkpoint.Capped[[]byte]
+	commitMut sync.Mutex
+	unwritten *[]byte
+}
+
+// NewCacheCheckpointer creates a checkpointer that persists checkpoints to a
+// key of a cache resource. The maximum number of pending (unacknowledged)
+// checkpoints is capped at maxPending, and once reached calls to Track block
+// until pending checkpoints are resolved.
+func NewCacheCheckpointer(res *Resources, cacheName, key string, maxPending int64) (*CacheCheckpointer, error) {
+	if !res.HasCache(cacheName) {
+		return nil, errors.New("cache resource '" + cacheName + "' was not found")
+	}
+	return &CacheCheckpointer{
+		res:       res,
+		cacheName: cacheName,
+		key:       key,
+		tracker:   checkpoint.NewCapped[[]byte](maxPending),
+	}, nil
+}
+
+// Get the latest persisted checkpoint from the cache, which should be used in
+// order to resume consumption when an input connects. If no checkpoint has
+// been persisted then nil is returned without an error.
+func (c *CacheCheckpointer) Get(ctx context.Context) (cp []byte, err error) {
+	if cerr := c.res.AccessCache(ctx, c.cacheName, func(ca Cache) {
+		if cp, err = ca.Get(ctx, c.key); errors.Is(err, ErrKeyNotFound) {
+			cp, err = nil, nil
+		}
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+// Track a checkpoint of a batch of messages that has been consumed along with
+// the acknowledgement function of the batch. The returned acknowledgement
+// function should be used in its place, and once called with a nil error, and
+// once all preceding checkpoints are also resolved, the highest resolved
+// checkpoint is written to the cache before the original acknowledgement
+// function is called.
+//
+// If the checkpoint cannot be written then the original acknowledgement
+// function is called with the error, and the write is attempted again by the
+// next acknowledgement, including a repeated call of the same function.
+//
+// A rejected (nacked) batch does not resolve its checkpoint, and therefore
+// prevents subsequent checkpoints from being persisted. It is therefore
+// recommended to use this alongside AutoRetryNacks, or to otherwise retry
+// rejected batches until they are delivered.
+func (c *CacheCheckpointer) Track(ctx context.Context, cp []byte, batchSize int64, ackFn AckFunc) (AckFunc, error) {
+	release, err := c.tracker.Track(ctx, cp, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func(ctx context.Context, err error) error {
+		if err != nil {
+			if ackFn != nil {
+				return ackFn(ctx, err)
+			}
+			return nil
+		}
+
+		err = c.commit(ctx, func() (highest *[]byte) {
+			once.Do(func() {
+				highest = release()
+			})
+			return
+		})
+		if ackFn != nil {
+			return ackFn(ctx, err)
+		}
+		return err
+	}, nil
+}
+
+func (c *CacheCheckpointer) commit(ctx context.Context, release func() *[]byte) (err error) {
+	// Releasing and writing the checkpoint happens under a single lock so that
+	// concurrent acknowledgements cannot write an older checkpoint over a
+	// newer one.
+	c.commitMut.Lock()
+	defer c.commitMut.Unlock()
+
+	if highest := release(); highest != nil {
+		c.unwritten = highest
+	}
+	if c.unwritten == nil {
+		return nil
+	}
+
+	// A checkpoint that failed to be written is kept so that it's written by a
+	// subsequent commit.
+	if cerr := c.res.AccessCache(ctx, c.cacheName, func(ca Cache) {
+		err = ca.Set(ctx, c.key, *c.unwritten, nil)
+	}); cerr != nil {
+		err = cerr
+	}
+	if err == nil {
+		c.unwritten = nil
+	}
+	return
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+)
+
+func TestCacheCheckpointer(t *testing.T) {
+	ctx := context.Background()
+	res := MockResources(MockResourcesOptAddCache("foo"))
+
+	_, err := NewCacheCheckpointer(res, "bar", "progress", 10)
+	require.Error(t, err)
+
+	cp, err := NewCacheCheckpointer(res, "foo", "progress", 10)
+	require.NoError(t, err)
+
+	current, err := cp.Get(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, current)
+
+	var acked []string
+	ackFor := func(name string) AckFunc {
+		return func(ctx context.Context, err error) error {
+			acked = append(acked, name)
+			return nil
+		}
+	}
+
+	ackA, err := cp.Track(ctx, []byte("a"), 1, ackFor("a"))
+	require.NoError(t, err)
+	ackB, err := cp.Track(ctx, []byte("b"), 1, ackFor("b"))
+	require.NoError(t, err)
+	ackC, err := cp.Track(ctx, []byte("c"), 1, ackFor("c"))
+	require.NoError(t, err)
+
+	// Acking b before a must not persist b.
+	require.NoError(t, ackB(ctx, nil))
+	current, err = cp.Get(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, current)
+
+	// Rejecting a does not resolve it.
+	require.NoError(t, ackA(ctx, errors.New("nope")))
+	current, err = cp.Get(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, current)
+
+	require.NoError(t, ackA(ctx, nil))
+	current, err = cp.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(current))
+
+	require.NoError(t, ackC(ctx, nil))
+	current, err = cp.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(current))
+
+	assert.Equal(t, []string{"b", "a", "a", "c"}, acked)
+
+	// A new checkpointer resumes from the persisted checkpoint.
+	cp2, err := NewCacheCheckpointer(res, "foo", "progress", 10)
+	require.NoError(t, err)
+
+	current, err = cp2.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(current))
+}
+
+func TestCacheCheckpointerCommitFailure(t *testing.T) {
+	ctx := context.Background()
+	res := MockResources(MockResourcesOptAddCache("foo"))
+	mgr := res.mgr.(*mock.Manager)
+
+	cp, err := NewCacheCheckpointer(res, "foo", "progress", 10)
+	require.NoError(t, err)
+
+	var ackErrs []error
+	ackA, err := cp.Track(ctx, []byte("a"), 1, func(ctx context.Context, err error) error {
+		ackErrs = append(ackErrs, err)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The original acknowledgement receives the error of a failed commit.
+	values := mgr.Caches["foo"]
+	delete(mgr.Caches, "foo")
+	require.NoError(t, ackA(ctx, nil))
+	require.Len(t, ackErrs, 1)
+	require.Error(t, ackErrs[0])
+
+	// Retrying the acknowledgement writes the checkpoint.
+	mgr.Caches["foo"] = values
+	require.NoError(t, ackA(ctx, nil))
+	require.Len(t, ackErrs, 2)
+	require.NoError(t, ackErrs[1])
+
+	current, err := cp.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(current))
+}
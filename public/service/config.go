@@ -138,6 +138,14 @@ func NewFloatField(name string) *ConfigField {
 	}
 }
 
+// NewFloatListField describes a new config field consisting of a list of
+// floats.
+func NewFloatListField(name string) *ConfigField {
+	return &ConfigField{
+		field: docs.FieldFloat(name, "").Array(),
+	}
+}
+
 // NewBoolField describes a new bool type config field.
 func NewBoolField(name string) *ConfigField {
 	return &ConfigField{
@@ -792,6 +800,32 @@ func (p *ParsedConfig) FieldFloat(path ...string) (float64, error) {
 	return f, nil
 }
 
+// FieldFloatList accesses a field that is a list of floats from the parsed
+// config by its name and returns the value. Returns an error if the field is
+// not found, or is not a list of floats.
+func (p *ParsedConfig) FieldFloatList(path ...string) ([]float64, error) {
+	v, exists := p.field(path...)
+	if !exists {
+		return nil, fmt.Errorf("field '%v' was not found in the config", p.fullDotPath(path...))
+	}
+	iList, ok := v.([]any)
+	if !ok {
+		if fList, ok := v.([]float64); ok {
+			return fList, nil
+		}
+		return nil, fmt.Errorf("expected field '%v' to be a float list, got %T", p.fullDotPath(path...), v)
+	}
+	fList := make([]float64, len(iList))
+	for i, ev := range iList {
+		fv, err := query.IGetNumber(ev)
+		if err != nil {
+			return nil, fmt.Errorf("expected field '%v' to be a float list, found an element of type %T", p.fullDotPath(path...), ev)
+		}
+		fList[i] = fv
+	}
+	return fList, nil
+}
+
 // FieldBool accesses a bool field from the parsed config by its name and
 // returns the value. Returns an error if the field is not found or is not a
 // bool.
@@ -22,7 +22,9 @@ type BatchPolicy struct {
 	Period   string
 
 	// Only available when using NewBatchPolicyField.
-	procs []processor.Config
+	compressedByteSize int
+	compression        string
+	procs              []processor.Config
 }
 
 func (b BatchPolicy) toInternal() batchconfig.Config {
@@ -31,6 +33,10 @@ func (b BatchPolicy) toInternal() batchconfig.Config {
 	batchConf.Count = b.Count
 	batchConf.Check = b.Check
 	batchConf.Period = b.Period
+	batchConf.CompressedByteSize = b.compressedByteSize
+	if b.compression != "" {
+		batchConf.Compression = b.compression
+	}
 	batchConf.Processors = b.procs
 	return batchConf
 }
@@ -143,6 +149,12 @@ func (p *ParsedConfig) FieldBatchPolicy(path ...string) (conf BatchPolicy, err e
 	if conf.Period, err = p.FieldString(append(path, "period")...); err != nil {
 		return
 	}
+	if conf.compressedByteSize, err = p.FieldInt(append(path, "compressed_byte_size")...); err != nil {
+		return
+	}
+	if conf.compression, err = p.FieldString(append(path, "compression")...); err != nil {
+		return
+	}
 	conf.procs, err = p.fieldProcessorListConfigs(append(path, "processors")...)
 	return
 }
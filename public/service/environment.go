@@ -320,6 +320,34 @@ func (e *Environment) WalkOutputs(fn func(name string, config *ConfigView)) {
 	}
 }
 
+// RegisterInputMiddleware adds a middleware that intercepts the batches of every
+// input constructed from this environment, including inputs registered as
+// plugins, which allows cross-cutting behaviour such as auditing, custom
+// metrics or attaching signatures to be added without modifying each input.
+//
+// The constructor is called for each instantiation of an input within a
+// config, including the child inputs of brokers, and when multiple middlewares
+// are registered they intercept batches in the order that they were
+// registered. Middlewares intercept batches after the processors of an input
+// have been applied.
+func (e *Environment) RegisterInputMiddleware(ctor InputMiddlewareConstructor) {
+	e.internal.InputMiddlewareAdd(inputMiddlewareCtor(ctor))
+}
+
+// RegisterOutputMiddleware adds a middleware that intercepts the batches of
+// every output constructed from this environment, including outputs registered
+// as plugins, which allows cross-cutting behaviour such as auditing, custom
+// metrics or attaching signatures to be added without modifying each output.
+//
+// The constructor is called for each instantiation of an output within a
+// config, including the child outputs of brokers, and when multiple
+// middlewares are registered they intercept batches in the order that they
+// were registered. Middlewares intercept batches before the processors of an
+// output are applied.
+func (e *Environment) RegisterOutputMiddleware(ctor OutputMiddlewareConstructor) {
+	e.internal.OutputMiddlewareAdd(outputMiddlewareCtor(ctor))
+}
+
 // RegisterProcessor attempts to register a new processor plugin by providing
 // a description of the configuration for the processor and a constructor for
 // the processor itself. The constructor will be called for each instantiation
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/lookup"
+)
+
+// LookupSourceFunc obtains the contents of a lookup table from a path, which is
+// a URL of the scheme that the source was registered under.
+type LookupSourceFunc func(ctx context.Context, path string) ([]byte, error)
+
+// RegisterLookupSource attempts to register a new source of lookup tables for
+// paths with a URL scheme, such as `s3`. Lookup tables are resources that are
+// declared under `lookup_resources` and can be queried from Bloblang with the
+// `lookup` function.
+//
+// Sources are called each time a table is refreshed, and therefore should be
+// configured from their environment rather than from the config itself.
+func RegisterLookupSource(scheme string, fn LookupSourceFunc) {
+	lookup.RegisterSource(scheme, lookup.Source(fn))
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
+)
+
+// MiddlewareNextFunc is provided to a middleware in order to pass a batch on to
+// the next stage, which is either the next middleware or the component being
+// intercepted. The call blocks until the batch has been acknowledged, and
+// returns the error it was rejected with, if any.
+type MiddlewareNextFunc func(ctx context.Context, batch MessageBatch) error
+
+// InputMiddleware intercepts the batches consumed by an input before they are
+// sent into the pipeline. Calling next sends a batch, which may be modified,
+// into the pipeline and blocks until it is acknowledged. The error returned by
+// the middleware is used in order to acknowledge the batch at the input, and
+// therefore returning an error causes the batch to be rejected.
+//
+// A middleware that returns without calling next acknowledges the batch without
+// it ever reaching the pipeline, which can be used in order to filter batches.
+type InputMiddleware func(ctx context.Context, batch MessageBatch, next MiddlewareNextFunc) error
+
+// OutputMiddleware intercepts the batches written by an output. Calling next
+// sends a batch, which may be modified, to the output and blocks until it has
+// been written, returning the error that the write failed with. The error
+// returned by the middleware is used in order to acknowledge the batch, and
+// therefore returning an error causes the batch to be rejected.
+//
+// A middleware that returns without calling next acknowledges the batch without
+// it ever being written.
+type OutputMiddleware func(ctx context.Context, batch MessageBatch, next MiddlewareNextFunc) error
+
+// InputMiddlewareConstructor is a func that's provided the type of an input
+// (kafka, http_server, etc) along with access to a service manager scoped to
+// it, and returns a middleware for the input, or nil if the input should not be
+// intercepted.
+type InputMiddlewareConstructor func(inputType string, mgr *Resources) (InputMiddleware, error)
+
+// OutputMiddlewareConstructor is a func that's provided the type of an output
+// (kafka, http_client, etc) along with access to a service manager scoped to
+// it, and returns a middleware for the output, or nil if the output should not
+// be intercepted.
+type OutputMiddlewareConstructor func(outputType string, mgr *Resources) (OutputMiddleware, error)
+
+func middlewareToInternal(mw func(context.Context, MessageBatch, MiddlewareNextFunc) error) transaction.Middleware {
+	return func(ctx context.Context, batch message.Batch, next func(context.Context, message.Batch) error) error {
+		pubBatch := make(MessageBatch, len(batch))
+		for i, p := range batch {
+			pubBatch[i] = NewInternalMessage(p)
+		}
+		return mw(ctx, pubBatch, func(ctx context.Context, b MessageBatch) error {
+			iBatch := make(message.Batch, len(b))
+			for i, m := range b {
+				iBatch[i] = m.part
+			}
+			return next(ctx, iBatch)
+		})
+	}
+}
+
+func inputMiddlewareCtor(ctor InputMiddlewareConstructor) bundle.InputMiddlewareConstructor {
+	return func(conf input.Config, nm bundle.NewManagement) (transaction.Middleware, error) {
+		mw, err := ctor(conf.Type, newResourcesFromManager(nm))
+		if err != nil || mw == nil {
+			return nil, err
+		}
+		return middlewareToInternal(mw), nil
+	}
+}
+
+func outputMiddlewareCtor(ctor OutputMiddlewareConstructor) bundle.OutputMiddlewareConstructor {
+	return func(conf output.Config, nm bundle.NewManagement) (transaction.Middleware, error) {
+		mw, err := ctor(conf.Type, newResourcesFromManager(nm))
+		if err != nil || mw == nil {
+			return nil, err
+		}
+		return middlewareToInternal(mw), nil
+	}
+}
@@ -0,0 +1,104 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestMiddlewareInputOutput(t *testing.T) {
+	env := service.NewEnvironment()
+
+	var mut sync.Mutex
+	var events []string
+	addEvent := func(e string) {
+		mut.Lock()
+		events = append(events, e)
+		mut.Unlock()
+	}
+
+	env.RegisterInputMiddleware(func(inputType string, mgr *service.Resources) (service.InputMiddleware, error) {
+		if inputType != "inproc" {
+			return nil, nil
+		}
+		return func(ctx context.Context, batch service.MessageBatch, next service.MiddlewareNextFunc) error {
+			b, _ := batch[0].AsBytes()
+			if string(b) == "skip" {
+				addEvent("in skip")
+				return nil
+			}
+			batch[0].MetaSetMut("signed", "yes")
+			err := next(ctx, batch)
+			addEvent("in ack " + string(b) + ": " + errString(err))
+			return err
+		}, nil
+	})
+
+	env.RegisterOutputMiddleware(func(outputType string, mgr *service.Resources) (service.OutputMiddleware, error) {
+		return func(ctx context.Context, batch service.MessageBatch, next service.MiddlewareNextFunc) error {
+			b, _ := batch[0].AsBytes()
+			if string(b) == "bad" {
+				return errors.New("bad message")
+			}
+			return next(ctx, batch)
+		}, nil
+	})
+	env.RegisterOutputMiddleware(func(outputType string, mgr *service.Resources) (service.OutputMiddleware, error) {
+		return func(ctx context.Context, batch service.MessageBatch, next service.MiddlewareNextFunc) error {
+			b, _ := batch[0].AsBytes()
+			addEvent("out second " + string(b))
+			return next(ctx, batch)
+		}, nil
+	})
+
+	sb := env.NewStreamBuilder()
+	require.NoError(t, sb.SetLoggerYAML(`level: none`))
+
+	produce, err := sb.AddProducerFunc()
+	require.NoError(t, err)
+
+	require.NoError(t, sb.AddConsumerFunc(func(ctx context.Context, m *service.Message) error {
+		b, _ := m.AsBytes()
+		signed, _ := m.MetaGet("signed")
+		addEvent("consumed " + string(b) + " signed " + signed)
+		return nil
+	}))
+
+	strm, err := sb.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), 10*time.Second)
+	defer done()
+
+	go func() {
+		_ = strm.Run(ctx)
+	}()
+
+	require.NoError(t, produce(ctx, service.NewMessage([]byte("a"))))
+	require.NoError(t, produce(ctx, service.NewMessage([]byte("skip"))))
+	require.EqualError(t, produce(ctx, service.NewMessage([]byte("bad"))), "bad message")
+
+	require.NoError(t, strm.Stop(ctx))
+
+	assert.Equal(t, []string{
+		"out second a",
+		"consumed a signed yes",
+		"in ack a: ",
+		"in skip",
+		"in ack bad: bad message",
+	}, events)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
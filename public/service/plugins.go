@@ -105,6 +105,22 @@ func RegisterBatchOutput(name string, spec *ConfigSpec, ctor BatchOutputConstruc
 	return globalEnvironment.RegisterBatchOutput(name, spec, ctor)
 }
 
+// RegisterInputMiddleware adds a middleware that intercepts the batches of every
+// input, including inputs registered as plugins. The constructor is called for
+// each instantiation of an input within a config, including the child inputs
+// of brokers.
+func RegisterInputMiddleware(ctor InputMiddlewareConstructor) {
+	globalEnvironment.RegisterInputMiddleware(ctor)
+}
+
+// RegisterOutputMiddleware adds a middleware that intercepts the batches of
+// every output, including outputs registered as plugins. The constructor is
+// called for each instantiation of an output within a config, including the
+// child outputs of brokers.
+func RegisterOutputMiddleware(ctor OutputMiddlewareConstructor) {
+	globalEnvironment.RegisterOutputMiddleware(ctor)
+}
+
 // ProcessorConstructor is a func that's provided a configuration type and
 // access to a service manager and must return an instantiation of a processor
 // based on the config, or an error.
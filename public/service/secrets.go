@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/secrets"
+)
+
+// SecretProviderFunc resolves the value of a secret at a path, where the format
+// of the path is specific to the provider.
+type SecretProviderFunc func(ctx context.Context, path string) (string, error)
+
+// RegisterSecretProvider attempts to register a new secret provider under a
+// name, which is then used when resolving secret references within config
+// files of the form `${secret:name:path}`, or `${secret:name:path#key}` in
+// order to extract a key from a secret that is a JSON object.
+//
+// Secrets are resolved when config files are read, which happens before any
+// components are created, and therefore a provider should be configured from
+// its environment rather than from the config itself.
+func RegisterSecretProvider(name string, fn SecretProviderFunc) {
+	secrets.RegisterProvider(name, secrets.ProviderFunc(fn))
+}
@@ -37,6 +37,10 @@ func TestStreamBuilderDefault(t *testing.T) {
     none: {}`,
 		`pipeline:
     threads: 0
+    sharding:
+        key: ""
+        queue_size: 64
+        pin_threads: false
     processors: []`,
 		`output:
     label: ""
@@ -408,6 +412,10 @@ type: local`))
     memory: {}`,
 		`pipeline:
     threads: 10
+    sharding:
+        key: ""
+        queue_size: 64
+        pin_threads: false
     processors:`,
 		`
         - label: ""
@@ -777,7 +785,12 @@ mapping: ""`,
 				{
 					typeStr: "tracer",
 					name:    "none",
-					conf:    `none: {}`,
+					conf: `none: {}
+sampling:
+    enabled: false
+    rules: []
+    default_ratio: 1
+    max_pending_traces: 10000`,
 				},
 			},
 		},
@@ -844,7 +857,12 @@ mapping: ""`,
 				{
 					typeStr: "tracer",
 					name:    "none",
-					conf:    `none: {}`,
+					conf: `none: {}
+sampling:
+    enabled: false
+    rules: []
+    default_ratio: 1
+    max_pending_traces: 10000`,
 				},
 			},
 		},
@@ -899,7 +917,12 @@ mapping: ""`,
 				{
 					typeStr: "tracer",
 					name:    "none",
-					conf:    `none: {}`,
+					conf: `none: {}
+sampling:
+    enabled: false
+    rules: []
+    default_ratio: 1
+    max_pending_traces: 10000`,
 				},
 			},
 		},
@@ -955,6 +978,10 @@ output:
     none: {}`,
 		`pipeline:
     threads: 5
+    sharding:
+        key: ""
+        queue_size: 64
+        pin_threads: false
     processors:`,
 		`
         - label: ""